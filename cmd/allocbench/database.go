@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+)
+
+// resetLeases empties the pool so every run starts from the same state
+func resetLeases(ctx context.Context, pool *pgxpool.Pool) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM leases"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE alloc_state SET last_token_id = min_token_id - 1 WHERE tenant_id = 'default'"); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// cleanup deletes the leases created by the run
+func cleanup(pool *pgxpool.Pool, prefix string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := pool.Exec(ctx, "DELETE FROM leases WHERE peer_id LIKE $1", prefix+"%"); err != nil {
+		fmt.Fprintf(os.Stderr, "allocbench: cleanup failed: %v\n", err)
+	}
+}
+
+// seedExpired allocates n leases and expires them, so the run can reuse them
+func seedExpired(ctx context.Context, pool *pgxpool.Pool, service *services.LeaseService, prefix string, n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := service.AllocateIP(ctx, fmt.Sprintf("%sexpired-%d", prefix, i)); err != nil {
+			return err
+		}
+	}
+
+	_, err := pool.Exec(ctx, "UPDATE leases SET expires_at = now() - interval '1 minute' WHERE peer_id LIKE $1", prefix+"expired-%")
+	return err
+}
+
+// databaseStats are the counters of pg_stat_database for the current database
+type databaseStats struct {
+	Commits   int64 `json:"commits"`
+	Rollbacks int64 `json:"rollbacks"`
+	Deadlocks int64 `json:"deadlocks"`
+}
+
+func readDatabaseStats(ctx context.Context, pool *pgxpool.Pool) (databaseStats, error) {
+	var s databaseStats
+	err := pool.QueryRow(ctx, `SELECT xact_commit, xact_rollback, deadlocks
+FROM pg_stat_database WHERE datname = current_database()`).Scan(&s.Commits, &s.Rollbacks, &s.Deadlocks)
+	if err != nil {
+		return s, fmt.Errorf("read pg_stat_database: %w", err)
+	}
+	return s, nil
+}
+
+func (s databaseStats) sub(before databaseStats) databaseStats {
+	return databaseStats{
+		Commits:   s.Commits - before.Commits,
+		Rollbacks: s.Rollbacks - before.Rollbacks,
+		Deadlocks: s.Deadlocks - before.Deadlocks,
+	}
+}
+
+// lockWaits summarizes how many sessions were waiting for a lock at each sample
+type lockWaits struct {
+	Samples       int     `json:"samples"`
+	WaitingSample int     `json:"samples_with_waits"` // samples in which any session waited
+	MaxWaiting    int     `json:"max_waiting"`
+	AvgWaiting    float64 `json:"avg_waiting"`
+	// WaitSeconds estimates the total time sessions spent waiting for locks
+	WaitSeconds float64 `json:"wait_seconds_estimate"`
+}
+
+type lockSampler struct {
+	pool     *pgxpool.Pool
+	interval time.Duration
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	result lockWaits
+	total  int
+}
+
+func newLockSampler(pool *pgxpool.Pool, interval time.Duration) *lockSampler {
+	return &lockSampler{pool: pool, interval: interval, stopCh: make(chan struct{})}
+}
+
+func (s *lockSampler) start(ctx context.Context) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sample(ctx)
+			}
+		}
+	}()
+}
+
+func (s *lockSampler) sample(ctx context.Context) {
+	var waiting int
+	err := s.pool.QueryRow(ctx, `SELECT count(*) FROM pg_stat_activity
+WHERE datname = current_database() AND wait_event_type = 'Lock'`).Scan(&waiting)
+	if err != nil {
+		return
+	}
+
+	s.result.Samples++
+	s.total += waiting
+	if waiting > 0 {
+		s.result.WaitingSample++
+	}
+	s.result.MaxWaiting = max(s.result.MaxWaiting, waiting)
+}
+
+func (s *lockSampler) stop() lockWaits {
+	close(s.stopCh)
+	s.wg.Wait()
+
+	if s.result.Samples > 0 {
+		s.result.AvgWaiting = float64(s.total) / float64(s.result.Samples)
+	}
+	s.result.WaitSeconds = float64(s.total) * s.interval.Seconds()
+	return s.result
+}
@@ -0,0 +1,224 @@
+// Command allocbench measures lease allocation under contention against a real
+// PostgreSQL database and prints a JSON report that can be compared across releases.
+//
+// It drives the same LeaseService and PostgreSQL repository as the server, without
+// Redis or HTTP in between, so the numbers reflect the allocator and the database only.
+// Run it against a dedicated database: with -reset it deletes every lease.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/allocation"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/zap"
+)
+
+type options struct {
+	DatabaseURL    string   `json:"-"`
+	Workers        int      `json:"workers"`
+	Allocations    int      `json:"allocations"`
+	Expired        int      `json:"expired"`
+	MaxRetries     int      `json:"max_retries"`
+	RetryDelay     duration `json:"retry_delay"`
+	PoolSize       int      `json:"pool_size"`
+	SampleInterval duration `json:"sample_interval"`
+	Reset          bool     `json:"reset"`
+	Strategy       string   `json:"strategy"`
+	ChunkSize      int      `json:"chunk_size"`
+	Label          string   `json:"label,omitempty"`
+}
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.DatabaseURL, "database-url", os.Getenv("DHCP2P_DATABASE_URL"), "PostgreSQL connection string (default $DHCP2P_DATABASE_URL)")
+	flag.IntVar(&opts.Workers, "workers", runtime.NumCPU()*4, "Concurrent allocating peers")
+	flag.IntVar(&opts.Allocations, "allocations", 2000, "Total allocations, spread over the workers")
+	flag.IntVar(&opts.Expired, "expired", 0, "Expired leases to seed before the run, to exercise reuse")
+	flag.IntVar(&opts.MaxRetries, "max-retries", config.NewDefaultAppConfig().Lease.MaxRetries, "lease.max_retries of the lease service")
+	flag.DurationVar((*time.Duration)(&opts.RetryDelay), "retry-delay", time.Duration(config.NewDefaultAppConfig().Lease.RetryDelay)*time.Millisecond, "lease.retry_delay of the lease service")
+	flag.IntVar(&opts.PoolSize, "pool-size", 0, "Database connections (default workers + 2)")
+	flag.DurationVar((*time.Duration)(&opts.SampleInterval), "sample-interval", 20*time.Millisecond, "How often lock waits are sampled")
+	flag.BoolVar(&opts.Reset, "reset", false, "Delete all leases and rewind alloc_state before the run")
+	flag.StringVar(&opts.Strategy, "strategy", config.NewDefaultAppConfig().Lease.AllocationStrategy, "lease.allocation_strategy of the lease service: lru, sequential or random")
+	flag.IntVar(&opts.ChunkSize, "chunk-size", config.NewDefaultAppConfig().Lease.AllocationChunkSize, "lease.allocation_chunk_size: token IDs reserved from alloc_state at once, 0 takes them one by one")
+	flag.StringVar(&opts.Label, "label", "", "Free-form label copied into the report, e.g. a release tag")
+	output := flag.String("output", "", "Write the JSON report to this file instead of stdout")
+	flag.Parse()
+
+	if opts.DatabaseURL == "" {
+		fail(fmt.Errorf("-database-url or DHCP2P_DATABASE_URL is required"))
+	}
+	if opts.Workers < 1 || opts.Allocations < 1 {
+		fail(fmt.Errorf("-workers and -allocations must be positive"))
+	}
+	if opts.PoolSize == 0 {
+		opts.PoolSize = opts.Workers + 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := run(ctx, opts)
+	if err != nil {
+		fail(err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fail(err)
+	}
+}
+
+func run(ctx context.Context, opts options) (*report, error) {
+	poolConfig, err := pgxpool.ParseConfig(opts.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse database URL: %w", err)
+	}
+	poolConfig.MaxConns = int32(opts.PoolSize)
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	if err := pool.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+
+	// Peer IDs of this run never collide with real peers or earlier runs
+	runID := time.Now().UTC().Format("20060102T150405.000")
+	prefix := "allocbench-" + runID + "-"
+
+	if opts.Reset {
+		if err := resetLeases(ctx, pool); err != nil {
+			return nil, fmt.Errorf("reset: %w", err)
+		}
+	}
+	defer cleanup(pool, prefix)
+
+	cfg := config.NewDefaultAppConfig()
+	cfg.Lease.MaxRetries = opts.MaxRetries
+	cfg.Lease.RetryDelay = int(time.Duration(opts.RetryDelay) / time.Millisecond)
+	cfg.Lease.AllocationStrategy = opts.Strategy
+	cfg.Lease.AllocationChunkSize = opts.ChunkSize
+
+	systemClock := clock.NewSystem()
+	leaseRepo := postgres.NewLeaseRepository(cfg, pool, nil, systemClock, nil)
+	defer leaseRepo.ReturnReservedTokenIDs(context.Background())
+
+	tenants, err := services.NewTenantService(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &countingRepository{LeaseRepository: leaseRepo}
+	strategy, err := allocation.NewStrategy(cfg, repo, tenants)
+	if err != nil {
+		return nil, err
+	}
+	service := services.NewLeaseService(cfg, repo, strategy, nil, nil, nil, nil, nil, systemClock, zap.NewNop())
+
+	if opts.Expired > 0 {
+		if err := seedExpired(ctx, pool, service, prefix, opts.Expired); err != nil {
+			return nil, fmt.Errorf("seed expired leases: %w", err)
+		}
+		repo.reset()
+	}
+
+	before, err := readDatabaseStats(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	sampler := newLockSampler(pool, time.Duration(opts.SampleInterval))
+	sampler.start(ctx)
+
+	latencies := make([]time.Duration, opts.Allocations)
+	var failures atomic.Int64
+	var lastErr atomic.Value
+	var next atomic.Int64
+
+	started := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				i := next.Add(1) - 1
+				if i >= int64(opts.Allocations) {
+					return
+				}
+
+				start := time.Now()
+				_, err := service.AllocateIP(ctx, fmt.Sprintf("%s%d", prefix, i))
+				latencies[i] = time.Since(start)
+				if err != nil {
+					failures.Add(1)
+					lastErr.Store(err.Error())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(started)
+
+	locks := sampler.stop()
+
+	after, err := readDatabaseStats(context.Background(), pool)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := min(int(next.Load()), opts.Allocations)
+	r := newReport(opts, latencies[:completed], elapsed)
+	r.Failures = failures.Load()
+	if msg, ok := lastErr.Load().(string); ok {
+		r.LastError = msg
+	}
+	r.Retries = repo.snapshot()
+	r.LockWaits = locks
+	r.Database = after.sub(before)
+	return r, nil
+}
+
+// duration is a time.Duration written as e.g. "500ms" in the report
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "allocbench: %v\n", err)
+	os.Exit(1)
+}
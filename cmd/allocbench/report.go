@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"runtime"
+	"slices"
+	"time"
+)
+
+type report struct {
+	Label     string    `json:"label,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	GoVersion string    `json:"go_version"`
+	Options   options   `json:"options"`
+
+	Allocations     int     `json:"allocations"` // completed, failed ones included
+	Failures        int64   `json:"failures"`
+	LastError       string  `json:"last_error,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Throughput      float64 `json:"allocations_per_second"`
+
+	Latency   latency       `json:"latency_ms"`
+	Retries   retryCounts   `json:"retries"`
+	LockWaits lockWaits     `json:"lock_waits"`
+	Database  databaseStats `json:"database"`
+}
+
+type latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+func newReport(opts options, latencies []time.Duration, elapsed time.Duration) *report {
+	r := &report{
+		Label:           opts.Label,
+		StartedAt:       time.Now().Add(-elapsed).UTC(),
+		GoVersion:       runtime.Version(),
+		Options:         opts,
+		Allocations:     len(latencies),
+		DurationSeconds: elapsed.Seconds(),
+	}
+	if elapsed > 0 {
+		r.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	r.Latency = summarize(latencies)
+	return r
+}
+
+// summarize computes the latency distribution in milliseconds, using nearest-rank
+// percentiles
+func summarize(latencies []time.Duration) latency {
+	if len(latencies) == 0 {
+		return latency{}
+	}
+
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+		rank = max(0, min(rank, len(sorted)-1))
+		return ms(sorted[rank])
+	}
+
+	return latency{
+		Min:  ms(sorted[0]),
+		Mean: ms(total / time.Duration(len(sorted))),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P99:  percentile(0.99),
+		Max:  ms(sorted[len(sorted)-1]),
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
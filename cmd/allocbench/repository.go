@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// countingRepository counts the repository errors on the allocation path. The lease
+// service retries every one of them until it gives up, so they measure contention.
+type countingRepository struct {
+	ports.LeaseRepository
+
+	reuseErrors    atomic.Int64
+	allocateErrors atomic.Int64
+}
+
+type retryCounts struct {
+	Reuse    int64 `json:"reuse"`    // failed FindAndReuseExpiredLease calls
+	Allocate int64 `json:"allocate"` // failed AllocateNewLease calls
+	Total    int64 `json:"total"`
+}
+
+func (r *countingRepository) FindAndReuseExpiredLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	lease, err := r.LeaseRepository.FindAndReuseExpiredLease(ctx, peerID)
+	if err != nil {
+		r.reuseErrors.Add(1)
+	}
+	return lease, err
+}
+
+func (r *countingRepository) AllocateNewLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	lease, err := r.LeaseRepository.AllocateNewLease(ctx, peerID)
+	if err != nil {
+		r.allocateErrors.Add(1)
+	}
+	return lease, err
+}
+
+func (r *countingRepository) reset() {
+	r.reuseErrors.Store(0)
+	r.allocateErrors.Store(0)
+}
+
+func (r *countingRepository) snapshot() retryCounts {
+	reuse, allocate := r.reuseErrors.Load(), r.allocateErrors.Load()
+	return retryCounts{Reuse: reuse, Allocate: allocate, Total: reuse + allocate}
+}
@@ -0,0 +1,142 @@
+// Command benchcheck compares `go test -bench -benchmem` output against a stored baseline
+// and fails when a benchmark got slower or allocates more than the given tolerances.
+//
+// Repeated runs of a benchmark (-count) are reduced to their median. Timings only compare
+// between runs on the same hardware, so a baseline taken on another CPU is reported before
+// the comparison; allocation counts do not depend on it. Benchmarks missing on either side
+// are listed but do not fail the check.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+)
+
+type options struct {
+	MaxSlowdown      float64
+	MaxAllocIncrease float64
+}
+
+func main() {
+	opts := options{}
+	baselinePath := flag.String("baseline", "", "Stored `go test -bench` output to compare against")
+	currentPath := flag.String("current", "-", "`go test -bench` output of this tree, - reads stdin")
+	flag.Float64Var(&opts.MaxSlowdown, "max-slowdown", 15, "Percent ns/op may grow before a benchmark fails")
+	flag.Float64Var(&opts.MaxAllocIncrease, "max-alloc-increase", 10, "Percent B/op and allocs/op may grow before a benchmark fails")
+	flag.Parse()
+
+	if *baselinePath == "" {
+		fail(fmt.Errorf("-baseline is required"))
+	}
+
+	baseline, err := parseFile(*baselinePath)
+	if err != nil {
+		fail(fmt.Errorf("baseline: %w", err))
+	}
+	current, err := parseFile(*currentPath)
+	if err != nil {
+		fail(fmt.Errorf("current: %w", err))
+	}
+	if len(current.Results) == 0 {
+		fail(fmt.Errorf("no benchmark results in %s", *currentPath))
+	}
+
+	if baseline.CPU != current.CPU {
+		fmt.Printf("baseline was taken on %q, this run on %q: ns/op is not comparable\n\n", baseline.CPU, current.CPU)
+	}
+
+	if regressions := compare(os.Stdout, baseline, current, opts); regressions > 0 {
+		fmt.Printf("\n%d regression(s) beyond -max-slowdown %.0f%% / -max-alloc-increase %.0f%%\n", regressions, opts.MaxSlowdown, opts.MaxAllocIncrease)
+		os.Exit(1)
+	}
+}
+
+// compare prints one row per benchmark and metric and returns the number of regressions
+func compare(out io.Writer, baseline, current *benchmarks, opts options) int {
+	names := make([]string, 0, len(current.Results))
+	for name := range current.Results {
+		names = append(names, name)
+	}
+	for name := range baseline.Results {
+		if _, ok := current.Results[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BENCHMARK\tMETRIC\tBASELINE\tCURRENT\tDELTA\t")
+
+	regressions := 0
+	for _, name := range names {
+		old, inBaseline := baseline.Results[name]
+		cur, inCurrent := current.Results[name]
+		switch {
+		case !inBaseline:
+			fmt.Fprintf(w, "%s\t\t\t\t\tnew, not in baseline\n", name)
+			continue
+		case !inCurrent:
+			fmt.Fprintf(w, "%s\t\t\t\t\tmissing from this run\n", name)
+			continue
+		}
+
+		metrics := []struct {
+			name      string
+			old, cur  float64
+			tolerance float64
+		}{
+			{"ns/op", old.NsPerOp, cur.NsPerOp, opts.MaxSlowdown},
+			{"B/op", old.BytesPerOp, cur.BytesPerOp, opts.MaxAllocIncrease},
+			{"allocs/op", old.AllocsPerOp, cur.AllocsPerOp, opts.MaxAllocIncrease},
+		}
+		for _, m := range metrics {
+			delta, regressed := change(m.old, m.cur, m.tolerance)
+			status := ""
+			if regressed {
+				status = "REGRESSION"
+				regressions++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%.0f\t%.0f\t%s\t%s\n", name, m.name, m.old, m.cur, delta, status)
+		}
+	}
+	w.Flush()
+
+	return regressions
+}
+
+// change formats the relative change from old to cur and reports whether it exceeds
+// tolerance percent. Growth from zero always counts as a regression.
+func change(old, cur, tolerance float64) (string, bool) {
+	if old == 0 {
+		if cur == 0 {
+			return "~", false
+		}
+		return "+inf", true
+	}
+
+	percent := (cur - old) / old * 100
+	return fmt.Sprintf("%+.1f%%", percent), percent > tolerance
+}
+
+func parseFile(path string) (*benchmarks, error) {
+	if path == "-" {
+		return parse(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parse(f)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "benchcheck: %v\n", err)
+	os.Exit(2)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// procsSuffix is the -GOMAXPROCS suffix go test appends to benchmark names
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+// result is the median of all runs of one benchmark
+type result struct {
+	NsPerOp     float64
+	BytesPerOp  float64
+	AllocsPerOp float64
+	Runs        int
+}
+
+// benchmarks are the results of one `go test -bench` output, keyed by benchmark name
+type benchmarks struct {
+	CPU     string
+	Results map[string]*result
+}
+
+// parse reads `go test -bench -benchmem` output. Lines other than benchmark results and
+// the cpu header are ignored, so the output of several packages can be concatenated.
+func parse(r io.Reader) (*benchmarks, error) {
+	runs := make(map[string][][3]float64)
+	parsed := &benchmarks{Results: make(map[string]*result)}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if cpu, ok := strings.CutPrefix(line, "cpu: "); ok {
+			parsed.CPU = strings.TrimSpace(cpu)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+
+		var metrics [3]float64
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid %s value %q", fields[0], fields[i+1], fields[i])
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				metrics[0] = value
+			case "B/op":
+				metrics[1] = value
+			case "allocs/op":
+				metrics[2] = value
+			}
+		}
+
+		name := procsSuffix.ReplaceAllString(fields[0], "")
+		runs[name] = append(runs[name], metrics)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for name, samples := range runs {
+		parsed.Results[name] = &result{
+			NsPerOp:     median(samples, 0),
+			BytesPerOp:  median(samples, 1),
+			AllocsPerOp: median(samples, 2),
+			Runs:        len(samples),
+		}
+	}
+	return parsed, nil
+}
+
+func median(samples [][3]float64, metric int) float64 {
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = sample[metric]
+	}
+	slices.Sort(values)
+
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
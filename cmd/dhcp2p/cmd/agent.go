@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/spf13/cobra"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/agent"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/discovery"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func agentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Keep the peer's lease alive",
+		Long: "Obtain a lease and renew it before it expires, allocating a new one if it is lost.\n" +
+			"The assigned IP can be written to a file or assigned to a network interface, and the\n" +
+			"agent's state is served on a local unix socket (see \"dhcp2p agent status\"). The lease\n" +
+			"is saved to --lease-file, so a restarted agent keeps its address.",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runAgent,
+	}
+
+	cmd.Flags().StringP(flag.SERVER_FLAG, flag.SERVER_FLAG_SHORT, "http://localhost:8088", "URL of the dhcp2p server")
+	cmd.Flags().Bool(flag.DISCOVER_FLAG, false, "Find the server on the local network over mDNS instead of using --"+flag.SERVER_FLAG)
+	cmd.Flags().Duration(flag.DISCOVER_TIMEOUT_FLAG, discovery.DefaultBrowseTimeout, "How long to wait for servers to answer with --"+flag.DISCOVER_FLAG)
+	cmd.Flags().StringP(flag.KEY_FLAG, flag.KEY_FLAG_SHORT, defaultKeyPath(), "Path to the peer key file")
+	cmd.Flags().Bool(flag.CREATE_KEY_FLAG, false, "Create the key file if it does not exist")
+	cmd.Flags().Bool(flag.SIGN_TIMESTAMP_FLAG, false, "Sign an X-Timestamp into every request")
+	cmd.Flags().Bool(flag.ETHEREUM_FLAG, false, "Identify the peer by the Ethereum address of a secp256k1 key")
+	cmd.Flags().Float64(flag.RENEW_FRACTION_FLAG, agent.DefaultConfig.RenewFraction, "Fraction of the remaining lease time after which to renew, when the server suggests no renewal time")
+	cmd.Flags().Duration(flag.RETRY_INTERVAL_FLAG, agent.DefaultConfig.RetryInterval, "Wait between failed attempts")
+	cmd.Flags().String(flag.IP_FILE_FLAG, "", "Write the assigned IP to this file")
+	cmd.Flags().StringP(flag.INTERFACE_FLAG, flag.INTERFACE_FLAG_SHORT, "", "Assign the IP to this network interface (needs CAP_NET_ADMIN)")
+	cmd.Flags().Int(flag.PREFIX_LENGTH_FLAG, 32, "Prefix length used with --"+flag.INTERFACE_FLAG)
+	cmd.Flags().String(flag.SOCKET_FLAG, defaultSocketPath(), "Unix socket serving the agent status; empty disables it")
+	cmd.Flags().Bool(flag.RELEASE_ON_EXIT_FLAG, false, "Release the lease when the agent stops")
+	cmd.Flags().String(flag.LEASE_FILE_FLAG, defaultLeaseFilePath(), "File the lease is saved to and resumed from after a restart; empty disables it")
+
+	cmd.AddCommand(agentStatusCmd())
+
+	return cmd
+}
+
+func runAgent(cmd *cobra.Command, args []string) error {
+	keyPath, _ := cmd.Flags().GetString(flag.KEY_FLAG)
+	createKey, _ := cmd.Flags().GetBool(flag.CREATE_KEY_FLAG)
+	sign, _ := cmd.Flags().GetBool(flag.SIGN_TIMESTAMP_FLAG)
+	ethereum, _ := cmd.Flags().GetBool(flag.ETHEREUM_FLAG)
+	ipFile, _ := cmd.Flags().GetString(flag.IP_FILE_FLAG)
+	iface, _ := cmd.Flags().GetString(flag.INTERFACE_FLAG)
+	prefixLength, _ := cmd.Flags().GetInt(flag.PREFIX_LENGTH_FLAG)
+	socketPath, _ := cmd.Flags().GetString(flag.SOCKET_FLAG)
+
+	cfg := agent.DefaultConfig
+	cfg.RenewFraction, _ = cmd.Flags().GetFloat64(flag.RENEW_FRACTION_FLAG)
+	cfg.RetryInterval, _ = cmd.Flags().GetDuration(flag.RETRY_INTERVAL_FLAG)
+	cfg.ReleaseOnStop, _ = cmd.Flags().GetBool(flag.RELEASE_ON_EXIT_FLAG)
+	cfg.LeaseFile, _ = cmd.Flags().GetString(flag.LEASE_FILE_FLAG)
+
+	if prefixLength < 0 || prefixLength > 32 {
+		return fmt.Errorf("--%s must be between 0 and 32", flag.PREFIX_LENGTH_FLAG)
+	}
+
+	server, err := serverURL(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Adopt the server's authentication requirements that the flags do not ask for
+	if info, err := fetchServerInfo(cmd.Context(), server); err == nil {
+		sign = sign || info.Auth.TimestampRequired
+		ethereum = ethereum || info.Auth.IdentityScheme == "ethereum"
+	}
+
+	key, err := client.LoadKey(keyPath)
+	if errors.Is(err, os.ErrNotExist) && createKey {
+		keyType := crypto.Ed25519
+		if ethereum {
+			keyType = crypto.Secp256k1
+		}
+		key, err = client.GenerateKeyOfType(keyPath, keyType)
+	}
+	if err != nil {
+		return fmt.Errorf("load key: %w", err)
+	}
+
+	var opts []client.Option
+	if sign {
+		opts = append(opts, client.WithSignedTimestamp())
+	}
+	if ethereum {
+		opts = append(opts, client.WithEthereumIdentity())
+	}
+	c, err := client.New(server, key, opts...)
+	if err != nil {
+		return err
+	}
+
+	var appliers []agent.Applier
+	if ipFile != "" {
+		appliers = append(appliers, agent.FileApplier{Path: ipFile})
+	}
+	if iface != "" {
+		appliers = append(appliers, agent.InterfaceApplier{Interface: iface, PrefixLength: prefixLength})
+	}
+
+	logger := newAgentLogger()
+	defer logger.Sync()
+
+	a, err := agent.New(c, cfg, appliers, logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("Agent started", zap.String("peerID", c.PeerID()), zap.String("server", server))
+
+	if socketPath != "" {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- a.Serve(ctx, socketPath)
+			cancel()
+		}()
+
+		a.Run(ctx)
+		cancel()
+		return <-serveErr
+	}
+	return a.Run(ctx)
+}
+
+// fetchServerInfo asks the server for its settings without retrying, so an unreachable
+// server does not delay the agent, which keeps retrying on its own
+func fetchServerInfo(ctx context.Context, server string) (*client.ServerInfo, error) {
+	c, err := client.New(server, nil, client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return c.ServerInfo(ctx)
+}
+
+func agentStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "status",
+		Short:         "Show the state of a running agent",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			socketPath, _ := cmd.Flags().GetString(flag.SOCKET_FLAG)
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer cancel()
+
+			status, err := agent.QueryStatus(ctx, socketPath)
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, status, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "STATE\tIP\tTOKEN ID\tEXPIRES AT\tNEXT RENEWAL\n")
+				tokenID, expiresAt := "-", "-"
+				if status.Lease != nil {
+					tokenID = fmt.Sprint(status.Lease.TokenID)
+					expiresAt = status.Lease.ExpiresAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", status.State, valueOr(status.IP, "-"), tokenID, expiresAt, status.NextRenewal.Format(time.RFC3339))
+				if status.LastError != "" {
+					fmt.Fprintf(w, "\nLAST ERROR\t%s\t%s\n", status.LastErrorTime.Format(time.RFC3339), status.LastError)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().String(flag.SOCKET_FLAG, defaultSocketPath(), "Unix socket of the agent")
+	cmd.Flags().StringP(flag.OUTPUT_FLAG, flag.OUTPUT_FLAG_SHORT, outputTable, "Output format (table or json)")
+
+	return cmd
+}
+
+// newAgentLogger logs to stderr only; the agent is a client-side process and should not
+// write the server's log files
+func newAgentLogger() *zap.Logger {
+	encoderCfg := zap.NewDevelopmentEncoderConfig()
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	return zap.New(zapcore.NewCore(zapcore.NewConsoleEncoder(encoderCfg), zapcore.Lock(os.Stderr), zap.InfoLevel))
+}
+
+func defaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "dhcp2p-agent.sock")
+	}
+	return filepath.Join(os.TempDir(), "dhcp2p-agent.sock")
+}
+
+// defaultLeaseFilePath keeps the lease next to the default key
+func defaultLeaseFilePath() string {
+	return filepath.Join(filepath.Dir(defaultKeyPath()), "agent-lease.json")
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
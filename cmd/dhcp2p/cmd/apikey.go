@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"go.uber.org/fx"
+)
+
+func apiKeyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apikey",
+		Short: "Manage the API keys of the admin API",
+		Long: "Create, list and revoke the keys admin API clients send in the X-API-Key header when\n" +
+			"admin_api_keys_enabled is set. Keys are stored hashed; the key itself is only shown\n" +
+			"when it is created.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString(flag.OUTPUT_FLAG)
+			if output != outputTable && output != outputJSON {
+				return fmt.Errorf("--%s must be %s or %s", flag.OUTPUT_FLAG, outputTable, outputJSON)
+			}
+			return nil
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.PersistentFlags().StringP(flag.OUTPUT_FLAG, flag.OUTPUT_FLAG_SHORT, outputTable, "Output format (table or json)")
+
+	cmd.AddCommand(apiKeyCreateCmd())
+	cmd.AddCommand(apiKeyListCmd())
+	cmd.AddCommand(apiKeyRevokeCmd())
+
+	return cmd
+}
+
+func apiKeyCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an API key and print it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _ := cmd.Flags().GetString(flag.NAME_FLAG)
+			scopeNames, _ := cmd.Flags().GetStringSlice(flag.SCOPE_FLAG)
+
+			scopes := make([]models.APIKeyScope, len(scopeNames))
+			for i, name := range scopeNames {
+				scopes[i] = models.APIKeyScope(strings.TrimSpace(name))
+			}
+
+			apiKeys, stop, err := startAPIKeyService()
+			if err != nil {
+				return err
+			}
+			defer stop()
+
+			issued, err := apiKeys.Create(context.Background(), name, scopes)
+			if err != nil {
+				return fmt.Errorf("create API key: %w", err)
+			}
+
+			return printOutput(cmd, issued, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "ID\tNAME\tSCOPES\tKEY\n%d\t%s\t%s\t%s\n", issued.ID, issued.Name, issued.ScopeNames(), issued.Key)
+				fmt.Fprintln(os.Stderr, "Store the key now, it cannot be shown again.")
+			})
+		},
+	}
+
+	scopeNames := make([]string, len(models.APIKeyScopes))
+	for i, scope := range models.APIKeyScopes {
+		scopeNames[i] = string(scope)
+	}
+
+	cmd.Flags().StringP(flag.NAME_FLAG, flag.NAME_FLAG_SHORT, "", "Name identifying the key holder (required)")
+	cmd.Flags().StringSliceP(flag.SCOPE_FLAG, flag.SCOPE_FLAG_SHORT, nil, "Scopes granted to the key, repeatable or comma-separated: "+strings.Join(scopeNames, ", "))
+	cmd.MarkFlagRequired(flag.NAME_FLAG)
+	cmd.MarkFlagRequired(flag.SCOPE_FLAG)
+
+	return cmd
+}
+
+func apiKeyListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the API keys, including revoked ones",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			apiKeys, stop, err := startAPIKeyService()
+			if err != nil {
+				return err
+			}
+			defer stop()
+
+			keys, err := apiKeys.List(context.Background())
+			if err != nil {
+				return fmt.Errorf("list API keys: %w", err)
+			}
+
+			return printOutput(cmd, keys, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tCREATED\tLAST USED\tREVOKED")
+				for _, key := range keys {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, key.ScopeNames(),
+						key.CreatedAt.Format(time.RFC3339), formatOptionalTime(key.LastUsedAt), formatOptionalTime(key.RevokedAt))
+				}
+			})
+		},
+	}
+}
+
+func apiKeyRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke <id>",
+		Short: "Revoke an API key",
+		Long:  "Revoke an API key for good. Requests with the key are refused from then on.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || id <= 0 {
+				return fmt.Errorf("invalid API key ID %q", args[0])
+			}
+
+			apiKeys, stop, err := startAPIKeyService()
+			if err != nil {
+				return err
+			}
+			defer stop()
+
+			key, err := apiKeys.Revoke(context.Background(), id)
+			if err != nil {
+				return fmt.Errorf("revoke API key: %w", err)
+			}
+
+			return printOutput(cmd, key, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "ID\tNAME\tREVOKED\n%d\t%s\t%s\n", key.ID, key.Name, formatOptionalTime(key.RevokedAt))
+			})
+		},
+	}
+}
+
+// startAPIKeyService starts the storage backend for an apikey command. The memory
+// backend is refused, the keys would be lost on exit.
+func startAPIKeyService() (ports.APIKeyService, func(), error) {
+	cfg, err := config.NewAppConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.StorageBackend == config.StorageBackendMemory {
+		return nil, nil, fmt.Errorf("API keys need a persistent storage backend, not %s", cfg.StorageBackend)
+	}
+
+	var apiKeys ports.APIKeyService
+	application := app.NewCommandApp(fx.Populate(&apiKeys))
+
+	ctx := context.Background()
+	if err := application.Start(ctx); err != nil {
+		return nil, nil, err
+	}
+	return apiKeys, func() { application.Stop(ctx) }, nil
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/spf13/cobra"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/discovery"
+)
+
+// Output formats of the client commands
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func clientCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "client",
+		Short: "Call a dhcp2p server as a peer",
+		Long: "Authenticate against a dhcp2p server with the peer key from the key file and manage\n" +
+			"the peer's lease. Intended for manual testing and node bootstrap scripts.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString(flag.OUTPUT_FLAG)
+			if output != outputTable && output != outputJSON {
+				return fmt.Errorf("--%s must be %s or %s", flag.OUTPUT_FLAG, outputTable, outputJSON)
+			}
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringP(flag.SERVER_FLAG, flag.SERVER_FLAG_SHORT, "http://localhost:8088", "URL of the dhcp2p server")
+	cmd.PersistentFlags().StringP(flag.KEY_FLAG, flag.KEY_FLAG_SHORT, defaultKeyPath(), "Path to the peer key file")
+	cmd.PersistentFlags().StringP(flag.OUTPUT_FLAG, flag.OUTPUT_FLAG_SHORT, outputTable, "Output format (table or json)")
+	cmd.PersistentFlags().Bool(flag.SIGN_TIMESTAMP_FLAG, false, "Sign an X-Timestamp into every request")
+	cmd.PersistentFlags().Bool(flag.AUTH_LOOKUPS_FLAG, false, "Authenticate lookups, for servers in strict mode")
+	cmd.PersistentFlags().Bool(flag.ETHEREUM_FLAG, false, "Identify the peer by the Ethereum address of a secp256k1 key, for servers with security.identity_scheme ethereum")
+	cmd.PersistentFlags().String(flag.API_KEY_FLAG, "", "API key of the tenant to be served for, for servers with tenants")
+	cmd.PersistentFlags().String(flag.ZONE_FLAG, "", "Rack or site to allocate leases in when the server has a free address there")
+	cmd.PersistentFlags().Bool(flag.DISCOVER_FLAG, false, "Find the server on the local network over mDNS instead of using --"+flag.SERVER_FLAG)
+	cmd.PersistentFlags().Duration(flag.DISCOVER_TIMEOUT_FLAG, discovery.DefaultBrowseTimeout, "How long to wait for servers to answer with --"+flag.DISCOVER_FLAG)
+
+	cmd.AddCommand(clientKeygenCmd())
+	cmd.AddCommand(clientAllocateCmd())
+	cmd.AddCommand(clientRenewCmd())
+	cmd.AddCommand(clientReleaseCmd())
+	cmd.AddCommand(clientStatusCmd())
+	cmd.AddCommand(clientServerInfoCmd())
+	cmd.AddCommand(clientDiscoverCmd())
+
+	// Failed calls are reported by main, without the usage text
+	for _, sub := range cmd.Commands() {
+		sub.SilenceUsage = true
+		sub.SilenceErrors = true
+	}
+
+	return cmd
+}
+
+func clientKeygenCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keygen",
+		Short: "Create a new peer key file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, _ := cmd.Flags().GetString(flag.KEY_FLAG)
+			ethereum, _ := cmd.Flags().GetBool(flag.ETHEREUM_FLAG)
+
+			// Ethereum addresses are only defined for secp256k1 keys
+			keyType := crypto.Ed25519
+			if ethereum {
+				keyType = crypto.Secp256k1
+			}
+			key, err := client.GenerateKeyOfType(path, keyType)
+			if err != nil {
+				return fmt.Errorf("generate key: %w", err)
+			}
+
+			peerID, err := client.PeerIdentity(key, ethereum)
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, map[string]string{"peer_id": peerID, "key": path}, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "PEER ID\tKEY\n%s\t%s\n", peerID, path)
+			})
+		},
+	}
+}
+
+func clientAllocateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "allocate",
+		Short: "Allocate a lease, or show the lease the peer already holds",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd, true)
+			if err != nil {
+				return err
+			}
+
+			req := &client.AllocateRequest{}
+			req.TokenID, _ = cmd.Flags().GetInt64(flag.TOKEN_ID_FLAG)
+			req.AffinityGroup, _ = cmd.Flags().GetString(flag.AFFINITY_GROUP_FLAG)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			allocation, err := c.AllocateIP(ctx, req)
+			if err != nil {
+				return err
+			}
+			if req.TokenID == 0 {
+				return printLease(cmd, allocation.Lease)
+			}
+
+			return printOutput(cmd, allocation, func(w *tabwriter.Writer) {
+				writeLeaseTable(w, allocation.Lease)
+				fmt.Fprintf(w, "\nREQUESTED\tGRANTED\tREASON\n%d\t%t\t%s\n", allocation.RequestedTokenID, allocation.Granted, allocation.Reason)
+			})
+		},
+	}
+
+	cmd.Flags().Int64P(flag.TOKEN_ID_FLAG, flag.TOKEN_ID_FLAG_SHORT, 0, "Preferred token ID")
+	cmd.Flags().String(flag.AFFINITY_GROUP_FLAG, "", "Affinity group to allocate next to")
+
+	return cmd
+}
+
+func clientRenewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "renew <token-id>",
+		Short: "Renew the peer's lease",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tokenID, err := parseTokenID(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := newClient(cmd, true)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			lease, err := c.RenewLease(ctx, tokenID)
+			if err != nil {
+				return err
+			}
+			if lease.RenewableAt != nil {
+				fmt.Fprintf(os.Stderr, "lease not renewed yet, renewable from %s\n", lease.RenewableAt.Format(time.RFC3339))
+			}
+			return printLease(cmd, lease)
+		},
+	}
+}
+
+func clientReleaseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "release <token-id>",
+		Short: "Release the peer's lease",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tokenID, err := parseTokenID(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := newClient(cmd, true)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			if err := c.ReleaseLease(ctx, tokenID); err != nil {
+				return err
+			}
+			return printOutput(cmd, map[string]any{"token_id": tokenID, "status": "released"}, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "TOKEN ID\tSTATUS\n%d\treleased\n", tokenID)
+			})
+		},
+	}
+}
+
+func clientStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"get"},
+		Short:   "Show the lease of this peer, another peer or a token ID",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tokenID, _ := cmd.Flags().GetInt64(flag.TOKEN_ID_FLAG)
+			peerID, _ := cmd.Flags().GetString(flag.PEER_ID_FLAG)
+			authLookups, _ := cmd.Flags().GetBool(flag.AUTH_LOOKUPS_FLAG)
+
+			// The own peer ID and authenticated lookups need the key
+			c, err := newClient(cmd, authLookups || (tokenID == 0 && peerID == ""))
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			var lease *client.Lease
+			switch {
+			case tokenID != 0:
+				lease, err = c.GetLeaseByTokenID(ctx, tokenID)
+			case peerID != "":
+				lease, err = c.GetLease(ctx, peerID)
+			default:
+				lease, err = c.GetLease(ctx, c.PeerID())
+			}
+			if err != nil {
+				return err
+			}
+			return printLease(cmd, lease)
+		},
+	}
+
+	cmd.Flags().Int64P(flag.TOKEN_ID_FLAG, flag.TOKEN_ID_FLAG_SHORT, 0, "Look up the lease on this token ID")
+	cmd.Flags().String(flag.PEER_ID_FLAG, "", "Look up the lease of this peer ID instead of the own one")
+	cmd.MarkFlagsMutuallyExclusive(flag.TOKEN_ID_FLAG, flag.PEER_ID_FLAG)
+
+	return cmd
+}
+
+func clientServerInfoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "server-info",
+		Short: "Show the server's version, settings and supported features",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd, false)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			info, err := c.ServerInfo(ctx)
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, info, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "VERSION\t%s\n", info.Version)
+				if info.Tenant != "" {
+					fmt.Fprintf(w, "TENANT\t%s\n", info.Tenant)
+				}
+				for _, pool := range info.Pools {
+					fmt.Fprintf(w, "POOL\t%s - %s (%d - %d)\n", pool.FirstIP, pool.LastIP, pool.MinTokenID, pool.MaxTokenID)
+				}
+				fmt.Fprintf(w, "LEASE TTL\t%d - %d minutes\n", info.LeaseTTL.MinMinutes, info.LeaseTTL.MaxMinutes)
+				fmt.Fprintf(w, "AUTH\t%s, identity %s, timestamp required %t\n", strings.Join(info.Auth.Methods, ", "), info.Auth.IdentityScheme, info.Auth.TimestampRequired)
+				fmt.Fprintf(w, "FEATURES\t%s\n", strings.Join(info.Features, ", "))
+				if info.LeaseSigning {
+					fmt.Fprintf(w, "SERVER PEER ID\t%s\n", info.PeerID)
+				}
+			})
+		},
+	}
+}
+
+func clientDiscoverCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "discover",
+		Short: "List the dhcp2p servers advertised on the local network",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			timeout, _ := cmd.Flags().GetDuration(flag.DISCOVER_TIMEOUT_FLAG)
+
+			services, err := discovery.Browse(cmd.Context(), timeout)
+			if err != nil {
+				return err
+			}
+
+			type discovered struct {
+				discovery.Service
+				URL string `json:"url"`
+			}
+			found := make([]discovered, 0, len(services))
+			for _, service := range services {
+				found = append(found, discovered{Service: service, URL: service.URL()})
+			}
+			return printOutput(cmd, found, func(w *tabwriter.Writer) {
+				fmt.Fprintf(w, "INSTANCE\tURL\tVERSION\tPEER ID\n")
+				for _, service := range found {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service.Instance, service.URL, service.Text[discovery.TextVersion], service.Text[discovery.TextPeerID])
+				}
+			})
+		},
+	}
+}
+
+// serverURL is the URL given with --server, or that of the first server answering over
+// mDNS with --discover
+func serverURL(cmd *cobra.Command) (string, error) {
+	if discover, _ := cmd.Flags().GetBool(flag.DISCOVER_FLAG); !discover {
+		server, _ := cmd.Flags().GetString(flag.SERVER_FLAG)
+		return server, nil
+	}
+
+	timeout, _ := cmd.Flags().GetDuration(flag.DISCOVER_TIMEOUT_FLAG)
+	services, err := discovery.Browse(cmd.Context(), timeout)
+	if err != nil {
+		return "", fmt.Errorf("discover server: %w", err)
+	}
+	if len(services) == 0 {
+		return "", fmt.Errorf("no dhcp2p server answered on the local network within %s", timeout)
+	}
+	return services[0].URL(), nil
+}
+
+// newClient creates a client from the persistent flags, loading the key only when needed
+func newClient(cmd *cobra.Command, needKey bool) (*client.Client, error) {
+	server, err := serverURL(cmd)
+	if err != nil {
+		return nil, err
+	}
+	keyPath, _ := cmd.Flags().GetString(flag.KEY_FLAG)
+
+	var opts []client.Option
+	if sign, _ := cmd.Flags().GetBool(flag.SIGN_TIMESTAMP_FLAG); sign {
+		opts = append(opts, client.WithSignedTimestamp())
+	}
+	if authLookups, _ := cmd.Flags().GetBool(flag.AUTH_LOOKUPS_FLAG); authLookups {
+		opts = append(opts, client.WithAuthenticatedLookups())
+	}
+	if ethereum, _ := cmd.Flags().GetBool(flag.ETHEREUM_FLAG); ethereum {
+		opts = append(opts, client.WithEthereumIdentity())
+	}
+	if apiKey, _ := cmd.Flags().GetString(flag.API_KEY_FLAG); apiKey != "" {
+		opts = append(opts, client.WithAPIKey(apiKey))
+	}
+	if zone, _ := cmd.Flags().GetString(flag.ZONE_FLAG); zone != "" {
+		opts = append(opts, client.WithZone(zone))
+	}
+
+	if !needKey {
+		return client.New(server, nil, opts...)
+	}
+
+	key, err := client.LoadKey(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("load key (create one with \"dhcp2p client keygen\"): %w", err)
+	}
+	return client.New(server, key, opts...)
+}
+
+func printLease(cmd *cobra.Command, lease *client.Lease) error {
+	return printOutput(cmd, lease, func(w *tabwriter.Writer) {
+		writeLeaseTable(w, lease)
+	})
+}
+
+func writeLeaseTable(w *tabwriter.Writer, lease *client.Lease) {
+	fmt.Fprintf(w, "TOKEN ID\tIP\tPEER ID\tEXPIRES AT\n")
+	fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", lease.TokenID, lease.IP(), lease.PeerID, lease.ExpiresAt.Format(time.RFC3339))
+}
+
+// printOutput prints v as JSON or calls table, depending on the output flag
+func printOutput(cmd *cobra.Command, v any, table func(w *tabwriter.Writer)) error {
+	output, _ := cmd.Flags().GetString(flag.OUTPUT_FLAG)
+	if output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+func parseTokenID(value string) (int64, error) {
+	tokenID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || tokenID <= 0 {
+		return 0, fmt.Errorf("invalid token ID %q", value)
+	}
+	return tokenID, nil
+}
+
+func defaultKeyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "dhcp2p.key"
+	}
+	return filepath.Join(home, ".dhcp2p", "key")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+)
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and maintain dhcp2p configuration",
+	}
+
+	cmd.AddCommand(configMigrateCmd())
+	cmd.AddCommand(configPrintCmd())
+	cmd.AddCommand(configValidateCmd())
+
+	return cmd
+}
+
+func configMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite a config file from an older version to the current schema",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inPath := viper.GetString(flag.CONFIG_FLAG)
+			if inPath == "" {
+				return fmt.Errorf("--%s is required", flag.CONFIG_FLAG)
+			}
+			outPath, _ := cmd.Flags().GetString(flag.OUTPUT_FLAG)
+
+			migrated, report, err := config.MigrateConfigFile(inPath)
+			if err != nil {
+				return err
+			}
+
+			printMigrationReport(report)
+
+			if outPath == "" {
+				return migrated.WriteConfigTo(os.Stdout)
+			}
+			if err := migrated.WriteConfigAs(outPath); err != nil {
+				return fmt.Errorf("write config: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Migrated config written to %s\n", outPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP(flag.OUTPUT_FLAG, flag.OUTPUT_FLAG_SHORT, "", "Path to write the migrated config (defaults to stdout)")
+
+	return cmd
+}
+
+func configPrintCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "print",
+		Short:        "Print the effective configuration with secrets redacted",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.NewAppConfig()
+			if err != nil {
+				return err
+			}
+
+			out := viper.New()
+			out.SetConfigType("yaml")
+			if err := out.MergeConfigMap(cfg.Settings()); err != nil {
+				return err
+			}
+			return out.WriteConfigTo(os.Stdout)
+		},
+	}
+}
+
+func configValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "validate",
+		Short:        "Check the configuration and report every problem",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if path := viper.GetString(flag.CONFIG_FLAG); path != "" {
+				if _, report, err := config.MigrateConfigFile(path); err == nil && report.HasChanges() {
+					fmt.Fprintf(os.Stderr, "%s uses keys of an older schema, run `dhcp2p config migrate` to rewrite it\n", path)
+				}
+			}
+			for _, warning := range config.CheckEnvironment(os.Environ()) {
+				fmt.Fprintf(os.Stderr, "env: %s\n", warning)
+			}
+
+			if _, err := config.NewAppConfig(); err != nil {
+				return err
+			}
+			fmt.Println("Config is valid")
+			return nil
+		},
+	}
+}
+
+func printMigrationReport(report *config.MigrationReport) {
+	oldKeys := make([]string, 0, len(report.Renamed))
+	for oldKey := range report.Renamed {
+		oldKeys = append(oldKeys, oldKey)
+	}
+	sort.Strings(oldKeys)
+
+	for _, oldKey := range oldKeys {
+		fmt.Fprintf(os.Stderr, "renamed: %s -> %s\n", oldKey, report.Renamed[oldKey])
+	}
+	for _, key := range report.Removed {
+		fmt.Fprintf(os.Stderr, "removed: %s\n", key)
+	}
+	for _, key := range report.Conflicts {
+		fmt.Fprintf(os.Stderr, "ignored: %s (the new key is already set)\n", key)
+	}
+	for _, key := range report.Unknown {
+		fmt.Fprintf(os.Stderr, "unknown: %s\n", key)
+	}
+	for _, warning := range report.EnvWarnings {
+		fmt.Fprintf(os.Stderr, "env: %s\n", warning)
+	}
+	if !report.HasChanges() && len(report.Unknown) == 0 {
+		fmt.Fprintln(os.Stderr, "Config is already up to date")
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"go.uber.org/fx"
+)
+
+func fsckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Verify the integrity of the stored leases and nonces",
+		Long: "Verify invariants the storage schema cannot enforce and optionally repair them.\n" +
+			"Exits with an error while violations remain, so it can run as a scheduled audit.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repair, _ := cmd.Flags().GetBool(flag.REPAIR_FLAG)
+			asJSON, _ := cmd.Flags().GetBool(flag.JSON_FLAG)
+
+			var checker ports.IntegrityChecker
+			application := app.NewCommandApp(fx.Populate(&checker))
+
+			ctx := context.Background()
+			if err := application.Start(ctx); err != nil {
+				return err
+			}
+			defer application.Stop(ctx)
+
+			report, err := checker.Check(ctx, repair)
+			if err != nil {
+				return fmt.Errorf("integrity check: %w", err)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printIntegrityReport(report)
+			}
+
+			if n := report.Unrepaired(); n > 0 {
+				return fmt.Errorf("%d integrity violation(s) found", n)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP(flag.REPAIR_FLAG, flag.REPAIR_FLAG_SHORT, false, "Repair violations that have a safe repair")
+	cmd.Flags().BoolP(flag.JSON_FLAG, flag.JSON_FLAG_SHORT, false, "Print the report as JSON")
+
+	return cmd
+}
+
+func printIntegrityReport(report *models.IntegrityReport) {
+	for _, check := range report.Checks {
+		if len(check.Violations) == 0 {
+			fmt.Printf("%s: ok\n", check.Check)
+			continue
+		}
+
+		fmt.Printf("%s: %d violation(s)\n", check.Check, len(check.Violations))
+		for _, v := range check.Violations {
+			status := ""
+			if v.Repaired {
+				status = " (repaired)"
+			}
+			fmt.Printf("  %s: %s%s\n", v.Subject, v.Detail, status)
+		}
+	}
+
+	fmt.Printf("%d violation(s), %d repaired\n", report.Violations(), report.Violations()-report.Unrepaired())
+}
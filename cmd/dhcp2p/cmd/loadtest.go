@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/spf13/cobra"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/loadtest"
+)
+
+func loadtestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Soak-test a running server with simulated peers",
+		Long: "Send allocations, renewals, lookups and releases of simulated peers, each with a key of\n" +
+			"its own, to a running dhcp2p server and report the latency percentiles and error rates\n" +
+			"of every operation. The peers' leases occupy the pool like those of real peers; they\n" +
+			"are released when the test ends unless --cleanup=false.",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runLoadtest,
+	}
+
+	defaults := loadtest.DefaultConfig
+	cmd.Flags().StringP(flag.SERVER_FLAG, flag.SERVER_FLAG_SHORT, "http://localhost:8088", "URL of the dhcp2p server")
+	cmd.Flags().Int(flag.CONCURRENCY_FLAG, defaults.Concurrency, "Workers sending operations in parallel")
+	cmd.Flags().DurationP(flag.DURATION_FLAG, flag.DURATION_FLAG_SHORT, defaults.Duration, "How long to send operations")
+	cmd.Flags().Float64(flag.RATE_FLAG, 0, "Operations per second across all workers; 0 sends as many as the server answers")
+	cmd.Flags().Int(flag.PEERS_FLAG, defaults.Peers, "Simulated peers, at least --"+flag.CONCURRENCY_FLAG)
+	cmd.Flags().String(flag.MIX_FLAG, defaults.Mix.String(), "Weights of the operations allocate, renew, get and release")
+	cmd.Flags().Duration(flag.OP_TIMEOUT_FLAG, defaults.Timeout, "Timeout of a single operation")
+	cmd.Flags().Bool(flag.CLEANUP_FLAG, defaults.Cleanup, "Release the peers' leases when the test ends")
+	cmd.Flags().Duration(flag.PROGRESS_FLAG, defaults.ProgressInterval, "Print the totals to stderr this often; 0 disables it")
+	cmd.Flags().Bool(flag.SIGN_TIMESTAMP_FLAG, false, "Sign an X-Timestamp into every request")
+	cmd.Flags().Bool(flag.AUTH_LOOKUPS_FLAG, false, "Authenticate lookups, for servers in strict mode")
+	cmd.Flags().Bool(flag.ETHEREUM_FLAG, false, "Identify the peers by the Ethereum addresses of secp256k1 keys")
+	cmd.Flags().String(flag.API_KEY_FLAG, "", "API key of the tenant to be served for, for servers with tenants")
+	cmd.Flags().StringP(flag.OUTPUT_FLAG, flag.OUTPUT_FLAG_SHORT, outputTable, "Output format (table or json)")
+
+	return cmd
+}
+
+func runLoadtest(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString(flag.OUTPUT_FLAG)
+	if output != outputTable && output != outputJSON {
+		return fmt.Errorf("--%s must be %s or %s", flag.OUTPUT_FLAG, outputTable, outputJSON)
+	}
+	mixValue, _ := cmd.Flags().GetString(flag.MIX_FLAG)
+	mix, err := loadtest.ParseMix(mixValue)
+	if err != nil {
+		return fmt.Errorf("--%s: %w", flag.MIX_FLAG, err)
+	}
+	sign, _ := cmd.Flags().GetBool(flag.SIGN_TIMESTAMP_FLAG)
+	authLookups, _ := cmd.Flags().GetBool(flag.AUTH_LOOKUPS_FLAG)
+	ethereum, _ := cmd.Flags().GetBool(flag.ETHEREUM_FLAG)
+	apiKey, _ := cmd.Flags().GetString(flag.API_KEY_FLAG)
+
+	cfg := loadtest.DefaultConfig
+	cfg.Server, _ = cmd.Flags().GetString(flag.SERVER_FLAG)
+	cfg.Concurrency, _ = cmd.Flags().GetInt(flag.CONCURRENCY_FLAG)
+	cfg.Duration, _ = cmd.Flags().GetDuration(flag.DURATION_FLAG)
+	cfg.Rate, _ = cmd.Flags().GetFloat64(flag.RATE_FLAG)
+	cfg.Peers, _ = cmd.Flags().GetInt(flag.PEERS_FLAG)
+	cfg.Mix = mix
+	cfg.Timeout, _ = cmd.Flags().GetDuration(flag.OP_TIMEOUT_FLAG)
+	cfg.Cleanup, _ = cmd.Flags().GetBool(flag.CLEANUP_FLAG)
+	cfg.ProgressInterval, _ = cmd.Flags().GetDuration(flag.PROGRESS_FLAG)
+	cfg.Progress = func(elapsed time.Duration, operations, errors int64) {
+		fmt.Fprintf(os.Stderr, "%s: %d operations, %d errors\n", elapsed.Round(time.Second), operations, errors)
+	}
+
+	// Adopt the server's authentication requirements that the flags do not ask for
+	if info, err := fetchServerInfo(cmd.Context(), cfg.Server); err == nil {
+		sign = sign || info.Auth.TimestampRequired
+		ethereum = ethereum || info.Auth.IdentityScheme == "ethereum"
+	}
+	if sign {
+		cfg.ClientOptions = append(cfg.ClientOptions, client.WithSignedTimestamp())
+	}
+	if authLookups {
+		cfg.ClientOptions = append(cfg.ClientOptions, client.WithAuthenticatedLookups())
+	}
+	if ethereum {
+		// Ethereum addresses are only defined for secp256k1 keys
+		cfg.KeyType = crypto.Secp256k1
+		cfg.ClientOptions = append(cfg.ClientOptions, client.WithEthereumIdentity())
+	}
+	if apiKey != "" {
+		cfg.ClientOptions = append(cfg.ClientOptions, client.WithAPIKey(apiKey))
+	}
+
+	// SIGINT ends the test early, the operations so far are still reported
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := loadtest.Run(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	return printOutput(cmd, report, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "OP\tCOUNT\tERRORS\tERROR RATE\tOPS/S\tMIN\tMEAN\tP50\tP90\tP99\tMAX\n")
+		for _, op := range report.Ops {
+			l := op.Latency
+			fmt.Fprintf(w, "%s\t%d\t%d\t%.2f%%\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\n",
+				op.Op, op.Count, op.Errors, op.ErrorRate*100, op.Throughput, l.Min, l.Mean, l.P50, l.P90, l.P99, l.Max)
+		}
+		fmt.Fprintf(w, "total\t%d\t%d\t%.2f%%\t%.1f\n", report.Operations, report.Errors, report.ErrorRate*100, report.Throughput)
+
+		if len(report.ErrorCodes) > 0 {
+			codes := make([]string, 0, len(report.ErrorCodes))
+			for code := range report.ErrorCodes {
+				codes = append(codes, code)
+			}
+			sort.Strings(codes)
+			fmt.Fprintf(w, "\nERROR\tCOUNT\n")
+			for _, code := range codes {
+				fmt.Fprintf(w, "%s\t%d\n", code, report.ErrorCodes[code])
+			}
+		}
+		fmt.Fprintf(w, "\nLatencies in milliseconds over %.1fs\n", report.DurationSeconds)
+	})
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"go.uber.org/fx"
+)
+
+func migrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply the database migrations shipped with this binary",
+		Long: "Apply pending migrations to the PostgreSQL database and verify that alloc_state matches\n" +
+			"the configured pool, provisioning the pools of new tenants. Safe to run from several instances\n" +
+			"at once; they wait for each other.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statusOnly, _ := cmd.Flags().GetBool(flag.STATUS_FLAG)
+
+			cfg, err := config.NewAppConfig()
+			if err != nil {
+				return err
+			}
+			if cfg.StorageBackend != config.StorageBackendPostgres {
+				return fmt.Errorf("migrations only apply to the %s storage backend, not %s", config.StorageBackendPostgres, cfg.StorageBackend)
+			}
+
+			var migrator ports.SchemaMigrator
+			var tenants ports.TenantResolver
+			application := app.NewCommandApp(fx.Populate(&migrator, &tenants))
+
+			ctx := context.Background()
+			if err := application.Start(ctx); err != nil {
+				return err
+			}
+			defer application.Stop(ctx)
+
+			status, err := migrator.Status(ctx)
+			if err != nil {
+				return fmt.Errorf("read schema version: %w", err)
+			}
+			if statusOnly {
+				printSchemaStatus(status)
+				return nil
+			}
+
+			applied, err := migrator.Migrate(ctx)
+			for _, version := range applied {
+				fmt.Printf("applied %s\n", version)
+			}
+			if err != nil {
+				return err
+			}
+			if len(applied) == 0 {
+				fmt.Printf("schema is up to date at %s\n", status.Current)
+			}
+
+			if err := migrator.VerifyAllocState(ctx, cfg.PoolMinTokenID, cfg.PoolMaxTokenID); err != nil {
+				return err
+			}
+			return migrator.ProvisionTenantPools(ctx, tenants.Tenants())
+		},
+	}
+
+	cmd.Flags().BoolP(flag.STATUS_FLAG, flag.STATUS_FLAG_SHORT, false, "Only print the applied and pending migrations")
+
+	return cmd
+}
+
+func printSchemaStatus(status *models.SchemaStatus) {
+	current := status.Current
+	if current == "" {
+		current = "none"
+	}
+	fmt.Printf("current: %s\nlatest:  %s\n", current, status.Latest)
+	for _, version := range status.Pending {
+		fmt.Printf("pending: %s\n", version)
+	}
+	for _, version := range status.Unknown {
+		fmt.Printf("unknown: %s (applied by a newer dhcp2p)\n", version)
+	}
+}
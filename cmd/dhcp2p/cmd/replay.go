@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/journal"
+	"go.uber.org/fx"
+)
+
+func replayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay [journal-file]",
+		Short: "Rebuild the leases and pools from the lease journal",
+		Long: "Apply the lease journal from its latest checkpoint on and import the resulting leases and\n" +
+			"pools, restoring the store as of the end of the journal, or as of --until or --until-seq.\n" +
+			"Reads the configured journal without a file. Refuses to overwrite stored leases unless\n" +
+			"--replace is given; the whole import is applied or nothing is.",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			replace, _ := cmd.Flags().GetBool(flag.REPLACE_FLAG)
+			untilSeq, _ := cmd.Flags().GetInt64(flag.UNTIL_SEQ_FLAG)
+			untilTime, _ := cmd.Flags().GetString(flag.UNTIL_FLAG)
+
+			var until time.Time
+			if untilTime != "" {
+				var err error
+				if until, err = time.Parse(time.RFC3339, untilTime); err != nil {
+					return fmt.Errorf("--%s must be an RFC 3339 time, e.g. 2024-05-01T12:00:00Z: %w", flag.UNTIL_FLAG, err)
+				}
+			}
+
+			cfg, err := config.NewAppConfig()
+			if err != nil {
+				return err
+			}
+			if cfg.StorageBackend == config.StorageBackendMemory {
+				return fmt.Errorf("replay needs a persistent storage backend, not %s", cfg.StorageBackend)
+			}
+
+			var service ports.LeaseJournalService
+			var configured ports.LeaseJournal
+			application := app.NewCommandApp(fx.Populate(&service, &configured))
+			ctx := context.Background()
+			if err := application.Start(ctx); err != nil {
+				return err
+			}
+			defer application.Stop(ctx)
+
+			var source ports.JournalSource = configured
+			if len(args) == 1 {
+				source = journal.NewFileSource(args[0])
+			} else if configured == nil {
+				return errors.New("the lease journal is not enabled, give the journal file to replay")
+			}
+
+			report, err := service.Replay(ctx, source, func(entry *models.JournalEntry) bool {
+				return (untilSeq == 0 || entry.Seq <= untilSeq) && (until.IsZero() || !entry.Time.After(until))
+			}, replace)
+			if appErr := domainErrors.GetAppError(err); appErr != nil && appErr.Details != "" {
+				// The violations are only carried in the details
+				return fmt.Errorf("replay: %s: %s", appErr.Message, appErr.Details)
+			}
+			if err != nil {
+				return fmt.Errorf("replay: %w", err)
+			}
+
+			fmt.Printf("replayed %d entries after checkpoint %d up to entry %d (%d skipped)\n", report.Entries, report.Checkpoint, report.LastSeq, report.Skipped)
+			fmt.Printf("imported %d pool(s) and %d lease(s), replaced %d lease(s)\n", report.Import.Pools, report.Import.Leases, report.Import.Replaced)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP(flag.REPLACE_FLAG, flag.REPLACE_FLAG_SHORT, false, "Replace the leases already stored")
+	cmd.Flags().StringP(flag.UNTIL_FLAG, flag.UNTIL_FLAG_SHORT, "", "Replay the entries journaled up to this RFC 3339 time")
+	cmd.Flags().Int64P(flag.UNTIL_SEQ_FLAG, flag.UNTIL_SEQ_FLAG_SHORT, 0, "Replay the entries up to this sequence number")
+
+	return cmd
+}
@@ -11,6 +11,8 @@ func RootCmd() *cobra.Command {
 		Use:   "dhcp2p",
 		Short: "DHCP2P",
 		Long:  "DHCP2P - DHCP2P Service",
+		// main reports the error returned by a command
+		SilenceErrors: true,
 	}
 
 	// Add flags
@@ -22,6 +24,16 @@ func RootCmd() *cobra.Command {
 	// Add commands
 	cmd.AddCommand(serveCmd())
 	cmd.AddCommand(versionCmd())
+	cmd.AddCommand(configCmd())
+	cmd.AddCommand(fsckCmd())
+	cmd.AddCommand(migrateCmd())
+	cmd.AddCommand(exportCmd())
+	cmd.AddCommand(importCmd())
+	cmd.AddCommand(replayCmd())
+	cmd.AddCommand(apiKeyCmd())
+	cmd.AddCommand(clientCmd())
+	cmd.AddCommand(agentCmd())
+	cmd.AddCommand(loadtestCmd())
 
 	return cmd
 }
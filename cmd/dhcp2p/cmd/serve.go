@@ -4,22 +4,41 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"go.uber.org/fx"
 )
 
 func serveCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Serve the dhcp2p",
-		Run: func(cmd *cobra.Command, args []string) {
-			application := app.NewApp()
+		// An invalid configuration is reported before anything starts
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dev, _ := cmd.Flags().GetBool(flag.DEV_FLAG); dev {
+				viper.Set("storage_backend", config.StorageBackendMemory)
+			}
+			if autoMigrate, _ := cmd.Flags().GetBool(flag.AUTO_MIGRATE_FLAG); autoMigrate {
+				viper.Set("auto_migrate", true)
+			}
+
+			application := app.NewApp(fx.Supply(config.BuildVersion(Build)))
+			if err := application.Err(); err != nil {
+				return err
+			}
 			application.Run()
+			return nil
 		},
 	}
 
 	// Add flags
 	cmd.Flags().IntP(flag.PORT_FLAG, flag.PORT_FLAG_SHORT, 0, "Port to run the server on")
 	cmd.Flags().StringP(flag.LOG_LEVEL_FLAG, flag.LOG_LEVEL_FLAG_SHORT, "", "Log level")
+	cmd.Flags().Bool(flag.DEV_FLAG, false, "Keep all data in memory and run without Postgres and Redis")
+	cmd.Flags().Bool(flag.AUTO_MIGRATE_FLAG, false, "Apply pending database migrations before serving")
+	cmd.Flags().StringP(flag.STORAGE_BACKEND_FLAG, flag.STORAGE_BACKEND_FLAG_SHORT, "", "Storage backend (postgres, embedded or memory)")
+	cmd.Flags().StringP(flag.STORAGE_PATH_FLAG, flag.STORAGE_PATH_FLAG_SHORT, "", "Data file of the embedded storage backend")
 	cmd.Flags().StringP(flag.DATABASE_URL_FLAG, flag.DATABASE_URL_FLAG_SHORT, "", "Database URL")
 	cmd.Flags().StringP(flag.REDIS_URL_FLAG, flag.REDIS_URL_FLAG_SHORT, "", "Redis URL")
 	cmd.Flags().StringP(flag.REDIS_PASSWORD_FLAG, flag.REDIS_PASSWORD_FLAG_SHORT, "", "Redis Password")
@@ -29,21 +48,19 @@ func serveCmd() *cobra.Command {
 	cmd.Flags().IntP(flag.MAX_LEASE_RETRIES_FLAG, flag.MAX_LEASE_RETRIES_FLAG_SHORT, 0, "Max Lease Retries")
 	cmd.Flags().IntP(flag.LEASE_RETRY_DELAY_FLAG, flag.LEASE_RETRY_DELAY_FLAG_SHORT, 0, "Lease Retry Delay") // in milliseconds
 
-	// Required flags
-	cmd.MarkFlagRequired(flag.DATABASE_URL_FLAG)
-	cmd.MarkFlagRequired(flag.REDIS_URL_FLAG)
-
 	// Bind flags
-	viper.BindPFlag("port", cmd.Flags().Lookup(flag.PORT_FLAG))
-	viper.BindPFlag("log", cmd.Flags().Lookup(flag.LOG_LEVEL_FLAG))
-	viper.BindPFlag("database_url", cmd.Flags().Lookup(flag.DATABASE_URL_FLAG))
-	viper.BindPFlag("redis_url", cmd.Flags().Lookup(flag.REDIS_URL_FLAG))
-	viper.BindPFlag("redis_password", cmd.Flags().Lookup(flag.REDIS_PASSWORD_FLAG))
-	viper.BindPFlag("nonce_ttl", cmd.Flags().Lookup(flag.NONCE_TTL_FLAG))
-	viper.BindPFlag("nonce_cleaner_interval", cmd.Flags().Lookup(flag.NONCE_CLEANER_INTERVAL_FLAG))
-	viper.BindPFlag("lease_ttl", cmd.Flags().Lookup(flag.LEASE_TTL_FLAG))
-	viper.BindPFlag("max_lease_retries", cmd.Flags().Lookup(flag.MAX_LEASE_RETRIES_FLAG))
-	viper.BindPFlag("lease_retry_delay", cmd.Flags().Lookup(flag.LEASE_RETRY_DELAY_FLAG))
+	viper.BindPFlag("server.port", cmd.Flags().Lookup(flag.PORT_FLAG))
+	viper.BindPFlag("server.log_level", cmd.Flags().Lookup(flag.LOG_LEVEL_FLAG))
+	viper.BindPFlag("storage_backend", cmd.Flags().Lookup(flag.STORAGE_BACKEND_FLAG))
+	viper.BindPFlag("storage_path", cmd.Flags().Lookup(flag.STORAGE_PATH_FLAG))
+	viper.BindPFlag("database.url", cmd.Flags().Lookup(flag.DATABASE_URL_FLAG))
+	viper.BindPFlag("redis.url", cmd.Flags().Lookup(flag.REDIS_URL_FLAG))
+	viper.BindPFlag("redis.password", cmd.Flags().Lookup(flag.REDIS_PASSWORD_FLAG))
+	viper.BindPFlag("nonce.ttl", cmd.Flags().Lookup(flag.NONCE_TTL_FLAG))
+	viper.BindPFlag("nonce.cleaner_interval", cmd.Flags().Lookup(flag.NONCE_CLEANER_INTERVAL_FLAG))
+	viper.BindPFlag("lease.ttl", cmd.Flags().Lookup(flag.LEASE_TTL_FLAG))
+	viper.BindPFlag("lease.max_retries", cmd.Flags().Lookup(flag.MAX_LEASE_RETRIES_FLAG))
+	viper.BindPFlag("lease.retry_delay", cmd.Flags().Lookup(flag.LEASE_RETRY_DELAY_FLAG))
 
 	return cmd
 }
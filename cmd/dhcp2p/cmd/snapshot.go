@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"go.uber.org/fx"
+)
+
+// stdioPath names standard input or output in place of a file
+const stdioPath = "-"
+
+func exportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export [file]",
+		Short: "Write the stored leases and pools to a JSON snapshot",
+		Long: "Write every lease, including expired and quarantined ones, and the allocation state of\n" +
+			"every tenant pool to a versioned JSON snapshot. Writes to standard output without a file\n" +
+			"or with \"-\".",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			snapshots, stop, err := startSnapshotService()
+			if err != nil {
+				return err
+			}
+			defer stop()
+
+			snapshot, err := snapshots.Export(context.Background())
+			if err != nil {
+				return fmt.Errorf("export: %w", err)
+			}
+
+			out := os.Stdout
+			if len(args) == 1 && args[0] != stdioPath {
+				if out, err = os.Create(args[0]); err != nil {
+					return err
+				}
+				defer out.Close()
+			}
+
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(snapshot); err != nil {
+				return err
+			}
+			if out != os.Stdout {
+				if err := out.Close(); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "exported %d pool(s) and %d lease(s) to %s\n", len(snapshot.Pools), len(snapshot.Leases), out.Name())
+			}
+			return nil
+		},
+	}
+}
+
+func importCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <file|->",
+		Short: "Restore the leases and pools from a JSON snapshot",
+		Long: "Restore a snapshot written by export, reading standard input with \"-\". The snapshot must\n" +
+			"match the configured tenant pools. Refuses to overwrite stored leases unless --replace is\n" +
+			"given; the whole import is applied or nothing is.",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			replace, _ := cmd.Flags().GetBool(flag.REPLACE_FLAG)
+
+			in := io.Reader(os.Stdin)
+			if args[0] != stdioPath {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+
+			var snapshot models.LeaseSnapshot
+			if err := json.NewDecoder(in).Decode(&snapshot); err != nil {
+				return fmt.Errorf("read snapshot: %w", err)
+			}
+
+			snapshots, stop, err := startSnapshotService()
+			if err != nil {
+				return err
+			}
+			defer stop()
+
+			report, err := snapshots.Import(context.Background(), &snapshot, replace)
+			if appErr := domainErrors.GetAppError(err); appErr != nil && appErr.Details != "" {
+				// The violations are only carried in the details
+				return fmt.Errorf("import: %s: %s", appErr.Message, appErr.Details)
+			}
+			if err != nil {
+				return fmt.Errorf("import: %w", err)
+			}
+
+			fmt.Printf("imported %d pool(s) and %d lease(s), replaced %d lease(s)\n", report.Pools, report.Leases, report.Replaced)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP(flag.REPLACE_FLAG, flag.REPLACE_FLAG_SHORT, false, "Replace the leases already stored")
+
+	return cmd
+}
+
+// startSnapshotService starts the storage backend for a snapshot command. The memory
+// backend is refused, it would export nothing and lose an import on exit.
+func startSnapshotService() (ports.LeaseSnapshotService, func(), error) {
+	cfg, err := config.NewAppConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.StorageBackend == config.StorageBackendMemory {
+		return nil, nil, fmt.Errorf("snapshots need a persistent storage backend, not %s", cfg.StorageBackend)
+	}
+
+	var snapshots ports.LeaseSnapshotService
+	application := app.NewCommandApp(fx.Populate(&snapshots))
+
+	ctx := context.Background()
+	if err := application.Start(ctx); err != nil {
+		return nil, nil, err
+	}
+	return snapshots, func() { application.Stop(ctx) }, nil
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// A version string that can be set with
+// Build is the version string of main.Build, which can be set with
 //
 //	-ldflags "-X main.Build=SOMEVERSION"
 //
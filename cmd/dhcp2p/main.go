@@ -15,6 +15,8 @@ import (
 var Build string
 
 func main() {
+	cmd.Build = Build
+
 	cmd := cmd.RootCmd()
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
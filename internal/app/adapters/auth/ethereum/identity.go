@@ -0,0 +1,30 @@
+package ethereum
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+)
+
+// AddressResolver identifies peers by the Ethereum address of their secp256k1 public key
+type AddressResolver struct {
+}
+
+var _ ports.IdentityResolver = &AddressResolver{}
+
+func NewAddressResolver() *AddressResolver {
+	return &AddressResolver{}
+}
+
+func (r *AddressResolver) ResolvePeerID(pubkey []byte) (string, error) {
+	pubKey, err := crypto.UnmarshalPublicKey(pubkey)
+	if err != nil {
+		return "", err
+	}
+
+	address, err := crypto.EthereumAddress(pubKey)
+	if err != nil {
+		return "", errors.ErrUnsupportedKeyType
+	}
+	return address, nil
+}
@@ -0,0 +1,20 @@
+package libp2p
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+)
+
+// PeerIDResolver identifies peers by the libp2p peer ID of their public key
+type PeerIDResolver struct {
+}
+
+var _ ports.IdentityResolver = &PeerIDResolver{}
+
+func NewPeerIDResolver() *PeerIDResolver {
+	return &PeerIDResolver{}
+}
+
+func (r *PeerIDResolver) ResolvePeerID(pubkey []byte) (string, error) {
+	return crypto.PeerIDFromPublicKey(pubkey)
+}
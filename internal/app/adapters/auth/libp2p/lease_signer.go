@@ -0,0 +1,254 @@
+package libp2p
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"go.uber.org/zap"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+)
+
+// LeaseSigner signs lease certificates with the server identity key. Without a
+// configured key file signing is disabled.
+//
+// Besides the current key the signer publishes retired keys, so certificates signed before
+// a rotation still verify: the keys of security.server_verification_key_paths and the keys
+// retired while the server runs.
+type LeaseSigner struct {
+	logger *zap.Logger
+	mu     sync.Mutex // serializes rotations and reloads
+	keys   atomic.Pointer[signingKeys]
+}
+
+// signingKeys is the key set at one time, replaced as a whole on changes
+type signingKeys struct {
+	path         string      // key file of the current key, empty when it was not read from a file
+	current      *signingKey // nil when signing is disabled
+	rotated      []*signingKey
+	verification []*signingKey
+	paths        []string // of the verification keys
+}
+
+type signingKey struct {
+	key  crypto.PrivKey
+	info *models.SigningKey
+}
+
+var _ ports.LeaseSigner = &LeaseSigner{}
+var _ config.Reloadable = &LeaseSigner{}
+
+// NewLeaseSigner loads the key at security.server_key_path, generating an Ed25519 key when the
+// file does not exist yet, and the retired keys of security.server_verification_key_paths
+func NewLeaseSigner(cfg *config.AppConfig, logger *zap.Logger) (*LeaseSigner, error) {
+	s := &LeaseSigner{logger: logger}
+	if cfg.Security.ServerKeyPath == "" {
+		s.keys.Store(&signingKeys{})
+		return s, nil
+	}
+
+	keys, err := s.load(cfg, &signingKeys{})
+	if err != nil {
+		return nil, err
+	}
+	s.keys.Store(keys)
+	return s, nil
+}
+
+// NewLeaseSignerWithKey creates a signer using key
+func NewLeaseSignerWithKey(key crypto.PrivKey, logger *zap.Logger) (*LeaseSigner, error) {
+	current, err := newSigningKey(key)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Signing leases with server key", zap.String("serverPeerID", current.info.PeerID), zap.String("keyID", current.info.KeyID))
+
+	s := &LeaseSigner{logger: logger}
+	s.keys.Store(&signingKeys{current: current})
+	return s, nil
+}
+
+func newSigningKey(key crypto.PrivKey) (*signingKey, error) {
+	pubkey, err := crypto.MarshalPublicKey(key.GetPublic())
+	if err != nil {
+		return nil, fmt.Errorf("marshal server public key: %w", err)
+	}
+	peerID, err := dhcp2pcrypto.PeerIDFromPublicKey(pubkey)
+	if err != nil {
+		return nil, fmt.Errorf("server public key: %w", err)
+	}
+
+	return &signingKey{key: key, info: &models.SigningKey{KeyID: dhcp2pcrypto.SigningKeyID(pubkey), PublicKey: pubkey, PeerID: peerID}}, nil
+}
+
+// load reads the key files cfg names into a key set following prev. Keys prev has already
+// loaded are reused, a current key that is replaced joins the rotated keys.
+func (s *LeaseSigner) load(cfg *config.AppConfig, prev *signingKeys) (*signingKeys, error) {
+	next := &signingKeys{path: cfg.Security.ServerKeyPath, rotated: prev.rotated, paths: cfg.Security.ServerVerificationKeyPaths}
+
+	if next.path != "" {
+		if next.path == prev.path && prev.current != nil {
+			next.current = prev.current
+		} else {
+			key, err := client.LoadOrGenerateKey(next.path)
+			if err != nil {
+				return nil, fmt.Errorf("load server key: %w", err)
+			}
+			if next.current, err = newSigningKey(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if prev.current != nil && (next.current == nil || prev.current.info.KeyID != next.current.info.KeyID) {
+		next.rotated = append([]*signingKey{prev.current}, prev.rotated...)
+	}
+
+	for _, path := range next.paths {
+		if i := slices.Index(prev.paths, path); i >= 0 {
+			next.verification = append(next.verification, prev.verification[i])
+			continue
+		}
+		key, err := client.LoadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("load server verification key: %w", err)
+		}
+		verification, err := newSigningKey(key)
+		if err != nil {
+			return nil, err
+		}
+		next.verification = append(next.verification, verification)
+	}
+
+	if next.current != nil && next.current != prev.current {
+		s.logger.Info("Signing leases with server key", zap.String("serverPeerID", next.current.info.PeerID), zap.String("keyID", next.current.info.KeyID))
+	}
+	return next, nil
+}
+
+// ApplyConfig switches to a reloaded security.server_key_path and
+// security.server_verification_key_paths. Key files that cannot be read leave the keys
+// unchanged.
+func (s *LeaseSigner) ApplyConfig(cfg *config.AppConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.keys.Load()
+	if prev.path == "" && prev.current != nil {
+		// The key was not read from a file, there is nothing to reload
+		return
+	}
+	if cfg.Security.ServerKeyPath == prev.path && slices.Equal(cfg.Security.ServerVerificationKeyPaths, prev.paths) {
+		return
+	}
+
+	next, err := s.load(cfg, prev)
+	if err != nil {
+		s.logger.Error("Server keys not reloaded", zap.Error(err))
+		return
+	}
+	s.keys.Store(next)
+}
+
+func (s *LeaseSigner) SignLease(lease *models.Lease) ([]byte, string, error) {
+	current := s.keys.Load().current
+	if current == nil {
+		return nil, "", nil
+	}
+
+	signature, err := current.key.Sign(dhcp2pcrypto.LeaseCertificatePayload(lease.Tenant, lease.TokenID, lease.PeerID, lease.ExpiresAt))
+	if err != nil {
+		return nil, "", err
+	}
+	return signature, current.info.KeyID, nil
+}
+
+func (s *LeaseSigner) Keys() []*models.SigningKey {
+	keys := s.keys.Load()
+	if keys.current == nil {
+		return nil
+	}
+
+	current := *keys.current.info
+	current.Current = true
+	published := []*models.SigningKey{&current}
+	for _, key := range slices.Concat(keys.rotated, keys.verification) {
+		if !slices.ContainsFunc(published, func(k *models.SigningKey) bool { return k.KeyID == key.info.KeyID }) {
+			published = append(published, key.info)
+		}
+	}
+	return published
+}
+
+// RotateKey generates a new Ed25519 key. A key read from a file is replaced on disk as
+// well: the current key file moves to <server_key_path>.<key ID> and the new key is saved
+// at server_key_path, so a restart keeps signing with it.
+func (s *LeaseSigner) RotateKey() (*models.SigningKeyRotation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.keys.Load()
+	if prev.current == nil {
+		return nil, domainErrors.ErrSigningDisabled
+	}
+
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		return nil, fmt.Errorf("generate server key: %w", err)
+	}
+	current, err := newSigningKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	published := *current.info
+	published.Current = true
+	rotation := &models.SigningKeyRotation{Current: &published, Retired: prev.current.info}
+	if prev.path != "" {
+		rotation.RetiredKeyPath = prev.path + "." + prev.current.info.KeyID
+		if err := retireKeyFile(prev.path, rotation.RetiredKeyPath, key); err != nil {
+			return nil, err
+		}
+	}
+
+	next := *prev
+	next.current = current
+	next.rotated = append([]*signingKey{prev.current}, prev.rotated...)
+	s.keys.Store(&next)
+
+	s.logger.Info("Rotated server key",
+		zap.String("keyID", current.info.KeyID),
+		zap.String("retiredKeyID", prev.current.info.KeyID),
+		zap.String("retiredKeyPath", rotation.RetiredKeyPath),
+	)
+	return rotation, nil
+}
+
+// retireKeyFile moves the key file at path to retiredPath and saves key at path
+func retireKeyFile(path, retiredPath string, key crypto.PrivKey) error {
+	if _, err := os.Stat(retiredPath); err == nil {
+		return fmt.Errorf("retire server key: %s already exists", retiredPath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("retire server key: %w", err)
+	}
+	if err := os.Rename(path, retiredPath); err != nil {
+		return fmt.Errorf("retire server key: %w", err)
+	}
+	if err := client.SaveKey(path, key); err != nil {
+		// Put the current key back, it stays in use
+		if restoreErr := os.Rename(retiredPath, path); restoreErr != nil {
+			return fmt.Errorf("save server key: %w (restoring %s: %v)", err, path, restoreErr)
+		}
+		return fmt.Errorf("save server key: %w", err)
+	}
+	return nil
+}
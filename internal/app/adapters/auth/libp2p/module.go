@@ -2,14 +2,18 @@ package libp2p
 
 import (
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
 )
 
 var Module = fx.Options(
 	fx.Provide(
+		NewSignatureVerifier,
 		fx.Annotate(
-			NewSignatureVerifier,
-			fx.As(new(ports.SignatureVerifier)),
+			NewLeaseSigner,
+			fx.As(fx.Self()),
+			fx.As(new(ports.LeaseSigner)),
 		),
 	),
+	config.ReloadTarget[*LeaseSigner](),
 )
@@ -2,10 +2,11 @@ package libp2p
 
 import (
 	"context"
+	"errors"
 
-	"github.com/libp2p/go-libp2p/core/crypto"
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
 )
 
 type SignatureVerifier struct {
@@ -18,17 +19,9 @@ func NewSignatureVerifier() *SignatureVerifier {
 }
 
 func (s *SignatureVerifier) VerifySignature(ctx context.Context, publicKey []byte, payload []byte, signature []byte) error {
-	pubKey, err := crypto.UnmarshalPublicKey(publicKey)
-	if err != nil {
-		return err
+	err := crypto.Verify(publicKey, payload, signature)
+	if errors.Is(err, crypto.ErrInvalidSignature) {
+		return domainErrors.ErrInvalidSignature
 	}
-
-	ok, err := pubKey.Verify(payload, signature)
-	if err != nil {
-		return err
-	}
-	if !ok {
-		return errors.ErrInvalidSignature
-	}
-	return nil
+	return err
 }
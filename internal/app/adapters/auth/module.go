@@ -1,10 +1,37 @@
 package auth
 
 import (
+	"fmt"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/ethereum"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
 )
 
 var Module = fx.Options(
 	libp2p.Module,
+	fx.Provide(NewIdentityResolver),
+	fx.Provide(
+		fx.Annotate(
+			func(cfg *config.AppConfig, verifier *libp2p.SignatureVerifier) *CachingSignatureVerifier {
+				return NewCachingSignatureVerifier(cfg, verifier)
+			},
+			fx.As(new(ports.SignatureVerifier)),
+			fx.As(new(ports.SignatureCacheReporter)),
+		),
+	),
 )
+
+// NewIdentityResolver returns the resolver of the configured identity scheme
+func NewIdentityResolver(cfg *config.AppConfig) (ports.IdentityResolver, error) {
+	switch cfg.Security.IdentityScheme {
+	case config.IdentitySchemePeerID, "":
+		return libp2p.NewPeerIDResolver(), nil
+	case config.IdentitySchemeEthereum:
+		return ethereum.NewAddressResolver(), nil
+	default:
+		return nil, fmt.Errorf("unknown identity scheme %q", cfg.Security.IdentityScheme)
+	}
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// CachingSignatureVerifier remembers signatures that verified, keyed by a hash of the
+// public key, the payload and the signature, so a repeated request skips the signature
+// check. Only the verification is cached: the nonce of an authenticated request is still
+// consumed, so a replayed request is rejected as before. Failed verifications are not
+// cached. The least recently used entry is evicted once the cache is full.
+type CachingSignatureVerifier struct {
+	verifier ports.SignatureVerifier
+	capacity int
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	entries   map[[sha256.Size]byte]*list.Element
+	lru       *list.List // front is the most recently used entry
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type signatureCacheEntry struct {
+	key       [sha256.Size]byte
+	expiresAt time.Time
+}
+
+var _ ports.SignatureVerifier = &CachingSignatureVerifier{}
+var _ ports.SignatureCacheReporter = &CachingSignatureVerifier{}
+
+func NewCachingSignatureVerifier(cfg *config.AppConfig, verifier ports.SignatureVerifier) *CachingSignatureVerifier {
+	return &CachingSignatureVerifier{
+		verifier: verifier,
+		capacity: cfg.Security.SignatureCacheSize,
+		ttl:      time.Duration(cfg.Security.SignatureCacheTTL) * time.Second,
+		entries:  make(map[[sha256.Size]byte]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *CachingSignatureVerifier) VerifySignature(ctx context.Context, pubKey []byte, payload []byte, signature []byte) error {
+	if c.capacity <= 0 || c.ttl <= 0 {
+		return c.verifier.VerifySignature(ctx, pubKey, payload, signature)
+	}
+
+	key := signatureCacheKey(pubKey, payload, signature)
+	if c.lookup(key) {
+		return nil
+	}
+
+	if err := c.verifier.VerifySignature(ctx, pubKey, payload, signature); err != nil {
+		return err
+	}
+	c.store(key)
+	return nil
+}
+
+func (c *CachingSignatureVerifier) SignatureCacheStats() *models.SignatureCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := &models.SignatureCacheStats{
+		Enabled:   c.capacity > 0 && c.ttl > 0,
+		Entries:   c.lru.Len(),
+		Capacity:  c.capacity,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+func (c *CachingSignatureVerifier) lookup(key [sha256.Size]byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*signatureCacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			c.lru.MoveToFront(element)
+			c.hits++
+			return true
+		}
+		c.remove(element)
+	}
+	c.misses++
+	return false
+}
+
+func (c *CachingSignatureVerifier) store(key [sha256.Size]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		// Verified concurrently by another request
+		element.Value.(*signatureCacheEntry).expiresAt = expiresAt
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	for c.lru.Len() >= c.capacity {
+		oldest := c.lru.Back()
+		if time.Now().Before(oldest.Value.(*signatureCacheEntry).expiresAt) {
+			c.evictions++
+		}
+		c.remove(oldest)
+	}
+	c.entries[key] = c.lru.PushFront(&signatureCacheEntry{key: key, expiresAt: expiresAt})
+}
+
+func (c *CachingSignatureVerifier) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*signatureCacheEntry).key)
+}
+
+// signatureCacheKey hashes the length prefixed inputs, so no two different inputs share
+// a key by moving bytes from one field to the next
+func signatureCacheKey(pubKey, payload, signature []byte) [sha256.Size]byte {
+	h := sha256.New()
+	for _, field := range [][]byte{pubKey, payload, signature} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+		h.Write(length[:])
+		h.Write(field)
+	}
+
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	return key
+}
@@ -0,0 +1,152 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/p2p"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/discovery"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Register answers multicast DNS queries for the server's _dhcp2p._tcp service for the
+// lifetime of the application, announcing it on start and withdrawing it on stop. With a
+// libp2p host the TXT record also carries its peer ID and the lease protocol, so peers
+// that found the server can use the protocol instead of HTTP. version and h are optional.
+func Register(lc fx.Lifecycle, cfg *config.AppConfig, version config.BuildVersion, h host.Host, logger *zap.Logger) error {
+	if !cfg.DiscoveryEnabled {
+		return nil
+	}
+
+	var iface *net.Interface
+	if cfg.DiscoveryInterface != "" {
+		var err error
+		if iface, err = net.InterfaceByName(cfg.DiscoveryInterface); err != nil {
+			return fmt.Errorf("discovery_interface: %w", err)
+		}
+	}
+
+	service, err := localService(cfg, iface)
+	if err != nil {
+		return err
+	}
+	if version != "" {
+		service.Text[discovery.TextVersion] = string(version)
+	}
+	if h != nil {
+		service.Text[discovery.TextPeerID] = h.ID().String()
+		service.Text[discovery.TextProtocol] = string(p2p.ProtocolID)
+	}
+
+	responder, err := discovery.NewResponder(service)
+	if err != nil {
+		return err
+	}
+
+	var conn *net.UDPConn
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			var err error
+			if conn, err = net.ListenMulticastUDP("udp4", iface, discovery.MulticastAddr); err != nil {
+				return fmt.Errorf("failed to join the mDNS group: %w", err)
+			}
+
+			go func() {
+				defer close(done)
+				if err := responder.Serve(conn); err != nil {
+					logger.Error("mDNS responder stopped", zap.Error(err))
+				}
+			}()
+
+			if err := responder.Announce(conn); err != nil {
+				logger.Warn("Failed to announce the service over mDNS", zap.Error(err))
+			}
+			logger.Info("Advertising the server over mDNS",
+				zap.String("instance", service.Instance),
+				zap.String("host", service.Host+".local"),
+				zap.Int("port", service.Port))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := responder.Goodbye(conn); err != nil {
+				logger.Warn("Failed to withdraw the service from mDNS", zap.Error(err))
+			}
+			conn.Close()
+			<-done
+			return nil
+		},
+	})
+	return nil
+}
+
+// localService describes the server with the host name and the IPv4 addresses of iface,
+// or of every interface that is up and can multicast when iface is nil
+func localService(cfg *config.AppConfig, iface *net.Interface) (discovery.Service, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return discovery.Service{}, fmt.Errorf("failed to read the host name: %w", err)
+	}
+	hostname = hostLabel(hostname)
+
+	interfaces := []net.Interface{}
+	if iface != nil {
+		interfaces = append(interfaces, *iface)
+	} else if interfaces, err = net.Interfaces(); err != nil {
+		return discovery.Service{}, err
+	}
+
+	var addrs []net.IP
+	for _, i := range interfaces {
+		if i.Flags&net.FlagUp == 0 || i.Flags&net.FlagLoopback != 0 || i.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		ifaceAddrs, err := i.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range ifaceAddrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				addrs = append(addrs, ipNet.IP.To4())
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		return discovery.Service{}, fmt.Errorf("no IPv4 address to advertise over mDNS")
+	}
+
+	instance := cfg.DiscoveryInstance
+	if instance == "" {
+		instance = hostname
+	}
+
+	return discovery.Service{
+		Instance: instance,
+		Host:     hostname,
+		Port:     cfg.Server.Port,
+		Addrs:    addrs,
+		Text:     make(map[string]string),
+	}, nil
+}
+
+// hostLabel is the first label of hostname with the characters a host name may not
+// contain replaced by hyphens
+func hostLabel(hostname string) string {
+	label, _, _ := strings.Cut(hostname, ".")
+	label = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, label)
+	if label == "" {
+		return "dhcp2p"
+	}
+	return label
+}
@@ -0,0 +1,15 @@
+package discovery
+
+import (
+	"go.uber.org/fx"
+)
+
+// Module advertises the server on the local network when discovery is enabled
+var Module = fx.Options(
+	fx.Invoke(
+		fx.Annotate(
+			Register,
+			fx.ParamTags(``, ``, `optional:"true"`, `optional:"true"`, ``),
+		),
+	),
+)
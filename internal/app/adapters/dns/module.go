@@ -0,0 +1,17 @@
+package dns
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Options(
+	fx.Provide(
+		fx.Annotate(
+			NewPublisher,
+			fx.ParamTags(``, ``, ``, `optional:"true"`, ``),
+			fx.As(new(ports.LeaseEventPublisher)),
+			fx.ResultTags(`group:"lease_event_publishers"`),
+		),
+	),
+)
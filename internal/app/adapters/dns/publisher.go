@@ -0,0 +1,252 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// ProviderRFC2136 is the built-in provider, as used in dns_provider
+const ProviderRFC2136 = "rfc2136"
+
+const (
+	// reconcileBatchSize is the number of active leases read per round trip while reconciling
+	reconcileBatchSize = 500
+	// activeWindow is wide enough for ListExpiringLeases to return every active lease
+	activeWindow = time.Duration(math.MaxInt32) * time.Second
+)
+
+// labelPattern matches the peer IDs and tenant IDs usable as a DNS label
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,63}$`)
+
+// Publisher keeps an address record <peerID>.<zone> for every active lease, with a
+// <peerID>.<tenant>.<zone> record for leases of other tenants than the default one.
+// Allocations and releases are applied in the background as they happen; drift left
+// by missed events is healed by reconciling the whole zone on startup.
+type Publisher struct {
+	enabled  bool
+	zone     string
+	provider ports.DNSProvider
+	repo     ports.LeaseRepository
+	logger   *zap.Logger
+	queue    chan *models.LeaseLifecycleEvent
+
+	mu      sync.RWMutex
+	stopped bool
+	ctx     context.Context // canceled when shutdown runs out of time
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+var _ ports.LeaseEventPublisher = &Publisher{}
+
+// NewPublisher creates the publisher, which does nothing unless DNS publishing is enabled.
+// A provider supplied to the app replaces the one selected by dns_provider.
+func NewPublisher(lc fx.Lifecycle, cfg *config.AppConfig, repo ports.LeaseRepository, provider ports.DNSProvider, logger *zap.Logger) (*Publisher, error) {
+	if !cfg.DNSEnabled {
+		return &Publisher{}, nil
+	}
+	if cfg.DNSZone == "" {
+		return nil, fmt.Errorf("dns_zone must be set to publish DNS records")
+	}
+
+	if provider == nil {
+		switch cfg.DNSProvider {
+		case ProviderRFC2136:
+			if cfg.DNSServer == "" {
+				return nil, fmt.Errorf("dns_server must be set for the %s DNS provider", ProviderRFC2136)
+			}
+			provider = NewRFC2136Provider(cfg.DNSZone, cfg.DNSServer, uint32(cfg.DNSRecordTTL), cfg.DNSTSIGKey, cfg.DNSTSIGSecret, cfg.DNSTSIGAlgorithm, time.Duration(cfg.DNSTimeout)*time.Second)
+		default:
+			return nil, fmt.Errorf("unknown DNS provider %q", cfg.DNSProvider)
+		}
+	}
+
+	p := &Publisher{
+		enabled:  true,
+		zone:     mdns.CanonicalName(cfg.DNSZone),
+		provider: provider,
+		repo:     repo,
+		logger:   logger.Named("dns"),
+		queue:    make(chan *models.LeaseLifecycleEvent, max(cfg.DNSQueueSize, 1)),
+		done:     make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go p.run()
+			return nil
+		},
+		OnStop: p.stop,
+	})
+
+	return p, nil
+}
+
+// Publish queues the record change for an allocation, release or expiry. Renewals leave
+// the record unchanged. An event that finds the queue full is dropped.
+func (p *Publisher) Publish(event *models.LeaseLifecycleEvent) {
+	if !p.enabled || event.Type == models.LeaseLifecycleRenewed {
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return
+	}
+
+	select {
+	case p.queue <- event:
+	default:
+		p.logger.Warn("DNS queue full, record change dropped",
+			zap.String("event", string(event.Type)),
+			zap.Int64("tokenID", event.TokenID),
+		)
+	}
+}
+
+// Hostname returns the fully qualified name published for a peer's lease, or an empty
+// string when the peer or tenant ID cannot be used as a DNS label
+func (p *Publisher) Hostname(peerID, tenantID string) string {
+	if !labelPattern.MatchString(peerID) {
+		return ""
+	}
+	if tenantID == "" || tenantID == models.DefaultTenantID {
+		return strings.ToLower(peerID) + "." + p.zone
+	}
+	if !labelPattern.MatchString(tenantID) {
+		return ""
+	}
+	return strings.ToLower(peerID) + "." + tenantID + "." + p.zone
+}
+
+// Reconcile publishes a record for every active lease and deletes every other record
+// of the zone
+func (p *Publisher) Reconcile(ctx context.Context) (*models.DNSReconcileResult, error) {
+	desired := make(map[string]string)
+	var afterTokenID int64
+	for {
+		leases, err := p.repo.ListExpiringLeases(ctx, activeWindow, afterTokenID, reconcileBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, lease := range leases {
+			if hostname := p.Hostname(lease.PeerID, lease.TenantID); hostname != "" {
+				desired[hostname] = utils.IPFromTokenID(uint32(lease.TokenID))
+			}
+		}
+		if len(leases) < reconcileBatchSize {
+			break
+		}
+		afterTokenID = leases[len(leases)-1].TokenID
+	}
+
+	records, err := p.provider.ListRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	published := make(map[string][]string)
+	for _, record := range records {
+		published[record.Hostname] = append(published[record.Hostname], record.IP)
+	}
+
+	result := &models.DNSReconcileResult{}
+	for hostname, ip := range desired {
+		if ips := published[hostname]; len(ips) == 1 && ips[0] == ip {
+			result.Unchanged++
+			continue
+		}
+		if err := p.provider.UpsertRecord(ctx, hostname, ip); err != nil {
+			return nil, err
+		}
+		result.Upserted++
+	}
+	for hostname := range published {
+		if _, ok := desired[hostname]; ok {
+			continue
+		}
+		if err := p.provider.DeleteRecord(ctx, hostname); err != nil {
+			return nil, err
+		}
+		result.Deleted++
+	}
+
+	return result, nil
+}
+
+// run reconciles the zone, then applies the queued events until the queue is closed
+func (p *Publisher) run() {
+	defer close(p.done)
+
+	if result, err := p.Reconcile(p.ctx); err != nil {
+		p.logger.Error("Error reconciling DNS records", zap.String("zone", p.zone), zap.Error(err))
+	} else {
+		p.logger.Info("Reconciled DNS records",
+			zap.String("zone", p.zone),
+			zap.Int("upserted", result.Upserted),
+			zap.Int("deleted", result.Deleted),
+			zap.Int("unchanged", result.Unchanged),
+		)
+	}
+
+	for event := range p.queue {
+		p.apply(event)
+	}
+}
+
+func (p *Publisher) apply(event *models.LeaseLifecycleEvent) {
+	hostname := p.Hostname(event.PeerID, event.TenantID)
+	if hostname == "" {
+		p.logger.Warn("Peer has no valid DNS hostname", zap.String("peerID", event.PeerID), zap.String("tenant", event.TenantID))
+		return
+	}
+
+	var err error
+	switch event.Type {
+	case models.LeaseLifecycleAllocated:
+		err = p.provider.UpsertRecord(p.ctx, hostname, utils.IPFromTokenID(uint32(event.TokenID)))
+	case models.LeaseLifecycleReleased, models.LeaseLifecycleExpired:
+		err = p.provider.DeleteRecord(p.ctx, hostname)
+	default:
+		return
+	}
+	if err != nil {
+		p.logger.Error("Error publishing DNS record",
+			zap.String("event", string(event.Type)),
+			zap.String("hostname", hostname),
+			zap.Int64("tokenID", event.TokenID),
+			zap.Error(err),
+		)
+	}
+}
+
+// stop applies the queued events until ctx is done, then abandons the rest
+func (p *Publisher) stop(ctx context.Context) error {
+	p.mu.Lock()
+	p.stopped = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		p.cancel()
+		<-p.done
+	}
+	p.cancel()
+	return nil
+}
@@ -0,0 +1,130 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// tsigFudge is the clock skew in seconds a server accepts for signed messages
+const tsigFudge = 300
+
+// RFC2136Provider manages the zone through dynamic updates (RFC 2136) sent to its
+// primary server, optionally signed with TSIG, and lists it through zone transfers
+type RFC2136Provider struct {
+	zone      string
+	server    string
+	ttl       uint32
+	keyName   string
+	secret    string
+	algorithm string
+	timeout   time.Duration
+}
+
+var _ ports.DNSProvider = &RFC2136Provider{}
+
+func NewRFC2136Provider(zone, server string, ttl uint32, keyName, secret, algorithm string, timeout time.Duration) *RFC2136Provider {
+	p := &RFC2136Provider{
+		zone:    mdns.CanonicalName(zone),
+		server:  server,
+		ttl:     ttl,
+		timeout: timeout,
+	}
+	if keyName != "" {
+		p.keyName = mdns.CanonicalName(keyName)
+		p.secret = secret
+		p.algorithm = mdns.CanonicalName(algorithm)
+	}
+	return p
+}
+
+func (p *RFC2136Provider) UpsertRecord(ctx context.Context, hostname, ip string) error {
+	addr := net.ParseIP(ip).To4()
+	if addr == nil {
+		return fmt.Errorf("invalid IPv4 address %q", ip)
+	}
+
+	rr := &mdns.A{
+		Hdr: mdns.RR_Header{Name: mdns.Fqdn(hostname), Rrtype: mdns.TypeA, Class: mdns.ClassINET, Ttl: p.ttl},
+		A:   addr,
+	}
+
+	m := new(mdns.Msg)
+	m.SetUpdate(p.zone)
+	m.RemoveRRset([]mdns.RR{rr})
+	m.Insert([]mdns.RR{rr})
+	return p.update(ctx, m)
+}
+
+func (p *RFC2136Provider) DeleteRecord(ctx context.Context, hostname string) error {
+	rr := &mdns.A{Hdr: mdns.RR_Header{Name: mdns.Fqdn(hostname), Rrtype: mdns.TypeA, Class: mdns.ClassINET}}
+
+	m := new(mdns.Msg)
+	m.SetUpdate(p.zone)
+	m.RemoveRRset([]mdns.RR{rr})
+	return p.update(ctx, m)
+}
+
+func (p *RFC2136Provider) ListRecords(ctx context.Context) ([]*models.DNSRecord, error) {
+	m := new(mdns.Msg)
+	m.SetAxfr(p.zone)
+	p.sign(m)
+
+	transfer := &mdns.Transfer{DialTimeout: p.timeout, ReadTimeout: p.timeout, WriteTimeout: p.timeout}
+	if p.keyName != "" {
+		transfer.TsigSecret = map[string]string{p.keyName: p.secret}
+	}
+
+	envelopes, err := transfer.In(m, p.server)
+	if err != nil {
+		return nil, fmt.Errorf("zone transfer of %s: %w", p.zone, err)
+	}
+
+	var records []*models.DNSRecord
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, fmt.Errorf("zone transfer of %s: %w", p.zone, envelope.Error)
+		}
+		for _, rr := range envelope.RR {
+			a, ok := rr.(*mdns.A)
+			if !ok || strings.EqualFold(a.Hdr.Name, p.zone) {
+				continue
+			}
+			records = append(records, &models.DNSRecord{Hostname: strings.ToLower(a.Hdr.Name), IP: a.A.String()})
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func (p *RFC2136Provider) update(ctx context.Context, m *mdns.Msg) error {
+	p.sign(m)
+
+	client := &mdns.Client{Net: "tcp", Timeout: p.timeout}
+	if p.keyName != "" {
+		client.TsigSecret = map[string]string{p.keyName: p.secret}
+	}
+
+	resp, _, err := client.ExchangeContext(ctx, m, p.server)
+	if err != nil {
+		return fmt.Errorf("dynamic update of %s: %w", p.zone, err)
+	}
+	if resp.Rcode != mdns.RcodeSuccess {
+		return fmt.Errorf("dynamic update of %s refused: %s", p.zone, mdns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
+
+func (p *RFC2136Provider) sign(m *mdns.Msg) {
+	if p.keyName != "" {
+		m.SetTsig(p.keyName, p.algorithm, tsigFudge, time.Now().Unix())
+	}
+}
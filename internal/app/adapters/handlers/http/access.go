@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// AccessHandler manages the deny and allow lists through the admin API
+type AccessHandler struct {
+	accessControl ports.AccessControlService
+}
+
+func NewAccessHandler(accessControl ports.AccessControlService) *AccessHandler {
+	return &AccessHandler{accessControl}
+}
+
+// ListRules lists the access rules, optionally of one list only
+func (h *AccessHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleListRules,
+		ValidateAccessListRequest,
+	)
+}
+
+func (h *AccessHandler) handleListRules(ctx context.Context, req interface{}) (interface{}, error) {
+	listReq := req.(*AccessListRequestData)
+	return h.accessControl.ListRules(ctx, listReq.List)
+}
+
+// AddRule puts a peer ID or public key on the deny or allow list
+func (h *AccessHandler) AddRule(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleAddRule,
+		ValidateAccessRuleRequest,
+	)
+}
+
+func (h *AccessHandler) handleAddRule(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.accessControl.AddRule(ctx, req.(*models.AccessRule))
+}
+
+// RemoveRule deletes an access rule by ID
+func (h *AccessHandler) RemoveRule(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRemoveRule,
+		ValidateAccessRuleIDRequest,
+	)
+}
+
+func (h *AccessHandler) handleRemoveRule(ctx context.Context, req interface{}) (interface{}, error) {
+	idReq := req.(*AccessRuleIDRequestData)
+	if err := h.accessControl.RemoveRule(ctx, idReq.ID); err != nil {
+		return nil, err
+	}
+	return map[string]string{"status": "success"}, nil
+}
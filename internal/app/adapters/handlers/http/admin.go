@@ -0,0 +1,173 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+type AdminHandler struct {
+	diagnostics ports.CacheDiagnostics
+	reclamation ports.ReclamationService
+	expiry      ports.ExpiryNotificationService
+	nonces      ports.NonceService
+	signatures  ports.SignatureCacheReporter
+	events      ports.EventBus
+	sampleSize  int
+}
+
+func NewAdminHandler(diagnostics ports.CacheDiagnostics, reclamation ports.ReclamationService, expiry ports.ExpiryNotificationService, nonces ports.NonceService, signatures ports.SignatureCacheReporter, events ports.EventBus, cfg *config.AppConfig) *AdminHandler {
+	return &AdminHandler{
+		diagnostics: diagnostics,
+		reclamation: reclamation,
+		expiry:      expiry,
+		nonces:      nonces,
+		signatures:  signatures,
+		events:      events,
+		sampleSize:  cfg.AdminMemorySampleSize,
+	}
+}
+
+// RedisMemory reports sampled Redis memory usage per key class
+func (h *AdminHandler) RedisMemory(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRedisMemory,
+		ValidateMemoryUsageRequest,
+	)
+}
+
+func (h *AdminHandler) handleRedisMemory(ctx context.Context, req interface{}) (interface{}, error) {
+	memReq := req.(*MemoryUsageRequestData)
+
+	sampleSize := memReq.SampleSize
+	if sampleSize == 0 {
+		sampleSize = h.sampleSize
+	}
+
+	return h.diagnostics.MemoryUsage(ctx, sampleSize)
+}
+
+// RunReclamation evaluates the reclaim policies on demand, as a dry run unless dryRun=false
+func (h *AdminHandler) RunReclamation(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRunReclamation,
+		ValidateReclaimRunRequest,
+	)
+}
+
+func (h *AdminHandler) handleRunReclamation(ctx context.Context, req interface{}) (interface{}, error) {
+	runReq := req.(*ReclaimRunRequestData)
+	return h.reclamation.Run(ctx, runReq.DryRun)
+}
+
+// ReclamationMetrics reports cumulative per-policy reclamation counters
+func (h *AdminHandler) ReclamationMetrics(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleReclamationMetrics, nil)
+}
+
+func (h *AdminHandler) handleReclamationMetrics(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.reclamation.Metrics(), nil
+}
+
+// EventBusMetrics reports the events published on the event bus and the deliveries to the
+// broker and every subscriber
+func (h *AdminHandler) EventBusMetrics(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleEventBusMetrics, nil)
+}
+
+func (h *AdminHandler) handleEventBusMetrics(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.events.Stats(), nil
+}
+
+// RunExpiryNotifications notifies the holders of expiring leases on demand
+func (h *AdminHandler) RunExpiryNotifications(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleRunExpiryNotifications, nil)
+}
+
+func (h *AdminHandler) handleRunExpiryNotifications(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.expiry.Run(ctx)
+}
+
+// ExpiryNotificationReport reports the outcome of the most recent expiry notification run
+func (h *AdminHandler) ExpiryNotificationReport(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleExpiryNotificationReport, nil)
+}
+
+func (h *AdminHandler) handleExpiryNotificationReport(ctx context.Context, req interface{}) (interface{}, error) {
+	report := h.expiry.LastReport()
+	if report == nil {
+		return nil, errors.ErrExpiryReportNotFound
+	}
+	return report, nil
+}
+
+// NonceMetrics reports nonce issuance counters and the peers requesting nonces most often
+func (h *AdminHandler) NonceMetrics(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleNonceMetrics, nil)
+}
+
+func (h *AdminHandler) handleNonceMetrics(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.nonces.Metrics(), nil
+}
+
+// PeerNonces lists the outstanding nonces of a peer
+func (h *AdminHandler) PeerNonces(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handlePeerNonces,
+		ValidatePeerIDParamRequest,
+	)
+}
+
+func (h *AdminHandler) handlePeerNonces(ctx context.Context, req interface{}) (interface{}, error) {
+	peerReq := req.(*PeerIDRequestData)
+	nonces, err := h.nonces.ListOutstandingNonces(ctx, peerReq.PeerID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &PeerNoncesResponse{PeerID: peerReq.PeerID, Nonces: make([]*NonceInfo, 0, len(nonces))}
+	for _, nonce := range nonces {
+		resp.Nonces = append(resp.Nonces, &NonceInfo{ID: nonce.ID, IssuedAt: nonce.IssuedAt, ExpiresAt: nonce.ExpiresAt})
+	}
+	return resp, nil
+}
+
+// RevokePeerNonces invalidates every outstanding nonce of a peer, e.g. when its nonces
+// may have been intercepted
+func (h *AdminHandler) RevokePeerNonces(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRevokePeerNonces,
+		ValidatePeerIDParamRequest,
+	)
+}
+
+func (h *AdminHandler) handleRevokePeerNonces(ctx context.Context, req interface{}) (interface{}, error) {
+	peerReq := req.(*PeerIDRequestData)
+	revoked, err := h.nonces.RevokeNonces(ctx, peerReq.PeerID)
+	if err != nil {
+		return nil, err
+	}
+	return &NonceRevocationResponse{Revoked: revoked}, nil
+}
+
+// SignatureCacheStats reports how often verified signatures were answered from the cache
+func (h *AdminHandler) SignatureCacheStats(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleSignatureCacheStats, nil)
+}
+
+func (h *AdminHandler) handleSignatureCacheStats(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.signatures.SignatureCacheStats(), nil
+}
@@ -11,10 +11,11 @@ import (
 
 type AuthHandler struct {
 	authService ports.AuthService
+	nonces      ports.NonceService
 }
 
-func NewAuthHandler(authService ports.AuthService) *AuthHandler {
-	return &AuthHandler{authService}
+func NewAuthHandler(authService ports.AuthService, nonces ports.NonceService) *AuthHandler {
+	return &AuthHandler{authService, nonces}
 }
 
 func (h *AuthHandler) RequestAuth(w http.ResponseWriter, r *http.Request) {
@@ -44,3 +45,39 @@ func (h *AuthHandler) handleAuthRequest(ctx context.Context, req interface{}) (i
 		Nonce:  nonce.NonceID,
 	}, nil
 }
+
+// RevokeNonce invalidates an outstanding nonce of the authenticated peer, e.g. one the
+// client requested but will not use
+func (h *AuthHandler) RevokeNonce(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRevokeNonce,
+		ValidateNonceRevokeRequest,
+	)
+}
+
+func (h *AuthHandler) handleRevokeNonce(ctx context.Context, req interface{}) (interface{}, error) {
+	revokeReq := req.(*NonceRevokeRequestData)
+	if err := h.nonces.RevokeNonce(ctx, revokeReq.PeerID, revokeReq.NonceID); err != nil {
+		return nil, err
+	}
+	return &NonceRevocationResponse{Revoked: 1}, nil
+}
+
+// RevokeNonces invalidates every outstanding nonce of the authenticated peer
+func (h *AuthHandler) RevokeNonces(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRevokeNonces,
+		ValidateLeaseRequest,
+	)
+}
+
+func (h *AuthHandler) handleRevokeNonces(ctx context.Context, req interface{}) (interface{}, error) {
+	peerReq := req.(*LeaseRequestData)
+	revoked, err := h.nonces.RevokeNonces(ctx, peerReq.PeerID)
+	if err != nil {
+		return nil, err
+	}
+	return &NonceRevocationResponse{Revoked: revoked}, nil
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// BanHandler shows and lifts the bans of clients that kept exceeding the rate limit. Its
+// routes are only served with rate_limit.ban_enabled.
+type BanHandler struct {
+	bans ports.BanService
+}
+
+func NewBanHandler(bans ports.BanService) *BanHandler {
+	return &BanHandler{bans}
+}
+
+// ListBans lists the active bans, the most recent first
+func (h *BanHandler) ListBans(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleListBans, nil)
+}
+
+func (h *BanHandler) handleListBans(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.bans.ListBans(ctx)
+}
+
+// BanMetrics reports the violations and bans of this instance since startup
+func (h *BanHandler) BanMetrics(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleBanMetrics, nil)
+}
+
+func (h *BanHandler) handleBanMetrics(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.bans.Metrics(), nil
+}
+
+// GetBanStatus reports the violations of a client and its active or last ban
+func (h *BanHandler) GetBanStatus(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleGetBanStatus,
+		ValidateBanSubjectRequest,
+	)
+}
+
+func (h *BanHandler) handleGetBanStatus(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.bans.GetBanStatus(ctx, req.(*BanSubjectRequestData).Subject)
+}
+
+// LiftBan ends the ban of a client and forgets its violations and earlier bans
+func (h *BanHandler) LiftBan(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleLiftBan,
+		ValidateBanSubjectRequest,
+	)
+}
+
+func (h *BanHandler) handleLiftBan(ctx context.Context, req interface{}) (interface{}, error) {
+	if err := h.bans.LiftBan(ctx, req.(*BanSubjectRequestData).Subject); err != nil {
+		return nil, err
+	}
+	return map[string]string{"status": "success"}, nil
+}
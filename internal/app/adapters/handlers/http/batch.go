@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/validation"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+const (
+	batchStatusOK    = "ok"
+	batchStatusError = "error"
+)
+
+// BatchHandler serves lease batches for gateways that manage many peer identities.
+// Every operation carries its own credentials, so it cannot sit behind WithAuth.
+type BatchHandler struct {
+	authService   ports.AuthService
+	accessControl ports.AccessControlService
+	leaseWriter   ports.LeaseWriter
+	maxOperations int
+}
+
+func NewBatchHandler(authService ports.AuthService, accessControl ports.AccessControlService, leaseWriter ports.LeaseWriter, cfg *config.AppConfig) *BatchHandler {
+	return &BatchHandler{
+		authService:   authService,
+		accessControl: accessControl,
+		leaseWriter:   leaseWriter,
+		maxOperations: cfg.Lease.BatchMaxOperations,
+	}
+}
+
+// ExecuteBatch runs mixed allocate/renew/release operations in one request
+func (h *BatchHandler) ExecuteBatch(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleExecuteBatch,
+		ValidateBatchRequest,
+	)
+}
+
+func (h *BatchHandler) handleExecuteBatch(ctx context.Context, req interface{}) (interface{}, error) {
+	batchReq := req.(*BatchRequest)
+	if h.maxOperations > 0 && len(batchReq.Operations) > h.maxOperations {
+		return nil, errors.ErrBatchTooLarge
+	}
+
+	results := make([]*BatchOperationResult, len(batchReq.Operations))
+	operations := make([]*models.LeaseOperation, 0, len(batchReq.Operations))
+	indexes := make([]int, 0, len(batchReq.Operations))
+
+	// Authenticate every item before touching the database; failures are reported per item
+	for i, item := range batchReq.Operations {
+		results[i] = &BatchOperationResult{Index: i, Op: item.Op}
+
+		op, err := h.authenticateOperation(ctx, item)
+		if err != nil {
+			results[i].setError(err)
+			continue
+		}
+
+		results[i].PeerID = op.PeerID
+		operations = append(operations, op)
+		indexes = append(indexes, i)
+	}
+
+	if len(operations) > 0 {
+		opResults, err := h.leaseWriter.ExecuteBatch(ctx, operations)
+		if err != nil {
+			return nil, err
+		}
+
+		for j, opResult := range opResults {
+			result := results[indexes[j]]
+			if opResult.Err != nil {
+				result.setError(opResult.Err)
+				continue
+			}
+			result.Status = batchStatusOK
+			result.Lease = opResult.Lease
+		}
+	}
+
+	return &BatchResponse{Results: results}, nil
+}
+
+func (h *BatchHandler) authenticateOperation(ctx context.Context, item *BatchOperationRequest) (*models.LeaseOperation, error) {
+	op := &models.LeaseOperation{Type: models.LeaseOperationType(item.Op)}
+	switch op.Type {
+	case models.LeaseOperationAllocate:
+	case models.LeaseOperationRenew, models.LeaseOperationRelease:
+		if item.TokenID <= 0 {
+			return nil, errors.ErrMissingTokenID
+		}
+		op.TokenID = item.TokenID
+	default:
+		return nil, errors.ErrInvalidOperation
+	}
+
+	if err := validation.ValidateNonce(item.Nonce); err != nil {
+		return nil, err
+	}
+
+	peerID, err := middleware.Authenticate(ctx, h.authService, h.accessControl, item.Pubkey, item.Nonce, item.Signature, item.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	op.PeerID = peerID
+
+	return op, nil
+}
+
+func (r *BatchOperationResult) setError(err error) {
+	_, errResp := utils.NewErrorResponse(err)
+	r.Status = batchStatusError
+	r.Error = &errResp
+}
@@ -0,0 +1,184 @@
+// Package clientip resolves the IP address of the client behind a chain of proxies.
+//
+// Forwarding headers are only believed when they come from a trusted proxy. The chain of
+// addresses a request passed through is read from the first of these headers present:
+//
+//	Forwarded: for=203.0.113.7, for="[2001:db8::1]:443"   RFC 7239
+//	X-Forwarded-For: 203.0.113.7, 10.0.0.2
+//	X-Real-IP: 203.0.113.7                                  a single address
+//
+// Each proxy appends the address it received the request from, so only the right end of
+// the chain is written by proxies we trust. The chain is walked from the peer address
+// leftwards and the first address that is not a trusted proxy is the client; anything
+// left of it may be forged by the client. When every address is trusted the leftmost
+// one is the client. An entry that is not an IP address ("unknown", an obfuscated
+// identifier or garbage) ends the walk, and the trusted proxy that reported it is taken
+// as the client instead.
+package clientip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Unknown is returned when the peer address of a request is not an IP address
+const Unknown = "unknown"
+
+// Networks is a list of IP networks, parsed from CIDR blocks and single addresses
+type Networks []netip.Prefix
+
+// ParseNetworks parses CIDR blocks and single IP addresses. Entries that are neither are
+// skipped, configured lists are validated on load.
+func ParseNetworks(entries []string) Networks {
+	networks := make(Networks, 0, len(entries))
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			networks = append(networks, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return networks
+}
+
+// Contains reports whether ip, with or without a port, is in one of the networks
+func (n Networks) Contains(ip string) bool {
+	addr, ok := parseAddr(ip)
+	return ok && n.contains(addr)
+}
+
+func (n Networks) contains(addr netip.Addr) bool {
+	for _, network := range n {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the IP address of the client that sent r, following the forwarding
+// headers through trustedProxies as described in the package documentation, or Unknown
+func Resolve(r *http.Request, trustedProxies Networks) string {
+	peer, ok := parseAddr(r.RemoteAddr)
+	if !ok {
+		return Unknown
+	}
+	if !trustedProxies.contains(peer) {
+		return peer.String()
+	}
+
+	client := peer
+	chain := forwardedChain(r.Header)
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(chain[i])
+		if !ok {
+			break
+		}
+		client = addr
+		if !trustedProxies.contains(addr) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedChain returns the addresses the request was forwarded for, from the client to
+// the last proxy, taken from the first forwarding header present
+func forwardedChain(header http.Header) []string {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		var chain []string
+		for _, value := range values {
+			for _, element := range splitQuoted(value, ',') {
+				chain = append(chain, forwardedFor(element))
+			}
+		}
+		return chain
+	}
+
+	if values := header.Values("X-Forwarded-For"); len(values) > 0 {
+		var chain []string
+		for _, value := range values {
+			for _, entry := range strings.Split(value, ",") {
+				if entry = strings.TrimSpace(entry); entry != "" {
+					chain = append(chain, entry)
+				}
+			}
+		}
+		return chain
+	}
+
+	if realIP := strings.TrimSpace(header.Get("X-Real-IP")); realIP != "" {
+		return []string{realIP}
+	}
+	return nil
+}
+
+// forwardedFor returns the unquoted for parameter of a Forwarded element, or an empty
+// string when the element has none
+func forwardedFor(element string) string {
+	for _, pair := range splitQuoted(element, ';') {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(name), "for") {
+			continue
+		}
+		return unquote(strings.TrimSpace(value))
+	}
+	return ""
+}
+
+// splitQuoted splits s at sep outside of quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, escaped, start := false, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote removes the quotes and escapes of an RFC 7230 quoted string
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	var b strings.Builder
+	escaped := false
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parseAddr parses an IP address, optionally with a port or, for IPv6, in brackets as
+// the Forwarded header writes it. IPv4-mapped IPv6 addresses are unmapped.
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	} else if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
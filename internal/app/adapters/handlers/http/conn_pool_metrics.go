@@ -0,0 +1,92 @@
+package http
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// poolMetric is a metric read from one sample of a connection pool
+type poolMetric[S any] struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     func(stats S) float64
+}
+
+// poolCollector exports a connection pool, sampling it once per scrape
+type poolCollector[S any] struct {
+	sample  func() S
+	metrics []poolMetric[S]
+}
+
+func (c *poolCollector[S]) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m.desc
+	}
+}
+
+func (c *poolCollector[S]) Collect(ch chan<- prometheus.Metric) {
+	stats := c.sample()
+	for _, m := range c.metrics {
+		ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, m.value(stats))
+	}
+}
+
+func poolGauge[S any](name, help string, value func(stats S) float64) poolMetric[S] {
+	return poolMetric[S]{desc: prometheus.NewDesc(name, help, nil, nil), valueType: prometheus.GaugeValue, value: value}
+}
+
+func poolCounter[S any](name, help string, value func(stats S) float64) poolMetric[S] {
+	return poolMetric[S]{desc: prometheus.NewDesc(name, help, nil, nil), valueType: prometheus.CounterValue, value: value}
+}
+
+func newDatabasePoolCollector(database ports.DatabasePoolStatsReporter) prometheus.Collector {
+	return &poolCollector[*models.DatabasePoolStats]{
+		sample: database.DatabasePoolStats,
+		metrics: []poolMetric[*models.DatabasePoolStats]{
+			poolGauge("dhcp2p_db_pool_max_conns", "Connections the Postgres pool may open.",
+				func(s *models.DatabasePoolStats) float64 { return float64(s.MaxConns) }),
+			poolGauge("dhcp2p_db_pool_total_conns", "Open Postgres connections, including those being established.",
+				func(s *models.DatabasePoolStats) float64 { return float64(s.TotalConns) }),
+			poolGauge("dhcp2p_db_pool_acquired_conns", "Postgres connections in use.",
+				func(s *models.DatabasePoolStats) float64 { return float64(s.AcquiredConns) }),
+			poolGauge("dhcp2p_db_pool_idle_conns", "Idle Postgres connections.",
+				func(s *models.DatabasePoolStats) float64 { return float64(s.IdleConns) }),
+			poolGauge("dhcp2p_db_pool_constructing_conns", "Postgres connections being established.",
+				func(s *models.DatabasePoolStats) float64 { return float64(s.ConstructingConns) }),
+			poolCounter("dhcp2p_db_pool_acquires_total", "Postgres connections acquired from the pool.",
+				func(s *models.DatabasePoolStats) float64 { return float64(s.AcquireCount) }),
+			poolCounter("dhcp2p_db_pool_empty_acquires_total", "Acquisitions that waited because no Postgres connection was idle.",
+				func(s *models.DatabasePoolStats) float64 { return float64(s.EmptyAcquireCount) }),
+			poolCounter("dhcp2p_db_pool_canceled_acquires_total", "Acquisitions canceled before a Postgres connection was free.",
+				func(s *models.DatabasePoolStats) float64 { return float64(s.CanceledAcquireCount) }),
+			poolCounter("dhcp2p_db_pool_acquire_wait_seconds_total", "Time spent acquiring Postgres connections.",
+				func(s *models.DatabasePoolStats) float64 { return s.AcquireDurationMs / 1000 }),
+		},
+	}
+}
+
+func newCachePoolCollector(cache ports.CachePoolStatsReporter) prometheus.Collector {
+	return &poolCollector[*models.CachePoolStats]{
+		sample: cache.CachePoolStats,
+		metrics: []poolMetric[*models.CachePoolStats]{
+			poolGauge("dhcp2p_redis_pool_total_conns", "Open Redis connections.",
+				func(s *models.CachePoolStats) float64 { return float64(s.TotalConns) }),
+			poolGauge("dhcp2p_redis_pool_idle_conns", "Idle Redis connections.",
+				func(s *models.CachePoolStats) float64 { return float64(s.IdleConns) }),
+			poolCounter("dhcp2p_redis_pool_stale_conns_total", "Redis connections closed as stale.",
+				func(s *models.CachePoolStats) float64 { return float64(s.StaleConns) }),
+			poolCounter("dhcp2p_redis_pool_hits_total", "Redis connections reused from the pool.",
+				func(s *models.CachePoolStats) float64 { return float64(s.Hits) }),
+			poolCounter("dhcp2p_redis_pool_misses_total", "Redis connections dialed because none was idle.",
+				func(s *models.CachePoolStats) float64 { return float64(s.Misses) }),
+			poolCounter("dhcp2p_redis_pool_timeouts_total", "Waits for a Redis connection that timed out.",
+				func(s *models.CachePoolStats) float64 { return float64(s.Timeouts) }),
+			poolCounter("dhcp2p_redis_pool_waits_total", "Waits for a Redis connection.",
+				func(s *models.CachePoolStats) float64 { return float64(s.WaitCount) }),
+			poolCounter("dhcp2p_redis_pool_wait_seconds_total", "Time spent waiting for Redis connections.",
+				func(s *models.CachePoolStats) float64 { return s.WaitDurationMs / 1000 }),
+		},
+	}
+}
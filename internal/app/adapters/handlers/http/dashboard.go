@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"net/http"
+
+	httpMiddleware "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// dashboardTopPeers is the number of peers listed by TopPeers
+const dashboardTopPeers = 10
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// DashboardHandler serves the dashboard page and the admin endpoints it reads from
+type DashboardHandler struct {
+	dashboard   ports.DashboardService
+	rateLimiter *httpMiddleware.RateLimiter
+	loadShedder *httpMiddleware.LoadShedder
+	cache       ports.CacheStatsReporter // nil without a lease cache
+	static      http.Handler
+}
+
+func NewDashboardHandler(dashboard ports.DashboardService, rateLimiter *httpMiddleware.RateLimiter, loadShedder *httpMiddleware.LoadShedder, cache ports.CacheStatsReporter) *DashboardHandler {
+	static, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+
+	return &DashboardHandler{
+		dashboard:   dashboard,
+		rateLimiter: rateLimiter,
+		loadShedder: loadShedder,
+		cache:       cache,
+		static:      http.StripPrefix("/dashboard/", http.FileServer(http.FS(static))),
+	}
+}
+
+// Static serves the dashboard page and its assets. They hold no data, so they are
+// served without the admin token the page asks for.
+func (h *DashboardHandler) Static(w http.ResponseWriter, r *http.Request) {
+	h.static.ServeHTTP(w, r)
+}
+
+// Summary reports pool utilization, lease counts, rate-limit and load-shed rejections and
+// cache hits
+func (h *DashboardHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleSummary, nil)
+}
+
+func (h *DashboardHandler) handleSummary(ctx context.Context, req interface{}) (interface{}, error) {
+	summary, err := h.dashboard.Summary(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.RateLimitRejections = h.rateLimiter.Rejected()
+	summary.LoadShedRejections = h.loadShedder.Shed()
+	if h.cache != nil {
+		summary.Cache = h.cache.CacheStats()
+	}
+	return summary, nil
+}
+
+// RecentAllocations lists the latest allocations, newest first
+func (h *DashboardHandler) RecentAllocations(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleRecentAllocations, nil)
+}
+
+func (h *DashboardHandler) handleRecentAllocations(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.dashboard.RecentAllocations(), nil
+}
+
+// TopPeers lists the peers with the most allocations and renewals
+func (h *DashboardHandler) TopPeers(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleTopPeers, nil)
+}
+
+func (h *DashboardHandler) handleTopPeers(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.dashboard.TopPeers(dashboardTopPeers), nil
+}
@@ -0,0 +1,115 @@
+// Reads the dashboard endpoints with the admin token entered by the operator. The token
+// is kept in session storage, so it is forgotten when the tab is closed.
+(function () {
+  "use strict";
+
+  const tokenKey = "dhcp2p.adminToken";
+  const refreshInterval = 5000;
+  let timer = null;
+
+  const $ = (id) => document.getElementById(id);
+
+  function showLogin(message) {
+    clearTimeout(timer);
+    sessionStorage.removeItem(tokenKey);
+    $("dashboard").hidden = true;
+    $("logout").hidden = true;
+    $("login").hidden = false;
+    $("status").textContent = message || "";
+  }
+
+  async function get(path) {
+    const response = await fetch("/v1/admin/dashboard/" + path, {
+      headers: { Authorization: "Bearer " + sessionStorage.getItem(tokenKey) },
+      cache: "no-store",
+    });
+    if (response.status === 401 || response.status === 403) {
+      throw new Error("unauthorized");
+    }
+    const body = await response.json();
+    if (!response.ok) {
+      throw new Error(body.message || response.statusText);
+    }
+    return body.data;
+  }
+
+  const number = (n) => n.toLocaleString();
+  const percent = (ratio) => (ratio * 100).toFixed(1) + "%";
+  const time = (value) => new Date(value).toLocaleString();
+
+  function cell(row, text, mono) {
+    const td = row.insertCell();
+    td.textContent = text;
+    if (mono) {
+      td.className = "mono";
+    }
+  }
+
+  function fill(tbody, items, columns) {
+    tbody.replaceChildren();
+    for (const item of items) {
+      const row = tbody.insertRow();
+      for (const [value, mono] of columns(item)) {
+        cell(row, value, mono);
+      }
+    }
+  }
+
+  function render(summary, recent, peers) {
+    $("pool-utilization").textContent = percent(summary.pool.utilization);
+    $("pool-bar").style.width = percent(Math.min(summary.pool.utilization, 1));
+    $("pool-detail").textContent = number(summary.pool.active) + " of " + number(summary.pool.size) + " addresses";
+
+    $("leases-active").textContent = number(summary.leases.active);
+    $("leases-detail").textContent = number(summary.leases.expired) + " expired, as of " + time(summary.leases.refreshed_at);
+
+    $("rejections").textContent = number(summary.rate_limit_rejections);
+    $("started").textContent = number(summary.load_shed_rejections) + " shed while busy, since " + time(summary.started_at);
+
+    if (summary.cache) {
+      $("cache-hit-rate").textContent = percent(summary.cache.hit_rate);
+      $("cache-detail").textContent = number(summary.cache.hits) + " hits, " + number(summary.cache.misses) + " misses";
+    } else {
+      $("cache-hit-rate").textContent = "-";
+      $("cache-detail").textContent = "no lease cache";
+    }
+
+    fill($("recent"), recent, (a) => [
+      [time(a.allocated_at)], [a.ip], [String(a.token_id)], [a.peer_id, true], [a.tenant], [time(a.expires_at)],
+    ]);
+    fill($("peers"), peers, (p) => [[p.peer_id, true], [p.tenant], [number(p.leases)]]);
+  }
+
+  async function refresh() {
+    try {
+      const [summary, recent, peers] = await Promise.all([get("summary"), get("recent-allocations"), get("top-peers")]);
+      render(summary, recent, peers);
+      $("login").hidden = true;
+      $("dashboard").hidden = false;
+      $("logout").hidden = false;
+      $("status").textContent = "Updated " + new Date().toLocaleTimeString();
+    } catch (err) {
+      if (err.message === "unauthorized") {
+        showLogin("Invalid admin token");
+        return;
+      }
+      $("status").textContent = "Refresh failed: " + err.message;
+    }
+    timer = setTimeout(refresh, refreshInterval);
+  }
+
+  $("login").addEventListener("submit", (event) => {
+    event.preventDefault();
+    sessionStorage.setItem(tokenKey, $("token").value);
+    $("token").value = "";
+    refresh();
+  });
+
+  $("logout").addEventListener("click", () => showLogin());
+
+  if (sessionStorage.getItem(tokenKey)) {
+    refresh();
+  } else {
+    showLogin();
+  }
+})();
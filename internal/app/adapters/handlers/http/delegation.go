@@ -0,0 +1,38 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// DelegationHandler serves lease allocations that gateways make for downstream peers
+type DelegationHandler struct {
+	delegationService ports.DelegationService
+}
+
+func NewDelegationHandler(delegationService ports.DelegationService) *DelegationHandler {
+	return &DelegationHandler{delegationService}
+}
+
+// AllocateDelegatedIP allocates a lease for the downstream peer the calling gateway vouches for
+func (h *DelegationHandler) AllocateDelegatedIP(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleAllocateDelegatedIP,
+		ValidateDelegationRequest,
+	)
+}
+
+func (h *DelegationHandler) handleAllocateDelegatedIP(ctx context.Context, req interface{}) (interface{}, error) {
+	delegationReq := req.(*DelegationRequestData)
+	return h.delegationService.AllocateDelegatedIP(ctx, &models.LeaseDelegationRequest{
+		GatewayPeerID:  delegationReq.GatewayPeerID,
+		GatewayPubkey:  delegationReq.GatewayPubkey,
+		DelegatePubkey: delegationReq.DelegatePubkey,
+		NonceID:        delegationReq.NonceID,
+		Signature:      delegationReq.Signature,
+	})
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// DiagnosticsHandler serves runtime statistics and the net/http/pprof profiles
+type DiagnosticsHandler struct {
+	database ports.DatabasePoolStatsReporter // nil without a Postgres pool
+	cache    ports.CachePoolStatsReporter    // nil without Redis
+	started  time.Time
+}
+
+func NewDiagnosticsHandler(database ports.DatabasePoolStatsReporter, cache ports.CachePoolStatsReporter) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		database: database,
+		cache:    cache,
+		started:  time.Now(),
+	}
+}
+
+// Runtime reports goroutines, memory, garbage collection and connection pool statistics
+func (h *DiagnosticsHandler) Runtime(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleRuntime, nil)
+}
+
+func (h *DiagnosticsHandler) handleRuntime(ctx context.Context, req interface{}) (interface{}, error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := &models.RuntimeStats{
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		Uptime:     time.Since(h.started).Seconds(),
+		Memory: &models.MemoryStats{
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			HeapIdle:     mem.HeapIdle,
+			HeapReleased: mem.HeapReleased,
+			HeapObjects:  mem.HeapObjects,
+			StackInuse:   mem.StackInuse,
+			Sys:          mem.Sys,
+			TotalAlloc:   mem.TotalAlloc,
+			Mallocs:      mem.Mallocs,
+			Frees:        mem.Frees,
+		},
+		GC: &models.GCStats{
+			NumGC:        mem.NumGC,
+			PauseTotalMs: float64(mem.PauseTotalNs) / float64(time.Millisecond),
+			NextGCBytes:  mem.NextGC,
+		},
+		SampledAt: time.Now(),
+	}
+	if mem.NumGC > 0 {
+		stats.GC.LastPauseMs = float64(mem.PauseNs[(mem.NumGC+255)%256]) / float64(time.Millisecond)
+		stats.GC.LastGC = time.Unix(0, int64(mem.LastGC))
+	}
+	if h.database != nil {
+		stats.Database = h.database.DatabasePoolStats()
+	}
+	if h.cache != nil {
+		stats.Cache = h.cache.CachePoolStats()
+	}
+	return stats, nil
+}
+
+// Vars serves the variables published through expvar, including memstats and cmdline
+func (h *DiagnosticsHandler) Vars(w http.ResponseWriter, r *http.Request) {
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+// ProfileIndex lists the available profiles
+func (h *DiagnosticsHandler) ProfileIndex(w http.ResponseWriter, r *http.Request) {
+	pprof.Index(w, r)
+}
+
+// Profile serves a named runtime profile such as heap, goroutine, allocs, block or mutex
+func (h *DiagnosticsHandler) Profile(w http.ResponseWriter, r *http.Request) {
+	pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+}
+
+// CPUProfile records a CPU profile for ?seconds= (default 30)
+func (h *DiagnosticsHandler) CPUProfile(w http.ResponseWriter, r *http.Request) {
+	pprof.Profile(w, r)
+}
+
+// Trace records an execution trace for ?seconds= (default 1)
+func (h *DiagnosticsHandler) Trace(w http.ResponseWriter, r *http.Request) {
+	pprof.Trace(w, r)
+}
+
+// Symbol resolves program counters, as used by go tool pprof
+func (h *DiagnosticsHandler) Symbol(w http.ResponseWriter, r *http.Request) {
+	pprof.Symbol(w, r)
+}
+
+// Cmdline reports the command line of the process
+func (h *DiagnosticsHandler) Cmdline(w http.ResponseWriter, r *http.Request) {
+	pprof.Cmdline(w, r)
+}
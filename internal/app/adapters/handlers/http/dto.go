@@ -1,24 +1,213 @@
 package http
 
-import "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+import (
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/pb"
+)
 
 type AuthResponse struct {
 	Pubkey string `json:"pubkey"`
 	Nonce  string `json:"nonce"`
 }
 
+// ProtoResponse implements utils.ProtoResponse
+func (r *AuthResponse) ProtoResponse() *pb.Response {
+	return &pb.Response{Body: &pb.Response_Nonce{Nonce: &pb.Nonce{Pubkey: r.Pubkey, Nonce: r.Nonce}}}
+}
+
+// NonceRevocationResponse reports how many outstanding nonces were revoked
+type NonceRevocationResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// PeerNoncesResponse lists the outstanding nonces of a peer, newest first
+type PeerNoncesResponse struct {
+	PeerID string       `json:"peer_id"`
+	Nonces []*NonceInfo `json:"nonces"`
+}
+
+type NonceInfo struct {
+	ID        string    `json:"id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StatusResponse acknowledges operations that return no resource
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+// ProtoResponse implements utils.ProtoResponse
+func (r *StatusResponse) ProtoResponse() *pb.Response {
+	return &pb.Response{Body: &pb.Response_Status{Status: &pb.Status{Status: r.Status}}}
+}
+
+// ServerInfoResponse describes the server. PublicKey is the base64-encoded libp2p public
+// key lease signatures verify against; it is absent when lease signing is disabled.
+type ServerInfoResponse struct {
+	Version            string            `json:"version"`
+	PublicKey          string            `json:"public_key,omitempty"`
+	PeerID             string            `json:"peer_id,omitempty"`
+	KeyID              string            `json:"key_id,omitempty"` // ID of the key leases are currently signed with
+	Tenant             string            `json:"tenant,omitempty"` // tenant of the request's API key, absent for the default tenant
+	LeaseSigning       bool              `json:"lease_signing"`
+	Pools              []*ServerPoolInfo `json:"pools"`
+	LeaseTTL           *LeaseTTLBounds   `json:"lease_ttl"`
+	Auth               *ServerAuthInfo   `json:"auth"`
+	Features           []string          `json:"features"`
+	BatchMaxOperations int               `json:"batch_max_operations"`
+	LeaseProtocol      string            `json:"lease_protocol,omitempty"` // libp2p protocol ID, absent when not served
+	HA                 *models.HAStatus  `json:"ha,omitempty"`             // role in active/standby mode, absent without it
+}
+
+// ServerKeysResponse is the set of keys lease certificates are verified against, empty
+// when lease signing is disabled
+type ServerKeysResponse struct {
+	CurrentKeyID string               `json:"current_key_id,omitempty"`
+	Keys         []*models.SigningKey `json:"keys"`
+}
+
+// ServerPoolInfo is a range of token IDs the server hands out
+type ServerPoolInfo struct {
+	MinTokenID int64    `json:"min_token_id"`
+	MaxTokenID int64    `json:"max_token_id"`
+	FirstIP    string   `json:"first_ip"`
+	LastIP     string   `json:"last_ip"`
+	Zones      []string `json:"zones,omitempty"` // racks or sites allocations can ask for
+}
+
+// PoolRangesResponse lists which token IDs of every tenant pool are handed out, the
+// default pool first
+type PoolRangesResponse struct {
+	Pools []*PoolRanges `json:"pools"`
+}
+
+// PoolRanges splits a tenant pool into the configured exclusions and the ranges left
+// between them, and lists the ranges of its zones
+type PoolRanges struct {
+	Tenant          string            `json:"tenant"`
+	Pool            *TokenRangeInfo   `json:"pool"`
+	Exclusions      []*TokenRangeInfo `json:"exclusions"`
+	Zones           []*TokenRangeInfo `json:"zones,omitempty"`
+	Allocatable     []*TokenRangeInfo `json:"allocatable"`
+	AllocatableSize int64             `json:"allocatable_size"`
+}
+
+// TokenRangeInfo is an inclusive range of token IDs and the addresses it spans
+type TokenRangeInfo struct {
+	FirstTokenID int64  `json:"first_token_id"`
+	LastTokenID  int64  `json:"last_token_id"`
+	FirstIP      string `json:"first_ip"`
+	LastIP       string `json:"last_ip"`
+	Size         int64  `json:"size"`
+	Reason       string `json:"reason,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+}
+
+// LeaseTTLBounds are the lease lifetimes the server grants, in minutes
+type LeaseTTLBounds struct {
+	MinMinutes int `json:"min_minutes"`
+	MaxMinutes int `json:"max_minutes"`
+}
+
+// ServerAuthInfo describes how peers authenticate
+type ServerAuthInfo struct {
+	Methods                []string `json:"methods"`
+	IdentityScheme         string   `json:"identity_scheme"`
+	TimestampRequired      bool     `json:"timestamp_required"`
+	TimestampWindowSeconds int      `json:"timestamp_window_seconds"`
+	LookupAuthRequired     bool     `json:"lookup_auth_required"`
+	AllowListRequired      bool     `json:"allow_list_required"`
+}
+
 type AllocateRequestedIPRequest struct {
 	IP string `json:"ip"`
 }
 
 type AllocateRequestedIPResponse struct {
-	Lease *models.Lease `json:"lease"`
+	Lease            *models.Lease `json:"lease"`
+	RequestedTokenID int64         `json:"requested_token_id"`
+	Granted          bool          `json:"granted"`
+	Reason           string        `json:"reason"`
+}
+
+// ProtoResponse implements utils.ProtoResponse
+func (r *AllocateRequestedIPResponse) ProtoResponse() *pb.Response {
+	return &pb.Response{Body: &pb.Response_Allocation{Allocation: &pb.Allocation{
+		Lease:            utils.LeaseMessage(r.Lease),
+		RequestedTokenId: r.RequestedTokenID,
+		Granted:          r.Granted,
+		Reason:           r.Reason,
+	}}}
+}
+
+// LeaseWaitResponse reports whether the lease changed during a wait. Event is the change
+// observed, empty when the lease already differed from the caller's copy; Lease is absent
+// once the token ID has no active lease.
+type LeaseWaitResponse struct {
+	Changed bool                           `json:"changed"`
+	Event   models.LeaseLifecycleEventType `json:"event,omitempty"`
+	Lease   *models.Lease                  `json:"lease,omitempty"`
 }
 
 type AllocateDynamicIPResponse struct {
 	Lease *models.Lease `json:"lease,omitempty"`
 }
 
+type BatchRequest struct {
+	Operations []*BatchOperationRequest `json:"operations"`
+}
+
+// BatchOperationRequest is one batch item, authenticated with the peer's own nonce and signature
+type BatchOperationRequest struct {
+	Op        string `json:"op"`
+	Pubkey    string `json:"pubkey"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+	Timestamp string `json:"timestamp,omitempty"`
+	TokenID   int64  `json:"token_id,omitempty"`
+}
+
+type BatchOperationResult struct {
+	Index  int                  `json:"index"`
+	Op     string               `json:"op"`
+	PeerID string               `json:"peer_id,omitempty"`
+	Status string               `json:"status"`
+	Lease  *models.Lease        `json:"lease,omitempty"`
+	Error  *utils.ErrorResponse `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []*BatchOperationResult `json:"results"`
+}
+
+// AccessRuleRequest puts a peer ID or public key on the deny or allow list
+type AccessRuleRequest struct {
+	List        string `json:"list"`
+	SubjectType string `json:"subject_type"`
+	Subject     string `json:"subject"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// PeerPolicyRequest adds a peer policy kept in the database
+type PeerPolicyRequest struct {
+	Name        string   `json:"name"`
+	Peers       []string `json:"peers"`
+	CanAllocate bool     `json:"can_allocate"`
+	CanReserve  bool     `json:"can_reserve"`
+	MaxPool     int      `json:"max_pool"`
+}
+
+// FaultRequest injects a fault into the calls to the target named in the URL
+type FaultRequest struct {
+	LatencyMs       int64   `json:"latency_ms"`
+	ErrorRate       float64 `json:"error_rate"`
+	DurationSeconds int64   `json:"duration_seconds,omitempty"` // 0 keeps the fault until it is cleared
+}
+
 // Request data structures for type safety
 type AuthRequestData struct {
 	Pubkey []byte
@@ -28,11 +217,101 @@ type LeaseRequestData struct {
 	PeerID string
 }
 
+type AllocateRequestData struct {
+	PeerID           string
+	RequestedTokenID int64             // zero when no preferred token ID was requested
+	AffinityGroup    string            // empty when the peer is not part of an affinity group
+	Zone             string            // rack or site to allocate in when possible, empty for any
+	Labels           map[string]string // nil leaves the labels of the lease unchanged
+}
+
+type TransferRequestData struct {
+	TokenID    int64
+	NonceID    string
+	FromPubkey []byte
+	ToPubkey   []byte
+	Signature  []byte
+}
+
+type DelegationRequestData struct {
+	GatewayPeerID  string
+	NonceID        string
+	GatewayPubkey  []byte
+	DelegatePubkey []byte
+	Signature      []byte
+}
+
+type ConflictRequestData struct {
+	PeerID         string
+	TokenID        int64
+	ObservedPeerID string // empty when the reporter could not identify the other node
+}
+
+type MemoryUsageRequestData struct {
+	SampleSize int // zero uses the configured default
+}
+
+type ReclaimRunRequestData struct {
+	DryRun bool
+}
+
+type SnapshotImportRequestData struct {
+	Snapshot models.LeaseSnapshot
+	Replace  bool
+}
+
+type AccessListRequestData struct {
+	List models.AccessList // empty for both lists
+}
+
+type AccessRuleIDRequestData struct {
+	ID int64
+}
+
+type PeerPolicyIDRequestData struct {
+	ID int64
+}
+
+type RevocationIDRequestData struct {
+	ID string
+}
+
+type FaultRequestData struct {
+	Fault    *models.Fault
+	Duration time.Duration // 0 keeps the fault until it is cleared
+}
+
+type FaultTargetRequestData struct {
+	Target models.FaultTarget
+}
+
+type BanSubjectRequestData struct {
+	Subject string // client IP
+}
+
 type TokenIDRequestData struct {
 	PeerID  string
 	TokenID int64
 }
 
+type RenewRequestData struct {
+	TokenIDRequestData
+	Labels map[string]string // nil leaves the labels of the lease unchanged
+}
+
+// LeaseWaitRequestData waits up to Timeout for a change of TokenID. ETag is the caller's
+// copy of the lease, from If-None-Match; a lease that already differs is returned at once.
+type LeaseWaitRequestData struct {
+	TokenID int64
+	Timeout time.Duration // 0 waits as long as the server allows
+	ETag    string
+}
+
 type PeerIDRequestData struct {
 	PeerID string
 }
+
+type NonceRevokeRequestData struct {
+	PeerID  string
+	NonceID string
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/base64"
 	"net/http"
+	"net/netip"
 	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/validation"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 )
 
 // HandlerFunc represents a standardized handler function signature
@@ -59,9 +64,11 @@ func (sc *ServiceCall) ExecuteServiceCall(
 
 // Common validation functions for different request types
 
-// ValidateAuthRequest validates an authentication request
+// ValidateAuthRequest validates an authentication request, with the public key in the
+// X-Pubkey header or a JSON body
 func ValidateAuthRequest(r *http.Request) (interface{}, error) {
-	pubkeyResult := validation.ValidateHeader(r, "X-Pubkey", validation.DefaultValidationConfig())
+	body := validation.RequestBodyFromContext(r)
+	pubkeyResult := validation.ValidateHeaderOrBody(r, "X-Pubkey", body.Pubkey, "pubkey", validation.DefaultValidationConfig())
 	if pubkeyResult.Error != nil {
 		return nil, pubkeyResult.Error
 	}
@@ -94,15 +101,114 @@ func ValidateLeaseRequest(r *http.Request) (interface{}, error) {
 	}, nil
 }
 
-// ValidateTokenIDRequest validates a request that includes a token ID
+// ValidateAllocateRequest validates an allocation request with an optional preferred token ID or affinity group,
+// given as query parameters or in a JSON body, and an optional zone hint in the X-Zone header or the body
+func ValidateAllocateRequest(r *http.Request) (interface{}, error) {
+	peerIDResult := validation.ValidatePeerIDFromContext(r)
+	if peerIDResult.Error != nil {
+		return nil, peerIDResult.Error
+	}
+
+	data := &AllocateRequestData{
+		PeerID: peerIDResult.Value,
+	}
+
+	if tokenIDStr := validation.TokenIDFromQueryOrBody(r); tokenIDStr != "" {
+		tokenIDResult := validation.ValidateTokenID(tokenIDStr)
+		if tokenIDResult.Error != nil {
+			return nil, tokenIDResult.Error
+		}
+		data.RequestedTokenID, _ = strconv.ParseInt(tokenIDResult.Value, 10, 64)
+	}
+
+	body := validation.RequestBodyFromContext(r)
+	affinityResult := validation.ValidateQueryOrBody(r, "affinityGroup", body.AffinityGroup, validation.AffinityGroupValidationConfig())
+	if affinityResult.Error != nil {
+		return nil, affinityResult.Error
+	}
+	data.AffinityGroup = affinityResult.Value
+
+	if data.RequestedTokenID != 0 && data.AffinityGroup != "" {
+		return nil, errors.ErrConflictingOptions
+	}
+
+	zoneResult := validation.ValidateHeaderOrBody(r, "X-Zone", body.Zone, "zone", validation.ZoneValidationConfig())
+	if zoneResult.Error != nil {
+		return nil, zoneResult.Error
+	}
+	data.Zone = zoneResult.Value
+
+	labels, err := validation.LabelsFromQueryOrBody(r)
+	if err != nil {
+		return nil, err
+	}
+	data.Labels = labels
+
+	return data, nil
+}
+
+// ValidateBatchRequest decodes a lease batch from the JSON request body
+func ValidateBatchRequest(r *http.Request) (interface{}, error) {
+	var batch BatchRequest
+	if err := utils.ParseRequestBody(r, &batch); err != nil {
+		return nil, errors.ErrInvalidRequest
+	}
+
+	if len(batch.Operations) == 0 {
+		return nil, errors.ErrEmptyBatch
+	}
+	for _, op := range batch.Operations {
+		if op == nil {
+			return nil, errors.ErrInvalidRequest
+		}
+	}
+
+	return &batch, nil
+}
+
+// maxMemorySampleSize bounds the per-class sample size accepted from admin requests
+const maxMemorySampleSize = 10000
+
+// ValidateMemoryUsageRequest validates a Redis memory report request with an optional sample size
+func ValidateMemoryUsageRequest(r *http.Request) (interface{}, error) {
+	data := &MemoryUsageRequestData{}
+
+	if samplesStr := r.URL.Query().Get("samples"); samplesStr != "" {
+		samples, err := strconv.Atoi(samplesStr)
+		if err != nil || samples <= 0 || samples > maxMemorySampleSize {
+			return nil, errors.ErrInvalidRequest
+		}
+		data.SampleSize = samples
+	}
+
+	return data, nil
+}
+
+// ValidateReclaimRunRequest validates an on-demand reclamation run. Runs are dry runs
+// unless the dryRun query parameter is explicitly false.
+func ValidateReclaimRunRequest(r *http.Request) (interface{}, error) {
+	data := &ReclaimRunRequestData{DryRun: true}
+
+	if dryRunStr := r.URL.Query().Get("dryRun"); dryRunStr != "" {
+		dryRun, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			return nil, errors.ErrInvalidRequest
+		}
+		data.DryRun = dryRun
+	}
+
+	return data, nil
+}
+
+// ValidateTokenIDRequest validates a request that includes a token ID, as the tokenID
+// query parameter or in a JSON body
 func ValidateTokenIDRequest(r *http.Request) (interface{}, error) {
 	peerIDResult := validation.ValidatePeerIDFromContext(r)
 	if peerIDResult.Error != nil {
 		return nil, peerIDResult.Error
 	}
 
-	tokenIDStr := r.URL.Query().Get("tokenID")
-	tokenIDResult := validation.ValidateTokenID(tokenIDStr)
+	tokenIDResult := validation.ValidateTokenID(validation.TokenIDFromQueryOrBody(r))
 	if tokenIDResult.Error != nil {
 		return nil, tokenIDResult.Error
 	}
@@ -115,6 +221,170 @@ func ValidateTokenIDRequest(r *http.Request) (interface{}, error) {
 	}, nil
 }
 
+// ValidateRenewRequest validates a renewal, which may replace the labels of the lease
+func ValidateRenewRequest(r *http.Request) (interface{}, error) {
+	tokenReq, err := ValidateTokenIDRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := validation.LabelsFromQueryOrBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenewRequestData{
+		TokenIDRequestData: *tokenReq.(*TokenIDRequestData),
+		Labels:             labels,
+	}, nil
+}
+
+// ValidateConflictRequest validates an address conflict report. The reporter must be
+// authenticated; observedPeerID is optional.
+func ValidateConflictRequest(r *http.Request) (interface{}, error) {
+	peerIDResult := validation.ValidatePeerIDFromContext(r)
+	if peerIDResult.Error != nil {
+		return nil, peerIDResult.Error
+	}
+
+	tokenIDResult := validation.ValidateTokenID(r.URL.Query().Get("tokenID"))
+	if tokenIDResult.Error != nil {
+		return nil, tokenIDResult.Error
+	}
+	tokenID, _ := strconv.ParseInt(tokenIDResult.Value, 10, 64)
+
+	observedConfig := validation.PeerIDValidationConfig()
+	observedConfig.Required = false
+	observedConfig.AllowEmpty = true
+	observedResult := validation.ValidateQueryParam(r, "observedPeerID", observedConfig)
+	if observedResult.Error != nil {
+		return nil, observedResult.Error
+	}
+
+	return &ConflictRequestData{
+		PeerID:         peerIDResult.Value,
+		TokenID:        tokenID,
+		ObservedPeerID: observedResult.Value,
+	}, nil
+}
+
+// ValidateTransferRequest validates a lease transfer request. The caller has already been
+// authenticated as the current owner, so X-Pubkey and X-Nonce identify the old peer.
+func ValidateTransferRequest(r *http.Request) (interface{}, error) {
+	peerIDResult := validation.ValidatePeerIDFromContext(r)
+	if peerIDResult.Error != nil {
+		return nil, peerIDResult.Error
+	}
+
+	tokenIDResult := validation.ValidateTokenID(r.URL.Query().Get("tokenID"))
+	if tokenIDResult.Error != nil {
+		return nil, tokenIDResult.Error
+	}
+	tokenID, _ := strconv.ParseInt(tokenIDResult.Value, 10, 64)
+
+	nonceResult := validation.ValidateHeader(r, "X-Nonce", validation.NonceValidationConfig())
+	if nonceResult.Error != nil {
+		return nil, nonceResult.Error
+	}
+
+	fromPubkey, err := decodePubkeyHeader(r, "X-Pubkey")
+	if err != nil {
+		return nil, err
+	}
+
+	toPubkey, err := decodePubkeyHeader(r, "X-New-Pubkey")
+	if err != nil {
+		return nil, err
+	}
+
+	signatureResult := validation.ValidateHeader(r, "X-Transfer-Signature", validation.SignatureValidationConfig())
+	if signatureResult.Error != nil {
+		return nil, signatureResult.Error
+	}
+	signatureValidation := validation.ValidateBase64Signature(signatureResult.Value)
+	if signatureValidation.Error != nil {
+		return nil, signatureValidation.Error
+	}
+	signature, err := base64.StdEncoding.DecodeString(signatureValidation.Value)
+	if err != nil {
+		return nil, errors.ErrInvalidSignature
+	}
+
+	return &TransferRequestData{
+		TokenID:    tokenID,
+		NonceID:    nonceResult.Value,
+		FromPubkey: fromPubkey,
+		ToPubkey:   toPubkey,
+		Signature:  signature,
+	}, nil
+}
+
+// ValidateDelegationRequest validates a delegated allocation: the gateway's credentials come
+// from the auth headers, the downstream key and the gateway's signature vouching for it
+// from X-Delegate-Pubkey and X-Delegation-Signature
+func ValidateDelegationRequest(r *http.Request) (interface{}, error) {
+	peerIDResult := validation.ValidatePeerIDFromContext(r)
+	if peerIDResult.Error != nil {
+		return nil, peerIDResult.Error
+	}
+
+	nonceResult := validation.ValidateHeader(r, "X-Nonce", validation.NonceValidationConfig())
+	if nonceResult.Error != nil {
+		return nil, nonceResult.Error
+	}
+
+	gatewayPubkey, err := decodePubkeyHeader(r, "X-Pubkey")
+	if err != nil {
+		return nil, err
+	}
+
+	delegatePubkey, err := decodePubkeyHeader(r, "X-Delegate-Pubkey")
+	if err != nil {
+		return nil, err
+	}
+
+	signatureResult := validation.ValidateHeader(r, "X-Delegation-Signature", validation.SignatureValidationConfig())
+	if signatureResult.Error != nil {
+		return nil, signatureResult.Error
+	}
+	signatureValidation := validation.ValidateBase64Signature(signatureResult.Value)
+	if signatureValidation.Error != nil {
+		return nil, signatureValidation.Error
+	}
+	signature, err := base64.StdEncoding.DecodeString(signatureValidation.Value)
+	if err != nil {
+		return nil, errors.ErrInvalidSignature
+	}
+
+	return &DelegationRequestData{
+		GatewayPeerID:  peerIDResult.Value,
+		NonceID:        nonceResult.Value,
+		GatewayPubkey:  gatewayPubkey,
+		DelegatePubkey: delegatePubkey,
+		Signature:      signature,
+	}, nil
+}
+
+// decodePubkeyHeader validates and decodes a base64-encoded public key header
+func decodePubkeyHeader(r *http.Request, headerName string) ([]byte, error) {
+	pubkeyResult := validation.ValidateHeader(r, headerName, validation.PubkeyValidationConfig())
+	if pubkeyResult.Error != nil {
+		return nil, pubkeyResult.Error
+	}
+
+	pubkeyValidation := validation.ValidateBase64Pubkey(pubkeyResult.Value)
+	if pubkeyValidation.Error != nil {
+		return nil, pubkeyValidation.Error
+	}
+
+	pubkey, err := base64.StdEncoding.DecodeString(pubkeyValidation.Value)
+	if err != nil {
+		return nil, errors.ErrInvalidPubkey
+	}
+
+	return pubkey, nil
+}
+
 // ValidatePeerIDParamRequest validates a request with peerID as URL parameter
 func ValidatePeerIDParamRequest(r *http.Request) (interface{}, error) {
 	peerIDResult := validation.ValidateURLParam(r, "peerID", validation.DefaultValidationConfig())
@@ -127,6 +397,25 @@ func ValidatePeerIDParamRequest(r *http.Request) (interface{}, error) {
 	}, nil
 }
 
+// ValidateNonceRevokeRequest validates the revocation of the nonce in the URL by the
+// authenticated peer
+func ValidateNonceRevokeRequest(r *http.Request) (interface{}, error) {
+	peerIDResult := validation.ValidatePeerIDFromContext(r)
+	if peerIDResult.Error != nil {
+		return nil, peerIDResult.Error
+	}
+
+	nonceResult := validation.ValidateURLParam(r, "nonceID", validation.NonceValidationConfig())
+	if nonceResult.Error != nil {
+		return nil, nonceResult.Error
+	}
+
+	return &NonceRevokeRequestData{
+		PeerID:  peerIDResult.Value,
+		NonceID: nonceResult.Value,
+	}, nil
+}
+
 // ValidateTokenIDParamRequest validates a request with tokenID as URL parameter
 func ValidateTokenIDParamRequest(r *http.Request) (interface{}, error) {
 	tokenIDStrResult := validation.ValidateURLParam(r, "tokenID", validation.DefaultValidationConfig())
@@ -145,3 +434,248 @@ func ValidateTokenIDParamRequest(r *http.Request) (interface{}, error) {
 		TokenID: tokenID,
 	}, nil
 }
+
+// ValidateLeaseWaitRequest validates a lease wait with the token ID path parameter, an
+// optional timeout query parameter such as 30s and an optional If-None-Match header
+func ValidateLeaseWaitRequest(r *http.Request) (interface{}, error) {
+	req, err := ValidateTokenIDParamRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &LeaseWaitRequestData{
+		TokenID: req.(*TokenIDRequestData).TokenID,
+		ETag:    r.Header.Get("If-None-Match"),
+	}
+	if timeoutStr := r.URL.Query().Get("timeout"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil || timeout <= 0 {
+			return nil, errors.ErrInvalidRequest.WithDetails("timeout must be a positive duration such as 30s")
+		}
+		data.Timeout = timeout
+	}
+
+	return data, nil
+}
+
+// maxAccessRuleReasonLength bounds the free-form reason stored with an access rule
+const maxAccessRuleReasonLength = 256
+
+// ValidateAccessListRequest validates an access rule listing with an optional list filter
+func ValidateAccessListRequest(r *http.Request) (interface{}, error) {
+	list := models.AccessList(r.URL.Query().Get("list"))
+	if list != "" && list != models.AccessListDeny && list != models.AccessListAllow {
+		return nil, errors.ErrInvalidAccessRule
+	}
+
+	return &AccessListRequestData{
+		List: list,
+	}, nil
+}
+
+// ValidateAccessRuleRequest decodes a new access rule from the JSON request body. Public
+// keys are re-encoded so they match the keys presented on authentication.
+func ValidateAccessRuleRequest(r *http.Request) (interface{}, error) {
+	var body AccessRuleRequest
+	if err := utils.ParseRequestBody(r, &body); err != nil {
+		return nil, errors.ErrInvalidRequest
+	}
+
+	rule := &models.AccessRule{
+		List:        models.AccessList(body.List),
+		SubjectType: models.AccessSubjectType(body.SubjectType),
+		Reason:      validation.RemoveControlCharacters(body.Reason),
+	}
+	if rule.List != models.AccessListDeny && rule.List != models.AccessListAllow {
+		return nil, errors.ErrInvalidAccessRule
+	}
+	if len(rule.Reason) > maxAccessRuleReasonLength {
+		return nil, errors.ErrInvalidAccessRule
+	}
+
+	switch rule.SubjectType {
+	case models.AccessSubjectPeerID:
+		peerIDResult := validation.ValidateValue(body.Subject, "subject", validation.PeerIDValidationConfig())
+		if peerIDResult.Error != nil {
+			return nil, errors.ErrInvalidAccessRule
+		}
+		rule.Subject = peerIDResult.Value
+	case models.AccessSubjectPubkey:
+		pubkeyValidation := validation.ValidateBase64Pubkey(body.Subject)
+		if pubkeyValidation.Error != nil {
+			return nil, errors.ErrInvalidAccessRule
+		}
+		pubkey, err := base64.StdEncoding.DecodeString(pubkeyValidation.Value)
+		if err != nil {
+			return nil, errors.ErrInvalidAccessRule
+		}
+		rule.Subject = base64.StdEncoding.EncodeToString(pubkey)
+	default:
+		return nil, errors.ErrInvalidAccessRule
+	}
+
+	return rule, nil
+}
+
+// ValidateAccessRuleIDRequest validates a request with an access rule ID as URL parameter
+func ValidateAccessRuleIDRequest(r *http.Request) (interface{}, error) {
+	idResult := validation.ValidateURLParam(r, "ruleID", validation.DefaultValidationConfig())
+	if idResult.Error != nil {
+		return nil, idResult.Error
+	}
+
+	id, err := strconv.ParseInt(idResult.Value, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, errors.ErrAccessRuleNotFound
+	}
+
+	return &AccessRuleIDRequestData{
+		ID: id,
+	}, nil
+}
+
+// ValidatePeerPolicyRequest validates the peer policy to create or replace
+func ValidatePeerPolicyRequest(r *http.Request) (interface{}, error) {
+	var body PeerPolicyRequest
+	if err := utils.ParseRequestBody(r, &body); err != nil {
+		return nil, errors.ErrInvalidRequest
+	}
+
+	policy := &models.PeerPolicy{
+		Name:        validation.RemoveControlCharacters(body.Name),
+		Peers:       body.Peers,
+		CanAllocate: body.CanAllocate,
+		CanReserve:  body.CanReserve,
+		MaxPool:     body.MaxPool,
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// ValidatePeerPolicyIDRequest validates a request with a peer policy ID as URL parameter
+func ValidatePeerPolicyIDRequest(r *http.Request) (interface{}, error) {
+	idResult := validation.ValidateURLParam(r, "policyID", validation.DefaultValidationConfig())
+	if idResult.Error != nil {
+		return nil, idResult.Error
+	}
+
+	id, err := strconv.ParseInt(idResult.Value, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, errors.ErrPeerPolicyNotFound
+	}
+
+	return &PeerPolicyIDRequestData{
+		ID: id,
+	}, nil
+}
+
+// ValidateFaultRequest reads the fault to inject into the target of the URL
+func ValidateFaultRequest(r *http.Request) (interface{}, error) {
+	var body FaultRequest
+	if err := utils.ParseRequestBody(r, &body); err != nil {
+		return nil, errors.ErrInvalidRequest
+	}
+
+	fault := &models.Fault{
+		Target:    models.FaultTarget(chi.URLParam(r, "target")),
+		LatencyMs: body.LatencyMs,
+		ErrorRate: body.ErrorRate,
+	}
+	if err := fault.Validate(); err != nil {
+		return nil, err
+	}
+	if body.DurationSeconds < 0 {
+		return nil, errors.ErrInvalidFault.WithDetails("duration_seconds must not be negative")
+	}
+	return &FaultRequestData{
+		Fault:    fault,
+		Duration: time.Duration(body.DurationSeconds) * time.Second,
+	}, nil
+}
+
+// ValidateFaultTargetRequest reads the fault target of the URL, the database or the cache
+func ValidateFaultTargetRequest(r *http.Request) (interface{}, error) {
+	target := models.FaultTarget(chi.URLParam(r, "target"))
+	if target != models.FaultTargetDatabase && target != models.FaultTargetCache {
+		return nil, errors.ErrInvalidFault.WithDetails("target must be database or cache")
+	}
+	return &FaultTargetRequestData{Target: target}, nil
+}
+
+// ValidateBanSubjectRequest reads the client IP or peer ID of the URL, in the form the
+// rate limiter tracks it
+func ValidateBanSubjectRequest(r *http.Request) (interface{}, error) {
+	client := chi.URLParam(r, "client")
+	if addr, err := netip.ParseAddr(client); err == nil {
+		return &BanSubjectRequestData{Subject: addr.String()}, nil
+	}
+
+	peerIDResult := validation.ValidateValue(client, "client", validation.PeerIDValidationConfig())
+	if peerIDResult.Error != nil {
+		return nil, errors.ErrInvalidClientIP
+	}
+	return &BanSubjectRequestData{Subject: peerIDResult.Value}, nil
+}
+
+// ValidateSnapshotImportRequest decodes a lease snapshot from the JSON request body. The
+// snapshot only replaces stored leases when the replace query parameter is true.
+func ValidateSnapshotImportRequest(r *http.Request) (interface{}, error) {
+	data := &SnapshotImportRequestData{}
+
+	if replaceStr := r.URL.Query().Get("replace"); replaceStr != "" {
+		replace, err := strconv.ParseBool(replaceStr)
+		if err != nil {
+			return nil, errors.ErrInvalidRequest
+		}
+		data.Replace = replace
+	}
+
+	if err := utils.ParseRequestBody(r, &data.Snapshot); err != nil {
+		return nil, errors.ErrInvalidSnapshot.WithDetails(err.Error())
+	}
+
+	return data, nil
+}
+
+// ValidateLeaseRevocationRequest decodes a revocation filter and reason from the JSON
+// request body. Revocations are dry runs unless the dryRun query parameter is explicitly
+// false.
+func ValidateLeaseRevocationRequest(r *http.Request) (interface{}, error) {
+	request := &models.LeaseRevocationRequest{DryRun: true}
+
+	if dryRunStr := r.URL.Query().Get("dryRun"); dryRunStr != "" {
+		dryRun, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			return nil, errors.ErrInvalidRequest
+		}
+		request.DryRun = dryRun
+	}
+
+	if err := utils.ParseRequestBody(r, request); err != nil {
+		return nil, errors.ErrInvalidRevocation.WithDetails(err.Error())
+	}
+	request.Reason = validation.RemoveControlCharacters(request.Reason)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// ValidateRevocationIDRequest validates a request with a revocation ID as URL parameter
+func ValidateRevocationIDRequest(r *http.Request) (interface{}, error) {
+	idResult := validation.ValidateURLParam(r, "revocationID", validation.DefaultValidationConfig())
+	if idResult.Error != nil {
+		return nil, idResult.Error
+	}
+
+	if _, err := uuid.Parse(idResult.Value); err != nil {
+		return nil, errors.ErrRevocationNotFound
+	}
+
+	return &RevocationIDRequestData{
+		ID: idResult.Value,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// FaultHandler injects faults into the calls to the database and Redis through the admin
+// API. Its routes are only served with fault_injection_enabled.
+type FaultHandler struct {
+	faults ports.FaultInjector
+}
+
+func NewFaultHandler(faults ports.FaultInjector) *FaultHandler {
+	return &FaultHandler{faults}
+}
+
+// ListFaults lists the faults in effect
+func (h *FaultHandler) ListFaults(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleListFaults, nil)
+}
+
+func (h *FaultHandler) handleListFaults(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.faults.Faults(), nil
+}
+
+// SetFault injects a fault into the calls to a target, replacing its previous fault
+func (h *FaultHandler) SetFault(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleSetFault,
+		ValidateFaultRequest,
+	)
+}
+
+func (h *FaultHandler) handleSetFault(ctx context.Context, req interface{}) (interface{}, error) {
+	faultReq := req.(*FaultRequestData)
+	return h.faults.SetFault(faultReq.Fault, faultReq.Duration), nil
+}
+
+// ClearFault stops injecting faults into the calls to a target
+func (h *FaultHandler) ClearFault(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleClearFault,
+		ValidateFaultTargetRequest,
+	)
+}
+
+func (h *FaultHandler) handleClearFault(ctx context.Context, req interface{}) (interface{}, error) {
+	h.faults.ClearFault(req.(*FaultTargetRequestData).Target)
+	return map[string]string{"status": "success"}, nil
+}
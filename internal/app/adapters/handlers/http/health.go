@@ -3,31 +3,56 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sync"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 )
 
+// HealthHandler reports liveness and readiness. cache is nil when the storage backend
+// does not use a cache, ha without active/standby mode.
 type HealthHandler struct {
-	db    *pgxpool.Pool
-	cache *redis.Client
+	db               ports.HealthChecker
+	cache            ports.HealthChecker
+	ha               ports.HAController
+	dbTimeout        time.Duration
+	cacheTimeout     time.Duration
+	degradedMode     bool // a failing cache reports degraded instead of not ready
+	degradedRenewals bool // a failing database reports degraded while the cache serves renewals
 }
 
-func NewHealthHandler(db *pgxpool.Pool, cache *redis.Client) *HealthHandler {
-	return &HealthHandler{db: db, cache: cache}
+func NewHealthHandler(db ports.HealthChecker, cache ports.HealthChecker, cfg *config.AppConfig, ha ports.HAController) *HealthHandler {
+	return &HealthHandler{
+		db:               db,
+		cache:            cache,
+		ha:               ha,
+		dbTimeout:        time.Duration(cfg.ReadinessDBTimeout) * time.Millisecond,
+		cacheTimeout:     time.Duration(cfg.ReadinessRedisTimeout) * time.Millisecond,
+		degradedMode:     cfg.ReadinessDegradedMode,
+		degradedRenewals: cfg.DegradedRenewalsEnabled,
+	}
 }
 
-// Health is a lightweight liveness check
+// Health is a lightweight liveness check, which also reports the role of the instance in
+// active/standby mode
 func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
-	utils.WriteResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+	health := map[string]string{"status": "ok"}
+	if h.ha != nil {
+		health["ha_role"] = h.ha.Status().Role
+	}
+	utils.WriteResponse(w, http.StatusOK, health)
 }
 
-// Readiness verifies dependencies (DB and Cache)
+// Readiness pings every dependency concurrently, each with its own timeout, and reports
+// per-component status and latency
 func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
-	if h.db == nil || h.cache == nil {
+	if h.db == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusServiceUnavailable)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -38,32 +63,72 @@ func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
-	defer cancel()
+	report := &models.ReadinessReport{Status: models.ReadinessReady}
+	if h.ha != nil {
+		report.HARole = h.ha.Status().Role
+	}
 
-	// Check DB
-	if err := h.db.Ping(ctx); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"type":    "internal_error",
-			"code":    "DATABASE_CHECK_FAILED",
-			"message": "Database health check failed",
-		})
-		return
+	var wg sync.WaitGroup
+	probe := func(name string, checker ports.HealthChecker, timeout time.Duration, required bool) *models.ComponentReadiness {
+		component := &models.ComponentReadiness{Name: name, Required: required}
+		report.Components = append(report.Components, component)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			component.Status, component.LatencyMs = ping(r.Context(), checker, timeout)
+		}()
+		return component
 	}
 
-	// Check Cache
-	if err := h.cache.Ping(ctx).Err(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"type":    "internal_error",
-			"code":    "REDIS_CHECK_FAILED",
-			"message": "Redis health check failed",
-		})
-		return
+	db := probe(models.HealthComponentDatabase, h.db, h.dbTimeout, !h.degradedRenewals || h.cache == nil)
+	var cache *models.ComponentReadiness
+	if h.cache != nil {
+		// The hybrid repositories fall back to the database when Redis is unavailable
+		cache = probe(models.HealthComponentRedis, h.cache, h.cacheTimeout, !h.degradedMode)
+	}
+	wg.Wait()
+
+	switch {
+	case db.Status != models.ComponentUp && (db.Required || cache.Status != models.ComponentUp):
+		report.Status = models.ReadinessNotReady
+		report.Code = "DATABASE_CHECK_FAILED"
+	case cache != nil && cache.Status != models.ComponentUp && cache.Required:
+		report.Status = models.ReadinessNotReady
+		report.Code = "REDIS_CHECK_FAILED"
+	case report.HARole == models.HARoleStandby:
+		// Load balancers send lease requests to the active instance only
+		report.Status = models.ReadinessNotReady
+		report.Code = domainErrors.ErrNotActive.Code
+	case db.Status != models.ComponentUp || (cache != nil && cache.Status != models.ComponentUp):
+		report.Status = models.ReadinessDegraded
+	}
+
+	status := http.StatusOK
+	if report.Status == models.ReadinessNotReady {
+		status = http.StatusServiceUnavailable
+	}
+	utils.WriteResponse(w, status, report)
+}
+
+// ping probes one dependency, returning its status and latency in milliseconds
+func ping(ctx context.Context, checker ports.HealthChecker, timeout time.Duration) (string, float64) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	utils.WriteResponse(w, http.StatusOK, map[string]string{"status": "ready"})
+	start := time.Now()
+	err := checker.Ping(ctx)
+	latency := float64(time.Since(start).Microseconds()) / 1000
+
+	switch {
+	case err == nil:
+		return models.ComponentUp, latency
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return models.ComponentTimeout, latency
+	default:
+		return models.ComponentDown, latency
+	}
 }
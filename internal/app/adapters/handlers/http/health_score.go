@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	httpMiddleware "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// HealthScoreHandler reports a composite health score so load balancers can shift traffic
+// away from a degraded instance before it fails outright
+type HealthScoreHandler struct {
+	db     ports.HealthChecker
+	cache  ports.HealthChecker // nil when the storage backend does not use a cache
+	stats  *httpMiddleware.RequestStats
+	policy *models.HealthScorePolicy
+}
+
+func NewHealthScoreHandler(db ports.HealthChecker, cache ports.HealthChecker, stats *httpMiddleware.RequestStats, cfg *config.AppConfig) *HealthScoreHandler {
+	policy := &models.HealthScorePolicy{
+		Threshold:          cfg.HealthScoreThreshold,
+		DBLatencyBudget:    time.Duration(cfg.HealthDBLatencyBudget) * time.Millisecond,
+		RedisLatencyBudget: time.Duration(cfg.HealthRedisLatencyBudget) * time.Millisecond,
+		ErrorRateBudget:    cfg.HealthErrorRateBudget,
+		MaxInFlight:        int64(cfg.HealthMaxInFlight),
+		DBWeight:           cfg.HealthWeightDB,
+		RedisWeight:        cfg.HealthWeightRedis,
+		ErrorRateWeight:    cfg.HealthWeightErrorRate,
+		SaturationWeight:   cfg.HealthWeightSaturation,
+	}
+	if cache == nil {
+		policy.RedisWeight = 0
+	}
+
+	return &HealthScoreHandler{db: db, cache: cache, stats: stats, policy: policy}
+}
+
+// Score samples dependency latency and request statistics and responds with the weighted
+// health score, using 503 when it falls below the configured threshold
+func (h *HealthScoreHandler) Score(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	signals := &models.HealthSignals{}
+	if h.db != nil {
+		start := time.Now()
+		signals.DBReachable = h.db.Ping(ctx) == nil
+		signals.DBLatency = time.Since(start)
+	}
+	if h.cache != nil {
+		start := time.Now()
+		signals.RedisReachable = h.cache.Ping(ctx) == nil
+		signals.RedisLatency = time.Since(start)
+	} else {
+		// Nothing to probe, the component carries no weight
+		signals.RedisReachable = true
+	}
+	if h.stats != nil {
+		signals.ErrorRate = h.stats.ErrorRate()
+		signals.InFlight = h.stats.InFlight()
+	}
+
+	score := h.policy.Score(signals)
+
+	status := http.StatusOK
+	if !score.Healthy {
+		status = http.StatusServiceUnavailable
+	}
+	utils.WriteResponse(w, status, utils.SuccessResponse{Data: score})
+}
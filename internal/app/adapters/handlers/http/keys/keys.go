@@ -1,5 +0,0 @@
-package keys
-
-const (
-	PeerIDContextKey = "peerID"
-)
@@ -2,48 +2,112 @@ package http
 
 import (
 	"context"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 )
 
+// LeaseHandler serves the lease API. Lookups and changes go to separate services, so a
+// read-only instance can refuse changes while answering lookups.
 type LeaseHandler struct {
-	leaseService ports.LeaseService
+	leaseReader ports.LeaseReader
+	leaseWriter ports.LeaseWriter
 }
 
-func NewLeaseHandler(leaseService ports.LeaseService) *LeaseHandler {
-	return &LeaseHandler{leaseService}
+func NewLeaseHandler(leaseReader ports.LeaseReader, leaseWriter ports.LeaseWriter) *LeaseHandler {
+	return &LeaseHandler{leaseReader, leaseWriter}
 }
 
 func (h *LeaseHandler) AllocateIP(w http.ResponseWriter, r *http.Request) {
 	sc := &ServiceCall{Handler: w, Request: r}
 	sc.ExecuteWithValidation(
 		h.handleAllocateIP,
-		ValidateLeaseRequest,
+		ValidateAllocateRequest,
 	)
 }
 
-func (h *LeaseHandler) GetLeaseByPeerID(w http.ResponseWriter, r *http.Request) {
+// TransferLease moves the caller's lease to a new peer identity
+func (h *LeaseHandler) TransferLease(w http.ResponseWriter, r *http.Request) {
 	sc := &ServiceCall{Handler: w, Request: r}
 	sc.ExecuteWithValidation(
-		h.handleGetLeaseByPeerID,
-		ValidatePeerIDParamRequest,
+		h.handleTransferLease,
+		ValidateTransferRequest,
 	)
 }
 
+// ReportConflict records that another node is using the caller's address
+func (h *LeaseHandler) ReportConflict(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleReportConflict,
+		ValidateConflictRequest,
+	)
+}
+
+func (h *LeaseHandler) GetLeaseByPeerID(w http.ResponseWriter, r *http.Request) {
+	h.serveLease(w, r, h.handleGetLeaseByPeerID, ValidatePeerIDParamRequest)
+}
+
 func (h *LeaseHandler) GetLeaseByTokenID(w http.ResponseWriter, r *http.Request) {
+	h.serveLease(w, r, h.handleGetLeaseByTokenID, ValidateTokenIDParamRequest)
+}
+
+// serveLease writes a looked up lease with its ETag and a Cache-Control max-age of its
+// remaining TTL. Requests whose If-None-Match names the ETag get 304 Not Modified.
+func (h *LeaseHandler) serveLease(w http.ResponseWriter, r *http.Request, handler HandlerFunc, validator func(*http.Request) (interface{}, error)) {
+	req, err := validator(r)
+	if err != nil {
+		utils.WriteDomainError(w, err)
+		return
+	}
+
+	result, err := handler(r.Context(), req)
+	if err != nil {
+		utils.WriteDomainError(w, err)
+		return
+	}
+
+	lease := result.(*models.Lease)
+	etag := utils.LeaseETag(lease)
+	maxAge := int(lease.TimeRemaining(time.Now()).Seconds())
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(maxAge))
+
+	if match := r.Header.Get("If-None-Match"); match != "" && utils.MatchesETag(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	utils.WriteSuccessResponse(w, lease)
+}
+
+// GetLeaseHistory returns every recorded event of a token ID, for debugging address conflicts
+func (h *LeaseHandler) GetLeaseHistory(w http.ResponseWriter, r *http.Request) {
 	sc := &ServiceCall{Handler: w, Request: r}
 	sc.ExecuteWithValidation(
-		h.handleGetLeaseByTokenID,
+		h.handleGetLeaseHistory,
 		ValidateTokenIDParamRequest,
 	)
 }
 
+// RenewLease extends the caller's lease. A renewal before the renewal window returns the
+// lease unchanged with a Retry-After header counting down to the window.
 func (h *LeaseHandler) RenewLease(w http.ResponseWriter, r *http.Request) {
 	sc := &ServiceCall{Handler: w, Request: r}
 	sc.ExecuteWithValidation(
-		h.handleRenewLease,
-		ValidateTokenIDRequest,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			result, err := h.handleRenewLease(ctx, req)
+			if lease, ok := result.(*models.Lease); ok && err == nil && lease.RenewableAt != nil {
+				retryAfter := int(math.Ceil(time.Until(*lease.RenewableAt).Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			}
+			return result, err
+		},
+		ValidateRenewRequest,
 	)
 }
 
@@ -58,30 +122,114 @@ func (h *LeaseHandler) ReleaseLease(w http.ResponseWriter, r *http.Request) {
 // Business logic handlers
 
 func (h *LeaseHandler) handleAllocateIP(ctx context.Context, req interface{}) (interface{}, error) {
-	leaseReq := req.(*LeaseRequestData)
-	return h.leaseService.AllocateIP(ctx, leaseReq.PeerID)
+	allocReq := req.(*AllocateRequestData)
+	if allocReq.RequestedTokenID != 0 {
+		return h.allocateRequestedIP(ctx, allocReq)
+	}
+
+	var lease *models.Lease
+	var err error
+	switch {
+	case allocReq.AffinityGroup != "":
+		// The group's leases already decide where the peer goes
+		lease, err = h.leaseWriter.AllocateAffinityIP(ctx, allocReq.PeerID, allocReq.AffinityGroup)
+	case allocReq.Zone != "":
+		lease, err = h.leaseWriter.AllocateZoneIP(ctx, allocReq.PeerID, allocReq.Zone)
+	default:
+		lease, err = h.leaseWriter.AllocateIP(ctx, allocReq.PeerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := h.setLabels(ctx, lease, allocReq.Labels); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (h *LeaseHandler) allocateRequestedIP(ctx context.Context, allocReq *AllocateRequestData) (*AllocateRequestedIPResponse, error) {
+	result, err := h.leaseWriter.AllocateRequestedIP(ctx, allocReq.PeerID, allocReq.RequestedTokenID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.setLabels(ctx, result.Lease, allocReq.Labels); err != nil {
+		return nil, err
+	}
+
+	return &AllocateRequestedIPResponse{
+		Lease:            result.Lease,
+		RequestedTokenID: result.RequestedTokenID,
+		Granted:          result.Granted,
+		Reason:           string(result.Reason),
+	}, nil
 }
 
 func (h *LeaseHandler) handleGetLeaseByPeerID(ctx context.Context, req interface{}) (interface{}, error) {
 	peerReq := req.(*PeerIDRequestData)
-	return h.leaseService.GetLeaseByPeerID(ctx, peerReq.PeerID)
+	return h.leaseReader.GetLeaseByPeerID(ctx, peerReq.PeerID)
 }
 
 func (h *LeaseHandler) handleGetLeaseByTokenID(ctx context.Context, req interface{}) (interface{}, error) {
 	tokenReq := req.(*TokenIDRequestData)
-	return h.leaseService.GetLeaseByTokenID(ctx, tokenReq.TokenID)
+	return h.leaseReader.GetLeaseByTokenID(ctx, tokenReq.TokenID)
 }
 
-func (h *LeaseHandler) handleRenewLease(ctx context.Context, req interface{}) (interface{}, error) {
+func (h *LeaseHandler) handleGetLeaseHistory(ctx context.Context, req interface{}) (interface{}, error) {
 	tokenReq := req.(*TokenIDRequestData)
-	return h.leaseService.RenewLease(ctx, tokenReq.TokenID, tokenReq.PeerID)
+	return h.leaseReader.GetLeaseHistory(ctx, tokenReq.TokenID)
+}
+
+func (h *LeaseHandler) handleRenewLease(ctx context.Context, req interface{}) (interface{}, error) {
+	renewReq := req.(*RenewRequestData)
+	lease, err := h.leaseWriter.RenewLease(ctx, renewReq.TokenID, renewReq.PeerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.setLabels(ctx, lease, renewReq.Labels); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// setLabels replaces the labels of a lease just allocated or renewed when the request
+// carried labels, and shows them on the returned lease
+func (h *LeaseHandler) setLabels(ctx context.Context, lease *models.Lease, labels map[string]string) error {
+	if labels == nil {
+		return nil
+	}
+	labels, err := h.leaseWriter.SetLeaseLabels(ctx, lease.TokenID, lease.PeerID, labels)
+	if err != nil {
+		return err
+	}
+	lease.Labels = labels
+	return nil
 }
 
 func (h *LeaseHandler) handleReleaseLease(ctx context.Context, req interface{}) (interface{}, error) {
 	tokenReq := req.(*TokenIDRequestData)
-	err := h.leaseService.ReleaseLease(ctx, tokenReq.TokenID, tokenReq.PeerID)
+	err := h.leaseWriter.ReleaseLease(ctx, tokenReq.TokenID, tokenReq.PeerID)
 	if err != nil {
 		return nil, err
 	}
-	return map[string]string{"status": "success"}, nil
+	return &StatusResponse{Status: "success"}, nil
+}
+
+func (h *LeaseHandler) handleTransferLease(ctx context.Context, req interface{}) (interface{}, error) {
+	transferReq := req.(*TransferRequestData)
+	return h.leaseWriter.TransferLease(ctx, &models.LeaseTransferRequest{
+		TokenID:    transferReq.TokenID,
+		FromPubkey: transferReq.FromPubkey,
+		ToPubkey:   transferReq.ToPubkey,
+		NonceID:    transferReq.NonceID,
+		Signature:  transferReq.Signature,
+	})
+}
+
+func (h *LeaseHandler) handleReportConflict(ctx context.Context, req interface{}) (interface{}, error) {
+	conflictReq := req.(*ConflictRequestData)
+	return h.leaseWriter.ReportConflict(ctx, &models.LeaseConflictReport{
+		TokenID:        conflictReq.TokenID,
+		PeerID:         conflictReq.PeerID,
+		ObservedPeerID: conflictReq.ObservedPeerID,
+	})
 }
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/query"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// leaseListSpec declares the parameters accepted by the lease listing
+var leaseListSpec = &query.Spec{
+	Fields: map[string]query.Field{
+		"token_id":       {Type: query.Int, Sortable: true, Operators: query.OrderedOperators},
+		"peer_id":        {Type: query.String, Operators: []models.FilterOperator{models.FilterEq}},
+		"affinity_group": {Type: query.String, Operators: query.TextOperators},
+		"label":          {Type: query.Label, Operators: []models.FilterOperator{models.FilterEq, models.FilterNe}},
+		"created_at":     {Type: query.Time, Sortable: true, Operators: query.OrderedOperators},
+		"updated_at":     {Type: query.Time, Sortable: true, Operators: query.OrderedOperators},
+		"expires_at":     {Type: query.Time, Sortable: true, Operators: query.OrderedOperators},
+	},
+	DefaultSort:  "token_id",
+	DefaultLimit: 50,
+	MaxLimit:     500,
+}
+
+// LeaseQueryHandler serves reporting endpoints backed by the lease read model
+type LeaseQueryHandler struct {
+	queryService ports.LeaseQueryService
+}
+
+func NewLeaseQueryHandler(queryService ports.LeaseQueryService) *LeaseQueryHandler {
+	return &LeaseQueryHandler{queryService}
+}
+
+func (h *LeaseQueryHandler) GetLeaseStats(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleGetLeaseStats, nil)
+}
+
+func (h *LeaseQueryHandler) handleGetLeaseStats(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.queryService.GetLeaseStats(ctx)
+}
+
+// ListLeases pages through the leases of the read model with sorting and filtering
+func (h *LeaseQueryHandler) ListLeases(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleListLeases,
+		ValidateLeaseListRequest,
+	)
+}
+
+func (h *LeaseQueryHandler) handleListLeases(ctx context.Context, req interface{}) (interface{}, error) {
+	opts := req.(*models.ListOptions)
+
+	page, err := h.queryService.ListLeases(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.HasMore {
+		last := page.Leases[len(page.Leases)-1]
+		page.NextCursor = query.EncodeCursor(opts, leaseSortValue(last, opts.SortField), last.TokenID)
+	}
+	return page, nil
+}
+
+// ValidateLeaseListRequest parses the pagination, sort and filter parameters of a lease listing
+func ValidateLeaseListRequest(r *http.Request) (interface{}, error) {
+	return leaseListSpec.Parse(r.URL.Query())
+}
+
+// SearchLeases finds leases by label selector and by the peer policy of their holders,
+// on top of the parameters of a listing
+func (h *LeaseQueryHandler) SearchLeases(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleSearchLeases,
+		ValidateLeaseSearchRequest,
+	)
+}
+
+func (h *LeaseQueryHandler) handleSearchLeases(ctx context.Context, req interface{}) (interface{}, error) {
+	search := req.(*models.LeaseSearch)
+
+	page, err := h.queryService.SearchLeases(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.HasMore {
+		last := page.Leases[len(page.Leases)-1]
+		page.NextCursor = query.EncodeCursor(&search.ListOptions, leaseSortValue(last.Lease, search.SortField), last.TokenID)
+	}
+	return page, nil
+}
+
+// ValidateLeaseSearchRequest parses the selector, policy and state parameters of a lease
+// search, and the others like those of a listing
+func ValidateLeaseSearchRequest(r *http.Request) (interface{}, error) {
+	values := maps.Clone(r.URL.Query())
+	search := &models.LeaseSearch{
+		State:  models.LeaseState(values.Get("state")),
+		Policy: values.Get("policy"),
+	}
+	selector := values.Get("selector")
+	for _, name := range []string{"selector", "policy", "state"} {
+		delete(values, name)
+	}
+
+	switch search.State {
+	case "":
+		search.State = models.LeaseStateActive
+	case models.LeaseStateActive, models.LeaseStateExpired, models.LeaseStateAll:
+	default:
+		return nil, domainErrors.ErrInvalidFilter.WithDetails(fmt.Sprintf("state must be %q, %q or %q", models.LeaseStateActive, models.LeaseStateExpired, models.LeaseStateAll))
+	}
+
+	opts, err := leaseListSpec.Parse(values)
+	if err != nil {
+		return nil, err
+	}
+	search.ListOptions = *opts
+
+	if selector != "" {
+		filters, err := models.ParseLabelSelector(selector)
+		if err != nil {
+			return nil, domainErrors.ErrInvalidFilter.WithDetails(err.Error())
+		}
+		search.Filters = append(search.Filters, filters...)
+	}
+	return search, nil
+}
+
+// leaseSortValue returns the value of a sortable lease field for the next page cursor
+func leaseSortValue(lease *models.Lease, field string) any {
+	switch field {
+	case "created_at":
+		return lease.CreatedAt
+	case "updated_at":
+		return lease.UpdatedAt
+	case "expires_at":
+		return lease.ExpiresAt
+	default:
+		return lease.TokenID
+	}
+}
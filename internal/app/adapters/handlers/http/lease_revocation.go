@@ -0,0 +1,46 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// LeaseRevocationHandler lets admins revoke the leases of compromised peers, address
+// ranges or labels in bulk
+type LeaseRevocationHandler struct {
+	revocations ports.LeaseRevocationService
+}
+
+func NewLeaseRevocationHandler(revocations ports.LeaseRevocationService) *LeaseRevocationHandler {
+	return &LeaseRevocationHandler{revocations: revocations}
+}
+
+// Revoke revokes the leases matching the filter of the JSON body, as a dry run unless
+// dryRun=false
+func (h *LeaseRevocationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRevoke,
+		ValidateLeaseRevocationRequest,
+	)
+}
+
+func (h *LeaseRevocationHandler) handleRevoke(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.revocations.Revoke(ctx, req.(*models.LeaseRevocationRequest))
+}
+
+// Revocation reports the progress of a revocation still running or recently finished
+func (h *LeaseRevocationHandler) Revocation(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRevocation,
+		ValidateRevocationIDRequest,
+	)
+}
+
+func (h *LeaseRevocationHandler) handleRevocation(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.revocations.Revocation(ctx, req.(*RevocationIDRequestData).ID)
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// LeaseWaitHandler long-polls for lease changes, for clients that cannot hold a stream open
+type LeaseWaitHandler struct {
+	leaseReader ports.LeaseReader
+	watcher     ports.LeaseWatcher
+	clock       ports.Clock
+	maxTimeout  time.Duration
+}
+
+func NewLeaseWaitHandler(leaseReader ports.LeaseReader, watcher ports.LeaseWatcher, clock ports.Clock, cfg *config.AppConfig) *LeaseWaitHandler {
+	return &LeaseWaitHandler{
+		leaseReader: leaseReader,
+		watcher:     watcher,
+		clock:       clock,
+		maxTimeout:  time.Duration(cfg.Lease.WaitMaxTimeout) * time.Second,
+	}
+}
+
+// Wait blocks until the lease of a token ID is allocated, renewed, released or expires,
+// or the timeout passes, and answers with the lease as it is then
+func (h *LeaseWaitHandler) Wait(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			result, err := h.handleWait(ctx, req)
+			if err == nil {
+				w.Header().Set("Cache-Control", "no-store")
+				if lease := result.(*LeaseWaitResponse).Lease; lease != nil {
+					w.Header().Set("ETag", utils.LeaseETag(lease))
+				}
+			}
+			return result, err
+		},
+		ValidateLeaseWaitRequest,
+	)
+}
+
+func (h *LeaseWaitHandler) handleWait(ctx context.Context, req interface{}) (interface{}, error) {
+	waitReq := req.(*LeaseWaitRequestData)
+
+	// Watch before the lookup, so no change after it is missed
+	events, stop := h.watcher.Watch(models.TenantFromContext(ctx), waitReq.TokenID)
+	defer stop()
+
+	lease, err := h.lookup(ctx, waitReq.TokenID)
+	if err != nil {
+		return nil, err
+	}
+	if waitReq.ETag != "" && (lease == nil || !utils.MatchesETag(waitReq.ETag, utils.LeaseETag(lease))) {
+		return &LeaseWaitResponse{Changed: true, Lease: lease}, nil
+	}
+
+	timeout := h.maxTimeout
+	if waitReq.Timeout > 0 {
+		timeout = min(waitReq.Timeout, timeout)
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// Expiry publishes no event until the lease is reclaimed, so the wait ends with it
+	var expired <-chan time.Time
+	if lease != nil {
+		expiry := time.NewTimer(lease.TimeRemaining(h.clock.Now()))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	select {
+	case event := <-events:
+		lease, err = h.lookup(ctx, waitReq.TokenID)
+		if err != nil {
+			return nil, err
+		}
+		return &LeaseWaitResponse{Changed: true, Event: event.Type, Lease: lease}, nil
+	case <-expired:
+		return &LeaseWaitResponse{Changed: true, Event: models.LeaseLifecycleExpired}, nil
+	case <-timer.C:
+		return &LeaseWaitResponse{Changed: false, Lease: lease}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lookup returns the active lease of tokenID, nil when there is none
+func (h *LeaseWaitHandler) lookup(ctx context.Context, tokenID int64) (*models.Lease, error) {
+	lease, err := h.leaseReader.GetLeaseByTokenID(ctx, tokenID)
+	if errors.Is(err, domainErrors.ErrLeaseNotFound) {
+		return nil, nil
+	}
+	return lease, err
+}
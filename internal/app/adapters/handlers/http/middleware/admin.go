@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// WithAdminToken middleware requires a matching "Authorization: Bearer <token>" header.
+// An empty configured token rejects every request.
+func WithAdminToken(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				utils.WriteDomainError(w, errors.ErrAdminUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithAdminAPIKey middleware admits requests whose X-API-Key header holds an admin API
+// key granting scope. Requests without an admin API key are left to the admin token
+// check, so either credential opens the route. A nil apiKeys only checks the token.
+func WithAdminAPIKey(token string, apiKeys ports.APIKeyService, scope models.APIKeyScope) func(next http.Handler) http.Handler {
+	withToken := WithAdminToken(token)
+	return func(next http.Handler) http.Handler {
+		byToken := withToken(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if apiKeys == nil || !strings.HasPrefix(key, models.AdminAPIKeyPrefix) {
+				byToken.ServeHTTP(w, r)
+				return
+			}
+
+			if _, err := apiKeys.Authorize(r.Context(), key, scope); err != nil {
+				utils.WriteDomainError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
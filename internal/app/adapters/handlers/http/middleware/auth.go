@@ -5,92 +5,130 @@ import (
 	"context"
 	"encoding/base64"
 	"net/http"
+	"strings"
 
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/keys"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/validation"
-	applicationUtils "github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
 )
 
-// WithAuth middleware validates the authentication headers and sets the peerID in the context
-func WithAuth(authService ports.AuthService) func(next http.Handler) http.Handler {
+// WithAuth middleware validates the authentication headers, or the credentials of a JSON
+// body decoded by WithRequestBody, refuses peers denied by access control and records the
+// peer and how it authenticated in the request context
+func WithAuth(authService ports.AuthService, accessControl ports.AccessControlService) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Validate headers using enhanced validation
-			pubkeyResult := validation.ValidateHeader(r, "X-Pubkey", validation.PubkeyValidationConfig())
+			// Credentials come from the JSON body decoded by WithRequestBody, or the headers
+			body := validation.RequestBodyFromContext(r)
+
+			pubkeyResult := validation.ValidateHeaderOrBody(r, "X-Pubkey", body.Pubkey, "pubkey", validation.PubkeyValidationConfig())
 			if pubkeyResult.Error != nil {
 				utils.WriteDomainError(w, pubkeyResult.Error)
 				return
 			}
 
-			nonceResult := validation.ValidateHeader(r, "X-Nonce", validation.NonceValidationConfig())
+			nonceResult := validation.ValidateHeaderOrBody(r, "X-Nonce", body.Nonce, "nonce", validation.NonceValidationConfig())
 			if nonceResult.Error != nil {
 				utils.WriteDomainError(w, nonceResult.Error)
 				return
 			}
 
-			signatureResult := validation.ValidateHeader(r, "X-Signature", validation.SignatureValidationConfig())
+			signatureResult := validation.ValidateHeaderOrBody(r, "X-Signature", body.Signature, "signature", validation.SignatureValidationConfig())
 			if signatureResult.Error != nil {
 				utils.WriteDomainError(w, signatureResult.Error)
 				return
 			}
 
-			// Validate and decode base64 data
-			pubkeyValidation := validation.ValidateBase64Pubkey(pubkeyResult.Value)
-			if pubkeyValidation.Error != nil {
-				utils.WriteDomainError(w, pubkeyValidation.Error)
-				return
-			}
-
-			signatureValidation := validation.ValidateBase64Signature(signatureResult.Value)
-			if signatureValidation.Error != nil {
-				utils.WriteDomainError(w, signatureValidation.Error)
-				return
-			}
-
-			// Decode the validated data
-			pub, err := base64.StdEncoding.DecodeString(pubkeyValidation.Value)
-			if err != nil {
-				utils.WriteDomainError(w, errors.ErrInvalidPubkey)
-				return
+			// Optional, verified against the acceptance window by the auth service
+			timestamp := strings.TrimSpace(body.Timestamp)
+			if timestamp == "" {
+				timestamp = strings.TrimSpace(r.Header.Get("X-Timestamp"))
 			}
 
-			sig, err := base64.StdEncoding.DecodeString(signatureValidation.Value)
-			if err != nil {
-				utils.WriteDomainError(w, errors.ErrInvalidSignature)
-				return
-			}
-
-			// Verify authentication
-			res, err := authService.VerifyAuth(r.Context(), &models.AuthVerifyRequest{
-				Pubkey:    pub,
-				NonceID:   nonceResult.Value,
-				Signature: sig,
-			})
+			peerID, err := Authenticate(r.Context(), authService, accessControl, pubkeyResult.Value, nonceResult.Value, signatureResult.Value, timestamp)
 			if err != nil {
 				utils.WriteDomainError(w, err)
 				return
 			}
 
-			if !bytes.Equal(res.Pubkey, pub) {
-				utils.WriteDomainError(w, errors.ErrPubkeyMismatch)
-				return
+			method := reqctx.AuthMethodHeaders
+			if body.Pubkey != "" {
+				method = reqctx.AuthMethodBody
 			}
-
-			// Set peerID to context
-			peerID, err := applicationUtils.GetPeerIDFromPubkey(res.Pubkey)
-			if err != nil {
-				// Convert libp2p errors to validation errors
-				utils.WriteDomainError(w, errors.ErrInvalidPubkey)
-				return
-			}
-			ctx := context.WithValue(r.Context(), keys.PeerIDContextKey, peerID)
+			ctx := reqctx.WithAuthMethod(reqctx.WithPeerID(r.Context(), peerID), method)
 			r = r.WithContext(ctx)
 
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// RequireCredentials rejects requests that carry none of the authentication headers with
+// 401 instead of the 400 WithAuth answers for incomplete credentials. It goes in front of
+// WithAuth on routes that are only protected in strict mode.
+func RequireCredentials(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Pubkey") == "" && r.Header.Get("X-Nonce") == "" && r.Header.Get("X-Signature") == "" {
+			utils.WriteDomainError(w, errors.ErrAuthenticationRequired)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Authenticate verifies base64-encoded credentials against a nonce and returns the peer ID
+// they prove ownership of, unless access control refuses it. The nonce is consumed once
+// the signature is verified. timestamp may be empty.
+func Authenticate(ctx context.Context, authService ports.AuthService, accessControl ports.AccessControlService, pubkey, nonceID, signature, timestamp string) (string, error) {
+	// Validate and decode base64 data
+	pubkeyValidation := validation.ValidateBase64Pubkey(pubkey)
+	if pubkeyValidation.Error != nil {
+		return "", pubkeyValidation.Error
+	}
+
+	signatureValidation := validation.ValidateBase64Signature(signature)
+	if signatureValidation.Error != nil {
+		return "", signatureValidation.Error
+	}
+
+	// Decode the validated data
+	pub, err := base64.StdEncoding.DecodeString(pubkeyValidation.Value)
+	if err != nil {
+		return "", errors.ErrInvalidPubkey
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signatureValidation.Value)
+	if err != nil {
+		return "", errors.ErrInvalidSignature
+	}
+
+	// Verify authentication
+	res, err := authService.VerifyAuth(ctx, &models.AuthVerifyRequest{
+		Pubkey:    pub,
+		NonceID:   nonceID,
+		Signature: sig,
+		Timestamp: timestamp,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !bytes.Equal(res.Pubkey, pub) {
+		return "", errors.ErrPubkeyMismatch
+	}
+
+	// The identity scheme of the auth service decides what the key authenticates as
+	if res.PeerID == "" {
+		return "", errors.ErrInvalidPubkey
+	}
+
+	// Deny and allow lists apply before any service sees the peer
+	if err := accessControl.CheckAccess(ctx, res.PeerID, pub); err != nil {
+		return "", err
+	}
+
+	return res.PeerID, nil
+}
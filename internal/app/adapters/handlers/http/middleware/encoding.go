@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// NegotiateEncoding middleware encodes the responses of the request in the media type its
+// Accept header prefers: JSON by default, CBOR, or protobuf for the responses that have a
+// message. Requests accepting none of them are refused with 406 NOT_ACCEPTABLE.
+func NegotiateEncoding(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		header := r.Header.Get("Accept")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		accept := utils.ParseAccept(header)
+		if accept.Negotiate(utils.MediaTypeJSON, utils.MediaTypeCBOR, utils.MediaTypeProtobuf) == "" {
+			utils.WriteDomainError(w, errors.ErrNotAcceptable.WithDetails("Accept: "+header))
+			return
+		}
+		next.ServeHTTP(utils.WithAccept(w, accept), r)
+	})
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client chosen key of a retryable request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	defaultIdempotencyClaim = time.Minute
+)
+
+// Idempotency replays the first response to a request carrying an Idempotency-Key to
+// retries of the same peer with the same key, so a retried allocation does not end up
+// with a second lease. A retry that does not accept the media type of the first response
+// is refused with 406 NOT_ACCEPTABLE instead of running again. It goes behind WithAuth,
+// which provides the peer ID.
+type Idempotency struct {
+	store    ports.IdempotencyStore
+	window   time.Duration
+	claimTTL time.Duration // how long a request may hold its key before a retry runs again
+	logger   *zap.Logger
+}
+
+func NewIdempotency(cfg *config.AppConfig, store ports.IdempotencyStore, logger *zap.Logger) *Idempotency {
+	claimTTL := time.Duration(cfg.Server.RequestTimeout) * time.Second
+	if claimTTL <= 0 {
+		claimTTL = defaultIdempotencyClaim
+	}
+
+	return &Idempotency{
+		store:    store,
+		window:   time.Duration(cfg.Lease.IdempotencyWindow) * time.Minute,
+		claimTTL: claimTTL,
+		logger:   logger,
+	}
+}
+
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || i.window <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			utils.WriteDomainError(w, domainErrors.ErrInvalidIdempotencyKey)
+			return
+		}
+
+		// Keys are scoped to the tenant, the peer and the operation
+		peerID, _ := reqctx.PeerID(r.Context())
+		storeKey := models.TenantKeyPrefix(r.Context()) + peerID + ":" + r.URL.Path + ":" + key
+
+		stored, err := i.store.Claim(r.Context(), storeKey, i.claimTTL)
+		switch {
+		case errors.Is(err, domainErrors.ErrIdempotencyInProgress):
+			utils.WriteDomainError(w, err)
+			return
+		case err != nil:
+			// Serving the request beats failing it because the store is unavailable
+			i.logger.Warn("Failed to claim idempotency key, serving without replay protection", zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		case stored != nil:
+			if !acceptsStored(r, stored) {
+				utils.WriteDomainError(w, domainErrors.ErrNotAcceptable.WithDetails("the original response is "+stored.ContentType))
+				return
+			}
+			w.Header().Set("Content-Type", stored.ContentType)
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(stored.Status)
+			w.Write(stored.Body)
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The request context may have timed out, the outcome must still be stored
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= http.StatusInternalServerError {
+			// Server errors are not final, a retry runs the request again
+			if err := i.store.Release(ctx, storeKey); err != nil {
+				i.logger.Warn("Failed to release idempotency key", zap.Error(err))
+			}
+			return
+		}
+
+		response := &models.IdempotentResponse{
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}
+		if err := i.store.Complete(ctx, storeKey, response, i.window); err != nil {
+			i.logger.Warn("Failed to store idempotent response", zap.Error(err))
+		}
+	})
+}
+
+// acceptsStored reports whether the Accept header of r takes the media type of a stored
+// response
+func acceptsStored(r *http.Request, stored *models.IdempotentResponse) bool {
+	mediaType, _, err := mime.ParseMediaType(stored.ContentType)
+	if err != nil {
+		return true
+	}
+	return utils.ParseAccept(r.Header.Get("Accept")).Quality(mediaType) > 0
+}
+
+// validIdempotencyKey accepts up to 255 visible ASCII characters
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingWriter passes a response through while keeping a copy of it
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
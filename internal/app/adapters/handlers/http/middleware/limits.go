@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// RequestLimits bounds how long a request may take and how large its body may be
+type RequestLimits struct {
+	timeout     time.Duration
+	overrides   map[string]time.Duration // lower-cased route pattern -> timeout
+	maxBodySize int64
+}
+
+// NewRequestLimits creates request limits from the configured timeouts and body size
+func NewRequestLimits(cfg *config.AppConfig) *RequestLimits {
+	overrides := make(map[string]time.Duration, len(cfg.Server.RequestTimeoutOverrides))
+	for pattern, seconds := range cfg.Server.RequestTimeoutOverrides {
+		// Viper lower-cases map keys, so patterns are matched without case
+		overrides[strings.ToLower(pattern)] = time.Duration(seconds) * time.Second
+	}
+
+	return &RequestLimits{
+		timeout:     time.Duration(cfg.Server.RequestTimeout) * time.Second,
+		overrides:   overrides,
+		maxBodySize: cfg.Server.MaxRequestBodySize,
+	}
+}
+
+// Middleware applies the timeout of the matched route to the request context and to
+// reading the request body, and caps the body size. Error responses written because a
+// limit was hit are replaced by 408 or 413.
+func (l *RequestLimits) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.maxBodySize > 0 && r.ContentLength > l.maxBodySize {
+			utils.WriteDomainError(w, domainErrors.ErrRequestTooLarge)
+			return
+		}
+
+		ctx := r.Context()
+		if timeout := l.timeoutFor(r); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			// The context does not interrupt body reads, so a client trickling its body
+			// would otherwise keep the handler waiting
+			_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(timeout))
+		}
+
+		lw := &limitWriter{ResponseWriter: w, ctx: ctx}
+		if r.Body != nil && r.Body != http.NoBody {
+			body := r.Body
+			if l.maxBodySize > 0 {
+				body = http.MaxBytesReader(w, body, l.maxBodySize)
+			}
+			r.Body = &limitBody{ReadCloser: body, w: lw}
+		}
+
+		next.ServeHTTP(lw, r.WithContext(ctx))
+
+		// The handler gave up without answering
+		if !lw.wroteHeader {
+			if err := lw.limitError(); err != nil {
+				utils.WriteDomainError(w, err)
+			}
+		}
+	})
+}
+
+// timeoutFor returns the timeout of the route the request is routed to
+func (l *RequestLimits) timeoutFor(r *http.Request) time.Duration {
+	if len(l.overrides) == 0 {
+		return l.timeout
+	}
+
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return l.timeout
+	}
+	pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+	if timeout, ok := l.overrides[strings.ToLower(pattern)]; ok {
+		return timeout
+	}
+	return l.timeout
+}
+
+// limitWriter replaces error responses caused by a hit limit with the limit's error
+type limitWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	bodyErr     error // set when reading the body failed on a limit
+	wroteHeader bool
+	replaced    bool // the handler's response is discarded
+}
+
+func (w *limitWriter) limitError() error {
+	if w.bodyErr != nil {
+		return w.bodyErr
+	}
+	if errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		return domainErrors.ErrRequestTimeout
+	}
+	return nil
+}
+
+func (w *limitWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	// Successful responses pass, the work they report was done
+	if status >= http.StatusBadRequest {
+		if err := w.limitError(); err != nil {
+			w.replaced = true
+			utils.WriteDomainError(w.ResponseWriter, err)
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *limitWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *limitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// limitBody records body read errors caused by the size limit or the read deadline
+type limitBody struct {
+	io.ReadCloser
+	w *limitWriter
+}
+
+func (b *limitBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		b.w.bodyErr = domainErrors.ErrRequestTooLarge
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		b.w.bodyErr = domainErrors.ErrRequestTimeout
+	}
+	return n, err
+}
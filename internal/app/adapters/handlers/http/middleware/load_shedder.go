@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// loadShedRetryAfter is how long refused clients are asked to wait, a saturated server
+// usually catches up within a second
+const loadShedRetryAfter = time.Second
+
+// LoadShedder bounds the requests served at once, so a spike queues in front of the
+// server instead of exhausting the database connection pool. A request that finds no free
+// slot within the queue timeout is refused with 503 SERVER_BUSY.
+type LoadShedder struct {
+	slots        chan struct{} // nil without a limit
+	queueTimeout time.Duration
+	shed         atomic.Int64 // requests refused since start
+}
+
+// NewLoadShedder creates a load shedder with the configured limit and queue timeout
+func NewLoadShedder(cfg *config.AppConfig) *LoadShedder {
+	ls := &LoadShedder{
+		queueTimeout: time.Duration(cfg.Server.QueueTimeout) * time.Millisecond,
+	}
+	if cfg.Server.MaxInFlight > 0 {
+		ls.slots = make(chan struct{}, cfg.Server.MaxInFlight)
+	}
+	return ls
+}
+
+// Middleware serves a request once it holds a slot. Health checks, admin routes and
+// lease waits are served without one: probes and operators must get through to a
+// saturated server, and waits block on lease events rather than on the database.
+func (ls *LoadShedder) Middleware(next http.Handler) http.Handler {
+	if ls.slots == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exemptFromLoadShedding(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !ls.acquire(r) {
+			ls.shed.Add(1)
+			w.Header().Set("Retry-After", retryAfterSeconds(loadShedRetryAfter))
+			utils.WriteDomainError(w, errors.ErrServerBusy)
+			return
+		}
+		defer func() { <-ls.slots }()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acquire takes a slot, waiting up to the queue timeout or until the request is canceled
+func (ls *LoadShedder) acquire(r *http.Request) bool {
+	select {
+	case ls.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if ls.queueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(ls.queueTimeout)
+	defer timer.Stop()
+	select {
+	case ls.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func exemptFromLoadShedding(path string) bool {
+	switch {
+	case path == "/health", path == "/ready", strings.HasPrefix(path, "/healthz/"):
+		return true
+	case strings.HasPrefix(path, "/v1/admin/"), strings.HasPrefix(path, "/dashboard"):
+		return true
+	case strings.HasPrefix(path, "/v1/lease/") && strings.HasSuffix(path, "/wait"):
+		return true
+	}
+	return false
+}
+
+// Shed returns the number of requests refused since start
+func (ls *LoadShedder) Shed() int64 {
+	return ls.shed.Load()
+}
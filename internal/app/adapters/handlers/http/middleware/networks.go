@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/clientip"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// AllowNetworks middleware refuses requests from clients outside the given CIDR blocks
+// and addresses with 403 NETWORK_NOT_ALLOWED. clientIP resolves the client address, e.g.
+// through trusted proxies. An empty list allows every client.
+func AllowNetworks(allowed []string, clientIP func(r *http.Request) string) func(next http.Handler) http.Handler {
+	networks := clientip.ParseNetworks(allowed)
+	return func(next http.Handler) http.Handler {
+		if len(networks) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !networks.Contains(clientIP(r)) {
+				utils.WriteDomainError(w, errors.ErrNetworkNotAllowed)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// redacted replaces the value of a header, query parameter or JSON field holding a credential
+const redacted = "[REDACTED]"
+
+type payloadLogSettings struct {
+	enabled  bool
+	limiter  *rate.Limiter
+	maxBytes int
+}
+
+func newPayloadLogSettings(cfg *config.AppConfig) *payloadLogSettings {
+	return &payloadLogSettings{
+		enabled:  cfg.Log.PayloadsEnabled,
+		limiter:  rate.NewLimiter(rate.Limit(cfg.Log.PayloadsPerSecond), max(cfg.Log.PayloadsPerSecond, 1)),
+		maxBytes: cfg.Log.PayloadMaxBytes,
+	}
+}
+
+// PayloadLog logs the headers and bodies of requests and responses to diagnose client
+// integrations. Signatures, public keys, API keys and tokens are redacted, and at most
+// log.payloads_per_second requests are logged; the others are served without logging.
+type PayloadLog struct {
+	settings atomic.Pointer[payloadLogSettings]
+	logger   *zap.Logger
+}
+
+func NewPayloadLog(cfg *config.AppConfig, logger *zap.Logger) *PayloadLog {
+	p := &PayloadLog{logger: logger.Named("payload")}
+	p.settings.Store(newPayloadLogSettings(cfg))
+	return p
+}
+
+// ApplyConfig switches payload logging on or off and applies the reloaded limits
+func (p *PayloadLog) ApplyConfig(cfg *config.AppConfig) {
+	p.settings.Store(newPayloadLogSettings(cfg))
+}
+
+func (p *PayloadLog) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings := p.settings.Load()
+		if !settings.enabled || !settings.limiter.Allow() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The body is bounded by RequestLimits, handlers read the copy
+		requestBody, err := io.ReadAll(r.Body)
+		if err != nil {
+			p.logger.Warn("Failed to read request body for payload logging", zap.Error(err))
+		}
+		r.Body = io.NopCloser(bytes.NewReader(requestBody))
+
+		responseBody := &limitedBuffer{limit: settings.maxBytes}
+		ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(responseBody)
+
+		start := time.Now()
+		next.ServeHTTP(ww, r)
+
+		requestID, _ := reqctx.RequestID(r.Context())
+		p.logger.Info("HTTP payload",
+			zap.String("requestID", requestID),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.String("query", redactQuery(r.URL.Query())),
+			zap.Int("status", ww.Status()),
+			zap.Duration("duration", time.Since(start)),
+			zap.Any("requestHeaders", redactHeaders(r.Header)),
+			zap.String("requestBody", payload(r.Header.Get("Content-Type"), requestBody, len(requestBody) > settings.maxBytes)),
+			zap.Any("responseHeaders", redactHeaders(ww.Header())),
+			zap.String("responseBody", payload(ww.Header().Get("Content-Type"), responseBody.Bytes(), responseBody.truncated)),
+		)
+	})
+}
+
+// limitedBuffer keeps the first limit bytes written to it
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// payload renders a body for the log. Only JSON bodies are logged, with their credentials
+// redacted; a body that cannot be redacted, because it is cut off or malformed, is
+// described by its size only.
+func payload(contentType string, body []byte, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	describe := func(reason string) string {
+		return "[" + strconv.Itoa(len(body)) + " bytes, " + reason + "]"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType != "application/json":
+		return describe(valueOr(mediaType, "no content type"))
+	case truncated:
+		return describe("larger than log.payload_max_bytes")
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return describe("malformed JSON")
+	}
+	redacted, _ := json.Marshal(redactJSON(value))
+	return string(redacted)
+}
+
+// redactJSON replaces the values of credential fields at any depth
+func redactJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if sensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	}
+	return value
+}
+
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitive(name) {
+			headers[name] = redacted
+		} else {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+func redactQuery(query url.Values) string {
+	for name := range query {
+		if sensitive(name) {
+			query[name] = []string{redacted}
+		}
+	}
+	return query.Encode()
+}
+
+// sensitive reports whether a header, query parameter or JSON field carries a credential:
+// signatures, public keys, API keys, tokens other than token IDs, and cookies
+func sensitive(name string) bool {
+	name = strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	switch name {
+	case "authorization", "cookie", "set_cookie", "key", "token":
+		return true
+	}
+	for _, part := range []string{"signature", "pubkey", "public_key", "secret", "password"} {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "_key") || strings.HasSuffix(name, "_keys") || strings.HasSuffix(name, "_token")
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -1,37 +1,148 @@
 package middleware
 
 import (
-	"net"
+	"container/list"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/clientip"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 )
 
-// RateLimiter manages rate limiting for HTTP requests
+const (
+	defaultLimiterIdleTimeout = 10 * time.Minute
+	defaultLimiterMaxEntries  = 10000
+)
+
+// limiterEntry is a per-client limiter of a class together with its last access time
+type limiterEntry struct {
+	key        string
+	class      string
+	limiter    *rate.Limiter
+	lastAccess time.Time
+}
+
+// rateLimitClass is the limit of a group of routes
+type rateLimitClass struct {
+	name      string // empty for the global limit
+	perMinute int
+	burst     int
+}
+
+// limit converts requests per minute to the token bucket rate
+func (c *rateLimitClass) limit() rate.Limit {
+	return rate.Limit(float64(c.perMinute) / 60.0)
+}
+
+// rateLimitSettings are the reloadable rate limiting settings
+type rateLimitSettings struct {
+	enabled        bool
+	global         *rateLimitClass
+	classes        map[string]*rateLimitClass // by name
+	routes         map[string]*rateLimitClass // lower-cased route pattern -> class
+	trustedProxies clientip.Networks
+}
+
+func newRateLimitSettings(cfg *config.AppConfig) *rateLimitSettings {
+	settings := &rateLimitSettings{
+		enabled: cfg.RateLimit.Enabled,
+		global: &rateLimitClass{
+			perMinute: cfg.RateLimit.RequestsPerMinute,
+			burst:     cfg.RateLimit.Burst,
+		},
+		classes:        make(map[string]*rateLimitClass, len(cfg.RateLimit.Classes)),
+		routes:         make(map[string]*rateLimitClass),
+		trustedProxies: clientip.ParseNetworks(cfg.RateLimit.TrustedProxies),
+	}
+	for _, classCfg := range cfg.RateLimit.Classes {
+		class := &rateLimitClass{name: classCfg.Name}
+		class.perMinute, class.burst = cfg.RateLimit.ClassLimits(classCfg)
+		settings.classes[class.name] = class
+		for _, route := range classCfg.Routes {
+			settings.routes[strings.ToLower(route)] = class
+		}
+	}
+	return settings
+}
+
+// class returns the class with the given name, or the global limit when it is gone
+func (s *rateLimitSettings) class(name string) *rateLimitClass {
+	if class, ok := s.classes[name]; ok {
+		return class
+	}
+	return s.global
+}
+
+// classFor returns the class of the route the request is routed to
+func (s *rateLimitSettings) classFor(r *http.Request) *rateLimitClass {
+	if len(s.routes) == 0 {
+		return s.global
+	}
+
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return s.global
+	}
+	pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+	if class, ok := s.routes[strings.ToLower(pattern)]; ok {
+		return class
+	}
+	return s.global
+}
+
+// RateLimiter manages rate limiting for HTTP requests. Routes in a rate limit class are
+// limited by a separate bucket per client. With a ban service, clients that keep
+// exceeding the limit are banned for a while, and so are authenticated peers (see
+// PeerMiddleware).
 type RateLimiter struct {
-	config        *config.AppConfig
+	settings      atomic.Pointer[rateLimitSettings]
+	bans          ports.BanService // nil unless rate_limit.ban_enabled
 	logger        *zap.Logger
-	limiters      sync.Map // map[string]*rate.Limiter
+	mu            sync.Mutex
+	limiters      map[string]*list.Element // class and client IP or peer -> element in lru
+	lru           *list.List               // front is the most recently used entry
+	idleTimeout   time.Duration
+	maxEntries    int
 	cleanupTicker *time.Ticker
 	stopCleanup   chan struct{}
+	rejected      atomic.Int64 // requests refused since start
 }
 
-// NewRateLimiter creates a new rate limiter instance
-func NewRateLimiter(cfg *config.AppConfig, logger *zap.Logger) *RateLimiter {
+// NewRateLimiter creates a new rate limiter instance. bans may be nil.
+func NewRateLimiter(cfg *config.AppConfig, bans ports.BanService, logger *zap.Logger) *RateLimiter {
+	idleTimeout := time.Duration(cfg.RateLimit.IdleTimeout) * time.Minute
+	if idleTimeout <= 0 {
+		idleTimeout = defaultLimiterIdleTimeout
+	}
+	maxEntries := cfg.RateLimit.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultLimiterMaxEntries
+	}
+
 	rl := &RateLimiter{
-		config:      cfg,
-		logger:      logger,
+		bans:        bans,
+		logger:      logger.Named("ratelimit"),
+		limiters:    make(map[string]*list.Element),
+		lru:         list.New(),
+		idleTimeout: idleTimeout,
+		maxEntries:  maxEntries,
 		stopCleanup: make(chan struct{}),
 	}
+	rl.settings.Store(newRateLimitSettings(cfg))
 
 	// Start cleanup goroutine to remove unused limiters
 	rl.startCleanup()
@@ -39,9 +150,31 @@ func NewRateLimiter(cfg *config.AppConfig, logger *zap.Logger) *RateLimiter {
 	return rl
 }
 
+// ApplyConfig switches to reloaded rate limiting settings. Tracked clients keep their
+// buckets with the new rate and burst of their class; buckets of a removed class take the
+// global limit until they are evicted.
+func (rl *RateLimiter) ApplyConfig(cfg *config.AppConfig) {
+	settings := newRateLimitSettings(cfg)
+	rl.settings.Store(settings)
+
+	now := time.Now()
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for elem := rl.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*limiterEntry)
+		class := settings.class(entry.class)
+		entry.limiter.SetLimitAt(now, class.limit())
+		entry.limiter.SetBurstAt(now, class.burst)
+	}
+}
+
 // startCleanup starts a background goroutine to clean up unused limiters
 func (rl *RateLimiter) startCleanup() {
-	rl.cleanupTicker = time.NewTicker(5 * time.Minute)
+	interval := rl.idleTimeout
+	if interval > 5*time.Minute {
+		interval = 5 * time.Minute
+	}
+	rl.cleanupTicker = time.NewTicker(interval)
 	go func() {
 		for {
 			select {
@@ -65,162 +198,252 @@ func (rl *RateLimiter) Stop() {
 	}
 }
 
-// cleanupUnusedLimiters removes limiters that haven't been used recently
+// cleanupUnusedLimiters removes limiters that have been idle for longer than the idle timeout
 func (rl *RateLimiter) cleanupUnusedLimiters() {
-	// Simple cleanup: remove all limiters periodically
-	// In a production system, you'd want to track last access time per limiter
-	// For now, we'll just clear all limiters every 5 minutes to prevent memory leaks
-	rl.limiters.Range(func(key, value interface{}) bool {
-		rl.limiters.Delete(key)
-		return true
-	})
-}
+	cutoff := time.Now().Add(-rl.idleTimeout)
 
-// extractClientIP extracts the client IP from the request, considering proxy headers
-func (rl *RateLimiter) extractClientIP(r *http.Request) string {
-	// Check X-Real-IP header first (if from trusted proxy)
-	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
-		if rl.isTrustedProxy(r.RemoteAddr) {
-			if ip := rl.parseIP(realIP); ip != "" {
-				return ip
-			}
-		}
-	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 
-	// Check X-Forwarded-For header (if from trusted proxy)
-	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
-		if rl.isTrustedProxy(r.RemoteAddr) {
-			// X-Forwarded-For can contain multiple IPs, take the first one
-			ips := strings.Split(forwardedFor, ",")
-			if len(ips) > 0 {
-				if ip := rl.parseIP(strings.TrimSpace(ips[0])); ip != "" {
-					return ip
-				}
-			}
+	// The list is ordered by access time, so stop at the first entry that is still fresh
+	removed := 0
+	for elem := rl.lru.Back(); elem != nil; elem = rl.lru.Back() {
+		entry := elem.Value.(*limiterEntry)
+		if entry.lastAccess.After(cutoff) {
+			break
 		}
+		rl.removeElement(elem)
+		removed++
 	}
 
-	// Fallback to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		// If SplitHostPort fails, try parsing as IP directly
-		if net.ParseIP(r.RemoteAddr) != nil {
-			return r.RemoteAddr
-		}
-		return "unknown"
+	if removed > 0 {
+		rl.logger.Debug("Evicted idle rate limiters", zap.Int("removed", removed), zap.Int("remaining", rl.lru.Len()))
 	}
-	return ip
 }
 
-// isTrustedProxy checks if the given IP is in the list of trusted proxies
-func (rl *RateLimiter) isTrustedProxy(proxyIP string) bool {
-	if len(rl.config.RateLimitTrustedProxies) == 0 {
-		return false
-	}
-
-	ip, _, err := net.SplitHostPort(proxyIP)
-	if err != nil {
-		ip = proxyIP
-	}
+// removeElement removes an entry from both the index and the LRU list. Caller must hold rl.mu.
+func (rl *RateLimiter) removeElement(elem *list.Element) {
+	entry := rl.lru.Remove(elem).(*limiterEntry)
+	delete(rl.limiters, entry.key)
+}
 
-	for _, trustedProxy := range rl.config.RateLimitTrustedProxies {
-		if trustedProxy == ip {
-			return true
-		}
-		// Check if it's a CIDR block
-		if _, network, err := net.ParseCIDR(trustedProxy); err == nil {
-			if network.Contains(net.ParseIP(ip)) {
-				return true
-			}
-		}
-	}
-	return false
+// ClientIP returns the IP address of the client that sent r, following the forwarding
+// headers of the configured trusted proxies
+func (rl *RateLimiter) ClientIP(r *http.Request) string {
+	return clientip.Resolve(r, rl.settings.Load().trustedProxies)
 }
 
-// parseIP validates and returns a valid IP address
-func (rl *RateLimiter) parseIP(ipStr string) string {
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		return ""
+// getOrCreateLimiter gets an existing limiter for the client IP or peer in the class or
+// creates a new one
+func (rl *RateLimiter) getOrCreateLimiter(class *rateLimitClass, client string) *rate.Limiter {
+	now := time.Now()
+	key := client
+	if class.name != "" {
+		key = class.name + " " + client
 	}
-	return ip.String()
-}
 
-// getOrCreateLimiter gets an existing limiter for the IP or creates a new one
-func (rl *RateLimiter) getOrCreateLimiter(clientIP string) *rate.Limiter {
-	// Try to load existing limiter first
-	if value, exists := rl.limiters.Load(clientIP); exists {
-		return value.(*rate.Limiter)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Reuse the existing limiter and mark it as most recently used
+	if elem, exists := rl.limiters[key]; exists {
+		entry := elem.Value.(*limiterEntry)
+		entry.lastAccess = now
+		rl.lru.MoveToFront(elem)
+		return entry.limiter
 	}
 
 	// Create new limiter with token bucket algorithm
 	// Rate is requests per minute, burst is the maximum burst capacity
-	ratePerSecond := float64(rl.config.RateLimitRequestsPerMinute) / 60.0
-	newLimiter := rate.NewLimiter(rate.Limit(ratePerSecond), rl.config.RateLimitBurst)
+	entry := &limiterEntry{
+		key:        key,
+		class:      class.name,
+		limiter:    rate.NewLimiter(class.limit(), class.burst),
+		lastAccess: now,
+	}
+	rl.limiters[key] = rl.lru.PushFront(entry)
 
-	// Try to store the new limiter, but if another goroutine beat us to it,
-	// use the existing one
-	if actual, loaded := rl.limiters.LoadOrStore(clientIP, newLimiter); loaded {
-		// Another goroutine created a limiter, use that one
-		return actual.(*rate.Limiter)
+	// Evict the least recently used limiters when over capacity
+	for rl.lru.Len() > rl.maxEntries {
+		rl.removeElement(rl.lru.Back())
 	}
 
-	// Our limiter was stored successfully
-	return newLimiter
+	return entry.limiter
+}
+
+// size returns the number of tracked limiters
+func (rl *RateLimiter) size() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.lru.Len()
 }
 
 // Allow checks if the request should be allowed based on rate limiting
 func (rl *RateLimiter) Allow(r *http.Request) (allowed bool, retryAfter time.Duration, remaining int) {
-	if !rl.config.RateLimitEnabled {
-		return true, 0, rl.config.RateLimitRequestsPerMinute
+	settings := rl.settings.Load()
+	allowed, retryAfter, remaining, _ = rl.allow(r, settings, settings.classFor(r))
+	return allowed, retryAfter, remaining
+}
+
+// allow takes a token from the client's bucket of the class. A refused request takes
+// nothing, so a client waiting retryAfter is served. reset is when the bucket is full again.
+func (rl *RateLimiter) allow(r *http.Request, settings *rateLimitSettings, class *rateLimitClass) (allowed bool, retryAfter time.Duration, remaining int, reset time.Time) {
+	now := time.Now()
+	if !settings.enabled {
+		return true, 0, class.perMinute, now
 	}
 
-	clientIP := rl.extractClientIP(r)
-	limiter := rl.getOrCreateLimiter(clientIP)
+	limiter := rl.getOrCreateLimiter(class, rl.ClientIP(r))
+	allowed = limiter.AllowN(now, 1)
 
-	// Check if request is allowed
-	now := time.Now()
-	if !limiter.AllowN(now, 1) {
-		// Rate limit exceeded - calculate when next token will be available
-		reservation := limiter.ReserveN(now, 1)
-		retryAfter = reservation.DelayFrom(now)
-		return false, retryAfter, 0
+	tokens := limiter.TokensAt(now)
+	reset = now.Add(tokenDelay(limiter, float64(limiter.Burst())-tokens))
+	if !allowed {
+		// Rate limit exceeded - the next token is available once the bucket refilled to one
+		return false, tokenDelay(limiter, 1-tokens), 0, reset
 	}
 
 	// Calculate remaining tokens
-	tokens := limiter.TokensAt(now)
 	remaining = int(tokens)
 	if remaining < 0 {
 		remaining = 0
 	}
 
-	return true, 0, remaining
+	return true, 0, remaining, reset
 }
 
-// RateLimitMiddleware creates a middleware that enforces rate limiting
-func RateLimitMiddleware(cfg *config.AppConfig, logger *zap.Logger) func(next http.Handler) http.Handler {
-	rateLimiter := NewRateLimiter(cfg, logger)
+// tokenDelay returns how long the limiter takes to refill the given number of tokens
+func tokenDelay(limiter *rate.Limiter, tokens float64) time.Duration {
+	if tokens <= 0 || limiter.Limit() <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / float64(limiter.Limit()) * float64(time.Second))
+}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			allowed, retryAfter, remaining := rateLimiter.Allow(r)
+// retryAfterSeconds formats a delay for the Retry-After header, rounded up so a client
+// waiting that long is served, and at least one second
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(d.Seconds()))))
+}
 
-			// Add rate limit headers
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(cfg.RateLimitRequestsPerMinute))
-			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+// Middleware enforces rate limiting and adds the rate limit headers
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ban := rl.checkBan(r, rl.ClientIP(r)); ban != nil {
+			rl.rejected.Add(1)
+			rl.writeBanned(w, ban)
+			return
+		}
 
-			// Calculate reset time (next minute)
-			resetTime := time.Now().Add(time.Minute).Unix()
-			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetTime, 10))
+		settings := rl.settings.Load()
+		class := settings.classFor(r)
+		allowed, retryAfter, remaining, reset := rl.allow(r, settings, class)
 
-			if !allowed {
-				// Rate limit exceeded
-				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
-				utils.WriteDomainError(w, errors.ErrRateLimitExceeded)
+		// Add rate limit headers, for the limit of the route's class
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(class.perMinute))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(float64(reset.UnixMilli())/1000)), 10))
+		if class.name != "" {
+			w.Header().Set("X-RateLimit-Class", class.name)
+		}
+
+		if !allowed {
+			// Rate limit exceeded
+			rl.rejected.Add(1)
+			rl.logger.Info("Rate limit exceeded", zap.String("clientIP", rl.ClientIP(r)), zap.String("path", r.URL.Path), zap.String("class", class.name))
+			if ban := rl.recordViolation(r, rl.ClientIP(r)); ban != nil {
+				rl.writeBanned(w, ban)
 				return
 			}
+			w.Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+			utils.WriteDomainError(w, errors.ErrRateLimitExceeded)
+			return
+		}
 
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PeerMiddleware bans authenticated peers that keep exceeding the limit like Middleware
+// bans client IPs, so a peer spreading its requests over many addresses is banned as
+// well. Each peer has its own bucket per class and its bans are kept under its peer ID.
+// It goes behind WithAuth and passes requests without an authenticated peer, or every
+// request without a ban service.
+func (rl *RateLimiter) PeerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings := rl.settings.Load()
+		peerID, ok := reqctx.PeerID(r.Context())
+		if rl.bans == nil || !settings.enabled || !ok {
 			next.ServeHTTP(w, r)
-		})
+			return
+		}
+
+		if ban := rl.checkBan(r, peerID); ban != nil {
+			rl.rejected.Add(1)
+			rl.writeBanned(w, ban)
+			return
+		}
+
+		class := settings.classFor(r)
+		now := time.Now()
+		limiter := rl.getOrCreateLimiter(class, "peer "+peerID)
+		if !limiter.AllowN(now, 1) {
+			rl.rejected.Add(1)
+			rl.logger.Info("Rate limit exceeded", zap.String("peerID", peerID), zap.String("path", r.URL.Path), zap.String("class", class.name))
+			if ban := rl.recordViolation(r, peerID); ban != nil {
+				rl.writeBanned(w, ban)
+				return
+			}
+			w.Header().Set("Retry-After", retryAfterSeconds(tokenDelay(limiter, 1-limiter.TokensAt(now))))
+			utils.WriteDomainError(w, errors.ErrRateLimitExceeded)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkBan returns the active ban of the client IP or peer, or nil. Requests are served
+// when the ban store cannot be asked, a failing store must not lock every client out.
+func (rl *RateLimiter) checkBan(r *http.Request, subject string) *models.Ban {
+	if rl.bans == nil || !rl.settings.Load().enabled {
+		return nil
+	}
+
+	ban, err := rl.bans.CheckBan(r.Context(), subject)
+	if err != nil {
+		rl.logger.Warn("Failed to check client ban, serving the request", zap.Error(err))
+		return nil
 	}
+	return ban
+}
+
+// recordViolation counts a rejected request of the client IP or peer towards a ban and
+// returns the ban it led to
+func (rl *RateLimiter) recordViolation(r *http.Request, subject string) *models.Ban {
+	if rl.bans == nil {
+		return nil
+	}
+
+	ban, err := rl.bans.RecordViolation(r.Context(), subject)
+	if err != nil {
+		rl.logger.Warn("Failed to record rate limit violation", zap.Error(err))
+		return nil
+	}
+	return ban
+}
+
+func (rl *RateLimiter) writeBanned(w http.ResponseWriter, ban *models.Ban) {
+	w.Header().Set("Retry-After", retryAfterSeconds(time.Until(ban.ExpiresAt)))
+	utils.WriteDomainError(w, errors.ErrClientBanned)
+}
+
+// Rejected returns the number of requests refused since start
+func (rl *RateLimiter) Rejected() int64 {
+	return rl.rejected.Load()
+}
+
+// RateLimitMiddleware creates a middleware that enforces rate limiting
+func RateLimitMiddleware(cfg *config.AppConfig, logger *zap.Logger) func(next http.Handler) http.Handler {
+	return NewRateLimiter(cfg, nil, logger).Middleware
 }
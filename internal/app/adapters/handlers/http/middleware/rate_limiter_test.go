@@ -15,13 +15,15 @@ import (
 func TestRateLimiter_ExtractClientIP(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 100,
-		RateLimitBurst:             20,
-		RateLimitTrustedProxies:    []string{"127.0.0.1", "10.0.0.0/8"},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 100,
+			Burst:             20,
+			TrustedProxies:    []string{"127.0.0.1", "10.0.0.0/8"},
+		},
 	}
 
-	rl := NewRateLimiter(cfg, logger)
+	rl := NewRateLimiter(cfg, nil, logger)
 	defer rl.Stop()
 
 	tests := []struct {
@@ -53,8 +55,8 @@ func TestRateLimiter_ExtractClientIP(t *testing.T) {
 			headers: map[string]string{
 				"X-Forwarded-For": "203.0.113.1, 198.51.100.1",
 			},
-			expectedIP:  "203.0.113.1",
-			description: "Should use first IP from X-Forwarded-For when from trusted proxy",
+			expectedIP:  "198.51.100.1",
+			description: "Should use the rightmost untrusted IP from X-Forwarded-For when from trusted proxy",
 		},
 		{
 			name:       "Untrusted proxy ignores headers",
@@ -95,7 +97,7 @@ func TestRateLimiter_ExtractClientIP(t *testing.T) {
 				req.Header.Set(key, value)
 			}
 
-			actualIP := rl.extractClientIP(req)
+			actualIP := rl.ClientIP(req)
 			assert.Equal(t, tt.expectedIP, actualIP, tt.description)
 		})
 	}
@@ -104,13 +106,15 @@ func TestRateLimiter_ExtractClientIP(t *testing.T) {
 func TestRateLimiter_TokenBucketBehavior(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 60, // 1 request per second
-		RateLimitBurst:             2,  // Allow burst of 2
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 60, // 1 request per second
+			Burst:             2,  // Allow burst of 2
+			TrustedProxies:    []string{},
+		},
 	}
 
-	rl := NewRateLimiter(cfg, logger)
+	rl := NewRateLimiter(cfg, nil, logger)
 	defer rl.Stop()
 
 	req := httptest.NewRequest("GET", "/test", nil)
@@ -137,13 +141,15 @@ func TestRateLimiter_TokenBucketBehavior(t *testing.T) {
 func TestRateLimiter_DifferentIPs(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 2, // Very low limit for testing
-		RateLimitBurst:             1,
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 2, // Very low limit for testing
+			Burst:             1,
+			TrustedProxies:    []string{},
+		},
 	}
 
-	rl := NewRateLimiter(cfg, logger)
+	rl := NewRateLimiter(cfg, nil, logger)
 	defer rl.Stop()
 
 	// Create requests from different IPs
@@ -172,13 +178,15 @@ func TestRateLimiter_DifferentIPs(t *testing.T) {
 func TestRateLimiter_Disabled(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           false,
-		RateLimitRequestsPerMinute: 1,
-		RateLimitBurst:             1,
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           false,
+			RequestsPerMinute: 1,
+			Burst:             1,
+			TrustedProxies:    []string{},
+		},
 	}
 
-	rl := NewRateLimiter(cfg, logger)
+	rl := NewRateLimiter(cfg, nil, logger)
 	defer rl.Stop()
 
 	req := httptest.NewRequest("GET", "/test", nil)
@@ -189,17 +197,19 @@ func TestRateLimiter_Disabled(t *testing.T) {
 		allowed, retryAfter, remaining := rl.Allow(req)
 		assert.True(t, allowed, "Request %d should be allowed when rate limiting is disabled", i+1)
 		assert.Equal(t, time.Duration(0), retryAfter)
-		assert.Equal(t, cfg.RateLimitRequestsPerMinute, remaining)
+		assert.Equal(t, cfg.RateLimit.RequestsPerMinute, remaining)
 	}
 }
 
 func TestRateLimitMiddleware_Headers(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 100,
-		RateLimitBurst:             20,
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 100,
+			Burst:             20,
+			TrustedProxies:    []string{},
+		},
 	}
 
 	middleware := RateLimitMiddleware(cfg, logger)
@@ -229,10 +239,12 @@ func TestRateLimitMiddleware_Headers(t *testing.T) {
 func TestRateLimitMiddleware_RateLimitExceeded(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 1, // Very low limit
-		RateLimitBurst:             1,
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 1, // Very low limit
+			Burst:             1,
+			TrustedProxies:    []string{},
+		},
 	}
 
 	middleware := RateLimitMiddleware(cfg, logger)
@@ -267,13 +279,15 @@ func TestRateLimitMiddleware_RateLimitExceeded(t *testing.T) {
 func TestRateLimiter_ConcurrentRequests(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 10,
-		RateLimitBurst:             5,
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 10,
+			Burst:             5,
+			TrustedProxies:    []string{},
+		},
 	}
 
-	rl := NewRateLimiter(cfg, logger)
+	rl := NewRateLimiter(cfg, nil, logger)
 	defer rl.Stop()
 
 	req := httptest.NewRequest("GET", "/test", nil)
@@ -303,13 +317,15 @@ func TestRateLimiter_ConcurrentRequests(t *testing.T) {
 func TestRateLimiter_TrustedProxyCIDR(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 100,
-		RateLimitBurst:             20,
-		RateLimitTrustedProxies:    []string{"10.0.0.0/8", "172.16.0.0/12"},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 100,
+			Burst:             20,
+			TrustedProxies:    []string{"10.0.0.0/8", "172.16.0.0/12"},
+		},
 	}
 
-	rl := NewRateLimiter(cfg, logger)
+	rl := NewRateLimiter(cfg, nil, logger)
 	defer rl.Stop()
 
 	tests := []struct {
@@ -344,7 +360,7 @@ func TestRateLimiter_TrustedProxyCIDR(t *testing.T) {
 			req.RemoteAddr = tt.remoteAddr
 			req.Header.Set("X-Real-IP", tt.xRealIP)
 
-			actualIP := rl.extractClientIP(req)
+			actualIP := rl.ClientIP(req)
 			assert.Equal(t, tt.expectedIP, actualIP)
 		})
 	}
@@ -353,13 +369,16 @@ func TestRateLimiter_TrustedProxyCIDR(t *testing.T) {
 func TestRateLimiter_Cleanup(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 100,
-		RateLimitBurst:             20,
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 100,
+			Burst:             20,
+			TrustedProxies:    []string{},
+			IdleTimeout:       10,
+		},
 	}
 
-	rl := NewRateLimiter(cfg, logger)
+	rl := NewRateLimiter(cfg, nil, logger)
 	defer rl.Stop()
 
 	// Create some limiters
@@ -372,33 +391,160 @@ func TestRateLimiter_Cleanup(t *testing.T) {
 	rl.Allow(req2)
 
 	// Verify limiters exist
-	_, exists1 := rl.limiters.Load("192.168.1.100")
+	_, exists1 := rl.limiters["192.168.1.100"]
 	assert.True(t, exists1, "Limiter for first IP should exist")
 
-	_, exists2 := rl.limiters.Load("192.168.1.101")
+	_, exists2 := rl.limiters["192.168.1.101"]
 	assert.True(t, exists2, "Limiter for second IP should exist")
 
+	// Make the first limiter look idle for longer than the timeout
+	rl.limiters["192.168.1.100"].Value.(*limiterEntry).lastAccess = time.Now().Add(-11 * time.Minute)
+	rl.lru.MoveToBack(rl.limiters["192.168.1.100"])
+
 	// Trigger cleanup
 	rl.cleanupUnusedLimiters()
 
-	// After cleanup, limiters should be removed
-	_, exists1After := rl.limiters.Load("192.168.1.100")
-	assert.False(t, exists1After, "Limiter for first IP should be cleaned up")
+	// Only the idle limiter should be removed
+	_, exists1After := rl.limiters["192.168.1.100"]
+	assert.False(t, exists1After, "Idle limiter for first IP should be cleaned up")
+
+	_, exists2After := rl.limiters["192.168.1.101"]
+	assert.True(t, exists2After, "Recently used limiter for second IP should be kept")
+	assert.Equal(t, 1, rl.size())
+}
+
+func TestRateLimiter_CleanupKeepsBucketState(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.AppConfig{
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 1,
+			Burst:             1,
+			TrustedProxies:    []string{},
+		},
+	}
+
+	rl := NewRateLimiter(cfg, nil, logger)
+	defer rl.Stop()
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "192.168.1.100:12345"
+
+	allowed, _, _ := rl.Allow(req)
+	assert.True(t, allowed)
+
+	// Cleanup must not reset the bucket of an active client
+	rl.cleanupUnusedLimiters()
+
+	allowed, _, _ = rl.Allow(req)
+	assert.False(t, allowed, "Active client should still be rate limited after cleanup")
+}
+
+func TestRateLimiter_MaxEntriesEvictsLeastRecentlyUsed(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.AppConfig{
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 100,
+			Burst:             20,
+			TrustedProxies:    []string{},
+			MaxEntries:        2,
+		},
+	}
+
+	rl := NewRateLimiter(cfg, nil, logger)
+	defer rl.Stop()
+
+	newReq := func(addr string) *http.Request {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = addr
+		return req
+	}
+
+	rl.Allow(newReq("192.168.1.1:1000"))
+	rl.Allow(newReq("192.168.1.2:1000"))
+
+	// Touch the first client so the second becomes least recently used
+	rl.Allow(newReq("192.168.1.1:1000"))
+	rl.Allow(newReq("192.168.1.3:1000"))
+
+	assert.Equal(t, 2, rl.size())
+	_, exists1 := rl.limiters["192.168.1.1"]
+	assert.True(t, exists1, "Recently used client should be kept")
+	_, exists2 := rl.limiters["192.168.1.2"]
+	assert.False(t, exists2, "Least recently used client should be evicted")
+	_, exists3 := rl.limiters["192.168.1.3"]
+	assert.True(t, exists3, "New client should be tracked")
+}
+
+func TestRateLimiter_ApplyConfig(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.AppConfig{
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 1,
+			Burst:             1,
+			TrustedProxies:    []string{},
+		},
+	}
+
+	rl := NewRateLimiter(cfg, nil, logger)
+	defer rl.Stop()
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "10.1.2.3:12345"
+	req.Header.Set("X-Real-IP", "203.0.113.7")
+
+	allowed, _, _ := rl.Allow(req)
+	assert.True(t, allowed)
+	allowed, _, _ = rl.Allow(req)
+	assert.False(t, allowed, "Burst of 1 should be used up")
+	assert.Equal(t, "10.1.2.3", rl.ClientIP(req))
+
+	rl.ApplyConfig(&config.AppConfig{
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 6000,
+			Burst:             50,
+			TrustedProxies:    []string{"10.0.0.0/8"},
+		},
+	})
+
+	// The tracked client keeps its bucket with the new rate and burst
+	time.Sleep(50 * time.Millisecond)
+	allowed, _, _ = rl.Allow(newRequest("10.1.2.3:12345"))
+	assert.True(t, allowed, "Existing limiter should refill at the reloaded rate")
+	assert.Equal(t, "203.0.113.7", rl.ClientIP(req), "Reloaded trusted proxies should apply")
 
-	_, exists2After := rl.limiters.Load("192.168.1.101")
-	assert.False(t, exists2After, "Limiter for second IP should be cleaned up")
+	w := httptest.NewRecorder()
+	rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, req)
+	assert.Equal(t, "6000", w.Header().Get("X-RateLimit-Limit"))
+
+	rl.ApplyConfig(&config.AppConfig{RateLimit: config.RateLimitConfig{Enabled: false, RequestsPerMinute: 6000}})
+	for i := 0; i < 100; i++ {
+		allowed, _, _ = rl.Allow(req)
+		assert.True(t, allowed, "Disabling rate limiting should apply without a restart")
+	}
+}
+
+func newRequest(remoteAddr string) *http.Request {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = remoteAddr
+	return req
 }
 
 func TestRateLimiter_Stop(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.AppConfig{
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 100,
-		RateLimitBurst:             20,
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 100,
+			Burst:             20,
+			TrustedProxies:    []string{},
+		},
 	}
 
-	rl := NewRateLimiter(cfg, logger)
+	rl := NewRateLimiter(cfg, nil, logger)
 
 	// Stop should not panic
 	assert.NotPanics(t, func() {
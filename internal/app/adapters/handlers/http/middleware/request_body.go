@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/validation"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// WithRequestBody middleware decodes the JSON body of application/json requests into a
+// validation.RequestBody in the context, where WithAuth and the lease and auth validators
+// read it. Malformed bodies are refused with 400 INVALID_REQUEST; requests with another
+// content type pass unchanged and are served from their headers.
+func WithRequestBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !validation.IsJSONRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := validation.DecodeRequestBody(r)
+		if err != nil {
+			utils.WriteDomainError(w, errors.ErrInvalidRequest.WithDetails(err.Error()))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(validation.ContextWithRequestBody(r.Context(), body)))
+	})
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+)
+
+const (
+	// RequestIDHeader carries the ID of a request, taken from the client or a proxy when
+	// it sends one and echoed in the response
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// WithRequestContext records the request ID and the client address in the request
+// context. A request ID sent by the client is kept when it is printable ASCII of at most
+// maxRequestIDLength bytes, otherwise a random one is assigned. clientIP resolves the
+// client address, e.g. RateLimiter.ClientIP.
+func WithRequestContext(clientIP func(r *http.Request) string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" || !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			ctx := reqctx.WithClientIP(reqctx.WithRequestID(r.Context(), requestID), clientIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func validRequestID(requestID string) bool {
+	if len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+const defaultErrorWindow = 60 * time.Second
+
+// requestBucket counts the requests completed during one second
+type requestBucket struct {
+	second int64
+	total  int64
+	errors int64
+}
+
+// RequestStats tracks in-flight requests and the rate of server errors over a sliding window.
+// It feeds the composite health score.
+type RequestStats struct {
+	inFlight atomic.Int64
+	mu       sync.Mutex
+	buckets  []requestBucket // one per second of the window, indexed by unix second
+}
+
+// NewRequestStats creates request statistics with the configured error rate window
+func NewRequestStats(cfg *config.AppConfig) *RequestStats {
+	window := time.Duration(cfg.HealthErrorWindow) * time.Second
+	if window < time.Second {
+		window = defaultErrorWindow
+	}
+
+	return &RequestStats{
+		buckets: make([]requestBucket, int(window/time.Second)),
+	}
+}
+
+// Middleware counts every request while it is being served and records whether it
+// completed with a 5xx status
+func (s *RequestStats) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			s.inFlight.Add(-1)
+			// A panic escaping the handlers is never answered successfully
+			if rec := recover(); rec != nil {
+				s.record(true)
+				panic(rec)
+			}
+			s.record(ww.Status() >= http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+func (s *RequestStats) record(failed bool) {
+	second := time.Now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := &s.buckets[second%int64(len(s.buckets))]
+	if b.second != second {
+		*b = requestBucket{second: second}
+	}
+	b.total++
+	if failed {
+		b.errors++
+	}
+}
+
+// InFlight returns the number of requests currently being served
+func (s *RequestStats) InFlight() int64 {
+	return s.inFlight.Load()
+}
+
+// ErrorRate returns the fraction of requests completed within the window that failed with
+// a server error, or 0 when no requests completed
+func (s *RequestStats) ErrorRate() float64 {
+	oldest := time.Now().Unix() - int64(len(s.buckets))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total, errors int64
+	for _, b := range s.buckets {
+		if b.second > oldest {
+			total += b.total
+			errors += b.errors
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(errors) / float64(total)
+}
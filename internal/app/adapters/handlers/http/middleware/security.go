@@ -6,14 +6,22 @@ import (
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/validation"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 )
 
-// SecurityMiddleware provides comprehensive request validation and sanitization
-func SecurityMiddleware() func(next http.Handler) http.Handler {
+// SecurityMiddleware refuses requests with suspicious header values or with headers or a
+// URL over the configured length limits
+func SecurityMiddleware(cfg *config.AppConfig) func(next http.Handler) http.Handler {
+	rules, err := validation.NewRequestRules(cfg.Security.HeaderPatterns, cfg.Security.HeaderPatternsExempt,
+		cfg.Security.MaxHeaderLength, cfg.Security.MaxHeaderNameLength, cfg.Security.MaxURLLength)
+	if err != nil {
+		// The patterns are checked when the configuration is validated
+		panic(err)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Validate and sanitize the request
-			if err := validation.ValidateAndSanitizeRequest(r); err != nil {
+			if err := rules.Validate(r); err != nil {
 				utils.WriteDomainError(w, err)
 				return
 			}
@@ -78,7 +86,8 @@ func SecurityHeadersMiddleware() func(next http.Handler) http.Handler {
 
 			// Cache control for sensitive endpoints
 			if r.URL.Path == "/request-auth" || r.URL.Path == "/allocate-ip" ||
-				r.URL.Path == "/renew-lease" || r.URL.Path == "/release-lease" {
+				r.URL.Path == "/renew-lease" || r.URL.Path == "/release-lease" ||
+				r.URL.Path == "/v1/lease/transfer" || r.URL.Path == "/v1/leases/batch" {
 				w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, private")
 				w.Header().Set("Pragma", "no-cache")
 				w.Header().Set("Expires", "0")
@@ -96,7 +105,7 @@ func CORSMiddleware() func(next http.Handler) http.Handler {
 			// Set CORS headers
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Pubkey, X-Nonce, X-Signature")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Pubkey, X-Nonce, X-Signature, X-Timestamp, X-New-Pubkey, X-Transfer-Signature, Idempotency-Key, If-None-Match, X-Request-ID, X-Zone")
 			w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
 
 			// Handle preflight requests
@@ -110,14 +119,14 @@ func CORSMiddleware() func(next http.Handler) http.Handler {
 	}
 }
 
-// CombinedSecurityMiddleware combines all security middlewares
-func CombinedSecurityMiddleware() func(next http.Handler) http.Handler {
+// CombinedSecurityMiddleware combines all security middlewares. Request body size is
+// limited by RequestLimits.
+func CombinedSecurityMiddleware(cfg *config.AppConfig) func(next http.Handler) http.Handler {
+	security := SecurityMiddleware(cfg)
 	return func(next http.Handler) http.Handler {
 		return CORSMiddleware()(
 			SecurityHeadersMiddleware()(
-				RequestSizeMiddleware(1024 * 1024)( // 1MB limit
-					SecurityMiddleware()(next),
-				),
+				security(next),
 			),
 		)
 	}
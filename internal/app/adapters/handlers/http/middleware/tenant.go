@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+)
+
+// APIKeyHeader carries the API key selecting the tenant a request is served for
+const APIKeyHeader = "X-API-Key"
+
+// WithTenant middleware scopes the request to the tenant owning the X-API-Key header.
+// Requests without the header are served for the default tenant, unknown keys are
+// refused. Admin API keys are left to the admin routes that check them.
+func WithTenant(tenants ports.TenantResolver) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := r.Header.Get(APIKeyHeader)
+			if apiKey == "" || strings.HasPrefix(apiKey, models.AdminAPIKeyPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenant, err := tenants.ResolveAPIKey(apiKey)
+			if err != nil {
+				utils.WriteDomainError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(reqctx.WithTenant(r.Context(), tenant.ID)))
+		})
+	}
+}
@@ -1,10 +1,86 @@
 package http
 
-import "go.uber.org/fx"
+import (
+	"go.uber.org/fx"
+
+	httpMiddleware "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
 
 var Module = fx.Options(
 	fx.Provide(NewLeaseHandler),
+	fx.Provide(NewLeaseWaitHandler),
+	fx.Provide(NewDelegationHandler),
 	fx.Provide(NewAuthHandler),
-	fx.Provide(NewHealthHandler),
+	fx.Provide(
+		fx.Annotate(
+			NewHealthHandler,
+			fx.ParamTags(`name:"database"`, `name:"cache" optional:"true"`, ``, `optional:"true"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewHealthScoreHandler,
+			fx.ParamTags(`name:"database"`, `name:"cache" optional:"true"`),
+		),
+	),
+	fx.Provide(httpMiddleware.NewRequestStats),
+	fx.Provide(httpMiddleware.NewRequestLimits),
+	fx.Provide(
+		fx.Annotate(
+			httpMiddleware.NewRateLimiter,
+			fx.ParamTags(``, `optional:"true"`),
+			fx.OnStop(func(rl *httpMiddleware.RateLimiter) { rl.Stop() }),
+		),
+	),
+	config.ReloadTarget[*httpMiddleware.RateLimiter](),
+	fx.Provide(httpMiddleware.NewLoadShedder),
+	fx.Provide(httpMiddleware.NewIdempotency),
+	fx.Provide(httpMiddleware.NewPayloadLog),
+	config.ReloadTarget[*httpMiddleware.PayloadLog](),
+	fx.Provide(NewAdminHandler),
+	fx.Provide(NewAccessHandler),
+	fx.Provide(NewPeerPolicyHandler),
+	fx.Provide(
+		fx.Annotate(
+			NewFaultHandler,
+			fx.ParamTags(`optional:"true"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewBanHandler,
+			fx.ParamTags(`optional:"true"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewPoolStatsHandler,
+			fx.ParamTags(``, ``, `optional:"true"`, `optional:"true"`),
+		),
+	),
+	fx.Provide(NewSnapshotHandler),
+	fx.Provide(NewLeaseRevocationHandler),
+	fx.Provide(
+		fx.Annotate(
+			NewServerInfoHandler,
+			fx.ParamTags(``, ``, ``, `optional:"true"`, `optional:"true"`, `optional:"true"`),
+		),
+	),
+	config.ReloadTarget[*ServerInfoHandler](),
+	fx.Provide(NewBatchHandler),
+	fx.Provide(NewLeaseQueryHandler),
+	fx.Provide(
+		fx.Annotate(
+			NewDashboardHandler,
+			fx.ParamTags(``, ``, ``, `optional:"true"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewDiagnosticsHandler,
+			fx.ParamTags(`optional:"true"`, `optional:"true"`),
+		),
+	),
 	fx.Provide(NewHTTPRouter),
 )
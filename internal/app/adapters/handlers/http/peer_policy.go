@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// PeerPolicyHandler manages the peer policies through the admin API
+type PeerPolicyHandler struct {
+	policies ports.PeerPolicyService
+}
+
+func NewPeerPolicyHandler(policies ports.PeerPolicyService) *PeerPolicyHandler {
+	return &PeerPolicyHandler{policies}
+}
+
+// ListPolicies lists the peer policies in the order they are matched
+func (h *PeerPolicyHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleListPolicies, nil)
+}
+
+func (h *PeerPolicyHandler) handleListPolicies(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.policies.ListPolicies(ctx)
+}
+
+// AddPolicy adds a peer policy kept in the database
+func (h *PeerPolicyHandler) AddPolicy(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleAddPolicy,
+		ValidatePeerPolicyRequest,
+	)
+}
+
+func (h *PeerPolicyHandler) handleAddPolicy(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.policies.AddPolicy(ctx, req.(*models.PeerPolicy))
+}
+
+// RemovePolicy deletes a peer policy kept in the database by ID
+func (h *PeerPolicyHandler) RemovePolicy(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleRemovePolicy,
+		ValidatePeerPolicyIDRequest,
+	)
+}
+
+func (h *PeerPolicyHandler) handleRemovePolicy(ctx context.Context, req interface{}) (interface{}, error) {
+	idReq := req.(*PeerPolicyIDRequestData)
+	if err := h.policies.RemovePolicy(ctx, idReq.ID); err != nil {
+		return nil, err
+	}
+	return map[string]string{"status": "success"}, nil
+}
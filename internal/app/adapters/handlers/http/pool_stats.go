@@ -0,0 +1,182 @@
+package http
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	appUtils "github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// PoolStatsHandler reports pool utilization and the database and cache connection pools
+// as JSON and as Prometheus metrics, and the token ID ranges pools hand out
+type PoolStatsHandler struct {
+	poolStats ports.PoolStatsService
+	tenants   ports.TenantResolver
+	database  ports.DatabasePoolStatsReporter // nil without a Postgres pool
+	cache     ports.CachePoolStatsReporter    // nil without Redis
+
+	mu          sync.Mutex // held while the gauges are set and gathered
+	metrics     http.Handler
+	tokens      *prometheus.GaugeVec
+	total       *prometheus.GaugeVec
+	allocations *prometheus.GaugeVec
+	utilization *prometheus.GaugeVec
+}
+
+func NewPoolStatsHandler(poolStats ports.PoolStatsService, tenants ports.TenantResolver, database ports.DatabasePoolStatsReporter, cache ports.CachePoolStatsReporter) *PoolStatsHandler {
+	h := &PoolStatsHandler{
+		poolStats: poolStats,
+		tenants:   tenants,
+		database:  database,
+		cache:     cache,
+		tokens: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dhcp2p_pool_tokens",
+			Help: "Token IDs of a tenant pool by state: allocated, reserved, grace, expired_reclaimable or free.",
+		}, []string{"tenant", "state"}),
+		total: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dhcp2p_pool_size",
+			Help: "Token IDs in a tenant pool.",
+		}, []string{"tenant"}),
+		allocations: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dhcp2p_pool_allocations_last_hour",
+			Help: "Leases allocated from a tenant pool over the last hour.",
+		}, []string{"tenant"}),
+		utilization: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dhcp2p_pool_utilization_ratio",
+			Help: "Allocated, reserved and grace token IDs of a tenant pool relative to its size.",
+		}, []string{"tenant"}),
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(h.tokens, h.total, h.allocations, h.utilization)
+	if database != nil {
+		registry.MustRegister(newDatabasePoolCollector(database))
+	}
+	if cache != nil {
+		registry.MustRegister(newCachePoolCollector(cache))
+	}
+	h.metrics = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return h
+}
+
+// PoolStats reports the token ID counts and allocation rate of every tenant pool
+func (h *PoolStatsHandler) PoolStats(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handlePoolStats, nil)
+}
+
+func (h *PoolStatsHandler) handlePoolStats(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.poolStats.PoolStats(ctx)
+}
+
+// PoolRanges reports the exclusions of every tenant pool and the ranges left to hand out
+func (h *PoolStatsHandler) PoolRanges(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handlePoolRanges, nil)
+}
+
+func (h *PoolStatsHandler) handlePoolRanges(ctx context.Context, req interface{}) (interface{}, error) {
+	tenants := h.tenants.Tenants()
+	resp := &PoolRangesResponse{Pools: make([]*PoolRanges, 0, len(tenants))}
+	for _, tenant := range tenants {
+		allocatable := tenant.AllocatableRanges()
+		resp.Pools = append(resp.Pools, &PoolRanges{
+			Tenant:          tenant.ID,
+			Pool:            newTokenRangeInfo(models.TokenRange{FirstTokenID: tenant.MinTokenID, LastTokenID: tenant.MaxTokenID}),
+			Exclusions:      newTokenRangeInfos(tenant.Exclusions),
+			Zones:           newZoneRangeInfos(tenant.Zones),
+			Allocatable:     newTokenRangeInfos(allocatable),
+			AllocatableSize: allocatable.Size(),
+		})
+	}
+	return resp, nil
+}
+
+func newTokenRangeInfos(ranges models.TokenRanges) []*TokenRangeInfo {
+	infos := make([]*TokenRangeInfo, 0, len(ranges))
+	for _, r := range ranges {
+		infos = append(infos, newTokenRangeInfo(r))
+	}
+	return infos
+}
+
+// newZoneRangeInfos lists the ranges of all zones by their first token ID
+func newZoneRangeInfos(zones models.Zones) []*TokenRangeInfo {
+	var infos []*TokenRangeInfo
+	for zone, ranges := range zones {
+		for _, r := range ranges {
+			info := newTokenRangeInfo(r)
+			info.Zone = zone
+			infos = append(infos, info)
+		}
+	}
+	slices.SortFunc(infos, func(a, b *TokenRangeInfo) int { return cmp.Compare(a.FirstTokenID, b.FirstTokenID) })
+	return infos
+}
+
+func newTokenRangeInfo(r models.TokenRange) *TokenRangeInfo {
+	return &TokenRangeInfo{
+		FirstTokenID: r.FirstTokenID,
+		LastTokenID:  r.LastTokenID,
+		FirstIP:      appUtils.IPFromTokenID(uint32(r.FirstTokenID)),
+		LastIP:       appUtils.IPFromTokenID(uint32(r.LastTokenID)),
+		Size:         r.Size(),
+		Reason:       r.Reason,
+	}
+}
+
+// ConnectionPools reports the database and cache connection pools of this instance
+func (h *PoolStatsHandler) ConnectionPools(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleConnectionPools, nil)
+}
+
+func (h *PoolStatsHandler) handleConnectionPools(ctx context.Context, req interface{}) (interface{}, error) {
+	pools := &models.ConnectionPoolStats{SampledAt: time.Now()}
+	if h.database != nil {
+		pools.Database = h.database.DatabasePoolStats()
+	}
+	if h.cache != nil {
+		pools.Cache = h.cache.CachePoolStats()
+	}
+	return pools, nil
+}
+
+// Metrics serves the pool stats and connection pool statistics in the Prometheus text format
+func (h *PoolStatsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	report, err := h.poolStats.PoolStats(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Reset drops tenants that are no longer configured
+	h.tokens.Reset()
+	h.total.Reset()
+	h.allocations.Reset()
+	h.utilization.Reset()
+	for _, pool := range report.Pools {
+		h.tokens.WithLabelValues(pool.Tenant, "allocated").Set(float64(pool.Allocated))
+		h.tokens.WithLabelValues(pool.Tenant, "reserved").Set(float64(pool.Reserved))
+		h.tokens.WithLabelValues(pool.Tenant, "grace").Set(float64(pool.Grace))
+		h.tokens.WithLabelValues(pool.Tenant, "expired_reclaimable").Set(float64(pool.ExpiredReclaimable))
+		h.tokens.WithLabelValues(pool.Tenant, "free").Set(float64(pool.Free))
+		h.total.WithLabelValues(pool.Tenant).Set(float64(pool.Total))
+		h.allocations.WithLabelValues(pool.Tenant).Set(float64(pool.AllocationsPerHour))
+		h.utilization.WithLabelValues(pool.Tenant).Set(pool.Utilization)
+	}
+	h.metrics.ServeHTTP(w, r)
+}
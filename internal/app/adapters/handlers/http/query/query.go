@@ -0,0 +1,218 @@
+// Package query parses the pagination, sorting and filtering parameters shared by
+// collection endpoints into models.ListOptions.
+//
+// A collection accepts:
+//
+//	limit=50              page size, up to the endpoint's maximum
+//	offset=100            items to skip, cannot be combined with cursor
+//	cursor=...            next_cursor of the previous page
+//	sort=expires_at       ascending, or sort=-expires_at for descending order
+//	peer_id=abc           equality filter, same as peer_id[eq]=abc
+//	expires_at[lt]=...    filter with an operator: eq, ne, lt, lte, gt, gte or prefix
+//	label=role=validator  label filter, the value is key=value
+//
+// Only the fields declared in a Spec may be sorted or filtered on; anything else is
+// rejected with a validation error.
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+const (
+	limitParam  = "limit"
+	offsetParam = "offset"
+	cursorParam = "cursor"
+	sortParam   = "sort"
+)
+
+// FieldType selects how filter values and cursors of a field are parsed
+type FieldType int
+
+const (
+	String FieldType = iota
+	Int
+	Time  // RFC 3339
+	Label // key=value, parsed into a models.LeaseLabel
+)
+
+// Operator sets for the common field types
+var (
+	TextOperators    = []models.FilterOperator{models.FilterEq, models.FilterNe, models.FilterPrefix}
+	OrderedOperators = []models.FilterOperator{models.FilterEq, models.FilterNe, models.FilterLt, models.FilterLte, models.FilterGt, models.FilterGte}
+)
+
+// Field describes a collection field that may be sorted or filtered on
+type Field struct {
+	Type      FieldType
+	Sortable  bool
+	Operators []models.FilterOperator // accepted in filters, none when the field cannot be filtered
+}
+
+// Spec describes the parameters accepted by one collection endpoint
+type Spec struct {
+	Fields       map[string]Field
+	DefaultSort  string // field name, prefixed with "-" for descending order
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// cursor is the decoded form of the opaque cursor parameter
+type cursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Value      string `json:"v"`
+	Key        int64  `json:"k"`
+}
+
+// Parse reads the list options from the URL query
+func (s *Spec) Parse(values url.Values) (*models.ListOptions, error) {
+	opts := &models.ListOptions{Limit: s.DefaultLimit}
+
+	sort := values.Get(sortParam)
+	if sort == "" {
+		sort = s.DefaultSort
+	}
+	opts.SortField, opts.Descending = strings.CutPrefix(sort, "-")
+	if field, ok := s.Fields[opts.SortField]; !ok || !field.Sortable {
+		return nil, errors.ErrInvalidSort.WithDetails(fmt.Sprintf("cannot sort by %q", opts.SortField))
+	}
+
+	for name, vals := range values {
+		switch name {
+		case sortParam:
+			continue
+		case limitParam:
+			limit, err := strconv.Atoi(vals[0])
+			if err != nil || limit <= 0 || limit > s.MaxLimit {
+				return nil, errors.ErrInvalidPagination.WithDetails(fmt.Sprintf("limit must be between 1 and %d", s.MaxLimit))
+			}
+			opts.Limit = limit
+		case offsetParam:
+			offset, err := strconv.Atoi(vals[0])
+			if err != nil || offset < 0 {
+				return nil, errors.ErrInvalidPagination.WithDetails("offset must be a non-negative integer")
+			}
+			opts.Offset = offset
+		case cursorParam:
+			after, err := s.decodeCursor(vals[0], opts)
+			if err != nil {
+				return nil, err
+			}
+			opts.After = after
+		default:
+			for _, val := range vals {
+				filter, err := s.parseFilter(name, val)
+				if err != nil {
+					return nil, err
+				}
+				opts.Filters = append(opts.Filters, filter)
+			}
+		}
+	}
+
+	if opts.After != nil && opts.Offset > 0 {
+		return nil, errors.ErrInvalidPagination.WithDetails("offset cannot be combined with cursor")
+	}
+
+	// Map iteration order is random, keep filters stable for callers and tests
+	slices.SortStableFunc(opts.Filters, func(a, b models.Filter) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+
+	return opts, nil
+}
+
+// parseFilter parses a "field" or "field[op]" parameter
+func (s *Spec) parseFilter(name, raw string) (models.Filter, error) {
+	fieldName, operator := name, models.FilterEq
+	if open := strings.IndexByte(name, '['); open > 0 && strings.HasSuffix(name, "]") {
+		fieldName = name[:open]
+		operator = models.FilterOperator(name[open+1 : len(name)-1])
+	}
+
+	field, ok := s.Fields[fieldName]
+	if !ok || len(field.Operators) == 0 {
+		return models.Filter{}, errors.ErrInvalidFilter.WithDetails(fmt.Sprintf("cannot filter by %q", fieldName))
+	}
+	if !slices.Contains(field.Operators, operator) {
+		return models.Filter{}, errors.ErrInvalidFilter.WithDetails(fmt.Sprintf("operator %q is not supported for %q", operator, fieldName))
+	}
+
+	value, err := parseValue(field.Type, raw)
+	if err != nil {
+		return models.Filter{}, errors.ErrInvalidFilter.WithDetails(fmt.Sprintf("invalid value for %q: %v", fieldName, err))
+	}
+
+	return models.Filter{Field: fieldName, Operator: operator, Value: value}, nil
+}
+
+// decodeCursor checks that the cursor was issued for the same ordering as the request
+func (s *Spec) decodeCursor(raw string, opts *models.ListOptions) (*models.Cursor, error) {
+	invalid := errors.ErrInvalidPagination.WithDetails("malformed cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, invalid
+	}
+	if c.Sort != opts.SortField || c.Descending != opts.Descending {
+		return nil, errors.ErrInvalidPagination.WithDetails("cursor was issued for a different sort order")
+	}
+
+	value, err := parseValue(s.Fields[c.Sort].Type, c.Value)
+	if err != nil {
+		return nil, invalid
+	}
+
+	return &models.Cursor{SortValue: value, Key: c.Key}, nil
+}
+
+// EncodeCursor returns the cursor continuing after the item with the given sort value and key
+func EncodeCursor(opts *models.ListOptions, sortValue any, key int64) string {
+	c := cursor{
+		Sort:       opts.SortField,
+		Descending: opts.Descending,
+		Value:      formatValue(sortValue),
+		Key:        key,
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parseValue(fieldType FieldType, raw string) (any, error) {
+	switch fieldType {
+	case Int:
+		return strconv.ParseInt(raw, 10, 64)
+	case Time:
+		return time.Parse(time.RFC3339Nano, raw)
+	case Label:
+		return models.ParseLeaseLabel(raw)
+	default:
+		return raw, nil
+	}
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -1,13 +1,15 @@
 package http
 
 import (
-	"time"
+	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 
 	httpMiddleware "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 )
 
@@ -15,43 +17,202 @@ type Router struct {
 	*chi.Mux
 }
 
-func NewHTTPRouter(logger *zap.Logger, authHandler *AuthHandler, leaseHandler *LeaseHandler, healthHandler *HealthHandler, cfg *config.AppConfig) *Router {
+func NewHTTPRouter(logger *zap.Logger, authHandler *AuthHandler, leaseHandler *LeaseHandler, leaseWaitHandler *LeaseWaitHandler, delegationHandler *DelegationHandler, healthHandler *HealthHandler, healthScoreHandler *HealthScoreHandler, serverInfoHandler *ServerInfoHandler, requestStats *httpMiddleware.RequestStats, requestLimits *httpMiddleware.RequestLimits, rateLimiter *httpMiddleware.RateLimiter, loadShedder *httpMiddleware.LoadShedder, idempotency *httpMiddleware.Idempotency, payloadLog *httpMiddleware.PayloadLog, adminHandler *AdminHandler, accessHandler *AccessHandler, peerPolicyHandler *PeerPolicyHandler, faultHandler *FaultHandler, banHandler *BanHandler, batchHandler *BatchHandler, leaseQueryHandler *LeaseQueryHandler, dashboardHandler *DashboardHandler, diagnosticsHandler *DiagnosticsHandler, poolStatsHandler *PoolStatsHandler, snapshotHandler *SnapshotHandler, revocationHandler *LeaseRevocationHandler, tenants ports.TenantResolver, apiKeys ports.APIKeyService, cfg *config.AppConfig) *Router {
 	r := chi.NewRouter()
 
+	// Track in-flight requests and server errors for the health score
+	r.Use(requestStats.Middleware)
+
+	// Assign the request ID and resolve the client address
+	r.Use(httpMiddleware.WithRequestContext(rateLimiter.ClientIP))
+
+	// Encode responses in the media type the Accept header prefers
+	r.Use(httpMiddleware.NegotiateEncoding)
+
+	// Bound request duration and body size
+	r.Use(requestLimits.Middleware)
+
 	// Apply security middleware to all routes
-	r.Use(httpMiddleware.CombinedSecurityMiddleware())
+	r.Use(httpMiddleware.CombinedSecurityMiddleware(cfg))
 
 	// Apply IP-based rate limiting
-	r.Use(httpMiddleware.RateLimitMiddleware(cfg, logger))
+	r.Use(rateLimiter.Middleware)
+
+	// Bound the requests served at once, refusing what does not fit within the queue timeout
+	r.Use(loadShedder.Middleware)
+
+	// Scope requests to the tenant of their API key
+	r.Use(httpMiddleware.WithTenant(tenants))
+
+	// Log payloads with credentials redacted, when enabled for debugging
+	r.Use(payloadLog.Middleware)
 
 	// Apply standard middleware
 	r.Use(middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: zap.NewStdLog(logger), NoColor: false}))
-	r.Use(middleware.Recoverer)                 // recover from panics
-	r.Use(middleware.Timeout(60 * time.Second)) // set timeout
+	r.Use(middleware.Recoverer) // recover from panics
+
+	// Lease routes, authenticated with headers or a JSON body. Retries with the same
+	// Idempotency-Key are replayed.
+	r.Group(func(lr chi.Router) {
+		lr.Use(
+			httpMiddleware.WithRequestBody,
+			httpMiddleware.WithAuth(authHandler.authService, accessHandler.accessControl),
+			rateLimiter.PeerMiddleware,
+			idempotency.Middleware,
+		)
+
+		lr.Post("/allocate-ip", leaseHandler.AllocateIP)
+		lr.Post("/renew-lease", leaseHandler.RenewLease)
+		lr.Post("/release-lease", leaseHandler.ReleaseLease)
+	})
 
 	// Protected routes
 	r.Group(func(pr chi.Router) {
 		// Authentication middleware
 		pr.Use(
-			httpMiddleware.WithAuth(authHandler.authService),
+			httpMiddleware.WithAuth(authHandler.authService, accessHandler.accessControl),
+			rateLimiter.PeerMiddleware,
 		)
 
-		// Lease routes
-		pr.Post("/allocate-ip", leaseHandler.AllocateIP)
-		pr.Post("/renew-lease", leaseHandler.RenewLease)
-		pr.Post("/release-lease", leaseHandler.ReleaseLease)
+		pr.Post("/v1/lease/transfer", leaseHandler.TransferLease)
+		pr.Post("/v1/lease/delegate", delegationHandler.AllocateDelegatedIP)
+		pr.Post("/v1/lease/conflict", leaseHandler.ReportConflict)
+		pr.Delete("/v1/nonces", authHandler.RevokeNonces)
+		pr.Delete("/v1/nonces/{nonceID}", authHandler.RevokeNonce)
 	})
 
-	// Public routes
-	r.Get("/lease/peer-id/{peerID}", leaseHandler.GetLeaseByPeerID)
-	r.Get("/lease/token-id/{tokenID}", leaseHandler.GetLeaseByTokenID)
+	// Lookup routes, public unless strict mode requires authentication
+	lookupRoutes := func(lr chi.Router) {
+		lr.Get("/lease/peer-id/{peerID}", leaseHandler.GetLeaseByPeerID)
+		lr.Get("/lease/token-id/{tokenID}", leaseHandler.GetLeaseByTokenID)
+		lr.Get("/v1/lease/{tokenID}/wait", leaseWaitHandler.Wait)
+	}
+	if cfg.Security.LookupAuthRequired {
+		r.Group(func(lr chi.Router) {
+			lr.Use(
+				httpMiddleware.RequireCredentials,
+				httpMiddleware.WithAuth(authHandler.authService, accessHandler.accessControl),
+				rateLimiter.PeerMiddleware,
+			)
+			lookupRoutes(lr)
+		})
+	} else {
+		lookupRoutes(r)
+	}
+
+	// Server info, including the keys issued leases are signed with
+	r.Get("/v1/server-info", serverInfoHandler.ServerInfo)
+	r.Get("/v1/server-info/keys", serverInfoHandler.Keys)
+
+	// Reporting routes (served from the lease read model)
+	r.Get("/v1/leases/stats", leaseQueryHandler.GetLeaseStats)
+
+	// Batch routes (each operation carries its own credentials)
+	r.Post("/v1/leases/batch", batchHandler.ExecuteBatch)
 
 	// Auth routes
-	r.Post("/request-auth", authHandler.RequestAuth)
+	r.With(httpMiddleware.WithRequestBody).Post("/request-auth", authHandler.RequestAuth)
 
 	// Health check routes (no authentication required)
 	r.Get("/health", healthHandler.Health)
 	r.Get("/ready", healthHandler.Readiness)
+	r.Get("/healthz/score", healthScoreHandler.Score)
+
+	// Admin routes (disabled unless configured), reachable from the management networks only
+	adminNetworks := httpMiddleware.AllowNetworks(cfg.AdminAllowedCIDRs, rateLimiter.ClientIP)
+	if cfg.AdminEnabled {
+		// The admin token opens every route, admin API keys the routes of their scopes
+		adminAuth := func(scope models.APIKeyScope) func(http.Handler) http.Handler {
+			if !cfg.AdminAPIKeysEnabled {
+				return httpMiddleware.WithAdminToken(cfg.AdminToken)
+			}
+			return httpMiddleware.WithAdminAPIKey(cfg.AdminToken, apiKeys, scope)
+		}
+
+		r.Route("/v1/admin", func(ar chi.Router) {
+			ar.Use(adminNetworks)
+
+			ar.With(adminAuth(models.APIKeyScopeLeasesRead)).Get("/leases", leaseQueryHandler.ListLeases)
+			ar.With(adminAuth(models.APIKeyScopeLeasesRead)).Get("/leases/search", leaseQueryHandler.SearchLeases)
+			ar.With(adminAuth(models.APIKeyScopeLeasesRead)).Get("/leases/{tokenID}/history", leaseHandler.GetLeaseHistory)
+			ar.With(adminAuth(models.APIKeyScopeLeasesRead)).Get("/export", snapshotHandler.Export)
+			ar.With(adminAuth(models.APIKeyScopeLeasesWrite)).Post("/import", snapshotHandler.Import)
+			ar.With(adminAuth(models.APIKeyScopeLeasesWrite)).Post("/leases/revoke", revocationHandler.Revoke)
+			ar.With(adminAuth(models.APIKeyScopeLeasesRead)).Get("/leases/revoke/{revocationID}", revocationHandler.Revocation)
+
+			// Every other route needs admin:full
+			ar.Group(func(fr chi.Router) {
+				fr.Use(adminAuth(models.APIKeyScopeAdminFull))
+
+				fr.Get("/diagnostics/redis-memory", adminHandler.RedisMemory)
+				fr.Get("/nonces/metrics", adminHandler.NonceMetrics)
+				fr.Get("/peers/{peerID}/nonces", adminHandler.PeerNonces)
+				fr.Delete("/peers/{peerID}/nonces", adminHandler.RevokePeerNonces)
+				fr.Get("/auth/signature-cache", adminHandler.SignatureCacheStats)
+				fr.Post("/server-keys/rotate", serverInfoHandler.RotateKey)
+				fr.Get("/pool-stats", poolStatsHandler.PoolStats)
+				fr.Get("/pool-ranges", poolStatsHandler.PoolRanges)
+				fr.Get("/metrics", poolStatsHandler.Metrics)
+				fr.Get("/pools", poolStatsHandler.ConnectionPools)
+				fr.Get("/reclamation/metrics", adminHandler.ReclamationMetrics)
+				fr.Get("/events/metrics", adminHandler.EventBusMetrics)
+				fr.Post("/reclamation/run", adminHandler.RunReclamation)
+				fr.Get("/expiry-notifications/report", adminHandler.ExpiryNotificationReport)
+				fr.Post("/expiry-notifications/run", adminHandler.RunExpiryNotifications)
+				fr.Get("/access-rules", accessHandler.ListRules)
+				fr.Post("/access-rules", accessHandler.AddRule)
+				fr.Delete("/access-rules/{ruleID}", accessHandler.RemoveRule)
+				fr.Get("/peer-policies", peerPolicyHandler.ListPolicies)
+				fr.Post("/peer-policies", peerPolicyHandler.AddPolicy)
+				fr.Delete("/peer-policies/{policyID}", peerPolicyHandler.RemovePolicy)
+
+				if cfg.DashboardEnabled {
+					fr.Get("/dashboard/summary", dashboardHandler.Summary)
+					fr.Get("/dashboard/recent-allocations", dashboardHandler.RecentAllocations)
+					fr.Get("/dashboard/top-peers", dashboardHandler.TopPeers)
+				}
+
+				if cfg.RateLimit.BanEnabled {
+					fr.Get("/bans", banHandler.ListBans)
+					fr.Get("/bans/metrics", banHandler.BanMetrics)
+					fr.Get("/bans/{client}", banHandler.GetBanStatus)
+					fr.Delete("/bans/{client}", banHandler.LiftBan)
+				}
+
+				if cfg.FaultInjectionEnabled {
+					fr.Get("/faults", faultHandler.ListFaults)
+					fr.Put("/faults/{target}", faultHandler.SetFault)
+					fr.Delete("/faults/{target}", faultHandler.ClearFault)
+				}
+
+				if cfg.DiagnosticsEnabled {
+					fr.Group(func(dr chi.Router) {
+						dr.Use(httpMiddleware.AllowNetworks(cfg.DiagnosticsAllowedCIDRs, rateLimiter.ClientIP))
+
+						dr.Get("/debug/runtime", diagnosticsHandler.Runtime)
+						dr.Get("/debug/vars", diagnosticsHandler.Vars)
+						dr.Get("/debug/pprof/", diagnosticsHandler.ProfileIndex)
+						dr.Get("/debug/pprof/cmdline", diagnosticsHandler.Cmdline)
+						dr.Get("/debug/pprof/profile", diagnosticsHandler.CPUProfile)
+						dr.Get("/debug/pprof/symbol", diagnosticsHandler.Symbol)
+						dr.Post("/debug/pprof/symbol", diagnosticsHandler.Symbol)
+						dr.Get("/debug/pprof/trace", diagnosticsHandler.Trace)
+						dr.Get("/debug/pprof/{profile}", diagnosticsHandler.Profile)
+					})
+				}
+			})
+		})
+
+		// Dashboard page, reading the admin routes above with the token it asks for
+		if cfg.DashboardEnabled {
+			r.Group(func(dr chi.Router) {
+				dr.Use(adminNetworks)
+
+				dr.Get("/dashboard", http.RedirectHandler("/dashboard/", http.StatusMovedPermanently).ServeHTTP)
+				dr.Get("/dashboard/*", dashboardHandler.Static)
+			})
+		}
+	}
 
 	return &Router{
 		Mux: r,
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"slices"
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p/core/host"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/p2p"
+	appUtils "github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// Authentication methods reported by /v1/server-info
+const (
+	AuthMethodNonceSignature = "nonce_signature" // X-Pubkey, X-Nonce and X-Signature headers, or JSON body fields
+	AuthMethodSecureChannel  = "secure_channel"  // libp2p lease protocol streams
+)
+
+// Optional API features reported by /v1/server-info
+const (
+	FeatureRequestedTokenID  = "requested_token_id"
+	FeatureAffinityGroups    = "affinity_groups"
+	FeatureLeaseTransfer     = "lease_transfer"
+	FeatureConflictReports   = "conflict_reports"
+	FeatureBatch             = "batch"
+	FeatureIdempotencyKeys   = "idempotency_keys"
+	FeatureLeaseCertificates = "lease_certificates"
+	FeatureTenants           = "tenants"
+	FeatureLeaseDelegation   = "lease_delegation"
+	FeatureJSONBodies        = "json_bodies"
+	FeatureCBORResponses     = "cbor_responses"
+	FeatureProtobufResponses = "protobuf_responses"
+	FeatureLeaseWait         = "lease_wait"
+	FeatureLeaseLabels       = "lease_labels"
+	FeatureZones             = "zones"
+)
+
+const unknownBuildVersion = "dev"
+
+// ServerInfoHandler describes the server and its capabilities, so clients can adapt to it
+// instead of assuming defaults. The description follows reloaded settings and rotated
+// signing keys; the pool is the one of the tenant the request is served for.
+type ServerInfoHandler struct {
+	signer        ports.LeaseSigner
+	tenants       ports.TenantResolver
+	version       string
+	leaseProtocol string // empty without a libp2p host
+	ha            ports.HAController
+	info          atomic.Pointer[ServerInfoResponse]
+}
+
+// NewServerInfoHandler creates the handler. version, h and ha are optional.
+func NewServerInfoHandler(signer ports.LeaseSigner, tenants ports.TenantResolver, cfg *config.AppConfig, version config.BuildVersion, h host.Host, ha ports.HAController) (*ServerInfoHandler, error) {
+	handler := &ServerInfoHandler{signer: signer, tenants: tenants, version: string(version), ha: ha}
+	if handler.version == "" {
+		handler.version = unknownBuildVersion
+	}
+
+	if h != nil {
+		handler.leaseProtocol = string(p2p.ProtocolID)
+	}
+
+	handler.ApplyConfig(cfg)
+	return handler, nil
+}
+
+// ApplyConfig describes the server with reloaded settings
+func (h *ServerInfoHandler) ApplyConfig(cfg *config.AppConfig) {
+	info := &ServerInfoResponse{
+		Version: h.version,
+		Pools:   []*ServerPoolInfo{newServerPoolInfo(cfg.PoolMinTokenID, cfg.PoolMaxTokenID, cfg.TenantZones()[models.DefaultTenantID])},
+		// Leases are granted and renewed for lease.ttl, clients cannot pick another lifetime
+		LeaseTTL: &LeaseTTLBounds{MinMinutes: cfg.Lease.TTL, MaxMinutes: cfg.Lease.TTL},
+		Auth: &ServerAuthInfo{
+			Methods:                []string{AuthMethodNonceSignature},
+			IdentityScheme:         cfg.Security.IdentityScheme,
+			TimestampRequired:      cfg.Security.TimestampRequired,
+			TimestampWindowSeconds: cfg.Security.TimestampWindow,
+			LookupAuthRequired:     cfg.Security.LookupAuthRequired,
+			AllowListRequired:      cfg.Security.AccessAllowListRequired,
+		},
+		Features: []string{
+			FeatureRequestedTokenID,
+			FeatureAffinityGroups,
+			FeatureLeaseTransfer,
+			FeatureConflictReports,
+			FeatureBatch,
+			FeatureJSONBodies,
+			FeatureCBORResponses,
+			FeatureProtobufResponses,
+			FeatureLeaseWait,
+			FeatureLeaseLabels,
+			FeatureZones,
+		},
+		BatchMaxOperations: cfg.Lease.BatchMaxOperations,
+		LeaseProtocol:      h.leaseProtocol,
+	}
+	if h.leaseProtocol != "" {
+		info.Auth.Methods = append(info.Auth.Methods, AuthMethodSecureChannel)
+	}
+	if cfg.Lease.IdempotencyWindow > 0 {
+		info.Features = append(info.Features, FeatureIdempotencyKeys)
+	}
+	if len(cfg.Tenants) > 0 {
+		info.Features = append(info.Features, FeatureTenants)
+	}
+	if len(cfg.DelegationGateways) > 0 {
+		info.Features = append(info.Features, FeatureLeaseDelegation)
+	}
+
+	h.info.Store(info)
+}
+
+// ServerInfo returns the server's version, pool, lease and authentication settings,
+// the optional features it supports, the key issued leases are signed with and its role
+// in active/standby mode
+func (h *ServerInfoHandler) ServerInfo(w http.ResponseWriter, r *http.Request) {
+	info := h.info.Load()
+
+	// The signing key changes with rotations, not only with reloads
+	if keys := h.signer.Keys(); len(keys) > 0 {
+		signed := *info
+		signed.PublicKey = base64.StdEncoding.EncodeToString(keys[0].PublicKey)
+		signed.PeerID = keys[0].PeerID
+		signed.KeyID = keys[0].KeyID
+		signed.LeaseSigning = true
+		signed.Features = append(slices.Clip(info.Features), FeatureLeaseCertificates)
+		info = &signed
+	}
+
+	if h.ha != nil {
+		status := h.ha.Status()
+		withHA := *info
+		withHA.HA = &status
+		info = &withHA
+	}
+
+	if tenantID := models.TenantFromContext(r.Context()); tenantID != models.DefaultTenantID {
+		tenant, err := h.tenants.Tenant(tenantID)
+		if err != nil {
+			utils.WriteDomainError(w, err)
+			return
+		}
+
+		scoped := *info
+		scoped.Tenant = tenant.ID
+		scoped.Pools = []*ServerPoolInfo{newServerPoolInfo(tenant.MinTokenID, tenant.MaxTokenID, tenant.Zones)}
+		info = &scoped
+	}
+
+	utils.WriteSuccessResponse(w, info)
+}
+
+// Keys publishes the keys lease certificates are verified against: the current key
+// leases are signed with and the retired keys earlier certificates were signed with
+func (h *ServerInfoHandler) Keys(w http.ResponseWriter, r *http.Request) {
+	keys := h.signer.Keys()
+
+	resp := &ServerKeysResponse{Keys: make([]*models.SigningKey, 0, len(keys))}
+	for _, key := range keys {
+		if key.Current {
+			resp.CurrentKeyID = key.KeyID
+		}
+		resp.Keys = append(resp.Keys, key)
+	}
+	utils.WriteSuccessResponse(w, resp)
+}
+
+// RotateKey replaces the key leases are signed with. The retired key stays published, so
+// certificates it signed still verify.
+func (h *ServerInfoHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteServiceCall(h.handleRotateKey, nil)
+}
+
+func (h *ServerInfoHandler) handleRotateKey(ctx context.Context, req interface{}) (interface{}, error) {
+	return h.signer.RotateKey()
+}
+
+func newServerPoolInfo(minTokenID, maxTokenID int64, zones models.Zones) *ServerPoolInfo {
+	info := &ServerPoolInfo{
+		MinTokenID: minTokenID,
+		MaxTokenID: maxTokenID,
+		FirstIP:    appUtils.IPFromTokenID(uint32(minTokenID)),
+		LastIP:     appUtils.IPFromTokenID(uint32(maxTokenID)),
+	}
+	if len(zones) > 0 {
+		info.Zones = zones.Names()
+	}
+	return info
+}
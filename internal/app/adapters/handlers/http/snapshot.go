@@ -0,0 +1,47 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// SnapshotHandler backs up and restores the lease state over the admin API
+type SnapshotHandler struct {
+	snapshots ports.LeaseSnapshotService
+}
+
+func NewSnapshotHandler(snapshots ports.LeaseSnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{snapshots: snapshots}
+}
+
+// Export answers with the snapshot itself rather than a data envelope, so the response
+// can be saved and passed to the import endpoint or the import command as it is
+func (h *SnapshotHandler) Export(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.snapshots.Export(r.Context())
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("dhcp2p-leases-%s.json", snapshot.ExportedAt.Format("20060102T150405Z"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	utils.WriteResponse(w, http.StatusOK, snapshot)
+}
+
+// Import restores an exported snapshot, replacing stored leases only with replace=true
+func (h *SnapshotHandler) Import(w http.ResponseWriter, r *http.Request) {
+	sc := &ServiceCall{Handler: w, Request: r}
+	sc.ExecuteWithValidation(
+		h.handleImport,
+		ValidateSnapshotImportRequest,
+	)
+}
+
+func (h *SnapshotHandler) handleImport(ctx context.Context, req interface{}) (interface{}, error) {
+	importReq := req.(*SnapshotImportRequestData)
+	return h.snapshots.Import(ctx, &importReq.Snapshot, importReq.Replace)
+}
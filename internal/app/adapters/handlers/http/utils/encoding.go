@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Media types responses are encoded in. JSON is the default, CBOR is available for every
+// response and protobuf for the responses that have a message in pkg/pb.
+const (
+	MediaTypeJSON     = "application/json"
+	MediaTypeCBOR     = "application/cbor"
+	MediaTypeProtobuf = "application/x-protobuf"
+)
+
+// cborEncoding mirrors the JSON bodies: struct fields keep their json names and times
+// are RFC 3339 strings
+var cborEncoding = func() cbor.EncMode {
+	mode, err := cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// mediaRange is one entry of an Accept header
+type mediaRange struct {
+	mediaType string // type/subtype, type/* or */*
+	quality   float64
+}
+
+// Accept is a parsed Accept header. The zero value accepts every media type.
+type Accept []mediaRange
+
+// ParseAccept parses an Accept header. Malformed entries are skipped.
+func ParseAccept(header string) Accept {
+	var accept Accept
+	for _, entry := range strings.Split(header, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(entry)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+		accept = append(accept, mediaRange{mediaType: mediaType, quality: quality})
+	}
+	return accept
+}
+
+// Quality returns the preference the header gives mediaType, from 0 (not acceptable) to
+// 1. The most specific matching range decides, as RFC 9110 prescribes.
+func (a Accept) Quality(mediaType string) float64 {
+	if a == nil {
+		return 1
+	}
+
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, r := range a {
+		var s int
+		switch r.mediaType {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+	return quality
+}
+
+// Negotiate returns the offered media type the header prefers, the earlier offer on a
+// tie, or an empty string when none is acceptable
+func (a Accept) Negotiate(offers ...string) string {
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		if q := a.Quality(offer); q > bestQuality {
+			best, bestQuality = offer, q
+		}
+	}
+	return best
+}
+
+// acceptWriter carries the Accept header of a request to the response helpers, which
+// only see the writer
+type acceptWriter struct {
+	http.ResponseWriter
+	accept Accept
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *acceptWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithAccept returns w, with the responses written to it encoded in the media type
+// accept prefers. Writers wrapping the result must implement Unwrap.
+func WithAccept(w http.ResponseWriter, accept Accept) http.ResponseWriter {
+	return &acceptWriter{ResponseWriter: w, accept: accept}
+}
+
+// acceptOf returns the Accept header attached to w by WithAccept, nil when there is none
+func acceptOf(w http.ResponseWriter) Accept {
+	for {
+		switch ww := w.(type) {
+		case *acceptWriter:
+			return ww.accept
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = ww.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// LeaseETag returns the entity tag of a lease. It changes whenever the lease is written,
+// allocated, renewed or transferred, or its labels change, and not as its TTL counts down.
+// The tag is weak: the JSON, CBOR and protobuf encodings of a lease share it.
+func LeaseETag(lease *models.Lease) string {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(lease.TokenID))
+	binary.BigEndian.PutUint64(buf[8:], uint64(lease.UpdatedAt.UnixNano()))
+	h := sha256.New()
+	h.Write(buf[:])
+	for _, key := range slices.Sorted(maps.Keys(lease.Labels)) {
+		h.Write([]byte(key + "=" + lease.Labels[key] + "\x00"))
+	}
+	sum := h.Sum(nil)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// MatchesETag reports whether an If-None-Match header names etag, using the weak
+// comparison RFC 9110 prescribes for it
+func MatchesETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ProtoResponse is implemented by response data with a protobuf encoding
+type ProtoResponse interface {
+	ProtoResponse() *pb.Response
+}
+
+// protoMessage returns the protobuf encoding of response data, nil when it has none
+func protoMessage(data interface{}) proto.Message {
+	switch d := data.(type) {
+	case ProtoResponse:
+		return d.ProtoResponse()
+	case *models.Lease:
+		return &pb.Response{Body: &pb.Response_Lease{Lease: LeaseMessage(d)}}
+	}
+	return nil
+}
+
+// LeaseMessage converts a lease to its protobuf message
+func LeaseMessage(lease *models.Lease) *pb.Lease {
+	if lease == nil {
+		return nil
+	}
+	return &pb.Lease{
+		TokenId:     lease.TokenID,
+		PeerId:      lease.PeerID,
+		CreatedAt:   timestamppb.New(lease.CreatedAt),
+		UpdatedAt:   timestamppb.New(lease.UpdatedAt),
+		ExpiresAt:   timestamppb.New(lease.ExpiresAt),
+		Ttl:         lease.Ttl,
+		Signature:   lease.Signature,
+		KeyId:       lease.KeyID,
+		Tenant:      lease.Tenant,
+		RenewableAt: timestampMessage(lease.RenewableAt),
+		RenewAfter:  timestampMessage(lease.RenewAfter),
+	}
+}
+
+func timestampMessage(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func (e ErrorResponse) protoResponse() *pb.Response {
+	return &pb.Response{Body: &pb.Response_Error{Error: &pb.Error{
+		Type:    e.Type,
+		Code:    e.Code,
+		Message: e.Message,
+		Details: e.Details,
+	}}}
+}
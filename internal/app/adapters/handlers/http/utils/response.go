@@ -3,8 +3,10 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"google.golang.org/protobuf/proto"
 )
 
 // ErrorResponse represents a structured error response
@@ -20,10 +22,8 @@ type SuccessResponse struct {
 	Data interface{} `json:"data"`
 }
 
-// WriteErrorResponse writes a structured error response
-func WriteErrorResponse(w http.ResponseWriter, err error) {
-	w.Header().Set("Content-Type", "application/json")
-
+// NewErrorResponse converts an error into its structured response form
+func NewErrorResponse(err error) (int, ErrorResponse) {
 	var appErr *errors.AppError
 	if errors.IsAppError(err) {
 		appErr = errors.GetAppError(err)
@@ -32,39 +32,64 @@ func WriteErrorResponse(w http.ResponseWriter, err error) {
 		appErr = errors.WrapError(err, errors.ErrorTypeInternal, "UNKNOWN_ERROR", "An unexpected error occurred")
 	}
 
-	w.WriteHeader(appErr.HTTPStatus())
-
-	errorResp := ErrorResponse{
+	return appErr.HTTPStatus(), ErrorResponse{
 		Type:    string(appErr.Type),
 		Code:    appErr.Code,
 		Message: appErr.Message,
 		Details: appErr.Details,
 	}
+}
 
-	if encodeErr := json.NewEncoder(w).Encode(errorResp); encodeErr != nil {
-		http.Error(w, "Failed to encode error response", http.StatusInternalServerError)
-	}
+// WriteErrorResponse writes a structured error response
+func WriteErrorResponse(w http.ResponseWriter, err error) {
+	status, errorResp := NewErrorResponse(err)
+	writeEncoded(w, status, errorResp, errorResp.protoResponse())
 }
 
 // WriteSuccessResponse writes a successful response
 func WriteSuccessResponse(w http.ResponseWriter, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
-	response := SuccessResponse{Data: data}
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
+	writeEncoded(w, http.StatusOK, SuccessResponse{Data: data}, protoMessage(data))
 }
 
 // WriteResponse writes a response with custom status code
 func WriteResponse(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+	writeEncoded(w, statusCode, data, nil)
+}
+
+// writeEncoded writes body in the media type the request prefers, see WithAccept: JSON,
+// CBOR or, when the body has one, its protobuf message. Requests accepting none of them
+// get 406 NOT_ACCEPTABLE in JSON.
+func writeEncoded(w http.ResponseWriter, statusCode int, body interface{}, message proto.Message) {
+	offers := []string{MediaTypeJSON, MediaTypeCBOR}
+	if message != nil {
+		offers = append(offers, MediaTypeProtobuf)
+	}
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	mediaType := acceptOf(w).Negotiate(offers...)
+	if mediaType == "" {
+		mediaType, statusCode = MediaTypeJSON, errors.ErrNotAcceptable.HTTPStatus()
+		_, body = NewErrorResponse(errors.ErrNotAcceptable.WithDetails("available: " + strings.Join(offers, ", ")))
+	}
+
+	var data []byte
+	var err error
+	switch mediaType {
+	case MediaTypeCBOR:
+		data, err = cborEncoding.Marshal(body)
+	case MediaTypeProtobuf:
+		data, err = proto.Marshal(message)
+	default:
+		data, err = json.Marshal(body)
+		data = append(data, '\n')
+	}
+	if err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
 	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(statusCode)
+	w.Write(data)
 }
 
 // ParseRequestBody parses the request body into the given struct
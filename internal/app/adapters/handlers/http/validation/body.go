@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// RequestBody is the JSON body the lease and auth endpoints accept in place of the
+// X-Pubkey, X-Nonce, X-Signature, X-Timestamp and X-Zone headers and the tokenID,
+// affinityGroup and labels query parameters. Fields left out fall back to the header or
+// parameter.
+type RequestBody struct {
+	Pubkey        string            `json:"pubkey,omitempty"`
+	Nonce         string            `json:"nonce,omitempty"`
+	Signature     string            `json:"signature,omitempty"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	TokenID       int64             `json:"token_id,omitempty"`
+	AffinityGroup string            `json:"affinity_group,omitempty"`
+	Zone          string            `json:"zone,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"` // an empty object clears the labels
+}
+
+// IsJSONRequest reports whether the Content-Type of r is application/json
+func IsJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// DecodeRequestBody strictly decodes the JSON body of r: unknown fields, values of the
+// wrong type and anything after the object are rejected. An empty body decodes to an
+// empty RequestBody, so clients that label header-only requests as JSON keep working.
+func DecodeRequestBody(r *http.Request) (*RequestBody, error) {
+	body := &RequestBody{}
+	if r.Body == nil || r.Body == http.NoBody {
+		return body, nil
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return body, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(body); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON object")
+	}
+	return body, nil
+}
+
+type requestBodyContextKey struct{}
+
+// ContextWithRequestBody returns a context carrying the JSON body decoded for a request
+func ContextWithRequestBody(ctx context.Context, body *RequestBody) context.Context {
+	return context.WithValue(ctx, requestBodyContextKey{}, body)
+}
+
+// RequestBodyFromContext returns the JSON body decoded for r, or an empty body when the
+// request did not carry one
+func RequestBodyFromContext(r *http.Request) *RequestBody {
+	if body, ok := r.Context().Value(requestBodyContextKey{}).(*RequestBody); ok {
+		return body
+	}
+	return &RequestBody{}
+}
+
+// ValidateHeaderOrBody validates bodyValue, a field of the JSON body, when it is set and
+// the header otherwise, so both are held to the same rules
+func ValidateHeaderOrBody(r *http.Request, headerName, bodyValue, fieldName string, config ValidationConfig) ValidationResult {
+	if bodyValue == "" {
+		return ValidateHeader(r, headerName, config)
+	}
+	return validateString(bodyValue, fieldName, config)
+}
+
+// ValidateQueryOrBody validates bodyValue when it is set and the query parameter otherwise
+func ValidateQueryOrBody(r *http.Request, paramName, bodyValue string, config ValidationConfig) ValidationResult {
+	if bodyValue == "" {
+		return ValidateQueryParam(r, paramName, config)
+	}
+	return validateString(bodyValue, paramName, config)
+}
+
+// TokenIDFromQueryOrBody returns the token ID of the JSON body, or the tokenID query
+// parameter when the body has none
+func TokenIDFromQueryOrBody(r *http.Request) string {
+	if tokenID := RequestBodyFromContext(r).TokenID; tokenID != 0 {
+		return strconv.FormatInt(tokenID, 10)
+	}
+	return r.URL.Query().Get("tokenID")
+}
+
+// LabelsFromQueryOrBody returns the lease labels of the JSON body, or the labels query
+// parameter when the body has none, normalized. The parameter is a comma separated list of
+// key=value pairs. It returns nil when neither is given and an empty map when the labels
+// are to be cleared, by an empty object or parameter.
+func LabelsFromQueryOrBody(r *http.Request) (map[string]string, error) {
+	labels := RequestBodyFromContext(r).Labels
+	if labels == nil {
+		query := r.URL.Query()
+		if !query.Has("labels") {
+			return nil, nil
+		}
+		labels = map[string]string{}
+		if raw := query.Get("labels"); raw != "" {
+			for _, pair := range strings.Split(raw, ",") {
+				key, value, ok := strings.Cut(pair, "=")
+				if !ok {
+					return nil, domainErrors.ErrInvalidLabels.WithDetails("labels must be key=value pairs separated by commas")
+				}
+				labels[strings.TrimSpace(key)] = value
+			}
+		}
+	}
+
+	normalized, err := models.NormalizeLeaseLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	if normalized == nil {
+		normalized = map[string]string{}
+	}
+	return normalized, nil
+}
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"encoding/base64"
+	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
@@ -179,35 +180,81 @@ func RemoveControlCharacters(input string) string {
 	}, input)
 }
 
-// ValidateAndSanitizeRequest performs comprehensive validation and sanitization
-func ValidateAndSanitizeRequest(r *http.Request) error {
-	// Check for suspicious patterns in headers
+// minEncodedLength is the length from which a header value that decodes as base64 is
+// taken for binary data, keys and signatures, and not matched against the patterns
+const minEncodedLength = 16
+
+// RequestRules are the checks every request passes before it is routed. Zero lengths
+// disable their check.
+type RequestRules struct {
+	Patterns            []*regexp.Regexp    // refused in header values
+	ExemptHeaders       map[string]struct{} // canonical names of headers not matched against Patterns
+	MaxHeaderLength     int
+	MaxHeaderNameLength int
+	MaxURLLength        int
+}
+
+// NewRequestRules compiles the patterns, which match without case
+func NewRequestRules(patterns, exemptHeaders []string, maxHeaderLength, maxHeaderNameLength, maxURLLength int) (*RequestRules, error) {
+	rules := &RequestRules{
+		ExemptHeaders:       make(map[string]struct{}, len(exemptHeaders)),
+		MaxHeaderLength:     maxHeaderLength,
+		MaxHeaderNameLength: maxHeaderNameLength,
+		MaxURLLength:        maxURLLength,
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, err
+		}
+		rules.Patterns = append(rules.Patterns, re)
+	}
+	for _, name := range exemptHeaders {
+		rules.ExemptHeaders[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+	return rules, nil
+}
+
+// Validate refuses requests with a header value matching a pattern or with a header or
+// URL over its length limit
+func (rules *RequestRules) Validate(r *http.Request) error {
 	for name, values := range r.Header {
+		if rules.MaxHeaderNameLength > 0 && len(name) > rules.MaxHeaderNameLength {
+			return errors.ErrInvalidRequest.WithDetails(fmt.Sprintf("header name exceeds %d bytes", rules.MaxHeaderNameLength))
+		}
+
+		_, exempt := rules.ExemptHeaders[name]
 		for _, value := range values {
-			// Check for potential injection attempts
-			if strings.Contains(strings.ToLower(value), "<script") ||
-				strings.Contains(strings.ToLower(value), "javascript:") ||
-				strings.Contains(strings.ToLower(value), "onload=") ||
-				strings.Contains(strings.ToLower(value), "onerror=") {
-				return errors.ErrInvalidPubkey
+			if rules.MaxHeaderLength > 0 && len(value) > rules.MaxHeaderLength {
+				return errors.ErrInvalidRequest.WithDetails(fmt.Sprintf("header %s exceeds %d bytes", name, rules.MaxHeaderLength))
 			}
-
-			// Check header length
-			if len(value) > 8192 { // 8KB limit per header
-				return errors.ErrInvalidPubkey
+			if exempt || isEncoded(value) {
+				continue
+			}
+			for _, pattern := range rules.Patterns {
+				if pattern.MatchString(value) {
+					return errors.ErrInvalidRequest.WithDetails(fmt.Sprintf("header %s contains a suspicious value", name))
+				}
 			}
-		}
-
-		// Check header name length
-		if len(name) > 256 {
-			return errors.ErrInvalidPubkey
 		}
 	}
 
-	// Check URL length
-	if len(r.URL.String()) > 8192 {
-		return errors.ErrInvalidPubkey
+	if rules.MaxURLLength > 0 && len(r.URL.String()) > rules.MaxURLLength {
+		return errors.ErrInvalidRequest.WithDetails(fmt.Sprintf("URL exceeds %d bytes", rules.MaxURLLength))
 	}
 
 	return nil
 }
+
+// isEncoded reports whether a header value is binary data in one of the base64 encodings
+func isEncoded(value string) bool {
+	if len(value) < minEncodedLength {
+		return false
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if _, err := encoding.DecodeString(value); err == nil {
+			return true
+		}
+	}
+	return false
+}
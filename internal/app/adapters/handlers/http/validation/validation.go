@@ -8,8 +8,9 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/keys"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
 )
 
 // ValidationResult represents the result of a validation operation
@@ -42,7 +43,7 @@ func DefaultValidationConfig() ValidationConfig {
 // PeerIDValidationConfig returns configuration for peer ID validation
 func PeerIDValidationConfig() ValidationConfig {
 	return ValidationConfig{
-		MaxLength:      128,
+		MaxLength:      models.MaxPeerIDLength,
 		MinLength:      10,
 		Required:       true,
 		AllowEmpty:     false,
@@ -85,6 +86,30 @@ func SignatureValidationConfig() ValidationConfig {
 	}
 }
 
+// AffinityGroupValidationConfig returns configuration for affinity group validation
+func AffinityGroupValidationConfig() ValidationConfig {
+	return ValidationConfig{
+		MaxLength:      64,
+		MinLength:      1,
+		Required:       false,
+		AllowEmpty:     true,
+		TrimWhitespace: true,
+		Pattern:        `^[a-zA-Z0-9._-]+$`,
+	}
+}
+
+// ZoneValidationConfig returns configuration for zone hint validation
+func ZoneValidationConfig() ValidationConfig {
+	return ValidationConfig{
+		MaxLength:      64,
+		MinLength:      1,
+		Required:       false,
+		AllowEmpty:     true,
+		TrimWhitespace: true,
+		Pattern:        `^[a-zA-Z0-9._-]+$`,
+	}
+}
+
 // ValidateHeader validates and extracts a header value
 func ValidateHeader(r *http.Request, headerName string, config ValidationConfig) ValidationResult {
 	value := r.Header.Get(headerName)
@@ -103,15 +128,16 @@ func ValidateQueryParam(r *http.Request, paramName string, config ValidationConf
 	return validateString(value, paramName, config)
 }
 
+// ValidateValue validates a value that did not arrive in an HTTP request, e.g. a field
+// of a p2p message
+func ValidateValue(value, fieldName string, config ValidationConfig) ValidationResult {
+	return validateString(value, fieldName, config)
+}
+
 // ValidatePeerIDFromContext validates and extracts peerID from request context
 func ValidatePeerIDFromContext(r *http.Request) ValidationResult {
-	peerIDValue := r.Context().Value(keys.PeerIDContextKey)
-	if peerIDValue == nil {
-		return ValidationResult{Error: errors.ErrMissingPeerID}
-	}
-
-	peerID, ok := peerIDValue.(string)
-	if !ok || peerID == "" {
+	peerID, ok := reqctx.PeerID(r.Context())
+	if !ok {
 		return ValidationResult{Error: errors.ErrMissingPeerID}
 	}
 
@@ -129,9 +155,8 @@ func ValidateTokenID(tokenIDStr string) ValidationResult {
 		return ValidationResult{Error: errors.ErrInvalidTokenID}
 	}
 
-	// Token IDs should be positive
-	if tokenID <= 0 {
-		return ValidationResult{Error: errors.ErrInvalidTokenID}
+	if err := models.ValidateTokenID(tokenID); err != nil {
+		return ValidationResult{Error: err}
 	}
 
 	return ValidationResult{Value: strconv.FormatInt(tokenID, 10)}
@@ -241,6 +266,10 @@ func validateString(value, fieldName string, config ValidationConfig) Validation
 		switch fieldName {
 		case "peerID":
 			return ValidationResult{Error: errors.ErrInvalidPeerID}
+		case "affinityGroup":
+			return ValidationResult{Error: errors.ErrInvalidAffinity}
+		case "zone", "X-Zone":
+			return ValidationResult{Error: errors.ErrInvalidZone}
 		case "pubkey":
 			return ValidationResult{Error: errors.ErrInvalidPubkey}
 		case "signature":
@@ -259,6 +288,10 @@ func validateString(value, fieldName string, config ValidationConfig) Validation
 			switch fieldName {
 			case "peerID":
 				return ValidationResult{Error: errors.ErrInvalidPeerID}
+			case "affinityGroup":
+				return ValidationResult{Error: errors.ErrInvalidAffinity}
+			case "zone", "X-Zone":
+				return ValidationResult{Error: errors.ErrInvalidZone}
 			case "nonce":
 				return ValidationResult{Error: errors.ErrInvalidNonce}
 			default:
@@ -3,10 +3,12 @@ package handlers
 import (
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/p2p"
 	"go.uber.org/fx"
 )
 
 var Module = fx.Options(
 	http.Module,
+	p2p.Module,
 	auth.Module,
 )
@@ -0,0 +1,276 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/validation"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// Handler serves the lease protocol on libp2p streams
+type Handler struct {
+	leaseReader     ports.LeaseReader
+	leaseWriter     ports.LeaseWriter
+	nonceService    ports.NonceService
+	authService     ports.AuthService
+	identity        ports.IdentityResolver
+	accessControl   ports.AccessControlService
+	timeout         time.Duration
+	maxMessageBytes int
+	logger          *zap.Logger
+}
+
+func NewHandler(cfg *config.AppConfig, leaseReader ports.LeaseReader, leaseWriter ports.LeaseWriter, nonceService ports.NonceService, authService ports.AuthService, identity ports.IdentityResolver, accessControl ports.AccessControlService, logger *zap.Logger) *Handler {
+	return &Handler{
+		leaseReader:     leaseReader,
+		leaseWriter:     leaseWriter,
+		nonceService:    nonceService,
+		authService:     authService,
+		identity:        identity,
+		accessControl:   accessControl,
+		timeout:         time.Duration(cfg.P2PStreamTimeout) * time.Second,
+		maxMessageBytes: cfg.P2PMaxMessageBytes,
+		logger:          logger,
+	}
+}
+
+// HandleStream answers requests until the peer closes the stream, stays idle longer than
+// the stream timeout, or sends a message that cannot be read
+func (h *Handler) HandleStream(s network.Stream) {
+	defer s.Close()
+
+	peerID, err := h.remoteIdentity(s)
+	if err != nil {
+		h.logger.Debug("Rejecting lease protocol stream", zap.String("remotePeer", s.Conn().RemotePeer().String()), zap.Error(err))
+		json.NewEncoder(s).Encode(&Response{Error: newError(err)})
+		return
+	}
+
+	scanner := bufio.NewScanner(s)
+	scanner.Buffer(make([]byte, 0, 512), h.maxMessageBytes)
+	encoder := json.NewEncoder(s)
+
+	for {
+		s.SetDeadline(time.Now().Add(h.timeout))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				h.logger.Debug("Lease protocol stream closed", zap.String("peerID", peerID), zap.Error(err))
+				if err == bufio.ErrTooLong {
+					encoder.Encode(&Response{Error: newError(errors.ErrRequestTooLarge)})
+				}
+				s.Reset()
+			}
+			return
+		}
+
+		var req Request
+		resp := &Response{}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = newError(errors.ErrInvalidRequest)
+		} else {
+			resp = h.Handle(context.Background(), peerID, &req)
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			h.logger.Debug("Failed to write lease protocol response", zap.String("peerID", peerID), zap.Error(err))
+			s.Reset()
+			return
+		}
+	}
+}
+
+// remoteIdentity resolves the identity of the stream's remote key under the configured
+// identity scheme, so a peer is the same lease holder over HTTP and libp2p. Peers refused
+// by access control are rejected like over HTTP.
+func (h *Handler) remoteIdentity(s network.Stream) (string, error) {
+	pubKey := s.Conn().RemotePublicKey()
+	if pubKey == nil {
+		return "", errors.ErrMissingPubkey
+	}
+	pubkey, err := crypto.MarshalPublicKey(pubKey)
+	if err != nil {
+		return "", errors.ErrInvalidPubkey
+	}
+	peerID, err := h.identity.ResolvePeerID(pubkey)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	if err := h.accessControl.CheckAccess(ctx, peerID, pubkey); err != nil {
+		return "", err
+	}
+	return peerID, nil
+}
+
+// Handle executes a request of the authenticated peer peerID, or of the peer it relays
+// the request for
+func (h *Handler) Handle(ctx context.Context, peerID string, req *Request) *Response {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	data, err := h.handle(ctx, peerID, req)
+	if err != nil {
+		return &Response{ID: req.ID, Error: newError(err)}
+	}
+	return &Response{ID: req.ID, Data: data}
+}
+
+func (h *Handler) handle(ctx context.Context, peerID string, req *Request) (any, error) {
+	if !req.relayed() {
+		return h.execute(ctx, peerID, req)
+	}
+
+	if req.Op == OpNonce {
+		return h.relayedNonce(ctx, req.Pubkey)
+	}
+
+	// The relayed peer passes the same checks as over HTTP, access control included
+	relayedPeerID, err := middleware.Authenticate(ctx, h.authService, h.accessControl, req.Pubkey, req.Nonce, req.Signature, req.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	h.logger.Debug("Executing relayed lease protocol request", zap.String("op", req.Op), zap.String("relayPeerID", peerID), zap.String("peerID", relayedPeerID))
+	return h.execute(ctx, relayedPeerID, req)
+}
+
+// relayedNonce issues a nonce for the peer owning pubkey, for it to sign the next request
+// relayed on its behalf
+func (h *Handler) relayedNonce(ctx context.Context, pubkey string) (*NonceResponse, error) {
+	result := validation.ValidateBase64Pubkey(pubkey)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	pub, err := base64.StdEncoding.DecodeString(result.Value)
+	if err != nil {
+		return nil, errors.ErrInvalidPubkey
+	}
+	peerID, err := h.identity.ResolvePeerID(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := h.nonceService.CreateNonce(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+	return &NonceResponse{Nonce: nonce.ID, ExpiresAt: nonce.ExpiresAt}, nil
+}
+
+func (h *Handler) execute(ctx context.Context, peerID string, req *Request) (any, error) {
+	ctx = reqctx.WithAuthMethod(reqctx.WithPeerID(ctx, peerID), reqctx.AuthMethodP2P)
+
+	switch req.Op {
+	case OpAllocate:
+		affinity := validation.ValidateValue(req.AffinityGroup, "affinityGroup", validation.AffinityGroupValidationConfig())
+		if affinity.Error != nil {
+			return nil, affinity.Error
+		}
+		if req.TokenID < 0 {
+			return nil, errors.ErrInvalidTokenID
+		}
+		zone := validation.ValidateValue(req.Zone, "zone", validation.ZoneValidationConfig())
+		if zone.Error != nil {
+			return nil, zone.Error
+		}
+		if req.TokenID != 0 && affinity.Value != "" {
+			return nil, errors.ErrConflictingOptions
+		}
+
+		if affinity.Value != "" {
+			return h.leaseWriter.AllocateAffinityIP(ctx, peerID, affinity.Value)
+		}
+		if req.TokenID == 0 && zone.Value != "" {
+			return h.leaseWriter.AllocateZoneIP(ctx, peerID, zone.Value)
+		}
+		if req.TokenID == 0 {
+			return h.leaseWriter.AllocateIP(ctx, peerID)
+		}
+
+		result, err := h.leaseWriter.AllocateRequestedIP(ctx, peerID, req.TokenID)
+		if err != nil {
+			return nil, err
+		}
+		return &AllocateResponse{
+			Lease:            result.Lease,
+			RequestedTokenID: result.RequestedTokenID,
+			Granted:          result.Granted,
+			Reason:           string(result.Reason),
+		}, nil
+
+	case OpRenew:
+		if err := validateTokenID(req.TokenID); err != nil {
+			return nil, err
+		}
+		return h.leaseWriter.RenewLease(ctx, req.TokenID, peerID)
+
+	case OpRelease:
+		if err := validateTokenID(req.TokenID); err != nil {
+			return nil, err
+		}
+		if err := h.leaseWriter.ReleaseLease(ctx, req.TokenID, peerID); err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "success"}, nil
+
+	case OpGet:
+		switch {
+		case req.TokenID != 0 && req.PeerID != "":
+			return nil, errors.ErrConflictingOptions
+		case req.TokenID != 0:
+			if err := validateTokenID(req.TokenID); err != nil {
+				return nil, err
+			}
+			return h.leaseReader.GetLeaseByTokenID(ctx, req.TokenID)
+		case req.PeerID != "":
+			return h.leaseReader.GetLeaseByPeerID(ctx, req.PeerID)
+		default:
+			return h.leaseReader.GetLeaseByPeerID(ctx, peerID)
+		}
+
+	case OpNonce:
+		nonce, err := h.nonceService.CreateNonce(ctx, peerID)
+		if err != nil {
+			return nil, err
+		}
+		return &NonceResponse{Nonce: nonce.ID, ExpiresAt: nonce.ExpiresAt}, nil
+
+	default:
+		return nil, errors.ErrInvalidRequest
+	}
+}
+
+func validateTokenID(tokenID int64) error {
+	if tokenID == 0 {
+		return errors.ErrMissingTokenID
+	}
+	return models.ValidateTokenID(tokenID)
+}
+
+func newError(err error) *Error {
+	appErr := errors.GetAppError(err)
+	if appErr == nil {
+		// Wrap unknown errors as internal errors
+		appErr = errors.WrapError(err, errors.ErrorTypeInternal, "UNKNOWN_ERROR", "An unexpected error occurred")
+	}
+
+	return &Error{
+		Type:    string(appErr.Type),
+		Code:    appErr.Code,
+		Message: appErr.Message,
+		Details: appErr.Details,
+	}
+}
@@ -0,0 +1,35 @@
+package p2p
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module serves the lease protocol on the libp2p host, if the application is given one
+var Module = fx.Options(
+	fx.Provide(NewHandler),
+	fx.Invoke(fx.Annotate(Register, fx.ParamTags(``, ``, `optional:"true"`, ``))),
+)
+
+// Register sets the lease protocol handler on h for the lifetime of the application.
+// Without a host the protocol is not served.
+func Register(lc fx.Lifecycle, handler *Handler, h host.Host, logger *zap.Logger) {
+	if h == nil {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			h.SetStreamHandler(ProtocolID, handler.HandleStream)
+			logger.Info("Serving lease protocol", zap.String("protocol", string(ProtocolID)), zap.String("peerID", h.ID().String()))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			h.RemoveStreamHandler(ProtocolID)
+			return nil
+		},
+	})
+}
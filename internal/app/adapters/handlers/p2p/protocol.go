@@ -0,0 +1,77 @@
+package p2p
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// ProtocolID identifies the lease protocol. Each message is a single line of JSON; a peer
+// may send several requests on one stream and receives one response line per request.
+const ProtocolID protocol.ID = "/dhcp2p/1.0.0"
+
+// Operations of the lease protocol
+const (
+	OpAllocate = "allocate" // allocate a lease, optionally a requested token ID or in an affinity group
+	OpRenew    = "renew"    // renew the caller's lease on token_id
+	OpRelease  = "release"  // release the caller's lease on token_id
+	OpGet      = "get"      // look up the lease of peer_id or token_id, or the caller's own lease
+	OpNonce    = "nonce"    // issue a nonce for the caller, or for the peer owning pubkey
+)
+
+// Request is a message sent by a peer. The peer is identified by the secure channel of
+// the connection, so its own requests carry no credentials.
+//
+// A peer can also relay requests of a peer that cannot reach the server itself. It asks
+// for a nonce with OpNonce and the other peer's pubkey, has that peer sign the nonce as
+// for HTTP, and sends the credentials along with each request, which is then executed as
+// the signing peer. Every relayed request consumes a nonce.
+type Request struct {
+	ID            string `json:"id,omitempty"` // echoed in the response
+	Op            string `json:"op"`
+	TokenID       int64  `json:"token_id,omitempty"`
+	PeerID        string `json:"peer_id,omitempty"`
+	AffinityGroup string `json:"affinity_group,omitempty"`
+	Zone          string `json:"zone,omitempty"` // preferred rack or site of an allocation
+
+	// Base64-encoded credentials of the peer a request is relayed for
+	Pubkey    string `json:"pubkey,omitempty"`
+	Nonce     string `json:"nonce,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// relayed tells whether the request is made on behalf of another peer
+func (r *Request) relayed() bool {
+	return r.Pubkey != "" || r.Nonce != "" || r.Signature != ""
+}
+
+// Response answers a Request with either Data or Error, matching the HTTP envelope
+type Response struct {
+	ID    string `json:"id,omitempty"`
+	Data  any    `json:"data,omitempty"`
+	Error *Error `json:"error,omitempty"`
+}
+
+// Error mirrors the HTTP error body
+type Error struct {
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+// AllocateResponse is returned for allocations of a requested token ID
+type AllocateResponse struct {
+	Lease            *models.Lease `json:"lease"`
+	RequestedTokenID int64         `json:"requested_token_id"`
+	Granted          bool          `json:"granted"`
+	Reason           string        `json:"reason"`
+}
+
+// NonceResponse is returned for OpNonce
+type NonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
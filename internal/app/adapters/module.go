@@ -1,12 +1,22 @@
 package adapters
 
 import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/discovery"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/dns"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/notifications"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories"
 	"go.uber.org/fx"
 )
 
-var Module = fx.Options(
-	handlers.Module,
-	repositories.Module,
-)
+// NewModule wires the handlers, the notification channels, the DNS publisher, the mDNS
+// advertiser and the repositories of the given storage backend
+func NewModule(storageBackend string) fx.Option {
+	return fx.Options(
+		handlers.Module,
+		notifications.Module,
+		dns.Module,
+		discovery.Module,
+		repositories.NewModule(storageBackend),
+	)
+}
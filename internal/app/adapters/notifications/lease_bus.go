@@ -0,0 +1,41 @@
+package notifications
+
+import (
+	"strconv"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// LeaseBusPublisher publishes lease lifecycle events on the event bus
+type LeaseBusPublisher struct {
+	bus    ports.EventBus
+	logger *zap.Logger
+}
+
+var _ ports.LeaseEventPublisher = &LeaseBusPublisher{}
+
+func NewLeaseBusPublisher(bus ports.EventBus, logger *zap.Logger) *LeaseBusPublisher {
+	return &LeaseBusPublisher{bus: bus, logger: logger.Named("events")}
+}
+
+// Publish queues the event on the bus; an event the bus rejects is dropped
+func (p *LeaseBusPublisher) Publish(event *models.LeaseLifecycleEvent) {
+	err := p.bus.Publish(string(event.Type), strconv.FormatInt(event.TokenID, 10), &models.LeaseEventPayload{
+		OccurredAt: event.OccurredAt.UTC(),
+		TokenID:    event.TokenID,
+		IP:         utils.IPFromTokenID(uint32(event.TokenID)),
+		PeerID:     event.PeerID,
+		Tenant:     event.TenantID,
+		ExpiresAt:  event.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		p.logger.Warn("Lease event not published on the event bus",
+			zap.String("event", string(event.Type)),
+			zap.Int64("tokenID", event.TokenID),
+			zap.Error(err),
+		)
+	}
+}
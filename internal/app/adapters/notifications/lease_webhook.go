@@ -0,0 +1,246 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/retry"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// EventHeader carries the event name of a lease webhook delivery
+const EventHeader = "X-DHCP2P-Event"
+
+// LeaseWebhookPayload is the body posted for every lease lifecycle event
+type LeaseWebhookPayload struct {
+	Event      string    `json:"event"`
+	SentAt     time.Time `json:"sent_at"`
+	OccurredAt time.Time `json:"occurred_at"`
+	TokenID    int64     `json:"token_id"`
+	IP         string    `json:"ip"`
+	PeerID     string    `json:"peer_id"`
+	Tenant     string    `json:"tenant"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// leaseWebhook is one configured endpoint with its own queue, so that a slow or failing
+// endpoint does not hold back the others
+type leaseWebhook struct {
+	url    string
+	secret []byte
+	events []models.LeaseLifecycleEventType // nil subscribes to every event
+	queue  chan *models.LeaseLifecycleEvent
+}
+
+// LeaseWebhookDispatcher posts lease lifecycle events to the configured webhooks in the
+// background, retrying failed deliveries with exponential backoff
+type LeaseWebhookDispatcher struct {
+	webhooks []*leaseWebhook
+	client   *http.Client
+	retry    retry.Policy
+	logger   *zap.Logger
+
+	mu      sync.RWMutex
+	stopped bool
+	ctx     context.Context // canceled when shutdown runs out of time
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+var _ ports.LeaseEventPublisher = &LeaseWebhookDispatcher{}
+
+func NewLeaseWebhookDispatcher(lc fx.Lifecycle, cfg *config.AppConfig, logger *zap.Logger) (*LeaseWebhookDispatcher, error) {
+	d := &LeaseWebhookDispatcher{
+		client: &http.Client{Timeout: time.Duration(cfg.LeaseWebhookTimeout) * time.Second},
+		retry: retry.Policy{
+			MaxAttempts:  cfg.LeaseWebhookRetries + 1,
+			InitialDelay: time.Duration(cfg.LeaseWebhookBackoff) * time.Millisecond,
+			Jitter:       retry.DefaultJitter,
+			Retryable:    isRetryableDelivery,
+		},
+		logger: logger.Named("webhooks"),
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+
+	for i, wc := range cfg.LeaseWebhooks {
+		if u, err := url.Parse(wc.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("lease_webhooks[%d]: url %q must be an absolute http or https URL", i, wc.URL)
+		}
+
+		webhook := &leaseWebhook{
+			url:    wc.URL,
+			secret: []byte(wc.Secret),
+			queue:  make(chan *models.LeaseLifecycleEvent, max(cfg.LeaseWebhookQueueSize, 1)),
+		}
+		for _, event := range wc.Events {
+			eventType := models.LeaseLifecycleEventType(event)
+			if !slices.Contains(models.LeaseLifecycleEventTypes, eventType) {
+				return nil, fmt.Errorf("lease_webhooks[%d]: unknown event %q", i, event)
+			}
+			webhook.events = append(webhook.events, eventType)
+		}
+		d.webhooks = append(d.webhooks, webhook)
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			for _, webhook := range d.webhooks {
+				d.wg.Add(1)
+				go d.run(webhook)
+			}
+			return nil
+		},
+		OnStop: d.stop,
+	})
+
+	return d, nil
+}
+
+// Publish queues the event for every webhook subscribed to it. An event that finds a
+// webhook's queue full is dropped for that webhook.
+func (d *LeaseWebhookDispatcher) Publish(event *models.LeaseLifecycleEvent) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return
+	}
+
+	for _, webhook := range d.webhooks {
+		if webhook.events != nil && !slices.Contains(webhook.events, event.Type) {
+			continue
+		}
+
+		select {
+		case webhook.queue <- event:
+		default:
+			d.logger.Warn("Lease webhook queue full, event dropped",
+				zap.String("url", webhook.url),
+				zap.String("event", string(event.Type)),
+				zap.Int64("tokenID", event.TokenID),
+			)
+		}
+	}
+}
+
+// stop delivers the queued events until ctx is done, then abandons the rest
+func (d *LeaseWebhookDispatcher) stop(ctx context.Context) error {
+	d.mu.Lock()
+	d.stopped = true
+	for _, webhook := range d.webhooks {
+		close(webhook.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+	return nil
+}
+
+func (d *LeaseWebhookDispatcher) run(webhook *leaseWebhook) {
+	defer d.wg.Done()
+	for event := range webhook.queue {
+		d.deliver(webhook, event)
+	}
+}
+
+// deliver posts the event, retrying up to the configured number of times
+func (d *LeaseWebhookDispatcher) deliver(webhook *leaseWebhook, event *models.LeaseLifecycleEvent) {
+	body, err := json.Marshal(&LeaseWebhookPayload{
+		Event:      string(event.Type),
+		SentAt:     time.Now().UTC(),
+		OccurredAt: event.OccurredAt.UTC(),
+		TokenID:    event.TokenID,
+		IP:         utils.IPFromTokenID(uint32(event.TokenID)),
+		PeerID:     event.PeerID,
+		Tenant:     event.TenantID,
+		ExpiresAt:  event.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		d.logger.Error("Error encoding lease webhook payload", zap.Error(err))
+		return
+	}
+
+	attempts := 0
+	err = d.retry.Do(d.ctx, func(ctx context.Context) error {
+		attempts++
+		return d.post(ctx, webhook, event.Type, body)
+	})
+	if err != nil {
+		d.logger.Error("Lease webhook delivery failed",
+			zap.String("url", webhook.url),
+			zap.String("event", string(event.Type)),
+			zap.Int64("tokenID", event.TokenID),
+			zap.Int("attempts", attempts),
+			zap.Error(err),
+		)
+	}
+}
+
+func (d *LeaseWebhookDispatcher) post(ctx context.Context, webhook *leaseWebhook, eventType models.LeaseLifecycleEventType, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(eventType))
+	if len(webhook.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(webhook.secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &deliveryError{status: resp.Status, code: resp.StatusCode}
+	}
+	return nil
+}
+
+// deliveryError is an unsuccessful response of a webhook endpoint
+type deliveryError struct {
+	status string
+	code   int
+}
+
+func (e *deliveryError) Error() string {
+	return "webhook responded with " + e.status
+}
+
+// isRetryableDelivery retries failed connections, server errors, timeouts and throttling.
+// Other client errors will not change on another attempt.
+func isRetryableDelivery(err error) bool {
+	var de *deliveryError
+	if errors.As(err, &de) {
+		return de.code >= 500 || de.code == http.StatusRequestTimeout || de.code == http.StatusTooManyRequests
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// LogChannel writes one structured log entry per expiring lease, for deployments that
+// route logs to their alerting pipeline
+type LogChannel struct {
+	logger *zap.Logger
+}
+
+var _ ports.ExpiryChannel = &LogChannel{}
+
+func NewLogChannel(logger *zap.Logger) *LogChannel {
+	return &LogChannel{logger: logger.Named("expiry")}
+}
+
+func (c *LogChannel) Name() string {
+	return ChannelLog
+}
+
+func (c *LogChannel) Notify(ctx context.Context, leases []*models.ExpiringLease) error {
+	for _, lease := range leases {
+		c.logger.Info("Lease expiring soon",
+			zap.String("peerID", lease.PeerID),
+			zap.Int64("tokenID", lease.TokenID),
+			zap.Time("expiresAt", lease.ExpiresAt),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package notifications
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Expiry notification channel names, as used in expiry_notify_channels
+const (
+	ChannelLog     = "log"
+	ChannelWebhook = "webhook"
+)
+
+var Module = fx.Options(
+	fx.Provide(NewExpiryChannels),
+	fx.Provide(
+		fx.Annotate(
+			NewLeaseWebhookDispatcher,
+			fx.As(new(ports.LeaseEventPublisher)),
+			fx.ResultTags(`group:"lease_event_publishers"`),
+		),
+		fx.Annotate(
+			NewLeaseBusPublisher,
+			fx.As(new(ports.LeaseEventPublisher)),
+			fx.ResultTags(`group:"lease_event_publishers"`),
+		),
+		fx.Annotate(
+			NewLeaseEventPublisher,
+			fx.ParamTags(`group:"lease_event_publishers"`),
+		),
+	),
+)
+
+// LeaseEventPublishers hands every lease lifecycle event to each of its publishers, such
+// as the webhook dispatcher, the event bus and the DNS publisher
+type LeaseEventPublishers []ports.LeaseEventPublisher
+
+func NewLeaseEventPublisher(publishers []ports.LeaseEventPublisher) ports.LeaseEventPublisher {
+	return LeaseEventPublishers(publishers)
+}
+
+func (p LeaseEventPublishers) Publish(event *models.LeaseLifecycleEvent) {
+	for _, publisher := range p {
+		publisher.Publish(event)
+	}
+}
+
+// NewExpiryChannels creates the expiry notification channels listed in the configuration
+func NewExpiryChannels(cfg *config.AppConfig, logger *zap.Logger) ([]ports.ExpiryChannel, error) {
+	channels := make([]ports.ExpiryChannel, 0, len(cfg.ExpiryNotifyChannels))
+	for _, name := range cfg.ExpiryNotifyChannels {
+		switch name {
+		case ChannelLog:
+			channels = append(channels, NewLogChannel(logger))
+		case ChannelWebhook:
+			if cfg.ExpiryWebhookURL == "" {
+				return nil, fmt.Errorf("expiry_webhook_url must be set for the %s expiry notification channel", ChannelWebhook)
+			}
+			channels = append(channels, NewWebhookChannel(cfg.ExpiryWebhookURL, cfg.ExpiryWebhookSecret, time.Duration(cfg.ExpiryWebhookTimeout)*time.Second))
+		default:
+			return nil, fmt.Errorf("unknown expiry notification channel %q", name)
+		}
+	}
+	return channels, nil
+}
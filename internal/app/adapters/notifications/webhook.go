@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+const (
+	// ExpiringEvent is the event name of expiring lease webhook deliveries
+	ExpiringEvent = "lease.expiring"
+	// SignatureHeader carries the hex HMAC-SHA256 of the request body when a secret is set
+	SignatureHeader = "X-DHCP2P-Signature"
+)
+
+// WebhookPayload is the body posted for every batch of expiring leases
+type WebhookPayload struct {
+	Event  string                  `json:"event"`
+	SentAt time.Time               `json:"sent_at"`
+	Leases []*models.ExpiringLease `json:"leases"`
+}
+
+// WebhookChannel posts batches of expiring leases to an HTTP endpoint
+type WebhookChannel struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+var _ ports.ExpiryChannel = &WebhookChannel{}
+
+func NewWebhookChannel(url string, secret string, timeout time.Duration) *WebhookChannel {
+	return &WebhookChannel{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *WebhookChannel) Name() string {
+	return ChannelWebhook
+}
+
+func (c *WebhookChannel) Notify(ctx context.Context, leases []*models.ExpiringLease) error {
+	body, err := json.Marshal(&WebhookPayload{Event: ExpiringEvent, SentAt: time.Now().UTC(), Leases: leases})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(c.secret, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as sent in SignatureHeader
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,106 @@
+package embedded
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+type AccessRuleRepository struct {
+	store *Store
+}
+
+var _ ports.AccessRuleRepository = &AccessRuleRepository{}
+
+func NewAccessRuleRepository(store *Store) *AccessRuleRepository {
+	return &AccessRuleRepository{store}
+}
+
+func (r *AccessRuleRepository) GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error) {
+	return r.listRules(func(record accessRuleRecord) bool {
+		return record.SubjectType == string(subjectType) && record.Subject == subject
+	})
+}
+
+func (r *AccessRuleRepository) ListAccessRules(ctx context.Context, list models.AccessList) ([]*models.AccessRule, error) {
+	return r.listRules(func(record accessRuleRecord) bool {
+		return list == "" || record.List == string(list)
+	})
+}
+
+// listRules returns the rules matching keep ordered by ID, like the Postgres queries
+func (r *AccessRuleRepository) listRules(keep func(record accessRuleRecord) bool) ([]*models.AccessRule, error) {
+	rules := []*models.AccessRule{}
+	err := r.store.view(func(st *state) error {
+		for _, record := range st.AccessRules {
+			if keep(record) {
+				rules = append(rules, toAccessRule(record))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(rules, func(a, b *models.AccessRule) int { return cmp.Compare(a.ID, b.ID) })
+	return rules, nil
+}
+
+func (r *AccessRuleRepository) CreateAccessRule(ctx context.Context, rule *models.AccessRule) (*models.AccessRule, error) {
+	var created accessRuleRecord
+	err := r.store.update(ctx, func(tx *tx) error {
+		for _, record := range tx.AccessRules {
+			if record.List == string(rule.List) && record.SubjectType == string(rule.SubjectType) && record.Subject == rule.Subject {
+				return domainErrors.ErrAccessRuleExists
+			}
+		}
+
+		created = accessRuleRecord{
+			ID:          tx.nextID(sequenceAccessRules),
+			List:        string(rule.List),
+			SubjectType: string(rule.SubjectType),
+			Subject:     rule.Subject,
+			Reason:      rule.Reason,
+			CreatedAt:   r.store.now(),
+		}
+		tx.putAccessRule(created)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAccessRule(created), nil
+}
+
+func (r *AccessRuleRepository) DeleteAccessRule(ctx context.Context, id int64) (*models.AccessRule, error) {
+	var deleted accessRuleRecord
+	err := r.store.update(ctx, func(tx *tx) error {
+		record, ok := tx.AccessRules[id]
+		if !ok {
+			return domainErrors.ErrAccessRuleNotFound
+		}
+		deleted = record
+		tx.deleteAccessRule(id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAccessRule(deleted), nil
+}
+
+func toAccessRule(record accessRuleRecord) *models.AccessRule {
+	return &models.AccessRule{
+		ID:          record.ID,
+		List:        models.AccessList(record.List),
+		SubjectType: models.AccessSubjectType(record.SubjectType),
+		Subject:     record.Subject,
+		Reason:      record.Reason,
+		CreatedAt:   record.CreatedAt,
+	}
+}
@@ -0,0 +1,134 @@
+package embedded
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+type APIKeyRepository struct {
+	store *Store
+}
+
+var _ ports.APIKeyRepository = &APIKeyRepository{}
+
+func NewAPIKeyRepository(store *Store) *APIKeyRepository {
+	return &APIKeyRepository{store}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	var created apiKeyRecord
+	err := r.store.update(ctx, func(tx *tx) error {
+		for _, record := range tx.APIKeys {
+			if record.KeyHash == key.Hash {
+				return domainErrors.ErrDuplicateRecord
+			}
+		}
+
+		scopes := make([]string, len(key.Scopes))
+		for i, scope := range key.Scopes {
+			scopes[i] = string(scope)
+		}
+
+		created = apiKeyRecord{
+			ID:        tx.nextID(sequenceAPIKeys),
+			Name:      key.Name,
+			KeyPrefix: key.Prefix,
+			KeyHash:   key.Hash,
+			Scopes:    scopes,
+			CreatedAt: r.store.now(),
+		}
+		tx.putAPIKey(created)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAPIKey(created), nil
+}
+
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key *models.APIKey
+	err := r.store.view(func(st *state) error {
+		for _, record := range st.APIKeys {
+			if record.KeyHash == hash {
+				key = toAPIKey(record)
+				return nil
+			}
+		}
+		return domainErrors.ErrAPIKeyNotFound
+	})
+	return key, err
+}
+
+// ListAPIKeys returns the keys ordered by ID, like the Postgres query
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	keys := []*models.APIKey{}
+	err := r.store.view(func(st *state) error {
+		for _, record := range st.APIKeys {
+			keys = append(keys, toAPIKey(record))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(keys, func(a, b *models.APIKey) int { return cmp.Compare(a.ID, b.ID) })
+	return keys, nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	var revoked apiKeyRecord
+	err := r.store.update(ctx, func(tx *tx) error {
+		record, ok := tx.APIKeys[id]
+		if !ok {
+			return domainErrors.ErrAPIKeyNotFound
+		}
+		if record.RevokedAt == nil {
+			now := r.store.now()
+			record.RevokedAt = &now
+			tx.putAPIKey(record)
+		}
+		revoked = record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAPIKey(revoked), nil
+}
+
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id int64) error {
+	return r.store.update(ctx, func(tx *tx) error {
+		record, ok := tx.APIKeys[id]
+		if !ok {
+			return domainErrors.ErrAPIKeyNotFound
+		}
+		now := r.store.now()
+		record.LastUsedAt = &now
+		tx.putAPIKey(record)
+		return nil
+	})
+}
+
+func toAPIKey(record apiKeyRecord) *models.APIKey {
+	scopes := make([]models.APIKeyScope, len(record.Scopes))
+	for i, scope := range record.Scopes {
+		scopes[i] = models.APIKeyScope(scope)
+	}
+	return &models.APIKey{
+		ID:         record.ID,
+		Name:       record.Name,
+		Prefix:     record.KeyPrefix,
+		Hash:       record.KeyHash,
+		Scopes:     scopes,
+		CreatedAt:  record.CreatedAt,
+		LastUsedAt: record.LastUsedAt,
+		RevokedAt:  record.RevokedAt,
+	}
+}
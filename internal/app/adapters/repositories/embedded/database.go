@@ -0,0 +1,197 @@
+package embedded
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// openTimeout is how long opening the database waits for another process to release it
+const openTimeout = time.Second
+
+// Buckets of the database, one per record type. Records are stored as JSON.
+var (
+	bucketPools        = []byte("pools")         // by tenant ID
+	bucketLeases       = []byte("leases")        // by token ID
+	bucketNonces       = []byte("nonces")        // by nonce ID
+	bucketHistory      = []byte("history")       // by sequence number, in the order of the events
+	bucketAccessRules  = []byte("access_rules")  // by ID
+	bucketAPIKeys      = []byte("api_keys")      // by ID
+	bucketPeerPolicies = []byte("peer_policies") // by ID
+	bucketSequences    = []byte("sequences")     // last ID handed out, by record type
+)
+
+// idKey encodes a token ID or record ID so that the keys of a bucket sort by it
+func idKey(id int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+}
+
+func stringKey(s string) []byte {
+	return []byte(s)
+}
+
+// openDatabase opens the database at path, creating it on first start. A data file of an
+// earlier version, a single JSON document, is converted first.
+func openDatabase(path string) (*bolt.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create storage directory: %w", err)
+	}
+	if err := convertLegacyFile(path); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("open storage file %s: in use by another process", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open storage file %s: %w", path, err)
+	}
+
+	err = db.Update(func(btx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketPools, bucketLeases, bucketNonces, bucketHistory, bucketAccessRules, bucketAPIKeys, bucketPeerPolicies, bucketSequences} {
+			if _, err := btx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("write storage file: %w", err)
+	}
+	return db, nil
+}
+
+// loadState reads every record of the database
+func loadState(db *bolt.DB) (*state, error) {
+	st := newState()
+	err := db.View(func(btx *bolt.Tx) error {
+		return errors.Join(
+			loadBucket(btx, bucketPools, func(k []byte, record poolRecord) { st.Pools[string(k)] = record }),
+			loadBucket(btx, bucketLeases, func(k []byte, record leaseRecord) { st.Leases[record.TokenID] = record }),
+			loadBucket(btx, bucketNonces, func(k []byte, record nonceRecord) { st.Nonces[record.ID] = record }),
+			loadBucket(btx, bucketHistory, func(k []byte, record historyRecord) { st.History = append(st.History, record) }),
+			loadBucket(btx, bucketAccessRules, func(k []byte, record accessRuleRecord) { st.AccessRules[record.ID] = record }),
+			loadBucket(btx, bucketAPIKeys, func(k []byte, record apiKeyRecord) { st.APIKeys[record.ID] = record }),
+			loadBucket(btx, bucketPeerPolicies, func(k []byte, record peerPolicyRecord) { st.PeerPolicies[record.ID] = record }),
+			loadBucket(btx, bucketSequences, func(k []byte, id int64) { st.Sequences[string(k)] = id }),
+		)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read storage file: %w", err)
+	}
+	return st, nil
+}
+
+// loadBucket decodes the records of a bucket in key order
+func loadBucket[V any](btx *bolt.Tx, name []byte, add func(k []byte, record V)) error {
+	return btx.Bucket(name).ForEach(func(k, v []byte) error {
+		var record V
+		if err := json.Unmarshal(v, &record); err != nil {
+			return fmt.Errorf("bucket %s, key %x: %w", name, k, err)
+		}
+		add(k, record)
+		return nil
+	})
+}
+
+// legacyState is the JSON document earlier versions kept the data in. The top-level pool
+// belongs to the default tenant.
+type legacyState struct {
+	MinTokenID       int64                      `json:"min_token_id"`
+	MaxTokenID       int64                      `json:"max_token_id"`
+	LastTokenID      int64                      `json:"last_token_id"`
+	Pools            map[string]poolRecord      `json:"pools"`
+	Leases           map[int64]leaseRecord      `json:"leases"`
+	Nonces           map[string]nonceRecord     `json:"nonces"`
+	History          []historyRecord            `json:"history"`
+	AccessRules      map[int64]accessRuleRecord `json:"access_rules"`
+	LastAccessRuleID int64                      `json:"last_access_rule_id"`
+	APIKeys          map[int64]apiKeyRecord     `json:"api_keys"`
+	LastAPIKeyID     int64                      `json:"last_api_key_id"`
+	PeerPolicies     map[int64]peerPolicyRecord `json:"peer_policies"`
+	LastPeerPolicyID int64                      `json:"last_peer_policy_id"`
+}
+
+// convertLegacyFile replaces a JSON data file with a database holding the same records.
+// The database is written next to it and renamed over it, so an interrupted conversion
+// leaves the JSON file in place.
+func convertLegacyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read storage file: %w", err)
+	}
+	if data = bytes.TrimSpace(data); len(data) == 0 || data[0] != '{' {
+		return nil
+	}
+
+	legacy := legacyState{MinTokenID: defaultMinTokenID, MaxTokenID: defaultMaxTokenID, LastTokenID: defaultLastTokenID}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("parse storage file %s: %w", path, err)
+	}
+
+	tx := newTx(newState())
+	tx.setPool(models.DefaultTenantID, poolRecord{MinTokenID: legacy.MinTokenID, MaxTokenID: legacy.MaxTokenID, LastTokenID: legacy.LastTokenID})
+	for tenantID, pool := range legacy.Pools {
+		tx.setPool(tenantID, pool)
+	}
+	for _, record := range legacy.Leases {
+		tx.putLease(record)
+	}
+	for _, record := range legacy.Nonces {
+		tx.putNonce(record)
+	}
+	tx.History = append(tx.History, legacy.History...)
+	for _, record := range legacy.AccessRules {
+		tx.putAccessRule(record)
+	}
+	for _, record := range legacy.APIKeys {
+		tx.putAPIKey(record)
+	}
+	for _, record := range legacy.PeerPolicies {
+		tx.putPeerPolicy(record)
+	}
+	tx.sequences.put(tx.Sequences, sequenceAccessRules, legacy.LastAccessRuleID)
+	tx.sequences.put(tx.Sequences, sequenceAPIKeys, legacy.LastAPIKeyID)
+	tx.sequences.put(tx.Sequences, sequencePeerPolicies, legacy.LastPeerPolicyID)
+
+	tmp := path + ".converting"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("convert storage file: %w", err)
+	}
+	db, err := bolt.Open(tmp, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return fmt.Errorf("convert storage file: %w", err)
+	}
+	err = db.Update(func(btx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketPools, bucketLeases, bucketNonces, bucketHistory, bucketAccessRules, bucketAPIKeys, bucketPeerPolicies, bucketSequences} {
+			if _, err := btx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return tx.write(btx)
+	})
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("convert storage file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("convert storage file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package embedded
+
+import (
+	"context"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// Diagnostics stands in for the Redis diagnostics with backends that do not use Redis
+type Diagnostics struct{}
+
+var _ ports.CacheDiagnostics = &Diagnostics{}
+
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{}
+}
+
+func (d *Diagnostics) MemoryUsage(ctx context.Context, sampleSize int) (*models.CacheMemoryReport, error) {
+	return nil, domainErrors.ErrRedisNotConfigured
+}
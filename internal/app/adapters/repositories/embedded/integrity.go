@@ -0,0 +1,148 @@
+package embedded
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// IntegrityChecker verifies the same invariants as the Postgres checker against the store
+type IntegrityChecker struct {
+	store *Store
+}
+
+var _ ports.IntegrityChecker = &IntegrityChecker{}
+
+func NewIntegrityChecker(store *Store) *IntegrityChecker {
+	return &IntegrityChecker{store: store}
+}
+
+func (c *IntegrityChecker) Check(ctx context.Context, repair bool) (*models.IntegrityReport, error) {
+	report := &models.IntegrityReport{Repair: repair, StartedAt: c.store.now()}
+
+	check := func(tx *tx) error {
+		now := c.store.now()
+		checkDuplicateActiveLeases(tx, report.AddCheck(models.IntegrityCheckDuplicateActiveLeases), repair, now)
+		checkAllocState(tx, report.AddCheck(models.IntegrityCheckAllocState), repair)
+		checkNonceUsedAt(tx, report.AddCheck(models.IntegrityCheckNonceUsedAt), repair)
+		return nil
+	}
+
+	var err error
+	if repair {
+		err = c.store.update(ctx, check)
+	} else {
+		// Without repair the checks change nothing
+		err = c.store.view(func(st *state) error { return check(newTx(st)) })
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report.FinishedAt = c.store.now()
+	return report, nil
+}
+
+// tenantPeer identifies the leases of a peer within one tenant
+type tenantPeer struct {
+	tenantID string
+	peerID   string
+}
+
+// checkDuplicateActiveLeases keeps the most recently updated active lease of each peer
+// and tenant and releases the others
+func checkDuplicateActiveLeases(tx *tx, result *models.IntegrityCheckResult, repair bool, now time.Time) {
+	byPeer := make(map[tenantPeer][]leaseRecord)
+	for _, record := range tx.Leases {
+		if record.ExpiresAt.After(now) {
+			key := tenantPeer{record.tenant(), record.PeerID}
+			byPeer[key] = append(byPeer[key], record)
+		}
+	}
+
+	peers := make([]tenantPeer, 0, len(byPeer))
+	for key, records := range byPeer {
+		if len(records) > 1 {
+			peers = append(peers, key)
+		}
+	}
+	slices.SortFunc(peers, func(a, b tenantPeer) int {
+		return cmp.Or(cmp.Compare(a.tenantID, b.tenantID), cmp.Compare(a.peerID, b.peerID))
+	})
+
+	for _, key := range peers {
+		records := byPeer[key]
+		slices.SortFunc(records, func(a, b leaseRecord) int {
+			if c := b.UpdatedAt.Compare(a.UpdatedAt); c != 0 {
+				return c
+			}
+			return cmp.Compare(b.TokenID, a.TokenID)
+		})
+
+		for _, record := range records[1:] {
+			if repair {
+				release(tx, key.tenantID, record.TokenID, record.PeerID, now)
+			}
+			result.Add(fmt.Sprintf("token:%d", record.TokenID), fmt.Sprintf("peer %s holds another active lease", key.peerID), repair)
+		}
+	}
+}
+
+// checkAllocState verifies that the allocation cursor of every tenant lies within its pool
+func checkAllocState(tx *tx, result *models.IntegrityCheckResult, repair bool) {
+	for _, tenantID := range tx.tenants() {
+		subject := "alloc_state"
+		if tenantID != models.DefaultTenantID {
+			subject = "alloc_state:" + tenantID
+		}
+		pool := tx.Pools[tenantID]
+		if models.AllocStateInBounds(pool.MinTokenID, pool.MaxTokenID, pool.LastTokenID) {
+			continue
+		}
+
+		var highest int64
+		for tokenID := range tx.Leases {
+			if tokenID >= pool.MinTokenID && tokenID <= pool.MaxTokenID {
+				highest = max(highest, tokenID)
+			}
+		}
+
+		last, ok := models.AllocStateRepair(pool.MinTokenID, pool.MaxTokenID, pool.LastTokenID, highest)
+		if !ok {
+			result.Add(subject, fmt.Sprintf("min_token_id %d is above max_token_id %d", pool.MinTokenID, pool.MaxTokenID), false)
+			continue
+		}
+
+		detail := fmt.Sprintf("last_token_id %d outside pool [%d, %d]", pool.LastTokenID, pool.MinTokenID, pool.MaxTokenID)
+		if repair {
+			pool.LastTokenID = last
+			tx.setPool(tenantID, pool)
+			detail = fmt.Sprintf("%s, reset to %d", detail, last)
+		}
+		result.Add(subject, detail, repair)
+	}
+}
+
+func checkNonceUsedAt(tx *tx, result *models.IntegrityCheckResult, repair bool) {
+	ids := make([]string, 0)
+	for id, record := range tx.Nonces {
+		if record.Used && record.UsedAt.IsZero() {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		record := tx.Nonces[id]
+		if repair {
+			record.UsedAt = record.IssuedAt
+			tx.putNonce(record)
+		}
+		result.Add("nonce:"+id, fmt.Sprintf("used nonce of peer %s has no used_at", record.PeerID), repair)
+	}
+}
@@ -0,0 +1,17 @@
+package embedded
+
+import "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+
+// LeaderElector always leads. Only one process can use a data file or an in-memory
+// store, so there are no other replicas to elect from.
+type LeaderElector struct{}
+
+var _ ports.LeaderElector = LeaderElector{}
+
+func NewLeaderElector() LeaderElector {
+	return LeaderElector{}
+}
+
+func (LeaderElector) IsLeader() bool {
+	return true
+}
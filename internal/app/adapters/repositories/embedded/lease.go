@@ -0,0 +1,768 @@
+package embedded
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// LeaseRepository implements the lease queries of the Postgres repository on top of the
+// embedded store. Lookups scan all leases, which is fine for the small pools it targets.
+// Every operation is scoped to the tenant of its context.
+type LeaseRepository struct {
+	store              *Store
+	leaseTTL           atomic.Int64 // nanoseconds, replaced on reload
+	affinityProbeLimit int
+	reclaimedOnly      bool                          // only reuse expired leases reclaimed by a policy
+	releaseGrace       time.Duration                 // released and expired leases are withheld from reuse this long
+	maxLeasesPerPeer   int                           // active leases a peer may hold, 0 disables the quota
+	tenantPools        map[string]poolRecord         // configured pools of tenants other than the default one
+	exclusions         map[string]models.TokenRanges // token IDs of each tenant's pool never handed out
+	zones              map[string]models.Zones       // ranges of each tenant's pool by zone
+}
+
+var _ ports.LeaseRepository = &LeaseRepository{}
+
+func NewLeaseRepository(cfg *config.AppConfig, store *Store) *LeaseRepository {
+	r := &LeaseRepository{
+		store:              store,
+		affinityProbeLimit: cfg.Lease.AffinityProbeLimit,
+		reclaimedOnly:      cfg.ReclaimEnabled && !cfg.ReclaimDryRun,
+		releaseGrace:       time.Duration(cfg.Lease.ReleaseGrace) * time.Second,
+		maxLeasesPerPeer:   cfg.Lease.MaxPerPeer,
+		tenantPools:        make(map[string]poolRecord, len(cfg.Tenants)),
+		exclusions:         cfg.TenantExclusions(),
+		zones:              cfg.TenantZones(),
+	}
+	for _, tenant := range cfg.Tenants {
+		r.tenantPools[tenant.ID] = poolRecord{
+			MinTokenID:  tenant.PoolMinTokenID,
+			MaxTokenID:  tenant.PoolMaxTokenID,
+			LastTokenID: tenant.PoolMinTokenID - 1,
+		}
+	}
+	r.ApplyConfig(cfg)
+	return r
+}
+
+// ApplyConfig switches to a reloaded lease TTL, which applies from the next allocation
+// or renewal
+func (r *LeaseRepository) ApplyConfig(cfg *config.AppConfig) {
+	r.leaseTTL.Store(int64(time.Duration(cfg.Lease.TTL) * time.Minute))
+}
+
+func (r *LeaseRepository) ttl() time.Duration {
+	return time.Duration(r.leaseTTL.Load())
+}
+
+func (r *LeaseRepository) FindAndReuseExpiredLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var lease *models.Lease
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		if err := r.checkLeaseQuota(tx.state, tenantID, peerID, now); err != nil {
+			return err
+		}
+		expired, ok := r.findExpiredLease(tx.state, tenantID, now)
+		if !ok {
+			return nil
+		}
+		lease = r.reuse(tx, expired, peerID, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (r *LeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var lease *models.Lease
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		if err := r.checkLeaseQuota(tx.state, tenantID, peerID, now); err != nil {
+			return err
+		}
+		var err error
+		lease, err = r.allocateNext(tx, tenantID, peerID, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// AllocateRequestedLease assigns a specific token ID to the peer if it is inside the pool
+// and either has never been leased or its previous lease has expired (and been reclaimed,
+// when reclamation policies are enforced).
+func (r *LeaseRepository) AllocateRequestedLease(ctx context.Context, peerID string, tokenID int64) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var lease *models.Lease
+	err := r.store.update(ctx, func(tx *tx) error {
+		var err error
+		lease, err = r.allocateRequested(tx, tenantID, peerID, tokenID, r.store.now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// AllocateAffinityLease tries to place the peer next to the leases already held by its
+// affinity group. nil is returned when the group is empty or none of the probed token IDs
+// is free.
+func (r *LeaseRepository) AllocateAffinityLease(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var lease *models.Lease
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+
+		var members []int64
+		for _, record := range tx.Leases {
+			if record.tenant() == tenantID && record.AffinityGroup == affinityGroup && record.ExpiresAt.After(now) {
+				members = append(members, record.TokenID)
+			}
+		}
+		slices.Sort(members)
+
+		for _, tokenID := range utils.AffinityCandidates(members, r.affinityProbeLimit) {
+			allocated, err := r.allocateRequested(tx, tenantID, peerID, tokenID, now)
+			if errors.Is(err, domainErrors.ErrTokenIDInUse) || errors.Is(err, domainErrors.ErrTokenIDOutOfRange) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			record := tx.Leases[allocated.TokenID]
+			record.AffinityGroup = affinityGroup
+			tx.putLease(record)
+			lease = allocated
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// AllocateZoneLease assigns the lowest free token ID of the zone. nil is returned when the
+// zone is unknown or has no free token ID left.
+func (r *LeaseRepository) AllocateZoneLease(ctx context.Context, peerID string, zone string) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+	ranges := r.zones[tenantID][zone]
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+
+	var lease *models.Lease
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		if err := r.checkLeaseQuota(tx.state, tenantID, peerID, now); err != nil {
+			return err
+		}
+
+		for _, zoneRange := range ranges {
+			for tokenID := zoneRange.FirstTokenID; tokenID <= zoneRange.LastTokenID; tokenID++ {
+				allocated, err := r.allocateRequested(tx, tenantID, peerID, tokenID, now)
+				if errors.Is(err, domainErrors.ErrTokenIDInUse) || errors.Is(err, domainErrors.ErrTokenIDOutOfRange) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				lease = allocated
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (r *LeaseRepository) SetLeaseAffinityGroup(ctx context.Context, tokenID int64, affinityGroup string) error {
+	tenantID := models.TenantFromContext(ctx)
+
+	return r.store.update(ctx, func(tx *tx) error {
+		record, ok := tx.Leases[tokenID]
+		if !ok || record.tenant() != tenantID {
+			return nil
+		}
+		record.AffinityGroup = affinityGroup
+		tx.putLease(record)
+		return nil
+	})
+}
+
+func (r *LeaseRepository) SetLeaseDelegator(ctx context.Context, tokenID int64, gatewayPeerID string) error {
+	tenantID := models.TenantFromContext(ctx)
+
+	return r.store.update(ctx, func(tx *tx) error {
+		record, ok := tx.Leases[tokenID]
+		if !ok || record.tenant() != tenantID {
+			return nil
+		}
+		record.DelegatedBy = gatewayPeerID
+		tx.putLease(record)
+		return nil
+	})
+}
+
+func (r *LeaseRepository) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) error {
+	tenantID := models.TenantFromContext(ctx)
+
+	return r.store.update(ctx, func(tx *tx) error {
+		record, ok := tx.Leases[tokenID]
+		if !ok || record.tenant() != tenantID || record.PeerID != peerID || !record.ExpiresAt.After(r.store.now()) {
+			return domainErrors.ErrLeaseNotFound
+		}
+		record.Labels = maps.Clone(labels)
+		tx.putLease(record)
+		return nil
+	})
+}
+
+func (r *LeaseRepository) CountDelegatedLeases(ctx context.Context, gatewayPeerID string) (int64, error) {
+	var count int64
+	err := r.store.view(func(st *state) error {
+		now := r.store.now()
+		for _, record := range st.Leases {
+			if record.DelegatedBy == gatewayPeerID && record.ExpiresAt.After(now) {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (r *LeaseRepository) GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var lease *models.Lease
+	err := r.store.view(func(st *state) error {
+		now := r.store.now()
+		record, ok := st.Leases[tokenID]
+		if !ok || record.tenant() != tenantID || !record.ExpiresAt.After(now) {
+			return domainErrors.ErrLeaseNotFound
+		}
+		lease = toLease(record, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (r *LeaseRepository) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var lease *models.Lease
+	err := r.store.view(func(st *state) error {
+		now := r.store.now()
+		record, ok := activeLeaseOf(st, tenantID, peerID, now)
+		if !ok {
+			return domainErrors.ErrLeaseNotFound
+		}
+		lease = toLease(record, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (r *LeaseRepository) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var lease *models.Lease
+	err := r.store.update(ctx, func(tx *tx) error {
+		var err error
+		lease, err = r.renew(tx, tenantID, tokenID, peerID, r.store.now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (r *LeaseRepository) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
+	tenantID := models.TenantFromContext(ctx)
+
+	return r.store.update(ctx, func(tx *tx) error {
+		release(tx, tenantID, tokenID, peerID, r.store.now())
+		return nil
+	})
+}
+
+func (r *LeaseRepository) RevokeLeases(ctx context.Context, leases []*models.Lease) ([]int64, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var revoked []int64
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		for _, lease := range leases {
+			record, ok := tx.Leases[lease.TokenID]
+			if !ok || record.tenant() != tenantID || record.PeerID != lease.PeerID || !record.ExpiresAt.After(now) {
+				continue
+			}
+			release(tx, tenantID, lease.TokenID, lease.PeerID, now)
+			revoked = append(revoked, lease.TokenID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// TransferLease reassigns an active lease owned by fromPeerID to toPeerID. The new
+// identity must not already hold an active lease of its own.
+func (r *LeaseRepository) TransferLease(ctx context.Context, tokenID int64, fromPeerID string, toPeerID string) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var lease *models.Lease
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		if _, ok := activeLeaseOf(tx.state, tenantID, toPeerID, now); ok {
+			return domainErrors.ErrLeaseAlreadyExists
+		}
+
+		record, ok := tx.Leases[tokenID]
+		if !ok || record.tenant() != tenantID || record.PeerID != fromPeerID || !record.ExpiresAt.After(now) {
+			// Unknown token ID, expired lease or owned by another peer
+			return domainErrors.ErrLeaseNotFound
+		}
+
+		record.PeerID = toPeerID
+		record.UpdatedAt = now
+		tx.putLease(record)
+		recordEvent(tx, models.LeaseEventTransfer, record, now)
+		lease = toLease(record, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// RecordConflict records a conflict reported by the holder of the active lease and, with a
+// positive quarantine, releases the lease and withholds the token ID until it ends.
+func (r *LeaseRepository) RecordConflict(ctx context.Context, tokenID int64, peerID string, quarantine time.Duration) (*models.LeaseConflict, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var conflict *models.LeaseConflict
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		record, ok := tx.Leases[tokenID]
+		if !ok || record.tenant() != tenantID || record.PeerID != peerID || !record.ExpiresAt.After(now) {
+			// Only the current holder can report a conflict on the address
+			return domainErrors.ErrLeaseNotFound
+		}
+
+		recordEvent(tx, models.LeaseEventConflict, record, now)
+		conflict = &models.LeaseConflict{TokenID: tokenID, PeerID: peerID, ReportedAt: now}
+		if quarantine > 0 {
+			until := now.Add(quarantine)
+			record.ExpiresAt = now
+			record.UpdatedAt = now
+			record.QuarantinedUntil = &until
+			tx.putLease(record)
+			recordEvent(tx, models.LeaseEventRelease, record, now)
+
+			conflict.Quarantined = true
+			conflict.QuarantinedUntil = until
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conflict, nil
+}
+
+// ExecuteBatch applies all operations in one write. Operations check all preconditions
+// before changing anything, so a failing item is reported without affecting the others.
+func (r *LeaseRepository) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	results := make([]*models.LeaseOperationResult, len(operations))
+	err := r.store.update(ctx, func(tx *tx) error {
+		for i, op := range operations {
+			lease, opErr := r.executeOperation(tx, tenantID, op, r.store.now())
+			results[i] = &models.LeaseOperationResult{Lease: lease, Err: opErr}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *LeaseRepository) executeOperation(tx *tx, tenantID string, op *models.LeaseOperation, now time.Time) (*models.Lease, error) {
+	switch op.Type {
+	case models.LeaseOperationAllocate:
+		// Mirrors LeaseService.AllocateIP: keep an existing lease, then reuse, then allocate
+		if record, ok := activeLeaseOf(tx.state, tenantID, op.PeerID, now); ok {
+			return toLease(record, now), nil
+		}
+		if err := r.checkLeaseQuota(tx.state, tenantID, op.PeerID, now); err != nil {
+			return nil, err
+		}
+		if expired, ok := r.findExpiredLease(tx.state, tenantID, now); ok {
+			return r.reuse(tx, expired, op.PeerID, now), nil
+		}
+		return r.allocateNext(tx, tenantID, op.PeerID, now)
+	case models.LeaseOperationRenew:
+		return r.renew(tx, tenantID, op.TokenID, op.PeerID, now)
+	case models.LeaseOperationRelease:
+		release(tx, tenantID, op.TokenID, op.PeerID, now)
+		return nil, nil
+	default:
+		return nil, domainErrors.ErrInvalidOperation
+	}
+}
+
+func (r *LeaseRepository) ListReclaimCandidates(ctx context.Context, afterTokenID int64, limit int) ([]*models.ReclaimCandidate, error) {
+	var candidates []*models.ReclaimCandidate
+	err := r.store.view(func(st *state) error {
+		now := r.store.now()
+		for _, record := range st.Leases {
+			if record.TokenID > afterTokenID && record.ExpiresAt.Before(now) && record.ReclaimedAt == nil {
+				candidates = append(candidates, &models.ReclaimCandidate{
+					TokenID:       record.TokenID,
+					PeerID:        record.PeerID,
+					TenantID:      record.tenant(),
+					AffinityGroup: record.AffinityGroup,
+					ExpiresAt:     record.ExpiresAt,
+					UpdatedAt:     record.UpdatedAt,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(candidates, func(a, b *models.ReclaimCandidate) int {
+		return cmp.Compare(a.TokenID, b.TokenID)
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+func (r *LeaseRepository) ListExpiringLeases(ctx context.Context, within time.Duration, afterTokenID int64, limit int) ([]*models.ExpiringLease, error) {
+	var leases []*models.ExpiringLease
+	err := r.store.view(func(st *state) error {
+		now := r.store.now()
+		until := now.Add(within)
+		for _, record := range st.Leases {
+			if record.TokenID > afterTokenID && record.ExpiresAt.After(now) && !record.ExpiresAt.After(until) {
+				leases = append(leases, &models.ExpiringLease{
+					TokenID:   record.TokenID,
+					PeerID:    record.PeerID,
+					TenantID:  record.tenant(),
+					ExpiresAt: record.ExpiresAt,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(leases, func(a, b *models.ExpiringLease) int {
+		return cmp.Compare(a.TokenID, b.TokenID)
+	})
+	if len(leases) > limit {
+		leases = leases[:limit]
+	}
+	return leases, nil
+}
+
+// ReclaimLeases marks the given leases as reclaimed. Leases that were reused or reclaimed
+// concurrently are skipped and not returned.
+func (r *LeaseRepository) ReclaimLeases(ctx context.Context, tokenIDs []int64) ([]int64, error) {
+	var reclaimed []int64
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		for _, tokenID := range tokenIDs {
+			record, ok := tx.Leases[tokenID]
+			if !ok || !record.ExpiresAt.Before(now) || record.ReclaimedAt != nil {
+				continue
+			}
+			record.ReclaimedAt = &now
+			tx.putLease(record)
+			reclaimed = append(reclaimed, tokenID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reclaimed, nil
+}
+
+func (r *LeaseRepository) GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var entries []*models.LeaseHistoryEntry
+	err := r.store.view(func(st *state) error {
+		for _, record := range st.History {
+			if record.TokenID == tokenID && tenantOf(record.TenantID) == tenantID {
+				entries = append(entries, &models.LeaseHistoryEntry{
+					TokenID:    record.TokenID,
+					PeerID:     record.PeerID,
+					Event:      models.LeaseEvent(record.Event),
+					ExpiresAt:  record.ExpiresAt,
+					OccurredAt: record.OccurredAt,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// findExpiredLease returns the lease of the tenant that expired first among those eligible
+// for reuse
+func (r *LeaseRepository) findExpiredLease(st *state, tenantID string, now time.Time) (leaseRecord, bool) {
+	var oldest leaseRecord
+	found := false
+	for _, record := range st.Leases {
+		if record.tenant() != tenantID || !record.ExpiresAt.Before(now.Add(-r.releaseGrace)) || (r.reclaimedOnly && record.ReclaimedAt == nil) || quarantined(record, now) {
+			continue
+		}
+		if r.exclusions[tenantID].Contains(record.TokenID) {
+			// Leased before the token ID was excluded
+			continue
+		}
+		if !found || record.ExpiresAt.Before(oldest.ExpiresAt) {
+			oldest, found = record, true
+		}
+	}
+	return oldest, found
+}
+
+// reuse hands an expired lease to peerID, recording the expiry of the previous lease first
+func (r *LeaseRepository) reuse(tx *tx, record leaseRecord, peerID string, now time.Time) *models.Lease {
+	recordEvent(tx, models.LeaseEventExpire, record, record.ExpiresAt)
+
+	record.PeerID = peerID
+	record.ExpiresAt = now.Add(r.ttl())
+	record.UpdatedAt = now
+	record.AffinityGroup = ""
+	record.DelegatedBy = ""
+	record.Labels = nil
+	record.ReclaimedAt = nil
+	record.QuarantinedUntil = nil
+	tx.putLease(record)
+	recordEvent(tx, models.LeaseEventAllocate, record, now)
+	return toLease(record, now)
+}
+
+// allocateNext hands out the next never leased token ID of the tenant's pool outside its
+// exclusions
+func (r *LeaseRepository) allocateNext(tx *tx, tenantID string, peerID string, now time.Time) (*models.Lease, error) {
+	pool, err := r.poolOf(tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for pool.LastTokenID < pool.MaxTokenID {
+		pool.LastTokenID++
+		tx.setPool(tenantID, pool)
+		if _, taken := tx.Leases[pool.LastTokenID]; taken {
+			// Token ID was already handed out as a preferred token, advance the cursor
+			continue
+		}
+		if r.exclusions[tenantID].Contains(pool.LastTokenID) {
+			continue
+		}
+		return r.insert(tx, tenantID, pool.LastTokenID, peerID, now), nil
+	}
+	// Pool exhausted
+	return nil, domainErrors.ErrAllocationFailed
+}
+
+func (r *LeaseRepository) allocateRequested(tx *tx, tenantID string, peerID string, tokenID int64, now time.Time) (*models.Lease, error) {
+	pool, err := r.poolOf(tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tokenID < pool.MinTokenID || tokenID > pool.MaxTokenID || r.exclusions[tenantID].Contains(tokenID) {
+		return nil, domainErrors.ErrTokenIDOutOfRange
+	}
+	if err := r.checkLeaseQuota(tx.state, tenantID, peerID, now); err != nil {
+		return nil, err
+	}
+
+	existing, ok := tx.Leases[tokenID]
+	switch {
+	case !ok:
+		// Never leased before
+		return r.insert(tx, tenantID, tokenID, peerID, now), nil
+	case existing.tenant() != tenantID:
+		// Left behind by another tenant whose pool used to cover the token ID
+		return nil, domainErrors.ErrTokenIDInUse
+	case existing.ExpiresAt.After(now):
+		return nil, domainErrors.ErrTokenIDInUse
+	case r.reclaimedOnly && existing.ReclaimedAt == nil:
+		// Expired but not reclaimed by any policy yet
+		return nil, domainErrors.ErrTokenIDInUse
+	case quarantined(existing, now):
+		// Withheld after an address conflict
+		return nil, domainErrors.ErrTokenIDInUse
+	case existing.ExpiresAt.After(now.Add(-r.releaseGrace)):
+		// Released or expired too recently, other nodes may still have the address cached
+		return nil, domainErrors.ErrTokenIDInUse
+	default:
+		// Previous lease expired, take it over
+		return r.reuse(tx, existing, peerID, now), nil
+	}
+}
+
+// poolOf returns the allocation state of a tenant. Tenants other than the default one
+// get theirs from the configuration on their first allocation.
+func (r *LeaseRepository) poolOf(tx *tx, tenantID string) (poolRecord, error) {
+	if pool, ok := tx.pool(tenantID); ok {
+		return pool, nil
+	}
+
+	pool, ok := r.tenantPools[tenantID]
+	if !ok {
+		return poolRecord{}, domainErrors.ErrTenantNotFound
+	}
+	tx.setPool(tenantID, pool)
+	return pool, nil
+}
+
+func (r *LeaseRepository) insert(tx *tx, tenantID string, tokenID int64, peerID string, now time.Time) *models.Lease {
+	record := leaseRecord{
+		TokenID:   tokenID,
+		PeerID:    peerID,
+		TenantID:  tenantField(tenantID),
+		ExpiresAt: now.Add(r.ttl()),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	tx.putLease(record)
+	recordEvent(tx, models.LeaseEventAllocate, record, now)
+	return toLease(record, now)
+}
+
+func (r *LeaseRepository) renew(tx *tx, tenantID string, tokenID int64, peerID string, now time.Time) (*models.Lease, error) {
+	record, ok := tx.Leases[tokenID]
+	if !ok || record.tenant() != tenantID || record.PeerID != peerID || !record.ExpiresAt.After(now) {
+		return nil, domainErrors.ErrLeaseNotFound
+	}
+
+	record.ExpiresAt = now.Add(r.ttl())
+	record.UpdatedAt = now
+	tx.putLease(record)
+	recordEvent(tx, models.LeaseEventRenew, record, now)
+	return toLease(record, now), nil
+}
+
+func release(tx *tx, tenantID string, tokenID int64, peerID string, now time.Time) {
+	record, ok := tx.Leases[tokenID]
+	if !ok || record.tenant() != tenantID || record.PeerID != peerID {
+		return
+	}
+	record.ExpiresAt = now
+	tx.putLease(record)
+	recordEvent(tx, models.LeaseEventRelease, record, now)
+}
+
+// recordEvent appends an event of the lease to the history
+func recordEvent(tx *tx, event models.LeaseEvent, record leaseRecord, at time.Time) {
+	tx.History = append(tx.History, historyRecord{
+		TokenID:    record.TokenID,
+		PeerID:     record.PeerID,
+		TenantID:   record.TenantID,
+		Event:      string(event),
+		ExpiresAt:  record.ExpiresAt,
+		OccurredAt: at,
+	})
+}
+
+// checkLeaseQuota fails with ErrLeaseQuotaExceeded once peerID holds maxLeasesPerPeer
+// active leases of the tenant
+func (r *LeaseRepository) checkLeaseQuota(st *state, tenantID string, peerID string, now time.Time) error {
+	if r.maxLeasesPerPeer <= 0 {
+		return nil
+	}
+
+	active := 0
+	for _, record := range st.Leases {
+		if record.tenant() == tenantID && record.PeerID == peerID && record.ExpiresAt.After(now) {
+			active++
+		}
+	}
+	if active >= r.maxLeasesPerPeer {
+		return domainErrors.ErrLeaseQuotaExceeded
+	}
+	return nil
+}
+
+// quarantined reports whether the token ID of record is withheld after an address conflict
+func quarantined(record leaseRecord, now time.Time) bool {
+	return record.QuarantinedUntil != nil && record.QuarantinedUntil.After(now)
+}
+
+// activeLeaseOf finds the unexpired lease held by peerID in the tenant
+func activeLeaseOf(st *state, tenantID string, peerID string, now time.Time) (leaseRecord, bool) {
+	for _, record := range st.Leases {
+		if record.tenant() == tenantID && record.PeerID == peerID && record.ExpiresAt.After(now) {
+			return record, true
+		}
+	}
+	return leaseRecord{}, false
+}
+
+func toLease(record leaseRecord, now time.Time) *models.Lease {
+	return &models.Lease{
+		TokenID:   record.TokenID,
+		PeerID:    record.PeerID,
+		ExpiresAt: record.ExpiresAt,
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+		Ttl:       int32(record.ExpiresAt.Sub(now).Round(time.Second) / time.Second),
+		Labels:    maps.Clone(record.Labels),
+	}
+}
@@ -0,0 +1,63 @@
+package embedded
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Options(
+	fx.Provide(NewStore),
+	fx.Provide(
+		fx.Annotate(
+			NewNonceRepository,
+			fx.As(new(ports.NonceRepository)),
+		),
+		fx.Annotate(
+			NewLeaseRepository,
+			fx.As(fx.Self()),
+			fx.As(new(ports.LeaseRepository)),
+		),
+		fx.Annotate(
+			NewAccessRuleRepository,
+			fx.As(new(ports.AccessRuleRepository)),
+		),
+		fx.Annotate(
+			NewAPIKeyRepository,
+			fx.As(new(ports.APIKeyRepository)),
+		),
+		fx.Annotate(
+			NewPeerPolicyRepository,
+			fx.As(new(ports.PeerPolicyRepository)),
+		),
+		fx.Annotate(
+			NewLeaseReadModel,
+			fx.As(new(ports.LeaseReadModel)),
+		),
+		fx.Annotate(
+			NewPoolStatsRepository,
+			fx.As(new(ports.PoolStatsRepository)),
+		),
+		fx.Annotate(
+			NewLeaseSnapshotRepository,
+			fx.As(new(ports.LeaseSnapshotRepository)),
+		),
+		fx.Annotate(
+			NewDiagnostics,
+			fx.As(new(ports.CacheDiagnostics)),
+		),
+		fx.Annotate(
+			NewIntegrityChecker,
+			fx.As(new(ports.IntegrityChecker)),
+		),
+		fx.Annotate(
+			NewLeaderElector,
+			fx.As(new(ports.LeaderElector)),
+		),
+		fx.Annotate(
+			func(store *Store) ports.HealthChecker { return store },
+			fx.ResultTags(`name:"database"`),
+		),
+	),
+	config.ReloadTarget[*LeaseRepository](),
+)
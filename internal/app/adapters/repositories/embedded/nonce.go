@@ -0,0 +1,161 @@
+package embedded
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+type NonceRepository struct {
+	store    *Store
+	nonceTTL time.Duration
+}
+
+var _ ports.NonceRepository = &NonceRepository{}
+
+func NewNonceRepository(cfg *config.AppConfig, store *Store) *NonceRepository {
+	return &NonceRepository{store, time.Duration(cfg.Nonce.TTL) * time.Minute}
+}
+
+// GetNonce returns the nonce if it is still unused and unexpired
+func (r *NonceRepository) GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
+	var nonce *models.Nonce
+	err := r.store.view(func(st *state) error {
+		record, err := usableNonce(st, nonceID, r.store.now())
+		if err != nil {
+			return err
+		}
+		nonce = toNonce(record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+func (r *NonceRepository) CreateNonce(ctx context.Context, peerID string) (*models.Nonce, error) {
+	now := r.store.now()
+	record := nonceRecord{
+		ID:        uuid.NewString(),
+		PeerID:    peerID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(r.nonceTTL),
+	}
+
+	err := r.store.update(ctx, func(tx *tx) error {
+		tx.putNonce(record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toNonce(record), nil
+}
+
+func (r *NonceRepository) ConsumeNonce(ctx context.Context, nonceID string, peerID string) error {
+	return r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		record, err := usableNonce(tx.state, nonceID, now)
+		if err != nil {
+			return err
+		}
+		if record.PeerID != peerID {
+			return domainErrors.ErrNonceNotFound
+		}
+
+		record.Used = true
+		record.UsedAt = now
+		tx.putNonce(record)
+		return nil
+	})
+}
+
+// ListOutstandingNonces returns the unused, unexpired nonces of a peer, newest first
+func (r *NonceRepository) ListOutstandingNonces(ctx context.Context, peerID string) ([]*models.Nonce, error) {
+	var nonces []*models.Nonce
+	err := r.store.view(func(st *state) error {
+		now := r.store.now()
+		for _, record := range st.Nonces {
+			if record.PeerID == peerID && !record.Used && record.ExpiresAt.After(now) {
+				nonces = append(nonces, toNonce(record))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(nonces, func(a, b *models.Nonce) int {
+		return b.IssuedAt.Compare(a.IssuedAt)
+	})
+	return nonces, nil
+}
+
+// RevokeNonce deletes an unused, unexpired nonce of the peer
+func (r *NonceRepository) RevokeNonce(ctx context.Context, nonceID string, peerID string) error {
+	return r.store.update(ctx, func(tx *tx) error {
+		record, err := usableNonce(tx.state, nonceID, r.store.now())
+		if err != nil {
+			return err
+		}
+		if record.PeerID != peerID {
+			return domainErrors.ErrNonceNotFound
+		}
+
+		tx.deleteNonce(nonceID)
+		return nil
+	})
+}
+
+func (r *NonceRepository) DeleteExpiredNonces(ctx context.Context, limit int) (int64, error) {
+	var deleted int64
+	err := r.store.update(ctx, func(tx *tx) error {
+		now := r.store.now()
+		for id, record := range tx.Nonces {
+			if limit > 0 && deleted >= int64(limit) {
+				break
+			}
+			if record.ExpiresAt.Before(now) {
+				tx.deleteNonce(id)
+				deleted++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func usableNonce(st *state, nonceID string, now time.Time) (nonceRecord, error) {
+	record, ok := st.Nonces[nonceID]
+	switch {
+	case !ok:
+		return record, domainErrors.ErrNonceNotFound
+	case record.Used:
+		return record, domainErrors.ErrNonceUsed
+	case !record.ExpiresAt.After(now):
+		return record, domainErrors.ErrNonceExpired
+	}
+	return record, nil
+}
+
+func toNonce(record nonceRecord) *models.Nonce {
+	return &models.Nonce{
+		ID:        record.ID,
+		PeerID:    record.PeerID,
+		IssuedAt:  record.IssuedAt,
+		ExpiresAt: record.ExpiresAt,
+		Used:      record.Used,
+		UsedAt:    record.UsedAt,
+	}
+}
@@ -0,0 +1,94 @@
+package embedded
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+type PeerPolicyRepository struct {
+	store *Store
+}
+
+var _ ports.PeerPolicyRepository = &PeerPolicyRepository{}
+
+func NewPeerPolicyRepository(store *Store) *PeerPolicyRepository {
+	return &PeerPolicyRepository{store}
+}
+
+// ListPeerPolicies returns the policies ordered by ID, like the Postgres query
+func (r *PeerPolicyRepository) ListPeerPolicies(ctx context.Context) ([]*models.PeerPolicy, error) {
+	policies := []*models.PeerPolicy{}
+	err := r.store.view(func(st *state) error {
+		for _, record := range st.PeerPolicies {
+			policies = append(policies, toPeerPolicy(record))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(policies, func(a, b *models.PeerPolicy) int { return cmp.Compare(a.ID, b.ID) })
+	return policies, nil
+}
+
+func (r *PeerPolicyRepository) CreatePeerPolicy(ctx context.Context, policy *models.PeerPolicy) (*models.PeerPolicy, error) {
+	var created peerPolicyRecord
+	err := r.store.update(ctx, func(tx *tx) error {
+		for _, record := range tx.PeerPolicies {
+			if record.Name == policy.Name {
+				return domainErrors.ErrPeerPolicyExists
+			}
+		}
+
+		created = peerPolicyRecord{
+			ID:          tx.nextID(sequencePeerPolicies),
+			Name:        policy.Name,
+			Peers:       slices.Clone(policy.Peers),
+			CanAllocate: policy.CanAllocate,
+			CanReserve:  policy.CanReserve,
+			MaxPool:     policy.MaxPool,
+			CreatedAt:   r.store.now(),
+		}
+		tx.putPeerPolicy(created)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toPeerPolicy(created), nil
+}
+
+func (r *PeerPolicyRepository) DeletePeerPolicy(ctx context.Context, id int64) (*models.PeerPolicy, error) {
+	var deleted peerPolicyRecord
+	err := r.store.update(ctx, func(tx *tx) error {
+		record, ok := tx.PeerPolicies[id]
+		if !ok {
+			return domainErrors.ErrPeerPolicyNotFound
+		}
+		deleted = record
+		tx.deletePeerPolicy(id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toPeerPolicy(deleted), nil
+}
+
+func toPeerPolicy(record peerPolicyRecord) *models.PeerPolicy {
+	return &models.PeerPolicy{
+		ID:          record.ID,
+		Name:        record.Name,
+		Peers:       slices.Clone(record.Peers),
+		CanAllocate: record.CanAllocate,
+		CanReserve:  record.CanReserve,
+		MaxPool:     record.MaxPool,
+		CreatedAt:   record.CreatedAt,
+	}
+}
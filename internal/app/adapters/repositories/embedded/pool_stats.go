@@ -0,0 +1,68 @@
+package embedded
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// PoolStatsRepository counts leases directly from the store
+type PoolStatsRepository struct {
+	store        *Store
+	releaseGrace time.Duration
+}
+
+var _ ports.PoolStatsRepository = &PoolStatsRepository{}
+
+func NewPoolStatsRepository(cfg *config.AppConfig, store *Store) *PoolStatsRepository {
+	return &PoolStatsRepository{store, time.Duration(cfg.Lease.ReleaseGrace) * time.Second}
+}
+
+func (r *PoolStatsRepository) CountPoolLeases(ctx context.Context, within time.Duration) (map[string]*models.PoolLeaseCounts, error) {
+	counts := make(map[string]*models.PoolLeaseCounts)
+	tenant := func(tenantID string) *models.PoolLeaseCounts {
+		if counts[tenantID] == nil {
+			counts[tenantID] = &models.PoolLeaseCounts{}
+		}
+		return counts[tenantID]
+	}
+
+	err := r.store.view(func(st *state) error {
+		now := r.store.now()
+		for _, record := range st.Leases {
+			c := tenant(record.tenant())
+			switch {
+			case record.ExpiresAt.After(now):
+				c.Allocated++
+			case quarantined(record, now):
+				c.Reserved++
+			case record.ExpiresAt.After(now.Add(-r.releaseGrace)):
+				c.Grace++
+			default:
+				c.ExpiredReclaimable++
+			}
+		}
+
+		// Allocations are appended to the history as they happen, so the window is at its
+		// end. Other events may be recorded with an earlier time and are skipped.
+		since := now.Add(-within)
+		for i := len(st.History) - 1; i >= 0; i-- {
+			event := st.History[i]
+			if event.Event != string(models.LeaseEventAllocate) {
+				continue
+			}
+			if !event.OccurredAt.After(since) {
+				break
+			}
+			tenant(tenantOf(event.TenantID)).Allocations++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,229 @@
+package embedded
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// LeaseReadModel computes reporting queries directly from the store. The data set is small
+// and held in memory, so there is nothing to materialize and Refresh is a no-op. Every
+// query is scoped to the tenant of its context.
+type LeaseReadModel struct {
+	store *Store
+}
+
+var _ ports.LeaseReadModel = &LeaseReadModel{}
+
+func NewLeaseReadModel(store *Store) *LeaseReadModel {
+	return &LeaseReadModel{store}
+}
+
+func (m *LeaseReadModel) Refresh(ctx context.Context) error {
+	return nil
+}
+
+func (m *LeaseReadModel) GetLeaseStats(ctx context.Context) (*models.LeaseStats, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	stats := &models.LeaseStats{}
+	err := m.store.view(func(st *state) error {
+		stats.RefreshedAt = m.store.now()
+		for _, record := range st.Leases {
+			if record.tenant() != tenantID {
+				continue
+			}
+			if record.ExpiresAt.After(stats.RefreshedAt) {
+				stats.Active++
+			} else {
+				stats.Expired++
+			}
+		}
+		stats.Total = stats.Active + stats.Expired
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (m *LeaseReadModel) ListLeases(ctx context.Context, opts *models.ListOptions) ([]*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	var leases []*models.Lease
+	err := m.store.view(func(st *state) error {
+		if _, ok := leaseFieldValue(leaseRecord{}, opts.SortField); !ok {
+			return fmt.Errorf("unsupported sort field %q", opts.SortField)
+		}
+
+		// order compares two leases by the sort field and then by token ID
+		order := func(a leaseRecord, sortValue any, tokenID int64) int {
+			value, _ := leaseFieldValue(a, opts.SortField)
+			c, _ := compareValues(value, sortValue)
+			c = cmp.Or(c, cmp.Compare(a.TokenID, tokenID))
+			if opts.Descending {
+				return -c
+			}
+			return c
+		}
+
+		var records []leaseRecord
+		for _, record := range st.Leases {
+			if record.tenant() != tenantID {
+				continue
+			}
+			match, err := matchesFilters(record, opts.Filters)
+			if err != nil {
+				return err
+			}
+			if !match {
+				continue
+			}
+			if opts.After != nil && order(record, opts.After.SortValue, opts.After.Key) <= 0 {
+				continue
+			}
+			records = append(records, record)
+		}
+
+		slices.SortFunc(records, func(a, b leaseRecord) int {
+			value, _ := leaseFieldValue(b, opts.SortField)
+			return order(a, value, b.TokenID)
+		})
+
+		if opts.After == nil {
+			records = records[min(opts.Offset, len(records)):]
+		}
+		records = records[:min(opts.Limit, len(records))]
+
+		now := m.store.now()
+		for _, record := range records {
+			leases = append(leases, toLease(record, now))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leases, nil
+}
+
+// leaseFieldValue returns the value of a listing field, typed like models.Filter values
+func leaseFieldValue(record leaseRecord, field string) (any, bool) {
+	switch field {
+	case "token_id":
+		return record.TokenID, true
+	case "peer_id":
+		return record.PeerID, true
+	case "affinity_group":
+		return record.AffinityGroup, true
+	case "created_at":
+		return record.CreatedAt, true
+	case "updated_at":
+		return record.UpdatedAt, true
+	case "expires_at":
+		return record.ExpiresAt, true
+	default:
+		return nil, false
+	}
+}
+
+func matchesFilters(record leaseRecord, filters []models.Filter) (bool, error) {
+	for _, filter := range filters {
+		if label, ok := filter.Value.(models.LeaseLabel); ok {
+			value, set := record.Labels[label.Key]
+			switch filter.Operator {
+			case models.FilterEq:
+				if !set || value != label.Value {
+					return false, nil
+				}
+			case models.FilterNe:
+				if set && value == label.Value {
+					return false, nil
+				}
+			default:
+				return false, fmt.Errorf("unsupported filter operator %q for labels", filter.Operator)
+			}
+			continue
+		}
+
+		value, ok := leaseFieldValue(record, filter.Field)
+		if !ok {
+			return false, fmt.Errorf("unsupported filter field %q", filter.Field)
+		}
+
+		if set, ok := filter.Value.([]string); ok {
+			s, _ := value.(string)
+			switch filter.Operator {
+			case models.FilterIn:
+				if !slices.Contains(set, s) {
+					return false, nil
+				}
+			case models.FilterNotIn:
+				if slices.Contains(set, s) {
+					return false, nil
+				}
+			default:
+				return false, fmt.Errorf("unsupported filter operator %q for a set of values", filter.Operator)
+			}
+			continue
+		}
+
+		c, ok := compareValues(value, filter.Value)
+		if !ok && filter.Operator != models.FilterPrefix {
+			return false, fmt.Errorf("invalid value for filter field %q", filter.Field)
+		}
+
+		var match bool
+		switch filter.Operator {
+		case models.FilterEq:
+			match = c == 0
+		case models.FilterNe:
+			match = c != 0
+		case models.FilterLt:
+			match = c < 0
+		case models.FilterLte:
+			match = c <= 0
+		case models.FilterGt:
+			match = c > 0
+		case models.FilterGte:
+			match = c >= 0
+		case models.FilterPrefix:
+			s, _ := value.(string)
+			prefix, _ := filter.Value.(string)
+			match = strings.HasPrefix(s, prefix)
+		default:
+			return false, fmt.Errorf("unsupported filter operator %q", filter.Operator)
+		}
+		if !match {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareValues orders two values of the same listing field type, ok is false when the
+// types differ
+func compareValues(a, b any) (int, bool) {
+	switch a := a.(type) {
+	case int64:
+		if b, ok := b.(int64); ok {
+			return cmp.Compare(a, b), true
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,116 @@
+package embedded
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// LeaseSnapshotRepository copies the pools and leases out of the store and replaces them
+// with a single update
+type LeaseSnapshotRepository struct {
+	store *Store
+}
+
+var _ ports.LeaseSnapshotRepository = &LeaseSnapshotRepository{}
+
+func NewLeaseSnapshotRepository(store *Store) *LeaseSnapshotRepository {
+	return &LeaseSnapshotRepository{store}
+}
+
+func (r *LeaseSnapshotRepository) ExportLeaseState(ctx context.Context) (*models.LeaseSnapshot, error) {
+	snapshot := &models.LeaseSnapshot{}
+	err := r.store.view(func(st *state) error {
+		snapshot.Pools = make([]*models.SnapshotPool, 0, len(st.Pools))
+		for _, tenantID := range st.tenants() {
+			pool := st.Pools[tenantID]
+			snapshot.Pools = append(snapshot.Pools, &models.SnapshotPool{
+				Tenant:      tenantID,
+				MinTokenID:  pool.MinTokenID,
+				MaxTokenID:  pool.MaxTokenID,
+				LastTokenID: pool.LastTokenID,
+			})
+		}
+
+		snapshot.Leases = make([]*models.SnapshotLease, 0, len(st.Leases))
+		for _, record := range st.Leases {
+			snapshot.Leases = append(snapshot.Leases, &models.SnapshotLease{
+				TokenID:          record.TokenID,
+				PeerID:           record.PeerID,
+				Tenant:           record.tenant(),
+				AffinityGroup:    record.AffinityGroup,
+				DelegatedBy:      record.DelegatedBy,
+				Labels:           record.Labels,
+				ExpiresAt:        record.ExpiresAt,
+				CreatedAt:        record.CreatedAt,
+				UpdatedAt:        record.UpdatedAt,
+				ReclaimedAt:      record.ReclaimedAt,
+				QuarantinedUntil: record.QuarantinedUntil,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(snapshot.Leases, func(a, b *models.SnapshotLease) int {
+		return cmp.Or(cmp.Compare(a.Tenant, b.Tenant), cmp.Compare(a.TokenID, b.TokenID))
+	})
+	return snapshot, nil
+}
+
+func (r *LeaseSnapshotRepository) ImportLeaseState(ctx context.Context, snapshot *models.LeaseSnapshot, replace bool) (*models.SnapshotImportReport, error) {
+	report := &models.SnapshotImportReport{Pools: len(snapshot.Pools), Leases: len(snapshot.Leases)}
+	err := r.store.update(ctx, func(tx *tx) error {
+		if len(tx.Leases) > 0 && !replace {
+			return domainErrors.ErrStoreNotEmpty
+		}
+		report.Replaced = int64(len(tx.Leases))
+
+		// Pools missing from the snapshot start over from their first token ID
+		for tenantID, pool := range tx.Pools {
+			if tenantID == models.DefaultTenantID {
+				pool.LastTokenID = pool.MinTokenID - 1
+				tx.setPool(tenantID, pool)
+			} else {
+				tx.deletePool(tenantID)
+			}
+		}
+		for _, pool := range snapshot.Pools {
+			tx.setPool(pool.Tenant, poolRecord{
+				MinTokenID:  pool.MinTokenID,
+				MaxTokenID:  pool.MaxTokenID,
+				LastTokenID: pool.LastTokenID,
+			})
+		}
+
+		for tokenID := range tx.Leases {
+			tx.deleteLease(tokenID)
+		}
+		for _, lease := range snapshot.Leases {
+			tx.putLease(leaseRecord{
+				TokenID:          lease.TokenID,
+				PeerID:           lease.PeerID,
+				TenantID:         tenantField(lease.Tenant),
+				AffinityGroup:    lease.AffinityGroup,
+				DelegatedBy:      lease.DelegatedBy,
+				Labels:           lease.Labels,
+				ExpiresAt:        lease.ExpiresAt,
+				CreatedAt:        lease.CreatedAt,
+				UpdatedAt:        lease.UpdatedAt,
+				ReclaimedAt:      lease.ReclaimedAt,
+				QuarantinedUntil: lease.QuarantinedUntil,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,265 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/fx"
+)
+
+// Allocation pool bounds, matching the alloc_state defaults of the Postgres schema
+const (
+	defaultMinTokenID  = 167902210
+	defaultMaxTokenID  = 168162304
+	defaultLastTokenID = defaultMinTokenID - 1
+)
+
+// leaseRecord mirrors a row of the leases table
+type leaseRecord struct {
+	TokenID       int64             `json:"token_id"`
+	PeerID        string            `json:"peer_id"`
+	TenantID      string            `json:"tenant_id,omitempty"` // empty for the default tenant
+	AffinityGroup string            `json:"affinity_group,omitempty"`
+	DelegatedBy   string            `json:"delegated_by,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	ExpiresAt     time.Time         `json:"expires_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	ReclaimedAt   *time.Time        `json:"reclaimed_at,omitempty"`
+	// QuarantinedUntil withholds the token ID from allocation after an address conflict
+	QuarantinedUntil *time.Time `json:"quarantined_until,omitempty"`
+}
+
+func (r leaseRecord) tenant() string {
+	return tenantOf(r.TenantID)
+}
+
+// nonceRecord mirrors a row of the nonces table
+type nonceRecord struct {
+	ID        string    `json:"id"`
+	PeerID    string    `json:"peer_id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Used      bool      `json:"used"`
+	UsedAt    time.Time `json:"used_at,omitzero"`
+}
+
+// historyRecord mirrors a row of the lease_history table
+type historyRecord struct {
+	TokenID    int64     `json:"token_id"`
+	PeerID     string    `json:"peer_id"`
+	TenantID   string    `json:"tenant_id,omitempty"` // empty for the default tenant
+	Event      string    `json:"event"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// poolRecord mirrors the pool bounds and allocation cursor of an alloc_state row
+type poolRecord struct {
+	MinTokenID  int64 `json:"min_token_id"`
+	MaxTokenID  int64 `json:"max_token_id"`
+	LastTokenID int64 `json:"last_token_id"`
+}
+
+// tenantField is the stored tenant of a record, the default tenant is left out so that
+// files written before tenants existed read the same
+func tenantField(tenantID string) string {
+	if tenantID == models.DefaultTenantID {
+		return ""
+	}
+	return tenantID
+}
+
+// tenantOf reverses tenantField
+func tenantOf(field string) string {
+	if field == "" {
+		return models.DefaultTenantID
+	}
+	return field
+}
+
+// accessRuleRecord mirrors a row of the access_rules table
+type accessRuleRecord struct {
+	ID          int64     `json:"id"`
+	List        string    `json:"list"`
+	SubjectType string    `json:"subject_type"`
+	Subject     string    `json:"subject"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// apiKeyRecord mirrors a row of the api_keys table
+type apiKeyRecord struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	KeyHash    string     `json:"key_hash"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// peerPolicyRecord mirrors a row of the peer_policies table
+type peerPolicyRecord struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Peers       []string  `json:"peers"`
+	CanAllocate bool      `json:"can_allocate"`
+	CanReserve  bool      `json:"can_reserve"`
+	MaxPool     int       `json:"max_pool"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Sequences of the IDs of the stored records
+const (
+	sequenceAccessRules  = "access_rules"
+	sequenceAPIKeys      = "api_keys"
+	sequencePeerPolicies = "peer_policies"
+)
+
+// state is everything the embedded backend stores, held in memory and mirrored by the
+// buckets of the database
+type state struct {
+	Pools        map[string]poolRecord // by tenant, the default one included
+	Leases       map[int64]leaseRecord
+	Nonces       map[string]nonceRecord
+	History      []historyRecord
+	AccessRules  map[int64]accessRuleRecord
+	APIKeys      map[int64]apiKeyRecord
+	PeerPolicies map[int64]peerPolicyRecord
+	Sequences    map[string]int64 // last ID handed out, by record type
+}
+
+func newState() *state {
+	return &state{
+		Pools: map[string]poolRecord{
+			models.DefaultTenantID: {MinTokenID: defaultMinTokenID, MaxTokenID: defaultMaxTokenID, LastTokenID: defaultLastTokenID},
+		},
+		Leases:       make(map[int64]leaseRecord),
+		Nonces:       make(map[string]nonceRecord),
+		AccessRules:  make(map[int64]accessRuleRecord),
+		APIKeys:      make(map[int64]apiKeyRecord),
+		PeerPolicies: make(map[int64]peerPolicyRecord),
+		Sequences:    make(map[string]int64),
+	}
+}
+
+// pool returns the allocation state of a tenant, false when the tenant has none yet
+func (st *state) pool(tenantID string) (poolRecord, bool) {
+	p, ok := st.Pools[tenantID]
+	return p, ok
+}
+
+// tenants returns the tenants with a pool, the default one first and the others by ID
+func (st *state) tenants() []string {
+	tenants := []string{models.DefaultTenantID}
+	for _, tenantID := range slices.Sorted(maps.Keys(st.Pools)) {
+		if tenantID != models.DefaultTenantID {
+			tenants = append(tenants, tenantID)
+		}
+	}
+	return tenants
+}
+
+// Store keeps the whole data set in memory and writes every change to a bbolt database,
+// one key per record, so a change only writes the records it touched. It is meant for
+// single-node deployments with a small pool: lookups scan the records in memory, and
+// only one process may open the database at a time.
+type Store struct {
+	db    *bolt.DB // nil for a store that is never written to disk
+	clock ports.Clock
+
+	mu       sync.RWMutex
+	state    *state
+	writeErr error // last write failure, reported by Ping until a write succeeds
+}
+
+var _ ports.HealthChecker = &Store{}
+
+func NewStore(lc fx.Lifecycle, cfg *config.AppConfig, clock ports.Clock) (*Store, error) {
+	if cfg.StoragePath == "" {
+		return nil, fmt.Errorf("storage_path must be set for the %s storage backend", config.StorageBackendEmbedded)
+	}
+
+	db, err := openDatabase(cfg.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	st, err := loadState(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &Store{db: db, clock: clock, state: st}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return db.Close()
+		},
+	})
+	return s, nil
+}
+
+// NewMemoryStore creates a store that only lives in memory, for development and tests
+func NewMemoryStore(clock ports.Clock) *Store {
+	return &Store{clock: clock, state: newState()}
+}
+
+// now is the time expiries of the stored leases and nonces are compared against
+func (s *Store) now() time.Time {
+	return s.clock.Now()
+}
+
+// view runs fn against the current state. fn must not modify it.
+func (s *Store) view(fn func(st *state) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(s.state)
+}
+
+// update runs fn against the current state and writes the records it changed to the
+// database in one transaction. When fn or the write fails, its changes are undone, so a
+// failed update leaves no trace.
+func (s *Store) update(ctx context.Context, fn func(tx *tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := newTx(s.state)
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	if s.db == nil || !tx.changed() {
+		return nil
+	}
+
+	err := s.db.Update(tx.write)
+	s.writeErr = err
+	if err != nil {
+		tx.rollback()
+		return fmt.Errorf("write storage file: %w", err)
+	}
+	return nil
+}
+
+// Ping reports whether the last write to the database succeeded
+func (s *Store) Ping(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.writeErr
+}
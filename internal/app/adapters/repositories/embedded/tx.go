@@ -0,0 +1,207 @@
+package embedded
+
+import (
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// tx is an update of the state in progress. The state is changed in place through the
+// methods of tx, which remember what every touched record held before, so that the
+// update can write just those records to the database or undo them.
+type tx struct {
+	*state
+
+	pools        table[string, poolRecord]
+	leases       table[int64, leaseRecord]
+	nonces       table[string, nonceRecord]
+	accessRules  table[int64, accessRuleRecord]
+	apiKeys      table[int64, apiKeyRecord]
+	peerPolicies table[int64, peerPolicyRecord]
+	sequences    table[string, int64]
+	history      int // length of the history before the update
+}
+
+func newTx(st *state) *tx {
+	return &tx{
+		state:        st,
+		pools:        table[string, poolRecord]{bucket: bucketPools, key: stringKey},
+		leases:       table[int64, leaseRecord]{bucket: bucketLeases, key: idKey},
+		nonces:       table[string, nonceRecord]{bucket: bucketNonces, key: stringKey},
+		accessRules:  table[int64, accessRuleRecord]{bucket: bucketAccessRules, key: idKey},
+		apiKeys:      table[int64, apiKeyRecord]{bucket: bucketAPIKeys, key: idKey},
+		peerPolicies: table[int64, peerPolicyRecord]{bucket: bucketPeerPolicies, key: idKey},
+		sequences:    table[string, int64]{bucket: bucketSequences, key: stringKey},
+		history:      len(st.History),
+	}
+}
+
+func (tx *tx) setPool(tenantID string, pool poolRecord) {
+	tx.pools.put(tx.Pools, tenantID, pool)
+}
+
+func (tx *tx) deletePool(tenantID string) {
+	tx.pools.delete(tx.Pools, tenantID)
+}
+
+func (tx *tx) putLease(record leaseRecord) {
+	tx.leases.put(tx.Leases, record.TokenID, record)
+}
+
+func (tx *tx) deleteLease(tokenID int64) {
+	tx.leases.delete(tx.Leases, tokenID)
+}
+
+func (tx *tx) putNonce(record nonceRecord) {
+	tx.nonces.put(tx.Nonces, record.ID, record)
+}
+
+func (tx *tx) deleteNonce(id string) {
+	tx.nonces.delete(tx.Nonces, id)
+}
+
+func (tx *tx) putAccessRule(record accessRuleRecord) {
+	tx.accessRules.put(tx.AccessRules, record.ID, record)
+}
+
+func (tx *tx) deleteAccessRule(id int64) {
+	tx.accessRules.delete(tx.AccessRules, id)
+}
+
+func (tx *tx) putAPIKey(record apiKeyRecord) {
+	tx.apiKeys.put(tx.APIKeys, record.ID, record)
+}
+
+func (tx *tx) putPeerPolicy(record peerPolicyRecord) {
+	tx.peerPolicies.put(tx.PeerPolicies, record.ID, record)
+}
+
+func (tx *tx) deletePeerPolicy(id int64) {
+	tx.peerPolicies.delete(tx.PeerPolicies, id)
+}
+
+// nextID hands out the next ID of a sequence
+func (tx *tx) nextID(sequence string) int64 {
+	id := tx.Sequences[sequence] + 1
+	tx.sequences.put(tx.Sequences, sequence, id)
+	return id
+}
+
+func (tx *tx) changed() bool {
+	return tx.pools.changed() || tx.leases.changed() || tx.nonces.changed() || tx.accessRules.changed() ||
+		tx.apiKeys.changed() || tx.peerPolicies.changed() || tx.sequences.changed() || len(tx.History) > tx.history
+}
+
+// write stores the records the update changed in the database
+func (tx *tx) write(btx *bolt.Tx) error {
+	for _, write := range []func(*bolt.Tx) error{
+		func(btx *bolt.Tx) error { return tx.pools.write(btx, tx.Pools) },
+		func(btx *bolt.Tx) error { return tx.leases.write(btx, tx.Leases) },
+		func(btx *bolt.Tx) error { return tx.nonces.write(btx, tx.Nonces) },
+		func(btx *bolt.Tx) error { return tx.accessRules.write(btx, tx.AccessRules) },
+		func(btx *bolt.Tx) error { return tx.apiKeys.write(btx, tx.APIKeys) },
+		func(btx *bolt.Tx) error { return tx.peerPolicies.write(btx, tx.PeerPolicies) },
+		func(btx *bolt.Tx) error { return tx.sequences.write(btx, tx.Sequences) },
+	} {
+		if err := write(btx); err != nil {
+			return err
+		}
+	}
+
+	history := btx.Bucket(bucketHistory)
+	for _, record := range tx.History[tx.history:] {
+		seq, err := history.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := putJSON(history, idKey(int64(seq)), record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollback undoes the changes of the update
+func (tx *tx) rollback() {
+	tx.pools.rollback(tx.Pools)
+	tx.leases.rollback(tx.Leases)
+	tx.nonces.rollback(tx.Nonces)
+	tx.accessRules.rollback(tx.AccessRules)
+	tx.apiKeys.rollback(tx.APIKeys)
+	tx.peerPolicies.rollback(tx.PeerPolicies)
+	tx.sequences.rollback(tx.Sequences)
+	tx.History = tx.History[:tx.history]
+}
+
+// table tracks the records of one map of the state an update touched, stored in bucket
+// under key
+type table[K comparable, V any] struct {
+	bucket []byte
+	key    func(K) []byte
+	before map[K]prior[V]
+}
+
+// prior is a record as it was before the update
+type prior[V any] struct {
+	value  V
+	exists bool
+}
+
+func (t *table[K, V]) put(m map[K]V, k K, v V) {
+	t.remember(m, k)
+	m[k] = v
+}
+
+func (t *table[K, V]) delete(m map[K]V, k K) {
+	t.remember(m, k)
+	delete(m, k)
+}
+
+func (t *table[K, V]) remember(m map[K]V, k K) {
+	if t.before == nil {
+		t.before = make(map[K]prior[V])
+	}
+	if _, ok := t.before[k]; !ok {
+		v, exists := m[k]
+		t.before[k] = prior[V]{v, exists}
+	}
+}
+
+func (t *table[K, V]) changed() bool {
+	return len(t.before) > 0
+}
+
+func (t *table[K, V]) write(btx *bolt.Tx, m map[K]V) error {
+	bucket := btx.Bucket(t.bucket)
+	for k := range t.before {
+		v, ok := m[k]
+		if !ok {
+			if err := bucket.Delete(t.key(k)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := putJSON(bucket, t.key(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *table[K, V]) rollback(m map[K]V) {
+	for k, p := range t.before {
+		if p.exists {
+			m[k] = p.value
+		} else {
+			delete(m, k)
+		}
+	}
+}
+
+func putJSON(bucket *bolt.Bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
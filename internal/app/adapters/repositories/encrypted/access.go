@@ -0,0 +1,66 @@
+package encrypted
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// AccessRuleRepository stores the subjects of access rules, peer IDs and public keys
+// alike, encrypted
+type AccessRuleRepository struct {
+	repo   ports.AccessRuleRepository
+	cipher ports.FieldCipher
+}
+
+var _ ports.AccessRuleRepository = &AccessRuleRepository{}
+
+func NewAccessRuleRepository(repo ports.AccessRuleRepository, cipher ports.FieldCipher) *AccessRuleRepository {
+	return &AccessRuleRepository{repo: repo, cipher: cipher}
+}
+
+func (r *AccessRuleRepository) GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error) {
+	if err := encrypt(r.cipher, &subject); err != nil {
+		return nil, err
+	}
+	return r.rules(r.repo.GetAccessRules(ctx, subjectType, subject))
+}
+
+func (r *AccessRuleRepository) ListAccessRules(ctx context.Context, list models.AccessList) ([]*models.AccessRule, error) {
+	return r.rules(r.repo.ListAccessRules(ctx, list))
+}
+
+func (r *AccessRuleRepository) CreateAccessRule(ctx context.Context, rule *models.AccessRule) (*models.AccessRule, error) {
+	encrypted := *rule
+	if err := encrypt(r.cipher, &encrypted.Subject); err != nil {
+		return nil, err
+	}
+	return r.rule(r.repo.CreateAccessRule(ctx, &encrypted))
+}
+
+func (r *AccessRuleRepository) DeleteAccessRule(ctx context.Context, id int64) (*models.AccessRule, error) {
+	return r.rule(r.repo.DeleteAccessRule(ctx, id))
+}
+
+func (r *AccessRuleRepository) rule(rule *models.AccessRule, err error) (*models.AccessRule, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := decrypt(r.cipher, &rule.Subject); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *AccessRuleRepository) rules(rules []*models.AccessRule, err error) ([]*models.AccessRule, error) {
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if err := decrypt(r.cipher, &rule.Subject); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
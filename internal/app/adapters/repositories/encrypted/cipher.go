@@ -0,0 +1,74 @@
+package encrypted
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/fieldcrypt"
+	"go.uber.org/zap"
+)
+
+// NewFieldCipher loads the key from security.field_encryption_key or the file at
+// security.field_encryption_key_path. Without either it returns nil and the repositories
+// are left undecorated.
+func NewFieldCipher(cfg *config.AppConfig, logger *zap.Logger) (ports.FieldCipher, error) {
+	encoded := cfg.Security.FieldEncryptionKey
+	if path := cfg.Security.FieldEncryptionKeyPath; path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read field encryption key: %w", err)
+		}
+		encoded = string(content)
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := fieldcrypt.ParseKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("field encryption key: %w", err)
+	}
+	cipher, err := fieldcrypt.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Encrypting peer IDs and public keys at rest")
+	return cipher, nil
+}
+
+func encrypt(cipher ports.FieldCipher, values ...*string) error {
+	for _, value := range values {
+		encrypted, err := cipher.Encrypt(*value)
+		if err != nil {
+			return fmt.Errorf("encrypt field: %w", err)
+		}
+		*value = encrypted
+	}
+	return nil
+}
+
+func decrypt(cipher ports.FieldCipher, values ...*string) error {
+	for _, value := range values {
+		decrypted, err := cipher.Decrypt(*value)
+		if err != nil {
+			return fmt.Errorf("decrypt field: %w", err)
+		}
+		*value = decrypted
+	}
+	return nil
+}
+
+// decryptLease decrypts the peer ID of lease in place, passing nil through
+func decryptLease(cipher ports.FieldCipher, lease *models.Lease) (*models.Lease, error) {
+	if lease == nil {
+		return nil, nil
+	}
+	if err := decrypt(cipher, &lease.PeerID); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
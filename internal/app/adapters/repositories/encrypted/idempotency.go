@@ -0,0 +1,56 @@
+package encrypted
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// IdempotencyStore encrypts idempotency keys, which are scoped to a peer ID, and the
+// stored responses, which hold leases
+type IdempotencyStore struct {
+	store  ports.IdempotencyStore
+	cipher ports.FieldCipher
+}
+
+var _ ports.IdempotencyStore = &IdempotencyStore{}
+
+func NewIdempotencyStore(store ports.IdempotencyStore, cipher ports.FieldCipher) *IdempotencyStore {
+	return &IdempotencyStore{store: store, cipher: cipher}
+}
+
+func (s *IdempotencyStore) Claim(ctx context.Context, key string, ttl time.Duration) (*models.IdempotentResponse, error) {
+	if err := encrypt(s.cipher, &key); err != nil {
+		return nil, err
+	}
+	response, err := s.store.Claim(ctx, key, ttl)
+	if err != nil || response == nil {
+		return response, err
+	}
+
+	body := string(response.Body)
+	if err := decrypt(s.cipher, &body); err != nil {
+		return nil, err
+	}
+	response.Body = []byte(body)
+	return response, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, response *models.IdempotentResponse, ttl time.Duration) error {
+	encrypted := *response
+	body := string(response.Body)
+	if err := encrypt(s.cipher, &key, &body); err != nil {
+		return err
+	}
+	encrypted.Body = []byte(body)
+	return s.store.Complete(ctx, key, &encrypted, ttl)
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	if err := encrypt(s.cipher, &key); err != nil {
+		return err
+	}
+	return s.store.Release(ctx, key)
+}
@@ -0,0 +1,215 @@
+package encrypted
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// LeaseRepository encrypts the peer IDs handed to the wrapped repository and decrypts the
+// ones it returns, so leases, their history and the cached lookups only hold ciphertext
+type LeaseRepository struct {
+	repo   ports.LeaseRepository
+	cipher ports.FieldCipher
+}
+
+var _ ports.LeaseRepository = &LeaseRepository{}
+
+func NewLeaseRepository(repo ports.LeaseRepository, cipher ports.FieldCipher) *LeaseRepository {
+	return &LeaseRepository{repo: repo, cipher: cipher}
+}
+
+func (r *LeaseRepository) FindAndReuseExpiredLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	return r.lease(r.repo.FindAndReuseExpiredLease(ctx, peerID))
+}
+
+func (r *LeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	return r.lease(r.repo.AllocateNewLease(ctx, peerID))
+}
+
+func (r *LeaseRepository) AllocateRequestedLease(ctx context.Context, peerID string, tokenID int64) (*models.Lease, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	return r.lease(r.repo.AllocateRequestedLease(ctx, peerID, tokenID))
+}
+
+func (r *LeaseRepository) AllocateAffinityLease(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	return r.lease(r.repo.AllocateAffinityLease(ctx, peerID, affinityGroup))
+}
+
+func (r *LeaseRepository) AllocateZoneLease(ctx context.Context, peerID string, zone string) (*models.Lease, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	return r.lease(r.repo.AllocateZoneLease(ctx, peerID, zone))
+}
+
+func (r *LeaseRepository) SetLeaseAffinityGroup(ctx context.Context, tokenID int64, affinityGroup string) error {
+	return r.repo.SetLeaseAffinityGroup(ctx, tokenID, affinityGroup)
+}
+
+func (r *LeaseRepository) SetLeaseDelegator(ctx context.Context, tokenID int64, gatewayPeerID string) error {
+	if err := encrypt(r.cipher, &gatewayPeerID); err != nil {
+		return err
+	}
+	return r.repo.SetLeaseDelegator(ctx, tokenID, gatewayPeerID)
+}
+
+func (r *LeaseRepository) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) error {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return err
+	}
+	return r.repo.SetLeaseLabels(ctx, tokenID, peerID, labels)
+}
+
+func (r *LeaseRepository) CountDelegatedLeases(ctx context.Context, gatewayPeerID string) (int64, error) {
+	if err := encrypt(r.cipher, &gatewayPeerID); err != nil {
+		return 0, err
+	}
+	return r.repo.CountDelegatedLeases(ctx, gatewayPeerID)
+}
+
+func (r *LeaseRepository) GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error) {
+	return r.lease(r.repo.GetLeaseByTokenID(ctx, tokenID))
+}
+
+func (r *LeaseRepository) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	return r.lease(r.repo.GetLeaseByPeerID(ctx, peerID))
+}
+
+func (r *LeaseRepository) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	return r.lease(r.repo.RenewLease(ctx, tokenID, peerID))
+}
+
+func (r *LeaseRepository) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return err
+	}
+	return r.repo.ReleaseLease(ctx, tokenID, peerID)
+}
+
+func (r *LeaseRepository) TransferLease(ctx context.Context, tokenID int64, fromPeerID string, toPeerID string) (*models.Lease, error) {
+	if err := encrypt(r.cipher, &fromPeerID, &toPeerID); err != nil {
+		return nil, err
+	}
+	return r.lease(r.repo.TransferLease(ctx, tokenID, fromPeerID, toPeerID))
+}
+
+func (r *LeaseRepository) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	// The caller's operations are left untouched
+	encrypted := make([]*models.LeaseOperation, len(operations))
+	for i, op := range operations {
+		copied := *op
+		if err := encrypt(r.cipher, &copied.PeerID); err != nil {
+			return nil, err
+		}
+		encrypted[i] = &copied
+	}
+
+	results, err := r.repo.ExecuteBatch(ctx, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		if result.Lease == nil {
+			continue
+		}
+		if err := decrypt(r.cipher, &result.Lease.PeerID); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (r *LeaseRepository) ListReclaimCandidates(ctx context.Context, afterTokenID int64, limit int) ([]*models.ReclaimCandidate, error) {
+	candidates, err := r.repo.ListReclaimCandidates(ctx, afterTokenID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		if err := decrypt(r.cipher, &candidate.PeerID); err != nil {
+			return nil, err
+		}
+	}
+	return candidates, nil
+}
+
+func (r *LeaseRepository) ListExpiringLeases(ctx context.Context, within time.Duration, afterTokenID int64, limit int) ([]*models.ExpiringLease, error) {
+	leases, err := r.repo.ListExpiringLeases(ctx, within, afterTokenID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, lease := range leases {
+		if err := decrypt(r.cipher, &lease.PeerID); err != nil {
+			return nil, err
+		}
+	}
+	return leases, nil
+}
+
+func (r *LeaseRepository) ReclaimLeases(ctx context.Context, tokenIDs []int64) ([]int64, error) {
+	return r.repo.ReclaimLeases(ctx, tokenIDs)
+}
+
+func (r *LeaseRepository) RevokeLeases(ctx context.Context, leases []*models.Lease) ([]int64, error) {
+	// The caller's leases are left untouched
+	encrypted := make([]*models.Lease, len(leases))
+	for i, lease := range leases {
+		encrypted[i] = &models.Lease{TokenID: lease.TokenID, PeerID: lease.PeerID}
+		if err := encrypt(r.cipher, &encrypted[i].PeerID); err != nil {
+			return nil, err
+		}
+	}
+	return r.repo.RevokeLeases(ctx, encrypted)
+}
+
+func (r *LeaseRepository) GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error) {
+	entries, err := r.repo.GetLeaseHistory(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if err := decrypt(r.cipher, &entry.PeerID); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func (r *LeaseRepository) RecordConflict(ctx context.Context, tokenID int64, peerID string, quarantine time.Duration) (*models.LeaseConflict, error) {
+	encryptedPeerID := peerID
+	if err := encrypt(r.cipher, &encryptedPeerID); err != nil {
+		return nil, err
+	}
+	conflict, err := r.repo.RecordConflict(ctx, tokenID, encryptedPeerID, quarantine)
+	if err != nil {
+		return nil, err
+	}
+	conflict.PeerID = peerID
+	return conflict, nil
+}
+
+func (r *LeaseRepository) lease(lease *models.Lease, err error) (*models.Lease, error) {
+	if err != nil {
+		return nil, err
+	}
+	return decryptLease(r.cipher, lease)
+}
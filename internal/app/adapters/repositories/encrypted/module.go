@@ -0,0 +1,46 @@
+package encrypted
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/fx"
+)
+
+// Module wraps the repositories that store peer IDs and public keys when a field
+// encryption key is configured, and leaves them as they are otherwise. The lease
+// repository and the snapshot repository are wrapped along with the lease journal, see
+// repositories.NewModule.
+var Module = fx.Options(
+	fx.Provide(NewFieldCipher),
+	fx.Decorate(
+		func(cipher ports.FieldCipher, repo ports.NonceRepository) ports.NonceRepository {
+			if cipher == nil {
+				return repo
+			}
+			return NewNonceRepository(repo, cipher)
+		},
+		func(cipher ports.FieldCipher, repo ports.AccessRuleRepository) ports.AccessRuleRepository {
+			if cipher == nil {
+				return repo
+			}
+			return NewAccessRuleRepository(repo, cipher)
+		},
+		func(cipher ports.FieldCipher, repo ports.PeerPolicyRepository) ports.PeerPolicyRepository {
+			if cipher == nil {
+				return repo
+			}
+			return NewPeerPolicyRepository(repo, cipher)
+		},
+		func(cipher ports.FieldCipher, readModel ports.LeaseReadModel) ports.LeaseReadModel {
+			if cipher == nil {
+				return readModel
+			}
+			return NewLeaseReadModel(readModel, cipher)
+		},
+		func(cipher ports.FieldCipher, store ports.IdempotencyStore) ports.IdempotencyStore {
+			if cipher == nil {
+				return store
+			}
+			return NewIdempotencyStore(store, cipher)
+		},
+	),
+)
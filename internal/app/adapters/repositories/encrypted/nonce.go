@@ -0,0 +1,79 @@
+package encrypted
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// NonceRepository stores the peer IDs nonces were issued to encrypted
+type NonceRepository struct {
+	repo   ports.NonceRepository
+	cipher ports.FieldCipher
+}
+
+var _ ports.NonceRepository = &NonceRepository{}
+
+func NewNonceRepository(repo ports.NonceRepository, cipher ports.FieldCipher) *NonceRepository {
+	return &NonceRepository{repo: repo, cipher: cipher}
+}
+
+func (r *NonceRepository) GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
+	nonce, err := r.repo.GetNonce(ctx, nonceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := decrypt(r.cipher, &nonce.PeerID); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+func (r *NonceRepository) CreateNonce(ctx context.Context, peerID string) (*models.Nonce, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	nonce, err := r.repo.CreateNonce(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := decrypt(r.cipher, &nonce.PeerID); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+func (r *NonceRepository) ConsumeNonce(ctx context.Context, nonceID string, peerID string) error {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return err
+	}
+	return r.repo.ConsumeNonce(ctx, nonceID, peerID)
+}
+
+func (r *NonceRepository) ListOutstandingNonces(ctx context.Context, peerID string) ([]*models.Nonce, error) {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return nil, err
+	}
+	nonces, err := r.repo.ListOutstandingNonces(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, nonce := range nonces {
+		if err := decrypt(r.cipher, &nonce.PeerID); err != nil {
+			return nil, err
+		}
+	}
+	return nonces, nil
+}
+
+func (r *NonceRepository) RevokeNonce(ctx context.Context, nonceID string, peerID string) error {
+	if err := encrypt(r.cipher, &peerID); err != nil {
+		return err
+	}
+	return r.repo.RevokeNonce(ctx, nonceID, peerID)
+}
+
+func (r *NonceRepository) DeleteExpiredNonces(ctx context.Context, limit int) (int64, error) {
+	return r.repo.DeleteExpiredNonces(ctx, limit)
+}
@@ -0,0 +1,71 @@
+package encrypted
+
+import (
+	"context"
+	"slices"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// PeerPolicyRepository stores the peer IDs listed by peer policies encrypted. The
+// wildcard is not a peer ID and is stored as it is.
+type PeerPolicyRepository struct {
+	repo   ports.PeerPolicyRepository
+	cipher ports.FieldCipher
+}
+
+var _ ports.PeerPolicyRepository = &PeerPolicyRepository{}
+
+func NewPeerPolicyRepository(repo ports.PeerPolicyRepository, cipher ports.FieldCipher) *PeerPolicyRepository {
+	return &PeerPolicyRepository{repo: repo, cipher: cipher}
+}
+
+func (r *PeerPolicyRepository) ListPeerPolicies(ctx context.Context) ([]*models.PeerPolicy, error) {
+	policies, err := r.repo.ListPeerPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, policy := range policies {
+		if err := r.peers(policy, decrypt); err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
+func (r *PeerPolicyRepository) CreatePeerPolicy(ctx context.Context, policy *models.PeerPolicy) (*models.PeerPolicy, error) {
+	encrypted := *policy
+	encrypted.Peers = slices.Clone(policy.Peers)
+	if err := r.peers(&encrypted, encrypt); err != nil {
+		return nil, err
+	}
+	return r.policy(r.repo.CreatePeerPolicy(ctx, &encrypted))
+}
+
+func (r *PeerPolicyRepository) DeletePeerPolicy(ctx context.Context, id int64) (*models.PeerPolicy, error) {
+	return r.policy(r.repo.DeletePeerPolicy(ctx, id))
+}
+
+func (r *PeerPolicyRepository) policy(policy *models.PeerPolicy, err error) (*models.PeerPolicy, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := r.peers(policy, decrypt); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// peers encrypts or decrypts the peer IDs of policy in place
+func (r *PeerPolicyRepository) peers(policy *models.PeerPolicy, transform func(ports.FieldCipher, ...*string) error) error {
+	for i := range policy.Peers {
+		if policy.Peers[i] == models.PeerPolicyWildcard {
+			continue
+		}
+		if err := transform(r.cipher, &policy.Peers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package encrypted
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// LeaseReadModel decrypts the peer IDs of listed leases. Ciphertext keeps equality but
+// not order, so peer IDs can only be filtered for (in)equality or set membership and not
+// sorted on.
+type LeaseReadModel struct {
+	readModel ports.LeaseReadModel
+	cipher    ports.FieldCipher
+}
+
+var _ ports.LeaseReadModel = &LeaseReadModel{}
+
+func NewLeaseReadModel(readModel ports.LeaseReadModel, cipher ports.FieldCipher) *LeaseReadModel {
+	return &LeaseReadModel{readModel: readModel, cipher: cipher}
+}
+
+func (m *LeaseReadModel) Refresh(ctx context.Context) error {
+	return m.readModel.Refresh(ctx)
+}
+
+func (m *LeaseReadModel) GetLeaseStats(ctx context.Context) (*models.LeaseStats, error) {
+	return m.readModel.GetLeaseStats(ctx)
+}
+
+func (m *LeaseReadModel) ListLeases(ctx context.Context, opts *models.ListOptions) ([]*models.Lease, error) {
+	if opts.SortField == "peer_id" {
+		return nil, domainErrors.ErrInvalidSort.WithDetails(`cannot sort by "peer_id" while peer IDs are stored encrypted`)
+	}
+
+	encrypted := *opts
+	encrypted.Filters = slices.Clone(opts.Filters)
+	for i, filter := range encrypted.Filters {
+		if filter.Field != "peer_id" {
+			continue
+		}
+		switch value := filter.Value.(type) {
+		case string:
+			if filter.Operator != models.FilterEq && filter.Operator != models.FilterNe {
+				break
+			}
+			if err := encrypt(m.cipher, &value); err != nil {
+				return nil, err
+			}
+			encrypted.Filters[i].Value = value
+			continue
+		case []string:
+			peerIDs := slices.Clone(value)
+			for j := range peerIDs {
+				if err := encrypt(m.cipher, &peerIDs[j]); err != nil {
+					return nil, err
+				}
+			}
+			encrypted.Filters[i].Value = peerIDs
+			continue
+		}
+		return nil, domainErrors.ErrInvalidFilter.WithDetails(fmt.Sprintf(
+			"operator %q is not supported for %q while peer IDs are stored encrypted", filter.Operator, filter.Field))
+	}
+
+	leases, err := m.readModel.ListLeases(ctx, &encrypted)
+	if err != nil {
+		return nil, err
+	}
+	for _, lease := range leases {
+		if err := decrypt(m.cipher, &lease.PeerID); err != nil {
+			return nil, err
+		}
+	}
+	return leases, nil
+}
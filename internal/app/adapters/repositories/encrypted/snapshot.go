@@ -0,0 +1,50 @@
+package encrypted
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// LeaseSnapshotRepository exports leases with plaintext peer IDs and encrypts them on
+// import, so snapshots move between stores with different keys or none. Exporting and
+// importing with the key configured also encrypts leases stored before encryption was
+// enabled.
+type LeaseSnapshotRepository struct {
+	repo   ports.LeaseSnapshotRepository
+	cipher ports.FieldCipher
+}
+
+var _ ports.LeaseSnapshotRepository = &LeaseSnapshotRepository{}
+
+func NewLeaseSnapshotRepository(repo ports.LeaseSnapshotRepository, cipher ports.FieldCipher) *LeaseSnapshotRepository {
+	return &LeaseSnapshotRepository{repo: repo, cipher: cipher}
+}
+
+func (r *LeaseSnapshotRepository) ExportLeaseState(ctx context.Context) (*models.LeaseSnapshot, error) {
+	snapshot, err := r.repo.ExportLeaseState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, lease := range snapshot.Leases {
+		if err := decrypt(r.cipher, &lease.PeerID, &lease.DelegatedBy); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+func (r *LeaseSnapshotRepository) ImportLeaseState(ctx context.Context, snapshot *models.LeaseSnapshot, replace bool) (*models.SnapshotImportReport, error) {
+	// The caller's snapshot is left untouched
+	encrypted := *snapshot
+	encrypted.Leases = make([]*models.SnapshotLease, len(snapshot.Leases))
+	for i, lease := range snapshot.Leases {
+		copied := *lease
+		if err := encrypt(r.cipher, &copied.PeerID, &copied.DelegatedBy); err != nil {
+			return nil, err
+		}
+		encrypted.Leases[i] = &copied
+	}
+	return r.repo.ImportLeaseState(ctx, &encrypted, replace)
+}
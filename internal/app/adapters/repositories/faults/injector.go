@@ -0,0 +1,109 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// ErrInjected fails the calls picked by the error rate of a fault. The adapters pass it
+// on as a connection error, so callers degrade as they would on a real outage.
+var ErrInjected = errors.New("injected fault")
+
+// Injector keeps the faults set through the admin API. Faults only live in this instance
+// and are lost on restart.
+type Injector struct {
+	mu     sync.RWMutex
+	faults map[models.FaultTarget]*models.Fault
+	clock  ports.Clock
+	logger *zap.Logger
+}
+
+var _ ports.FaultInjector = &Injector{}
+
+func NewInjector(clock ports.Clock, logger *zap.Logger) *Injector {
+	return &Injector{
+		faults: make(map[models.FaultTarget]*models.Fault),
+		clock:  clock,
+		logger: logger.Named("audit"),
+	}
+}
+
+func (i *Injector) Inject(ctx context.Context, target models.FaultTarget) error {
+	fault := i.fault(target)
+	if fault == nil {
+		return nil
+	}
+
+	if latency := fault.Latency(); latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if rand.Float64() < fault.ErrorRate {
+		return ErrInjected
+	}
+	return nil
+}
+
+// fault returns the fault of target, or nil when it has none or it expired
+func (i *Injector) fault(target models.FaultTarget) *models.Fault {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	fault := i.faults[target]
+	if fault == nil || (!fault.ExpiresAt.IsZero() && !i.clock.Now().Before(fault.ExpiresAt)) {
+		return nil
+	}
+	return fault
+}
+
+func (i *Injector) Faults() []*models.Fault {
+	faults := []*models.Fault{}
+	for _, target := range []models.FaultTarget{models.FaultTargetDatabase, models.FaultTargetCache} {
+		if fault := i.fault(target); fault != nil {
+			copied := *fault
+			faults = append(faults, &copied)
+		}
+	}
+	return faults
+}
+
+func (i *Injector) SetFault(fault *models.Fault, duration time.Duration) *models.Fault {
+	copied := *fault
+	copied.ExpiresAt = time.Time{}
+	if duration > 0 {
+		copied.ExpiresAt = i.clock.Now().Add(duration)
+	}
+
+	i.mu.Lock()
+	i.faults[fault.Target] = &copied
+	i.mu.Unlock()
+
+	i.logger.Warn("fault injected",
+		zap.String("event", "fault_injected"),
+		zap.String("target", string(fault.Target)),
+		zap.Int64("latencyMs", fault.LatencyMs),
+		zap.Float64("errorRate", fault.ErrorRate),
+		zap.Time("expiresAt", copied.ExpiresAt),
+	)
+	return &copied
+}
+
+func (i *Injector) ClearFault(target models.FaultTarget) {
+	i.mu.Lock()
+	delete(i.faults, target)
+	i.mu.Unlock()
+
+	i.logger.Info("fault cleared", zap.String("event", "fault_cleared"), zap.String("target", string(target)))
+}
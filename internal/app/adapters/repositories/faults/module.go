@@ -0,0 +1,19 @@
+package faults
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module provides the fault injector to the Postgres and Redis adapters, or nil unless
+// fault_injection_enabled is set, in which case the adapters install no hooks
+var Module = fx.Provide(
+	func(cfg *config.AppConfig, clock ports.Clock, logger *zap.Logger) ports.FaultInjector {
+		if !cfg.FaultInjectionEnabled {
+			return nil
+		}
+		return NewInjector(clock, logger)
+	},
+)
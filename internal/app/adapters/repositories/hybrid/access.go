@@ -0,0 +1,77 @@
+package hybrid
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// AccessRuleRepository caches the rules of each subject looked up on authentication.
+// Changes drop the cached rules of their subject, other instances pick them up as soon
+// as they share the cache.
+type AccessRuleRepository struct {
+	dbRepo ports.AccessRuleRepository
+	cache  ports.AccessRuleCache
+	logger *zap.Logger
+}
+
+var _ ports.AccessRuleRepository = &AccessRuleRepository{}
+
+func NewAccessRuleRepository(dbRepo ports.AccessRuleRepository, cache ports.AccessRuleCache, logger *zap.Logger) *AccessRuleRepository {
+	return &AccessRuleRepository{dbRepo, cache, logger.Named("cache")}
+}
+
+func (r *AccessRuleRepository) GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error) {
+	// Try cache first
+	rules, err := r.cache.GetAccessRules(ctx, subjectType, subject)
+	if err == nil {
+		return rules, nil
+	}
+	r.logger.Debug("cache GetAccessRules failed, falling back to DB", zap.Error(err), zap.String("subject", subject))
+
+	// Fallback to database
+	rules, err = r.dbRepo.GetAccessRules(ctx, subjectType, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the result, including that there are no rules
+	if cacheErr := r.cache.SetAccessRules(ctx, subjectType, subject, rules); cacheErr != nil {
+		r.logger.Warn("Failed to cache access rules", zap.Error(cacheErr))
+	}
+
+	return rules, nil
+}
+
+func (r *AccessRuleRepository) ListAccessRules(ctx context.Context, list models.AccessList) ([]*models.AccessRule, error) {
+	return r.dbRepo.ListAccessRules(ctx, list)
+}
+
+func (r *AccessRuleRepository) CreateAccessRule(ctx context.Context, rule *models.AccessRule) (*models.AccessRule, error) {
+	created, err := r.dbRepo.CreateAccessRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidate(ctx, created)
+	return created, nil
+}
+
+func (r *AccessRuleRepository) DeleteAccessRule(ctx context.Context, id int64) (*models.AccessRule, error) {
+	deleted, err := r.dbRepo.DeleteAccessRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidate(ctx, deleted)
+	return deleted, nil
+}
+
+// invalidate drops the cached rules of the subject of rule
+func (r *AccessRuleRepository) invalidate(ctx context.Context, rule *models.AccessRule) {
+	if err := r.cache.DeleteAccessRules(ctx, rule.SubjectType, rule.Subject); err != nil {
+		r.logger.Warn("Failed to invalidate cached access rules, the change applies once they expire", zap.Error(err), zap.String("subject", rule.Subject))
+	}
+}
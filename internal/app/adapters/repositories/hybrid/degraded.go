@@ -0,0 +1,156 @@
+package hybrid
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// DegradedRenewals renews leases from the cache while the database is unreachable. Each
+// renewal is journaled before it is answered and replayed to the database once it
+// recovers. Leases that are not cached cannot be renewed, and nothing else is written.
+type DegradedRenewals struct {
+	journal  *RenewalJournal
+	writer   ports.LeaseRenewalWriter
+	cache    ports.LeaseCache
+	interval time.Duration
+	leaseTTL atomic.Int64 // nanoseconds
+	clock    ports.Clock
+	logger   *zap.Logger
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewDegradedRenewals opens the renewal journal and replays it in the background. It
+// returns nil when degraded renewals are disabled.
+func NewDegradedRenewals(lc fx.Lifecycle, cfg *config.AppConfig, writer ports.LeaseRenewalWriter, cache ports.LeaseCache, clock ports.Clock, logger *zap.Logger) (*DegradedRenewals, error) {
+	if !cfg.DegradedRenewalsEnabled {
+		return nil, nil
+	}
+
+	journal, err := OpenRenewalJournal(cfg.DegradedJournalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DegradedRenewals{
+		journal:  journal,
+		writer:   writer,
+		cache:    cache,
+		interval: time.Duration(cfg.DegradedReplayInterval) * time.Second,
+		clock:    clock,
+		logger:   logger.With(zap.String("component", "degraded_renewals")),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	d.ApplyConfig(cfg)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if pending := journal.Pending(); pending > 0 {
+				d.logger.Warn("Renewal journal holds renewals from a previous run", zap.Int("pending", pending))
+			}
+			go d.run()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(d.stopCh)
+			<-d.doneCh
+
+			// Leave nothing behind that the database could take now
+			if err := d.Replay(ctx); err != nil {
+				d.logger.Warn("Renewals remain journaled for the next start", zap.Error(err), zap.Int("pending", journal.Pending()))
+			}
+			return journal.Close()
+		},
+	})
+
+	return d, nil
+}
+
+// ApplyConfig implements config.Reloadable
+func (d *DegradedRenewals) ApplyConfig(cfg *config.AppConfig) {
+	if d == nil {
+		return
+	}
+	d.leaseTTL.Store(int64(time.Duration(cfg.Lease.TTL) * time.Minute))
+}
+
+// Pending returns the number of renewals awaiting replay
+func (d *DegradedRenewals) Pending() int {
+	return d.journal.Pending()
+}
+
+// Renew extends a cached lease of peerID by the lease TTL. cause is the database error
+// that made the renewal fall back to the cache, reported when the cache cannot help.
+func (d *DegradedRenewals) Renew(ctx context.Context, tokenID int64, peerID string, cause error) (*models.Lease, error) {
+	cached, err := d.cache.GetLeaseByTokenID(ctx, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domainErrors.ErrStorageDegraded, cause)
+	}
+
+	lease, renewal, ok := extendCachedLease(ctx, cached, peerID, time.Duration(d.leaseTTL.Load()), d.clock.Now())
+	if !ok {
+		return nil, domainErrors.ErrLeaseNotFound
+	}
+
+	// The renewal only counts once it is on disk
+	if err := d.journal.Append(renewal); err != nil {
+		d.logger.Error("Failed to journal renewal", zap.Error(err), zap.Int64("tokenID", tokenID))
+		return nil, fmt.Errorf("%w: %w", domainErrors.ErrStorageDegraded, err)
+	}
+
+	if err := d.cache.SetLease(ctx, lease); err != nil {
+		d.logger.Warn("Failed to cache renewed lease", zap.Error(err), zap.Int64("tokenID", tokenID))
+	}
+
+	return lease, nil
+}
+
+// Replay writes journaled renewals to the database and removes them from the journal.
+// Renewals of leases that changed in the meantime are skipped.
+func (d *DegradedRenewals) Replay(ctx context.Context) error {
+	return d.journal.Drain(func(renewals []*models.LeaseRenewal) error {
+		applied, err := d.writer.ApplyRenewals(ctx, renewals)
+		if err != nil {
+			return err
+		}
+		d.logger.Info("Replayed journaled renewals",
+			zap.Int("renewals", len(renewals)),
+			zap.Int("applied", applied),
+			zap.Int("skipped", len(renewals)-applied))
+		return nil
+	})
+}
+
+func (d *DegradedRenewals) run() {
+	defer close(d.doneCh)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+			if d.journal.Pending() == 0 {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), d.interval)
+			if err := d.Replay(ctx); err != nil {
+				d.logger.Debug("Database not ready for journaled renewals", zap.Error(err), zap.Int("pending", d.journal.Pending()))
+			}
+			cancel()
+		}
+	}
+}
@@ -2,39 +2,87 @@ package hybrid
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
 
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/retry"
 	"go.uber.org/zap"
 )
 
 type LeaseRepository struct {
-	dbRepo ports.LeaseRepository
-	cache  ports.LeaseCache
-	logger *zap.Logger
+	dbRepo      ports.LeaseRepository
+	cache       ports.LeaseCache
+	degraded    *DegradedRenewals    // renews cached leases while the database is unreachable, nil when disabled
+	writeBehind *WriteBehindRenewals // renews cached leases in the cache and writes them in batches, nil when disabled
+	logger      *zap.Logger
+
+	// Lookups answered from the cache, including cached misses, and from the database
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// dbReadRetry retries lookups falling back to the database when the connection fails,
+// so that a database failover does not surface as an error while the cache is cold
+var dbReadRetry = retry.Policy{
+	MaxAttempts:  3,
+	InitialDelay: 50 * time.Millisecond,
+	MaxDelay:     500 * time.Millisecond,
+	Jitter:       retry.DefaultJitter,
+	Retryable:    retry.IsTransient,
 }
 
 var _ ports.LeaseRepository = &LeaseRepository{}
+var _ ports.CacheStatsReporter = &LeaseRepository{}
+
+func NewLeaseRepository(dbRepo ports.LeaseRepository, cache ports.LeaseCache, degraded *DegradedRenewals, writeBehind *WriteBehindRenewals, logger *zap.Logger) *LeaseRepository {
+	return &LeaseRepository{dbRepo: dbRepo, cache: cache, degraded: degraded, writeBehind: writeBehind, logger: logger.Named("cache")}
+}
 
-func NewLeaseRepository(dbRepo ports.LeaseRepository, cache ports.LeaseCache, logger *zap.Logger) *LeaseRepository {
-	return &LeaseRepository{dbRepo, cache, logger}
+// CacheStats reports how many lease lookups were answered from the cache
+func (r *LeaseRepository) CacheStats() *models.CacheStats {
+	return models.NewCacheStats(r.hits.Load(), r.misses.Load())
 }
 
 func (r *LeaseRepository) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
 	// Try cache first
 	lease, err := r.cache.GetLeaseByPeerID(ctx, peerID)
 	if err == nil {
+		r.hits.Add(1)
+		if lease == nil {
+			// Cached "not found" entry
+			return nil, domainErrors.ErrLeaseNotFound
+		}
 		return lease, nil
 	}
 	// Log cache errors and fall back to DB
 	r.logger.Debug("cache GetLeaseByPeerID failed, falling back to DB", zap.Error(err), zap.String("peerID", peerID))
+	r.misses.Add(1)
 
 	// Fallback to database
-	lease, err = r.dbRepo.GetLeaseByPeerID(ctx, peerID)
+	lease, err = retry.DoValue(ctx, dbReadRetry, func(ctx context.Context) (*models.Lease, error) {
+		return r.dbRepo.GetLeaseByPeerID(ctx, peerID)
+	})
 	if err != nil {
+		if errors.Is(err, domainErrors.ErrLeaseNotFound) {
+			// Remember the miss so repeated lookups during allocation storms skip the DB
+			if cacheErr := r.cache.SetPeerNotFound(ctx, peerID); cacheErr != nil {
+				r.logger.Warn("Failed to cache missing lease", zap.Error(cacheErr))
+			}
+		}
 		return nil, err
 	}
 
+	// A buffered renewal is newer than what the database holds
+	if r.writeBehind != nil {
+		lease = r.writeBehind.Reconcile(ctx, lease)
+	}
+
 	// Cache the result
 	if cacheErr := r.cache.SetLease(ctx, lease); cacheErr != nil {
 		r.logger.Warn("Failed to cache lease", zap.Error(cacheErr))
@@ -47,16 +95,34 @@ func (r *LeaseRepository) GetLeaseByTokenID(ctx context.Context, tokenID int64)
 	// Try cache first
 	lease, err := r.cache.GetLeaseByTokenID(ctx, tokenID)
 	if err == nil {
+		r.hits.Add(1)
+		if lease == nil {
+			// Cached "not found" entry
+			return nil, domainErrors.ErrLeaseNotFound
+		}
 		return lease, nil
 	}
 	r.logger.Debug("cache GetLeaseByTokenID failed, falling back to DB", zap.Error(err), zap.Int64("tokenID", tokenID))
+	r.misses.Add(1)
 
 	// Fallback to database
-	lease, err = r.dbRepo.GetLeaseByTokenID(ctx, tokenID)
+	lease, err = retry.DoValue(ctx, dbReadRetry, func(ctx context.Context) (*models.Lease, error) {
+		return r.dbRepo.GetLeaseByTokenID(ctx, tokenID)
+	})
 	if err != nil {
+		if errors.Is(err, domainErrors.ErrLeaseNotFound) {
+			if cacheErr := r.cache.SetTokenNotFound(ctx, tokenID); cacheErr != nil {
+				r.logger.Warn("Failed to cache missing lease", zap.Error(cacheErr))
+			}
+		}
 		return nil, err
 	}
 
+	// A buffered renewal is newer than what the database holds
+	if r.writeBehind != nil {
+		lease = r.writeBehind.Reconcile(ctx, lease)
+	}
+
 	// Cache the result
 	if cacheErr := r.cache.SetLease(ctx, lease); cacheErr != nil {
 		r.logger.Warn("Failed to cache lease", zap.Error(cacheErr))
@@ -65,43 +131,190 @@ func (r *LeaseRepository) GetLeaseByTokenID(ctx context.Context, tokenID int64)
 	return lease, nil
 }
 
+// degradedError reports a write the database could not take as ErrStorageDegraded while
+// degraded renewals are enabled, so that clients learn only renewals are accepted
+func (r *LeaseRepository) degradedError(err error) error {
+	if r.degraded != nil && errors.Is(err, domainErrors.ErrDatabaseConnection) {
+		return fmt.Errorf("%w: %w", domainErrors.ErrStorageDegraded, err)
+	}
+	return err
+}
+
+// writeRenewals writes journaled and buffered renewals before an allocation, which could
+// otherwise hand out a lease that was renewed without the database knowing
+func (r *LeaseRepository) writeRenewals(ctx context.Context) error {
+	if r.degraded != nil && r.degraded.Pending() > 0 {
+		if err := r.degraded.Replay(ctx); err != nil {
+			r.logger.Warn("Failed to replay journaled renewals before allocating", zap.Error(err))
+			return r.degradedError(err)
+		}
+	}
+	if r.writeBehind != nil && r.writeBehind.Pending() > 0 {
+		if err := r.writeBehind.Flush(ctx); err != nil {
+			r.logger.Warn("Failed to flush renewals before allocating", zap.Error(err))
+			return r.degradedError(err)
+		}
+	}
+	return nil
+}
+
+// forgetRenewal drops a buffered renewal of a lease that was written to the database since
+func (r *LeaseRepository) forgetRenewal(ctx context.Context, tokenID int64) {
+	if r.writeBehind != nil {
+		r.writeBehind.Forget(ctx, tokenID)
+	}
+}
+
+// cacheAllocatedLease caches a lease that was just assigned to a peer. If that fails the
+// entries are evicted instead, so a cached "not found" marker cannot hide the new lease.
+func (r *LeaseRepository) cacheAllocatedLease(ctx context.Context, lease *models.Lease, msg string) {
+	cacheErr := r.cache.SetLease(ctx, lease)
+	if cacheErr == nil {
+		return
+	}
+	r.logger.Warn(msg, zap.Error(cacheErr))
+
+	if cacheErr := r.cache.DeleteLease(ctx, lease.PeerID, lease.TokenID); cacheErr != nil {
+		r.logger.Warn("Failed to evict lease from cache", zap.Error(cacheErr))
+	}
+}
+
 func (r *LeaseRepository) FindAndReuseExpiredLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	if err := r.writeRenewals(ctx); err != nil {
+		return nil, err
+	}
+
 	// This operation always goes to database (complex query)
 	lease, err := r.dbRepo.FindAndReuseExpiredLease(ctx, peerID)
 	if err != nil || lease == nil {
-		return lease, err
+		return lease, r.degradedError(err)
 	}
 
 	// Cache the reused lease
-	if cacheErr := r.cache.SetLease(ctx, lease); cacheErr != nil {
-		r.logger.Warn("Failed to cache reused lease", zap.Error(cacheErr))
-	}
+	r.cacheAllocatedLease(ctx, lease, "Failed to cache reused lease")
 
 	return lease, nil
 }
 
 func (r *LeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	if err := r.writeRenewals(ctx); err != nil {
+		return nil, err
+	}
+
 	// Create in database
 	lease, err := r.dbRepo.AllocateNewLease(ctx, peerID)
 	if err != nil {
+		return nil, r.degradedError(err)
+	}
+
+	// Cache the new lease
+	r.cacheAllocatedLease(ctx, lease, "Failed to cache new lease")
+
+	return lease, nil
+}
+
+func (r *LeaseRepository) AllocateRequestedLease(ctx context.Context, peerID string, tokenID int64) (*models.Lease, error) {
+	if err := r.writeRenewals(ctx); err != nil {
 		return nil, err
 	}
 
+	// Create in database
+	lease, err := r.dbRepo.AllocateRequestedLease(ctx, peerID, tokenID)
+	if err != nil {
+		return nil, r.degradedError(err)
+	}
+
 	// Cache the new lease
-	if cacheErr := r.cache.SetLease(ctx, lease); cacheErr != nil {
-		r.logger.Warn("Failed to cache new lease", zap.Error(cacheErr))
+	r.cacheAllocatedLease(ctx, lease, "Failed to cache requested lease")
+
+	return lease, nil
+}
+
+func (r *LeaseRepository) AllocateAffinityLease(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error) {
+	if err := r.writeRenewals(ctx); err != nil {
+		return nil, err
+	}
+
+	// Create in database
+	lease, err := r.dbRepo.AllocateAffinityLease(ctx, peerID, affinityGroup)
+	if err != nil || lease == nil {
+		return lease, r.degradedError(err)
+	}
+
+	// Cache the new lease
+	r.cacheAllocatedLease(ctx, lease, "Failed to cache affinity lease")
+
+	return lease, nil
+}
+
+func (r *LeaseRepository) AllocateZoneLease(ctx context.Context, peerID string, zone string) (*models.Lease, error) {
+	if err := r.writeRenewals(ctx); err != nil {
+		return nil, err
+	}
+
+	// Create in database
+	lease, err := r.dbRepo.AllocateZoneLease(ctx, peerID, zone)
+	if err != nil || lease == nil {
+		return lease, r.degradedError(err)
 	}
 
+	// Cache the new lease
+	r.cacheAllocatedLease(ctx, lease, "Failed to cache zone lease")
+
 	return lease, nil
 }
 
+func (r *LeaseRepository) SetLeaseAffinityGroup(ctx context.Context, tokenID int64, affinityGroup string) error {
+	// Affinity groups are not cached
+	return r.dbRepo.SetLeaseAffinityGroup(ctx, tokenID, affinityGroup)
+}
+
+func (r *LeaseRepository) SetLeaseDelegator(ctx context.Context, tokenID int64, gatewayPeerID string) error {
+	// Delegators are not cached
+	return r.dbRepo.SetLeaseDelegator(ctx, tokenID, gatewayPeerID)
+}
+
+// SetLeaseLabels writes the labels to the database and then to the cached lease. The cached
+// copy is updated rather than evicted, it may carry a renewal the database has not seen yet.
+func (r *LeaseRepository) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) error {
+	if err := r.dbRepo.SetLeaseLabels(ctx, tokenID, peerID, labels); err != nil {
+		return r.degradedError(err)
+	}
+
+	cached, err := r.cache.GetLeaseByTokenID(ctx, tokenID)
+	if err != nil || cached == nil {
+		return nil
+	}
+	cached.Labels = labels
+	if cacheErr := r.cache.SetLease(ctx, cached); cacheErr != nil {
+		r.logger.Warn("Failed to cache lease labels", zap.Error(cacheErr))
+	}
+	return nil
+}
+
+func (r *LeaseRepository) CountDelegatedLeases(ctx context.Context, gatewayPeerID string) (int64, error) {
+	return r.dbRepo.CountDelegatedLeases(ctx, gatewayPeerID)
+}
+
 func (r *LeaseRepository) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
+	if r.writeBehind != nil {
+		if lease, ok := r.writeBehind.Renew(ctx, tokenID, peerID); ok {
+			return lease, nil
+		}
+	}
+
 	// Update database
 	lease, err := r.dbRepo.RenewLease(ctx, tokenID, peerID)
 	if err != nil {
+		if r.degraded != nil && errors.Is(err, domainErrors.ErrDatabaseConnection) {
+			r.logger.Warn("Database unreachable, renewing lease from cache", zap.Error(err), zap.Int64("tokenID", tokenID))
+			return r.degraded.Renew(ctx, tokenID, peerID, err)
+		}
 		return nil, err
 	}
 
+	r.forgetRenewal(ctx, tokenID)
+
 	// Cache the renewed lease
 	if cacheErr := r.cache.SetLease(ctx, lease); cacheErr != nil {
 		r.logger.Warn("Failed to cache renewed lease", zap.Error(cacheErr))
@@ -114,8 +327,9 @@ func (r *LeaseRepository) ReleaseLease(ctx context.Context, tokenID int64, peerI
 	// Update database
 	err := r.dbRepo.ReleaseLease(ctx, tokenID, peerID)
 	if err != nil {
-		return err
+		return r.degradedError(err)
 	}
+	r.forgetRenewal(ctx, tokenID)
 
 	// Remove from cache
 	if cacheErr := r.cache.DeleteLease(ctx, peerID, tokenID); cacheErr != nil {
@@ -124,3 +338,124 @@ func (r *LeaseRepository) ReleaseLease(ctx context.Context, tokenID int64, peerI
 
 	return nil
 }
+
+func (r *LeaseRepository) TransferLease(ctx context.Context, tokenID int64, fromPeerID string, toPeerID string) (*models.Lease, error) {
+	// Update in database
+	lease, err := r.dbRepo.TransferLease(ctx, tokenID, fromPeerID, toPeerID)
+	if err != nil {
+		return nil, r.degradedError(err)
+	}
+
+	r.forgetRenewal(ctx, tokenID)
+
+	// Drop the old owner's entries before caching the new owner
+	if cacheErr := r.cache.DeleteLease(ctx, fromPeerID, tokenID); cacheErr != nil {
+		r.logger.Warn("Failed to delete transferred lease from cache", zap.Error(cacheErr))
+	}
+	r.cacheAllocatedLease(ctx, lease, "Failed to cache transferred lease")
+
+	return lease, nil
+}
+
+func (r *LeaseRepository) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	if err := r.writeRenewals(ctx); err != nil {
+		return nil, err
+	}
+
+	// Execute in database
+	results, err := r.dbRepo.ExecuteBatch(ctx, operations)
+	if err != nil {
+		return nil, r.degradedError(err)
+	}
+
+	// Mirror successful operations into the cache in one pipeline
+	var upserts, removals []*models.Lease
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		if operations[i].TokenID != 0 {
+			r.forgetRenewal(ctx, operations[i].TokenID)
+		}
+		if operations[i].Type == models.LeaseOperationRelease {
+			removals = append(removals, &models.Lease{PeerID: operations[i].PeerID, TokenID: operations[i].TokenID})
+			continue
+		}
+		upserts = append(upserts, result.Lease)
+	}
+
+	if cacheErr := r.cache.UpdateLeases(ctx, upserts, removals); cacheErr != nil {
+		r.logger.Warn("Failed to update cache after lease batch", zap.Error(cacheErr))
+
+		// Make sure no "not found" entry outlives the allocations
+		if cacheErr := r.cache.UpdateLeases(ctx, nil, upserts); cacheErr != nil {
+			r.logger.Warn("Failed to evict batch leases from cache", zap.Error(cacheErr))
+		}
+	}
+
+	return results, nil
+}
+
+func (r *LeaseRepository) ListReclaimCandidates(ctx context.Context, afterTokenID int64, limit int) ([]*models.ReclaimCandidate, error) {
+	// Expired leases are never cached
+	return r.dbRepo.ListReclaimCandidates(ctx, afterTokenID, limit)
+}
+
+func (r *LeaseRepository) ListExpiringLeases(ctx context.Context, within time.Duration, afterTokenID int64, limit int) ([]*models.ExpiringLease, error) {
+	// A page scan, which the cache cannot answer
+	return r.dbRepo.ListExpiringLeases(ctx, within, afterTokenID, limit)
+}
+
+func (r *LeaseRepository) ReclaimLeases(ctx context.Context, tokenIDs []int64) ([]int64, error) {
+	// Only expired leases are reclaimed, so there is nothing to evict
+	return r.dbRepo.ReclaimLeases(ctx, tokenIDs)
+}
+
+func (r *LeaseRepository) RevokeLeases(ctx context.Context, leases []*models.Lease) ([]int64, error) {
+	if err := r.writeRenewals(ctx); err != nil {
+		return nil, err
+	}
+
+	revoked, err := r.dbRepo.RevokeLeases(ctx, leases)
+	if err != nil {
+		return nil, r.degradedError(err)
+	}
+
+	// Evict the revoked leases in one pipeline
+	var removals []*models.Lease
+	for _, lease := range leases {
+		if slices.Contains(revoked, lease.TokenID) {
+			r.forgetRenewal(ctx, lease.TokenID)
+			removals = append(removals, &models.Lease{PeerID: lease.PeerID, TokenID: lease.TokenID})
+		}
+	}
+	if len(removals) > 0 {
+		if cacheErr := r.cache.UpdateLeases(ctx, nil, removals); cacheErr != nil {
+			r.logger.Warn("Failed to remove revoked leases from cache", zap.Error(cacheErr))
+		}
+	}
+
+	return revoked, nil
+}
+
+func (r *LeaseRepository) GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error) {
+	// History is never cached
+	return r.dbRepo.GetLeaseHistory(ctx, tokenID)
+}
+
+func (r *LeaseRepository) RecordConflict(ctx context.Context, tokenID int64, peerID string, quarantine time.Duration) (*models.LeaseConflict, error) {
+	conflict, err := r.dbRepo.RecordConflict(ctx, tokenID, peerID, quarantine)
+	if err != nil {
+		return nil, r.degradedError(err)
+	}
+
+	// A quarantined lease was released
+	if conflict.Quarantined {
+		r.forgetRenewal(ctx, tokenID)
+		if cacheErr := r.cache.DeleteLease(ctx, peerID, tokenID); cacheErr != nil {
+			r.logger.Warn("Failed to remove quarantined lease from cache", zap.Error(cacheErr))
+		}
+	}
+
+	return conflict, nil
+}
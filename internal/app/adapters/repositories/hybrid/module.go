@@ -1,23 +1,58 @@
 package hybrid
 
 import (
+	"time"
+
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/redis"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
 var Module = fx.Options(
 	fx.Provide(
+		// Journal renewals of cached leases while the database is unreachable
+		func(
+			lc fx.Lifecycle,
+			cfg *config.AppConfig,
+			clock ports.Clock,
+			logger *zap.Logger,
+			dbLeaseRepo *postgres.LeaseRepository,
+			cache *redis.LeaseCache,
+		) (*DegradedRenewals, error) {
+			return NewDegradedRenewals(lc, cfg, dbLeaseRepo, cache, clock, logger)
+		},
+		// Buffer renewals of cached leases and write them in batches
+		func(
+			lc fx.Lifecycle,
+			cfg *config.AppConfig,
+			clock ports.Clock,
+			logger *zap.Logger,
+			dbLeaseRepo *postgres.LeaseRepository,
+			cache *redis.LeaseCache,
+		) *WriteBehindRenewals {
+			return NewWriteBehindRenewals(lc, cfg, dbLeaseRepo, cache, clock, logger)
+		},
+		// Lets the standby evict the lease changes of the active instance from the cache
+		func(cache *redis.LeaseCache) ports.LeaseCache {
+			return cache
+		},
 		// Wrap DB repos with caches to expose as default implementations
 		fx.Annotate(
 			func(
+				cfg *config.AppConfig,
+				clock ports.Clock,
 				logger *zap.Logger,
 				dbNonceRepo *postgres.NonceRepository,
 				cache *redis.NonceCache,
 			) ports.NonceRepository {
-				return NewNonceRepository(dbNonceRepo, cache, logger)
+				var degradedTTL time.Duration
+				if cfg.DegradedRenewalsEnabled {
+					degradedTTL = time.Duration(cfg.Nonce.TTL) * time.Minute
+				}
+				return NewNonceRepository(dbNonceRepo, cache, degradedTTL, clock, logger)
 			},
 			fx.As(new(ports.NonceRepository)),
 		),
@@ -26,10 +61,25 @@ var Module = fx.Options(
 				logger *zap.Logger,
 				dbLeaseRepo *postgres.LeaseRepository,
 				cache *redis.LeaseCache,
-			) ports.LeaseRepository {
-				return NewLeaseRepository(dbLeaseRepo, cache, logger)
+				degraded *DegradedRenewals,
+				writeBehind *WriteBehindRenewals,
+			) *LeaseRepository {
+				return NewLeaseRepository(dbLeaseRepo, cache, degraded, writeBehind, logger)
 			},
 			fx.As(new(ports.LeaseRepository)),
+			fx.As(new(ports.CacheStatsReporter)),
+		),
+		fx.Annotate(
+			func(
+				logger *zap.Logger,
+				dbAccessRuleRepo *postgres.AccessRuleRepository,
+				cache *redis.AccessRuleCache,
+			) ports.AccessRuleRepository {
+				return NewAccessRuleRepository(dbAccessRuleRepo, cache, logger)
+			},
+			fx.As(new(ports.AccessRuleRepository)),
 		),
 	),
+	config.ReloadTarget[*DegradedRenewals](),
+	config.ReloadTarget[*WriteBehindRenewals](),
 )
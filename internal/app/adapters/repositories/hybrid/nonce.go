@@ -2,7 +2,11 @@ package hybrid
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/google/uuid"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"go.uber.org/zap"
@@ -11,13 +15,23 @@ import (
 type NonceRepository struct {
 	dbRepo ports.NonceRepository
 	cache  ports.NonceCache
+	clock  ports.Clock
 	logger *zap.Logger
+
+	// Lifetime of nonces issued from the cache alone while the database is unreachable,
+	// 0 when degraded renewals are disabled
+	degradedTTL time.Duration
 }
 
 var _ ports.NonceRepository = &NonceRepository{}
 
-func NewNonceRepository(dbRepo ports.NonceRepository, cache ports.NonceCache, logger *zap.Logger) *NonceRepository {
-	return &NonceRepository{dbRepo, cache, logger}
+func NewNonceRepository(dbRepo ports.NonceRepository, cache ports.NonceCache, degradedTTL time.Duration, clock ports.Clock, logger *zap.Logger) *NonceRepository {
+	return &NonceRepository{dbRepo, cache, clock, logger.Named("cache"), degradedTTL}
+}
+
+// degraded tells whether err calls for serving nonces from the cache alone
+func (r *NonceRepository) degraded(err error) bool {
+	return r.degradedTTL > 0 && errors.Is(err, domainErrors.ErrDatabaseConnection)
 }
 
 func (r *NonceRepository) GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
@@ -44,6 +58,9 @@ func (r *NonceRepository) GetNonce(ctx context.Context, nonceID string) (*models
 func (r *NonceRepository) CreateNonce(ctx context.Context, peerID string) (*models.Nonce, error) {
 	// Create in database first
 	nonce, err := r.dbRepo.CreateNonce(ctx, peerID)
+	if r.degraded(err) {
+		return r.createCachedNonce(ctx, peerID, err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +76,9 @@ func (r *NonceRepository) CreateNonce(ctx context.Context, peerID string) (*mode
 func (r *NonceRepository) ConsumeNonce(ctx context.Context, nonceID string, peerID string) error {
 	// Update database
 	err := r.dbRepo.ConsumeNonce(ctx, nonceID, peerID)
+	if r.degraded(err) {
+		return r.consumeCachedNonce(ctx, nonceID, peerID, err)
+	}
 	if err != nil {
 		return err
 	}
@@ -71,7 +91,69 @@ func (r *NonceRepository) ConsumeNonce(ctx context.Context, nonceID string, peer
 	return nil
 }
 
-func (r *NonceRepository) DeleteExpiredNonces(ctx context.Context) error {
+func (r *NonceRepository) ListOutstandingNonces(ctx context.Context, peerID string) ([]*models.Nonce, error) {
+	// The cache is keyed by nonce ID, so only the database can answer per-peer queries
+	nonces, err := r.dbRepo.ListOutstandingNonces(ctx, peerID)
+	if r.degraded(err) {
+		// The outstanding nonce cap is not enforced until the database recovers
+		return nil, nil
+	}
+	return nonces, err
+}
+
+func (r *NonceRepository) RevokeNonce(ctx context.Context, nonceID string, peerID string) error {
+	err := r.dbRepo.RevokeNonce(ctx, nonceID, peerID)
+	if r.degraded(err) {
+		// A nonce issued while the database was unreachable only lives in the cache
+		return r.consumeCachedNonce(ctx, nonceID, peerID, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Remove from cache, so that GetNonce no longer finds it
+	if cacheErr := r.cache.DeleteNonce(ctx, nonceID); cacheErr != nil {
+		r.logger.Warn("Failed to remove revoked nonce from cache", zap.Error(cacheErr))
+	}
+
+	return nil
+}
+
+func (r *NonceRepository) DeleteExpiredNonces(ctx context.Context, limit int) (int64, error) {
 	// Only database cleanup needed - Redis TTL handles cache cleanup
-	return r.dbRepo.DeleteExpiredNonces(ctx)
+	return r.dbRepo.DeleteExpiredNonces(ctx, limit)
+}
+
+// createCachedNonce issues a nonce that only lives in the cache. It cannot be consumed
+// once the database is back, so clients holding one have to request another.
+func (r *NonceRepository) createCachedNonce(ctx context.Context, peerID string, cause error) (*models.Nonce, error) {
+	now := r.clock.Now()
+	nonce := &models.Nonce{
+		ID:        uuid.NewString(),
+		PeerID:    peerID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(r.degradedTTL),
+	}
+	if err := r.cache.CreateNonce(ctx, nonce); err != nil {
+		r.logger.Warn("Failed to cache nonce while the database is unreachable", zap.Error(err))
+		return nil, cause
+	}
+	return nonce, nil
+}
+
+// consumeCachedNonce takes a nonce out of the cache, so that no other request can use it
+func (r *NonceRepository) consumeCachedNonce(ctx context.Context, nonceID string, peerID string, cause error) error {
+	nonce, err := r.cache.TakeNonce(ctx, nonceID)
+	if errors.Is(err, domainErrors.ErrNonceNotFound) {
+		return domainErrors.ErrNonceNotFound
+	}
+	if err != nil {
+		r.logger.Warn("Failed to consume cached nonce while the database is unreachable", zap.Error(err))
+		return cause
+	}
+
+	if nonce.PeerID != peerID || nonce.Used || nonce.IsExpired(r.clock.Now()) {
+		return domainErrors.ErrNonceNotFound
+	}
+	return nil
 }
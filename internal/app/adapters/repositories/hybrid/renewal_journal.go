@@ -0,0 +1,186 @@
+package hybrid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// RenewalJournal is an append-only log of renewals accepted while the database was
+// unreachable, one JSON object per line. Every entry is synced to disk before Append
+// returns, so journaled renewals survive a restart until they have been replayed.
+type RenewalJournal struct {
+	drainMu sync.Mutex // serializes drains
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	pending int
+}
+
+// OpenRenewalJournal opens the journal at path, creating it if needed. A line left
+// incomplete by a crash during a write is discarded.
+func OpenRenewalJournal(path string) (*RenewalJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create journal directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open renewal journal: %w", err)
+	}
+
+	j := &RenewalJournal{path: path, file: file}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read renewal journal: %w", err)
+	}
+
+	complete := len(data)
+	if complete > 0 && data[complete-1] != '\n' {
+		complete = bytes.LastIndexByte(data, '\n') + 1
+		if err := file.Truncate(int64(complete)); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("truncate renewal journal: %w", err)
+		}
+	}
+
+	renewals, err := parseRenewals(data[:complete])
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("parse renewal journal %s: %w", path, err)
+	}
+	j.pending = len(renewals)
+
+	return j, nil
+}
+
+// Append durably records a renewal
+func (j *RenewalJournal) Append(renewal *models.LeaseRenewal) error {
+	line, err := json.Marshal(renewal)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Write(line); err != nil {
+		return fmt.Errorf("write renewal journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("write renewal journal: %w", err)
+	}
+	j.pending++
+	return nil
+}
+
+// Pending returns the number of renewals awaiting replay
+func (j *RenewalJournal) Pending() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.pending
+}
+
+// Drain passes the journaled renewals to fn and removes them once fn succeeds. Renewals
+// appended while fn runs are kept for the next drain. When fn fails, the journal is left
+// unchanged, so fn must tolerate renewals it has already applied.
+func (j *RenewalJournal) Drain(fn func(renewals []*models.LeaseRenewal) error) error {
+	j.drainMu.Lock()
+	defer j.drainMu.Unlock()
+
+	j.mu.Lock()
+	data, err := os.ReadFile(j.path)
+	j.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("read renewal journal: %w", err)
+	}
+
+	renewals, err := parseRenewals(data)
+	if err != nil {
+		return fmt.Errorf("parse renewal journal %s: %w", j.path, err)
+	}
+	if len(renewals) == 0 {
+		return nil
+	}
+
+	if err := fn(renewals); err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.discard(len(data), len(renewals))
+}
+
+// discard replaces the journal with what was appended after its first size bytes.
+// Caller must hold j.mu.
+func (j *RenewalJournal) discard(size int, count int) error {
+	data, err := os.ReadFile(j.path)
+	if err != nil {
+		return fmt.Errorf("read renewal journal: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write renewal journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data[size:]); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write renewal journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write renewal journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write renewal journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("write renewal journal: %w", err)
+	}
+
+	// Appends go to the new file from now on
+	file, err := os.OpenFile(j.path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open renewal journal: %w", err)
+	}
+	j.file.Close()
+	j.file = file
+	j.pending -= count
+	return nil
+}
+
+// Close closes the journal file. Pending renewals stay on disk.
+func (j *RenewalJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
+
+// parseRenewals decodes complete journal lines
+func parseRenewals(data []byte) ([]*models.LeaseRenewal, error) {
+	var renewals []*models.LeaseRenewal
+	for line := range bytes.Lines(data) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var renewal models.LeaseRenewal
+		if err := json.Unmarshal(line, &renewal); err != nil {
+			return nil, err
+		}
+		renewals = append(renewals, &renewal)
+	}
+	return renewals, nil
+}
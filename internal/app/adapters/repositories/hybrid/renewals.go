@@ -0,0 +1,32 @@
+package hybrid
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// extendCachedLease returns a copy of cached extended by ttl from now, together with the
+// renewal that writes it to the database. ok is false unless cached is the active lease
+// of peerID.
+func extendCachedLease(ctx context.Context, cached *models.Lease, peerID string, ttl time.Duration, now time.Time) (*models.Lease, *models.LeaseRenewal, bool) {
+	if cached == nil || cached.PeerID != peerID || cached.IsExpired(now) {
+		return nil, nil, false
+	}
+
+	lease := *cached
+	lease.ExpiresAt = now.Add(ttl)
+	lease.UpdatedAt = now
+	lease.Ttl = int32(ttl.Seconds())
+	lease.Signature = nil
+	lease.RenewableAt = nil
+
+	return &lease, &models.LeaseRenewal{
+		TokenID:   lease.TokenID,
+		PeerID:    lease.PeerID,
+		TenantID:  models.TenantFromContext(ctx),
+		ExpiresAt: lease.ExpiresAt,
+		RenewedAt: now,
+	}, true
+}
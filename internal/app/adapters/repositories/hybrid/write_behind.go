@@ -0,0 +1,233 @@
+package hybrid
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// renewalKey identifies a lease across tenants
+type renewalKey struct {
+	tenantID string
+	tokenID  int64
+}
+
+// WriteBehindRenewals answers renewals of cached leases from the cache and writes them to
+// the database in batches. Repeated renewals of a lease between two flushes are written
+// once. Buffered renewals are lost if the process dies, in which case their leases keep
+// the expiry of their previous renewal.
+type WriteBehindRenewals struct {
+	writer    ports.LeaseRenewalWriter
+	cache     ports.LeaseCache
+	interval  time.Duration
+	batchSize int
+	leaseTTL  atomic.Int64 // nanoseconds
+	clock     ports.Clock
+	logger    *zap.Logger
+
+	mu      sync.Mutex
+	pending map[renewalKey]*models.LeaseRenewal
+	flushMu sync.Mutex // serializes flushes
+
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+}
+
+// NewWriteBehindRenewals flushes buffered renewals every lease.write_behind_interval and
+// on shutdown. It returns nil when write-behind is disabled.
+func NewWriteBehindRenewals(lc fx.Lifecycle, cfg *config.AppConfig, writer ports.LeaseRenewalWriter, cache ports.LeaseCache, clock ports.Clock, logger *zap.Logger) *WriteBehindRenewals {
+	if cfg.Lease.WriteBehindInterval <= 0 {
+		return nil
+	}
+
+	w := &WriteBehindRenewals{
+		writer:    writer,
+		cache:     cache,
+		interval:  time.Duration(cfg.Lease.WriteBehindInterval) * time.Second,
+		batchSize: cfg.Lease.WriteBehindBatchSize,
+		clock:     clock,
+		logger:    logger.With(zap.String("component", "write_behind_renewals")),
+		pending:   make(map[renewalKey]*models.LeaseRenewal),
+		flushCh:   make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+	w.ApplyConfig(cfg)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go w.run()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(w.stopCh)
+			<-w.doneCh
+
+			if err := w.Flush(ctx); err != nil {
+				w.logger.Error("Failed to flush renewals on shutdown, they are lost", zap.Error(err), zap.Int("pending", w.Pending()))
+				return err
+			}
+			return nil
+		},
+	})
+
+	return w
+}
+
+// ApplyConfig implements config.Reloadable
+func (w *WriteBehindRenewals) ApplyConfig(cfg *config.AppConfig) {
+	if w == nil {
+		return
+	}
+	w.leaseTTL.Store(int64(time.Duration(cfg.Lease.TTL) * time.Minute))
+}
+
+// Pending returns the number of leases with renewals awaiting a flush
+func (w *WriteBehindRenewals) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.pending)
+}
+
+// Renew extends the cached lease of peerID by the lease TTL and buffers the renewal. ok is
+// false when the renewal has to be written through instead: the lease is not cached, or
+// it could expire in the database before the next flush.
+func (w *WriteBehindRenewals) Renew(ctx context.Context, tokenID int64, peerID string) (*models.Lease, bool) {
+	cached, err := w.cache.GetLeaseByTokenID(ctx, tokenID)
+	if err != nil {
+		return nil, false
+	}
+
+	now := w.clock.Now()
+	if cached != nil && cached.ExpiresAt.Before(now.Add(2*w.interval)) {
+		return nil, false
+	}
+	lease, renewal, ok := extendCachedLease(ctx, cached, peerID, time.Duration(w.leaseTTL.Load()), now)
+	if !ok {
+		return nil, false
+	}
+
+	// Without the cache entry the renewal would be invisible until the flush
+	if err := w.cache.SetLease(ctx, lease); err != nil {
+		w.logger.Debug("Failed to cache renewed lease, writing it through", zap.Error(err), zap.Int64("tokenID", tokenID))
+		return nil, false
+	}
+
+	w.mu.Lock()
+	w.pending[renewalKey{renewal.TenantID, tokenID}] = renewal
+	full := len(w.pending) >= w.batchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.flushCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return lease, true
+}
+
+// Reconcile applies a buffered renewal to a lease read from the database, which does not
+// reflect it yet. A renewal older than the last write of the lease is dropped.
+func (w *WriteBehindRenewals) Reconcile(ctx context.Context, lease *models.Lease) *models.Lease {
+	key := renewalKey{models.TenantFromContext(ctx), lease.TokenID}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	renewal, ok := w.pending[key]
+	if !ok {
+		return lease
+	}
+	if renewal.PeerID != lease.PeerID || !renewal.RenewedAt.After(lease.UpdatedAt) {
+		delete(w.pending, key)
+		return lease
+	}
+
+	reconciled := *lease
+	reconciled.ExpiresAt = renewal.ExpiresAt
+	reconciled.UpdatedAt = renewal.RenewedAt
+	reconciled.Ttl = int32(renewal.ExpiresAt.Sub(w.clock.Now()).Seconds())
+	return &reconciled
+}
+
+// Forget drops the buffered renewal of a lease that was just written otherwise
+func (w *WriteBehindRenewals) Forget(ctx context.Context, tokenID int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.pending, renewalKey{models.TenantFromContext(ctx), tokenID})
+}
+
+// Flush writes the buffered renewals in batches of lease.write_behind_batch_size. Renewals
+// that could not be written stay buffered for the next flush.
+func (w *WriteBehindRenewals) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	renewals := slices.Collect(maps.Values(w.pending))
+	clear(w.pending)
+	w.mu.Unlock()
+
+	for batch := range slices.Chunk(renewals, max(w.batchSize, 1)) {
+		applied, err := w.writer.ApplyRenewals(ctx, batch)
+		if err != nil {
+			w.requeue(renewals)
+			return err
+		}
+		w.logger.Debug("Flushed renewals", zap.Int("renewals", len(batch)), zap.Int("applied", applied))
+		renewals = renewals[len(batch):]
+	}
+	return nil
+}
+
+// requeue buffers renewals again unless the lease was renewed since
+func (w *WriteBehindRenewals) requeue(renewals []*models.LeaseRenewal) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, renewal := range renewals {
+		key := renewalKey{renewal.TenantID, renewal.TokenID}
+		if _, renewed := w.pending[key]; !renewed {
+			w.pending[key] = renewal
+		}
+	}
+}
+
+func (w *WriteBehindRenewals) run() {
+	defer close(w.doneCh)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+		case <-w.flushCh:
+		}
+
+		if w.Pending() == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), w.interval)
+		if err := w.Flush(ctx); err != nil {
+			w.logger.Warn("Failed to flush renewals, retrying with the next flush", zap.Error(err), zap.Int("pending", w.Pending()))
+		}
+		cancel()
+	}
+}
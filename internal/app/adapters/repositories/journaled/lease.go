@@ -0,0 +1,226 @@
+package journaled
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// LeaseRepository records every lease mutation of the wrapped repository in the lease
+// journal once the store accepted it. A mutation the journal fails to record is logged
+// and still succeeds; the journal writes a checkpoint at its next check instead.
+type LeaseRepository struct {
+	repo    ports.LeaseRepository
+	journal ports.LeaseJournal
+	logger  *zap.Logger
+}
+
+var _ ports.LeaseRepository = &LeaseRepository{}
+
+func NewLeaseRepository(repo ports.LeaseRepository, journal ports.LeaseJournal, logger *zap.Logger) *LeaseRepository {
+	return &LeaseRepository{repo: repo, journal: journal, logger: logger.Named("journal")}
+}
+
+func (r *LeaseRepository) FindAndReuseExpiredLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	return r.allocated(ctx, false, "")(r.repo.FindAndReuseExpiredLease(ctx, peerID))
+}
+
+func (r *LeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (*models.Lease, error) {
+	return r.allocated(ctx, true, "")(r.repo.AllocateNewLease(ctx, peerID))
+}
+
+func (r *LeaseRepository) AllocateRequestedLease(ctx context.Context, peerID string, tokenID int64) (*models.Lease, error) {
+	return r.allocated(ctx, false, "")(r.repo.AllocateRequestedLease(ctx, peerID, tokenID))
+}
+
+func (r *LeaseRepository) AllocateAffinityLease(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error) {
+	return r.allocated(ctx, false, affinityGroup)(r.repo.AllocateAffinityLease(ctx, peerID, affinityGroup))
+}
+
+func (r *LeaseRepository) AllocateZoneLease(ctx context.Context, peerID string, zone string) (*models.Lease, error) {
+	return r.allocated(ctx, false, "")(r.repo.AllocateZoneLease(ctx, peerID, zone))
+}
+
+func (r *LeaseRepository) SetLeaseAffinityGroup(ctx context.Context, tokenID int64, affinityGroup string) error {
+	if err := r.repo.SetLeaseAffinityGroup(ctx, tokenID, affinityGroup); err != nil {
+		return err
+	}
+	r.record(ctx, &models.JournalEntry{Op: models.JournalOpAffinityGroup, TokenID: tokenID, AffinityGroup: affinityGroup})
+	return nil
+}
+
+func (r *LeaseRepository) SetLeaseDelegator(ctx context.Context, tokenID int64, gatewayPeerID string) error {
+	if err := r.repo.SetLeaseDelegator(ctx, tokenID, gatewayPeerID); err != nil {
+		return err
+	}
+	r.record(ctx, &models.JournalEntry{Op: models.JournalOpDelegator, TokenID: tokenID, DelegatedBy: gatewayPeerID})
+	return nil
+}
+
+func (r *LeaseRepository) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) error {
+	if err := r.repo.SetLeaseLabels(ctx, tokenID, peerID, labels); err != nil {
+		return err
+	}
+	r.record(ctx, &models.JournalEntry{Op: models.JournalOpLabels, TokenID: tokenID, PeerID: peerID, Labels: labels})
+	return nil
+}
+
+func (r *LeaseRepository) CountDelegatedLeases(ctx context.Context, gatewayPeerID string) (int64, error) {
+	return r.repo.CountDelegatedLeases(ctx, gatewayPeerID)
+}
+
+func (r *LeaseRepository) GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error) {
+	return r.repo.GetLeaseByTokenID(ctx, tokenID)
+}
+
+func (r *LeaseRepository) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
+	return r.repo.GetLeaseByPeerID(ctx, peerID)
+}
+
+func (r *LeaseRepository) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
+	lease, err := r.repo.RenewLease(ctx, tokenID, peerID)
+	if err != nil {
+		return nil, err
+	}
+	r.record(ctx, renewEntry(lease))
+	return lease, nil
+}
+
+func (r *LeaseRepository) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
+	if err := r.repo.ReleaseLease(ctx, tokenID, peerID); err != nil {
+		return err
+	}
+	r.record(ctx, &models.JournalEntry{Op: models.JournalOpRelease, TokenID: tokenID, PeerID: peerID})
+	return nil
+}
+
+func (r *LeaseRepository) TransferLease(ctx context.Context, tokenID int64, fromPeerID string, toPeerID string) (*models.Lease, error) {
+	lease, err := r.repo.TransferLease(ctx, tokenID, fromPeerID, toPeerID)
+	if err != nil {
+		return nil, err
+	}
+	r.record(ctx, &models.JournalEntry{Op: models.JournalOpTransfer, TokenID: tokenID, PeerID: toPeerID})
+	return lease, nil
+}
+
+func (r *LeaseRepository) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	results, err := r.repo.ExecuteBatch(ctx, operations)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range results {
+		if result.Err != nil || i >= len(operations) {
+			continue
+		}
+		switch op := operations[i]; op.Type {
+		case models.LeaseOperationAllocate:
+			r.record(ctx, allocateEntry(ctx, result.Lease, false, ""))
+		case models.LeaseOperationRenew:
+			r.record(ctx, renewEntry(result.Lease))
+		case models.LeaseOperationRelease:
+			r.record(ctx, &models.JournalEntry{Op: models.JournalOpRelease, TokenID: op.TokenID, PeerID: op.PeerID})
+		}
+	}
+	return results, nil
+}
+
+func (r *LeaseRepository) ListReclaimCandidates(ctx context.Context, afterTokenID int64, limit int) ([]*models.ReclaimCandidate, error) {
+	return r.repo.ListReclaimCandidates(ctx, afterTokenID, limit)
+}
+
+func (r *LeaseRepository) ListExpiringLeases(ctx context.Context, within time.Duration, afterTokenID int64, limit int) ([]*models.ExpiringLease, error) {
+	return r.repo.ListExpiringLeases(ctx, within, afterTokenID, limit)
+}
+
+func (r *LeaseRepository) ReclaimLeases(ctx context.Context, tokenIDs []int64) ([]int64, error) {
+	reclaimed, err := r.repo.ReclaimLeases(ctx, tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(reclaimed) > 0 {
+		r.record(ctx, &models.JournalEntry{Op: models.JournalOpReclaim, TokenIDs: reclaimed})
+	}
+	return reclaimed, nil
+}
+
+func (r *LeaseRepository) RevokeLeases(ctx context.Context, leases []*models.Lease) ([]int64, error) {
+	revoked, err := r.repo.RevokeLeases(ctx, leases)
+	if err != nil {
+		return nil, err
+	}
+	for _, lease := range leases {
+		if slices.Contains(revoked, lease.TokenID) {
+			r.record(ctx, &models.JournalEntry{Op: models.JournalOpRelease, TokenID: lease.TokenID, PeerID: lease.PeerID})
+		}
+	}
+	return revoked, nil
+}
+
+func (r *LeaseRepository) GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error) {
+	return r.repo.GetLeaseHistory(ctx, tokenID)
+}
+
+func (r *LeaseRepository) RecordConflict(ctx context.Context, tokenID int64, peerID string, quarantine time.Duration) (*models.LeaseConflict, error) {
+	conflict, err := r.repo.RecordConflict(ctx, tokenID, peerID, quarantine)
+	if err != nil {
+		return nil, err
+	}
+	if conflict.Quarantined {
+		reportedAt, quarantinedUntil := conflict.ReportedAt, conflict.QuarantinedUntil
+		r.record(ctx, &models.JournalEntry{
+			Op:               models.JournalOpQuarantine,
+			TokenID:          tokenID,
+			PeerID:           peerID,
+			ExpiresAt:        &reportedAt,
+			QuarantinedUntil: &quarantinedUntil,
+		})
+	}
+	return conflict, nil
+}
+
+// allocated returns a function recording the lease returned by an allocation
+func (r *LeaseRepository) allocated(ctx context.Context, cursor bool, affinityGroup string) func(*models.Lease, error) (*models.Lease, error) {
+	return func(lease *models.Lease, err error) (*models.Lease, error) {
+		if err != nil || lease == nil {
+			return lease, err
+		}
+		r.record(ctx, allocateEntry(ctx, lease, cursor, affinityGroup))
+		return lease, nil
+	}
+}
+
+func (r *LeaseRepository) record(ctx context.Context, entry *models.JournalEntry) {
+	if entry.Tenant == "" {
+		entry.Tenant = models.TenantFromContext(ctx)
+	}
+	if err := r.journal.Append(ctx, entry); err != nil {
+		r.logger.Error("Failed to journal lease mutation",
+			zap.String("op", string(entry.Op)),
+			zap.Int64("tokenID", entry.TokenID),
+			zap.Error(err),
+		)
+	}
+}
+
+func allocateEntry(ctx context.Context, lease *models.Lease, cursor bool, affinityGroup string) *models.JournalEntry {
+	createdAt, expiresAt := lease.CreatedAt, lease.ExpiresAt
+	return &models.JournalEntry{
+		Op:            models.JournalOpAllocate,
+		Tenant:        models.TenantFromContext(ctx),
+		TokenID:       lease.TokenID,
+		PeerID:        lease.PeerID,
+		AffinityGroup: affinityGroup,
+		CreatedAt:     &createdAt,
+		ExpiresAt:     &expiresAt,
+		Cursor:        cursor,
+	}
+}
+
+func renewEntry(lease *models.Lease) *models.JournalEntry {
+	expiresAt := lease.ExpiresAt
+	return &models.JournalEntry{Op: models.JournalOpRenew, TokenID: lease.TokenID, PeerID: lease.PeerID, ExpiresAt: &expiresAt}
+}
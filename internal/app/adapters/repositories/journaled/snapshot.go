@@ -0,0 +1,44 @@
+package journaled
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// LeaseSnapshotRepository records an imported snapshot as a checkpoint of the lease
+// journal, since it replaces every lease at once
+type LeaseSnapshotRepository struct {
+	repo    ports.LeaseSnapshotRepository
+	journal ports.LeaseJournal
+	logger  *zap.Logger
+}
+
+var _ ports.LeaseSnapshotRepository = &LeaseSnapshotRepository{}
+
+func NewLeaseSnapshotRepository(repo ports.LeaseSnapshotRepository, journal ports.LeaseJournal, logger *zap.Logger) *LeaseSnapshotRepository {
+	return &LeaseSnapshotRepository{repo: repo, journal: journal, logger: logger.Named("journal")}
+}
+
+func (r *LeaseSnapshotRepository) ExportLeaseState(ctx context.Context) (*models.LeaseSnapshot, error) {
+	return r.repo.ExportLeaseState(ctx)
+}
+
+func (r *LeaseSnapshotRepository) ImportLeaseState(ctx context.Context, snapshot *models.LeaseSnapshot, replace bool) (*models.SnapshotImportReport, error) {
+	report, err := r.repo.ImportLeaseState(ctx, snapshot, replace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pools missing from the snapshot were reset, so the checkpoint is read back
+	imported, err := r.repo.ExportLeaseState(ctx)
+	if err == nil {
+		_, err = r.journal.Checkpoint(ctx, imported)
+	}
+	if err != nil {
+		r.logger.Error("Failed to journal imported lease snapshot", zap.Error(err))
+	}
+	return report, nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// AccessRuleCache is an in-process stand-in for the Redis access rule cache
+type AccessRuleCache struct {
+	rules *entries[[]*models.AccessRule]
+	ttl   time.Duration
+}
+
+var _ ports.AccessRuleCache = &AccessRuleCache{}
+
+func NewAccessRuleCache(cfg *config.AppConfig, clock ports.Clock) *AccessRuleCache {
+	return &AccessRuleCache{
+		rules: newEntries[[]*models.AccessRule](clock),
+		ttl:   time.Duration(cfg.Security.AccessCacheTTL) * time.Second,
+	}
+}
+
+func accessKey(subjectType models.AccessSubjectType, subject string) string {
+	return string(subjectType) + ":" + subject
+}
+
+func (c *AccessRuleCache) GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error) {
+	rules, ok := c.rules.get(accessKey(subjectType, subject))
+	if !ok {
+		return nil, errors.ErrAccessRuleNotFound
+	}
+	return slices.Clone(rules), nil
+}
+
+func (c *AccessRuleCache) SetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string, rules []*models.AccessRule) error {
+	if c.ttl <= 0 {
+		return nil
+	}
+	c.rules.set(accessKey(subjectType, subject), slices.Clone(rules), c.ttl, false)
+	return nil
+}
+
+func (c *AccessRuleCache) DeleteAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) error {
+	c.rules.delete(accessKey(subjectType, subject))
+	return nil
+}
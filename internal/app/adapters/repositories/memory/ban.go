@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// BanStore is an in-process stand-in for the Redis ban store
+type BanStore struct {
+	violations *entries[int64]
+	bans       *entries[*models.Ban]
+}
+
+var _ ports.BanStore = &BanStore{}
+
+func NewBanStore(clock ports.Clock) *BanStore {
+	return &BanStore{violations: newEntries[int64](clock), bans: newEntries[*models.Ban](clock)}
+}
+
+func (s *BanStore) AddViolation(ctx context.Context, subject string, window time.Duration) (int64, error) {
+	return s.violations.update(subject, window, func(count int64) int64 { return count + 1 }), nil
+}
+
+func (s *BanStore) Violations(ctx context.Context, subject string) (int64, error) {
+	count, _ := s.violations.get(subject)
+	return count, nil
+}
+
+func (s *BanStore) GetBan(ctx context.Context, subject string) (*models.Ban, error) {
+	ban, ok := s.bans.get(subject)
+	if !ok {
+		return nil, errors.ErrBanNotFound
+	}
+	return ban, nil
+}
+
+func (s *BanStore) SaveBan(ctx context.Context, ban *models.Ban, ttl time.Duration) error {
+	s.bans.set(ban.Subject, ban, ttl, false)
+	s.violations.delete(ban.Subject)
+	return nil
+}
+
+func (s *BanStore) ListBans(ctx context.Context) ([]*models.Ban, error) {
+	return s.bans.values(), nil
+}
+
+func (s *BanStore) DeleteBan(ctx context.Context, subject string) error {
+	s.bans.delete(subject)
+	s.violations.delete(subject)
+	return nil
+}
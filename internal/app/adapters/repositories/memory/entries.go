@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// sweepInterval bounds how often expired entries are purged on write
+const sweepInterval = time.Minute
+
+// entry is a cached value together with its expiry
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// entries is a map whose values expire like Redis keys with a TTL. Expired entries are
+// ignored on read and purged on write at most once per sweepInterval.
+type entries[V any] struct {
+	clock     ports.Clock
+	mu        sync.Mutex
+	items     map[string]entry[V]
+	lastSweep time.Time
+}
+
+func newEntries[V any](clock ports.Clock) *entries[V] {
+	return &entries[V]{clock: clock, items: make(map[string]entry[V]), lastSweep: clock.Now()}
+}
+
+func (e *entries[V]) get(key string) (V, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	item, ok := e.items[key]
+	if !ok || !item.expiresAt.After(e.clock.Now()) {
+		var zero V
+		return zero, false
+	}
+	return item.value, true
+}
+
+// set stores value under key for ttl. With onlyIfAbsent an unexpired entry is kept,
+// like SET NX.
+func (e *entries[V]) set(key string, value V, ttl time.Duration, onlyIfAbsent bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	if onlyIfAbsent {
+		if item, ok := e.items[key]; ok && item.expiresAt.After(now) {
+			return
+		}
+	}
+	e.items[key] = entry[V]{value: value, expiresAt: now.Add(ttl)}
+	e.sweep(now)
+}
+
+// claim stores value under key for ttl unless an unexpired entry exists, in which case
+// the existing value is returned with false
+func (e *entries[V]) claim(key string, value V, ttl time.Duration) (V, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	if item, ok := e.items[key]; ok && item.expiresAt.After(now) {
+		return item.value, false
+	}
+	e.items[key] = entry[V]{value: value, expiresAt: now.Add(ttl)}
+	e.sweep(now)
+	return value, true
+}
+
+// take removes and returns the unexpired value under key, like GETDEL
+func (e *entries[V]) take(key string) (V, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	item, ok := e.items[key]
+	delete(e.items, key)
+	if !ok || !item.expiresAt.After(e.clock.Now()) {
+		var zero V
+		return zero, false
+	}
+	return item.value, true
+}
+
+// update replaces the unexpired value under key with fn of it, keeping its expiry, or
+// stores fn of the zero value for ttl, like INCR on a key that expires with its first
+// increment
+func (e *entries[V]) update(key string, ttl time.Duration, fn func(value V) V) V {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	item, ok := e.items[key]
+	if !ok || !item.expiresAt.After(now) {
+		var zero V
+		item = entry[V]{value: zero, expiresAt: now.Add(ttl)}
+	}
+	item.value = fn(item.value)
+	e.items[key] = item
+	e.sweep(now)
+	return item.value
+}
+
+// values returns the unexpired values
+func (e *entries[V]) values() []V {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	var values []V
+	for _, item := range e.items {
+		if item.expiresAt.After(now) {
+			values = append(values, item.value)
+		}
+	}
+	return values
+}
+
+func (e *entries[V]) delete(keys ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, key := range keys {
+		delete(e.items, key)
+	}
+}
+
+// sweep drops expired entries. Caller must hold e.mu.
+func (e *entries[V]) sweep(now time.Time) {
+	if now.Sub(e.lastSweep) < sweepInterval {
+		return
+	}
+	e.lastSweep = now
+	for key, item := range e.items {
+		if !item.expiresAt.After(now) {
+			delete(e.items, key)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// IdempotencyStore is an in-process stand-in for the Redis idempotency store. A nil
+// response marks a claimed key whose request is still being served.
+type IdempotencyStore struct {
+	responses *entries[*models.IdempotentResponse]
+}
+
+var _ ports.IdempotencyStore = &IdempotencyStore{}
+
+func NewIdempotencyStore(clock ports.Clock) *IdempotencyStore {
+	return &IdempotencyStore{responses: newEntries[*models.IdempotentResponse](clock)}
+}
+
+func (s *IdempotencyStore) Claim(ctx context.Context, key string, ttl time.Duration) (*models.IdempotentResponse, error) {
+	response, claimed := s.responses.claim(key, nil, ttl)
+	if claimed {
+		return nil, nil
+	}
+	if response == nil {
+		return nil, errors.ErrIdempotencyInProgress
+	}
+	return response, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, response *models.IdempotentResponse, ttl time.Duration) error {
+	s.responses.set(key, response, ttl, false)
+	return nil
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	s.responses.delete(key)
+	return nil
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// LeaseCache is an in-process stand-in for the Redis lease cache with the same keys,
+// TTLs and "not found" entries. A nil lease is the "not found" marker.
+type LeaseCache struct {
+	leases      *entries[*models.Lease]
+	negativeTTL time.Duration
+}
+
+var _ ports.LeaseCache = &LeaseCache{}
+
+func NewLeaseCache(cfg *config.AppConfig, clock ports.Clock) *LeaseCache {
+	return &LeaseCache{
+		leases:      newEntries[*models.Lease](clock),
+		negativeTTL: time.Duration(cfg.Cache.NegativeTTL) * time.Second,
+	}
+}
+
+func peerKey(ctx context.Context, peerID string) string {
+	return models.TenantKeyPrefix(ctx) + "peer:" + peerID
+}
+
+func tokenKey(ctx context.Context, tokenID int64) string {
+	return models.TenantKeyPrefix(ctx) + fmt.Sprintf("token:%d", tokenID)
+}
+
+func (c *LeaseCache) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
+	return c.getLease(peerKey(ctx, peerID))
+}
+
+func (c *LeaseCache) GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error) {
+	return c.getLease(tokenKey(ctx, tokenID))
+}
+
+func (c *LeaseCache) getLease(key string) (*models.Lease, error) {
+	lease, ok := c.leases.get(key)
+	if !ok {
+		return nil, errors.ErrLeaseNotFound
+	}
+	if lease == nil {
+		return nil, nil
+	}
+
+	// Hand out a copy so callers cannot modify the cached lease
+	cached := *lease
+	return &cached, nil
+}
+
+func (c *LeaseCache) SetLease(ctx context.Context, lease *models.Lease) error {
+	c.setLease(ctx, lease)
+	return nil
+}
+
+func (c *LeaseCache) setLease(ctx context.Context, lease *models.Lease) {
+	ttl := time.Duration(lease.Ttl) * time.Second
+	if ttl <= 0 {
+		// Do not cache already expired leases
+		return
+	}
+
+	cached := *lease
+	c.leases.set(peerKey(ctx, lease.PeerID), &cached, ttl, false)
+	c.leases.set(tokenKey(ctx, lease.TokenID), &cached, ttl, false)
+}
+
+func (c *LeaseCache) SetPeerNotFound(ctx context.Context, peerID string) error {
+	c.setNotFound(peerKey(ctx, peerID))
+	return nil
+}
+
+func (c *LeaseCache) SetTokenNotFound(ctx context.Context, tokenID int64) error {
+	c.setNotFound(tokenKey(ctx, tokenID))
+	return nil
+}
+
+// setNotFound never replaces a cached lease, see the Redis cache
+func (c *LeaseCache) setNotFound(key string) {
+	if c.negativeTTL <= 0 {
+		return
+	}
+	c.leases.set(key, nil, c.negativeTTL, true)
+}
+
+func (c *LeaseCache) DeleteLease(ctx context.Context, peerID string, tokenID int64) error {
+	c.leases.delete(peerKey(ctx, peerID), tokenKey(ctx, tokenID))
+	return nil
+}
+
+func (c *LeaseCache) UpdateLeases(ctx context.Context, upserts []*models.Lease, removals []*models.Lease) error {
+	for _, lease := range removals {
+		c.leases.delete(peerKey(ctx, lease.PeerID), tokenKey(ctx, lease.TokenID))
+	}
+	for _, lease := range upserts {
+		c.setLease(ctx, lease)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module wires the same topology as the Postgres backend, a store behind a cache, with
+// everything held in memory so the service runs without external dependencies
+var Module = fx.Options(
+	fx.Provide(
+		func(logger *zap.Logger, clock ports.Clock) *embedded.Store {
+			logger.Warn("Using in-memory storage, all leases and nonces are lost on restart")
+			return embedded.NewMemoryStore(clock)
+		},
+	),
+	fx.Provide(embedded.NewNonceRepository),
+	fx.Provide(embedded.NewLeaseRepository),
+	config.ReloadTarget[*embedded.LeaseRepository](),
+	fx.Provide(NewNonceCache),
+	fx.Provide(NewLeaseCache),
+	fx.Provide(embedded.NewAccessRuleRepository),
+	fx.Provide(NewAccessRuleCache),
+	fx.Provide(
+		fx.Annotate(
+			func(
+				clock ports.Clock,
+				logger *zap.Logger,
+				storeNonceRepo *embedded.NonceRepository,
+				cache *NonceCache,
+			) ports.NonceRepository {
+				return hybrid.NewNonceRepository(storeNonceRepo, cache, 0, clock, logger)
+			},
+			fx.As(new(ports.NonceRepository)),
+		),
+		fx.Annotate(
+			func(
+				logger *zap.Logger,
+				storeLeaseRepo *embedded.LeaseRepository,
+				cache *LeaseCache,
+			) ports.LeaseRepository {
+				return hybrid.NewLeaseRepository(storeLeaseRepo, cache, nil, nil, logger)
+			},
+			fx.As(new(ports.LeaseRepository)),
+		),
+		fx.Annotate(
+			func(
+				logger *zap.Logger,
+				storeAccessRuleRepo *embedded.AccessRuleRepository,
+				cache *AccessRuleCache,
+			) ports.AccessRuleRepository {
+				return hybrid.NewAccessRuleRepository(storeAccessRuleRepo, cache, logger)
+			},
+			fx.As(new(ports.AccessRuleRepository)),
+		),
+		fx.Annotate(
+			embedded.NewAPIKeyRepository,
+			fx.As(new(ports.APIKeyRepository)),
+		),
+		fx.Annotate(
+			embedded.NewPeerPolicyRepository,
+			fx.As(new(ports.PeerPolicyRepository)),
+		),
+		fx.Annotate(
+			NewIdempotencyStore,
+			fx.As(new(ports.IdempotencyStore)),
+		),
+		fx.Annotate(
+			NewBanStore,
+			fx.As(new(ports.BanStore)),
+		),
+		fx.Annotate(
+			embedded.NewLeaseReadModel,
+			fx.As(new(ports.LeaseReadModel)),
+		),
+		fx.Annotate(
+			embedded.NewPoolStatsRepository,
+			fx.As(new(ports.PoolStatsRepository)),
+		),
+		fx.Annotate(
+			embedded.NewLeaseSnapshotRepository,
+			fx.As(new(ports.LeaseSnapshotRepository)),
+		),
+		fx.Annotate(
+			embedded.NewDiagnostics,
+			fx.As(new(ports.CacheDiagnostics)),
+		),
+		fx.Annotate(
+			embedded.NewIntegrityChecker,
+			fx.As(new(ports.IntegrityChecker)),
+		),
+		fx.Annotate(
+			embedded.NewLeaderElector,
+			fx.As(new(ports.LeaderElector)),
+		),
+		fx.Annotate(
+			func(store *embedded.Store) ports.HealthChecker { return store },
+			fx.ResultTags(`name:"database"`),
+		),
+	),
+)
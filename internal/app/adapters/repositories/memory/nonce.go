@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// NonceCache is an in-process stand-in for the Redis nonce cache
+type NonceCache struct {
+	nonces   *entries[models.Nonce]
+	nonceTTL time.Duration
+}
+
+var _ ports.NonceCache = &NonceCache{}
+
+func NewNonceCache(cfg *config.AppConfig, clock ports.Clock) *NonceCache {
+	return &NonceCache{
+		nonces:   newEntries[models.Nonce](clock),
+		nonceTTL: time.Duration(cfg.Nonce.TTL) * time.Minute,
+	}
+}
+
+func (c *NonceCache) GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
+	nonce, ok := c.nonces.get(nonceID)
+	if !ok {
+		return nil, errors.ErrNonceNotFound
+	}
+	return &nonce, nil
+}
+
+func (c *NonceCache) CreateNonce(ctx context.Context, nonce *models.Nonce) error {
+	c.nonces.set(nonce.ID, *nonce, c.nonceTTL, false)
+	return nil
+}
+
+func (c *NonceCache) DeleteNonce(ctx context.Context, nonceID string) error {
+	c.nonces.delete(nonceID)
+	return nil
+}
+
+func (c *NonceCache) TakeNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
+	nonce, ok := c.nonces.take(nonceID)
+	if !ok {
+		return nil, errors.ErrNonceNotFound
+	}
+	return &nonce, nil
+}
@@ -1,14 +1,84 @@
 package repositories
 
 import (
+	"fmt"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/encrypted"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/faults"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/journaled"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/redis"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 )
 
-var Module = fx.Options(
-	postgres.Module,
-	redis.Module,
-	hybrid.Module,
+// leaseDecorators wraps the lease and snapshot repositories of the storage backend.
+// Peer IDs are encrypted right above the store when a field encryption key is set, and
+// the lease journal records the mutations above that, with the peer IDs the services see.
+var leaseDecorators = fx.Decorate(
+	fx.Annotate(
+		func(cipher ports.FieldCipher, journal ports.LeaseJournal, logger *zap.Logger, repo ports.LeaseRepository) ports.LeaseRepository {
+			if cipher != nil {
+				repo = encrypted.NewLeaseRepository(repo, cipher)
+			}
+			if journal != nil {
+				repo = journaled.NewLeaseRepository(repo, journal, logger)
+			}
+			return repo
+		},
+		fx.ParamTags(`optional:"true"`),
+	),
+	fx.Annotate(
+		func(cipher ports.FieldCipher, journal ports.LeaseJournal, logger *zap.Logger, repo ports.LeaseSnapshotRepository) ports.LeaseSnapshotRepository {
+			if cipher != nil {
+				repo = encrypted.NewLeaseSnapshotRepository(repo, cipher)
+			}
+			if journal != nil {
+				repo = journaled.NewLeaseSnapshotRepository(repo, journal, logger)
+			}
+			return repo
+		},
+		fx.ParamTags(`optional:"true"`),
+	),
 )
+
+// NewModule wires the repositories of the given storage backend
+func NewModule(storageBackend string) fx.Option {
+	switch storageBackend {
+	case config.StorageBackendPostgres, "":
+		return fx.Options(
+			postgres.Module,
+			redis.Module,
+			faults.Module,
+			hybrid.Module,
+			// Peer IDs and public keys reach Postgres and Redis encrypted when a key is set
+			encrypted.Module,
+			leaseDecorators,
+		)
+	case config.StorageBackendEmbedded:
+		return fx.Options(
+			embedded.Module,
+			// A single instance needs no shared store for idempotency keys and bans
+			fx.Provide(
+				fx.Annotate(
+					memory.NewIdempotencyStore,
+					fx.As(new(ports.IdempotencyStore)),
+				),
+				fx.Annotate(
+					memory.NewBanStore,
+					fx.As(new(ports.BanStore)),
+				),
+			),
+			leaseDecorators,
+		)
+	case config.StorageBackendMemory:
+		return fx.Options(memory.Module, leaseDecorators)
+	default:
+		return fx.Error(fmt.Errorf("unknown storage backend %q", storageBackend))
+	}
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+type AccessRuleRepository struct {
+	query *qDb.Queries
+}
+
+var _ ports.AccessRuleRepository = &AccessRuleRepository{}
+
+func NewAccessRuleRepository(db *pgxpool.Pool) *AccessRuleRepository {
+	return &AccessRuleRepository{qDb.New(db)}
+}
+
+func (r *AccessRuleRepository) GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) (_ []*models.AccessRule, err error) {
+	defer translate(&err, domainErrors.ErrAccessRuleNotFound)
+	rows, err := r.query.GetAccessRulesBySubject(ctx, qDb.GetAccessRulesBySubjectParams{
+		SubjectType: string(subjectType),
+		Subject:     subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAccessRules(rows), nil
+}
+
+func (r *AccessRuleRepository) ListAccessRules(ctx context.Context, list models.AccessList) (_ []*models.AccessRule, err error) {
+	defer translate(&err, domainErrors.ErrAccessRuleNotFound)
+	rows, err := r.query.ListAccessRules(ctx, string(list))
+	if err != nil {
+		return nil, err
+	}
+	return toAccessRules(rows), nil
+}
+
+func (r *AccessRuleRepository) CreateAccessRule(ctx context.Context, rule *models.AccessRule) (_ *models.AccessRule, err error) {
+	defer translate(&err, domainErrors.ErrAccessRuleNotFound)
+	row, err := r.query.InsertAccessRule(ctx, qDb.InsertAccessRuleParams{
+		List:        string(rule.List),
+		SubjectType: string(rule.SubjectType),
+		Subject:     rule.Subject,
+		Reason:      rule.Reason,
+	})
+	if err != nil {
+		// The insert does nothing when the subject is already on the list
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainErrors.ErrAccessRuleExists
+		}
+		return nil, err
+	}
+	return toAccessRule(row), nil
+}
+
+func (r *AccessRuleRepository) DeleteAccessRule(ctx context.Context, id int64) (_ *models.AccessRule, err error) {
+	defer translate(&err, domainErrors.ErrAccessRuleNotFound)
+	row, err := r.query.DeleteAccessRule(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainErrors.ErrAccessRuleNotFound
+		}
+		return nil, err
+	}
+	return toAccessRule(row), nil
+}
+
+func toAccessRules(rows []qDb.AccessRule) []*models.AccessRule {
+	rules := make([]*models.AccessRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, toAccessRule(row))
+	}
+	return rules
+}
+
+func toAccessRule(row qDb.AccessRule) *models.AccessRule {
+	return &models.AccessRule{
+		ID:          row.ID,
+		List:        models.AccessList(row.List),
+		SubjectType: models.AccessSubjectType(row.SubjectType),
+		Subject:     row.Subject,
+		Reason:      row.Reason,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+}
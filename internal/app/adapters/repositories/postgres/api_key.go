@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+type APIKeyRepository struct {
+	query *qDb.Queries
+}
+
+var _ ports.APIKeyRepository = &APIKeyRepository{}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{qDb.New(db)}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (_ *models.APIKey, err error) {
+	defer translate(&err, domainErrors.ErrAPIKeyNotFound)
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	row, err := r.query.InsertAPIKey(ctx, qDb.InsertAPIKeyParams{
+		Name:      key.Name,
+		KeyPrefix: key.Prefix,
+		KeyHash:   key.Hash,
+		Scopes:    scopes,
+	})
+	if err != nil {
+		// The insert does nothing when a key with the same hash exists
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainErrors.ErrDuplicateRecord
+		}
+		return nil, err
+	}
+	return toAPIKey(row), nil
+}
+
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (_ *models.APIKey, err error) {
+	defer translate(&err, domainErrors.ErrAPIKeyNotFound)
+	row, err := r.query.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return toAPIKey(row), nil
+}
+
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) (_ []*models.APIKey, err error) {
+	defer translate(&err, domainErrors.ErrAPIKeyNotFound)
+	rows, err := r.query.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*models.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, toAPIKey(row))
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int64) (_ *models.APIKey, err error) {
+	defer translate(&err, domainErrors.ErrAPIKeyNotFound)
+	row, err := r.query.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toAPIKey(row), nil
+}
+
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id int64) (err error) {
+	defer translate(&err, domainErrors.ErrAPIKeyNotFound)
+	return r.query.TouchAPIKey(ctx, id)
+}
+
+func toAPIKey(row qDb.ApiKey) *models.APIKey {
+	scopes := make([]models.APIKeyScope, len(row.Scopes))
+	for i, scope := range row.Scopes {
+		scopes[i] = models.APIKeyScope(scope)
+	}
+	return &models.APIKey{
+		ID:         row.ID,
+		Name:       row.Name,
+		Prefix:     row.KeyPrefix,
+		Hash:       row.KeyHash,
+		Scopes:     scopes,
+		CreatedAt:  row.CreatedAt.Time,
+		LastUsedAt: timePtr(row.LastUsedAt),
+		RevokedAt:  timePtr(row.RevokedAt),
+	}
+}
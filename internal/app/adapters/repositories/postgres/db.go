@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
 )
@@ -14,32 +17,17 @@ type DBPool struct {
 	pool *pgxpool.Pool
 }
 
-func NewDBPool(lc fx.Lifecycle, cfg *config.AppConfig) (*pgxpool.Pool, error) {
-	dbURL := cfg.DatabaseURL
+var _ ports.DatabasePoolStatsReporter = &DBPool{}
+
+func NewDBPool(lc fx.Lifecycle, cfg *config.AppConfig, faults ports.FaultInjector) (*pgxpool.Pool, error) {
+	dbURL := cfg.Database.URL
 	if dbURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL environment variable not set")
 	}
 
-	poolConfig, err := pgxpool.ParseConfig(dbURL)
+	poolConfig, err := newPoolConfig(dbURL, cfg, faults)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse database config: %w", err)
-	}
-
-	// Configure pool settings from config
-	if cfg.DBMaxConns > 0 {
-		poolConfig.MaxConns = int32(cfg.DBMaxConns)
-	}
-	if cfg.DBMinConns > 0 {
-		poolConfig.MinConns = int32(cfg.DBMinConns)
-	}
-	if cfg.DBMaxConnLifetime > 0 {
-		poolConfig.MaxConnLifetime = time.Duration(cfg.DBMaxConnLifetime) * time.Minute
-	}
-	if cfg.DBMaxConnIdleTime > 0 {
-		poolConfig.MaxConnIdleTime = time.Duration(cfg.DBMaxConnIdleTime) * time.Minute
-	}
-	if cfg.DBHealthCheckPeriod > 0 {
-		poolConfig.HealthCheckPeriod = time.Duration(cfg.DBHealthCheckPeriod) * time.Second
+		return nil, err
 	}
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
@@ -63,3 +51,69 @@ func NewDBPool(lc fx.Lifecycle, cfg *config.AppConfig) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+// newPoolConfig parses dbURL and applies the pool settings shared by the primary and
+// the read replicas. Faults, when injected, delay or fail acquiring a connection, which
+// every query and transaction does.
+func newPoolConfig(dbURL string, cfg *config.AppConfig, faults ports.FaultInjector) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database config: %w", err)
+	}
+
+	// Configure pool settings from config
+	if cfg.Database.MaxConns > 0 {
+		poolConfig.MaxConns = int32(cfg.Database.MaxConns)
+	}
+	if cfg.Database.MinConns > 0 {
+		poolConfig.MinConns = int32(cfg.Database.MinConns)
+	}
+	if cfg.Database.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = time.Duration(cfg.Database.MaxConnLifetime) * time.Minute
+	}
+	if cfg.Database.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = time.Duration(cfg.Database.MaxConnIdleTime) * time.Minute
+	}
+	if cfg.Database.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = time.Duration(cfg.Database.HealthCheckPeriod) * time.Second
+	}
+	if faults != nil {
+		poolConfig.PrepareConn = func(ctx context.Context, _ *pgx.Conn) (bool, error) {
+			if err := faults.Inject(ctx, models.FaultTargetDatabase); err != nil {
+				// The connection stays in the pool, the query fails like on a lost connection
+				return true, injectedError(err)
+			}
+			return true, nil
+		}
+	}
+
+	return poolConfig, nil
+}
+
+// NewHealthChecker exposes the pool as the database health check
+func NewHealthChecker(pool *pgxpool.Pool) ports.HealthChecker {
+	return pool
+}
+
+// NewPoolStatsReporter exposes the statistics of the pool for runtime diagnostics
+func NewPoolStatsReporter(pool *pgxpool.Pool) *DBPool {
+	return &DBPool{pool: pool}
+}
+
+func (p *DBPool) DatabasePoolStats() *models.DatabasePoolStats {
+	stat := p.pool.Stat()
+	return &models.DatabasePoolStats{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		IdleConns:               stat.IdleConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		AcquireDurationMs:       float64(stat.AcquireDuration()) / float64(time.Millisecond),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	}
+}
@@ -8,18 +8,74 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AccessRule struct {
+	ID          int64
+	List        string
+	SubjectType string
+	Subject     string
+	Reason      string
+	CreatedAt   pgtype.Timestamptz
+}
+
 type AllocState struct {
 	ID          int32
 	LastTokenID int64
 	MaxTokenID  int64
+	MinTokenID  int64
+	TenantID    string
+}
+
+type ApiKey struct {
+	ID         int64
+	Name       string
+	KeyPrefix  string
+	KeyHash    string
+	Scopes     []string
+	CreatedAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+	RevokedAt  pgtype.Timestamptz
+}
+
+type HaState struct {
+	ID             bool
+	Epoch          int64
+	ActiveInstance string
+	ActivatedAt    pgtype.Timestamptz
 }
 
 type Lease struct {
-	TokenID   int64
-	PeerID    string
-	ExpiresAt pgtype.Timestamptz
-	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
+	TokenID          int64
+	PeerID           string
+	ExpiresAt        pgtype.Timestamptz
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	AffinityGroup    pgtype.Text
+	ReclaimedAt      pgtype.Timestamptz
+	QuarantinedUntil pgtype.Timestamptz
+	TenantID         string
+	DelegatedBy      pgtype.Text
+	Labels           []byte
+}
+
+type LeaseHistory struct {
+	ID         int64
+	TokenID    int64
+	PeerID     string
+	Event      string
+	ExpiresAt  pgtype.Timestamptz
+	OccurredAt pgtype.Timestamptz
+	TenantID   string
+}
+
+type LeaseReadModel struct {
+	TokenID       int64
+	PeerID        string
+	AffinityGroup pgtype.Text
+	Labels        []byte
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	ExpiresAt     pgtype.Timestamptz
+	RefreshedAt   pgtype.Timestamptz
 }
 
 type Nonce struct {
@@ -30,3 +86,13 @@ type Nonce struct {
 	Used      bool
 	UsedAt    pgtype.Timestamptz
 }
+
+type PeerPolicy struct {
+	ID          int64
+	Name        string
+	Peers       []string
+	CanAllocate bool
+	CanReserve  bool
+	MaxPool     int32
+	CreatedAt   pgtype.Timestamptz
+}
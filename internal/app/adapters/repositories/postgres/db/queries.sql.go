@@ -11,20 +11,66 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const activateHAEpoch = `-- name: ActivateHAEpoch :one
+UPDATE ha_state
+SET epoch = epoch + 1,
+    active_instance = $1::text,
+    activated_at = now()
+WHERE id
+RETURNING epoch
+`
+
+func (q *Queries) ActivateHAEpoch(ctx context.Context, instance string) (int64, error) {
+	row := q.db.QueryRow(ctx, activateHAEpoch, instance)
+	var epoch int64
+	err := row.Scan(&epoch)
+	return epoch, err
+}
+
 const allocateNextTokenID = `-- name: AllocateNextTokenID :one
 UPDATE alloc_state
 SET last_token_id = (last_token_id + 1)
-WHERE id = 1 AND last_token_id < max_token_id
+WHERE tenant_id = $1 AND last_token_id < max_token_id
 RETURNING last_token_id
 `
 
-func (q *Queries) AllocateNextTokenID(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, allocateNextTokenID)
+func (q *Queries) AllocateNextTokenID(ctx context.Context, tenantID string) (int64, error) {
+	row := q.db.QueryRow(ctx, allocateNextTokenID, tenantID)
 	var last_token_id int64
 	err := row.Scan(&last_token_id)
 	return last_token_id, err
 }
 
+const applyLeaseRenewal = `-- name: ApplyLeaseRenewal :one
+UPDATE leases
+SET expires_at = $3::timestamptz,
+    updated_at = $4::timestamptz
+WHERE token_id = $1 AND peer_id = $2 AND tenant_id = $5
+  AND updated_at < $4::timestamptz AND reclaimed_at IS NULL
+RETURNING expires_at
+`
+
+type ApplyLeaseRenewalParams struct {
+	TokenID   int64
+	PeerID    string
+	ExpiresAt pgtype.Timestamptz
+	RenewedAt pgtype.Timestamptz
+	TenantID  string
+}
+
+func (q *Queries) ApplyLeaseRenewal(ctx context.Context, arg ApplyLeaseRenewalParams) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, applyLeaseRenewal,
+		arg.TokenID,
+		arg.PeerID,
+		arg.ExpiresAt,
+		arg.RenewedAt,
+		arg.TenantID,
+	)
+	var expires_at pgtype.Timestamptz
+	err := row.Scan(&expires_at)
+	return expires_at, err
+}
+
 const consumeNonce = `-- name: ConsumeNonce :one
 UPDATE nonces
 SET used = true, used_at = now()
@@ -51,6 +97,116 @@ func (q *Queries) ConsumeNonce(ctx context.Context, arg ConsumeNonceParams) (Non
 	return i, err
 }
 
+const countActiveLeasesByPeerID = `-- name: CountActiveLeasesByPeerID :one
+SELECT count(*) FROM leases
+WHERE peer_id = $1 AND tenant_id = $2 AND expires_at > now()
+`
+
+type CountActiveLeasesByPeerIDParams struct {
+	PeerID   string
+	TenantID string
+}
+
+func (q *Queries) CountActiveLeasesByPeerID(ctx context.Context, arg CountActiveLeasesByPeerIDParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countActiveLeasesByPeerID, arg.PeerID, arg.TenantID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countAllocationsSince = `-- name: CountAllocationsSince :many
+SELECT tenant_id, COUNT(*)::bigint AS allocations
+FROM lease_history
+WHERE event = 'allocate' AND occurred_at > now() - ($1::int * interval '1 second')
+GROUP BY tenant_id
+`
+
+type CountAllocationsSinceRow struct {
+	TenantID    string
+	Allocations int64
+}
+
+func (q *Queries) CountAllocationsSince(ctx context.Context, within int32) ([]CountAllocationsSinceRow, error) {
+	rows, err := q.db.Query(ctx, countAllocationsSince, within)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountAllocationsSinceRow
+	for rows.Next() {
+		var i CountAllocationsSinceRow
+		if err := rows.Scan(
+			&i.TenantID,
+			&i.Allocations,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countDelegatedLeases = `-- name: CountDelegatedLeases :one
+SELECT count(*) FROM leases
+WHERE delegated_by = $1 AND expires_at > now()
+`
+
+func (q *Queries) CountDelegatedLeases(ctx context.Context, delegatedBy pgtype.Text) (int64, error) {
+	row := q.db.QueryRow(ctx, countDelegatedLeases, delegatedBy)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPoolLeases = `-- name: CountPoolLeases :many
+SELECT tenant_id,
+       COUNT(*) FILTER (WHERE expires_at > now())::bigint AS allocated,
+       COUNT(*) FILTER (WHERE expires_at <= now() AND quarantined_until > now())::bigint AS reserved,
+       COUNT(*) FILTER (WHERE expires_at <= now() AND expires_at > now() - ($1::int * interval '1 second')
+                          AND (quarantined_until IS NULL OR quarantined_until <= now()))::bigint AS grace,
+       COUNT(*) FILTER (WHERE expires_at <= now() - ($1::int * interval '1 second')
+                          AND (quarantined_until IS NULL OR quarantined_until <= now()))::bigint AS expired_reclaimable
+FROM leases
+GROUP BY tenant_id
+`
+
+type CountPoolLeasesRow struct {
+	TenantID           string
+	Allocated          int64
+	Reserved           int64
+	Grace              int64
+	ExpiredReclaimable int64
+}
+
+func (q *Queries) CountPoolLeases(ctx context.Context, grace int32) ([]CountPoolLeasesRow, error) {
+	rows, err := q.db.Query(ctx, countPoolLeases, grace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountPoolLeasesRow
+	for rows.Next() {
+		var i CountPoolLeasesRow
+		if err := rows.Scan(
+			&i.TenantID,
+			&i.Allocated,
+			&i.Reserved,
+			&i.Grace,
+			&i.ExpiredReclaimable,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createNonce = `-- name: CreateNonce :one
 INSERT INTO nonces (peer_id, issued_at, expires_at) 
 VALUES ($1, now(), now() + ($2::int * interval '1 minute')) 
@@ -76,24 +232,101 @@ func (q *Queries) CreateNonce(ctx context.Context, arg CreateNonceParams) (Nonce
 	return i, err
 }
 
-const deleteExpiredNonces = `-- name: DeleteExpiredNonces :exec
-DELETE FROM nonces WHERE expires_at < now()
+const deleteAccessRule = `-- name: DeleteAccessRule :one
+DELETE FROM access_rules
+WHERE id = $1
+RETURNING id, list, subject_type, subject, reason, created_at
 `
 
-func (q *Queries) DeleteExpiredNonces(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, deleteExpiredNonces)
-	return err
+func (q *Queries) DeleteAccessRule(ctx context.Context, id int64) (AccessRule, error) {
+	row := q.db.QueryRow(ctx, deleteAccessRule, id)
+	var i AccessRule
+	err := row.Scan(
+		&i.ID,
+		&i.List,
+		&i.SubjectType,
+		&i.Subject,
+		&i.Reason,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAllLeases = `-- name: DeleteAllLeases :execrows
+DELETE FROM leases
+`
+
+func (q *Queries) DeleteAllLeases(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAllLeases)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredNonces = `-- name: DeleteExpiredNonces :execrows
+DELETE FROM nonces
+WHERE id IN (
+  SELECT id FROM nonces
+  WHERE expires_at < now()
+  ORDER BY expires_at
+  LIMIT NULLIF($1::int, 0)
+  FOR UPDATE SKIP LOCKED
+)
+`
+
+func (q *Queries) DeleteExpiredNonces(ctx context.Context, batchSize int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredNonces, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePeerPolicy = `-- name: DeletePeerPolicy :one
+DELETE FROM peer_policies
+WHERE id = $1
+RETURNING id, name, peers, can_allocate, can_reserve, max_pool, created_at
+`
+
+func (q *Queries) DeletePeerPolicy(ctx context.Context, id int64) (PeerPolicy, error) {
+	row := q.db.QueryRow(ctx, deletePeerPolicy, id)
+	var i PeerPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Peers,
+		&i.CanAllocate,
+		&i.CanReserve,
+		&i.MaxPool,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
 const findExpiredLeaseForReuse = `-- name: FindExpiredLeaseForReuse :one
 SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
 FROM leases
-WHERE expires_at < now()
+WHERE tenant_id = $1 AND expires_at < now() - ($2::int * interval '1 second')
+  AND (NOT $3::boolean OR reclaimed_at IS NOT NULL)
+  AND (quarantined_until IS NULL OR quarantined_until <= now())
+  AND NOT EXISTS (
+      SELECT 1 FROM unnest($4::bigint[], $5::bigint[]) AS excluded(first_token_id, last_token_id)
+      WHERE token_id BETWEEN excluded.first_token_id AND excluded.last_token_id
+  )
 ORDER BY expires_at ASC
 LIMIT 1
 FOR UPDATE SKIP LOCKED
 `
 
+type FindExpiredLeaseForReuseParams struct {
+	TenantID      string
+	Grace         int32
+	ReclaimedOnly bool
+	ExcludedFirst []int64
+	ExcludedLast  []int64
+}
+
 type FindExpiredLeaseForReuseRow struct {
 	TokenID   int64
 	PeerID    string
@@ -103,8 +336,14 @@ type FindExpiredLeaseForReuseRow struct {
 	Ttl       int32
 }
 
-func (q *Queries) FindExpiredLeaseForReuse(ctx context.Context) (FindExpiredLeaseForReuseRow, error) {
-	row := q.db.QueryRow(ctx, findExpiredLeaseForReuse)
+func (q *Queries) FindExpiredLeaseForReuse(ctx context.Context, arg FindExpiredLeaseForReuseParams) (FindExpiredLeaseForReuseRow, error) {
+	row := q.db.QueryRow(ctx, findExpiredLeaseForReuse,
+		arg.TenantID,
+		arg.Grace,
+		arg.ReclaimedOnly,
+		arg.ExcludedFirst,
+		arg.ExcludedLast,
+	)
 	var i FindExpiredLeaseForReuseRow
 	err := row.Scan(
 		&i.TokenID,
@@ -117,12 +356,233 @@ func (q *Queries) FindExpiredLeaseForReuse(ctx context.Context) (FindExpiredLeas
 	return i, err
 }
 
+const findFreeZoneTokenIDs = `-- name: FindFreeZoneTokenIDs :many
+SELECT candidate.token_id::bigint AS token_id
+FROM unnest($1::bigint[], $2::bigint[]) AS zone(first_token_id, last_token_id)
+CROSS JOIN LATERAL generate_series(zone.first_token_id, zone.last_token_id) AS candidate(token_id)
+WHERE NOT EXISTS (
+      SELECT 1 FROM leases
+      WHERE leases.token_id = candidate.token_id
+        AND (leases.tenant_id <> $3
+             OR leases.expires_at >= now() - ($4::int * interval '1 second')
+             OR ($5::boolean AND leases.reclaimed_at IS NULL)
+             OR leases.quarantined_until > now())
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM unnest($6::bigint[], $7::bigint[]) AS excluded(first_token_id, last_token_id)
+      WHERE candidate.token_id BETWEEN excluded.first_token_id AND excluded.last_token_id
+  )
+LIMIT $8
+`
+
+type FindFreeZoneTokenIDsParams struct {
+	ZoneFirst     []int64
+	ZoneLast      []int64
+	TenantID      string
+	Grace         int32
+	ReclaimedOnly bool
+	ExcludedFirst []int64
+	ExcludedLast  []int64
+	MaxCandidates int32
+}
+
+func (q *Queries) FindFreeZoneTokenIDs(ctx context.Context, arg FindFreeZoneTokenIDsParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, findFreeZoneTokenIDs,
+		arg.ZoneFirst,
+		arg.ZoneLast,
+		arg.TenantID,
+		arg.Grace,
+		arg.ReclaimedOnly,
+		arg.ExcludedFirst,
+		arg.ExcludedLast,
+		arg.MaxCandidates,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var token_id int64
+		if err := rows.Scan(&token_id); err != nil {
+			return nil, err
+		}
+		items = append(items, token_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
+SELECT id, name, key_prefix, key_hash, scopes, created_at, last_used_at, revoked_at
+FROM api_keys
+WHERE key_hash = $1
+`
+
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyByHash, keyHash)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getAccessRulesBySubject = `-- name: GetAccessRulesBySubject :many
+SELECT id, list, subject_type, subject, reason, created_at
+FROM access_rules
+WHERE subject_type = $1 AND subject = $2
+ORDER BY id
+`
+
+type GetAccessRulesBySubjectParams struct {
+	SubjectType string
+	Subject     string
+}
+
+func (q *Queries) GetAccessRulesBySubject(ctx context.Context, arg GetAccessRulesBySubjectParams) ([]AccessRule, error) {
+	rows, err := q.db.Query(ctx, getAccessRulesBySubject, arg.SubjectType, arg.Subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccessRule
+	for rows.Next() {
+		var i AccessRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.List,
+			&i.SubjectType,
+			&i.Subject,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAffinityGroupTokenIDs = `-- name: GetAffinityGroupTokenIDs :many
+SELECT token_id
+FROM leases
+WHERE affinity_group = $1 AND tenant_id = $2 AND expires_at > now()
+ORDER BY token_id
+`
+
+type GetAffinityGroupTokenIDsParams struct {
+	AffinityGroup pgtype.Text
+	TenantID      string
+}
+
+func (q *Queries) GetAffinityGroupTokenIDs(ctx context.Context, arg GetAffinityGroupTokenIDsParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, getAffinityGroupTokenIDs, arg.AffinityGroup, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var token_id int64
+		if err := rows.Scan(&token_id); err != nil {
+			return nil, err
+		}
+		items = append(items, token_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllocState = `-- name: GetAllocState :one
+SELECT id, last_token_id, max_token_id, min_token_id, tenant_id
+FROM alloc_state
+WHERE tenant_id = $1
+`
+
+func (q *Queries) GetAllocState(ctx context.Context, tenantID string) (AllocState, error) {
+	row := q.db.QueryRow(ctx, getAllocState, tenantID)
+	var i AllocState
+	err := row.Scan(
+		&i.ID,
+		&i.LastTokenID,
+		&i.MaxTokenID,
+		&i.MinTokenID,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getHAEpochForShare = `-- name: GetHAEpochForShare :one
+SELECT epoch FROM ha_state WHERE id FOR SHARE
+`
+
+func (q *Queries) GetHAEpochForShare(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, getHAEpochForShare)
+	var epoch int64
+	err := row.Scan(&epoch)
+	return epoch, err
+}
+
+const getHAState = `-- name: GetHAState :one
+SELECT id, epoch, active_instance, activated_at FROM ha_state WHERE id
+`
+
+func (q *Queries) GetHAState(ctx context.Context) (HaState, error) {
+	row := q.db.QueryRow(ctx, getHAState)
+	var i HaState
+	err := row.Scan(
+		&i.ID,
+		&i.Epoch,
+		&i.ActiveInstance,
+		&i.ActivatedAt,
+	)
+	return i, err
+}
+
+const getHighestLeasedTokenID = `-- name: GetHighestLeasedTokenID :one
+SELECT COALESCE(MAX(token_id), 0)::bigint AS highest_token_id
+FROM leases
+WHERE token_id BETWEEN $1 AND $2
+`
+
+type GetHighestLeasedTokenIDParams struct {
+	MinTokenID int64
+	MaxTokenID int64
+}
+
+func (q *Queries) GetHighestLeasedTokenID(ctx context.Context, arg GetHighestLeasedTokenIDParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getHighestLeasedTokenID, arg.MinTokenID, arg.MaxTokenID)
+	var highest_token_id int64
+	err := row.Scan(&highest_token_id)
+	return highest_token_id, err
+}
+
 const getLeaseByPeerID = `-- name: GetLeaseByPeerID :one
-SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
+SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels
 FROM leases
-WHERE peer_id = $1 AND expires_at > now()
+WHERE peer_id = $1 AND tenant_id = $2 AND expires_at > now()
 `
 
+type GetLeaseByPeerIDParams struct {
+	PeerID   string
+	TenantID string
+}
+
 type GetLeaseByPeerIDRow struct {
 	TokenID   int64
 	PeerID    string
@@ -130,10 +590,11 @@ type GetLeaseByPeerIDRow struct {
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
 	Ttl       int32
+	Labels    []byte
 }
 
-func (q *Queries) GetLeaseByPeerID(ctx context.Context, peerID string) (GetLeaseByPeerIDRow, error) {
-	row := q.db.QueryRow(ctx, getLeaseByPeerID, peerID)
+func (q *Queries) GetLeaseByPeerID(ctx context.Context, arg GetLeaseByPeerIDParams) (GetLeaseByPeerIDRow, error) {
+	row := q.db.QueryRow(ctx, getLeaseByPeerID, arg.PeerID, arg.TenantID)
 	var i GetLeaseByPeerIDRow
 	err := row.Scan(
 		&i.TokenID,
@@ -142,16 +603,22 @@ func (q *Queries) GetLeaseByPeerID(ctx context.Context, peerID string) (GetLease
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Ttl,
+		&i.Labels,
 	)
 	return i, err
 }
 
 const getLeaseByTokenID = `-- name: GetLeaseByTokenID :one
-SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
+SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels
 FROM leases
-WHERE token_id = $1 AND expires_at > now()
+WHERE token_id = $1 AND tenant_id = $2 AND expires_at > now()
 `
 
+type GetLeaseByTokenIDParams struct {
+	TokenID  int64
+	TenantID string
+}
+
 type GetLeaseByTokenIDRow struct {
 	TokenID   int64
 	PeerID    string
@@ -159,10 +626,11 @@ type GetLeaseByTokenIDRow struct {
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
 	Ttl       int32
+	Labels    []byte
 }
 
-func (q *Queries) GetLeaseByTokenID(ctx context.Context, tokenID int64) (GetLeaseByTokenIDRow, error) {
-	row := q.db.QueryRow(ctx, getLeaseByTokenID, tokenID)
+func (q *Queries) GetLeaseByTokenID(ctx context.Context, arg GetLeaseByTokenIDParams) (GetLeaseByTokenIDRow, error) {
+	row := q.db.QueryRow(ctx, getLeaseByTokenID, arg.TokenID, arg.TenantID)
 	var i GetLeaseByTokenIDRow
 	err := row.Scan(
 		&i.TokenID,
@@ -171,6 +639,114 @@ func (q *Queries) GetLeaseByTokenID(ctx context.Context, tokenID int64) (GetLeas
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Ttl,
+		&i.Labels,
+	)
+	return i, err
+}
+
+const getLeaseForUpdate = `-- name: GetLeaseForUpdate :one
+SELECT token_id, peer_id, expires_at, created_at, updated_at, reclaimed_at, quarantined_until, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
+FROM leases
+WHERE token_id = $1 AND tenant_id = $2
+FOR UPDATE
+`
+
+type GetLeaseForUpdateParams struct {
+	TokenID  int64
+	TenantID string
+}
+
+type GetLeaseForUpdateRow struct {
+	TokenID          int64
+	PeerID           string
+	ExpiresAt        pgtype.Timestamptz
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	ReclaimedAt      pgtype.Timestamptz
+	QuarantinedUntil pgtype.Timestamptz
+	Ttl              int32
+}
+
+func (q *Queries) GetLeaseForUpdate(ctx context.Context, arg GetLeaseForUpdateParams) (GetLeaseForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getLeaseForUpdate, arg.TokenID, arg.TenantID)
+	var i GetLeaseForUpdateRow
+	err := row.Scan(
+		&i.TokenID,
+		&i.PeerID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReclaimedAt,
+		&i.QuarantinedUntil,
+		&i.Ttl,
+	)
+	return i, err
+}
+
+const getLeaseHistory = `-- name: GetLeaseHistory :many
+SELECT id, token_id, peer_id, event, expires_at, occurred_at, tenant_id
+FROM lease_history
+WHERE token_id = $1 AND tenant_id = $2
+ORDER BY id
+`
+
+type GetLeaseHistoryParams struct {
+	TokenID  int64
+	TenantID string
+}
+
+func (q *Queries) GetLeaseHistory(ctx context.Context, arg GetLeaseHistoryParams) ([]LeaseHistory, error) {
+	rows, err := q.db.Query(ctx, getLeaseHistory, arg.TokenID, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LeaseHistory
+	for rows.Next() {
+		var i LeaseHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokenID,
+			&i.PeerID,
+			&i.Event,
+			&i.ExpiresAt,
+			&i.OccurredAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLeaseReadModelStats = `-- name: GetLeaseReadModelStats :one
+SELECT COUNT(*) FILTER (WHERE expires_at > now())::bigint AS active,
+       COUNT(*) FILTER (WHERE expires_at <= now())::bigint AS expired,
+       COUNT(*)::bigint AS total,
+       MAX(refreshed_at)::timestamptz AS refreshed_at
+FROM lease_read_model
+WHERE tenant_id = $1
+`
+
+type GetLeaseReadModelStatsRow struct {
+	Active      int64
+	Expired     int64
+	Total       int64
+	RefreshedAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetLeaseReadModelStats(ctx context.Context, tenantID string) (GetLeaseReadModelStatsRow, error) {
+	row := q.db.QueryRow(ctx, getLeaseReadModelStats, tenantID)
+	var i GetLeaseReadModelStatsRow
+	err := row.Scan(
+		&i.Active,
+		&i.Expired,
+		&i.Total,
+		&i.RefreshedAt,
 	)
 	return i, err
 }
@@ -194,16 +770,109 @@ func (q *Queries) GetNonce(ctx context.Context, id pgtype.UUID) (Nonce, error) {
 	return i, err
 }
 
+const insertAPIKey = `-- name: InsertAPIKey :one
+INSERT INTO api_keys (name, key_prefix, key_hash, scopes)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (key_hash) DO NOTHING
+RETURNING id, name, key_prefix, key_hash, scopes, created_at, last_used_at, revoked_at
+`
+
+type InsertAPIKeyParams struct {
+	Name      string
+	KeyPrefix string
+	KeyHash   string
+	Scopes    []string
+}
+
+func (q *Queries) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, insertAPIKey,
+		arg.Name,
+		arg.KeyPrefix,
+		arg.KeyHash,
+		arg.Scopes,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const insertAccessRule = `-- name: InsertAccessRule :one
+INSERT INTO access_rules (list, subject_type, subject, reason)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (subject_type, subject, list) DO NOTHING
+RETURNING id, list, subject_type, subject, reason, created_at
+`
+
+type InsertAccessRuleParams struct {
+	List        string
+	SubjectType string
+	Subject     string
+	Reason      string
+}
+
+func (q *Queries) InsertAccessRule(ctx context.Context, arg InsertAccessRuleParams) (AccessRule, error) {
+	row := q.db.QueryRow(ctx, insertAccessRule,
+		arg.List,
+		arg.SubjectType,
+		arg.Subject,
+		arg.Reason,
+	)
+	var i AccessRule
+	err := row.Scan(
+		&i.ID,
+		&i.List,
+		&i.SubjectType,
+		&i.Subject,
+		&i.Reason,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertAllocState = `-- name: InsertAllocState :exec
+INSERT INTO alloc_state (tenant_id, last_token_id, min_token_id, max_token_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (tenant_id) DO NOTHING
+`
+
+type InsertAllocStateParams struct {
+	TenantID    string
+	LastTokenID int64
+	MinTokenID  int64
+	MaxTokenID  int64
+}
+
+func (q *Queries) InsertAllocState(ctx context.Context, arg InsertAllocStateParams) error {
+	_, err := q.db.Exec(ctx, insertAllocState,
+		arg.TenantID,
+		arg.LastTokenID,
+		arg.MinTokenID,
+		arg.MaxTokenID,
+	)
+	return err
+}
+
 const insertLease = `-- name: InsertLease :one
-INSERT INTO leases (token_id, peer_id, expires_at, created_at, updated_at)
-VALUES ($1, $2, now() + ($3::int * interval '1 minute'), now(), now())
+INSERT INTO leases (token_id, peer_id, tenant_id, expires_at, created_at, updated_at)
+VALUES ($1, $2, $3, now() + ($4::int * interval '1 minute'), now(), now())
+ON CONFLICT (token_id) DO NOTHING
 RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
 `
 
 type InsertLeaseParams struct {
-	TokenID int64
-	PeerID  string
-	Ttl     int32
+	TokenID  int64
+	PeerID   string
+	TenantID string
+	Ttl      int32
 }
 
 type InsertLeaseRow struct {
@@ -216,7 +885,12 @@ type InsertLeaseRow struct {
 }
 
 func (q *Queries) InsertLease(ctx context.Context, arg InsertLeaseParams) (InsertLeaseRow, error) {
-	row := q.db.QueryRow(ctx, insertLease, arg.TokenID, arg.PeerID, arg.Ttl)
+	row := q.db.QueryRow(ctx, insertLease,
+		arg.TokenID,
+		arg.PeerID,
+		arg.TenantID,
+		arg.Ttl,
+	)
 	var i InsertLeaseRow
 	err := row.Scan(
 		&i.TokenID,
@@ -229,34 +903,586 @@ func (q *Queries) InsertLease(ctx context.Context, arg InsertLeaseParams) (Inser
 	return i, err
 }
 
-const releaseLease = `-- name: ReleaseLease :exec
+const insertLeaseHistory = `-- name: InsertLeaseHistory :exec
+INSERT INTO lease_history (token_id, peer_id, tenant_id, event, expires_at, occurred_at)
+VALUES ($1, $2, $3, $4, $5, COALESCE($6::timestamptz, now()))
+`
+
+type InsertLeaseHistoryParams struct {
+	TokenID    int64
+	PeerID     string
+	TenantID   string
+	Event      string
+	ExpiresAt  pgtype.Timestamptz
+	OccurredAt pgtype.Timestamptz
+}
+
+func (q *Queries) InsertLeaseHistory(ctx context.Context, arg InsertLeaseHistoryParams) error {
+	_, err := q.db.Exec(ctx, insertLeaseHistory,
+		arg.TokenID,
+		arg.PeerID,
+		arg.TenantID,
+		arg.Event,
+		arg.ExpiresAt,
+		arg.OccurredAt,
+	)
+	return err
+}
+
+const insertPeerPolicy = `-- name: InsertPeerPolicy :one
+INSERT INTO peer_policies (name, peers, can_allocate, can_reserve, max_pool)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (name) DO NOTHING
+RETURNING id, name, peers, can_allocate, can_reserve, max_pool, created_at
+`
+
+type InsertPeerPolicyParams struct {
+	Name        string
+	Peers       []string
+	CanAllocate bool
+	CanReserve  bool
+	MaxPool     int32
+}
+
+func (q *Queries) InsertPeerPolicy(ctx context.Context, arg InsertPeerPolicyParams) (PeerPolicy, error) {
+	row := q.db.QueryRow(ctx, insertPeerPolicy,
+		arg.Name,
+		arg.Peers,
+		arg.CanAllocate,
+		arg.CanReserve,
+		arg.MaxPool,
+	)
+	var i PeerPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Peers,
+		&i.CanAllocate,
+		&i.CanReserve,
+		&i.MaxPool,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT id, name, key_prefix, key_hash, scopes, created_at, last_used_at, revoked_at
+FROM api_keys
+ORDER BY id
+`
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]ApiKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.KeyPrefix,
+			&i.KeyHash,
+			&i.Scopes,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccessRules = `-- name: ListAccessRules :many
+SELECT id, list, subject_type, subject, reason, created_at
+FROM access_rules
+WHERE $1::text = '' OR list = $1::text
+ORDER BY id
+`
+
+func (q *Queries) ListAccessRules(ctx context.Context, list string) ([]AccessRule, error) {
+	rows, err := q.db.Query(ctx, listAccessRules, list)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccessRule
+	for rows.Next() {
+		var i AccessRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.List,
+			&i.SubjectType,
+			&i.Subject,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllLeases = `-- name: ListAllLeases :many
+SELECT token_id, peer_id, expires_at, created_at, updated_at, affinity_group, reclaimed_at, quarantined_until, tenant_id, delegated_by, labels
+FROM leases
+ORDER BY tenant_id, token_id
+`
+
+func (q *Queries) ListAllLeases(ctx context.Context) ([]Lease, error) {
+	rows, err := q.db.Query(ctx, listAllLeases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Lease
+	for rows.Next() {
+		var i Lease
+		if err := rows.Scan(
+			&i.TokenID,
+			&i.PeerID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AffinityGroup,
+			&i.ReclaimedAt,
+			&i.QuarantinedUntil,
+			&i.TenantID,
+			&i.DelegatedBy,
+			&i.Labels,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllocStates = `-- name: ListAllocStates :many
+SELECT id, last_token_id, max_token_id, min_token_id, tenant_id
+FROM alloc_state
+ORDER BY id
+`
+
+func (q *Queries) ListAllocStates(ctx context.Context) ([]AllocState, error) {
+	rows, err := q.db.Query(ctx, listAllocStates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AllocState
+	for rows.Next() {
+		var i AllocState
+		if err := rows.Scan(
+			&i.ID,
+			&i.LastTokenID,
+			&i.MaxTokenID,
+			&i.MinTokenID,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDuplicateActiveLeases = `-- name: ListDuplicateActiveLeases :many
+SELECT token_id, peer_id, tenant_id, expires_at, updated_at
+FROM leases
+WHERE expires_at > now() AND (tenant_id, peer_id) IN (
+    SELECT tenant_id, peer_id FROM leases WHERE expires_at > now() GROUP BY tenant_id, peer_id HAVING COUNT(*) > 1
+)
+ORDER BY tenant_id, peer_id, updated_at DESC, token_id DESC
+`
+
+type ListDuplicateActiveLeasesRow struct {
+	TokenID   int64
+	PeerID    string
+	TenantID  string
+	ExpiresAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListDuplicateActiveLeases(ctx context.Context) ([]ListDuplicateActiveLeasesRow, error) {
+	rows, err := q.db.Query(ctx, listDuplicateActiveLeases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDuplicateActiveLeasesRow
+	for rows.Next() {
+		var i ListDuplicateActiveLeasesRow
+		if err := rows.Scan(
+			&i.TokenID,
+			&i.PeerID,
+			&i.TenantID,
+			&i.ExpiresAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiringLeases = `-- name: ListExpiringLeases :many
+SELECT token_id, peer_id, tenant_id, expires_at
+FROM leases
+WHERE expires_at > now()
+  AND expires_at <= now() + ($1::int * interval '1 second')
+  AND token_id > $2
+ORDER BY token_id
+LIMIT $3
+`
+
+type ListExpiringLeasesParams struct {
+	Within       int32
+	AfterTokenID int64
+	BatchSize    int32
+}
+
+type ListExpiringLeasesRow struct {
+	TokenID   int64
+	PeerID    string
+	TenantID  string
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListExpiringLeases(ctx context.Context, arg ListExpiringLeasesParams) ([]ListExpiringLeasesRow, error) {
+	rows, err := q.db.Query(ctx, listExpiringLeases, arg.Within, arg.AfterTokenID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpiringLeasesRow
+	for rows.Next() {
+		var i ListExpiringLeasesRow
+		if err := rows.Scan(
+			&i.TokenID,
+			&i.PeerID,
+			&i.TenantID,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutstandingNonces = `-- name: ListOutstandingNonces :many
+SELECT id, peer_id, issued_at, expires_at, used, used_at FROM nonces
+WHERE peer_id = $1 AND used = false AND expires_at > now()
+ORDER BY issued_at DESC
+`
+
+func (q *Queries) ListOutstandingNonces(ctx context.Context, peerID string) ([]Nonce, error) {
+	rows, err := q.db.Query(ctx, listOutstandingNonces, peerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Nonce
+	for rows.Next() {
+		var i Nonce
+		if err := rows.Scan(
+			&i.ID,
+			&i.PeerID,
+			&i.IssuedAt,
+			&i.ExpiresAt,
+			&i.Used,
+			&i.UsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPeerPolicies = `-- name: ListPeerPolicies :many
+SELECT id, name, peers, can_allocate, can_reserve, max_pool, created_at
+FROM peer_policies
+ORDER BY id
+`
+
+func (q *Queries) ListPeerPolicies(ctx context.Context) ([]PeerPolicy, error) {
+	rows, err := q.db.Query(ctx, listPeerPolicies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PeerPolicy
+	for rows.Next() {
+		var i PeerPolicy
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Peers,
+			&i.CanAllocate,
+			&i.CanReserve,
+			&i.MaxPool,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReclaimCandidates = `-- name: ListReclaimCandidates :many
+SELECT token_id, peer_id, tenant_id, affinity_group, expires_at, updated_at
+FROM leases
+WHERE expires_at < now() AND reclaimed_at IS NULL AND token_id > $1
+ORDER BY token_id
+LIMIT $2
+`
+
+type ListReclaimCandidatesParams struct {
+	AfterTokenID int64
+	BatchSize    int32
+}
+
+type ListReclaimCandidatesRow struct {
+	TokenID       int64
+	PeerID        string
+	TenantID      string
+	AffinityGroup pgtype.Text
+	ExpiresAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
+func (q *Queries) ListReclaimCandidates(ctx context.Context, arg ListReclaimCandidatesParams) ([]ListReclaimCandidatesRow, error) {
+	rows, err := q.db.Query(ctx, listReclaimCandidates, arg.AfterTokenID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReclaimCandidatesRow
+	for rows.Next() {
+		var i ListReclaimCandidatesRow
+		if err := rows.Scan(
+			&i.TokenID,
+			&i.PeerID,
+			&i.TenantID,
+			&i.AffinityGroup,
+			&i.ExpiresAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsedNoncesWithoutUsedAt = `-- name: ListUsedNoncesWithoutUsedAt :many
+SELECT id, peer_id
+FROM nonces
+WHERE used = true AND used_at IS NULL
+ORDER BY id
+`
+
+type ListUsedNoncesWithoutUsedAtRow struct {
+	ID     pgtype.UUID
+	PeerID string
+}
+
+func (q *Queries) ListUsedNoncesWithoutUsedAt(ctx context.Context) ([]ListUsedNoncesWithoutUsedAtRow, error) {
+	rows, err := q.db.Query(ctx, listUsedNoncesWithoutUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsedNoncesWithoutUsedAtRow
+	for rows.Next() {
+		var i ListUsedNoncesWithoutUsedAtRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PeerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockLeaseState = `-- name: LockLeaseState :exec
+LOCK TABLE leases, alloc_state IN EXCLUSIVE MODE
+`
+
+func (q *Queries) LockLeaseState(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockLeaseState)
+	return err
+}
+
+const lockPeerLeases = `-- name: LockPeerLeases :exec
+SELECT pg_advisory_xact_lock(hashtext('leases'), hashtext($1::text || ':' || $2::text))
+`
+
+type LockPeerLeasesParams struct {
+	TenantID string
+	PeerID   string
+}
+
+func (q *Queries) LockPeerLeases(ctx context.Context, arg LockPeerLeasesParams) error {
+	_, err := q.db.Exec(ctx, lockPeerLeases, arg.TenantID, arg.PeerID)
+	return err
+}
+
+const notifyLeaseDelta = `-- name: NotifyLeaseDelta :exec
+SELECT pg_notify('dhcp2p_lease_deltas', $1::text)
+`
+
+func (q *Queries) NotifyLeaseDelta(ctx context.Context, payload string) error {
+	_, err := q.db.Exec(ctx, notifyLeaseDelta, payload)
+	return err
+}
+
+const quarantineLease = `-- name: QuarantineLease :one
+UPDATE leases
+SET expires_at = now(),
+    updated_at = now(),
+    quarantined_until = now() + ($2::int * interval '1 second')
+WHERE token_id = $1
+RETURNING expires_at, quarantined_until
+`
+
+type QuarantineLeaseParams struct {
+	TokenID    int64
+	Quarantine int32
+}
+
+type QuarantineLeaseRow struct {
+	ExpiresAt        pgtype.Timestamptz
+	QuarantinedUntil pgtype.Timestamptz
+}
+
+func (q *Queries) QuarantineLease(ctx context.Context, arg QuarantineLeaseParams) (QuarantineLeaseRow, error) {
+	row := q.db.QueryRow(ctx, quarantineLease, arg.TokenID, arg.Quarantine)
+	var i QuarantineLeaseRow
+	err := row.Scan(&i.ExpiresAt, &i.QuarantinedUntil)
+	return i, err
+}
+
+const reclaimLeases = `-- name: ReclaimLeases :many
 UPDATE leases
-SET expires_at = now()
-WHERE token_id = $1 AND peer_id = $2
+SET reclaimed_at = now()
+WHERE token_id = ANY($1::bigint[]) AND expires_at < now() AND reclaimed_at IS NULL
+RETURNING token_id
 `
 
-type ReleaseLeaseParams struct {
-	TokenID int64
-	PeerID  string
+func (q *Queries) ReclaimLeases(ctx context.Context, tokenIds []int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, reclaimLeases, tokenIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var token_id int64
+		if err := rows.Scan(&token_id); err != nil {
+			return nil, err
+		}
+		items = append(items, token_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) ReleaseLease(ctx context.Context, arg ReleaseLeaseParams) error {
-	_, err := q.db.Exec(ctx, releaseLease, arg.TokenID, arg.PeerID)
+const refreshLeaseReadModel = `-- name: RefreshLeaseReadModel :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY lease_read_model
+`
+
+func (q *Queries) RefreshLeaseReadModel(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, refreshLeaseReadModel)
 	return err
 }
 
+const releaseLeaderLock = `-- name: ReleaseLeaderLock :exec
+SELECT pg_advisory_unlock(hashtext('leader'), hashtext($1::text))
+`
+
+func (q *Queries) ReleaseLeaderLock(ctx context.Context, name string) error {
+	_, err := q.db.Exec(ctx, releaseLeaderLock, name)
+	return err
+}
+
+const releaseLease = `-- name: ReleaseLease :one
+UPDATE leases
+SET expires_at = now(),
+    updated_at = now()
+WHERE token_id = $1 AND peer_id = $2 AND tenant_id = $3
+RETURNING expires_at
+`
+
+type ReleaseLeaseParams struct {
+	TokenID  int64
+	PeerID   string
+	TenantID string
+}
+
+func (q *Queries) ReleaseLease(ctx context.Context, arg ReleaseLeaseParams) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, releaseLease, arg.TokenID, arg.PeerID, arg.TenantID)
+	var expires_at pgtype.Timestamptz
+	err := row.Scan(&expires_at)
+	return expires_at, err
+}
+
 const renewLease = `-- name: RenewLease :one
 UPDATE leases
 SET expires_at = now() + ($3::int * interval '1 minute'),
     updated_at = now()
-WHERE token_id = $1 AND peer_id = $2 AND expires_at > now()
-RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
+WHERE token_id = $1 AND peer_id = $2 AND tenant_id = $4 AND expires_at > now()
+RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels
 `
 
 type RenewLeaseParams struct {
-	TokenID int64
-	PeerID  string
-	Ttl     int32
+	TokenID  int64
+	PeerID   string
+	Ttl      int32
+	TenantID string
 }
 
 type RenewLeaseRow struct {
@@ -266,10 +1492,16 @@ type RenewLeaseRow struct {
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
 	Ttl       int32
+	Labels    []byte
 }
 
 func (q *Queries) RenewLease(ctx context.Context, arg RenewLeaseParams) (RenewLeaseRow, error) {
-	row := q.db.QueryRow(ctx, renewLease, arg.TokenID, arg.PeerID, arg.Ttl)
+	row := q.db.QueryRow(ctx, renewLease,
+		arg.TokenID,
+		arg.PeerID,
+		arg.Ttl,
+		arg.TenantID,
+	)
 	var i RenewLeaseRow
 	err := row.Scan(
 		&i.TokenID,
@@ -278,15 +1510,94 @@ func (q *Queries) RenewLease(ctx context.Context, arg RenewLeaseParams) (RenewLe
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Ttl,
+		&i.Labels,
 	)
 	return i, err
 }
 
+const repairNonceUsedAt = `-- name: RepairNonceUsedAt :execrows
+UPDATE nonces
+SET used_at = issued_at
+WHERE used = true AND used_at IS NULL
+`
+
+func (q *Queries) RepairNonceUsedAt(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, repairNonceUsedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reserveTokenIDs = `-- name: ReserveTokenIDs :one
+WITH reserved AS (
+    SELECT last_token_id FROM alloc_state WHERE tenant_id = $1 FOR UPDATE
+)
+UPDATE alloc_state
+SET last_token_id = LEAST(alloc_state.last_token_id + $2::bigint, alloc_state.max_token_id)
+FROM reserved
+WHERE alloc_state.tenant_id = $1 AND alloc_state.last_token_id < alloc_state.max_token_id
+RETURNING (reserved.last_token_id + 1)::bigint AS first_token_id, alloc_state.last_token_id
+`
+
+type ReserveTokenIDsParams struct {
+	TenantID string
+	Count    int64
+}
+
+type ReserveTokenIDsRow struct {
+	FirstTokenID int64
+	LastTokenID  int64
+}
+
+func (q *Queries) ReserveTokenIDs(ctx context.Context, arg ReserveTokenIDsParams) (ReserveTokenIDsRow, error) {
+	row := q.db.QueryRow(ctx, reserveTokenIDs, arg.TenantID, arg.Count)
+	var i ReserveTokenIDsRow
+	err := row.Scan(&i.FirstTokenID, &i.LastTokenID)
+	return i, err
+}
+
+const resetAllocStatesExcept = `-- name: ResetAllocStatesExcept :exec
+UPDATE alloc_state
+SET last_token_id = min_token_id - 1
+WHERE NOT (tenant_id = ANY($1::text[]))
+`
+
+func (q *Queries) ResetAllocStatesExcept(ctx context.Context, tenantIds []string) error {
+	_, err := q.db.Exec(ctx, resetAllocStatesExcept, tenantIds)
+	return err
+}
+
+const returnTokenIDs = `-- name: ReturnTokenIDs :execrows
+UPDATE alloc_state
+SET last_token_id = $1::bigint - 1
+WHERE tenant_id = $2 AND last_token_id = $3::bigint
+`
+
+type ReturnTokenIDsParams struct {
+	FirstTokenID int64
+	TenantID     string
+	LastTokenID  int64
+}
+
+func (q *Queries) ReturnTokenIDs(ctx context.Context, arg ReturnTokenIDsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, returnTokenIDs, arg.FirstTokenID, arg.TenantID, arg.LastTokenID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const reuseLease = `-- name: ReuseLease :one
 UPDATE leases
 SET peer_id = $1,
     expires_at = now() + ($3::int * interval '1 minute'),
-    updated_at = now()
+    updated_at = now(),
+    affinity_group = NULL,
+    delegated_by = NULL,
+    labels = NULL,
+    reclaimed_at = NULL,
+    quarantined_until = NULL
 WHERE token_id = $2
 RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
 `
@@ -319,3 +1630,255 @@ func (q *Queries) ReuseLease(ctx context.Context, arg ReuseLeaseParams) (ReuseLe
 	)
 	return i, err
 }
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, now())
+WHERE id = $1
+RETURNING id, name, key_prefix, key_hash, scopes, created_at, last_used_at, revoked_at
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int64) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, revokeAPIKey, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const revokeLeases = `-- name: RevokeLeases :many
+UPDATE leases
+SET expires_at = now(),
+    updated_at = now()
+FROM unnest($1::bigint[], $2::text[]) AS revoked(token_id, peer_id)
+WHERE leases.token_id = revoked.token_id AND leases.peer_id = revoked.peer_id
+  AND leases.tenant_id = $3 AND leases.expires_at > now()
+RETURNING leases.token_id, leases.peer_id, leases.expires_at
+`
+
+type RevokeLeasesParams struct {
+	TokenIds []int64
+	PeerIds  []string
+	TenantID string
+}
+
+type RevokeLeasesRow struct {
+	TokenID   int64
+	PeerID    string
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) RevokeLeases(ctx context.Context, arg RevokeLeasesParams) ([]RevokeLeasesRow, error) {
+	rows, err := q.db.Query(ctx, revokeLeases, arg.TokenIds, arg.PeerIds, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RevokeLeasesRow
+	for rows.Next() {
+		var i RevokeLeasesRow
+		if err := rows.Scan(&i.TokenID, &i.PeerID, &i.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeNonce = `-- name: RevokeNonce :execrows
+DELETE FROM nonces
+WHERE id = $1 AND peer_id = $2 AND used = false AND expires_at > now()
+`
+
+type RevokeNonceParams struct {
+	ID     pgtype.UUID
+	PeerID string
+}
+
+func (q *Queries) RevokeNonce(ctx context.Context, arg RevokeNonceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeNonce, arg.ID, arg.PeerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setAllocStateLastTokenID = `-- name: SetAllocStateLastTokenID :exec
+UPDATE alloc_state
+SET last_token_id = $2
+WHERE id = $1
+`
+
+type SetAllocStateLastTokenIDParams struct {
+	ID          int32
+	LastTokenID int64
+}
+
+func (q *Queries) SetAllocStateLastTokenID(ctx context.Context, arg SetAllocStateLastTokenIDParams) error {
+	_, err := q.db.Exec(ctx, setAllocStateLastTokenID, arg.ID, arg.LastTokenID)
+	return err
+}
+
+const setLeaseAffinityGroup = `-- name: SetLeaseAffinityGroup :exec
+UPDATE leases
+SET affinity_group = $2
+WHERE token_id = $1 AND tenant_id = $3
+`
+
+type SetLeaseAffinityGroupParams struct {
+	TokenID       int64
+	AffinityGroup pgtype.Text
+	TenantID      string
+}
+
+func (q *Queries) SetLeaseAffinityGroup(ctx context.Context, arg SetLeaseAffinityGroupParams) error {
+	_, err := q.db.Exec(ctx, setLeaseAffinityGroup, arg.TokenID, arg.AffinityGroup, arg.TenantID)
+	return err
+}
+
+const setLeaseDelegator = `-- name: SetLeaseDelegator :exec
+UPDATE leases
+SET delegated_by = $2
+WHERE token_id = $1 AND tenant_id = $3
+`
+
+type SetLeaseDelegatorParams struct {
+	TokenID     int64
+	DelegatedBy pgtype.Text
+	TenantID    string
+}
+
+func (q *Queries) SetLeaseDelegator(ctx context.Context, arg SetLeaseDelegatorParams) error {
+	_, err := q.db.Exec(ctx, setLeaseDelegator, arg.TokenID, arg.DelegatedBy, arg.TenantID)
+	return err
+}
+
+const setLeaseLabels = `-- name: SetLeaseLabels :execrows
+UPDATE leases
+SET labels = $3
+WHERE token_id = $1 AND peer_id = $2 AND tenant_id = $4 AND expires_at > now()
+`
+
+type SetLeaseLabelsParams struct {
+	TokenID  int64
+	PeerID   string
+	Labels   []byte
+	TenantID string
+}
+
+func (q *Queries) SetLeaseLabels(ctx context.Context, arg SetLeaseLabelsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setLeaseLabels,
+		arg.TokenID,
+		arg.PeerID,
+		arg.Labels,
+		arg.TenantID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, id)
+	return err
+}
+
+const transferLease = `-- name: TransferLease :one
+UPDATE leases
+SET peer_id = $1,
+    updated_at = now()
+WHERE token_id = $2 AND tenant_id = $3 AND peer_id = $4 AND expires_at > now()
+RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels
+`
+
+type TransferLeaseParams struct {
+	ToPeerID   string
+	TokenID    int64
+	TenantID   string
+	FromPeerID string
+}
+
+type TransferLeaseRow struct {
+	TokenID   int64
+	PeerID    string
+	ExpiresAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	Ttl       int32
+	Labels    []byte
+}
+
+func (q *Queries) TransferLease(ctx context.Context, arg TransferLeaseParams) (TransferLeaseRow, error) {
+	row := q.db.QueryRow(ctx, transferLease,
+		arg.ToPeerID,
+		arg.TokenID,
+		arg.TenantID,
+		arg.FromPeerID,
+	)
+	var i TransferLeaseRow
+	err := row.Scan(
+		&i.TokenID,
+		&i.PeerID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Ttl,
+		&i.Labels,
+	)
+	return i, err
+}
+
+const tryLeaderLock = `-- name: TryLeaderLock :one
+SELECT pg_try_advisory_lock(hashtext('leader'), hashtext($1::text))
+`
+
+func (q *Queries) TryLeaderLock(ctx context.Context, name string) (bool, error) {
+	row := q.db.QueryRow(ctx, tryLeaderLock, name)
+	var pg_try_advisory_lock bool
+	err := row.Scan(&pg_try_advisory_lock)
+	return pg_try_advisory_lock, err
+}
+
+const upsertAllocState = `-- name: UpsertAllocState :exec
+INSERT INTO alloc_state (tenant_id, last_token_id, min_token_id, max_token_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (tenant_id) DO UPDATE
+SET last_token_id = EXCLUDED.last_token_id,
+    min_token_id = EXCLUDED.min_token_id,
+    max_token_id = EXCLUDED.max_token_id
+`
+
+type UpsertAllocStateParams struct {
+	TenantID    string
+	LastTokenID int64
+	MinTokenID  int64
+	MaxTokenID  int64
+}
+
+func (q *Queries) UpsertAllocState(ctx context.Context, arg UpsertAllocStateParams) error {
+	_, err := q.db.Exec(ctx, upsertAllocState,
+		arg.TenantID,
+		arg.LastTokenID,
+		arg.MinTokenID,
+		arg.MaxTokenID,
+	)
+	return err
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/faults"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// SQLSTATE codes translated into domain errors
+const (
+	uniqueViolationCode      = "23505"
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+	lockNotAvailableCode     = "55P03"
+	queryCanceledCode        = "57014" // statement_timeout and lock_timeout
+	connectionExceptionClass = "08"
+	connectionFailureCode    = "08006"
+	adminShutdownCode        = "57P01"
+	cannotConnectNowCode     = "57P03"
+)
+
+// isUniqueViolation reports whether err is a unique constraint violation. Allocations check
+// for existing leases before writing, so one only surfaces when a concurrent allocation
+// slipped past those checks.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// TranslateError converts a pgx error into the domain error callers can act on, so
+// handlers answer with an accurate status instead of a 500. pgx.ErrNoRows becomes
+// notFound. Other translated errors wrap both the domain error and the driver error,
+// which keeps the driver error available to retry classification and logs. Domain
+// errors and errors without a translation are returned unchanged.
+func TranslateError(err error, notFound error) error {
+	if err == nil || domainErrors.IsAppError(err) {
+		return err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return notFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == uniqueViolationCode:
+			return fmt.Errorf("%w: %w", domainErrors.ErrDuplicateRecord, err)
+		case pgErr.Code == serializationFailureCode, pgErr.Code == deadlockDetectedCode, pgErr.Code == lockNotAvailableCode:
+			return fmt.Errorf("%w: %w", domainErrors.ErrConcurrentUpdate, err)
+		case pgErr.Code == queryCanceledCode:
+			return fmt.Errorf("%w: %w", domainErrors.ErrRequestTimeout, err)
+		case pgErr.Code == adminShutdownCode, pgErr.Code == cannotConnectNowCode, strings.HasPrefix(pgErr.Code, connectionExceptionClass):
+			return fmt.Errorf("%w: %w", domainErrors.ErrDatabaseConnection, err)
+		}
+		return err
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	if pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", domainErrors.ErrRequestTimeout, err)
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) || pgconn.SafeToRetry(err) {
+		return fmt.Errorf("%w: %w", domainErrors.ErrDatabaseConnection, err)
+	}
+	return err
+}
+
+// translate replaces *err with its translation. Deferred by the public repository methods,
+// which name their error result for it.
+func translate(err *error, notFound error) {
+	*err = TranslateError(*err, notFound)
+}
+
+// injectedError reports an injected fault as a connection failure, which TranslateError
+// turns into ErrDatabaseConnection like a real outage
+func injectedError(err error) error {
+	if !errors.Is(err, faults.ErrInjected) {
+		return err
+	}
+	return &pgconn.PgError{Severity: "FATAL", Code: connectionFailureCode, Message: err.Error()}
+}
@@ -0,0 +1,375 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// haLockName names the advisory lock held by the active instance
+	haLockName = "ha_active"
+	// leaseDeltaChannel is the channel lease changes are streamed on, as named in the
+	// NotifyLeaseDelta query
+	leaseDeltaChannel = "dhcp2p_lease_deltas"
+	// mirrorTimeout bounds the eviction of one lease change from the standby's cache
+	mirrorTimeout = 5 * time.Second
+)
+
+// HAController runs the instance as one of an active/standby pair sharing a database.
+// The active instance holds a session advisory lock, as the leader of LeaderElector does,
+// and advances the epoch in ha_state when it takes the lock.
+//
+// Lease writes read the epoch in their transaction, see LeaseRepository. A takeover waits
+// for the writes in flight and every later write of the former active instance fails,
+// so the two instances never change leases at the same time, even before the former
+// one notices it lost the lock.
+//
+// The active instance streams its lease changes with NOTIFY in the transaction of the
+// change. The standby evicts them from its cache, so it serves current leases from the
+// moment it takes over. alloc_state is read in every allocating transaction and needs
+// no mirroring.
+type HAController struct {
+	pool     *pgxpool.Pool
+	queries  *qDb.Queries
+	enabled  bool
+	instance string
+	interval time.Duration
+	mirror   ports.LeaseCache // nil without a cache
+	logger   *zap.Logger
+
+	mu     sync.Mutex    // serializes campaigns
+	conn   *pgxpool.Conn // holds the lock while active
+	active atomic.Bool
+	epoch  atomic.Int64 // term this instance was made active in
+
+	statusMu sync.Mutex
+	status   models.HAStatus
+
+	cancel context.CancelFunc // stops the campaigns and the mirroring
+	done   sync.WaitGroup
+}
+
+var (
+	_ ports.HAController  = &HAController{}
+	_ ports.LeaderElector = &HAController{}
+)
+
+// NewHAController creates the controller. Without active/standby mode the instance is
+// always active and nothing is fenced or streamed. mirror is optional.
+func NewHAController(lc fx.Lifecycle, cfg *config.AppConfig, pool *pgxpool.Pool, mirror ports.LeaseCache, logger *zap.Logger) (*HAController, error) {
+	c := &HAController{
+		pool:     pool,
+		queries:  qDb.New(pool),
+		enabled:  cfg.HAEnabled,
+		instance: cfg.HAInstance,
+		interval: time.Duration(cfg.HACheckInterval) * time.Second,
+		mirror:   mirror,
+		logger:   logger.With(zap.String("component", "ha")),
+	}
+
+	if !c.enabled {
+		c.active.Store(true)
+		return c, nil
+	}
+
+	if c.instance == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("ha_instance is not set and the host name is unknown: %w", err)
+		}
+		c.instance = hostname
+	}
+	c.status = models.HAStatus{Role: models.HARoleStandby, Instance: c.instance}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// Campaign before serving, so a sole instance is active right away
+			c.campaign(ctx)
+
+			runCtx, cancel := context.WithCancel(context.Background())
+			c.cancel = cancel
+			c.done.Add(2)
+			go c.run(runCtx)
+			go c.mirrorDeltas(runCtx)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			c.cancel()
+			c.done.Wait()
+			c.resign(ctx)
+			return nil
+		},
+	})
+
+	return c, nil
+}
+
+// Enabled reports whether the instance is one of an active/standby pair
+func (c *HAController) Enabled() bool {
+	return c != nil && c.enabled
+}
+
+func (c *HAController) IsActive() bool {
+	return c.active.Load()
+}
+
+// IsLeader lets the active instance run the maintenance jobs
+func (c *HAController) IsLeader() bool {
+	return c.IsActive()
+}
+
+func (c *HAController) Status() models.HAStatus {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+
+	status := c.status
+	if c.IsActive() {
+		status.Role = models.HARoleActive
+		status.Epoch = c.epoch.Load()
+	} else {
+		status.Role = models.HARoleStandby
+	}
+	return status
+}
+
+// fence fails with ErrNotActive unless this instance is active in the current epoch. It
+// keeps the epoch locked until the transaction of q ends, so a takeover waits for it.
+func (c *HAController) fence(ctx context.Context, q *qDb.Queries) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if !c.IsActive() {
+		return domainErrors.ErrNotActive
+	}
+
+	epoch, err := q.GetHAEpochForShare(ctx)
+	if err != nil {
+		return err
+	}
+	if epoch != c.epoch.Load() {
+		c.logger.Warn("Lease write fenced off, another instance took over", zap.Int64("epoch", c.epoch.Load()), zap.Int64("currentEpoch", epoch))
+		return domainErrors.ErrNotActive
+	}
+	return nil
+}
+
+// notify streams a lease change to the standby when the transaction of q commits
+func (c *HAController) notify(ctx context.Context, q *qDb.Queries, event models.LeaseEvent, tokenID int64, peerID string) error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	payload, err := json.Marshal(&models.LeaseDelta{
+		Epoch:    c.epoch.Load(),
+		TenantID: models.TenantFromContext(ctx),
+		TokenID:  tokenID,
+		PeerID:   peerID,
+		Event:    event,
+	})
+	if err != nil {
+		return err
+	}
+	return q.NotifyLeaseDelta(ctx, string(payload))
+}
+
+func (c *HAController) run(ctx context.Context) {
+	defer c.done.Done()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			campaignCtx, cancel := context.WithTimeout(ctx, c.interval)
+			c.campaign(campaignCtx)
+			cancel()
+		}
+	}
+}
+
+// campaign checks the lock connection of the active instance, or lets the standby try to
+// take over otherwise
+func (c *HAController) campaign(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		if err := c.conn.Ping(ctx); err != nil {
+			c.logger.Warn("Lost the active role, the lock connection failed", zap.Error(err))
+			c.drop(ctx)
+		}
+		return
+	}
+
+	defer c.refreshStatus(ctx)
+
+	conn, err := c.pool.Acquire(ctx)
+	if err != nil {
+		c.logger.Warn("Failed to acquire a connection for the active role", zap.Error(err))
+		return
+	}
+
+	locked, err := qDb.New(conn).TryLeaderLock(ctx, haLockName)
+	if err != nil {
+		c.logger.Warn("Failed to try the active lock", zap.Error(err))
+		conn.Release()
+		return
+	}
+	if !locked {
+		conn.Release()
+		return
+	}
+
+	// Advancing the epoch waits for the writes of the former active instance in flight
+	// and fences off the rest
+	epoch, err := qDb.New(conn).ActivateHAEpoch(ctx, c.instance)
+	if err != nil {
+		c.logger.Warn("Failed to advance the epoch, giving up the active lock", zap.Error(err))
+		conn.Conn().Close(ctx)
+		conn.Release()
+		return
+	}
+
+	c.conn = conn
+	c.epoch.Store(epoch)
+	c.active.Store(true)
+	c.logger.Info("Became the active instance", zap.String("instance", c.instance), zap.Int64("epoch", epoch))
+}
+
+// refreshStatus records which instance is active, as the standby sees it in ha_state
+func (c *HAController) refreshStatus(ctx context.Context) {
+	state, err := c.queries.GetHAState(ctx)
+	if err != nil {
+		c.logger.Debug("Failed to read ha_state", zap.Error(err))
+		return
+	}
+
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.ActiveInstance = state.ActiveInstance
+	c.status.ActivatedAt = state.ActivatedAt.Time
+	if !c.IsActive() {
+		c.status.Epoch = state.Epoch
+	}
+}
+
+// resign releases the lock so the standby can take over without waiting for this
+// connection to close
+func (c *HAController) resign(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return
+	}
+	c.active.Store(false)
+	if err := qDb.New(c.conn).ReleaseLeaderLock(ctx, haLockName); err != nil {
+		c.logger.Warn("Failed to release the active lock", zap.Error(err))
+		c.drop(ctx)
+		return
+	}
+
+	c.conn.Release()
+	c.conn = nil
+	c.logger.Info("Resigned the active role")
+}
+
+// drop closes the lock connection instead of returning it to the pool, where another
+// request would inherit a lock it might still hold
+func (c *HAController) drop(ctx context.Context) {
+	c.active.Store(false)
+	c.conn.Conn().Close(ctx)
+	c.conn.Release()
+	c.conn = nil
+}
+
+// mirrorDeltas listens for the lease changes of the active instance until ctx is done,
+// reconnecting after failures
+func (c *HAController) mirrorDeltas(ctx context.Context) {
+	defer c.done.Done()
+
+	for {
+		err := c.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("Lost the lease change stream, reconnecting", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.interval):
+		}
+	}
+}
+
+func (c *HAController) listen(ctx context.Context) error {
+	conn, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// A listening connection must not return to the pool
+	defer func() {
+		conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+leaseDeltaChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		c.apply(ctx, notification.Payload)
+	}
+}
+
+// apply evicts a lease change of the active instance from the standby's cache
+func (c *HAController) apply(ctx context.Context, payload string) {
+	if c.IsActive() {
+		// The change is this instance's own
+		return
+	}
+
+	var delta models.LeaseDelta
+	if err := json.Unmarshal([]byte(payload), &delta); err != nil {
+		c.logger.Warn("Ignoring a malformed lease change", zap.Error(err))
+		return
+	}
+
+	if c.mirror != nil {
+		mirrorCtx, cancel := context.WithTimeout(models.WithTenant(ctx, delta.TenantID), mirrorTimeout)
+		defer cancel()
+		if err := c.mirror.DeleteLease(mirrorCtx, delta.PeerID, delta.TokenID); err != nil {
+			c.logger.Warn("Failed to evict a mirrored lease change from the cache", zap.Int64("tokenID", delta.TokenID), zap.Error(err))
+		}
+	}
+
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.MirroredDeltas++
+	c.status.LastDeltaAt = time.Now()
+	if delta.Epoch > c.status.Epoch {
+		c.status.Epoch = delta.Epoch
+	}
+}
@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// IntegrityChecker verifies invariants that the schema cannot enforce on its own
+type IntegrityChecker struct {
+	pool *pgxpool.Pool
+}
+
+var _ ports.IntegrityChecker = &IntegrityChecker{}
+
+func NewIntegrityChecker(db *pgxpool.Pool) *IntegrityChecker {
+	return &IntegrityChecker{pool: db}
+}
+
+// Check runs all checks in one transaction, which is only committed when repairing
+func (c *IntegrityChecker) Check(ctx context.Context, repair bool) (*models.IntegrityReport, error) {
+	report := &models.IntegrityReport{Repair: repair, StartedAt: time.Now()}
+
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := qDb.New(tx)
+	if err := checkDuplicateActiveLeases(ctx, q, report.AddCheck(models.IntegrityCheckDuplicateActiveLeases), repair); err != nil {
+		return nil, err
+	}
+	if err := checkAllocState(ctx, q, report.AddCheck(models.IntegrityCheckAllocState), repair); err != nil {
+		return nil, err
+	}
+	if err := checkNonceUsedAt(ctx, q, report.AddCheck(models.IntegrityCheckNonceUsedAt), repair); err != nil {
+		return nil, err
+	}
+
+	if repair {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// checkDuplicateActiveLeases finds peers holding more than one active lease of a tenant.
+// The most recently updated lease is kept and the others are released.
+func checkDuplicateActiveLeases(ctx context.Context, q *qDb.Queries, result *models.IntegrityCheckResult, repair bool) error {
+	rows, err := q.ListDuplicateActiveLeases(ctx)
+	if err != nil {
+		return err
+	}
+
+	var keptTenant, kept string
+	for i, row := range rows {
+		// Rows are grouped by tenant and peer with the lease to keep first
+		if i == 0 || row.TenantID != keptTenant || row.PeerID != kept {
+			keptTenant, kept = row.TenantID, row.PeerID
+			continue
+		}
+
+		if repair {
+			if err := releaseLease(models.WithTenant(ctx, row.TenantID), q, nil, row.TokenID, row.PeerID); err != nil {
+				return err
+			}
+		}
+		result.Add(fmt.Sprintf("token:%d", row.TokenID), fmt.Sprintf("peer %s holds another active lease", row.PeerID), repair)
+	}
+	return nil
+}
+
+// checkAllocState verifies that the allocation cursor lies within the pool bounds
+func checkAllocState(ctx context.Context, q *qDb.Queries, result *models.IntegrityCheckResult, repair bool) error {
+	states, err := q.ListAllocStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, st := range states {
+		if st.TenantID == models.DefaultTenantID {
+			found = true
+		}
+		if models.AllocStateInBounds(st.MinTokenID, st.MaxTokenID, st.LastTokenID) {
+			continue
+		}
+
+		subject := fmt.Sprintf("alloc_state:%d", st.ID)
+		detail := fmt.Sprintf("last_token_id %d outside pool [%d, %d]", st.LastTokenID, st.MinTokenID, st.MaxTokenID)
+
+		highest, err := q.GetHighestLeasedTokenID(ctx, qDb.GetHighestLeasedTokenIDParams{MinTokenID: st.MinTokenID, MaxTokenID: st.MaxTokenID})
+		if err != nil {
+			return err
+		}
+		last, ok := models.AllocStateRepair(st.MinTokenID, st.MaxTokenID, st.LastTokenID, highest)
+		if !ok {
+			result.Add(subject, fmt.Sprintf("min_token_id %d is above max_token_id %d", st.MinTokenID, st.MaxTokenID), false)
+			continue
+		}
+
+		if repair {
+			if err := q.SetAllocStateLastTokenID(ctx, qDb.SetAllocStateLastTokenIDParams{ID: st.ID, LastTokenID: last}); err != nil {
+				return err
+			}
+			detail = fmt.Sprintf("%s, reset to %d", detail, last)
+		}
+		result.Add(subject, detail, repair)
+	}
+
+	if !found {
+		// The row is seeded by the migrations; allocation fails without it
+		result.Add("alloc_state:1", "allocation state row is missing", false)
+	}
+	return nil
+}
+
+// checkNonceUsedAt finds consumed nonces without a consumption time. The issue time
+// is the earliest the nonce can have been used, so it stands in for the missing value.
+func checkNonceUsedAt(ctx context.Context, q *qDb.Queries, result *models.IntegrityCheckResult, repair bool) error {
+	rows, err := q.ListUsedNoncesWithoutUsedAt(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if repair {
+		if _, err := q.RepairNonceUsedAt(ctx); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		result.Add("nonce:"+row.ID.String(), fmt.Sprintf("used nonce of peer %s has no used_at", row.PeerID), repair)
+	}
+	return nil
+}
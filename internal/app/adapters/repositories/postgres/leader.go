@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// leaderLockName names the advisory lock held by the leader
+const leaderLockName = "maintenance"
+
+// LeaderElector elects the replica that runs the maintenance jobs with a session advisory
+// lock. The leader holds the lock on a connection taken out of the pool and checks it every
+// interval. When the leader dies or its connection breaks, Postgres releases the lock and
+// the first replica to try next takes over, within one interval.
+type LeaderElector struct {
+	pool     *pgxpool.Pool
+	enabled  bool
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu     sync.Mutex    // serializes campaigns
+	conn   *pgxpool.Conn // holds the lock while leading
+	leader atomic.Bool
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+var _ ports.LeaderElector = &LeaderElector{}
+
+func NewLeaderElector(lc fx.Lifecycle, cfg *config.AppConfig, pool *pgxpool.Pool, logger *zap.Logger) *LeaderElector {
+	e := &LeaderElector{
+		pool:     pool,
+		enabled:  cfg.LeaderElectionEnabled,
+		interval: time.Duration(cfg.LeaderElectionInterval) * time.Second,
+		logger:   logger.With(zap.String("component", "leader_elector")),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if cfg.ReadOnly {
+		// A read-only replica never campaigns, leaving the jobs to the others
+		return e
+	}
+	if !e.enabled {
+		// Every replica runs the maintenance jobs
+		e.leader.Store(true)
+		return e
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// Campaign before the jobs start, so a sole replica runs them right away
+			e.campaign(ctx)
+			go e.run()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(e.stopCh)
+			<-e.done
+			e.resign(ctx)
+			return nil
+		},
+	})
+
+	return e
+}
+
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+func (e *LeaderElector) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+			e.campaign(ctx)
+			cancel()
+		}
+	}
+}
+
+// campaign checks the connection of a leader, or tries to take the lock otherwise
+func (e *LeaderElector) campaign(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		if err := e.conn.Ping(ctx); err != nil {
+			e.logger.Warn("Lost leadership, the lock connection failed", zap.Error(err))
+			e.drop(ctx)
+		}
+		return
+	}
+
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		e.logger.Warn("Failed to acquire a connection for leader election", zap.Error(err))
+		return
+	}
+
+	locked, err := qDb.New(conn).TryLeaderLock(ctx, leaderLockName)
+	if err != nil {
+		e.logger.Warn("Failed to try the leader lock", zap.Error(err))
+		conn.Release()
+		return
+	}
+	if !locked {
+		conn.Release()
+		return
+	}
+
+	e.conn = conn
+	e.leader.Store(true)
+	e.logger.Info("Acquired leadership, running maintenance jobs")
+}
+
+// resign releases the lock so another replica can take over without waiting for this
+// connection to close
+func (e *LeaderElector) resign(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return
+	}
+	if err := qDb.New(e.conn).ReleaseLeaderLock(ctx, leaderLockName); err != nil {
+		e.logger.Warn("Failed to release the leader lock", zap.Error(err))
+		e.drop(ctx)
+		return
+	}
+
+	e.conn.Release()
+	e.conn = nil
+	e.leader.Store(false)
+	e.logger.Info("Resigned leadership")
+}
+
+// drop closes the lock connection instead of returning it to the pool, where another
+// request would inherit a lock it might still hold
+func (e *LeaderElector) drop(ctx context.Context) {
+	e.leader.Store(false)
+	e.conn.Conn().Close(ctx)
+	e.conn.Release()
+	e.conn = nil
+}
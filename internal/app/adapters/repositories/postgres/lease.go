@@ -2,51 +2,136 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 )
 
+// zoneCandidates is how many free token IDs of a zone an allocation tries, more than one
+// only matters when concurrent allocations take them first
+const zoneCandidates = 8
+
 type LeaseRepository struct {
-	pool     *pgxpool.Pool
-	queries  *qDb.Queries
-	leaseTTL time.Duration
+	pool               *pgxpool.Pool
+	queries            *qDb.Queries
+	replicas           *ReplicaPools // serve lease lookups, nil reads from the primary
+	leaseTTL           atomic.Int64  // nanoseconds, replaced on reload
+	affinityProbeLimit int
+	reclaimedOnly      bool                          // only reuse expired leases reclaimed by a policy
+	releaseGrace       time.Duration                 // released and expired leases are withheld from reuse this long
+	maxLeasesPerPeer   int                           // active leases a peer may hold, 0 disables the quota
+	chunkSize          int                           // token IDs reserved at once, 1 or less takes them from alloc_state one by one
+	exclusions         map[string]models.TokenRanges // token IDs of each tenant's pool never handed out
+	zones              map[string]models.Zones       // ranges of each tenant's pool by zone
+	clock              ports.Clock                   // expiry checks made outside SQL, queries use now() of the database
+	ha                 *HAController                 // fences writes and streams them to the standby, nil without one
+
+	reservationsMu sync.Mutex
+	reservations   map[string]*tokenReservation // per tenant
 }
 
-var _ ports.LeaseRepository = &LeaseRepository{}
+var (
+	_ ports.LeaseRepository    = &LeaseRepository{}
+	_ ports.LeaseRenewalWriter = &LeaseRepository{}
+)
 
-func NewLeaseRepository(cfg *config.AppConfig, db *pgxpool.Pool) *LeaseRepository {
-	return &LeaseRepository{db, qDb.New(db), time.Duration(cfg.LeaseTTL) * time.Minute}
+func NewLeaseRepository(cfg *config.AppConfig, db *pgxpool.Pool, replicas *ReplicaPools, clock ports.Clock, ha *HAController) *LeaseRepository {
+	r := &LeaseRepository{
+		pool:               db,
+		queries:            qDb.New(db),
+		replicas:           replicas,
+		affinityProbeLimit: cfg.Lease.AffinityProbeLimit,
+		reclaimedOnly:      cfg.ReclaimEnabled && !cfg.ReclaimDryRun,
+		releaseGrace:       time.Duration(cfg.Lease.ReleaseGrace) * time.Second,
+		maxLeasesPerPeer:   cfg.Lease.MaxPerPeer,
+		chunkSize:          cfg.Lease.AllocationChunkSize,
+		exclusions:         cfg.TenantExclusions(),
+		zones:              cfg.TenantZones(),
+		reservations:       make(map[string]*tokenReservation),
+		clock:              clock,
+		ha:                 ha,
+	}
+	r.ApplyConfig(cfg)
+	return r
 }
 
-func (r *LeaseRepository) FindAndReuseExpiredLease(ctx context.Context, peerID string) (*models.Lease, error) {
+// ApplyConfig switches to a reloaded lease TTL, which applies from the next allocation
+// or renewal
+func (r *LeaseRepository) ApplyConfig(cfg *config.AppConfig) {
+	r.leaseTTL.Store(int64(time.Duration(cfg.Lease.TTL) * time.Minute))
+}
+
+func (r *LeaseRepository) ttl() time.Duration {
+	return time.Duration(r.leaseTTL.Load())
+}
+
+// begin starts a lease write transaction, failing with ErrNotActive on the standby
+func (r *LeaseRepository) begin(ctx context.Context) (pgx.Tx, error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := r.ha.fence(ctx, r.queries.WithTx(tx)); err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// exec runs a single statement write, in a fenced transaction in active/standby mode
+func (r *LeaseRepository) exec(ctx context.Context, write func(q *qDb.Queries) error) error {
+	if !r.ha.Enabled() {
+		return write(r.queries)
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := write(r.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *LeaseRepository) FindAndReuseExpiredLease(ctx context.Context, peerID string) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer tx.Rollback(ctx)
 
 	q := r.queries.WithTx(tx)
 
-	expired, err := q.FindExpiredLeaseForReuse(ctx)
+	if err := r.checkLeaseQuota(ctx, q, peerID); err != nil {
+		return nil, err
+	}
+
+	expired, err := q.FindExpiredLeaseForReuse(ctx, r.expiredLeaseParams(ctx))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	lease, err := q.ReuseLease(ctx, qDb.ReuseLeaseParams{
-		PeerID:  peerID,
-		TokenID: expired.TokenID,
-		Ttl:     int32(r.leaseTTL.Minutes()),
-	})
+	lease, err := r.reuseLease(ctx, q, expired.TokenID, expired.PeerID, expired.ExpiresAt, peerID)
 	if err != nil {
 		return nil, err
 	}
@@ -55,18 +140,12 @@ func (r *LeaseRepository) FindAndReuseExpiredLease(ctx context.Context, peerID s
 		return nil, err
 	}
 
-	return &models.Lease{
-		TokenID:   lease.TokenID,
-		PeerID:    lease.PeerID,
-		ExpiresAt: lease.ExpiresAt.Time,
-		CreatedAt: lease.CreatedAt.Time,
-		UpdatedAt: lease.UpdatedAt.Time,
-		Ttl:       lease.Ttl,
-	}, nil
+	return lease, nil
 }
 
-func (r *LeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (*models.Lease, error) {
-	tx, err := r.pool.Begin(ctx)
+func (r *LeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -74,24 +153,331 @@ func (r *LeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (
 
 	q := r.queries.WithTx(tx)
 
-	tokenID, err := q.AllocateNextTokenID(ctx)
+	if err := r.checkLeaseQuota(ctx, q, peerID); err != nil {
+		return nil, err
+	}
+
+	var lease *models.Lease
+	var tokenID int64
+	for {
+		tokenID, err = r.nextTokenID(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		if r.excluded(ctx, tokenID) {
+			continue
+		}
+
+		lease, err = r.insertLease(ctx, q, tokenID, peerID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Token ID was already handed out as a preferred token, advance the cursor
+			continue
+		}
+		if err != nil {
+			r.giveBackTokenID(ctx, tokenID)
+			return nil, err
+		}
+		break
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.giveBackTokenID(ctx, tokenID)
+		return nil, err
+	}
+
+	return lease, nil
+}
+
+// nextTokenID advances the allocation cursor of the tenant, or takes a token ID reserved
+// by this instance when lease.allocation_chunk_size is set
+func (r *LeaseRepository) nextTokenID(ctx context.Context, q *qDb.Queries) (int64, error) {
+	tenantID := models.TenantFromContext(ctx)
+	if reservation := r.reservation(tenantID); reservation != nil {
+		// Reserved on the pool, the block outlives this transaction
+		return reservation.take(ctx, r.queries)
+	}
+
+	tokenID, err := q.AllocateNextTokenID(ctx, tenantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Pool exhausted
+		return 0, domainErrors.ErrAllocationFailed
+	}
+	return tokenID, err
+}
+
+// excluded reports whether tokenID is excluded from the pool of the context's tenant. The
+// allocation cursor passes over excluded token IDs without handing them out.
+func (r *LeaseRepository) excluded(ctx context.Context, tokenID int64) bool {
+	return r.exclusions[models.TenantFromContext(ctx)].Contains(tokenID)
+}
+
+// expiredLeaseParams selects the expired leases of the context's tenant that may be
+// reused, leaving out those on token IDs excluded since they were leased
+func (r *LeaseRepository) expiredLeaseParams(ctx context.Context) qDb.FindExpiredLeaseForReuseParams {
+	params := qDb.FindExpiredLeaseForReuseParams{
+		TenantID:      models.TenantFromContext(ctx),
+		Grace:         int32(r.releaseGrace / time.Second),
+		ReclaimedOnly: r.reclaimedOnly,
+	}
+	for _, excluded := range r.exclusions[params.TenantID] {
+		params.ExcludedFirst = append(params.ExcludedFirst, excluded.FirstTokenID)
+		params.ExcludedLast = append(params.ExcludedLast, excluded.LastTokenID)
+	}
+	return params
+}
+
+// giveBackTokenID keeps a reserved token ID whose allocation failed for the next one.
+// Without a reservation the rolled back transaction already restored the cursor.
+func (r *LeaseRepository) giveBackTokenID(ctx context.Context, tokenID int64) {
+	if reservation := r.reservation(models.TenantFromContext(ctx)); reservation != nil {
+		reservation.giveBack(tokenID)
+	}
+}
+
+// reservation returns the token ID reservation of a tenant, nil when token IDs are taken
+// from alloc_state one by one
+func (r *LeaseRepository) reservation(tenantID string) *tokenReservation {
+	r.reservationsMu.Lock()
+	defer r.reservationsMu.Unlock()
+
+	reservation, ok := r.reservations[tenantID]
+	if !ok {
+		reservation = newTokenReservation(r.chunkSize, tenantID)
+		r.reservations[tenantID] = reservation
+	}
+	return reservation
+}
+
+// ReturnReservedTokenIDs hands the unused token IDs reserved by this instance back to
+// alloc_state, called on shutdown
+func (r *LeaseRepository) ReturnReservedTokenIDs(ctx context.Context) (err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	r.reservationsMu.Lock()
+	defer r.reservationsMu.Unlock()
+
+	var errs []error
+	for _, reservation := range r.reservations {
+		if reservation != nil {
+			errs = append(errs, reservation.returnUnused(ctx, r.queries))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AllocateRequestedLease assigns a specific token ID to the peer if it is inside the pool
+// and either has never been leased or its previous lease has expired (and been reclaimed,
+// when reclamation policies are enforced).
+func (r *LeaseRepository) AllocateRequestedLease(ctx context.Context, peerID string, tokenID int64) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	lease, err := q.InsertLease(ctx, qDb.InsertLeaseParams{
-		TokenID: tokenID,
-		PeerID:  peerID,
-		Ttl:     int32(r.leaseTTL.Minutes()),
-	})
+	q := r.queries.WithTx(tx)
+
+	state, err := q.GetAllocState(ctx, models.TenantFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
+	if tokenID < state.MinTokenID || tokenID > state.MaxTokenID || r.excluded(ctx, tokenID) {
+		return nil, domainErrors.ErrTokenIDOutOfRange
+	}
+
+	if err := r.checkLeaseQuota(ctx, q, peerID); err != nil {
+		return nil, err
+	}
+
+	var lease *models.Lease
+	existing, err := q.GetLeaseForUpdate(ctx, qDb.GetLeaseForUpdateParams{
+		TokenID:  tokenID,
+		TenantID: models.TenantFromContext(ctx),
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// Never leased before, insert a fresh lease
+		lease, err = r.insertLease(ctx, q, tokenID, peerID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Lost a race with a concurrent allocation of the same token ID
+			return nil, domainErrors.ErrTokenIDInUse
+		}
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case existing.ExpiresAt.Time.After(r.clock.Now()):
+		return nil, domainErrors.ErrTokenIDInUse
+	case r.reclaimedOnly && !existing.ReclaimedAt.Valid:
+		// Expired but not reclaimed by any policy yet
+		return nil, domainErrors.ErrTokenIDInUse
+	case existing.QuarantinedUntil.Valid && existing.QuarantinedUntil.Time.After(r.clock.Now()):
+		// Withheld after an address conflict
+		return nil, domainErrors.ErrTokenIDInUse
+	case existing.ExpiresAt.Time.After(r.clock.Now().Add(-r.releaseGrace)):
+		// Released or expired too recently, other nodes may still have the address cached
+		return nil, domainErrors.ErrTokenIDInUse
+	default:
+		// Previous lease expired, take it over
+		lease, err = r.reuseLease(ctx, q, tokenID, existing.PeerID, existing.ExpiresAt, peerID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
 
+	return lease, nil
+}
+
+// AllocateAffinityLease tries to place the peer next to the leases already held by its
+// affinity group so that members end up in one contiguous sub-range. Contiguity is best
+// effort: nil is returned when the group is empty or none of the probed token IDs is free.
+func (r *LeaseRepository) AllocateAffinityLease(ctx context.Context, peerID string, affinityGroup string) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	group := pgtype.Text{String: affinityGroup, Valid: true}
+	tenantID := models.TenantFromContext(ctx)
+
+	members, err := r.queries.GetAffinityGroupTokenIDs(ctx, qDb.GetAffinityGroupTokenIDsParams{
+		AffinityGroup: group,
+		TenantID:      tenantID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tokenID := range utils.AffinityCandidates(members, r.affinityProbeLimit) {
+		lease, err := r.AllocateRequestedLease(ctx, peerID, tokenID)
+		if errors.Is(err, domainErrors.ErrTokenIDInUse) || errors.Is(err, domainErrors.ErrTokenIDOutOfRange) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := r.exec(ctx, func(q *qDb.Queries) error {
+			return q.SetLeaseAffinityGroup(ctx, qDb.SetLeaseAffinityGroupParams{
+				TokenID:       lease.TokenID,
+				AffinityGroup: group,
+				TenantID:      tenantID,
+			})
+		}); err != nil {
+			return nil, err
+		}
+		return lease, nil
+	}
+
+	return nil, nil
+}
+
+// AllocateZoneLease assigns a free token ID of the zone. nil is returned when the zone is
+// unknown or has no free token ID left.
+func (r *LeaseRepository) AllocateZoneLease(ctx context.Context, peerID string, zone string) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	ranges := r.zones[models.TenantFromContext(ctx)][zone]
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+
+	expired := r.expiredLeaseParams(ctx)
+	params := qDb.FindFreeZoneTokenIDsParams{
+		TenantID:      expired.TenantID,
+		Grace:         expired.Grace,
+		ReclaimedOnly: expired.ReclaimedOnly,
+		ExcludedFirst: expired.ExcludedFirst,
+		ExcludedLast:  expired.ExcludedLast,
+		MaxCandidates: zoneCandidates,
+	}
+	for _, zoneRange := range ranges {
+		params.ZoneFirst = append(params.ZoneFirst, zoneRange.FirstTokenID)
+		params.ZoneLast = append(params.ZoneLast, zoneRange.LastTokenID)
+	}
+	candidates, err := r.queries.FindFreeZoneTokenIDs(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tokenID := range candidates {
+		lease, err := r.AllocateRequestedLease(ctx, peerID, tokenID)
+		if errors.Is(err, domainErrors.ErrTokenIDInUse) || errors.Is(err, domainErrors.ErrTokenIDOutOfRange) {
+			// Taken by a concurrent allocation since it was found
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return lease, nil
+	}
+
+	return nil, nil
+}
+
+func (r *LeaseRepository) SetLeaseAffinityGroup(ctx context.Context, tokenID int64, affinityGroup string) (err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	return r.exec(ctx, func(q *qDb.Queries) error {
+		return q.SetLeaseAffinityGroup(ctx, qDb.SetLeaseAffinityGroupParams{
+			TokenID:       tokenID,
+			AffinityGroup: pgtype.Text{String: affinityGroup, Valid: true},
+			TenantID:      models.TenantFromContext(ctx),
+		})
+	})
+}
+
+func (r *LeaseRepository) SetLeaseDelegator(ctx context.Context, tokenID int64, gatewayPeerID string) (err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	return r.exec(ctx, func(q *qDb.Queries) error {
+		return q.SetLeaseDelegator(ctx, qDb.SetLeaseDelegatorParams{
+			TokenID:     tokenID,
+			DelegatedBy: pgtype.Text{String: gatewayPeerID, Valid: true},
+			TenantID:    models.TenantFromContext(ctx),
+		})
+	})
+}
+
+func (r *LeaseRepository) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) (err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	return r.exec(ctx, func(q *qDb.Queries) error {
+		updated, err := q.SetLeaseLabels(ctx, qDb.SetLeaseLabelsParams{
+			TokenID:  tokenID,
+			PeerID:   peerID,
+			Labels:   encodeLabels(labels),
+			TenantID: models.TenantFromContext(ctx),
+		})
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			// Unknown token ID, expired lease or owned by another peer
+			return domainErrors.ErrLeaseNotFound
+		}
+		return nil
+	})
+}
+
+func (r *LeaseRepository) CountDelegatedLeases(ctx context.Context, gatewayPeerID string) (_ int64, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	return r.queries.CountDelegatedLeases(ctx, pgtype.Text{String: gatewayPeerID, Valid: true})
+}
+
+func (r *LeaseRepository) GetLeaseByTokenID(ctx context.Context, leaseID int64) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	var lease qDb.GetLeaseByTokenIDRow
+	err = r.replicas.Read(r.queries, func(q *qDb.Queries) (err error) {
+		lease, err = q.GetLeaseByTokenID(ctx, qDb.GetLeaseByTokenIDParams{
+			TokenID:  leaseID,
+			TenantID: models.TenantFromContext(ctx),
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainErrors.ErrLeaseNotFound
+		}
+		return nil, err
+	}
 	return &models.Lease{
 		TokenID:   lease.TokenID,
 		PeerID:    lease.PeerID,
@@ -99,12 +485,24 @@ func (r *LeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (
 		CreatedAt: lease.CreatedAt.Time,
 		UpdatedAt: lease.UpdatedAt.Time,
 		Ttl:       lease.Ttl,
+		Labels:    decodeLabels(lease.Labels),
 	}, nil
 }
 
-func (r *LeaseRepository) GetLeaseByTokenID(ctx context.Context, leaseID int64) (*models.Lease, error) {
-	lease, err := r.queries.GetLeaseByTokenID(ctx, leaseID)
+func (r *LeaseRepository) GetLeaseByPeerID(ctx context.Context, peerID string) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	var lease qDb.GetLeaseByPeerIDRow
+	err = r.replicas.Read(r.queries, func(q *qDb.Queries) (err error) {
+		lease, err = q.GetLeaseByPeerID(ctx, qDb.GetLeaseByPeerIDParams{
+			PeerID:   peerID,
+			TenantID: models.TenantFromContext(ctx),
+		})
+		return err
+	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainErrors.ErrLeaseNotFound
+		}
 		return nil, err
 	}
 	return &models.Lease{
@@ -114,14 +512,260 @@ func (r *LeaseRepository) GetLeaseByTokenID(ctx context.Context, leaseID int64)
 		CreatedAt: lease.CreatedAt.Time,
 		UpdatedAt: lease.UpdatedAt.Time,
 		Ttl:       lease.Ttl,
+		Labels:    decodeLabels(lease.Labels),
 	}, nil
 }
 
-func (r *LeaseRepository) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
-	lease, err := r.queries.GetLeaseByPeerID(ctx, peerID)
+func (r *LeaseRepository) RenewLease(ctx context.Context, tokenID int64, peerID string) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
+
+	lease, err := r.renewLease(ctx, r.queries.WithTx(tx), tokenID, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return lease, nil
+}
+
+// ApplyRenewals implements ports.LeaseRenewalWriter. Renewals are applied oldest first, so
+// batches journaled by different instances interleave correctly.
+func (r *LeaseRepository) ApplyRenewals(ctx context.Context, renewals []*models.LeaseRenewal) (_ int, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	ordered := slices.Clone(renewals)
+	slices.SortStableFunc(ordered, func(a, b *models.LeaseRenewal) int {
+		return a.RenewedAt.Compare(b.RenewedAt)
+	})
+
+	q := r.queries.WithTx(tx)
+	applied := 0
+	for _, renewal := range ordered {
+		expiresAt, err := q.ApplyLeaseRenewal(ctx, qDb.ApplyLeaseRenewalParams{
+			TokenID:   renewal.TokenID,
+			PeerID:    renewal.PeerID,
+			ExpiresAt: pgtype.Timestamptz{Time: renewal.ExpiresAt, Valid: true},
+			RenewedAt: pgtype.Timestamptz{Time: renewal.RenewedAt, Valid: true},
+			TenantID:  renewal.TenantID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		tenantCtx := models.WithTenant(ctx, renewal.TenantID)
+		if err := recordLeaseEvent(tenantCtx, q, r.ha, models.LeaseEventRenew, renewal.TokenID, renewal.PeerID, expiresAt); err != nil {
+			return 0, err
+		}
+		applied++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return applied, nil
+}
+
+func (r *LeaseRepository) ReleaseLease(ctx context.Context, tokenID int64, peerID string) (err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := releaseLease(ctx, r.queries.WithTx(tx), r.ha, tokenID, peerID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *LeaseRepository) ListReclaimCandidates(ctx context.Context, afterTokenID int64, limit int) (_ []*models.ReclaimCandidate, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	rows, err := r.queries.ListReclaimCandidates(ctx, qDb.ListReclaimCandidatesParams{
+		AfterTokenID: afterTokenID,
+		BatchSize:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*models.ReclaimCandidate, len(rows))
+	for i, row := range rows {
+		candidates[i] = &models.ReclaimCandidate{
+			TokenID:       row.TokenID,
+			PeerID:        row.PeerID,
+			TenantID:      row.TenantID,
+			AffinityGroup: row.AffinityGroup.String,
+			ExpiresAt:     row.ExpiresAt.Time,
+			UpdatedAt:     row.UpdatedAt.Time,
+		}
+	}
+	return candidates, nil
+}
+
+func (r *LeaseRepository) ListExpiringLeases(ctx context.Context, within time.Duration, afterTokenID int64, limit int) (_ []*models.ExpiringLease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	rows, err := r.queries.ListExpiringLeases(ctx, qDb.ListExpiringLeasesParams{
+		Within:       int32(within / time.Second),
+		AfterTokenID: afterTokenID,
+		BatchSize:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	leases := make([]*models.ExpiringLease, len(rows))
+	for i, row := range rows {
+		leases[i] = &models.ExpiringLease{
+			TokenID:   row.TokenID,
+			PeerID:    row.PeerID,
+			TenantID:  row.TenantID,
+			ExpiresAt: row.ExpiresAt.Time,
+		}
+	}
+	return leases, nil
+}
+
+// ReclaimLeases marks the given leases as reclaimed. Leases that were reused or reclaimed
+// concurrently are skipped and not returned.
+func (r *LeaseRepository) ReclaimLeases(ctx context.Context, tokenIDs []int64) (_ []int64, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+
+	var reclaimed []int64
+	err = r.exec(ctx, func(q *qDb.Queries) (err error) {
+		reclaimed, err = q.ReclaimLeases(ctx, tokenIDs)
+		return err
+	})
+	return reclaimed, err
+}
+
+func (r *LeaseRepository) RevokeLeases(ctx context.Context, leases []*models.Lease) (_ []int64, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := r.queries.WithTx(tx)
+	params := qDb.RevokeLeasesParams{
+		TokenIds: make([]int64, len(leases)),
+		PeerIds:  make([]string, len(leases)),
+		TenantID: models.TenantFromContext(ctx),
+	}
+	for i, lease := range leases {
+		params.TokenIds[i], params.PeerIds[i] = lease.TokenID, lease.PeerID
+	}
+	rows, err := q.RevokeLeases(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make([]int64, len(rows))
+	for i, row := range rows {
+		if err := recordLeaseEvent(ctx, q, r.ha, models.LeaseEventRelease, row.TokenID, row.PeerID, row.ExpiresAt); err != nil {
+			return nil, err
+		}
+		revoked[i] = row.TokenID
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+func (r *LeaseRepository) GetLeaseHistory(ctx context.Context, tokenID int64) (_ []*models.LeaseHistoryEntry, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	var rows []qDb.LeaseHistory
+	err = r.replicas.Read(r.queries, func(q *qDb.Queries) (err error) {
+		rows, err = q.GetLeaseHistory(ctx, qDb.GetLeaseHistoryParams{
+			TokenID:  tokenID,
+			TenantID: models.TenantFromContext(ctx),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*models.LeaseHistoryEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = &models.LeaseHistoryEntry{
+			TokenID:    row.TokenID,
+			PeerID:     row.PeerID,
+			Event:      models.LeaseEvent(row.Event),
+			ExpiresAt:  row.ExpiresAt.Time,
+			OccurredAt: row.OccurredAt.Time,
+		}
+	}
+	return entries, nil
+}
+
+// TransferLease reassigns an active lease owned by fromPeerID to toPeerID. The new
+// identity must not already hold an active lease of its own.
+func (r *LeaseRepository) TransferLease(ctx context.Context, tokenID int64, fromPeerID string, toPeerID string) (_ *models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := r.queries.WithTx(tx)
+
+	tenantID := models.TenantFromContext(ctx)
+
+	_, err = q.GetLeaseByPeerID(ctx, qDb.GetLeaseByPeerIDParams{PeerID: toPeerID, TenantID: tenantID})
+	if err == nil {
+		return nil, domainErrors.ErrLeaseAlreadyExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	lease, err := q.TransferLease(ctx, qDb.TransferLeaseParams{
+		ToPeerID:   toPeerID,
+		TokenID:    tokenID,
+		TenantID:   tenantID,
+		FromPeerID: fromPeerID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Unknown token ID, expired lease or owned by another peer
+			return nil, domainErrors.ErrLeaseNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, domainErrors.ErrLeaseAlreadyExists
+		}
+		return nil, err
+	}
+
+	if err := recordLeaseEvent(ctx, q, r.ha, models.LeaseEventTransfer, lease.TokenID, lease.PeerID, lease.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	return &models.Lease{
 		TokenID:   lease.TokenID,
 		PeerID:    lease.PeerID,
@@ -129,35 +773,354 @@ func (r *LeaseRepository) GetLeaseByPeerID(ctx context.Context, peerID string) (
 		CreatedAt: lease.CreatedAt.Time,
 		UpdatedAt: lease.UpdatedAt.Time,
 		Ttl:       lease.Ttl,
+		Labels:    decodeLabels(lease.Labels),
 	}, nil
 }
 
-func (r *LeaseRepository) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
-	lease, err := r.queries.RenewLease(ctx, qDb.RenewLeaseParams{
-		TokenID: tokenID,
-		PeerID:  peerID,
-		Ttl:     int32(r.leaseTTL.Minutes()),
+// RecordConflict records a conflict reported by the holder of the active lease and, with a
+// positive quarantine, releases the lease and withholds the token ID until it ends.
+func (r *LeaseRepository) RecordConflict(ctx context.Context, tokenID int64, peerID string, quarantine time.Duration) (_ *models.LeaseConflict, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := r.queries.WithTx(tx)
+
+	lease, err := q.GetLeaseForUpdate(ctx, qDb.GetLeaseForUpdateParams{
+		TokenID:  tokenID,
+		TenantID: models.TenantFromContext(ctx),
 	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainErrors.ErrLeaseNotFound
+		}
+		return nil, err
+	}
+	if lease.PeerID != peerID || !lease.ExpiresAt.Time.After(r.clock.Now()) {
+		// Only the current holder can report a conflict on the address
+		return nil, domainErrors.ErrLeaseNotFound
+	}
+
+	if err := recordLeaseEvent(ctx, q, r.ha, models.LeaseEventConflict, tokenID, peerID, lease.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	conflict := &models.LeaseConflict{TokenID: tokenID, PeerID: peerID, ReportedAt: r.clock.Now()}
+	if quarantine > 0 {
+		quarantined, err := q.QuarantineLease(ctx, qDb.QuarantineLeaseParams{
+			TokenID:    tokenID,
+			Quarantine: int32(quarantine / time.Second),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := recordLeaseEvent(ctx, q, r.ha, models.LeaseEventRelease, tokenID, peerID, quarantined.ExpiresAt); err != nil {
+			return nil, err
+		}
+		conflict.Quarantined = true
+		conflict.QuarantinedUntil = quarantined.QuarantinedUntil.Time
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return conflict, nil
+}
+
+// ExecuteBatch runs every operation inside a single transaction. Each item gets its own
+// savepoint so a failing item is rolled back and reported without aborting the others.
+func (r *LeaseRepository) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) (_ []*models.LeaseOperationResult, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
+
+	results := make([]*models.LeaseOperationResult, len(operations))
+	for i, op := range operations {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		lease, opErr := r.executeOperation(ctx, r.queries.WithTx(savepoint), op)
+		if opErr != nil {
+			if err := savepoint.Rollback(ctx); err != nil {
+				return nil, err
+			}
+		} else if err := savepoint.Commit(ctx); err != nil {
+			return nil, err
+		}
+
+		results[i] = &models.LeaseOperationResult{Lease: lease, Err: opErr}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *LeaseRepository) executeOperation(ctx context.Context, q *qDb.Queries, op *models.LeaseOperation) (*models.Lease, error) {
+	switch op.Type {
+	case models.LeaseOperationAllocate:
+		return r.allocateInTx(ctx, q, op.PeerID)
+	case models.LeaseOperationRenew:
+		lease, err := r.renewLease(ctx, q, op.TokenID, op.PeerID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, domainErrors.ErrLeaseNotFound
+			}
+			return nil, err
+		}
+		return lease, nil
+	case models.LeaseOperationRelease:
+		return nil, releaseLease(ctx, q, r.ha, op.TokenID, op.PeerID)
+	default:
+		return nil, domainErrors.ErrInvalidOperation
+	}
+}
+
+// allocateInTx mirrors LeaseService.AllocateIP inside a transaction: an existing lease is
+// returned as is, then expired leases are reused before new token IDs are handed out.
+func (r *LeaseRepository) allocateInTx(ctx context.Context, q *qDb.Queries, peerID string) (*models.Lease, error) {
+	tenantID := models.TenantFromContext(ctx)
+
+	existing, err := q.GetLeaseByPeerID(ctx, qDb.GetLeaseByPeerIDParams{PeerID: peerID, TenantID: tenantID})
+	if err == nil {
+		return &models.Lease{
+			TokenID:   existing.TokenID,
+			PeerID:    existing.PeerID,
+			ExpiresAt: existing.ExpiresAt.Time,
+			CreatedAt: existing.CreatedAt.Time,
+			UpdatedAt: existing.UpdatedAt.Time,
+			Ttl:       existing.Ttl,
+			Labels:    decodeLabels(existing.Labels),
+		}, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	if err := r.checkLeaseQuota(ctx, q, peerID); err != nil {
+		return nil, err
+	}
+
+	expired, err := q.FindExpiredLeaseForReuse(ctx, r.expiredLeaseParams(ctx))
+	switch {
+	case err == nil:
+		return r.reuseLease(ctx, q, expired.TokenID, expired.PeerID, expired.ExpiresAt, peerID)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	for {
+		tokenID, err := q.AllocateNextTokenID(ctx, tenantID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				// Pool exhausted
+				return nil, domainErrors.ErrAllocationFailed
+			}
+			return nil, err
+		}
+		if r.excluded(ctx, tokenID) {
+			continue
+		}
+
+		lease, err := r.insertLease(ctx, q, tokenID, peerID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Token ID was already handed out as a preferred token, advance the cursor
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return lease, nil
+	}
+}
+
+// insertLease leases a never leased token ID to peerID. pgx.ErrNoRows is returned when
+// the token ID is already taken.
+func (r *LeaseRepository) insertLease(ctx context.Context, q *qDb.Queries, tokenID int64, peerID string) (*models.Lease, error) {
+	inserted, err := q.InsertLease(ctx, qDb.InsertLeaseParams{
+		TokenID:  tokenID,
+		PeerID:   peerID,
+		TenantID: models.TenantFromContext(ctx),
+		Ttl:      int32(r.ttl().Minutes()),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domainErrors.ErrLeaseAlreadyExists
+		}
+		return nil, err
+	}
+
+	if err := recordLeaseEvent(ctx, q, r.ha, models.LeaseEventAllocate, inserted.TokenID, inserted.PeerID, inserted.ExpiresAt); err != nil {
+		return nil, err
+	}
+
 	return &models.Lease{
-		TokenID:   lease.TokenID,
-		PeerID:    lease.PeerID,
-		ExpiresAt: lease.ExpiresAt.Time,
-		CreatedAt: lease.CreatedAt.Time,
-		UpdatedAt: lease.UpdatedAt.Time,
-		Ttl:       lease.Ttl,
+		TokenID:   inserted.TokenID,
+		PeerID:    inserted.PeerID,
+		ExpiresAt: inserted.ExpiresAt.Time,
+		CreatedAt: inserted.CreatedAt.Time,
+		UpdatedAt: inserted.UpdatedAt.Time,
+		Ttl:       inserted.Ttl,
 	}, nil
 }
 
-func (r *LeaseRepository) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
-	err := r.queries.ReleaseLease(ctx, qDb.ReleaseLeaseParams{
-		TokenID: tokenID,
+// reuseLease hands an expired lease to peerID. The expiry of the previous holder's lease
+// is recorded before the new allocation.
+func (r *LeaseRepository) reuseLease(ctx context.Context, q *qDb.Queries, tokenID int64, previousPeerID string, previousExpiresAt pgtype.Timestamptz, peerID string) (*models.Lease, error) {
+	if err := q.InsertLeaseHistory(ctx, qDb.InsertLeaseHistoryParams{
+		TokenID:    tokenID,
+		PeerID:     previousPeerID,
+		TenantID:   models.TenantFromContext(ctx),
+		Event:      string(models.LeaseEventExpire),
+		ExpiresAt:  previousExpiresAt,
+		OccurredAt: previousExpiresAt,
+	}); err != nil {
+		return nil, err
+	}
+	if err := r.ha.notify(ctx, q, models.LeaseEventExpire, tokenID, previousPeerID); err != nil {
+		return nil, err
+	}
+
+	reused, err := q.ReuseLease(ctx, qDb.ReuseLeaseParams{
 		PeerID:  peerID,
+		TokenID: tokenID,
+		Ttl:     int32(r.ttl().Minutes()),
 	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domainErrors.ErrLeaseAlreadyExists
+		}
+		return nil, err
+	}
+
+	if err := recordLeaseEvent(ctx, q, r.ha, models.LeaseEventAllocate, reused.TokenID, reused.PeerID, reused.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return &models.Lease{
+		TokenID:   reused.TokenID,
+		PeerID:    reused.PeerID,
+		ExpiresAt: reused.ExpiresAt.Time,
+		CreatedAt: reused.CreatedAt.Time,
+		UpdatedAt: reused.UpdatedAt.Time,
+		Ttl:       reused.Ttl,
+	}, nil
+}
+
+// renewLease extends the active lease of peerID. pgx.ErrNoRows is returned when the peer
+// holds no active lease with that token ID.
+func (r *LeaseRepository) renewLease(ctx context.Context, q *qDb.Queries, tokenID int64, peerID string) (*models.Lease, error) {
+	renewed, err := q.RenewLease(ctx, qDb.RenewLeaseParams{
+		TokenID:  tokenID,
+		PeerID:   peerID,
+		Ttl:      int32(r.ttl().Minutes()),
+		TenantID: models.TenantFromContext(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordLeaseEvent(ctx, q, r.ha, models.LeaseEventRenew, renewed.TokenID, renewed.PeerID, renewed.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return &models.Lease{
+		TokenID:   renewed.TokenID,
+		PeerID:    renewed.PeerID,
+		ExpiresAt: renewed.ExpiresAt.Time,
+		CreatedAt: renewed.CreatedAt.Time,
+		UpdatedAt: renewed.UpdatedAt.Time,
+		Ttl:       renewed.Ttl,
+		Labels:    decodeLabels(renewed.Labels),
+	}, nil
+}
+
+// releaseLease expires the lease of peerID right away. Releasing a lease the peer does not
+// hold is a no-op.
+func releaseLease(ctx context.Context, q *qDb.Queries, ha *HAController, tokenID int64, peerID string) error {
+	expiresAt, err := q.ReleaseLease(ctx, qDb.ReleaseLeaseParams{
+		TokenID:  tokenID,
+		PeerID:   peerID,
+		TenantID: models.TenantFromContext(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	return recordLeaseEvent(ctx, q, ha, models.LeaseEventRelease, tokenID, peerID, expiresAt)
+}
+
+// recordLeaseEvent appends an event to the lease history of the context's tenant in the
+// caller's transaction, and streams it to the standby with it
+func recordLeaseEvent(ctx context.Context, q *qDb.Queries, ha *HAController, event models.LeaseEvent, tokenID int64, peerID string, expiresAt pgtype.Timestamptz) error {
+	if err := q.InsertLeaseHistory(ctx, qDb.InsertLeaseHistoryParams{
+		TokenID:   tokenID,
+		PeerID:    peerID,
+		TenantID:  models.TenantFromContext(ctx),
+		Event:     string(event),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+	return ha.notify(ctx, q, event, tokenID, peerID)
+}
+
+// checkLeaseQuota serializes allocations for peerID until the transaction ends, so that
+// concurrent requests of one peer cannot both pass the check, and fails with
+// ErrLeaseQuotaExceeded once the peer holds maxLeasesPerPeer active leases.
+func (r *LeaseRepository) checkLeaseQuota(ctx context.Context, q *qDb.Queries, peerID string) error {
+	if r.maxLeasesPerPeer <= 0 {
+		return nil
+	}
+
+	tenantID := models.TenantFromContext(ctx)
+
+	if err := q.LockPeerLeases(ctx, qDb.LockPeerLeasesParams{TenantID: tenantID, PeerID: peerID}); err != nil {
 		return err
 	}
+
+	active, err := q.CountActiveLeasesByPeerID(ctx, qDb.CountActiveLeasesByPeerIDParams{
+		PeerID:   peerID,
+		TenantID: tenantID,
+	})
+	if err != nil {
+		return err
+	}
+	if active >= int64(r.maxLeasesPerPeer) {
+		return domainErrors.ErrLeaseQuotaExceeded
+	}
 	return nil
 }
+
+// encodeLabels returns the labels column for labels, NULL when there are none
+func encodeLabels(labels map[string]string) []byte {
+	if len(labels) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(labels)
+	return data
+}
+
+// decodeLabels reads the labels column, written by encodeLabels only
+func decodeLabels(data []byte) map[string]string {
+	if len(data) == 0 {
+		return nil
+	}
+	var labels map[string]string
+	_ = json.Unmarshal(data, &labels)
+	return labels
+}
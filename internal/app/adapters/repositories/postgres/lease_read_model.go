@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// LeaseReadModel serves reporting queries from the lease_read_model materialized view.
+// Every query is scoped to the tenant of its context.
+type LeaseReadModel struct {
+	pool    *pgxpool.Pool
+	queries *qDb.Queries
+}
+
+var _ ports.LeaseReadModel = &LeaseReadModel{}
+
+func NewLeaseReadModel(db *pgxpool.Pool) *LeaseReadModel {
+	return &LeaseReadModel{db, qDb.New(db)}
+}
+
+// Refresh rebuilds the view without blocking concurrent readers
+func (m *LeaseReadModel) Refresh(ctx context.Context) (err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	return m.queries.RefreshLeaseReadModel(ctx)
+}
+
+func (m *LeaseReadModel) GetLeaseStats(ctx context.Context) (_ *models.LeaseStats, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	stats, err := m.queries.GetLeaseReadModelStats(ctx, models.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LeaseStats{
+		Active:      stats.Active,
+		Expired:     stats.Expired,
+		Total:       stats.Total,
+		RefreshedAt: stats.RefreshedAt.Time,
+	}, nil
+}
+
+// leaseListColumns maps the fields a lease listing may sort or filter on to view columns
+var leaseListColumns = map[string]string{
+	"token_id":       "token_id",
+	"peer_id":        "peer_id",
+	"affinity_group": "affinity_group",
+	"created_at":     "created_at",
+	"updated_at":     "updated_at",
+	"expires_at":     "expires_at",
+}
+
+var filterComparisons = map[models.FilterOperator]string{
+	models.FilterEq:  "=",
+	models.FilterNe:  "<>",
+	models.FilterLt:  "<",
+	models.FilterLte: "<=",
+	models.FilterGt:  ">",
+	models.FilterGte: ">=",
+}
+
+// ListLeases builds the query from the options. Column names come from leaseListColumns
+// only, values are always passed as arguments.
+func (m *LeaseReadModel) ListLeases(ctx context.Context, opts *models.ListOptions) (_ []*models.Lease, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+	sortColumn, ok := leaseListColumns[opts.SortField]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", opts.SortField)
+	}
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where := []string{"tenant_id = " + arg(models.TenantFromContext(ctx))}
+
+	for _, filter := range opts.Filters {
+		if label, ok := filter.Value.(models.LeaseLabel); ok {
+			condition, err := labelCondition(filter.Operator, arg(label.Key), arg(label.Value))
+			if err != nil {
+				return nil, err
+			}
+			where = append(where, condition)
+			continue
+		}
+		column, ok := leaseListColumns[filter.Field]
+		if !ok {
+			return nil, fmt.Errorf("unsupported filter field %q", filter.Field)
+		}
+		switch filter.Operator {
+		case models.FilterPrefix:
+			where = append(where, fmt.Sprintf("starts_with(%s, %s)", column, arg(filter.Value)))
+			continue
+		case models.FilterIn:
+			where = append(where, fmt.Sprintf("%s = ANY(%s::text[])", column, arg(filter.Value)))
+			continue
+		case models.FilterNotIn:
+			where = append(where, fmt.Sprintf("%s <> ALL(%s::text[])", column, arg(filter.Value)))
+			continue
+		}
+		comparison, ok := filterComparisons[filter.Operator]
+		if !ok {
+			return nil, fmt.Errorf("unsupported filter operator %q", filter.Operator)
+		}
+		where = append(where, fmt.Sprintf("%s %s %s", column, comparison, arg(filter.Value)))
+	}
+
+	direction, after := "ASC", ">"
+	if opts.Descending {
+		direction, after = "DESC", "<"
+	}
+	if opts.After != nil {
+		where = append(where, fmt.Sprintf("(%s, token_id) %s (%s, %s)", sortColumn, after, arg(opts.After.SortValue), arg(opts.After.Key)))
+	}
+
+	var sql strings.Builder
+	sql.WriteString("SELECT token_id, peer_id, created_at, updated_at, expires_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels FROM lease_read_model")
+	sql.WriteString(" WHERE " + strings.Join(where, " AND "))
+	fmt.Fprintf(&sql, " ORDER BY %s %s, token_id %s LIMIT %s", sortColumn, direction, direction, arg(opts.Limit))
+	if opts.After == nil && opts.Offset > 0 {
+		sql.WriteString(" OFFSET " + arg(opts.Offset))
+	}
+
+	rows, err := m.pool.Query(ctx, sql.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leases []*models.Lease
+	for rows.Next() {
+		lease := &models.Lease{}
+		var labels []byte
+		if err := rows.Scan(&lease.TokenID, &lease.PeerID, &lease.CreatedAt, &lease.UpdatedAt, &lease.ExpiresAt, &lease.Ttl, &labels); err != nil {
+			return nil, err
+		}
+		lease.Labels = decodeLabels(labels)
+		leases = append(leases, lease)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return leases, nil
+}
+
+// labelCondition matches the leases whose label key equals, or differs from, value. Leases
+// without the label differ from any value. Equality uses containment, which the GIN index
+// on labels serves.
+func labelCondition(operator models.FilterOperator, key, value string) (string, error) {
+	switch operator {
+	case models.FilterEq:
+		return fmt.Sprintf("labels @> jsonb_build_object(%s::text, %s::text)", key, value), nil
+	case models.FilterNe:
+		return fmt.Sprintf("labels ->> %s::text IS DISTINCT FROM %s::text", key, value), nil
+	default:
+		return "", fmt.Errorf("unsupported filter operator %q for labels", operator)
+	}
+}
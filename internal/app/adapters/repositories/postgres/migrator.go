@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/migrations"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSchemaTooNew       = errors.New("database schema is newer than this binary")
+	ErrSchemaOutdated     = errors.New("database schema has pending migrations")
+	ErrAllocStateMissing  = errors.New("alloc_state row of the default tenant is missing")
+	ErrAllocStateMismatch = errors.New("alloc_state does not match the configured pool")
+)
+
+// migrationLockID serializes migrations between instances starting at the same time
+const migrationLockID = 0x64686370 // "dhcp"
+
+const (
+	createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version character varying(64) NOT NULL,
+  applied_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (version)
+)`
+	insertSchemaMigration = `INSERT INTO schema_migrations (version) VALUES ($1)`
+
+	listAppliedMigrations = `SELECT version FROM schema_migrations`
+	listAtlasMigrations   = `SELECT version FROM atlas_schema_revisions.atlas_schema_revisions
+WHERE applied = total`
+)
+
+// Migrator applies the embedded migrations and records them in schema_migrations
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []migrations.Migration
+	logger     *zap.Logger
+}
+
+var _ ports.SchemaMigrator = &Migrator{}
+
+func NewMigrator(db *pgxpool.Pool, logger *zap.Logger) (*Migrator, error) {
+	all, err := migrations.All()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return &Migrator{pool: db, migrations: all, logger: logger.With(zap.String("component", "migrator"))}, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) (*models.SchemaStatus, error) {
+	return m.status(ctx, m.pool)
+}
+
+func (m *Migrator) Migrate(ctx context.Context) ([]string, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if _, err := conn.Exec(ctx, createSchemaMigrations); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	// Read the status under the lock, another instance may just have migrated
+	status, err := m.status(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(status.Unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown migration(s) %s", ErrSchemaTooNew, strings.Join(status.Unknown, ", "))
+	}
+
+	applied := make([]string, 0, len(status.Pending))
+	for _, migration := range m.migrations {
+		if !slices.Contains(status.Pending, migration.Version) {
+			continue
+		}
+
+		if err := m.apply(ctx, conn, migration); err != nil {
+			return applied, err
+		}
+		m.logger.Info("Applied migration", zap.String("version", migration.Version))
+		applied = append(applied, migration.Version)
+	}
+
+	return applied, nil
+}
+
+// apply runs a migration and records it in the same transaction, so a failed migration
+// leaves neither its changes nor its version behind
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, migration migrations.Migration) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, migration.SQL); err != nil {
+		return fmt.Errorf("migration %s failed: %w", migration.Name, err)
+	}
+	if _, err := tx.Exec(ctx, insertSchemaMigration, migration.Version); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (m *Migrator) VerifyAllocState(ctx context.Context, minTokenID, maxTokenID int64) error {
+	states, err := qDb.New(m.pool).ListAllocStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, state := range states {
+		if state.TenantID != models.DefaultTenantID {
+			continue
+		}
+		if state.MinTokenID != minTokenID || state.MaxTokenID != maxTokenID {
+			return fmt.Errorf("%w: stored pool [%d, %d], configured [%d, %d]",
+				ErrAllocStateMismatch, state.MinTokenID, state.MaxTokenID, minTokenID, maxTokenID)
+		}
+		return nil
+	}
+
+	return ErrAllocStateMissing
+}
+
+// ProvisionTenantPools creates the allocation state of tenants that have none yet and
+// checks that the stored pools of the others still match. The default tenant is left to
+// VerifyAllocState.
+func (m *Migrator) ProvisionTenantPools(ctx context.Context, tenants []*models.Tenant) error {
+	q := qDb.New(m.pool)
+
+	configured := make(map[string]*models.Tenant, len(tenants))
+	for _, tenant := range tenants {
+		if tenant.ID == models.DefaultTenantID {
+			continue
+		}
+		configured[tenant.ID] = tenant
+
+		if err := q.InsertAllocState(ctx, qDb.InsertAllocStateParams{
+			TenantID:    tenant.ID,
+			LastTokenID: tenant.MinTokenID - 1,
+			MinTokenID:  tenant.MinTokenID,
+			MaxTokenID:  tenant.MaxTokenID,
+		}); err != nil {
+			return fmt.Errorf("failed to provision pool of tenant %q: %w", tenant.ID, err)
+		}
+	}
+
+	states, err := q.ListAllocStates(ctx)
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		tenant, ok := configured[state.TenantID]
+		if !ok {
+			continue
+		}
+		if state.MinTokenID != tenant.MinTokenID || state.MaxTokenID != tenant.MaxTokenID {
+			return fmt.Errorf("%w: tenant %q stored pool [%d, %d], configured [%d, %d]",
+				ErrAllocStateMismatch, tenant.ID, state.MinTokenID, state.MaxTokenID, tenant.MinTokenID, tenant.MaxTokenID)
+		}
+	}
+	return nil
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// status reads the versions applied by this runner, plus those Atlas applied completely,
+// so a database migrated with "atlas migrate apply" is picked up without reapplying anything
+func (m *Migrator) status(ctx context.Context, db querier) (*models.SchemaStatus, error) {
+	var applied []string
+	for _, source := range []struct{ table, query string }{
+		{"public.schema_migrations", listAppliedMigrations},
+		{"atlas_schema_revisions.atlas_schema_revisions", listAtlasMigrations},
+	} {
+		var exists bool
+		if err := db.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", source.table).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		versions, err := queryVersions(ctx, db, source.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", source.table, err)
+		}
+		for _, version := range versions {
+			if !slices.Contains(applied, version) {
+				applied = append(applied, version)
+			}
+		}
+	}
+
+	return models.NewSchemaStatus(migrations.Versions(m.migrations), applied), nil
+}
+
+func queryVersions(ctx context.Context, db querier, query string) ([]string, error) {
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
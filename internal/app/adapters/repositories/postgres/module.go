@@ -1,12 +1,116 @@
 package postgres
 
 import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 )
 
 var Module = fx.Options(
 	// Repositories
 	fx.Provide(NewDBPool),
+	fx.Provide(NewReplicaPools),
 	fx.Provide(NewNonceRepository),
-	fx.Provide(NewLeaseRepository),
+	fx.Provide(
+		fx.Annotate(
+			NewLeaseRepository,
+			fx.OnStop(func(ctx context.Context, repo *LeaseRepository) error {
+				return repo.ReturnReservedTokenIDs(ctx)
+			}),
+		),
+	),
+	config.ReloadTarget[*LeaseRepository](),
+	fx.Provide(NewAccessRuleRepository),
+	fx.Provide(
+		fx.Annotate(
+			NewAPIKeyRepository,
+			fx.As(new(ports.APIKeyRepository)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewPeerPolicyRepository,
+			fx.As(new(ports.PeerPolicyRepository)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewHealthChecker,
+			fx.ResultTags(`name:"database"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewPoolStatsReporter,
+			fx.As(new(ports.DatabasePoolStatsReporter)),
+		),
+	),
+
+	// Read models
+	fx.Provide(
+		fx.Annotate(
+			NewLeaseReadModel,
+			fx.As(new(ports.LeaseReadModel)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewPoolStatsRepository,
+			fx.As(new(ports.PoolStatsRepository)),
+		),
+	),
+
+	// Active/standby mode
+	fx.Provide(
+		fx.Annotate(
+			NewHAController,
+			fx.ParamTags(``, ``, ``, `optional:"true"`),
+		),
+		// Handlers only report a role in active/standby mode
+		func(ha *HAController) ports.HAController {
+			if !ha.Enabled() {
+				return nil
+			}
+			return ha
+		},
+	),
+
+	// Maintenance
+	fx.Provide(
+		// The active instance runs the jobs in active/standby mode
+		func(lc fx.Lifecycle, cfg *config.AppConfig, pool *pgxpool.Pool, ha *HAController, logger *zap.Logger) ports.LeaderElector {
+			if ha.Enabled() {
+				return ha
+			}
+			return NewLeaderElector(lc, cfg, pool, logger)
+		},
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewIntegrityChecker,
+			fx.As(new(ports.IntegrityChecker)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewMigrator,
+			fx.As(new(ports.SchemaMigrator)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewLeaseSnapshotRepository,
+			fx.As(new(ports.LeaseSnapshotRepository)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewSchemaGuard,
+			fx.As(new(ports.SchemaGuard)),
+		),
+	),
 )
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
@@ -20,14 +21,14 @@ type NonceRepository struct {
 var _ ports.NonceRepository = &NonceRepository{}
 
 func NewNonceRepository(cfg *config.AppConfig, db *pgxpool.Pool) *NonceRepository {
-	return &NonceRepository{qDb.New(db), time.Duration(cfg.NonceTTL) * time.Minute}
+	return &NonceRepository{qDb.New(db), time.Duration(cfg.Nonce.TTL) * time.Minute}
 }
 
-func (r *NonceRepository) GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
+func (r *NonceRepository) GetNonce(ctx context.Context, nonceID string) (_ *models.Nonce, err error) {
+	defer translate(&err, domainErrors.ErrNonceNotFound)
 	var id pgtype.UUID
-	err := id.Scan(nonceID)
-	if err != nil {
-		return nil, err
+	if err := id.Scan(nonceID); err != nil {
+		return nil, domainErrors.ErrInvalidNonce
 	}
 
 	nonce, err := r.query.GetNonce(ctx, id)
@@ -44,7 +45,8 @@ func (r *NonceRepository) GetNonce(ctx context.Context, nonceID string) (*models
 	}, nil
 }
 
-func (r *NonceRepository) CreateNonce(ctx context.Context, peerID string) (*models.Nonce, error) {
+func (r *NonceRepository) CreateNonce(ctx context.Context, peerID string) (_ *models.Nonce, err error) {
+	defer translate(&err, domainErrors.ErrNonceNotFound)
 	params := qDb.CreateNonceParams{
 		PeerID: peerID,
 		Ttl:    int32(r.nonceTTL.Minutes()),
@@ -65,11 +67,11 @@ func (r *NonceRepository) CreateNonce(ctx context.Context, peerID string) (*mode
 	}, nil
 }
 
-func (r *NonceRepository) ConsumeNonce(ctx context.Context, nonceID string, peerID string) error {
+func (r *NonceRepository) ConsumeNonce(ctx context.Context, nonceID string, peerID string) (err error) {
+	defer translate(&err, domainErrors.ErrNonceNotFound)
 	var id pgtype.UUID
-	err := id.Scan(nonceID)
-	if err != nil {
-		return err
+	if err := id.Scan(nonceID); err != nil {
+		return domainErrors.ErrInvalidNonce
 	}
 	_, err = r.query.ConsumeNonce(ctx, qDb.ConsumeNonceParams{
 		ID:     id,
@@ -78,6 +80,49 @@ func (r *NonceRepository) ConsumeNonce(ctx context.Context, nonceID string, peer
 	return err
 }
 
-func (r *NonceRepository) DeleteExpiredNonces(ctx context.Context) error {
-	return r.query.DeleteExpiredNonces(ctx)
+// ListOutstandingNonces returns the unused, unexpired nonces of a peer, newest first
+func (r *NonceRepository) ListOutstandingNonces(ctx context.Context, peerID string) (_ []*models.Nonce, err error) {
+	defer translate(&err, domainErrors.ErrNonceNotFound)
+	rows, err := r.query.ListOutstandingNonces(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	nonces := make([]*models.Nonce, len(rows))
+	for i, nonce := range rows {
+		nonces[i] = &models.Nonce{
+			ID:        nonce.ID.String(),
+			PeerID:    nonce.PeerID,
+			IssuedAt:  nonce.IssuedAt.Time,
+			ExpiresAt: nonce.ExpiresAt.Time,
+			Used:      nonce.Used,
+			UsedAt:    nonce.UsedAt.Time,
+		}
+	}
+	return nonces, nil
+}
+
+// RevokeNonce deletes an unused, unexpired nonce of the peer
+func (r *NonceRepository) RevokeNonce(ctx context.Context, nonceID string, peerID string) (err error) {
+	defer translate(&err, domainErrors.ErrNonceNotFound)
+	var id pgtype.UUID
+	if err := id.Scan(nonceID); err != nil {
+		return domainErrors.ErrInvalidNonce
+	}
+	revoked, err := r.query.RevokeNonce(ctx, qDb.RevokeNonceParams{
+		ID:     id,
+		PeerID: peerID,
+	})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return domainErrors.ErrNonceNotFound
+	}
+	return nil
+}
+
+func (r *NonceRepository) DeleteExpiredNonces(ctx context.Context, limit int) (_ int64, err error) {
+	defer translate(&err, domainErrors.ErrNonceNotFound)
+	return r.query.DeleteExpiredNonces(ctx, int32(limit))
 }
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+type PeerPolicyRepository struct {
+	query *qDb.Queries
+}
+
+var _ ports.PeerPolicyRepository = &PeerPolicyRepository{}
+
+func NewPeerPolicyRepository(db *pgxpool.Pool) *PeerPolicyRepository {
+	return &PeerPolicyRepository{qDb.New(db)}
+}
+
+func (r *PeerPolicyRepository) ListPeerPolicies(ctx context.Context) (_ []*models.PeerPolicy, err error) {
+	defer translate(&err, domainErrors.ErrPeerPolicyNotFound)
+	rows, err := r.query.ListPeerPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]*models.PeerPolicy, 0, len(rows))
+	for _, row := range rows {
+		policies = append(policies, toPeerPolicy(row))
+	}
+	return policies, nil
+}
+
+func (r *PeerPolicyRepository) CreatePeerPolicy(ctx context.Context, policy *models.PeerPolicy) (_ *models.PeerPolicy, err error) {
+	defer translate(&err, domainErrors.ErrPeerPolicyNotFound)
+	row, err := r.query.InsertPeerPolicy(ctx, qDb.InsertPeerPolicyParams{
+		Name:        policy.Name,
+		Peers:       policy.Peers,
+		CanAllocate: policy.CanAllocate,
+		CanReserve:  policy.CanReserve,
+		MaxPool:     int32(policy.MaxPool),
+	})
+	if err != nil {
+		// The insert does nothing when a policy with the same name exists
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainErrors.ErrPeerPolicyExists
+		}
+		return nil, err
+	}
+	return toPeerPolicy(row), nil
+}
+
+func (r *PeerPolicyRepository) DeletePeerPolicy(ctx context.Context, id int64) (_ *models.PeerPolicy, err error) {
+	defer translate(&err, domainErrors.ErrPeerPolicyNotFound)
+	row, err := r.query.DeletePeerPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toPeerPolicy(row), nil
+}
+
+func toPeerPolicy(row qDb.PeerPolicy) *models.PeerPolicy {
+	return &models.PeerPolicy{
+		ID:          row.ID,
+		Name:        row.Name,
+		Peers:       row.Peers,
+		CanAllocate: row.CanAllocate,
+		CanReserve:  row.CanReserve,
+		MaxPool:     int(row.MaxPool),
+		CreatedAt:   row.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// PoolStatsRepository counts leases per tenant with one aggregate query over leases and
+// one over the recent lease_history rows. Both run on a read replica when configured.
+type PoolStatsRepository struct {
+	queries      *qDb.Queries
+	replicas     *ReplicaPools
+	releaseGrace time.Duration
+}
+
+var _ ports.PoolStatsRepository = &PoolStatsRepository{}
+
+func NewPoolStatsRepository(cfg *config.AppConfig, db *pgxpool.Pool, replicas *ReplicaPools) *PoolStatsRepository {
+	return &PoolStatsRepository{qDb.New(db), replicas, time.Duration(cfg.Lease.ReleaseGrace) * time.Second}
+}
+
+func (r *PoolStatsRepository) CountPoolLeases(ctx context.Context, within time.Duration) (_ map[string]*models.PoolLeaseCounts, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+
+	counts := make(map[string]*models.PoolLeaseCounts)
+	tenant := func(tenantID string) *models.PoolLeaseCounts {
+		if counts[tenantID] == nil {
+			counts[tenantID] = &models.PoolLeaseCounts{}
+		}
+		return counts[tenantID]
+	}
+
+	err = r.replicas.Read(r.queries, func(q *qDb.Queries) error {
+		leases, err := q.CountPoolLeases(ctx, int32(r.releaseGrace/time.Second))
+		if err != nil {
+			return err
+		}
+		allocations, err := q.CountAllocationsSince(ctx, int32(within/time.Second))
+		if err != nil {
+			return err
+		}
+
+		clear(counts)
+		for _, row := range leases {
+			c := tenant(row.TenantID)
+			c.Allocated, c.Reserved, c.Grace, c.ExpiredReclaimable = row.Allocated, row.Reserved, row.Grace, row.ExpiredReclaimable
+		}
+		for _, row := range allocations {
+			tenant(row.TenantID).Allocations = row.Allocations
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
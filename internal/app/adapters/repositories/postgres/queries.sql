@@ -13,32 +13,84 @@ SET used = true, used_at = now()
 WHERE id = $1 AND peer_id = $2 AND used = false AND expires_at > now()
 RETURNING id, peer_id, issued_at, expires_at, used, used_at;
 
--- name: DeleteExpiredNonces :exec
-DELETE FROM nonces WHERE expires_at < now();
+-- name: ListOutstandingNonces :many
+SELECT id, peer_id, issued_at, expires_at, used, used_at FROM nonces
+WHERE peer_id = $1 AND used = false AND expires_at > now()
+ORDER BY issued_at DESC;
+
+-- name: RevokeNonce :execrows
+DELETE FROM nonces
+WHERE id = $1 AND peer_id = $2 AND used = false AND expires_at > now();
+
+-- name: DeleteExpiredNonces :execrows
+DELETE FROM nonces
+WHERE id IN (
+  SELECT id FROM nonces
+  WHERE expires_at < now()
+  ORDER BY expires_at
+  LIMIT NULLIF(sqlc.arg(batch_size)::int, 0)
+  FOR UPDATE SKIP LOCKED
+);
 
 -- name: GetLeaseByTokenID :one
-SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
+SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels
 FROM leases
-WHERE token_id = $1 AND expires_at > now();
+WHERE token_id = $1 AND tenant_id = $2 AND expires_at > now();
 
 -- name: GetLeaseByPeerID :one
-SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
+SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels
 FROM leases
-WHERE peer_id = $1 AND expires_at > now();
+WHERE peer_id = $1 AND tenant_id = $2 AND expires_at > now();
+
+-- name: LockPeerLeases :exec
+SELECT pg_advisory_xact_lock(hashtext('leases'), hashtext(sqlc.arg(tenant_id)::text || ':' || sqlc.arg(peer_id)::text));
+
+-- name: CountActiveLeasesByPeerID :one
+SELECT count(*) FROM leases
+WHERE peer_id = $1 AND tenant_id = $2 AND expires_at > now();
 
 -- name: FindExpiredLeaseForReuse :one
 SELECT token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
 FROM leases
-WHERE expires_at < now()
+WHERE tenant_id = sqlc.arg(tenant_id) AND expires_at < now() - (sqlc.arg(grace)::int * interval '1 second')
+  AND (NOT sqlc.arg(reclaimed_only)::boolean OR reclaimed_at IS NOT NULL)
+  AND (quarantined_until IS NULL OR quarantined_until <= now())
+  AND NOT EXISTS (
+      SELECT 1 FROM unnest(sqlc.arg(excluded_first)::bigint[], sqlc.arg(excluded_last)::bigint[]) AS excluded(first_token_id, last_token_id)
+      WHERE token_id BETWEEN excluded.first_token_id AND excluded.last_token_id
+  )
 ORDER BY expires_at ASC
 LIMIT 1
 FOR UPDATE SKIP LOCKED;
 
+-- name: FindFreeZoneTokenIDs :many
+SELECT candidate.token_id::bigint AS token_id
+FROM unnest(sqlc.arg(zone_first)::bigint[], sqlc.arg(zone_last)::bigint[]) AS zone(first_token_id, last_token_id)
+CROSS JOIN LATERAL generate_series(zone.first_token_id, zone.last_token_id) AS candidate(token_id)
+WHERE NOT EXISTS (
+      SELECT 1 FROM leases
+      WHERE leases.token_id = candidate.token_id
+        AND (leases.tenant_id <> sqlc.arg(tenant_id)
+             OR leases.expires_at >= now() - (sqlc.arg(grace)::int * interval '1 second')
+             OR (sqlc.arg(reclaimed_only)::boolean AND leases.reclaimed_at IS NULL)
+             OR leases.quarantined_until > now())
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM unnest(sqlc.arg(excluded_first)::bigint[], sqlc.arg(excluded_last)::bigint[]) AS excluded(first_token_id, last_token_id)
+      WHERE candidate.token_id BETWEEN excluded.first_token_id AND excluded.last_token_id
+  )
+LIMIT sqlc.arg(max_candidates);
+
 -- name: ReuseLease :one
 UPDATE leases
 SET peer_id = $1,
     expires_at = now() + (sqlc.arg(ttl)::int * interval '1 minute'),
-    updated_at = now()
+    updated_at = now(),
+    affinity_group = NULL,
+    delegated_by = NULL,
+    labels = NULL,
+    reclaimed_at = NULL,
+    quarantined_until = NULL
 WHERE token_id = $2
 RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl;
 
@@ -46,21 +98,319 @@ RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH F
 UPDATE leases
 SET expires_at = now() + (sqlc.arg(ttl)::int * interval '1 minute'),
     updated_at = now()
-WHERE token_id = $1 AND peer_id = $2 AND expires_at > now()
-RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl;
+WHERE token_id = $1 AND peer_id = $2 AND tenant_id = sqlc.arg(tenant_id) AND expires_at > now()
+RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels;
+
+-- name: ApplyLeaseRenewal :one
+UPDATE leases
+SET expires_at = sqlc.arg(expires_at)::timestamptz,
+    updated_at = sqlc.arg(renewed_at)::timestamptz
+WHERE token_id = $1 AND peer_id = $2 AND tenant_id = sqlc.arg(tenant_id)
+  AND updated_at < sqlc.arg(renewed_at)::timestamptz AND reclaimed_at IS NULL
+RETURNING expires_at;
 
 -- name: InsertLease :one
-INSERT INTO leases (token_id, peer_id, expires_at, created_at, updated_at)
-VALUES ($1, $2, now() + (sqlc.arg(ttl)::int * interval '1 minute'), now(), now())
+INSERT INTO leases (token_id, peer_id, tenant_id, expires_at, created_at, updated_at)
+VALUES ($1, $2, $3, now() + (sqlc.arg(ttl)::int * interval '1 minute'), now(), now())
+ON CONFLICT (token_id) DO NOTHING
 RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl;
 
 -- name: AllocateNextTokenID :one
 UPDATE alloc_state
 SET last_token_id = (last_token_id + 1)
-WHERE id = 1 AND last_token_id < max_token_id
+WHERE tenant_id = $1 AND last_token_id < max_token_id
 RETURNING last_token_id;
 
--- name: ReleaseLease :exec
+-- name: ReserveTokenIDs :one
+WITH reserved AS (
+    SELECT last_token_id FROM alloc_state WHERE tenant_id = sqlc.arg(tenant_id) FOR UPDATE
+)
+UPDATE alloc_state
+SET last_token_id = LEAST(alloc_state.last_token_id + sqlc.arg(count)::bigint, alloc_state.max_token_id)
+FROM reserved
+WHERE alloc_state.tenant_id = sqlc.arg(tenant_id) AND alloc_state.last_token_id < alloc_state.max_token_id
+RETURNING (reserved.last_token_id + 1)::bigint AS first_token_id, alloc_state.last_token_id;
+
+-- name: ReturnTokenIDs :execrows
+UPDATE alloc_state
+SET last_token_id = sqlc.arg(first_token_id)::bigint - 1
+WHERE tenant_id = sqlc.arg(tenant_id) AND last_token_id = sqlc.arg(last_token_id)::bigint;
+
+-- name: GetAllocState :one
+SELECT id, last_token_id, max_token_id, min_token_id, tenant_id
+FROM alloc_state
+WHERE tenant_id = $1;
+
+-- name: InsertAllocState :exec
+INSERT INTO alloc_state (tenant_id, last_token_id, min_token_id, max_token_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (tenant_id) DO NOTHING;
+
+-- name: GetLeaseForUpdate :one
+SELECT token_id, peer_id, expires_at, created_at, updated_at, reclaimed_at, quarantined_until, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl
+FROM leases
+WHERE token_id = $1 AND tenant_id = $2
+FOR UPDATE;
+
+-- name: ReleaseLease :one
+UPDATE leases
+SET expires_at = now(),
+    updated_at = now()
+WHERE token_id = $1 AND peer_id = $2 AND tenant_id = $3
+RETURNING expires_at;
+
+-- name: GetAffinityGroupTokenIDs :many
+SELECT token_id
+FROM leases
+WHERE affinity_group = $1 AND tenant_id = $2 AND expires_at > now()
+ORDER BY token_id;
+
+-- name: SetLeaseAffinityGroup :exec
+UPDATE leases
+SET affinity_group = $2
+WHERE token_id = $1 AND tenant_id = $3;
+
+-- name: SetLeaseDelegator :exec
+UPDATE leases
+SET delegated_by = $2
+WHERE token_id = $1 AND tenant_id = $3;
+
+-- name: SetLeaseLabels :execrows
+UPDATE leases
+SET labels = $3
+WHERE token_id = $1 AND peer_id = $2 AND tenant_id = $4 AND expires_at > now();
+
+-- name: CountDelegatedLeases :one
+SELECT count(*) FROM leases
+WHERE delegated_by = $1 AND expires_at > now();
+
+-- name: TransferLease :one
+UPDATE leases
+SET peer_id = sqlc.arg(to_peer_id),
+    updated_at = now()
+WHERE token_id = sqlc.arg(token_id) AND tenant_id = sqlc.arg(tenant_id) AND peer_id = sqlc.arg(from_peer_id) AND expires_at > now()
+RETURNING token_id, peer_id, expires_at, created_at, updated_at, EXTRACT(EPOCH FROM (expires_at - now()))::int AS ttl, labels;
+
+-- name: RefreshLeaseReadModel :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY lease_read_model;
+
+-- name: GetLeaseReadModelStats :one
+SELECT COUNT(*) FILTER (WHERE expires_at > now())::bigint AS active,
+       COUNT(*) FILTER (WHERE expires_at <= now())::bigint AS expired,
+       COUNT(*)::bigint AS total,
+       MAX(refreshed_at)::timestamptz AS refreshed_at
+FROM lease_read_model
+WHERE tenant_id = $1;
+
+-- name: CountPoolLeases :many
+SELECT tenant_id,
+       COUNT(*) FILTER (WHERE expires_at > now())::bigint AS allocated,
+       COUNT(*) FILTER (WHERE expires_at <= now() AND quarantined_until > now())::bigint AS reserved,
+       COUNT(*) FILTER (WHERE expires_at <= now() AND expires_at > now() - (sqlc.arg(grace)::int * interval '1 second')
+                          AND (quarantined_until IS NULL OR quarantined_until <= now()))::bigint AS grace,
+       COUNT(*) FILTER (WHERE expires_at <= now() - (sqlc.arg(grace)::int * interval '1 second')
+                          AND (quarantined_until IS NULL OR quarantined_until <= now()))::bigint AS expired_reclaimable
+FROM leases
+GROUP BY tenant_id;
+
+-- name: CountAllocationsSince :many
+SELECT tenant_id, COUNT(*)::bigint AS allocations
+FROM lease_history
+WHERE event = 'allocate' AND occurred_at > now() - (sqlc.arg(within)::int * interval '1 second')
+GROUP BY tenant_id;
+
+-- name: ListReclaimCandidates :many
+SELECT token_id, peer_id, tenant_id, affinity_group, expires_at, updated_at
+FROM leases
+WHERE expires_at < now() AND reclaimed_at IS NULL AND token_id > sqlc.arg(after_token_id)
+ORDER BY token_id
+LIMIT sqlc.arg(batch_size);
+
+-- name: ReclaimLeases :many
+UPDATE leases
+SET reclaimed_at = now()
+WHERE token_id = ANY(sqlc.arg(token_ids)::bigint[]) AND expires_at < now() AND reclaimed_at IS NULL
+RETURNING token_id;
+
+-- name: RevokeLeases :many
 UPDATE leases
-SET expires_at = now()
-WHERE token_id = $1 AND peer_id = $2;
\ No newline at end of file
+SET expires_at = now(),
+    updated_at = now()
+FROM unnest(sqlc.arg(token_ids)::bigint[], sqlc.arg(peer_ids)::text[]) AS revoked(token_id, peer_id)
+WHERE leases.token_id = revoked.token_id AND leases.peer_id = revoked.peer_id
+  AND leases.tenant_id = sqlc.arg(tenant_id) AND leases.expires_at > now()
+RETURNING leases.token_id, leases.peer_id, leases.expires_at;
+
+-- name: ListDuplicateActiveLeases :many
+SELECT token_id, peer_id, tenant_id, expires_at, updated_at
+FROM leases
+WHERE expires_at > now() AND (tenant_id, peer_id) IN (
+    SELECT tenant_id, peer_id FROM leases WHERE expires_at > now() GROUP BY tenant_id, peer_id HAVING COUNT(*) > 1
+)
+ORDER BY tenant_id, peer_id, updated_at DESC, token_id DESC;
+
+-- name: ListAllocStates :many
+SELECT id, last_token_id, max_token_id, min_token_id, tenant_id
+FROM alloc_state
+ORDER BY id;
+
+-- name: GetHighestLeasedTokenID :one
+SELECT COALESCE(MAX(token_id), 0)::bigint AS highest_token_id
+FROM leases
+WHERE token_id BETWEEN sqlc.arg(min_token_id) AND sqlc.arg(max_token_id);
+
+-- name: SetAllocStateLastTokenID :exec
+UPDATE alloc_state
+SET last_token_id = $2
+WHERE id = $1;
+
+-- name: ListUsedNoncesWithoutUsedAt :many
+SELECT id, peer_id
+FROM nonces
+WHERE used = true AND used_at IS NULL
+ORDER BY id;
+
+-- name: RepairNonceUsedAt :execrows
+UPDATE nonces
+SET used_at = issued_at
+WHERE used = true AND used_at IS NULL;
+
+-- name: ListExpiringLeases :many
+SELECT token_id, peer_id, tenant_id, expires_at
+FROM leases
+WHERE expires_at > now()
+  AND expires_at <= now() + (sqlc.arg(within)::int * interval '1 second')
+  AND token_id > sqlc.arg(after_token_id)
+ORDER BY token_id
+LIMIT sqlc.arg(batch_size);
+
+-- name: InsertLeaseHistory :exec
+INSERT INTO lease_history (token_id, peer_id, tenant_id, event, expires_at, occurred_at)
+VALUES ($1, $2, $3, $4, $5, COALESCE(sqlc.narg(occurred_at)::timestamptz, now()));
+
+-- name: GetLeaseHistory :many
+SELECT id, token_id, peer_id, event, expires_at, occurred_at, tenant_id
+FROM lease_history
+WHERE token_id = $1 AND tenant_id = $2
+ORDER BY id;
+
+-- name: QuarantineLease :one
+UPDATE leases
+SET expires_at = now(),
+    updated_at = now(),
+    quarantined_until = now() + (sqlc.arg(quarantine)::int * interval '1 second')
+WHERE token_id = $1
+RETURNING expires_at, quarantined_until;
+
+-- name: InsertAccessRule :one
+INSERT INTO access_rules (list, subject_type, subject, reason)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (subject_type, subject, list) DO NOTHING
+RETURNING id, list, subject_type, subject, reason, created_at;
+
+-- name: DeleteAccessRule :one
+DELETE FROM access_rules
+WHERE id = $1
+RETURNING id, list, subject_type, subject, reason, created_at;
+
+-- name: GetAccessRulesBySubject :many
+SELECT id, list, subject_type, subject, reason, created_at
+FROM access_rules
+WHERE subject_type = $1 AND subject = $2
+ORDER BY id;
+
+-- name: ListAccessRules :many
+SELECT id, list, subject_type, subject, reason, created_at
+FROM access_rules
+WHERE sqlc.arg(list)::text = '' OR list = sqlc.arg(list)::text
+ORDER BY id;
+
+-- name: InsertAPIKey :one
+INSERT INTO api_keys (name, key_prefix, key_hash, scopes)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (key_hash) DO NOTHING
+RETURNING id, name, key_prefix, key_hash, scopes, created_at, last_used_at, revoked_at;
+
+-- name: GetAPIKeyByHash :one
+SELECT id, name, key_prefix, key_hash, scopes, created_at, last_used_at, revoked_at
+FROM api_keys
+WHERE key_hash = $1;
+
+-- name: ListAPIKeys :many
+SELECT id, name, key_prefix, key_hash, scopes, created_at, last_used_at, revoked_at
+FROM api_keys
+ORDER BY id;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, now())
+WHERE id = $1
+RETURNING id, name, key_prefix, key_hash, scopes, created_at, last_used_at, revoked_at;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1;
+
+-- name: InsertPeerPolicy :one
+INSERT INTO peer_policies (name, peers, can_allocate, can_reserve, max_pool)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (name) DO NOTHING
+RETURNING id, name, peers, can_allocate, can_reserve, max_pool, created_at;
+
+-- name: ListPeerPolicies :many
+SELECT id, name, peers, can_allocate, can_reserve, max_pool, created_at
+FROM peer_policies
+ORDER BY id;
+
+-- name: DeletePeerPolicy :one
+DELETE FROM peer_policies
+WHERE id = $1
+RETURNING id, name, peers, can_allocate, can_reserve, max_pool, created_at;
+
+-- name: TryLeaderLock :one
+SELECT pg_try_advisory_lock(hashtext('leader'), hashtext(sqlc.arg(name)::text));
+
+-- name: ReleaseLeaderLock :exec
+SELECT pg_advisory_unlock(hashtext('leader'), hashtext(sqlc.arg(name)::text));
+
+-- name: ActivateHAEpoch :one
+UPDATE ha_state
+SET epoch = epoch + 1,
+    active_instance = sqlc.arg(instance)::text,
+    activated_at = now()
+WHERE id
+RETURNING epoch;
+
+-- name: GetHAEpochForShare :one
+SELECT epoch FROM ha_state WHERE id FOR SHARE;
+
+-- name: GetHAState :one
+SELECT id, epoch, active_instance, activated_at FROM ha_state WHERE id;
+
+-- name: NotifyLeaseDelta :exec
+SELECT pg_notify('dhcp2p_lease_deltas', sqlc.arg(payload)::text);
+
+-- name: ListAllLeases :many
+SELECT token_id, peer_id, expires_at, created_at, updated_at, affinity_group, reclaimed_at, quarantined_until, tenant_id, delegated_by, labels
+FROM leases
+ORDER BY tenant_id, token_id;
+
+-- name: LockLeaseState :exec
+LOCK TABLE leases, alloc_state IN EXCLUSIVE MODE;
+
+-- name: DeleteAllLeases :execrows
+DELETE FROM leases;
+
+-- name: UpsertAllocState :exec
+INSERT INTO alloc_state (tenant_id, last_token_id, min_token_id, max_token_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (tenant_id) DO UPDATE
+SET last_token_id = EXCLUDED.last_token_id,
+    min_token_id = EXCLUDED.min_token_id,
+    max_token_id = EXCLUDED.max_token_id;
+
+-- name: ResetAllocStatesExcept :exec
+UPDATE alloc_state
+SET last_token_id = min_token_id - 1
+WHERE NOT (tenant_id = ANY(sqlc.arg(tenant_ids)::text[]));
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// ReplicaPools routes read-only lookups to the read replicas in turn. A replica that
+// cannot be reached is skipped for the retry interval and its lookups go to the primary,
+// so replicas never make a lookup fail that the primary could answer. Replicas lag
+// behind the primary, so a lookup right after a write may not see it yet.
+type ReplicaPools struct {
+	replicas      []*replica
+	next          atomic.Uint64
+	retryInterval time.Duration
+	logger        *zap.Logger
+}
+
+type replica struct {
+	addr      string // host:port, without credentials
+	pool      *pgxpool.Pool
+	queries   *qDb.Queries
+	downUntil atomic.Int64 // unix nanoseconds until which the replica is skipped
+}
+
+func NewReplicaPools(lc fx.Lifecycle, cfg *config.AppConfig, faults ports.FaultInjector, logger *zap.Logger) (*ReplicaPools, error) {
+	p := &ReplicaPools{
+		retryInterval: time.Duration(cfg.Database.ReplicaRetryInterval) * time.Second,
+		logger:        logger.With(zap.String("component", "replica_pools")),
+	}
+
+	for i, replicaURL := range cfg.Database.ReplicaURLs {
+		poolConfig, err := newPoolConfig(replicaURL, cfg, faults)
+		if err != nil {
+			p.close()
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+		if err != nil {
+			p.close()
+			return nil, fmt.Errorf("replica %d: failed to create connection pool: %w", i, err)
+		}
+		p.replicas = append(p.replicas, &replica{
+			addr:    fmt.Sprintf("%s:%d", poolConfig.ConnConfig.Host, poolConfig.ConnConfig.Port),
+			pool:    pool,
+			queries: qDb.New(pool),
+		})
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// A replica that is down at startup is only skipped, the primary serves its lookups
+			for _, r := range p.replicas {
+				if err := r.pool.Ping(ctx); err != nil {
+					p.markDown(r, err)
+				}
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			p.close()
+			return nil
+		},
+	})
+
+	return p, nil
+}
+
+// Read runs fn against the next available replica, or against primary when no replica is
+// configured or available. A replica failing with a connection error is marked down and
+// fn runs again against primary.
+func (p *ReplicaPools) Read(primary *qDb.Queries, fn func(q *qDb.Queries) error) error {
+	r := p.pick()
+	if r == nil {
+		return fn(primary)
+	}
+
+	err := fn(r.queries)
+	if err == nil || !isConnectionError(err) {
+		return err
+	}
+	p.markDown(r, err)
+	return fn(primary)
+}
+
+// pick returns the next replica in turn that is not marked down, or nil
+func (p *ReplicaPools) pick() *replica {
+	if p == nil || len(p.replicas) == 0 {
+		return nil
+	}
+
+	now := time.Now().UnixNano()
+	start := p.next.Add(1)
+	for i := range p.replicas {
+		r := p.replicas[(start+uint64(i))%uint64(len(p.replicas))]
+		if r.downUntil.Load() <= now {
+			return r
+		}
+	}
+	return nil
+}
+
+func (p *ReplicaPools) markDown(r *replica, err error) {
+	r.downUntil.Store(time.Now().Add(p.retryInterval).UnixNano())
+	p.logger.Warn("read replica unavailable, reading from the primary",
+		zap.String("replica", r.addr), zap.Duration("retryIn", p.retryInterval), zap.Error(err))
+}
+
+func (p *ReplicaPools) close() {
+	for _, r := range p.replicas {
+		r.pool.Close()
+	}
+}
+
+// isConnectionError reports whether err means the database could not be reached, as
+// opposed to an error the query itself caused
+func isConnectionError(err error) bool {
+	return errors.Is(TranslateError(err, pgx.ErrNoRows), domainErrors.ErrDatabaseConnection)
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// tokenReservation hands out token IDs from blocks reserved on alloc_state, so the
+// alloc_state row is locked once per block instead of once per allocation. Reserving
+// runs outside the allocating transaction and commits immediately. Each tenant reserves
+// from its own alloc_state row.
+type tokenReservation struct {
+	size     int64
+	tenantID string
+
+	mu       sync.Mutex
+	next     int64   // next token ID of the current block
+	last     int64   // last token ID of the current block, below next once it is used up
+	returned []int64 // token IDs taken from a block whose allocation did not commit
+}
+
+// newTokenReservation returns nil when token IDs are taken from alloc_state one by one
+func newTokenReservation(size int, tenantID string) *tokenReservation {
+	if size <= 1 {
+		return nil
+	}
+	return &tokenReservation{size: int64(size), tenantID: tenantID, last: -1}
+}
+
+// take returns an unused reserved token ID, reserving the next block when needed
+func (r *tokenReservation) take(ctx context.Context, q *qDb.Queries) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := len(r.returned); n > 0 {
+		tokenID := r.returned[n-1]
+		r.returned = r.returned[:n-1]
+		return tokenID, nil
+	}
+
+	if r.next > r.last {
+		block, err := q.ReserveTokenIDs(ctx, qDb.ReserveTokenIDsParams{TenantID: r.tenantID, Count: r.size})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				// Pool exhausted
+				return 0, domainErrors.ErrAllocationFailed
+			}
+			return 0, err
+		}
+		r.next, r.last = block.FirstTokenID, block.LastTokenID
+	}
+
+	tokenID := r.next
+	r.next++
+	return tokenID, nil
+}
+
+// giveBack makes a token ID whose allocation did not commit available again
+func (r *tokenReservation) giveBack(tokenID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.returned = append(r.returned, tokenID)
+}
+
+// returnUnused rewinds alloc_state over the unused token IDs at the end of the current
+// block. This only works while no other instance has reserved after it; otherwise the
+// token IDs stay behind the cursor, where only requested allocations can reach them.
+func (r *tokenReservation) returnUnused(ctx context.Context, q *qDb.Queries) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next > r.last && len(r.returned) == 0 {
+		return nil
+	}
+
+	// Given back token IDs directly below the block can be rewound as well
+	first := r.next
+	slices.Sort(r.returned)
+	for n := len(r.returned); n > 0 && r.returned[n-1] == first-1; n-- {
+		first--
+		r.returned = r.returned[:n-1]
+	}
+	if first > r.last {
+		return nil
+	}
+
+	rewound, err := q.ReturnTokenIDs(ctx, qDb.ReturnTokenIDsParams{
+		FirstTokenID: first,
+		TenantID:     r.tenantID,
+		LastTokenID:  r.last,
+	})
+	if err != nil {
+		return err
+	}
+	if rewound > 0 {
+		r.next, r.last, r.returned = 0, -1, nil
+	}
+	return nil
+}
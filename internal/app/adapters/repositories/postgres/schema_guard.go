@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// SchemaGuard keeps the server from starting against a schema it was not built for
+type SchemaGuard struct {
+	migrator    ports.SchemaMigrator
+	tenants     ports.TenantResolver
+	enabled     bool
+	autoMigrate bool
+	minTokenID  int64
+	maxTokenID  int64
+	logger      *zap.Logger
+}
+
+var _ ports.SchemaGuard = &SchemaGuard{}
+
+func NewSchemaGuard(lc fx.Lifecycle, cfg *config.AppConfig, migrator ports.SchemaMigrator, tenants ports.TenantResolver, logger *zap.Logger) *SchemaGuard {
+	g := &SchemaGuard{migrator, tenants, cfg.SchemaCheckEnabled, cfg.AutoMigrate, cfg.PoolMinTokenID, cfg.PoolMaxTokenID, logger.With(zap.String("component", "schema_guard"))}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return g.Check(ctx)
+		},
+	})
+
+	return g
+}
+
+func (g *SchemaGuard) Check(ctx context.Context) error {
+	if !g.enabled {
+		g.logger.Warn("Schema check disabled, the database schema is not verified")
+		return nil
+	}
+
+	status, err := g.migrator.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if len(status.Unknown) > 0 {
+		return fmt.Errorf("%w: unknown migration(s) %s, upgrade dhcp2p to at least this version",
+			ErrSchemaTooNew, strings.Join(status.Unknown, ", "))
+	}
+
+	if len(status.Pending) > 0 {
+		if !g.autoMigrate {
+			return fmt.Errorf("%w: %s; run \"dhcp2p migrate\" or enable auto_migrate",
+				ErrSchemaOutdated, strings.Join(status.Pending, ", "))
+		}
+
+		applied, err := g.migrator.Migrate(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		g.logger.Info("Applied pending migrations", zap.Strings("versions", applied))
+	}
+
+	if err := g.migrator.VerifyAllocState(ctx, g.minTokenID, g.maxTokenID); err != nil {
+		return err
+	}
+
+	// The default tenant is always configured, the others get their pools on first start
+	if tenants := g.tenants.Tenants(); len(tenants) > 1 {
+		if err := g.migrator.ProvisionTenantPools(ctx, tenants); err != nil {
+			return err
+		}
+	}
+
+	g.logger.Info("Schema verified", zap.String("version", status.Latest))
+	return nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	qDb "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres/db"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// leaseColumns are the columns of the leases table filled by an import
+var leaseColumns = []string{
+	"token_id", "peer_id", "expires_at", "created_at", "updated_at",
+	"affinity_group", "reclaimed_at", "quarantined_until", "tenant_id", "delegated_by", "labels",
+}
+
+// LeaseSnapshotRepository exports the leases and alloc_state tables from one snapshot of
+// the primary and replaces both in one transaction on import
+type LeaseSnapshotRepository struct {
+	pool    *pgxpool.Pool
+	queries *qDb.Queries
+}
+
+var _ ports.LeaseSnapshotRepository = &LeaseSnapshotRepository{}
+
+func NewLeaseSnapshotRepository(db *pgxpool.Pool) *LeaseSnapshotRepository {
+	return &LeaseSnapshotRepository{pool: db, queries: qDb.New(db)}
+}
+
+func (r *LeaseSnapshotRepository) ExportLeaseState(ctx context.Context) (_ *models.LeaseSnapshot, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+
+	// Both tables are read from the same snapshot, so the cursors match the leases
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := r.queries.WithTx(tx)
+	states, err := q.ListAllocStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	leases, err := q.ListAllLeases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.LeaseSnapshot{
+		Pools:  make([]*models.SnapshotPool, 0, len(states)),
+		Leases: make([]*models.SnapshotLease, 0, len(leases)),
+	}
+	for _, st := range states {
+		snapshot.Pools = append(snapshot.Pools, &models.SnapshotPool{
+			Tenant:      st.TenantID,
+			MinTokenID:  st.MinTokenID,
+			MaxTokenID:  st.MaxTokenID,
+			LastTokenID: st.LastTokenID,
+		})
+	}
+	for _, lease := range leases {
+		snapshot.Leases = append(snapshot.Leases, &models.SnapshotLease{
+			TokenID:          lease.TokenID,
+			PeerID:           lease.PeerID,
+			Tenant:           lease.TenantID,
+			AffinityGroup:    lease.AffinityGroup.String,
+			DelegatedBy:      lease.DelegatedBy.String,
+			Labels:           decodeLabels(lease.Labels),
+			ExpiresAt:        lease.ExpiresAt.Time,
+			CreatedAt:        lease.CreatedAt.Time,
+			UpdatedAt:        lease.UpdatedAt.Time,
+			ReclaimedAt:      timePtr(lease.ReclaimedAt),
+			QuarantinedUntil: timePtr(lease.QuarantinedUntil),
+		})
+	}
+	return snapshot, nil
+}
+
+func (r *LeaseSnapshotRepository) ImportLeaseState(ctx context.Context, snapshot *models.LeaseSnapshot, replace bool) (_ *models.SnapshotImportReport, err error) {
+	defer translate(&err, domainErrors.ErrLeaseNotFound)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Allocations wait for the import instead of interleaving with it
+	q := r.queries.WithTx(tx)
+	if err := q.LockLeaseState(ctx); err != nil {
+		return nil, err
+	}
+
+	replaced, err := q.DeleteAllLeases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if replaced > 0 && !replace {
+		return nil, domainErrors.ErrStoreNotEmpty
+	}
+
+	tenants := make([]string, 0, len(snapshot.Pools))
+	for _, pool := range snapshot.Pools {
+		if err := q.UpsertAllocState(ctx, qDb.UpsertAllocStateParams{
+			TenantID:    pool.Tenant,
+			LastTokenID: pool.LastTokenID,
+			MinTokenID:  pool.MinTokenID,
+			MaxTokenID:  pool.MaxTokenID,
+		}); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, pool.Tenant)
+	}
+	if err := q.ResetAllocStatesExcept(ctx, tenants); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]any, 0, len(snapshot.Leases))
+	for _, lease := range snapshot.Leases {
+		rows = append(rows, []any{
+			lease.TokenID,
+			lease.PeerID,
+			pgtype.Timestamptz{Time: lease.ExpiresAt, Valid: true},
+			pgtype.Timestamptz{Time: lease.CreatedAt, Valid: true},
+			pgtype.Timestamptz{Time: lease.UpdatedAt, Valid: true},
+			pgtype.Text{String: lease.AffinityGroup, Valid: lease.AffinityGroup != ""},
+			timestamptz(lease.ReclaimedAt),
+			timestamptz(lease.QuarantinedUntil),
+			lease.Tenant,
+			pgtype.Text{String: lease.DelegatedBy, Valid: lease.DelegatedBy != ""},
+			encodeLabels(lease.Labels),
+		})
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"leases"}, leaseColumns, pgx.CopyFromRows(rows)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &models.SnapshotImportReport{
+		Pools:    len(snapshot.Pools),
+		Leases:   len(snapshot.Leases),
+		Replaced: replaced,
+	}, nil
+}
+
+func timePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func timestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
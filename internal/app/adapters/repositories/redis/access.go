@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// AccessRuleCache stores the rules of a subject as a JSON array, an empty array when the
+// subject is on neither list
+type AccessRuleCache struct {
+	client    redis.UniversalClient
+	ttl       time.Duration
+	keyPrefix string
+	guard     *failoverGuard
+}
+
+var _ ports.AccessRuleCache = &AccessRuleCache{}
+
+func NewAccessRuleCache(client redis.UniversalClient, cfg *config.AppConfig) *AccessRuleCache {
+	return &AccessRuleCache{
+		client:    client,
+		ttl:       time.Duration(cfg.Security.AccessCacheTTL) * time.Second,
+		keyPrefix: "access:",
+		guard:     newFailoverGuard(client, "access:*", time.Duration(cfg.Redis.FailoverHoldoff)*time.Second),
+	}
+}
+
+func (c *AccessRuleCache) key(subjectType models.AccessSubjectType, subject string) string {
+	return c.keyPrefix + string(subjectType) + ":" + subject
+}
+
+func (c *AccessRuleCache) GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error) {
+	if err := c.guard.check(ctx); err != nil {
+		return nil, err
+	}
+
+	data, err := c.client.Get(ctx, c.key(subjectType, subject)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, errors.ErrAccessRuleNotFound
+		}
+		return nil, c.guard.observe(err)
+	}
+
+	var rules []*models.AccessRule
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (c *AccessRuleCache) SetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string, rules []*models.AccessRule) error {
+	if c.ttl <= 0 {
+		return nil
+	}
+	if rules == nil {
+		rules = []*models.AccessRule{}
+	}
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	return c.guard.observe(c.client.Set(ctx, c.key(subjectType, subject), data, c.ttl).Err())
+}
+
+func (c *AccessRuleCache) DeleteAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) error {
+	return c.guard.observe(c.client.Del(ctx, c.key(subjectType, subject)).Err())
+}
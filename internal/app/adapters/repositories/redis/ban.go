@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// addViolation increments the violation counter and starts its window with the first
+// violation, in one step so a counter never outlives its window
+var addViolation = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// BanStore keeps rate limit violations as counters expiring with their window and bans
+// as JSON, so every instance refuses a banned client
+type BanStore struct {
+	client          redis.UniversalClient
+	violationPrefix string
+	banPrefix       string
+}
+
+var _ ports.BanStore = &BanStore{}
+
+func NewBanStore(client redis.UniversalClient) *BanStore {
+	return &BanStore{client: client, violationPrefix: "ratelimit:violations:", banPrefix: "ratelimit:ban:"}
+}
+
+func (s *BanStore) AddViolation(ctx context.Context, subject string, window time.Duration) (int64, error) {
+	count, err := addViolation.Run(ctx, s.client, []string{s.violationPrefix + subject}, window.Milliseconds()).Int64()
+	return count, TranslateError(err)
+}
+
+func (s *BanStore) Violations(ctx context.Context, subject string) (int64, error) {
+	count, err := s.client.Get(ctx, s.violationPrefix+subject).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, TranslateError(err)
+}
+
+func (s *BanStore) GetBan(ctx context.Context, subject string) (*models.Ban, error) {
+	return s.getBan(ctx, s.banPrefix+subject)
+}
+
+func (s *BanStore) getBan(ctx context.Context, key string) (*models.Ban, error) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, domainErrors.ErrBanNotFound
+	}
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+
+	var ban models.Ban
+	if err := json.Unmarshal(data, &ban); err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+func (s *BanStore) SaveBan(ctx context.Context, ban *models.Ban, ttl time.Duration) error {
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+
+	// Not a transaction, the keys may live on different cluster slots
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.banPrefix+ban.Subject, data, ttl)
+		pipe.Del(ctx, s.violationPrefix+ban.Subject)
+		return nil
+	})
+	return TranslateError(err)
+}
+
+func (s *BanStore) ListBans(ctx context.Context) ([]*models.Ban, error) {
+	var keys []string
+	var mu sync.Mutex
+	err := scanKeys(ctx, s.client, s.banPrefix+"*", func(key string) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, key)
+	})
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+
+	bans := make([]*models.Ban, 0, len(keys))
+	for _, key := range keys {
+		ban, err := s.getBan(ctx, key)
+		if errors.Is(err, domainErrors.ErrBanNotFound) {
+			// Expired since the scan
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, nil
+}
+
+func (s *BanStore) DeleteBan(ctx context.Context, subject string) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.banPrefix+subject)
+		pipe.Del(ctx, s.violationPrefix+subject)
+		return nil
+	})
+	return TranslateError(err)
+}
@@ -0,0 +1,228 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// ErrCacheMiss is returned by Cache reads of keys that hold no value
+var ErrCacheMiss = errors.New("cache miss")
+
+// absentMarker is stored in place of a value to remember that none exists. No codec
+// encodes a struct as this single byte.
+const absentMarker = "-"
+
+// KeyBuilder names the keys of one kind of cached value: the prefix, the tenant of the
+// request when the values are tenant scoped, then the parts joined by colons
+type KeyBuilder struct {
+	Prefix       string
+	TenantScoped bool
+}
+
+func (b KeyBuilder) Key(ctx context.Context, parts ...string) string {
+	key := b.Prefix
+	if b.TenantScoped {
+		key += models.TenantKeyPrefix(ctx)
+	}
+	return key + strings.Join(parts, ":")
+}
+
+// Pattern matches every key of the builder, across tenants
+func (b KeyBuilder) Pattern() string {
+	return b.Prefix + "*"
+}
+
+// TTLPolicy decides how long a value stays cached. Values with a non-positive TTL are
+// not cached.
+type TTLPolicy[T any] func(value T) time.Duration
+
+// FixedTTL caches every value for ttl
+func FixedTTL[T any](ttl time.Duration) TTLPolicy[T] {
+	return func(T) time.Duration { return ttl }
+}
+
+// Cache stores values of type T in Redis under the keys of its KeyBuilder, encoded with
+// its codec. Reads are bypassed while the failover guard holds them off.
+//
+// A value the codec cannot decode reads as a miss, so switching cache.codec only costs
+// the entries written before the switch.
+type Cache[T any] struct {
+	client redis.UniversalClient
+	keys   KeyBuilder
+	codec  Codec
+	ttl    TTLPolicy[T]
+	guard  *failoverGuard
+}
+
+func NewCache[T any](client redis.UniversalClient, keys KeyBuilder, codec Codec, ttl TTLPolicy[T], failoverHoldoff time.Duration) *Cache[T] {
+	return &Cache[T]{
+		client: client,
+		keys:   keys,
+		codec:  codec,
+		ttl:    ttl,
+		guard:  newFailoverGuard(client, keys.Pattern(), failoverHoldoff),
+	}
+}
+
+func (c *Cache[T]) Key(ctx context.Context, parts ...string) string {
+	return c.keys.Key(ctx, parts...)
+}
+
+// Get returns the value under key. found is false when the key remembers that no value
+// exists, see SetAbsent, and the error is ErrCacheMiss when the key is not cached.
+func (c *Cache[T]) Get(ctx context.Context, key string) (value T, found bool, err error) {
+	if err := c.guard.check(ctx); err != nil {
+		return value, false, err
+	}
+
+	data, err := c.client.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return value, false, ErrCacheMiss
+		}
+		return value, false, c.guard.observe(err)
+	}
+	if data == absentMarker {
+		return value, false, nil
+	}
+
+	value, err = c.decode(data)
+	return value, err == nil, err
+}
+
+// Take returns the value under key and deletes it in one command, so that no other
+// reader gets it as well
+func (c *Cache[T]) Take(ctx context.Context, key string) (value T, err error) {
+	if err := c.guard.check(ctx); err != nil {
+		return value, err
+	}
+
+	data, err := c.client.GetDel(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return value, ErrCacheMiss
+		}
+		return value, c.guard.observe(err)
+	}
+	if data == absentMarker {
+		return value, ErrCacheMiss
+	}
+	return c.decode(data)
+}
+
+// GetMany reads keys in one pipeline and returns the values found by key. Misses and
+// absent values are left out.
+func (c *Cache[T]) GetMany(ctx context.Context, keys ...string) (map[string]T, error) {
+	if err := c.guard.check(ctx); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return map[string]T{}, nil
+	}
+
+	pipe := c.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, c.guard.observe(err)
+	}
+
+	values := make(map[string]T, len(keys))
+	for i, cmd := range cmds {
+		data, err := cmd.Result()
+		if err != nil || data == absentMarker {
+			continue
+		}
+		if value, err := c.decode(data); err == nil {
+			values[keys[i]] = value
+		}
+	}
+	return values, nil
+}
+
+// Set stores value under every key with the TTL of the policy, in one pipeline
+func (c *Cache[T]) Set(ctx context.Context, value T, keys ...string) error {
+	batch := c.Batch()
+	if err := batch.Set(ctx, value, keys...); err != nil {
+		return err
+	}
+	return batch.Exec(ctx)
+}
+
+// SetAbsent remembers for ttl that key has no value. The marker is only written if the key
+// is not cached, so a value cached concurrently is never replaced by it.
+func (c *Cache[T]) SetAbsent(ctx context.Context, key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return c.guard.observe(c.client.SetNX(ctx, key, absentMarker, ttl).Err())
+}
+
+// Delete removes keys in one pipeline. Every key is deleted by a command of its own, as
+// the keys may live in different cluster slots.
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	batch := c.Batch()
+	batch.Delete(ctx, keys...)
+	return batch.Exec(ctx)
+}
+
+// Batch queues writes to the cache to send them in one pipeline
+func (c *Cache[T]) Batch() *CacheBatch[T] {
+	return &CacheBatch[T]{cache: c, pipe: c.client.Pipeline()}
+}
+
+func (c *Cache[T]) decode(data string) (value T, err error) {
+	if err := c.codec.Unmarshal([]byte(data), &value); err != nil {
+		// Written with another codec, or by an older version
+		return value, ErrCacheMiss
+	}
+	return value, nil
+}
+
+// CacheBatch is a pipeline of writes to a Cache
+type CacheBatch[T any] struct {
+	cache  *Cache[T]
+	pipe   redis.Pipeliner
+	queued int
+}
+
+// Set queues value under every key with the TTL of the policy. The value is encoded once.
+func (b *CacheBatch[T]) Set(ctx context.Context, value T, keys ...string) error {
+	ttl := b.cache.ttl(value)
+	if ttl <= 0 || len(keys) == 0 {
+		return nil
+	}
+
+	data, err := b.cache.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		b.pipe.Set(ctx, key, data, ttl)
+	}
+	b.queued += len(keys)
+	return nil
+}
+
+func (b *CacheBatch[T]) Delete(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		b.pipe.Del(ctx, key)
+	}
+	b.queued += len(keys)
+}
+
+// Exec sends the queued writes, if any
+func (b *CacheBatch[T]) Exec(ctx context.Context) error {
+	if b.queued == 0 {
+		return nil
+	}
+	_, err := b.pipe.Exec(ctx)
+	return b.cache.guard.observe(err)
+}
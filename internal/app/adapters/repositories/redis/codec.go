@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Codec names accepted by cache.codec
+const (
+	CodecJSON = "json"
+	CodecCBOR = "cbor"
+)
+
+// Codec turns cached values into the bytes stored in Redis and back
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec stores values as JSON, readable with redis-cli
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// CBORCodec stores values as CBOR, which is smaller and faster to decode than JSON.
+// Struct fields keep their json names.
+type CBORCodec struct {
+	enc cbor.EncMode
+}
+
+func NewCBORCodec() (*CBORCodec, error) {
+	enc, err := cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	if err != nil {
+		return nil, err
+	}
+	return &CBORCodec{enc: enc}, nil
+}
+
+func (c *CBORCodec) Marshal(v any) ([]byte, error) {
+	return c.enc.Marshal(v)
+}
+
+func (c *CBORCodec) Unmarshal(data []byte, v any) error {
+	return cbor.Unmarshal(data, v)
+}
+
+// NewCodec returns the codec named by cache.codec, JSON when it is empty
+func NewCodec(name string) (Codec, error) {
+	switch name {
+	case "", CodecJSON:
+		return JSONCodec{}, nil
+	case CodecCBOR:
+		return NewCBORCodec()
+	default:
+		return nil, fmt.Errorf("unknown cache codec %q, expected %s or %s", name, CodecJSON, CodecCBOR)
+	}
+}
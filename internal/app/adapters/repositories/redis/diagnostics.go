@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// KeyClasses lists the key patterns written by the cache adapters
+var KeyClasses = []string{
+	"lease:peer:*",
+	"lease:token:*",
+	"nonce:*",
+	"access:*",
+	"ratelimit:*",
+}
+
+const scanBatchSize = 1000
+
+type Diagnostics struct {
+	client redis.UniversalClient
+}
+
+var (
+	_ ports.CacheDiagnostics       = &Diagnostics{}
+	_ ports.CachePoolStatsReporter = &Diagnostics{}
+)
+
+func NewDiagnostics(client redis.UniversalClient) *Diagnostics {
+	return &Diagnostics{client: client}
+}
+
+func (d *Diagnostics) MemoryUsage(ctx context.Context, sampleSize int) (*models.CacheMemoryReport, error) {
+	report := &models.CacheMemoryReport{
+		SampleSize:  sampleSize,
+		Classes:     make([]*models.KeyClassMemoryUsage, 0, len(KeyClasses)),
+		GeneratedAt: time.Now(),
+	}
+
+	for _, pattern := range KeyClasses {
+		usage, err := d.keyClassUsage(ctx, pattern, sampleSize)
+		if err != nil {
+			return nil, err
+		}
+		report.Classes = append(report.Classes, usage)
+		report.TotalEstimatedBytes += usage.EstimatedBytes
+	}
+
+	return report, nil
+}
+
+// keyClassUsage counts every key matching pattern and measures the first sampleSize of them
+func (d *Diagnostics) keyClassUsage(ctx context.Context, pattern string, sampleSize int) (*models.KeyClassMemoryUsage, error) {
+	usage := &models.KeyClassMemoryUsage{Pattern: pattern}
+
+	var mu sync.Mutex
+	var sample []string
+	err := scanKeys(ctx, d.client, pattern, func(key string) {
+		mu.Lock()
+		defer mu.Unlock()
+		usage.Keys++
+		if len(sample) < sampleSize {
+			sample = append(sample, key)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sample) == 0 {
+		return usage, nil
+	}
+
+	pipe := d.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(sample))
+	for i, key := range sample {
+		cmds[i] = pipe.MemoryUsage(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for _, cmd := range cmds {
+		bytes, err := cmd.Result()
+		if err != nil {
+			// Key expired between SCAN and MEMORY USAGE
+			if err == redis.Nil {
+				continue
+			}
+			return nil, err
+		}
+		usage.SampledKeys++
+		usage.SampledBytes += bytes
+	}
+
+	if usage.SampledKeys > 0 {
+		usage.AvgBytes = float64(usage.SampledBytes) / float64(usage.SampledKeys)
+		usage.EstimatedBytes = int64(usage.AvgBytes * float64(usage.Keys))
+	}
+
+	return usage, nil
+}
+
+// CachePoolStats reports the client's connection pools, summed over all nodes in cluster mode
+func (d *Diagnostics) CachePoolStats() *models.CachePoolStats {
+	stats := d.client.PoolStats()
+	return &models.CachePoolStats{
+		TotalConns:     stats.TotalConns,
+		IdleConns:      stats.IdleConns,
+		StaleConns:     stats.StaleConns,
+		Hits:           stats.Hits,
+		Misses:         stats.Misses,
+		Timeouts:       stats.Timeouts,
+		WaitCount:      stats.WaitCount,
+		WaitDurationMs: float64(stats.WaitDurationNs) / float64(time.Millisecond),
+	}
+}
@@ -0,0 +1,24 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// TranslateError converts the error of a Redis command into a domain error, wrapping both
+// so the command error stays available to logs and failover detection. redis.Nil is
+// returned unchanged for callers to map onto their own not found error, as are domain
+// errors and canceled contexts.
+func TranslateError(err error) error {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) || domainErrors.IsAppError(err) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", domainErrors.ErrRequestTimeout, err)
+	}
+	return fmt.Errorf("%w: %w", domainErrors.ErrRedisConnection, err)
+}
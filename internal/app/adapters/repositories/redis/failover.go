@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrFailoverHoldoff is returned by cache reads while they are bypassed after a failover
+var ErrFailoverHoldoff = errors.New("cache reads suspended after a redis failover")
+
+// failoverErrorPrefixes are replies of servers that are restarting, demoted or not
+// yet serving their slots
+var failoverErrorPrefixes = []string{"READONLY", "LOADING", "MASTERDOWN", "CLUSTERDOWN", "TRYAGAIN"}
+
+// failoverGuard bypasses cache reads for a while after errors that point at a failover
+// or a restarted server. Writes acknowledged by a demoted master can be lost, so a lease
+// deleted from the cache may come back. When the holdoff ends, the guard purges the keys
+// of its cache once before reads are served from Redis again.
+type failoverGuard struct {
+	client  redis.UniversalClient
+	pattern string
+	holdoff time.Duration
+
+	last  atomic.Int64 // unix nanoseconds of the last failover error
+	purge atomic.Bool  // keys must be purged before the next read
+}
+
+func newFailoverGuard(client redis.UniversalClient, pattern string, holdoff time.Duration) *failoverGuard {
+	return &failoverGuard{client: client, pattern: pattern, holdoff: holdoff}
+}
+
+// observe records failover errors and returns err translated into a domain error
+func (g *failoverGuard) observe(err error) error {
+	if g.holdoff > 0 && isFailoverError(err) {
+		g.last.Store(time.Now().UnixNano())
+		g.purge.Store(true)
+	}
+	return TranslateError(err)
+}
+
+// check returns ErrFailoverHoldoff while reads are bypassed, and purges the cached keys
+// once the holdoff has passed
+func (g *failoverGuard) check(ctx context.Context) error {
+	if !g.purge.Load() {
+		return nil
+	}
+	if time.Since(time.Unix(0, g.last.Load())) < g.holdoff {
+		return ErrFailoverHoldoff
+	}
+	if !g.purge.CompareAndSwap(true, false) {
+		// Another request is purging
+		return ErrFailoverHoldoff
+	}
+
+	if err := g.purgeKeys(ctx); err != nil {
+		g.purge.Store(true)
+		return g.observe(err)
+	}
+	return nil
+}
+
+func (g *failoverGuard) purgeKeys(ctx context.Context) error {
+	var mu sync.Mutex
+	var keys []string
+	if err := scanKeys(ctx, g.client, g.pattern, func(key string) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, key)
+	}); err != nil {
+		return err
+	}
+
+	// Single key commands, so a cluster pipeline can route every one to its slot
+	for batch := range slices.Chunk(keys, scanBatchSize) {
+		pipe := g.client.Pipeline()
+		for _, key := range batch {
+			pipe.Unlink(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isFailoverError reports whether err means the server is unreachable or changing roles
+func isFailoverError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	for _, prefix := range failoverErrorPrefixes {
+		if redis.HasErrorPrefix(err, prefix) {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.Is(err, io.EOF) || errors.Is(err, redis.ErrClosed) || errors.As(err, &netErr)
+}
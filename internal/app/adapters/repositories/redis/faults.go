@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// faultHook delays and fails commands and pipelines by the fault injected into the
+// cache. A failed command is not sent, callers see a connection error like on an outage.
+type faultHook struct {
+	faults ports.FaultInjector
+}
+
+var _ redis.Hook = faultHook{}
+
+func (h faultHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h faultHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.faults.Inject(ctx, models.FaultTargetCache); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h faultHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.faults.Inject(ctx, models.FaultTargetCache); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// idempotencyPending is stored under a claimed key until its response is complete
+const idempotencyPending = "pending"
+
+// IdempotencyStore keeps responses to requests with an idempotency key in Redis, so
+// retries reaching another instance are replayed as well
+type IdempotencyStore struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+var _ ports.IdempotencyStore = &IdempotencyStore{}
+
+func NewIdempotencyStore(client redis.UniversalClient) *IdempotencyStore {
+	return &IdempotencyStore{client: client, keyPrefix: "idempotency:"}
+}
+
+func (s *IdempotencyStore) Claim(ctx context.Context, key string, ttl time.Duration) (*models.IdempotentResponse, error) {
+	claimed, err := s.client.SetNX(ctx, s.keyPrefix+key, idempotencyPending, ttl).Result()
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	data, err := s.client.Get(ctx, s.keyPrefix+key).Result()
+	if err == redis.Nil || data == idempotencyPending {
+		// Still being served, or the claim expired in between; the client retries either way
+		return nil, errors.ErrIdempotencyInProgress
+	}
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+
+	var response models.IdempotentResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, response *models.IdempotentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return TranslateError(s.client.Set(ctx, s.keyPrefix+key, data, ttl).Err())
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	return TranslateError(s.client.Del(ctx, s.keyPrefix+key).Err())
+}
@@ -2,93 +2,91 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 )
 
 type LeaseCache struct {
-	client    *redis.Client
-	leaseTTL  time.Duration
-	keyPrefix string
+	cache       *Cache[*models.Lease]
+	negativeTTL time.Duration
 }
 
 var _ ports.LeaseCache = &LeaseCache{}
 
-func NewLeaseCache(client *redis.Client, cfg *config.AppConfig) *LeaseCache {
-	return &LeaseCache{
-		client:    client,
-		leaseTTL:  time.Duration(cfg.LeaseTTL) * time.Minute,
-		keyPrefix: "lease:",
+func NewLeaseCache(client redis.UniversalClient, cfg *config.AppConfig) (*LeaseCache, error) {
+	codec, err := NewCodec(cfg.Cache.Codec)
+	if err != nil {
+		return nil, err
 	}
+
+	return &LeaseCache{
+		// Leases are cached for as long as the database says they are active
+		cache: NewCache(client, KeyBuilder{Prefix: "lease:", TenantScoped: true}, codec, func(lease *models.Lease) time.Duration {
+			return time.Duration(lease.Ttl) * time.Second
+		}, time.Duration(cfg.Redis.FailoverHoldoff)*time.Second),
+		negativeTTL: time.Duration(cfg.Cache.NegativeTTL) * time.Second,
+	}, nil
+}
+
+func (c *LeaseCache) peerKey(ctx context.Context, peerID string) string {
+	return c.cache.Key(ctx, "peer", peerID)
+}
+
+func (c *LeaseCache) tokenKey(ctx context.Context, tokenID int64) string {
+	return c.cache.Key(ctx, "token", strconv.FormatInt(tokenID, 10))
 }
 
 func (c *LeaseCache) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
-	key := c.keyPrefix + "peer:" + peerID
-	return c.getLease(ctx, key)
+	return c.getLease(ctx, c.peerKey(ctx, peerID))
 }
 
 func (c *LeaseCache) GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error) {
-	key := c.keyPrefix + "token:" + fmt.Sprintf("%d", tokenID)
-	return c.getLease(ctx, key)
+	return c.getLease(ctx, c.tokenKey(ctx, tokenID))
 }
 
+// getLease returns nil without an error for a lease remembered as not found
 func (c *LeaseCache) getLease(ctx context.Context, key string) (*models.Lease, error) {
-	data, err := c.client.Get(ctx, key).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, errors.ErrLeaseNotFound
-		}
-		return nil, err
-	}
-
-	var lease models.Lease
-	if err := json.Unmarshal([]byte(data), &lease); err != nil {
-		return nil, err
+	lease, _, err := c.cache.Get(ctx, key)
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, domainErrors.ErrLeaseNotFound
 	}
-
-	return &lease, nil
+	return lease, err
 }
 
+// SetLease caches the lease under its peer and token keys until it expires. Already
+// expired leases are not cached.
 func (c *LeaseCache) SetLease(ctx context.Context, lease *models.Lease) error {
-	data, err := json.Marshal(lease)
-	if err != nil {
-		return err
-	}
-
-	// Use TTL from lease object (calculated by database)
-	ttl := time.Duration(lease.Ttl) * time.Second
-	if ttl <= 0 {
-		// Do not cache already expired leases
-		return nil
-	}
-
-	// Set both peer and token keys
-	peerKey := c.keyPrefix + "peer:" + lease.PeerID
-	tokenKey := c.keyPrefix + "token:" + fmt.Sprintf("%d", lease.TokenID)
+	return c.cache.Set(ctx, lease, c.peerKey(ctx, lease.PeerID), c.tokenKey(ctx, lease.TokenID))
+}
 
-	pipe := c.client.Pipeline()
-	pipe.Set(ctx, peerKey, data, ttl)
-	pipe.Set(ctx, tokenKey, data, ttl)
+func (c *LeaseCache) SetPeerNotFound(ctx context.Context, peerID string) error {
+	return c.cache.SetAbsent(ctx, c.peerKey(ctx, peerID), c.negativeTTL)
+}
 
-	_, err = pipe.Exec(ctx)
-	return err
+func (c *LeaseCache) SetTokenNotFound(ctx context.Context, tokenID int64) error {
+	return c.cache.SetAbsent(ctx, c.tokenKey(ctx, tokenID), c.negativeTTL)
 }
 
 func (c *LeaseCache) DeleteLease(ctx context.Context, peerID string, tokenID int64) error {
-	peerKey := c.keyPrefix + "peer:" + peerID
-	tokenKey := c.keyPrefix + "token:" + fmt.Sprintf("%d", tokenID)
-
-	pipe := c.client.Pipeline()
-	pipe.Del(ctx, peerKey)
-	pipe.Del(ctx, tokenKey)
+	return c.cache.Delete(ctx, c.peerKey(ctx, peerID), c.tokenKey(ctx, tokenID))
+}
 
-	_, err := pipe.Exec(ctx)
-	return err
+func (c *LeaseCache) UpdateLeases(ctx context.Context, upserts []*models.Lease, removals []*models.Lease) error {
+	batch := c.cache.Batch()
+	for _, lease := range removals {
+		batch.Delete(ctx, c.peerKey(ctx, lease.PeerID), c.tokenKey(ctx, lease.TokenID))
+	}
+	for _, lease := range upserts {
+		if err := batch.Set(ctx, lease, c.peerKey(ctx, lease.PeerID), c.tokenKey(ctx, lease.TokenID)); err != nil {
+			return err
+		}
+	}
+	return batch.Exec(ctx)
 }
@@ -1,9 +1,39 @@
 package redis
 
-import "go.uber.org/fx"
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/fx"
+)
 
 var Module = fx.Options(
 	fx.Provide(NewRedisClient),
 	fx.Provide(NewNonceCache),
 	fx.Provide(NewLeaseCache),
+	fx.Provide(NewAccessRuleCache),
+	fx.Provide(
+		fx.Annotate(
+			NewIdempotencyStore,
+			fx.As(new(ports.IdempotencyStore)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewBanStore,
+			fx.As(new(ports.BanStore)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewHealthChecker,
+			fx.As(new(ports.HealthChecker)),
+			fx.ResultTags(`name:"cache"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewDiagnostics,
+			fx.As(new(ports.CacheDiagnostics)),
+			fx.As(new(ports.CachePoolStatsReporter)),
+		),
+	),
 )
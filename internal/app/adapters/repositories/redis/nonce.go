@@ -2,61 +2,57 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 )
 
+// NonceCache keeps nonces by ID. Nonce IDs are unique across tenants, so the keys are not
+// tenant scoped.
 type NonceCache struct {
-	client    *redis.Client
-	nonceTTL  time.Duration
-	keyPrefix string
+	cache *Cache[*models.Nonce]
 }
 
 var _ ports.NonceCache = &NonceCache{}
 
-func NewNonceCache(client *redis.Client, cfg *config.AppConfig) *NonceCache {
-	return &NonceCache{
-		client:    client,
-		nonceTTL:  time.Duration(cfg.NonceTTL) * time.Minute,
-		keyPrefix: "nonce:",
-	}
-}
-
-func (c *NonceCache) GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
-	key := c.keyPrefix + nonceID
-	data, err := c.client.Get(ctx, key).Result()
+func NewNonceCache(client redis.UniversalClient, cfg *config.AppConfig) (*NonceCache, error) {
+	codec, err := NewCodec(cfg.Cache.Codec)
 	if err != nil {
-		if err == redis.Nil {
-			return nil, errors.ErrNonceNotFound
-		}
 		return nil, err
 	}
 
-	var nonce models.Nonce
-	if err := json.Unmarshal([]byte(data), &nonce); err != nil {
-		return nil, err
-	}
-
-	return &nonce, nil
+	return &NonceCache{
+		cache: NewCache(client, KeyBuilder{Prefix: "nonce:"}, codec,
+			FixedTTL[*models.Nonce](time.Duration(cfg.Nonce.TTL)*time.Minute),
+			time.Duration(cfg.Redis.FailoverHoldoff)*time.Second),
+	}, nil
 }
 
-func (c *NonceCache) CreateNonce(ctx context.Context, nonce *models.Nonce) error {
-	key := c.keyPrefix + nonce.ID
-	data, err := json.Marshal(nonce)
-	if err != nil {
-		return err
+func (c *NonceCache) GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
+	nonce, _, err := c.cache.Get(ctx, c.cache.Key(ctx, nonceID))
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, domainErrors.ErrNonceNotFound
 	}
+	return nonce, err
+}
 
-	return c.client.Set(ctx, key, data, c.nonceTTL).Err()
+func (c *NonceCache) CreateNonce(ctx context.Context, nonce *models.Nonce) error {
+	return c.cache.Set(ctx, nonce, c.cache.Key(ctx, nonce.ID))
 }
 
 func (c *NonceCache) DeleteNonce(ctx context.Context, nonceID string) error {
-	key := c.keyPrefix + nonceID
-	return c.client.Del(ctx, key).Err()
+	return c.cache.Delete(ctx, c.cache.Key(ctx, nonceID))
+}
+
+func (c *NonceCache) TakeNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
+	nonce, err := c.cache.Take(ctx, c.cache.Key(ctx, nonceID))
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, domainErrors.ErrNonceNotFound
+	}
+	return nonce, err
 }
@@ -3,33 +3,91 @@ package redis
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
 )
 
-func NewRedisClient(lc fx.Lifecycle, cfg *config.AppConfig) (*redis.Client, error) {
-	redisURL := cfg.RedisURL
-	if redisURL == "" {
-		return nil, fmt.Errorf("REDIS_URL environment variable not set")
-	}
+// NewRedisClient connects to Redis in the configured mode. The caches only use commands
+// on single keys, so the same code runs against a standalone server, a Sentinel managed
+// master or a cluster. Faults, when injected, delay or fail the commands of every cache.
+func NewRedisClient(lc fx.Lifecycle, cfg *config.AppConfig, faults ports.FaultInjector) (redis.UniversalClient, error) {
+	var redisClient redis.UniversalClient
 
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:         redisURL,
-		Password:     cfg.RedisPassword,
-		MaxRetries:   cfg.RedisMaxRetries,
-		PoolSize:     cfg.RedisPoolSize,
-		MinIdleConns: cfg.RedisMinIdleConns,
-		DialTimeout:  time.Duration(cfg.RedisDialTimeout) * time.Second,
-		ReadTimeout:  time.Duration(cfg.RedisReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.RedisWriteTimeout) * time.Second,
-	})
+	switch cfg.Redis.Mode {
+	case config.RedisModeStandalone, "":
+		redisURL := cfg.Redis.URL
+		if redisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL environment variable not set")
+		}
+
+		// redis.url is either host:port or a redis:// or rediss:// URL, whose
+		// credentials and database number are kept
+		opts := &redis.Options{Addr: redisURL}
+		if strings.Contains(redisURL, "://") {
+			parsed, err := redis.ParseURL(redisURL)
+			if err != nil {
+				return nil, fmt.Errorf("parse redis.url: %w", err)
+			}
+			opts = parsed
+		}
+		if cfg.Redis.Password != "" {
+			opts.Password = cfg.Redis.Password
+		}
+		opts.MaxRetries = cfg.Redis.MaxRetries
+		opts.PoolSize = cfg.Redis.PoolSize
+		opts.MinIdleConns = cfg.Redis.MinIdleConns
+		opts.DialTimeout = time.Duration(cfg.Redis.DialTimeout) * time.Second
+		opts.ReadTimeout = time.Duration(cfg.Redis.ReadTimeout) * time.Second
+		opts.WriteTimeout = time.Duration(cfg.Redis.WriteTimeout) * time.Second
+
+		redisClient = redis.NewClient(opts)
+	case config.RedisModeSentinel:
+		if len(cfg.Redis.Addrs) == 0 || cfg.Redis.MasterName == "" {
+			return nil, fmt.Errorf("redis.addrs and redis.master_name must be set in %s mode", config.RedisModeSentinel)
+		}
+
+		redisClient = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.Redis.MasterName,
+			SentinelAddrs:    cfg.Redis.Addrs,
+			SentinelPassword: cfg.Redis.SentinelPassword,
+			Password:         cfg.Redis.Password,
+			MaxRetries:       cfg.Redis.MaxRetries,
+			PoolSize:         cfg.Redis.PoolSize,
+			MinIdleConns:     cfg.Redis.MinIdleConns,
+			DialTimeout:      time.Duration(cfg.Redis.DialTimeout) * time.Second,
+			ReadTimeout:      time.Duration(cfg.Redis.ReadTimeout) * time.Second,
+			WriteTimeout:     time.Duration(cfg.Redis.WriteTimeout) * time.Second,
+		})
+	case config.RedisModeCluster:
+		if len(cfg.Redis.Addrs) == 0 {
+			return nil, fmt.Errorf("redis.addrs must be set in %s mode", config.RedisModeCluster)
+		}
+
+		redisClient = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.Redis.Addrs,
+			Password:     cfg.Redis.Password,
+			MaxRetries:   cfg.Redis.MaxRetries,
+			PoolSize:     cfg.Redis.PoolSize,
+			MinIdleConns: cfg.Redis.MinIdleConns,
+			DialTimeout:  time.Duration(cfg.Redis.DialTimeout) * time.Second,
+			ReadTimeout:  time.Duration(cfg.Redis.ReadTimeout) * time.Second,
+			WriteTimeout: time.Duration(cfg.Redis.WriteTimeout) * time.Second,
+		})
+	default:
+		return nil, fmt.Errorf("unknown redis.mode %q", cfg.Redis.Mode)
+	}
+	if faults != nil {
+		redisClient.AddHook(faultHook{faults})
+	}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			if err := redisClient.Ping(ctx).Err(); err != nil {
+			if err := ping(ctx, redisClient); err != nil {
 				return fmt.Errorf("failed to ping redis: %w", err)
 			}
 			return nil
@@ -41,3 +99,47 @@ func NewRedisClient(lc fx.Lifecycle, cfg *config.AppConfig) (*redis.Client, erro
 
 	return redisClient, nil
 }
+
+// ping checks the server, or every shard of a cluster
+func ping(ctx context.Context, client redis.UniversalClient) error {
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		return cluster.ForEachShard(ctx, func(ctx context.Context, shard *redis.Client) error {
+			return shard.Ping(ctx).Err()
+		})
+	}
+	return client.Ping(ctx).Err()
+}
+
+// scanKeys calls fn for every key matching pattern, scanning every master of a cluster.
+// fn may be called concurrently in cluster mode.
+func scanKeys(ctx context.Context, client redis.UniversalClient, pattern string, fn func(key string)) error {
+	scan := func(ctx context.Context, node redis.Cmdable) error {
+		iter := node.Scan(ctx, 0, pattern, scanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			fn(iter.Val())
+		}
+		return iter.Err()
+	}
+
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return scan(ctx, master)
+		})
+	}
+	return scan(ctx, client)
+}
+
+// HealthChecker pings Redis for readiness and health checks
+type HealthChecker struct {
+	client redis.UniversalClient
+}
+
+var _ ports.HealthChecker = &HealthChecker{}
+
+func NewHealthChecker(client redis.UniversalClient) *HealthChecker {
+	return &HealthChecker{client}
+}
+
+func (c *HealthChecker) Ping(ctx context.Context) error {
+	return ping(ctx, c.client)
+}
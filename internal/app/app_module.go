@@ -5,11 +5,21 @@ import (
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/application"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	infrastructure "github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/reload"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/server"
 	"go.uber.org/fx"
 )
 
-func NewApp() *fx.App {
+// NewApp wires the server. opts can supply components that are not built in, such as a
+// libp2p host.Host on which the lease protocol is then served.
+func NewApp(opts ...fx.Option) *fx.App {
+	// The storage backend decides which repositories are wired, so it is read up front
+	cfg, err := config.NewAppConfig()
+	if err != nil {
+		return fx.New(fx.NopLogger, fx.Error(err))
+	}
+
 	return fx.New(
 		// Use the zap logger (for debugging)
 		// fx.WithLogger(func(log *zap.Logger) fxevent.Logger {
@@ -20,14 +30,44 @@ func NewApp() *fx.App {
 		fx.NopLogger,
 
 		// Add modules
-		adapters.Module,
+		adapters.NewModule(cfg.StorageBackend),
 		application.Module,
 		infrastructure.Module,
+		fx.Options(opts...),
+
+		// Verify the schema before anything serves (postgres backend only)
+		fx.Invoke(fx.Annotate(func(schemaGuard ports.SchemaGuard) {}, fx.ParamTags(`optional:"true"`))),
 
 		// Invoke the servers
 		fx.Invoke(func(server *server.HTTPServer) {}),
 
 		// Invoke the jobs
 		fx.Invoke(func(nonceCleaner ports.NonceCleaner) {}),
+		fx.Invoke(func(readModelRefresher ports.LeaseReadModelRefresher) {}),
+		fx.Invoke(func(leaseReclaimer ports.LeaseReclaimer) {}),
+		fx.Invoke(func(leaseExpiryNotifier ports.LeaseExpiryNotifier) {}),
+		fx.Invoke(func(peerPolicyRefresher ports.PeerPolicyRefresher) {}),
+		fx.Invoke(func(leaseJournalVerifier ports.LeaseJournalVerifier) {}),
+
+		// Reload settings on SIGHUP and config file changes
+		fx.Invoke(func(reloader *reload.Reloader) {}),
+	)
+}
+
+// NewCommandApp wires the application for a one-off command. Only the components
+// requested through opts, e.g. with fx.Populate, are constructed and started; no
+// servers or jobs run.
+func NewCommandApp(opts ...fx.Option) *fx.App {
+	cfg, err := config.NewAppConfig()
+	if err != nil {
+		return fx.New(fx.NopLogger, fx.Error(err))
+	}
+
+	return fx.New(
+		fx.NopLogger,
+		adapters.NewModule(cfg.StorageBackend),
+		application.Module,
+		infrastructure.Module,
+		fx.Options(opts...),
 	)
 }
@@ -0,0 +1,135 @@
+// Package allocation implements the strategies that pick token IDs for new leases.
+package allocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+)
+
+// Strategy names, as used in lease.allocation_strategy
+const (
+	StrategyLRU        = "lru"
+	StrategySequential = "sequential"
+	StrategyRandom     = "random"
+)
+
+var Module = fx.Options(
+	fx.Provide(NewStrategy),
+)
+
+// NewStrategy creates the allocation strategy selected in the configuration
+func NewStrategy(cfg *config.AppConfig, repo ports.LeaseRepository, tenants ports.TenantResolver) (ports.AllocationStrategy, error) {
+	switch cfg.Lease.AllocationStrategy {
+	case StrategyLRU, "":
+		return NewLRU(repo), nil
+	case StrategySequential:
+		return NewSequential(repo), nil
+	case StrategyRandom:
+		return NewRandom(repo, tenants, cfg.Lease.AllocationRandomProbes), nil
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %q", cfg.Lease.AllocationStrategy)
+	}
+}
+
+// LRU reuses the lease that expired longest ago and only hands out a never leased token ID
+// when no expired lease is left. Addresses are recycled in the order they were freed,
+// giving stale ARP and DNS entries the most time to age out.
+type LRU struct {
+	repo ports.LeaseRepository
+}
+
+var _ ports.AllocationStrategy = &LRU{}
+
+func NewLRU(repo ports.LeaseRepository) *LRU {
+	return &LRU{repo}
+}
+
+func (s *LRU) Name() string {
+	return StrategyLRU
+}
+
+func (s *LRU) Allocate(ctx context.Context, peerID string) (*models.Lease, error) {
+	lease, err := s.repo.FindAndReuseExpiredLease(ctx, peerID)
+	if err != nil || lease != nil {
+		return lease, err
+	}
+	return s.repo.AllocateNewLease(ctx, peerID)
+}
+
+// Sequential hands out never leased token IDs in ascending order and only reuses expired
+// leases, least recently used first, once the pool cursor has reached the end.
+type Sequential struct {
+	repo ports.LeaseRepository
+}
+
+var _ ports.AllocationStrategy = &Sequential{}
+
+func NewSequential(repo ports.LeaseRepository) *Sequential {
+	return &Sequential{repo}
+}
+
+func (s *Sequential) Name() string {
+	return StrategySequential
+}
+
+func (s *Sequential) Allocate(ctx context.Context, peerID string) (*models.Lease, error) {
+	lease, err := s.repo.AllocateNewLease(ctx, peerID)
+	if !errors.Is(err, domainErrors.ErrAllocationFailed) {
+		return lease, err
+	}
+
+	// Pool cursor exhausted
+	lease, err = s.repo.FindAndReuseExpiredLease(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+	if lease == nil {
+		return nil, domainErrors.ErrAllocationFailed
+	}
+	return lease, nil
+}
+
+// Random requests uniformly random token IDs of the tenant's pool so that addresses cannot
+// be predicted from the order of allocation. After probes taken token IDs in a row it falls
+// back to LRU, which always finds a free token ID if there is one.
+type Random struct {
+	repo     ports.LeaseRepository
+	tenants  ports.TenantResolver
+	probes   int
+	fallback *LRU
+}
+
+var _ ports.AllocationStrategy = &Random{}
+
+func NewRandom(repo ports.LeaseRepository, tenants ports.TenantResolver, probes int) *Random {
+	return &Random{repo, tenants, probes, NewLRU(repo)}
+}
+
+func (s *Random) Name() string {
+	return StrategyRandom
+}
+
+func (s *Random) Allocate(ctx context.Context, peerID string) (*models.Lease, error) {
+	tenant, err := s.tenants.Tenant(models.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	for range s.probes {
+		tokenID := tenant.MinTokenID + rand.Int64N(tenant.MaxTokenID-tenant.MinTokenID+1)
+		lease, err := s.repo.AllocateRequestedLease(ctx, peerID, tokenID)
+		if errors.Is(err, domainErrors.ErrTokenIDInUse) || errors.Is(err, domainErrors.ErrTokenIDOutOfRange) {
+			continue
+		}
+		return lease, err
+	}
+	return s.fallback.Allocate(ctx, peerID)
+}
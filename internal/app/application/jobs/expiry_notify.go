@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// LeaseExpiryNotifierJob periodically notifies the holders of leases that are about to
+// expire, on the elected leader. It does nothing unless expiry notifications are enabled
+// in the configuration.
+type LeaseExpiryNotifierJob struct {
+	service  ports.ExpiryNotificationService
+	leader   ports.LeaderElector
+	enabled  bool
+	interval time.Duration
+	logger   *zap.Logger
+
+	stopCh chan struct{}
+}
+
+var _ ports.LeaseExpiryNotifier = &LeaseExpiryNotifierJob{}
+
+func NewLeaseExpiryNotifierJob(lc fx.Lifecycle, cfg *config.AppConfig, service ports.ExpiryNotificationService, leader ports.LeaderElector, logger *zap.Logger) *LeaseExpiryNotifierJob {
+	j := &LeaseExpiryNotifierJob{service, leader, cfg.ExpiryNotifyEnabled, time.Duration(cfg.ExpiryNotifyInterval) * time.Minute, logger.With(zap.String("job", "lease_expiry_notifier")), make(chan struct{})}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return j.Run(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			close(j.stopCh)
+			return nil
+		},
+	})
+
+	return j
+}
+
+func (j *LeaseExpiryNotifierJob) Run(ctx context.Context) error {
+	if !j.enabled {
+		return nil
+	}
+
+	go func() {
+		runCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stopCh:
+				return
+			case <-ticker.C:
+				j.run(runCtx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (j *LeaseExpiryNotifierJob) run(ctx context.Context) {
+	if !j.leader.IsLeader() {
+		return
+	}
+
+	report, err := j.service.Run(ctx)
+	if err != nil {
+		j.logger.Error("Failed to notify expiring leases", zap.Error(err))
+		return
+	}
+
+	for _, channel := range report.Channels {
+		j.logger.Info("Sent expiring lease notifications",
+			zap.String("channel", channel.Channel),
+			zap.Int("batches", channel.Batches),
+			zap.Int("notified", channel.Notified),
+			zap.Int("failed", channel.Failed),
+		)
+	}
+}
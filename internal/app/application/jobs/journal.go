@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// LeaseJournalVerifierJob checks the lease journal against the store. The journal
+// records the mutations of this instance, so the job runs on followers too. It does
+// nothing unless the lease journal is enabled.
+type LeaseJournalVerifierJob struct {
+	service  ports.LeaseJournalService
+	enabled  bool
+	interval time.Duration
+	logger   *zap.Logger
+
+	stopCh chan struct{}
+}
+
+var _ ports.LeaseJournalVerifier = &LeaseJournalVerifierJob{}
+
+func NewLeaseJournalVerifierJob(lc fx.Lifecycle, cfg *config.AppConfig, service ports.LeaseJournalService, logger *zap.Logger) *LeaseJournalVerifierJob {
+	j := &LeaseJournalVerifierJob{service, cfg.LeaseJournalEnabled, time.Duration(cfg.LeaseJournalVerifyInterval) * time.Second, logger.With(zap.String("job", "lease_journal_verifier")), make(chan struct{})}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return j.Run(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			close(j.stopCh)
+			return nil
+		},
+	})
+
+	return j
+}
+
+// Run checks the journal before the servers start, which writes the first checkpoint of
+// a new journal and fails the start when that is impossible, and then every interval
+func (j *LeaseJournalVerifierJob) Run(ctx context.Context) error {
+	if !j.enabled {
+		return nil
+	}
+	if _, err := j.service.Verify(ctx); err != nil {
+		return err
+	}
+	if j.interval == 0 {
+		return nil
+	}
+
+	go func() {
+		runCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stopCh:
+				return
+			case <-ticker.C:
+				j.run(runCtx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (j *LeaseJournalVerifierJob) run(ctx context.Context) {
+	verification, err := j.service.Verify(ctx)
+	if err != nil {
+		j.logger.Error("Failed to verify the lease journal", zap.Error(err))
+		return
+	}
+
+	j.logger.Debug("Verified the lease journal",
+		zap.Int64("seq", verification.Seq),
+		zap.Int("mismatches", len(verification.Mismatches)),
+		zap.Bool("checkpointed", verification.Checkpointed),
+	)
+}
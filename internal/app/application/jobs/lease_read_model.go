@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type LeaseReadModelRefresherJob struct {
+	readModel ports.LeaseReadModel
+	leader    ports.LeaderElector
+	interval  time.Duration
+	logger    *zap.Logger
+
+	stopCh chan struct{}
+}
+
+var _ ports.LeaseReadModelRefresher = &LeaseReadModelRefresherJob{}
+
+func NewLeaseReadModelRefresherJob(lc fx.Lifecycle, cfg *config.AppConfig, readModel ports.LeaseReadModel, leader ports.LeaderElector, logger *zap.Logger) *LeaseReadModelRefresherJob {
+	j := &LeaseReadModelRefresherJob{readModel, leader, time.Duration(cfg.ReadModelRefreshInterval) * time.Second, logger.With(zap.String("job", "lease_read_model_refresher")), make(chan struct{})}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return j.Run(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			close(j.stopCh)
+			return nil
+		},
+	})
+
+	return j
+}
+
+func (j *LeaseReadModelRefresherJob) Run(ctx context.Context) error {
+	go func() {
+		runCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		// Refresh the read model on start
+		j.run(runCtx)
+
+		for {
+			select {
+			case <-j.stopCh:
+				return
+			case <-ticker.C:
+				j.run(runCtx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (j *LeaseReadModelRefresherJob) run(ctx context.Context) {
+	if !j.leader.IsLeader() {
+		return
+	}
+
+	err := j.readModel.Refresh(ctx)
+	if err != nil {
+		j.logger.Error("Failed to refresh lease read model", zap.Error(err))
+		return
+	}
+
+	j.logger.Debug("Refreshed lease read model")
+}
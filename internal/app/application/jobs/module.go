@@ -2,11 +2,29 @@ package jobs
 
 import (
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
 )
 
 var Module = fx.Options(
 	fx.Provide(
 		fx.Annotate(NewNonceCleanerJob, fx.As(new(ports.NonceCleaner))),
+		fx.Annotate(NewLeaseReadModelRefresherJob, fx.As(new(ports.LeaseReadModelRefresher))),
+		fx.Annotate(NewLeaseReclaimerJob, fx.As(new(ports.LeaseReclaimer))),
+		fx.Annotate(NewLeaseExpiryNotifierJob, fx.As(new(ports.LeaseExpiryNotifier))),
+		fx.Annotate(NewPeerPolicyRefresherJob, fx.As(new(ports.PeerPolicyRefresher))),
+		fx.Annotate(NewLeaseJournalVerifierJob, fx.As(new(ports.LeaseJournalVerifier))),
 	),
+	// A read-only instance leaves the jobs to the instances changing leases
+	fx.Decorate(func(cfg *config.AppConfig, leader ports.LeaderElector) ports.LeaderElector {
+		if cfg.ReadOnly {
+			return follower{}
+		}
+		return leader
+	}),
 )
+
+// follower never leads
+type follower struct{}
+
+func (follower) IsLeader() bool { return false }
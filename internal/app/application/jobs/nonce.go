@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"math/rand/v2"
 	"time"
 
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
@@ -10,9 +11,14 @@ import (
 	"go.uber.org/zap"
 )
 
+// NonceCleanerJob periodically deletes expired nonces on the elected leader. Every run is
+// delayed by a random jitter, so replicas do not clean the nonces table at the same time
+// when leader election is disabled.
 type NonceCleanerJob struct {
-	repo     ports.NonceRepository
+	service  ports.NonceService
+	leader   ports.LeaderElector
 	interval time.Duration
+	jitter   time.Duration
 	logger   *zap.Logger
 
 	stopCh chan struct{}
@@ -20,8 +26,8 @@ type NonceCleanerJob struct {
 
 var _ ports.NonceCleaner = &NonceCleanerJob{}
 
-func NewNonceCleanerJob(lc fx.Lifecycle, cfg *config.AppConfig, repo ports.NonceRepository, logger *zap.Logger) *NonceCleanerJob {
-	j := &NonceCleanerJob{repo, time.Duration(cfg.NonceCleanerInterval) * time.Minute, logger.With(zap.String("job", "nonce_cleaner")), make(chan struct{})}
+func NewNonceCleanerJob(lc fx.Lifecycle, cfg *config.AppConfig, service ports.NonceService, leader ports.LeaderElector, logger *zap.Logger) *NonceCleanerJob {
+	j := &NonceCleanerJob{service, leader, time.Duration(cfg.Nonce.CleanerInterval) * time.Minute, time.Duration(cfg.Nonce.CleanerJitter) * time.Second, logger.With(zap.String("job", "nonce_cleaner")), make(chan struct{})}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -41,18 +47,17 @@ func (j *NonceCleanerJob) Run(ctx context.Context) error {
 		runCtx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		ticker := time.NewTicker(j.interval)
-		defer ticker.Stop()
-
-		// Delete expired nonces on start
-		j.run(runCtx)
+		// Delete expired nonces on start, then every interval
+		timer := time.NewTimer(j.delay(0))
+		defer timer.Stop()
 
 		for {
 			select {
 			case <-j.stopCh:
 				return
-			case <-ticker.C:
+			case <-timer.C:
 				j.run(runCtx)
+				timer.Reset(j.delay(j.interval))
 			}
 		}
 	}()
@@ -60,12 +65,28 @@ func (j *NonceCleanerJob) Run(ctx context.Context) error {
 	return nil
 }
 
+// delay adds a random share of the jitter to interval
+func (j *NonceCleanerJob) delay(interval time.Duration) time.Duration {
+	if j.jitter <= 0 {
+		return interval
+	}
+	return interval + rand.N(j.jitter)
+}
+
 func (j *NonceCleanerJob) run(ctx context.Context) {
-	err := j.repo.DeleteExpiredNonces(ctx)
+	if !j.leader.IsLeader() {
+		return
+	}
+
+	report, err := j.service.CleanupExpired(ctx)
 	if err != nil {
-		j.logger.Error("Failed to delete expired nonces", zap.Error(err))
+		j.logger.Error("Failed to delete expired nonces", zap.Int64("deleted", report.Deleted), zap.Error(err))
 		return
 	}
 
-	j.logger.Info("Deleted expired nonces")
+	j.logger.Info("Deleted expired nonces",
+		zap.Int64("deleted", report.Deleted),
+		zap.Int("batches", report.Batches),
+		zap.Duration("duration", report.FinishedAt.Sub(report.StartedAt)),
+	)
 }
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// PeerPolicyRefresherJob reloads the peer policies kept in the database. Every instance
+// authorizes peers itself, so it runs on followers and read-only instances too.
+type PeerPolicyRefresherJob struct {
+	service  ports.PeerPolicyService
+	enabled  bool
+	interval time.Duration
+	logger   *zap.Logger
+
+	stopCh chan struct{}
+}
+
+var _ ports.PeerPolicyRefresher = &PeerPolicyRefresherJob{}
+
+func NewPeerPolicyRefresherJob(lc fx.Lifecycle, cfg *config.AppConfig, service ports.PeerPolicyService, logger *zap.Logger) *PeerPolicyRefresherJob {
+	j := &PeerPolicyRefresherJob{service, cfg.PeerPolicySource == config.PeerPolicySourceDatabase, time.Duration(cfg.PeerPolicyRefreshInterval) * time.Second, logger.With(zap.String("job", "peer_policy_refresher")), make(chan struct{})}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return j.Run(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			close(j.stopCh)
+			return nil
+		},
+	})
+
+	return j
+}
+
+// Run loads the policies before the servers start, failing the start when they cannot
+// be loaded, and then refreshes them every interval
+func (j *PeerPolicyRefresherJob) Run(ctx context.Context) error {
+	if !j.enabled {
+		return nil
+	}
+	if err := j.service.Refresh(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		runCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stopCh:
+				return
+			case <-ticker.C:
+				j.run(runCtx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (j *PeerPolicyRefresherJob) run(ctx context.Context) {
+	if err := j.service.Refresh(ctx); err != nil {
+		j.logger.Error("Failed to refresh peer policies, keeping the current ones", zap.Error(err))
+		return
+	}
+
+	j.logger.Debug("Refreshed peer policies")
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// LeaseReclaimerJob periodically runs the reclamation policies on the elected leader. It
+// does nothing unless reclamation is enabled in the configuration.
+type LeaseReclaimerJob struct {
+	service  ports.ReclamationService
+	leader   ports.LeaderElector
+	enabled  bool
+	dryRun   bool
+	interval time.Duration
+	logger   *zap.Logger
+
+	stopCh chan struct{}
+}
+
+var _ ports.LeaseReclaimer = &LeaseReclaimerJob{}
+
+func NewLeaseReclaimerJob(lc fx.Lifecycle, cfg *config.AppConfig, service ports.ReclamationService, leader ports.LeaderElector, logger *zap.Logger) *LeaseReclaimerJob {
+	j := &LeaseReclaimerJob{service, leader, cfg.ReclaimEnabled, cfg.ReclaimDryRun, time.Duration(cfg.ReclaimInterval) * time.Minute, logger.With(zap.String("job", "lease_reclaimer")), make(chan struct{})}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return j.Run(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			close(j.stopCh)
+			return nil
+		},
+	})
+
+	return j
+}
+
+func (j *LeaseReclaimerJob) Run(ctx context.Context) error {
+	if !j.enabled {
+		return nil
+	}
+
+	go func() {
+		runCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stopCh:
+				return
+			case <-ticker.C:
+				j.run(runCtx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (j *LeaseReclaimerJob) run(ctx context.Context) {
+	if !j.leader.IsLeader() {
+		return
+	}
+
+	report, err := j.service.Run(ctx, j.dryRun)
+	if err != nil {
+		j.logger.Error("Failed to reclaim expired leases", zap.Error(err))
+		return
+	}
+
+	for _, policy := range report.Policies {
+		j.logger.Info("Evaluated reclaim policy",
+			zap.String("policy", policy.Policy),
+			zap.Bool("dryRun", report.DryRun),
+			zap.Int("matched", policy.Matched),
+			zap.Int64("reclaimed", policy.Reclaimed),
+		)
+	}
+}
@@ -1,12 +1,14 @@
 package application
 
 import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/allocation"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/jobs"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
 	"go.uber.org/fx"
 )
 
 var Module = fx.Options(
+	allocation.Module,
 	services.Module,
 	jobs.Module,
 )
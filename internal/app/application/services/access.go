@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// AccessControlService refuses peers on the deny list and, when the allow list is
+// required, peers that are not on it. A deny rule wins over an allow rule.
+type AccessControlService struct {
+	rules             ports.AccessRuleRepository
+	allowListRequired bool
+}
+
+var _ ports.AccessControlService = &AccessControlService{}
+
+func NewAccessControlService(cfg *config.AppConfig, rules ports.AccessRuleRepository) *AccessControlService {
+	return &AccessControlService{rules, cfg.Security.AccessAllowListRequired}
+}
+
+func (s *AccessControlService) CheckAccess(ctx context.Context, peerID string, pubkey []byte) error {
+	subjects := map[models.AccessSubjectType]string{
+		models.AccessSubjectPeerID: peerID,
+		models.AccessSubjectPubkey: base64.StdEncoding.EncodeToString(pubkey),
+	}
+
+	allowed := false
+	for subjectType, subject := range subjects {
+		if subject == "" {
+			continue
+		}
+
+		rules, err := s.rules.GetAccessRules(ctx, subjectType, subject)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			switch rule.List {
+			case models.AccessListDeny:
+				return errors.ErrPeerBlocked
+			case models.AccessListAllow:
+				allowed = true
+			}
+		}
+	}
+
+	if s.allowListRequired && !allowed {
+		return errors.ErrPeerNotAllowed
+	}
+	return nil
+}
+
+func (s *AccessControlService) ListRules(ctx context.Context, list models.AccessList) ([]*models.AccessRule, error) {
+	return s.rules.ListAccessRules(ctx, list)
+}
+
+func (s *AccessControlService) AddRule(ctx context.Context, rule *models.AccessRule) (*models.AccessRule, error) {
+	return s.rules.CreateAccessRule(ctx, rule)
+}
+
+func (s *AccessControlService) RemoveRule(ctx context.Context, id int64) error {
+	_, err := s.rules.DeleteAccessRule(ctx, id)
+	return err
+}
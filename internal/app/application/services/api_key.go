@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"go.uber.org/zap"
+)
+
+const (
+	maxAPIKeyNameLength = 64
+	apiKeyIDBytes       = 4  // identifying part of a key, stored in plaintext as its prefix
+	apiKeySecretBytes   = 32 // secret part of a key
+)
+
+// APIKeyService issues admin API keys of the form dhcp2p_<id>_<secret>. Only the SHA-256
+// of a key is stored, which is enough for keys drawn from 256 random bits. Every use of
+// a key, and every refusal, is written to the audit log.
+type APIKeyService struct {
+	repo   ports.APIKeyRepository
+	logger *zap.Logger
+}
+
+var _ ports.APIKeyService = &APIKeyService{}
+
+func NewAPIKeyService(repo ports.APIKeyRepository, logger *zap.Logger) *APIKeyService {
+	return &APIKeyService{repo, logger.Named("audit")}
+}
+
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []models.APIKeyScope) (*models.IssuedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, domainErrors.ErrInvalidAPIKeyName
+	}
+	if len(scopes) == 0 {
+		return nil, domainErrors.ErrInvalidAPIKeyScope
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, domainErrors.ErrInvalidAPIKeyScope
+		}
+	}
+	scopes = slices.Compact(slices.Sorted(slices.Values(scopes)))
+
+	random := make([]byte, apiKeyIDBytes+apiKeySecretBytes)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	prefix := models.AdminAPIKeyPrefix + hex.EncodeToString(random[:apiKeyIDBytes])
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(random[apiKeyIDBytes:])
+
+	created, err := s.repo.CreateAPIKey(ctx, &models.APIKey{
+		Name:   name,
+		Prefix: prefix,
+		Hash:   hashAPIKey(key),
+		Scopes: scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, "api_key_created", created).Info("API key created")
+	return &models.IssuedAPIKey{APIKey: created, Key: key}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]*models.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id int64) (*models.APIKey, error) {
+	key, err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, "api_key_revoked", key).Info("API key revoked")
+	return key, nil
+}
+
+func (s *APIKeyService) Authorize(ctx context.Context, key string, scope models.APIKeyScope) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, models.AdminAPIKeyPrefix) {
+		return nil, domainErrors.ErrAdminUnauthorized
+	}
+
+	apiKey, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, domainErrors.ErrAPIKeyNotFound) {
+		s.audit(ctx, "api_key_rejected", nil).Warn("unknown API key", zap.String("scope", string(scope)))
+		return nil, domainErrors.ErrAdminUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	audit := s.audit(ctx, "api_key_used", apiKey).With(zap.String("scope", string(scope)))
+	if apiKey.Revoked() {
+		audit.Warn("revoked API key refused")
+		return nil, domainErrors.ErrAdminUnauthorized
+	}
+	if !apiKey.Grants(scope) {
+		audit.Warn("API key refused for missing scope")
+		return nil, domainErrors.ErrAPIKeyScope
+	}
+
+	// Tracking the last use must not lock operators out while the store struggles
+	if err := s.repo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		audit.Error("error recording API key use", zap.Error(err))
+	}
+
+	audit.Info("API key used")
+	return apiKey, nil
+}
+
+// audit returns the audit logger for an event on key, which may be nil when the key is
+// unknown, carrying the request the event happened in
+func (s *APIKeyService) audit(ctx context.Context, event string, key *models.APIKey) *zap.Logger {
+	rc := reqctx.FromContext(ctx)
+	fields := []zap.Field{zap.String("event", event)}
+	if key != nil {
+		fields = append(fields, zap.Int64("keyID", key.ID), zap.String("keyName", key.Name), zap.String("keyPrefix", key.Prefix))
+	}
+	if rc.RequestID != "" {
+		fields = append(fields, zap.String("requestID", rc.RequestID))
+	}
+	if rc.ClientIP != "" {
+		fields = append(fields, zap.String("clientIP", rc.ClientIP))
+	}
+	return s.logger.With(fields...)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,29 +2,36 @@ package services
 
 import (
 	"context"
-	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"time"
 
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
 )
 
 type AuthService struct {
-	nonceService ports.NonceService
+	nonceService      ports.NonceService
+	identity          ports.IdentityResolver
+	clock             ports.Clock
+	timestampRequired bool
+	timestampWindow   time.Duration
 }
 
 var _ ports.AuthService = &AuthService{}
 
-func NewAuthService(nonceService ports.NonceService) *AuthService {
-	return &AuthService{nonceService}
+func NewAuthService(appConfig *config.AppConfig, nonceService ports.NonceService, identity ports.IdentityResolver, clock ports.Clock) *AuthService {
+	return &AuthService{nonceService, identity, clock, appConfig.Security.TimestampRequired, time.Duration(appConfig.Security.TimestampWindow) * time.Second}
 }
 
 func (s *AuthService) RequestAuth(ctx context.Context, request *models.AuthRequest) (*models.AuthResponse, error) {
 	if request == nil {
 		return nil, errors.ErrMissingPubkey
 	}
-	peerID, err := utils.GetPeerIDFromPubkey(request.Pubkey)
+	peerID, err := s.identity.ResolvePeerID(request.Pubkey)
 	if err != nil {
 		return nil, err
 	}
@@ -45,28 +52,60 @@ func (s *AuthService) VerifyAuth(ctx context.Context, request *models.AuthVerify
 		return nil, errors.ErrMissingPeerID
 	}
 
-	payload := sha256.Sum256([]byte(request.NonceID))
-
 	// Check if signature is not nil
 	if request.Signature == nil {
 		return nil, errors.ErrInvalidSignature
 	}
 
+	// Reject stale or future timestamps before consuming the nonce
+	if err := s.verifyTimestamp(request.Timestamp); err != nil {
+		return nil, err
+	}
+
 	// Verify nonce
 	err := s.nonceService.VerifyNonce(ctx, &models.NonceRequest{
 		NonceID:   request.NonceID,
 		Pubkey:    request.Pubkey,
-		Payload:   payload[:],
+		Payload:   crypto.AuthPayload(request.NonceID, request.Timestamp),
 		Signature: request.Signature,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	peerID, err := s.identity.ResolvePeerID(request.Pubkey)
+	if err != nil {
+		return nil, err
+	}
+
 	// Nonce is valid, return the peerID
 	response := &models.AuthVerifyResponse{
 		Pubkey: request.Pubkey,
+		PeerID: peerID,
 	}
 
 	return response, nil
 }
+
+// verifyTimestamp checks that a signed timestamp is within the acceptance window of the
+// server clock. The reported skew helps clients detect a badly set clock.
+func (s *AuthService) verifyTimestamp(timestamp string) error {
+	if timestamp == "" {
+		if s.timestampRequired {
+			return errors.ErrMissingTimestamp
+		}
+		return nil
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || seconds <= 0 {
+		return errors.ErrInvalidTimestamp
+	}
+
+	skew := time.Unix(seconds, 0).Sub(s.clock.Now()).Truncate(time.Second)
+	if skew > s.timestampWindow || -skew > s.timestampWindow {
+		return errors.ErrTimestampOutOfWindow.WithDetails(fmt.Sprintf("client clock skew %s exceeds %s", skew, s.timestampWindow))
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// banCacheTTL bounds how long an instance keeps refusing a client from its own memory, so
+// a ban lifted on another instance ends here soon after
+const banCacheTTL = 10 * time.Second
+
+// BanService bans a client IP or peer once its rate limit violations within the window
+// reach the threshold. Every further ban while the previous one is remembered lasts twice
+// as long, up to the maximum. Bans and lifted bans are written to the audit log.
+type BanService struct {
+	store       ports.BanStore
+	clock       ports.Clock
+	threshold   int64
+	window      time.Duration
+	duration    time.Duration
+	maxDuration time.Duration
+	logger      *zap.Logger
+
+	// Active bans seen by this instance, so banned clients are refused without asking the
+	// store on every request
+	mu     sync.Mutex
+	cached map[string]cachedBan
+
+	violations atomic.Int64
+	bans       atomic.Int64
+	refused    atomic.Int64
+	lifted     atomic.Int64
+}
+
+type cachedBan struct {
+	ban   *models.Ban
+	until time.Time
+}
+
+var _ ports.BanService = &BanService{}
+
+func NewBanService(cfg *config.AppConfig, store ports.BanStore, clock ports.Clock, logger *zap.Logger) *BanService {
+	return &BanService{
+		store:       store,
+		clock:       clock,
+		threshold:   int64(cfg.RateLimit.BanThreshold),
+		window:      time.Duration(cfg.RateLimit.BanWindow) * time.Second,
+		duration:    time.Duration(cfg.RateLimit.BanDuration) * time.Second,
+		maxDuration: time.Duration(cfg.RateLimit.BanMaxDuration) * time.Second,
+		logger:      logger.Named("audit"),
+		cached:      make(map[string]cachedBan),
+	}
+}
+
+func (s *BanService) CheckBan(ctx context.Context, subject string) (*models.Ban, error) {
+	now := s.clock.Now()
+	if ban := s.cachedBan(subject, now); ban != nil {
+		s.refused.Add(1)
+		return ban, nil
+	}
+
+	ban, err := s.store.GetBan(ctx, subject)
+	if errors.Is(err, domainErrors.ErrBanNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ban.Active(now) {
+		return nil, nil
+	}
+
+	s.cache(ban, now)
+	s.refused.Add(1)
+	return ban, nil
+}
+
+func (s *BanService) RecordViolation(ctx context.Context, subject string) (*models.Ban, error) {
+	s.violations.Add(1)
+	count, err := s.store.AddViolation(ctx, subject, s.window)
+	if err != nil || count < s.threshold {
+		return nil, err
+	}
+
+	level := 1
+	last, err := s.store.GetBan(ctx, subject)
+	switch {
+	case err == nil:
+		level = last.Level + 1
+	case !errors.Is(err, domainErrors.ErrBanNotFound):
+		return nil, err
+	}
+
+	duration := s.banDuration(level)
+	now := s.clock.Now()
+	ban := &models.Ban{
+		Subject:    subject,
+		Level:      level,
+		Violations: count,
+		BannedAt:   now,
+		ExpiresAt:  now.Add(duration),
+	}
+	// Remembered for the maximum duration after it ends, a client banned again within
+	// that time is banned longer
+	if err := s.store.SaveBan(ctx, ban, duration+s.maxDuration); err != nil {
+		return nil, err
+	}
+
+	s.cache(ban, now)
+	s.bans.Add(1)
+	s.audit(ctx, "client_banned", subject).Warn("client banned after repeated rate limit violations",
+		zap.Int("level", level), zap.Int64("violations", count), zap.Duration("duration", duration), zap.Time("expiresAt", ban.ExpiresAt))
+	return ban, nil
+}
+
+// banDuration doubles the first ban's duration for every level above 1, up to the maximum
+func (s *BanService) banDuration(level int) time.Duration {
+	duration := s.duration
+	for i := 1; i < level && duration < s.maxDuration; i++ {
+		duration *= 2
+	}
+	return min(duration, s.maxDuration)
+}
+
+func (s *BanService) ListBans(ctx context.Context) ([]*models.Ban, error) {
+	bans, err := s.store.ListBans(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	bans = slices.DeleteFunc(bans, func(ban *models.Ban) bool { return !ban.Active(now) })
+	slices.SortFunc(bans, func(a, b *models.Ban) int { return b.BannedAt.Compare(a.BannedAt) })
+	return bans, nil
+}
+
+func (s *BanService) GetBanStatus(ctx context.Context, subject string) (*models.BanStatus, error) {
+	status := &models.BanStatus{Subject: subject}
+
+	violations, err := s.store.Violations(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	status.Violations = violations
+
+	ban, err := s.store.GetBan(ctx, subject)
+	switch {
+	case err == nil:
+		status.Ban = ban
+		status.Banned = ban.Active(s.clock.Now())
+	case !errors.Is(err, domainErrors.ErrBanNotFound):
+		return nil, err
+	}
+	return status, nil
+}
+
+func (s *BanService) LiftBan(ctx context.Context, subject string) error {
+	ban, err := s.store.GetBan(ctx, subject)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteBan(ctx, subject); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.cached, subject)
+	s.mu.Unlock()
+
+	s.lifted.Add(1)
+	s.audit(ctx, "client_unbanned", subject).Info("ban lifted", zap.Int("level", ban.Level), zap.Time("expiresAt", ban.ExpiresAt))
+	return nil
+}
+
+func (s *BanService) Metrics() *models.BanMetrics {
+	return &models.BanMetrics{
+		Violations: s.violations.Load(),
+		Bans:       s.bans.Load(),
+		Refused:    s.refused.Load(),
+		Lifted:     s.lifted.Load(),
+	}
+}
+
+func (s *BanService) cachedBan(subject string, now time.Time) *models.Ban {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached, ok := s.cached[subject]
+	if !ok {
+		return nil
+	}
+	if !cached.until.After(now) {
+		delete(s.cached, subject)
+		return nil
+	}
+	return cached.ban
+}
+
+func (s *BanService) cache(ban *models.Ban, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subject, cached := range s.cached {
+		if !cached.until.After(now) {
+			delete(s.cached, subject)
+		}
+	}
+	until := now.Add(banCacheTTL)
+	if ban.ExpiresAt.Before(until) {
+		until = ban.ExpiresAt
+	}
+	s.cached[ban.Subject] = cachedBan{ban: ban, until: until}
+}
+
+func (s *BanService) audit(ctx context.Context, event, subject string) *zap.Logger {
+	rc := reqctx.FromContext(ctx)
+	fields := []zap.Field{zap.String("event", event), zap.String("subject", subject)}
+	if rc.RequestID != "" {
+		fields = append(fields, zap.String("requestID", rc.RequestID))
+	}
+	if rc.ClientIP != "" && rc.ClientIP != subject {
+		// The admin lifting a ban
+		fields = append(fields, zap.String("clientIP", rc.ClientIP))
+	}
+	return s.logger.With(fields...)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// DashboardService builds the dashboard from the lease read model and the lease events
+// it is subscribed to. Only dashboardTrackedPeers peers are counted; once full, a new
+// peer replaces the least active one and inherits its count, so the counts of the top
+// peers are upper bounds while the busiest peers are still reported.
+type DashboardService struct {
+	enabled bool
+	queries ports.LeaseQueryService
+	tenants ports.TenantResolver
+	started time.Time
+
+	mu           sync.Mutex
+	recent       []*models.DashboardAllocation // ring buffer, next is the oldest entry once full
+	next         int
+	peers        map[dashboardPeerKey]int64
+	trackedPeers int
+}
+
+type dashboardPeerKey struct {
+	tenant string
+	peerID string
+}
+
+var (
+	_ ports.DashboardService    = &DashboardService{}
+	_ ports.LeaseEventPublisher = &DashboardService{}
+)
+
+func NewDashboardService(cfg *config.AppConfig, queries ports.LeaseQueryService, tenants ports.TenantResolver) *DashboardService {
+	return &DashboardService{
+		enabled:      cfg.DashboardEnabled,
+		queries:      queries,
+		tenants:      tenants,
+		started:      time.Now(),
+		recent:       make([]*models.DashboardAllocation, 0, max(cfg.DashboardRecentAllocations, 1)),
+		peers:        make(map[dashboardPeerKey]int64),
+		trackedPeers: max(cfg.DashboardTrackedPeers, 1),
+	}
+}
+
+// Publish records allocations and renewals, doing nothing unless the dashboard is enabled
+func (s *DashboardService) Publish(event *models.LeaseLifecycleEvent) {
+	if !s.enabled {
+		return
+	}
+
+	switch event.Type {
+	case models.LeaseLifecycleAllocated:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.recordAllocation(&models.DashboardAllocation{
+			TokenID:     event.TokenID,
+			IP:          utils.IPFromTokenID(uint32(event.TokenID)),
+			PeerID:      event.PeerID,
+			Tenant:      event.TenantID,
+			AllocatedAt: event.OccurredAt,
+			ExpiresAt:   event.ExpiresAt,
+		})
+		s.countPeer(dashboardPeerKey{event.TenantID, event.PeerID})
+	case models.LeaseLifecycleRenewed:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.countPeer(dashboardPeerKey{event.TenantID, event.PeerID})
+	}
+}
+
+func (s *DashboardService) Summary(ctx context.Context) (*models.DashboardSummary, error) {
+	stats, err := s.queries.GetLeaseStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &models.PoolUtilization{Active: stats.Active}
+	for _, tenant := range s.tenants.Tenants() {
+		pool.Size += tenant.MaxTokenID - tenant.MinTokenID + 1
+	}
+	if pool.Size > 0 {
+		pool.Utilization = float64(pool.Active) / float64(pool.Size)
+	}
+
+	return &models.DashboardSummary{
+		Pool:      pool,
+		Leases:    stats,
+		StartedAt: s.started,
+	}, nil
+}
+
+func (s *DashboardService) RecentAllocations() []*models.DashboardAllocation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	allocations := make([]*models.DashboardAllocation, 0, len(s.recent))
+	for i := range s.recent {
+		allocations = append(allocations, s.recent[(s.next-1-i+len(s.recent))%len(s.recent)])
+	}
+	return allocations
+}
+
+func (s *DashboardService) TopPeers(limit int) []*models.DashboardPeer {
+	s.mu.Lock()
+	peers := make([]*models.DashboardPeer, 0, len(s.peers))
+	for key, n := range s.peers {
+		peers = append(peers, &models.DashboardPeer{PeerID: key.peerID, Tenant: key.tenant, Leases: n})
+	}
+	s.mu.Unlock()
+
+	slices.SortFunc(peers, func(a, b *models.DashboardPeer) int {
+		return cmp.Or(cmp.Compare(b.Leases, a.Leases), strings.Compare(a.Tenant, b.Tenant), strings.Compare(a.PeerID, b.PeerID))
+	})
+	if len(peers) > limit {
+		peers = peers[:limit]
+	}
+	return peers
+}
+
+func (s *DashboardService) recordAllocation(allocation *models.DashboardAllocation) {
+	if len(s.recent) < cap(s.recent) {
+		s.recent = append(s.recent, allocation)
+	} else {
+		s.recent[s.next] = allocation
+	}
+	s.next = (s.next + 1) % cap(s.recent)
+}
+
+func (s *DashboardService) countPeer(key dashboardPeerKey) {
+	if _, ok := s.peers[key]; !ok && len(s.peers) >= s.trackedPeers {
+		var evicted dashboardPeerKey
+		var fewest int64 = -1
+		for k, n := range s.peers {
+			if fewest < 0 || n < fewest {
+				evicted, fewest = k, n
+			}
+		}
+		delete(s.peers, evicted)
+		s.peers[key] = fewest
+	}
+	s.peers[key]++
+}
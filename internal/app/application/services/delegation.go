@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+	"go.uber.org/zap"
+)
+
+// DelegationService allocates leases for downstream peers behind a relay or gateway node.
+// The gateway authenticates itself and vouches for the downstream key with a signature;
+// the downstream peer never talks to the server.
+type DelegationService struct {
+	leases        ports.LeaseWriter
+	repo          ports.LeaseRepository
+	verifier      ports.SignatureVerifier
+	identity      ports.IdentityResolver
+	accessControl ports.AccessControlService
+	logger        *zap.Logger
+	quotas        map[string]int // delegated leases per gateway peer ID, 0 for no quota
+}
+
+var _ ports.DelegationService = &DelegationService{}
+
+func NewDelegationService(cfg *config.AppConfig, leases ports.LeaseWriter, repo ports.LeaseRepository, verifier ports.SignatureVerifier, identity ports.IdentityResolver, accessControl ports.AccessControlService, logger *zap.Logger) *DelegationService {
+	quotas := make(map[string]int, len(cfg.DelegationGateways))
+	for _, gateway := range cfg.DelegationGateways {
+		quotas[gateway.PeerID] = gateway.MaxLeases
+	}
+	return &DelegationService{leases, repo, verifier, identity, accessControl, logger, quotas}
+}
+
+// AllocateDelegatedIP checks that the caller is a gateway, that its signature vouches for
+// the downstream key and that the downstream peer may use the service, then allocates
+// the lease like a regular one and records the gateway as its delegator. The quota is
+// checked before allocating, so concurrent delegations of one gateway may overshoot it.
+func (s *DelegationService) AllocateDelegatedIP(ctx context.Context, request *models.LeaseDelegationRequest) (*models.Lease, error) {
+	audit := s.logger.Named("audit").With(zap.String("event", "lease_delegation"), zap.String("gatewayPeerID", request.GatewayPeerID))
+
+	quota, ok := s.quotas[request.GatewayPeerID]
+	if !ok {
+		audit.Warn("lease delegation rejected", zap.Error(errors.ErrNotAGateway))
+		return nil, errors.ErrNotAGateway
+	}
+
+	delegatePeerID, err := s.identity.ResolvePeerID(request.DelegatePubkey)
+	if err != nil {
+		return nil, errors.ErrInvalidPubkey
+	}
+	audit = audit.With(zap.String("delegatePeerID", delegatePeerID))
+
+	if delegatePeerID == request.GatewayPeerID {
+		return nil, errors.ErrDelegationToSelf
+	}
+
+	payload := crypto.DelegationPayload(request.NonceID, delegatePeerID)
+	if err := s.verifier.VerifySignature(ctx, request.GatewayPubkey, payload, request.Signature); err != nil {
+		audit.Warn("lease delegation rejected", zap.Error(err))
+		return nil, errors.ErrDelegationUnauthorized
+	}
+
+	if err := s.accessControl.CheckAccess(ctx, delegatePeerID, request.DelegatePubkey); err != nil {
+		audit.Warn("lease delegation rejected", zap.Error(err))
+		return nil, err
+	}
+
+	if quota > 0 {
+		delegated, err := s.repo.CountDelegatedLeases(ctx, request.GatewayPeerID)
+		if err != nil {
+			return nil, err
+		}
+		if delegated >= int64(quota) {
+			audit.Warn("lease delegation rejected", zap.Int64("delegatedLeases", delegated), zap.Error(errors.ErrDelegationQuota))
+			return nil, errors.ErrDelegationQuota
+		}
+	}
+
+	lease, err := s.leases.AllocateIP(ctx, delegatePeerID)
+	if err != nil {
+		audit.Warn("lease delegation failed", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.repo.SetLeaseDelegator(ctx, lease.TokenID, request.GatewayPeerID); err != nil {
+		audit.Error("error recording lease delegator", zap.Int64("tokenID", lease.TokenID), zap.Error(err))
+	}
+
+	audit.Info("lease delegated", zap.Int64("tokenID", lease.TokenID))
+	return lease, nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// ExpiryNotificationService tells the holders of leases that are about to expire, so
+// peers with broken renewal timers can react before losing their address
+type ExpiryNotificationService struct {
+	repo      ports.LeaseRepository
+	channels  []ports.ExpiryChannel
+	window    time.Duration
+	batchSize int
+	logger    *zap.Logger
+
+	mu   sync.Mutex
+	last *models.ExpiryNotificationReport
+}
+
+var _ ports.ExpiryNotificationService = &ExpiryNotificationService{}
+
+func NewExpiryNotificationService(appConfig *config.AppConfig, repo ports.LeaseRepository, channels []ports.ExpiryChannel, logger *zap.Logger) *ExpiryNotificationService {
+	return &ExpiryNotificationService{
+		repo:      repo,
+		channels:  channels,
+		window:    time.Duration(appConfig.ExpiryNotifyWindow) * time.Minute,
+		batchSize: appConfig.ExpiryNotifyBatchSize,
+		logger:    logger,
+	}
+}
+
+// Run pages through the expiring leases and hands every page to each channel as one
+// batch. A failing channel does not stop delivery through the others.
+func (s *ExpiryNotificationService) Run(ctx context.Context) (*models.ExpiryNotificationReport, error) {
+	report := &models.ExpiryNotificationReport{
+		StartedAt: time.Now(),
+		Channels:  make([]*models.ExpiryChannelResult, len(s.channels)),
+	}
+	report.Until = report.StartedAt.Add(s.window)
+	for i, channel := range s.channels {
+		report.Channels[i] = &models.ExpiryChannelResult{Channel: channel.Name()}
+	}
+
+	err := s.run(ctx, report)
+	report.FinishedAt = time.Now()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.last = report
+	s.mu.Unlock()
+
+	return report, nil
+}
+
+func (s *ExpiryNotificationService) run(ctx context.Context, report *models.ExpiryNotificationReport) error {
+	var afterTokenID int64
+	for {
+		leases, err := s.repo.ListExpiringLeases(ctx, s.window, afterTokenID, s.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(leases) == 0 {
+			return nil
+		}
+		afterTokenID = leases[len(leases)-1].TokenID
+		report.Found += len(leases)
+
+		for i, channel := range s.channels {
+			result := report.Channels[i]
+			result.Batches++
+			if err := channel.Notify(ctx, leases); err != nil {
+				result.Failed += len(leases)
+				result.LastError = err.Error()
+				s.logger.Warn("Failed to deliver expiring lease notifications",
+					zap.String("channel", result.Channel),
+					zap.Int("leases", len(leases)),
+					zap.Error(err),
+				)
+				continue
+			}
+			result.Notified += len(leases)
+		}
+
+		if len(leases) < s.batchSize {
+			return nil
+		}
+	}
+}
+
+func (s *ExpiryNotificationService) LastReport() *models.ExpiryNotificationReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.last
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// errReplayEnd stops reading the journal at the first entry past the replay target
+var errReplayEnd = errors.New("end of replay")
+
+// LeaseJournalService checks the lease journal against the store and rebuilds the
+// store from a journal
+type LeaseJournalService struct {
+	journal   ports.LeaseJournal
+	repo      ports.LeaseSnapshotRepository
+	snapshots ports.LeaseSnapshotService
+	clock     ports.Clock
+	logger    *zap.Logger
+
+	mu       sync.Mutex
+	previous []string // mismatches of the last check
+}
+
+var _ ports.LeaseJournalService = &LeaseJournalService{}
+
+// NewLeaseJournalService creates the service; journal is nil unless the lease journal is
+// enabled, which only allows replays
+func NewLeaseJournalService(journal ports.LeaseJournal, repo ports.LeaseSnapshotRepository, snapshots ports.LeaseSnapshotService, clock ports.Clock, logger *zap.Logger) *LeaseJournalService {
+	return &LeaseJournalService{journal: journal, repo: repo, snapshots: snapshots, clock: clock, logger: logger.Named("journal")}
+}
+
+// Verify compares the leases the journal describes with the stored ones. The store and
+// the journal are written one after the other, so a mutation in flight can tell them
+// apart for a moment; only differences seen in two checks in a row are reported.
+func (s *LeaseJournalService) Verify(ctx context.Context) (*models.JournalVerification, error) {
+	if s.journal == nil {
+		return nil, errors.New("the lease journal is not enabled")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.repo.ExportLeaseState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.journal.NeedsCheckpoint() {
+		s.previous = nil
+		return s.checkpoint(ctx, store, nil)
+	}
+
+	seq, mismatches := s.journal.Compare(store, s.clock.Now())
+	var persistent []string
+	for _, mismatch := range mismatches {
+		if slices.Contains(s.previous, mismatch) {
+			persistent = append(persistent, mismatch)
+		}
+	}
+	s.previous = mismatches
+	if len(persistent) == 0 {
+		return &models.JournalVerification{Seq: seq}, nil
+	}
+
+	s.logger.Error("Lease journal differs from the store, writing a checkpoint",
+		zap.Int64("seq", seq),
+		zap.Strings("mismatches", persistent),
+	)
+	s.previous = nil
+	return s.checkpoint(ctx, store, persistent)
+}
+
+// checkpoint records the store in the journal
+func (s *LeaseJournalService) checkpoint(ctx context.Context, store *models.LeaseSnapshot, mismatches []string) (*models.JournalVerification, error) {
+	store.Version = models.SnapshotVersion
+	store.ExportedAt = s.clock.Now().UTC()
+	seq, err := s.journal.Checkpoint(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Wrote lease journal checkpoint", zap.Int64("seq", seq), zap.Int("leases", len(store.Leases)))
+	return &models.JournalVerification{Seq: seq, Mismatches: mismatches, Checkpointed: true}, nil
+}
+
+func (s *LeaseJournalService) Replay(ctx context.Context, source ports.JournalSource, until func(entry *models.JournalEntry) bool, replace bool) (*models.JournalReplayReport, error) {
+	state := models.NewJournalState()
+	report := &models.JournalReplayReport{}
+	var last *models.JournalEntry
+
+	err := source.Read(ctx, func(entry *models.JournalEntry) error {
+		if until != nil && !until(entry) {
+			return errReplayEnd
+		}
+		if state.Apply(entry) {
+			report.Entries++
+		} else if state.Checkpoint() > 0 {
+			report.Skipped++
+		}
+		if entry.Op == models.JournalOpCheckpoint {
+			report.Entries, report.Skipped = 0, 0
+		}
+		last = entry
+		return nil
+	})
+	if err != nil && !errors.Is(err, errReplayEnd) {
+		return nil, fmt.Errorf("read lease journal: %w", err)
+	}
+	if state.Checkpoint() == 0 {
+		return nil, errors.New("the lease journal holds no checkpoint up to the replay target")
+	}
+	report.Checkpoint = state.Checkpoint()
+	report.LastSeq = state.Seq()
+
+	snapshot := state.Snapshot()
+	snapshot.ExportedAt = last.Time
+	if report.Import, err = s.snapshots.Import(ctx, snapshot, replace); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Replayed lease journal",
+		zap.Int64("checkpoint", report.Checkpoint),
+		zap.Int64("lastSeq", report.LastSeq),
+		zap.Int("entries", report.Entries),
+		zap.Int("skipped", report.Skipped),
+	)
+	return report, nil
+}
@@ -2,103 +2,494 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"strconv"
 	"time"
 
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/retry"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
 	"go.uber.org/zap"
 )
 
 type LeaseService struct {
-	repo       ports.LeaseRepository
-	logger     *zap.Logger
-	maxRetries int
-	retryDelay time.Duration
+	repo               ports.LeaseRepository
+	strategy           ports.AllocationStrategy
+	verifier           ports.SignatureVerifier
+	identity           ports.IdentityResolver
+	accessControl      ports.AccessControlService // nil skips the deny and allow lists on transfers
+	signer             ports.LeaseSigner          // nil leaves leases unsigned
+	events             ports.LeaseEventPublisher  // nil publishes no lifecycle events
+	clock              ports.Clock
+	logger             *zap.Logger
+	allocationRetry    retry.Policy
+	batchMaxOperations int
+	conflictQuarantine time.Duration // 0 keeps the lease of a peer reporting a conflict
+	renewalWindow      time.Duration // 0 renews leases any time
+	renewHint          float64       // share of the remaining lifetime after which holders should renew, 0 omits the hint
+	renewHintJitter    float64
 }
 
 var _ ports.LeaseService = &LeaseService{}
 
-func NewLeaseService(appConfig *config.AppConfig, repo ports.LeaseRepository, logger *zap.Logger) *LeaseService {
-	return &LeaseService{repo, logger, appConfig.MaxLeaseRetries, time.Duration(appConfig.LeaseRetryDelay) * time.Millisecond}
+func NewLeaseService(appConfig *config.AppConfig, repo ports.LeaseRepository, strategy ports.AllocationStrategy, verifier ports.SignatureVerifier, identity ports.IdentityResolver, accessControl ports.AccessControlService, signer ports.LeaseSigner, events ports.LeaseEventPublisher, clock ports.Clock, logger *zap.Logger) *LeaseService {
+	allocationRetry := retry.Policy{
+		MaxAttempts:  appConfig.Lease.MaxRetries,
+		InitialDelay: time.Duration(appConfig.Lease.RetryDelay) * time.Millisecond,
+		MaxDelay:     time.Duration(appConfig.Lease.RetryMaxDelay) * time.Millisecond,
+		Jitter:       retry.DefaultJitter,
+		// A concurrent allocation for the same peer won, retrying cannot succeed
+		Retryable: func(err error) bool { return !isFinalAllocationError(err) },
+	}
+	return &LeaseService{repo, strategy, verifier, identity, accessControl, signer, events, clock, logger, allocationRetry, appConfig.Lease.BatchMaxOperations, time.Duration(appConfig.Lease.ConflictQuarantine) * time.Minute, time.Duration(appConfig.Lease.RenewalWindow) * time.Minute, float64(appConfig.Lease.RenewHintPercent) / 100, float64(appConfig.Lease.RenewHintJitter) / 100}
 }
 
+// AllocateIP returns the peer's active lease or allocates one with the configured
+// allocation strategy, retrying failed attempts
 func (s *LeaseService) AllocateIP(ctx context.Context, peerID string) (*models.Lease, error) {
-	var lease *models.Lease
-	var err error
+	if err := models.ValidatePeerID(peerID); err != nil {
+		return nil, err
+	}
 
 	// Check if the lease is already allocated
-	lease, err = s.repo.GetLeaseByPeerID(ctx, peerID)
+	lease, err := s.repo.GetLeaseByPeerID(ctx, peerID)
 	if lease != nil && err == nil {
-		return lease, nil
+		return s.issue(ctx, lease), nil
 	}
 
-	maxRetries := s.maxRetries
-	retries := 0
+	policy := s.allocationRetry
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		s.logger.
+			With(zap.String("retries", strconv.Itoa(attempt)), zap.String("peerID", peerID), zap.String("strategy", s.strategy.Name())).
+			Error("error allocating lease", zap.Error(err), zap.Duration("retryIn", delay))
+	}
 
-	// Try to find an expired lease for reuse
-	for {
-		retries++
-		if retries > maxRetries {
-			// If we've tried too many times, try to allocate a new lease
-			break
-		}
+	lease, err = retry.DoValue(ctx, policy, func(ctx context.Context) (*models.Lease, error) {
+		return s.strategy.Allocate(ctx, peerID)
+	})
+	if isFinalAllocationError(err) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate new lease: %v", err)
+	}
 
-		lease, err = s.repo.FindAndReuseExpiredLease(ctx, peerID)
-		if err != nil {
-			// If we encounter an error, try again
-			s.logger.With(zap.String("retries", strconv.Itoa(retries)), zap.String("peerID", peerID)).Error("error finding and reusing expired lease", zap.Error(err))
+	s.publish(ctx, models.LeaseLifecycleAllocated, lease)
+	return s.issue(ctx, lease), nil
+}
 
-			// Sleep for 500 milliseconds
-			time.Sleep(s.retryDelay)
-			continue
-		}
+// AllocateRequestedIP tries to assign the requested token ID to the peer and falls back
+// to a regular allocation when it cannot be granted. The result explains what happened.
+func (s *LeaseService) AllocateRequestedIP(ctx context.Context, peerID string, requestedTokenID int64) (*models.AllocationResult, error) {
+	if err := models.ValidatePeerID(peerID); err != nil {
+		return nil, err
+	}
+	result := &models.AllocationResult{RequestedTokenID: requestedTokenID}
 
-		if lease != nil {
-			// Already reused, return the lease
-			return lease, nil
-		}
+	// A peer that already holds a lease keeps it
+	lease, err := s.repo.GetLeaseByPeerID(ctx, peerID)
+	if lease != nil && err == nil {
+		result.Lease = s.issue(ctx, lease)
+		result.Granted = lease.TokenID == requestedTokenID
+		result.Reason = models.AllocationReasonExistingLease
+		return result, nil
+	}
+
+	lease, err = s.repo.AllocateRequestedLease(ctx, peerID, requestedTokenID)
+	if err == nil {
+		s.publish(ctx, models.LeaseLifecycleAllocated, lease)
+		result.Lease = s.issue(ctx, lease)
+		result.Granted = true
+		result.Reason = models.AllocationReasonGranted
+		return result, nil
+	}
+
+	switch {
+	case isFinalAllocationError(err):
+		return nil, err
+	case errors.Is(err, domainErrors.ErrTokenIDOutOfRange):
+		result.Reason = models.AllocationReasonOutOfRange
+	case errors.Is(err, domainErrors.ErrTokenIDInUse):
+		result.Reason = models.AllocationReasonInUse
+	default:
+		s.logger.With(zap.Int64("tokenID", requestedTokenID), zap.String("peerID", peerID)).Error("error allocating requested lease", zap.Error(err))
+		result.Reason = models.AllocationReasonUnavailable
+	}
+
+	// Fall back to a regular allocation
+	lease, err = s.AllocateIP(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+	result.Lease = lease
+
+	return result, nil
+}
+
+// AllocateAffinityIP allocates a lease next to the other members of the affinity group when
+// possible, falling back to a regular allocation. The lease is tagged with the group either way.
+func (s *LeaseService) AllocateAffinityIP(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error) {
+	if err := models.ValidatePeerID(peerID); err != nil {
+		return nil, err
+	}
+
+	// A peer that already holds a lease keeps it
+	lease, err := s.repo.GetLeaseByPeerID(ctx, peerID)
+	if lease != nil && err == nil {
+		return s.issue(ctx, lease), nil
+	}
+
+	lease, err = s.repo.AllocateAffinityLease(ctx, peerID, affinityGroup)
+	if isFinalAllocationError(err) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.With(zap.String("affinityGroup", affinityGroup), zap.String("peerID", peerID)).Error("error allocating affinity lease", zap.Error(err))
+	}
+	if lease != nil {
+		s.publish(ctx, models.LeaseLifecycleAllocated, lease)
+		return s.issue(ctx, lease), nil
+	}
+
+	// No contiguous slot, fall back to a regular allocation
+	lease, err = s.AllocateIP(ctx, peerID)
+	if err != nil {
+		return nil, err
 	}
 
-	// If no expired lease found, allocate a new one
-	retries = 0
-	for {
-		retries++
-		if retries > maxRetries {
-			return nil, fmt.Errorf("failed to allocate new lease: %v", err)
+	if err := s.repo.SetLeaseAffinityGroup(ctx, lease.TokenID, affinityGroup); err != nil {
+		s.logger.With(zap.String("affinityGroup", affinityGroup), zap.String("peerID", peerID)).Error("error tagging lease with affinity group", zap.Error(err))
+	}
+
+	return lease, nil
+}
+
+// AllocateZoneIP allocates a lease in the zone of the pool the peer asked for, so its
+// address is routed to its rack or site, falling back to a regular allocation when the
+// zone is unknown or full
+func (s *LeaseService) AllocateZoneIP(ctx context.Context, peerID string, zone string) (*models.Lease, error) {
+	if err := models.ValidatePeerID(peerID); err != nil {
+		return nil, err
+	}
+
+	// A peer that already holds a lease keeps it, wherever it is
+	lease, err := s.repo.GetLeaseByPeerID(ctx, peerID)
+	if lease != nil && err == nil {
+		return s.issue(ctx, lease), nil
+	}
+
+	lease, err = s.repo.AllocateZoneLease(ctx, peerID, zone)
+	if isFinalAllocationError(err) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.With(zap.String("zone", zone), zap.String("peerID", peerID)).Error("error allocating zone lease", zap.Error(err))
+	}
+	if lease != nil {
+		s.publish(ctx, models.LeaseLifecycleAllocated, lease)
+		return s.issue(ctx, lease), nil
+	}
+
+	// No free token ID in the zone, fall back to any zone
+	return s.AllocateIP(ctx, peerID)
+}
+
+// TransferLease hands an active lease over to a new peer identity, e.g. after key rotation.
+// The current owner authorizes the new public key by signing the transfer payload.
+func (s *LeaseService) TransferLease(ctx context.Context, request *models.LeaseTransferRequest) (*models.Lease, error) {
+	if err := models.ValidateTokenID(request.TokenID); err != nil {
+		return nil, err
+	}
+	audit := s.logger.Named("audit").With(zap.String("event", "lease_transfer"), zap.Int64("tokenID", request.TokenID))
+
+	fromPeerID, err := s.identity.ResolvePeerID(request.FromPubkey)
+	if err != nil {
+		return nil, domainErrors.ErrInvalidPubkey
+	}
+	toPeerID, err := s.identity.ResolvePeerID(request.ToPubkey)
+	if err != nil {
+		return nil, domainErrors.ErrInvalidPubkey
+	}
+	audit = audit.With(zap.String("fromPeerID", fromPeerID), zap.String("toPeerID", toPeerID))
+
+	if fromPeerID == toPeerID {
+		return nil, domainErrors.ErrTransferToSelf
+	}
+
+	payload := crypto.TransferPayload(request.NonceID, request.TokenID, toPeerID)
+	if err := s.verifier.VerifySignature(ctx, request.FromPubkey, payload, request.Signature); err != nil {
+		audit.Warn("lease transfer rejected", zap.Error(err))
+		return nil, domainErrors.ErrTransferUnauthorized
+	}
+
+	// A peer refused by the deny or allow list must not receive a lease either
+	if s.accessControl != nil {
+		if err := s.accessControl.CheckAccess(ctx, toPeerID, request.ToPubkey); err != nil {
+			audit.Warn("lease transfer rejected", zap.Error(err))
+			return nil, err
 		}
+	}
+
+	lease, err := s.repo.TransferLease(ctx, request.TokenID, fromPeerID, toPeerID)
+	if err != nil {
+		audit.Warn("lease transfer failed", zap.Error(err))
+		return nil, err
+	}
 
-		lease, err = s.repo.AllocateNewLease(ctx, peerID)
-		if err != nil {
-			s.logger.
-				With(zap.String("retries", strconv.Itoa(retries)), zap.String("peerID", peerID)).
-				Error("error allocating new lease", zap.Error(err))
+	audit.Info("lease transferred")
+	return s.issue(ctx, lease), nil
+}
+
+// ExecuteBatch runs allocate/renew/release operations for already authenticated peers in a
+// single repository transaction and reports a result per operation.
+func (s *LeaseService) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	if len(operations) == 0 {
+		return nil, domainErrors.ErrEmptyBatch
+	}
+	if s.batchMaxOperations > 0 && len(operations) > s.batchMaxOperations {
+		return nil, domainErrors.ErrBatchTooLarge
+	}
 
-			// Sleep for retry delay
-			time.Sleep(s.retryDelay)
+	results, err := s.repo.ExecuteBatch(ctx, operations)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range results {
+		if result.Err != nil {
 			continue
 		}
-
-		if lease != nil {
-			return lease, nil
+		switch operation := operations[i]; operation.Type {
+		case models.LeaseOperationAllocate:
+			s.publish(ctx, models.LeaseLifecycleAllocated, result.Lease)
+		case models.LeaseOperationRenew:
+			s.publish(ctx, models.LeaseLifecycleRenewed, result.Lease)
+		case models.LeaseOperationRelease:
+			s.publish(ctx, models.LeaseLifecycleReleased, &models.Lease{TokenID: operation.TokenID, PeerID: operation.PeerID, ExpiresAt: s.clock.Now()})
 		}
+		if result.Lease != nil {
+			result.Lease = s.issue(ctx, result.Lease)
+		}
+	}
+	return results, nil
+}
+
+// issue prepares a lease handed to its holder: signed, and with the time to renew it
+func (s *LeaseService) issue(ctx context.Context, lease *models.Lease) *models.Lease {
+	lease = s.sign(ctx, lease)
+	renewAfter := s.renewAfter(lease)
+	if renewAfter == nil {
+		return lease
+	}
+
+	issued := *lease
+	issued.RenewAfter = renewAfter
+	return &issued
+}
+
+// renewAfter suggests when to renew lease: after renewHint of its remaining lifetime, or
+// of the renewal window when that opens later, moved by up to renewHintJitter either way so
+// that peers allocated together do not all renew together
+func (s *LeaseService) renewAfter(lease *models.Lease) *time.Time {
+	if s.renewHint <= 0 {
+		return nil
+	}
+	now := s.clock.Now()
+	remaining := lease.TimeRemaining(now)
+	if remaining <= 0 {
+		return nil
+	}
+
+	start, span := now, remaining
+	if s.renewalWindow > 0 && remaining > s.renewalWindow {
+		start, span = lease.ExpiresAt.Add(-s.renewalWindow), s.renewalWindow
 	}
+	fraction := s.renewHint + s.renewHintJitter*(2*rand.Float64()-1)
+	renewAfter := start.Add(time.Duration(float64(span) * fraction))
+	return &renewAfter
+}
+
+// sign returns a copy of lease carrying the server's certificate signature, which binds
+// the lease to the tenant of ctx. The lease was already committed, so a lease that cannot
+// be signed is returned unsigned. So is an inconsistent lease, the server never certifies
+// one.
+func (s *LeaseService) sign(ctx context.Context, lease *models.Lease) *models.Lease {
+	if s.signer == nil {
+		return lease
+	}
+	if err := lease.Validate(); err != nil {
+		s.logger.Error("refusing to sign an invalid lease", zap.Int64("tokenID", lease.TokenID), zap.String("peerID", lease.PeerID), zap.Error(err))
+		return lease
+	}
+
+	signed := *lease
+	signed.Tenant = models.TenantFromContext(ctx)
+	signature, keyID, err := s.signer.SignLease(&signed)
+	if err != nil {
+		s.logger.Error("error signing lease", zap.Int64("tokenID", lease.TokenID), zap.String("peerID", lease.PeerID), zap.Error(err))
+		return lease
+	}
+	if signature == nil {
+		return lease
+	}
+
+	signed.Signature = signature
+	signed.KeyID = keyID
+	return &signed
+}
+
+// publish hands a lifecycle event for lease to the event publisher, if any
+func (s *LeaseService) publish(ctx context.Context, eventType models.LeaseLifecycleEventType, lease *models.Lease) {
+	if s.events == nil || lease == nil {
+		return
+	}
+
+	s.events.Publish(&models.LeaseLifecycleEvent{
+		Type:       eventType,
+		TokenID:    lease.TokenID,
+		PeerID:     lease.PeerID,
+		TenantID:   models.TenantFromContext(ctx),
+		ExpiresAt:  lease.ExpiresAt,
+		OccurredAt: s.clock.Now(),
+	})
+}
+
+// validateLeaseKey checks the token ID and peer ID a lease is addressed by
+func validateLeaseKey(tokenID int64, peerID string) error {
+	if err := models.ValidateTokenID(tokenID); err != nil {
+		return err
+	}
+	return models.ValidatePeerID(peerID)
+}
+
+// isFinalAllocationError reports whether an allocation failed in a way that neither
+// retrying nor falling back to another allocation path can fix
+func isFinalAllocationError(err error) bool {
+	return errors.Is(err, domainErrors.ErrLeaseQuotaExceeded) || errors.Is(err, domainErrors.ErrLeaseAlreadyExists) ||
+		errors.Is(err, domainErrors.ErrStorageDegraded)
 }
 
 func (s *LeaseService) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
+	if err := models.ValidatePeerID(peerID); err != nil {
+		return nil, err
+	}
 	return s.repo.GetLeaseByPeerID(ctx, peerID)
 }
 
 func (s *LeaseService) GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error) {
+	if err := models.ValidateTokenID(tokenID); err != nil {
+		return nil, err
+	}
 	return s.repo.GetLeaseByTokenID(ctx, tokenID)
 }
 
+// RenewLease extends the peer's lease by the lease TTL. With a renewal window configured, a
+// lease that does not expire within the window yet is returned unchanged from the lookup
+// path instead, carrying the time from which it can be renewed.
 func (s *LeaseService) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
-	return s.repo.RenewLease(ctx, tokenID, peerID)
+	if err := validateLeaseKey(tokenID, peerID); err != nil {
+		return nil, err
+	}
+	if lease := s.earlyRenewal(ctx, tokenID, peerID); lease != nil {
+		return s.issue(ctx, lease), nil
+	}
+
+	lease, err := s.repo.RenewLease(ctx, tokenID, peerID)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, models.LeaseLifecycleRenewed, lease)
+	return s.issue(ctx, lease), nil
+}
+
+// earlyRenewal returns a copy of the peer's active lease when the renewal window has not
+// opened yet. Anything else, including leases of other peers, is left to the renewal to report.
+func (s *LeaseService) earlyRenewal(ctx context.Context, tokenID int64, peerID string) *models.Lease {
+	if s.renewalWindow <= 0 {
+		return nil
+	}
+
+	lease, err := s.repo.GetLeaseByTokenID(ctx, tokenID)
+	if err != nil || lease == nil || lease.PeerID != peerID {
+		return nil
+	}
+
+	if lease.TimeRemaining(s.clock.Now()) <= s.renewalWindow {
+		return nil
+	}
+	renewableAt := lease.ExpiresAt.Add(-s.renewalWindow)
+
+	early := *lease
+	early.RenewableAt = &renewableAt
+	return &early
 }
 
 func (s *LeaseService) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
-	return s.repo.ReleaseLease(ctx, tokenID, peerID)
+	if err := validateLeaseKey(tokenID, peerID); err != nil {
+		return err
+	}
+	if err := s.repo.ReleaseLease(ctx, tokenID, peerID); err != nil {
+		return err
+	}
+	s.publish(ctx, models.LeaseLifecycleReleased, &models.Lease{TokenID: tokenID, PeerID: peerID, ExpiresAt: s.clock.Now()})
+	return nil
+}
+
+// GetLeaseHistory returns the lifecycle of a token ID, failing with ErrLeaseNotFound when
+// it was never leased
+func (s *LeaseService) GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error) {
+	if err := models.ValidateTokenID(tokenID); err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.GetLeaseHistory(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, domainErrors.ErrLeaseNotFound
+	}
+	return entries, nil
+}
+
+// ReportConflict records that another node was seen using the reporter's address. The
+// reporter must hold the active lease; with a quarantine configured its lease is released
+// and the token ID withheld, so that the next allocation moves it to a fresh address.
+func (s *LeaseService) ReportConflict(ctx context.Context, report *models.LeaseConflictReport) (*models.LeaseConflict, error) {
+	if err := validateLeaseKey(report.TokenID, report.PeerID); err != nil {
+		return nil, err
+	}
+	conflict, err := s.repo.RecordConflict(ctx, report.TokenID, report.PeerID, s.conflictQuarantine)
+	if err != nil {
+		return nil, err
+	}
+	conflict.ObservedPeerID = report.ObservedPeerID
+
+	s.logger.Warn("Address conflict reported",
+		zap.Int64("tokenID", conflict.TokenID),
+		zap.String("peerID", conflict.PeerID),
+		zap.String("observedPeerID", conflict.ObservedPeerID),
+		zap.Bool("quarantined", conflict.Quarantined),
+	)
+
+	return conflict, nil
+}
+
+// SetLeaseLabels replaces the labels of the peer's active lease after normalizing them, so
+// operators can group leases by what their holders report about themselves
+func (s *LeaseService) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) (map[string]string, error) {
+	if err := validateLeaseKey(tokenID, peerID); err != nil {
+		return nil, err
+	}
+	labels, err := models.NormalizeLeaseLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetLeaseLabels(ctx, tokenID, peerID, labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
 }
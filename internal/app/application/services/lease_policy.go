@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// PolicyLeaseWriter checks allocations, and the recipients of transfers, against the peer
+// policies before handing them to the lease writer. Renewals, releases, conflict reports
+// and labels concern a lease the peer already holds and are passed through.
+type PolicyLeaseWriter struct {
+	leases   ports.LeaseWriter
+	policies ports.PeerPolicyService
+	identity ports.IdentityResolver
+}
+
+var _ ports.LeaseWriter = &PolicyLeaseWriter{}
+
+func NewPolicyLeaseWriter(leases ports.LeaseWriter, policies ports.PeerPolicyService, identity ports.IdentityResolver) *PolicyLeaseWriter {
+	return &PolicyLeaseWriter{leases, policies, identity}
+}
+
+func (w *PolicyLeaseWriter) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
+	return w.leases.RenewLease(ctx, tokenID, peerID)
+}
+
+func (w *PolicyLeaseWriter) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
+	return w.leases.ReleaseLease(ctx, tokenID, peerID)
+}
+
+func (w *PolicyLeaseWriter) AllocateIP(ctx context.Context, peerID string) (*models.Lease, error) {
+	if err := w.policies.Authorize(ctx, peerID, models.PeerPermissionAllocate); err != nil {
+		return nil, err
+	}
+	return w.leases.AllocateIP(ctx, peerID)
+}
+
+func (w *PolicyLeaseWriter) AllocateRequestedIP(ctx context.Context, peerID string, requestedTokenID int64) (*models.AllocationResult, error) {
+	if err := w.policies.Authorize(ctx, peerID, models.PeerPermissionReserve); err != nil {
+		return nil, err
+	}
+	return w.leases.AllocateRequestedIP(ctx, peerID, requestedTokenID)
+}
+
+func (w *PolicyLeaseWriter) AllocateAffinityIP(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error) {
+	if err := w.policies.Authorize(ctx, peerID, models.PeerPermissionAllocate); err != nil {
+		return nil, err
+	}
+	return w.leases.AllocateAffinityIP(ctx, peerID, affinityGroup)
+}
+
+func (w *PolicyLeaseWriter) AllocateZoneIP(ctx context.Context, peerID string, zone string) (*models.Lease, error) {
+	if err := w.policies.Authorize(ctx, peerID, models.PeerPermissionAllocate); err != nil {
+		return nil, err
+	}
+	return w.leases.AllocateZoneIP(ctx, peerID, zone)
+}
+
+func (w *PolicyLeaseWriter) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) (map[string]string, error) {
+	return w.leases.SetLeaseLabels(ctx, tokenID, peerID, labels)
+}
+
+// TransferLease refuses a recipient its policy would not let allocate, so that a transfer
+// cannot hand out a lease the policies deny
+func (w *PolicyLeaseWriter) TransferLease(ctx context.Context, request *models.LeaseTransferRequest) (*models.Lease, error) {
+	fromPeerID, err := w.identity.ResolvePeerID(request.FromPubkey)
+	if err != nil {
+		return nil, domainErrors.ErrInvalidPubkey
+	}
+	toPeerID, err := w.identity.ResolvePeerID(request.ToPubkey)
+	if err != nil {
+		return nil, domainErrors.ErrInvalidPubkey
+	}
+	if err := w.policies.AuthorizeTransfer(ctx, fromPeerID, toPeerID); err != nil {
+		return nil, err
+	}
+	return w.leases.TransferLease(ctx, request)
+}
+
+// ExecuteBatch fails the allocations that are refused and executes the remaining
+// operations as one batch
+func (w *PolicyLeaseWriter) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	results := make([]*models.LeaseOperationResult, len(operations))
+	allowed := make([]*models.LeaseOperation, 0, len(operations))
+	indexes := make([]int, 0, len(operations))
+	for i, operation := range operations {
+		if operation.Type == models.LeaseOperationAllocate {
+			if err := w.policies.Authorize(ctx, operation.PeerID, models.PeerPermissionAllocate); err != nil {
+				results[i] = &models.LeaseOperationResult{Err: err}
+				continue
+			}
+		}
+		allowed = append(allowed, operation)
+		indexes = append(indexes, i)
+	}
+
+	if len(allowed) == len(operations) {
+		return w.leases.ExecuteBatch(ctx, operations)
+	}
+	if len(allowed) > 0 {
+		executed, err := w.leases.ExecuteBatch(ctx, allowed)
+		if err != nil {
+			return nil, err
+		}
+		for j, result := range executed {
+			results[indexes[j]] = result
+		}
+	}
+	return results, nil
+}
+
+func (w *PolicyLeaseWriter) ReportConflict(ctx context.Context, report *models.LeaseConflictReport) (*models.LeaseConflict, error) {
+	return w.leases.ReportConflict(ctx, report)
+}
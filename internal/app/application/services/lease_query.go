@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// LeaseQueryService answers reporting queries from the read model instead of the
+// transactional lease tables. Results may lag writes by one refresh interval.
+type LeaseQueryService struct {
+	readModel       ports.LeaseReadModel
+	policies        ports.PeerPolicyService
+	policiesEnabled bool // whether a peer policy source is configured
+	clock           ports.Clock
+}
+
+var _ ports.LeaseQueryService = &LeaseQueryService{}
+
+func NewLeaseQueryService(cfg *config.AppConfig, readModel ports.LeaseReadModel, policies ports.PeerPolicyService, clock ports.Clock) *LeaseQueryService {
+	return &LeaseQueryService{
+		readModel:       readModel,
+		policies:        policies,
+		policiesEnabled: cfg.PeerPolicySource != "",
+		clock:           clock,
+	}
+}
+
+func (s *LeaseQueryService) GetLeaseStats(ctx context.Context) (*models.LeaseStats, error) {
+	return s.readModel.GetLeaseStats(ctx)
+}
+
+// ListLeases returns one page of leases. One extra lease is read to tell whether another
+// page follows.
+func (s *LeaseQueryService) ListLeases(ctx context.Context, opts *models.ListOptions) (*models.LeasePage, error) {
+	limit := opts.Limit
+	probe := *opts
+	probe.Limit = limit + 1
+
+	leases, err := s.readModel.ListLeases(ctx, &probe)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.LeasePage{Leases: leases}
+	if len(leases) > limit {
+		page.Leases = leases[:limit]
+		page.HasMore = true
+	}
+	if page.Leases == nil {
+		page.Leases = []*models.Lease{}
+	}
+	return page, nil
+}
+
+// SearchLeases lists the leases matching the search and tells for each the address it
+// stands for and the peer policy governing its holder. The policy of a search is resolved
+// to the peer IDs it governs at the time of the search.
+func (s *LeaseQueryService) SearchLeases(ctx context.Context, search *models.LeaseSearch) (*models.LeaseSearchPage, error) {
+	opts := search.ListOptions
+	opts.Filters = append([]models.Filter(nil), search.Filters...)
+
+	now := s.clock.Now()
+	switch search.State {
+	case models.LeaseStateActive, "":
+		opts.Filters = append(opts.Filters, models.Filter{Field: "expires_at", Operator: models.FilterGt, Value: now})
+	case models.LeaseStateExpired:
+		opts.Filters = append(opts.Filters, models.Filter{Field: "expires_at", Operator: models.FilterLte, Value: now})
+	}
+
+	var policies []*models.PeerPolicy
+	if s.policiesEnabled {
+		var err error
+		if policies, err = s.policies.ListPolicies(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if search.Policy != "" {
+		if !s.policiesEnabled {
+			return nil, domainErrors.ErrInvalidFilter.WithDetails("cannot search by policy without peer policies")
+		}
+		filter, ok := models.PeerPolicyFilter(policies, search.Policy)
+		if !ok {
+			return nil, domainErrors.ErrInvalidFilter.WithDetails(fmt.Sprintf("no peer policy is named %q", search.Policy))
+		}
+		opts.Filters = append(opts.Filters, filter)
+	}
+
+	page, err := s.ListLeases(ctx, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.LeaseSearchPage{Leases: make([]*models.LeaseSearchHit, len(page.Leases)), HasMore: page.HasMore}
+	for i, lease := range page.Leases {
+		hit := &models.LeaseSearchHit{Lease: lease, IP: utils.IPFromTokenID(uint32(lease.TokenID))}
+		if policy := models.PeerPolicyFor(policies, lease.PeerID); policy != nil {
+			hit.Policy = policy.Name
+		}
+		result.Leases[i] = hit
+	}
+	return result, nil
+}
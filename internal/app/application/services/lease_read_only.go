@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// ReadOnlyLeaseWriter stands in for the lease writer of a read-only instance, refusing
+// every lease change with ErrReadOnly
+type ReadOnlyLeaseWriter struct{}
+
+var _ ports.LeaseWriter = ReadOnlyLeaseWriter{}
+
+func NewReadOnlyLeaseWriter() ReadOnlyLeaseWriter {
+	return ReadOnlyLeaseWriter{}
+}
+
+func (ReadOnlyLeaseWriter) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
+	return nil, domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
+	return domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) AllocateIP(ctx context.Context, peerID string) (*models.Lease, error) {
+	return nil, domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) AllocateRequestedIP(ctx context.Context, peerID string, requestedTokenID int64) (*models.AllocationResult, error) {
+	return nil, domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) AllocateAffinityIP(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error) {
+	return nil, domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) AllocateZoneIP(ctx context.Context, peerID string, zone string) (*models.Lease, error) {
+	return nil, domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) TransferLease(ctx context.Context, request *models.LeaseTransferRequest) (*models.Lease, error) {
+	return nil, domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	return nil, domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) ReportConflict(ctx context.Context, report *models.LeaseConflictReport) (*models.LeaseConflict, error) {
+	return nil, domainErrors.ErrReadOnly
+}
+
+func (ReadOnlyLeaseWriter) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) (map[string]string, error) {
+	return nil, domainErrors.ErrReadOnly
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// revocationsKept bounds the finished revocations whose outcome Revocation still reports
+const revocationsKept = 100
+
+// LeaseRevocationService revokes the active leases an admin selects by peer, token range,
+// CIDR or label. Every revocation is written to the audit log once, when it finishes.
+type LeaseRevocationService struct {
+	repo      ports.LeaseRepository
+	readModel ports.LeaseReadModel
+	tenants   ports.TenantResolver
+	events    ports.LeaseEventPublisher // nil publishes no lifecycle events
+	clock     ports.Clock
+	batchSize int
+	wait      time.Duration
+	logger    *zap.Logger
+
+	mu          sync.Mutex
+	revocations map[string]*revocationRun
+	finished    []string // IDs of the kept finished revocations, oldest first
+}
+
+// revocationRun is a revocation in progress or finished; the service's mu guards the
+// revocation
+type revocationRun struct {
+	revocation *models.LeaseRevocation
+	done       chan struct{}
+}
+
+var _ ports.LeaseRevocationService = &LeaseRevocationService{}
+
+func NewLeaseRevocationService(cfg *config.AppConfig, repo ports.LeaseRepository, readModel ports.LeaseReadModel, tenants ports.TenantResolver, events ports.LeaseEventPublisher, clock ports.Clock, logger *zap.Logger) *LeaseRevocationService {
+	return &LeaseRevocationService{
+		repo:        repo,
+		readModel:   readModel,
+		tenants:     tenants,
+		events:      events,
+		clock:       clock,
+		batchSize:   cfg.Lease.RevocationBatchSize,
+		wait:        time.Duration(cfg.Lease.RevocationWait) * time.Second,
+		logger:      logger.Named("audit"),
+		revocations: make(map[string]*revocationRun),
+	}
+}
+
+func (s *LeaseRevocationService) Revoke(ctx context.Context, request *models.LeaseRevocationRequest) (*models.LeaseRevocation, error) {
+	startedAt := s.clock.Now()
+	filters, err := s.filters(&request.Filter, startedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leases allocated since the last refresh must not escape the revocation
+	if err := s.readModel.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	matched, err := s.match(ctx, s.tenantIDs(&request.Filter), filters)
+	if err != nil {
+		return nil, err
+	}
+
+	revocation := &models.LeaseRevocation{
+		State:     models.LeaseRevocationRunning,
+		DryRun:    request.DryRun,
+		Filter:    request.Filter,
+		Reason:    request.Reason,
+		Matched:   len(matched),
+		TokenIDs:  []int64{},
+		StartedAt: startedAt,
+	}
+	if request.DryRun {
+		for _, lease := range matched {
+			revocation.TokenIDs = append(revocation.TokenIDs, lease.TokenID)
+		}
+		revocation.State = models.LeaseRevocationCompleted
+		finishedAt := s.clock.Now()
+		revocation.FinishedAt = &finishedAt
+		return revocation, nil
+	}
+
+	revocation.ID = uuid.NewString()
+	run := &revocationRun{revocation: revocation, done: make(chan struct{})}
+	s.mu.Lock()
+	s.revocations[revocation.ID] = run
+	s.mu.Unlock()
+
+	// The revocation outlives the request when it takes longer than the wait
+	go s.run(context.WithoutCancel(ctx), run, matched)
+
+	timer := time.NewTimer(s.wait)
+	defer timer.Stop()
+	select {
+	case <-run.done:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return s.snapshot(run), nil
+}
+
+func (s *LeaseRevocationService) Revocation(ctx context.Context, id string) (*models.LeaseRevocation, error) {
+	s.mu.Lock()
+	run, ok := s.revocations[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, domainErrors.ErrRevocationNotFound
+	}
+	return s.snapshot(run), nil
+}
+
+// filters translates a revocation filter into read model filters for the leases active
+// at now. The tenant's pool and the CIDR narrow the requested token range.
+func (s *LeaseRevocationService) filters(filter *models.LeaseRevocationFilter, now time.Time) ([]models.Filter, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	minTokenID, maxTokenID := filter.MinTokenID, filter.MaxTokenID
+	narrow := func(first, last int64) {
+		minTokenID = max(minTokenID, first)
+		if maxTokenID == 0 || last < maxTokenID {
+			maxTokenID = last
+		}
+	}
+	if filter.Tenant != "" {
+		tenant, err := s.tenants.Tenant(filter.Tenant)
+		if err != nil {
+			return nil, err
+		}
+		narrow(tenant.MinTokenID, tenant.MaxTokenID)
+	}
+	if filter.CIDR != "" {
+		first, last, err := cidrTokenRange(filter.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		narrow(first, last)
+	}
+
+	filters := []models.Filter{{Field: "expires_at", Operator: models.FilterGt, Value: now}}
+	if len(filter.PeerIDs) > 0 {
+		peerIDs := slices.Clone(filter.PeerIDs)
+		slices.Sort(peerIDs)
+		filters = append(filters, models.Filter{Field: "peer_id", Operator: models.FilterIn, Value: slices.Compact(peerIDs)})
+	}
+	if minTokenID > 0 {
+		filters = append(filters, models.Filter{Field: "token_id", Operator: models.FilterGte, Value: minTokenID})
+	}
+	if maxTokenID > 0 {
+		filters = append(filters, models.Filter{Field: "token_id", Operator: models.FilterLte, Value: maxTokenID})
+	}
+	if filter.LabelSelector != "" {
+		labels, _ := models.ParseLabelSelector(filter.LabelSelector)
+		filters = append(filters, labels...)
+	}
+	return filters, nil
+}
+
+// cidrTokenRange returns the token IDs of the usable addresses of an IPv4 prefix. Token
+// IDs skip the .0 and .255 addresses, so the addresses of any prefix map to a contiguous
+// range.
+func cidrTokenRange(cidr string) (int64, int64, error) {
+	prefix := netip.MustParsePrefix(cidr).Masked()
+	first := prefix.Addr().As4()
+	last := first
+	for i := range last {
+		hostBits := max(0, min(8, 32-prefix.Bits()-8*(3-i)))
+		last[i] |= byte(1<<hostBits - 1)
+	}
+	if first[3] == 0 {
+		first[3] = 1
+	}
+	if last[3] == 255 {
+		last[3] = 254
+	}
+
+	firstTokenID := utils.TokenIDFromIP(netip.AddrFrom4(first).String())
+	lastTokenID := utils.TokenIDFromIP(netip.AddrFrom4(last).String())
+	if firstTokenID == 0 || lastTokenID == 0 || firstTokenID > lastTokenID {
+		return 0, 0, domainErrors.ErrInvalidRevocation.WithDetails("cidr must contain usable addresses within 10.0.0.0/8")
+	}
+	return int64(firstTokenID), int64(lastTokenID), nil
+}
+
+// tenantIDs returns the tenants whose leases a revocation filter selects from: the one it
+// names, every tenant otherwise
+func (s *LeaseRevocationService) tenantIDs(filter *models.LeaseRevocationFilter) []string {
+	if filter.Tenant != "" {
+		return []string{filter.Tenant}
+	}
+
+	var tenantIDs []string
+	for _, tenant := range s.tenants.Tenants() {
+		tenantIDs = append(tenantIDs, tenant.ID)
+	}
+	return tenantIDs
+}
+
+// match lists the leases of the tenants matching filters in token ID order. The read model
+// serves one tenant at a time, a page of batchSize at a time.
+func (s *LeaseRevocationService) match(ctx context.Context, tenantIDs []string, filters []models.Filter) ([]*models.Lease, error) {
+	var matched []*models.Lease
+	for _, tenantID := range tenantIDs {
+		tenantCtx := models.WithTenant(ctx, tenantID)
+		opts := &models.ListOptions{Limit: s.batchSize, SortField: "token_id", Filters: filters}
+		for {
+			leases, err := s.readModel.ListLeases(tenantCtx, opts)
+			if err != nil {
+				return nil, err
+			}
+			matched = append(matched, leases...)
+			if len(leases) < opts.Limit {
+				break
+			}
+			last := leases[len(leases)-1]
+			opts.After = &models.Cursor{SortValue: last.TokenID, Key: last.TokenID}
+		}
+	}
+	slices.SortFunc(matched, func(a, b *models.Lease) int { return cmp.Compare(a.TokenID, b.TokenID) })
+	return matched, nil
+}
+
+func (s *LeaseRevocationService) run(ctx context.Context, run *revocationRun, matched []*models.Lease) {
+	defer close(run.done)
+
+	err := s.revoke(ctx, run, matched)
+
+	s.mu.Lock()
+	revocation := run.revocation
+	finishedAt := s.clock.Now()
+	revocation.FinishedAt = &finishedAt
+	revocation.State = models.LeaseRevocationCompleted
+	if err != nil {
+		revocation.State = models.LeaseRevocationFailed
+		revocation.Error = err.Error()
+	}
+	s.finished = append(s.finished, revocation.ID)
+	if len(s.finished) > revocationsKept {
+		delete(s.revocations, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+	fields := []zap.Field{
+		zap.String("event", "lease_revocation"),
+		zap.String("revocationID", revocation.ID),
+		zap.Any("filter", revocation.Filter),
+		zap.String("reason", revocation.Reason),
+		zap.Int("matched", revocation.Matched),
+		zap.Int("revoked", revocation.Revoked),
+		zap.Int("skipped", revocation.Skipped),
+		zap.Duration("duration", finishedAt.Sub(revocation.StartedAt)),
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		s.logger.Error("Lease revocation failed", append(fields, zap.Error(err))...)
+		return
+	}
+	s.logger.Info("Revoked leases", fields...)
+}
+
+// revoke releases the matched leases in batches. A batch spanning two pools is revoked
+// in one transaction per tenant; a lease outside every pool is skipped.
+func (s *LeaseRevocationService) revoke(ctx context.Context, run *revocationRun, matched []*models.Lease) error {
+	for start := 0; start < len(matched); start += s.batchSize {
+		batch := matched[start:min(start+s.batchSize, len(matched))]
+
+		byTenant := make(map[string][]*models.Lease)
+		skipped := 0
+		for _, lease := range batch {
+			tenant := s.tenantOf(lease.TokenID)
+			if tenant == nil {
+				skipped++
+				continue
+			}
+			byTenant[tenant.ID] = append(byTenant[tenant.ID], lease)
+		}
+
+		var revoked []int64
+		for tenantID, leases := range byTenant {
+			tenantRevoked, err := s.repo.RevokeLeases(models.WithTenant(ctx, tenantID), leases)
+			if err != nil {
+				return err
+			}
+			s.publishReleased(tenantID, leases, tenantRevoked)
+			revoked = append(revoked, tenantRevoked...)
+		}
+		slices.Sort(revoked)
+
+		s.mu.Lock()
+		revocation := run.revocation
+		revocation.Processed += len(batch)
+		revocation.Revoked += len(revoked)
+		revocation.Skipped += len(batch) - len(revoked)
+		revocation.TokenIDs = append(revocation.TokenIDs, revoked...)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+func (s *LeaseRevocationService) tenantOf(tokenID int64) *models.Tenant {
+	for _, tenant := range s.tenants.Tenants() {
+		if tenant.Contains(tokenID) {
+			return tenant
+		}
+	}
+	return nil
+}
+
+// publishReleased publishes a lease.released event for every lease that was revoked
+func (s *LeaseRevocationService) publishReleased(tenantID string, leases []*models.Lease, revoked []int64) {
+	if s.events == nil {
+		return
+	}
+
+	now := s.clock.Now()
+	for _, lease := range leases {
+		if !slices.Contains(revoked, lease.TokenID) {
+			continue
+		}
+		s.events.Publish(&models.LeaseLifecycleEvent{
+			Type:       models.LeaseLifecycleReleased,
+			TokenID:    lease.TokenID,
+			PeerID:     lease.PeerID,
+			TenantID:   tenantID,
+			ExpiresAt:  now,
+			OccurredAt: now,
+		})
+	}
+}
+
+// snapshot copies the revocation of a run
+func (s *LeaseRevocationService) snapshot(run *revocationRun) *models.LeaseRevocation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revocation := *run.revocation
+	revocation.TokenIDs = slices.Clone(run.revocation.TokenIDs)
+	return &revocation
+}
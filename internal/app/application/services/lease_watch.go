@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// LeaseWatchService hands the lease events of the event bus to the requests waiting for
+// a change of the token ID. The bus delivers the events of every instance sharing its
+// broker, so a wait ends whichever instance changed the lease.
+type LeaseWatchService struct {
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	watchers map[leaseWatchKey]map[chan *models.LeaseLifecycleEvent]struct{}
+}
+
+type leaseWatchKey struct {
+	tenant  string
+	tokenID int64
+}
+
+var _ ports.LeaseWatcher = &LeaseWatchService{}
+
+func NewLeaseWatchService(bus ports.EventBus, logger *zap.Logger) *LeaseWatchService {
+	s := &LeaseWatchService{
+		logger:   logger.Named("watch"),
+		watchers: make(map[leaseWatchKey]map[chan *models.LeaseLifecycleEvent]struct{}),
+	}
+	for _, eventType := range models.LeaseLifecycleEventTypes {
+		bus.Subscribe("lease-watch", string(eventType), s.handle)
+	}
+	return s
+}
+
+// handle wakes the watchers of the lease of a bus event. A malformed event is dropped, a
+// redelivery could not decode it either.
+func (s *LeaseWatchService) handle(ctx context.Context, event *models.Event) error {
+	var payload models.LeaseEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		s.logger.Warn("Dropping malformed lease event", zap.String("topic", event.Topic), zap.String("eventID", event.ID), zap.Error(err))
+		return nil
+	}
+
+	s.Publish(&models.LeaseLifecycleEvent{
+		Type:       models.LeaseLifecycleEventType(event.Topic),
+		TokenID:    payload.TokenID,
+		PeerID:     payload.PeerID,
+		TenantID:   payload.Tenant,
+		ExpiresAt:  payload.ExpiresAt,
+		OccurredAt: payload.OccurredAt,
+	})
+	return nil
+}
+
+// Publish wakes the watchers of the lease without blocking
+func (s *LeaseWatchService) Publish(event *models.LeaseLifecycleEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for events := range s.watchers[leaseWatchKey{event.TenantID, event.TokenID}] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+func (s *LeaseWatchService) Watch(tenantID string, tokenID int64) (<-chan *models.LeaseLifecycleEvent, func()) {
+	key := leaseWatchKey{tenantID, tokenID}
+	events := make(chan *models.LeaseLifecycleEvent, 1)
+
+	s.mu.Lock()
+	if s.watchers[key] == nil {
+		s.watchers[key] = make(map[chan *models.LeaseLifecycleEvent]struct{})
+	}
+	s.watchers[key][events] = struct{}{}
+	s.mu.Unlock()
+
+	stop := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.watchers[key], events)
+		if len(s.watchers[key]) == 0 {
+			delete(s.watchers, key)
+		}
+	}
+	return events, stop
+}
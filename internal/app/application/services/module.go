@@ -2,7 +2,9 @@ package services
 
 import (
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 )
 
 var Module = fx.Options(
@@ -14,10 +16,95 @@ var Module = fx.Options(
 		fx.Annotate(
 			NewLeaseService,
 			fx.As(new(ports.LeaseService)),
+			fx.As(new(ports.LeaseReader)),
+		),
+		// A read-only instance refuses lease changes, peer policies gate allocations
+		func(cfg *config.AppConfig, service ports.LeaseService, policies ports.PeerPolicyService, identity ports.IdentityResolver) ports.LeaseWriter {
+			if cfg.ReadOnly {
+				return NewReadOnlyLeaseWriter()
+			}
+			if cfg.PeerPolicySource != "" {
+				return NewPolicyLeaseWriter(service, policies, identity)
+			}
+			return service
+		},
+		fx.Annotate(
+			NewLeaseQueryService,
+			fx.As(new(ports.LeaseQueryService)),
+		),
+		fx.Annotate(
+			NewReclamationService,
+			fx.As(fx.Self()),
+			fx.As(new(ports.ReclamationService)),
+		),
+		fx.Annotate(
+			NewExpiryNotificationService,
+			fx.As(new(ports.ExpiryNotificationService)),
 		),
 		fx.Annotate(
 			NewAuthService,
 			fx.As(new(ports.AuthService)),
 		),
+		fx.Annotate(
+			NewAccessControlService,
+			fx.As(new(ports.AccessControlService)),
+		),
+		// Rate limit violations only escalate to bans when enabled
+		func(cfg *config.AppConfig, store ports.BanStore, clock ports.Clock, logger *zap.Logger) ports.BanService {
+			if !cfg.RateLimit.BanEnabled {
+				return nil
+			}
+			return NewBanService(cfg, store, clock, logger)
+		},
+		fx.Annotate(
+			NewPeerPolicyService,
+			fx.As(fx.Self()),
+			fx.As(new(ports.PeerPolicyService)),
+		),
+		fx.Annotate(
+			NewAPIKeyService,
+			fx.As(new(ports.APIKeyService)),
+		),
+		fx.Annotate(
+			NewDelegationService,
+			fx.As(new(ports.DelegationService)),
+		),
+		fx.Annotate(
+			NewTenantService,
+			fx.As(new(ports.TenantResolver)),
+		),
+		fx.Annotate(
+			NewDashboardService,
+			fx.As(fx.Self()),
+			fx.As(new(ports.DashboardService)),
+		),
+		fx.Annotate(
+			NewPoolStatsService,
+			fx.As(new(ports.PoolStatsService)),
+		),
+		fx.Annotate(
+			NewLeaseSnapshotService,
+			fx.As(new(ports.LeaseSnapshotService)),
+		),
+		fx.Annotate(
+			NewLeaseRevocationService,
+			fx.As(new(ports.LeaseRevocationService)),
+		),
+		fx.Annotate(
+			NewLeaseJournalService,
+			fx.As(new(ports.LeaseJournalService)),
+		),
+		// The dashboard observes allocations and renewals as they are published
+		fx.Annotate(
+			func(dashboard *DashboardService) ports.LeaseEventPublisher { return dashboard },
+			fx.ResultTags(`group:"lease_event_publishers"`),
+		),
+		// Requests waiting for a lease change are woken by its events on the event bus
+		fx.Annotate(
+			NewLeaseWatchService,
+			fx.As(new(ports.LeaseWatcher)),
+		),
 	),
+	config.ReloadTarget[*ReclamationService](),
+	config.ReloadTarget[*PeerPolicyService](),
 )
@@ -1,30 +1,87 @@
 package services
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+const (
+	nonceRateWindow   = time.Minute
+	nonceMetricsPeers = 20 // busiest peers reported by Metrics
 )
 
 type NonceService struct {
 	repo              ports.NonceRepository
 	signatureVerifier ports.SignatureVerifier
+	identity          ports.IdentityResolver
+	maxOutstanding    int
+	reuseAtCap        bool
+	cleanupBatchSize  int           // expired nonces deleted per statement, 0 deletes them all at once
+	cleanupBatchPause time.Duration // pause between cleanup batches
+
+	mu      sync.Mutex
+	metrics models.NonceMetrics
+	rates   nonceRates
 }
 
 var _ ports.NonceService = &NonceService{}
 
-func NewNonceService(repo ports.NonceRepository, signatureVerifier ports.SignatureVerifier) *NonceService {
-	return &NonceService{repo, signatureVerifier}
+func NewNonceService(cfg *config.AppConfig, repo ports.NonceRepository, signatureVerifier ports.SignatureVerifier, identity ports.IdentityResolver) *NonceService {
+	return &NonceService{
+		repo:              repo,
+		signatureVerifier: signatureVerifier,
+		identity:          identity,
+		maxOutstanding:    cfg.Nonce.MaxOutstanding,
+		reuseAtCap:        cfg.Nonce.ReuseAtCap,
+		cleanupBatchSize:  cfg.Nonce.CleanerBatchSize,
+		cleanupBatchPause: time.Duration(cfg.Nonce.CleanerBatchPause) * time.Millisecond,
+	}
 }
 
+// CreateNonce issues a nonce for the peer. Once the peer holds maxOutstanding unused nonces,
+// its newest one is handed out again or the request is rejected, depending on reuseAtCap.
+// Concurrent requests of one peer may overshoot the cap slightly.
 func (s *NonceService) CreateNonce(ctx context.Context, peerID string) (*models.Nonce, error) {
+	if err := models.ValidatePeerID(peerID); err != nil {
+		return nil, err
+	}
+	s.record(peerID)
+
+	if s.maxOutstanding > 0 {
+		outstanding, err := s.repo.ListOutstandingNonces(ctx, peerID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(outstanding) >= s.maxOutstanding {
+			if !s.reuseAtCap {
+				s.count(&s.metrics.Rejected)
+				return nil, domainErrors.ErrTooManyNonces
+			}
+			s.count(&s.metrics.Reused)
+			return outstanding[0], nil
+		}
+	}
+
 	nonce, err := s.repo.CreateNonce(ctx, peerID)
 	if err != nil {
 		return nil, err
 	}
+	if err := nonce.Validate(); err != nil {
+		return nil, err
+	}
 
+	s.count(&s.metrics.Issued)
 	return nonce, nil
 }
 
@@ -40,8 +97,11 @@ func (s *NonceService) VerifyNonce(ctx context.Context, request *models.NonceReq
 	if err != nil {
 		return err
 	}
+	if err := nonce.Validate(); err != nil {
+		return err
+	}
 
-	peerID, err := utils.GetPeerIDFromPubkey(request.Pubkey)
+	peerID, err := s.identity.ResolvePeerID(request.Pubkey)
 	if err != nil {
 		return err
 	}
@@ -54,3 +114,178 @@ func (s *NonceService) VerifyNonce(ctx context.Context, request *models.NonceReq
 
 	return nil
 }
+
+// ListOutstandingNonces returns the unused, unexpired nonces of the peer, newest first
+func (s *NonceService) ListOutstandingNonces(ctx context.Context, peerID string) ([]*models.Nonce, error) {
+	if err := models.ValidatePeerID(peerID); err != nil {
+		return nil, err
+	}
+	nonces, err := s.repo.ListOutstandingNonces(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+	if nonces == nil {
+		nonces = []*models.Nonce{}
+	}
+	return nonces, nil
+}
+
+// RevokeNonce deletes an outstanding nonce of the peer. A nonce that is used, expired or
+// issued to another peer is not found.
+func (s *NonceService) RevokeNonce(ctx context.Context, peerID string, nonceID string) error {
+	err := s.repo.RevokeNonce(ctx, nonceID, peerID)
+	if errors.Is(err, domainErrors.ErrNonceNotFound) || errors.Is(err, domainErrors.ErrNonceUsed) || errors.Is(err, domainErrors.ErrNonceExpired) {
+		return domainErrors.ErrNonceNotFoundErr
+	}
+	if err != nil {
+		return err
+	}
+
+	s.count(&s.metrics.Revoked)
+	return nil
+}
+
+// RevokeNonces deletes every outstanding nonce of the peer. Nonces used concurrently are
+// not counted.
+func (s *NonceService) RevokeNonces(ctx context.Context, peerID string) (int64, error) {
+	nonces, err := s.ListOutstandingNonces(ctx, peerID)
+	if err != nil {
+		return 0, err
+	}
+
+	var revoked int64
+	for _, nonce := range nonces {
+		err := s.RevokeNonce(ctx, peerID, nonce.ID)
+		if errors.Is(err, domainErrors.ErrNonceNotFoundErr) {
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// CleanupExpired deletes expired nonces in batches of cleanupBatchSize, pausing between
+// batches so the deletes do not hold locks on the nonces table for long. The run stops
+// after the first batch that is not full. Nonces deleted before an error are counted.
+func (s *NonceService) CleanupExpired(ctx context.Context) (*models.NonceCleanupReport, error) {
+	report := &models.NonceCleanupReport{StartedAt: time.Now()}
+
+	var err error
+	for {
+		var deleted int64
+		deleted, err = s.repo.DeleteExpiredNonces(ctx, s.cleanupBatchSize)
+		if err != nil {
+			break
+		}
+		report.Batches++
+		report.Deleted += deleted
+
+		if s.cleanupBatchSize <= 0 || deleted < int64(s.cleanupBatchSize) {
+			break
+		}
+
+		timer := time.NewTimer(s.cleanupBatchPause)
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-timer.C:
+		}
+		timer.Stop()
+		if err != nil {
+			break
+		}
+	}
+	report.FinishedAt = time.Now()
+
+	s.mu.Lock()
+	s.metrics.Deleted += report.Deleted
+	if err == nil {
+		s.metrics.LastCleanup = report
+	}
+	s.mu.Unlock()
+
+	return report, err
+}
+
+// Metrics returns a snapshot of the nonce issuance counters and the busiest peers
+func (s *NonceService) Metrics() *models.NonceMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.metrics
+	snapshot.Peers = s.rates.busiest(time.Now(), nonceMetricsPeers)
+	return &snapshot
+}
+
+func (s *NonceService) record(peerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates.add(peerID, time.Now())
+}
+
+func (s *NonceService) count(counter *int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*counter++
+}
+
+// nonceRates counts nonce requests per peer in fixed windows and estimates the rate over the
+// last window from the current and previous one, so only peers seen in the last two windows
+// are kept in memory.
+type nonceRates struct {
+	windowStart time.Time
+	current     map[string]int64
+	previous    map[string]int64
+}
+
+func (r *nonceRates) add(peerID string, now time.Time) {
+	r.roll(now)
+	r.current[peerID]++
+}
+
+func (r *nonceRates) roll(now time.Time) {
+	elapsed := now.Sub(r.windowStart)
+	if r.current != nil && elapsed < nonceRateWindow {
+		return
+	}
+
+	if r.current != nil && elapsed < 2*nonceRateWindow {
+		r.previous = r.current
+	} else {
+		r.previous = nil
+	}
+	r.current = make(map[string]int64)
+	r.windowStart = now.Truncate(nonceRateWindow)
+}
+
+// busiest returns up to limit peers ordered by their estimated requests over the last window
+func (r *nonceRates) busiest(now time.Time, limit int) []*models.NoncePeerRate {
+	r.roll(now)
+	previousWeight := 1 - float64(now.Sub(r.windowStart))/float64(nonceRateWindow)
+
+	estimates := make(map[string]int64, len(r.current))
+	for peerID, n := range r.previous {
+		estimates[peerID] = int64(float64(n) * previousWeight)
+	}
+	for peerID, n := range r.current {
+		estimates[peerID] += n
+	}
+
+	peers := make([]*models.NoncePeerRate, 0, len(estimates))
+	for peerID, n := range estimates {
+		if n > 0 {
+			peers = append(peers, &models.NoncePeerRate{PeerID: peerID, PerMinute: n})
+		}
+	}
+	slices.SortFunc(peers, func(a, b *models.NoncePeerRate) int {
+		return cmp.Or(cmp.Compare(b.PerMinute, a.PerMinute), strings.Compare(a.PeerID, b.PeerID))
+	})
+
+	if len(peers) > limit {
+		peers = peers[:limit]
+	}
+	return peers
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// PeerPolicyService authorizes the lease operations of peers by the peer policies of the
+// configuration file or of the database. Policies from the configuration file are replaced
+// on reload, those from the database on Refresh. Without a policy source every peer is
+// authorized.
+type PeerPolicyService struct {
+	source   string
+	repo     ports.PeerPolicyRepository
+	leases   ports.LeaseRepository // counts the leases held by the peers of a policy
+	policies atomic.Pointer[[]*models.PeerPolicy]
+	logger   *zap.Logger
+}
+
+var _ ports.PeerPolicyService = &PeerPolicyService{}
+
+func NewPeerPolicyService(cfg *config.AppConfig, repo ports.PeerPolicyRepository, leases ports.LeaseRepository, logger *zap.Logger) *PeerPolicyService {
+	s := &PeerPolicyService{
+		source: cfg.PeerPolicySource,
+		repo:   repo,
+		leases: leases,
+		logger: logger.Named("audit"),
+	}
+	s.ApplyConfig(cfg)
+	return s
+}
+
+// ApplyConfig replaces the policies of the config source with reloaded ones
+func (s *PeerPolicyService) ApplyConfig(cfg *config.AppConfig) {
+	if s.source != config.PeerPolicySourceConfig {
+		return
+	}
+
+	policies := make([]*models.PeerPolicy, len(cfg.PeerPolicies))
+	for i, p := range cfg.PeerPolicies {
+		policies[i] = &models.PeerPolicy{
+			Name:        p.Name,
+			Peers:       p.Peers,
+			CanAllocate: p.CanAllocate,
+			CanReserve:  p.CanReserve,
+			MaxPool:     p.MaxPool,
+		}
+	}
+	s.policies.Store(&policies)
+}
+
+func (s *PeerPolicyService) Authorize(ctx context.Context, peerID string, permission models.PeerPermission) error {
+	return s.authorize(ctx, peerID, permission, "")
+}
+
+func (s *PeerPolicyService) AuthorizeTransfer(ctx context.Context, fromPeerID string, toPeerID string) error {
+	return s.authorize(ctx, toPeerID, models.PeerPermissionAllocate, fromPeerID)
+}
+
+// authorize checks permission for peerID. The pool of a policy governing fromPeerID as
+// well is not checked: the lease only changes hands within it.
+func (s *PeerPolicyService) authorize(ctx context.Context, peerID string, permission models.PeerPermission, fromPeerID string) error {
+	if s.source == "" {
+		return nil
+	}
+
+	policies, err := s.current(ctx)
+	if err != nil {
+		return err
+	}
+
+	audit := s.logger.With(zap.String("event", "peer_policy_denied"), zap.String("peerID", peerID), zap.String("permission", string(permission)))
+	policy := models.PeerPolicyFor(policies, peerID)
+	if policy == nil {
+		audit.Warn("no peer policy matches the peer")
+		return domainErrors.ErrPeerPolicyDenied
+	}
+	audit = audit.With(zap.String("policy", policy.Name))
+	if !policy.Grants(permission) {
+		audit.Warn("peer policy does not grant the permission")
+		return domainErrors.ErrPeerPolicyDenied
+	}
+
+	if policy.MaxPool > 0 && (fromPeerID == "" || models.PeerPolicyFor(policies, fromPeerID) != policy) {
+		held, err := s.poolLeases(ctx, policy, peerID)
+		if err != nil {
+			return err
+		}
+		if held >= policy.MaxPool {
+			audit.Warn("peer policy pool exhausted", zap.Int("poolLeases", held))
+			return domainErrors.ErrPeerPoolExhausted
+		}
+	}
+	return nil
+}
+
+// poolLeases counts the active leases held by the peers of policy other than peerID. A
+// peer already holding a lease is given that lease again, so it counts as 0.
+func (s *PeerPolicyService) poolLeases(ctx context.Context, policy *models.PeerPolicy, peerID string) (int, error) {
+	if lease, err := s.leases.GetLeaseByPeerID(ctx, peerID); lease != nil && err == nil {
+		return 0, nil
+	}
+
+	held := 0
+	for _, peer := range policy.Peers {
+		if peer == peerID {
+			continue
+		}
+		lease, err := s.leases.GetLeaseByPeerID(ctx, peer)
+		if errors.Is(err, domainErrors.ErrLeaseNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if lease != nil {
+			held++
+		}
+	}
+	return held, nil
+}
+
+// ListPolicies returns the policies of the configuration file for the config source, and
+// those kept in the database otherwise
+func (s *PeerPolicyService) ListPolicies(ctx context.Context) ([]*models.PeerPolicy, error) {
+	if s.source == config.PeerPolicySourceConfig {
+		return *s.policies.Load(), nil
+	}
+	return s.repo.ListPeerPolicies(ctx)
+}
+
+func (s *PeerPolicyService) AddPolicy(ctx context.Context, policy *models.PeerPolicy) (*models.PeerPolicy, error) {
+	if s.source == config.PeerPolicySourceConfig {
+		return nil, domainErrors.ErrPeerPoliciesFixed
+	}
+	policy.Name = strings.TrimSpace(policy.Name)
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.CreatePeerPolicy(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("peer policy added", zap.String("event", "peer_policy_added"), zap.Int64("policyID", created.ID), zap.String("policy", created.Name))
+	s.refreshAfterChange(ctx)
+	return created, nil
+}
+
+func (s *PeerPolicyService) RemovePolicy(ctx context.Context, id int64) error {
+	if s.source == config.PeerPolicySourceConfig {
+		return domainErrors.ErrPeerPoliciesFixed
+	}
+
+	removed, err := s.repo.DeletePeerPolicy(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("peer policy removed", zap.String("event", "peer_policy_removed"), zap.Int64("policyID", removed.ID), zap.String("policy", removed.Name))
+	s.refreshAfterChange(ctx)
+	return nil
+}
+
+// refreshAfterChange applies a change made through this instance right away. Should that
+// fail, the change was still stored and the next periodic refresh applies it.
+func (s *PeerPolicyService) refreshAfterChange(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		s.logger.Error("error reloading peer policies", zap.Error(err))
+	}
+}
+
+// Refresh loads the policies kept in the database. The previous policies stay in effect
+// when they cannot be loaded.
+func (s *PeerPolicyService) Refresh(ctx context.Context) error {
+	if s.source != config.PeerPolicySourceDatabase {
+		return nil
+	}
+
+	policies, err := s.repo.ListPeerPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	s.policies.Store(&policies)
+	return nil
+}
+
+// current returns the policies in effect, loading the database policies on first use
+func (s *PeerPolicyService) current(ctx context.Context) ([]*models.PeerPolicy, error) {
+	if policies := s.policies.Load(); policies != nil {
+		return *policies, nil
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return *s.policies.Load(), nil
+}
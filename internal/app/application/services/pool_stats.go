@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// poolStatsAllocationWindow is the period allocations are counted over
+const poolStatsAllocationWindow = time.Hour
+
+// PoolStatsService relates the lease counts of every tenant to the size of its pool.
+// A report is reused for the cache TTL, so dashboards and scrapers polling several
+// instances do not each run the aggregate queries.
+type PoolStatsService struct {
+	repo     ports.PoolStatsRepository
+	tenants  ports.TenantResolver
+	cacheTTL time.Duration
+
+	mu     sync.Mutex // held while computing, so concurrent requests share one computation
+	report *models.PoolStatsReport
+}
+
+var _ ports.PoolStatsService = &PoolStatsService{}
+
+func NewPoolStatsService(cfg *config.AppConfig, repo ports.PoolStatsRepository, tenants ports.TenantResolver) *PoolStatsService {
+	return &PoolStatsService{
+		repo:     repo,
+		tenants:  tenants,
+		cacheTTL: time.Duration(cfg.AdminPoolStatsCacheTTL) * time.Second,
+	}
+}
+
+func (s *PoolStatsService) PoolStats(ctx context.Context) (*models.PoolStatsReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.report != nil && time.Since(s.report.ComputedAt) < s.cacheTTL {
+		return s.report, nil
+	}
+
+	computedAt := time.Now()
+	counts, err := s.repo.CountPoolLeases(ctx, poolStatsAllocationWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.PoolStatsReport{ComputedAt: computedAt}
+	for _, tenant := range s.tenants.Tenants() {
+		tenantCounts := counts[tenant.ID]
+		if tenantCounts == nil {
+			tenantCounts = &models.PoolLeaseCounts{}
+		}
+		report.Pools = append(report.Pools, models.NewPoolStats(tenant, tenantCounts))
+	}
+
+	s.report = report
+	return report, nil
+}
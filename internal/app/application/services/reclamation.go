@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// ReclamationService decides which expired leases may be handed to other peers by
+// evaluating them against the configured reclaim policies.
+type ReclamationService struct {
+	repo      ports.LeaseRepository
+	configs   []config.ReclaimPolicyConfig
+	policies  atomic.Pointer[[]*models.ReclaimPolicy] // rebuilt when the lease TTL is reloaded
+	batchSize int
+	events    ports.LeaseEventPublisher // nil publishes no lifecycle events
+	logger    *zap.Logger
+
+	mu      sync.Mutex
+	metrics *models.ReclaimMetrics
+}
+
+var _ ports.ReclamationService = &ReclamationService{}
+
+func NewReclamationService(appConfig *config.AppConfig, repo ports.LeaseRepository, events ports.LeaseEventPublisher, logger *zap.Logger) *ReclamationService {
+	metrics := &models.ReclaimMetrics{Policies: make([]*models.ReclaimPolicyMetrics, len(appConfig.ReclaimPolicies))}
+	for i, p := range appConfig.ReclaimPolicies {
+		metrics.Policies[i] = &models.ReclaimPolicyMetrics{Policy: p.Name}
+	}
+
+	s := &ReclamationService{
+		repo:      repo,
+		configs:   appConfig.ReclaimPolicies,
+		batchSize: appConfig.ReclaimBatchSize,
+		events:    events,
+		logger:    logger.Named("audit"),
+		metrics:   metrics,
+	}
+	s.ApplyConfig(appConfig)
+	return s
+}
+
+// ApplyConfig rebuilds the policies for a reloaded lease TTL. The policies themselves
+// are not reloadable, a run in progress keeps the policies it started with.
+func (s *ReclamationService) ApplyConfig(appConfig *config.AppConfig) {
+	leaseTTL := time.Duration(appConfig.Lease.TTL) * time.Minute
+
+	policies := make([]*models.ReclaimPolicy, len(s.configs))
+	for i, p := range s.configs {
+		policies[i] = newReclaimPolicy(p, leaseTTL)
+	}
+	s.policies.Store(&policies)
+}
+
+// newReclaimPolicy converts a policy from configuration, keeping the stricter of the two
+// "not renewed" thresholds when both are set.
+func newReclaimPolicy(p config.ReclaimPolicyConfig, leaseTTL time.Duration) *models.ReclaimPolicy {
+	notRenewedFor := time.Duration(p.NotRenewedFor) * time.Minute
+	if byTTL := time.Duration(p.NotRenewedForTTLs * float64(leaseTTL)); byTTL > notRenewedFor {
+		notRenewedFor = byTTL
+	}
+
+	return &models.ReclaimPolicy{
+		Name:               p.Name,
+		NotRenewedFor:      notRenewedFor,
+		ExpiredFor:         time.Duration(p.ExpiredFor) * time.Minute,
+		SkipAffinityGroups: p.SkipAffinityGroups,
+	}
+}
+
+func (s *ReclamationService) Run(ctx context.Context, dryRun bool) (*models.ReclaimReport, error) {
+	policies := *s.policies.Load()
+	report := &models.ReclaimReport{
+		DryRun:    dryRun,
+		StartedAt: time.Now(),
+		Policies:  make([]*models.ReclaimPolicyResult, len(policies)),
+	}
+	for i, p := range policies {
+		report.Policies[i] = &models.ReclaimPolicyResult{Policy: p.Name, TokenIDs: []int64{}}
+	}
+
+	err := s.run(ctx, report, policies)
+	report.FinishedAt = time.Now()
+	s.record(report, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *ReclamationService) run(ctx context.Context, report *models.ReclaimReport, policies []*models.ReclaimPolicy) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	var afterTokenID int64
+	for {
+		candidates, err := s.repo.ListReclaimCandidates(ctx, afterTokenID, s.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+		afterTokenID = candidates[len(candidates)-1].TokenID
+
+		// Attribute each lease to the first policy that matches it
+		matched := make([][]*models.ReclaimCandidate, len(policies))
+		for _, candidate := range candidates {
+			report.Evaluated++
+			for i, policy := range policies {
+				if policy.Matches(candidate, report.StartedAt) {
+					matched[i] = append(matched[i], candidate)
+					break
+				}
+			}
+		}
+
+		for i, policyCandidates := range matched {
+			if len(policyCandidates) == 0 {
+				continue
+			}
+
+			tokenIDs := make([]int64, len(policyCandidates))
+			for j, candidate := range policyCandidates {
+				tokenIDs[j] = candidate.TokenID
+			}
+
+			result := report.Policies[i]
+			result.Matched += len(tokenIDs)
+			result.TokenIDs = append(result.TokenIDs, tokenIDs...)
+			if report.DryRun {
+				continue
+			}
+
+			reclaimed, err := s.repo.ReclaimLeases(ctx, tokenIDs)
+			if err != nil {
+				return err
+			}
+			result.Reclaimed += int64(len(reclaimed))
+			report.Reclaimed += int64(len(reclaimed))
+
+			s.logger.Info("Reclaimed expired leases",
+				zap.String("policy", result.Policy),
+				zap.Int("reclaimed", len(reclaimed)),
+				zap.Int64s("tokenIDs", tokenIDs),
+			)
+			s.publishExpired(policyCandidates, reclaimed)
+		}
+
+		if len(candidates) < s.batchSize {
+			return nil
+		}
+	}
+}
+
+// publishExpired publishes a lease.expired event for every candidate that was reclaimed
+func (s *ReclamationService) publishExpired(candidates []*models.ReclaimCandidate, reclaimed []int64) {
+	if s.events == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, candidate := range candidates {
+		if !slices.Contains(reclaimed, candidate.TokenID) {
+			continue
+		}
+		s.events.Publish(&models.LeaseLifecycleEvent{
+			Type:       models.LeaseLifecycleExpired,
+			TokenID:    candidate.TokenID,
+			PeerID:     candidate.PeerID,
+			TenantID:   candidate.TenantID,
+			ExpiresAt:  candidate.ExpiresAt,
+			OccurredAt: now,
+		})
+	}
+}
+
+func (s *ReclamationService) record(report *models.ReclaimReport, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.metrics.Runs++
+	s.metrics.LastRunAt = report.StartedAt
+	if err != nil {
+		s.metrics.Failures++
+	}
+
+	for i, result := range report.Policies {
+		metrics := s.metrics.Policies[i]
+		if report.DryRun {
+			metrics.DryRunMatched += int64(result.Matched)
+			continue
+		}
+		metrics.Matched += int64(result.Matched)
+		metrics.Reclaimed += result.Reclaimed
+	}
+}
+
+// Metrics returns a snapshot of the cumulative reclamation counters
+func (s *ReclamationService) Metrics() *models.ReclaimMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := *s.metrics
+	snapshot.Policies = make([]*models.ReclaimPolicyMetrics, len(s.metrics.Policies))
+	for i, p := range s.metrics.Policies {
+		policy := *p
+		snapshot.Policies[i] = &policy
+	}
+	return &snapshot
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// LeaseSnapshotService stamps exported snapshots and checks imported ones against the
+// configured tenants before they reach the store
+type LeaseSnapshotService struct {
+	repo    ports.LeaseSnapshotRepository
+	tenants ports.TenantResolver
+	logger  *zap.Logger
+}
+
+var _ ports.LeaseSnapshotService = &LeaseSnapshotService{}
+
+func NewLeaseSnapshotService(repo ports.LeaseSnapshotRepository, tenants ports.TenantResolver, logger *zap.Logger) *LeaseSnapshotService {
+	return &LeaseSnapshotService{repo: repo, tenants: tenants, logger: logger}
+}
+
+func (s *LeaseSnapshotService) Export(ctx context.Context) (*models.LeaseSnapshot, error) {
+	exportedAt := time.Now().UTC()
+	snapshot, err := s.repo.ExportLeaseState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Version = models.SnapshotVersion
+	snapshot.ExportedAt = exportedAt
+	return snapshot, nil
+}
+
+func (s *LeaseSnapshotService) Import(ctx context.Context, snapshot *models.LeaseSnapshot, replace bool) (*models.SnapshotImportReport, error) {
+	if violations := snapshot.Violations(s.tenants.Tenants()); len(violations) > 0 {
+		return nil, domainErrors.ErrInvalidSnapshot.WithDetails(strings.Join(violations, "; "))
+	}
+
+	report, err := s.repo.ImportLeaseState(ctx, snapshot, replace)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Imported lease snapshot",
+		zap.Time("exportedAt", snapshot.ExportedAt),
+		zap.Int("pools", report.Pools),
+		zap.Int("leases", report.Leases),
+		zap.Int64("replaced", report.Replaced),
+	)
+	return report, nil
+}
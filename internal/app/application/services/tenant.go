@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// tenantIDPattern keeps tenant IDs usable as key and column values
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// TenantService resolves API keys to the configured tenants. The default tenant is served
+// from the top-level pool and has no API key.
+type TenantService struct {
+	tenants []*models.Tenant
+	byID    map[string]*models.Tenant
+	byKey   map[string]*models.Tenant
+}
+
+var _ ports.TenantResolver = &TenantService{}
+
+// NewTenantService validates the tenant configuration: IDs and API keys must be unique
+// and no two pools may overlap
+func NewTenantService(cfg *config.AppConfig) (*TenantService, error) {
+	s := &TenantService{
+		byID:  make(map[string]*models.Tenant, len(cfg.Tenants)+1),
+		byKey: make(map[string]*models.Tenant),
+	}
+
+	exclusions, zones := cfg.TenantExclusions(), cfg.TenantZones()
+	if err := s.add(&models.Tenant{ID: models.DefaultTenantID, MinTokenID: cfg.PoolMinTokenID, MaxTokenID: cfg.PoolMaxTokenID, Exclusions: exclusions[models.DefaultTenantID], Zones: zones[models.DefaultTenantID]}); err != nil {
+		return nil, err
+	}
+
+	for _, tc := range cfg.Tenants {
+		if !tenantIDPattern.MatchString(tc.ID) {
+			return nil, fmt.Errorf("tenant ID %q must be 1 to 64 lower-case letters, digits, '-' or '_'", tc.ID)
+		}
+		if _, ok := s.byID[tc.ID]; ok {
+			return nil, fmt.Errorf("tenant %q is configured more than once", tc.ID)
+		}
+
+		tenant := &models.Tenant{ID: tc.ID, MinTokenID: tc.PoolMinTokenID, MaxTokenID: tc.PoolMaxTokenID, Exclusions: exclusions[tc.ID], Zones: zones[tc.ID]}
+		if err := s.add(tenant); err != nil {
+			return nil, err
+		}
+
+		if len(tc.APIKeys) == 0 {
+			return nil, fmt.Errorf("tenant %q needs at least one API key", tc.ID)
+		}
+		for _, key := range tc.APIKeys {
+			if key == "" {
+				return nil, fmt.Errorf("tenant %q has an empty API key", tc.ID)
+			}
+			if other, ok := s.byKey[key]; ok {
+				return nil, fmt.Errorf("tenants %q and %q share an API key", other.ID, tc.ID)
+			}
+			s.byKey[key] = tenant
+		}
+	}
+
+	return s, nil
+}
+
+// add registers a tenant after checking its pool against those already registered
+func (s *TenantService) add(tenant *models.Tenant) error {
+	if tenant.MinTokenID <= 0 || tenant.MaxTokenID < tenant.MinTokenID {
+		return fmt.Errorf("pool [%d, %d] of tenant %q is empty or invalid", tenant.MinTokenID, tenant.MaxTokenID, tenant.ID)
+	}
+	for _, other := range s.tenants {
+		if tenant.MinTokenID <= other.MaxTokenID && other.MinTokenID <= tenant.MaxTokenID {
+			return fmt.Errorf("pool [%d, %d] of tenant %q overlaps pool [%d, %d] of tenant %q",
+				tenant.MinTokenID, tenant.MaxTokenID, tenant.ID, other.MinTokenID, other.MaxTokenID, other.ID)
+		}
+	}
+
+	s.tenants = append(s.tenants, tenant)
+	s.byID[tenant.ID] = tenant
+	return nil
+}
+
+func (s *TenantService) ResolveAPIKey(apiKey string) (*models.Tenant, error) {
+	tenant, ok := s.byKey[apiKey]
+	if !ok {
+		return nil, errors.ErrInvalidAPIKey
+	}
+	return tenant, nil
+}
+
+func (s *TenantService) Tenant(id string) (*models.Tenant, error) {
+	tenant, ok := s.byID[id]
+	if !ok {
+		return nil, errors.ErrTenantNotFound
+	}
+	return tenant, nil
+}
+
+func (s *TenantService) Tenants() []*models.Tenant {
+	return slices.Clone(s.tenants)
+}
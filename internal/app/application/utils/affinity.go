@@ -0,0 +1,29 @@
+package utils
+
+// AffinityCandidates lists up to limit token IDs to probe for a group: gaps inside the
+// group's current range first, then alternately the token IDs just above and below it.
+// members must be sorted ascending.
+func AffinityCandidates(members []int64, limit int) []int64 {
+	if len(members) == 0 || limit <= 0 {
+		return nil
+	}
+
+	candidates := make([]int64, 0, limit)
+	for i := 1; i < len(members) && len(candidates) < limit; i++ {
+		for tokenID := members[i-1] + 1; tokenID < members[i] && len(candidates) < limit; tokenID++ {
+			candidates = append(candidates, tokenID)
+		}
+	}
+
+	above, below := members[len(members)-1]+1, members[0]-1
+	for len(candidates) < limit {
+		candidates = append(candidates, above)
+		above++
+		if len(candidates) < limit && below > 0 {
+			candidates = append(candidates, below)
+			below--
+		}
+	}
+
+	return candidates
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAffinityCandidates tests the AffinityCandidates function
+func TestAffinityCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		members  []int64
+		limit    int
+		expected []int64
+	}{
+		{
+			name:     "empty group",
+			members:  nil,
+			limit:    4,
+			expected: nil,
+		},
+		{
+			name:     "single member grows both ways",
+			members:  []int64{100},
+			limit:    4,
+			expected: []int64{101, 99, 102, 98},
+		},
+		{
+			name:     "gaps are filled first",
+			members:  []int64{100, 103},
+			limit:    4,
+			expected: []int64{101, 102, 104, 99},
+		},
+		{
+			name:     "limit caps gap probing",
+			members:  []int64{100, 110},
+			limit:    3,
+			expected: []int64{101, 102, 103},
+		},
+		{
+			name:     "zero limit disables probing",
+			members:  []int64{100},
+			limit:    0,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AffinityCandidates(tt.members, tt.limit))
+		})
+	}
+}
@@ -10,13 +10,18 @@ import (
 type ErrorType string
 
 const (
-	ErrorTypeValidation ErrorType = "validation_error"
-	ErrorTypeAuth       ErrorType = "auth_error"
-	ErrorTypeNotFound   ErrorType = "not_found"
-	ErrorTypeConflict   ErrorType = "conflict"
-	ErrorTypeInternal   ErrorType = "internal_error"
-	ErrorTypeRateLimit  ErrorType = "rate_limit_error"
-	ErrorTypeBadRequest ErrorType = "bad_request"
+	ErrorTypeValidation    ErrorType = "validation_error"
+	ErrorTypeAuth          ErrorType = "auth_error"
+	ErrorTypeNotFound      ErrorType = "not_found"
+	ErrorTypeConflict      ErrorType = "conflict"
+	ErrorTypeInternal      ErrorType = "internal_error"
+	ErrorTypeRateLimit     ErrorType = "rate_limit_error"
+	ErrorTypeBadRequest    ErrorType = "bad_request"
+	ErrorTypeTimeout       ErrorType = "timeout_error"
+	ErrorTypeTooLarge      ErrorType = "payload_too_large"
+	ErrorTypeForbidden     ErrorType = "forbidden"
+	ErrorTypeNotAcceptable ErrorType = "not_acceptable"
+	ErrorTypeUnavailable   ErrorType = "service_unavailable"
 )
 
 // AppError represents a structured application error
@@ -48,12 +53,22 @@ func (e *AppError) HTTPStatus() int {
 		return http.StatusBadRequest
 	case ErrorTypeAuth:
 		return http.StatusUnauthorized
+	case ErrorTypeForbidden:
+		return http.StatusForbidden
 	case ErrorTypeNotFound:
 		return http.StatusNotFound
 	case ErrorTypeConflict:
 		return http.StatusConflict
 	case ErrorTypeRateLimit:
 		return http.StatusTooManyRequests
+	case ErrorTypeTimeout:
+		return http.StatusRequestTimeout
+	case ErrorTypeTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrorTypeNotAcceptable:
+		return http.StatusNotAcceptable
+	case ErrorTypeUnavailable:
+		return http.StatusServiceUnavailable
 	case ErrorTypeInternal:
 		return http.StatusInternalServerError
 	default:
@@ -61,6 +76,18 @@ func (e *AppError) HTTPStatus() int {
 	}
 }
 
+// WithDetails returns a copy of the error carrying request specific details. The copy
+// unwraps to the original error, so errors.Is keeps matching the predefined value.
+func (e *AppError) WithDetails(details string) *AppError {
+	return &AppError{
+		Type:    e.Type,
+		Code:    e.Code,
+		Message: e.Message,
+		Details: details,
+		Cause:   e,
+	}
+}
+
 // NewAppError creates a new application error
 func NewAppError(errorType ErrorType, code, message string, cause error) *AppError {
 	return &AppError{
@@ -81,6 +108,11 @@ func NewAuthError(code, message string, cause error) *AppError {
 	return NewAppError(ErrorTypeAuth, code, message, cause)
 }
 
+// NewForbiddenError creates an error for an authenticated caller that is refused
+func NewForbiddenError(code, message string, cause error) *AppError {
+	return NewAppError(ErrorTypeForbidden, code, message, cause)
+}
+
 // NewNotFoundError creates a not found error
 func NewNotFoundError(code, message string, cause error) *AppError {
 	return NewAppError(ErrorTypeNotFound, code, message, cause)
@@ -101,6 +133,26 @@ func NewRateLimitError(code, message string, cause error) *AppError {
 	return NewAppError(ErrorTypeRateLimit, code, message, cause)
 }
 
+// NewTimeoutError creates a request timeout error
+func NewTimeoutError(code, message string, cause error) *AppError {
+	return NewAppError(ErrorTypeTimeout, code, message, cause)
+}
+
+// NewTooLargeError creates a payload too large error
+func NewTooLargeError(code, message string, cause error) *AppError {
+	return NewAppError(ErrorTypeTooLarge, code, message, cause)
+}
+
+// NewNotAcceptableError creates an error for a response format the client cannot accept
+func NewNotAcceptableError(code, message string, cause error) *AppError {
+	return NewAppError(ErrorTypeNotAcceptable, code, message, cause)
+}
+
+// NewUnavailableError creates an error for an operation a degraded server cannot serve
+func NewUnavailableError(code, message string, cause error) *AppError {
+	return NewAppError(ErrorTypeUnavailable, code, message, cause)
+}
+
 // WrapError wraps an existing error with additional context
 func WrapError(err error, errorType ErrorType, code, message string) *AppError {
 	return &AppError{
@@ -139,27 +191,84 @@ var (
 	ErrInvalidTokenID     = NewValidationError("INVALID_TOKEN_ID", "Invalid token ID format", nil)
 	ErrInvalidPubkey      = NewValidationError("INVALID_PUBKEY", "Invalid public key format", nil)
 	ErrInvalidNonce       = NewValidationError("INVALID_NONCE", "Invalid nonce format", nil)
+	ErrInvalidLease       = NewValidationError("INVALID_LEASE", "Lease is inconsistent", nil)
 	ErrInvalidSignature   = NewValidationError("INVALID_SIGNATURE", "Invalid signature format", nil)
 	ErrInvalidRequest     = NewValidationError("INVALID_REQUEST", "Invalid request format", nil)
 	ErrInvalidContentType = NewValidationError("INVALID_CONTENT_TYPE", "Invalid content type", nil)
-	ErrRequestTooLarge    = NewValidationError("REQUEST_TOO_LARGE", "Request size exceeds limit", nil)
 	ErrInvalidURL         = NewValidationError("INVALID_URL", "Invalid URL format", nil)
 	ErrInvalidHeader      = NewValidationError("INVALID_HEADER", "Invalid header format", nil)
+	ErrTokenIDOutOfRange  = NewValidationError("TOKEN_ID_OUT_OF_RANGE", "Token ID is outside the allocation pool", nil)
+	ErrInvalidAffinity    = NewValidationError("INVALID_AFFINITY_GROUP", "Invalid affinity group format", nil)
+	ErrInvalidZone        = NewValidationError("INVALID_ZONE", "Invalid zone format", nil)
+	ErrInvalidLabels      = NewValidationError("INVALID_LABELS", "Lease labels are invalid or too large", nil)
+	ErrConflictingOptions = NewValidationError("CONFLICTING_OPTIONS", "tokenID and affinityGroup cannot be combined", nil)
+	ErrTransferToSelf     = NewValidationError("TRANSFER_TO_SELF", "Lease cannot be transferred to its current owner", nil)
+	ErrDelegationToSelf   = NewValidationError("DELEGATION_TO_SELF", "Gateway cannot delegate a lease to itself", nil)
+	ErrInvalidOperation   = NewValidationError("INVALID_OPERATION", "Unknown batch operation", nil)
+	ErrBatchTooLarge      = NewValidationError("BATCH_TOO_LARGE", "Batch contains too many operations", nil)
+	ErrEmptyBatch         = NewValidationError("EMPTY_BATCH", "Batch contains no operations", nil)
+	ErrMissingTimestamp   = NewValidationError("MISSING_TIMESTAMP", "X-Timestamp header is required", nil)
+	ErrInvalidTimestamp   = NewValidationError("INVALID_TIMESTAMP", "Timestamp must be Unix seconds", nil)
+	ErrUnsupportedKeyType = NewValidationError("UNSUPPORTED_KEY_TYPE", "Public key type is not supported by the identity scheme", nil)
+	ErrInvalidPagination  = NewValidationError("INVALID_PAGINATION", "Invalid limit, offset or cursor", nil)
+	ErrInvalidSort        = NewValidationError("INVALID_SORT", "Invalid sort field", nil)
+	ErrInvalidFilter      = NewValidationError("INVALID_FILTER", "Invalid filter", nil)
+	ErrInvalidAccessRule  = NewValidationError("INVALID_ACCESS_RULE", "Access rule needs a list of deny or allow, a subject type of peer_id or pubkey and a valid subject", nil)
+	ErrInvalidSnapshot    = NewValidationError("INVALID_SNAPSHOT", "Snapshot is inconsistent or does not match the configured pools", nil)
+	ErrInvalidAPIKeyName  = NewValidationError("INVALID_API_KEY_NAME", "API key name must be 1 to 64 characters", nil)
+	ErrInvalidAPIKeyScope = NewValidationError("INVALID_API_KEY_SCOPE", "API key needs at least one scope of leases:read, leases:write or admin:full", nil)
+	ErrInvalidPeerPolicy  = NewValidationError("INVALID_PEER_POLICY", "Peer policy needs a name, peer IDs or \"*\" and a non-negative max_pool", nil)
+	ErrInvalidFault       = NewValidationError("INVALID_FAULT", "Fault needs a target of database or cache, a non-negative latency and an error rate between 0 and 1", nil)
+	ErrInvalidClientIP    = NewValidationError("INVALID_CLIENT_IP", "Client must be an IP address or a peer ID", nil)
+	ErrInvalidRevocation  = NewValidationError("INVALID_REVOCATION", "Revocation needs peer IDs, a token range, a CIDR or a label selector", nil)
 
 	// Authentication errors
-	ErrNonceExpired          = NewAuthError("NONCE_EXPIRED", "Nonce has expired", nil)
-	ErrNonceNotFound         = NewAuthError("NONCE_NOT_FOUND", "Nonce not found", nil)
-	ErrNonceUsed             = NewAuthError("NONCE_USED", "Nonce has already been used", nil)
-	ErrPubkeyMismatch        = NewAuthError("PUBKEY_MISMATCH", "Public key mismatch", nil)
-	ErrSignatureVerification = NewAuthError("SIGNATURE_VERIFICATION_FAILED", "Signature verification failed", nil)
+	ErrNonceExpired           = NewAuthError("NONCE_EXPIRED", "Nonce has expired", nil)
+	ErrNonceNotFound          = NewAuthError("NONCE_NOT_FOUND", "Nonce not found", nil)
+	ErrNonceUsed              = NewAuthError("NONCE_USED", "Nonce has already been used", nil)
+	ErrPubkeyMismatch         = NewAuthError("PUBKEY_MISMATCH", "Public key mismatch", nil)
+	ErrSignatureVerification  = NewAuthError("SIGNATURE_VERIFICATION_FAILED", "Signature verification failed", nil)
+	ErrAdminUnauthorized      = NewAuthError("ADMIN_UNAUTHORIZED", "Admin credentials are missing or invalid", nil)
+	ErrTransferUnauthorized   = NewAuthError("TRANSFER_UNAUTHORIZED", "Transfer signature does not authorize the new public key", nil)
+	ErrDelegationUnauthorized = NewAuthError("DELEGATION_UNAUTHORIZED", "Delegation signature does not vouch for the downstream public key", nil)
+	ErrTimestampOutOfWindow   = NewAuthError("TIMESTAMP_OUT_OF_WINDOW", "Request timestamp is outside the accepted window", nil)
+	ErrAuthenticationRequired = NewAuthError("AUTHENTICATION_REQUIRED", "Authentication is required", nil)
+	ErrInvalidAPIKey          = NewAuthError("INVALID_API_KEY", "API key does not belong to any tenant", nil)
+
+	// Access control errors
+	ErrPeerBlocked       = NewForbiddenError("PEER_BLOCKED", "Peer is on the deny list", nil)
+	ErrPeerNotAllowed    = NewForbiddenError("PEER_NOT_ALLOWED", "Peer is not on the allow list", nil)
+	ErrNotAGateway       = NewForbiddenError("NOT_A_GATEWAY", "Peer is not configured as a delegation gateway", nil)
+	ErrNetworkNotAllowed = NewForbiddenError("NETWORK_NOT_ALLOWED", "Requests from this network are not allowed", nil)
+	ErrAPIKeyScope       = NewForbiddenError("API_KEY_SCOPE", "API key does not grant the scope this route requires", nil)
+	ErrPeerPolicyDenied  = NewForbiddenError("PEER_POLICY_DENIED", "No peer policy grants the peer this operation", nil)
+	ErrPeerPoliciesFixed = NewForbiddenError("PEER_POLICIES_FIXED", "Peer policies are read from the configuration file, edit it and reload instead", nil)
 
 	// Not found errors
-	ErrLeaseNotFound    = NewNotFoundError("LEASE_NOT_FOUND", "Lease not found", nil)
-	ErrNonceNotFoundErr = NewNotFoundError("NONCE_NOT_FOUND", "Nonce not found", nil)
+	ErrLeaseNotFound        = NewNotFoundError("LEASE_NOT_FOUND", "Lease not found", nil)
+	ErrNonceNotFoundErr     = NewNotFoundError("NONCE_NOT_FOUND", "Nonce not found", nil)
+	ErrRedisNotConfigured   = NewNotFoundError("REDIS_NOT_CONFIGURED", "The storage backend does not use Redis", nil)
+	ErrExpiryReportNotFound = NewNotFoundError("EXPIRY_REPORT_NOT_FOUND", "No expiry notification run has completed yet", nil)
+	ErrAccessRuleNotFound   = NewNotFoundError("ACCESS_RULE_NOT_FOUND", "Access rule not found", nil)
+	ErrBanNotFound          = NewNotFoundError("BAN_NOT_FOUND", "Client has no ban", nil)
+	ErrTenantNotFound       = NewNotFoundError("TENANT_NOT_FOUND", "Tenant not found", nil)
+	ErrAPIKeyNotFound       = NewNotFoundError("API_KEY_NOT_FOUND", "API key not found", nil)
+	ErrPeerPolicyNotFound   = NewNotFoundError("PEER_POLICY_NOT_FOUND", "Peer policy not found", nil)
+	ErrRevocationNotFound   = NewNotFoundError("REVOCATION_NOT_FOUND", "Revocation not found", nil)
 
 	// Conflict errors
 	ErrLeaseAlreadyExists = NewConflictError("LEASE_ALREADY_EXISTS", "Lease already exists", nil)
 	ErrLeaseExpired       = NewConflictError("LEASE_EXPIRED", "Lease has expired", nil)
+	ErrTokenIDInUse       = NewConflictError("TOKEN_ID_IN_USE", "Token ID is leased to another peer", nil)
+	ErrLeaseQuotaExceeded = NewConflictError("LEASE_QUOTA_EXCEEDED", "Peer already holds the maximum number of leases", nil)
+	ErrDelegationQuota    = NewConflictError("DELEGATION_QUOTA_EXCEEDED", "Gateway already holds the maximum number of delegated leases", nil)
+	ErrPeerPoolExhausted  = NewConflictError("PEER_POOL_EXHAUSTED", "The peers of the peer policy already hold max_pool leases", nil)
+	ErrPeerPolicyExists   = NewConflictError("PEER_POLICY_EXISTS", "A peer policy with this name already exists", nil)
+	ErrAccessRuleExists   = NewConflictError("ACCESS_RULE_EXISTS", "The subject is already on this list", nil)
+	ErrDuplicateRecord    = NewConflictError("DUPLICATE_RECORD", "A record with the same key already exists", nil)
+	ErrConcurrentUpdate   = NewConflictError("CONCURRENT_UPDATE", "The record was changed by a concurrent request, retry the request", nil)
+	ErrStoreNotEmpty      = NewConflictError("STORE_NOT_EMPTY", "The store already holds leases, import with replace to overwrite them", nil)
+	ErrSigningDisabled    = NewConflictError("LEASE_SIGNING_DISABLED", "The server does not sign leases, set security.server_key_path to enable signing", nil)
 
 	// Internal errors
 	ErrDatabaseConnection  = NewInternalError("DATABASE_CONNECTION_FAILED", "Database connection failed", nil)
@@ -167,7 +276,31 @@ var (
 	ErrMissingDependencies = NewInternalError("MISSING_DEPENDENCIES", "Missing required dependencies", nil)
 	ErrAllocationFailed    = NewInternalError("ALLOCATION_FAILED", "Failed to allocate lease", nil)
 
+	// Degraded mode errors
+	ErrStorageDegraded = NewUnavailableError("STORAGE_DEGRADED", "The database is unavailable, only renewals of cached leases are accepted until it recovers", nil)
+
+	// Load shedding errors
+	ErrServerBusy = NewUnavailableError("SERVER_BUSY", "The server is serving as many requests as it can, retry later", nil)
+
+	// High availability errors
+	ErrNotActive = NewUnavailableError("HA_STANDBY", "This instance is the standby, lease changes are served by the active instance", nil)
+
+	// Read-only profile errors
+	ErrReadOnly = NewForbiddenError("READ_ONLY", "This instance only serves lease lookups, lease changes are served by other instances", nil)
+
 	// Rate limit errors
 	ErrRateLimitExceeded = NewRateLimitError("RATE_LIMIT_EXCEEDED", "Rate limit exceeded", nil)
-)
+	ErrTooManyNonces     = NewRateLimitError("TOO_MANY_NONCES", "Peer holds too many outstanding nonces", nil)
+	ErrClientBanned      = NewRateLimitError("CLIENT_BANNED", "Client is temporarily banned after repeated rate limit violations", nil)
+
+	// Idempotency errors
+	ErrInvalidIdempotencyKey = NewValidationError("INVALID_IDEMPOTENCY_KEY", "Idempotency key must be 1 to 255 visible ASCII characters", nil)
+	ErrIdempotencyInProgress = NewConflictError("IDEMPOTENCY_KEY_IN_PROGRESS", "A request with this idempotency key is still being processed", nil)
 
+	// Request limit errors
+	ErrRequestTimeout  = NewTimeoutError("REQUEST_TIMEOUT", "Request did not complete in time", nil)
+	ErrRequestTooLarge = NewTooLargeError("REQUEST_TOO_LARGE", "Request size exceeds limit", nil)
+
+	// Response encoding errors
+	ErrNotAcceptable = NewNotAcceptableError("NOT_ACCEPTABLE", "The response cannot be encoded in any of the accepted media types", nil)
+)
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// AccessList is the list an access rule belongs to
+type AccessList string
+
+const (
+	AccessListDeny  AccessList = "deny"  // matching peers are rejected
+	AccessListAllow AccessList = "allow" // matching peers are admitted when the allow list is required
+)
+
+// AccessSubjectType is what an access rule matches on
+type AccessSubjectType string
+
+const (
+	AccessSubjectPeerID AccessSubjectType = "peer_id" // identity under the configured identity scheme
+	AccessSubjectPubkey AccessSubjectType = "pubkey"  // base64 (standard encoding) of the marshaled public key
+)
+
+// AccessRule puts a peer identity or public key on the deny or allow list
+type AccessRule struct {
+	ID          int64             `json:"id"`
+	List        AccessList        `json:"list"`
+	SubjectType AccessSubjectType `json:"subject_type"`
+	Subject     string            `json:"subject"`
+	Reason      string            `json:"reason,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
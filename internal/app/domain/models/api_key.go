@@ -0,0 +1,75 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// AdminAPIKeyPrefix starts every admin API key, which tells them apart from the tenant
+// API keys sent in the same header
+const AdminAPIKeyPrefix = "dhcp2p_"
+
+// APIKeyScope is a set of admin routes an API key may call
+type APIKeyScope string
+
+const (
+	APIKeyScopeLeasesRead  APIKeyScope = "leases:read"  // list leases, their history and export them
+	APIKeyScopeLeasesWrite APIKeyScope = "leases:write" // import leases, implies leases:read
+	APIKeyScopeAdminFull   APIKeyScope = "admin:full"   // every admin route
+)
+
+// APIKeyScopes lists the known scopes
+var APIKeyScopes = []APIKeyScope{APIKeyScopeLeasesRead, APIKeyScopeLeasesWrite, APIKeyScopeAdminFull}
+
+// Valid reports whether the scope is known
+func (s APIKeyScope) Valid() bool {
+	return slices.Contains(APIKeyScopes, s)
+}
+
+// APIKey authenticates an operator or tool against the admin API. Only a hash of the key
+// is stored; Prefix is the non-secret start of the key, shown to identify it.
+type APIKey struct {
+	ID         int64         `json:"id"`
+	Name       string        `json:"name"`
+	Prefix     string        `json:"prefix"`
+	Hash       string        `json:"-"` // hex SHA-256 of the whole key
+	Scopes     []APIKeyScope `json:"scopes"`
+	CreatedAt  time.Time     `json:"created_at"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty"`
+}
+
+// Revoked reports whether the key was revoked
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Grants reports whether the key may call routes requiring scope
+func (k *APIKey) Grants(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		switch {
+		case s == scope, s == APIKeyScopeAdminFull:
+			return true
+		case s == APIKeyScopeLeasesWrite && scope == APIKeyScopeLeasesRead:
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeNames returns the scopes as a comma-separated list
+func (k *APIKey) ScopeNames() string {
+	names := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ",")
+}
+
+// IssuedAPIKey is a newly created API key together with its plaintext, which is not
+// stored and cannot be retrieved again
+type IssuedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
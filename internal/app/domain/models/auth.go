@@ -12,8 +12,21 @@ type AuthVerifyRequest struct {
 	NonceID   string
 	Signature []byte
 	Pubkey    []byte
+	Timestamp string // Unix seconds signed together with the nonce, empty when not sent
 }
 
 type AuthVerifyResponse struct {
 	Pubkey []byte
+	PeerID string // identity the public key authenticates as
+}
+
+// SignatureCacheStats reports how often verified signatures were answered from the cache
+type SignatureCacheStats struct {
+	Enabled   bool    `json:"enabled"`
+	Entries   int     `json:"entries"`
+	Capacity  int     `json:"capacity"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"` // entries dropped for capacity before they expired
+	HitRate   float64 `json:"hit_rate"`  // hits / (hits + misses), 0 before the first verification
 }
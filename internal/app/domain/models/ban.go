@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Ban refuses every request of a client until it expires. A client banned again while its
+// previous ban is remembered gets the next level, banned twice as long.
+type Ban struct {
+	Subject    string    `json:"subject"`    // client IP or peer ID
+	Level      int       `json:"level"`      // 1 for the first ban
+	Violations int64     `json:"violations"` // rate limit violations within the window that led to the ban
+	BannedAt   time.Time `json:"banned_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Active reports whether the ban still refuses requests at now
+func (b *Ban) Active(now time.Time) bool {
+	return b.ExpiresAt.After(now)
+}
+
+// BanStatus is what is known about the violations and bans of a client
+type BanStatus struct {
+	Subject    string `json:"subject"`
+	Banned     bool   `json:"banned"`
+	Violations int64  `json:"violations"`    // within the current window
+	Ban        *Ban   `json:"ban,omitempty"` // the active ban, or the last one while it is remembered
+}
+
+// BanMetrics counts rate limit violations and bans of this instance since startup
+type BanMetrics struct {
+	Violations int64 `json:"violations"`
+	Bans       int64 `json:"bans"`
+	Refused    int64 `json:"refused"` // requests of banned clients
+	Lifted     int64 `json:"lifted"`  // bans lifted by an admin
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// PoolUtilization relates the active leases to the addresses of every tenant pool
+type PoolUtilization struct {
+	Size        int64   `json:"size"`
+	Active      int64   `json:"active"`
+	Utilization float64 `json:"utilization"` // active / size, between 0 and 1
+}
+
+// CacheStats counts lease lookups answered from the cache and from the database
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // hits / (hits + misses), 0 before the first lookup
+}
+
+// NewCacheStats computes the hit rate of the counters
+func NewCacheStats(hits, misses int64) *CacheStats {
+	stats := &CacheStats{Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+// DashboardSummary is the overview shown by the admin dashboard
+type DashboardSummary struct {
+	Pool                *PoolUtilization `json:"pool"`
+	Leases              *LeaseStats      `json:"leases"`
+	RateLimitRejections int64            `json:"rate_limit_rejections"` // since startup
+	LoadShedRejections  int64            `json:"load_shed_rejections"`  // refused with SERVER_BUSY since startup
+	Cache               *CacheStats      `json:"cache,omitempty"`       // omitted without a lease cache
+	StartedAt           time.Time        `json:"started_at"`
+}
+
+// DashboardAllocation is a lease recently allocated by this instance
+type DashboardAllocation struct {
+	TokenID     int64     `json:"token_id"`
+	IP          string    `json:"ip"`
+	PeerID      string    `json:"peer_id"`
+	Tenant      string    `json:"tenant"`
+	AllocatedAt time.Time `json:"allocated_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// DashboardPeer counts the allocations and renewals of one peer since startup
+type DashboardPeer struct {
+	PeerID string `json:"peer_id"`
+	Tenant string `json:"tenant"`
+	Leases int64  `json:"leases"`
+}
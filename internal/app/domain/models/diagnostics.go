@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// KeyClassMemoryUsage reports sampled Redis memory usage for one key pattern
+type KeyClassMemoryUsage struct {
+	Pattern        string  `json:"pattern"`
+	Keys           int64   `json:"keys"`
+	SampledKeys    int64   `json:"sampled_keys"`
+	SampledBytes   int64   `json:"sampled_bytes"`
+	AvgBytes       float64 `json:"avg_bytes"`
+	EstimatedBytes int64   `json:"estimated_bytes"`
+}
+
+// CacheMemoryReport aggregates memory usage across all key classes
+type CacheMemoryReport struct {
+	SampleSize          int                    `json:"sample_size"`
+	Classes             []*KeyClassMemoryUsage `json:"classes"`
+	TotalEstimatedBytes int64                  `json:"total_estimated_bytes"`
+	GeneratedAt         time.Time              `json:"generated_at"`
+}
+
+// RuntimeStats is a snapshot of the Go runtime and the connection pools of this instance
+type RuntimeStats struct {
+	GoVersion  string             `json:"go_version"`
+	NumCPU     int                `json:"num_cpu"`
+	GOMAXPROCS int                `json:"gomaxprocs"`
+	Goroutines int                `json:"goroutines"`
+	Uptime     float64            `json:"uptime_seconds"`
+	Memory     *MemoryStats       `json:"memory"`
+	GC         *GCStats           `json:"gc"`
+	Database   *DatabasePoolStats `json:"database,omitempty"` // omitted without a Postgres pool
+	Cache      *CachePoolStats    `json:"cache,omitempty"`    // omitted without Redis
+	SampledAt  time.Time          `json:"sampled_at"`
+}
+
+// MemoryStats reports heap and total memory obtained from the OS, in bytes
+type MemoryStats struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	Sys          uint64 `json:"sys"`
+	TotalAlloc   uint64 `json:"total_alloc"` // cumulative
+	Mallocs      uint64 `json:"mallocs"`     // cumulative
+	Frees        uint64 `json:"frees"`       // cumulative
+}
+
+// GCStats reports garbage collection since startup
+type GCStats struct {
+	NumGC        uint32    `json:"num_gc"`
+	PauseTotalMs float64   `json:"pause_total_ms"`
+	LastPauseMs  float64   `json:"last_pause_ms"`
+	LastGC       time.Time `json:"last_gc"`
+	NextGCBytes  uint64    `json:"next_gc_bytes"`
+}
+
+// ConnectionPoolStats is a snapshot of the connection pools of this instance
+type ConnectionPoolStats struct {
+	Database  *DatabasePoolStats `json:"database,omitempty"` // omitted without a Postgres pool
+	Cache     *CachePoolStats    `json:"cache,omitempty"`    // omitted without Redis
+	SampledAt time.Time          `json:"sampled_at"`
+}
+
+// DatabasePoolStats reports the Postgres connection pool. Counters are cumulative.
+type DatabasePoolStats struct {
+	MaxConns                int32   `json:"max_conns"`
+	TotalConns              int32   `json:"total_conns"`
+	AcquiredConns           int32   `json:"acquired_conns"`
+	IdleConns               int32   `json:"idle_conns"`
+	ConstructingConns       int32   `json:"constructing_conns"`
+	AcquireCount            int64   `json:"acquire_count"`
+	EmptyAcquireCount       int64   `json:"empty_acquire_count"` // acquisitions that waited for a connection
+	CanceledAcquireCount    int64   `json:"canceled_acquire_count"`
+	AcquireDurationMs       float64 `json:"acquire_duration_ms"` // total time spent acquiring
+	NewConnsCount           int64   `json:"new_conns_count"`
+	MaxLifetimeDestroyCount int64   `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroyCount     int64   `json:"max_idle_destroy_count"`
+}
+
+// CachePoolStats reports the Redis connection pools, summed over all nodes. Counters are
+// cumulative.
+type CachePoolStats struct {
+	TotalConns     uint32  `json:"total_conns"`
+	IdleConns      uint32  `json:"idle_conns"`
+	StaleConns     uint32  `json:"stale_conns"`
+	Hits           uint32  `json:"hits"`     // connections reused from the pool
+	Misses         uint32  `json:"misses"`   // connections dialed because none was idle
+	Timeouts       uint32  `json:"timeouts"` // waits for a connection that timed out
+	WaitCount      uint32  `json:"wait_count"`
+	WaitDurationMs float64 `json:"wait_duration_ms"` // total time spent waiting for a connection
+}
@@ -0,0 +1,15 @@
+package models
+
+// DNSRecord is an address record published for the holder of a lease
+type DNSRecord struct {
+	Hostname string // fully qualified, lower case
+	IP       string
+}
+
+// DNSReconcileResult counts the changes a reconciliation made to bring the zone in line
+// with the active leases
+type DNSReconcileResult struct {
+	Upserted  int
+	Deleted   int
+	Unchanged int
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is a message published on the event bus. A redelivered event keeps its ID, so
+// consumers can drop the duplicates at-least-once delivery allows.
+type Event struct {
+	ID      string          `json:"id"`
+	Topic   string          `json:"topic"`            // e.g. lease.allocated
+	Key     string          `json:"key,omitempty"`    // keeps related events in order, the partition key on Kafka
+	Source  string          `json:"source,omitempty"` // the instance that published the event
+	Time    time.Time       `json:"time"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// EventBusStats reports the event bus since startup
+type EventBusStats struct {
+	Broker    string            `json:"broker"`    // memory, nats or kafka
+	Published int64             `json:"published"` // events accepted by Publish
+	Rejected  int64             `json:"rejected"`  // events refused because a queue was full or the bus stopped
+	Received  int64             `json:"received"`  // events of other instances received from the broker
+	Sinks     []*EventSinkStats `json:"sinks"`
+}
+
+// EventSinkStats reports the deliveries to the broker or to one subscriber
+type EventSinkStats struct {
+	Name      string `json:"name"`            // "broker" or the name of the subscriber
+	Topic     string `json:"topic,omitempty"` // empty when the sink receives every topic
+	Queued    int    `json:"queued"`          // events waiting for delivery
+	Delivered int64  `json:"delivered"`
+	Retries   int64  `json:"retries"`   // failed attempts that were retried
+	Abandoned int64  `json:"abandoned"` // events still undelivered when the bus stopped
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ExpiringLease is an active lease that expires within the notification window
+type ExpiringLease struct {
+	TokenID   int64     `json:"token_id"`
+	PeerID    string    `json:"peer_id"`
+	TenantID  string    `json:"tenant"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExpiryChannelResult counts the deliveries of one notification channel during a run
+type ExpiryChannelResult struct {
+	Channel   string `json:"channel"`
+	Batches   int    `json:"batches"`
+	Notified  int    `json:"notified"` // leases in batches that were delivered
+	Failed    int    `json:"failed"`   // leases in batches that could not be delivered
+	LastError string `json:"last_error,omitempty"`
+}
+
+// ExpiryNotificationReport is the outcome of one expiring lease notification run
+type ExpiryNotificationReport struct {
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt time.Time              `json:"finished_at"`
+	Until      time.Time              `json:"until"` // leases expiring before this time were included
+	Found      int                    `json:"found"`
+	Channels   []*ExpiryChannelResult `json:"channels"`
+}
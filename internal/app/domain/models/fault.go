@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// FaultTarget is a dependency faults can be injected into
+type FaultTarget string
+
+const (
+	FaultTargetDatabase FaultTarget = "database" // the Postgres primary and its read replicas
+	FaultTargetCache    FaultTarget = "cache"    // Redis
+)
+
+// maxFaultLatency bounds the injected latency, so a fault slows calls down instead of
+// hanging them
+const maxFaultLatency = time.Minute
+
+// Fault slows down the calls of this instance to a dependency and fails a share of them,
+// to test how the instance degrades
+type Fault struct {
+	Target    FaultTarget `json:"target"`
+	LatencyMs int64       `json:"latency_ms"`          // added to every call
+	ErrorRate float64     `json:"error_rate"`          // share of calls failing, from 0 to 1
+	ExpiresAt time.Time   `json:"expires_at,omitzero"` // zero keeps the fault until it is cleared
+}
+
+// Latency returns the latency added to every call
+func (f *Fault) Latency() time.Duration {
+	return time.Duration(f.LatencyMs) * time.Millisecond
+}
+
+// Validate fails with ErrInvalidFault for an unknown target, a latency out of range or an
+// error rate outside [0, 1]
+func (f *Fault) Validate() error {
+	if f.Target != FaultTargetDatabase && f.Target != FaultTargetCache {
+		return domainErrors.ErrInvalidFault.WithDetails("target must be database or cache")
+	}
+	if f.LatencyMs < 0 || f.Latency() > maxFaultLatency {
+		return domainErrors.ErrInvalidFault.WithDetails("latency_ms must be between 0 and 60000")
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		return domainErrors.ErrInvalidFault.WithDetails("error_rate must be between 0 and 1")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Roles of an instance in an active/standby pair
+const (
+	HARoleActive  = "active"  // allocates, renews and releases leases
+	HARoleStandby = "standby" // mirrors the active instance's lease changes and takes over when it fails
+)
+
+// HAStatus describes the instance's part in an active/standby pair
+type HAStatus struct {
+	Role           string    `json:"role"`
+	Instance       string    `json:"instance"`
+	Epoch          int64     `json:"epoch"`                     // term of the active instance; writes of earlier terms are fenced off
+	ActiveInstance string    `json:"active_instance,omitempty"` // last instance known to have become active
+	ActivatedAt    time.Time `json:"activated_at,omitzero"`     // when that instance became active
+	MirroredDeltas int64     `json:"mirrored_deltas"`           // lease changes of the active instance applied while standing by
+	LastDeltaAt    time.Time `json:"last_delta_at,omitzero"`    // when the last of them arrived
+}
+
+// LeaseDelta is a lease change streamed from the active instance to the standby. It is
+// sent in the transaction of the change, so the standby only sees committed changes.
+type LeaseDelta struct {
+	Epoch    int64      `json:"epoch"`
+	TenantID string     `json:"tenant_id"`
+	TokenID  int64      `json:"token_id"`
+	PeerID   string     `json:"peer_id"`
+	Event    LeaseEvent `json:"event"`
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Health score component names
+const (
+	HealthComponentDatabase   = "database"
+	HealthComponentRedis      = "redis"
+	HealthComponentErrorRate  = "error_rate"
+	HealthComponentSaturation = "saturation"
+)
+
+// HealthScorePolicy weighs health signals into a score between 0 and 100. Each signal scores
+// 1 when ideal and falls linearly to 0 when it reaches its budget.
+type HealthScorePolicy struct {
+	Threshold          float64 // minimum score reported as healthy
+	DBLatencyBudget    time.Duration
+	RedisLatencyBudget time.Duration
+	ErrorRateBudget    float64 // fraction of requests failing with a 5xx
+	MaxInFlight        int64
+	DBWeight           float64
+	RedisWeight        float64
+	ErrorRateWeight    float64
+	SaturationWeight   float64
+}
+
+// HealthSignals is a point-in-time sample of the inputs to the health score
+type HealthSignals struct {
+	DBReachable    bool
+	DBLatency      time.Duration
+	RedisReachable bool
+	RedisLatency   time.Duration
+	ErrorRate      float64
+	InFlight       int64
+}
+
+// HealthComponent is the contribution of one signal to the health score
+type HealthComponent struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"` // latency in milliseconds, error rate or in-flight requests
+	Score  float64 `json:"score"` // between 0 and 1
+	Weight float64 `json:"weight"`
+}
+
+// HealthScore is the composite health of the instance as reported to load balancers
+type HealthScore struct {
+	Score      float64            `json:"score"`
+	Threshold  float64            `json:"threshold"`
+	Healthy    bool               `json:"healthy"`
+	Components []*HealthComponent `json:"components"`
+}
+
+// Score computes the weighted health score. An unreachable database or Redis drops the score
+// to 0 regardless of the other signals, since the instance cannot serve leases without them.
+func (p *HealthScorePolicy) Score(signals *HealthSignals) *HealthScore {
+	components := []*HealthComponent{
+		{
+			Name:   HealthComponentDatabase,
+			Value:  milliseconds(signals.DBLatency),
+			Score:  budgetScore(float64(signals.DBLatency), float64(p.DBLatencyBudget)),
+			Weight: p.DBWeight,
+		},
+		{
+			Name:   HealthComponentRedis,
+			Value:  milliseconds(signals.RedisLatency),
+			Score:  budgetScore(float64(signals.RedisLatency), float64(p.RedisLatencyBudget)),
+			Weight: p.RedisWeight,
+		},
+		{
+			Name:   HealthComponentErrorRate,
+			Value:  signals.ErrorRate,
+			Score:  budgetScore(signals.ErrorRate, p.ErrorRateBudget),
+			Weight: p.ErrorRateWeight,
+		},
+		{
+			Name:   HealthComponentSaturation,
+			Value:  float64(signals.InFlight),
+			Score:  budgetScore(float64(signals.InFlight), float64(p.MaxInFlight)),
+			Weight: p.SaturationWeight,
+		},
+	}
+	if !signals.DBReachable {
+		components[0].Score = 0
+	}
+	if !signals.RedisReachable {
+		components[1].Score = 0
+	}
+
+	var score, totalWeight float64
+	for _, c := range components {
+		score += c.Score * c.Weight
+		totalWeight += c.Weight
+	}
+	if totalWeight > 0 {
+		score = score / totalWeight * 100
+	}
+	if !signals.DBReachable || !signals.RedisReachable {
+		score = 0
+	}
+	score = math.Round(score*100) / 100
+
+	return &HealthScore{
+		Score:      score,
+		Threshold:  p.Threshold,
+		Healthy:    score >= p.Threshold,
+		Components: components,
+	}
+}
+
+// budgetScore scores value linearly from 1 at zero to 0 at budget. A non-positive budget
+// disables the signal, which then always scores 1.
+func budgetScore(value, budget float64) float64 {
+	if budget <= 0 {
+		return 1
+	}
+	return math.Round(math.Max(0, 1-value/budget)*1000) / 1000
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// Readiness statuses
+const (
+	ReadinessReady    = "ready"
+	ReadinessDegraded = "degraded" // an optional dependency is down but requests are still served
+	ReadinessNotReady = "not_ready"
+)
+
+// Component probe statuses
+const (
+	ComponentUp      = "up"
+	ComponentDown    = "down"
+	ComponentTimeout = "timeout"
+)
+
+// ComponentReadiness is the outcome of probing one dependency
+type ComponentReadiness struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Required  bool    `json:"required"` // a required component being down makes the instance not ready
+}
+
+// ReadinessReport is the readiness of the instance and each of its dependencies
+type ReadinessReport struct {
+	Status     string                `json:"status"`
+	Code       string                `json:"code,omitempty"`    // failed check, set when not ready
+	HARole     string                `json:"ha_role,omitempty"` // role in active/standby mode, absent without it
+	Components []*ComponentReadiness `json:"components"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// IdempotentResponse is the first response to a request carrying an idempotency key,
+// replayed to retries of the request
+type IdempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
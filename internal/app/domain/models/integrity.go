@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Integrity check names
+const (
+	IntegrityCheckDuplicateActiveLeases = "duplicate_active_leases"
+	IntegrityCheckAllocState            = "alloc_state_bounds"
+	IntegrityCheckNonceUsedAt           = "used_nonce_without_used_at"
+)
+
+// IntegrityViolation is one broken invariant found by an integrity check
+type IntegrityViolation struct {
+	Subject  string `json:"subject"` // the offending row, e.g. "token:167902210"
+	Detail   string `json:"detail"`
+	Repaired bool   `json:"repaired"`
+}
+
+// IntegrityCheckResult lists the violations found by one check
+type IntegrityCheckResult struct {
+	Check      string                `json:"check"`
+	Violations []*IntegrityViolation `json:"violations"`
+}
+
+// IntegrityReport is the outcome of an integrity run. Violations are only repaired when
+// Repair is set, and only where a safe repair exists.
+type IntegrityReport struct {
+	Repair     bool                    `json:"repair"`
+	StartedAt  time.Time               `json:"started_at"`
+	FinishedAt time.Time               `json:"finished_at"`
+	Checks     []*IntegrityCheckResult `json:"checks"`
+}
+
+// AddCheck appends an empty result for check and returns it
+func (r *IntegrityReport) AddCheck(check string) *IntegrityCheckResult {
+	result := &IntegrityCheckResult{Check: check, Violations: []*IntegrityViolation{}}
+	r.Checks = append(r.Checks, result)
+	return result
+}
+
+// Add records a violation
+func (r *IntegrityCheckResult) Add(subject, detail string, repaired bool) {
+	r.Violations = append(r.Violations, &IntegrityViolation{Subject: subject, Detail: detail, Repaired: repaired})
+}
+
+// Violations returns the number of violations found across all checks
+func (r *IntegrityReport) Violations() int {
+	var n int
+	for _, c := range r.Checks {
+		n += len(c.Violations)
+	}
+	return n
+}
+
+// Unrepaired returns the number of violations that are still present
+func (r *IntegrityReport) Unrepaired() int {
+	var n int
+	for _, c := range r.Checks {
+		for _, v := range c.Violations {
+			if !v.Repaired {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// AllocStateRepair returns the last_token_id that brings an allocation state back within
+// the pool bounds, or ok=false when the bounds themselves are invalid. highestLeased is the
+// highest leased token ID inside the pool, or 0 when there is none.
+func AllocStateRepair(minTokenID, maxTokenID, lastTokenID, highestLeased int64) (int64, bool) {
+	if minTokenID > maxTokenID {
+		return 0, false
+	}
+	if lastTokenID > maxTokenID {
+		return maxTokenID, true
+	}
+	// Never move the cursor below a leased token, or allocation would collide with it
+	return max(minTokenID-1, highestLeased), true
+}
+
+// AllocStateInBounds reports whether the allocation cursor lies within the pool
+func AllocStateInBounds(minTokenID, maxTokenID, lastTokenID int64) bool {
+	return minTokenID <= maxTokenID && lastTokenID >= minTokenID-1 && lastTokenID <= maxTokenID
+}
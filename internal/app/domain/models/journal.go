@@ -0,0 +1,262 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// JournalOp is the lease mutation a journal entry records
+type JournalOp string
+
+const (
+	JournalOpCheckpoint    JournalOp = "checkpoint"     // Snapshot holds the whole lease state
+	JournalOpAllocate      JournalOp = "allocate"       // the token ID was leased to PeerID, anew or taken over
+	JournalOpRenew         JournalOp = "renew"          // ExpiresAt is the new expiry
+	JournalOpRelease       JournalOp = "release"        // the lease expired at the entry time
+	JournalOpTransfer      JournalOp = "transfer"       // PeerID is the new holder
+	JournalOpLabels        JournalOp = "labels"         // Labels replace those of the lease
+	JournalOpAffinityGroup JournalOp = "affinity_group" // AffinityGroup is set on the lease
+	JournalOpDelegator     JournalOp = "delegator"      // DelegatedBy is set on the lease
+	JournalOpReclaim       JournalOp = "reclaim"        // TokenIDs were reclaimed at the entry time
+	JournalOpQuarantine    JournalOp = "quarantine"     // the lease expired and is withheld until QuarantinedUntil
+)
+
+// journalMaxMismatches caps the mismatches reported for one comparison
+const journalMaxMismatches = 20
+
+// JournalEntry is one record of the lease journal. Seq increases by one from entry to
+// entry; the fields besides Seq, Time and Op depend on the operation.
+type JournalEntry struct {
+	Seq              int64             `json:"seq"`
+	Time             time.Time         `json:"time"`
+	Op               JournalOp         `json:"op"`
+	Tenant           string            `json:"tenant,omitempty"`
+	TokenID          int64             `json:"token_id,omitempty"`
+	TokenIDs         []int64           `json:"token_ids,omitempty"`
+	PeerID           string            `json:"peer_id,omitempty"`
+	AffinityGroup    string            `json:"affinity_group,omitempty"`
+	DelegatedBy      string            `json:"delegated_by,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	CreatedAt        *time.Time        `json:"created_at,omitempty"`
+	ExpiresAt        *time.Time        `json:"expires_at,omitempty"`
+	QuarantinedUntil *time.Time        `json:"quarantined_until,omitempty"`
+	Cursor           bool              `json:"cursor,omitempty"` // the allocation advanced the pool's cursor to TokenID
+	Snapshot         *LeaseSnapshot    `json:"snapshot,omitempty"`
+}
+
+// JournalVerification is the outcome of checking the lease journal against the store
+type JournalVerification struct {
+	Seq          int64    `json:"seq"`                  // last sequence number of the journal
+	Mismatches   []string `json:"mismatches,omitempty"` // differences seen in two checks in a row
+	Checkpointed bool     `json:"checkpointed"`         // a checkpoint of the store was written
+}
+
+// JournalReplayReport summarizes a replay
+type JournalReplayReport struct {
+	Checkpoint int64                 `json:"checkpoint"` // sequence number of the checkpoint replayed from
+	LastSeq    int64                 `json:"last_seq"`   // sequence number of the last entry applied
+	Entries    int                   `json:"entries"`    // entries applied on top of the checkpoint
+	Skipped    int                   `json:"skipped"`    // entries about leases the state did not hold
+	Import     *SnapshotImportReport `json:"import"`
+}
+
+// JournalState is the lease state described by a journal, built by applying its entries
+// in order from a checkpoint on. Entries before the first checkpoint are skipped.
+type JournalState struct {
+	seq        int64
+	checkpoint int64
+	pools      map[string]*SnapshotPool
+	leases     map[int64]*SnapshotLease
+}
+
+func NewJournalState() *JournalState {
+	return &JournalState{}
+}
+
+// Seq returns the sequence number of the last entry applied
+func (s *JournalState) Seq() int64 {
+	return s.seq
+}
+
+// Checkpoint returns the sequence number of the checkpoint the state starts from, 0
+// before one was applied
+func (s *JournalState) Checkpoint() int64 {
+	return s.checkpoint
+}
+
+// Apply applies an entry and reports whether it changed the state. An entry about a
+// lease the state does not hold changes nothing.
+func (s *JournalState) Apply(entry *JournalEntry) bool {
+	s.seq = entry.Seq
+	if entry.Op == JournalOpCheckpoint {
+		s.restore(entry)
+		return true
+	}
+	if s.checkpoint == 0 {
+		return false
+	}
+
+	if entry.Op == JournalOpAllocate {
+		s.leases[entry.TokenID] = &SnapshotLease{
+			TokenID:       entry.TokenID,
+			PeerID:        entry.PeerID,
+			Tenant:        entry.Tenant,
+			AffinityGroup: entry.AffinityGroup,
+			ExpiresAt:     timeOrZero(entry.ExpiresAt),
+			CreatedAt:     timeOr(entry.CreatedAt, entry.Time),
+			UpdatedAt:     entry.Time,
+		}
+		if pool, ok := s.pools[entry.Tenant]; ok && entry.Cursor {
+			pool.LastTokenID = entry.TokenID
+		}
+		return true
+	}
+
+	if entry.Op == JournalOpReclaim {
+		changed := false
+		for _, tokenID := range entry.TokenIDs {
+			if lease, ok := s.leases[tokenID]; ok {
+				reclaimedAt := entry.Time
+				lease.ReclaimedAt = &reclaimedAt
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	lease, ok := s.leases[entry.TokenID]
+	if !ok {
+		return false
+	}
+	switch entry.Op {
+	case JournalOpRenew:
+		lease.ExpiresAt = timeOrZero(entry.ExpiresAt)
+		lease.UpdatedAt = entry.Time
+	case JournalOpRelease:
+		// Only the holder's release counts, the store ignores anyone else's
+		if lease.PeerID != entry.PeerID {
+			return false
+		}
+		lease.ExpiresAt = entry.Time
+		lease.UpdatedAt = entry.Time
+	case JournalOpTransfer:
+		lease.PeerID = entry.PeerID
+		lease.UpdatedAt = entry.Time
+	case JournalOpLabels:
+		lease.Labels = maps.Clone(entry.Labels)
+	case JournalOpAffinityGroup:
+		lease.AffinityGroup = entry.AffinityGroup
+	case JournalOpDelegator:
+		lease.DelegatedBy = entry.DelegatedBy
+	case JournalOpQuarantine:
+		lease.ExpiresAt = timeOr(entry.ExpiresAt, entry.Time)
+		lease.UpdatedAt = entry.Time
+		lease.QuarantinedUntil = entry.QuarantinedUntil
+	default:
+		return false
+	}
+	return true
+}
+
+// restore replaces the state with the snapshot of a checkpoint
+func (s *JournalState) restore(entry *JournalEntry) {
+	s.checkpoint = entry.Seq
+	s.pools = make(map[string]*SnapshotPool)
+	s.leases = make(map[int64]*SnapshotLease)
+	if entry.Snapshot == nil {
+		return
+	}
+	for _, pool := range entry.Snapshot.Pools {
+		copied := *pool
+		s.pools[pool.Tenant] = &copied
+	}
+	for _, lease := range entry.Snapshot.Leases {
+		copied := *lease
+		copied.Labels = maps.Clone(lease.Labels)
+		s.leases[lease.TokenID] = &copied
+	}
+}
+
+// Snapshot returns a copy of the state, pools ordered by tenant and leases by tenant and
+// token ID like an export
+func (s *JournalState) Snapshot() *LeaseSnapshot {
+	snapshot := &LeaseSnapshot{
+		Version: SnapshotVersion,
+		Pools:   make([]*SnapshotPool, 0, len(s.pools)),
+		Leases:  make([]*SnapshotLease, 0, len(s.leases)),
+	}
+	for _, pool := range s.pools {
+		copied := *pool
+		snapshot.Pools = append(snapshot.Pools, &copied)
+	}
+	for _, lease := range s.leases {
+		copied := *lease
+		copied.Labels = maps.Clone(lease.Labels)
+		snapshot.Leases = append(snapshot.Leases, &copied)
+	}
+	slices.SortFunc(snapshot.Pools, func(a, b *SnapshotPool) int {
+		return cmp.Compare(a.Tenant, b.Tenant)
+	})
+	slices.SortFunc(snapshot.Leases, func(a, b *SnapshotLease) int {
+		return cmp.Or(cmp.Compare(a.Tenant, b.Tenant), cmp.Compare(a.TokenID, b.TokenID))
+	})
+	return snapshot
+}
+
+// Mismatches compares the leases active at now with those of the store: who holds each
+// token ID, in which tenant, and until when. Expired leases are not compared, their
+// release time is only known to the second. At most journalMaxMismatches are listed.
+func (s *JournalState) Mismatches(store *LeaseSnapshot, now time.Time) []string {
+	stored := make(map[int64]*SnapshotLease, len(store.Leases))
+	for _, lease := range store.Leases {
+		if lease.ExpiresAt.After(now) {
+			stored[lease.TokenID] = lease
+		}
+	}
+	journaled := make(map[int64]*SnapshotLease, len(s.leases))
+	for tokenID, lease := range s.leases {
+		if lease.ExpiresAt.After(now) {
+			journaled[tokenID] = lease
+		}
+	}
+
+	var mismatches []string
+	for _, tokenID := range slices.Sorted(maps.Keys(stored)) {
+		have, want := journaled[tokenID], stored[tokenID]
+		switch {
+		case have == nil:
+			mismatches = append(mismatches, fmt.Sprintf("token ID %d: leased to %q in the store, not in the journal", tokenID, want.PeerID))
+		case have.PeerID != want.PeerID || have.Tenant != want.Tenant:
+			mismatches = append(mismatches, fmt.Sprintf("token ID %d: leased to %q of tenant %q in the store, to %q of tenant %q in the journal",
+				tokenID, want.PeerID, want.Tenant, have.PeerID, have.Tenant))
+		case !have.ExpiresAt.Equal(want.ExpiresAt):
+			mismatches = append(mismatches, fmt.Sprintf("token ID %d: expires at %s in the store, at %s in the journal",
+				tokenID, want.ExpiresAt.UTC().Format(time.RFC3339Nano), have.ExpiresAt.UTC().Format(time.RFC3339Nano)))
+		}
+	}
+	for _, tokenID := range slices.Sorted(maps.Keys(journaled)) {
+		if stored[tokenID] == nil {
+			mismatches = append(mismatches, fmt.Sprintf("token ID %d: leased to %q in the journal, not in the store", tokenID, journaled[tokenID].PeerID))
+		}
+	}
+
+	if len(mismatches) > journalMaxMismatches {
+		omitted := len(mismatches) - journalMaxMismatches
+		mismatches = append(mismatches[:journalMaxMismatches], fmt.Sprintf("and %d more", omitted))
+	}
+	return mismatches
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	return timeOr(t, time.Time{})
+}
+
+func timeOr(t *time.Time, fallback time.Time) time.Time {
+	if t == nil {
+		return fallback
+	}
+	return *t
+}
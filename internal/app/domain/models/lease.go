@@ -2,8 +2,13 @@ package models
 
 import (
 	"time"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 )
 
+// MaxPeerIDLength is the longest peer ID accepted anywhere a peer ID is taken
+const MaxPeerIDLength = 128
+
 type Lease struct {
 	TokenID   int64     `json:"token_id"`
 	PeerID    string    `json:"peer_id"`
@@ -11,4 +16,170 @@ type Lease struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 	Ttl       int32     `json:"ttl"`
+	Signature []byte    `json:"signature,omitempty"` // server's signature over the lease certificate, set on issued leases
+	KeyID     string    `json:"key_id,omitempty"`    // ID of the server key the signature was made with
+	Tenant    string    `json:"tenant,omitempty"`    // tenant whose pool the signature binds the token ID to, set on signed leases
+
+	Labels map[string]string `json:"labels,omitempty"` // set by the holder, see NormalizeLeaseLabels
+
+	RenewableAt *time.Time `json:"renewable_at,omitempty"` // set when a renewal came before the renewal window, the lease is unchanged
+	RenewAfter  *time.Time `json:"renew_after,omitempty"`  // server-suggested renewal time, set on leases handed to their holder
+}
+
+// ValidatePeerID fails with ErrMissingPeerID for an empty peer ID and with ErrInvalidPeerID
+// for one longer than MaxPeerIDLength
+func ValidatePeerID(peerID string) error {
+	if peerID == "" {
+		return domainErrors.ErrMissingPeerID
+	}
+	if len(peerID) > MaxPeerIDLength {
+		return domainErrors.ErrInvalidPeerID
+	}
+	return nil
+}
+
+// ValidateTokenID fails with ErrInvalidTokenID unless the token ID is positive. Whether it
+// lies in a pool is up to the tenant, see Tenant.Contains.
+func ValidateTokenID(tokenID int64) error {
+	if tokenID <= 0 {
+		return domainErrors.ErrInvalidTokenID
+	}
+	return nil
+}
+
+// Validate checks the token ID and peer ID of the lease and that it expires after it was
+// created. An expired lease is still valid.
+func (l *Lease) Validate() error {
+	if err := ValidateTokenID(l.TokenID); err != nil {
+		return err
+	}
+	if err := ValidatePeerID(l.PeerID); err != nil {
+		return err
+	}
+	if !l.CreatedAt.IsZero() && !l.ExpiresAt.After(l.CreatedAt) {
+		return domainErrors.ErrInvalidLease.WithDetails("expires_at must be after created_at")
+	}
+	return nil
+}
+
+// IsExpired reports whether the lease has expired at now. A lease expires at ExpiresAt.
+func (l *Lease) IsExpired(now time.Time) bool {
+	return !l.ExpiresAt.After(now)
+}
+
+// TimeRemaining returns how long the lease is still active at now, zero once it expired
+func (l *Lease) TimeRemaining(now time.Time) time.Duration {
+	return max(l.ExpiresAt.Sub(now), 0)
+}
+
+// AllocationReason explains how a requested token ID was handled during allocation
+type AllocationReason string
+
+const (
+	AllocationReasonGranted       AllocationReason = "granted"        // the requested token ID was assigned
+	AllocationReasonExistingLease AllocationReason = "existing_lease" // the peer already holds a lease
+	AllocationReasonInUse         AllocationReason = "in_use"         // the token ID is leased to another peer
+	AllocationReasonOutOfRange    AllocationReason = "out_of_range"   // the token ID is outside the pool
+	AllocationReasonUnavailable   AllocationReason = "unavailable"    // the token ID could not be assigned for another reason
+)
+
+// AllocationResult is the outcome of an allocation that asked for a preferred token ID
+type AllocationResult struct {
+	Lease            *Lease
+	RequestedTokenID int64
+	Granted          bool
+	Reason           AllocationReason
+}
+
+// LeaseTransferRequest moves an active lease from one peer identity to another.
+// Signature must be made by the current owner's key over the transfer payload.
+type LeaseTransferRequest struct {
+	TokenID    int64
+	FromPubkey []byte
+	ToPubkey   []byte
+	NonceID    string
+	Signature  []byte
+}
+
+// LeaseDelegationRequest allocates a lease for a downstream peer on behalf of an
+// authenticated gateway. Signature must be made by the gateway's key over the delegation payload.
+type LeaseDelegationRequest struct {
+	GatewayPeerID  string
+	GatewayPubkey  []byte
+	DelegatePubkey []byte
+	NonceID        string
+	Signature      []byte
+}
+
+// LeaseOperationType identifies an operation inside a lease batch
+type LeaseOperationType string
+
+const (
+	LeaseOperationAllocate LeaseOperationType = "allocate"
+	LeaseOperationRenew    LeaseOperationType = "renew"
+	LeaseOperationRelease  LeaseOperationType = "release"
+)
+
+// LeaseOperation is one item of a lease batch, performed on behalf of an authenticated peer
+type LeaseOperation struct {
+	Type    LeaseOperationType
+	PeerID  string
+	TokenID int64 // required for renew and release
+}
+
+// LeaseOperationResult holds the outcome of the operation at the same index in the batch.
+// Lease is nil for releases and failed operations.
+type LeaseOperationResult struct {
+	Lease *Lease
+	Err   error
+}
+
+// LeaseEvent is a step in the lifecycle of a token ID
+type LeaseEvent string
+
+const (
+	LeaseEventAllocate LeaseEvent = "allocate"
+	LeaseEventRenew    LeaseEvent = "renew"
+	LeaseEventRelease  LeaseEvent = "release"
+	LeaseEventTransfer LeaseEvent = "transfer" // PeerID is the new owner
+	LeaseEventExpire   LeaseEvent = "expire"   // recorded when an expired lease is taken over
+	LeaseEventConflict LeaseEvent = "conflict" // PeerID reported another node using the address
+)
+
+// LeaseHistoryEntry records one event of a token ID. ExpiresAt is the lease expiry after
+// the event; for expire events OccurredAt is the time the lease expired.
+type LeaseHistoryEntry struct {
+	TokenID    int64      `json:"token_id"`
+	PeerID     string     `json:"peer_id"`
+	Event      LeaseEvent `json:"event"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// LeaseRenewal is a renewal accepted without writing the database, e.g. while it was
+// unreachable, to be applied to the stored lease later
+type LeaseRenewal struct {
+	TokenID   int64     `json:"token_id"`
+	PeerID    string    `json:"peer_id"`
+	TenantID  string    `json:"tenant_id"`
+	ExpiresAt time.Time `json:"expires_at"` // lease expiry after the renewal
+	RenewedAt time.Time `json:"renewed_at"`
+}
+
+// LeaseConflictReport is sent by a peer that observes another node using its address
+type LeaseConflictReport struct {
+	TokenID        int64
+	PeerID         string // reporter, must hold the active lease of TokenID
+	ObservedPeerID string // the other node, when the reporter could identify it
+}
+
+// LeaseConflict is a recorded conflict report. A quarantined token ID was released and is
+// withheld from allocation until QuarantinedUntil.
+type LeaseConflict struct {
+	TokenID          int64     `json:"token_id"`
+	PeerID           string    `json:"peer_id"`
+	ObservedPeerID   string    `json:"observed_peer_id,omitempty"`
+	ReportedAt       time.Time `json:"reported_at"`
+	Quarantined      bool      `json:"quarantined"`
+	QuarantinedUntil time.Time `json:"quarantined_until,omitzero"`
 }
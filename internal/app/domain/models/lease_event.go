@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// LeaseLifecycleEventType names a change of a lease published to subscribers such as webhooks
+type LeaseLifecycleEventType string
+
+const (
+	LeaseLifecycleAllocated LeaseLifecycleEventType = "lease.allocated"
+	LeaseLifecycleRenewed   LeaseLifecycleEventType = "lease.renewed"
+	LeaseLifecycleReleased  LeaseLifecycleEventType = "lease.released"
+	LeaseLifecycleExpired   LeaseLifecycleEventType = "lease.expired" // published when the reclaimer reclaims the lease
+)
+
+// LeaseLifecycleEventTypes lists every published event type
+var LeaseLifecycleEventTypes = []LeaseLifecycleEventType{
+	LeaseLifecycleAllocated,
+	LeaseLifecycleRenewed,
+	LeaseLifecycleReleased,
+	LeaseLifecycleExpired,
+}
+
+// LeaseLifecycleEvent describes a lease after it changed
+type LeaseLifecycleEvent struct {
+	Type       LeaseLifecycleEventType
+	TokenID    int64
+	PeerID     string
+	TenantID   string
+	ExpiresAt  time.Time
+	OccurredAt time.Time
+}
+
+// LeaseEventPayload is the payload of a lease lifecycle event on the event bus, published
+// on the topic named after the event type and keyed by the token ID
+type LeaseEventPayload struct {
+	OccurredAt time.Time `json:"occurred_at"`
+	TokenID    int64     `json:"token_id"`
+	IP         string    `json:"ip"`
+	PeerID     string    `json:"peer_id"`
+	Tenant     string    `json:"tenant"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// Limits of the labels a client may attach to its lease
+const (
+	MaxLeaseLabels          = 16   // labels per lease
+	MaxLeaseLabelValueBytes = 128  // bytes per value, after trimming
+	MaxLeaseLabelsBytes     = 1024 // bytes of all keys and values together
+)
+
+// leaseLabelKeyPattern admits lowercase keys such as "role", "region" or "app.version" of
+// at most 63 characters
+var leaseLabelKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]{0,62}$`)
+
+// LeaseLabel selects the leases whose label Key is set to Value
+type LeaseLabel struct {
+	Key   string
+	Value string
+}
+
+// ParseLeaseLabel parses a "key=value" label selector
+func ParseLeaseLabel(raw string) (LeaseLabel, error) {
+	key, value, ok := strings.Cut(raw, "=")
+	if !ok || !leaseLabelKeyPattern.MatchString(key) {
+		return LeaseLabel{}, fmt.Errorf("label must be key=value with a lowercase key")
+	}
+	return LeaseLabel{Key: key, Value: value}, nil
+}
+
+// ParseLabelSelector parses a comma-separated selector such as "role=gateway,region!=eu"
+// into label filters: key=value requires the label, key!=value excludes it and also
+// matches leases without the label
+func ParseLabelSelector(selector string) ([]Filter, error) {
+	var filters []Filter
+	for _, requirement := range strings.Split(selector, ",") {
+		requirement = strings.TrimSpace(requirement)
+		raw, operator := requirement, FilterEq
+		if key, value, ok := strings.Cut(requirement, "!="); ok {
+			raw, operator = key+"="+value, FilterNe
+		}
+		label, err := ParseLeaseLabel(raw)
+		if err != nil {
+			return nil, fmt.Errorf("selector requirement %q must be key=value or key!=value with a lowercase key", requirement)
+		}
+		filters = append(filters, Filter{Field: "label", Operator: operator, Value: label})
+	}
+	return filters, nil
+}
+
+// NormalizeLeaseLabels checks labels supplied by a client and returns them with values
+// trimmed, or nil when there are none. Keys must match leaseLabelKeyPattern and values must
+// be printable UTF-8, within the limits above; anything else fails with ErrInvalidLabels.
+func NormalizeLeaseLabels(labels map[string]string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	if len(labels) > MaxLeaseLabels {
+		return nil, domainErrors.ErrInvalidLabels.WithDetails(fmt.Sprintf("at most %d labels are allowed", MaxLeaseLabels))
+	}
+
+	normalized := make(map[string]string, len(labels))
+	size := 0
+	for key, value := range labels {
+		if !leaseLabelKeyPattern.MatchString(key) {
+			return nil, domainErrors.ErrInvalidLabels.WithDetails(fmt.Sprintf("label key %q must be lowercase letters, digits, '.', '_', '/' or '-', up to 63 characters", key))
+		}
+		value = strings.TrimSpace(value)
+		if !utf8.ValidString(value) || strings.IndexFunc(value, unicode.IsControl) >= 0 {
+			return nil, domainErrors.ErrInvalidLabels.WithDetails(fmt.Sprintf("label %q must be printable UTF-8", key))
+		}
+		if len(value) > MaxLeaseLabelValueBytes {
+			return nil, domainErrors.ErrInvalidLabels.WithDetails(fmt.Sprintf("label %q exceeds %d bytes", key, MaxLeaseLabelValueBytes))
+		}
+		normalized[key] = value
+		size += len(key) + len(value)
+	}
+	if size > MaxLeaseLabelsBytes {
+		return nil, domainErrors.ErrInvalidLabels.WithDetails(fmt.Sprintf("labels exceed %d bytes", MaxLeaseLabelsBytes))
+	}
+	return normalized, nil
+}
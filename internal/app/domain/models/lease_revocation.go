@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// Limits of a revocation request
+const (
+	MaxRevocationPeerIDs      = 1000
+	maxRevocationReasonLength = 256
+)
+
+// LeaseRevocationFilter selects the active leases an admin revokes. A lease must match
+// every criterion given, and at least one besides the tenant is required so that a
+// revocation never empties a pool by accident.
+type LeaseRevocationFilter struct {
+	Tenant        string   `json:"tenant,omitempty"` // restricts the revocation to the tenant's pool
+	PeerIDs       []string `json:"peer_ids,omitempty"`
+	MinTokenID    int64    `json:"min_token_id,omitempty"`
+	MaxTokenID    int64    `json:"max_token_id,omitempty"`
+	CIDR          string   `json:"cidr,omitempty"`           // IPv4 prefix within 10.0.0.0/8 such as 10.0.4.0/22
+	LabelSelector string   `json:"label_selector,omitempty"` // e.g. "role=gateway,region!=eu"
+}
+
+// Validate fails with ErrInvalidRevocation for a filter without criteria, with too many
+// or invalid peer IDs, an inverted token range, a malformed CIDR or label selector
+func (f *LeaseRevocationFilter) Validate() error {
+	if len(f.PeerIDs) == 0 && f.MinTokenID == 0 && f.MaxTokenID == 0 && f.CIDR == "" && f.LabelSelector == "" {
+		return domainErrors.ErrInvalidRevocation
+	}
+
+	if len(f.PeerIDs) > MaxRevocationPeerIDs {
+		return domainErrors.ErrInvalidRevocation.WithDetails(fmt.Sprintf("at most %d peer IDs are allowed", MaxRevocationPeerIDs))
+	}
+	for _, peerID := range f.PeerIDs {
+		if err := ValidatePeerID(peerID); err != nil {
+			return domainErrors.ErrInvalidRevocation.WithDetails("invalid peer ID " + peerID)
+		}
+	}
+
+	if f.MinTokenID < 0 || f.MaxTokenID < 0 {
+		return domainErrors.ErrInvalidRevocation.WithDetails("token IDs must not be negative")
+	}
+	if f.MaxTokenID > 0 && f.MinTokenID > f.MaxTokenID {
+		return domainErrors.ErrInvalidRevocation.WithDetails("min_token_id must not exceed max_token_id")
+	}
+
+	if f.CIDR != "" {
+		prefix, err := netip.ParsePrefix(f.CIDR)
+		if err != nil || !prefix.Addr().Is4() {
+			return domainErrors.ErrInvalidRevocation.WithDetails("cidr must be an IPv4 prefix such as 10.0.4.0/22")
+		}
+	}
+
+	if f.LabelSelector != "" {
+		if _, err := ParseLabelSelector(f.LabelSelector); err != nil {
+			return domainErrors.ErrInvalidRevocation.WithDetails(err.Error())
+		}
+	}
+	return nil
+}
+
+// LeaseRevocationRequest asks to revoke the leases matching Filter. A dry run only lists
+// them.
+type LeaseRevocationRequest struct {
+	Filter LeaseRevocationFilter `json:"filter"`
+	Reason string                `json:"reason,omitempty"` // recorded in the audit log
+	DryRun bool                  `json:"-"`
+}
+
+// Validate checks the filter and that the reason is at most 256 characters
+func (r *LeaseRevocationRequest) Validate() error {
+	if err := r.Filter.Validate(); err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(r.Reason)) > maxRevocationReasonLength {
+		return domainErrors.ErrInvalidRevocation.WithDetails(fmt.Sprintf("reason must be at most %d characters", maxRevocationReasonLength))
+	}
+	return nil
+}
+
+// LeaseRevocationState is the progress of a revocation
+type LeaseRevocationState string
+
+const (
+	LeaseRevocationRunning   LeaseRevocationState = "running"
+	LeaseRevocationCompleted LeaseRevocationState = "completed"
+	LeaseRevocationFailed    LeaseRevocationState = "failed" // stopped at a batch that could not be revoked
+)
+
+// LeaseRevocation reports a revocation. The leases matching the filter are revoked in
+// batches of one transaction each, so a failed revocation keeps the batches before the
+// failure revoked. A lease released, transferred or expired between matching and its
+// batch is skipped.
+type LeaseRevocation struct {
+	ID         string                `json:"id,omitempty"` // empty for dry runs, which are not kept
+	State      LeaseRevocationState  `json:"state"`
+	DryRun     bool                  `json:"dry_run"`
+	Filter     LeaseRevocationFilter `json:"filter"`
+	Reason     string                `json:"reason,omitempty"`
+	Matched    int                   `json:"matched"`   // active leases matching the filter at the start
+	Processed  int                   `json:"processed"` // matched leases whose batch was revoked
+	Revoked    int                   `json:"revoked"`
+	Skipped    int                   `json:"skipped"`
+	TokenIDs   []int64               `json:"token_ids"` // the matched token IDs of a dry run, the revoked ones otherwise
+	Error      string                `json:"error,omitempty"`
+	StartedAt  time.Time             `json:"started_at"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// LeaseState selects leases by whether they have expired
+type LeaseState string
+
+const (
+	LeaseStateActive  LeaseState = "active"
+	LeaseStateExpired LeaseState = "expired"
+	LeaseStateAll     LeaseState = "all"
+)
+
+// LeaseSearch selects leases by their fields and labels, like a listing, and by the
+// attributes of their holders
+type LeaseSearch struct {
+	ListOptions
+	State  LeaseState // LeaseStateActive when empty
+	Policy string     // name of the peer policy governing the holders, empty for any
+}
+
+// LeaseSearchHit is a lease found by a search with the address it stands for and the
+// peer policy governing its holder
+type LeaseSearchHit struct {
+	*Lease
+	IP     string `json:"ip"`
+	Policy string `json:"policy,omitempty"` // empty without peer policies
+}
+
+// LeaseSearchPage is one page of search results
+type LeaseSearchPage struct {
+	Leases     []*LeaseSearchHit `json:"leases"`
+	HasMore    bool              `json:"has_more"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
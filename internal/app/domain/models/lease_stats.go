@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LeaseStats summarizes the lease table as of the last read model refresh
+type LeaseStats struct {
+	Active      int64     `json:"active"`
+	Expired     int64     `json:"expired"`
+	Total       int64     `json:"total"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
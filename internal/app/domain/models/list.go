@@ -0,0 +1,49 @@
+package models
+
+// FilterOperator compares a collection field with a filter value
+type FilterOperator string
+
+const (
+	FilterEq     FilterOperator = "eq"
+	FilterNe     FilterOperator = "ne"
+	FilterLt     FilterOperator = "lt"
+	FilterLte    FilterOperator = "lte"
+	FilterGt     FilterOperator = "gt"
+	FilterGte    FilterOperator = "gte"
+	FilterPrefix FilterOperator = "prefix" // strings only
+	FilterIn     FilterOperator = "in"     // the value is a []string the field must be one of
+	FilterNotIn  FilterOperator = "not_in" // the value is a []string the field must not be one of
+)
+
+// Filter keeps the items whose field compares to Value with Operator. Value is a string,
+// int64, time.Time, []string or LeaseLabel depending on the field and operator.
+type Filter struct {
+	Field    string
+	Operator FilterOperator
+	Value    any
+}
+
+// Cursor identifies the last item of the previous page by its sort value and key, so the
+// next page starts right after it even when items are added or removed in between.
+type Cursor struct {
+	SortValue any
+	Key       int64
+}
+
+// ListOptions selects one page of a collection. Items are ordered by SortField and then by
+// their key. When After is set it takes precedence over Offset.
+type ListOptions struct {
+	Limit      int
+	Offset     int
+	After      *Cursor
+	SortField  string
+	Descending bool
+	Filters    []Filter
+}
+
+// LeasePage is one page of a lease listing
+type LeasePage struct {
+	Leases     []*Lease `json:"leases"`
+	HasMore    bool     `json:"has_more"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 )
 
 type Nonce struct {
@@ -13,9 +15,59 @@ type Nonce struct {
 	UsedAt    time.Time
 }
 
+// Validate checks that the nonce has an ID, a valid peer ID and expires after it was
+// issued. Expired and used nonces are still valid.
+func (n *Nonce) Validate() error {
+	if n.ID == "" {
+		return domainErrors.ErrMissingNonce
+	}
+	if err := ValidatePeerID(n.PeerID); err != nil {
+		return err
+	}
+	if !n.ExpiresAt.After(n.IssuedAt) {
+		return domainErrors.ErrInvalidNonce.WithDetails("expires_at must be after issued_at")
+	}
+	return nil
+}
+
+// IsExpired reports whether the nonce has expired at now. A nonce expires at ExpiresAt.
+func (n *Nonce) IsExpired(now time.Time) bool {
+	return !n.ExpiresAt.After(now)
+}
+
+// TimeRemaining returns how long the nonce can still be used at now, zero once it expired
+func (n *Nonce) TimeRemaining(now time.Time) time.Duration {
+	return max(n.ExpiresAt.Sub(now), 0)
+}
+
 type NonceRequest struct {
 	NonceID   string
 	Pubkey    []byte
 	Payload   []byte
 	Signature []byte
 }
+
+// NoncePeerRate reports how many nonces one peer requested over the last minute
+type NoncePeerRate struct {
+	PeerID    string `json:"peer_id"`
+	PerMinute int64  `json:"per_minute"`
+}
+
+// NonceCleanupReport is the outcome of one expired nonce cleanup run
+type NonceCleanupReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Batches    int       `json:"batches"`
+	Deleted    int64     `json:"deleted"`
+}
+
+// NonceMetrics summarizes nonce issuance and cleanup since startup
+type NonceMetrics struct {
+	Issued      int64               `json:"issued"`
+	Reused      int64               `json:"reused"`
+	Rejected    int64               `json:"rejected"`
+	Revoked     int64               `json:"revoked"`                // outstanding nonces revoked by their peer or an admin
+	Deleted     int64               `json:"deleted"`                // expired nonces removed by cleanup runs
+	LastCleanup *NonceCleanupReport `json:"last_cleanup,omitempty"` // nil until a cleanup run completed
+	Peers       []*NoncePeerRate    `json:"peers"`                  // busiest peers first
+}
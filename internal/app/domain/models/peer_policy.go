@@ -0,0 +1,148 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+// PeerPolicyWildcard in the peers of a policy matches every peer no other policy lists
+const PeerPolicyWildcard = "*"
+
+const maxPeerPolicyNameLength = 64
+
+// PeerPermission is an operation a peer policy may grant
+type PeerPermission string
+
+const (
+	PeerPermissionAllocate PeerPermission = "can_allocate" // allocate a lease, also through a gateway or a batch
+	PeerPermissionReserve  PeerPermission = "can_reserve"  // allocate a requested token ID
+)
+
+// PeerPolicy grants permissions to a group of peers. A peer is governed by the first
+// policy listing its peer ID, or else by the first wildcard policy; a peer no policy
+// matches is refused.
+type PeerPolicy struct {
+	ID          int64     `json:"id,omitempty"` // 0 for policies from the configuration file
+	Name        string    `json:"name"`
+	Peers       []string  `json:"peers"`
+	CanAllocate bool      `json:"can_allocate"`
+	CanReserve  bool      `json:"can_reserve"`
+	MaxPool     int       `json:"max_pool"` // active leases the listed peers may hold together, 0 for no limit
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+}
+
+// Validate fails with ErrInvalidPeerPolicy for a policy without a name or peers, with an
+// invalid peer ID, or with a max_pool on a wildcard, whose group cannot be counted
+func (p *PeerPolicy) Validate() error {
+	name := strings.TrimSpace(p.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxPeerPolicyNameLength {
+		return domainErrors.ErrInvalidPeerPolicy.WithDetails("name must be 1 to 64 characters")
+	}
+	if len(p.Peers) == 0 {
+		return domainErrors.ErrInvalidPeerPolicy.WithDetails("peers must not be empty")
+	}
+	for _, peer := range p.Peers {
+		if peer == PeerPolicyWildcard {
+			continue
+		}
+		if err := ValidatePeerID(peer); err != nil {
+			return domainErrors.ErrInvalidPeerPolicy.WithDetails("invalid peer ID " + peer)
+		}
+	}
+	if p.MaxPool < 0 {
+		return domainErrors.ErrInvalidPeerPolicy.WithDetails("max_pool must not be negative")
+	}
+	if p.MaxPool > 0 && p.Wildcard() {
+		return domainErrors.ErrInvalidPeerPolicy.WithDetails("max_pool cannot be set on a wildcard policy")
+	}
+	return nil
+}
+
+// Wildcard reports whether the policy matches every peer
+func (p *PeerPolicy) Wildcard() bool {
+	return slices.Contains(p.Peers, PeerPolicyWildcard)
+}
+
+// Lists reports whether the policy names peerID explicitly
+func (p *PeerPolicy) Lists(peerID string) bool {
+	return slices.Contains(p.Peers, peerID)
+}
+
+// Grants reports whether the policy grants permission. Reserving a token ID allocates a
+// lease, so it needs both permissions.
+func (p *PeerPolicy) Grants(permission PeerPermission) bool {
+	switch permission {
+	case PeerPermissionAllocate:
+		return p.CanAllocate
+	case PeerPermissionReserve:
+		return p.CanAllocate && p.CanReserve
+	default:
+		return false
+	}
+}
+
+// PeerPolicyFor returns the policy governing peerID, nil when none matches
+func PeerPolicyFor(policies []*PeerPolicy, peerID string) *PeerPolicy {
+	var wildcard *PeerPolicy
+	for _, policy := range policies {
+		if policy.Lists(peerID) {
+			return policy
+		}
+		if wildcard == nil && policy.Wildcard() {
+			wildcard = policy
+		}
+	}
+	return wildcard
+}
+
+// PeerPolicyFilter returns a peer_id filter selecting the peers the named policy governs
+// under the first-match rule of PeerPolicyFor, false when no policy has that name. The
+// peers of a listing policy are those it lists and no earlier policy does; the first
+// wildcard policy governs every peer except those another policy claims first.
+func PeerPolicyFilter(policies []*PeerPolicy, name string) (Filter, bool) {
+	index := slices.IndexFunc(policies, func(p *PeerPolicy) bool { return p.Name == name })
+	if index < 0 {
+		return Filter{}, false
+	}
+	policy := policies[index]
+
+	claimed := make(map[string]bool)
+	wildcardBefore := false
+	for _, earlier := range policies[:index] {
+		for _, peer := range earlier.Peers {
+			claimed[peer] = true
+		}
+		wildcardBefore = wildcardBefore || earlier.Wildcard()
+	}
+
+	if !policy.Wildcard() || wildcardBefore {
+		peers := []string{}
+		for _, peer := range policy.Peers {
+			if peer != PeerPolicyWildcard && !claimed[peer] {
+				peers = append(peers, peer)
+			}
+		}
+		slices.Sort(peers)
+		return Filter{Field: "peer_id", Operator: FilterIn, Value: slices.Compact(peers)}, true
+	}
+
+	// Peers listed by a later policy belong to it unless this policy lists them as well
+	for _, later := range policies[index+1:] {
+		for _, peer := range later.Peers {
+			if !policy.Lists(peer) {
+				claimed[peer] = true
+			}
+		}
+	}
+	delete(claimed, PeerPolicyWildcard)
+	excluded := make([]string, 0, len(claimed))
+	for peer := range claimed {
+		excluded = append(excluded, peer)
+	}
+	slices.Sort(excluded)
+	return Filter{Field: "peer_id", Operator: FilterNotIn, Value: excluded}, true
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// PoolLeaseCounts counts the leases of a tenant pool by state
+type PoolLeaseCounts struct {
+	Allocated          int64 // active leases
+	Reserved           int64 // expired leases withheld from allocation by a quarantine
+	Grace              int64 // leases released or expired within the release grace period
+	ExpiredReclaimable int64 // expired leases a new allocation may take over
+	Allocations        int64 // allocations within the counted window
+}
+
+// PoolStats reports how the token IDs of a tenant pool are used. Every token ID is
+// counted in exactly one of allocated, reserved, grace, expired_reclaimable and free.
+type PoolStats struct {
+	Tenant             string  `json:"tenant"`
+	Total              int64   `json:"total"`
+	Allocated          int64   `json:"allocated"`
+	Reserved           int64   `json:"reserved"`
+	Grace              int64   `json:"grace"` // released or expired too recently to be reused
+	ExpiredReclaimable int64   `json:"expired_reclaimable"`
+	Free               int64   `json:"free"`                 // token IDs never leased
+	AllocationsPerHour int64   `json:"allocations_per_hour"` // allocations over the last hour
+	Utilization        float64 `json:"utilization"`          // (allocated + reserved + grace) / total, between 0 and 1
+}
+
+// NewPoolStats relates the lease counts of a pool to its size
+func NewPoolStats(tenant *Tenant, counts *PoolLeaseCounts) *PoolStats {
+	stats := &PoolStats{
+		Tenant:             tenant.ID,
+		Total:              tenant.MaxTokenID - tenant.MinTokenID + 1,
+		Allocated:          counts.Allocated,
+		Reserved:           counts.Reserved,
+		Grace:              counts.Grace,
+		ExpiredReclaimable: counts.ExpiredReclaimable,
+		AllocationsPerHour: counts.Allocations,
+	}
+	withheld := stats.Allocated + stats.Reserved + stats.Grace
+	stats.Free = max(stats.Total-withheld-stats.ExpiredReclaimable, 0)
+	if stats.Total > 0 {
+		stats.Utilization = float64(withheld) / float64(stats.Total)
+	}
+	return stats
+}
+
+// PoolStatsReport lists the stats of every tenant pool, the default pool first
+type PoolStatsReport struct {
+	Pools      []*PoolStats `json:"pools"`
+	ComputedAt time.Time    `json:"computed_at"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// ReclaimPolicy decides when an expired lease may be reclaimed and handed to another peer.
+// Every configured condition must hold for the policy to match; zero values disable a condition.
+type ReclaimPolicy struct {
+	Name               string
+	NotRenewedFor      time.Duration // minimum time since the lease was last allocated, renewed or transferred
+	ExpiredFor         time.Duration // minimum time since the lease expired
+	SkipAffinityGroups bool          // leases in an affinity group are reserved for the group and never match
+}
+
+// Matches reports whether the candidate satisfies every condition of the policy at now
+func (p *ReclaimPolicy) Matches(candidate *ReclaimCandidate, now time.Time) bool {
+	if p.SkipAffinityGroups && candidate.AffinityGroup != "" {
+		return false
+	}
+	if now.Sub(candidate.UpdatedAt) < p.NotRenewedFor {
+		return false
+	}
+	if now.Sub(candidate.ExpiresAt) < p.ExpiredFor {
+		return false
+	}
+	return true
+}
+
+// ReclaimCandidate is an expired lease that has not been reclaimed yet
+type ReclaimCandidate struct {
+	TokenID       int64
+	PeerID        string
+	TenantID      string
+	AffinityGroup string
+	ExpiresAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ReclaimPolicyResult lists the leases attributed to one policy during a reclamation run
+type ReclaimPolicyResult struct {
+	Policy    string  `json:"policy"`
+	Matched   int     `json:"matched"`
+	Reclaimed int64   `json:"reclaimed"`
+	TokenIDs  []int64 `json:"token_ids"`
+}
+
+// ReclaimReport is the outcome of a reclamation run. In dry-run mode leases are only
+// matched against the policies and Reclaimed stays zero.
+type ReclaimReport struct {
+	DryRun     bool                   `json:"dry_run"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt time.Time              `json:"finished_at"`
+	Evaluated  int                    `json:"evaluated"`
+	Reclaimed  int64                  `json:"reclaimed"`
+	Policies   []*ReclaimPolicyResult `json:"policies"`
+}
+
+// ReclaimPolicyMetrics holds cumulative counters for one policy since startup
+type ReclaimPolicyMetrics struct {
+	Policy        string `json:"policy"`
+	Matched       int64  `json:"matched"`
+	DryRunMatched int64  `json:"dry_run_matched"`
+	Reclaimed     int64  `json:"reclaimed"`
+}
+
+// ReclaimMetrics summarizes the reclamation engine since startup
+type ReclaimMetrics struct {
+	Runs      int64                   `json:"runs"`
+	Failures  int64                   `json:"failures"`
+	LastRunAt time.Time               `json:"last_run_at"`
+	Policies  []*ReclaimPolicyMetrics `json:"policies"`
+}
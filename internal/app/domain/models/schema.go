@@ -0,0 +1,43 @@
+package models
+
+import "slices"
+
+// SchemaStatus compares the migrations recorded in the database with those shipped in
+// the binary
+type SchemaStatus struct {
+	Current string   `json:"current"` // newest applied version, empty on a fresh database
+	Latest  string   `json:"latest"`  // newest version shipped in the binary
+	Applied []string `json:"applied"`
+	Pending []string `json:"pending"` // shipped but not applied, oldest first
+	Unknown []string `json:"unknown"` // applied but not shipped, i.e. written by a newer binary
+}
+
+// NewSchemaStatus derives the status from the shipped and applied migration versions.
+// Versions are timestamps, so they order lexicographically.
+func NewSchemaStatus(available, applied []string) *SchemaStatus {
+	status := &SchemaStatus{Applied: slices.Sorted(slices.Values(applied))}
+
+	for _, version := range status.Applied {
+		if !slices.Contains(available, version) {
+			status.Unknown = append(status.Unknown, version)
+		}
+	}
+	for _, version := range slices.Sorted(slices.Values(available)) {
+		if !slices.Contains(applied, version) {
+			status.Pending = append(status.Pending, version)
+		}
+	}
+
+	if n := len(status.Applied); n > 0 {
+		status.Current = status.Applied[n-1]
+	}
+	if n := len(available); n > 0 {
+		status.Latest = slices.Max(available)
+	}
+	return status
+}
+
+// UpToDate reports whether every shipped migration, and nothing else, has been applied
+func (s *SchemaStatus) UpToDate() bool {
+	return len(s.Pending) == 0 && len(s.Unknown) == 0
+}
@@ -0,0 +1,20 @@
+package models
+
+// SigningKey is a server key lease certificates are verified against. Leases are signed
+// with the current key; retired keys stay published until the certificates they signed
+// are no longer needed.
+type SigningKey struct {
+	KeyID     string `json:"key_id"`
+	PublicKey []byte `json:"public_key"` // marshalled libp2p public key
+	PeerID    string `json:"peer_id"`
+	Current   bool   `json:"current"`
+}
+
+// SigningKeyRotation is the outcome of replacing the current signing key
+type SigningKeyRotation struct {
+	Current *SigningKey `json:"current"`
+	Retired *SigningKey `json:"retired"`
+	// File the retired key was moved to, empty when the key was not read from a file. List
+	// it in security.server_verification_key_paths to keep publishing the key after a restart.
+	RetiredKeyPath string `json:"retired_key_path,omitempty"`
+}
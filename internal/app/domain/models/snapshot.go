@@ -0,0 +1,121 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot format written by exports. Imports
+// accept this version only.
+const SnapshotVersion = 1
+
+// snapshotMaxViolations caps the violations reported for one snapshot
+const snapshotMaxViolations = 20
+
+// LeaseSnapshot is the lease state of a store: the pool of every tenant with its
+// allocation cursor, and every lease, including expired and quarantined ones. Nonces and
+// the lease history are not part of it.
+type LeaseSnapshot struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Pools      []*SnapshotPool  `json:"pools"`
+	Leases     []*SnapshotLease `json:"leases"`
+}
+
+// SnapshotPool mirrors the alloc_state row of a tenant
+type SnapshotPool struct {
+	Tenant      string `json:"tenant"`
+	MinTokenID  int64  `json:"min_token_id"`
+	MaxTokenID  int64  `json:"max_token_id"`
+	LastTokenID int64  `json:"last_token_id"` // allocation cursor, min_token_id - 1 before the first allocation
+}
+
+// SnapshotLease mirrors a row of the leases table
+type SnapshotLease struct {
+	TokenID          int64             `json:"token_id"`
+	PeerID           string            `json:"peer_id"`
+	Tenant           string            `json:"tenant"`
+	AffinityGroup    string            `json:"affinity_group,omitempty"`
+	DelegatedBy      string            `json:"delegated_by,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	ExpiresAt        time.Time         `json:"expires_at"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	ReclaimedAt      *time.Time        `json:"reclaimed_at,omitempty"`
+	QuarantinedUntil *time.Time        `json:"quarantined_until,omitempty"` // the token ID is withheld from allocation until then
+}
+
+// SnapshotImportReport summarizes an import
+type SnapshotImportReport struct {
+	Pools    int   `json:"pools"`
+	Leases   int   `json:"leases"`
+	Replaced int64 `json:"replaced"` // leases removed from the store
+}
+
+// Violations checks that the snapshot can be imported into a server configured with
+// tenants: it must have the current version, only hold pools of configured tenants with
+// the configured bounds and cursors within them, and every lease must belong to the pool
+// of its tenant. At most snapshotMaxViolations are listed.
+func (s *LeaseSnapshot) Violations(tenants []*Tenant) []string {
+	if s.Version != SnapshotVersion {
+		return []string{fmt.Sprintf("unsupported snapshot version %d, expected %d", s.Version, SnapshotVersion)}
+	}
+
+	var violations []string
+	var omitted int
+	violate := func(format string, args ...any) {
+		if len(violations) == snapshotMaxViolations {
+			omitted++
+			return
+		}
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+
+	configured := make(map[string]*Tenant, len(tenants))
+	for _, tenant := range tenants {
+		configured[tenant.ID] = tenant
+	}
+
+	pools := make(map[string]bool, len(s.Pools))
+	for i, pool := range s.Pools {
+		tenant, ok := configured[pool.Tenant]
+		switch {
+		case !ok:
+			violate("pools[%d]: tenant %q is not configured", i, pool.Tenant)
+		case pools[pool.Tenant]:
+			violate("pools[%d]: tenant %q has more than one pool", i, pool.Tenant)
+		case pool.MinTokenID != tenant.MinTokenID || pool.MaxTokenID != tenant.MaxTokenID:
+			violate("pools[%d]: pool of tenant %q is [%d, %d], configured [%d, %d]",
+				i, pool.Tenant, pool.MinTokenID, pool.MaxTokenID, tenant.MinTokenID, tenant.MaxTokenID)
+		case !AllocStateInBounds(pool.MinTokenID, pool.MaxTokenID, pool.LastTokenID):
+			violate("pools[%d]: last_token_id %d of tenant %q is outside [%d, %d]",
+				i, pool.LastTokenID, pool.Tenant, pool.MinTokenID-1, pool.MaxTokenID)
+		}
+		pools[pool.Tenant] = true
+	}
+
+	tokenIDs := make(map[int64]bool, len(s.Leases))
+	for i, lease := range s.Leases {
+		tenant, ok := configured[lease.Tenant]
+		switch {
+		case lease.PeerID == "":
+			violate("leases[%d]: token ID %d has no peer ID", i, lease.TokenID)
+		case tokenIDs[lease.TokenID]:
+			violate("leases[%d]: token ID %d is leased more than once", i, lease.TokenID)
+		case !ok:
+			violate("leases[%d]: tenant %q of token ID %d is not configured", i, lease.Tenant, lease.TokenID)
+		case !tenant.Contains(lease.TokenID):
+			violate("leases[%d]: token ID %d is outside the pool of tenant %q", i, lease.TokenID, lease.Tenant)
+		default:
+			if _, err := NormalizeLeaseLabels(lease.Labels); err != nil {
+				violate("leases[%d]: labels of token ID %d are invalid: %v", i, lease.TokenID, err)
+			}
+		}
+		tokenIDs[lease.TokenID] = true
+	}
+
+	if omitted > 0 {
+		violations = append(violations, fmt.Sprintf("and %d more", omitted))
+	}
+	return violations
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// DefaultTenantID is the tenant of requests without an API key. It is served from the
+// top-level pool and owns every lease stored before tenants were introduced.
+const DefaultTenantID = "default"
+
+// Tenant is a namespace with its own token ID pool. Leases, their history and the
+// allocation cursor are kept per tenant; pools of different tenants never overlap, so
+// a token ID, and the address derived from it, belongs to exactly one tenant.
+type Tenant struct {
+	ID         string      `json:"id"`
+	MinTokenID int64       `json:"min_token_id"`
+	MaxTokenID int64       `json:"max_token_id"`
+	Exclusions TokenRanges `json:"exclusions,omitempty"` // sub-ranges of the pool never handed out
+
+	// Zones maps the rack or site names of the pool to their sub-ranges. Allocations with
+	// a zone hint prefer token IDs of that zone.
+	Zones Zones `json:"zones,omitempty"`
+}
+
+// Contains reports whether tokenID lies within the tenant's pool
+func (t *Tenant) Contains(tokenID int64) bool {
+	return tokenID >= t.MinTokenID && tokenID <= t.MaxTokenID
+}
+
+// Allocatable reports whether tokenID lies within the tenant's pool and outside its
+// exclusions
+func (t *Tenant) Allocatable(tokenID int64) bool {
+	return t.Contains(tokenID) && !t.Exclusions.Contains(tokenID)
+}
+
+// AllocatableRanges returns the ranges of the pool left between the exclusions
+func (t *Tenant) AllocatableRanges() TokenRanges {
+	ranges := TokenRanges{}
+	next := t.MinTokenID
+	for _, excluded := range t.Exclusions {
+		if excluded.FirstTokenID > next {
+			ranges = append(ranges, TokenRange{FirstTokenID: next, LastTokenID: excluded.FirstTokenID - 1})
+		}
+		next = max(next, excluded.LastTokenID+1)
+	}
+	if next <= t.MaxTokenID {
+		ranges = append(ranges, TokenRange{FirstTokenID: next, LastTokenID: t.MaxTokenID})
+	}
+	return ranges
+}
+
+// TokenRange is an inclusive range of token IDs
+type TokenRange struct {
+	FirstTokenID int64  `json:"first_token_id"`
+	LastTokenID  int64  `json:"last_token_id"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// Size returns the number of token IDs in the range
+func (r TokenRange) Size() int64 {
+	return r.LastTokenID - r.FirstTokenID + 1
+}
+
+// TokenRanges are token ID ranges sorted by their first token ID, none overlapping
+type TokenRanges []TokenRange
+
+// Contains reports whether tokenID lies within one of the ranges
+func (rs TokenRanges) Contains(tokenID int64) bool {
+	i := sort.Search(len(rs), func(i int) bool { return rs[i].LastTokenID >= tokenID })
+	return i < len(rs) && rs[i].FirstTokenID <= tokenID
+}
+
+// Size returns the number of token IDs in the ranges
+func (rs TokenRanges) Size() int64 {
+	var size int64
+	for _, r := range rs {
+		size += r.Size()
+	}
+	return size
+}
+
+// Zones maps zone names to the ranges of a pool they cover. No two zones overlap.
+type Zones map[string]TokenRanges
+
+// ZoneOf returns the zone tokenID lies in, empty when it lies in none
+func (zs Zones) ZoneOf(tokenID int64) string {
+	for zone, ranges := range zs {
+		if ranges.Contains(tokenID) {
+			return zone
+		}
+	}
+	return ""
+}
+
+// Names returns the zone names in alphabetical order
+func (zs Zones) Names() []string {
+	return slices.Sorted(maps.Keys(zs))
+}
+
+var zonePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// ValidZone reports whether zone is usable as a zone name, e.g. "rack-12" or "fra1"
+func ValidZone(zone string) bool {
+	return zonePattern.MatchString(zone)
+}
+
+type tenantContextKey struct{}
+
+// WithTenant returns a context scoping lease operations to the tenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant lease operations are scoped to, the default
+// tenant when the context names none
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenantID
+}
+
+// TenantKeyPrefix scopes cache keys to the context's tenant. It is empty for the default
+// tenant, whose keys predate tenants.
+func TenantKeyPrefix(ctx context.Context) string {
+	if tenantID := TenantFromContext(ctx); tenantID != DefaultTenantID {
+		return "t:" + tenantID + ":"
+	}
+	return ""
+}
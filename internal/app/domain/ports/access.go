@@ -0,0 +1,36 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// AccessControlService decides whether an authenticated peer may use the service and
+// manages the deny and allow lists
+type AccessControlService interface {
+	// CheckAccess returns ErrPeerBlocked or ErrPeerNotAllowed when the peer, known by its
+	// identity and marshaled public key, is refused
+	CheckAccess(ctx context.Context, peerID string, pubkey []byte) error
+	ListRules(ctx context.Context, list models.AccessList) ([]*models.AccessRule, error)
+	AddRule(ctx context.Context, rule *models.AccessRule) (*models.AccessRule, error)
+	RemoveRule(ctx context.Context, id int64) error
+}
+
+type AccessRuleRepository interface {
+	// GetAccessRules returns the rules of both lists matching a subject
+	GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error)
+	// ListAccessRules returns the rules of list, or of both lists when list is empty
+	ListAccessRules(ctx context.Context, list models.AccessList) ([]*models.AccessRule, error)
+	CreateAccessRule(ctx context.Context, rule *models.AccessRule) (*models.AccessRule, error)
+	// DeleteAccessRule removes a rule and returns it
+	DeleteAccessRule(ctx context.Context, id int64) (*models.AccessRule, error)
+}
+
+// AccessRuleCache caches the rules matching a subject, including the absence of any.
+// GetAccessRules returns ErrAccessRuleNotFound for a subject that is not cached.
+type AccessRuleCache interface {
+	GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error)
+	SetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string, rules []*models.AccessRule) error
+	DeleteAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) error
+}
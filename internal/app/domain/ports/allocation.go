@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// AllocationStrategy decides which token ID a peer without a lease gets by choosing the
+// order in which the allocation paths of the LeaseRepository are tried. The repository
+// stays responsible for never handing out a token ID twice, whichever strategy drives it.
+type AllocationStrategy interface {
+	Name() string
+	// Allocate makes one allocation attempt. It fails with ErrAllocationFailed when no
+	// token ID is free.
+	Allocate(ctx context.Context, peerID string) (*models.Lease, error)
+}
@@ -0,0 +1,31 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// APIKeyService issues admin API keys and checks the keys presented to the admin API
+type APIKeyService interface {
+	// Create issues a key with the given scopes. The plaintext key is only returned here.
+	Create(ctx context.Context, name string, scopes []models.APIKeyScope) (*models.IssuedAPIKey, error)
+	List(ctx context.Context) ([]*models.APIKey, error)
+	// Revoke disables a key for good and returns it
+	Revoke(ctx context.Context, id int64) (*models.APIKey, error)
+	// Authorize returns the key a plaintext key belongs to, failing with
+	// ErrAdminUnauthorized for unknown or revoked keys and ErrAPIKeyScope when the key
+	// does not grant scope
+	Authorize(ctx context.Context, key string, scope models.APIKeyScope) (*models.APIKey, error)
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	// GetAPIKeyByHash returns the key, revoked or not, with the given hash
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	// RevokeAPIKey marks a key as revoked, keeping the time of an earlier revocation
+	RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error)
+	// TouchAPIKey records that a key was just used
+	TouchAPIKey(ctx context.Context, id int64) error
+}
@@ -0,0 +1,44 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// BanService escalates repeated rate limit violations of a client IP or an authenticated
+// peer to temporary bans.
+// It is only provided with rate_limit.ban_enabled.
+type BanService interface {
+	// CheckBan returns the active ban of subject, or nil
+	CheckBan(ctx context.Context, subject string) (*models.Ban, error)
+	// RecordViolation counts a rate limit violation of subject and bans it once the
+	// violations within the window reach the threshold. It returns the new ban, or nil.
+	RecordViolation(ctx context.Context, subject string) (*models.Ban, error)
+	// ListBans returns the active bans
+	ListBans(ctx context.Context) ([]*models.Ban, error)
+	GetBanStatus(ctx context.Context, subject string) (*models.BanStatus, error)
+	// LiftBan removes the ban of subject and forgets its violations and earlier bans. It
+	// fails with ErrBanNotFound when none is remembered.
+	LiftBan(ctx context.Context, subject string) error
+	Metrics() *models.BanMetrics
+}
+
+// BanStore keeps the violations and bans of clients, shared by the instances
+type BanStore interface {
+	// AddViolation counts a violation of subject and returns the violations in the window
+	// that started with the first of them
+	AddViolation(ctx context.Context, subject string, window time.Duration) (int64, error)
+	// Violations returns the violations of subject in the current window
+	Violations(ctx context.Context, subject string) (int64, error)
+	// GetBan returns the last ban of subject, expired or not, while it is remembered, or
+	// fails with ErrBanNotFound
+	GetBan(ctx context.Context, subject string) (*models.Ban, error)
+	// SaveBan remembers ban for ttl and clears the violations of its subject
+	SaveBan(ctx context.Context, ban *models.Ban, ttl time.Duration) error
+	// ListBans returns the remembered bans, expired or not
+	ListBans(ctx context.Context) ([]*models.Ban, error)
+	// DeleteBan forgets the ban and violations of subject
+	DeleteBan(ctx context.Context, subject string) error
+}
@@ -0,0 +1,10 @@
+package ports
+
+// FieldCipher encrypts the peer-identifying values a storage backend keeps, such as peer
+// IDs and public keys. Encryption is deterministic, so stored values can still be looked
+// up by equality.
+type FieldCipher interface {
+	Encrypt(plaintext string) (string, error)
+	// Decrypt returns values stored before encryption was enabled unchanged
+	Decrypt(ciphertext string) (string, error)
+}
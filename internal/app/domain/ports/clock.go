@@ -0,0 +1,9 @@
+package ports
+
+import "time"
+
+// Clock tells the time that lease, nonce and cache expiries are computed against, so
+// that tests can move it instead of waiting
+type Clock interface {
+	Now() time.Time
+}
@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// DashboardService gathers what the admin dashboard shows. Recent allocations and top
+// peers are observed from lease events of this instance only.
+type DashboardService interface {
+	Summary(ctx context.Context) (*models.DashboardSummary, error)
+	// RecentAllocations returns the latest allocations, newest first
+	RecentAllocations() []*models.DashboardAllocation
+	// TopPeers returns up to limit peers with the most allocations and renewals
+	TopPeers(limit int) []*models.DashboardPeer
+}
+
+// CacheStatsReporter is implemented by lease repositories reading through a cache
+type CacheStatsReporter interface {
+	CacheStats() *models.CacheStats
+}
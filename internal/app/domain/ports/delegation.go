@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// DelegationService lets configured gateway peers allocate leases on behalf of the
+// downstream peers they vouch for
+type DelegationService interface {
+	// AllocateDelegatedIP returns the downstream peer's active lease or allocates one,
+	// counting it against the gateway's delegation quota
+	AllocateDelegatedIP(ctx context.Context, request *models.LeaseDelegationRequest) (*models.Lease, error)
+}
@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+type CacheDiagnostics interface {
+	// MemoryUsage samples up to sampleSize keys per key class and extrapolates their memory usage
+	MemoryUsage(ctx context.Context, sampleSize int) (*models.CacheMemoryReport, error)
+}
+
+// DatabasePoolStatsReporter reports the state of the database connection pool
+type DatabasePoolStatsReporter interface {
+	DatabasePoolStats() *models.DatabasePoolStats
+}
+
+// CachePoolStatsReporter reports the state of the cache connection pool
+type CachePoolStatsReporter interface {
+	CachePoolStats() *models.CachePoolStats
+}
@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// DNSProvider manages the address records of the zone peer hostnames are published in
+type DNSProvider interface {
+	// UpsertRecord replaces the address records of hostname with a single record for ip
+	UpsertRecord(ctx context.Context, hostname, ip string) error
+	// DeleteRecord removes the address records of hostname, if any
+	DeleteRecord(ctx context.Context, hostname string) error
+	// ListRecords returns the address records of the zone, except those of the zone apex
+	ListRecords(ctx context.Context) ([]*models.DNSRecord, error)
+}
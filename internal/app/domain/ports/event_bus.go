@@ -0,0 +1,37 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// EventHandler consumes an event delivered by the event bus. An error has the event
+// delivered again after a backoff.
+type EventHandler func(ctx context.Context, event *models.Event) error
+
+// EventBus publishes events to the subscribers in this process and to the configured
+// message broker. Every accepted event is delivered at least once to each of them.
+type EventBus interface {
+	// Publish queues an event with payload encoded as JSON without waiting for its
+	// delivery. It fails when a queue is full or the bus stopped, so the event is not
+	// lost silently.
+	Publish(topic, key string, payload any) error
+	// Subscribe delivers the events of topic published in this process and, through the
+	// broker, by the other instances to handler, one at a time. name identifies the
+	// subscriber in the stats.
+	Subscribe(name, topic string, handler EventHandler)
+	Stats() *models.EventBusStats
+}
+
+// EventBroker forwards events to an external message broker and receives the events the
+// other instances forwarded
+type EventBroker interface {
+	// Publish returns once the broker has acknowledged the event
+	Publish(ctx context.Context, event *models.Event) error
+	// Receive hands the events of topic published on the broker from now on to handler
+	// until the broker is closed, including the ones of this instance. Events published
+	// while the broker cannot be reached may be missed.
+	Receive(topic string, handler func(event *models.Event)) error
+	Close() error
+}
@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// ExpiryChannel delivers notices about expiring leases to their holders
+type ExpiryChannel interface {
+	Name() string
+	// Notify delivers one batch; an error marks the whole batch as failed
+	Notify(ctx context.Context, leases []*models.ExpiringLease) error
+}
+
+type ExpiryNotificationService interface {
+	// Run notifies the holders of every lease expiring within the window through all channels
+	Run(ctx context.Context) (*models.ExpiryNotificationReport, error)
+	// LastReport returns the report of the most recent run, or nil before the first run
+	LastReport() *models.ExpiryNotificationReport
+}
+
+type LeaseExpiryNotifier interface {
+	Run(ctx context.Context) error
+}
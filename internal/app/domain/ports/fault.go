@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// FaultInjector injects latency and errors into the calls of this instance to the database
+// and Redis, to test how it degrades. It is only provided with fault_injection_enabled.
+type FaultInjector interface {
+	// Inject delays a call to target by the latency of its fault and fails it at the
+	// fault's error rate. It returns nil at once when target has no fault.
+	Inject(ctx context.Context, target models.FaultTarget) error
+	// Faults returns the faults in effect
+	Faults() []*models.Fault
+	// SetFault replaces the fault of a target and returns it. A positive duration clears
+	// it after that long.
+	SetFault(fault *models.Fault, duration time.Duration) *models.Fault
+	// ClearFault removes the fault of a target, if any
+	ClearFault(target models.FaultTarget)
+}
@@ -0,0 +1,12 @@
+package ports
+
+import "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+
+// HAController runs the instance as one of an active/standby pair. Only the active
+// instance changes leases; the standby mirrors its changes and takes over when it fails.
+type HAController interface {
+	// IsActive reports whether this instance may currently change leases
+	IsActive() bool
+	// Status describes the instance's role, term and mirroring progress
+	Status() models.HAStatus
+}
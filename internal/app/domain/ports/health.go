@@ -0,0 +1,8 @@
+package ports
+
+import "context"
+
+// HealthChecker probes a dependency the service needs to serve requests
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// IdempotencyStore keeps the first response to a request carrying an idempotency key
+type IdempotencyStore interface {
+	// Claim reserves key for ttl while its request is served and returns nil. When the key
+	// was completed before, the stored response is returned instead; while another request
+	// holds the claim, Claim fails with ErrIdempotencyInProgress.
+	Claim(ctx context.Context, key string, ttl time.Duration) (*models.IdempotentResponse, error)
+	// Complete stores the response under a claimed key for ttl
+	Complete(ctx context.Context, key string, response *models.IdempotentResponse, ttl time.Duration) error
+	// Release drops a claim without a response, so the request can be retried
+	Release(ctx context.Context, key string) error
+}
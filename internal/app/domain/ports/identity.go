@@ -0,0 +1,6 @@
+package ports
+
+// IdentityResolver derives the peer identity that a public key authenticates as
+type IdentityResolver interface {
+	ResolvePeerID(pubkey []byte) (string, error)
+}
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+type IntegrityChecker interface {
+	// Check verifies the storage invariants and reports every violation. With repair set,
+	// violations that have a safe repair are fixed as part of the same run.
+	Check(ctx context.Context, repair bool) (*models.IntegrityReport, error)
+}
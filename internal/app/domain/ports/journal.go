@@ -0,0 +1,56 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// JournalSource reads the entries of a lease journal
+type JournalSource interface {
+	// Read passes the entries to fn in sequence order, stopping at the first error
+	Read(ctx context.Context, fn func(entry *models.JournalEntry) error) error
+}
+
+// JournalSink stores the entries of a lease journal
+type JournalSink interface {
+	JournalSource
+	// Append stores an entry after those appended before
+	Append(ctx context.Context, entry *models.JournalEntry) error
+	Close(ctx context.Context) error
+}
+
+// LeaseJournal records every lease mutation with a sequence number, for point-in-time
+// recovery with the replay command
+type LeaseJournal interface {
+	JournalSource
+	// Append numbers and records an entry. After a failed append the journal misses a
+	// mutation until the next checkpoint, see NeedsCheckpoint.
+	Append(ctx context.Context, entry *models.JournalEntry) error
+	// Checkpoint records the whole lease state, which later entries apply to, and returns
+	// its sequence number
+	Checkpoint(ctx context.Context, snapshot *models.LeaseSnapshot) (int64, error)
+	// NeedsCheckpoint reports whether the journal has no checkpoint yet or lost an entry
+	NeedsCheckpoint() bool
+	// Compare returns the last sequence number and how the leases the recorded entries
+	// describe differ from those of the store, see JournalState.Mismatches
+	Compare(store *models.LeaseSnapshot, now time.Time) (int64, []string)
+}
+
+// LeaseJournalService keeps the lease journal consistent with the store and restores
+// the store from it
+type LeaseJournalService interface {
+	// Verify writes a checkpoint when the journal needs one and compares it with the
+	// store otherwise. A difference seen twice in a row is reported and re-anchors the
+	// journal with a checkpoint.
+	Verify(ctx context.Context) (*models.JournalVerification, error)
+	// Replay folds the entries of source up to the last one accepted by until, starting
+	// at the latest checkpoint, and imports the result. Unless replace is set the store
+	// must hold no leases.
+	Replay(ctx context.Context, source JournalSource, until func(entry *models.JournalEntry) bool, replace bool) (*models.JournalReplayReport, error)
+}
+
+type LeaseJournalVerifier interface {
+	Run(ctx context.Context) error
+}
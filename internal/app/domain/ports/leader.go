@@ -0,0 +1,8 @@
+package ports
+
+// LeaderElector picks the one replica that runs the periodic maintenance jobs, so
+// replicas sharing a store do not duplicate their work
+type LeaderElector interface {
+	// IsLeader reports whether this instance currently leads
+	IsLeader() bool
+}
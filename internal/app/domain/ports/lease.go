@@ -2,30 +2,103 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 )
 
-type LeaseService interface {
+// LeaseReader answers lease lookups, for consumers that never change leases
+type LeaseReader interface {
 	GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error)
 	GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error)
+	GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error)
+}
+
+// LeaseWriter allocates leases and changes them
+type LeaseWriter interface {
 	RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error)
 	ReleaseLease(ctx context.Context, tokenID int64, peerID string) error
 	AllocateIP(ctx context.Context, peerID string) (*models.Lease, error)
+	AllocateRequestedIP(ctx context.Context, peerID string, requestedTokenID int64) (*models.AllocationResult, error)
+	AllocateAffinityIP(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error)
+	// AllocateZoneIP allocates a lease in the zone of the pool when it has a free token ID,
+	// anywhere in the pool otherwise
+	AllocateZoneIP(ctx context.Context, peerID string, zone string) (*models.Lease, error)
+	TransferLease(ctx context.Context, request *models.LeaseTransferRequest) (*models.Lease, error)
+	ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error)
+	ReportConflict(ctx context.Context, report *models.LeaseConflictReport) (*models.LeaseConflict, error)
+	// SetLeaseLabels replaces the labels of the peer's active lease and returns them
+	// normalized; no labels clears them
+	SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) (map[string]string, error)
+}
+
+// LeaseService is the full lease service
+type LeaseService interface {
+	LeaseReader
+	LeaseWriter
 }
 
 type LeaseRepository interface {
 	FindAndReuseExpiredLease(ctx context.Context, peerID string) (*models.Lease, error)
 	AllocateNewLease(ctx context.Context, peerID string) (*models.Lease, error)
+	AllocateRequestedLease(ctx context.Context, peerID string, tokenID int64) (*models.Lease, error)
+	AllocateAffinityLease(ctx context.Context, peerID string, affinityGroup string) (*models.Lease, error)
+	SetLeaseAffinityGroup(ctx context.Context, tokenID int64, affinityGroup string) error
+	// AllocateZoneLease assigns a free token ID of the zone of the context's tenant. nil is
+	// returned when the zone is unknown or has no free token ID left.
+	AllocateZoneLease(ctx context.Context, peerID string, zone string) (*models.Lease, error)
+	// SetLeaseDelegator records the gateway a lease was allocated through, until the token
+	// ID is reused
+	SetLeaseDelegator(ctx context.Context, tokenID int64, gatewayPeerID string) error
+	// CountDelegatedLeases counts the active leases allocated through a gateway, in all tenants
+	CountDelegatedLeases(ctx context.Context, gatewayPeerID string) (int64, error)
+	// SetLeaseLabels replaces the labels of the active lease of tokenID held by peerID,
+	// failing with ErrLeaseNotFound for anyone else. Nil labels clear them.
+	SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) error
 	GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error)
 	GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error)
 	RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error)
 	ReleaseLease(ctx context.Context, tokenID int64, peerID string) error
+	TransferLease(ctx context.Context, tokenID int64, fromPeerID string, toPeerID string) (*models.Lease, error)
+	// ExecuteBatch runs all operations in one transaction; a failing item only rolls back itself
+	ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error)
+	// ListReclaimCandidates pages through expired, unreclaimed leases ordered by token ID
+	ListReclaimCandidates(ctx context.Context, afterTokenID int64, limit int) ([]*models.ReclaimCandidate, error)
+	// ListExpiringLeases pages through active leases expiring within the given duration,
+	// ordered by token ID
+	ListExpiringLeases(ctx context.Context, within time.Duration, afterTokenID int64, limit int) ([]*models.ExpiringLease, error)
+	// ReclaimLeases marks expired leases as reclaimed and returns the token IDs that were updated
+	ReclaimLeases(ctx context.Context, tokenIDs []int64) ([]int64, error)
+	// RevokeLeases releases those of the leases that are still active and held by the same
+	// peer in the context's tenant, all in one transaction, and returns their token IDs
+	RevokeLeases(ctx context.Context, leases []*models.Lease) ([]int64, error)
+	// GetLeaseHistory returns the events recorded for a token ID, oldest first
+	GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error)
+	// RecordConflict records a conflict reported by the holder of the active lease of
+	// tokenID, failing with ErrLeaseNotFound for anyone else. A positive quarantine also
+	// releases the lease and withholds the token ID from allocation for that long.
+	RecordConflict(ctx context.Context, tokenID int64, peerID string, quarantine time.Duration) (*models.LeaseConflict, error)
+}
+
+// LeaseRenewalWriter applies renewals accepted without writing the database
+type LeaseRenewalWriter interface {
+	// ApplyRenewals writes the renewals in one transaction and returns how many were
+	// applied. A renewal is skipped when its lease was written after it was accepted, e.g.
+	// released, transferred, reclaimed or renewed again.
+	ApplyRenewals(ctx context.Context, renewals []*models.LeaseRenewal) (int, error)
 }
 
 type LeaseCache interface {
+	// GetLeaseByPeerID and GetLeaseByTokenID return a nil lease and nil error when a
+	// "not found" entry is cached, and an error on a cache miss.
 	GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error)
 	GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error)
 	SetLease(ctx context.Context, lease *models.Lease) error
+	// SetPeerNotFound and SetTokenNotFound cache short-lived "not found" entries. They never
+	// replace a cached lease and are overwritten by SetLease.
+	SetPeerNotFound(ctx context.Context, peerID string) error
+	SetTokenNotFound(ctx context.Context, tokenID int64) error
 	DeleteLease(ctx context.Context, peerID string, tokenID int64) error
+	// UpdateLeases caches upserts and evicts removals in a single round trip
+	UpdateLeases(ctx context.Context, upserts []*models.Lease, removals []*models.Lease) error
 }
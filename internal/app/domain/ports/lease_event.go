@@ -0,0 +1,17 @@
+package ports
+
+import "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+
+// LeaseEventPublisher hands lease lifecycle events to their subscribers. Publish must not
+// block the lease operation the event comes from; events may be dropped under load.
+type LeaseEventPublisher interface {
+	Publish(event *models.LeaseLifecycleEvent)
+}
+
+// LeaseWatcher lets callers wait for the lifecycle events of a token ID published by any
+// instance sharing the event bus broker
+type LeaseWatcher interface {
+	// Watch delivers the next event of the tenant's tokenID published after the call until
+	// stop is called; further events are dropped while it is not received
+	Watch(tenantID string, tokenID int64) (events <-chan *models.LeaseLifecycleEvent, stop func())
+}
@@ -0,0 +1,32 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// LeaseQueryService serves reporting queries from the lease read model
+type LeaseQueryService interface {
+	GetLeaseStats(ctx context.Context) (*models.LeaseStats, error)
+	ListLeases(ctx context.Context, opts *models.ListOptions) (*models.LeasePage, error)
+	// SearchLeases fails with ErrInvalidFilter when the search names a peer policy that
+	// is not in effect
+	SearchLeases(ctx context.Context, search *models.LeaseSearch) (*models.LeaseSearchPage, error)
+}
+
+// LeaseReadModel is a denormalized, eventually consistent copy of the leases used for
+// reporting so that those queries do not contend with allocation writes.
+type LeaseReadModel interface {
+	Refresh(ctx context.Context) error
+	GetLeaseStats(ctx context.Context) (*models.LeaseStats, error)
+	// ListLeases returns up to opts.Limit leases ordered by opts.SortField and token ID.
+	// Supported fields are token_id, peer_id, affinity_group, created_at, updated_at and
+	// expires_at, and label for filters, whose value is a models.LeaseLabel. peer_id also
+	// takes the in and not_in operators.
+	ListLeases(ctx context.Context, opts *models.ListOptions) ([]*models.Lease, error)
+}
+
+type LeaseReadModelRefresher interface {
+	Run(ctx context.Context) error
+}
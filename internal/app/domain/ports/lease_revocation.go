@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+type LeaseRevocationService interface {
+	// Revoke releases the active leases matching the request's filter. A dry run returns
+	// the matches right away; otherwise the revocation continues in the background when it
+	// takes longer than the configured wait, and Revocation reports its progress.
+	Revoke(ctx context.Context, request *models.LeaseRevocationRequest) (*models.LeaseRevocation, error)
+	// Revocation returns a revocation started on this instance, failing with
+	// ErrRevocationNotFound once it is no longer kept
+	Revocation(ctx context.Context, id string) (*models.LeaseRevocation, error)
+}
@@ -10,18 +10,35 @@ type NonceRepository interface {
 	GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error)
 	CreateNonce(ctx context.Context, peerID string) (*models.Nonce, error)
 	ConsumeNonce(ctx context.Context, nonceID string, peerID string) error
-	DeleteExpiredNonces(ctx context.Context) error
+	ListOutstandingNonces(ctx context.Context, peerID string) ([]*models.Nonce, error)
+	// RevokeNonce deletes an unused, unexpired nonce of the peer, failing with
+	// ErrNonceNotFound when the peer holds no such nonce
+	RevokeNonce(ctx context.Context, nonceID string, peerID string) error
+	// DeleteExpiredNonces deletes up to limit expired nonces, all of them when limit is 0,
+	// and returns how many were deleted
+	DeleteExpiredNonces(ctx context.Context, limit int) (int64, error)
 }
 
 type NonceCache interface {
 	GetNonce(ctx context.Context, nonceID string) (*models.Nonce, error)
 	CreateNonce(ctx context.Context, nonce *models.Nonce) error
 	DeleteNonce(ctx context.Context, nonceID string) error
+	// TakeNonce removes and returns a nonce in one step, so that it is handed out once
+	TakeNonce(ctx context.Context, nonceID string) (*models.Nonce, error)
 }
 
 type NonceService interface {
 	CreateNonce(ctx context.Context, peerID string) (*models.Nonce, error)
 	VerifyNonce(ctx context.Context, request *models.NonceRequest) error
+	CleanupExpired(ctx context.Context) (*models.NonceCleanupReport, error)
+	// ListOutstandingNonces returns the nonces the peer could still authenticate with
+	ListOutstandingNonces(ctx context.Context, peerID string) ([]*models.Nonce, error)
+	// RevokeNonce invalidates an outstanding nonce of the peer, so that it can no longer
+	// authenticate a request
+	RevokeNonce(ctx context.Context, peerID string, nonceID string) error
+	// RevokeNonces invalidates every outstanding nonce of the peer and returns how many
+	RevokeNonces(ctx context.Context, peerID string) (int64, error)
+	Metrics() *models.NonceMetrics
 }
 
 type NonceCleaner interface {
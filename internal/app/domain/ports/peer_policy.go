@@ -0,0 +1,43 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// PeerPolicyService decides which lease operations an authenticated peer may perform,
+// and manages the policies when they are kept in the database
+type PeerPolicyService interface {
+	// Authorize returns ErrPeerPolicyDenied when the peer's policy does not grant
+	// permission, and ErrPeerPoolExhausted when allocating a lease would take the peers
+	// of the policy past its max_pool. A peer that already holds a lease is not counted
+	// against max_pool again.
+	Authorize(ctx context.Context, peerID string, permission models.PeerPermission) error
+	// AuthorizeTransfer checks that the recipient of a transferred lease may allocate, as
+	// Authorize does. A transfer between peers of the same policy leaves its pool as it is,
+	// so max_pool is not checked for it.
+	AuthorizeTransfer(ctx context.Context, fromPeerID string, toPeerID string) error
+	// ListPolicies returns the policies in effect, in the order they are matched
+	ListPolicies(ctx context.Context) ([]*models.PeerPolicy, error)
+	// AddPolicy and RemovePolicy fail with ErrPeerPoliciesFixed when the policies are
+	// read from the configuration file
+	AddPolicy(ctx context.Context, policy *models.PeerPolicy) (*models.PeerPolicy, error)
+	RemovePolicy(ctx context.Context, id int64) error
+	// Refresh reloads the policies kept in the database
+	Refresh(ctx context.Context) error
+}
+
+// PeerPolicyRefresher periodically reloads the policies kept in the database, so changes
+// made through other instances are picked up
+type PeerPolicyRefresher interface {
+	Run(ctx context.Context) error
+}
+
+type PeerPolicyRepository interface {
+	// ListPeerPolicies returns the policies ordered by ID
+	ListPeerPolicies(ctx context.Context) ([]*models.PeerPolicy, error)
+	CreatePeerPolicy(ctx context.Context, policy *models.PeerPolicy) (*models.PeerPolicy, error)
+	// DeletePeerPolicy removes a policy and returns it
+	DeletePeerPolicy(ctx context.Context, id int64) (*models.PeerPolicy, error)
+}
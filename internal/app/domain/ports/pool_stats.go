@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// PoolStatsService reports the use of every tenant pool for capacity planning
+type PoolStatsService interface {
+	// PoolStats returns the stats of every tenant pool. Reports are cached briefly, so
+	// repeated calls may return the same report.
+	PoolStats(ctx context.Context) (*models.PoolStatsReport, error)
+}
+
+// PoolStatsRepository counts leases with aggregate queries instead of reading them
+type PoolStatsRepository interface {
+	// CountPoolLeases counts the leases of every tenant by state, and the allocations
+	// within the given duration. Tenants without leases are left out.
+	CountPoolLeases(ctx context.Context, within time.Duration) (map[string]*models.PoolLeaseCounts, error)
+}
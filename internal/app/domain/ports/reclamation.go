@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+type ReclamationService interface {
+	// Run evaluates every expired lease against the reclaim policies. With dryRun set
+	// the matches are only reported and no lease is reclaimed.
+	Run(ctx context.Context, dryRun bool) (*models.ReclaimReport, error)
+	Metrics() *models.ReclaimMetrics
+}
+
+type LeaseReclaimer interface {
+	Run(ctx context.Context) error
+}
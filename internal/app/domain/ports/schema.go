@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+type SchemaMigrator interface {
+	// Status compares the applied migrations with those shipped in the binary
+	Status(ctx context.Context) (*models.SchemaStatus, error)
+	// Migrate applies the pending migrations in order and returns their versions. It
+	// refuses to run against a schema written by a newer binary.
+	Migrate(ctx context.Context) ([]string, error)
+	// VerifyAllocState checks that the allocation state exists and covers exactly the
+	// given pool
+	VerifyAllocState(ctx context.Context, minTokenID, maxTokenID int64) error
+	// ProvisionTenantPools creates the allocation state of new tenants and checks that
+	// the pools of known ones are unchanged
+	ProvisionTenantPools(ctx context.Context, tenants []*models.Tenant) error
+}
+
+type SchemaGuard interface {
+	// Check refuses an incompatible schema, applying pending migrations first when
+	// automatic migration is enabled
+	Check(ctx context.Context) error
+}
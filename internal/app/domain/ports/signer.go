@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// LeaseSigner certifies issued leases with the server's key, so that other peers can
+// verify who holds an address without asking the server
+type LeaseSigner interface {
+	// SignLease signs the certificate payload of lease, which binds lease.Tenant, with the
+	// current key and returns the signature and the ID of the key, a nil signature when
+	// signing is disabled
+	SignLease(lease *models.Lease) (signature []byte, keyID string, err error)
+	// Keys returns the current key followed by the retired keys certificates are still
+	// verified against, nil when signing is disabled
+	Keys() []*models.SigningKey
+	// RotateKey replaces the current key with a new one, retiring the current key. Fails
+	// with ErrSigningDisabled when signing is disabled.
+	RotateKey() (*models.SigningKeyRotation, error)
+}
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// LeaseSnapshotService backs up and restores the lease state, e.g. to move it to
+// another storage backend
+type LeaseSnapshotService interface {
+	Export(ctx context.Context) (*models.LeaseSnapshot, error)
+	// Import validates the snapshot against the configured pools, failing with
+	// ErrInvalidSnapshot, and replaces the stored lease state with it. Unless replace is
+	// set it fails with ErrStoreNotEmpty when leases are stored already.
+	Import(ctx context.Context, snapshot *models.LeaseSnapshot, replace bool) (*models.SnapshotImportReport, error)
+}
+
+// LeaseSnapshotRepository reads and replaces the stored pools and leases as a whole
+type LeaseSnapshotRepository interface {
+	// ExportLeaseState returns the pools and the leases, ordered by tenant and token ID,
+	// as of one point in time
+	ExportLeaseState(ctx context.Context) (*models.LeaseSnapshot, error)
+	// ImportLeaseState replaces the pools and leases atomically. Pools of tenants missing
+	// from the snapshot are reset to their first token ID. Unless replace is set it fails
+	// with ErrStoreNotEmpty when leases are stored already.
+	ImportLeaseState(ctx context.Context, snapshot *models.LeaseSnapshot, replace bool) (*models.SnapshotImportReport, error)
+}
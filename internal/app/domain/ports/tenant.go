@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// TenantResolver knows the configured tenants and the API keys identifying them
+type TenantResolver interface {
+	// ResolveAPIKey returns the tenant an API key belongs to, failing with
+	// ErrInvalidAPIKey for unknown keys
+	ResolveAPIKey(apiKey string) (*models.Tenant, error)
+	// Tenant returns a configured tenant, failing with ErrTenantNotFound
+	Tenant(id string) (*models.Tenant, error)
+	// Tenants returns all tenants, the default tenant first
+	Tenants() []*models.Tenant
+}
@@ -2,8 +2,15 @@ package ports
 
 import (
 	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 )
 
 type SignatureVerifier interface {
 	VerifySignature(ctx context.Context, pubKey []byte, payload []byte, signature []byte) error
 }
+
+// SignatureCacheReporter reports the effectiveness of the verified signature cache
+type SignatureCacheReporter interface {
+	SignatureCacheStats() *models.SignatureCacheStats
+}
@@ -0,0 +1,108 @@
+// Package reqctx carries the values scoped to one request through its context: who sent
+// it, how they authenticated, where from and under which request ID. Handlers,
+// middleware and services read them through the typed accessors here instead of
+// context.Value, so a value is always stored under the same key with the same type.
+//
+// The tenant is kept by models.WithTenant, which the storage adapters read as well;
+// WithTenant and FromContext go through it.
+package reqctx
+
+import (
+	"context"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// AuthMethod is how the peer of a request proved its identity
+type AuthMethod string
+
+const (
+	AuthMethodHeaders AuthMethod = "headers" // X-Pubkey, X-Nonce and X-Signature headers
+	AuthMethodBody    AuthMethod = "body"    // credentials in the JSON body
+	AuthMethodP2P     AuthMethod = "p2p"     // key of a libp2p stream, or a request relayed over one
+)
+
+// RequestContext is a snapshot of the request values of a context. Values that were not
+// set are empty.
+type RequestContext struct {
+	RequestID  string
+	PeerID     string // authenticated peer
+	TenantID   string // the default tenant unless one was set
+	AuthMethod AuthMethod
+	ClientIP   string
+}
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	peerIDKey
+	authMethodKey
+	clientIPKey
+)
+
+// FromContext returns the request values of ctx
+func FromContext(ctx context.Context) RequestContext {
+	requestID, _ := RequestID(ctx)
+	peerID, _ := PeerID(ctx)
+	authMethod, _ := AuthenticatedBy(ctx)
+	clientIP, _ := ClientIP(ctx)
+	return RequestContext{
+		RequestID:  requestID,
+		PeerID:     peerID,
+		TenantID:   models.TenantFromContext(ctx),
+		AuthMethod: authMethod,
+		ClientIP:   clientIP,
+	}
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the ID of the request, ok is false when none was set
+func RequestID(ctx context.Context) (requestID string, ok bool) {
+	return value[string](ctx, requestIDKey)
+}
+
+// WithPeerID records the peer the request was authenticated as
+func WithPeerID(ctx context.Context, peerID string) context.Context {
+	return context.WithValue(ctx, peerIDKey, peerID)
+}
+
+// PeerID returns the authenticated peer, ok is false before authentication
+func PeerID(ctx context.Context) (peerID string, ok bool) {
+	return value[string](ctx, peerIDKey)
+}
+
+// WithAuthMethod records how the peer of the request authenticated
+func WithAuthMethod(ctx context.Context, method AuthMethod) context.Context {
+	return context.WithValue(ctx, authMethodKey, method)
+}
+
+// AuthenticatedBy returns how the peer of the request authenticated, ok is false before
+// authentication
+func AuthenticatedBy(ctx context.Context) (method AuthMethod, ok bool) {
+	return value[AuthMethod](ctx, authMethodKey)
+}
+
+func WithClientIP(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, clientIPKey, clientIP)
+}
+
+// ClientIP returns the address of the client, behind trusted proxies, that sent the
+// request. ok is false when it was not resolved.
+func ClientIP(ctx context.Context) (clientIP string, ok bool) {
+	return value[string](ctx, clientIPKey)
+}
+
+// WithTenant scopes the request to the tenant, see models.WithTenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return models.WithTenant(ctx, tenantID)
+}
+
+// value returns the non-empty value stored under key
+func value[T ~string](ctx context.Context, key contextKey) (T, bool) {
+	v, ok := ctx.Value(key).(T)
+	return v, ok && v != ""
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
 
 	"github.com/spf13/viper"
@@ -12,120 +17,950 @@ const (
 	ENV_PREFIX = "DHCP2P"
 )
 
+// Identity schemes
+const (
+	IdentitySchemePeerID   = "peer_id"  // libp2p peer ID of the public key
+	IdentitySchemeEthereum = "ethereum" // Ethereum address of a secp256k1 public key
+)
+
+// Storage backends
+const (
+	StorageBackendPostgres = "postgres" // PostgreSQL with a Redis cache
+	StorageBackendEmbedded = "embedded" // single bbolt file on local disk, no external services
+	StorageBackendMemory   = "memory"   // nothing is persisted, for development and tests
+)
+
+// Redis deployment modes
+const (
+	RedisModeStandalone = "standalone" // single server at redis_url
+	RedisModeSentinel   = "sentinel"   // master discovered through the sentinels in redis_addrs
+	RedisModeCluster    = "cluster"    // Redis Cluster seeded with redis_addrs
+)
+
+// Peer policy sources
+const (
+	PeerPolicySourceConfig   = "config"   // peer_policies of the configuration file, applied on reload
+	PeerPolicySourceDatabase = "database" // policies managed through the admin API and kept in the storage backend
+)
+
+// AppConfig is the complete configuration. Settings of the server, its stores, leases,
+// nonces and request security are grouped into sections, e.g. lease.ttl; the remaining
+// settings are still top-level keys.
 type AppConfig struct {
-	Port                 int    `mapstructure:"port"`
-	LogLevel             string `mapstructure:"log_level"`
-	DatabaseURL          string `mapstructure:"database_url"`
-	RedisURL             string `mapstructure:"redis_url"`
-	RedisPassword        string `mapstructure:"redis_password"`
-	NonceTTL             int    `mapstructure:"nonce_ttl"`              // in minutes
-	NonceCleanerInterval int    `mapstructure:"nonce_cleaner_interval"` // in minutes
-	LeaseTTL             int    `mapstructure:"lease_ttl"`              // in minutes
-	MaxLeaseRetries      int    `mapstructure:"max_lease_retries"`
-	LeaseRetryDelay      int    `mapstructure:"lease_retry_delay"` // in milliseconds
-
-	// Redis Configuration
-	RedisMaxRetries   int `mapstructure:"redis_max_retries"`
-	RedisPoolSize     int `mapstructure:"redis_pool_size"`
-	RedisMinIdleConns int `mapstructure:"redis_min_idle_conns"`
-	RedisDialTimeout  int `mapstructure:"redis_dial_timeout"`  // seconds
-	RedisReadTimeout  int `mapstructure:"redis_read_timeout"`  // seconds
-	RedisWriteTimeout int `mapstructure:"redis_write_timeout"` // seconds
-
-	// Cache Configuration
-	CacheEnabled    bool `mapstructure:"cache_enabled"`
-	CacheDefaultTTL int  `mapstructure:"cache_default_ttl"` // minutes
-
-	// PostgreSQL Pool Configuration
-	DBMaxConns          int `mapstructure:"db_max_conns"`           // maximum number of connections in the pool
-	DBMinConns          int `mapstructure:"db_min_conns"`           // minimum number of connections in the pool
-	DBMaxConnLifetime   int `mapstructure:"db_max_conn_lifetime"`   // maximum lifetime of a connection in minutes
-	DBMaxConnIdleTime   int `mapstructure:"db_max_conn_idle_time"`  // maximum idle time of a connection in minutes
-	DBHealthCheckPeriod int `mapstructure:"db_health_check_period"` // health check period in seconds
-
-	// Rate Limiting Configuration
-	RateLimitEnabled           bool     `mapstructure:"rate_limit_enabled"`             // enable/disable rate limiting
-	RateLimitRequestsPerMinute int      `mapstructure:"rate_limit_requests_per_minute"` // requests per minute per IP
-	RateLimitBurst             int      `mapstructure:"rate_limit_burst"`               // burst capacity for token bucket
-	RateLimitTrustedProxies    []string `mapstructure:"rate_limit_trusted_proxies"`     // trusted proxy IPs for header validation
+	Server    ServerConfig    `mapstructure:"server"`
+	Log       LogConfig       `mapstructure:"log"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Lease     LeaseConfig     `mapstructure:"lease"`
+	Nonce     NonceConfig     `mapstructure:"nonce"`
+	Security  SecurityConfig  `mapstructure:"security"`
+
+	// Storage Configuration
+	StorageBackend string `mapstructure:"storage_backend"` // postgres, embedded or memory
+	StoragePath    string `mapstructure:"storage_path"`    // data file of the embedded backend
+
+	// Degraded Renewal Configuration
+	DegradedRenewalsEnabled bool   `mapstructure:"degraded_renewals_enabled"` // renew cached leases from Redis while Postgres is unreachable
+	DegradedJournalPath     string `mapstructure:"degraded_journal_path"`     // write-ahead log of renewals awaiting replay to Postgres
+	DegradedReplayInterval  int    `mapstructure:"degraded_replay_interval"`  // seconds between attempts to replay journaled renewals
+
+	// Pool Configuration
+	PoolMinTokenID int64              `mapstructure:"pool_min_token_id"` // first token ID handed out, must match alloc_state.min_token_id
+	PoolMaxTokenID int64              `mapstructure:"pool_max_token_id"` // last token ID handed out, must match alloc_state.max_token_id
+	PoolExclusions []TokenRangeConfig `mapstructure:"pool_exclusions"`   // sub-ranges of the pool never handed out
+	PoolZones      []ZoneRangeConfig  `mapstructure:"pool_zones"`        // sub-ranges of the pool by rack or site, preferred by zone hints
+
+	// Tenant Configuration
+	Tenants []TenantConfig `mapstructure:"tenants"` // tenants besides the default one, each with its own pool and API keys
+
+	// Delegation Configuration
+	DelegationGateways []DelegationGatewayConfig `mapstructure:"delegation_gateways"` // peers allowed to allocate leases for downstream peers
+
+	// Peer Policy Configuration
+	PeerPolicySource          string             `mapstructure:"peer_policy_source"`           // empty lets every authenticated peer allocate, config or database
+	PeerPolicies              []PeerPolicyConfig `mapstructure:"peer_policies"`                // policies of the config source, in the order they are matched
+	PeerPolicyRefreshInterval int                `mapstructure:"peer_policy_refresh_interval"` // seconds between reloads of the database policies
+
+	// Schema Configuration
+	SchemaCheckEnabled bool `mapstructure:"schema_check_enabled"` // verify the schema version and alloc_state before serving
+	AutoMigrate        bool `mapstructure:"auto_migrate"`         // apply pending migrations on startup instead of refusing to start
+
+	// P2P Configuration
+	P2PStreamTimeout   int `mapstructure:"p2p_stream_timeout"`    // seconds a lease protocol stream may stay idle
+	P2PMaxMessageBytes int `mapstructure:"p2p_max_message_bytes"` // largest request accepted on a lease protocol stream
+
+	// Read Model Configuration
+	ReadModelRefreshInterval int `mapstructure:"read_model_refresh_interval"` // seconds between lease read model refreshes
+
+	// Leader Election Configuration
+	LeaderElectionEnabled  bool `mapstructure:"leader_election_enabled"`  // only the elected replica runs the maintenance jobs
+	LeaderElectionInterval int  `mapstructure:"leader_election_interval"` // seconds between leadership checks and takeover attempts
+
+	// High Availability Configuration
+	HAEnabled       bool   `mapstructure:"ha_enabled"`        // run as one of an active/standby pair, only the active instance changes leases
+	HAInstance      string `mapstructure:"ha_instance"`       // name of this instance in ha_state and /v1/server-info, empty uses the host name
+	HACheckInterval int    `mapstructure:"ha_check_interval"` // seconds between checks of the active instance and takeover attempts
+
+	// Read-Only Profile Configuration
+	ReadOnly bool `mapstructure:"read_only"` // serve lease lookups only, refusing lease changes and running no maintenance jobs
+
+	// Reclamation Configuration
+	ReclaimEnabled   bool                  `mapstructure:"reclaim_enabled"`    // reclaim expired leases through policies instead of reusing them ad hoc
+	ReclaimDryRun    bool                  `mapstructure:"reclaim_dry_run"`    // evaluate policies and report matches without reclaiming
+	ReclaimInterval  int                   `mapstructure:"reclaim_interval"`   // minutes between reclamation runs
+	ReclaimBatchSize int                   `mapstructure:"reclaim_batch_size"` // expired leases evaluated per database round trip
+	ReclaimPolicies  []ReclaimPolicyConfig `mapstructure:"reclaim_policies"`   // a lease is reclaimed by the first matching policy
+
+	// Expiry Notification Configuration
+	ExpiryNotifyEnabled   bool     `mapstructure:"expiry_notify_enabled"`    // notify holders of leases about to expire
+	ExpiryNotifyInterval  int      `mapstructure:"expiry_notify_interval"`   // minutes between notification runs
+	ExpiryNotifyWindow    int      `mapstructure:"expiry_notify_window"`     // minutes ahead in which an expiring lease is included
+	ExpiryNotifyBatchSize int      `mapstructure:"expiry_notify_batch_size"` // leases per notification batch
+	ExpiryNotifyChannels  []string `mapstructure:"expiry_notify_channels"`   // log and/or webhook
+	ExpiryWebhookURL      string   `mapstructure:"expiry_webhook_url"`       // endpoint receiving batches for the webhook channel
+	ExpiryWebhookSecret   string   `mapstructure:"expiry_webhook_secret"`    // HMAC-SHA256 key signing webhook bodies, empty disables
+	ExpiryWebhookTimeout  int      `mapstructure:"expiry_webhook_timeout"`   // seconds per webhook request
+
+	// Lease Webhook Configuration
+	LeaseWebhooks         []LeaseWebhookConfig `mapstructure:"lease_webhooks"`           // endpoints receiving lease lifecycle events
+	LeaseWebhookTimeout   int                  `mapstructure:"lease_webhook_timeout"`    // seconds per delivery attempt
+	LeaseWebhookRetries   int                  `mapstructure:"lease_webhook_retries"`    // attempts after a failed delivery before the event is dropped
+	LeaseWebhookBackoff   int                  `mapstructure:"lease_webhook_backoff"`    // milliseconds before the first retry, doubled for every further one
+	LeaseWebhookQueueSize int                  `mapstructure:"lease_webhook_queue_size"` // events buffered per endpoint, further events are dropped
+
+	// DNS Configuration
+	DNSEnabled       bool   `mapstructure:"dns_enabled"`        // publish <peerID>.<dns_zone> records for active leases
+	DNSZone          string `mapstructure:"dns_zone"`           // zone dedicated to the published records
+	DNSRecordTTL     int    `mapstructure:"dns_record_ttl"`     // seconds
+	DNSProvider      string `mapstructure:"dns_provider"`       // rfc2136; ignored when a provider is supplied to the app
+	DNSServer        string `mapstructure:"dns_server"`         // host:port of the primary server accepting dynamic updates
+	DNSTSIGKey       string `mapstructure:"dns_tsig_key"`       // TSIG key name, empty sends unsigned updates
+	DNSTSIGSecret    string `mapstructure:"dns_tsig_secret"`    // base64 TSIG secret
+	DNSTSIGAlgorithm string `mapstructure:"dns_tsig_algorithm"` // e.g. hmac-sha256
+	DNSTimeout       int    `mapstructure:"dns_timeout"`        // seconds per update or zone transfer
+	DNSQueueSize     int    `mapstructure:"dns_queue_size"`     // lease events buffered for publishing, further events are dropped
+
+	// Event Bus Configuration
+	EventBusBroker          string `mapstructure:"event_bus_broker"`            // memory, nats or kafka
+	EventBusTopicPrefix     string `mapstructure:"event_bus_topic_prefix"`      // prepended to topics on the broker, e.g. dhcp2p.lease.allocated
+	EventBusQueueSize       int    `mapstructure:"event_bus_queue_size"`        // events buffered per subscriber and for the broker, publishing fails beyond
+	EventBusRetryBackoff    int    `mapstructure:"event_bus_retry_backoff"`     // milliseconds before the first redelivery, doubled for every further one
+	EventBusMaxRetryBackoff int    `mapstructure:"event_bus_max_retry_backoff"` // milliseconds, longest wait between redeliveries
+	EventBusTimeout         int    `mapstructure:"event_bus_timeout"`           // seconds the broker has to acknowledge an event
+	NATSURL                 string `mapstructure:"nats_url"`                    // nats:// or tls:// server of the nats broker, a JetStream stream must capture the subjects
+	KafkaRESTURL            string `mapstructure:"kafka_rest_url"`              // Kafka REST Proxy (v2 API) of the kafka broker
+
+	// Lease Journal Configuration
+	LeaseJournalEnabled        bool   `mapstructure:"lease_journal_enabled"`         // journal every lease mutation for point-in-time recovery with dhcp2p replay
+	LeaseJournalSink           string `mapstructure:"lease_journal_sink"`            // file or s3
+	LeaseJournalPath           string `mapstructure:"lease_journal_path"`            // journal file of the file sink
+	LeaseJournalS3Endpoint     string `mapstructure:"lease_journal_s3_endpoint"`     // http or https URL of the S3-compatible store, buckets addressed by path
+	LeaseJournalS3Bucket       string `mapstructure:"lease_journal_s3_bucket"`       // bucket holding the journal segments
+	LeaseJournalS3Prefix       string `mapstructure:"lease_journal_s3_prefix"`       // key prefix of the journal segments
+	LeaseJournalS3Region       string `mapstructure:"lease_journal_s3_region"`       // region the requests are signed for
+	LeaseJournalS3AccessKey    string `mapstructure:"lease_journal_s3_access_key"`   // access key ID
+	LeaseJournalS3SecretKey    string `mapstructure:"lease_journal_s3_secret_key"`   // secret access key
+	LeaseJournalFlushInterval  int    `mapstructure:"lease_journal_flush_interval"`  // milliseconds entries are buffered before the s3 sink writes them as one segment
+	LeaseJournalVerifyInterval int    `mapstructure:"lease_journal_verify_interval"` // seconds between checks of the journal against the store, 0 only checks on start
+
+	// Discovery Configuration
+	DiscoveryEnabled   bool   `mapstructure:"discovery_enabled"`   // advertise the server as _dhcp2p._tcp over multicast DNS
+	DiscoveryInstance  string `mapstructure:"discovery_instance"`  // instance name, empty uses the host name
+	DiscoveryInterface string `mapstructure:"discovery_interface"` // network interface to advertise on, empty uses the system default
+
+	// Readiness Configuration
+	ReadinessDBTimeout    int  `mapstructure:"readiness_db_timeout"`    // milliseconds a database ping may take on /ready
+	ReadinessRedisTimeout int  `mapstructure:"readiness_redis_timeout"` // milliseconds a Redis ping may take on /ready
+	ReadinessDegradedMode bool `mapstructure:"readiness_degraded_mode"` // report ready (degraded) while only Redis is down
+
+	// Health Score Configuration
+	HealthScoreThreshold     float64 `mapstructure:"health_score_threshold"`      // minimum score (0-100) answered with 200 on /healthz/score
+	HealthDBLatencyBudget    int     `mapstructure:"health_db_latency_budget"`    // database ping latency in milliseconds at which its score reaches 0
+	HealthRedisLatencyBudget int     `mapstructure:"health_redis_latency_budget"` // Redis ping latency in milliseconds at which its score reaches 0
+	HealthErrorRateBudget    float64 `mapstructure:"health_error_rate_budget"`    // 5xx response rate at which its score reaches 0
+	HealthErrorWindow        int     `mapstructure:"health_error_window"`         // seconds of requests considered for the error rate
+	HealthMaxInFlight        int     `mapstructure:"health_max_in_flight"`        // in-flight requests at which the saturation score reaches 0
+	HealthWeightDB           float64 `mapstructure:"health_weight_db"`            // weight of database latency
+	HealthWeightRedis        float64 `mapstructure:"health_weight_redis"`         // weight of Redis latency
+	HealthWeightErrorRate    float64 `mapstructure:"health_weight_error_rate"`    // weight of the error rate
+	HealthWeightSaturation   float64 `mapstructure:"health_weight_saturation"`    // weight of in-flight saturation
+
+	// Admin API Configuration
+	AdminEnabled           bool     `mapstructure:"admin_enabled"`              // expose /v1/admin routes
+	AdminToken             string   `mapstructure:"admin_token"`                // bearer token required by admin routes
+	AdminAPIKeysEnabled    bool     `mapstructure:"admin_api_keys_enabled"`     // accept admin API keys in X-API-Key besides the admin token
+	AdminMemorySampleSize  int      `mapstructure:"admin_memory_sample_size"`   // keys sampled per key class for Redis memory reports
+	AdminPoolStatsCacheTTL int      `mapstructure:"admin_pool_stats_cache_ttl"` // seconds a pool stats report is reused, 0 recomputes it on every request
+	AdminAllowedCIDRs      []string `mapstructure:"admin_allowed_cidrs"`        // networks admin routes and the dashboard accept requests from, empty allows all
+
+	// Dashboard Configuration
+	DashboardEnabled           bool `mapstructure:"dashboard_enabled"`            // serve the dashboard at /dashboard/, requires admin_enabled
+	DashboardRecentAllocations int  `mapstructure:"dashboard_recent_allocations"` // allocations listed as recent
+	DashboardTrackedPeers      int  `mapstructure:"dashboard_tracked_peers"`      // peers whose lease activity is counted for the top peers
+
+	// Diagnostics Configuration
+	DiagnosticsEnabled      bool     `mapstructure:"diagnostics_enabled"`       // serve pprof profiles and runtime stats under /v1/admin/debug, requires admin_enabled
+	DiagnosticsAllowedCIDRs []string `mapstructure:"diagnostics_allowed_cidrs"` // networks diagnostics routes accept requests from, on top of admin_allowed_cidrs; empty allows all
+
+	// Fault Injection Configuration
+	FaultInjectionEnabled bool `mapstructure:"fault_injection_enabled"` // let the admin API inject latency and errors into Postgres and Redis calls, for staging only; requires admin_enabled
+
+	// Reload Configuration
+	ConfigWatch bool `mapstructure:"config_watch"` // reload when the config file changes, in addition to SIGHUP
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Port                    int            `mapstructure:"port"`
+	LogLevel                string         `mapstructure:"log_level"`                 // debug, info, warn or error
+	RequestTimeout          int            `mapstructure:"request_timeout"`           // seconds a request may take, 0 disables the timeout
+	RequestTimeoutOverrides map[string]int `mapstructure:"request_timeout_overrides"` // seconds per route pattern, e.g. /v1/admin/reclamation/run
+	MaxRequestBodySize      int64          `mapstructure:"max_request_body_size"`     // bytes a request body may have, 0 disables the limit
+	MaxInFlight             int            `mapstructure:"max_in_flight"`             // requests served at once, further ones wait for a slot; 0 disables the limit
+	QueueTimeout            int            `mapstructure:"queue_timeout"`             // milliseconds a request waits for a slot before it is refused with 503
+}
+
+// LogConfig configures how the server logs, on top of server.log_level
+type LogConfig struct {
+	Encoding           string            `mapstructure:"encoding"`            // console or json, for standard output
+	Levels             map[string]string `mapstructure:"levels"`              // levels of named loggers such as audit or cache, overriding server.log_level
+	SamplingInitial    int               `mapstructure:"sampling_initial"`    // entries with the same level and message logged each second before sampling, 0 disables sampling
+	SamplingThereafter int               `mapstructure:"sampling_thereafter"` // every this many of the further entries is logged
+	File               string            `mapstructure:"file"`                // log file written as JSON, empty logs to standard output only
+	FileMaxSize        int               `mapstructure:"file_max_size"`       // megabytes at which the log file is rotated
+	FileMaxBackups     int               `mapstructure:"file_max_backups"`    // rotated files kept, 0 keeps all
+	FileMaxAge         int               `mapstructure:"file_max_age"`        // days rotated files are kept, 0 keeps them regardless of age
+	FileCompress       bool              `mapstructure:"file_compress"`       // gzip rotated files
+	PayloadsEnabled    bool              `mapstructure:"payloads_enabled"`    // log request and response headers and bodies with credentials redacted, for debugging
+	PayloadsPerSecond  int               `mapstructure:"payloads_per_second"` // requests whose payloads are logged each second at most
+	PayloadMaxBytes    int               `mapstructure:"payload_max_bytes"`   // bodies longer than this are logged by their size only
+}
+
+// DatabaseConfig configures the PostgreSQL connection pool of the postgres storage backend
+type DatabaseConfig struct {
+	URL               string `mapstructure:"url"`
+	MaxConns          int    `mapstructure:"max_conns"`           // maximum number of connections in the pool
+	MinConns          int    `mapstructure:"min_conns"`           // minimum number of connections in the pool
+	MaxConnLifetime   int    `mapstructure:"max_conn_lifetime"`   // maximum lifetime of a connection in minutes
+	MaxConnIdleTime   int    `mapstructure:"max_conn_idle_time"`  // maximum idle time of a connection in minutes
+	HealthCheckPeriod int    `mapstructure:"health_check_period"` // health check period in seconds
+
+	ReplicaURLs          []string `mapstructure:"replica_urls"`           // read replicas serving lease lookups, empty reads from the primary
+	ReplicaRetryInterval int      `mapstructure:"replica_retry_interval"` // seconds a replica that failed to connect is skipped
+}
+
+// RedisConfig configures the Redis client caching leases, nonces and access rules
+type RedisConfig struct {
+	URL              string   `mapstructure:"url"` // host:port or redis:// URL of a standalone server
+	Password         string   `mapstructure:"password"`
+	Mode             string   `mapstructure:"mode"`              // standalone, sentinel or cluster
+	Addrs            []string `mapstructure:"addrs"`             // sentinel addresses or cluster seed nodes
+	MasterName       string   `mapstructure:"master_name"`       // master name monitored by the sentinels
+	SentinelPassword string   `mapstructure:"sentinel_password"` // password of the sentinels, if different from password
+	FailoverHoldoff  int      `mapstructure:"failover_holdoff"`  // seconds cache reads are bypassed after a failover error, 0 disables
+	MaxRetries       int      `mapstructure:"max_retries"`
+	PoolSize         int      `mapstructure:"pool_size"`
+	MinIdleConns     int      `mapstructure:"min_idle_conns"`
+	DialTimeout      int      `mapstructure:"dial_timeout"`  // seconds
+	ReadTimeout      int      `mapstructure:"read_timeout"`  // seconds
+	WriteTimeout     int      `mapstructure:"write_timeout"` // seconds
+}
+
+// CacheConfig configures the lease cache
+type CacheConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	DefaultTTL  int    `mapstructure:"default_ttl"`  // minutes
+	NegativeTTL int    `mapstructure:"negative_ttl"` // seconds to cache "lease not found" lookups, 0 disables
+	Codec       string `mapstructure:"codec"`        // encoding of cached leases and nonces in Redis: json or cbor
+}
+
+// RateLimitConfig configures the per-client rate limiter of the HTTP API
+type RateLimitConfig struct {
+	Enabled           bool     `mapstructure:"enabled"`             // enable/disable rate limiting
+	RequestsPerMinute int      `mapstructure:"requests_per_minute"` // requests per minute per IP
+	Burst             int      `mapstructure:"burst"`               // burst capacity for token bucket
+	TrustedProxies    []string `mapstructure:"trusted_proxies"`     // trusted proxy IPs for header validation
+	IdleTimeout       int      `mapstructure:"idle_timeout"`        // minutes a limiter may stay unused before eviction
+	MaxEntries        int      `mapstructure:"max_entries"`         // maximum tracked clients, least recently used are evicted first
+	BanEnabled        bool     `mapstructure:"ban_enabled"`         // temporarily ban clients that keep exceeding the limit
+	BanThreshold      int      `mapstructure:"ban_threshold"`       // violations within the window that ban a client
+	BanWindow         int      `mapstructure:"ban_window"`          // seconds in which violations are counted
+	BanDuration       int      `mapstructure:"ban_duration"`        // seconds of the first ban, doubled for every further ban
+	BanMaxDuration    int      `mapstructure:"ban_max_duration"`    // longest ban in seconds, also how long a ban is remembered after it ends
+
+	Classes []RateLimitClassConfig `mapstructure:"classes"` // routes limited separately from the others, each route in one class at most
+}
+
+// RateLimitClassConfig gives a group of routes its own limit. Each client has a separate
+// bucket per class, so requests to these routes do not count against the global limit.
+type RateLimitClassConfig struct {
+	Name              string   `mapstructure:"name"`
+	Routes            []string `mapstructure:"routes"`              // route patterns, e.g. /allocate-ip
+	RequestsPerMinute int      `mapstructure:"requests_per_minute"` // 0 scales the global rate by multiplier
+	Burst             int      `mapstructure:"burst"`               // 0 scales the global burst by multiplier
+	Multiplier        float64  `mapstructure:"multiplier"`          // of the global rate and burst, for what is not set directly
+}
+
+// ClassLimits returns the rate and burst of a class, scaling the global ones by its
+// multiplier where they are not set directly
+func (c *RateLimitConfig) ClassLimits(class RateLimitClassConfig) (requestsPerMinute, burst int) {
+	scale := func(value int) int {
+		return int(math.Round(float64(value) * class.Multiplier))
+	}
+
+	requestsPerMinute, burst = class.RequestsPerMinute, class.Burst
+	if requestsPerMinute == 0 {
+		requestsPerMinute = scale(c.RequestsPerMinute)
+	}
+	if burst == 0 {
+		burst = scale(c.Burst)
+	}
+	return requestsPerMinute, burst
+}
+
+// LeaseConfig configures how leases are allocated, renewed and retried
+type LeaseConfig struct {
+	TTL                    int    `mapstructure:"ttl"`                      // in minutes
+	MaxRetries             int    `mapstructure:"max_retries"`              // allocation attempts after a conflict
+	MaxPerPeer             int    `mapstructure:"max_per_peer"`             // active leases a peer may hold, 0 disables the quota
+	ConflictQuarantine     int    `mapstructure:"conflict_quarantine"`      // minutes a conflicting token ID is withheld, 0 keeps the lease
+	ReleaseGrace           int    `mapstructure:"release_grace"`            // seconds a released or expired token ID is withheld before reuse, 0 reuses it at once
+	RenewalWindow          int    `mapstructure:"renewal_window"`           // minutes before expiry from which renewals are accepted, 0 accepts them any time
+	RenewHintPercent       int    `mapstructure:"renew_hint_percent"`       // share of the remaining lifetime, or of the open renewal window, after which holders are told to renew, 0 omits renew_after
+	RenewHintJitter        int    `mapstructure:"renew_hint_jitter"`        // percent the renew_after hint is randomly moved by either way
+	RetryDelay             int    `mapstructure:"retry_delay"`              // milliseconds before the first retry, doubled for every further one
+	RetryMaxDelay          int    `mapstructure:"retry_max_delay"`          // milliseconds a single retry waits at most
+	AllocationStrategy     string `mapstructure:"allocation_strategy"`      // lru, sequential or random
+	AllocationRandomProbes int    `mapstructure:"allocation_random_probes"` // random token IDs tried before the random strategy falls back to lru
+	AllocationChunkSize    int    `mapstructure:"allocation_chunk_size"`    // token IDs an instance reserves from alloc_state at once, 0 or 1 disables reservation
+	AffinityProbeLimit     int    `mapstructure:"affinity_probe_limit"`     // token IDs probed for contiguous affinity group allocation
+	BatchMaxOperations     int    `mapstructure:"batch_max_operations"`     // maximum operations per lease batch request
+	IdempotencyWindow      int    `mapstructure:"idempotency_window"`       // minutes responses are replayed to retries with the same Idempotency-Key, 0 disables
+	WaitMaxTimeout         int    `mapstructure:"wait_max_timeout"`         // seconds a /v1/lease/{tokenID}/wait request may block, also its default timeout
+	WriteBehindInterval    int    `mapstructure:"write_behind_interval"`    // seconds renewals of cached leases are buffered before they are written, 0 writes them through
+	WriteBehindBatchSize   int    `mapstructure:"write_behind_batch_size"`  // buffered renewals that trigger an early write, also the renewals written per transaction
+	RevocationBatchSize    int    `mapstructure:"revocation_batch_size"`    // leases an admin revocation releases per transaction
+	RevocationWait         int    `mapstructure:"revocation_wait"`          // seconds a revocation request waits for the revocation before reporting its progress
+}
+
+// NonceConfig configures the nonces signed by clients to authenticate
+type NonceConfig struct {
+	TTL               int  `mapstructure:"ttl"`                 // in minutes
+	CleanerInterval   int  `mapstructure:"cleaner_interval"`    // in minutes
+	CleanerJitter     int  `mapstructure:"cleaner_jitter"`      // up to this many seconds of random delay before each cleanup run
+	CleanerBatchSize  int  `mapstructure:"cleaner_batch_size"`  // expired nonces deleted per statement, 0 deletes them all at once
+	CleanerBatchPause int  `mapstructure:"cleaner_batch_pause"` // milliseconds between cleanup batches
+	MaxOutstanding    int  `mapstructure:"max_outstanding"`     // unused nonces a peer may hold, 0 disables the cap
+	ReuseAtCap        bool `mapstructure:"reuse_at_cap"`        // hand out the newest outstanding nonce instead of rejecting
+}
+
+// SecurityConfig configures request authentication, access control and lease signing
+type SecurityConfig struct {
+	TimestampRequired       bool   `mapstructure:"timestamp_required"`         // reject authenticated requests without X-Timestamp
+	TimestampWindow         int    `mapstructure:"timestamp_window"`           // seconds a signed timestamp may deviate from server time
+	SignatureCacheSize      int    `mapstructure:"signature_cache_size"`       // verified signatures remembered, 0 disables the cache
+	SignatureCacheTTL       int    `mapstructure:"signature_cache_ttl"`        // seconds a verified signature is remembered
+	LookupAuthRequired      bool   `mapstructure:"lookup_auth_required"`       // strict mode: lease lookups require authentication
+	AccessAllowListRequired bool   `mapstructure:"access_allow_list_required"` // only peers on the allow list may authenticate
+	AccessCacheTTL          int    `mapstructure:"access_cache_ttl"`           // seconds the access rules of a peer are cached
+	IdentityScheme          string `mapstructure:"identity_scheme"`            // peer_id or ethereum
+	ServerKeyPath           string `mapstructure:"server_key_path"`            // key file leases are signed with, generated when missing; empty disables signing
+	FieldEncryptionKey      string `mapstructure:"field_encryption_key"`       // base64 AES-256 key peer IDs and public keys are stored encrypted with; empty disables encryption
+	FieldEncryptionKeyPath  string `mapstructure:"field_encryption_key_path"`  // file holding the base64 key instead, e.g. written by a KMS or secrets agent
+
+	// Key files of retired signing keys, published with the current key so certificates
+	// they signed still verify
+	ServerVerificationKeyPaths []string `mapstructure:"server_verification_key_paths"`
+
+	// Checks every request passes before it is routed
+	HeaderPatterns       []string `mapstructure:"header_patterns"`        // regular expressions refused in header values, matched without case
+	HeaderPatternsExempt []string `mapstructure:"header_patterns_exempt"` // headers whose values are not matched against header_patterns
+	MaxHeaderLength      int      `mapstructure:"max_header_length"`      // bytes a header value may have, 0 disables the limit
+	MaxHeaderNameLength  int      `mapstructure:"max_header_name_length"` // bytes a header name may have, 0 disables the limit
+	MaxURLLength         int      `mapstructure:"max_url_length"`         // bytes the request URL may have, 0 disables the limit
+}
+
+// ReclaimPolicyConfig configures one lease reclamation policy. All non-zero conditions must
+// hold for an expired lease to be reclaimed by the policy.
+type ReclaimPolicyConfig struct {
+	Name               string  `mapstructure:"name"`
+	NotRenewedFor      int     `mapstructure:"not_renewed_for"`      // minutes since the lease was last renewed
+	NotRenewedForTTLs  float64 `mapstructure:"not_renewed_for_ttls"` // same as not_renewed_for, in multiples of lease_ttl
+	ExpiredFor         int     `mapstructure:"expired_for"`          // minutes since the lease expired
+	SkipAffinityGroups bool    `mapstructure:"skip_affinity_groups"` // treat leases in an affinity group as reserved
+}
+
+// TenantConfig configures a tenant. Requests carrying one of its API keys in X-API-Key
+// only see and allocate leases of its pool, which must not overlap any other pool.
+type TenantConfig struct {
+	ID             string             `mapstructure:"id"`
+	APIKeys        []string           `mapstructure:"api_keys"`
+	PoolMinTokenID int64              `mapstructure:"pool_min_token_id"` // first token ID of the tenant's pool
+	PoolMaxTokenID int64              `mapstructure:"pool_max_token_id"` // last token ID of the tenant's pool
+	PoolExclusions []TokenRangeConfig `mapstructure:"pool_exclusions"`   // sub-ranges of the tenant's pool never handed out
+	PoolZones      []ZoneRangeConfig  `mapstructure:"pool_zones"`        // sub-ranges of the tenant's pool by rack or site
+}
+
+// TokenRangeConfig is an inclusive range of token IDs excluded from a pool, such as the
+// addresses of static infrastructure managed outside dhcp2p
+type TokenRangeConfig struct {
+	FirstTokenID int64  `mapstructure:"first_token_id"`
+	LastTokenID  int64  `mapstructure:"last_token_id"`
+	Reason       string `mapstructure:"reason"` // shown next to the range by the admin API
+}
+
+// TenantExclusions returns the exclusions of every pool by tenant ID, sorted by their
+// first token ID. Pools without exclusions are left out.
+func (c *AppConfig) TenantExclusions() map[string]models.TokenRanges {
+	exclusions := make(map[string]models.TokenRanges)
+	add := func(tenantID string, configured []TokenRangeConfig) {
+		if len(configured) == 0 {
+			return
+		}
+		ranges := make(models.TokenRanges, 0, len(configured))
+		for _, rc := range configured {
+			ranges = append(ranges, models.TokenRange{FirstTokenID: rc.FirstTokenID, LastTokenID: rc.LastTokenID, Reason: rc.Reason})
+		}
+		slices.SortFunc(ranges, func(a, b models.TokenRange) int { return cmp.Compare(a.FirstTokenID, b.FirstTokenID) })
+		exclusions[tenantID] = ranges
+	}
+
+	add(models.DefaultTenantID, c.PoolExclusions)
+	for _, tenant := range c.Tenants {
+		add(tenant.ID, tenant.PoolExclusions)
+	}
+	return exclusions
+}
+
+// ZoneRangeConfig tags an inclusive range of token IDs of a pool with the zone, such as
+// the rack or site, its addresses are routed to. A zone may span several ranges.
+type ZoneRangeConfig struct {
+	Zone         string `mapstructure:"zone"`
+	FirstTokenID int64  `mapstructure:"first_token_id"`
+	LastTokenID  int64  `mapstructure:"last_token_id"`
+}
+
+// TenantZones returns the zones of every pool by tenant ID, each with its ranges sorted by
+// their first token ID. Pools without zones are left out.
+func (c *AppConfig) TenantZones() map[string]models.Zones {
+	zones := make(map[string]models.Zones)
+	add := func(tenantID string, configured []ZoneRangeConfig) {
+		if len(configured) == 0 {
+			return
+		}
+		byZone := make(models.Zones)
+		for _, zc := range configured {
+			byZone[zc.Zone] = append(byZone[zc.Zone], models.TokenRange{FirstTokenID: zc.FirstTokenID, LastTokenID: zc.LastTokenID})
+		}
+		for _, ranges := range byZone {
+			slices.SortFunc(ranges, func(a, b models.TokenRange) int { return cmp.Compare(a.FirstTokenID, b.FirstTokenID) })
+		}
+		zones[tenantID] = byZone
+	}
+
+	add(models.DefaultTenantID, c.PoolZones)
+	for _, tenant := range c.Tenants {
+		add(tenant.ID, tenant.PoolZones)
+	}
+	return zones
+}
+
+// LeaseWebhookConfig configures an endpoint receiving lease lifecycle events
+type LeaseWebhookConfig struct {
+	URL    string   `mapstructure:"url"`
+	Secret string   `mapstructure:"secret"` // HMAC-SHA256 key signing request bodies, empty disables
+	Events []string `mapstructure:"events"` // lease.allocated, lease.renewed, lease.released and/or lease.expired, empty for all
+}
+
+// DelegationGatewayConfig allows a gateway peer to allocate leases on behalf of the
+// downstream peers it vouches for
+type DelegationGatewayConfig struct {
+	PeerID    string `mapstructure:"peer_id"`
+	MaxLeases int    `mapstructure:"max_leases"` // active delegated leases the gateway may hold, 0 disables the quota
+}
+
+// PeerPolicyConfig grants permissions to the listed peer IDs, or to every peer no other
+// policy lists with "*"
+type PeerPolicyConfig struct {
+	Name        string   `mapstructure:"name"`
+	Peers       []string `mapstructure:"peers"`
+	CanAllocate bool     `mapstructure:"can_allocate"`
+	CanReserve  bool     `mapstructure:"can_reserve"` // allocate requested token IDs, needs can_allocate
+	MaxPool     int      `mapstructure:"max_pool"`    // active leases the listed peers may hold together, 0 for no limit
 }
 
 // NewDefaultAppConfig returns an AppConfig with all default values
 func NewDefaultAppConfig() *AppConfig {
 	return &AppConfig{
 		// Server Configuration
-		Port:     8088,
-		LogLevel: "info",
+		Server: ServerConfig{
+			Port:                    8088,
+			LogLevel:                "info",
+			RequestTimeout:          60, // seconds
+			RequestTimeoutOverrides: map[string]int{},
+			MaxRequestBodySize:      1 << 20, // 1MB
+			MaxInFlight:             0,
+			QueueTimeout:            1000, // milliseconds
+		},
 
-		// Nonce Configuration
-		NonceTTL:             5, // minutes
-		NonceCleanerInterval: 5, // minutes
+		// Log Configuration
+		Log: LogConfig{
+			Encoding:           "console",
+			Levels:             map[string]string{},
+			SamplingInitial:    100,
+			SamplingThereafter: 100,
+			File:               "logs/app.log",
+			FileMaxSize:        10, // megabytes
+			FileMaxBackups:     3,
+			FileMaxAge:         7, // days
+			PayloadsPerSecond:  10,
+			PayloadMaxBytes:    8192,
+		},
 
-		// Lease Configuration
-		LeaseTTL:        120, // minutes
-		MaxLeaseRetries: 3,
-		LeaseRetryDelay: 500, // milliseconds
+		// PostgreSQL Pool Configuration
+		Database: DatabaseConfig{
+			MaxConns:          25,
+			MinConns:          5,
+			MaxConnLifetime:   30, // minutes
+			MaxConnIdleTime:   5,  // minutes
+			HealthCheckPeriod: 30, // seconds
+
+			// Read Replica Configuration
+			ReplicaURLs:          []string{},
+			ReplicaRetryInterval: 30, // seconds
+		},
 
 		// Redis Configuration
-		RedisMaxRetries:   3,
-		RedisPoolSize:     10,
-		RedisMinIdleConns: 5,
-		RedisDialTimeout:  5, // seconds
-		RedisReadTimeout:  3, // seconds
-		RedisWriteTimeout: 3, // seconds
+		Redis: RedisConfig{
+			Mode:            RedisModeStandalone,
+			Addrs:           []string{},
+			FailoverHoldoff: 10, // seconds
+			MaxRetries:      3,
+			PoolSize:        10,
+			MinIdleConns:    5,
+			DialTimeout:     5, // seconds
+			ReadTimeout:     3, // seconds
+			WriteTimeout:    3, // seconds
+		},
 
 		// Cache Configuration
-		CacheEnabled:    true,
-		CacheDefaultTTL: 30, // minutes
-
-		// PostgreSQL Pool Configuration
-		DBMaxConns:          25,
-		DBMinConns:          5,
-		DBMaxConnLifetime:   30, // minutes
-		DBMaxConnIdleTime:   5,  // minutes
-		DBHealthCheckPeriod: 30, // seconds
+		Cache: CacheConfig{
+			Enabled:     true,
+			DefaultTTL:  30, // minutes
+			NegativeTTL: 5,  // seconds
+			Codec:       "json",
+		},
 
 		// Rate Limiting Configuration
-		RateLimitEnabled:           true,
-		RateLimitRequestsPerMinute: 100,
-		RateLimitBurst:             20,
-		RateLimitTrustedProxies:    []string{},
+		RateLimit: RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 100,
+			Burst:             20,
+			TrustedProxies:    []string{},
+			IdleTimeout:       10, // minutes
+			MaxEntries:        10000,
+			BanEnabled:        false,
+			BanThreshold:      10,
+			BanWindow:         60,   // seconds
+			BanDuration:       60,   // seconds
+			BanMaxDuration:    3600, // seconds
+			Classes:           []RateLimitClassConfig{},
+		},
+
+		// Lease Configuration
+		Lease: LeaseConfig{
+			TTL:                    120, // minutes
+			MaxRetries:             3,
+			MaxPerPeer:             1,
+			ConflictQuarantine:     0,    // minutes
+			ReleaseGrace:           0,    // seconds
+			RenewHintPercent:       50,   // percent
+			RenewHintJitter:        10,   // percent
+			RetryDelay:             500,  // milliseconds
+			RetryMaxDelay:          5000, // milliseconds
+			AllocationStrategy:     "lru",
+			AllocationRandomProbes: 8,
+			AllocationChunkSize:    0,
+			AffinityProbeLimit:     16,
+			BatchMaxOperations:     100,
+			IdempotencyWindow:      60, // minutes
+			WaitMaxTimeout:         30, // seconds
+			WriteBehindInterval:    0,  // seconds
+			WriteBehindBatchSize:   500,
+			RevocationBatchSize:    500,
+			RevocationWait:         10, // seconds
+		},
+
+		// Nonce Configuration
+		Nonce: NonceConfig{
+			TTL:               5,  // minutes
+			CleanerInterval:   5,  // minutes
+			CleanerJitter:     30, // seconds
+			CleanerBatchSize:  1000,
+			CleanerBatchPause: 100, // milliseconds
+			MaxOutstanding:    5,
+			ReuseAtCap:        true,
+		},
+
+		// Security Configuration
+		Security: SecurityConfig{
+			TimestampRequired:       false,
+			TimestampWindow:         30, // seconds
+			SignatureCacheSize:      0,
+			SignatureCacheTTL:       60, // seconds
+			LookupAuthRequired:      false,
+			AccessAllowListRequired: false,
+			AccessCacheTTL:          60, // seconds
+			IdentityScheme:          IdentitySchemePeerID,
+			HeaderPatterns:          []string{`<script`, `javascript:`, `onload\s*=`, `onerror\s*=`},
+			HeaderPatternsExempt:    []string{},
+			MaxHeaderLength:         8192, // bytes
+			MaxHeaderNameLength:     256,  // bytes
+			MaxURLLength:            8192, // bytes
+		},
+
+		// Storage Configuration
+		StorageBackend: StorageBackendPostgres,
+		StoragePath:    "./data/dhcp2p.json",
+
+		// Degraded Renewal Configuration
+		DegradedRenewalsEnabled: false,
+		DegradedJournalPath:     "./data/renewals.journal",
+		DegradedReplayInterval:  5, // seconds
+
+		// Pool Configuration
+		PoolMinTokenID: 167902210,
+		PoolMaxTokenID: 168162304,
+		PoolExclusions: []TokenRangeConfig{},
+		PoolZones:      []ZoneRangeConfig{},
+
+		// Peer Policy Configuration
+		PeerPolicySource:          "",
+		PeerPolicyRefreshInterval: 30, // seconds
+
+		// Schema Configuration
+		SchemaCheckEnabled: true,
+		AutoMigrate:        false,
+
+		// P2P Configuration
+		P2PStreamTimeout:   30, // seconds
+		P2PMaxMessageBytes: 4096,
+
+		// Read Model Configuration
+		ReadModelRefreshInterval: 30, // seconds
+
+		// Leader Election Configuration
+		LeaderElectionEnabled:  true,
+		LeaderElectionInterval: 10, // seconds
+
+		// High Availability Configuration
+		HACheckInterval: 5, // seconds
+
+		// Reclamation Configuration
+		ReclaimEnabled:   false,
+		ReclaimDryRun:    false,
+		ReclaimInterval:  10, // minutes
+		ReclaimBatchSize: 500,
+		ReclaimPolicies: []ReclaimPolicyConfig{
+			{Name: "expired"},
+		},
+
+		// Expiry Notification Configuration
+		ExpiryNotifyEnabled:   false,
+		ExpiryNotifyInterval:  1440, // minutes
+		ExpiryNotifyWindow:    1440, // minutes
+		ExpiryNotifyBatchSize: 500,
+		ExpiryNotifyChannels:  []string{"log"},
+		ExpiryWebhookTimeout:  10, // seconds
+
+		// Lease Webhook Configuration
+		LeaseWebhookTimeout:   10, // seconds
+		LeaseWebhookRetries:   3,
+		LeaseWebhookBackoff:   1000, // milliseconds
+		LeaseWebhookQueueSize: 1000,
+
+		// DNS Configuration
+		DNSRecordTTL:     300, // seconds
+		DNSProvider:      "rfc2136",
+		DNSTSIGAlgorithm: "hmac-sha256",
+		DNSTimeout:       5, // seconds
+		DNSQueueSize:     1000,
+
+		// Event Bus Configuration
+		EventBusBroker:          "memory",
+		EventBusTopicPrefix:     "dhcp2p",
+		EventBusQueueSize:       10000,
+		EventBusRetryBackoff:    500,   // milliseconds
+		EventBusMaxRetryBackoff: 30000, // milliseconds
+		EventBusTimeout:         5,     // seconds
+
+		// Lease Journal Configuration
+		LeaseJournalEnabled:        false,
+		LeaseJournalSink:           "file",
+		LeaseJournalPath:           "./data/leases.journal",
+		LeaseJournalS3Prefix:       "dhcp2p/journal",
+		LeaseJournalS3Region:       "us-east-1",
+		LeaseJournalFlushInterval:  1000, // milliseconds
+		LeaseJournalVerifyInterval: 300,  // seconds
+
+		// Readiness Configuration
+		ReadinessDBTimeout:    2000, // milliseconds
+		ReadinessRedisTimeout: 1000, // milliseconds
+		ReadinessDegradedMode: false,
+
+		// Health Score Configuration
+		HealthScoreThreshold:     50,
+		HealthDBLatencyBudget:    250, // milliseconds
+		HealthRedisLatencyBudget: 100, // milliseconds
+		HealthErrorRateBudget:    0.25,
+		HealthErrorWindow:        60, // seconds
+		HealthMaxInFlight:        512,
+		HealthWeightDB:           0.3,
+		HealthWeightRedis:        0.2,
+		HealthWeightErrorRate:    0.3,
+		HealthWeightSaturation:   0.2,
+
+		// Admin API Configuration
+		AdminEnabled:           false,
+		AdminAPIKeysEnabled:    false,
+		AdminMemorySampleSize:  100,
+		AdminPoolStatsCacheTTL: 15,
+		AdminAllowedCIDRs:      []string{},
+
+		// Dashboard Configuration
+		DashboardEnabled:           false,
+		DashboardRecentAllocations: 20,
+		DashboardTrackedPeers:      1000,
+
+		// Diagnostics Configuration
+		DiagnosticsEnabled:      false,
+		DiagnosticsAllowedCIDRs: []string{},
+
+		// Fault Injection Configuration
+		FaultInjectionEnabled: false,
+
+		// Reload Configuration
+		ConfigWatch: false,
 	}
 }
 
+// NewAppConfig reads the configuration from the defaults, the config file and the
+// environment, and rejects it with every violation listed if it does not validate
 func NewAppConfig() (*AppConfig, error) {
 	v := viper.GetViper()
 
-	// Set environment prefix
+	// Set environment prefix, keys in sections are read from e.g. DHCP2P_LEASE_TTL
 	v.SetEnvPrefix(ENV_PREFIX)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
+	bindLegacyEnv(v)
 
 	// Set default values from our centralized defaults
 	defaults := NewDefaultAppConfig()
-	v.SetDefault("port", defaults.Port)
-	v.SetDefault("log_level", defaults.LogLevel)
-	v.SetDefault("nonce_ttl", defaults.NonceTTL)
-	v.SetDefault("nonce_cleaner_interval", defaults.NonceCleanerInterval)
-	v.SetDefault("lease_ttl", defaults.LeaseTTL)
-	v.SetDefault("max_lease_retries", defaults.MaxLeaseRetries)
-	v.SetDefault("lease_retry_delay", defaults.LeaseRetryDelay)
-	v.SetDefault("redis_max_retries", defaults.RedisMaxRetries)
-	v.SetDefault("redis_pool_size", defaults.RedisPoolSize)
-	v.SetDefault("redis_min_idle_conns", defaults.RedisMinIdleConns)
-	v.SetDefault("redis_dial_timeout", defaults.RedisDialTimeout)
-	v.SetDefault("redis_read_timeout", defaults.RedisReadTimeout)
-	v.SetDefault("redis_write_timeout", defaults.RedisWriteTimeout)
-	v.SetDefault("cache_enabled", defaults.CacheEnabled)
-	v.SetDefault("cache_default_ttl", defaults.CacheDefaultTTL)
-	v.SetDefault("db_max_conns", defaults.DBMaxConns)
-	v.SetDefault("db_min_conns", defaults.DBMinConns)
-	v.SetDefault("db_max_conn_lifetime", defaults.DBMaxConnLifetime)
-	v.SetDefault("db_max_conn_idle_time", defaults.DBMaxConnIdleTime)
-	v.SetDefault("db_health_check_period", defaults.DBHealthCheckPeriod)
-	v.SetDefault("rate_limit_enabled", defaults.RateLimitEnabled)
-	v.SetDefault("rate_limit_requests_per_minute", defaults.RateLimitRequestsPerMinute)
-	v.SetDefault("rate_limit_burst", defaults.RateLimitBurst)
-	v.SetDefault("rate_limit_trusted_proxies", defaults.RateLimitTrustedProxies)
+	v.SetDefault("server.port", defaults.Server.Port)
+	v.SetDefault("server.log_level", defaults.Server.LogLevel)
+	v.SetDefault("log.encoding", defaults.Log.Encoding)
+	v.SetDefault("log.levels", defaults.Log.Levels)
+	v.SetDefault("log.sampling_initial", defaults.Log.SamplingInitial)
+	v.SetDefault("log.sampling_thereafter", defaults.Log.SamplingThereafter)
+	v.SetDefault("log.file", defaults.Log.File)
+	v.SetDefault("log.file_max_size", defaults.Log.FileMaxSize)
+	v.SetDefault("log.file_max_backups", defaults.Log.FileMaxBackups)
+	v.SetDefault("log.file_max_age", defaults.Log.FileMaxAge)
+	v.SetDefault("log.file_compress", defaults.Log.FileCompress)
+	v.SetDefault("log.payloads_enabled", defaults.Log.PayloadsEnabled)
+	v.SetDefault("log.payloads_per_second", defaults.Log.PayloadsPerSecond)
+	v.SetDefault("log.payload_max_bytes", defaults.Log.PayloadMaxBytes)
+	v.SetDefault("nonce.ttl", defaults.Nonce.TTL)
+	v.SetDefault("nonce.cleaner_interval", defaults.Nonce.CleanerInterval)
+	v.SetDefault("nonce.cleaner_jitter", defaults.Nonce.CleanerJitter)
+	v.SetDefault("nonce.cleaner_batch_size", defaults.Nonce.CleanerBatchSize)
+	v.SetDefault("nonce.cleaner_batch_pause", defaults.Nonce.CleanerBatchPause)
+	v.SetDefault("nonce.max_outstanding", defaults.Nonce.MaxOutstanding)
+	v.SetDefault("nonce.reuse_at_cap", defaults.Nonce.ReuseAtCap)
+	v.SetDefault("storage_backend", defaults.StorageBackend)
+	v.SetDefault("storage_path", defaults.StoragePath)
+	v.SetDefault("degraded_renewals_enabled", defaults.DegradedRenewalsEnabled)
+	v.SetDefault("degraded_journal_path", defaults.DegradedJournalPath)
+	v.SetDefault("degraded_replay_interval", defaults.DegradedReplayInterval)
+	v.SetDefault("pool_min_token_id", defaults.PoolMinTokenID)
+	v.SetDefault("pool_max_token_id", defaults.PoolMaxTokenID)
+	v.SetDefault("pool_exclusions", defaults.PoolExclusions)
+	v.SetDefault("pool_zones", defaults.PoolZones)
+	v.SetDefault("tenants", defaults.Tenants)
+	v.SetDefault("delegation_gateways", defaults.DelegationGateways)
+	v.SetDefault("peer_policy_source", defaults.PeerPolicySource)
+	v.SetDefault("peer_policies", defaults.PeerPolicies)
+	v.SetDefault("peer_policy_refresh_interval", defaults.PeerPolicyRefreshInterval)
+	v.SetDefault("schema_check_enabled", defaults.SchemaCheckEnabled)
+	v.SetDefault("auto_migrate", defaults.AutoMigrate)
+	v.SetDefault("security.timestamp_required", defaults.Security.TimestampRequired)
+	v.SetDefault("security.timestamp_window", defaults.Security.TimestampWindow)
+	v.SetDefault("security.signature_cache_size", defaults.Security.SignatureCacheSize)
+	v.SetDefault("security.signature_cache_ttl", defaults.Security.SignatureCacheTTL)
+	v.SetDefault("security.lookup_auth_required", defaults.Security.LookupAuthRequired)
+	v.SetDefault("security.access_allow_list_required", defaults.Security.AccessAllowListRequired)
+	v.SetDefault("security.access_cache_ttl", defaults.Security.AccessCacheTTL)
+	v.SetDefault("security.identity_scheme", defaults.Security.IdentityScheme)
+	v.SetDefault("security.server_key_path", defaults.Security.ServerKeyPath)
+	v.SetDefault("security.server_verification_key_paths", defaults.Security.ServerVerificationKeyPaths)
+	v.SetDefault("security.field_encryption_key", defaults.Security.FieldEncryptionKey)
+	v.SetDefault("security.field_encryption_key_path", defaults.Security.FieldEncryptionKeyPath)
+	v.SetDefault("security.header_patterns", defaults.Security.HeaderPatterns)
+	v.SetDefault("security.header_patterns_exempt", defaults.Security.HeaderPatternsExempt)
+	v.SetDefault("security.max_header_length", defaults.Security.MaxHeaderLength)
+	v.SetDefault("security.max_header_name_length", defaults.Security.MaxHeaderNameLength)
+	v.SetDefault("security.max_url_length", defaults.Security.MaxURLLength)
+	v.SetDefault("p2p_stream_timeout", defaults.P2PStreamTimeout)
+	v.SetDefault("p2p_max_message_bytes", defaults.P2PMaxMessageBytes)
+	v.SetDefault("lease.ttl", defaults.Lease.TTL)
+	v.SetDefault("lease.max_retries", defaults.Lease.MaxRetries)
+	v.SetDefault("lease.max_per_peer", defaults.Lease.MaxPerPeer)
+	v.SetDefault("lease.conflict_quarantine", defaults.Lease.ConflictQuarantine)
+	v.SetDefault("lease.release_grace", defaults.Lease.ReleaseGrace)
+	v.SetDefault("lease.renewal_window", defaults.Lease.RenewalWindow)
+	v.SetDefault("lease.renew_hint_percent", defaults.Lease.RenewHintPercent)
+	v.SetDefault("lease.renew_hint_jitter", defaults.Lease.RenewHintJitter)
+	v.SetDefault("lease.allocation_strategy", defaults.Lease.AllocationStrategy)
+	v.SetDefault("lease.allocation_random_probes", defaults.Lease.AllocationRandomProbes)
+	v.SetDefault("lease.allocation_chunk_size", defaults.Lease.AllocationChunkSize)
+	v.SetDefault("lease.retry_delay", defaults.Lease.RetryDelay)
+	v.SetDefault("lease.retry_max_delay", defaults.Lease.RetryMaxDelay)
+	v.SetDefault("lease.affinity_probe_limit", defaults.Lease.AffinityProbeLimit)
+	v.SetDefault("lease.batch_max_operations", defaults.Lease.BatchMaxOperations)
+	v.SetDefault("lease.idempotency_window", defaults.Lease.IdempotencyWindow)
+	v.SetDefault("lease.wait_max_timeout", defaults.Lease.WaitMaxTimeout)
+	v.SetDefault("lease.write_behind_interval", defaults.Lease.WriteBehindInterval)
+	v.SetDefault("lease.write_behind_batch_size", defaults.Lease.WriteBehindBatchSize)
+	v.SetDefault("lease.revocation_batch_size", defaults.Lease.RevocationBatchSize)
+	v.SetDefault("lease.revocation_wait", defaults.Lease.RevocationWait)
+	v.SetDefault("read_model_refresh_interval", defaults.ReadModelRefreshInterval)
+	v.SetDefault("leader_election_enabled", defaults.LeaderElectionEnabled)
+	v.SetDefault("leader_election_interval", defaults.LeaderElectionInterval)
+	v.SetDefault("ha_enabled", defaults.HAEnabled)
+	v.SetDefault("ha_instance", defaults.HAInstance)
+	v.SetDefault("ha_check_interval", defaults.HACheckInterval)
+	v.SetDefault("read_only", defaults.ReadOnly)
+	v.SetDefault("reclaim_enabled", defaults.ReclaimEnabled)
+	v.SetDefault("reclaim_dry_run", defaults.ReclaimDryRun)
+	v.SetDefault("reclaim_interval", defaults.ReclaimInterval)
+	v.SetDefault("reclaim_batch_size", defaults.ReclaimBatchSize)
+	v.SetDefault("reclaim_policies", defaults.ReclaimPolicies)
+	v.SetDefault("expiry_notify_enabled", defaults.ExpiryNotifyEnabled)
+	v.SetDefault("expiry_notify_interval", defaults.ExpiryNotifyInterval)
+	v.SetDefault("expiry_notify_window", defaults.ExpiryNotifyWindow)
+	v.SetDefault("expiry_notify_batch_size", defaults.ExpiryNotifyBatchSize)
+	v.SetDefault("expiry_notify_channels", defaults.ExpiryNotifyChannels)
+	v.SetDefault("expiry_webhook_url", defaults.ExpiryWebhookURL)
+	v.SetDefault("expiry_webhook_secret", defaults.ExpiryWebhookSecret)
+	v.SetDefault("expiry_webhook_timeout", defaults.ExpiryWebhookTimeout)
+	v.SetDefault("lease_webhooks", defaults.LeaseWebhooks)
+	v.SetDefault("lease_webhook_timeout", defaults.LeaseWebhookTimeout)
+	v.SetDefault("lease_webhook_retries", defaults.LeaseWebhookRetries)
+	v.SetDefault("lease_webhook_backoff", defaults.LeaseWebhookBackoff)
+	v.SetDefault("lease_webhook_queue_size", defaults.LeaseWebhookQueueSize)
+	v.SetDefault("dns_enabled", defaults.DNSEnabled)
+	v.SetDefault("dns_zone", defaults.DNSZone)
+	v.SetDefault("dns_record_ttl", defaults.DNSRecordTTL)
+	v.SetDefault("dns_provider", defaults.DNSProvider)
+	v.SetDefault("dns_server", defaults.DNSServer)
+	v.SetDefault("dns_tsig_key", defaults.DNSTSIGKey)
+	v.SetDefault("dns_tsig_secret", defaults.DNSTSIGSecret)
+	v.SetDefault("dns_tsig_algorithm", defaults.DNSTSIGAlgorithm)
+	v.SetDefault("dns_timeout", defaults.DNSTimeout)
+	v.SetDefault("dns_queue_size", defaults.DNSQueueSize)
+	v.SetDefault("event_bus_broker", defaults.EventBusBroker)
+	v.SetDefault("event_bus_topic_prefix", defaults.EventBusTopicPrefix)
+	v.SetDefault("event_bus_queue_size", defaults.EventBusQueueSize)
+	v.SetDefault("event_bus_retry_backoff", defaults.EventBusRetryBackoff)
+	v.SetDefault("event_bus_max_retry_backoff", defaults.EventBusMaxRetryBackoff)
+	v.SetDefault("event_bus_timeout", defaults.EventBusTimeout)
+	v.SetDefault("nats_url", defaults.NATSURL)
+	v.SetDefault("kafka_rest_url", defaults.KafkaRESTURL)
+	v.SetDefault("lease_journal_enabled", defaults.LeaseJournalEnabled)
+	v.SetDefault("lease_journal_sink", defaults.LeaseJournalSink)
+	v.SetDefault("lease_journal_path", defaults.LeaseJournalPath)
+	v.SetDefault("lease_journal_s3_endpoint", defaults.LeaseJournalS3Endpoint)
+	v.SetDefault("lease_journal_s3_bucket", defaults.LeaseJournalS3Bucket)
+	v.SetDefault("lease_journal_s3_prefix", defaults.LeaseJournalS3Prefix)
+	v.SetDefault("lease_journal_s3_region", defaults.LeaseJournalS3Region)
+	v.SetDefault("lease_journal_s3_access_key", defaults.LeaseJournalS3AccessKey)
+	v.SetDefault("lease_journal_s3_secret_key", defaults.LeaseJournalS3SecretKey)
+	v.SetDefault("lease_journal_flush_interval", defaults.LeaseJournalFlushInterval)
+	v.SetDefault("lease_journal_verify_interval", defaults.LeaseJournalVerifyInterval)
+	v.SetDefault("discovery_enabled", defaults.DiscoveryEnabled)
+	v.SetDefault("discovery_instance", defaults.DiscoveryInstance)
+	v.SetDefault("discovery_interface", defaults.DiscoveryInterface)
+	v.SetDefault("redis.url", defaults.Redis.URL)
+	v.SetDefault("redis.password", defaults.Redis.Password)
+	v.SetDefault("redis.mode", defaults.Redis.Mode)
+	v.SetDefault("redis.addrs", defaults.Redis.Addrs)
+	v.SetDefault("redis.master_name", defaults.Redis.MasterName)
+	v.SetDefault("redis.sentinel_password", defaults.Redis.SentinelPassword)
+	v.SetDefault("redis.failover_holdoff", defaults.Redis.FailoverHoldoff)
+	v.SetDefault("redis.max_retries", defaults.Redis.MaxRetries)
+	v.SetDefault("redis.pool_size", defaults.Redis.PoolSize)
+	v.SetDefault("redis.min_idle_conns", defaults.Redis.MinIdleConns)
+	v.SetDefault("redis.dial_timeout", defaults.Redis.DialTimeout)
+	v.SetDefault("redis.read_timeout", defaults.Redis.ReadTimeout)
+	v.SetDefault("redis.write_timeout", defaults.Redis.WriteTimeout)
+	v.SetDefault("cache.enabled", defaults.Cache.Enabled)
+	v.SetDefault("cache.default_ttl", defaults.Cache.DefaultTTL)
+	v.SetDefault("cache.negative_ttl", defaults.Cache.NegativeTTL)
+	v.SetDefault("cache.codec", defaults.Cache.Codec)
+	v.SetDefault("database.url", defaults.Database.URL)
+	v.SetDefault("database.max_conns", defaults.Database.MaxConns)
+	v.SetDefault("database.min_conns", defaults.Database.MinConns)
+	v.SetDefault("database.max_conn_lifetime", defaults.Database.MaxConnLifetime)
+	v.SetDefault("database.max_conn_idle_time", defaults.Database.MaxConnIdleTime)
+	v.SetDefault("database.health_check_period", defaults.Database.HealthCheckPeriod)
+	v.SetDefault("database.replica_urls", defaults.Database.ReplicaURLs)
+	v.SetDefault("database.replica_retry_interval", defaults.Database.ReplicaRetryInterval)
+	v.SetDefault("server.request_timeout", defaults.Server.RequestTimeout)
+	v.SetDefault("server.request_timeout_overrides", defaults.Server.RequestTimeoutOverrides)
+	v.SetDefault("server.max_request_body_size", defaults.Server.MaxRequestBodySize)
+	v.SetDefault("server.max_in_flight", defaults.Server.MaxInFlight)
+	v.SetDefault("server.queue_timeout", defaults.Server.QueueTimeout)
+	v.SetDefault("rate_limit.enabled", defaults.RateLimit.Enabled)
+	v.SetDefault("rate_limit.requests_per_minute", defaults.RateLimit.RequestsPerMinute)
+	v.SetDefault("rate_limit.burst", defaults.RateLimit.Burst)
+	v.SetDefault("rate_limit.trusted_proxies", defaults.RateLimit.TrustedProxies)
+	v.SetDefault("rate_limit.idle_timeout", defaults.RateLimit.IdleTimeout)
+	v.SetDefault("rate_limit.max_entries", defaults.RateLimit.MaxEntries)
+	v.SetDefault("rate_limit.ban_enabled", defaults.RateLimit.BanEnabled)
+	v.SetDefault("rate_limit.ban_threshold", defaults.RateLimit.BanThreshold)
+	v.SetDefault("rate_limit.ban_window", defaults.RateLimit.BanWindow)
+	v.SetDefault("rate_limit.ban_duration", defaults.RateLimit.BanDuration)
+	v.SetDefault("rate_limit.ban_max_duration", defaults.RateLimit.BanMaxDuration)
+	v.SetDefault("rate_limit.classes", defaults.RateLimit.Classes)
+	v.SetDefault("readiness_db_timeout", defaults.ReadinessDBTimeout)
+	v.SetDefault("readiness_redis_timeout", defaults.ReadinessRedisTimeout)
+	v.SetDefault("readiness_degraded_mode", defaults.ReadinessDegradedMode)
+	v.SetDefault("health_score_threshold", defaults.HealthScoreThreshold)
+	v.SetDefault("health_db_latency_budget", defaults.HealthDBLatencyBudget)
+	v.SetDefault("health_redis_latency_budget", defaults.HealthRedisLatencyBudget)
+	v.SetDefault("health_error_rate_budget", defaults.HealthErrorRateBudget)
+	v.SetDefault("health_error_window", defaults.HealthErrorWindow)
+	v.SetDefault("health_max_in_flight", defaults.HealthMaxInFlight)
+	v.SetDefault("health_weight_db", defaults.HealthWeightDB)
+	v.SetDefault("health_weight_redis", defaults.HealthWeightRedis)
+	v.SetDefault("health_weight_error_rate", defaults.HealthWeightErrorRate)
+	v.SetDefault("health_weight_saturation", defaults.HealthWeightSaturation)
+	v.SetDefault("admin_enabled", defaults.AdminEnabled)
+	v.SetDefault("admin_token", defaults.AdminToken)
+	v.SetDefault("admin_api_keys_enabled", defaults.AdminAPIKeysEnabled)
+	v.SetDefault("admin_memory_sample_size", defaults.AdminMemorySampleSize)
+	v.SetDefault("admin_pool_stats_cache_ttl", defaults.AdminPoolStatsCacheTTL)
+	v.SetDefault("admin_allowed_cidrs", defaults.AdminAllowedCIDRs)
+	v.SetDefault("dashboard_enabled", defaults.DashboardEnabled)
+	v.SetDefault("dashboard_recent_allocations", defaults.DashboardRecentAllocations)
+	v.SetDefault("dashboard_tracked_peers", defaults.DashboardTrackedPeers)
+	v.SetDefault("diagnostics_enabled", defaults.DiagnosticsEnabled)
+	v.SetDefault("diagnostics_allowed_cidrs", defaults.DiagnosticsAllowedCIDRs)
+	v.SetDefault("fault_injection_enabled", defaults.FaultInjectionEnabled)
+	v.SetDefault("config_watch", defaults.ConfigWatch)
 
 	// Load config file if exists
 	configPath := v.GetString(flag.CONFIG_FLAG)
@@ -141,17 +976,17 @@ func NewAppConfig() (*AppConfig, error) {
 	}
 
 	// Try to read file (ignore if not found)
-	if err := v.ReadInConfig(); err != nil {
-		// Ignore "not found", but fail on parsing error
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("read config: %w", err)
-		}
+	if err := readConfigFile(v); err != nil {
+		return nil, err
 	}
 
 	var c AppConfig
 	if err := v.Unmarshal(&c); err != nil {
 		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &c, nil
 }
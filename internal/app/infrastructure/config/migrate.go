@@ -0,0 +1,363 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// KeyMigration describes how a configuration key changed between versions
+type KeyMigration struct {
+	OldKey   string
+	NewKey   string // empty when the key was removed without replacement
+	Note     string
+	EnvAlias bool // the environment variable of OldKey still sets NewKey
+}
+
+// keyMigrations lists every renamed or removed configuration key, oldest first.
+// Add an entry here whenever a key in AppConfig is renamed or dropped.
+var keyMigrations = []KeyMigration{
+	{OldKey: "log", NewKey: "log_level", Note: "the --log-level flag was historically bound to \"log\""},
+
+	// Moved into the server section
+	{OldKey: "port", NewKey: "server.port", EnvAlias: true},
+	{OldKey: "log_level", NewKey: "server.log_level", EnvAlias: true},
+	{OldKey: "request_timeout", NewKey: "server.request_timeout", EnvAlias: true},
+	{OldKey: "request_timeout_overrides", NewKey: "server.request_timeout_overrides", EnvAlias: true},
+	{OldKey: "max_request_body_size", NewKey: "server.max_request_body_size", EnvAlias: true},
+
+	// Moved into the database section
+	{OldKey: "database_url", NewKey: "database.url", EnvAlias: true},
+	{OldKey: "db_max_conns", NewKey: "database.max_conns", EnvAlias: true},
+	{OldKey: "db_min_conns", NewKey: "database.min_conns", EnvAlias: true},
+	{OldKey: "db_max_conn_lifetime", NewKey: "database.max_conn_lifetime", EnvAlias: true},
+	{OldKey: "db_max_conn_idle_time", NewKey: "database.max_conn_idle_time", EnvAlias: true},
+	{OldKey: "db_health_check_period", NewKey: "database.health_check_period", EnvAlias: true},
+
+	// Moved into the redis section
+	{OldKey: "redis_url", NewKey: "redis.url", EnvAlias: true},
+	{OldKey: "redis_password", NewKey: "redis.password", EnvAlias: true},
+	{OldKey: "redis_mode", NewKey: "redis.mode", EnvAlias: true},
+	{OldKey: "redis_addrs", NewKey: "redis.addrs", EnvAlias: true},
+	{OldKey: "redis_master_name", NewKey: "redis.master_name", EnvAlias: true},
+	{OldKey: "redis_sentinel_password", NewKey: "redis.sentinel_password", EnvAlias: true},
+	{OldKey: "redis_failover_holdoff", NewKey: "redis.failover_holdoff", EnvAlias: true},
+	{OldKey: "redis_max_retries", NewKey: "redis.max_retries", EnvAlias: true},
+	{OldKey: "redis_pool_size", NewKey: "redis.pool_size", EnvAlias: true},
+	{OldKey: "redis_min_idle_conns", NewKey: "redis.min_idle_conns", EnvAlias: true},
+	{OldKey: "redis_dial_timeout", NewKey: "redis.dial_timeout", EnvAlias: true},
+	{OldKey: "redis_read_timeout", NewKey: "redis.read_timeout", EnvAlias: true},
+	{OldKey: "redis_write_timeout", NewKey: "redis.write_timeout", EnvAlias: true},
+
+	// Moved into the cache section
+	{OldKey: "cache_enabled", NewKey: "cache.enabled", EnvAlias: true},
+	{OldKey: "cache_default_ttl", NewKey: "cache.default_ttl", EnvAlias: true},
+	{OldKey: "cache_negative_ttl", NewKey: "cache.negative_ttl", EnvAlias: true},
+
+	// Moved into the rate_limit section
+	{OldKey: "rate_limit_enabled", NewKey: "rate_limit.enabled", EnvAlias: true},
+	{OldKey: "rate_limit_requests_per_minute", NewKey: "rate_limit.requests_per_minute", EnvAlias: true},
+	{OldKey: "rate_limit_burst", NewKey: "rate_limit.burst", EnvAlias: true},
+	{OldKey: "rate_limit_trusted_proxies", NewKey: "rate_limit.trusted_proxies", EnvAlias: true},
+	{OldKey: "rate_limit_idle_timeout", NewKey: "rate_limit.idle_timeout", EnvAlias: true},
+	{OldKey: "rate_limit_max_entries", NewKey: "rate_limit.max_entries", EnvAlias: true},
+
+	// Moved into the lease section
+	{OldKey: "lease_ttl", NewKey: "lease.ttl", EnvAlias: true},
+	{OldKey: "max_lease_retries", NewKey: "lease.max_retries", EnvAlias: true},
+	{OldKey: "max_leases_per_peer", NewKey: "lease.max_per_peer", EnvAlias: true},
+	{OldKey: "conflict_quarantine", NewKey: "lease.conflict_quarantine", EnvAlias: true},
+	{OldKey: "renewal_window", NewKey: "lease.renewal_window", EnvAlias: true},
+	{OldKey: "lease_retry_delay", NewKey: "lease.retry_delay", EnvAlias: true},
+	{OldKey: "lease_retry_max_delay", NewKey: "lease.retry_max_delay", EnvAlias: true},
+	{OldKey: "allocation_strategy", NewKey: "lease.allocation_strategy", EnvAlias: true},
+	{OldKey: "allocation_random_probes", NewKey: "lease.allocation_random_probes", EnvAlias: true},
+	{OldKey: "allocation_chunk_size", NewKey: "lease.allocation_chunk_size", EnvAlias: true},
+	{OldKey: "affinity_probe_limit", NewKey: "lease.affinity_probe_limit", EnvAlias: true},
+	{OldKey: "batch_max_operations", NewKey: "lease.batch_max_operations", EnvAlias: true},
+	{OldKey: "idempotency_window", NewKey: "lease.idempotency_window", EnvAlias: true},
+
+	// Moved into the nonce section
+	{OldKey: "nonce_ttl", NewKey: "nonce.ttl", EnvAlias: true},
+	{OldKey: "nonce_cleaner_interval", NewKey: "nonce.cleaner_interval", EnvAlias: true},
+	{OldKey: "nonce_cleaner_jitter", NewKey: "nonce.cleaner_jitter", EnvAlias: true},
+	{OldKey: "nonce_cleaner_batch_size", NewKey: "nonce.cleaner_batch_size", EnvAlias: true},
+	{OldKey: "nonce_cleaner_batch_pause", NewKey: "nonce.cleaner_batch_pause", EnvAlias: true},
+	{OldKey: "nonce_max_outstanding", NewKey: "nonce.max_outstanding", EnvAlias: true},
+	{OldKey: "nonce_reuse_at_cap", NewKey: "nonce.reuse_at_cap", EnvAlias: true},
+
+	// Moved into the security section
+	{OldKey: "auth_timestamp_required", NewKey: "security.timestamp_required", EnvAlias: true},
+	{OldKey: "auth_timestamp_window", NewKey: "security.timestamp_window", EnvAlias: true},
+	{OldKey: "auth_signature_cache_size", NewKey: "security.signature_cache_size", EnvAlias: true},
+	{OldKey: "auth_signature_cache_ttl", NewKey: "security.signature_cache_ttl", EnvAlias: true},
+	{OldKey: "lookup_auth_required", NewKey: "security.lookup_auth_required", EnvAlias: true},
+	{OldKey: "access_allow_list_required", NewKey: "security.access_allow_list_required", EnvAlias: true},
+	{OldKey: "access_cache_ttl", NewKey: "security.access_cache_ttl", EnvAlias: true},
+	{OldKey: "identity_scheme", NewKey: "security.identity_scheme", EnvAlias: true},
+	{OldKey: "server_key_path", NewKey: "security.server_key_path", EnvAlias: true},
+}
+
+// MigrationReport summarizes the result of migrating a configuration
+type MigrationReport struct {
+	Renamed     map[string]string // old key -> new key
+	Removed     []string          // keys dropped without replacement
+	Unknown     []string          // keys that are neither current nor known legacy keys
+	Conflicts   []string          // legacy keys ignored because the new key was also set
+	EnvWarnings []string          // environment variables that use legacy or unknown names
+}
+
+// HasChanges reports whether the migration rewrote or dropped any key
+func (r *MigrationReport) HasChanges() bool {
+	return len(r.Renamed) > 0 || len(r.Removed) > 0
+}
+
+// KnownKeys returns the set of configuration keys understood by AppConfig
+func KnownKeys() map[string]struct{} {
+	keys := make(map[string]struct{})
+	collectKeys(reflect.TypeOf(AppConfig{}), "", keys)
+	return keys
+}
+
+func collectKeys(t reflect.Type, prefix string, keys map[string]struct{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if field.Type.Kind() == reflect.Struct {
+			collectKeys(field.Type, name, keys)
+			continue
+		}
+		keys[name] = struct{}{}
+	}
+}
+
+// MigrateSettings maps legacy keys in settings onto the current schema.
+// settings must be keyed by flattened, lowercase viper keys.
+func MigrateSettings(settings map[string]interface{}) (map[string]interface{}, *MigrationReport) {
+	known := KnownKeys()
+	migrations := legacyKeys()
+
+	report := &MigrationReport{Renamed: make(map[string]string)}
+	migrated := make(map[string]interface{}, len(settings))
+
+	// Current keys always win over legacy ones
+	for key, value := range settings {
+		if _, ok := known[key]; ok {
+			migrated[key] = value
+		}
+	}
+
+	// Keys renamed fewer times are newer and win over older names of the same setting
+	keys := sortedKeys(settings)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return renameCount(keys[i], migrations) < renameCount(keys[j], migrations)
+	})
+
+	for _, key := range keys {
+		if _, ok := known[key]; ok {
+			continue
+		}
+
+		m, isLegacy := resolveMigration(key, migrations)
+		switch {
+		case !isLegacy:
+			report.Unknown = append(report.Unknown, key)
+		case m.NewKey == "":
+			report.Removed = append(report.Removed, key)
+		default:
+			if _, exists := migrated[m.NewKey]; exists {
+				report.Conflicts = append(report.Conflicts, key)
+				continue
+			}
+			migrated[m.NewKey] = settings[key]
+			report.Renamed[key] = m.NewKey
+		}
+	}
+
+	return migrated, report
+}
+
+// resolveMigration follows chained renames (a -> b -> c) to the final key
+func resolveMigration(key string, migrations map[string]KeyMigration) (KeyMigration, bool) {
+	m, ok := migrations[key]
+	if !ok {
+		return KeyMigration{}, false
+	}
+
+	seen := map[string]bool{key: true}
+	for m.NewKey != "" {
+		next, ok := migrations[m.NewKey]
+		if !ok || seen[m.NewKey] {
+			break
+		}
+		seen[m.NewKey] = true
+		m = KeyMigration{OldKey: key, NewKey: next.NewKey, Note: next.Note}
+	}
+	return m, true
+}
+
+// renameCount is the number of renames between key and the current schema
+func renameCount(key string, migrations map[string]KeyMigration) int {
+	count := 0
+	seen := map[string]bool{}
+	for m, ok := migrations[key]; ok && !seen[key]; m, ok = migrations[key] {
+		seen[key] = true
+		count++
+		key = m.NewKey
+	}
+	return count
+}
+
+// CheckEnvironment reports DHCP2P_* environment variables that use legacy or unknown names
+func CheckEnvironment(environ []string) []string {
+	known := KnownKeys()
+	knownEnvNames := make(map[string]bool, len(known))
+	for key := range known {
+		knownEnvNames[envName(key)] = true
+	}
+	migrations := legacyKeys()
+
+	var warnings []string
+	prefix := ENV_PREFIX + "_"
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimPrefix(name, prefix))
+		if _, ok := known[key]; ok {
+			continue
+		}
+		// Nested keys are exposed through the environment with underscores
+		if knownEnvNames[name] {
+			continue
+		}
+		if m, ok := migrations[key]; ok && m.EnvAlias {
+			continue
+		}
+
+		m, isLegacy := resolveMigration(key, migrations)
+		switch {
+		case !isLegacy:
+			warnings = append(warnings, fmt.Sprintf("%s: unknown configuration variable", name))
+		case m.NewKey == "":
+			warnings = append(warnings, fmt.Sprintf("%s: no longer supported and will be ignored", name))
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: renamed to %s", name, envName(m.NewKey)))
+		}
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
+
+// MigrateConfigFile reads the config file at inPath, migrates it to the current schema
+// and returns a viper instance holding the migrated settings, ready to be written.
+func MigrateConfigFile(inPath string) (*viper.Viper, *MigrationReport, error) {
+	out, report, err := migrateFile(inPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.EnvWarnings = CheckEnvironment(os.Environ())
+
+	return out, report, nil
+}
+
+func migrateFile(inPath string) (*viper.Viper, *MigrationReport, error) {
+	in := viper.New()
+	in.SetConfigFile(inPath)
+	if err := in.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("read config: %w", err)
+	}
+
+	settings := make(map[string]interface{})
+	flattenSettings("", in.AllSettings(), KnownKeys(), legacyKeys(), settings)
+
+	migrated, report := MigrateSettings(settings)
+
+	out := viper.New()
+	out.SetConfigType("yaml")
+	for key, value := range migrated {
+		out.Set(key, value)
+	}
+
+	return out, report, nil
+}
+
+// flattenSettings keys nested settings by their dotted path. Values of known and legacy
+// keys are kept whole, so maps such as request_timeout_overrides stay one setting.
+func flattenSettings(prefix string, nested map[string]interface{}, known map[string]struct{}, migrations map[string]KeyMigration, flat map[string]interface{}) {
+	for key, value := range nested {
+		key = prefix + key
+		_, isKnown := known[key]
+		_, isLegacy := migrations[key]
+		if m, ok := value.(map[string]interface{}); ok && !isKnown && !isLegacy {
+			flattenSettings(key+".", m, known, migrations, flat)
+			continue
+		}
+		flat[key] = value
+	}
+}
+
+// legacyKeys indexes keyMigrations by their old key
+func legacyKeys() map[string]KeyMigration {
+	migrations := make(map[string]KeyMigration, len(keyMigrations))
+	for _, m := range keyMigrations {
+		migrations[m.OldKey] = m
+	}
+	return migrations
+}
+
+// readConfigFile reads the config file into v and maps keys of older schemas onto the
+// current one, so files written for an earlier version keep working. A missing config
+// file is not an error.
+func readConfigFile(v *viper.Viper) error {
+	if err := v.ReadInConfig(); err != nil {
+		// Ignore "not found", but fail on parsing error
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			return nil
+		}
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	migrated, _, err := migrateFile(v.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
+	return v.MergeConfigMap(migrated.AllSettings())
+}
+
+// bindLegacyEnv keeps the environment variables of keys that moved into a section
+// working, e.g. DHCP2P_DB_MAX_CONNS still sets database.max_conns
+func bindLegacyEnv(v *viper.Viper) {
+	for _, m := range keyMigrations {
+		if m.EnvAlias {
+			v.BindEnv(m.NewKey, envName(m.NewKey), envName(m.OldKey))
+		}
+	}
+}
+
+func envName(key string) string {
+	return ENV_PREFIX + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces secrets in Settings
+const RedactedValue = "REDACTED"
+
+// secretKeys lists settings whose values are replaced by RedactedValue. Fields of
+// list entries are keyed without an index, e.g. lease_webhooks.secret.
+var secretKeys = map[string]bool{
+	"admin_token":                   true,
+	"redis.password":                true,
+	"redis.sentinel_password":       true,
+	"expiry_webhook_secret":         true,
+	"lease_webhooks.secret":         true,
+	"dns_tsig_secret":               true,
+	"tenants.api_keys":              true,
+	"security.field_encryption_key": true,
+	"lease_journal_s3_secret_key":   true,
+}
+
+// credentialURLKeys lists settings holding connection strings that may embed a password
+var credentialURLKeys = map[string]bool{
+	"database.url":          true,
+	"database.replica_urls": true,
+	"redis.url":             true,
+	"nats_url":              true,
+	"kafka_rest_url":        true,
+}
+
+var dsnPasswordPattern = regexp.MustCompile(`(password=)('[^']*'|\S*)`)
+
+// Settings returns the configuration keyed like the config file, with secrets redacted
+func (c *AppConfig) Settings() map[string]interface{} {
+	return structSettings(reflect.ValueOf(*c), "")
+}
+
+func structSettings(v reflect.Value, prefix string) map[string]interface{} {
+	settings := make(map[string]interface{})
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		settings[name] = settingValue(v.Field(i), prefix+name)
+	}
+	return settings
+}
+
+func settingValue(v reflect.Value, key string) interface{} {
+	switch v.Kind() {
+	case reflect.Struct:
+		return structSettings(v, key+".")
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			items := make([]interface{}, v.Len())
+			for i := range items {
+				items[i] = structSettings(v.Index(i), key+".")
+			}
+			return items
+		}
+	}
+
+	if v.IsZero() {
+		return v.Interface()
+	}
+	switch {
+	case secretKeys[key] && v.Kind() == reflect.Slice:
+		redacted := make([]string, v.Len())
+		for i := range redacted {
+			redacted[i] = RedactedValue
+		}
+		return redacted
+	case secretKeys[key]:
+		return RedactedValue
+	case credentialURLKeys[key] && v.Kind() == reflect.Slice:
+		redacted := make([]string, v.Len())
+		for i := range redacted {
+			redacted[i] = redactURL(v.Index(i).String())
+		}
+		return redacted
+	case credentialURLKeys[key]:
+		return redactURL(v.String())
+	}
+	return v.Interface()
+}
+
+// redactURL hides the password of a URL or of a key=value connection string
+func redactURL(raw string) string {
+	if !strings.Contains(raw, "://") {
+		return dsnPasswordPattern.ReplaceAllString(raw, "${1}"+RedactedValue)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return RedactedValue
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), RedactedValue)
+	}
+	return u.String()
+}
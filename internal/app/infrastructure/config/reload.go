@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+)
+
+// ReloadableKeys are the settings a reload applies while the server runs. Changes to any
+// other setting are reported and only take effect after a restart.
+var ReloadableKeys = []string{
+	"server.log_level",
+	"log.levels",
+	"log.payloads_enabled",
+	"log.payloads_per_second",
+	"log.payload_max_bytes",
+	"lease.ttl",
+	"rate_limit.enabled",
+	"rate_limit.requests_per_minute",
+	"rate_limit.burst",
+	"rate_limit.trusted_proxies",
+	"rate_limit.classes",
+	"peer_policies",
+	"security.server_key_path",
+	"security.server_verification_key_paths",
+}
+
+// Reloadable is implemented by components that pick up reloaded settings. ApplyConfig
+// receives the whole configuration, of which only ReloadableKeys may have changed.
+type Reloadable interface {
+	ApplyConfig(cfg *AppConfig)
+}
+
+// ReloadTarget registers a component that is already provided as a Reloadable, so a
+// reload reaches it
+func ReloadTarget[T Reloadable]() fx.Option {
+	return fx.Provide(
+		fx.Annotate(
+			func(target T) Reloadable { return target },
+			fx.ResultTags(`group:"reloadable"`),
+		),
+	)
+}
+
+// ReloadResult is the outcome of reading the configuration again
+type ReloadResult struct {
+	Config  *AppConfig // current configuration with the reloadable settings replaced
+	Applied []string   // reloadable settings that changed
+	Ignored []string   // other settings that changed and need a restart
+}
+
+// Reload reads the configuration file and environment again, in the same way as
+// NewAppConfig, and merges the reloadable settings into current. Nothing is applied when
+// the merged configuration does not validate.
+func Reload(current *AppConfig) (*ReloadResult, error) {
+	v := viper.GetViper()
+	if err := readConfigFile(v); err != nil {
+		return nil, err
+	}
+
+	var next AppConfig
+	if err := v.Unmarshal(&next); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	merged := *current
+	result := &ReloadResult{Config: &merged}
+	mergeReloadable("", reflect.ValueOf(&merged).Elem(), reflect.ValueOf(next), result)
+
+	if err := merged.Validate(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// mergeReloadable copies the reloadable settings of next into merged and records every
+// changed setting, descending into sections
+func mergeReloadable(prefix string, merged, next reflect.Value, result *ReloadResult) {
+	for i := 0; i < merged.NumField(); i++ {
+		key := prefix + merged.Type().Field(i).Tag.Get("mapstructure")
+		if merged.Field(i).Kind() == reflect.Struct {
+			mergeReloadable(key+".", merged.Field(i), next.Field(i), result)
+			continue
+		}
+		if reflect.DeepEqual(merged.Field(i).Interface(), next.Field(i).Interface()) {
+			continue
+		}
+		if slices.Contains(ReloadableKeys, key) {
+			merged.Field(i).Set(next.Field(i))
+			result.Applied = append(result.Applied, key)
+		} else {
+			result.Ignored = append(result.Ignored, key)
+		}
+	}
+}
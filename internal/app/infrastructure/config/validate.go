@@ -0,0 +1,716 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/fieldcrypt"
+	"go.uber.org/zap/zapcore"
+)
+
+// ValidationError lists every setting that failed validation, so all of them can be
+// fixed at once
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Violations), strings.Join(e.Violations, "\n  - "))
+}
+
+// validator collects violations in the order the settings are checked
+type validator struct {
+	violations []string
+}
+
+func (v *validator) failf(format string, args ...any) {
+	v.violations = append(v.violations, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) positive(key string, value int) {
+	if value <= 0 {
+		v.failf("%s must be greater than 0, got %d", key, value)
+	}
+}
+
+func (v *validator) nonNegative(key string, value int) {
+	if value < 0 {
+		v.failf("%s must not be negative, got %d", key, value)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.failf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value)
+	}
+}
+
+func (v *validator) httpURL(key, value string) {
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.failf("%s must be an absolute http or https URL, got %q", key, value)
+	}
+}
+
+// networks checks that every entry is an IP address or a CIDR block
+func (v *validator) networks(key string, entries []string) {
+	for i, entry := range entries {
+		if net.ParseIP(entry) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(entry); err != nil {
+			v.failf("%s[%d]: %q is neither an IP address nor a CIDR block", key, i, entry)
+		}
+	}
+}
+
+// isPostgresURL accepts postgres:// URLs and key=value connection strings
+func isPostgresURL(value string) bool {
+	if !strings.Contains(value, "://") {
+		return true
+	}
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") && u.Host != ""
+}
+
+// Validate checks ranges, formats and the consistency between settings. Settings of
+// disabled features are only checked for their format, not for their range.
+func (c *AppConfig) Validate() error {
+	v := &validator{}
+
+	// Server
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		v.failf("server.port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if _, err := zapcore.ParseLevel(c.Server.LogLevel); err != nil {
+		v.failf("server.log_level must be one of debug, info, warn, error, got %q", c.Server.LogLevel)
+	}
+
+	// Logging
+	v.oneOf("log.encoding", c.Log.Encoding, "console", "json")
+	for _, name := range slices.Sorted(maps.Keys(c.Log.Levels)) {
+		if _, err := zapcore.ParseLevel(c.Log.Levels[name]); err != nil {
+			v.failf("log.levels.%s must be one of debug, info, warn, error, got %q", name, c.Log.Levels[name])
+		}
+	}
+	v.nonNegative("log.sampling_initial", c.Log.SamplingInitial)
+	if c.Log.SamplingInitial > 0 {
+		v.positive("log.sampling_thereafter", c.Log.SamplingThereafter)
+	}
+	if c.Log.File != "" {
+		v.positive("log.file_max_size", c.Log.FileMaxSize)
+		v.nonNegative("log.file_max_backups", c.Log.FileMaxBackups)
+		v.nonNegative("log.file_max_age", c.Log.FileMaxAge)
+	}
+	if c.Log.PayloadsEnabled {
+		v.positive("log.payloads_per_second", c.Log.PayloadsPerSecond)
+		v.positive("log.payload_max_bytes", c.Log.PayloadMaxBytes)
+	}
+
+	// Storage
+	v.oneOf("storage_backend", c.StorageBackend, StorageBackendPostgres, StorageBackendEmbedded, StorageBackendMemory)
+	if c.StorageBackend == StorageBackendEmbedded && c.StoragePath == "" {
+		v.failf("storage_path must be set for the %s storage backend", StorageBackendEmbedded)
+	}
+	if c.DegradedRenewalsEnabled {
+		if c.StorageBackend != StorageBackendPostgres && c.StorageBackend != "" {
+			v.failf("degraded renewals are only supported by the %s storage backend", StorageBackendPostgres)
+		}
+		if c.DegradedJournalPath == "" {
+			v.failf("degraded_journal_path must be set when degraded renewals are enabled")
+		}
+		v.positive("degraded_replay_interval", c.DegradedReplayInterval)
+	}
+	if c.Database.URL != "" && !isPostgresURL(c.Database.URL) {
+		v.failf("database.url must be a postgres:// URL or a key=value connection string")
+	}
+	for i, replicaURL := range c.Database.ReplicaURLs {
+		if replicaURL == "" || !isPostgresURL(replicaURL) {
+			v.failf("database.replica_urls[%d] must be a postgres:// URL or a key=value connection string", i)
+		}
+	}
+	if len(c.Database.ReplicaURLs) > 0 {
+		if c.Database.URL == "" {
+			v.failf("database.replica_urls requires database.url, writes always go to the primary")
+		}
+		v.positive("database.replica_retry_interval", c.Database.ReplicaRetryInterval)
+	}
+	c.validateRedis(v)
+
+	// Pool and tenants
+	c.validatePools(v)
+
+	// Nonces
+	v.positive("nonce.ttl", c.Nonce.TTL)
+	v.positive("nonce.cleaner_interval", c.Nonce.CleanerInterval)
+	v.nonNegative("nonce.cleaner_jitter", c.Nonce.CleanerJitter)
+	v.nonNegative("nonce.cleaner_batch_size", c.Nonce.CleanerBatchSize)
+	v.nonNegative("nonce.cleaner_batch_pause", c.Nonce.CleanerBatchPause)
+	v.nonNegative("nonce.max_outstanding", c.Nonce.MaxOutstanding)
+
+	// Leases
+	v.positive("lease.ttl", c.Lease.TTL)
+	v.nonNegative("lease.max_retries", c.Lease.MaxRetries)
+	v.nonNegative("lease.max_per_peer", c.Lease.MaxPerPeer)
+	v.nonNegative("lease.conflict_quarantine", c.Lease.ConflictQuarantine)
+	v.nonNegative("lease.release_grace", c.Lease.ReleaseGrace)
+	v.nonNegative("lease.renewal_window", c.Lease.RenewalWindow)
+	v.nonNegative("lease.renew_hint_jitter", c.Lease.RenewHintJitter)
+	if c.Lease.RenewHintPercent != 0 {
+		// The jittered hint must stay after now and before the expiry
+		if c.Lease.RenewHintPercent-c.Lease.RenewHintJitter <= 0 || c.Lease.RenewHintPercent+c.Lease.RenewHintJitter >= 100 {
+			v.failf("lease.renew_hint_percent (%d) give or take lease.renew_hint_jitter (%d) must stay between 0 and 100", c.Lease.RenewHintPercent, c.Lease.RenewHintJitter)
+		}
+	}
+	v.positive("lease.wait_max_timeout", c.Lease.WaitMaxTimeout)
+	v.nonNegative("lease.write_behind_interval", c.Lease.WriteBehindInterval)
+	if c.Lease.WriteBehindInterval > 0 {
+		if c.StorageBackend != StorageBackendPostgres && c.StorageBackend != "" {
+			v.failf("lease.write_behind_interval is only supported by the %s storage backend", StorageBackendPostgres)
+		}
+		v.positive("lease.write_behind_batch_size", c.Lease.WriteBehindBatchSize)
+	}
+	v.nonNegative("lease.retry_delay", c.Lease.RetryDelay)
+	v.nonNegative("lease.retry_max_delay", c.Lease.RetryMaxDelay)
+	if c.Lease.RetryMaxDelay < c.Lease.RetryDelay {
+		v.failf("lease.retry_max_delay (%d) must not be less than lease.retry_delay (%d)", c.Lease.RetryMaxDelay, c.Lease.RetryDelay)
+	}
+	if c.Lease.AllocationStrategy != "" {
+		v.oneOf("lease.allocation_strategy", c.Lease.AllocationStrategy, "lru", "sequential", "random")
+	}
+	if c.Lease.AllocationStrategy == "random" {
+		v.positive("lease.allocation_random_probes", c.Lease.AllocationRandomProbes)
+	}
+	v.nonNegative("lease.allocation_chunk_size", c.Lease.AllocationChunkSize)
+	v.positive("lease.affinity_probe_limit", c.Lease.AffinityProbeLimit)
+	v.positive("lease.batch_max_operations", c.Lease.BatchMaxOperations)
+	v.nonNegative("lease.idempotency_window", c.Lease.IdempotencyWindow)
+	v.positive("lease.revocation_batch_size", c.Lease.RevocationBatchSize)
+	v.nonNegative("lease.revocation_wait", c.Lease.RevocationWait)
+
+	// Authentication and access control
+	v.positive("security.timestamp_window", c.Security.TimestampWindow)
+	v.nonNegative("security.signature_cache_size", c.Security.SignatureCacheSize)
+	if c.Security.SignatureCacheSize > 0 {
+		v.positive("security.signature_cache_ttl", c.Security.SignatureCacheTTL)
+	}
+	v.nonNegative("security.access_cache_ttl", c.Security.AccessCacheTTL)
+	v.oneOf("security.identity_scheme", c.Security.IdentityScheme, IdentitySchemePeerID, IdentitySchemeEthereum)
+	if c.Security.FieldEncryptionKey != "" || c.Security.FieldEncryptionKeyPath != "" {
+		if c.Security.FieldEncryptionKey != "" && c.Security.FieldEncryptionKeyPath != "" {
+			v.failf("security.field_encryption_key and security.field_encryption_key_path are mutually exclusive")
+		}
+		if c.StorageBackend != StorageBackendPostgres && c.StorageBackend != "" {
+			v.failf("field encryption is only supported by the %s storage backend", StorageBackendPostgres)
+		}
+	}
+	if c.Security.FieldEncryptionKey != "" {
+		if _, err := fieldcrypt.ParseKey(c.Security.FieldEncryptionKey); err != nil {
+			v.failf("security.field_encryption_key: %v", err)
+		}
+	}
+	for i, path := range c.Security.ServerVerificationKeyPaths {
+		if path == "" || path == c.Security.ServerKeyPath {
+			v.failf("security.server_verification_key_paths[%d]: must be a key file other than security.server_key_path", i)
+		}
+	}
+	for i, pattern := range c.Security.HeaderPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			v.failf("security.header_patterns[%d]: %v", i, err)
+		}
+	}
+	v.nonNegative("security.max_header_length", c.Security.MaxHeaderLength)
+	v.nonNegative("security.max_header_name_length", c.Security.MaxHeaderNameLength)
+	v.nonNegative("security.max_url_length", c.Security.MaxURLLength)
+	for i, gateway := range c.DelegationGateways {
+		if gateway.PeerID == "" {
+			v.failf("delegation_gateways[%d]: peer_id must be set", i)
+		}
+		v.nonNegative(fmt.Sprintf("delegation_gateways[%d]: max_leases", i), gateway.MaxLeases)
+	}
+
+	// P2P
+	v.positive("p2p_stream_timeout", c.P2PStreamTimeout)
+	v.positive("p2p_max_message_bytes", c.P2PMaxMessageBytes)
+
+	// Jobs
+	v.positive("read_model_refresh_interval", c.ReadModelRefreshInterval)
+	if c.LeaderElectionEnabled {
+		v.positive("leader_election_interval", c.LeaderElectionInterval)
+	}
+	if c.HAEnabled {
+		if c.StorageBackend != StorageBackendPostgres && c.StorageBackend != "" {
+			v.failf("active/standby mode is only supported by the %s storage backend", StorageBackendPostgres)
+		}
+		v.positive("ha_check_interval", c.HACheckInterval)
+		// Renewals buffered by the former active instance would be lost on takeover
+		if c.Lease.WriteBehindInterval > 0 {
+			v.failf("lease.write_behind_interval must be 0 in active/standby mode")
+		}
+		// A read-only instance taking over would fence off the instance changing leases
+		if c.ReadOnly {
+			v.failf("read_only and ha_enabled cannot both be set")
+		}
+	}
+	c.validatePeerPolicies(v)
+	c.validateReclamation(v)
+	c.validateNotifications(v)
+	c.validateDNS(v)
+	c.validateEventBus(v)
+	c.validateLeaseJournal(v)
+	if c.DiscoveryEnabled && len(c.DiscoveryInstance) > 63 {
+		v.failf("discovery_instance must not be longer than 63 bytes, got %d", len(c.DiscoveryInstance))
+	}
+
+	// Cache
+	if c.Cache.Enabled {
+		v.positive("cache.default_ttl", c.Cache.DefaultTTL)
+	}
+	v.nonNegative("cache.negative_ttl", c.Cache.NegativeTTL)
+	v.oneOf("cache.codec", c.Cache.Codec, "json", "cbor")
+
+	// PostgreSQL pool
+	v.positive("database.max_conns", c.Database.MaxConns)
+	v.nonNegative("database.min_conns", c.Database.MinConns)
+	if c.Database.MinConns > c.Database.MaxConns {
+		v.failf("database.min_conns (%d) must not exceed database.max_conns (%d)", c.Database.MinConns, c.Database.MaxConns)
+	}
+	v.positive("database.max_conn_lifetime", c.Database.MaxConnLifetime)
+	v.positive("database.max_conn_idle_time", c.Database.MaxConnIdleTime)
+	v.positive("database.health_check_period", c.Database.HealthCheckPeriod)
+
+	// HTTP requests
+	v.nonNegative("server.request_timeout", c.Server.RequestTimeout)
+	for _, pattern := range slices.Sorted(maps.Keys(c.Server.RequestTimeoutOverrides)) {
+		if !strings.HasPrefix(pattern, "/") {
+			v.failf("server.request_timeout_overrides: route pattern %q must start with /", pattern)
+		}
+		v.positive(fmt.Sprintf("server.request_timeout_overrides[%s]", pattern), c.Server.RequestTimeoutOverrides[pattern])
+	}
+	if c.Server.MaxRequestBodySize < 0 {
+		v.failf("server.max_request_body_size must not be negative, got %d", c.Server.MaxRequestBodySize)
+	}
+	v.nonNegative("server.max_in_flight", c.Server.MaxInFlight)
+	v.nonNegative("server.queue_timeout", c.Server.QueueTimeout)
+
+	// Rate limiting
+	if c.RateLimit.Enabled {
+		v.positive("rate_limit.requests_per_minute", c.RateLimit.RequestsPerMinute)
+		v.positive("rate_limit.burst", c.RateLimit.Burst)
+		if c.RateLimit.Burst > c.RateLimit.RequestsPerMinute {
+			v.failf("rate_limit.burst (%d) must not exceed rate_limit.requests_per_minute (%d)", c.RateLimit.Burst, c.RateLimit.RequestsPerMinute)
+		}
+		v.positive("rate_limit.idle_timeout", c.RateLimit.IdleTimeout)
+		v.positive("rate_limit.max_entries", c.RateLimit.MaxEntries)
+		if c.RateLimit.BanEnabled {
+			v.positive("rate_limit.ban_threshold", c.RateLimit.BanThreshold)
+			v.positive("rate_limit.ban_window", c.RateLimit.BanWindow)
+			v.positive("rate_limit.ban_duration", c.RateLimit.BanDuration)
+			if c.RateLimit.BanMaxDuration < c.RateLimit.BanDuration {
+				v.failf("rate_limit.ban_max_duration (%d) must not be less than rate_limit.ban_duration (%d)", c.RateLimit.BanMaxDuration, c.RateLimit.BanDuration)
+			}
+		}
+		c.validateRateLimitClasses(v)
+	}
+	v.networks("rate_limit.trusted_proxies", c.RateLimit.TrustedProxies)
+
+	// Readiness and health
+	v.positive("readiness_db_timeout", c.ReadinessDBTimeout)
+	v.positive("readiness_redis_timeout", c.ReadinessRedisTimeout)
+	c.validateHealthScore(v)
+
+	// Admin API
+	if c.AdminEnabled && c.AdminToken == "" && !c.AdminAPIKeysEnabled {
+		v.failf("admin_token must be set when admin_enabled is true, otherwise every admin request is rejected")
+	}
+	if c.DashboardEnabled && !c.AdminEnabled {
+		v.failf("dashboard_enabled requires admin_enabled")
+	}
+	if c.DiagnosticsEnabled && !c.AdminEnabled {
+		v.failf("diagnostics_enabled requires admin_enabled")
+	}
+	if c.FaultInjectionEnabled && !c.AdminEnabled {
+		v.failf("fault_injection_enabled requires admin_enabled")
+	}
+	if c.FaultInjectionEnabled && c.StorageBackend != StorageBackendPostgres && c.StorageBackend != "" {
+		v.failf("fault injection is only supported by the %s storage backend", StorageBackendPostgres)
+	}
+	v.positive("admin_memory_sample_size", c.AdminMemorySampleSize)
+	v.nonNegative("admin_pool_stats_cache_ttl", c.AdminPoolStatsCacheTTL)
+	v.networks("admin_allowed_cidrs", c.AdminAllowedCIDRs)
+	v.networks("diagnostics_allowed_cidrs", c.DiagnosticsAllowedCIDRs)
+	if c.DashboardEnabled {
+		v.positive("dashboard_recent_allocations", c.DashboardRecentAllocations)
+		v.positive("dashboard_tracked_peers", c.DashboardTrackedPeers)
+	}
+
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+	return nil
+}
+
+func (c *AppConfig) validateRedis(v *validator) {
+	v.oneOf("redis.mode", c.Redis.Mode, RedisModeStandalone, RedisModeSentinel, RedisModeCluster)
+	switch c.Redis.Mode {
+	case RedisModeStandalone:
+		if c.Redis.URL == "" {
+			break
+		}
+		if strings.Contains(c.Redis.URL, "://") {
+			if u, err := url.Parse(c.Redis.URL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+				v.failf("redis.url must be host:port or a redis:// or rediss:// URL")
+			}
+		} else if _, _, err := net.SplitHostPort(c.Redis.URL); err != nil {
+			v.failf("redis.url must be host:port or a redis:// or rediss:// URL, got %q", c.Redis.URL)
+		}
+	case RedisModeSentinel:
+		if len(c.Redis.Addrs) == 0 || c.Redis.MasterName == "" {
+			v.failf("redis.addrs and redis.master_name must be set in %s mode", RedisModeSentinel)
+		}
+	case RedisModeCluster:
+		if len(c.Redis.Addrs) == 0 {
+			v.failf("redis.addrs must be set in %s mode", RedisModeCluster)
+		}
+	}
+	for i, addr := range c.Redis.Addrs {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			v.failf("redis.addrs[%d] must be host:port, got %q", i, addr)
+		}
+	}
+
+	v.nonNegative("redis.failover_holdoff", c.Redis.FailoverHoldoff)
+	if c.Redis.MaxRetries < -1 {
+		v.failf("redis.max_retries must be -1 (no retries) or more, got %d", c.Redis.MaxRetries)
+	}
+	v.positive("redis.pool_size", c.Redis.PoolSize)
+	v.nonNegative("redis.min_idle_conns", c.Redis.MinIdleConns)
+	if c.Redis.MinIdleConns > c.Redis.PoolSize {
+		v.failf("redis.min_idle_conns (%d) must not exceed redis.pool_size (%d)", c.Redis.MinIdleConns, c.Redis.PoolSize)
+	}
+	v.positive("redis.dial_timeout", c.Redis.DialTimeout)
+	v.positive("redis.read_timeout", c.Redis.ReadTimeout)
+	v.positive("redis.write_timeout", c.Redis.WriteTimeout)
+}
+
+// validatePools checks that every pool is a range of IPv4 addresses in integer form.
+// Overlaps between tenant pools are reported when the tenants are loaded.
+func (c *AppConfig) validatePools(v *validator) {
+	checkRange := func(prefix string, minTokenID, maxTokenID int64) {
+		if minTokenID < 0 || maxTokenID > 1<<32-1 {
+			v.failf("%spool_min_token_id and pool_max_token_id must be between 0 and %d", prefix, int64(1<<32-1))
+		}
+		if minTokenID > maxTokenID {
+			v.failf("%spool_min_token_id (%d) must not exceed pool_max_token_id (%d)", prefix, minTokenID, maxTokenID)
+		}
+	}
+
+	checkRange("", c.PoolMinTokenID, c.PoolMaxTokenID)
+	validatePoolExclusions(v, "", c.PoolMinTokenID, c.PoolMaxTokenID, c.PoolExclusions)
+	validatePoolZones(v, "", c.PoolMinTokenID, c.PoolMaxTokenID, c.PoolZones)
+	for i, tenant := range c.Tenants {
+		prefix := fmt.Sprintf("tenants[%d]: ", i)
+		if tenant.ID == "" {
+			v.failf("%sid must be set", prefix)
+		}
+		if len(tenant.APIKeys) == 0 {
+			v.failf("%sapi_keys must contain at least one key", prefix)
+		}
+		for j, key := range tenant.APIKeys {
+			if strings.HasPrefix(key, models.AdminAPIKeyPrefix) {
+				v.failf("%sapi_keys[%d] must not start with %q, which marks admin API keys", prefix, j, models.AdminAPIKeyPrefix)
+			}
+		}
+		checkRange(prefix, tenant.PoolMinTokenID, tenant.PoolMaxTokenID)
+		validatePoolExclusions(v, prefix, tenant.PoolMinTokenID, tenant.PoolMaxTokenID, tenant.PoolExclusions)
+		validatePoolZones(v, prefix, tenant.PoolMinTokenID, tenant.PoolMaxTokenID, tenant.PoolZones)
+	}
+}
+
+// validatePoolExclusions checks that the exclusions of a pool lie within it, do not
+// overlap and leave at least one token ID to hand out
+func validatePoolExclusions(v *validator, prefix string, minTokenID, maxTokenID int64, exclusions []TokenRangeConfig) {
+	var excluded int64
+	for i, exclusion := range exclusions {
+		name := fmt.Sprintf("%spool_exclusions[%d]", prefix, i)
+		if exclusion.FirstTokenID > exclusion.LastTokenID {
+			v.failf("%s: first_token_id (%d) must not exceed last_token_id (%d)", name, exclusion.FirstTokenID, exclusion.LastTokenID)
+			continue
+		}
+		if exclusion.FirstTokenID < minTokenID || exclusion.LastTokenID > maxTokenID {
+			v.failf("%s: [%d, %d] must lie within the pool [%d, %d]", name, exclusion.FirstTokenID, exclusion.LastTokenID, minTokenID, maxTokenID)
+			continue
+		}
+		for j, other := range exclusions[:i] {
+			if exclusion.FirstTokenID <= other.LastTokenID && other.FirstTokenID <= exclusion.LastTokenID {
+				v.failf("%s: [%d, %d] overlaps pool_exclusions[%d]", name, exclusion.FirstTokenID, exclusion.LastTokenID, j)
+			}
+		}
+		excluded += exclusion.LastTokenID - exclusion.FirstTokenID + 1
+	}
+	if len(exclusions) > 0 && excluded >= maxTokenID-minTokenID+1 {
+		v.failf("%spool_exclusions must leave at least one token ID of the pool to hand out", prefix)
+	}
+}
+
+// validatePoolZones checks that the zone ranges of a pool are named, lie within it and do
+// not overlap, so every token ID belongs to at most one zone
+func validatePoolZones(v *validator, prefix string, minTokenID, maxTokenID int64, zones []ZoneRangeConfig) {
+	for i, zone := range zones {
+		name := fmt.Sprintf("%spool_zones[%d]", prefix, i)
+		if !models.ValidZone(zone.Zone) {
+			v.failf("%s: zone %q must be 1 to 64 letters, digits, '.', '_' or '-'", name, zone.Zone)
+		}
+		if zone.FirstTokenID > zone.LastTokenID {
+			v.failf("%s: first_token_id (%d) must not exceed last_token_id (%d)", name, zone.FirstTokenID, zone.LastTokenID)
+			continue
+		}
+		if zone.FirstTokenID < minTokenID || zone.LastTokenID > maxTokenID {
+			v.failf("%s: [%d, %d] must lie within the pool [%d, %d]", name, zone.FirstTokenID, zone.LastTokenID, minTokenID, maxTokenID)
+			continue
+		}
+		for j, other := range zones[:i] {
+			if zone.FirstTokenID <= other.LastTokenID && other.FirstTokenID <= zone.LastTokenID {
+				v.failf("%s: [%d, %d] overlaps pool_zones[%d]", name, zone.FirstTokenID, zone.LastTokenID, j)
+			}
+		}
+	}
+}
+
+func (c *AppConfig) validateReclamation(v *validator) {
+	if c.ReclaimEnabled {
+		v.positive("reclaim_interval", c.ReclaimInterval)
+		v.positive("reclaim_batch_size", c.ReclaimBatchSize)
+		if len(c.ReclaimPolicies) == 0 {
+			v.failf("reclaim_policies must contain at least one policy when reclaim_enabled is true")
+		}
+	}
+
+	names := make(map[string]struct{}, len(c.ReclaimPolicies))
+	for i, policy := range c.ReclaimPolicies {
+		prefix := fmt.Sprintf("reclaim_policies[%d]", i)
+		if policy.Name == "" {
+			v.failf("%s: name must be set", prefix)
+		} else if _, ok := names[policy.Name]; ok {
+			v.failf("%s: name %q is used by another policy", prefix, policy.Name)
+		}
+		names[policy.Name] = struct{}{}
+
+		v.nonNegative(prefix+": not_renewed_for", policy.NotRenewedFor)
+		v.nonNegative(prefix+": expired_for", policy.ExpiredFor)
+		if policy.NotRenewedForTTLs < 0 {
+			v.failf("%s: not_renewed_for_ttls must not be negative, got %g", prefix, policy.NotRenewedForTTLs)
+		}
+	}
+}
+
+func (c *AppConfig) validatePeerPolicies(v *validator) {
+	if c.PeerPolicySource != "" {
+		v.oneOf("peer_policy_source", c.PeerPolicySource, PeerPolicySourceConfig, PeerPolicySourceDatabase)
+	}
+	switch c.PeerPolicySource {
+	case PeerPolicySourceConfig:
+		if len(c.PeerPolicies) == 0 {
+			v.failf("peer_policies must contain at least one policy when peer_policy_source is %s", PeerPolicySourceConfig)
+		}
+	case PeerPolicySourceDatabase:
+		v.positive("peer_policy_refresh_interval", c.PeerPolicyRefreshInterval)
+	}
+
+	names := make(map[string]struct{}, len(c.PeerPolicies))
+	for i, policy := range c.PeerPolicies {
+		prefix := fmt.Sprintf("peer_policies[%d]", i)
+		if policy.Name == "" {
+			v.failf("%s: name must be set", prefix)
+		} else if _, ok := names[policy.Name]; ok {
+			v.failf("%s: name %q is used by another policy", prefix, policy.Name)
+		}
+		names[policy.Name] = struct{}{}
+
+		if len(policy.Peers) == 0 {
+			v.failf("%s: peers must list peer IDs or %q", prefix, models.PeerPolicyWildcard)
+		}
+		if slices.Contains(policy.Peers, "") {
+			v.failf("%s: peers must not contain empty peer IDs", prefix)
+		}
+		v.nonNegative(prefix+": max_pool", policy.MaxPool)
+		if policy.MaxPool > 0 && slices.Contains(policy.Peers, models.PeerPolicyWildcard) {
+			v.failf("%s: max_pool cannot be set on a policy matching %q", prefix, models.PeerPolicyWildcard)
+		}
+	}
+}
+
+// validateRateLimitClasses checks that every class has a usable limit and that no route
+// is in two classes. Routes are matched without case, like request_timeout_overrides.
+func (c *AppConfig) validateRateLimitClasses(v *validator) {
+	names := make(map[string]struct{}, len(c.RateLimit.Classes))
+	routes := make(map[string]string)
+	for i, class := range c.RateLimit.Classes {
+		prefix := fmt.Sprintf("rate_limit.classes[%d]", i)
+		if class.Name == "" {
+			v.failf("%s: name must be set", prefix)
+		} else if _, ok := names[class.Name]; ok {
+			v.failf("%s: name %q is used by another class", prefix, class.Name)
+		}
+		names[class.Name] = struct{}{}
+
+		if len(class.Routes) == 0 {
+			v.failf("%s: routes must list at least one route pattern", prefix)
+		}
+		for _, route := range class.Routes {
+			if !strings.HasPrefix(route, "/") {
+				v.failf("%s: route pattern %q must start with /", prefix, route)
+			}
+			if other, ok := routes[strings.ToLower(route)]; ok {
+				v.failf("%s: route %q is already in class %q", prefix, route, other)
+			}
+			routes[strings.ToLower(route)] = class.Name
+		}
+
+		v.nonNegative(prefix+": requests_per_minute", class.RequestsPerMinute)
+		v.nonNegative(prefix+": burst", class.Burst)
+		if class.Multiplier < 0 {
+			v.failf("%s: multiplier must not be negative, got %g", prefix, class.Multiplier)
+		}
+		perMinute, burst := c.RateLimit.ClassLimits(class)
+		if perMinute <= 0 || burst <= 0 {
+			v.failf("%s: needs requests_per_minute and burst, or a multiplier leaving both positive", prefix)
+		} else if burst > perMinute {
+			v.failf("%s: burst (%d) must not exceed requests_per_minute (%d)", prefix, burst, perMinute)
+		}
+	}
+}
+
+func (c *AppConfig) validateNotifications(v *validator) {
+	if c.ExpiryNotifyEnabled {
+		v.positive("expiry_notify_interval", c.ExpiryNotifyInterval)
+		v.positive("expiry_notify_window", c.ExpiryNotifyWindow)
+		v.positive("expiry_notify_batch_size", c.ExpiryNotifyBatchSize)
+		v.positive("expiry_webhook_timeout", c.ExpiryWebhookTimeout)
+		for _, channel := range c.ExpiryNotifyChannels {
+			v.oneOf("expiry_notify_channels", channel, "log", "webhook")
+		}
+		if slices.Contains(c.ExpiryNotifyChannels, "webhook") && c.ExpiryWebhookURL == "" {
+			v.failf("expiry_webhook_url must be set for the webhook expiry notification channel")
+		}
+	}
+	if c.ExpiryWebhookURL != "" {
+		v.httpURL("expiry_webhook_url", c.ExpiryWebhookURL)
+	}
+
+	for i, webhook := range c.LeaseWebhooks {
+		prefix := fmt.Sprintf("lease_webhooks[%d]", i)
+		v.httpURL(prefix+": url", webhook.URL)
+		for _, event := range webhook.Events {
+			v.oneOf(prefix+": events", event, "lease.allocated", "lease.renewed", "lease.released", "lease.expired")
+		}
+	}
+	if len(c.LeaseWebhooks) > 0 {
+		v.positive("lease_webhook_timeout", c.LeaseWebhookTimeout)
+		v.nonNegative("lease_webhook_retries", c.LeaseWebhookRetries)
+		v.nonNegative("lease_webhook_backoff", c.LeaseWebhookBackoff)
+		v.positive("lease_webhook_queue_size", c.LeaseWebhookQueueSize)
+	}
+}
+
+// validateDNS leaves dns_provider alone, a provider supplied to the app replaces it
+func (c *AppConfig) validateDNS(v *validator) {
+	if !c.DNSEnabled {
+		return
+	}
+	if c.DNSZone == "" {
+		v.failf("dns_zone must be set when dns_enabled is true")
+	}
+	if c.DNSProvider == "rfc2136" {
+		if c.DNSServer == "" {
+			v.failf("dns_server must be set for the rfc2136 DNS provider")
+		} else if _, _, err := net.SplitHostPort(c.DNSServer); err != nil {
+			v.failf("dns_server must be host:port, got %q", c.DNSServer)
+		}
+	}
+	if c.DNSTSIGKey != "" && c.DNSTSIGSecret == "" {
+		v.failf("dns_tsig_secret must be set when dns_tsig_key is set")
+	}
+	v.positive("dns_record_ttl", c.DNSRecordTTL)
+	v.positive("dns_timeout", c.DNSTimeout)
+	v.positive("dns_queue_size", c.DNSQueueSize)
+}
+
+func (c *AppConfig) validateEventBus(v *validator) {
+	v.oneOf("event_bus_broker", c.EventBusBroker, "memory", "nats", "kafka")
+	switch c.EventBusBroker {
+	case "nats":
+		if u, err := url.Parse(c.NATSURL); err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" {
+			v.failf("nats_url must be a nats:// or tls:// URL for the nats event bus broker, got %q", c.NATSURL)
+		}
+	case "kafka":
+		v.httpURL("kafka_rest_url", c.KafkaRESTURL)
+	}
+	v.positive("event_bus_queue_size", c.EventBusQueueSize)
+	v.positive("event_bus_retry_backoff", c.EventBusRetryBackoff)
+	if c.EventBusMaxRetryBackoff < c.EventBusRetryBackoff {
+		v.failf("event_bus_max_retry_backoff must be at least event_bus_retry_backoff (%d), got %d", c.EventBusRetryBackoff, c.EventBusMaxRetryBackoff)
+	}
+	v.positive("event_bus_timeout", c.EventBusTimeout)
+}
+
+func (c *AppConfig) validateLeaseJournal(v *validator) {
+	if !c.LeaseJournalEnabled {
+		return
+	}
+	v.oneOf("lease_journal_sink", c.LeaseJournalSink, "file", "s3")
+	switch c.LeaseJournalSink {
+	case "file":
+		if c.LeaseJournalPath == "" {
+			v.failf("lease_journal_path must be set for the file lease journal sink")
+		}
+	case "s3":
+		v.httpURL("lease_journal_s3_endpoint", c.LeaseJournalS3Endpoint)
+		if c.LeaseJournalS3Bucket == "" || c.LeaseJournalS3Region == "" {
+			v.failf("lease_journal_s3_bucket and lease_journal_s3_region must be set for the s3 lease journal sink")
+		}
+		if c.LeaseJournalS3AccessKey == "" || c.LeaseJournalS3SecretKey == "" {
+			v.failf("lease_journal_s3_access_key and lease_journal_s3_secret_key must be set for the s3 lease journal sink")
+		}
+		v.positive("lease_journal_flush_interval", c.LeaseJournalFlushInterval)
+	}
+	v.nonNegative("lease_journal_verify_interval", c.LeaseJournalVerifyInterval)
+}
+
+func (c *AppConfig) validateHealthScore(v *validator) {
+	if c.HealthScoreThreshold < 0 || c.HealthScoreThreshold > 100 {
+		v.failf("health_score_threshold must be between 0 and 100, got %g", c.HealthScoreThreshold)
+	}
+	v.positive("health_db_latency_budget", c.HealthDBLatencyBudget)
+	v.positive("health_redis_latency_budget", c.HealthRedisLatencyBudget)
+	if c.HealthErrorRateBudget <= 0 || c.HealthErrorRateBudget > 1 {
+		v.failf("health_error_rate_budget must be greater than 0 and at most 1, got %g", c.HealthErrorRateBudget)
+	}
+	v.positive("health_error_window", c.HealthErrorWindow)
+	v.positive("health_max_in_flight", c.HealthMaxInFlight)
+
+	weights := []struct {
+		key   string
+		value float64
+	}{
+		{"health_weight_db", c.HealthWeightDB},
+		{"health_weight_redis", c.HealthWeightRedis},
+		{"health_weight_error_rate", c.HealthWeightErrorRate},
+		{"health_weight_saturation", c.HealthWeightSaturation},
+	}
+	total := 0.0
+	for _, weight := range weights {
+		if weight.value < 0 {
+			v.failf("%s must not be negative, got %g", weight.key, weight.value)
+		}
+		total += weight.value
+	}
+	if total <= 0 {
+		v.failf("at least one of health_weight_db, health_weight_redis, health_weight_error_rate and health_weight_saturation must be greater than 0")
+	}
+}
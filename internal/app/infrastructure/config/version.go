@@ -0,0 +1,5 @@
+package config
+
+// BuildVersion is the version of the running binary. It is set at compile time with
+// -ldflags "-X main.Build=SOMEVERSION" and supplied to the application by the serve command.
+type BuildVersion string
@@ -0,0 +1,294 @@
+// Package events is the publish/subscribe backbone of the server. Events published on the
+// bus are delivered to the subscribers of their topic in this process and, unless the
+// broker is memory, forwarded to NATS or Kafka. The events other instances forwarded come
+// back from the broker to the subscribers of their topic.
+//
+// Delivery is at least once: the broker and every subscriber have their own queue, and
+// a delivery that fails is retried with exponential backoff until it succeeds or the bus
+// stops. Consumers tell redeliveries apart by the event ID.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/retry"
+	"go.uber.org/zap"
+)
+
+// Event bus brokers, as used in event_bus_broker
+const (
+	BrokerMemory = "memory" // subscribers in this process only
+	BrokerNATS   = "nats"
+	BrokerKafka  = "kafka"
+)
+
+// brokerSinkName names the sink forwarding to the broker in the stats
+const brokerSinkName = "broker"
+
+var (
+	ErrQueueFull  = errors.New("event queue full")
+	ErrBusStopped = errors.New("event bus stopped")
+)
+
+// sink is the broker or a subscriber, with its queue and delivery counters
+type sink struct {
+	name    string
+	topic   string // empty receives every topic
+	deliver ports.EventHandler
+	queue   chan *models.Event
+
+	delivered atomic.Int64
+	retries   atomic.Int64
+	abandoned atomic.Int64
+}
+
+// Bus delivers events to the broker and the subscribers of this process
+type Bus struct {
+	brokerName string
+	broker     ports.EventBroker // nil for the memory broker
+	source     string            // tags the events of this instance, which come back from the broker
+	queueSize  int
+	retry      retry.Policy
+	logger     *zap.Logger
+
+	mu        sync.RWMutex
+	sinks     []*sink
+	receiving map[string]bool // topics received from the broker
+	stopped   bool
+	ctx       context.Context // canceled when stopping runs out of time
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	published atomic.Int64
+	rejected  atomic.Int64
+	received  atomic.Int64
+}
+
+var _ ports.EventBus = &Bus{}
+
+// NewBus creates a bus forwarding every event to broker, which is nil for the memory broker
+func NewBus(cfg *config.AppConfig, broker ports.EventBroker, logger *zap.Logger) *Bus {
+	b := &Bus{
+		brokerName: cfg.EventBusBroker,
+		broker:     broker,
+		source:     uuid.NewString(),
+		queueSize:  cfg.EventBusQueueSize,
+		retry: retry.Policy{
+			MaxAttempts:  math.MaxInt,
+			InitialDelay: time.Duration(cfg.EventBusRetryBackoff) * time.Millisecond,
+			MaxDelay:     time.Duration(cfg.EventBusMaxRetryBackoff) * time.Millisecond,
+			Jitter:       retry.DefaultJitter,
+		},
+		logger:    logger.Named("events"),
+		receiving: make(map[string]bool),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+
+	if broker != nil {
+		timeout := time.Duration(cfg.EventBusTimeout) * time.Second
+		b.addSink(brokerSinkName, "", func(ctx context.Context, event *models.Event) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return broker.Publish(ctx, event)
+		})
+	}
+	return b
+}
+
+// Publish queues the event for the broker and the subscribers of topic. Should any of
+// their queues be full, the event is still queued for the others and ErrQueueFull returned.
+func (b *Bus) Publish(topic, key string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", topic, err)
+	}
+	event := &models.Event{
+		ID:      uuid.NewString(),
+		Topic:   topic,
+		Key:     key,
+		Source:  b.source,
+		Time:    time.Now().UTC(),
+		Payload: data,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.stopped {
+		b.rejected.Add(1)
+		return ErrBusStopped
+	}
+
+	var full []string
+	for _, s := range b.sinks {
+		if s.topic != "" && s.topic != topic {
+			continue
+		}
+		select {
+		case s.queue <- event:
+		default:
+			full = append(full, s.name)
+		}
+	}
+	if len(full) > 0 {
+		b.rejected.Add(1)
+		b.logger.Warn("Event queue full, event not delivered", zap.String("topic", topic), zap.Strings("sinks", full))
+		return fmt.Errorf("%w: %v", ErrQueueFull, full)
+	}
+	b.published.Add(1)
+	return nil
+}
+
+// Subscribe delivers the events of topic to handler, starting with the next one published
+// here or received from the broker
+func (b *Bus) Subscribe(name, topic string, handler ports.EventHandler) {
+	b.addSink(name, topic, handler)
+	if b.broker == nil {
+		return
+	}
+
+	b.mu.Lock()
+	receive := !b.stopped && !b.receiving[topic]
+	b.receiving[topic] = true
+	b.mu.Unlock()
+	if !receive {
+		return
+	}
+	if err := b.broker.Receive(topic, b.receive); err != nil {
+		b.logger.Warn("Failed to receive events from the broker, only events of this instance are delivered", zap.String("topic", topic), zap.Error(err))
+	}
+}
+
+// receive queues an event of another instance for the subscribers of its topic. The
+// broker sink receives every topic, so the event is not forwarded again.
+func (b *Bus) receive(event *models.Event) {
+	if event.Source == b.source {
+		return // delivered when it was published
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.stopped {
+		return
+	}
+
+	b.received.Add(1)
+	for _, s := range b.sinks {
+		if s.topic != event.Topic {
+			continue
+		}
+		select {
+		case s.queue <- event:
+		default:
+			b.logger.Warn("Event queue full, received event not delivered", zap.String("topic", event.Topic), zap.String("sink", s.name))
+		}
+	}
+}
+
+func (b *Bus) addSink(name, topic string, deliver ports.EventHandler) {
+	s := &sink{name: name, topic: topic, deliver: deliver, queue: make(chan *models.Event, b.queueSize)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return
+	}
+	b.sinks = append(b.sinks, s)
+	b.wg.Add(1)
+	go b.run(s)
+}
+
+func (b *Bus) run(s *sink) {
+	defer b.wg.Done()
+	for event := range s.queue {
+		b.deliver(s, event)
+	}
+}
+
+// deliver retries until the event is delivered or the bus gives up on its queues
+func (b *Bus) deliver(s *sink, event *models.Event) {
+	policy := b.retry
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		s.retries.Add(1)
+		b.logger.Warn("Event delivery failed, retrying",
+			zap.String("sink", s.name),
+			zap.String("topic", event.Topic),
+			zap.String("eventID", event.ID),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+	}
+
+	if err := policy.Do(b.ctx, func(ctx context.Context) error { return s.deliver(ctx, event) }); err != nil {
+		s.abandoned.Add(1)
+		return
+	}
+	s.delivered.Add(1)
+}
+
+// Stop delivers the queued events until ctx is done, then abandons the rest and closes
+// the broker
+func (b *Bus) Stop(ctx context.Context) error {
+	b.mu.Lock()
+	b.stopped = true
+	for _, s := range b.sinks {
+		close(s.queue)
+	}
+	b.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		// Deliveries fail fast from now on, the remaining events are counted as abandoned
+		b.cancel()
+		<-drained
+		b.logger.Warn("Event bus stopped with undelivered events")
+	}
+	b.cancel()
+
+	if b.broker != nil {
+		return b.broker.Close()
+	}
+	return nil
+}
+
+func (b *Bus) Stats() *models.EventBusStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := &models.EventBusStats{
+		Broker:    b.brokerName,
+		Published: b.published.Load(),
+		Rejected:  b.rejected.Load(),
+		Received:  b.received.Load(),
+		Sinks:     make([]*models.EventSinkStats, 0, len(b.sinks)),
+	}
+	for _, s := range b.sinks {
+		stats.Sinks = append(stats.Sinks, &models.EventSinkStats{
+			Name:      s.name,
+			Topic:     s.topic,
+			Queued:    len(s.queue),
+			Delivered: s.delivered.Load(),
+			Retries:   s.retries.Load(),
+			Abandoned: s.abandoned.Load(),
+		})
+	}
+	return stats
+}
@@ -0,0 +1,313 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+const (
+	// kafkaContentType is the embedded JSON format of the Kafka REST Proxy v2
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	// kafkaV2ContentType is the format of the other REST Proxy v2 requests
+	kafkaV2ContentType = "application/vnd.kafka.v2+json"
+
+	kafkaPollTimeout    = time.Second      // how long the proxy holds a poll without records
+	kafkaRequestTimeout = 30 * time.Second // of a consumer request
+	kafkaRetryDelay     = 5 * time.Second  // before the consumer starts over after a failure
+)
+
+// KafkaBroker produces events to Kafka through a Kafka REST Proxy (v2 API). The topic of
+// an event is its topic behind the topic prefix and the record key is the event key, so
+// that the events of one lease stay in order on one partition. An event counts as
+// delivered once the proxy returned the offset of its record.
+//
+// Events are received by a consumer in a group of its own, so every instance receives
+// every event, starting at the latest offsets when the consumer is created.
+type KafkaBroker struct {
+	baseURL string
+	prefix  string
+	client  *http.Client
+	logger  *zap.Logger
+
+	mu       sync.Mutex
+	handlers map[string]func(event *models.Event) // by Kafka topic
+	changed  chan struct{}                        // the consumer has to subscribe to the topics again
+	cancel   context.CancelFunc                   // stops the consumer, nil until the first Receive
+	done     chan struct{}                        // closed when the consumer stopped
+	closed   bool
+}
+
+var _ ports.EventBroker = &KafkaBroker{}
+
+func NewKafkaBroker(restURL, prefix string, logger *zap.Logger) (*KafkaBroker, error) {
+	u, err := url.Parse(restURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("kafka_rest_url must be an absolute http or https URL, got %q", restURL)
+	}
+	// Timeouts come from the context of each publish
+	return &KafkaBroker{
+		baseURL:  strings.TrimRight(restURL, "/"),
+		prefix:   prefix,
+		client:   &http.Client{},
+		logger:   logger.Named("events"),
+		handlers: make(map[string]func(event *models.Event)),
+		changed:  make(chan struct{}, 1),
+	}, nil
+}
+
+type kafkaRecord struct {
+	Key   string        `json:"key,omitempty"`
+	Value *models.Event `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// kafkaErrorResponse is the body of an unsuccessful REST Proxy response
+type kafkaErrorResponse struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (b *KafkaBroker) Publish(ctx context.Context, event *models.Event) error {
+	body, err := json.Marshal(&kafkaProduceRequest{Records: []kafkaRecord{{Key: event.Key, Value: event}}})
+	if err != nil {
+		return err
+	}
+
+	topic := topicName(b.prefix, event.Topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json, application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e kafkaErrorResponse
+		if json.Unmarshal(data, &e) == nil && e.Message != "" {
+			return fmt.Errorf("producing to Kafka topic %s: %s (%d)", topic, e.Message, e.ErrorCode)
+		}
+		return fmt.Errorf("producing to Kafka topic %s: REST proxy responded with %s", topic, resp.Status)
+	}
+
+	var produced kafkaProduceResponse
+	if err := json.Unmarshal(data, &produced); err != nil {
+		return fmt.Errorf("decoding Kafka REST proxy response: %w", err)
+	}
+	if len(produced.Offsets) != 1 {
+		return fmt.Errorf("Kafka REST proxy returned %d offsets for one record", len(produced.Offsets))
+	}
+	if offset := produced.Offsets[0]; offset.ErrorCode != nil || offset.Error != "" {
+		return fmt.Errorf("producing to Kafka topic %s: %s", topic, offset.Error)
+	}
+	return nil
+}
+
+func (b *KafkaBroker) Receive(topic string, handler func(event *models.Event)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.New("the Kafka broker is closed")
+	}
+
+	b.handlers[topicName(b.prefix, topic)] = handler
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+	if b.cancel == nil {
+		var ctx context.Context
+		ctx, b.cancel = context.WithCancel(context.Background())
+		b.done = make(chan struct{})
+		go b.consume(ctx)
+	}
+	return nil
+}
+
+// consume receives the records of the subscribed topics until ctx is done, with a new
+// consumer instance after a failure
+func (b *KafkaBroker) consume(ctx context.Context) {
+	defer close(b.done)
+
+	group := "dhcp2p-" + uuid.NewString()
+	for {
+		err := b.consumeInstance(ctx, group)
+		if ctx.Err() != nil {
+			return
+		}
+		b.logger.Warn("Kafka consumer failed, events of other instances are missed until it is back", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(kafkaRetryDelay):
+		}
+	}
+}
+
+// consumeInstance creates a consumer instance, polls it until it fails or ctx is done
+// and deletes it
+func (b *KafkaBroker) consumeInstance(ctx context.Context, group string) error {
+	var created struct {
+		InstanceID string `json:"instance_id"`
+	}
+	options := map[string]string{"format": "json", "auto.offset.reset": "latest"}
+	if err := b.call(ctx, http.MethodPost, "/consumers/"+url.PathEscape(group), options, &created); err != nil {
+		return fmt.Errorf("creating consumer: %w", err)
+	}
+	instance := "/consumers/" + url.PathEscape(group) + "/instances/" + url.PathEscape(created.InstanceID)
+	defer func() {
+		if err := b.call(context.Background(), http.MethodDelete, instance, nil, nil); err != nil {
+			b.logger.Warn("Failed to delete the Kafka consumer, the proxy drops it once idle", zap.Error(err))
+		}
+	}()
+
+	// A new instance subscribes whether or not the topics changed
+	select {
+	case <-b.changed:
+	default:
+	}
+	subscribe := true
+	for {
+		if subscribe {
+			if err := b.call(ctx, http.MethodPost, instance+"/subscription", map[string][]string{"topics": b.topics()}, nil); err != nil {
+				return fmt.Errorf("subscribing: %w", err)
+			}
+		}
+
+		var records []struct {
+			Topic string          `json:"topic"`
+			Value json.RawMessage `json:"value"`
+		}
+		path := fmt.Sprintf("%s/records?timeout=%d", instance, kafkaPollTimeout.Milliseconds())
+		if err := b.call(ctx, http.MethodGet, path, nil, &records); err != nil {
+			return fmt.Errorf("polling records: %w", err)
+		}
+		for _, record := range records {
+			var event models.Event
+			if err := json.Unmarshal(record.Value, &event); err != nil {
+				continue
+			}
+			b.mu.Lock()
+			handler := b.handlers[record.Topic]
+			b.mu.Unlock()
+			if handler != nil {
+				handler(&event)
+			}
+		}
+
+		select {
+		case <-b.changed:
+			subscribe = true
+		default:
+			subscribe = false
+		}
+	}
+}
+
+// topics returns the Kafka topics of the handlers
+func (b *KafkaBroker) topics() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	topics := make([]string, 0, len(b.handlers))
+	for topic := range b.handlers {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// call sends a consumer request with in as JSON body, if any, and decodes the JSON
+// response into out, if any. It gives up after kafkaRequestTimeout.
+func (b *KafkaBroker) call(ctx context.Context, method, path string, in, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, kafkaRequestTimeout)
+	defer cancel()
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", kafkaV2ContentType)
+	}
+	req.Header.Set("Accept", kafkaContentType+", "+kafkaV2ContentType+", application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e kafkaErrorResponse
+		if json.Unmarshal(data, &e) == nil && e.Message != "" {
+			return fmt.Errorf("%s (%d)", e.Message, e.ErrorCode)
+		}
+		return fmt.Errorf("REST proxy responded with %s", resp.Status)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Close stops receiving, deleting the consumer
+func (b *KafkaBroker) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	cancel, done := b.cancel, b.done
+	b.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	b.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var Module = fx.Options(
+	fx.Provide(
+		NewBroker,
+		fx.Annotate(
+			newLifecycleBus,
+			fx.As(fx.Self()),
+			fx.As(new(ports.EventBus)),
+		),
+	),
+)
+
+// NewBroker creates the broker named by event_bus_broker, nil for the memory broker
+func NewBroker(cfg *config.AppConfig, logger *zap.Logger) (ports.EventBroker, error) {
+	switch cfg.EventBusBroker {
+	case BrokerMemory, "":
+		return nil, nil
+	case BrokerNATS:
+		return NewNATSBroker(cfg.NATSURL, cfg.EventBusTopicPrefix)
+	case BrokerKafka:
+		return NewKafkaBroker(cfg.KafkaRESTURL, cfg.EventBusTopicPrefix, logger)
+	default:
+		return nil, fmt.Errorf("unknown event bus broker %q", cfg.EventBusBroker)
+	}
+}
+
+// newLifecycleBus creates the bus and drains it on shutdown
+func newLifecycleBus(lc fx.Lifecycle, cfg *config.AppConfig, broker ports.EventBroker, logger *zap.Logger) *Bus {
+	bus := NewBus(cfg, broker, logger)
+	lc.Append(fx.Hook{OnStop: bus.Stop})
+	return bus
+}
+
+// topicName is the name of topic on the broker, e.g. dhcp2p.lease.allocated
+func topicName(prefix, topic string) string {
+	if prefix == "" {
+		return topic
+	}
+	return prefix + "." + topic
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// NATSBroker publishes events to NATS JetStream. The subject of an event is its topic
+// behind the topic prefix, and a stream must capture those subjects: an event counts as
+// delivered once the stream acknowledged it. The event ID is sent as Nats-Msg-Id, so
+// JetStream drops a redelivered event within the stream's duplicate window. Events of
+// other instances are received with plain subscriptions to their subjects.
+type NATSBroker struct {
+	url    string
+	prefix string
+
+	mu     sync.Mutex
+	conn   *nats.Conn // nil until first used
+	js     jetstream.JetStream
+	closed bool
+}
+
+var _ ports.EventBroker = &NATSBroker{}
+
+func NewNATSBroker(rawURL, prefix string) (*NATSBroker, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" {
+		return nil, fmt.Errorf("nats_url must be a nats:// or tls:// URL, got %q", rawURL)
+	}
+	return &NATSBroker{url: rawURL, prefix: prefix}, nil
+}
+
+func (b *NATSBroker) Publish(ctx context.Context, event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	conn, js, err := b.connect()
+	if err != nil {
+		return err
+	}
+	if !conn.IsConnected() {
+		if err := conn.LastError(); err != nil {
+			return fmt.Errorf("not connected to NATS: %w", err)
+		}
+		return errors.New("not connected to NATS")
+	}
+
+	msg := &nats.Msg{Subject: topicName(b.prefix, event.Topic), Data: data}
+	_, err = js.PublishMsg(ctx, msg, jetstream.WithMsgID(event.ID))
+	var apiErr *jetstream.APIError
+	switch {
+	case errors.Is(err, jetstream.ErrNoStreamResponse):
+		return errors.New("no JetStream stream captures the subject")
+	case errors.Is(err, jetstream.ErrInvalidJSAck):
+		return errors.New("the reply is not a JetStream acknowledgement")
+	case errors.As(err, &apiErr):
+		return fmt.Errorf("JetStream refused the event: %s (%d)", apiErr.Description, apiErr.Code)
+	}
+	return err
+}
+
+func (b *NATSBroker) Receive(topic string, handler func(event *models.Event)) error {
+	conn, _, err := b.connect()
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Subscribe(topicName(b.prefix, topic), func(msg *nats.Msg) {
+		var event models.Event
+		if err := json.Unmarshal(msg.Data, &event); err == nil {
+			handler(&event)
+		}
+	})
+	return err
+}
+
+// connect connects on first use. The client authenticates with the user info of the URL,
+// either user:password or a token, and upgrades tls:// connections after the server's
+// INFO. It keeps reconnecting when the server cannot be reached, also at first, and
+// subscribes again once it is back.
+func (b *NATSBroker) connect() (*nats.Conn, jetstream.JetStream, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, nats.ErrConnectionClosed
+	}
+	if b.conn != nil {
+		return b.conn, b.js, nil
+	}
+
+	conn, err := nats.Connect(b.url, nats.Name("dhcp2p"), nats.MaxReconnects(-1), nats.RetryOnFailedConnect(true))
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	b.conn, b.js = conn, js
+	return conn, js, nil
+}
+
+func (b *NATSBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn, b.js = nil, nil
+	}
+	return nil
+}
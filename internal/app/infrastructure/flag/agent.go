@@ -0,0 +1,22 @@
+package flag
+
+const (
+	RENEW_FRACTION_FLAG        = "renew-fraction"
+	RENEW_FRACTION_FLAG_SHORT  = ""
+	RETRY_INTERVAL_FLAG        = "retry-interval"
+	RETRY_INTERVAL_FLAG_SHORT  = ""
+	IP_FILE_FLAG               = "ip-file"
+	IP_FILE_FLAG_SHORT         = ""
+	INTERFACE_FLAG             = "interface"
+	INTERFACE_FLAG_SHORT       = "i"
+	PREFIX_LENGTH_FLAG         = "prefix-length"
+	PREFIX_LENGTH_FLAG_SHORT   = ""
+	SOCKET_FLAG                = "socket"
+	SOCKET_FLAG_SHORT          = ""
+	RELEASE_ON_EXIT_FLAG       = "release-on-exit"
+	RELEASE_ON_EXIT_FLAG_SHORT = ""
+	LEASE_FILE_FLAG            = "lease-file"
+	LEASE_FILE_FLAG_SHORT      = ""
+	CREATE_KEY_FLAG            = "create-key"
+	CREATE_KEY_FLAG_SHORT      = ""
+)
@@ -0,0 +1,8 @@
+package flag
+
+const (
+	NAME_FLAG        = "name"
+	NAME_FLAG_SHORT  = "n"
+	SCOPE_FLAG       = "scope"
+	SCOPE_FLAG_SHORT = "s"
+)
@@ -0,0 +1,28 @@
+package flag
+
+const (
+	SERVER_FLAG                 = "server"
+	SERVER_FLAG_SHORT           = "s"
+	KEY_FLAG                    = "key"
+	KEY_FLAG_SHORT              = "k"
+	SIGN_TIMESTAMP_FLAG         = "sign-timestamp"
+	SIGN_TIMESTAMP_FLAG_SHORT   = ""
+	AUTH_LOOKUPS_FLAG           = "auth-lookups"
+	AUTH_LOOKUPS_FLAG_SHORT     = ""
+	TOKEN_ID_FLAG               = "token-id"
+	TOKEN_ID_FLAG_SHORT         = "t"
+	AFFINITY_GROUP_FLAG         = "affinity-group"
+	AFFINITY_GROUP_FLAG_SHORT   = ""
+	PEER_ID_FLAG                = "peer-id"
+	PEER_ID_FLAG_SHORT          = ""
+	ETHEREUM_FLAG               = "ethereum"
+	ETHEREUM_FLAG_SHORT         = ""
+	API_KEY_FLAG                = "api-key"
+	API_KEY_FLAG_SHORT          = ""
+	DISCOVER_FLAG               = "discover"
+	DISCOVER_FLAG_SHORT         = ""
+	DISCOVER_TIMEOUT_FLAG       = "discover-timeout"
+	DISCOVER_TIMEOUT_FLAG_SHORT = ""
+	ZONE_FLAG                   = "zone"
+	ZONE_FLAG_SHORT             = ""
+)
@@ -0,0 +1,6 @@
+package flag
+
+const (
+	OUTPUT_FLAG       = "output"
+	OUTPUT_FLAG_SHORT = "o"
+)
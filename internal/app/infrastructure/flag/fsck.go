@@ -0,0 +1,8 @@
+package flag
+
+const (
+	REPAIR_FLAG       = "repair"
+	REPAIR_FLAG_SHORT = ""
+	JSON_FLAG         = "json"
+	JSON_FLAG_SHORT   = ""
+)
@@ -0,0 +1,20 @@
+package flag
+
+const (
+	CONCURRENCY_FLAG       = "concurrency"
+	CONCURRENCY_FLAG_SHORT = "c"
+	DURATION_FLAG          = "duration"
+	DURATION_FLAG_SHORT    = "d"
+	RATE_FLAG              = "rate"
+	RATE_FLAG_SHORT        = ""
+	PEERS_FLAG             = "peers"
+	PEERS_FLAG_SHORT       = ""
+	MIX_FLAG               = "mix"
+	MIX_FLAG_SHORT         = ""
+	OP_TIMEOUT_FLAG        = "op-timeout"
+	OP_TIMEOUT_FLAG_SHORT  = ""
+	CLEANUP_FLAG           = "cleanup"
+	CLEANUP_FLAG_SHORT     = ""
+	PROGRESS_FLAG          = "progress"
+	PROGRESS_FLAG_SHORT    = ""
+)
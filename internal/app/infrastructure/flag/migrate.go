@@ -0,0 +1,6 @@
+package flag
+
+const (
+	STATUS_FLAG       = "status"
+	STATUS_FLAG_SHORT = ""
+)
@@ -0,0 +1,8 @@
+package flag
+
+const (
+	UNTIL_FLAG           = "until"
+	UNTIL_FLAG_SHORT     = ""
+	UNTIL_SEQ_FLAG       = "until-seq"
+	UNTIL_SEQ_FLAG_SHORT = ""
+)
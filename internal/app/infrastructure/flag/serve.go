@@ -5,6 +5,12 @@ const (
 	PORT_FLAG_SHORT                   = "p"
 	LOG_LEVEL_FLAG                    = "log-level"
 	LOG_LEVEL_FLAG_SHORT              = "l"
+	DEV_FLAG                          = "dev"
+	AUTO_MIGRATE_FLAG                 = "auto-migrate"
+	STORAGE_BACKEND_FLAG              = "storage-backend"
+	STORAGE_BACKEND_FLAG_SHORT        = ""
+	STORAGE_PATH_FLAG                 = "storage-path"
+	STORAGE_PATH_FLAG_SHORT           = ""
 	DATABASE_URL_FLAG                 = "database-url"
 	DATABASE_URL_FLAG_SHORT           = ""
 	REDIS_URL_FLAG                    = "redis-url"
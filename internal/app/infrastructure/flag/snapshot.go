@@ -0,0 +1,6 @@
+package flag
+
+const (
+	REPLACE_FLAG       = "replace"
+	REPLACE_FLAG_SHORT = ""
+)
@@ -0,0 +1,167 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// FileSink appends journal entries to a local file, one JSON object per line. Every
+// entry is synced to disk before Append returns.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+var _ ports.JournalSink = &FileSink{}
+
+// OpenFileSink opens the journal file at path, creating it if needed. A line left
+// incomplete by a crash during a write is discarded.
+func OpenFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create journal directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open lease journal: %w", err)
+	}
+
+	size, err := completeSize(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read lease journal: %w", err)
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("truncate lease journal: %w", err)
+	}
+
+	return &FileSink{path: path, file: file, size: size}, nil
+}
+
+func (s *FileSink) Append(ctx context.Context, entry *models.JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(line); err != nil {
+		// Drop what part of the line was written, the next entry starts on a new line
+		s.file.Truncate(s.size)
+		return fmt.Errorf("write lease journal: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Truncate(s.size)
+		return fmt.Errorf("write lease journal: %w", err)
+	}
+	s.size += int64(len(line))
+	return nil
+}
+
+func (s *FileSink) Read(ctx context.Context, fn func(entry *models.JournalEntry) error) error {
+	return NewFileSource(s.path).Read(ctx, fn)
+}
+
+func (s *FileSink) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// FileSource reads a journal file, e.g. a copy taken from a failed server
+type FileSource struct {
+	path string
+}
+
+var _ ports.JournalSource = &FileSource{}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+// Read passes the complete lines of the file to fn; a trailing incomplete line is
+// skipped
+func (s *FileSource) Read(ctx context.Context, fn func(entry *models.JournalEntry) error) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return readEntries(ctx, file, fn)
+}
+
+// readEntries decodes the complete lines of r. Lines hold checkpoints of the whole lease
+// state, so they are not limited in length.
+func readEntries(ctx context.Context, r io.Reader, fn func(entry *models.JournalEntry) error) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var entry models.JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+}
+
+// completeSize returns the size of the file up to the end of its last complete line
+func completeSize(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return 0, nil
+	}
+
+	// Search backwards for the last newline, a block at a time
+	buf := make([]byte, 64*1024)
+	for end := size; end > 0; {
+		start := max(end-int64(len(buf)), 0)
+		block := buf[:end-start]
+		if _, err := file.ReadAt(block, start); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(block, '\n'); i >= 0 {
+			return start + int64(i) + 1, nil
+		}
+		end = start
+	}
+	return 0, nil
+}
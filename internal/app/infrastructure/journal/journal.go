@@ -0,0 +1,102 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+)
+
+// Journal numbers lease mutations and appends them to a sink. It applies what it
+// appends to a JournalState as well, so the leases it describes can be compared with
+// those of the store at any time.
+type Journal struct {
+	mu    sync.Mutex
+	sink  ports.JournalSink
+	clock ports.Clock
+	state *models.JournalState
+	gap   bool // an entry is missing since the last checkpoint
+}
+
+var _ ports.LeaseJournal = &Journal{}
+
+// Open continues the journal stored in sink. A missing entry, e.g. one whose append
+// failed before a restart, leaves the journal waiting for a checkpoint.
+func Open(ctx context.Context, sink ports.JournalSink, clock ports.Clock) (*Journal, error) {
+	j := &Journal{sink: sink, clock: clock, state: models.NewJournalState()}
+	err := sink.Read(ctx, func(entry *models.JournalEntry) error {
+		if entry.Seq <= j.state.Seq() {
+			return fmt.Errorf("entry %d follows entry %d", entry.Seq, j.state.Seq())
+		}
+		if entry.Seq != j.state.Seq()+1 && entry.Op != models.JournalOpCheckpoint {
+			j.gap = true
+		}
+		if entry.Op == models.JournalOpCheckpoint {
+			j.gap = false
+		}
+		j.state.Apply(entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read lease journal: %w", err)
+	}
+	return j, nil
+}
+
+func (j *Journal) Append(ctx context.Context, entry *models.JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.append(ctx, entry)
+}
+
+func (j *Journal) Checkpoint(ctx context.Context, snapshot *models.LeaseSnapshot) (int64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry := &models.JournalEntry{Op: models.JournalOpCheckpoint, Snapshot: snapshot}
+	if err := j.append(ctx, entry); err != nil {
+		return 0, err
+	}
+	j.gap = false
+	return entry.Seq, nil
+}
+
+func (j *Journal) NeedsCheckpoint() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.gap || j.state.Checkpoint() == 0
+}
+
+func (j *Journal) Compare(store *models.LeaseSnapshot, now time.Time) (int64, []string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.state.Seq(), j.state.Mismatches(store, now)
+}
+
+func (j *Journal) Read(ctx context.Context, fn func(entry *models.JournalEntry) error) error {
+	return j.sink.Read(ctx, fn)
+}
+
+// Close closes the sink
+func (j *Journal) Close(ctx context.Context) error {
+	return j.sink.Close(ctx)
+}
+
+// append numbers and stores the entry. A failed append keeps its sequence number for
+// the next entry. Caller must hold j.mu.
+func (j *Journal) append(ctx context.Context, entry *models.JournalEntry) error {
+	entry.Seq = j.state.Seq() + 1
+	entry.Time = j.clock.Now().UTC()
+	if err := j.sink.Append(ctx, entry); err != nil {
+		j.gap = true
+		return fmt.Errorf("append to lease journal: %w", err)
+	}
+	j.state.Apply(entry)
+	return nil
+}
@@ -0,0 +1,58 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	SinkFile = "file"
+	SinkS3   = "s3"
+)
+
+var Module = fx.Provide(NewLeaseJournal)
+
+// NewLeaseJournal opens the journal in the sink named by lease_journal_sink, or returns
+// nil unless lease_journal_enabled is set
+func NewLeaseJournal(lc fx.Lifecycle, cfg *config.AppConfig, clock ports.Clock, logger *zap.Logger) (ports.LeaseJournal, error) {
+	if !cfg.LeaseJournalEnabled {
+		return nil, nil
+	}
+
+	sink, err := NewSink(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	journal, err := Open(context.Background(), sink, clock)
+	if err != nil {
+		sink.Close(context.Background())
+		return nil, err
+	}
+	lc.Append(fx.Hook{OnStop: journal.Close})
+	return journal, nil
+}
+
+// NewSink creates the sink named by lease_journal_sink
+func NewSink(cfg *config.AppConfig, logger *zap.Logger) (ports.JournalSink, error) {
+	switch cfg.LeaseJournalSink {
+	case SinkFile, "":
+		return OpenFileSink(cfg.LeaseJournalPath)
+	case SinkS3:
+		return NewS3Sink(S3Config{
+			Endpoint:  cfg.LeaseJournalS3Endpoint,
+			Bucket:    cfg.LeaseJournalS3Bucket,
+			Prefix:    cfg.LeaseJournalS3Prefix,
+			Region:    cfg.LeaseJournalS3Region,
+			AccessKey: cfg.LeaseJournalS3AccessKey,
+			SecretKey: cfg.LeaseJournalS3SecretKey,
+		}, time.Duration(cfg.LeaseJournalFlushInterval)*time.Millisecond, logger)
+	default:
+		return nil, fmt.Errorf("unknown lease journal sink %q", cfg.LeaseJournalSink)
+	}
+}
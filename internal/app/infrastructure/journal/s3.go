@@ -0,0 +1,353 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"go.uber.org/zap"
+)
+
+// S3Config locates the journal in an S3-compatible object store
+type S3Config struct {
+	Endpoint  string // http or https URL of the store, buckets are addressed by path
+	Bucket    string
+	Prefix    string // key prefix of the segments
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Sink writes journal entries to an S3-compatible object store. Entries are buffered
+// and written every flush interval as one segment object named after the sequence
+// number of its first entry, so the segments list in journal order. Entries still
+// buffered when the server crashes are lost; the consistency check notices the missing
+// mutations and writes a checkpoint.
+type S3Sink struct {
+	client *s3Client
+	prefix string
+	logger *zap.Logger
+
+	flushMu sync.Mutex // serializes flushes
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	first   int64 // sequence number of the first buffered entry
+	last    int64 // sequence number of the last buffered entry
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+var _ ports.JournalSink = &S3Sink{}
+
+// NewS3Sink creates the sink and starts flushing it every interval
+func NewS3Sink(cfg S3Config, interval time.Duration, logger *zap.Logger) (*S3Sink, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("lease_journal_s3_endpoint must be an absolute http or https URL, got %q", cfg.Endpoint)
+	}
+
+	s := &S3Sink{
+		client: &s3Client{
+			endpoint:  u,
+			bucket:    cfg.Bucket,
+			region:    cfg.Region,
+			accessKey: cfg.AccessKey,
+			secretKey: cfg.SecretKey,
+			http:      &http.Client{Timeout: 30 * time.Second},
+		},
+		prefix: strings.Trim(cfg.Prefix, "/"),
+		logger: logger.Named("journal"),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	go s.flushLoop(interval)
+	return s, nil
+}
+
+// Append buffers the entry until the next flush
+func (s *S3Sink) Append(ctx context.Context, entry *models.JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.buf.Len() == 0 {
+		s.first = entry.Seq
+	}
+	s.buf.Write(line)
+	s.buf.WriteByte('\n')
+	s.last = entry.Seq
+	return nil
+}
+
+// Read passes the entries of every segment to fn, followed by those not flushed yet
+func (s *S3Sink) Read(ctx context.Context, fn func(entry *models.JournalEntry) error) error {
+	keys, err := s.client.list(ctx, s.segmentPrefix())
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		body, err := s.client.get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if err := readEntries(ctx, bytes.NewReader(body), fn); err != nil {
+			return fmt.Errorf("segment %s: %w", key, err)
+		}
+	}
+
+	s.mu.Lock()
+	pending := bytes.Clone(s.buf.Bytes())
+	s.mu.Unlock()
+	return readEntries(ctx, bytes.NewReader(pending), fn)
+}
+
+// Close stops the flushes and writes the buffered entries
+func (s *S3Sink) Close(ctx context.Context) error {
+	close(s.stopCh)
+	<-s.doneCh
+	return s.flush(ctx)
+}
+
+func (s *S3Sink) flushLoop(interval time.Duration) {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval+30*time.Second)
+			if err := s.flush(ctx); err != nil {
+				s.logger.Warn("Failed to write lease journal segment, retrying with the next flush", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
+// flush writes the buffered entries as one segment. Entries appended meanwhile stay
+// buffered; after a failure the next flush writes the same segment again, with them.
+func (s *S3Sink) flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	data := bytes.Clone(s.buf.Bytes())
+	first, last := s.first, s.last
+	s.mu.Unlock()
+	if len(data) == 0 {
+		return nil
+	}
+
+	if err := s.client.put(ctx, s.segmentPrefix()+fmt.Sprintf("%020d.jsonl", first), data); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.buf.Next(len(data))
+	s.first = last + 1
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *S3Sink) segmentPrefix() string {
+	if s.prefix == "" {
+		return ""
+	}
+	return s.prefix + "/"
+}
+
+// s3Client speaks the few S3 operations the sink needs, signed with AWS Signature
+// Version 4
+type s3Client struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	http      *http.Client
+}
+
+// s3ListResult is the body of a ListObjectsV2 response
+type s3ListResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// s3Error is the body of an unsuccessful response
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (c *s3Client) put(ctx context.Context, key string, body []byte) error {
+	_, err := c.do(ctx, http.MethodPut, key, nil, body)
+	return err
+}
+
+func (c *s3Client) get(ctx context.Context, key string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, key, nil, nil)
+}
+
+// list returns the keys starting with prefix in lexicographic order
+func (c *s3Client) list(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	query := map[string]string{"list-type": "2", "prefix": prefix}
+	for {
+		body, err := c.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result s3ListResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("decode object list: %w", err)
+		}
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		query["continuation-token"] = result.NextContinuationToken
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// do sends a signed request for key in the bucket and returns the response body
+func (c *s3Client) do(ctx context.Context, method, key string, query map[string]string, body []byte) ([]byte, error) {
+	path := strings.TrimRight(c.endpoint.Path, "/") + "/" + c.bucket + "/" + key
+	canonicalURI := s3Escape(path, true)
+	canonicalQuery := s3CanonicalQuery(query)
+
+	target := *c.endpoint
+	target.Path = path
+	target.RawPath = canonicalURI
+	target.RawQuery = canonicalQuery
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	signS3Request(req, canonicalURI, canonicalQuery, body, c.accessKey, c.secretKey, c.region, time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		var s3Err s3Error
+		if xml.Unmarshal(respBody, &s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("s3 %s %s: %s: %s", method, path, s3Err.Code, s3Err.Message)
+		}
+		return nil, fmt.Errorf("s3 %s %s: unexpected status %d", method, path, resp.StatusCode)
+	}
+	return respBody, nil
+}
+
+// signS3Request sets the AWS Signature Version 4 headers on req, signing the host, the
+// payload hash and the date
+func signS3Request(req *http.Request, canonicalURI, canonicalQuery string, body []byte, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// s3CanonicalQuery encodes the query sorted by name, as signed and as sent
+func s3CanonicalQuery(query map[string]string) string {
+	names := slices.Sorted(maps.Keys(query))
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = s3Escape(name, false) + "=" + s3Escape(query[name], false)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes everything but the unreserved characters of RFC 3986, and
+// slashes when keepSlash is set
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -2,6 +2,10 @@ package logger
 
 import (
 	"context"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/mattn/go-colorable"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
@@ -11,42 +15,131 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-func getLoggerLevel(cfg *config.AppConfig) zap.AtomicLevel {
-	switch cfg.LogLevel {
-	case "debug":
-		return zap.NewAtomicLevelAt(zap.DebugLevel)
+func parseLevel(level string) zapcore.Level {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return zapcore.InfoLevel
 	}
-	return zap.NewAtomicLevelAt(zap.InfoLevel)
+	return parsed
 }
 
-func NewLogger(lc fx.Lifecycle, cfg *config.AppConfig) *zap.Logger {
-	stdout := zapcore.AddSync(colorable.NewColorableStdout())
-	file := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   "logs/app.log",
-		MaxSize:    10, // megabytes
-		MaxBackups: 3,
-		MaxAge:     7, // days
-	})
+// Level holds the level of the server log and the levels of named loggers. It is shared by
+// all cores of the logger, so a reload can change it.
+type Level struct {
+	zap.AtomicLevel
+	modules atomic.Pointer[map[string]zapcore.Level]
+}
+
+func NewLevel(cfg *config.AppConfig) *Level {
+	l := &Level{AtomicLevel: zap.NewAtomicLevelAt(parseLevel(cfg.Server.LogLevel))}
+	l.storeModules(cfg)
+	return l
+}
 
-	level := getLoggerLevel(cfg)
+// ApplyConfig switches to the reloaded log levels
+func (l *Level) ApplyConfig(cfg *config.AppConfig) {
+	l.SetLevel(parseLevel(cfg.Server.LogLevel))
+	l.storeModules(cfg)
+}
+
+func (l *Level) storeModules(cfg *config.AppConfig) {
+	modules := make(map[string]zapcore.Level, len(cfg.Log.Levels))
+	for name, level := range cfg.Log.Levels {
+		modules[name] = parseLevel(level)
+	}
+	l.modules.Store(&modules)
+}
+
+// For returns the level of the logger named name. A logger without a level of its own
+// takes that of its closest parent, e.g. "cache.redis" that of "cache", and else the
+// level of the server log.
+func (l *Level) For(name string) zapcore.Level {
+	modules := *l.modules.Load()
+	for name != "" {
+		if level, ok := modules[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return l.Level()
+}
+
+// Enabled reports whether any logger logs at level, so entries no logger wants are
+// dropped before they are built
+func (l *Level) Enabled(level zapcore.Level) bool {
+	if l.AtomicLevel.Enabled(level) {
+		return true
+	}
+	for _, module := range *l.modules.Load() {
+		if module.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
 
+// levelCore filters entries by the level of the logger that wrote them
+type levelCore struct {
+	zapcore.Core
+	level *Level
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{c.Core.With(fields), c.level}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.For(entry.LoggerName).Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+func NewLogger(lc fx.Lifecycle, cfg *config.AppConfig, level *Level) *zap.Logger {
 	productionCfg := zap.NewProductionEncoderConfig()
 	productionCfg.TimeKey = "timestamp"
 	productionCfg.EncodeTime = zapcore.ISO8601TimeEncoder
-	productionCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
 
 	developmentCfg := zap.NewDevelopmentEncoderConfig()
 	developmentCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
 
-	consoleEncoder := zapcore.NewConsoleEncoder(developmentCfg)
-	fileEncoder := zapcore.NewJSONEncoder(productionCfg)
+	stdoutEncoder := zapcore.NewConsoleEncoder(developmentCfg)
+	if cfg.Log.Encoding == "json" {
+		stdoutEncoder = zapcore.NewJSONEncoder(productionCfg)
+	}
+	// Standard output is not synced, syncing a pipe or terminal fails
+	stdout := zapcore.Lock(zapcore.AddSync(struct{ io.Writer }{colorable.NewColorableStdout()}))
+
+	// The cores log everything, levelCore decides what gets through
+	cores := []zapcore.Core{zapcore.NewCore(stdoutEncoder, stdout, zapcore.DebugLevel)}
 
-	core := zapcore.NewTee(
-		zapcore.NewCore(consoleEncoder, stdout, level),
-		zapcore.NewCore(fileEncoder, file, level),
-	)
+	if cfg.Log.File != "" {
+		file := zapcore.AddSync(&lumberjack.Logger{
+			Filename:   cfg.Log.File,
+			MaxSize:    cfg.Log.FileMaxSize, // megabytes
+			MaxBackups: cfg.Log.FileMaxBackups,
+			MaxAge:     cfg.Log.FileMaxAge, // days
+			Compress:   cfg.Log.FileCompress,
+		})
+		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(productionCfg), file, zapcore.DebugLevel))
+	}
+
+	core := zapcore.NewTee(cores...)
+	if cfg.Log.SamplingInitial > 0 {
+		// Entries repeating the same message, such as cache misses or rate limit
+		// rejections, are thinned out beyond the first ones each second
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.Log.SamplingInitial, cfg.Log.SamplingThereafter)
+	}
 
-	logger := zap.New(core)
+	logger := zap.New(&levelCore{core, level})
 
 	lc.Append(fx.Hook{
 		OnStop: func(ctx context.Context) error {
@@ -1,7 +1,11 @@
 package logger
 
-import "go.uber.org/fx"
+import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+)
 
 var Module = fx.Options(
-	fx.Provide(NewLogger),
+	fx.Provide(NewLevel, NewLogger),
+	config.ReloadTarget[*Level](),
 )
@@ -0,0 +1,2 @@
+-- Modify "alloc_state" table
+ALTER TABLE "public"."alloc_state" ADD COLUMN "min_token_id" bigint NOT NULL DEFAULT 167902210;
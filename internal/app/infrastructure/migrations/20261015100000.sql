@@ -0,0 +1,4 @@
+-- Modify "leases" table
+ALTER TABLE "public"."leases" ADD COLUMN "affinity_group" character varying(128) NULL;
+-- Create index "idx_leases_affinity_group" to table: "leases"
+CREATE INDEX "idx_leases_affinity_group" ON "public"."leases" ("affinity_group");
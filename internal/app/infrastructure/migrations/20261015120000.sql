@@ -0,0 +1,2 @@
+-- Modify "leases" table
+ALTER TABLE "public"."leases" ADD COLUMN "reclaimed_at" timestamptz NULL;
@@ -0,0 +1,2 @@
+-- Create index "idx_nonces_peer_id" to table: "nonces"
+CREATE INDEX "idx_nonces_peer_id" ON "public"."nonces" ("peer_id");
@@ -0,0 +1,12 @@
+-- Create "lease_history" table
+CREATE TABLE "public"."lease_history" (
+  "id" bigserial NOT NULL,
+  "token_id" bigint NOT NULL,
+  "peer_id" character varying(128) NOT NULL,
+  "event" character varying(16) NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "occurred_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_lease_history_token_id" to table: "lease_history"
+CREATE INDEX "idx_lease_history_token_id" ON "public"."lease_history" ("token_id", "id");
@@ -0,0 +1,2 @@
+-- Modify "leases" table
+ALTER TABLE "public"."leases" ADD COLUMN "quarantined_until" timestamptz NULL;
@@ -0,0 +1,12 @@
+-- Create "access_rules" table
+CREATE TABLE "public"."access_rules" (
+  "id" bigserial NOT NULL,
+  "list" character varying(8) NOT NULL,
+  "subject_type" character varying(16) NOT NULL,
+  "subject" text NOT NULL,
+  "reason" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_access_rules_subject" to table: "access_rules"
+CREATE UNIQUE INDEX "idx_access_rules_subject" ON "public"."access_rules" ("subject_type", "subject", "list");
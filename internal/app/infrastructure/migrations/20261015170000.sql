@@ -0,0 +1,12 @@
+-- Modify "alloc_state" table
+ALTER TABLE "public"."alloc_state" ADD COLUMN "tenant_id" character varying(64) NOT NULL DEFAULT 'default';
+-- Create index "idx_alloc_state_tenant_id" to table: "alloc_state"
+CREATE UNIQUE INDEX "idx_alloc_state_tenant_id" ON "public"."alloc_state" ("tenant_id");
+-- Modify "leases" table
+ALTER TABLE "public"."leases" ADD COLUMN "tenant_id" character varying(64) NOT NULL DEFAULT 'default';
+-- Create index "idx_leases_tenant_id_peer_id" to table: "leases"
+CREATE INDEX "idx_leases_tenant_id_peer_id" ON "public"."leases" ("tenant_id", "peer_id");
+-- Modify "lease_history" table
+ALTER TABLE "public"."lease_history" ADD COLUMN "tenant_id" character varying(64) NOT NULL DEFAULT 'default';
+-- Move the id sequence past the seeded row, tenants add rows of their own
+SELECT setval(pg_get_serial_sequence('"public"."alloc_state"', 'id'), (SELECT COALESCE(MAX(id), 1) FROM "public"."alloc_state"));
@@ -0,0 +1,4 @@
+-- Modify "leases" table
+ALTER TABLE "public"."leases" ADD COLUMN "delegated_by" character varying(128) NULL;
+-- Create index "idx_leases_delegated_by" to table: "leases"
+CREATE INDEX "idx_leases_delegated_by" ON "public"."leases" ("delegated_by");
@@ -0,0 +1,2 @@
+-- Create index "idx_nonces_expires_at" to table: "nonces"
+CREATE INDEX "idx_nonces_expires_at" ON "public"."nonces" ("expires_at");
@@ -0,0 +1,2 @@
+-- Create index "idx_lease_history_occurred_at" to table: "lease_history"
+CREATE INDEX "idx_lease_history_occurred_at" ON "public"."lease_history" ("occurred_at");
@@ -0,0 +1,18 @@
+-- Drop "lease_read_model" materialized view, it selects "leases"."peer_id"
+DROP MATERIALIZED VIEW "public"."lease_read_model";
+-- Modify "nonces" table
+ALTER TABLE "public"."nonces" ALTER COLUMN "peer_id" TYPE character varying(256);
+-- Modify "leases" table
+ALTER TABLE "public"."leases" ALTER COLUMN "peer_id" TYPE character varying(256), ALTER COLUMN "delegated_by" TYPE character varying(256);
+-- Modify "lease_history" table
+ALTER TABLE "public"."lease_history" ALTER COLUMN "peer_id" TYPE character varying(256);
+-- Create "lease_read_model" materialized view
+CREATE MATERIALIZED VIEW "public"."lease_read_model" AS
+SELECT token_id, peer_id, affinity_group, created_at, updated_at, expires_at, now() AS refreshed_at
+FROM "public"."leases";
+-- Create index "idx_lease_read_model_token_id" to view: "lease_read_model"
+CREATE UNIQUE INDEX "idx_lease_read_model_token_id" ON "public"."lease_read_model" ("token_id");
+-- Create index "idx_lease_read_model_peer_id" to view: "lease_read_model"
+CREATE INDEX "idx_lease_read_model_peer_id" ON "public"."lease_read_model" ("peer_id");
+-- Create index "idx_lease_read_model_expires_at" to view: "lease_read_model"
+CREATE INDEX "idx_lease_read_model_expires_at" ON "public"."lease_read_model" ("expires_at");
@@ -0,0 +1,12 @@
+-- Create "ha_state" table
+CREATE TABLE "public"."ha_state" (
+  "id" boolean NOT NULL DEFAULT true,
+  "epoch" bigint NOT NULL DEFAULT 0,
+  "active_instance" character varying(255) NOT NULL DEFAULT '',
+  "activated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "ha_state_single_row" CHECK (id)
+);
+-- Ensure ha_state has its only row
+INSERT INTO "public"."ha_state" ("id") VALUES (true)
+ON CONFLICT ("id") DO NOTHING;
@@ -0,0 +1,14 @@
+-- Create "api_keys" table
+CREATE TABLE "public"."api_keys" (
+  "id" bigserial NOT NULL,
+  "name" character varying(64) NOT NULL,
+  "key_prefix" character varying(32) NOT NULL,
+  "key_hash" character(64) NOT NULL,
+  "scopes" text[] NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "last_used_at" timestamptz NULL,
+  "revoked_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_api_keys_key_hash" to table: "api_keys"
+CREATE UNIQUE INDEX "idx_api_keys_key_hash" ON "public"."api_keys" ("key_hash");
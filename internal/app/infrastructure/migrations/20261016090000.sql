@@ -0,0 +1,14 @@
+-- Create "peer_policies" table
+CREATE TABLE "public"."peer_policies" (
+  "id" bigserial NOT NULL,
+  "name" character varying(64) NOT NULL,
+  "peers" text[] NOT NULL,
+  "can_allocate" boolean NOT NULL DEFAULT false,
+  "can_reserve" boolean NOT NULL DEFAULT false,
+  "max_pool" integer NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "peer_policies_max_pool_check" CHECK (max_pool >= 0)
+);
+-- Create index "idx_peer_policies_name" to table: "peer_policies"
+CREATE UNIQUE INDEX "idx_peer_policies_name" ON "public"."peer_policies" ("name");
@@ -0,0 +1,16 @@
+-- Drop "lease_read_model" materialized view, it gains the "labels" column
+DROP MATERIALIZED VIEW "public"."lease_read_model";
+-- Modify "leases" table
+ALTER TABLE "public"."leases" ADD COLUMN "labels" jsonb NULL;
+-- Create "lease_read_model" materialized view
+CREATE MATERIALIZED VIEW "public"."lease_read_model" AS
+SELECT token_id, peer_id, affinity_group, labels, created_at, updated_at, expires_at, now() AS refreshed_at
+FROM "public"."leases";
+-- Create index "idx_lease_read_model_token_id" to view: "lease_read_model"
+CREATE UNIQUE INDEX "idx_lease_read_model_token_id" ON "public"."lease_read_model" ("token_id");
+-- Create index "idx_lease_read_model_peer_id" to view: "lease_read_model"
+CREATE INDEX "idx_lease_read_model_peer_id" ON "public"."lease_read_model" ("peer_id");
+-- Create index "idx_lease_read_model_expires_at" to view: "lease_read_model"
+CREATE INDEX "idx_lease_read_model_expires_at" ON "public"."lease_read_model" ("expires_at");
+-- Create index "idx_lease_read_model_labels" to view: "lease_read_model"
+CREATE INDEX "idx_lease_read_model_labels" ON "public"."lease_read_model" USING GIN ("labels");
@@ -0,0 +1,14 @@
+-- Drop "lease_read_model" materialized view, it gains the "tenant_id" column
+DROP MATERIALIZED VIEW "public"."lease_read_model";
+-- Create "lease_read_model" materialized view
+CREATE MATERIALIZED VIEW "public"."lease_read_model" AS
+SELECT tenant_id, token_id, peer_id, affinity_group, labels, created_at, updated_at, expires_at, now() AS refreshed_at
+FROM "public"."leases";
+-- Create index "idx_lease_read_model_tenant_id_token_id" to view: "lease_read_model"
+CREATE UNIQUE INDEX "idx_lease_read_model_tenant_id_token_id" ON "public"."lease_read_model" ("tenant_id", "token_id");
+-- Create index "idx_lease_read_model_tenant_id_peer_id" to view: "lease_read_model"
+CREATE INDEX "idx_lease_read_model_tenant_id_peer_id" ON "public"."lease_read_model" ("tenant_id", "peer_id");
+-- Create index "idx_lease_read_model_expires_at" to view: "lease_read_model"
+CREATE INDEX "idx_lease_read_model_expires_at" ON "public"."lease_read_model" ("expires_at");
+-- Create index "idx_lease_read_model_labels" to view: "lease_read_model"
+CREATE INDEX "idx_lease_read_model_labels" ON "public"."lease_read_model" USING GIN ("labels");
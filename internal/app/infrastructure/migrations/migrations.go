@@ -0,0 +1,59 @@
+// Package migrations embeds the SQL migrations so the binary can apply them itself.
+// The files are generated by Atlas from schema.hcl; see README.md.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is a single migration file
+type Migration struct {
+	Version string // file name up to the first "_" or ".sql", e.g. "20251003103548"
+	Name    string // file name
+	SQL     string
+}
+
+// All returns the embedded migrations ordered by version
+func All() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		version, _, _ := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %s", other, name, version)
+		}
+		seen[version] = name
+
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Versions returns the versions of the given migrations
+func Versions(migrations []Migration) []string {
+	versions := make([]string, len(migrations))
+	for i, m := range migrations {
+		versions[i] = m.Version
+	}
+	return versions
+}
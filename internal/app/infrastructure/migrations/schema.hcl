@@ -10,7 +10,7 @@ table "nonces" {
         null = false
     }
     column "peer_id" {
-        type = varchar(128)
+        type = varchar(256)
         null = false
     }
     column "issued_at" {
@@ -34,6 +34,13 @@ table "nonces" {
     primary_key {
         columns = [column.id]
     }
+
+    index "idx_nonces_peer_id" {
+        columns = [column.peer_id]
+    }
+    index "idx_nonces_expires_at" {
+        columns = [column.expires_at]
+    }
 }
 
 table "leases" {
@@ -43,7 +50,7 @@ table "leases" {
     null = false
   }
   column "peer_id" {
-    type = varchar(128)
+    type = varchar(256)
     null = false
   }
   column "expires_at" {
@@ -60,6 +67,31 @@ table "leases" {
     null = false
     default = sql("now()")
   }
+  column "affinity_group" {
+    type = varchar(128)
+    null = true
+  }
+  column "reclaimed_at" {
+    type = timestamptz
+    null = true
+  }
+  column "quarantined_until" {
+    type = timestamptz
+    null = true
+  }
+  column "tenant_id" {
+    type = varchar(64)
+    null = false
+    default = "default"
+  }
+  column "delegated_by" {
+    type = varchar(256)
+    null = true
+  }
+  column "labels" {
+    type = jsonb
+    null = true
+  }
 
   primary_key {
     columns = [column.token_id]
@@ -68,6 +100,62 @@ table "leases" {
   index "idx_leases_expires_at" {
     columns = [column.expires_at]
   }
+
+  index "idx_leases_affinity_group" {
+    columns = [column.affinity_group]
+  }
+
+  index "idx_leases_delegated_by" {
+    columns = [column.delegated_by]
+  }
+
+  index "idx_leases_tenant_id_peer_id" {
+    columns = [column.tenant_id, column.peer_id]
+  }
+}
+
+table "lease_history" {
+  schema = schema.public
+  column "id" {
+    type = bigserial
+  }
+  column "token_id" {
+    type = bigint
+    null = false
+  }
+  column "peer_id" {
+    type = varchar(256)
+    null = false
+  }
+  column "event" {
+    type = varchar(16)
+    null = false
+  }
+  column "expires_at" {
+    type = timestamptz
+    null = false
+  }
+  column "occurred_at" {
+    type = timestamptz
+    null = false
+    default = sql("now()")
+  }
+  column "tenant_id" {
+    type = varchar(64)
+    null = false
+    default = "default"
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_lease_history_token_id" {
+    columns = [column.token_id, column.id]
+  }
+  index "idx_lease_history_occurred_at" {
+    columns = [column.occurred_at]
+  }
 }
 
 table "alloc_state" {
@@ -84,8 +172,185 @@ table "alloc_state" {
     null = false
     default = 168162304
   }
+  column "min_token_id" {
+    type = bigint
+    null = false
+    default = 167902210
+  }
+  column "tenant_id" {
+    type = varchar(64)
+    null = false
+    default = "default"
+  }
 
   primary_key {
     columns = [column.id]
   }
+
+  index "idx_alloc_state_tenant_id" {
+    unique  = true
+    columns = [column.tenant_id]
+  }
+}
+
+table "access_rules" {
+  schema = schema.public
+  column "id" {
+    type = bigserial
+  }
+  column "list" {
+    type = varchar(8)
+    null = false
+  }
+  column "subject_type" {
+    type = varchar(16)
+    null = false
+  }
+  column "subject" {
+    type = text
+    null = false
+  }
+  column "reason" {
+    type = text
+    null = false
+    default = ""
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+    default = sql("now()")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_access_rules_subject" {
+    unique  = true
+    columns = [column.subject_type, column.subject, column.list]
+  }
+}
+
+table "api_keys" {
+  schema = schema.public
+  column "id" {
+    type = bigserial
+  }
+  column "name" {
+    type = varchar(64)
+    null = false
+  }
+  column "key_prefix" {
+    type = varchar(32)
+    null = false
+  }
+  column "key_hash" {
+    type = char(64)
+    null = false
+  }
+  column "scopes" {
+    type = sql("text[]")
+    null = false
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+    default = sql("now()")
+  }
+  column "last_used_at" {
+    type = timestamptz
+    null = true
+  }
+  column "revoked_at" {
+    type = timestamptz
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_api_keys_key_hash" {
+    unique  = true
+    columns = [column.key_hash]
+  }
+}
+
+table "peer_policies" {
+  schema = schema.public
+  column "id" {
+    type = bigserial
+  }
+  column "name" {
+    type = varchar(64)
+    null = false
+  }
+  column "peers" {
+    type = sql("text[]")
+    null = false
+  }
+  column "can_allocate" {
+    type = boolean
+    null = false
+    default = false
+  }
+  column "can_reserve" {
+    type = boolean
+    null = false
+    default = false
+  }
+  column "max_pool" {
+    type = integer
+    null = false
+    default = 0
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+    default = sql("now()")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_peer_policies_name" {
+    unique  = true
+    columns = [column.name]
+  }
+
+  check "peer_policies_max_pool_check" {
+    expr = "max_pool >= 0"
+  }
+}
+
+table "ha_state" {
+  schema = schema.public
+  column "id" {
+    type = boolean
+    null = false
+    default = true
+  }
+  column "epoch" {
+    type = bigint
+    null = false
+    default = 0
+  }
+  column "active_instance" {
+    type = varchar(255)
+    null = false
+    default = ""
+  }
+  column "activated_at" {
+    type = timestamptz
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  check "ha_state_single_row" {
+    expr = "id"
+  }
 }
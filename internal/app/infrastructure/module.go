@@ -1,14 +1,28 @@
 package infrastructure
 
 import (
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/events"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/journal"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/logger"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/reload"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/server"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
 	"go.uber.org/fx"
 )
 
 var Module = fx.Options(
 	config.Module,
+	events.Module,
+	journal.Module,
 	logger.Module,
+	reload.Module,
 	server.Module,
+	fx.Provide(
+		fx.Annotate(
+			clock.NewSystem,
+			fx.As(new(ports.Clock)),
+		),
+	),
 )
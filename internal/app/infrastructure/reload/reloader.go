@@ -0,0 +1,122 @@
+// Package reload applies configuration changes without a restart. A reload is triggered
+// by SIGHUP or, with config_watch enabled, by a change of the configuration file.
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var Module = fx.Options(
+	fx.Provide(
+		fx.Annotate(
+			NewReloader,
+			fx.ParamTags(``, ``, ``, `group:"reloadable"`),
+		),
+	),
+)
+
+// Reloader re-reads the configuration and hands the reloadable settings to every
+// registered config.Reloadable. Settings that need a restart are logged and ignored.
+type Reloader struct {
+	logger  *zap.Logger
+	targets []config.Reloadable
+
+	mu      sync.Mutex
+	current *config.AppConfig
+
+	trigger chan struct{}
+	done    chan struct{}
+}
+
+func NewReloader(lc fx.Lifecycle, cfg *config.AppConfig, logger *zap.Logger, targets []config.Reloadable) *Reloader {
+	r := &Reloader{
+		logger:  logger.Named("reload"),
+		targets: targets,
+		current: cfg,
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	signals := make(chan os.Signal, 1)
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			signal.Notify(signals, syscall.SIGHUP)
+			if cfg.ConfigWatch {
+				// Editors write a file in several steps, the buffered trigger coalesces them
+				viper.OnConfigChange(func(fsnotify.Event) { r.Trigger() })
+				viper.WatchConfig()
+			}
+			go r.loop(signals)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			signal.Stop(signals)
+			close(r.done)
+			return nil
+		},
+	})
+
+	return r
+}
+
+// Trigger schedules a reload unless one is already pending
+func (r *Reloader) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Reloader) loop(signals <-chan os.Signal) {
+	for {
+		select {
+		case <-signals:
+			r.logger.Info("Received SIGHUP, reloading configuration")
+		case <-r.trigger:
+			r.logger.Info("Configuration file changed, reloading configuration")
+		case <-r.done:
+			return
+		}
+
+		if _, err := r.Reload(); err != nil {
+			r.logger.Error("Failed to reload configuration, keeping the current one", zap.Error(err))
+		}
+	}
+}
+
+// Reload reads the configuration again and applies the reloadable settings that changed
+func (r *Reloader) Reload() (*config.ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result, err := config.Reload(r.current)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Ignored) > 0 {
+		r.logger.Warn("Changed settings need a restart and were ignored", zap.Strings("keys", result.Ignored))
+	}
+	if len(result.Applied) == 0 {
+		r.logger.Info("No reloadable settings changed")
+		return result, nil
+	}
+
+	for _, target := range r.targets {
+		target.ApplyConfig(result.Config)
+	}
+	r.current = result.Config
+
+	r.logger.Info("Applied reloaded configuration", zap.Strings("keys", result.Applied))
+	return result, nil
+}
@@ -18,18 +18,18 @@ type HTTPServer struct {
 
 func NewHTTPServer(lc fx.Lifecycle, cfg *config.AppConfig, router *handlers.Router, logger *zap.Logger) *HTTPServer {
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", cfg.Port),
+		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler: router.Mux,
 	}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+			ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
 			if err != nil {
 				return err
 			}
 
-			logger.With(zap.Int("port", cfg.Port)).Info("HTTPServer is running")
+			logger.With(zap.Int("port", cfg.Server.Port)).Info("HTTPServer is running")
 
 			go server.Serve(ln)
 			return nil
@@ -0,0 +1,48 @@
+// Package clock provides the system clock and a fake clock that tests move by hand.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// System reads the time of the operating system
+type System struct{}
+
+func NewSystem() System {
+	return System{}
+}
+
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Fake only moves when it is set or advanced. It is safe for concurrent use.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set moves the clock to t, which may lie in the past
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = t
+}
+
+// Advance moves the clock forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
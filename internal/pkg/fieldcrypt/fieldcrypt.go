@@ -0,0 +1,114 @@
+// Package fieldcrypt encrypts single values deterministically with AES-256-GCM, so that
+// encrypted columns and cache keys can still be looked up by equality.
+//
+// The nonce of a value is derived from an HMAC of the value itself (a synthetic IV), so
+// the same plaintext always encrypts to the same ciphertext under the same key. This
+// reveals which stored values are equal, and nothing else about them.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length of a key in bytes
+const KeySize = 32
+
+// Prefix marks encrypted values. Values without it are passed through by Decrypt, so
+// rows written before encryption was enabled stay readable.
+const Prefix = "enc1:"
+
+var encoding = base64.RawURLEncoding
+
+// ErrMalformed is returned for values carrying Prefix that do not decrypt under the key
+var ErrMalformed = errors.New("fieldcrypt: malformed or foreign ciphertext")
+
+// Cipher encrypts and decrypts values with one key. It is safe for concurrent use.
+type Cipher struct {
+	aead   cipher.AEAD
+	macKey []byte
+}
+
+// ParseKey decodes a base64 (standard or URL encoding, padding optional) key of KeySize
+// bytes, ignoring surrounding whitespace
+func ParseKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimRight(strings.TrimSpace(encoded), "=")
+	key, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		key, err = base64.RawURLEncoding.DecodeString(encoded)
+	}
+	if err != nil {
+		return nil, errors.New("key is not valid base64")
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key is %d bytes, expected %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// New derives separate encryption and nonce keys from key, which must be KeySize bytes
+func New(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("fieldcrypt: key is %d bytes, expected %d", len(key), KeySize)
+	}
+
+	encKey, err := hkdf.Key(sha256.New, key, nil, "dhcp2p field encryption", KeySize)
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := hkdf.Key(sha256.New, key, nil, "dhcp2p field nonce", KeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead, macKey: macKey}, nil
+}
+
+// Encrypt returns Prefix followed by the base64url encoded nonce and sealed value. The
+// empty string stays empty, so optional columns keep their meaning.
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write([]byte(plaintext))
+	nonce := mac.Sum(nil)[:c.aead.NonceSize()]
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return Prefix + encoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt. Values without Prefix are returned unchanged.
+func (c *Cipher) Decrypt(ciphertext string) (string, error) {
+	encoded, ok := strings.CutPrefix(ciphertext, Prefix)
+	if !ok {
+		return ciphertext, nil
+	}
+
+	sealed, err := encoding.DecodeString(encoded)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,142 @@
+// Package retry runs operations again after transient failures, waiting with exponential
+// backoff and jitter between attempts.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+)
+
+// DefaultJitter randomizes delays by up to 20%, enough to spread out clients that
+// failed together
+const DefaultJitter = 0.2
+
+// Policy describes how often and how patiently an operation is retried
+type Policy struct {
+	MaxAttempts  int           // attempts including the first one, at least 1
+	InitialDelay time.Duration // wait before the second attempt
+	MaxDelay     time.Duration // cap on a single wait, 0 leaves it uncapped
+	Multiplier   float64       // growth of the wait per attempt, 2 when not above 1
+	Jitter       float64       // fraction of each wait that is randomized, between 0 and 1
+
+	// Retryable tells whether an error may go away on another attempt. Nil retries
+	// every error. Errors wrapped with Permanent are never retried.
+	Retryable func(err error) bool
+	// OnRetry is called before waiting for the next attempt
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable or the
+// attempts are used up, and returns its last error. When ctx is done while waiting,
+// the last error is returned joined with the context's error.
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := max(p.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= attempts || (p.Retryable != nil && !p.Retryable(err)) {
+			return err
+		}
+
+		delay := p.Delay(attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// DoValue is Do for operations returning a value
+func DoValue[T any](ctx context.Context, p Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := p.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// Delay returns the wait after the given failed attempt, counted from 1
+func (p Policy) Delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialDelay)
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+		if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 {
+		delay = min(delay, float64(p.MaxDelay))
+	}
+
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error returned to Do as final, it is returned without retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// IsTransient reports whether err comes from a failure that typically clears up on its
+// own: a network timeout, a refused or reset connection, a connection closed mid-reply,
+// or a driver error marked safe to retry. Context cancellation is never transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Implemented by pgconn errors for failures before the server saw the query
+	var safe interface{ SafeToRetry() bool }
+	if errors.As(err, &safe) && safe.SafeToRetry() {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed)
+}
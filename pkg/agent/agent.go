@@ -0,0 +1,341 @@
+// Package agent keeps a peer's lease alive. An Agent obtains a lease, renews it at a
+// fraction of its lifetime, allocates again when the lease is lost, and hands every new
+// address to its Appliers. With a lease file it resumes its lease after a restart.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	"go.uber.org/zap"
+)
+
+// Agent states
+const (
+	StateAllocating = "allocating" // no lease yet, or the previous one was lost
+	StateBound      = "bound"      // holding a lease
+	StateRenewing   = "renewing"   // holding a lease whose renewal failed so far
+	StateStopped    = "stopped"
+)
+
+// Config controls the renewal schedule
+type Config struct {
+	// RenewFraction of the remaining lifetime after which a lease is renewed, in (0, 1).
+	// It applies when the server does not suggest a renewal time.
+	RenewFraction float64
+	// RetryInterval is the wait after a failed allocation or renewal
+	RetryInterval time.Duration
+	// ReleaseOnStop gives the lease back when Run returns
+	ReleaseOnStop bool
+	// LeaseFile keeps the held lease, its token ID, expiry and server, so that a restarted
+	// agent resumes it rather than allocating a new address; empty keeps it in memory only
+	LeaseFile string
+}
+
+// DefaultConfig renews halfway through the lease and retries every 10 seconds
+var DefaultConfig = Config{RenewFraction: 0.5, RetryInterval: 10 * time.Second}
+
+// Status is a snapshot of the agent
+type Status struct {
+	PeerID        string        `json:"peer_id"`
+	State         string        `json:"state"`
+	Lease         *client.Lease `json:"lease,omitempty"`
+	IP            string        `json:"ip,omitempty"`
+	NextRenewal   time.Time     `json:"next_renewal,omitzero"`
+	LastRenewal   time.Time     `json:"last_renewal,omitzero"`
+	Renewals      int           `json:"renewals"`
+	Allocations   int           `json:"allocations"`
+	LastError     string        `json:"last_error,omitempty"`
+	LastErrorTime time.Time     `json:"last_error_time,omitzero"`
+}
+
+// Applier makes an assigned address effective, e.g. by writing it to a file. previous
+// is the address applied before, or empty.
+type Applier interface {
+	Apply(ctx context.Context, ip, previous string) error
+}
+
+type Agent struct {
+	client   *client.Client
+	cfg      Config
+	appliers []Applier
+	logger   *zap.Logger
+
+	mu     sync.RWMutex
+	status Status
+}
+
+func New(c *client.Client, cfg Config, appliers []Applier, logger *zap.Logger) (*Agent, error) {
+	if cfg.RenewFraction <= 0 || cfg.RenewFraction >= 1 {
+		return nil, fmt.Errorf("renew fraction must be between 0 and 1, got %v", cfg.RenewFraction)
+	}
+	if cfg.RetryInterval <= 0 {
+		return nil, fmt.Errorf("retry interval must be positive")
+	}
+
+	return &Agent{
+		client:   c,
+		cfg:      cfg,
+		appliers: appliers,
+		logger:   logger,
+		status:   Status{PeerID: c.PeerID(), State: StateAllocating},
+	}, nil
+}
+
+// Status returns a snapshot of the agent's state
+func (a *Agent) Status() Status {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	status := a.status
+	if status.Lease != nil {
+		lease := *status.Lease
+		status.Lease = &lease
+	}
+	return status
+}
+
+// Run keeps the lease alive until ctx is cancelled, starting from the saved lease
+func (a *Agent) Run(ctx context.Context) error {
+	defer a.stop()
+
+	wait := a.resume(ctx)
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		if a.lease() == nil {
+			wait = a.allocate(ctx)
+		} else {
+			wait = a.renew(ctx)
+		}
+	}
+}
+
+// resume takes over the lease saved by a previous run when the server confirms that the
+// peer still holds it, and returns the time until the next step. A lease the server does
+// not confirm is allocated again, asking for the same token ID; while the server cannot
+// be reached, the saved lease is applied and renewed like a held one.
+func (a *Agent) resume(ctx context.Context) time.Duration {
+	if a.cfg.LeaseFile == "" {
+		return 0
+	}
+	saved, err := loadLease(a.cfg.LeaseFile)
+	if err != nil {
+		a.fail("resume", err)
+		return 0
+	}
+	if saved == nil {
+		return 0
+	}
+	if saved.Server != a.client.BaseURL() || saved.PeerID != a.client.PeerID() {
+		a.logger.Info("Ignoring the saved lease of another server or peer", zap.String("server", saved.Server), zap.String("peerID", saved.PeerID))
+		return 0
+	}
+
+	// Allocations ask for the saved token ID from now on
+	previous := &client.Lease{TokenID: saved.TokenID, PeerID: saved.PeerID, ExpiresAt: saved.ExpiresAt}
+	a.mu.Lock()
+	a.status.Lease = previous
+	a.mu.Unlock()
+
+	if !time.Now().Before(saved.ExpiresAt) {
+		a.logger.Info("Saved lease expired, allocating again", zap.Int64("tokenID", saved.TokenID))
+		return 0
+	}
+
+	lease, err := a.client.GetLease(ctx, saved.PeerID)
+	if err == nil && lease.TokenID != saved.TokenID {
+		a.logger.Info("Peer holds another lease than the saved one, allocating again", zap.Int64("tokenID", saved.TokenID), zap.Int64("heldTokenID", lease.TokenID))
+		return 0
+	}
+	if err != nil {
+		if lost(err) {
+			a.logger.Info("Saved lease lost, allocating again", zap.Int64("tokenID", saved.TokenID), zap.Error(err))
+			return 0
+		}
+
+		a.fail("resume", err)
+		a.apply(ctx, previous.IP())
+		a.mu.Lock()
+		a.status.State = StateRenewing
+		a.mu.Unlock()
+		return 0
+	}
+
+	a.logger.Info("Lease resumed", zap.Int64("tokenID", lease.TokenID), zap.String("ip", lease.IP()))
+	return a.bind(ctx, lease, bindResumed)
+}
+
+// allocate obtains a lease, preferring the token ID held before, and returns the time
+// until the next step
+func (a *Agent) allocate(ctx context.Context) time.Duration {
+	req := &client.AllocateRequest{}
+	if previous := a.Status().Lease; previous != nil {
+		req.TokenID = previous.TokenID
+	}
+
+	allocation, err := a.client.AllocateIP(ctx, req)
+	if err == nil && allocation.Lease == nil {
+		err = errors.New("server returned no lease")
+	}
+	if err != nil {
+		a.fail("allocate", err)
+		return a.cfg.RetryInterval
+	}
+
+	a.logger.Info("Lease allocated", zap.Int64("tokenID", allocation.Lease.TokenID), zap.String("ip", allocation.Lease.IP()))
+	return a.bind(ctx, allocation.Lease, bindAllocated)
+}
+
+// renew extends the held lease. A lease the server no longer knows, or that is leased to
+// another peer, is allocated again; so is one that expired while renewals kept failing.
+func (a *Agent) renew(ctx context.Context) time.Duration {
+	current := a.lease()
+
+	lease, err := a.client.RenewLease(ctx, current.TokenID)
+	if err == nil {
+		return a.bind(ctx, lease, bindRenewed)
+	}
+	a.fail("renew", err)
+
+	if lost(err) || !time.Now().Before(current.ExpiresAt) {
+		a.logger.Warn("Lease lost, allocating again", zap.Int64("tokenID", current.TokenID), zap.Error(err))
+		a.mu.Lock()
+		a.status.State = StateAllocating
+		a.mu.Unlock()
+		return 0
+	}
+
+	a.mu.Lock()
+	a.status.State = StateRenewing
+	a.mu.Unlock()
+
+	// Keep retrying, but never sleep past the expiry
+	return min(a.cfg.RetryInterval, time.Until(current.ExpiresAt))
+}
+
+// How bind obtained a lease
+const (
+	bindAllocated = iota
+	bindRenewed
+	bindResumed
+)
+
+// bind records and saves the lease, applies a changed address and schedules the renewal
+func (a *Agent) bind(ctx context.Context, lease *client.Lease, how int) time.Duration {
+	a.apply(ctx, lease.IP())
+	if a.cfg.LeaseFile != "" {
+		saved := &savedLease{Server: a.client.BaseURL(), PeerID: a.client.PeerID(), TokenID: lease.TokenID, ExpiresAt: lease.ExpiresAt}
+		if err := saveLease(a.cfg.LeaseFile, saved); err != nil {
+			a.fail("save", err)
+		}
+	}
+
+	wait := a.renewalDelay(lease)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.status.State = StateBound
+	a.status.Lease = lease
+	a.status.NextRenewal = time.Now().Add(wait)
+	switch how {
+	case bindAllocated:
+		a.status.Allocations++
+	case bindRenewed:
+		a.status.Renewals++
+		a.status.LastRenewal = time.Now()
+	}
+	return wait
+}
+
+// apply hands an address that differs from the applied one to the appliers
+func (a *Agent) apply(ctx context.Context, ip string) {
+	previous := a.Status().IP
+	if ip == previous {
+		return
+	}
+
+	for _, applier := range a.appliers {
+		if err := applier.Apply(ctx, ip, previous); err != nil {
+			a.fail("apply", err)
+		}
+	}
+
+	a.mu.Lock()
+	a.status.IP = ip
+	a.mu.Unlock()
+}
+
+// renewalDelay returns the time until lease should be renewed: the renewal time suggested by
+// the server when it lies before the expiry, RenewFraction of the remaining lifetime otherwise
+func (a *Agent) renewalDelay(lease *client.Lease) time.Duration {
+	remaining := time.Until(lease.ExpiresAt)
+	if lease.RenewAfter != nil {
+		if wait := time.Until(*lease.RenewAfter); wait < remaining {
+			return max(wait, 0)
+		}
+	}
+	return time.Duration(float64(remaining) * a.cfg.RenewFraction)
+}
+
+func (a *Agent) lease() *client.Lease {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.status.State == StateAllocating {
+		return nil
+	}
+	return a.status.Lease
+}
+
+func (a *Agent) fail(op string, err error) {
+	a.logger.Warn("Lease "+op+" failed", zap.Error(err))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status.LastError = fmt.Sprintf("%s: %v", op, err)
+	a.status.LastErrorTime = time.Now()
+}
+
+func (a *Agent) stop() {
+	if lease := a.lease(); lease != nil && a.cfg.ReleaseOnStop {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := a.client.ReleaseLease(ctx, lease.TokenID); err != nil {
+			a.logger.Warn("Failed to release lease", zap.Int64("tokenID", lease.TokenID), zap.Error(err))
+		} else {
+			a.logger.Info("Lease released", zap.Int64("tokenID", lease.TokenID))
+			if a.cfg.LeaseFile != "" {
+				if err := removeLease(a.cfg.LeaseFile); err != nil {
+					a.logger.Warn("Failed to remove the lease file", zap.Error(err))
+				}
+			}
+		}
+	}
+
+	a.mu.Lock()
+	a.status.State = StateStopped
+	a.mu.Unlock()
+}
+
+// lost reports whether a renewal error means the lease no longer belongs to the peer
+func lost(err error) bool {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusConflict
+}
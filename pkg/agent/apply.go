@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// FileApplier writes the address to a file, followed by a newline. The file is replaced
+// atomically so readers never see a partial write.
+type FileApplier struct {
+	Path string
+}
+
+func (f FileApplier) Apply(_ context.Context, ip, _ string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), "."+filepath.Base(f.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write IP file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(ip + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write IP file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write IP file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write IP file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return fmt.Errorf("failed to write IP file: %w", err)
+	}
+	return nil
+}
+
+// InterfaceApplier assigns the address to a network interface with iproute2, removing
+// the previous one. It needs CAP_NET_ADMIN.
+type InterfaceApplier struct {
+	Interface    string
+	PrefixLength int
+	// Run executes a command; nil runs it with os/exec
+	Run func(ctx context.Context, name string, args ...string) error
+}
+
+func (i InterfaceApplier) Apply(ctx context.Context, ip, previous string) error {
+	run := i.Run
+	if run == nil {
+		run = runCommand
+	}
+
+	if err := run(ctx, "ip", "addr", "replace", i.prefix(ip), "dev", i.Interface); err != nil {
+		return fmt.Errorf("failed to assign %s to %s: %w", ip, i.Interface, err)
+	}
+	if previous != "" && previous != ip {
+		if err := run(ctx, "ip", "addr", "del", i.prefix(previous), "dev", i.Interface); err != nil {
+			return fmt.Errorf("failed to remove %s from %s: %w", previous, i.Interface, err)
+		}
+	}
+	return nil
+}
+
+func (i InterfaceApplier) prefix(ip string) string {
+	return fmt.Sprintf("%s/%d", ip, i.PrefixLength)
+}
+
+func runCommand(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
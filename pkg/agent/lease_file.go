@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// savedLease is the lease an agent keeps in Config.LeaseFile, so that an agent restarted
+// after a crash or an upgrade resumes it instead of allocating a new address
+type savedLease struct {
+	Server    string    `json:"server"`
+	PeerID    string    `json:"peer_id"`
+	TokenID   int64     `json:"token_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// loadLease reads the saved lease, or returns nil when there is none
+func loadLease(path string) (*savedLease, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease file: %w", err)
+	}
+
+	lease := &savedLease{}
+	if err := json.Unmarshal(data, lease); err != nil {
+		return nil, fmt.Errorf("failed to parse lease file: %w", err)
+	}
+	return lease, nil
+}
+
+// saveLease replaces the lease file atomically, creating its directory if needed, so a
+// crash never leaves a partial file behind
+func saveLease(path string, lease *savedLease) error {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	return nil
+}
+
+// removeLease deletes the lease file after the lease was released
+func removeLease(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove lease file: %w", err)
+	}
+	return nil
+}
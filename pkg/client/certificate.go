@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+)
+
+var (
+	// ErrUnsignedLease is returned by VerifyLease for a lease without signature
+	ErrUnsignedLease = errors.New("dhcp2p: lease is not signed")
+	// ErrInvalidLeaseSignature is returned by VerifyLease when the signature does not match
+	ErrInvalidLeaseSignature = errors.New("dhcp2p: invalid lease signature")
+	// ErrLeaseSigningDisabled is returned by ServerInfo.Key for servers that do not sign leases
+	ErrLeaseSigningDisabled = errors.New("dhcp2p: server does not sign leases")
+	// ErrUnknownSigningKey is returned by ServerKeySet.VerifyLease for a lease signed with a
+	// key the set does not hold
+	ErrUnknownSigningKey = errors.New("dhcp2p: lease signed with an unknown server key")
+)
+
+// ServerKey is a key the server signs or signed lease certificates with
+type ServerKey struct {
+	KeyID     string `json:"key_id"`
+	PublicKey []byte `json:"public_key"` // marshalled libp2p public key
+	PeerID    string `json:"peer_id"`
+	Current   bool   `json:"current"` // leases are signed with the current key, the others are retired
+}
+
+// ServerKeySet is the set of keys the server publishes for verifying lease certificates.
+// Servers rotate their signing key; a retired key stays in the set while certificates it
+// signed are in use.
+type ServerKeySet struct {
+	CurrentKeyID string       `json:"current_key_id"`
+	Keys         []*ServerKey `json:"keys"`
+}
+
+// ServerInfo returns the description of the server, including the key its lease
+// signatures verify against. Fetch it once over a trusted connection and keep the key.
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	info := &ServerInfo{}
+	if err := c.do(ctx, http.MethodGet, "/v1/server-info", nil, false, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ServerKeys returns the keys the server's lease signatures verify against. Fetch them
+// over a trusted connection and again when a lease names a key the set does not hold.
+func (c *Client) ServerKeys(ctx context.Context) (*ServerKeySet, error) {
+	keys := &ServerKeySet{}
+	if err := c.do(ctx, http.MethodGet, "/v1/server-info/keys", nil, false, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Key decodes the public key with keyID
+func (s *ServerKeySet) Key(keyID string) (crypto.PubKey, error) {
+	for _, key := range s.Keys {
+		if key.KeyID == keyID {
+			return crypto.UnmarshalPublicKey(key.PublicKey)
+		}
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+// VerifyLease checks lease against the key of the set it names, see VerifyLease. A lease
+// without key ID is checked against the current key.
+func (s *ServerKeySet) VerifyLease(lease *Lease) error {
+	if len(lease.Signature) == 0 {
+		return ErrUnsignedLease
+	}
+
+	keyID := lease.KeyID
+	if keyID == "" {
+		keyID = s.CurrentKeyID
+	}
+	key, err := s.Key(keyID)
+	if err != nil {
+		return err
+	}
+	return VerifyLease(key, lease)
+}
+
+// Key decodes the server public key
+func (i *ServerInfo) Key() (crypto.PubKey, error) {
+	if !i.LeaseSigning || i.PublicKey == "" {
+		return nil, ErrLeaseSigningDisabled
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(i.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("dhcp2p: server public key is not base64: %w", err)
+	}
+	return crypto.UnmarshalPublicKey(raw)
+}
+
+// VerifyLease checks that lease was signed by the server holding serverKey, which proves
+// that lease.PeerID was given lease.TokenID of the pool of lease.Tenant until
+// lease.ExpiresAt. Peers can verify a
+// lease shown to them without contacting the server. Whether the lease has expired, or
+// was released early, is up to the caller.
+func VerifyLease(serverKey crypto.PubKey, lease *Lease) error {
+	if len(lease.Signature) == 0 {
+		return ErrUnsignedLease
+	}
+
+	ok, err := serverKey.Verify(dhcp2pcrypto.LeaseCertificatePayload(lease.Tenant, lease.TokenID, lease.PeerID, lease.ExpiresAt), lease.Signature)
+	if err != nil {
+		return fmt.Errorf("dhcp2p: verify lease signature: %w", err)
+	}
+	if !ok {
+		return ErrInvalidLeaseSignature
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+)
+
+// ErrNoKey is returned by calls that need authentication on a client created without a key
+var ErrNoKey = errors.New("dhcp2p: client has no private key")
+
+// Client calls the dhcp2p API on behalf of one peer identity. Authenticated calls request
+// a nonce, sign it with the peer's key and send the X-Pubkey, X-Nonce and X-Signature
+// headers; each attempt uses a fresh nonce. A Client is safe for concurrent use.
+type Client struct {
+	baseURL       string
+	httpClient    *http.Client
+	middlewares   []Middleware
+	retry         RetryPolicy
+	signTimestamp bool
+	authLookups   bool
+	ethereum      bool
+	apiKey        string
+	zone          string
+
+	key    crypto.PrivKey
+	pubkey string // base64-encoded marshalled public key
+	peerID string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends requests with hc instead of a client with a 30 second timeout.
+// hc itself is not modified; middlewares wrap a copy of it.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithMiddleware adds middlewares around every request, see Chain
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithSignedTimestamp binds the current time into every signature through X-Timestamp,
+// as required by servers with security.timestamp_required set
+func WithSignedTimestamp() Option {
+	return func(c *Client) {
+		c.signTimestamp = true
+	}
+}
+
+// WithAuthenticatedLookups authenticates GetLease and GetLeaseByTokenID too, as required
+// by servers running in strict mode (security.lookup_auth_required)
+func WithAuthenticatedLookups() Option {
+	return func(c *Client) {
+		c.authLookups = true
+	}
+}
+
+// WithEthereumIdentity reports the Ethereum address of the key as PeerID, matching
+// servers with security.identity_scheme set to ethereum. The key must be a secp256k1 key.
+func WithEthereumIdentity() Option {
+	return func(c *Client) {
+		c.ethereum = true
+	}
+}
+
+// WithAPIKey sends apiKey in the X-API-Key header, so that the server serves the client
+// from the pool of the tenant owning the key
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithZone sends zone in the X-Zone header, so that the server allocates the peer an
+// address of that rack or site when one is free. See ServerInfo pools for the zones.
+func WithZone(zone string) Option {
+	return func(c *Client) {
+		c.zone = zone
+	}
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8088". key is
+// the peer's libp2p private key; it may be nil when only lookups are used.
+func New(baseURL string, key crypto.PrivKey, opts ...Option) (*Client, error) {
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("dhcp2p: invalid base URL: %w", err)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if len(c.middlewares) > 0 {
+		hc := *c.httpClient
+		hc.Transport = Chain(hc.Transport, c.middlewares...)
+		c.httpClient = &hc
+	}
+
+	if key != nil {
+		pub, err := crypto.MarshalPublicKey(key.GetPublic())
+		if err != nil {
+			return nil, fmt.Errorf("dhcp2p: marshal public key: %w", err)
+		}
+		id, err := PeerIdentity(key, c.ethereum)
+		if err != nil {
+			return nil, fmt.Errorf("dhcp2p: derive peer ID: %w", err)
+		}
+		c.key = key
+		c.pubkey = base64.StdEncoding.EncodeToString(pub)
+		c.peerID = id
+	}
+
+	return c, nil
+}
+
+// PeerID returns the identity of the client's key, or "" without a key
+func (c *Client) PeerID() string {
+	return c.peerID
+}
+
+// BaseURL returns the URL of the server the client talks to, without a trailing slash
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// PeerIdentity returns the identity a server derives from key: its libp2p peer ID, or its
+// Ethereum address for servers using Ethereum identities
+func PeerIdentity(key crypto.PrivKey, ethereum bool) (string, error) {
+	if ethereum {
+		return dhcp2pcrypto.EthereumAddress(key.GetPublic())
+	}
+
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// AllocateIP allocates a lease for the client's peer, or returns the lease it already
+// holds. req may be nil.
+func (c *Client) AllocateIP(ctx context.Context, req *AllocateRequest) (*Allocation, error) {
+	query := url.Values{}
+	if req != nil && req.TokenID != 0 {
+		query.Set("tokenID", strconv.FormatInt(req.TokenID, 10))
+	}
+	if req != nil && req.AffinityGroup != "" {
+		query.Set("affinityGroup", req.AffinityGroup)
+	}
+	if req != nil && req.Labels != nil {
+		query.Set("labels", labelsQuery(req.Labels))
+	}
+
+	// The response only carries the allocation details when a token ID was requested
+	if query.Has("tokenID") {
+		allocation := &Allocation{}
+		if err := c.do(ctx, http.MethodPost, "/allocate-ip", query, true, allocation); err != nil {
+			return nil, err
+		}
+		return allocation, nil
+	}
+
+	lease := &Lease{}
+	if err := c.do(ctx, http.MethodPost, "/allocate-ip", query, true, lease); err != nil {
+		return nil, err
+	}
+	return &Allocation{Lease: lease}, nil
+}
+
+// RenewLease extends the client's lease on tokenID by the server's lease TTL
+func (c *Client) RenewLease(ctx context.Context, tokenID int64) (*Lease, error) {
+	lease := &Lease{}
+	if err := c.do(ctx, http.MethodPost, "/renew-lease", tokenQuery(tokenID), true, lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// ReleaseLease gives the client's lease on tokenID back to the pool. When a retry
+// follows a release whose response was lost, the retry fails with LEASE_NOT_FOUND.
+func (c *Client) ReleaseLease(ctx context.Context, tokenID int64) error {
+	return c.do(ctx, http.MethodPost, "/release-lease", tokenQuery(tokenID), true, nil)
+}
+
+// RevokeNonces invalidates the nonces the server issued to the client's key and that were
+// not used yet, e.g. when requests signed with them may have been intercepted. It returns
+// how many were revoked; the nonce authenticating the call itself is used up anyway.
+func (c *Client) RevokeNonces(ctx context.Context) (int64, error) {
+	var resp struct {
+		Revoked int64 `json:"revoked"`
+	}
+	if err := c.do(ctx, http.MethodDelete, "/v1/nonces", nil, true, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Revoked, nil
+}
+
+// GetLease returns the active lease of peerID. It fails with LEASE_NOT_FOUND when the
+// peer holds none.
+func (c *Client) GetLease(ctx context.Context, peerID string) (*Lease, error) {
+	lease := &Lease{}
+	if err := c.do(ctx, http.MethodGet, "/lease/peer-id/"+url.PathEscape(peerID), nil, c.authLookups, lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// GetLeaseByTokenID returns the active lease on tokenID
+func (c *Client) GetLeaseByTokenID(ctx context.Context, tokenID int64) (*Lease, error) {
+	lease := &Lease{}
+	if err := c.do(ctx, http.MethodGet, "/lease/token-id/"+strconv.FormatInt(tokenID, 10), nil, c.authLookups, lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// do performs a call, retrying it according to the retry policy
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, auth bool, out any) error {
+	if auth && c.key == nil {
+		return ErrNoKey
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.attempt(ctx, method, path, query, auth, out)
+		if err == nil || !retryable(err) || attempt >= c.retry.MaxAttempts {
+			return err
+		}
+
+		timer := time.NewTimer(c.retry.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, path string, query url.Values, auth bool, out any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return err
+	}
+
+	if auth {
+		if err := c.authenticate(ctx, req.Header); err != nil {
+			return err
+		}
+	}
+
+	return c.send(req, out)
+}
+
+// authenticate requests a nonce and sets the signed authentication headers
+func (c *Client) authenticate(ctx context.Context, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/request-auth", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Pubkey", c.pubkey)
+
+	var auth struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := c.send(req, &auth); err != nil {
+		return err
+	}
+
+	timestamp := ""
+	if c.signTimestamp {
+		timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+		header.Set("X-Timestamp", timestamp)
+	}
+
+	signature, err := c.key.Sign(dhcp2pcrypto.AuthPayload(auth.Nonce, timestamp))
+	if err != nil {
+		return fmt.Errorf("dhcp2p: sign nonce: %w", err)
+	}
+
+	header.Set("X-Pubkey", c.pubkey)
+	header.Set("X-Nonce", auth.Nonce)
+	header.Set("X-Signature", base64.StdEncoding.EncodeToString(signature))
+	return nil
+}
+
+// send performs a single request and decodes the data of a successful response into out
+func (c *Client) send(req *http.Request, out any) error {
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.zone != "" {
+		req.Header.Set("X-Zone", c.zone)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+		if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Code == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	envelope := struct {
+		Data any `json:"data"`
+	}{Data: out}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("dhcp2p: decode response: %w", err)
+	}
+	return nil
+}
+
+func tokenQuery(tokenID int64) url.Values {
+	return url.Values{"tokenID": {strconv.FormatInt(tokenID, 10)}}
+}
+
+// labelsQuery encodes labels as the key=value list of the labels parameter
+func labelsQuery(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ",")
+}
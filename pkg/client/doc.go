@@ -0,0 +1,23 @@
+// Package client is the Go SDK for the dhcp2p lease API.
+//
+// A Client acts for one peer identity given by its libp2p private key. Authenticated
+// calls such as AllocateIP, RenewLease and ReleaseLease perform the authentication
+// dance on every attempt: they request a nonce, sign it and send the X-Pubkey, X-Nonce
+// and X-Signature headers. Failed calls are retried according to a RetryPolicy.
+//
+//	c, err := client.New("http://localhost:8088", key)
+//	if err != nil {
+//		return err
+//	}
+//	allocation, err := c.AllocateIP(ctx, nil)
+//
+// Every request the SDK sends passes through a chain of Middleware, so embedding
+// applications can add logging, metrics, custom headers or trace propagation without
+// forking the SDK. A Middleware wraps an http.RoundTripper; Chain composes them around
+// a base transport, WithMiddleware installs them on a Client, and the helpers in this
+// package cover the common cases.
+//
+// Servers with a key configured sign the leases they issue. VerifyLease checks such a
+// lease against the server key from ServerInfo, so peers can prove address ownership to
+// each other without contacting the server.
+package client
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+// A key file holds one base64-encoded libp2p private key, as produced by
+// crypto.MarshalPrivateKey, and is only readable by its owner.
+
+// LoadKey reads the private key stored at path. Besides key files written by SaveKey it
+// accepts a hex-encoded secp256k1 private key as exported by Ethereum wallets, with or
+// without 0x prefix.
+func LoadKey(path string) (crypto.PrivKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(content))
+
+	if secret, err := hex.DecodeString(strings.TrimPrefix(text, "0x")); err == nil && len(secret) == 32 {
+		key, err := crypto.UnmarshalSecp256k1PrivateKey(secret)
+		if err != nil {
+			return nil, fmt.Errorf("dhcp2p: key file %s: %w", path, err)
+		}
+		return key, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("dhcp2p: key file %s is not base64: %w", path, err)
+	}
+
+	key, err := crypto.UnmarshalPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("dhcp2p: key file %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// SaveKey writes key to path, creating missing directories. An existing file is never
+// overwritten, so a peer identity cannot be replaced by accident.
+func SaveKey(path string, key crypto.PrivKey) error {
+	raw, err := crypto.MarshalPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(raw) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// GenerateKey creates a new Ed25519 identity and saves it to path
+func GenerateKey(path string) (crypto.PrivKey, error) {
+	return GenerateKeyOfType(path, crypto.Ed25519)
+}
+
+// GenerateKeyOfType creates a new identity of keyType, crypto.Ed25519 or
+// crypto.Secp256k1, and saves it to path. Servers using Ethereum identities need
+// Secp256k1 keys.
+func GenerateKeyOfType(path string, keyType int) (crypto.PrivKey, error) {
+	if keyType != crypto.Ed25519 && keyType != crypto.Secp256k1 {
+		return nil, fmt.Errorf("dhcp2p: unsupported key type %d", keyType)
+	}
+
+	key, _, err := crypto.GenerateKeyPairWithReader(keyType, 0, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveKey(path, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// LoadOrGenerateKey loads the key at path, generating one when the file does not exist
+func LoadOrGenerateKey(path string) (crypto.PrivKey, error) {
+	key, err := LoadKey(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return GenerateKey(path)
+	}
+	return key, err
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Middleware wraps the round trip of every request the client sends
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps base with the middlewares. The first middleware is the outermost, so it
+// sees the request first and the response last. A nil base uses http.DefaultTransport.
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+// RoundTripInfo describes a completed round trip. Response is nil when Err is set.
+type RoundTripInfo struct {
+	Request  *http.Request
+	Response *http.Response
+	Err      error
+	Duration time.Duration
+}
+
+// OnRequest calls fn before every request is sent. fn may modify the request, which is
+// already a copy owned by the middleware chain.
+func OnRequest(fn func(req *http.Request)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			fn(req)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// OnResponse calls fn after every round trip, successful or not, e.g. to record metrics.
+// fn must not read the response body.
+func OnResponse(fn func(info *RoundTripInfo)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			fn(&RoundTripInfo{Request: req, Response: resp, Err: err, Duration: time.Since(start)})
+			return resp, err
+		})
+	}
+}
+
+// Headers sets the given headers on every request, replacing values already present
+func Headers(headers http.Header) Middleware {
+	return OnRequest(func(req *http.Request) {
+		for key, values := range headers {
+			req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	})
+}
+
+// Propagate lets a tracing library inject its context, e.g. a W3C traceparent header,
+// into every request. inject receives the request context and headers.
+func Propagate(inject func(ctx context.Context, header http.Header)) Middleware {
+	return OnRequest(func(req *http.Request) {
+		inject(req.Context(), req.Header)
+	})
+}
+
+// Logging logs every round trip at debug level, and failed ones at warn level. Request
+// and response bodies and authentication headers are never logged.
+func Logging(logger *zap.Logger) Middleware {
+	return OnResponse(func(info *RoundTripInfo) {
+		fields := []zap.Field{
+			zap.String("method", info.Request.Method),
+			zap.String("url", info.Request.URL.Redacted()),
+			zap.Duration("duration", info.Duration),
+		}
+
+		switch {
+		case info.Err != nil:
+			logger.Warn("dhcp2p request failed", append(fields, zap.Error(info.Err))...)
+		case info.Response.StatusCode >= http.StatusInternalServerError:
+			logger.Warn("dhcp2p request failed", append(fields, zap.Int("status", info.Response.StatusCode))...)
+		default:
+			logger.Debug("dhcp2p request", append(fields, zap.Int("status", info.Response.StatusCode))...)
+		}
+	})
+}
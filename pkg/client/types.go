@@ -0,0 +1,144 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+)
+
+// Lease is an IP lease held by a peer
+type Lease struct {
+	TokenID   int64     `json:"token_id"`
+	PeerID    string    `json:"peer_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	TTL       int32     `json:"ttl"`                 // lease lifetime as reported by the server
+	Signature []byte    `json:"signature,omitempty"` // server's certificate signature, see VerifyLease
+	KeyID     string    `json:"key_id,omitempty"`    // server key the signature was made with, see ServerKeySet
+	Tenant    string    `json:"tenant,omitempty"`    // tenant whose pool the signature binds the token ID to
+
+	Labels map[string]string `json:"labels,omitempty"` // attached with AllocateRequest.Labels
+
+	// RenewableAt is set by RenewLease when the server's renewal window has not opened
+	// yet; the lease was not extended and can be renewed from then on
+	RenewableAt *time.Time `json:"renewable_at,omitempty"`
+
+	// RenewAfter is when the server suggests renewing the lease. Servers spread it out so
+	// their clients do not renew all at once; nil when the server gives no suggestion.
+	RenewAfter *time.Time `json:"renew_after,omitempty"`
+}
+
+// IP returns the IPv4 address the lease's token ID stands for
+func (l *Lease) IP() string {
+	return utils.IPFromTokenID(uint32(l.TokenID))
+}
+
+// ServerInfo describes a dhcp2p server and its capabilities. PublicKey, PeerID and KeyID
+// are empty when the server does not sign leases.
+type ServerInfo struct {
+	Version            string       `json:"version"`
+	PublicKey          string       `json:"public_key"` // base64-encoded libp2p public key of the current signing key
+	PeerID             string       `json:"peer_id"`
+	KeyID              string       `json:"key_id"`
+	Tenant             string       `json:"tenant"` // tenant of the client's API key, empty for the default tenant
+	LeaseSigning       bool         `json:"lease_signing"`
+	Pools              []*PoolRange `json:"pools"`
+	LeaseTTL           TTLBounds    `json:"lease_ttl"`
+	Auth               AuthInfo     `json:"auth"`
+	Features           []string     `json:"features"` // see the Feature constants
+	BatchMaxOperations int          `json:"batch_max_operations"`
+	LeaseProtocol      string       `json:"lease_protocol"` // libp2p protocol ID, empty when not served
+}
+
+// Optional features a server may report in ServerInfo.Features
+const (
+	FeatureRequestedTokenID  = "requested_token_id"
+	FeatureAffinityGroups    = "affinity_groups"
+	FeatureLeaseTransfer     = "lease_transfer"
+	FeatureConflictReports   = "conflict_reports"
+	FeatureBatch             = "batch"
+	FeatureIdempotencyKeys   = "idempotency_keys"
+	FeatureLeaseCertificates = "lease_certificates"
+	FeatureTenants           = "tenants"
+	FeatureLeaseDelegation   = "lease_delegation"
+	FeatureLeaseLabels       = "lease_labels"
+	FeatureZones             = "zones"
+)
+
+// HasFeature reports whether the server supports feature
+func (i *ServerInfo) HasFeature(feature string) bool {
+	return slices.Contains(i.Features, feature)
+}
+
+// PoolRange is a range of token IDs a server hands out
+type PoolRange struct {
+	MinTokenID int64    `json:"min_token_id"`
+	MaxTokenID int64    `json:"max_token_id"`
+	FirstIP    string   `json:"first_ip"`
+	LastIP     string   `json:"last_ip"`
+	Zones      []string `json:"zones,omitempty"` // racks or sites WithZone can name
+}
+
+// TTLBounds are the lease lifetimes a server grants, in minutes
+type TTLBounds struct {
+	MinMinutes int `json:"min_minutes"`
+	MaxMinutes int `json:"max_minutes"`
+}
+
+// AuthInfo describes how a server authenticates peers
+type AuthInfo struct {
+	Methods                []string `json:"methods"`
+	IdentityScheme         string   `json:"identity_scheme"`    // peer_id or ethereum
+	TimestampRequired      bool     `json:"timestamp_required"` // use WithSignedTimestamp
+	TimestampWindowSeconds int      `json:"timestamp_window_seconds"`
+	LookupAuthRequired     bool     `json:"lookup_auth_required"` // use WithAuthenticatedLookups
+	AllowListRequired      bool     `json:"allow_list_required"`
+}
+
+// AllocateRequest holds the optional inputs of an allocation. TokenID and AffinityGroup
+// cannot be combined.
+type AllocateRequest struct {
+	TokenID       int64  // preferred token ID, e.g. the one held before a restart
+	AffinityGroup string // peers sharing a group get neighbouring token IDs
+
+	// Labels replace the labels of the lease, e.g. {"role": "validator"}. Keys are lowercase
+	// and values cannot contain commas; nil leaves the labels unchanged.
+	Labels map[string]string
+}
+
+// Allocation is the result of AllocateIP. Granted and Reason are only set when a
+// preferred token ID was requested.
+type Allocation struct {
+	Lease            *Lease `json:"lease"`
+	RequestedTokenID int64  `json:"requested_token_id"`
+	Granted          bool   `json:"granted"`
+	Reason           string `json:"reason"` // granted, existing_lease, in_use, out_of_range or unavailable
+}
+
+// APIError is an error response of the dhcp2p API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"type"`
+	Code       string `json:"code"` // e.g. LEASE_NOT_FOUND
+	Message    string `json:"message"`
+	Details    string `json:"details,omitempty"`
+
+	retryAfter time.Duration
+}
+
+// IsCode reports whether err is an API error with the given code
+func IsCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+func (e *APIError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("dhcp2p: %s (%d %s): %s", e.Message, e.StatusCode, e.Code, e.Details)
+	}
+	return fmt.Sprintf("dhcp2p: %s (%d %s)", e.Message, e.StatusCode, e.Code)
+}
@@ -0,0 +1,22 @@
+// Package crypto is the reference for the signatures dhcp2p exchanges. The server, the
+// Go client and the agent all use it, so clients in other languages can check their
+// implementation against it.
+//
+// Peers are identified by the libp2p peer ID of their public key, or by its Ethereum
+// address on servers using Ethereum identities. Public keys travel as protobuf-encoded
+// libp2p keys; bare secp256k1 keys, compressed or uncompressed, are accepted as well.
+//
+// Every signed payload is the SHA-256 digest of a UTF-8 message:
+//
+//	authentication      <nonce>, or <nonce>:<unix timestamp> when X-Timestamp is sent
+//	delegation          dhcp2p-lease-delegation:<nonce>:<delegate peer ID>
+//	transfer            dhcp2p-lease-transfer:<nonce>:<token ID>:<new peer ID>
+//	lease certificate   dhcp2p-lease-certificate:<tenant>:<token ID>:<peer ID>:<unix expiry>
+//
+// Token IDs and timestamps are written in decimal. The digest is signed with the key's
+// own scheme: Ed25519, ECDSA over secp256k1 or RSA PKCS#1 v1.5, as implemented by libp2p.
+//
+//	if err := crypto.Verify(pubkey, crypto.AuthPayload(nonce, timestamp), signature); err != nil {
+//		return err
+//	}
+package crypto
@@ -0,0 +1,90 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	libp2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	pb "github.com/libp2p/go-libp2p/core/crypto/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/crypto/sha3"
+)
+
+// UnmarshalPublicKey parses a libp2p public key. Bare secp256k1 keys, compressed (33 bytes)
+// or uncompressed (65 bytes) as used by Ethereum tooling, are accepted as well.
+func UnmarshalPublicKey(pubkey []byte) (libp2pcrypto.PubKey, error) {
+	pubKey, err := libp2pcrypto.UnmarshalPublicKey(pubkey)
+	if err == nil {
+		return pubKey, nil
+	}
+
+	if len(pubkey) == secp256k1.PubKeyBytesLenCompressed || len(pubkey) == secp256k1.PubKeyBytesLenUncompressed {
+		if pubKey, secpErr := libp2pcrypto.UnmarshalSecp256k1PublicKey(pubkey); secpErr == nil {
+			return pubKey, nil
+		}
+	}
+	return nil, err
+}
+
+// PeerIDFromPublicKey returns the libp2p peer ID of a public key in any form
+// UnmarshalPublicKey accepts
+func PeerIDFromPublicKey(pubkey []byte) (string, error) {
+	pubKey, err := UnmarshalPublicKey(pubkey)
+	if err != nil {
+		return "", err
+	}
+
+	peerID, err := peer.IDFromPublicKey(pubKey)
+	if err != nil {
+		return "", err
+	}
+	return peerID.String(), nil
+}
+
+// EthereumAddress returns the EIP-55 checksummed address of a secp256k1 public key
+func EthereumAddress(pubKey libp2pcrypto.PubKey) (string, error) {
+	if pubKey.Type() != pb.KeyType_Secp256k1 {
+		return "", fmt.Errorf("ethereum addresses require a secp256k1 key, got %s", pubKey.Type())
+	}
+
+	raw, err := pubKey.Raw()
+	if err != nil {
+		return "", err
+	}
+	key, err := secp256k1.ParsePubKey(raw)
+	if err != nil {
+		return "", err
+	}
+
+	// The address is the last 20 bytes of the Keccak-256 hash of the uncompressed key
+	// without its 0x04 prefix
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(key.SerializeUncompressed()[1:])
+	address := hex.EncodeToString(hash.Sum(nil)[12:])
+
+	// EIP-55: upper-case each letter whose nibble in the hash of the address is >= 8
+	hash = sha3.NewLegacyKeccak256()
+	hash.Write([]byte(address))
+	checksum := hash.Sum(nil)
+
+	result := []byte(address)
+	for i, c := range result {
+		nibble := checksum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = checksum[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			result[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(result), nil
+}
+
+// SigningKeyID returns the ID of the marshalled public key pubkey: the hex-encoded first
+// 8 bytes of its SHA-256 digest
+func SigningKeyID(pubkey []byte) string {
+	digest := sha256.Sum256(pubkey)
+	return hex.EncodeToString(digest[:8])
+}
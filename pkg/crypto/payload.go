@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+)
+
+// AuthPayload returns the digest a peer signs to authenticate with a nonce. When the
+// request carries an X-Timestamp the timestamp is bound into the signature so captured
+// requests cannot be replayed once they fall outside the acceptance window.
+func AuthPayload(nonceID string, timestamp string) []byte {
+	message := nonceID
+	if timestamp != "" {
+		message = nonceID + ":" + timestamp
+	}
+
+	payload := sha256.Sum256([]byte(message))
+	return payload[:]
+}
+
+// DelegationPayload returns the digest a gateway signs to vouch for delegatePeerID when
+// allocating a lease on its behalf. Binding the nonce makes the authorization single use.
+func DelegationPayload(nonceID string, delegatePeerID string) []byte {
+	payload := sha256.Sum256([]byte(fmt.Sprintf("dhcp2p-lease-delegation:%s:%s", nonceID, delegatePeerID)))
+	return payload[:]
+}
+
+// TransferPayload returns the digest the current lease owner signs to authorize handing
+// tokenID over to newPeerID. Binding the nonce makes the authorization single use.
+func TransferPayload(nonceID string, tokenID int64, newPeerID string) []byte {
+	payload := sha256.Sum256([]byte(fmt.Sprintf("dhcp2p-lease-transfer:%s:%d:%s", nonceID, tokenID, newPeerID)))
+	return payload[:]
+}
+
+// LeaseCertificatePayload returns the digest the server signs to certify that peerID holds
+// tokenID of tenant's pool until expiresAt. A tenant has a single pool, so binding the
+// tenant binds the pool too. The expiry is bound in whole seconds.
+func LeaseCertificatePayload(tenant string, tokenID int64, peerID string, expiresAt time.Time) []byte {
+	payload := sha256.Sum256([]byte(fmt.Sprintf("dhcp2p-lease-certificate:%s:%d:%s:%d", tenant, tokenID, peerID, expiresAt.Unix())))
+	return payload[:]
+}
@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"errors"
+)
+
+// ErrInvalidSignature is returned by Verify when the signature does not match
+var ErrInvalidSignature = errors.New("dhcp2p: invalid signature")
+
+// Verify checks that signature is the signature of payload by the key pubkey, given in any
+// form UnmarshalPublicKey accepts
+func Verify(pubkey []byte, payload []byte, signature []byte) error {
+	pubKey, err := UnmarshalPublicKey(pubkey)
+	if err != nil {
+		return err
+	}
+
+	ok, err := pubKey.Verify(payload, signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
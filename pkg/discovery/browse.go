@@ -0,0 +1,155 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// queryInterval is the wait before a query that got no answer is sent again
+const queryInterval = time.Second
+
+// Browse queries the local network for dhcp2p servers for the duration of timeout and
+// returns the services that answered, in the order they answered
+func Browse(ctx context.Context, timeout time.Duration) ([]Service, error) {
+	return BrowseAddr(ctx, MulticastAddr, timeout)
+}
+
+// BrowseAddr is Browse sending its queries to addr instead of the multicast group, e.g.
+// to ask a single responder
+func BrowseAddr(ctx context.Context, addr net.Addr, timeout time.Duration) ([]Service, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Queries from a port other than 5353 are answered by unicast, so no multicast
+	// membership is needed to receive the answers
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open socket: %w", err)
+	}
+	defer conn.Close()
+
+	query := new(dns.Msg)
+	query.SetQuestion(serviceName(), dns.TypePTR)
+	query.RecursionDesired = false
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	b := newBrowser()
+	buf := make([]byte, dns.MaxMsgSize)
+	nextQuery := time.Now()
+	for ctx.Err() == nil {
+		if !time.Now().Before(nextQuery) {
+			if _, err := conn.WriteTo(packed, addr); err != nil {
+				return nil, fmt.Errorf("failed to send query: %w", err)
+			}
+			nextQuery = time.Now().Add(queryInterval)
+		}
+
+		readDeadline := nextQuery
+		if deadline.Before(readDeadline) {
+			readDeadline = deadline
+		}
+		conn.SetReadDeadline(readDeadline)
+		n, _, err := conn.ReadFrom(buf)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resp := new(dns.Msg)
+		if err := resp.Unpack(buf[:n]); err != nil || !resp.Response {
+			continue
+		}
+		b.add(resp)
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, ctx.Err()
+	}
+	return b.services(), nil
+}
+
+// browser collects the records of the answers and assembles services from them
+type browser struct {
+	instances []string // fully qualified instance names, in the order they were seen
+	srv       map[string]*dns.SRV
+	txt       map[string]*dns.TXT
+	addrs     map[string][]net.IP
+}
+
+func newBrowser() *browser {
+	return &browser{
+		srv:   make(map[string]*dns.SRV),
+		txt:   make(map[string]*dns.TXT),
+		addrs: make(map[string][]net.IP),
+	}
+}
+
+func (b *browser) add(resp *dns.Msg) {
+	for _, rr := range append(resp.Answer, resp.Extra...) {
+		name := dns.CanonicalName(rr.Header().Name)
+		switch rr := rr.(type) {
+		case *dns.PTR:
+			if name != dns.CanonicalName(serviceName()) {
+				continue
+			}
+			if instance := dns.CanonicalName(rr.Ptr); !slices.Contains(b.instances, instance) {
+				b.instances = append(b.instances, instance)
+			}
+		case *dns.SRV:
+			b.srv[name] = rr
+		case *dns.TXT:
+			b.txt[name] = rr
+		case *dns.A:
+			if !slices.ContainsFunc(b.addrs[name], rr.A.Equal) {
+				b.addrs[name] = append(b.addrs[name], rr.A)
+			}
+		}
+	}
+}
+
+// services are the instances whose SRV record has been seen
+func (b *browser) services() []Service {
+	var services []Service
+	for _, name := range b.instances {
+		srv, ok := b.srv[name]
+		if !ok {
+			continue
+		}
+		instance, ok := instanceFromName(srv.Hdr.Name)
+		if !ok {
+			continue
+		}
+
+		target := dns.CanonicalName(srv.Target)
+		service := Service{
+			Instance: instance,
+			Host:     strings.TrimSuffix(target, "."+Domain),
+			Port:     int(srv.Port),
+			Addrs:    b.addrs[target],
+			Text:     make(map[string]string),
+		}
+		if txt, ok := b.txt[name]; ok {
+			for _, attr := range txt.Txt {
+				if key, value, _ := strings.Cut(attr, "="); key != "" {
+					service.Text[strings.ToLower(key)] = value
+				}
+			}
+		}
+		services = append(services, service)
+	}
+	return services
+}
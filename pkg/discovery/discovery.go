@@ -0,0 +1,120 @@
+// Package discovery finds dhcp2p servers on the local network with multicast DNS service
+// discovery (RFC 6762 and RFC 6763).
+//
+// A server advertises itself as <instance>._dhcp2p._tcp.local. with a Responder; clients
+// call Browse and connect to the URL of a Service that answered:
+//
+//	services, err := discovery.Browse(ctx, discovery.DefaultBrowseTimeout)
+//	if err != nil {
+//		return err
+//	}
+//	if len(services) > 0 {
+//		c, err := client.New(services[0].URL(), key)
+//	}
+//
+// Only IPv4 is used. Discovery tells nothing about whether a server can be trusted:
+// anyone on the link can answer, so clients that need assurance verify the server key.
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ServiceType is the DNS-SD service type of the dhcp2p HTTP API
+	ServiceType = "_dhcp2p._tcp"
+	// Domain is the multicast DNS domain services are advertised in
+	Domain = "local."
+
+	// DefaultBrowseTimeout is long enough for servers to answer a query and its retry
+	DefaultBrowseTimeout = 2 * time.Second
+)
+
+// TXT attributes of an advertised service
+const (
+	TextVersion  = "version"  // build version of the server
+	TextPeerID   = "peer_id"  // libp2p peer ID of the server host, if it has one
+	TextProtocol = "protocol" // lease protocol served on that host
+)
+
+// MulticastAddr is the IPv4 multicast DNS group
+var MulticastAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is one advertised dhcp2p server
+type Service struct {
+	Instance string            `json:"instance"` // instance name, unique on the network
+	Host     string            `json:"host"`     // host name, without the .local. suffix
+	Port     int               `json:"port"`     // port of the HTTP API
+	Addrs    []net.IP          `json:"addrs"`    // IPv4 addresses of the host
+	Text     map[string]string `json:"text"`     // TXT attributes, see TextVersion and the others
+}
+
+// URL is the base URL of the service's HTTP API, preferring its first address over the
+// host name, which only resolves on systems with an mDNS resolver
+func (s Service) URL() string {
+	host := s.Host + ".local"
+	if len(s.Addrs) > 0 {
+		host = s.Addrs[0].String()
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(s.Port))
+}
+
+// serviceName is the fully qualified name the service type is browsed under
+func serviceName() string {
+	return ServiceType + "." + Domain
+}
+
+// instanceName is the fully qualified name of a service instance. Characters with a
+// meaning in domain names are escaped, so the instance stays a single label.
+func instanceName(instance string) string {
+	var b strings.Builder
+	for i := 0; i < len(instance); i++ {
+		switch c := instance[i]; {
+		case strings.IndexByte(`.\()@;" `, c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String() + "." + serviceName()
+}
+
+// instanceFromName is the instance of a fully qualified instance name, the reverse of
+// instanceName
+func instanceFromName(name string) (string, bool) {
+	label, ok := strings.CutSuffix(strings.ToLower(name), "."+strings.ToLower(serviceName()))
+	if !ok || label == "" {
+		return "", false
+	}
+	label = name[:len(label)]
+
+	var b strings.Builder
+	for i := 0; i < len(label); i++ {
+		if label[i] != '\\' || i+1 == len(label) {
+			b.WriteByte(label[i])
+			continue
+		}
+		if i+3 < len(label) {
+			if n, err := strconv.ParseUint(label[i+1:i+4], 10, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(label[i+1])
+		i++
+	}
+	return b.String(), true
+}
+
+// hostName is the fully qualified name of a host
+func hostName(host string) string {
+	return host + "." + Domain
+}
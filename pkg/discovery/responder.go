@@ -0,0 +1,233 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// recordTTL is the TTL of advertised records, the RFC 6762 recommendation for records
+	// naming a host
+	recordTTL = 120
+	// legacyUnicastTTL caps the TTL of answers to queriers that are not mDNS responders
+	// themselves, as RFC 6762 section 6.7 asks
+	legacyUnicastTTL = 10
+	// cacheFlush is the top bit of the record class, telling caches the record replaces
+	// every other record of its name and type
+	cacheFlush = 1 << 15
+	// unicastResponse is the top bit of the question class, asking for a unicast reply
+	unicastResponse = 1 << 15
+
+	// servicesName enumerates the service types on the network, see RFC 6763 section 9
+	servicesName = "_services._dns-sd._udp." + Domain
+)
+
+// Responder answers multicast DNS queries for one service
+type Responder struct {
+	instance string // fully qualified instance name
+	host     string // fully qualified host name
+	service  Service
+}
+
+// NewResponder creates a responder for service, which needs an instance, host and port
+func NewResponder(service Service) (*Responder, error) {
+	if service.Instance == "" {
+		return nil, fmt.Errorf("service instance must not be empty")
+	}
+	if labels, ok := dns.IsDomainName(service.Host); !ok || labels != 1 {
+		return nil, fmt.Errorf("service host must be a single DNS label, got %q", service.Host)
+	}
+	if service.Port <= 0 || service.Port > 65535 {
+		return nil, fmt.Errorf("service port must be between 1 and 65535, got %d", service.Port)
+	}
+
+	return &Responder{
+		instance: instanceName(service.Instance),
+		host:     dns.CanonicalName(hostName(service.Host)),
+		service:  service,
+	}, nil
+}
+
+// Serve answers the queries read from conn until it is closed. Queries sent from the
+// mDNS port are answered to the multicast group, others are answered to their sender.
+func (r *Responder) Serve(conn net.PacketConn) error {
+	buf := make([]byte, dns.MaxMsgSize)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		query := new(dns.Msg)
+		if err := query.Unpack(buf[:n]); err != nil || query.Response || query.Opcode != dns.OpcodeQuery {
+			continue
+		}
+
+		legacy := !fromMDNSPort(from)
+		resp := r.answer(query, legacy)
+		if resp == nil {
+			continue
+		}
+
+		to := net.Addr(MulticastAddr)
+		if legacy || wantsUnicast(query) {
+			to = from
+		}
+		packed, err := resp.Pack()
+		if err != nil {
+			return err
+		}
+		conn.WriteTo(packed, to)
+	}
+}
+
+// Announce sends the records of the service to the multicast group unasked, so caches
+// learn about it when it starts
+func (r *Responder) Announce(conn net.PacketConn) error {
+	return r.send(conn, r.announcement(recordTTL))
+}
+
+// Goodbye tells caches to drop the records of the service, when it stops
+func (r *Responder) Goodbye(conn net.PacketConn) error {
+	return r.send(conn, r.announcement(0))
+}
+
+func (r *Responder) send(conn net.PacketConn, msg *dns.Msg) error {
+	packed, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+	_, err = conn.WriteTo(packed, MulticastAddr)
+	return err
+}
+
+func (r *Responder) announcement(ttl uint32) *dns.Msg {
+	msg := &dns.Msg{MsgHdr: dns.MsgHdr{Response: true, Authoritative: true}}
+	msg.Answer = append(msg.Answer, r.ptr(ttl), r.srv(ttl, true), r.txt(ttl, true))
+	msg.Answer = append(msg.Answer, r.addrs(ttl, true)...)
+	return msg
+}
+
+// answer is the response to query, nil when it asks for nothing the responder knows.
+// Answers to legacy queriers repeat the query, have a short TTL and no cache-flush bits.
+func (r *Responder) answer(query *dns.Msg, legacy bool) *dns.Msg {
+	ttl := uint32(recordTTL)
+	if legacy {
+		ttl = legacyUnicastTTL
+	}
+	flush := !legacy
+
+	resp := &dns.Msg{MsgHdr: dns.MsgHdr{Response: true, Authoritative: true}}
+	if legacy {
+		resp.Id = query.Id
+		resp.Question = query.Question
+	}
+
+	var withService, withAddrs bool
+	for _, q := range query.Question {
+		if q.Qclass&^unicastResponse != dns.ClassINET && q.Qclass&^unicastResponse != dns.ClassANY {
+			continue
+		}
+		name := dns.CanonicalName(q.Name)
+		switch {
+		case name == dns.CanonicalName(servicesName) && matchesType(q, dns.TypePTR):
+			resp.Answer = append(resp.Answer, &dns.PTR{Hdr: header(servicesName, dns.TypePTR, ttl, false), Ptr: serviceName()})
+		case name == dns.CanonicalName(serviceName()) && matchesType(q, dns.TypePTR):
+			resp.Answer = append(resp.Answer, r.ptr(ttl))
+			withService = true
+		case name == dns.CanonicalName(r.instance):
+			if matchesType(q, dns.TypeSRV) {
+				resp.Answer = append(resp.Answer, r.srv(ttl, flush))
+				withAddrs = true
+			}
+			if matchesType(q, dns.TypeTXT) {
+				resp.Answer = append(resp.Answer, r.txt(ttl, flush))
+			}
+		case name == r.host && matchesType(q, dns.TypeA):
+			resp.Answer = append(resp.Answer, r.addrs(ttl, flush)...)
+		}
+	}
+	if len(resp.Answer) == 0 {
+		return nil
+	}
+
+	// Save the querier a round trip for the records it asks for next
+	if withService {
+		resp.Extra = append(resp.Extra, r.srv(ttl, flush), r.txt(ttl, flush))
+		withAddrs = true
+	}
+	if withAddrs {
+		resp.Extra = append(resp.Extra, r.addrs(ttl, flush)...)
+	}
+	return resp
+}
+
+func (r *Responder) ptr(ttl uint32) dns.RR {
+	return &dns.PTR{Hdr: header(serviceName(), dns.TypePTR, ttl, false), Ptr: r.instance}
+}
+
+func (r *Responder) srv(ttl uint32, flush bool) dns.RR {
+	return &dns.SRV{Hdr: header(r.instance, dns.TypeSRV, ttl, flush), Port: uint16(r.service.Port), Target: r.host}
+}
+
+// txt lists the attributes sorted by key, with an empty string when there are none as
+// RFC 6763 section 6.1 asks
+func (r *Responder) txt(ttl uint32, flush bool) dns.RR {
+	keys := make([]string, 0, len(r.service.Text))
+	for key := range r.service.Text {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	txt := &dns.TXT{Hdr: header(r.instance, dns.TypeTXT, ttl, flush), Txt: []string{""}}
+	if len(keys) > 0 {
+		txt.Txt = txt.Txt[:0]
+	}
+	for _, key := range keys {
+		txt.Txt = append(txt.Txt, key+"="+r.service.Text[key])
+	}
+	return txt
+}
+
+func (r *Responder) addrs(ttl uint32, flush bool) []dns.RR {
+	var rrs []dns.RR
+	for _, ip := range r.service.Addrs {
+		if ip4 := ip.To4(); ip4 != nil {
+			rrs = append(rrs, &dns.A{Hdr: header(r.host, dns.TypeA, ttl, flush), A: ip4})
+		}
+	}
+	return rrs
+}
+
+func header(name string, rrtype uint16, ttl uint32, flush bool) dns.RR_Header {
+	class := uint16(dns.ClassINET)
+	if flush {
+		class |= cacheFlush
+	}
+	return dns.RR_Header{Name: name, Rrtype: rrtype, Class: class, Ttl: ttl}
+}
+
+func matchesType(q dns.Question, rrtype uint16) bool {
+	return q.Qtype == rrtype || q.Qtype == dns.TypeANY
+}
+
+func wantsUnicast(query *dns.Msg) bool {
+	for _, q := range query.Question {
+		if q.Qclass&unicastResponse != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func fromMDNSPort(addr net.Addr) bool {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	return ok && udpAddr.Port == MulticastAddr.Port
+}
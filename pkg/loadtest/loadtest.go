@@ -0,0 +1,344 @@
+// Package loadtest drives a running dhcp2p server with simulated peers. Each worker acts
+// for its own share of peers, picks operations from a weighted mix and records their
+// latencies and errors, so a deployment can be soak-tested with the traffic of real
+// clients instead of the mocks of the load tests.
+package loadtest
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	"golang.org/x/time/rate"
+)
+
+// Op is an operation a simulated peer performs
+type Op string
+
+const (
+	OpAllocate Op = "allocate" // allocate a lease, or get back the one the peer holds
+	OpRenew    Op = "renew"    // renew the peer's lease
+	OpGet      Op = "get"      // look up the peer's lease
+	OpRelease  Op = "release"  // release the peer's lease
+)
+
+// Ops lists the operations in the order they are reported
+var Ops = []Op{OpAllocate, OpRenew, OpGet, OpRelease}
+
+// Mix weighs the operations the workers pick. A peer without a lease allocates instead
+// of renewing or releasing.
+type Mix map[Op]int
+
+// DefaultMix mostly renews and looks up leases, like a fleet of agents with steady churn
+var DefaultMix = Mix{OpAllocate: 1, OpRenew: 4, OpGet: 4, OpRelease: 1}
+
+// ParseMix reads a mix such as "allocate=1,renew=4,get=4,release=1". Operations left out
+// are not performed.
+func ParseMix(value string) (Mix, error) {
+	mix := Mix{}
+	for _, part := range strings.Split(value, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		op := Op(strings.TrimSpace(name))
+		if !ok || !slices.Contains(Ops, op) {
+			return nil, fmt.Errorf("invalid operation %q, expected one of allocate, renew, get or release with a weight, e.g. renew=4", part)
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight %q of %s", weight, op)
+		}
+		mix[op] = w
+	}
+	if mix.total() == 0 {
+		return nil, errors.New("the mix needs at least one operation with a positive weight")
+	}
+	return mix, nil
+}
+
+// String formats the mix the way ParseMix reads it
+func (m Mix) String() string {
+	var parts []string
+	for _, op := range Ops {
+		if m[op] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", op, m[op]))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (m Mix) total() int {
+	total := 0
+	for _, op := range Ops {
+		total += m[op]
+	}
+	return total
+}
+
+// pick draws an operation by weight
+func (m Mix) pick(rng *mathrand.Rand) Op {
+	n := rng.IntN(m.total())
+	for _, op := range Ops {
+		if n < m[op] {
+			return op
+		}
+		n -= m[op]
+	}
+	return OpGet
+}
+
+// Config describes a load test
+type Config struct {
+	Server      string        // URL of the dhcp2p server
+	Concurrency int           // workers sending operations in parallel
+	Duration    time.Duration // how long operations are sent
+	Rate        float64       // operations per second across all workers, 0 for as many as the server answers
+	Peers       int           // simulated peers, each with a key of its own; at least Concurrency
+	KeyType     int           // type of the generated keys, crypto.Ed25519 or crypto.Secp256k1
+	Mix         Mix
+	Timeout     time.Duration // timeout of a single operation
+	// Cleanup releases the leases the peers hold once the test ends, so the pool is not
+	// left occupied until they expire
+	Cleanup bool
+	// ClientOptions are applied to the client of every peer, e.g. to send an API key
+	ClientOptions []client.Option
+	// Progress, when set, is called every ProgressInterval with the totals so far
+	Progress         func(elapsed time.Duration, operations, errors int64)
+	ProgressInterval time.Duration
+}
+
+// DefaultConfig runs 10 workers for 100 peers for a minute
+var DefaultConfig = Config{
+	Concurrency:      10,
+	Duration:         time.Minute,
+	Peers:            100,
+	KeyType:          crypto.Ed25519,
+	Mix:              DefaultMix,
+	Timeout:          10 * time.Second,
+	Cleanup:          true,
+	ProgressInterval: 10 * time.Second,
+}
+
+func (c *Config) validate() error {
+	switch {
+	case c.Concurrency <= 0:
+		return errors.New("concurrency must be positive")
+	case c.Duration <= 0:
+		return errors.New("duration must be positive")
+	case c.Rate < 0:
+		return errors.New("rate must not be negative")
+	case c.Peers < c.Concurrency:
+		return fmt.Errorf("peers (%d) must be at least the concurrency (%d), a peer is only used by one worker", c.Peers, c.Concurrency)
+	case c.Timeout <= 0:
+		return errors.New("timeout must be positive")
+	case c.Mix.total() == 0:
+		return errors.New("the mix needs at least one operation with a positive weight")
+	}
+	return nil
+}
+
+// peer is a simulated peer and the lease it holds, 0 for none
+type peer struct {
+	client  *client.Client
+	tokenID int64
+}
+
+// Run generates the keys of the peers, sends operations until the duration is over or ctx
+// is canceled, and reports what was sent. Operations are sent once, without retries, so
+// every failure the server answers with is counted.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	peers, err := newPeers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var limiter *rate.Limiter
+	if cfg.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.Rate), max(1, int(cfg.Rate/float64(cfg.Concurrency))))
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var operations, failures atomic.Int64
+	workers := make([]*recorder, cfg.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := range workers {
+		workers[w] = newRecorder()
+		// Worker w acts for peers w, w+Concurrency, ..., so no two workers share a peer
+		var own []*peer
+		for i := w; i < len(peers); i += cfg.Concurrency {
+			own = append(own, peers[i])
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := mathrand.New(mathrand.NewPCG(mathrand.Uint64(), mathrand.Uint64()))
+			for i := 0; ; i++ {
+				if limiter != nil && limiter.Wait(runCtx) != nil {
+					return
+				}
+				if runCtx.Err() != nil {
+					return
+				}
+
+				op, latency, err := perform(runCtx, cfg.Timeout, own[i%len(own)], cfg.Mix.pick(rng))
+				if err != nil && runCtx.Err() != nil {
+					// Cut off by the end of the test, not failed
+					return
+				}
+				workers[w].record(op, latency, err)
+				operations.Add(1)
+				if err != nil {
+					failures.Add(1)
+				}
+			}
+		}()
+	}
+
+	stopProgress := reportProgress(runCtx, cfg, start, &operations, &failures)
+	wg.Wait()
+	stopProgress()
+	elapsed := time.Since(start)
+
+	if cfg.Cleanup {
+		release(cfg.Timeout, peers)
+	}
+
+	merged := newRecorder()
+	for _, r := range workers {
+		merged.merge(r)
+	}
+	return merged.report(elapsed), nil
+}
+
+func newPeers(cfg Config) ([]*peer, error) {
+	// One transport for all peers, keeping a connection per worker open. Operations are
+	// bounded by the context of each, so the client sets no timeout of its own.
+	hc := &http.Client{
+		Transport: &http.Transport{MaxIdleConnsPerHost: cfg.Concurrency, IdleConnTimeout: 90 * time.Second},
+	}
+	opts := append([]client.Option{
+		client.WithHTTPClient(hc),
+		client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}),
+	}, cfg.ClientOptions...)
+
+	peers := make([]*peer, cfg.Peers)
+	for i := range peers {
+		key, _, err := crypto.GenerateKeyPairWithReader(cfg.KeyType, 0, rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generate peer key: %w", err)
+		}
+		c, err := client.New(cfg.Server, key, opts...)
+		if err != nil {
+			return nil, err
+		}
+		peers[i] = &peer{client: c}
+	}
+	return peers, nil
+}
+
+// perform sends op for p and returns the operation actually performed: a peer without a
+// lease allocates instead of renewing or releasing
+func perform(ctx context.Context, timeout time.Duration, p *peer, op Op) (Op, time.Duration, error) {
+	if p.tokenID == 0 && (op == OpRenew || op == OpRelease) {
+		op = OpAllocate
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch op {
+	case OpAllocate:
+		var allocation *client.Allocation
+		if allocation, err = p.client.AllocateIP(ctx, nil); err == nil {
+			p.tokenID = allocation.Lease.TokenID
+		}
+	case OpRenew:
+		_, err = p.client.RenewLease(ctx, p.tokenID)
+	case OpGet:
+		_, err = p.client.GetLease(ctx, p.client.PeerID())
+		if client.IsCode(err, "LEASE_NOT_FOUND") && p.tokenID == 0 {
+			// Looking up a peer without a lease is expected to find nothing
+			err = nil
+		}
+	case OpRelease:
+		if err = p.client.ReleaseLease(ctx, p.tokenID); err == nil {
+			p.tokenID = 0
+		}
+	}
+	latency := time.Since(start)
+
+	// A lease that expired or was reclaimed is gone, the peer allocates again
+	if client.IsCode(err, "LEASE_NOT_FOUND") || client.IsCode(err, "LEASE_EXPIRED") {
+		p.tokenID = 0
+	}
+	return op, latency, err
+}
+
+// release gives back the leases the peers still hold
+func release(timeout time.Duration, peers []*peer) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 16)
+	for _, p := range peers {
+		if p.tokenID == 0 {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if p.client.ReleaseLease(ctx, p.tokenID) == nil {
+				p.tokenID = 0
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func reportProgress(ctx context.Context, cfg Config, start time.Time, operations, failures *atomic.Int64) (stop func()) {
+	if cfg.Progress == nil || cfg.ProgressInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(cfg.ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cfg.Progress(time.Since(start), operations.Load(), failures.Load())
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,222 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+)
+
+// Report summarizes a load test. Latencies are in milliseconds.
+type Report struct {
+	DurationSeconds float64 `json:"duration_seconds"`
+	Operations      int64   `json:"operations"`
+	Errors          int64   `json:"errors"`
+	ErrorRate       float64 `json:"error_rate"`
+	Throughput      float64 `json:"operations_per_second"`
+
+	Ops []*OpReport `json:"ops"` // in the order of Ops, without the operations never sent
+	// ErrorCodes counts the errors by API error code; failures without a response are
+	// counted as NETWORK_ERROR or TIMEOUT
+	ErrorCodes map[string]int64 `json:"error_codes,omitempty"`
+}
+
+// OpReport summarizes the operations of one kind
+type OpReport struct {
+	Op         Op      `json:"op"`
+	Count      int64   `json:"count"`
+	Errors     int64   `json:"errors"`
+	ErrorRate  float64 `json:"error_rate"`
+	Throughput float64 `json:"operations_per_second"`
+	Latency    Latency `json:"latency_ms"`
+}
+
+// Latency is a latency distribution in milliseconds. Percentiles are accurate to about
+// 1%, they are read from a histogram so long soak tests run in constant memory.
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// bucketGrowth is the ratio between the bounds of consecutive histogram buckets
+const bucketGrowth = 1.02
+
+// histogram counts latencies in microseconds in buckets growing by bucketGrowth, keeping
+// the exact minimum, maximum and sum
+type histogram struct {
+	buckets  map[int]int64
+	count    int64
+	sum      time.Duration
+	min, max time.Duration
+}
+
+func bucketOf(d time.Duration) int {
+	us := max(1, d.Microseconds())
+	return int(math.Log(float64(us)) / math.Log(bucketGrowth))
+}
+
+// bucketValue returns the middle of a bucket
+func bucketValue(b int) time.Duration {
+	lower := math.Pow(bucketGrowth, float64(b))
+	return time.Duration(lower*(1+bucketGrowth)/2) * time.Microsecond
+}
+
+func (h *histogram) add(d time.Duration) {
+	if h.buckets == nil {
+		h.buckets = map[int]int64{}
+	}
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.buckets[bucketOf(d)]++
+	h.count++
+	h.sum += d
+}
+
+func (h *histogram) merge(o *histogram) {
+	if o.count == 0 {
+		return
+	}
+	if h.buckets == nil {
+		h.buckets = map[int]int64{}
+	}
+	if h.count == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	h.max = max(h.max, o.max)
+	for b, n := range o.buckets {
+		h.buckets[b] += n
+	}
+	h.count += o.count
+	h.sum += o.sum
+}
+
+// summarize computes the distribution, using nearest-rank percentiles clamped to the
+// exact minimum and maximum
+func (h *histogram) summarize() Latency {
+	if h.count == 0 {
+		return Latency{}
+	}
+
+	lowest, highest := bucketOf(h.min), bucketOf(h.max)
+	percentile := func(p float64) float64 {
+		rank := max(1, int64(math.Ceil(p*float64(h.count))))
+		var seen int64
+		for b := lowest; b <= highest; b++ {
+			if seen += h.buckets[b]; seen >= rank {
+				return ms(min(h.max, max(h.min, bucketValue(b))))
+			}
+		}
+		return ms(h.max)
+	}
+
+	return Latency{
+		Min:  ms(h.min),
+		Mean: ms(h.sum / time.Duration(h.count)),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P99:  percentile(0.99),
+		Max:  ms(h.max),
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// recorder collects the results of one worker, so workers do not contend on a lock
+type recorder struct {
+	latencies  map[Op]*histogram
+	errors     map[Op]int64
+	errorCodes map[string]int64
+}
+
+func newRecorder() *recorder {
+	return &recorder{latencies: map[Op]*histogram{}, errors: map[Op]int64{}, errorCodes: map[string]int64{}}
+}
+
+func (r *recorder) histogram(op Op) *histogram {
+	h, ok := r.latencies[op]
+	if !ok {
+		h = &histogram{}
+		r.latencies[op] = h
+	}
+	return h
+}
+
+func (r *recorder) record(op Op, latency time.Duration, err error) {
+	r.histogram(op).add(latency)
+	if err != nil {
+		r.errors[op]++
+		r.errorCodes[errorCode(err)]++
+	}
+}
+
+func (r *recorder) merge(o *recorder) {
+	for op, h := range o.latencies {
+		r.histogram(op).merge(h)
+	}
+	for op, n := range o.errors {
+		r.errors[op] += n
+	}
+	for code, n := range o.errorCodes {
+		r.errorCodes[code] += n
+	}
+}
+
+func (r *recorder) report(elapsed time.Duration) *Report {
+	report := &Report{DurationSeconds: elapsed.Seconds(), ErrorCodes: r.errorCodes}
+	for _, op := range Ops {
+		h, ok := r.latencies[op]
+		if !ok {
+			continue
+		}
+		opReport := &OpReport{
+			Op:        op,
+			Count:     h.count,
+			Errors:    r.errors[op],
+			ErrorRate: errorRate(r.errors[op], h.count),
+			Latency:   h.summarize(),
+		}
+		if elapsed > 0 {
+			opReport.Throughput = float64(h.count) / elapsed.Seconds()
+		}
+		report.Ops = append(report.Ops, opReport)
+		report.Operations += h.count
+		report.Errors += r.errors[op]
+	}
+	report.ErrorRate = errorRate(report.Errors, report.Operations)
+	if elapsed > 0 {
+		report.Throughput = float64(report.Operations) / elapsed.Seconds()
+	}
+	return report
+}
+
+func errorRate(errors, count int64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(errors) / float64(count)
+}
+
+// errorCode returns the API error code of err, or what kept the request from being answered
+func errorCode(err error) string {
+	var apiErr *client.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return "TIMEOUT"
+	default:
+		return "NETWORK_ERROR"
+	}
+}
@@ -0,0 +1,645 @@
+// Protobuf encoding of DHCP2P API responses, served to clients that send
+// Accept: application/x-protobuf. The messages mirror the JSON bodies field by field.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: dhcp2p.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Response is the body of every protobuf encoded response. It takes the place of the
+// JSON {"data": ...} envelope and of the JSON error body.
+type Response struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Response_Lease
+	//	*Response_Nonce
+	//	*Response_Allocation
+	//	*Response_Status
+	//	*Response_Error
+	Body          isResponse_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	mi := &file_dhcp2p_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_dhcp2p_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_dhcp2p_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Response) GetBody() isResponse_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Response) GetLease() *Lease {
+	if x != nil {
+		if x, ok := x.Body.(*Response_Lease); ok {
+			return x.Lease
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetNonce() *Nonce {
+	if x != nil {
+		if x, ok := x.Body.(*Response_Nonce); ok {
+			return x.Nonce
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetAllocation() *Allocation {
+	if x != nil {
+		if x, ok := x.Body.(*Response_Allocation); ok {
+			return x.Allocation
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetStatus() *Status {
+	if x != nil {
+		if x, ok := x.Body.(*Response_Status); ok {
+			return x.Status
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Body.(*Response_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isResponse_Body interface {
+	isResponse_Body()
+}
+
+type Response_Lease struct {
+	Lease *Lease `protobuf:"bytes,1,opt,name=lease,proto3,oneof"`
+}
+
+type Response_Nonce struct {
+	Nonce *Nonce `protobuf:"bytes,2,opt,name=nonce,proto3,oneof"`
+}
+
+type Response_Allocation struct {
+	Allocation *Allocation `protobuf:"bytes,3,opt,name=allocation,proto3,oneof"`
+}
+
+type Response_Status struct {
+	Status *Status `protobuf:"bytes,4,opt,name=status,proto3,oneof"`
+}
+
+type Response_Error struct {
+	Error *Error `protobuf:"bytes,15,opt,name=error,proto3,oneof"`
+}
+
+func (*Response_Lease) isResponse_Body() {}
+
+func (*Response_Nonce) isResponse_Body() {}
+
+func (*Response_Allocation) isResponse_Body() {}
+
+func (*Response_Status) isResponse_Body() {}
+
+func (*Response_Error) isResponse_Body() {}
+
+// Lease is a token ID leased to a peer
+type Lease struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	TokenId   int64                  `protobuf:"varint,1,opt,name=token_id,json=tokenId,proto3" json:"token_id,omitempty"`
+	PeerId    string                 `protobuf:"bytes,2,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Ttl       int32                  `protobuf:"varint,6,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Server's signature over the lease certificate, empty when lease signing is disabled
+	Signature []byte `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`
+	// Set when a renewal came before the renewal window, the lease is unchanged
+	RenewableAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=renewable_at,json=renewableAt,proto3" json:"renewable_at,omitempty"`
+	// Server-suggested time to renew, spread out so clients do not renew all at once
+	RenewAfter *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=renew_after,json=renewAfter,proto3" json:"renew_after,omitempty"`
+	// ID of the server key the signature was made with, listed at /v1/server-info/keys
+	KeyId string `protobuf:"bytes,10,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	// Tenant whose pool the signature binds the token ID to, set on signed leases
+	Tenant        string `protobuf:"bytes,11,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Lease) Reset() {
+	*x = Lease{}
+	mi := &file_dhcp2p_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Lease) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Lease) ProtoMessage() {}
+
+func (x *Lease) ProtoReflect() protoreflect.Message {
+	mi := &file_dhcp2p_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Lease.ProtoReflect.Descriptor instead.
+func (*Lease) Descriptor() ([]byte, []int) {
+	return file_dhcp2p_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Lease) GetTokenId() int64 {
+	if x != nil {
+		return x.TokenId
+	}
+	return 0
+}
+
+func (x *Lease) GetPeerId() string {
+	if x != nil {
+		return x.PeerId
+	}
+	return ""
+}
+
+func (x *Lease) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Lease) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Lease) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Lease) GetTtl() int32 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+func (x *Lease) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *Lease) GetRenewableAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RenewableAt
+	}
+	return nil
+}
+
+func (x *Lease) GetRenewAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RenewAfter
+	}
+	return nil
+}
+
+func (x *Lease) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *Lease) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+// Nonce is the answer to an authentication request
+type Nonce struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Base64-encoded public key the nonce was issued for
+	Pubkey        string `protobuf:"bytes,1,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	Nonce         string `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Nonce) Reset() {
+	*x = Nonce{}
+	mi := &file_dhcp2p_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Nonce) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Nonce) ProtoMessage() {}
+
+func (x *Nonce) ProtoReflect() protoreflect.Message {
+	mi := &file_dhcp2p_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Nonce.ProtoReflect.Descriptor instead.
+func (*Nonce) Descriptor() ([]byte, []int) {
+	return file_dhcp2p_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Nonce) GetPubkey() string {
+	if x != nil {
+		return x.Pubkey
+	}
+	return ""
+}
+
+func (x *Nonce) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+// Allocation is the answer to an allocation that asked for a preferred token ID
+type Allocation struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Lease            *Lease                 `protobuf:"bytes,1,opt,name=lease,proto3" json:"lease,omitempty"`
+	RequestedTokenId int64                  `protobuf:"varint,2,opt,name=requested_token_id,json=requestedTokenId,proto3" json:"requested_token_id,omitempty"`
+	Granted          bool                   `protobuf:"varint,3,opt,name=granted,proto3" json:"granted,omitempty"`
+	Reason           string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Allocation) Reset() {
+	*x = Allocation{}
+	mi := &file_dhcp2p_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Allocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Allocation) ProtoMessage() {}
+
+func (x *Allocation) ProtoReflect() protoreflect.Message {
+	mi := &file_dhcp2p_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Allocation.ProtoReflect.Descriptor instead.
+func (*Allocation) Descriptor() ([]byte, []int) {
+	return file_dhcp2p_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Allocation) GetLease() *Lease {
+	if x != nil {
+		return x.Lease
+	}
+	return nil
+}
+
+func (x *Allocation) GetRequestedTokenId() int64 {
+	if x != nil {
+		return x.RequestedTokenId
+	}
+	return 0
+}
+
+func (x *Allocation) GetGranted() bool {
+	if x != nil {
+		return x.Granted
+	}
+	return false
+}
+
+func (x *Allocation) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Status acknowledges a request that has no other result, e.g. a release
+type Status struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_dhcp2p_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_dhcp2p_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_dhcp2p_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Status) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// Error describes why a request failed
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Details       string                 `protobuf:"bytes,4,opt,name=details,proto3" json:"details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_dhcp2p_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_dhcp2p_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_dhcp2p_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Error) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Error) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Error) GetDetails() string {
+	if x != nil {
+		return x.Details
+	}
+	return ""
+}
+
+var File_dhcp2p_proto protoreflect.FileDescriptor
+
+const file_dhcp2p_proto_rawDesc = "" +
+	"\n" +
+	"\fdhcp2p.proto\x12\tdhcp2p.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf6\x01\n" +
+	"\bResponse\x12(\n" +
+	"\x05lease\x18\x01 \x01(\v2\x10.dhcp2p.v1.LeaseH\x00R\x05lease\x12(\n" +
+	"\x05nonce\x18\x02 \x01(\v2\x10.dhcp2p.v1.NonceH\x00R\x05nonce\x127\n" +
+	"\n" +
+	"allocation\x18\x03 \x01(\v2\x15.dhcp2p.v1.AllocationH\x00R\n" +
+	"allocation\x12+\n" +
+	"\x06status\x18\x04 \x01(\v2\x11.dhcp2p.v1.StatusH\x00R\x06status\x12(\n" +
+	"\x05error\x18\x0f \x01(\v2\x10.dhcp2p.v1.ErrorH\x00R\x05errorB\x06\n" +
+	"\x04body\"\xc7\x03\n" +
+	"\x05Lease\x12\x19\n" +
+	"\btoken_id\x18\x01 \x01(\x03R\atokenId\x12\x17\n" +
+	"\apeer_id\x18\x02 \x01(\tR\x06peerId\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x10\n" +
+	"\x03ttl\x18\x06 \x01(\x05R\x03ttl\x12\x1c\n" +
+	"\tsignature\x18\a \x01(\fR\tsignature\x12=\n" +
+	"\frenewable_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\vrenewableAt\x12;\n" +
+	"\vrenew_after\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"renewAfter\x12\x15\n" +
+	"\x06key_id\x18\n" +
+	" \x01(\tR\x05keyId\x12\x16\n" +
+	"\x06tenant\x18\v \x01(\tR\x06tenant\"5\n" +
+	"\x05Nonce\x12\x16\n" +
+	"\x06pubkey\x18\x01 \x01(\tR\x06pubkey\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\tR\x05nonce\"\x94\x01\n" +
+	"\n" +
+	"Allocation\x12&\n" +
+	"\x05lease\x18\x01 \x01(\v2\x10.dhcp2p.v1.LeaseR\x05lease\x12,\n" +
+	"\x12requested_token_id\x18\x02 \x01(\x03R\x10requestedTokenId\x12\x18\n" +
+	"\agranted\x18\x03 \x01(\bR\agranted\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\" \n" +
+	"\x06Status\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"c\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x18\n" +
+	"\adetails\x18\x04 \x01(\tR\adetailsB1Z/github.com/unicornultrafoundation/dhcp2p/pkg/pbb\x06proto3"
+
+var (
+	file_dhcp2p_proto_rawDescOnce sync.Once
+	file_dhcp2p_proto_rawDescData []byte
+)
+
+func file_dhcp2p_proto_rawDescGZIP() []byte {
+	file_dhcp2p_proto_rawDescOnce.Do(func() {
+		file_dhcp2p_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dhcp2p_proto_rawDesc), len(file_dhcp2p_proto_rawDesc)))
+	})
+	return file_dhcp2p_proto_rawDescData
+}
+
+var file_dhcp2p_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_dhcp2p_proto_goTypes = []any{
+	(*Response)(nil),              // 0: dhcp2p.v1.Response
+	(*Lease)(nil),                 // 1: dhcp2p.v1.Lease
+	(*Nonce)(nil),                 // 2: dhcp2p.v1.Nonce
+	(*Allocation)(nil),            // 3: dhcp2p.v1.Allocation
+	(*Status)(nil),                // 4: dhcp2p.v1.Status
+	(*Error)(nil),                 // 5: dhcp2p.v1.Error
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_dhcp2p_proto_depIdxs = []int32{
+	1,  // 0: dhcp2p.v1.Response.lease:type_name -> dhcp2p.v1.Lease
+	2,  // 1: dhcp2p.v1.Response.nonce:type_name -> dhcp2p.v1.Nonce
+	3,  // 2: dhcp2p.v1.Response.allocation:type_name -> dhcp2p.v1.Allocation
+	4,  // 3: dhcp2p.v1.Response.status:type_name -> dhcp2p.v1.Status
+	5,  // 4: dhcp2p.v1.Response.error:type_name -> dhcp2p.v1.Error
+	6,  // 5: dhcp2p.v1.Lease.created_at:type_name -> google.protobuf.Timestamp
+	6,  // 6: dhcp2p.v1.Lease.updated_at:type_name -> google.protobuf.Timestamp
+	6,  // 7: dhcp2p.v1.Lease.expires_at:type_name -> google.protobuf.Timestamp
+	6,  // 8: dhcp2p.v1.Lease.renewable_at:type_name -> google.protobuf.Timestamp
+	6,  // 9: dhcp2p.v1.Lease.renew_after:type_name -> google.protobuf.Timestamp
+	1,  // 10: dhcp2p.v1.Allocation.lease:type_name -> dhcp2p.v1.Lease
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_dhcp2p_proto_init() }
+func file_dhcp2p_proto_init() {
+	if File_dhcp2p_proto != nil {
+		return
+	}
+	file_dhcp2p_proto_msgTypes[0].OneofWrappers = []any{
+		(*Response_Lease)(nil),
+		(*Response_Nonce)(nil),
+		(*Response_Allocation)(nil),
+		(*Response_Status)(nil),
+		(*Response_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dhcp2p_proto_rawDesc), len(file_dhcp2p_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_dhcp2p_proto_goTypes,
+		DependencyIndexes: file_dhcp2p_proto_depIdxs,
+		MessageInfos:      file_dhcp2p_proto_msgTypes,
+	}.Build()
+	File_dhcp2p_proto = out.File
+	file_dhcp2p_proto_goTypes = nil
+	file_dhcp2p_proto_depIdxs = nil
+}
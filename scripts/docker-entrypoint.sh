@@ -69,9 +69,10 @@ run_migrations() {
                 --url "$DATABASE_URL"
             print_success "Migrations completed successfully"
         else
-            print_error "Atlas CLI not found. Cannot run migrations."
-            print_error "Please ensure migrations are run separately or Atlas CLI is installed."
-            exit 1
+            # The binary embeds the same migrations
+            print_warning "Atlas CLI not found, using the built-in migration runner"
+            DHCP2P_DATABASE_URL="$DATABASE_URL" /dhcp2p migrate
+            print_success "Migrations completed successfully"
         fi
     else
         print_warning "Skipping migrations (RUN_MIGRATIONS=false)"
@@ -18,7 +18,7 @@ import (
 func setupRedisForBenchmark(b *testing.B) *redisclient.Client {
 	// Try to connect to external Redis
 	client := redisclient.NewClient(&redisclient.Options{
-		Addr:     "127.0.0.1:6380",  // Use port 6380 to avoid conflicts
+		Addr:     "127.0.0.1:6380", // Use port 6380 to avoid conflicts
 		Password: "",
 		DB:       0,
 	})
@@ -28,7 +28,7 @@ func setupRedisForBenchmark(b *testing.B) *redisclient.Client {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		err := client.Ping(ctx).Err()
 		cancel()
-		
+
 		if err == nil {
 			// Only log on the first successful connection
 			if i == 0 {
@@ -36,7 +36,7 @@ func setupRedisForBenchmark(b *testing.B) *redisclient.Client {
 			}
 			return client
 		}
-		
+
 		if i < 9 {
 			// Only log failures on the first attempt to reduce noise
 			if i == 0 {
@@ -59,10 +59,15 @@ func BenchmarkRedisCache_SetLease(b *testing.B) {
 	redisClient := setupRedisForBenchmark(b)
 
 	cfg := &config.AppConfig{
-		LeaseTTL: 3600,
+		Lease: config.LeaseConfig{
+			TTL: 3600,
+		},
 	}
 
-	leaseCache := redis.NewLeaseCache(redisClient, cfg)
+	leaseCache, err := redis.NewLeaseCache(redisClient, cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
 	builder := fixtures.NewTestBuilder()
 
 	var tokenCounter int64
@@ -92,10 +97,15 @@ func BenchmarkRedisCache_GetLeaseByPeerID(b *testing.B) {
 	redisClient := setupRedisForBenchmark(b)
 
 	cfg := &config.AppConfig{
-		LeaseTTL: 3600,
+		Lease: config.LeaseConfig{
+			TTL: 3600,
+		},
 	}
 
-	leaseCache := redis.NewLeaseCache(redisClient, cfg)
+	leaseCache, err := redis.NewLeaseCache(redisClient, cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
 	builder := fixtures.NewTestBuilder()
 
 	// Clear existing data before running benchmark
@@ -132,10 +142,15 @@ func BenchmarkRedisCache_ConcurrentOperations(b *testing.B) {
 	redisClient := setupRedisForBenchmark(b)
 
 	cfg := &config.AppConfig{
-		LeaseTTL: 3600,
+		Lease: config.LeaseConfig{
+			TTL: 3600,
+		},
 	}
 
-	leaseCache := redis.NewLeaseCache(redisClient, cfg)
+	leaseCache, err := redis.NewLeaseCache(redisClient, cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
 	builder := fixtures.NewTestBuilder()
 
 	var tokenCounter int64
@@ -144,7 +159,7 @@ func BenchmarkRedisCache_ConcurrentOperations(b *testing.B) {
 		for pb.Next() {
 			currentToken := atomic.AddInt64(&tokenCounter, 1)
 			// Use unique peerID per goroutine to avoid race conditions
-			workerID := currentToken % 100 // 100 workers max
+			workerID := currentToken % 100                      // 100 workers max
 			tokenID := int64(167772161 + (currentToken-1)%1000) // Smaller range
 			peerID := fmt.Sprintf("worker-%d", workerID)
 
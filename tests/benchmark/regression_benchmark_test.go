@@ -0,0 +1,236 @@
+//go:build benchmark
+
+package benchmark
+
+// The benchmarks in this file are the performance gate of `make bench-check`, which compares
+// them against tests/benchmark/baseline.txt. They run the allocator, cache layer and crypto
+// of the server on the in-memory store, so they need neither PostgreSQL nor Redis.
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/allocation"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+	"go.uber.org/zap"
+)
+
+const (
+	fixtureActiveLeases  = 800
+	fixtureExpiredLeases = 200
+	nonceBatchSize       = 256
+)
+
+// leaseFixture is the default pool seeded with active and expired leases, served by the
+// hybrid repository with an in-memory cache like `dhcp2p serve --dev`
+type leaseFixture struct {
+	repo   ports.LeaseRepository
+	cache  *memory.LeaseCache
+	active []*models.SnapshotLease
+}
+
+func newLeaseFixture(b *testing.B, cfg *config.AppConfig) *leaseFixture {
+	b.Helper()
+
+	now := time.Now().UTC()
+	snapshot := &models.LeaseSnapshot{
+		Pools: []*models.SnapshotPool{{
+			Tenant:      models.DefaultTenantID,
+			MinTokenID:  cfg.PoolMinTokenID,
+			MaxTokenID:  cfg.PoolMaxTokenID,
+			LastTokenID: cfg.PoolMinTokenID + fixtureActiveLeases + fixtureExpiredLeases - 1,
+		}},
+	}
+	for i := range fixtureActiveLeases + fixtureExpiredLeases {
+		lease := &models.SnapshotLease{
+			TokenID:   cfg.PoolMinTokenID + int64(i),
+			PeerID:    fmt.Sprintf("fixture-peer-%d", i),
+			Tenant:    models.DefaultTenantID,
+			ExpiresAt: now.Add(time.Duration(cfg.Lease.TTL) * time.Minute),
+			CreatedAt: now.Add(-24 * time.Hour),
+			UpdatedAt: now,
+		}
+		if i >= fixtureActiveLeases {
+			lease.ExpiresAt = now.Add(-time.Duration(i) * time.Minute)
+		}
+		snapshot.Leases = append(snapshot.Leases, lease)
+	}
+
+	store := embedded.NewMemoryStore(clock.NewSystem())
+	if _, err := embedded.NewLeaseSnapshotRepository(store).ImportLeaseState(context.Background(), snapshot, false); err != nil {
+		b.Fatal(err)
+	}
+
+	cache := memory.NewLeaseCache(cfg, clock.NewSystem())
+	return &leaseFixture{
+		repo:   hybrid.NewLeaseRepository(embedded.NewLeaseRepository(cfg, store), cache, nil, nil, zap.NewNop()),
+		cache:  cache,
+		active: snapshot.Leases[:fixtureActiveLeases],
+	}
+}
+
+// BenchmarkAllocateIP allocates leases to new peers with the configured strategy and
+// signs them. The pool is seeded again whenever its expired leases are used up, so every
+// allocation reuses one, as it does in a pool that has filled up once.
+func BenchmarkAllocateIP(b *testing.B) {
+	cfg := config.NewDefaultAppConfig()
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	signer, err := libp2p.NewLeaseSignerWithKey(key, zap.NewNop())
+	if err != nil {
+		b.Fatal(err)
+	}
+	tenants, err := services.NewTenantService(cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	newService := func() *services.LeaseService {
+		fixture := newLeaseFixture(b, cfg)
+		strategy, err := allocation.NewStrategy(cfg, fixture.repo, tenants)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return services.NewLeaseService(cfg, fixture.repo, strategy, nil, nil, nil, signer, nil, clock.NewSystem(), zap.NewNop())
+	}
+
+	ctx := context.Background()
+	var service *services.LeaseService
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%fixtureExpiredLeases == 0 {
+			b.StopTimer()
+			service = newService()
+			b.StartTimer()
+		}
+
+		lease, err := service.AllocateIP(ctx, "benchmark-peer-"+strconv.Itoa(i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if lease.Signature == nil {
+			b.Fatal("lease is not signed")
+		}
+	}
+}
+
+// BenchmarkVerifyAuth verifies signed nonces of an Ed25519 peer and consumes them. Nonces
+// are issued in batches on a fresh store while the timer is stopped.
+func BenchmarkVerifyAuth(b *testing.B) {
+	cfg := config.NewDefaultAppConfig()
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	pubkey, err := crypto.MarshalPublicKey(key.GetPublic())
+	if err != nil {
+		b.Fatal(err)
+	}
+	identity, err := auth.NewIdentityResolver(cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	peerID, err := identity.ResolvePeerID(pubkey)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var service *services.AuthService
+	requests := make([]*models.AuthVerifyRequest, 0, nonceBatchSize)
+	issue := func(n int) {
+		// Consumed nonces stay in the store until they expire, so every batch starts over
+		nonceRepo := hybrid.NewNonceRepository(embedded.NewNonceRepository(cfg, embedded.NewMemoryStore(clock.NewSystem())), memory.NewNonceCache(cfg, clock.NewSystem()), 0, clock.NewSystem(), zap.NewNop())
+		nonceService := services.NewNonceService(cfg, nonceRepo, libp2p.NewSignatureVerifier(), identity)
+		service = services.NewAuthService(cfg, nonceService, identity, clock.NewSystem())
+
+		requests = requests[:0]
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		for range n {
+			nonce, err := nonceRepo.CreateNonce(ctx, peerID)
+			if err != nil {
+				b.Fatal(err)
+			}
+			signature, err := key.Sign(dhcp2pcrypto.AuthPayload(nonce.ID, timestamp))
+			if err != nil {
+				b.Fatal(err)
+			}
+			requests = append(requests, &models.AuthVerifyRequest{
+				Pubkey:    pubkey,
+				NonceID:   nonce.ID,
+				Timestamp: timestamp,
+				Signature: signature,
+			})
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%nonceBatchSize == 0 {
+			b.StopTimer()
+			issue(min(nonceBatchSize, b.N-i))
+			b.StartTimer()
+		}
+
+		if _, err := service.VerifyAuth(ctx, requests[i%nonceBatchSize]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkHybridGetLease looks up active leases by token ID through the cache layer. On
+// a miss the entry is evicted again after each lookup, which is part of the measurement.
+func BenchmarkHybridGetLease(b *testing.B) {
+	cfg := config.NewDefaultAppConfig()
+	fixture := newLeaseFixture(b, cfg)
+	ctx := context.Background()
+
+	b.Run("CacheHit", func(b *testing.B) {
+		for _, lease := range fixture.active {
+			if _, err := fixture.repo.GetLeaseByTokenID(ctx, lease.TokenID); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := fixture.repo.GetLeaseByTokenID(ctx, fixture.active[i%len(fixture.active)].TokenID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("CacheMiss", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			lease := fixture.active[i%len(fixture.active)]
+			if _, err := fixture.repo.GetLeaseByTokenID(ctx, lease.TokenID); err != nil {
+				b.Fatal(err)
+			}
+			if err := fixture.cache.DeleteLease(ctx, lease.PeerID, lease.TokenID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
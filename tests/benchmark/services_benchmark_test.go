@@ -8,11 +8,13 @@ import (
 	"sync/atomic"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/allocation"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
 	"github.com/unicornultrafoundation/dhcp2p/tests/fixtures"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 	"go.uber.org/zap"
 )
 
@@ -23,9 +25,11 @@ func BenchmarkLeaseService_AllocateIP(b *testing.B) {
 	mockRepo := mocks.NewMockLeaseRepository(ctrl)
 	builder := fixtures.NewTestBuilder()
 	service := services.NewLeaseService(&config.AppConfig{
-		MaxLeaseRetries: 3,
-		LeaseRetryDelay: 100,
-	}, mockRepo, zap.NewNop())
+		Lease: config.LeaseConfig{
+			MaxRetries: 3,
+			RetryDelay: 100,
+		},
+	}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	lease := builder.NewLease().Build()
 
@@ -50,7 +54,7 @@ func BenchmarkLeaseService_GetLeaseByPeerID(b *testing.B) {
 
 	mockRepo := mocks.NewMockLeaseRepository(ctrl)
 	builder := fixtures.NewTestBuilder()
-	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, zap.NewNop())
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	lease := builder.NewLease().Build()
 
@@ -73,7 +77,7 @@ func BenchmarkLeaseService_RenewLease(b *testing.B) {
 
 	mockRepo := mocks.NewMockLeaseRepository(ctrl)
 	builder := fixtures.NewTestBuilder()
-	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, zap.NewNop())
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	lease := builder.NewLease().Build()
 
@@ -123,9 +127,11 @@ func BenchmarkConcurrentLeaseAllocation(b *testing.B) {
 	mockRepo := mocks.NewMockLeaseRepository(ctrl)
 	builder := fixtures.NewTestBuilder()
 	service := services.NewLeaseService(&config.AppConfig{
-		MaxLeaseRetries: 3,
-		LeaseRetryDelay: 10, // Lower delay for benchmarking
-	}, mockRepo, zap.NewNop())
+		Lease: config.LeaseConfig{
+			MaxRetries: 3,
+			RetryDelay: 10, // Lower delay for benchmarking
+		},
+	}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	lease := builder.NewLease().Build()
 
@@ -11,7 +11,7 @@ import (
 )
 
 const (
-	APIVersion = "v1"
+	APIVersion      = "v1"
 	ContractVersion = "1.0.0"
 )
 
@@ -78,7 +78,7 @@ func TestLeaseAPI_Contract_GetLease(t *testing.T) {
 		method := "GET"
 		path := fmt.Sprintf("/api/%s/leases/{peer_id}", APIVersion)
 		expectedStatus := http.StatusOK
-		
+
 		assert.Equal(t, "GET", method)
 		assert.Equal(t, "/api/v1/leases/{peer_id}", path)
 		assert.Equal(t, http.StatusOK, expectedStatus)
@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// simulatedClockSchema installs a now() that reads the simulated time. Sessions find it
+// before the built-in one when simclock precedes pg_catalog on their search_path.
+const simulatedClockSchema = `
+CREATE SCHEMA IF NOT EXISTS simclock;
+CREATE TABLE IF NOT EXISTS simclock.clock (at timestamptz NOT NULL);
+CREATE OR REPLACE FUNCTION simclock.now() RETURNS timestamptz
+    LANGUAGE sql STABLE AS 'SELECT at FROM simclock.clock';
+`
+
+// SimulatedClock is a clock shared by a test and PostgreSQL. Queries run through a pool
+// from Pool see its time in now(); column defaults bound when a table was created keep
+// the real clock. Time only moves when the test sets it.
+type SimulatedClock struct {
+	db *pgxpool.Pool
+
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewSimulatedClock installs the simulated now() in the database and sets it to start
+func NewSimulatedClock(ctx context.Context, connStr string, start time.Time) (*SimulatedClock, error) {
+	db, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if _, err := db.Exec(ctx, simulatedClockSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to install simulated clock: %w", err)
+	}
+	if _, err := db.Exec(ctx, "DELETE FROM simclock.clock"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO simclock.clock (at) VALUES (now())"); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	c := &SimulatedClock{db: db}
+	if err := c.Set(ctx, start); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Pool opens a pool whose sessions resolve now() to the simulated time
+func (c *SimulatedClock) Pool(ctx context.Context, connStr string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = "simclock, pg_catalog, public"
+	return pgxpool.NewWithConfig(ctx, cfg)
+}
+
+// Now returns the simulated time, in the microsecond precision of PostgreSQL
+func (c *SimulatedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to t
+func (c *SimulatedClock) Set(ctx context.Context, t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t = t.Truncate(time.Microsecond)
+	if _, err := c.db.Exec(ctx, "UPDATE simclock.clock SET at = $1", t); err != nil {
+		return fmt.Errorf("failed to set simulated clock: %w", err)
+	}
+	c.now = t
+	return nil
+}
+
+// Advance moves the clock forward by d
+func (c *SimulatedClock) Advance(ctx context.Context, d time.Duration) error {
+	return c.Set(ctx, c.Now().Add(d))
+}
+
+// Close releases the connection used to move the clock
+func (c *SimulatedClock) Close() {
+	c.db.Close()
+}
@@ -14,13 +14,13 @@ import (
 
 // ContainerPool manages a pool of reusable test containers
 type ContainerPool struct {
-	mu                  sync.RWMutex
-	postgresPool        chan testcontainers.Container
-	redisPool           chan testcontainers.Container
-	maxPoolSize         int
+	mu                   sync.RWMutex
+	postgresPool         chan testcontainers.Container
+	redisPool            chan testcontainers.Container
+	maxPoolSize          int
 	postgresCreatedCount int
 	redisCreatedCount    int
-	logger              interface{} // Use interface to avoid import cycles
+	logger               interface{} // Use interface to avoid import cycles
 }
 
 // NewContainerPool creates a new container pool
@@ -179,13 +179,13 @@ func (p *ContainerPool) getPostgresConnectionString(ctx context.Context, contain
 	if pgContainer, ok := container.(*postgres.PostgresContainer); ok {
 		return pgContainer.ConnectionString(ctx, "sslmode=disable")
 	}
-	
+
 	// Fallback: try to get host and port manually
 	host, err := container.Host(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get postgres host: %w", err)
 	}
-	
+
 	// Retry getting the port mapping with exponential backoff
 	var port nat.Port
 	for i := 0; i < 10; i++ {
@@ -198,7 +198,7 @@ func (p *ContainerPool) getPostgresConnectionString(ctx context.Context, contain
 		}
 		time.Sleep(time.Duration(200*(i+1)) * time.Millisecond)
 	}
-	
+
 	return fmt.Sprintf("postgres://test:test@%s:%s/dhcp2p_test?sslmode=disable", host, port.Port()), nil
 }
 
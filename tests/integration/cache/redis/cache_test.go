@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/redis"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	testconfig "github.com/unicornultrafoundation/dhcp2p/tests/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/helpers"
+)
+
+func TestCache_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	pool := helpers.GetGlobalPool()
+	redisContainer, connStr, err := pool.GetRedisContainer(ctx)
+	require.NoError(t, err)
+	defer pool.ReturnRedisContainer(redisContainer)
+
+	redisClient := redisclient.NewClient(&redisclient.Options{
+		Addr:         connStr,
+		DialTimeout:  testconfig.TestTimeouts.DatabaseConnect,
+		ReadTimeout:  testconfig.TestTimeouts.RequestTimeout,
+		WriteTimeout: testconfig.TestTimeouts.RequestTimeout,
+	})
+	require.NoError(t, redisClient.Ping(ctx).Err(), "Failed to connect to Redis")
+
+	cborCodec, err := redis.NewCodec(redis.CodecCBOR)
+	require.NoError(t, err)
+
+	keys := redis.KeyBuilder{Prefix: "typed:"}
+	ttl := redis.FixedTTL[*models.Nonce](time.Minute)
+	jsonCache := redis.NewCache(redisClient, keys, redis.JSONCodec{}, ttl, 0)
+	cborCache := redis.NewCache(redisClient, keys, cborCodec, ttl, 0)
+
+	nonce := &models.Nonce{ID: "n1", PeerID: "peer1", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Minute)}
+
+	t.Run("GetMany", func(t *testing.T) {
+		require.NoError(t, cborCache.Set(ctx, nonce, cborCache.Key(ctx, "a"), cborCache.Key(ctx, "b")))
+		require.NoError(t, cborCache.SetAbsent(ctx, cborCache.Key(ctx, "absent"), time.Minute))
+
+		values, err := cborCache.GetMany(ctx, cborCache.Key(ctx, "a"), cborCache.Key(ctx, "b"), cborCache.Key(ctx, "absent"), cborCache.Key(ctx, "missing"))
+		require.NoError(t, err)
+		assert.Len(t, values, 2)
+		assert.Equal(t, "peer1", values[cborCache.Key(ctx, "b")].PeerID)
+	})
+
+	t.Run("absent values", func(t *testing.T) {
+		value, found, err := cborCache.Get(ctx, cborCache.Key(ctx, "absent"))
+		require.NoError(t, err)
+		assert.False(t, found)
+		assert.Nil(t, value)
+
+		_, _, err = cborCache.Get(ctx, cborCache.Key(ctx, "missing"))
+		assert.ErrorIs(t, err, redis.ErrCacheMiss)
+	})
+
+	t.Run("entries of another codec read as misses", func(t *testing.T) {
+		_, _, err := jsonCache.Get(ctx, jsonCache.Key(ctx, "a"))
+		assert.ErrorIs(t, err, redis.ErrCacheMiss)
+	})
+
+	t.Run("Take", func(t *testing.T) {
+		taken, err := cborCache.Take(ctx, cborCache.Key(ctx, "a"))
+		require.NoError(t, err)
+		assert.Equal(t, "n1", taken.ID)
+
+		_, err = cborCache.Take(ctx, cborCache.Key(ctx, "a"))
+		assert.ErrorIs(t, err, redis.ErrCacheMiss)
+	})
+}
@@ -46,11 +46,17 @@ func TestLeaseCache_Integration(t *testing.T) {
 
 	// Create test config
 	cfg := &config.AppConfig{
-		LeaseTTL: testconfig.DefaultTTL,
+		Lease: config.LeaseConfig{
+			TTL: testconfig.DefaultTTL,
+		},
+		Cache: config.CacheConfig{
+			NegativeTTL: 5,
+		},
 	}
 
 	// Create LeaseCache
-	leaseCache := redis.NewLeaseCache(redisClient, cfg)
+	leaseCache, err := redis.NewLeaseCache(redisClient, cfg)
+	require.NoError(t, err)
 
 	t.Run("SetLease", func(t *testing.T) {
 		lease := builder.NewLease().WithPeerID("peer123").Build()
@@ -103,6 +109,32 @@ func TestLeaseCache_Integration(t *testing.T) {
 		assert.Nil(t, lease)
 	})
 
+	t.Run("NegativeEntry", func(t *testing.T) {
+		err := leaseCache.SetPeerNotFound(ctx, "peer-negative")
+		require.NoError(t, err)
+
+		// A cached "not found" entry is a hit without a lease
+		lease, err := leaseCache.GetLeaseByPeerID(ctx, "peer-negative")
+		assert.NoError(t, err)
+		assert.Nil(t, lease)
+
+		// Allocation replaces the marker
+		allocated := builder.NewLease().WithTokenID(33333).WithPeerID("peer-negative").Build()
+		require.NoError(t, leaseCache.SetLease(ctx, allocated))
+
+		lease, err = leaseCache.GetLeaseByPeerID(ctx, "peer-negative")
+		assert.NoError(t, err)
+		require.NotNil(t, lease)
+		assert.Equal(t, allocated.TokenID, lease.TokenID)
+
+		// A marker never replaces a cached lease
+		require.NoError(t, leaseCache.SetTokenNotFound(ctx, allocated.TokenID))
+		lease, err = leaseCache.GetLeaseByTokenID(ctx, allocated.TokenID)
+		assert.NoError(t, err)
+		require.NotNil(t, lease)
+		assert.Equal(t, allocated.PeerID, lease.PeerID)
+	})
+
 	t.Run("DeleteLease", func(t *testing.T) {
 		lease := builder.NewLease().WithTokenID(22222).WithPeerID("peer-delete").Build()
 
@@ -50,11 +50,14 @@ func TestNonceCache_Integration(t *testing.T) {
 
 	// Create test config
 	cfg := &config.AppConfig{
-		NonceTTL: 5, // 5 minutes
+		Nonce: config.NonceConfig{
+			TTL: 5, // 5 minutes
+		},
 	}
 
 	// Create NonceCache
-	nonceCache := redis.NewNonceCache(redisClient, cfg)
+	nonceCache, err := redis.NewNonceCache(redisClient, cfg)
+	require.NoError(t, err)
 
 	t.Run("CreateNonce", func(t *testing.T) {
 		nonce := &models.Nonce{
@@ -128,9 +131,12 @@ func TestNonceCache_Integration(t *testing.T) {
 
 	t.Run("NonceTTL", func(t *testing.T) {
 		// Create a nonce with very short TTL
-		shortTTLCache := redis.NewNonceCache(redisClient, &config.AppConfig{
-			NonceTTL: 1, // 1 minute
+		shortTTLCache, err := redis.NewNonceCache(redisClient, &config.AppConfig{
+			Nonce: config.NonceConfig{
+				TTL: 1, // 1 minute
+			},
 		})
+		require.NoError(t, err)
 
 		nonce := &models.Nonce{
 			ID:        "test-nonce-ttl",
@@ -140,7 +146,7 @@ func TestNonceCache_Integration(t *testing.T) {
 			Used:      false,
 		}
 
-		err := shortTTLCache.CreateNonce(ctx, nonce)
+		err = shortTTLCache.CreateNonce(ctx, nonce)
 		require.NoError(t, err)
 
 		// Verify nonce exists
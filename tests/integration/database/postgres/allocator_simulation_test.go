@@ -0,0 +1,418 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	postgresModule "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/helpers"
+	"go.uber.org/zap"
+	"pgregory.net/rapid"
+)
+
+// The simulation drives two repository instances sharing one database through random
+// sequences of allocations, renewals, releases, conflict reports, restarts and clock
+// jumps, and compares the database with a model of the expected leases after every
+// step. The pool is small so that it runs out and token IDs are reused. Failures print
+// the rapid seed to replay; -rapid.checks and -rapid.steps scale the run.
+
+const (
+	simPoolSize  = 12
+	simInstances = 2
+	simPeers     = 10
+)
+
+var simStart = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// simLease is a lease row as the model expects it
+type simLease struct {
+	peerID           string
+	expiresAt        time.Time
+	quarantinedUntil time.Time // zero when not quarantined
+}
+
+// allocatorMachine is a rapid state machine over the Postgres lease repository
+type allocatorMachine struct {
+	ctx     context.Context
+	cfg     *config.AppConfig
+	db      *pgxpool.Pool
+	clock   *helpers.SimulatedClock
+	repos   []*postgres.LeaseRepository
+	peers   []string
+	leases  map[int64]*simLease
+	ttl     time.Duration
+	grace   time.Duration
+	minID   int64
+	maxID   int64
+	history int // operations run, for failure messages
+}
+
+func TestLeaseAllocator_Simulation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	postgresContainer, err := postgresModule.RunContainer(ctx,
+		testcontainers.WithImage("postgres:15-alpine"),
+		postgresModule.WithDatabase("dhcp2p_test"),
+		postgresModule.WithUsername("test"),
+		postgresModule.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	connStr, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	// The real schema, applied the way the server does
+	migrationPool, err := pgxpool.New(ctx, connStr)
+	require.NoError(t, err)
+	migrator, err := postgres.NewMigrator(migrationPool, zap.NewNop())
+	require.NoError(t, err)
+	_, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+	migrationPool.Close()
+
+	clock, err := helpers.NewSimulatedClock(ctx, connStr, simStart)
+	require.NoError(t, err)
+	defer clock.Close()
+
+	db, err := clock.Pool(ctx, connStr)
+	require.NoError(t, err)
+	defer db.Close()
+
+	cfg := config.NewDefaultAppConfig()
+	cfg.Lease.TTL = 10                // minutes
+	cfg.Lease.ReleaseGrace = 30       // seconds
+	cfg.Lease.AllocationChunkSize = 3 // instances reserve interleaved blocks
+	cfg.Lease.MaxPerPeer = 1
+	cfg.PoolMaxTokenID = cfg.PoolMinTokenID + simPoolSize - 1
+
+	peers := make([]string, simPeers)
+	for i := range peers {
+		peers[i] = fmt.Sprintf("sim-peer-%d", i)
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		m := &allocatorMachine{
+			ctx:    ctx,
+			cfg:    cfg,
+			db:     db,
+			clock:  clock,
+			peers:  peers,
+			leases: make(map[int64]*simLease),
+			ttl:    time.Duration(cfg.Lease.TTL) * time.Minute,
+			grace:  time.Duration(cfg.Lease.ReleaseGrace) * time.Second,
+			minID:  cfg.PoolMinTokenID,
+			maxID:  cfg.PoolMaxTokenID,
+		}
+		m.reset(t)
+		t.Repeat(rapid.StateMachineActions(m))
+	})
+}
+
+// reset empties the pool, rewinds the clock and starts fresh instances
+func (m *allocatorMachine) reset(t *rapid.T) {
+	_, err := m.db.Exec(m.ctx, "TRUNCATE leases, lease_history")
+	m.must(t, err)
+	_, err = m.db.Exec(m.ctx,
+		"UPDATE alloc_state SET min_token_id = $1, max_token_id = $2, last_token_id = $1 - 1 WHERE tenant_id = 'default'",
+		m.minID, m.maxID)
+	m.must(t, err)
+	m.must(t, m.clock.Set(m.ctx, simStart))
+
+	m.repos = make([]*postgres.LeaseRepository, simInstances)
+	for i := range m.repos {
+		m.repos[i] = m.newRepo()
+	}
+}
+
+func (m *allocatorMachine) newRepo() *postgres.LeaseRepository {
+	return postgres.NewLeaseRepository(m.cfg, m.db, nil, m.clock, nil)
+}
+
+func (m *allocatorMachine) must(t *rapid.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("step %d: %v", m.history, err)
+	}
+}
+
+func (m *allocatorMachine) drawRepo(t *rapid.T) *postgres.LeaseRepository {
+	return m.repos[rapid.IntRange(0, simInstances-1).Draw(t, "instance")]
+}
+
+// drawToken picks the active lease of peerID most of the time, otherwise any token ID
+// around the pool
+func (m *allocatorMachine) drawToken(t *rapid.T, peerID string) int64 {
+	if tokenID, ok := m.heldBy(peerID); ok && rapid.IntRange(0, 3).Draw(t, "own") > 0 {
+		return tokenID
+	}
+	return rapid.Int64Range(m.minID-1, m.maxID+1).Draw(t, "tokenID")
+}
+
+func (m *allocatorMachine) heldBy(peerID string) (int64, bool) {
+	now := m.clock.Now()
+	for tokenID, lease := range m.leases {
+		if lease.peerID == peerID && lease.expiresAt.After(now) {
+			return tokenID, true
+		}
+	}
+	return 0, false
+}
+
+// reusable mirrors FindExpiredLeaseForReuse
+func (m *allocatorMachine) reusable(lease *simLease) bool {
+	now := m.clock.Now()
+	return lease.expiresAt.Before(now.Add(-m.grace)) && !lease.quarantinedUntil.After(now)
+}
+
+// requestable mirrors the checks of AllocateRequestedLease on a leased token ID
+func (m *allocatorMachine) requestable(lease *simLease) bool {
+	now := m.clock.Now()
+	return !lease.expiresAt.After(now.Add(-m.grace)) && !lease.quarantinedUntil.After(now)
+}
+
+func (m *allocatorMachine) grant(tokenID int64, peerID string) {
+	m.leases[tokenID] = &simLease{peerID: peerID, expiresAt: m.clock.Now().Add(m.ttl)}
+}
+
+// Allocate runs the LRU strategy: reuse the lease that expired first, otherwise take a
+// token ID that was never leased
+func (m *allocatorMachine) Allocate(t *rapid.T) {
+	m.history++
+	repo := m.drawRepo(t)
+	peerID := rapid.SampledFrom(m.peers).Draw(t, "peerID")
+	_, holding := m.heldBy(peerID)
+
+	lease, err := repo.FindAndReuseExpiredLease(m.ctx, peerID)
+	if holding {
+		if !errors.Is(err, domainErrors.ErrLeaseQuotaExceeded) {
+			t.Fatalf("step %d: %s holds a lease, reuse returned %v, %v", m.history, peerID, lease, err)
+		}
+		return
+	}
+	m.must(t, err)
+
+	if lease != nil {
+		previous, ok := m.leases[lease.TokenID]
+		if !ok || !m.reusable(previous) {
+			t.Fatalf("step %d: reused token %d, which is not reusable: %+v", m.history, lease.TokenID, previous)
+		}
+		for tokenID, other := range m.leases {
+			if m.reusable(other) && other.expiresAt.Before(previous.expiresAt) {
+				t.Fatalf("step %d: reused token %d although token %d expired earlier", m.history, lease.TokenID, tokenID)
+			}
+		}
+	} else {
+		for tokenID, other := range m.leases {
+			if m.reusable(other) {
+				t.Fatalf("step %d: no lease reused although token %d is reusable", m.history, tokenID)
+			}
+		}
+
+		lease, err = repo.AllocateNewLease(m.ctx, peerID)
+		if errors.Is(err, domainErrors.ErrAllocationFailed) {
+			var last int64
+			m.must(t, m.db.QueryRow(m.ctx, "SELECT last_token_id FROM alloc_state WHERE tenant_id = 'default'").Scan(&last))
+			if last != m.maxID {
+				t.Fatalf("step %d: pool reported exhausted with the cursor at %d of %d", m.history, last, m.maxID)
+			}
+			return
+		}
+		m.must(t, err)
+
+		// Blocks reserved by the instances never overlap, and never cover leased token IDs
+		if previous, ok := m.leases[lease.TokenID]; ok {
+			t.Fatalf("step %d: new lease got token %d, which was leased before: %+v", m.history, lease.TokenID, previous)
+		}
+	}
+
+	m.checkGranted(t, lease.TokenID, lease.PeerID, lease.ExpiresAt, peerID)
+	m.grant(lease.TokenID, peerID)
+}
+
+// AllocateRequested asks for a specific token ID, possibly outside the pool
+func (m *allocatorMachine) AllocateRequested(t *rapid.T) {
+	m.history++
+	repo := m.drawRepo(t)
+	peerID := rapid.SampledFrom(m.peers).Draw(t, "peerID")
+	tokenID := rapid.Int64Range(m.minID-1, m.maxID+1).Draw(t, "tokenID")
+	_, holding := m.heldBy(peerID)
+	previous, leased := m.leases[tokenID]
+
+	lease, err := repo.AllocateRequestedLease(m.ctx, peerID, tokenID)
+	switch {
+	case tokenID < m.minID || tokenID > m.maxID:
+		m.expectError(t, err, domainErrors.ErrTokenIDOutOfRange)
+	case holding:
+		m.expectError(t, err, domainErrors.ErrLeaseQuotaExceeded)
+	case leased && !m.requestable(previous):
+		m.expectError(t, err, domainErrors.ErrTokenIDInUse)
+	default:
+		m.must(t, err)
+		m.checkGranted(t, lease.TokenID, lease.PeerID, lease.ExpiresAt, peerID)
+		if lease.TokenID != tokenID {
+			t.Fatalf("step %d: requested token %d, got %d", m.history, tokenID, lease.TokenID)
+		}
+		m.grant(tokenID, peerID)
+	}
+}
+
+// Renew extends an active lease of its holder
+func (m *allocatorMachine) Renew(t *rapid.T) {
+	m.history++
+	repo := m.drawRepo(t)
+	peerID := rapid.SampledFrom(m.peers).Draw(t, "peerID")
+	tokenID := m.drawToken(t, peerID)
+
+	lease, err := repo.RenewLease(m.ctx, tokenID, peerID)
+	if current, ok := m.leases[tokenID]; ok && current.peerID == peerID && current.expiresAt.After(m.clock.Now()) {
+		m.must(t, err)
+		m.checkGranted(t, lease.TokenID, lease.PeerID, lease.ExpiresAt, peerID)
+		current.expiresAt = lease.ExpiresAt
+		return
+	}
+	m.expectError(t, err, domainErrors.ErrLeaseNotFound)
+}
+
+// Release expires the lease of peerID at once, a no-op on leases of other peers
+func (m *allocatorMachine) Release(t *rapid.T) {
+	m.history++
+	repo := m.drawRepo(t)
+	peerID := rapid.SampledFrom(m.peers).Draw(t, "peerID")
+	tokenID := m.drawToken(t, peerID)
+
+	m.must(t, repo.ReleaseLease(m.ctx, tokenID, peerID))
+	if current, ok := m.leases[tokenID]; ok && current.peerID == peerID {
+		current.expiresAt = m.clock.Now()
+	}
+}
+
+// ReportConflict releases the lease of its holder and withholds the token ID
+func (m *allocatorMachine) ReportConflict(t *rapid.T) {
+	m.history++
+	repo := m.drawRepo(t)
+	peerID := rapid.SampledFrom(m.peers).Draw(t, "peerID")
+	tokenID := m.drawToken(t, peerID)
+	quarantine := time.Duration(rapid.IntRange(0, 120).Draw(t, "quarantineSeconds")) * time.Second
+
+	_, err := repo.RecordConflict(m.ctx, tokenID, peerID, quarantine)
+	current, ok := m.leases[tokenID]
+	if !ok || current.peerID != peerID || !current.expiresAt.After(m.clock.Now()) {
+		m.expectError(t, err, domainErrors.ErrLeaseNotFound)
+		return
+	}
+	m.must(t, err)
+	if quarantine > 0 {
+		current.expiresAt = m.clock.Now()
+		current.quarantinedUntil = current.expiresAt.Add(quarantine)
+	}
+}
+
+// AdvanceClock lets time pass, expiring leases and ending grace periods and quarantines
+func (m *allocatorMachine) AdvanceClock(t *rapid.T) {
+	m.history++
+	seconds := rapid.IntRange(1, 900).Draw(t, "seconds")
+	m.must(t, m.clock.Advance(m.ctx, time.Duration(seconds)*time.Second))
+}
+
+// RestartInstance shuts an instance down, returning its reserved token IDs, and starts
+// a new one in its place
+func (m *allocatorMachine) RestartInstance(t *rapid.T) {
+	m.history++
+	i := rapid.IntRange(0, simInstances-1).Draw(t, "instance")
+	m.must(t, m.repos[i].ReturnReservedTokenIDs(m.ctx))
+	m.repos[i] = m.newRepo()
+}
+
+// Check compares the leases table with the model and asserts the pool invariants
+func (m *allocatorMachine) Check(t *rapid.T) {
+	rows, err := m.db.Query(m.ctx, "SELECT token_id, peer_id, expires_at, quarantined_until FROM leases WHERE tenant_id = 'default'")
+	m.must(t, err)
+	defer rows.Close()
+
+	now := m.clock.Now()
+	seen := 0
+	activePeers := make(map[string]int64)
+	for rows.Next() {
+		var tokenID int64
+		var peerID string
+		var expiresAt time.Time
+		var quarantinedUntil *time.Time
+		m.must(t, rows.Scan(&tokenID, &peerID, &expiresAt, &quarantinedUntil))
+		seen++
+
+		if tokenID < m.minID || tokenID > m.maxID {
+			t.Fatalf("step %d: token %d outside the pool [%d, %d]", m.history, tokenID, m.minID, m.maxID)
+		}
+
+		expected, ok := m.leases[tokenID]
+		if !ok {
+			t.Fatalf("step %d: unexpected lease of token %d to %s", m.history, tokenID, peerID)
+		}
+		if peerID != expected.peerID || !expiresAt.Equal(expected.expiresAt) {
+			t.Fatalf("step %d: token %d held by %s until %s, expected %s until %s",
+				m.history, tokenID, peerID, expiresAt, expected.peerID, expected.expiresAt)
+		}
+		if got := quarantinedUntil != nil && quarantinedUntil.After(now); got != expected.quarantinedUntil.After(now) {
+			t.Fatalf("step %d: token %d quarantined %v, expected %v", m.history, tokenID, got, !got)
+		}
+
+		if expiresAt.After(now) {
+			if other, ok := activePeers[peerID]; ok {
+				t.Fatalf("step %d: %s holds tokens %d and %d beyond its quota", m.history, peerID, other, tokenID)
+			}
+			activePeers[peerID] = tokenID
+		}
+	}
+	m.must(t, rows.Err())
+	if seen != len(m.leases) {
+		t.Fatalf("step %d: %d leases in the database, expected %d", m.history, seen, len(m.leases))
+	}
+
+	var last int64
+	m.must(t, m.db.QueryRow(m.ctx, "SELECT last_token_id FROM alloc_state WHERE tenant_id = 'default'").Scan(&last))
+	if last < m.minID-1 || last > m.maxID {
+		t.Fatalf("step %d: allocation cursor %d outside the pool [%d, %d]", m.history, last, m.minID, m.maxID)
+	}
+}
+
+// checkGranted asserts that a lease just granted to peerID is within the pool and runs
+// for a full TTL from now
+func (m *allocatorMachine) checkGranted(t *rapid.T, tokenID int64, peerID string, expiresAt time.Time, expectedPeerID string) {
+	if tokenID < m.minID || tokenID > m.maxID {
+		t.Fatalf("step %d: granted token %d outside the pool [%d, %d]", m.history, tokenID, m.minID, m.maxID)
+	}
+	if peerID != expectedPeerID {
+		t.Fatalf("step %d: token %d granted to %s instead of %s", m.history, tokenID, peerID, expectedPeerID)
+	}
+	if want := m.clock.Now().Add(m.ttl); !expiresAt.Equal(want) {
+		t.Fatalf("step %d: token %d expires at %s, expected %s", m.history, tokenID, expiresAt, want)
+	}
+}
+
+func (m *allocatorMachine) expectError(t *rapid.T, err error, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Fatalf("step %d: expected %v, got %v", m.history, expected, err)
+	}
+}
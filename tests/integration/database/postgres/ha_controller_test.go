@@ -0,0 +1,132 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	postgresModule "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func TestHAController_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	postgresContainer, err := postgresModule.RunContainer(ctx,
+		testcontainers.WithImage("postgres:15-alpine"),
+		postgresModule.WithDatabase("dhcp2p_test"),
+		postgresModule.WithUsername("test"),
+		postgresModule.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	connStr, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	migrationPool, err := pgxpool.New(ctx, connStr)
+	require.NoError(t, err)
+	migrator, err := postgres.NewMigrator(migrationPool, zap.NewNop())
+	require.NoError(t, err)
+	_, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+	migrationPool.Close()
+
+	// Each instance has a pool of its own
+	newInstance := func(name string) (*postgres.HAController, *postgres.LeaseRepository, *fxtest.Lifecycle) {
+		cfg := config.NewDefaultAppConfig()
+		cfg.HAEnabled = true
+		cfg.HAInstance = name
+		cfg.HACheckInterval = 1
+
+		pool, err := pgxpool.New(ctx, connStr)
+		require.NoError(t, err)
+		t.Cleanup(pool.Close)
+
+		lc := fxtest.NewLifecycle(t)
+		ha, err := postgres.NewHAController(lc, cfg, pool, nil, zap.NewNop())
+		require.NoError(t, err)
+		return ha, postgres.NewLeaseRepository(cfg, pool, nil, clock.NewSystem(), ha), lc
+	}
+
+	first, firstRepo, firstLC := newInstance("dhcp2p-a")
+	second, secondRepo, secondLC := newInstance("dhcp2p-b")
+
+	firstLC.RequireStart()
+	secondLC.RequireStart()
+	defer secondLC.RequireStop()
+
+	require.True(t, first.IsActive())
+	require.False(t, second.IsActive())
+	activeEpoch := first.Status().Epoch
+
+	t.Run("only the active instance changes leases", func(t *testing.T) {
+		lease, err := firstRepo.AllocateNewLease(ctx, "peer-a")
+		require.NoError(t, err)
+		require.NoError(t, firstRepo.SetLeaseAffinityGroup(ctx, lease.TokenID, "rack-1"))
+
+		_, err = secondRepo.AllocateNewLease(ctx, "peer-b")
+		assert.ErrorIs(t, err, domainErrors.ErrNotActive)
+		assert.ErrorIs(t, secondRepo.SetLeaseAffinityGroup(ctx, lease.TokenID, "rack-2"), domainErrors.ErrNotActive)
+		assert.ErrorIs(t, secondRepo.ReleaseLease(ctx, lease.TokenID, "peer-a"), domainErrors.ErrNotActive)
+	})
+
+	t.Run("the standby mirrors lease changes", func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			return second.Status().MirroredDeltas > 0
+		}, 5*time.Second, 100*time.Millisecond)
+
+		status := second.Status()
+		assert.Equal(t, models.HARoleStandby, status.Role)
+		assert.Equal(t, "dhcp2p-a", status.ActiveInstance)
+		assert.Equal(t, activeEpoch, status.Epoch)
+	})
+
+	t.Run("the standby takes over when the active instance stops", func(t *testing.T) {
+		firstLC.RequireStop()
+		assert.False(t, first.IsActive())
+
+		assert.Eventually(t, second.IsActive, 5*time.Second, 100*time.Millisecond)
+		assert.Greater(t, second.Status().Epoch, activeEpoch)
+
+		lease, err := secondRepo.AllocateNewLease(ctx, "peer-b")
+		require.NoError(t, err)
+
+		// The former active instance stays fenced off
+		_, err = firstRepo.RenewLease(ctx, lease.TokenID, "peer-b")
+		assert.ErrorIs(t, err, domainErrors.ErrNotActive)
+	})
+
+	t.Run("writes are not fenced without active/standby mode", func(t *testing.T) {
+		pool, err := pgxpool.New(ctx, connStr)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		cfg := config.NewDefaultAppConfig()
+		ha, err := postgres.NewHAController(fxtest.NewLifecycle(t), cfg, pool, nil, zap.NewNop())
+		require.NoError(t, err)
+		assert.True(t, ha.IsActive())
+		assert.False(t, ha.Enabled())
+	})
+}
@@ -0,0 +1,86 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	postgresModule "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func TestLeaderElector_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	postgresContainer, err := postgresModule.RunContainer(ctx,
+		testcontainers.WithImage("postgres:15-alpine"),
+		postgresModule.WithDatabase("dhcp2p_test"),
+		postgresModule.WithUsername("test"),
+		postgresModule.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	connStr, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	cfg := config.NewDefaultAppConfig()
+	cfg.LeaderElectionInterval = 1
+
+	// Each replica has a pool of its own
+	newReplica := func() (*postgres.LeaderElector, *fxtest.Lifecycle) {
+		pool, err := pgxpool.New(ctx, connStr)
+		require.NoError(t, err)
+		t.Cleanup(pool.Close)
+
+		lc := fxtest.NewLifecycle(t)
+		return postgres.NewLeaderElector(lc, cfg, pool, zap.NewNop()), lc
+	}
+
+	first, firstLC := newReplica()
+	second, secondLC := newReplica()
+
+	firstLC.RequireStart()
+	secondLC.RequireStart()
+	defer secondLC.RequireStop()
+
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+
+	t.Run("another replica takes over when the leader stops", func(t *testing.T) {
+		firstLC.RequireStop()
+		assert.False(t, first.IsLeader())
+
+		assert.Eventually(t, second.IsLeader, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("every replica leads without election", func(t *testing.T) {
+		disabled := config.NewDefaultAppConfig()
+		disabled.LeaderElectionEnabled = false
+
+		pool, err := pgxpool.New(ctx, connStr)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		elector := postgres.NewLeaderElector(fxtest.NewLifecycle(t), disabled, pool, zap.NewNop())
+		assert.True(t, elector.IsLeader())
+	})
+}
@@ -9,13 +9,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/allocation"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
 	testconfig "github.com/unicornultrafoundation/dhcp2p/tests/config"
 	"github.com/unicornultrafoundation/dhcp2p/tests/fixtures"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 	"go.uber.org/zap"
 )
 
@@ -91,9 +93,11 @@ func runLoadTest(t *testing.T, concurrentUsers int, duration time.Duration, requ
 	mockRepo.EXPECT().AllocateNewLease(gomock.Any(), gomock.Any()).Return(lease, nil).AnyTimes()
 
 	service := services.NewLeaseService(&config.AppConfig{
-		MaxLeaseRetries: 3,
-		LeaseRetryDelay: 10, // Lower delay for load testing
-	}, mockRepo, zap.NewNop())
+		Lease: config.LeaseConfig{
+			MaxRetries: 3,
+			RetryDelay: 10, // Lower delay for load testing
+		},
+	}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	ctx, cancel := context.WithTimeout(context.Background(), duration+30*time.Second)
 	defer cancel()
@@ -235,7 +239,7 @@ func TestLoad_LeaseOperations_MixedWorkload(t *testing.T) {
 	mockRepo.EXPECT().RenewLease(gomock.Any(), gomock.Any(), gomock.Any()).Return(lease, nil).AnyTimes()
 	mockRepo.EXPECT().ReleaseLease(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
-	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, zap.NewNop())
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	ctx, cancel := context.WithTimeout(context.Background(), testconfig.LoadTestDuration)
 	defer cancel()
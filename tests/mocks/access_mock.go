@@ -0,0 +1,243 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../internal/app/domain/ports/access.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+// MockAccessControlService is a mock of AccessControlService interface.
+type MockAccessControlService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessControlServiceMockRecorder
+}
+
+// MockAccessControlServiceMockRecorder is the mock recorder for MockAccessControlService.
+type MockAccessControlServiceMockRecorder struct {
+	mock *MockAccessControlService
+}
+
+// NewMockAccessControlService creates a new mock instance.
+func NewMockAccessControlService(ctrl *gomock.Controller) *MockAccessControlService {
+	mock := &MockAccessControlService{ctrl: ctrl}
+	mock.recorder = &MockAccessControlServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessControlService) EXPECT() *MockAccessControlServiceMockRecorder {
+	return m.recorder
+}
+
+// AddRule mocks base method.
+func (m *MockAccessControlService) AddRule(ctx context.Context, rule *models.AccessRule) (*models.AccessRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRule", ctx, rule)
+	ret0, _ := ret[0].(*models.AccessRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRule indicates an expected call of AddRule.
+func (mr *MockAccessControlServiceMockRecorder) AddRule(ctx, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRule", reflect.TypeOf((*MockAccessControlService)(nil).AddRule), ctx, rule)
+}
+
+// CheckAccess mocks base method.
+func (m *MockAccessControlService) CheckAccess(ctx context.Context, peerID string, pubkey []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckAccess", ctx, peerID, pubkey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckAccess indicates an expected call of CheckAccess.
+func (mr *MockAccessControlServiceMockRecorder) CheckAccess(ctx, peerID, pubkey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAccess", reflect.TypeOf((*MockAccessControlService)(nil).CheckAccess), ctx, peerID, pubkey)
+}
+
+// ListRules mocks base method.
+func (m *MockAccessControlService) ListRules(ctx context.Context, list models.AccessList) ([]*models.AccessRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRules", ctx, list)
+	ret0, _ := ret[0].([]*models.AccessRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRules indicates an expected call of ListRules.
+func (mr *MockAccessControlServiceMockRecorder) ListRules(ctx, list interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRules", reflect.TypeOf((*MockAccessControlService)(nil).ListRules), ctx, list)
+}
+
+// RemoveRule mocks base method.
+func (m *MockAccessControlService) RemoveRule(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRule indicates an expected call of RemoveRule.
+func (mr *MockAccessControlServiceMockRecorder) RemoveRule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRule", reflect.TypeOf((*MockAccessControlService)(nil).RemoveRule), ctx, id)
+}
+
+// MockAccessRuleRepository is a mock of AccessRuleRepository interface.
+type MockAccessRuleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessRuleRepositoryMockRecorder
+}
+
+// MockAccessRuleRepositoryMockRecorder is the mock recorder for MockAccessRuleRepository.
+type MockAccessRuleRepositoryMockRecorder struct {
+	mock *MockAccessRuleRepository
+}
+
+// NewMockAccessRuleRepository creates a new mock instance.
+func NewMockAccessRuleRepository(ctrl *gomock.Controller) *MockAccessRuleRepository {
+	mock := &MockAccessRuleRepository{ctrl: ctrl}
+	mock.recorder = &MockAccessRuleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessRuleRepository) EXPECT() *MockAccessRuleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAccessRule mocks base method.
+func (m *MockAccessRuleRepository) CreateAccessRule(ctx context.Context, rule *models.AccessRule) (*models.AccessRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessRule", ctx, rule)
+	ret0, _ := ret[0].(*models.AccessRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccessRule indicates an expected call of CreateAccessRule.
+func (mr *MockAccessRuleRepositoryMockRecorder) CreateAccessRule(ctx, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessRule", reflect.TypeOf((*MockAccessRuleRepository)(nil).CreateAccessRule), ctx, rule)
+}
+
+// DeleteAccessRule mocks base method.
+func (m *MockAccessRuleRepository) DeleteAccessRule(ctx context.Context, id int64) (*models.AccessRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccessRule", ctx, id)
+	ret0, _ := ret[0].(*models.AccessRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccessRule indicates an expected call of DeleteAccessRule.
+func (mr *MockAccessRuleRepositoryMockRecorder) DeleteAccessRule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccessRule", reflect.TypeOf((*MockAccessRuleRepository)(nil).DeleteAccessRule), ctx, id)
+}
+
+// GetAccessRules mocks base method.
+func (m *MockAccessRuleRepository) GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessRules", ctx, subjectType, subject)
+	ret0, _ := ret[0].([]*models.AccessRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessRules indicates an expected call of GetAccessRules.
+func (mr *MockAccessRuleRepositoryMockRecorder) GetAccessRules(ctx, subjectType, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessRules", reflect.TypeOf((*MockAccessRuleRepository)(nil).GetAccessRules), ctx, subjectType, subject)
+}
+
+// ListAccessRules mocks base method.
+func (m *MockAccessRuleRepository) ListAccessRules(ctx context.Context, list models.AccessList) ([]*models.AccessRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccessRules", ctx, list)
+	ret0, _ := ret[0].([]*models.AccessRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccessRules indicates an expected call of ListAccessRules.
+func (mr *MockAccessRuleRepositoryMockRecorder) ListAccessRules(ctx, list interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccessRules", reflect.TypeOf((*MockAccessRuleRepository)(nil).ListAccessRules), ctx, list)
+}
+
+// MockAccessRuleCache is a mock of AccessRuleCache interface.
+type MockAccessRuleCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessRuleCacheMockRecorder
+}
+
+// MockAccessRuleCacheMockRecorder is the mock recorder for MockAccessRuleCache.
+type MockAccessRuleCacheMockRecorder struct {
+	mock *MockAccessRuleCache
+}
+
+// NewMockAccessRuleCache creates a new mock instance.
+func NewMockAccessRuleCache(ctrl *gomock.Controller) *MockAccessRuleCache {
+	mock := &MockAccessRuleCache{ctrl: ctrl}
+	mock.recorder = &MockAccessRuleCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessRuleCache) EXPECT() *MockAccessRuleCacheMockRecorder {
+	return m.recorder
+}
+
+// DeleteAccessRules mocks base method.
+func (m *MockAccessRuleCache) DeleteAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccessRules", ctx, subjectType, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccessRules indicates an expected call of DeleteAccessRules.
+func (mr *MockAccessRuleCacheMockRecorder) DeleteAccessRules(ctx, subjectType, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccessRules", reflect.TypeOf((*MockAccessRuleCache)(nil).DeleteAccessRules), ctx, subjectType, subject)
+}
+
+// GetAccessRules mocks base method.
+func (m *MockAccessRuleCache) GetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string) ([]*models.AccessRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessRules", ctx, subjectType, subject)
+	ret0, _ := ret[0].([]*models.AccessRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessRules indicates an expected call of GetAccessRules.
+func (mr *MockAccessRuleCacheMockRecorder) GetAccessRules(ctx, subjectType, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessRules", reflect.TypeOf((*MockAccessRuleCache)(nil).GetAccessRules), ctx, subjectType, subject)
+}
+
+// SetAccessRules mocks base method.
+func (m *MockAccessRuleCache) SetAccessRules(ctx context.Context, subjectType models.AccessSubjectType, subject string, rules []*models.AccessRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccessRules", ctx, subjectType, subject, rules)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAccessRules indicates an expected call of SetAccessRules.
+func (mr *MockAccessRuleCacheMockRecorder) SetAccessRules(ctx, subjectType, subject, rules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccessRules", reflect.TypeOf((*MockAccessRuleCache)(nil).SetAccessRules), ctx, subjectType, subject, rules)
+}
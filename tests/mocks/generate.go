@@ -1,8 +1,24 @@
 package mocks
 
 //go:generate mockgen -source=../../internal/app/domain/ports/lease.go -destination=lease_repository_mock.go -package=mocks
-//go:generate mockgen -source=../../internal/app/domain/ports/nonce.go -destination=nonce_repository_mock.go -package=mocks  
+//go:generate mockgen -source=../../internal/app/domain/ports/nonce.go -destination=nonce_repository_mock.go -package=mocks
 //go:generate mockgen -source=../../internal/app/domain/ports/auth.go -destination=auth_repository_mock.go -package=mocks
 //go:generate mockgen -source=../../internal/app/domain/ports/verifier.go -destination=verifier_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/diagnostics.go -destination=diagnostics_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/lease_query.go -destination=lease_query_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/reclamation.go -destination=reclamation_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/expiry.go -destination=expiry_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/schema.go -destination=schema_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/identity.go -destination=identity_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/access.go -destination=access_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/signer.go -destination=signer_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/tenant.go -destination=tenant_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/delegation.go -destination=delegation_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/lease_event.go -destination=lease_event_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/dns.go -destination=dns_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/pool_stats.go -destination=pool_stats_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/snapshot.go -destination=snapshot_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/event_bus.go -destination=event_bus_mock.go -package=mocks
+//go:generate mockgen -source=../../internal/app/domain/ports/lease_revocation.go -destination=lease_revocation_mock.go -package=mocks
 
 //go:generate echo "Mock generation completed. Run 'go generate' from tests/mocks directory."
@@ -7,11 +7,252 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 )
 
+// MockLeaseReader is a mock of LeaseReader interface.
+type MockLeaseReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaseReaderMockRecorder
+}
+
+// MockLeaseReaderMockRecorder is the mock recorder for MockLeaseReader.
+type MockLeaseReaderMockRecorder struct {
+	mock *MockLeaseReader
+}
+
+// NewMockLeaseReader creates a new mock instance.
+func NewMockLeaseReader(ctrl *gomock.Controller) *MockLeaseReader {
+	mock := &MockLeaseReader{ctrl: ctrl}
+	mock.recorder = &MockLeaseReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaseReader) EXPECT() *MockLeaseReaderMockRecorder {
+	return m.recorder
+}
+
+// GetLeaseByPeerID mocks base method.
+func (m *MockLeaseReader) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeaseByPeerID", ctx, peerID)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLeaseByPeerID indicates an expected call of GetLeaseByPeerID.
+func (mr *MockLeaseReaderMockRecorder) GetLeaseByPeerID(ctx, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeaseByPeerID", reflect.TypeOf((*MockLeaseReader)(nil).GetLeaseByPeerID), ctx, peerID)
+}
+
+// GetLeaseByTokenID mocks base method.
+func (m *MockLeaseReader) GetLeaseByTokenID(ctx context.Context, tokenID int64) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeaseByTokenID", ctx, tokenID)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLeaseByTokenID indicates an expected call of GetLeaseByTokenID.
+func (mr *MockLeaseReaderMockRecorder) GetLeaseByTokenID(ctx, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeaseByTokenID", reflect.TypeOf((*MockLeaseReader)(nil).GetLeaseByTokenID), ctx, tokenID)
+}
+
+// GetLeaseHistory mocks base method.
+func (m *MockLeaseReader) GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeaseHistory", ctx, tokenID)
+	ret0, _ := ret[0].([]*models.LeaseHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLeaseHistory indicates an expected call of GetLeaseHistory.
+func (mr *MockLeaseReaderMockRecorder) GetLeaseHistory(ctx, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeaseHistory", reflect.TypeOf((*MockLeaseReader)(nil).GetLeaseHistory), ctx, tokenID)
+}
+
+// MockLeaseWriter is a mock of LeaseWriter interface.
+type MockLeaseWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaseWriterMockRecorder
+}
+
+// MockLeaseWriterMockRecorder is the mock recorder for MockLeaseWriter.
+type MockLeaseWriterMockRecorder struct {
+	mock *MockLeaseWriter
+}
+
+// NewMockLeaseWriter creates a new mock instance.
+func NewMockLeaseWriter(ctrl *gomock.Controller) *MockLeaseWriter {
+	mock := &MockLeaseWriter{ctrl: ctrl}
+	mock.recorder = &MockLeaseWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaseWriter) EXPECT() *MockLeaseWriterMockRecorder {
+	return m.recorder
+}
+
+// AllocateAffinityIP mocks base method.
+func (m *MockLeaseWriter) AllocateAffinityIP(ctx context.Context, peerID, affinityGroup string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateAffinityIP", ctx, peerID, affinityGroup)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateAffinityIP indicates an expected call of AllocateAffinityIP.
+func (mr *MockLeaseWriterMockRecorder) AllocateAffinityIP(ctx, peerID, affinityGroup interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateAffinityIP", reflect.TypeOf((*MockLeaseWriter)(nil).AllocateAffinityIP), ctx, peerID, affinityGroup)
+}
+
+// AllocateIP mocks base method.
+func (m *MockLeaseWriter) AllocateIP(ctx context.Context, peerID string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateIP", ctx, peerID)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateIP indicates an expected call of AllocateIP.
+func (mr *MockLeaseWriterMockRecorder) AllocateIP(ctx, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateIP", reflect.TypeOf((*MockLeaseWriter)(nil).AllocateIP), ctx, peerID)
+}
+
+// AllocateRequestedIP mocks base method.
+func (m *MockLeaseWriter) AllocateRequestedIP(ctx context.Context, peerID string, requestedTokenID int64) (*models.AllocationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateRequestedIP", ctx, peerID, requestedTokenID)
+	ret0, _ := ret[0].(*models.AllocationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateRequestedIP indicates an expected call of AllocateRequestedIP.
+func (mr *MockLeaseWriterMockRecorder) AllocateRequestedIP(ctx, peerID, requestedTokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateRequestedIP", reflect.TypeOf((*MockLeaseWriter)(nil).AllocateRequestedIP), ctx, peerID, requestedTokenID)
+}
+
+// AllocateZoneIP mocks base method.
+func (m *MockLeaseWriter) AllocateZoneIP(ctx context.Context, peerID, zone string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateZoneIP", ctx, peerID, zone)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateZoneIP indicates an expected call of AllocateZoneIP.
+func (mr *MockLeaseWriterMockRecorder) AllocateZoneIP(ctx, peerID, zone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateZoneIP", reflect.TypeOf((*MockLeaseWriter)(nil).AllocateZoneIP), ctx, peerID, zone)
+}
+
+// ExecuteBatch mocks base method.
+func (m *MockLeaseWriter) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteBatch", ctx, operations)
+	ret0, _ := ret[0].([]*models.LeaseOperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteBatch indicates an expected call of ExecuteBatch.
+func (mr *MockLeaseWriterMockRecorder) ExecuteBatch(ctx, operations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteBatch", reflect.TypeOf((*MockLeaseWriter)(nil).ExecuteBatch), ctx, operations)
+}
+
+// ReleaseLease mocks base method.
+func (m *MockLeaseWriter) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLease", ctx, tokenID, peerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLease indicates an expected call of ReleaseLease.
+func (mr *MockLeaseWriterMockRecorder) ReleaseLease(ctx, tokenID, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLease", reflect.TypeOf((*MockLeaseWriter)(nil).ReleaseLease), ctx, tokenID, peerID)
+}
+
+// RenewLease mocks base method.
+func (m *MockLeaseWriter) RenewLease(ctx context.Context, tokenID int64, peerID string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewLease", ctx, tokenID, peerID)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewLease indicates an expected call of RenewLease.
+func (mr *MockLeaseWriterMockRecorder) RenewLease(ctx, tokenID, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewLease", reflect.TypeOf((*MockLeaseWriter)(nil).RenewLease), ctx, tokenID, peerID)
+}
+
+// ReportConflict mocks base method.
+func (m *MockLeaseWriter) ReportConflict(ctx context.Context, report *models.LeaseConflictReport) (*models.LeaseConflict, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportConflict", ctx, report)
+	ret0, _ := ret[0].(*models.LeaseConflict)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReportConflict indicates an expected call of ReportConflict.
+func (mr *MockLeaseWriterMockRecorder) ReportConflict(ctx, report interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportConflict", reflect.TypeOf((*MockLeaseWriter)(nil).ReportConflict), ctx, report)
+}
+
+// SetLeaseLabels mocks base method.
+func (m *MockLeaseWriter) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLeaseLabels", ctx, tokenID, peerID, labels)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetLeaseLabels indicates an expected call of SetLeaseLabels.
+func (mr *MockLeaseWriterMockRecorder) SetLeaseLabels(ctx, tokenID, peerID, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLeaseLabels", reflect.TypeOf((*MockLeaseWriter)(nil).SetLeaseLabels), ctx, tokenID, peerID, labels)
+}
+
+// TransferLease mocks base method.
+func (m *MockLeaseWriter) TransferLease(ctx context.Context, request *models.LeaseTransferRequest) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferLease", ctx, request)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferLease indicates an expected call of TransferLease.
+func (mr *MockLeaseWriterMockRecorder) TransferLease(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferLease", reflect.TypeOf((*MockLeaseWriter)(nil).TransferLease), ctx, request)
+}
+
 // MockLeaseService is a mock of LeaseService interface.
 type MockLeaseService struct {
 	ctrl     *gomock.Controller
@@ -35,6 +276,21 @@ func (m *MockLeaseService) EXPECT() *MockLeaseServiceMockRecorder {
 	return m.recorder
 }
 
+// AllocateAffinityIP mocks base method.
+func (m *MockLeaseService) AllocateAffinityIP(ctx context.Context, peerID, affinityGroup string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateAffinityIP", ctx, peerID, affinityGroup)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateAffinityIP indicates an expected call of AllocateAffinityIP.
+func (mr *MockLeaseServiceMockRecorder) AllocateAffinityIP(ctx, peerID, affinityGroup interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateAffinityIP", reflect.TypeOf((*MockLeaseService)(nil).AllocateAffinityIP), ctx, peerID, affinityGroup)
+}
+
 // AllocateIP mocks base method.
 func (m *MockLeaseService) AllocateIP(ctx context.Context, peerID string) (*models.Lease, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +306,51 @@ func (mr *MockLeaseServiceMockRecorder) AllocateIP(ctx, peerID interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateIP", reflect.TypeOf((*MockLeaseService)(nil).AllocateIP), ctx, peerID)
 }
 
+// AllocateRequestedIP mocks base method.
+func (m *MockLeaseService) AllocateRequestedIP(ctx context.Context, peerID string, requestedTokenID int64) (*models.AllocationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateRequestedIP", ctx, peerID, requestedTokenID)
+	ret0, _ := ret[0].(*models.AllocationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateRequestedIP indicates an expected call of AllocateRequestedIP.
+func (mr *MockLeaseServiceMockRecorder) AllocateRequestedIP(ctx, peerID, requestedTokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateRequestedIP", reflect.TypeOf((*MockLeaseService)(nil).AllocateRequestedIP), ctx, peerID, requestedTokenID)
+}
+
+// AllocateZoneIP mocks base method.
+func (m *MockLeaseService) AllocateZoneIP(ctx context.Context, peerID, zone string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateZoneIP", ctx, peerID, zone)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateZoneIP indicates an expected call of AllocateZoneIP.
+func (mr *MockLeaseServiceMockRecorder) AllocateZoneIP(ctx, peerID, zone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateZoneIP", reflect.TypeOf((*MockLeaseService)(nil).AllocateZoneIP), ctx, peerID, zone)
+}
+
+// ExecuteBatch mocks base method.
+func (m *MockLeaseService) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteBatch", ctx, operations)
+	ret0, _ := ret[0].([]*models.LeaseOperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteBatch indicates an expected call of ExecuteBatch.
+func (mr *MockLeaseServiceMockRecorder) ExecuteBatch(ctx, operations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteBatch", reflect.TypeOf((*MockLeaseService)(nil).ExecuteBatch), ctx, operations)
+}
+
 // GetLeaseByPeerID mocks base method.
 func (m *MockLeaseService) GetLeaseByPeerID(ctx context.Context, peerID string) (*models.Lease, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +381,21 @@ func (mr *MockLeaseServiceMockRecorder) GetLeaseByTokenID(ctx, tokenID interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeaseByTokenID", reflect.TypeOf((*MockLeaseService)(nil).GetLeaseByTokenID), ctx, tokenID)
 }
 
+// GetLeaseHistory mocks base method.
+func (m *MockLeaseService) GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeaseHistory", ctx, tokenID)
+	ret0, _ := ret[0].([]*models.LeaseHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLeaseHistory indicates an expected call of GetLeaseHistory.
+func (mr *MockLeaseServiceMockRecorder) GetLeaseHistory(ctx, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeaseHistory", reflect.TypeOf((*MockLeaseService)(nil).GetLeaseHistory), ctx, tokenID)
+}
+
 // ReleaseLease mocks base method.
 func (m *MockLeaseService) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
 	m.ctrl.T.Helper()
@@ -109,6 +425,51 @@ func (mr *MockLeaseServiceMockRecorder) RenewLease(ctx, tokenID, peerID interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewLease", reflect.TypeOf((*MockLeaseService)(nil).RenewLease), ctx, tokenID, peerID)
 }
 
+// ReportConflict mocks base method.
+func (m *MockLeaseService) ReportConflict(ctx context.Context, report *models.LeaseConflictReport) (*models.LeaseConflict, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportConflict", ctx, report)
+	ret0, _ := ret[0].(*models.LeaseConflict)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReportConflict indicates an expected call of ReportConflict.
+func (mr *MockLeaseServiceMockRecorder) ReportConflict(ctx, report interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportConflict", reflect.TypeOf((*MockLeaseService)(nil).ReportConflict), ctx, report)
+}
+
+// SetLeaseLabels mocks base method.
+func (m *MockLeaseService) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLeaseLabels", ctx, tokenID, peerID, labels)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetLeaseLabels indicates an expected call of SetLeaseLabels.
+func (mr *MockLeaseServiceMockRecorder) SetLeaseLabels(ctx, tokenID, peerID, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLeaseLabels", reflect.TypeOf((*MockLeaseService)(nil).SetLeaseLabels), ctx, tokenID, peerID, labels)
+}
+
+// TransferLease mocks base method.
+func (m *MockLeaseService) TransferLease(ctx context.Context, request *models.LeaseTransferRequest) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferLease", ctx, request)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferLease indicates an expected call of TransferLease.
+func (mr *MockLeaseServiceMockRecorder) TransferLease(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferLease", reflect.TypeOf((*MockLeaseService)(nil).TransferLease), ctx, request)
+}
+
 // MockLeaseRepository is a mock of LeaseRepository interface.
 type MockLeaseRepository struct {
 	ctrl     *gomock.Controller
@@ -132,6 +493,21 @@ func (m *MockLeaseRepository) EXPECT() *MockLeaseRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AllocateAffinityLease mocks base method.
+func (m *MockLeaseRepository) AllocateAffinityLease(ctx context.Context, peerID, affinityGroup string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateAffinityLease", ctx, peerID, affinityGroup)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateAffinityLease indicates an expected call of AllocateAffinityLease.
+func (mr *MockLeaseRepositoryMockRecorder) AllocateAffinityLease(ctx, peerID, affinityGroup interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateAffinityLease", reflect.TypeOf((*MockLeaseRepository)(nil).AllocateAffinityLease), ctx, peerID, affinityGroup)
+}
+
 // AllocateNewLease mocks base method.
 func (m *MockLeaseRepository) AllocateNewLease(ctx context.Context, peerID string) (*models.Lease, error) {
 	m.ctrl.T.Helper()
@@ -147,6 +523,66 @@ func (mr *MockLeaseRepositoryMockRecorder) AllocateNewLease(ctx, peerID interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateNewLease", reflect.TypeOf((*MockLeaseRepository)(nil).AllocateNewLease), ctx, peerID)
 }
 
+// AllocateRequestedLease mocks base method.
+func (m *MockLeaseRepository) AllocateRequestedLease(ctx context.Context, peerID string, tokenID int64) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateRequestedLease", ctx, peerID, tokenID)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateRequestedLease indicates an expected call of AllocateRequestedLease.
+func (mr *MockLeaseRepositoryMockRecorder) AllocateRequestedLease(ctx, peerID, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateRequestedLease", reflect.TypeOf((*MockLeaseRepository)(nil).AllocateRequestedLease), ctx, peerID, tokenID)
+}
+
+// AllocateZoneLease mocks base method.
+func (m *MockLeaseRepository) AllocateZoneLease(ctx context.Context, peerID, zone string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateZoneLease", ctx, peerID, zone)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateZoneLease indicates an expected call of AllocateZoneLease.
+func (mr *MockLeaseRepositoryMockRecorder) AllocateZoneLease(ctx, peerID, zone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateZoneLease", reflect.TypeOf((*MockLeaseRepository)(nil).AllocateZoneLease), ctx, peerID, zone)
+}
+
+// CountDelegatedLeases mocks base method.
+func (m *MockLeaseRepository) CountDelegatedLeases(ctx context.Context, gatewayPeerID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDelegatedLeases", ctx, gatewayPeerID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDelegatedLeases indicates an expected call of CountDelegatedLeases.
+func (mr *MockLeaseRepositoryMockRecorder) CountDelegatedLeases(ctx, gatewayPeerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDelegatedLeases", reflect.TypeOf((*MockLeaseRepository)(nil).CountDelegatedLeases), ctx, gatewayPeerID)
+}
+
+// ExecuteBatch mocks base method.
+func (m *MockLeaseRepository) ExecuteBatch(ctx context.Context, operations []*models.LeaseOperation) ([]*models.LeaseOperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteBatch", ctx, operations)
+	ret0, _ := ret[0].([]*models.LeaseOperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteBatch indicates an expected call of ExecuteBatch.
+func (mr *MockLeaseRepositoryMockRecorder) ExecuteBatch(ctx, operations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteBatch", reflect.TypeOf((*MockLeaseRepository)(nil).ExecuteBatch), ctx, operations)
+}
+
 // FindAndReuseExpiredLease mocks base method.
 func (m *MockLeaseRepository) FindAndReuseExpiredLease(ctx context.Context, peerID string) (*models.Lease, error) {
 	m.ctrl.T.Helper()
@@ -192,6 +628,81 @@ func (mr *MockLeaseRepositoryMockRecorder) GetLeaseByTokenID(ctx, tokenID interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeaseByTokenID", reflect.TypeOf((*MockLeaseRepository)(nil).GetLeaseByTokenID), ctx, tokenID)
 }
 
+// GetLeaseHistory mocks base method.
+func (m *MockLeaseRepository) GetLeaseHistory(ctx context.Context, tokenID int64) ([]*models.LeaseHistoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeaseHistory", ctx, tokenID)
+	ret0, _ := ret[0].([]*models.LeaseHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLeaseHistory indicates an expected call of GetLeaseHistory.
+func (mr *MockLeaseRepositoryMockRecorder) GetLeaseHistory(ctx, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeaseHistory", reflect.TypeOf((*MockLeaseRepository)(nil).GetLeaseHistory), ctx, tokenID)
+}
+
+// ListExpiringLeases mocks base method.
+func (m *MockLeaseRepository) ListExpiringLeases(ctx context.Context, within time.Duration, afterTokenID int64, limit int) ([]*models.ExpiringLease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiringLeases", ctx, within, afterTokenID, limit)
+	ret0, _ := ret[0].([]*models.ExpiringLease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiringLeases indicates an expected call of ListExpiringLeases.
+func (mr *MockLeaseRepositoryMockRecorder) ListExpiringLeases(ctx, within, afterTokenID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiringLeases", reflect.TypeOf((*MockLeaseRepository)(nil).ListExpiringLeases), ctx, within, afterTokenID, limit)
+}
+
+// ListReclaimCandidates mocks base method.
+func (m *MockLeaseRepository) ListReclaimCandidates(ctx context.Context, afterTokenID int64, limit int) ([]*models.ReclaimCandidate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReclaimCandidates", ctx, afterTokenID, limit)
+	ret0, _ := ret[0].([]*models.ReclaimCandidate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReclaimCandidates indicates an expected call of ListReclaimCandidates.
+func (mr *MockLeaseRepositoryMockRecorder) ListReclaimCandidates(ctx, afterTokenID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReclaimCandidates", reflect.TypeOf((*MockLeaseRepository)(nil).ListReclaimCandidates), ctx, afterTokenID, limit)
+}
+
+// ReclaimLeases mocks base method.
+func (m *MockLeaseRepository) ReclaimLeases(ctx context.Context, tokenIDs []int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReclaimLeases", ctx, tokenIDs)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReclaimLeases indicates an expected call of ReclaimLeases.
+func (mr *MockLeaseRepositoryMockRecorder) ReclaimLeases(ctx, tokenIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReclaimLeases", reflect.TypeOf((*MockLeaseRepository)(nil).ReclaimLeases), ctx, tokenIDs)
+}
+
+// RecordConflict mocks base method.
+func (m *MockLeaseRepository) RecordConflict(ctx context.Context, tokenID int64, peerID string, quarantine time.Duration) (*models.LeaseConflict, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordConflict", ctx, tokenID, peerID, quarantine)
+	ret0, _ := ret[0].(*models.LeaseConflict)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordConflict indicates an expected call of RecordConflict.
+func (mr *MockLeaseRepositoryMockRecorder) RecordConflict(ctx, tokenID, peerID, quarantine interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordConflict", reflect.TypeOf((*MockLeaseRepository)(nil).RecordConflict), ctx, tokenID, peerID, quarantine)
+}
+
 // ReleaseLease mocks base method.
 func (m *MockLeaseRepository) ReleaseLease(ctx context.Context, tokenID int64, peerID string) error {
 	m.ctrl.T.Helper()
@@ -221,6 +732,116 @@ func (mr *MockLeaseRepositoryMockRecorder) RenewLease(ctx, tokenID, peerID inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewLease", reflect.TypeOf((*MockLeaseRepository)(nil).RenewLease), ctx, tokenID, peerID)
 }
 
+// RevokeLeases mocks base method.
+func (m *MockLeaseRepository) RevokeLeases(ctx context.Context, leases []*models.Lease) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeLeases", ctx, leases)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeLeases indicates an expected call of RevokeLeases.
+func (mr *MockLeaseRepositoryMockRecorder) RevokeLeases(ctx, leases interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeLeases", reflect.TypeOf((*MockLeaseRepository)(nil).RevokeLeases), ctx, leases)
+}
+
+// SetLeaseAffinityGroup mocks base method.
+func (m *MockLeaseRepository) SetLeaseAffinityGroup(ctx context.Context, tokenID int64, affinityGroup string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLeaseAffinityGroup", ctx, tokenID, affinityGroup)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLeaseAffinityGroup indicates an expected call of SetLeaseAffinityGroup.
+func (mr *MockLeaseRepositoryMockRecorder) SetLeaseAffinityGroup(ctx, tokenID, affinityGroup interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLeaseAffinityGroup", reflect.TypeOf((*MockLeaseRepository)(nil).SetLeaseAffinityGroup), ctx, tokenID, affinityGroup)
+}
+
+// SetLeaseDelegator mocks base method.
+func (m *MockLeaseRepository) SetLeaseDelegator(ctx context.Context, tokenID int64, gatewayPeerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLeaseDelegator", ctx, tokenID, gatewayPeerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLeaseDelegator indicates an expected call of SetLeaseDelegator.
+func (mr *MockLeaseRepositoryMockRecorder) SetLeaseDelegator(ctx, tokenID, gatewayPeerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLeaseDelegator", reflect.TypeOf((*MockLeaseRepository)(nil).SetLeaseDelegator), ctx, tokenID, gatewayPeerID)
+}
+
+// SetLeaseLabels mocks base method.
+func (m *MockLeaseRepository) SetLeaseLabels(ctx context.Context, tokenID int64, peerID string, labels map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLeaseLabels", ctx, tokenID, peerID, labels)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLeaseLabels indicates an expected call of SetLeaseLabels.
+func (mr *MockLeaseRepositoryMockRecorder) SetLeaseLabels(ctx, tokenID, peerID, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLeaseLabels", reflect.TypeOf((*MockLeaseRepository)(nil).SetLeaseLabels), ctx, tokenID, peerID, labels)
+}
+
+// TransferLease mocks base method.
+func (m *MockLeaseRepository) TransferLease(ctx context.Context, tokenID int64, fromPeerID, toPeerID string) (*models.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferLease", ctx, tokenID, fromPeerID, toPeerID)
+	ret0, _ := ret[0].(*models.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferLease indicates an expected call of TransferLease.
+func (mr *MockLeaseRepositoryMockRecorder) TransferLease(ctx, tokenID, fromPeerID, toPeerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferLease", reflect.TypeOf((*MockLeaseRepository)(nil).TransferLease), ctx, tokenID, fromPeerID, toPeerID)
+}
+
+// MockLeaseRenewalWriter is a mock of LeaseRenewalWriter interface.
+type MockLeaseRenewalWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaseRenewalWriterMockRecorder
+}
+
+// MockLeaseRenewalWriterMockRecorder is the mock recorder for MockLeaseRenewalWriter.
+type MockLeaseRenewalWriterMockRecorder struct {
+	mock *MockLeaseRenewalWriter
+}
+
+// NewMockLeaseRenewalWriter creates a new mock instance.
+func NewMockLeaseRenewalWriter(ctrl *gomock.Controller) *MockLeaseRenewalWriter {
+	mock := &MockLeaseRenewalWriter{ctrl: ctrl}
+	mock.recorder = &MockLeaseRenewalWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaseRenewalWriter) EXPECT() *MockLeaseRenewalWriterMockRecorder {
+	return m.recorder
+}
+
+// ApplyRenewals mocks base method.
+func (m *MockLeaseRenewalWriter) ApplyRenewals(ctx context.Context, renewals []*models.LeaseRenewal) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyRenewals", ctx, renewals)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyRenewals indicates an expected call of ApplyRenewals.
+func (mr *MockLeaseRenewalWriterMockRecorder) ApplyRenewals(ctx, renewals interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyRenewals", reflect.TypeOf((*MockLeaseRenewalWriter)(nil).ApplyRenewals), ctx, renewals)
+}
+
 // MockLeaseCache is a mock of LeaseCache interface.
 type MockLeaseCache struct {
 	ctrl     *gomock.Controller
@@ -301,3 +922,45 @@ func (mr *MockLeaseCacheMockRecorder) SetLease(ctx, lease interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLease", reflect.TypeOf((*MockLeaseCache)(nil).SetLease), ctx, lease)
 }
+
+// SetPeerNotFound mocks base method.
+func (m *MockLeaseCache) SetPeerNotFound(ctx context.Context, peerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPeerNotFound", ctx, peerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPeerNotFound indicates an expected call of SetPeerNotFound.
+func (mr *MockLeaseCacheMockRecorder) SetPeerNotFound(ctx, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPeerNotFound", reflect.TypeOf((*MockLeaseCache)(nil).SetPeerNotFound), ctx, peerID)
+}
+
+// SetTokenNotFound mocks base method.
+func (m *MockLeaseCache) SetTokenNotFound(ctx context.Context, tokenID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTokenNotFound", ctx, tokenID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTokenNotFound indicates an expected call of SetTokenNotFound.
+func (mr *MockLeaseCacheMockRecorder) SetTokenNotFound(ctx, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTokenNotFound", reflect.TypeOf((*MockLeaseCache)(nil).SetTokenNotFound), ctx, tokenID)
+}
+
+// UpdateLeases mocks base method.
+func (m *MockLeaseCache) UpdateLeases(ctx context.Context, upserts, removals []*models.Lease) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLeases", ctx, upserts, removals)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLeases indicates an expected call of UpdateLeases.
+func (mr *MockLeaseCacheMockRecorder) UpdateLeases(ctx, upserts, removals interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLeases", reflect.TypeOf((*MockLeaseCache)(nil).UpdateLeases), ctx, upserts, removals)
+}
@@ -65,17 +65,18 @@ func (mr *MockNonceRepositoryMockRecorder) CreateNonce(ctx, peerID interface{})
 }
 
 // DeleteExpiredNonces mocks base method.
-func (m *MockNonceRepository) DeleteExpiredNonces(ctx context.Context) error {
+func (m *MockNonceRepository) DeleteExpiredNonces(ctx context.Context, limit int) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteExpiredNonces", ctx)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteExpiredNonces", ctx, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteExpiredNonces indicates an expected call of DeleteExpiredNonces.
-func (mr *MockNonceRepositoryMockRecorder) DeleteExpiredNonces(ctx interface{}) *gomock.Call {
+func (mr *MockNonceRepositoryMockRecorder) DeleteExpiredNonces(ctx, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredNonces", reflect.TypeOf((*MockNonceRepository)(nil).DeleteExpiredNonces), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredNonces", reflect.TypeOf((*MockNonceRepository)(nil).DeleteExpiredNonces), ctx, limit)
 }
 
 // GetNonce mocks base method.
@@ -93,6 +94,35 @@ func (mr *MockNonceRepositoryMockRecorder) GetNonce(ctx, nonceID interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonce", reflect.TypeOf((*MockNonceRepository)(nil).GetNonce), ctx, nonceID)
 }
 
+// ListOutstandingNonces mocks base method.
+func (m *MockNonceRepository) ListOutstandingNonces(ctx context.Context, peerID string) ([]*models.Nonce, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOutstandingNonces", ctx, peerID)
+	ret0, _ := ret[0].([]*models.Nonce)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOutstandingNonces indicates an expected call of ListOutstandingNonces.
+func (mr *MockNonceRepositoryMockRecorder) ListOutstandingNonces(ctx, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOutstandingNonces", reflect.TypeOf((*MockNonceRepository)(nil).ListOutstandingNonces), ctx, peerID)
+}
+
+// RevokeNonce mocks base method.
+func (m *MockNonceRepository) RevokeNonce(ctx context.Context, nonceID, peerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeNonce", ctx, nonceID, peerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeNonce indicates an expected call of RevokeNonce.
+func (mr *MockNonceRepositoryMockRecorder) RevokeNonce(ctx, nonceID, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeNonce", reflect.TypeOf((*MockNonceRepository)(nil).RevokeNonce), ctx, nonceID, peerID)
+}
+
 // MockNonceCache is a mock of NonceCache interface.
 type MockNonceCache struct {
 	ctrl     *gomock.Controller
@@ -159,6 +189,21 @@ func (mr *MockNonceCacheMockRecorder) GetNonce(ctx, nonceID interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonce", reflect.TypeOf((*MockNonceCache)(nil).GetNonce), ctx, nonceID)
 }
 
+// TakeNonce mocks base method.
+func (m *MockNonceCache) TakeNonce(ctx context.Context, nonceID string) (*models.Nonce, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeNonce", ctx, nonceID)
+	ret0, _ := ret[0].(*models.Nonce)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeNonce indicates an expected call of TakeNonce.
+func (mr *MockNonceCacheMockRecorder) TakeNonce(ctx, nonceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeNonce", reflect.TypeOf((*MockNonceCache)(nil).TakeNonce), ctx, nonceID)
+}
+
 // MockNonceService is a mock of NonceService interface.
 type MockNonceService struct {
 	ctrl     *gomock.Controller
@@ -182,6 +227,21 @@ func (m *MockNonceService) EXPECT() *MockNonceServiceMockRecorder {
 	return m.recorder
 }
 
+// CleanupExpired mocks base method.
+func (m *MockNonceService) CleanupExpired(ctx context.Context) (*models.NonceCleanupReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CleanupExpired", ctx)
+	ret0, _ := ret[0].(*models.NonceCleanupReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CleanupExpired indicates an expected call of CleanupExpired.
+func (mr *MockNonceServiceMockRecorder) CleanupExpired(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanupExpired", reflect.TypeOf((*MockNonceService)(nil).CleanupExpired), ctx)
+}
+
 // CreateNonce mocks base method.
 func (m *MockNonceService) CreateNonce(ctx context.Context, peerID string) (*models.Nonce, error) {
 	m.ctrl.T.Helper()
@@ -197,6 +257,64 @@ func (mr *MockNonceServiceMockRecorder) CreateNonce(ctx, peerID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNonce", reflect.TypeOf((*MockNonceService)(nil).CreateNonce), ctx, peerID)
 }
 
+// ListOutstandingNonces mocks base method.
+func (m *MockNonceService) ListOutstandingNonces(ctx context.Context, peerID string) ([]*models.Nonce, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOutstandingNonces", ctx, peerID)
+	ret0, _ := ret[0].([]*models.Nonce)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOutstandingNonces indicates an expected call of ListOutstandingNonces.
+func (mr *MockNonceServiceMockRecorder) ListOutstandingNonces(ctx, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOutstandingNonces", reflect.TypeOf((*MockNonceService)(nil).ListOutstandingNonces), ctx, peerID)
+}
+
+// Metrics mocks base method.
+func (m *MockNonceService) Metrics() *models.NonceMetrics {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Metrics")
+	ret0, _ := ret[0].(*models.NonceMetrics)
+	return ret0
+}
+
+// Metrics indicates an expected call of Metrics.
+func (mr *MockNonceServiceMockRecorder) Metrics() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Metrics", reflect.TypeOf((*MockNonceService)(nil).Metrics))
+}
+
+// RevokeNonce mocks base method.
+func (m *MockNonceService) RevokeNonce(ctx context.Context, peerID, nonceID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeNonce", ctx, peerID, nonceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeNonce indicates an expected call of RevokeNonce.
+func (mr *MockNonceServiceMockRecorder) RevokeNonce(ctx, peerID, nonceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeNonce", reflect.TypeOf((*MockNonceService)(nil).RevokeNonce), ctx, peerID, nonceID)
+}
+
+// RevokeNonces mocks base method.
+func (m *MockNonceService) RevokeNonces(ctx context.Context, peerID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeNonces", ctx, peerID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeNonces indicates an expected call of RevokeNonces.
+func (mr *MockNonceServiceMockRecorder) RevokeNonces(ctx, peerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeNonces", reflect.TypeOf((*MockNonceService)(nil).RevokeNonces), ctx, peerID)
+}
+
 // VerifyNonce mocks base method.
 func (m *MockNonceService) VerifyNonce(ctx context.Context, request *models.NonceRequest) error {
 	m.ctrl.T.Helper()
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	models "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 )
 
 // MockSignatureVerifier is a mock of SignatureVerifier interface.
@@ -47,3 +48,40 @@ func (mr *MockSignatureVerifierMockRecorder) VerifySignature(ctx, pubKey, payloa
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySignature", reflect.TypeOf((*MockSignatureVerifier)(nil).VerifySignature), ctx, pubKey, payload, signature)
 }
+
+// MockSignatureCacheReporter is a mock of SignatureCacheReporter interface.
+type MockSignatureCacheReporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignatureCacheReporterMockRecorder
+}
+
+// MockSignatureCacheReporterMockRecorder is the mock recorder for MockSignatureCacheReporter.
+type MockSignatureCacheReporterMockRecorder struct {
+	mock *MockSignatureCacheReporter
+}
+
+// NewMockSignatureCacheReporter creates a new mock instance.
+func NewMockSignatureCacheReporter(ctrl *gomock.Controller) *MockSignatureCacheReporter {
+	mock := &MockSignatureCacheReporter{ctrl: ctrl}
+	mock.recorder = &MockSignatureCacheReporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSignatureCacheReporter) EXPECT() *MockSignatureCacheReporterMockRecorder {
+	return m.recorder
+}
+
+// SignatureCacheStats mocks base method.
+func (m *MockSignatureCacheReporter) SignatureCacheStats() *models.SignatureCacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignatureCacheStats")
+	ret0, _ := ret[0].(*models.SignatureCacheStats)
+	return ret0
+}
+
+// SignatureCacheStats indicates an expected call of SignatureCacheStats.
+func (mr *MockSignatureCacheReporterMockRecorder) SignatureCacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignatureCacheStats", reflect.TypeOf((*MockSignatureCacheReporter)(nil).SignatureCacheStats))
+}
@@ -0,0 +1,121 @@
+package libp2p
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+)
+
+func newSignerConfig(t *testing.T) *config.AppConfig {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Security.ServerKeyPath = filepath.Join(t.TempDir(), "server.key")
+	return cfg
+}
+
+// verify checks signature against the key with keyID among signer's keys
+func verify(t *testing.T, signer *libp2p.LeaseSigner, lease *models.Lease, signature []byte, keyID string) bool {
+	t.Helper()
+	for _, key := range signer.Keys() {
+		if key.KeyID != keyID {
+			continue
+		}
+		pubkey, err := crypto.UnmarshalPublicKey(key.PublicKey)
+		require.NoError(t, err)
+		ok, err := pubkey.Verify(dhcp2pcrypto.LeaseCertificatePayload(lease.Tenant, lease.TokenID, lease.PeerID, lease.ExpiresAt), signature)
+		require.NoError(t, err)
+		return ok
+	}
+	return false
+}
+
+func TestLeaseSigner_RotateKey(t *testing.T) {
+	cfg := newSignerConfig(t)
+	signer, err := libp2p.NewLeaseSigner(cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	lease := &models.Lease{TokenID: 167772161, PeerID: "12D3KooWExamplePeerID", ExpiresAt: time.Now().Add(time.Hour)}
+	signature, oldKeyID, err := signer.SignLease(lease)
+	require.NoError(t, err)
+	require.Len(t, signer.Keys(), 1)
+	assert.True(t, signer.Keys()[0].Current)
+	assert.Equal(t, oldKeyID, signer.Keys()[0].KeyID)
+
+	rotation, err := signer.RotateKey()
+	require.NoError(t, err)
+	assert.Equal(t, oldKeyID, rotation.Retired.KeyID)
+	assert.True(t, rotation.Current.Current)
+	assert.Equal(t, cfg.Security.ServerKeyPath+"."+oldKeyID, rotation.RetiredKeyPath)
+
+	// New leases are signed with the new key, the retired key still verifies earlier ones
+	newSignature, newKeyID, err := signer.SignLease(lease)
+	require.NoError(t, err)
+	assert.Equal(t, rotation.Current.KeyID, newKeyID)
+	assert.NotEqual(t, oldKeyID, newKeyID)
+	keys := signer.Keys()
+	require.Len(t, keys, 2)
+	assert.Equal(t, newKeyID, keys[0].KeyID)
+	assert.True(t, keys[0].Current)
+	assert.False(t, keys[1].Current)
+	assert.True(t, verify(t, signer, lease, signature, oldKeyID))
+	assert.True(t, verify(t, signer, lease, newSignature, newKeyID))
+
+	// The new key is saved, a restart keeps it and publishes the retired key once listed
+	cfg.Security.ServerVerificationKeyPaths = []string{rotation.RetiredKeyPath}
+	restarted, err := libp2p.NewLeaseSigner(cfg, zap.NewNop())
+	require.NoError(t, err)
+	keys = restarted.Keys()
+	require.Len(t, keys, 2)
+	assert.Equal(t, newKeyID, keys[0].KeyID)
+	assert.Equal(t, oldKeyID, keys[1].KeyID)
+}
+
+func TestLeaseSigner_ApplyConfig(t *testing.T) {
+	cfg := newSignerConfig(t)
+	signer, err := libp2p.NewLeaseSigner(cfg, zap.NewNop())
+	require.NoError(t, err)
+	oldKeyID := signer.Keys()[0].KeyID
+
+	// A reload switching to another key file retires the current key
+	reloaded := *cfg
+	reloaded.Security.ServerKeyPath = filepath.Join(t.TempDir(), "next.key")
+	signer.ApplyConfig(&reloaded)
+
+	keys := signer.Keys()
+	require.Len(t, keys, 2)
+	assert.NotEqual(t, oldKeyID, keys[0].KeyID)
+	assert.Equal(t, oldKeyID, keys[1].KeyID)
+	_, err = os.Stat(reloaded.Security.ServerKeyPath)
+	assert.NoError(t, err, "the new key file is generated")
+
+	// Unreadable key files leave the keys unchanged
+	broken := reloaded
+	broken.Security.ServerVerificationKeyPaths = []string{filepath.Join(t.TempDir(), "missing.key")}
+	signer.ApplyConfig(&broken)
+	assert.Equal(t, keys, signer.Keys())
+}
+
+func TestLeaseSigner_Disabled(t *testing.T) {
+	signer, err := libp2p.NewLeaseSigner(config.NewDefaultAppConfig(), zap.NewNop())
+	require.NoError(t, err)
+
+	signature, keyID, err := signer.SignLease(&models.Lease{TokenID: 167772161, PeerID: "12D3KooWExamplePeerID"})
+	require.NoError(t, err)
+	assert.Nil(t, signature)
+	assert.Empty(t, keyID)
+	assert.Nil(t, signer.Keys())
+
+	_, err = signer.RotateKey()
+	assert.ErrorIs(t, err, domainErrors.ErrSigningDisabled)
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func newCachingVerifier(t *testing.T, size, ttl int) (*auth.CachingSignatureVerifier, *mocks.MockSignatureVerifier) {
+	ctrl := gomock.NewController(t)
+	verifier := mocks.NewMockSignatureVerifier(ctrl)
+	cfg := &config.AppConfig{Security: config.SecurityConfig{SignatureCacheSize: size, SignatureCacheTTL: ttl}}
+	return auth.NewCachingSignatureVerifier(cfg, verifier), verifier
+}
+
+func TestCachingSignatureVerifier_VerifySignature(t *testing.T) {
+	ctx := context.Background()
+	pubkey, payload, signature := []byte("pubkey"), []byte("nonce-1"), []byte("signature")
+
+	t.Run("repeated signatures are verified once", func(t *testing.T) {
+		cache, verifier := newCachingVerifier(t, 10, 60)
+		verifier.EXPECT().VerifySignature(ctx, pubkey, payload, signature).Return(nil).Times(1)
+
+		assert.NoError(t, cache.VerifySignature(ctx, pubkey, payload, signature))
+		assert.NoError(t, cache.VerifySignature(ctx, pubkey, payload, signature))
+
+		stats := cache.SignatureCacheStats()
+		assert.True(t, stats.Enabled)
+		assert.Equal(t, int64(1), stats.Hits)
+		assert.Equal(t, int64(1), stats.Misses)
+		assert.Equal(t, 0.5, stats.HitRate)
+		assert.Equal(t, 1, stats.Entries)
+	})
+
+	t.Run("a different payload is verified again", func(t *testing.T) {
+		cache, verifier := newCachingVerifier(t, 10, 60)
+		verifier.EXPECT().VerifySignature(ctx, pubkey, payload, signature).Return(nil)
+		verifier.EXPECT().VerifySignature(ctx, pubkey, []byte("nonce-2"), signature).Return(domainErrors.ErrInvalidSignature)
+
+		assert.NoError(t, cache.VerifySignature(ctx, pubkey, payload, signature))
+		assert.ErrorIs(t, cache.VerifySignature(ctx, pubkey, []byte("nonce-2"), signature), domainErrors.ErrInvalidSignature)
+	})
+
+	t.Run("failed verifications are not cached", func(t *testing.T) {
+		cache, verifier := newCachingVerifier(t, 10, 60)
+		verifier.EXPECT().VerifySignature(ctx, pubkey, payload, signature).Return(domainErrors.ErrInvalidSignature).Times(2)
+
+		assert.ErrorIs(t, cache.VerifySignature(ctx, pubkey, payload, signature), domainErrors.ErrInvalidSignature)
+		assert.ErrorIs(t, cache.VerifySignature(ctx, pubkey, payload, signature), domainErrors.ErrInvalidSignature)
+		assert.Equal(t, 0, cache.SignatureCacheStats().Entries)
+	})
+
+	t.Run("evicts the least recently used entry", func(t *testing.T) {
+		cache, verifier := newCachingVerifier(t, 2, 60)
+		verifier.EXPECT().VerifySignature(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(4)
+
+		for _, nonce := range []string{"a", "b", "a", "c", "b"} {
+			assert.NoError(t, cache.VerifySignature(ctx, pubkey, []byte(nonce), signature))
+		}
+
+		stats := cache.SignatureCacheStats()
+		assert.Equal(t, 2, stats.Entries)
+		assert.Equal(t, int64(2), stats.Evictions)
+		assert.Equal(t, int64(1), stats.Hits)
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		cache, verifier := newCachingVerifier(t, 10, 1)
+		verifier.EXPECT().VerifySignature(ctx, pubkey, payload, signature).Return(nil).Times(2)
+
+		assert.NoError(t, cache.VerifySignature(ctx, pubkey, payload, signature))
+		time.Sleep(1100 * time.Millisecond)
+		assert.NoError(t, cache.VerifySignature(ctx, pubkey, payload, signature))
+		assert.Equal(t, int64(0), cache.SignatureCacheStats().Hits)
+	})
+
+	t.Run("disabled without a size", func(t *testing.T) {
+		cache, verifier := newCachingVerifier(t, 0, 60)
+		verifier.EXPECT().VerifySignature(ctx, pubkey, payload, signature).Return(nil).Times(2)
+
+		assert.NoError(t, cache.VerifySignature(ctx, pubkey, payload, signature))
+		assert.NoError(t, cache.VerifySignature(ctx, pubkey, payload, signature))
+
+		stats := cache.SignatureCacheStats()
+		assert.False(t, stats.Enabled)
+		assert.Equal(t, int64(0), stats.Misses)
+	})
+}
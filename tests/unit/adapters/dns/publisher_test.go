@@ -0,0 +1,122 @@
+package dns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/dns"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func newDNSConfig() *config.AppConfig {
+	cfg := config.NewDefaultAppConfig()
+	cfg.DNSEnabled = true
+	cfg.DNSZone = "Subnet.Example"
+	return cfg
+}
+
+func TestPublisher_Hostname(t *testing.T) {
+	publisher, err := dns.NewPublisher(fxtest.NewLifecycle(t), newDNSConfig(), nil, mocks.NewMockDNSProvider(gomock.NewController(t)), zap.NewNop())
+	require.NoError(t, err)
+
+	assert.Equal(t, "12d3koowpeer.subnet.example.", publisher.Hostname("12D3KooWPeer", models.DefaultTenantID))
+	assert.Equal(t, "12d3koowpeer.acme.subnet.example.", publisher.Hostname("12D3KooWPeer", "acme"))
+	assert.Empty(t, publisher.Hostname("peer.with.dots", models.DefaultTenantID))
+	assert.Empty(t, publisher.Hostname("", models.DefaultTenantID))
+}
+
+func TestPublisher_Reconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockRepo.EXPECT().ListExpiringLeases(gomock.Any(), gomock.Any(), int64(0), gomock.Any()).Return([]*models.ExpiringLease{
+		{TokenID: 167772161, PeerID: "peer1", TenantID: models.DefaultTenantID},
+		{TokenID: 167772162, PeerID: "peer2", TenantID: models.DefaultTenantID},
+		{TokenID: 167772163, PeerID: "peer3", TenantID: "acme"},
+	}, nil)
+
+	mockProvider := mocks.NewMockDNSProvider(ctrl)
+	mockProvider.EXPECT().ListRecords(gomock.Any()).Return([]*models.DNSRecord{
+		{Hostname: "peer1.subnet.example.", IP: "10.0.0.1"}, // up to date
+		{Hostname: "peer2.subnet.example.", IP: "10.0.0.9"}, // stale address
+		{Hostname: "gone.subnet.example.", IP: "10.0.0.4"},  // lease released while down
+	}, nil)
+	mockProvider.EXPECT().UpsertRecord(gomock.Any(), "peer2.subnet.example.", "10.0.0.2").Return(nil)
+	mockProvider.EXPECT().UpsertRecord(gomock.Any(), "peer3.acme.subnet.example.", "10.0.0.3").Return(nil)
+	mockProvider.EXPECT().DeleteRecord(gomock.Any(), "gone.subnet.example.").Return(nil)
+
+	publisher, err := dns.NewPublisher(fxtest.NewLifecycle(t), newDNSConfig(), mockRepo, mockProvider, zap.NewNop())
+	require.NoError(t, err)
+
+	result, err := publisher.Reconcile(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &models.DNSReconcileResult{Upserted: 2, Deleted: 1, Unchanged: 1}, result)
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockRepo.EXPECT().ListExpiringLeases(gomock.Any(), gomock.Any(), int64(0), gomock.Any()).Return(nil, nil)
+
+	mockProvider := mocks.NewMockDNSProvider(ctrl)
+	mockProvider.EXPECT().ListRecords(gomock.Any()).Return(nil, nil)
+	gomock.InOrder(
+		mockProvider.EXPECT().UpsertRecord(gomock.Any(), "peer1.subnet.example.", "10.0.0.1").Return(nil),
+		mockProvider.EXPECT().DeleteRecord(gomock.Any(), "peer1.subnet.example.").Return(nil),
+		mockProvider.EXPECT().DeleteRecord(gomock.Any(), "peer2.acme.subnet.example.").Return(nil),
+	)
+
+	lc := fxtest.NewLifecycle(t)
+	publisher, err := dns.NewPublisher(lc, newDNSConfig(), mockRepo, mockProvider, zap.NewNop())
+	require.NoError(t, err)
+	lc.RequireStart()
+
+	event := func(eventType models.LeaseLifecycleEventType, tokenID int64, peerID, tenantID string) *models.LeaseLifecycleEvent {
+		return &models.LeaseLifecycleEvent{Type: eventType, TokenID: tokenID, PeerID: peerID, TenantID: tenantID, OccurredAt: time.Now()}
+	}
+	publisher.Publish(event(models.LeaseLifecycleAllocated, 167772161, "peer1", models.DefaultTenantID))
+	publisher.Publish(event(models.LeaseLifecycleRenewed, 167772161, "peer1", models.DefaultTenantID))
+	publisher.Publish(event(models.LeaseLifecycleReleased, 167772161, "peer1", models.DefaultTenantID))
+	publisher.Publish(event(models.LeaseLifecycleExpired, 167772162, "peer2", "acme"))
+
+	// Stopping applies the queued events
+	lc.RequireStop()
+}
+
+func TestNewPublisher(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		publisher, err := dns.NewPublisher(fxtest.NewLifecycle(t), config.NewDefaultAppConfig(), nil, nil, zap.NewNop())
+		require.NoError(t, err)
+		publisher.Publish(&models.LeaseLifecycleEvent{Type: models.LeaseLifecycleAllocated, PeerID: "peer1"})
+	})
+
+	t.Run("missing zone", func(t *testing.T) {
+		cfg := newDNSConfig()
+		cfg.DNSZone = ""
+		_, err := dns.NewPublisher(fxtest.NewLifecycle(t), cfg, nil, nil, zap.NewNop())
+		assert.Error(t, err)
+	})
+
+	t.Run("rfc2136 without server", func(t *testing.T) {
+		_, err := dns.NewPublisher(fxtest.NewLifecycle(t), newDNSConfig(), nil, nil, zap.NewNop())
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		cfg := newDNSConfig()
+		cfg.DNSProvider = "route53"
+		_, err := dns.NewPublisher(fxtest.NewLifecycle(t), cfg, nil, nil, zap.NewNop())
+		assert.Error(t, err)
+	})
+}
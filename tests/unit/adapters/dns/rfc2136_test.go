@@ -0,0 +1,121 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/dns"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+const (
+	testZone      = "subnet.example."
+	testKeyName   = "dhcp2p."
+	testKeySecret = "c2VjcmV0LWtleS1mb3ItdGVzdHM="
+)
+
+// testZoneServer is a primary server for testZone that applies signed dynamic updates
+// and serves zone transfers
+type testZoneServer struct {
+	mu      sync.Mutex
+	records map[string]string // hostname -> IPv4
+}
+
+func (s *testZoneServer) ServeDNS(w mdns.ResponseWriter, r *mdns.Msg) {
+	m := new(mdns.Msg)
+	m.SetReply(r)
+	if r.IsTsig() == nil || w.TsigStatus() != nil {
+		m.Rcode = mdns.RcodeRefused
+		w.WriteMsg(m)
+		return
+	}
+
+	if r.Opcode == mdns.OpcodeUpdate {
+		s.mu.Lock()
+		for _, rr := range r.Ns {
+			switch {
+			case rr.Header().Class == mdns.ClassANY:
+				delete(s.records, rr.Header().Name)
+			case rr.Header().Rrtype == mdns.TypeA:
+				s.records[rr.Header().Name] = rr.(*mdns.A).A.String()
+			}
+		}
+		s.mu.Unlock()
+		m.SetTsig(testKeyName, mdns.HmacSHA256, 300, time.Now().Unix())
+		w.WriteMsg(m)
+		return
+	}
+
+	soa, _ := mdns.NewRR(testZone + " 300 IN SOA ns1." + testZone + " admin." + testZone + " 1 60 60 60 60")
+	apex, _ := mdns.NewRR(testZone + " 300 IN A 192.0.2.1")
+	rrs := []mdns.RR{soa, apex}
+	s.mu.Lock()
+	for hostname, ip := range s.records {
+		rr, _ := mdns.NewRR(hostname + " 300 IN A " + ip)
+		rrs = append(rrs, rr)
+	}
+	s.mu.Unlock()
+	rrs = append(rrs, soa)
+
+	ch := make(chan *mdns.Envelope, 1)
+	ch <- &mdns.Envelope{RR: rrs}
+	close(ch)
+	r.SetTsig(testKeyName, mdns.HmacSHA256, 300, time.Now().Unix())
+	tr := new(mdns.Transfer)
+	tr.TsigSecret = map[string]string{testKeyName: testKeySecret}
+	tr.Out(w, r, ch)
+	w.Hijack()
+}
+
+func startTestZoneServer(t *testing.T) (*testZoneServer, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	zone := &testZoneServer{records: map[string]string{}}
+	started := make(chan struct{})
+	server := &mdns.Server{
+		Listener:          listener,
+		Handler:           zone,
+		TsigSecret:        map[string]string{testKeyName: testKeySecret},
+		NotifyStartedFunc: func() { close(started) },
+		// The default accept func refuses updates
+		MsgAcceptFunc: func(mdns.Header) mdns.MsgAcceptAction { return mdns.MsgAccept },
+	}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+
+	return zone, listener.Addr().String()
+}
+
+func TestRFC2136Provider(t *testing.T) {
+	zone, addr := startTestZoneServer(t)
+	ctx := context.Background()
+
+	provider := dns.NewRFC2136Provider("Subnet.Example", addr, 300, "dhcp2p", testKeySecret, "hmac-sha256", time.Second)
+
+	require.NoError(t, provider.UpsertRecord(ctx, "peer1.subnet.example.", "10.0.0.1"))
+	require.NoError(t, provider.UpsertRecord(ctx, "peer2.subnet.example.", "10.0.0.2"))
+	require.NoError(t, provider.UpsertRecord(ctx, "peer1.subnet.example.", "10.0.0.3"))
+	require.NoError(t, provider.DeleteRecord(ctx, "peer2.subnet.example."))
+	assert.Equal(t, map[string]string{"peer1.subnet.example.": "10.0.0.3"}, zone.records)
+
+	records, err := provider.ListRecords(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*models.DNSRecord{{Hostname: "peer1.subnet.example.", IP: "10.0.0.3"}}, records, "the apex is not listed")
+
+	t.Run("unsigned updates are refused", func(t *testing.T) {
+		unsigned := dns.NewRFC2136Provider(testZone, addr, 300, "", "", "", time.Second)
+		assert.Error(t, unsigned.UpsertRecord(ctx, "peer3.subnet.example.", "10.0.0.4"))
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		assert.Error(t, provider.UpsertRecord(ctx, "peer3.subnet.example.", "not-an-ip"))
+	})
+}
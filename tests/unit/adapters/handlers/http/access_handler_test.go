@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestAccessHandler_AddRule(t *testing.T) {
+	pubkey := base64.StdEncoding.EncodeToString(make([]byte, 36))
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(*mocks.MockAccessControlService)
+		expectedStatus int
+	}{
+		{
+			name: "deny peer ID",
+			body: `{"list":"deny","subject_type":"peer_id","subject":"12D3KooWExamplePeerID","reason":"abuse"}`,
+			mockSetup: func(m *mocks.MockAccessControlService) {
+				m.EXPECT().AddRule(gomock.Any(), &models.AccessRule{
+					List:        models.AccessListDeny,
+					SubjectType: models.AccessSubjectPeerID,
+					Subject:     "12D3KooWExamplePeerID",
+					Reason:      "abuse",
+				}).Return(&models.AccessRule{ID: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "allow public key",
+			body: `{"list":"allow","subject_type":"pubkey","subject":"` + pubkey + `"}`,
+			mockSetup: func(m *mocks.MockAccessControlService) {
+				m.EXPECT().AddRule(gomock.Any(), &models.AccessRule{
+					List:        models.AccessListAllow,
+					SubjectType: models.AccessSubjectPubkey,
+					Subject:     pubkey,
+				}).Return(&models.AccessRule{ID: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "already on the list",
+			body: `{"list":"deny","subject_type":"peer_id","subject":"12D3KooWExamplePeerID"}`,
+			mockSetup: func(m *mocks.MockAccessControlService) {
+				m.EXPECT().AddRule(gomock.Any(), gomock.Any()).Return(nil, errors.ErrAccessRuleExists)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "unknown list",
+			body:           `{"list":"block","subject_type":"peer_id","subject":"12D3KooWExamplePeerID"}`,
+			mockSetup:      func(m *mocks.MockAccessControlService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid public key",
+			body:           `{"list":"deny","subject_type":"pubkey","subject":"not base64"}`,
+			mockSetup:      func(m *mocks.MockAccessControlService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockAccessControlService(ctrl)
+			tt.mockSetup(mockService)
+
+			handler := handlers.NewAccessHandler(mockService)
+			w := httptest.NewRecorder()
+			handler.AddRule(w, httptest.NewRequest(http.MethodPost, "/v1/admin/access-rules", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAccessHandler_ListRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockAccessControlService(ctrl)
+	mockService.EXPECT().ListRules(gomock.Any(), models.AccessListAllow).Return([]*models.AccessRule{}, nil)
+	handler := handlers.NewAccessHandler(mockService)
+
+	w := httptest.NewRecorder()
+	handler.ListRules(w, httptest.NewRequest(http.MethodGet, "/v1/admin/access-rules?list=allow", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ListRules(w, httptest.NewRequest(http.MethodGet, "/v1/admin/access-rules?list=other", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAccessHandler_RemoveRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockAccessControlService(ctrl)
+	mockService.EXPECT().RemoveRule(gomock.Any(), int64(3)).Return(nil)
+	mockService.EXPECT().RemoveRule(gomock.Any(), int64(4)).Return(errors.ErrAccessRuleNotFound)
+	handler := handlers.NewAccessHandler(mockService)
+
+	w := httptest.NewRecorder()
+	handler.RemoveRule(w, createRequestWithURLParams(http.MethodDelete, "/v1/admin/access-rules/3", map[string]string{"ruleID": "3"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.RemoveRule(w, createRequestWithURLParams(http.MethodDelete, "/v1/admin/access-rules/4", map[string]string{"ruleID": "4"}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,341 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestAdminHandler_RedisMemory(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*mocks.MockCacheDiagnostics)
+		expectedStatus int
+	}{
+		{
+			name: "uses configured sample size",
+			url:  "/v1/admin/diagnostics/redis-memory",
+			setupMock: func(m *mocks.MockCacheDiagnostics) {
+				m.EXPECT().MemoryUsage(gomock.Any(), 100).Return(&models.CacheMemoryReport{
+					SampleSize: 100,
+					Classes: []*models.KeyClassMemoryUsage{
+						{Pattern: "nonce:*", Keys: 10, SampledKeys: 10, SampledBytes: 1000, AvgBytes: 100, EstimatedBytes: 1000},
+					},
+					TotalEstimatedBytes: 1000,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "sample size from query",
+			url:  "/v1/admin/diagnostics/redis-memory?samples=5",
+			setupMock: func(m *mocks.MockCacheDiagnostics) {
+				m.EXPECT().MemoryUsage(gomock.Any(), 5).Return(&models.CacheMemoryReport{SampleSize: 5}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid sample size",
+			url:            "/v1/admin/diagnostics/redis-memory?samples=-1",
+			setupMock:      func(m *mocks.MockCacheDiagnostics) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockDiagnostics := mocks.NewMockCacheDiagnostics(ctrl)
+			tt.setupMock(mockDiagnostics)
+			handler := handlers.NewAdminHandler(mockDiagnostics, nil, nil, nil, nil, nil, &config.AppConfig{AdminMemorySampleSize: 100})
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.RedisMemory(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					Data models.CacheMemoryReport `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			}
+		})
+	}
+}
+
+func TestAdminHandler_RunReclamation(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*mocks.MockReclamationService)
+		expectedStatus int
+	}{
+		{
+			name: "dry run by default",
+			url:  "/v1/admin/reclamation/run",
+			setupMock: func(m *mocks.MockReclamationService) {
+				m.EXPECT().Run(gomock.Any(), true).Return(&models.ReclaimReport{DryRun: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "explicit reclaim",
+			url:  "/v1/admin/reclamation/run?dryRun=false",
+			setupMock: func(m *mocks.MockReclamationService) {
+				m.EXPECT().Run(gomock.Any(), false).Return(&models.ReclaimReport{Reclaimed: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid dry run flag",
+			url:            "/v1/admin/reclamation/run?dryRun=maybe",
+			setupMock:      func(m *mocks.MockReclamationService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockReclamation := mocks.NewMockReclamationService(ctrl)
+			tt.setupMock(mockReclamation)
+			handler := handlers.NewAdminHandler(nil, mockReclamation, nil, nil, nil, nil, &config.AppConfig{})
+
+			req := httptest.NewRequest("POST", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.RunReclamation(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAdminHandler_ReclamationMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReclamation := mocks.NewMockReclamationService(ctrl)
+	mockReclamation.EXPECT().Metrics().Return(&models.ReclaimMetrics{
+		Runs:     2,
+		Policies: []*models.ReclaimPolicyMetrics{{Policy: "stale", Matched: 4, Reclaimed: 4}},
+	})
+	handler := handlers.NewAdminHandler(nil, mockReclamation, nil, nil, nil, nil, &config.AppConfig{})
+
+	req := httptest.NewRequest("GET", "/v1/admin/reclamation/metrics", nil)
+	w := httptest.NewRecorder()
+
+	handler.ReclamationMetrics(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data models.ReclaimMetrics `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.Data.Runs)
+	assert.Equal(t, int64(4), response.Data.Policies[0].Reclaimed)
+}
+
+func TestAdminHandler_NonceMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNonces := mocks.NewMockNonceService(ctrl)
+	mockNonces.EXPECT().Metrics().Return(&models.NonceMetrics{
+		Issued:   10,
+		Rejected: 3,
+		Peers:    []*models.NoncePeerRate{{PeerID: "peer-1", PerMinute: 7}},
+	})
+	handler := handlers.NewAdminHandler(nil, nil, nil, mockNonces, nil, nil, &config.AppConfig{})
+
+	req := httptest.NewRequest("GET", "/v1/admin/nonces/metrics", nil)
+	w := httptest.NewRecorder()
+
+	handler.NonceMetrics(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data models.NonceMetrics `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(3), response.Data.Rejected)
+	assert.Equal(t, "peer-1", response.Data.Peers[0].PeerID)
+}
+
+func TestAdminHandler_PeerNonces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	issuedAt := time.Now().UTC().Truncate(time.Second)
+	mockNonces := mocks.NewMockNonceService(ctrl)
+	mockNonces.EXPECT().ListOutstandingNonces(gomock.Any(), "peer-1").Return([]*models.Nonce{
+		{ID: "nonce-1", PeerID: "peer-1", IssuedAt: issuedAt, ExpiresAt: issuedAt.Add(time.Minute)},
+		{ID: "nonce-2", PeerID: "peer-1", IssuedAt: issuedAt, ExpiresAt: issuedAt.Add(time.Minute)},
+	}, nil)
+	handler := handlers.NewAdminHandler(nil, nil, nil, mockNonces, nil, nil, &config.AppConfig{})
+
+	w := httptest.NewRecorder()
+	handler.PeerNonces(w, createRequestWithURLParams("GET", "/v1/admin/peers/peer-1/nonces", map[string]string{"peerID": "peer-1"}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data handlers.PeerNoncesResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "peer-1", response.Data.PeerID)
+	if assert.Len(t, response.Data.Nonces, 2) {
+		assert.Equal(t, "nonce-1", response.Data.Nonces[0].ID)
+		assert.True(t, response.Data.Nonces[0].ExpiresAt.Equal(issuedAt.Add(time.Minute)))
+	}
+}
+
+func TestAdminHandler_RevokePeerNonces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNonces := mocks.NewMockNonceService(ctrl)
+	mockNonces.EXPECT().RevokeNonces(gomock.Any(), "peer-1").Return(int64(2), nil)
+	handler := handlers.NewAdminHandler(nil, nil, nil, mockNonces, nil, nil, &config.AppConfig{})
+
+	w := httptest.NewRecorder()
+	handler.RevokePeerNonces(w, createRequestWithURLParams("DELETE", "/v1/admin/peers/peer-1/nonces", map[string]string{"peerID": "peer-1"}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data handlers.NonceRevocationResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.Data.Revoked)
+}
+
+func TestAdminHandler_SignatureCacheStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSignatures := mocks.NewMockSignatureCacheReporter(ctrl)
+	mockSignatures.EXPECT().SignatureCacheStats().Return(&models.SignatureCacheStats{
+		Enabled: true,
+		Hits:    30,
+		Misses:  10,
+		HitRate: 0.75,
+	})
+	handler := handlers.NewAdminHandler(nil, nil, nil, nil, mockSignatures, nil, &config.AppConfig{})
+
+	req := httptest.NewRequest("GET", "/v1/admin/auth/signature-cache", nil)
+	w := httptest.NewRecorder()
+
+	handler.SignatureCacheStats(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data models.SignatureCacheStats `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(30), response.Data.Hits)
+	assert.Equal(t, 0.75, response.Data.HitRate)
+}
+
+func TestAdminHandler_EventBusMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEvents := mocks.NewMockEventBus(ctrl)
+	mockEvents.EXPECT().Stats().Return(&models.EventBusStats{
+		Broker:    "nats",
+		Published: 12,
+		Sinks:     []*models.EventSinkStats{{Name: "broker", Delivered: 11, Queued: 1, Retries: 2}},
+	})
+	handler := handlers.NewAdminHandler(nil, nil, nil, nil, nil, mockEvents, &config.AppConfig{})
+
+	req := httptest.NewRequest("GET", "/v1/admin/events/metrics", nil)
+	w := httptest.NewRecorder()
+
+	handler.EventBusMetrics(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data models.EventBusStats `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "nats", response.Data.Broker)
+	assert.Equal(t, int64(12), response.Data.Published)
+	assert.Equal(t, int64(2), response.Data.Sinks[0].Retries)
+}
+
+func TestAdminHandler_ExpiryNotificationReport(t *testing.T) {
+	tests := []struct {
+		name           string
+		report         *models.ExpiryNotificationReport
+		expectedStatus int
+	}{
+		{
+			name:           "no run yet",
+			report:         nil,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "last run",
+			report:         &models.ExpiryNotificationReport{Found: 7},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExpiry := mocks.NewMockExpiryNotificationService(ctrl)
+			mockExpiry.EXPECT().LastReport().Return(tt.report)
+			handler := handlers.NewAdminHandler(nil, nil, mockExpiry, nil, nil, nil, &config.AppConfig{})
+
+			req := httptest.NewRequest("GET", "/v1/admin/expiry-notifications/report", nil)
+			w := httptest.NewRecorder()
+
+			handler.ExpiryNotificationReport(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAdminHandler_RunExpiryNotifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockExpiry := mocks.NewMockExpiryNotificationService(ctrl)
+	mockExpiry.EXPECT().Run(gomock.Any()).Return(&models.ExpiryNotificationReport{
+		Found:    3,
+		Channels: []*models.ExpiryChannelResult{{Channel: "log", Batches: 1, Notified: 3}},
+	}, nil)
+	handler := handlers.NewAdminHandler(nil, nil, mockExpiry, nil, nil, nil, &config.AppConfig{})
+
+	req := httptest.NewRequest("POST", "/v1/admin/expiry-notifications/run", nil)
+	w := httptest.NewRecorder()
+
+	handler.RunExpiryNotifications(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data models.ExpiryNotificationReport `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 3, response.Data.Found)
+	assert.Equal(t, 3, response.Data.Channels[0].Notified)
+}
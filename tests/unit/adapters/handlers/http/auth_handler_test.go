@@ -6,14 +6,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	httpMiddleware "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
 )
 
@@ -151,7 +155,7 @@ func TestAuthHandler_RequestAuth(t *testing.T) {
 			mockService := mocks.NewMockAuthService(ctrl)
 			tt.mockSetup(ctrl, mockService)
 
-			handler := handlers.NewAuthHandler(mockService)
+			handler := handlers.NewAuthHandler(mockService, nil)
 
 			req := httptest.NewRequest("POST", "/request-auth", nil)
 			for key, value := range tt.headers {
@@ -179,13 +183,46 @@ func TestAuthHandler_RequestAuth(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_RequestAuth_JSONBody(t *testing.T) {
+	pubkey := base64.StdEncoding.EncodeToString([]byte("valid-pubkey-data"))
+
+	serve := func(mockService *mocks.MockAuthService, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/request-auth", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		httpMiddleware.WithRequestBody(http.HandlerFunc(handlers.NewAuthHandler(mockService, nil).RequestAuth)).ServeHTTP(w, req)
+		return w
+	}
+
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockAuthService(ctrl)
+	mockService.EXPECT().RequestAuth(gomock.Any(), &models.AuthRequest{
+		Pubkey: []byte("valid-pubkey-data"),
+	}).Return(&models.AuthResponse{NonceID: "test-nonce-id"}, nil)
+
+	w := serve(mockService, `{"pubkey": "`+pubkey+`"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "test-nonce-id")
+
+	w = serve(mockService, `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(mockService, `{"pubkey": "`+base64.StdEncoding.EncodeToString([]byte("short"))+`"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_PUBKEY")
+
+	w = serve(mockService, `{"pubkey": "`+pubkey+`", "peer_id": "12D3KooW"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_REQUEST")
+}
+
 func TestAuthHandler_RequestAuth_EdgeCases(t *testing.T) {
 	t.Run("context cancellation", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		mockService := mocks.NewMockAuthService(ctrl)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, nil)
 
 		req := httptest.NewRequest("POST", "/request-auth", nil)
 		req.Header.Set("X-Pubkey", base64.StdEncoding.EncodeToString([]byte("valid-pubkey-data")))
@@ -208,7 +245,7 @@ func TestAuthHandler_RequestAuth_EdgeCases(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockService := mocks.NewMockAuthService(ctrl)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, nil)
 
 		// Create a very large pubkey (but still within limits)
 		largePubkey := make([]byte, 1000)
@@ -238,7 +275,7 @@ func TestAuthHandler_RequestAuth_EdgeCases(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockService := mocks.NewMockAuthService(ctrl)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, nil)
 
 		// Create pubkey with special characters
 		specialPubkey := []byte("valid-pubkey-with-special-chars!@#$%^&*()")
@@ -268,7 +305,7 @@ func TestAuthHandler_RequestAuth_EdgeCases(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockService := mocks.NewMockAuthService(ctrl)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, nil)
 
 		req := httptest.NewRequest("POST", "/request-auth", nil)
 		req.Header.Set("X-Pubkey", base64.StdEncoding.EncodeToString([]byte("valid-pubkey-data")))
@@ -298,7 +335,7 @@ func TestAuthHandler_RequestAuth_EdgeCases(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockService := mocks.NewMockAuthService(ctrl)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, nil)
 
 		req := httptest.NewRequest("POST", "/request-auth", nil)
 		req.Header.Set("x-pubkey", base64.StdEncoding.EncodeToString([]byte("valid-pubkey-data"))) // lowercase
@@ -329,7 +366,7 @@ func TestAuthHandler_RequestAuth_Concurrent(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockService := mocks.NewMockAuthService(ctrl)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, nil)
 
 		const numRequests = 10
 		results := make(chan struct {
@@ -380,3 +417,93 @@ func TestAuthHandler_RequestAuth_Concurrent(t *testing.T) {
 		assert.Equal(t, numRequests, successfulRequests)
 	})
 }
+
+func TestAuthHandler_RevokeNonce(t *testing.T) {
+	const nonceID = "0f8fad5b-d9cb-469f-a165-70867728950e"
+
+	tests := []struct {
+		name           string
+		peerID         string
+		nonceID        string
+		mockSetup      func(*mocks.MockNonceService)
+		expectedStatus int
+	}{
+		{
+			name:    "revoked",
+			peerID:  "peer123",
+			nonceID: nonceID,
+			mockSetup: func(m *mocks.MockNonceService) {
+				m.EXPECT().RevokeNonce(gomock.Any(), "peer123", nonceID).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "unknown nonce",
+			peerID:  "peer123",
+			nonceID: nonceID,
+			mockSetup: func(m *mocks.MockNonceService) {
+				m.EXPECT().RevokeNonce(gomock.Any(), "peer123", nonceID).Return(errors.ErrNonceNotFoundErr)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "malformed nonce",
+			peerID:         "peer123",
+			nonceID:        "not-a-nonce",
+			mockSetup:      func(m *mocks.MockNonceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing peer ID",
+			nonceID:        nonceID,
+			mockSetup:      func(m *mocks.MockNonceService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockNonces := mocks.NewMockNonceService(ctrl)
+			tt.mockSetup(mockNonces)
+			handler := handlers.NewAuthHandler(nil, mockNonces)
+
+			req := httptest.NewRequest("DELETE", "/v1/nonces/"+tt.nonceID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("nonceID", tt.nonceID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			if tt.peerID != "" {
+				ctx = reqctx.WithPeerID(ctx, tt.peerID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.RevokeNonce(w, req.WithContext(ctx))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAuthHandler_RevokeNonces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNonces := mocks.NewMockNonceService(ctrl)
+	mockNonces.EXPECT().RevokeNonces(gomock.Any(), "peer123").Return(int64(3), nil)
+	handler := handlers.NewAuthHandler(nil, mockNonces)
+
+	req := httptest.NewRequest("DELETE", "/v1/nonces", nil)
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	w := httptest.NewRecorder()
+
+	handler.RevokeNonces(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data handlers.NonceRevocationResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(3), response.Data.Revoked)
+}
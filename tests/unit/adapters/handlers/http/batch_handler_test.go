@@ -0,0 +1,98 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestBatchHandler_ExecuteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuth := mocks.NewMockAuthService(ctrl)
+	mockService := mocks.NewMockLeaseService(ctrl)
+	mockAccess := mocks.NewMockAccessControlService(ctrl)
+	mockAccess.EXPECT().CheckAccess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	handler := handlers.NewBatchHandler(mockAuth, mockAccess, mockService, &config.AppConfig{Lease: config.LeaseConfig{BatchMaxOperations: 10}})
+
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	pubkey, err := crypto.MarshalPublicKey(key.GetPublic())
+	require.NoError(t, err)
+	peerID, err := dhcp2pcrypto.PeerIDFromPublicKey(pubkey)
+	require.NoError(t, err)
+
+	encodedPubkey := base64.StdEncoding.EncodeToString(pubkey)
+	signature := base64.StdEncoding.EncodeToString(make([]byte, 64))
+
+	body, err := json.Marshal(handlers.BatchRequest{Operations: []*handlers.BatchOperationRequest{
+		{Op: "allocate", Pubkey: encodedPubkey, Nonce: "0f8fad5b-d9cb-469f-a165-70867728950e", Signature: signature},
+		{Op: "renew", Pubkey: encodedPubkey, Nonce: "1f8fad5b-d9cb-469f-a165-70867728950e", Signature: signature, TokenID: 42},
+		{Op: "explode", Pubkey: encodedPubkey, Nonce: "2f8fad5b-d9cb-469f-a165-70867728950e", Signature: signature},
+	}})
+	require.NoError(t, err)
+
+	mockAuth.EXPECT().VerifyAuth(gomock.Any(), gomock.Any()).Return(&models.AuthVerifyResponse{Pubkey: pubkey, PeerID: peerID}, nil).Times(2)
+	mockService.EXPECT().ExecuteBatch(gomock.Any(), []*models.LeaseOperation{
+		{Type: models.LeaseOperationAllocate, PeerID: peerID},
+		{Type: models.LeaseOperationRenew, PeerID: peerID, TokenID: 42},
+	}).Return([]*models.LeaseOperationResult{
+		{Lease: &models.Lease{TokenID: 41, PeerID: peerID}},
+		{Err: errors.ErrLeaseNotFound},
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/v1/leases/batch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ExecuteBatch(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data handlers.BatchResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data.Results, 3)
+
+	assert.Equal(t, "ok", response.Data.Results[0].Status)
+	assert.Equal(t, int64(41), response.Data.Results[0].Lease.TokenID)
+	assert.Equal(t, "error", response.Data.Results[1].Status)
+	assert.Equal(t, "LEASE_NOT_FOUND", response.Data.Results[1].Error.Code)
+	assert.Equal(t, "error", response.Data.Results[2].Status)
+	assert.Equal(t, "INVALID_OPERATION", response.Data.Results[2].Error.Code)
+}
+
+func TestBatchHandler_RejectsInvalidBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := handlers.NewBatchHandler(mocks.NewMockAuthService(ctrl), mocks.NewMockAccessControlService(ctrl), mocks.NewMockLeaseService(ctrl), &config.AppConfig{Lease: config.LeaseConfig{BatchMaxOperations: 1}})
+
+	for _, body := range []string{
+		`not json`,
+		`{"operations":[]}`,
+		`{"operations":[{"op":"allocate"},{"op":"allocate"}]}`,
+	} {
+		req := httptest.NewRequest("POST", "/v1/leases/batch", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		handler.ExecuteBatch(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
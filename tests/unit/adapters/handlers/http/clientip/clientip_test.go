@@ -0,0 +1,198 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/clientip"
+)
+
+func TestNetworks_Contains(t *testing.T) {
+	networks := clientip.ParseNetworks([]string{"10.0.0.0/8", "192.0.2.7", "2001:db8::/32", "not-a-network"})
+	assert.Len(t, networks, 3)
+
+	assert.True(t, networks.Contains("10.1.2.3"))
+	assert.True(t, networks.Contains("10.1.2.3:5678"))
+	assert.True(t, networks.Contains("::ffff:10.1.2.3"))
+	assert.True(t, networks.Contains("192.0.2.7"))
+	assert.True(t, networks.Contains("[2001:db8::1]:443"))
+	assert.True(t, networks.Contains("[2001:db8::1]"))
+	assert.False(t, networks.Contains("192.0.2.8"))
+	assert.False(t, networks.Contains("unknown"))
+	assert.False(t, clientip.ParseNetworks(nil).Contains("10.1.2.3"))
+}
+
+func TestResolve(t *testing.T) {
+	trusted := clientip.ParseNetworks([]string{"10.0.0.0/8", "2001:db8:ffff::/48"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		expected   string
+	}{
+		{
+			name:       "peer address",
+			remoteAddr: "192.0.2.1:1234",
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "peer address without port",
+			remoteAddr: "192.0.2.1",
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "IPv4-mapped peer address",
+			remoteAddr: "[::ffff:192.0.2.1]:1234",
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "invalid peer address",
+			remoteAddr: "invalid",
+			expected:   "unknown",
+		},
+		{
+			name:       "headers from untrusted peer are ignored",
+			remoteAddr: "192.0.2.1:1234",
+			headers: map[string][]string{
+				"Forwarded":       {"for=198.51.100.1"},
+				"X-Forwarded-For": {"198.51.100.1"},
+				"X-Real-Ip":       {"198.51.100.1"},
+			},
+			expected: "192.0.2.1",
+		},
+		{
+			name:       "trusted peer without headers",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "X-Real-IP",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Real-Ip": {"198.51.100.1"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "invalid X-Real-IP",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Real-Ip": {"bogus"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "X-Forwarded-For single client",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "X-Forwarded-For skips trusted proxies from the right",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1, 10.0.0.3, 10.0.0.2"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "X-Forwarded-For spoofed entries left of the client are ignored",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.9, 203.0.113.66, 198.51.100.1, 10.0.0.2"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "X-Forwarded-For across several header lines",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.66", "198.51.100.1, 10.0.0.2"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "X-Forwarded-For of trusted addresses only",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			expected:   "10.0.0.3",
+		},
+		{
+			name:       "X-Forwarded-For invalid entry stops at the proxy reporting it",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1, bogus, 10.0.0.2"}},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "X-Forwarded-For takes precedence over X-Real-IP",
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1"},
+				"X-Real-Ip":       {"198.51.100.2"},
+			},
+			expected: "198.51.100.1",
+		},
+		{
+			name:       "Forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"for=198.51.100.1;proto=https;by=10.0.0.1"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "Forwarded skips trusted proxies from the right",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"for=203.0.113.66, for=198.51.100.1;proto=https, For=\"10.0.0.2:8080\""}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "Forwarded quoted IPv6 with port",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {`for="[2001:db8::1]:4711", for="[2001:db8:ffff::2]"`}},
+			expected:   "2001:db8::1",
+		},
+		{
+			name:       "Forwarded quoted parameters containing separators",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {`host="a,b;c", for=198.51.100.1;ext="x\"y,z"`}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "Forwarded unknown client stops at the proxy reporting it",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"for=198.51.100.1, for=unknown, for=10.0.0.2"}},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "Forwarded obfuscated client stops at the peer",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"for=_hidden"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "Forwarded element without for stops at the peer",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"proto=https"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "Forwarded takes precedence over X-Forwarded-For",
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"Forwarded":       {"for=198.51.100.1"},
+				"X-Forwarded-For": {"198.51.100.2"},
+			},
+			expected: "198.51.100.1",
+		},
+		{
+			name:       "trusted IPv6 peer",
+			remoteAddr: "[2001:db8:ffff::1]:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"2001:db8::5"}},
+			expected:   "2001:db8::5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, values := range tt.headers {
+				for _, v := range values {
+					req.Header.Add(k, v)
+				}
+			}
+			assert.Equal(t, tt.expected, clientip.Resolve(req, trusted))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestDiagnosticsHandler_Runtime(t *testing.T) {
+	t.Run("includes connection pool statistics", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		database := mocks.NewMockDatabasePoolStatsReporter(ctrl)
+		database.EXPECT().DatabasePoolStats().Return(&models.DatabasePoolStats{MaxConns: 25, TotalConns: 10, AcquiredConns: 4, IdleConns: 6})
+		cache := mocks.NewMockCachePoolStatsReporter(ctrl)
+		cache.EXPECT().CachePoolStats().Return(&models.CachePoolStats{TotalConns: 8, IdleConns: 5, Hits: 120, Misses: 8})
+
+		w := httptest.NewRecorder()
+		handlers.NewDiagnosticsHandler(database, cache).Runtime(w, httptest.NewRequest(http.MethodGet, "/v1/admin/debug/runtime", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data models.RuntimeStats `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Positive(t, resp.Data.Goroutines)
+		assert.Positive(t, resp.Data.Memory.HeapAlloc)
+		assert.Equal(t, &models.DatabasePoolStats{MaxConns: 25, TotalConns: 10, AcquiredConns: 4, IdleConns: 6}, resp.Data.Database)
+		assert.Equal(t, &models.CachePoolStats{TotalConns: 8, IdleConns: 5, Hits: 120, Misses: 8}, resp.Data.Cache)
+	})
+
+	t.Run("omits missing pools", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.NewDiagnosticsHandler(nil, nil).Runtime(w, httptest.NewRequest(http.MethodGet, "/v1/admin/debug/runtime", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), `"database"`)
+		assert.NotContains(t, w.Body.String(), `"cache"`)
+	})
+}
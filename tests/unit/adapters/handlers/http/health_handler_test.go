@@ -3,19 +3,22 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 )
 
-// MockDB is a mock for pgxpool.Pool
+// MockDB is a mock health checker for the database or cache
 type MockDB struct {
 	mock.Mock
 }
@@ -25,31 +28,11 @@ func (m *MockDB) Ping(ctx context.Context) error {
 	return args.Error(0)
 }
 
-// MockRedisClient is a mock for redis.Client
-type MockRedisClient struct {
-	mock.Mock
-}
-
-func (m *MockRedisClient) Ping(ctx context.Context) *redis.StatusCmd {
-	args := m.Called(ctx)
-	return args.Get(0).(*redis.StatusCmd)
-}
-
-// MockStatusCmd is a mock for redis.StatusCmd
-type MockStatusCmd struct {
-	mock.Mock
-}
-
-func (m *MockStatusCmd) Err() error {
-	args := m.Called()
-	return args.Error(0)
-}
-
 func TestHealthHandler_Health(t *testing.T) {
 	tests := []struct {
 		name           string
-		db             *pgxpool.Pool
-		cache          *redis.Client
+		db             ports.HealthChecker
+		cache          ports.HealthChecker
 		expectedStatus int
 		expectedBody   map[string]string
 	}{
@@ -71,7 +54,7 @@ func TestHealthHandler_Health(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := handlers.NewHealthHandler(tt.db, tt.cache)
+			handler := handlers.NewHealthHandler(tt.db, tt.cache, config.NewDefaultAppConfig(), nil)
 
 			req := httptest.NewRequest("GET", "/health", nil)
 			w := httptest.NewRecorder()
@@ -89,74 +72,163 @@ func TestHealthHandler_Health(t *testing.T) {
 	}
 }
 
+// blockingChecker never answers before the probe times out
+type blockingChecker struct{}
+
+func (blockingChecker) Ping(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
 func TestHealthHandler_Readiness(t *testing.T) {
+	pingErr := errors.New("connection refused")
+
 	tests := []struct {
-		name           string
-		db             *pgxpool.Pool
-		cache          *redis.Client
-		mockSetup      func(*MockDB, *MockRedisClient)
-		expectedStatus int
-		expectedError  bool
+		name             string
+		mockSetup        func(*MockDB, *MockDB)
+		withDB           bool
+		withCache        bool
+		degradedMode     bool
+		degradedRenewals bool
+		expectedStatus   int
+		expectedBody     string // readiness status, or the error code for missing dependencies
+		expectedCode     string
+		components       map[string]string
 	}{
 		{
-			name:           "successful readiness check with nil dependencies",
-			db:             nil,
-			cache:          nil,
-			mockSetup:      func(mockDB *MockDB, mockRedis *MockRedisClient) {},
+			name:           "nil dependencies",
+			mockSetup:      func(mockDB *MockDB, mockCache *MockDB) {},
 			expectedStatus: http.StatusServiceUnavailable,
-			expectedError:  true,
+			expectedCode:   "MISSING_DEPENDENCIES",
 		},
 		{
 			name:           "nil database",
-			db:             nil,
-			cache:          &redis.Client{},
-			mockSetup:      func(mockDB *MockDB, mockRedis *MockRedisClient) {},
+			withCache:      true,
+			mockSetup:      func(mockDB *MockDB, mockCache *MockDB) {},
 			expectedStatus: http.StatusServiceUnavailable,
-			expectedError:  true,
+			expectedCode:   "MISSING_DEPENDENCIES",
 		},
 		{
-			name:           "nil cache",
-			db:             &pgxpool.Pool{},
-			cache:          nil,
-			mockSetup:      func(mockDB *MockDB, mockRedis *MockRedisClient) {},
+			name:   "nil cache skips the cache check",
+			withDB: true,
+			mockSetup: func(mockDB *MockDB, mockCache *MockDB) {
+				mockDB.On("Ping", mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.ReadinessReady,
+			components:     map[string]string{"database": models.ComponentUp},
+		},
+		{
+			name:      "all dependencies reachable",
+			withDB:    true,
+			withCache: true,
+			mockSetup: func(mockDB *MockDB, mockCache *MockDB) {
+				mockDB.On("Ping", mock.Anything).Return(nil)
+				mockCache.On("Ping", mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.ReadinessReady,
+			components:     map[string]string{"database": models.ComponentUp, "redis": models.ComponentUp},
+		},
+		{
+			name:      "database ping failure",
+			withDB:    true,
+			withCache: true,
+			mockSetup: func(mockDB *MockDB, mockCache *MockDB) {
+				mockDB.On("Ping", mock.Anything).Return(pingErr)
+				mockCache.On("Ping", mock.Anything).Return(nil)
+			},
 			expectedStatus: http.StatusServiceUnavailable,
-			expectedError:  true,
+			expectedBody:   models.ReadinessNotReady,
+			expectedCode:   "DATABASE_CHECK_FAILED",
+			components:     map[string]string{"database": models.ComponentDown, "redis": models.ComponentUp},
 		},
 		{
-			name:           "both nil dependencies",
-			db:             nil,
-			cache:          nil,
-			mockSetup:      func(mockDB *MockDB, mockRedis *MockRedisClient) {},
+			name:      "cache ping failure",
+			withDB:    true,
+			withCache: true,
+			mockSetup: func(mockDB *MockDB, mockCache *MockDB) {
+				mockDB.On("Ping", mock.Anything).Return(nil)
+				mockCache.On("Ping", mock.Anything).Return(pingErr)
+			},
 			expectedStatus: http.StatusServiceUnavailable,
-			expectedError:  true,
+			expectedBody:   models.ReadinessNotReady,
+			expectedCode:   "REDIS_CHECK_FAILED",
+			components:     map[string]string{"database": models.ComponentUp, "redis": models.ComponentDown},
 		},
 		{
-			name:  "database ping failure",
-			db:    nil, // Use nil to trigger the nil check in the handler
-			cache: nil,
-			mockSetup: func(mockDB *MockDB, mockRedis *MockRedisClient) {
-				// No setup needed for nil dependencies
+			name:         "cache ping failure in degraded mode",
+			withDB:       true,
+			withCache:    true,
+			degradedMode: true,
+			mockSetup: func(mockDB *MockDB, mockCache *MockDB) {
+				mockDB.On("Ping", mock.Anything).Return(nil)
+				mockCache.On("Ping", mock.Anything).Return(pingErr)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.ReadinessDegraded,
+			components:     map[string]string{"database": models.ComponentUp, "redis": models.ComponentDown},
+		},
+		{
+			name:         "database failure is fatal in degraded mode",
+			withDB:       true,
+			withCache:    true,
+			degradedMode: true,
+			mockSetup: func(mockDB *MockDB, mockCache *MockDB) {
+				mockDB.On("Ping", mock.Anything).Return(pingErr)
+				mockCache.On("Ping", mock.Anything).Return(nil)
 			},
 			expectedStatus: http.StatusServiceUnavailable,
-			expectedError:  true,
+			expectedBody:   models.ReadinessNotReady,
+			expectedCode:   "DATABASE_CHECK_FAILED",
+			components:     map[string]string{"database": models.ComponentDown, "redis": models.ComponentUp},
 		},
 		{
-			name:  "cache ping failure",
-			db:    nil, // Use nil to trigger the nil check in the handler
-			cache: nil,
-			mockSetup: func(mockDB *MockDB, mockRedis *MockRedisClient) {
-				// No setup needed for nil dependencies
+			name:             "database failure with degraded renewals",
+			withDB:           true,
+			withCache:        true,
+			degradedRenewals: true,
+			mockSetup: func(mockDB *MockDB, mockCache *MockDB) {
+				mockDB.On("Ping", mock.Anything).Return(pingErr)
+				mockCache.On("Ping", mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.ReadinessDegraded,
+			components:     map[string]string{"database": models.ComponentDown, "redis": models.ComponentUp},
+		},
+		{
+			name:             "database and cache failure with degraded renewals",
+			withDB:           true,
+			withCache:        true,
+			degradedMode:     true,
+			degradedRenewals: true,
+			mockSetup: func(mockDB *MockDB, mockCache *MockDB) {
+				mockDB.On("Ping", mock.Anything).Return(pingErr)
+				mockCache.On("Ping", mock.Anything).Return(pingErr)
 			},
 			expectedStatus: http.StatusServiceUnavailable,
-			expectedError:  true,
+			expectedBody:   models.ReadinessNotReady,
+			expectedCode:   "DATABASE_CHECK_FAILED",
+			components:     map[string]string{"database": models.ComponentDown, "redis": models.ComponentDown},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Note: In a real test, you would need to properly mock the pgxpool.Pool and redis.Client
-			// For now, we'll test the basic functionality without mocking
-			handler := handlers.NewHealthHandler(tt.db, tt.cache)
+			mockDB, mockCache := &MockDB{}, &MockDB{}
+			tt.mockSetup(mockDB, mockCache)
+
+			var db, cache ports.HealthChecker
+			if tt.withDB {
+				db = mockDB
+			}
+			if tt.withCache {
+				cache = mockCache
+			}
+			cfg := config.NewDefaultAppConfig()
+			cfg.ReadinessDegradedMode = tt.degradedMode
+			cfg.DegradedRenewalsEnabled = tt.degradedRenewals
+			handler := handlers.NewHealthHandler(db, cache, cfg, nil)
 
 			req := httptest.NewRequest("GET", "/ready", nil)
 			w := httptest.NewRecorder()
@@ -166,22 +238,101 @@ func TestHealthHandler_Readiness(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 
-			if !tt.expectedError {
-				var response map[string]string
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.Equal(t, "ready", response["status"])
-			} else {
-				// Check that response contains error information
-				assert.NotEmpty(t, w.Body.String())
+			var response struct {
+				Status     string                       `json:"status"`
+				Code       string                       `json:"code"`
+				Components []*models.ComponentReadiness `json:"components"`
 			}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response.Status)
+			assert.Equal(t, tt.expectedCode, response.Code)
+
+			components := make(map[string]string)
+			for _, c := range response.Components {
+				components[c.Name] = c.Status
+			}
+			if tt.components != nil {
+				assert.Equal(t, tt.components, components)
+			}
+			mockDB.AssertExpectations(t)
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHealthHandler_ReadinessTimeouts(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.ReadinessDBTimeout = 20
+	cfg.ReadinessRedisTimeout = 10
+	cfg.ReadinessDegradedMode = true
+
+	mockDB := &MockDB{}
+	mockDB.On("Ping", mock.Anything).Return(nil)
+	handler := handlers.NewHealthHandler(mockDB, blockingChecker{}, cfg, nil)
+
+	req := httptest.NewRequest("GET", "/ready", nil)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.Readiness(w, req)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report models.ReadinessReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, models.ReadinessDegraded, report.Status)
+	assert.Len(t, report.Components, 2)
+	assert.Equal(t, models.ComponentTimeout, report.Components[1].Status)
+	assert.False(t, report.Components[1].Required)
+	assert.GreaterOrEqual(t, report.Components[1].LatencyMs, float64(10))
+}
+
+// fakeHAController reports a fixed active/standby status
+type fakeHAController struct {
+	status models.HAStatus
+}
+
+func (c fakeHAController) IsActive() bool          { return c.status.Role == models.HARoleActive }
+func (c fakeHAController) Status() models.HAStatus { return c.status }
+
+func TestHealthHandler_HARole(t *testing.T) {
+	for _, tt := range []struct {
+		role           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{role: models.HARoleActive, expectedStatus: http.StatusOK},
+		{role: models.HARoleStandby, expectedStatus: http.StatusServiceUnavailable, expectedCode: "HA_STANDBY"},
+	} {
+		t.Run(tt.role, func(t *testing.T) {
+			mockDB := &MockDB{}
+			mockDB.On("Ping", mock.Anything).Return(nil)
+			ha := fakeHAController{status: models.HAStatus{Role: tt.role, Instance: "dhcp2p-a"}}
+			handler := handlers.NewHealthHandler(mockDB, nil, config.NewDefaultAppConfig(), ha)
+
+			w := httptest.NewRecorder()
+			handler.Health(w, httptest.NewRequest("GET", "/health", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			var health map[string]string
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+			assert.Equal(t, map[string]string{"status": "ok", "ha_role": tt.role}, health)
+
+			w = httptest.NewRecorder()
+			handler.Readiness(w, httptest.NewRequest("GET", "/ready", nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var report models.ReadinessReport
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, tt.role, report.HARole)
+			assert.Equal(t, tt.expectedCode, report.Code)
 		})
 	}
 }
 
 func TestHealthHandler_EdgeCases(t *testing.T) {
 	t.Run("context timeout", func(t *testing.T) {
-		handler := handlers.NewHealthHandler(nil, nil)
+		handler := handlers.NewHealthHandler(nil, nil, config.NewDefaultAppConfig(), nil)
 
 		// Create a request with a very short timeout
 		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
@@ -198,7 +349,7 @@ func TestHealthHandler_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("concurrent health checks", func(t *testing.T) {
-		handler := handlers.NewHealthHandler(nil, nil)
+		handler := handlers.NewHealthHandler(nil, nil, config.NewDefaultAppConfig(), nil)
 
 		const numRequests = 10
 		results := make(chan struct {
@@ -239,7 +390,7 @@ func TestHealthHandler_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("concurrent readiness checks", func(t *testing.T) {
-		handler := handlers.NewHealthHandler(nil, nil) // Will fail due to nil dependencies
+		handler := handlers.NewHealthHandler(nil, nil, config.NewDefaultAppConfig(), nil) // Will fail due to nil dependencies
 
 		const numRequests = 10
 		results := make(chan struct {
@@ -280,7 +431,7 @@ func TestHealthHandler_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("different HTTP methods", func(t *testing.T) {
-		handler := handlers.NewHealthHandler(nil, nil)
+		handler := handlers.NewHealthHandler(nil, nil, config.NewDefaultAppConfig(), nil)
 
 		methods := []string{"GET", "POST", "PUT", "DELETE", "PATCH"}
 
@@ -298,7 +449,7 @@ func TestHealthHandler_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("malformed request", func(t *testing.T) {
-		handler := handlers.NewHealthHandler(nil, nil)
+		handler := handlers.NewHealthHandler(nil, nil, config.NewDefaultAppConfig(), nil)
 
 		// Create a request with malformed URL
 		req := httptest.NewRequest("GET", "/health?invalid=%%%", nil)
@@ -313,7 +464,7 @@ func TestHealthHandler_EdgeCases(t *testing.T) {
 
 func TestHealthHandler_ResponseFormat(t *testing.T) {
 	t.Run("health response format", func(t *testing.T) {
-		handler := handlers.NewHealthHandler(nil, nil)
+		handler := handlers.NewHealthHandler(nil, nil, config.NewDefaultAppConfig(), nil)
 
 		req := httptest.NewRequest("GET", "/health", nil)
 		w := httptest.NewRecorder()
@@ -331,7 +482,7 @@ func TestHealthHandler_ResponseFormat(t *testing.T) {
 	})
 
 	t.Run("readiness response format", func(t *testing.T) {
-		handler := handlers.NewHealthHandler(nil, nil)
+		handler := handlers.NewHealthHandler(nil, nil, config.NewDefaultAppConfig(), nil)
 
 		req := httptest.NewRequest("GET", "/ready", nil)
 		w := httptest.NewRecorder()
@@ -349,3 +500,41 @@ func TestHealthHandler_ResponseFormat(t *testing.T) {
 		assert.Contains(t, response, "message")
 	})
 }
+
+func TestHealthScoreHandler_Score(t *testing.T) {
+	t.Run("missing dependencies score 0", func(t *testing.T) {
+		cfg := config.NewDefaultAppConfig()
+		handler := handlers.NewHealthScoreHandler(nil, nil, middleware.NewRequestStats(cfg), cfg)
+
+		req := httptest.NewRequest("GET", "/healthz/score", nil)
+		w := httptest.NewRecorder()
+
+		handler.Score(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response struct {
+			Data models.HealthScore `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, response.Data.Score)
+		assert.Equal(t, cfg.HealthScoreThreshold, response.Data.Threshold)
+		assert.False(t, response.Data.Healthy)
+		assert.Len(t, response.Data.Components, 4)
+	})
+
+	t.Run("zero threshold always passes", func(t *testing.T) {
+		cfg := config.NewDefaultAppConfig()
+		cfg.HealthScoreThreshold = 0
+		handler := handlers.NewHealthScoreHandler(nil, nil, nil, cfg)
+
+		req := httptest.NewRequest("GET", "/healthz/score", nil)
+		w := httptest.NewRecorder()
+
+		handler.Score(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -2,19 +2,23 @@ package http
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/keys"
+	httpMiddleware "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 )
 
 // Helper function to create a request with chi URL parameters
@@ -70,12 +74,12 @@ func TestLeaseHandler_AllocateIP(t *testing.T) {
 			mockService := mocks.NewMockLeaseService(ctrl)
 			tt.mockSetup(ctrl, mockService)
 
-			handler := handlers.NewLeaseHandler(mockService)
+			handler := handlers.NewLeaseHandler(mockService, mockService)
 
 			// Create request with proper context (set by auth middleware)
 			req := httptest.NewRequest("POST", "/allocate-ip", nil)
 			if tt.peerID != "" {
-				req = req.WithContext(context.WithValue(req.Context(), keys.PeerIDContextKey, tt.peerID))
+				req = req.WithContext(reqctx.WithPeerID(req.Context(), tt.peerID))
 			}
 			w := httptest.NewRecorder()
 
@@ -95,12 +99,163 @@ func TestLeaseHandler_AllocateIP(t *testing.T) {
 	}
 }
 
+func TestLeaseHandler_AllocateIP_RequestedTokenID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	fallbackLease := &models.Lease{
+		TokenID:   167772161,
+		PeerID:    "peer123",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mockService.EXPECT().AllocateRequestedIP(gomock.Any(), "peer123", int64(167772200)).Return(&models.AllocationResult{
+		Lease:            fallbackLease,
+		RequestedTokenID: 167772200,
+		Reason:           models.AllocationReasonInUse,
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/allocate-ip?tokenID=167772200", nil)
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	w := httptest.NewRecorder()
+
+	handler.AllocateIP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data handlers.AllocateRequestedIPResponse `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, fallbackLease.TokenID, response.Data.Lease.TokenID)
+	assert.Equal(t, int64(167772200), response.Data.RequestedTokenID)
+	assert.False(t, response.Data.Granted)
+	assert.Equal(t, "in_use", response.Data.Reason)
+}
+
+func TestLeaseHandler_AllocateIP_AffinityGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	mockService.EXPECT().AllocateAffinityIP(gomock.Any(), "peer123", "gateway-1").Return(&models.Lease{
+		TokenID: 167772162,
+		PeerID:  "peer123",
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/allocate-ip?affinityGroup=gateway-1", nil)
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	w := httptest.NewRecorder()
+
+	handler.AllocateIP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Invalid group names and combining with tokenID are rejected
+	for _, url := range []string{
+		"/allocate-ip?affinityGroup=bad%20group",
+		"/allocate-ip?affinityGroup=gateway-1&tokenID=167772200",
+	} {
+		req := httptest.NewRequest("POST", url, nil)
+		req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+		w := httptest.NewRecorder()
+
+		handler.AllocateIP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+}
+
+func TestLeaseHandler_AllocateIP_Zone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	newRequest := func(url, zone string) *http.Request {
+		req := httptest.NewRequest("POST", url, nil)
+		req.Header.Set("X-Zone", zone)
+		return req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	}
+
+	mockService.EXPECT().AllocateZoneIP(gomock.Any(), "peer123", "rack-1").Return(&models.Lease{
+		TokenID: 167772200,
+		PeerID:  "peer123",
+	}, nil)
+	w := httptest.NewRecorder()
+	handler.AllocateIP(w, newRequest("/allocate-ip", "rack-1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// An affinity group takes precedence over the zone
+	mockService.EXPECT().AllocateAffinityIP(gomock.Any(), "peer123", "gateway-1").Return(&models.Lease{
+		TokenID: 167772162,
+		PeerID:  "peer123",
+	}, nil)
+	w = httptest.NewRecorder()
+	handler.AllocateIP(w, newRequest("/allocate-ip?affinityGroup=gateway-1", "rack-1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.AllocateIP(w, newRequest("/allocate-ip", "rack 1"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_ZONE")
+}
+
+func TestLeaseHandler_TransferLease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	oldPubkey := base64.StdEncoding.EncodeToString([]byte("old-public-key-bytes-1234"))
+	newPubkey := base64.StdEncoding.EncodeToString([]byte("new-public-key-bytes-1234"))
+	signature := base64.StdEncoding.EncodeToString([]byte("transfer-signature-bytes-1234567890"))
+	nonceID := "0f8fad5b-d9cb-469f-a165-70867728950e"
+
+	newRequest := func(withNewPubkey bool) *http.Request {
+		req := httptest.NewRequest("POST", "/v1/lease/transfer?tokenID=167772161", nil)
+		req.Header.Set("X-Pubkey", oldPubkey)
+		req.Header.Set("X-Nonce", nonceID)
+		req.Header.Set("X-Transfer-Signature", signature)
+		if withNewPubkey {
+			req.Header.Set("X-New-Pubkey", newPubkey)
+		}
+		return req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	}
+
+	mockService.EXPECT().TransferLease(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *models.LeaseTransferRequest) (*models.Lease, error) {
+			assert.Equal(t, int64(167772161), request.TokenID)
+			assert.Equal(t, nonceID, request.NonceID)
+			assert.Equal(t, []byte("new-public-key-bytes-1234"), request.ToPubkey)
+			return &models.Lease{TokenID: 167772161, PeerID: "new-peer"}, nil
+		})
+
+	w := httptest.NewRecorder()
+	handler.TransferLease(w, newRequest(true))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The new public key is mandatory
+	w = httptest.NewRecorder()
+	handler.TransferLease(w, newRequest(false))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestLeaseHandler_GetLeaseByPeerID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := mocks.NewMockLeaseService(ctrl)
-	handler := handlers.NewLeaseHandler(mockService)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
 
 	expectedLease := &models.Lease{
 		TokenID:   167772161,
@@ -128,12 +283,34 @@ func TestLeaseHandler_GetLeaseByPeerID(t *testing.T) {
 	assert.Equal(t, expectedLease.PeerID, response.Data.PeerID)
 }
 
+func TestLeaseHandler_ReadOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reader := mocks.NewMockLeaseReader(ctrl)
+	handler := handlers.NewLeaseHandler(reader, services.NewReadOnlyLeaseWriter())
+
+	lease := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Hour)}
+	reader.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(lease, nil)
+
+	w := httptest.NewRecorder()
+	handler.GetLeaseByPeerID(w, createRequestWithURLParams("GET", "/lease/peer-id/peer123", map[string]string{"peerID": "peer123"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req := httptest.NewRequest("POST", "/allocate-ip", nil)
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	w = httptest.NewRecorder()
+	handler.AllocateIP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "READ_ONLY")
+}
+
 func TestLeaseHandler_GetLeaseByTokenID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := mocks.NewMockLeaseService(ctrl)
-	handler := handlers.NewLeaseHandler(mockService)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
 
 	expectedLease := &models.Lease{
 		TokenID:   167772161,
@@ -161,12 +338,126 @@ func TestLeaseHandler_GetLeaseByTokenID(t *testing.T) {
 	assert.Equal(t, expectedLease.PeerID, response.Data.PeerID)
 }
 
+func TestLeaseHandler_GetLeaseByTokenID_ConditionalGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	lease := &models.Lease{
+		TokenID:   167772161,
+		PeerID:    "peer123",
+		UpdatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockService.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167772161)).Return(lease, nil).Times(3)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := createRequestWithURLParams("GET", "/lease/token-id/167772161", map[string]string{"tokenID": "167772161"})
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.GetLeaseByTokenID(w, req)
+		return w
+	}
+
+	w := get("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+	assert.Regexp(t, `^private, max-age=(3599|3600)$`, w.Header().Get("Cache-Control"))
+
+	w = get(etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	// A renewal changes the ETag
+	lease.UpdatedAt = lease.UpdatedAt.Add(time.Second)
+	w = get(etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestLeaseHandler_GetLeaseHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	history := []*models.LeaseHistoryEntry{
+		{TokenID: 167772161, PeerID: "peer123", Event: models.LeaseEventAllocate},
+		{TokenID: 167772161, PeerID: "peer456", Event: models.LeaseEventTransfer},
+	}
+	mockService.EXPECT().GetLeaseHistory(gomock.Any(), int64(167772161)).Return(history, nil)
+
+	req := createRequestWithURLParams("GET", "/v1/admin/leases/167772161/history", map[string]string{"tokenID": "167772161"})
+	w := httptest.NewRecorder()
+
+	handler.GetLeaseHistory(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []models.LeaseHistoryEntry `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, models.LeaseEventTransfer, response.Data[1].Event)
+	assert.Equal(t, "peer456", response.Data[1].PeerID)
+}
+
+func TestLeaseHandler_ReportConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	report := &models.LeaseConflictReport{TokenID: 167772161, PeerID: "peer123", ObservedPeerID: "12D3KooWOther"}
+	mockService.EXPECT().ReportConflict(gomock.Any(), report).Return(&models.LeaseConflict{
+		TokenID:        167772161,
+		PeerID:         "peer123",
+		ObservedPeerID: "12D3KooWOther",
+		Quarantined:    true,
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/v1/lease/conflict?tokenID=167772161&observedPeerID=12D3KooWOther", nil)
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	w := httptest.NewRecorder()
+
+	handler.ReportConflict(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.LeaseConflict `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response.Data.Quarantined)
+	assert.Equal(t, "12D3KooWOther", response.Data.ObservedPeerID)
+
+	// The observed peer ID is optional but must be well-formed when present
+	req = httptest.NewRequest("POST", "/v1/lease/conflict?tokenID=167772161&observedPeerID=not+a+peer", nil)
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	w = httptest.NewRecorder()
+
+	handler.ReportConflict(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestLeaseHandler_RenewLease(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := mocks.NewMockLeaseService(ctrl)
-	handler := handlers.NewLeaseHandler(mockService)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
 
 	expectedLease := &models.Lease{
 		TokenID:   167772161,
@@ -179,7 +470,7 @@ func TestLeaseHandler_RenewLease(t *testing.T) {
 
 	// Create request with proper context and query parameter
 	req := httptest.NewRequest("POST", "/renew-lease?tokenID=167772161", nil)
-	req = req.WithContext(context.WithValue(req.Context(), keys.PeerIDContextKey, "peer123"))
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
 	w := httptest.NewRecorder()
 
 	handler.RenewLease(w, req)
@@ -195,18 +486,49 @@ func TestLeaseHandler_RenewLease(t *testing.T) {
 	assert.Equal(t, expectedLease.PeerID, response.Data.PeerID)
 }
 
+func TestLeaseHandler_RenewLease_TooEarly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	renewableAt := time.Now().Add(90 * time.Second)
+	mockService.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(&models.Lease{
+		TokenID:     167772161,
+		PeerID:      "peer123",
+		ExpiresAt:   renewableAt.Add(30 * time.Minute),
+		RenewableAt: &renewableAt,
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/renew-lease?tokenID=167772161", nil)
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+	w := httptest.NewRecorder()
+
+	handler.RenewLease(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+
+	var response struct {
+		Data models.Lease `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotNil(t, response.Data.RenewableAt)
+}
+
 func TestLeaseHandler_ReleaseLease(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := mocks.NewMockLeaseService(ctrl)
-	handler := handlers.NewLeaseHandler(mockService)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
 
 	mockService.EXPECT().ReleaseLease(gomock.Any(), int64(167772161), "peer123").Return(nil)
 
 	// Create request with proper context and query parameter
 	req := httptest.NewRequest("POST", "/release-lease?tokenID=167772161", nil)
-	req = req.WithContext(context.WithValue(req.Context(), keys.PeerIDContextKey, "peer123"))
+	req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
 	w := httptest.NewRecorder()
 
 	handler.ReleaseLease(w, req)
@@ -253,7 +575,7 @@ func TestLeaseHandler_ValidationErrors(t *testing.T) {
 			name: "missing token ID query parameter for RenewLease",
 			setupRequest: func() *http.Request {
 				req := httptest.NewRequest("POST", "/renew-lease", nil)
-				return req.WithContext(context.WithValue(req.Context(), keys.PeerIDContextKey, "peer123"))
+				return req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
@@ -261,7 +583,7 @@ func TestLeaseHandler_ValidationErrors(t *testing.T) {
 			name: "missing token ID query parameter for ReleaseLease",
 			setupRequest: func() *http.Request {
 				req := httptest.NewRequest("POST", "/release-lease", nil)
-				return req.WithContext(context.WithValue(req.Context(), keys.PeerIDContextKey, "peer123"))
+				return req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
@@ -273,7 +595,7 @@ func TestLeaseHandler_ValidationErrors(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockService := mocks.NewMockLeaseService(ctrl)
-			handler := handlers.NewLeaseHandler(mockService)
+			handler := handlers.NewLeaseHandler(mockService, mockService)
 
 			req := tt.setupRequest()
 			w := httptest.NewRecorder()
@@ -296,3 +618,106 @@ func TestLeaseHandler_ValidationErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestLeaseHandler_JSONBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	serve := func(h http.HandlerFunc, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+		w := httptest.NewRecorder()
+		httpMiddleware.WithRequestBody(h).ServeHTTP(w, req)
+		return w
+	}
+	lease := &models.Lease{TokenID: 167772161, PeerID: "peer123"}
+
+	mockService.EXPECT().AllocateIP(gomock.Any(), "peer123").Return(lease, nil)
+	assert.Equal(t, http.StatusOK, serve(handler.AllocateIP, "/allocate-ip", `{}`).Code)
+
+	mockService.EXPECT().AllocateAffinityIP(gomock.Any(), "peer123", "gateway-1").Return(lease, nil)
+	assert.Equal(t, http.StatusOK, serve(handler.AllocateIP, "/allocate-ip", `{"affinity_group": "gateway-1"}`).Code)
+
+	mockService.EXPECT().AllocateRequestedIP(gomock.Any(), "peer123", int64(167772200)).Return(&models.AllocationResult{Lease: lease, RequestedTokenID: 167772200}, nil)
+	assert.Equal(t, http.StatusOK, serve(handler.AllocateIP, "/allocate-ip", `{"token_id": 167772200}`).Code)
+
+	mockService.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(lease, nil)
+	assert.Equal(t, http.StatusOK, serve(handler.RenewLease, "/renew-lease", `{"token_id": 167772161}`).Code)
+
+	// The body takes precedence over the query parameter
+	mockService.EXPECT().ReleaseLease(gomock.Any(), int64(167772161), "peer123").Return(nil)
+	assert.Equal(t, http.StatusOK, serve(handler.ReleaseLease, "/release-lease?tokenID=167772999", `{"token_id": 167772161}`).Code)
+
+	// Body values go through the same validation as query parameters
+	for name, body := range map[string]string{
+		"negative token ID":      `{"token_id": -1}`,
+		"invalid affinity group": `{"affinity_group": "bad group"}`,
+		"conflicting options":    `{"token_id": 167772200, "affinity_group": "gateway-1"}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, serve(handler.AllocateIP, "/allocate-ip", body).Code, name)
+	}
+	w := serve(handler.RenewLease, "/renew-lease", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "MISSING_TOKEN_ID")
+}
+
+func TestLeaseHandler_Labels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockLeaseService(ctrl)
+	handler := handlers.NewLeaseHandler(mockService, mockService)
+
+	serve := func(h http.HandlerFunc, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(reqctx.WithPeerID(req.Context(), "peer123"))
+		w := httptest.NewRecorder()
+		httpMiddleware.WithRequestBody(h).ServeHTTP(w, req)
+		return w
+	}
+	labelsOf := func(w *httptest.ResponseRecorder) map[string]string {
+		var response struct {
+			Data models.Lease `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Data.Labels
+	}
+	labels := map[string]string{"role": "validator", "region": "eu-west"}
+
+	// Labels of the body are trimmed and attached to the allocated lease
+	mockService.EXPECT().AllocateIP(gomock.Any(), "peer123").Return(&models.Lease{TokenID: 167772161, PeerID: "peer123"}, nil)
+	mockService.EXPECT().SetLeaseLabels(gomock.Any(), int64(167772161), "peer123", labels).Return(labels, nil)
+	w := serve(handler.AllocateIP, "/allocate-ip", `{"labels": {"role": "validator", "region": " eu-west "}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, labels, labelsOf(w))
+
+	// So are those of the query parameter, on renewal
+	mockService.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(&models.Lease{TokenID: 167772161, PeerID: "peer123"}, nil)
+	mockService.EXPECT().SetLeaseLabels(gomock.Any(), int64(167772161), "peer123", labels).Return(labels, nil)
+	w = serve(handler.RenewLease, "/renew-lease?tokenID=167772161&labels=role=validator,region=eu-west", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, labels, labelsOf(w))
+
+	// An empty object clears the labels, leaving them out keeps them
+	mockService.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(&models.Lease{TokenID: 167772161, PeerID: "peer123"}, nil)
+	mockService.EXPECT().SetLeaseLabels(gomock.Any(), int64(167772161), "peer123", map[string]string{}).Return(nil, nil)
+	assert.Equal(t, http.StatusOK, serve(handler.RenewLease, "/renew-lease", `{"token_id": 167772161, "labels": {}}`).Code)
+
+	mockService.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(&models.Lease{TokenID: 167772161, PeerID: "peer123", Labels: labels}, nil)
+	w = serve(handler.RenewLease, "/renew-lease", `{"token_id": 167772161}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, labels, labelsOf(w))
+
+	// Invalid labels are refused before anything is allocated
+	for name, url := range map[string]string{
+		"uppercase key":    "/allocate-ip?labels=Role=validator",
+		"missing value":    "/allocate-ip?labels=role",
+		"control in value": "/allocate-ip?labels=role=a%07b",
+		"oversized value":  "/allocate-ip?labels=role=" + strings.Repeat("x", models.MaxLeaseLabelValueBytes+1),
+	} {
+		w := serve(handler.AllocateIP, url, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		assert.Contains(t, w.Body.String(), "INVALID_LABELS", name)
+	}
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestLeaseQueryHandler_GetLeaseStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockLeaseQueryService)
+		expectedStatus int
+		expectedStats  *models.LeaseStats
+	}{
+		{
+			name: "returns stats",
+			setupMock: func(m *mocks.MockLeaseQueryService) {
+				m.EXPECT().GetLeaseStats(gomock.Any()).Return(&models.LeaseStats{Active: 5, Expired: 2, Total: 7}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedStats:  &models.LeaseStats{Active: 5, Expired: 2, Total: 7},
+		},
+		{
+			name: "service error",
+			setupMock: func(m *mocks.MockLeaseQueryService) {
+				m.EXPECT().GetLeaseStats(gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mocks.NewMockLeaseQueryService(ctrl)
+			tt.setupMock(mockService)
+			handler := handlers.NewLeaseQueryHandler(mockService)
+
+			req := httptest.NewRequest("GET", "/v1/leases/stats", nil)
+			w := httptest.NewRecorder()
+
+			handler.GetLeaseStats(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStats != nil {
+				var response struct {
+					Data models.LeaseStats `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, *tt.expectedStats, response.Data)
+			}
+		})
+	}
+}
+
+func TestLeaseQueryHandler_ListLeases(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expiresAt := time.Date(2024, 1, 15, 12, 30, 0, 0, time.UTC)
+	mockService := mocks.NewMockLeaseQueryService(ctrl)
+	mockService.EXPECT().ListLeases(gomock.Any(), &models.ListOptions{
+		Limit:      1,
+		SortField:  "expires_at",
+		Descending: true,
+		Filters:    []models.Filter{{Field: "peer_id", Operator: models.FilterEq, Value: "peer-1"}},
+	}).Return(&models.LeasePage{
+		Leases:  []*models.Lease{{TokenID: 167902210, PeerID: "peer-1", ExpiresAt: expiresAt}},
+		HasMore: true,
+	}, nil)
+	handler := handlers.NewLeaseQueryHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/v1/admin/leases?limit=1&sort=-expires_at&peer_id=peer-1", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListLeases(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data models.LeasePage `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Data.Leases, 1)
+	assert.True(t, response.Data.HasMore)
+
+	// The cursor continues after the last lease of the page
+	next, err := handlers.ValidateLeaseListRequest(httptest.NewRequest("GET", "/v1/admin/leases?sort=-expires_at&cursor="+response.Data.NextCursor, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Cursor{SortValue: expiresAt, Key: 167902210}, next.(*models.ListOptions).After)
+}
+
+func TestLeaseQueryHandler_ListLeases_InvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := handlers.NewLeaseQueryHandler(mocks.NewMockLeaseQueryService(ctrl))
+
+	req := httptest.NewRequest("GET", "/v1/admin/leases?sort=owner", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListLeases(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_SORT")
+}
+
+func TestValidateLeaseListRequest_LabelFilter(t *testing.T) {
+	opts, err := handlers.ValidateLeaseListRequest(httptest.NewRequest("GET", "/v1/admin/leases?label=role=validator&label[ne]=region=eu", nil))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []models.Filter{
+		{Field: "label", Operator: models.FilterEq, Value: models.LeaseLabel{Key: "role", Value: "validator"}},
+		{Field: "label", Operator: models.FilterNe, Value: models.LeaseLabel{Key: "region", Value: "eu"}},
+	}, opts.(*models.ListOptions).Filters)
+
+	for _, query := range []string{"label=role", "label[prefix]=role=v", "label=Role=validator"} {
+		_, err := handlers.ValidateLeaseListRequest(httptest.NewRequest("GET", "/v1/admin/leases?"+query, nil))
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidFilter, query)
+	}
+}
+
+func TestLeaseQueryHandler_SearchLeases(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockLeaseQueryService(ctrl)
+	mockService.EXPECT().SearchLeases(gomock.Any(), &models.LeaseSearch{
+		ListOptions: models.ListOptions{
+			Limit:     1,
+			SortField: "token_id",
+			Filters: []models.Filter{
+				{Field: "label", Operator: models.FilterEq, Value: models.LeaseLabel{Key: "role", Value: "gateway"}},
+				{Field: "label", Operator: models.FilterNe, Value: models.LeaseLabel{Key: "region", Value: "eu"}},
+			},
+		},
+		State:  models.LeaseStateActive,
+		Policy: "gateways",
+	}).Return(&models.LeaseSearchPage{
+		Leases:  []*models.LeaseSearchHit{{Lease: &models.Lease{TokenID: 167902210, PeerID: "peer-gw"}, IP: "10.2.0.2", Policy: "gateways"}},
+		HasMore: true,
+	}, nil)
+	handler := handlers.NewLeaseQueryHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/v1/admin/leases/search?limit=1&selector=role=gateway,region!=eu&policy=gateways", nil)
+	w := httptest.NewRecorder()
+
+	handler.SearchLeases(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data models.LeaseSearchPage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data.Leases, 1)
+	assert.Equal(t, int64(167902210), response.Data.Leases[0].TokenID)
+	assert.Equal(t, "10.2.0.2", response.Data.Leases[0].IP)
+	assert.NotEmpty(t, response.Data.NextCursor)
+}
+
+func TestValidateLeaseSearchRequest_Invalid(t *testing.T) {
+	for _, query := range []string{"selector=role", "selector=Role!=gateway", "state=leased", "owner=peer-1"} {
+		_, err := handlers.ValidateLeaseSearchRequest(httptest.NewRequest("GET", "/v1/admin/leases/search?"+query, nil))
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidFilter, query)
+	}
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestLeaseRevocationHandler_Revoke(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		body       string
+		setupMock  func(m *mocks.MockLeaseRevocationService)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "dry run by default",
+			url:  "/v1/admin/leases/revoke",
+			body: `{"filter": {"peer_ids": ["peer-1"]}, "reason": "key leaked"}`,
+			setupMock: func(m *mocks.MockLeaseRevocationService) {
+				m.EXPECT().Revoke(gomock.Any(), &models.LeaseRevocationRequest{
+					Filter: models.LeaseRevocationFilter{PeerIDs: []string{"peer-1"}},
+					Reason: "key leaked",
+					DryRun: true,
+				}).Return(&models.LeaseRevocation{State: models.LeaseRevocationCompleted, DryRun: true, Matched: 1, TokenIDs: []int64{167772161}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "revokes with dryRun=false",
+			url:  "/v1/admin/leases/revoke?dryRun=false",
+			body: `{"filter": {"cidr": "10.0.4.0/22", "label_selector": "role=edge"}}`,
+			setupMock: func(m *mocks.MockLeaseRevocationService) {
+				m.EXPECT().Revoke(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, request *models.LeaseRevocationRequest) (*models.LeaseRevocation, error) {
+					assert.False(t, request.DryRun)
+					return &models.LeaseRevocation{ID: "4f9d3c52-8a0e-4c4b-9f57-1d2c3b4a5e6f", State: models.LeaseRevocationRunning}, nil
+				})
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "filter without criteria",
+			url:        "/v1/admin/leases/revoke",
+			body:       `{"filter": {"tenant": "default"}}`,
+			setupMock:  func(m *mocks.MockLeaseRevocationService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_REVOCATION",
+		},
+		{
+			name:       "malformed CIDR",
+			url:        "/v1/admin/leases/revoke",
+			body:       `{"filter": {"cidr": "10.0.4.0"}}`,
+			setupMock:  func(m *mocks.MockLeaseRevocationService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_REVOCATION",
+		},
+		{
+			name:       "malformed label selector",
+			url:        "/v1/admin/leases/revoke",
+			body:       `{"filter": {"label_selector": "Role"}}`,
+			setupMock:  func(m *mocks.MockLeaseRevocationService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_REVOCATION",
+		},
+		{
+			name:       "inverted token range",
+			url:        "/v1/admin/leases/revoke",
+			body:       `{"filter": {"min_token_id": 20, "max_token_id": 10}}`,
+			setupMock:  func(m *mocks.MockLeaseRevocationService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_REVOCATION",
+		},
+		{
+			name:       "invalid dryRun flag",
+			url:        "/v1/admin/leases/revoke?dryRun=maybe",
+			body:       `{"filter": {"peer_ids": ["peer-1"]}}`,
+			setupMock:  func(m *mocks.MockLeaseRevocationService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_REQUEST",
+		},
+		{
+			name: "unknown tenant",
+			url:  "/v1/admin/leases/revoke",
+			body: `{"filter": {"tenant": "acme", "peer_ids": ["peer-1"]}}`,
+			setupMock: func(m *mocks.MockLeaseRevocationService) {
+				m.EXPECT().Revoke(gomock.Any(), gomock.Any()).Return(nil, errors.ErrTenantNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   "TENANT_NOT_FOUND",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			revocations := mocks.NewMockLeaseRevocationService(ctrl)
+			tt.setupMock(revocations)
+
+			w := httptest.NewRecorder()
+			handlers.NewLeaseRevocationHandler(revocations).Revoke(w, httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body)))
+			require.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantCode != "" {
+				var resp struct {
+					Code string `json:"code"`
+				}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, tt.wantCode, resp.Code)
+			}
+		})
+	}
+}
+
+func TestLeaseRevocationHandler_Revocation(t *testing.T) {
+	const id = "4f9d3c52-8a0e-4c4b-9f57-1d2c3b4a5e6f"
+
+	get := func(handler *handlers.LeaseRevocationHandler, revocationID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/admin/leases/revoke/"+revocationID, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("revocationID", revocationID)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		w := httptest.NewRecorder()
+		handler.Revocation(w, r)
+		return w
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	revocations := mocks.NewMockLeaseRevocationService(ctrl)
+	revocations.EXPECT().Revocation(gomock.Any(), id).Return(&models.LeaseRevocation{ID: id, State: models.LeaseRevocationRunning, Matched: 1200, Processed: 500}, nil)
+	handler := handlers.NewLeaseRevocationHandler(revocations)
+
+	w := get(handler, id)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data models.LeaseRevocation `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 500, resp.Data.Processed)
+
+	// IDs that are no UUID are never found
+	w = get(handler, "42")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
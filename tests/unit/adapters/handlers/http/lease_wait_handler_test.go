@@ -0,0 +1,156 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+type leaseWaitResponse struct {
+	Data struct {
+		Changed bool          `json:"changed"`
+		Event   string        `json:"event"`
+		Lease   *models.Lease `json:"lease"`
+	} `json:"data"`
+}
+
+func TestLeaseWaitHandler_Wait(t *testing.T) {
+	const tokenID = int64(167772161)
+	// The handler's clock runs ahead of the wall clock, so expiry must be measured by it
+	now := time.Now().Add(time.Hour)
+	lease := &models.Lease{TokenID: tokenID, PeerID: "peer123", UpdatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	renewed := &models.Lease{TokenID: tokenID, PeerID: "peer123", UpdatedAt: now.Add(time.Minute), ExpiresAt: now.Add(2 * time.Hour)}
+
+	tests := []struct {
+		name          string
+		query         string
+		ifNoneMatch   string
+		event         *models.LeaseLifecycleEvent // published while waiting
+		setupMock     func(*mocks.MockLeaseService)
+		expectedCode  int
+		expectChanged bool
+		expectedEvent string
+		expectedLease *models.Lease
+	}{
+		{
+			name:  "renewal ends the wait",
+			event: &models.LeaseLifecycleEvent{Type: models.LeaseLifecycleRenewed, TokenID: tokenID},
+			setupMock: func(m *mocks.MockLeaseService) {
+				gomock.InOrder(
+					m.EXPECT().GetLeaseByTokenID(gomock.Any(), tokenID).Return(lease, nil),
+					m.EXPECT().GetLeaseByTokenID(gomock.Any(), tokenID).Return(renewed, nil),
+				)
+			},
+			expectedCode:  http.StatusOK,
+			expectChanged: true,
+			expectedEvent: string(models.LeaseLifecycleRenewed),
+			expectedLease: renewed,
+		},
+		{
+			name:  "release ends the wait without a lease",
+			event: &models.LeaseLifecycleEvent{Type: models.LeaseLifecycleReleased, TokenID: tokenID},
+			setupMock: func(m *mocks.MockLeaseService) {
+				gomock.InOrder(
+					m.EXPECT().GetLeaseByTokenID(gomock.Any(), tokenID).Return(lease, nil),
+					m.EXPECT().GetLeaseByTokenID(gomock.Any(), tokenID).Return(nil, errors.ErrLeaseNotFound),
+				)
+			},
+			expectedCode:  http.StatusOK,
+			expectChanged: true,
+			expectedEvent: string(models.LeaseLifecycleReleased),
+		},
+		{
+			name:  "timeout returns the unchanged lease",
+			query: "?timeout=10ms",
+			setupMock: func(m *mocks.MockLeaseService) {
+				m.EXPECT().GetLeaseByTokenID(gomock.Any(), tokenID).Return(lease, nil)
+			},
+			expectedCode:  http.StatusOK,
+			expectedLease: lease,
+		},
+		{
+			name:        "stale copy returns at once",
+			ifNoneMatch: `W/"stale"`,
+			setupMock: func(m *mocks.MockLeaseService) {
+				m.EXPECT().GetLeaseByTokenID(gomock.Any(), tokenID).Return(lease, nil)
+			},
+			expectedCode:  http.StatusOK,
+			expectChanged: true,
+			expectedLease: lease,
+		},
+		{
+			name:  "expiry ends the wait",
+			query: "?timeout=1s",
+			setupMock: func(m *mocks.MockLeaseService) {
+				expiring := &models.Lease{TokenID: tokenID, PeerID: "peer123", ExpiresAt: now.Add(10 * time.Millisecond)}
+				m.EXPECT().GetLeaseByTokenID(gomock.Any(), tokenID).Return(expiring, nil)
+			},
+			expectedCode:  http.StatusOK,
+			expectChanged: true,
+			expectedEvent: string(models.LeaseLifecycleExpired),
+		},
+		{
+			name:         "invalid timeout",
+			query:        "?timeout=soon",
+			setupMock:    func(m *mocks.MockLeaseService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mocks.NewMockLeaseService(ctrl)
+			tt.setupMock(mockService)
+
+			events := make(chan *models.LeaseLifecycleEvent, 1)
+			if tt.event != nil {
+				events <- tt.event
+			}
+			mockWatcher := mocks.NewMockLeaseWatcher(ctrl)
+			mockWatcher.EXPECT().Watch(models.DefaultTenantID, tokenID).Return(events, func() {}).MaxTimes(1)
+
+			handler := handlers.NewLeaseWaitHandler(mockService, mockWatcher, clock.NewFake(now), config.NewDefaultAppConfig())
+			req := createRequestWithURLParams("GET", "/v1/lease/167772161/wait"+tt.query, map[string]string{"tokenID": "167772161"})
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.Wait(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response leaseWaitResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectChanged, response.Data.Changed)
+			assert.Equal(t, tt.expectedEvent, response.Data.Event)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			if tt.expectedLease == nil {
+				assert.Nil(t, response.Data.Lease)
+				assert.Empty(t, w.Header().Get("ETag"))
+				return
+			}
+			require.NotNil(t, response.Data.Lease)
+			assert.Equal(t, tt.expectedLease.UpdatedAt.UnixNano(), response.Data.Lease.UpdatedAt.UnixNano())
+			assert.Equal(t, utils.LeaseETag(tt.expectedLease), w.Header().Get("ETag"))
+		})
+	}
+}
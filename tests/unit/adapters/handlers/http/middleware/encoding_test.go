@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	handler := middleware.NegotiateEncoding(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteSuccessResponse(w, map[string]string{"status": "ok"})
+	}))
+
+	tests := []struct {
+		name         string
+		accept       string
+		expectedCode int
+		expectedType string
+	}{
+		{"no accept header", "", http.StatusOK, utils.MediaTypeJSON},
+		{"any media type", "*/*", http.StatusOK, utils.MediaTypeJSON},
+		{"cbor", "application/cbor", http.StatusOK, utils.MediaTypeCBOR},
+		{"nothing acceptable", "text/html", http.StatusNotAcceptable, utils.MediaTypeJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		})
+	}
+}
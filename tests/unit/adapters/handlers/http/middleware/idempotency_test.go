@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+)
+
+// countingHandler answers with the number of times it ran
+func countingHandler(status int) (http.Handler, *int) {
+	calls := 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	}), &calls
+}
+
+func idempotentRequest(peerID, key string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/leases/allocate", nil)
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	return req.WithContext(reqctx.WithPeerID(req.Context(), peerID))
+}
+
+func newIdempotency(window int) *middleware.Idempotency {
+	return middleware.NewIdempotency(&config.AppConfig{Lease: config.LeaseConfig{IdempotencyWindow: window}}, memory.NewIdempotencyStore(clock.NewSystem()), zap.NewNop())
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	next, calls := countingHandler(http.StatusOK)
+	handler := newIdempotency(60).Middleware(next)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, idempotentRequest("peer-a", "key-1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, idempotentRequest("peer-a", "key-1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"call":1}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "true", w.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, 1, *calls)
+
+	t.Run("other peer", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, idempotentRequest("peer-b", "key-1"))
+		assert.Equal(t, `{"call":2}`, w.Body.String())
+	})
+
+	t.Run("without key", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, idempotentRequest("peer-a", ""))
+		handler.ServeHTTP(w, idempotentRequest("peer-a", ""))
+		assert.Equal(t, 4, *calls)
+	})
+}
+
+func TestIdempotency_ReplayHonorsAccept(t *testing.T) {
+	next, calls := countingHandler(http.StatusOK)
+	handler := newIdempotency(60).Middleware(next)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, idempotentRequest("peer-a", "key-1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, accept := range []string{"application/cbor", "application/x-protobuf, application/json;q=0"} {
+		req := idempotentRequest("peer-a", "key-1")
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotAcceptable, w.Code, accept)
+		assert.Contains(t, w.Body.String(), "NOT_ACCEPTABLE", accept)
+		assert.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader), accept)
+	}
+
+	for _, accept := range []string{"application/cbor, application/json;q=0.5", "*/*"} {
+		req := idempotentRequest("peer-a", "key-1")
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, `{"call":1}`, w.Body.String(), accept)
+		assert.Equal(t, "true", w.Header().Get(middleware.IdempotentReplayedHeader), accept)
+	}
+	assert.Equal(t, 1, *calls)
+}
+
+func TestIdempotency_ClientErrorsAreReplayed(t *testing.T) {
+	next, calls := countingHandler(http.StatusConflict)
+	handler := newIdempotency(60).Middleware(next)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, idempotentRequest("peer-a", "key-1"))
+		assert.Equal(t, http.StatusConflict, w.Code)
+	}
+	assert.Equal(t, 1, *calls)
+}
+
+func TestIdempotency_ServerErrorsReleaseKey(t *testing.T) {
+	next, calls := countingHandler(http.StatusInternalServerError)
+	handler := newIdempotency(60).Middleware(next)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, idempotentRequest("peer-a", "key-1"))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader))
+	}
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	idempotency := newIdempotency(60)
+
+	var inner *httptest.ResponseRecorder
+	handler := idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A retry arriving while the first request is still being served
+		inner = httptest.NewRecorder()
+		idempotency.Middleware(http.NotFoundHandler()).ServeHTTP(inner, idempotentRequest("peer-a", "key-1"))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("peer-a", "key-1"))
+	assert.Equal(t, http.StatusConflict, inner.Code)
+	assert.Equal(t, "IDEMPOTENCY_KEY_IN_PROGRESS", errorCode(t, inner))
+}
+
+func TestIdempotency_InvalidKey(t *testing.T) {
+	next, calls := countingHandler(http.StatusOK)
+	handler := newIdempotency(60).Middleware(next)
+
+	for _, key := range []string{"with space", strings.Repeat("k", 256)} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, idempotentRequest("peer-a", key))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_IDEMPOTENCY_KEY", errorCode(t, w))
+	}
+	assert.Equal(t, 0, *calls)
+}
+
+func TestIdempotency_Disabled(t *testing.T) {
+	next, calls := countingHandler(http.StatusOK)
+	handler := newIdempotency(0).Middleware(next)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, idempotentRequest("peer-a", "key-1"))
+		assert.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader))
+	}
+	assert.Equal(t, 2, *calls)
+}
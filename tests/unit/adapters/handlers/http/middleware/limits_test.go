@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// decodeHandler decodes a JSON body like the handlers do and answers 400 when it fails
+var decodeHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if err := utils.ParseRequestBody(r, &body); err != nil {
+		utils.WriteDomainError(w, errors.ErrInvalidRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+})
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp utils.ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp.Code
+}
+
+func TestRequestLimits_BodySize(t *testing.T) {
+	limits := middleware.NewRequestLimits(&config.AppConfig{Server: config.ServerConfig{MaxRequestBodySize: 16}})
+	handler := limits.Middleware(decodeHandler)
+
+	t.Run("within limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"a":1}`)))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("declared length above limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"a":"0123456789abcdef"}`)))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, "REQUEST_TOO_LARGE", errorCode(t, w))
+	})
+
+	t.Run("streamed body above limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"a":"0123456789abcdef"}`))
+		req.ContentLength = -1
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "the handler's 400 is replaced")
+		assert.Equal(t, "REQUEST_TOO_LARGE", errorCode(t, w))
+	})
+}
+
+func TestRequestLimits_Timeout(t *testing.T) {
+	limits := middleware.NewRequestLimits(&config.AppConfig{Server: config.ServerConfig{RequestTimeout: 1}})
+
+	t.Run("handler failing on the deadline", func(t *testing.T) {
+		handler := limits.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			utils.WriteDomainError(w, r.Context().Err())
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+		assert.Equal(t, http.StatusRequestTimeout, w.Code)
+		assert.Equal(t, "REQUEST_TIMEOUT", errorCode(t, w))
+	})
+
+	t.Run("handler returning without an answer", func(t *testing.T) {
+		handler := limits.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+		assert.Equal(t, http.StatusRequestTimeout, w.Code)
+	})
+
+	t.Run("completed work is answered", func(t *testing.T) {
+		handler := limits.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, "ok")
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ok", w.Body.String())
+	})
+}
+
+func TestRequestLimits_RouteOverride(t *testing.T) {
+	limits := middleware.NewRequestLimits(&config.AppConfig{
+		Server: config.ServerConfig{
+			RequestTimeout:          1,
+			RequestTimeoutOverrides: map[string]int{"/v1/admin/leases/{tokenid}/history": 300},
+		},
+	})
+
+	remaining := func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		require.True(t, ok)
+		w.Header().Set("X-Remaining", time.Until(deadline).Round(time.Second).String())
+	}
+
+	r := chi.NewRouter()
+	r.Use(limits.Middleware)
+	r.Get("/v1/admin/leases/{tokenID}/history", remaining)
+	r.Get("/health", remaining)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/admin/leases/167902210/history", nil))
+	assert.Equal(t, "5m0s", w.Header().Get("X-Remaining"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, "1s", w.Header().Get("X-Remaining"))
+}
+
+func TestRequestLimits_SlowBody(t *testing.T) {
+	limits := middleware.NewRequestLimits(&config.AppConfig{Server: config.ServerConfig{RequestTimeout: 1, MaxRequestBodySize: 1024}})
+	server := httptest.NewServer(limits.Middleware(decodeHandler))
+	defer server.Close()
+
+	// The body never completes, so only the read deadline ends the request
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go io.WriteString(pw, `{"a":`)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, pr)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+// blockingHandler holds every request until release is closed, signaling entered first
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+}
+
+func TestLoadShedder_RefusesWhenSaturated(t *testing.T) {
+	shedder := middleware.NewLoadShedder(&config.AppConfig{Server: config.ServerConfig{MaxInFlight: 1, QueueTimeout: 20}})
+	entered, release := make(chan struct{}, 2), make(chan struct{})
+	handler := shedder.Middleware(blockingHandler(entered, release))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/allocate-ip", nil))
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/renew-lease", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "SERVER_BUSY", errorCode(t, w))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, int64(1), shedder.Shed())
+
+	// Probes and operators still get through
+	for _, path := range []string{"/health", "/ready", "/v1/admin/pool-stats", "/v1/lease/42/wait"} {
+		go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		select {
+		case <-entered:
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not served", path)
+		}
+	}
+
+	close(release)
+	<-done
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/renew-lease", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "the slot is free again")
+}
+
+func TestLoadShedder_QueuesUntilSlotIsFree(t *testing.T) {
+	shedder := middleware.NewLoadShedder(&config.AppConfig{Server: config.ServerConfig{MaxInFlight: 1, QueueTimeout: 5000}})
+	entered, release := make(chan struct{}, 2), make(chan struct{})
+	handler := shedder.Middleware(blockingHandler(entered, release))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/allocate-ip", nil))
+	<-entered
+
+	queued := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/allocate-ip", nil))
+		queued <- w.Code
+	}()
+
+	select {
+	case <-entered:
+		t.Fatal("the second request was served while the first held the only slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-entered
+	assert.Equal(t, http.StatusOK, <-queued)
+	assert.Zero(t, shedder.Shed())
+}
+
+func TestLoadShedder_Disabled(t *testing.T) {
+	shedder := middleware.NewLoadShedder(&config.AppConfig{})
+	entered, release := make(chan struct{}, 10), make(chan struct{})
+	close(release)
+	handler := shedder.Middleware(blockingHandler(entered, release))
+
+	for range 10 {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/server-info", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
@@ -9,13 +9,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/keys"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 )
 
 func TestWithAuth(t *testing.T) {
@@ -41,11 +42,28 @@ func TestWithAuth(t *testing.T) {
 					Signature: make([]byte, 64),
 				}).Return(&models.AuthVerifyResponse{
 					Pubkey: make([]byte, 32),
+					PeerID: "peer123",
 				}, nil)
 			},
-			expectedStatus: http.StatusBadRequest, // Will fail at GetPeerIDFromPubkey
+			expectedStatus: http.StatusOK,
+			expectedError:  false,
+			expectedPeerID: "peer123",
+		},
+		{
+			name: "no identity resolved",
+			headers: map[string]string{
+				"X-Pubkey":    base64.StdEncoding.EncodeToString(make([]byte, 32)),
+				"X-Nonce":     "12345678-1234-1234-1234-123456789012",
+				"X-Signature": base64.StdEncoding.EncodeToString(make([]byte, 64)),
+			},
+			mockSetup: func(ctrl *gomock.Controller, mockService *mocks.MockAuthService) {
+				mockService.EXPECT().VerifyAuth(gomock.Any(), gomock.Any()).Return(&models.AuthVerifyResponse{
+					Pubkey: make([]byte, 32),
+				}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
 			expectedError:  true,
-			expectedPeerID: "", // Will be set by GetPeerIDFromPubkey
+			expectedPeerID: "",
 		},
 		{
 			name: "missing pubkey header",
@@ -165,10 +183,11 @@ func TestWithAuth(t *testing.T) {
 			},
 			mockSetup: func(ctrl *gomock.Controller, mockService *mocks.MockAuthService) {
 				mockService.EXPECT().VerifyAuth(gomock.Any(), gomock.Any()).Return(&models.AuthVerifyResponse{
-					Pubkey: make([]byte, 32), // Different pubkey (all zeros)
+					Pubkey: make([]byte, 33), // Different pubkey
+					PeerID: "peer123",
 				}, nil)
 			},
-			expectedStatus: http.StatusBadRequest, // Will fail at GetPeerIDFromPubkey
+			expectedStatus: http.StatusUnauthorized,
 			expectedError:  true,
 			expectedPeerID: "",
 		},
@@ -186,9 +205,30 @@ func TestWithAuth(t *testing.T) {
 					Signature: make([]byte, 64),
 				}).Return(&models.AuthVerifyResponse{
 					Pubkey: make([]byte, 32),
+					PeerID: "peer123",
 				}, nil)
 			},
-			expectedStatus: http.StatusBadRequest, // Will fail at GetPeerIDFromPubkey
+			expectedStatus: http.StatusOK,
+			expectedError:  false,
+			expectedPeerID: "peer123",
+		},
+		{
+			name: "timestamp forwarded to auth service",
+			headers: map[string]string{
+				"X-Pubkey":    base64.StdEncoding.EncodeToString(make([]byte, 32)),
+				"X-Nonce":     "12345678-1234-1234-1234-123456789012",
+				"X-Signature": base64.StdEncoding.EncodeToString(make([]byte, 64)),
+				"X-Timestamp": " 1700000000 ",
+			},
+			mockSetup: func(ctrl *gomock.Controller, mockService *mocks.MockAuthService) {
+				mockService.EXPECT().VerifyAuth(gomock.Any(), &models.AuthVerifyRequest{
+					Pubkey:    make([]byte, 32),
+					NonceID:   "12345678-1234-1234-1234-123456789012",
+					Signature: make([]byte, 64),
+					Timestamp: "1700000000",
+				}).Return(nil, errors.ErrTimestampOutOfWindow)
+			},
+			expectedStatus: http.StatusUnauthorized,
 			expectedError:  true,
 			expectedPeerID: "",
 		},
@@ -204,8 +244,7 @@ func TestWithAuth(t *testing.T) {
 
 			// Create a test handler that checks if peerID is set in context
 			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				peerID := r.Context().Value(keys.PeerIDContextKey)
-				if peerID != nil {
+				if _, ok := reqctx.PeerID(r.Context()); ok {
 					w.WriteHeader(http.StatusOK)
 					w.Write([]byte("authenticated"))
 				} else {
@@ -215,7 +254,9 @@ func TestWithAuth(t *testing.T) {
 			})
 
 			// Apply auth middleware
-			authMiddleware := middleware.WithAuth(mockService)
+			mockAccess := mocks.NewMockAccessControlService(ctrl)
+			mockAccess.EXPECT().CheckAccess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			authMiddleware := middleware.WithAuth(mockService, mockAccess)
 			handler := authMiddleware(testHandler)
 
 			req := httptest.NewRequest("POST", "/test", nil)
@@ -237,6 +278,63 @@ func TestWithAuth(t *testing.T) {
 	}
 }
 
+func TestWithAuth_AccessControl(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockAuthService(ctrl)
+	mockService.EXPECT().VerifyAuth(gomock.Any(), gomock.Any()).Return(&models.AuthVerifyResponse{
+		Pubkey: make([]byte, 32),
+		PeerID: "peer123",
+	}, nil)
+	mockAccess := mocks.NewMockAccessControlService(ctrl)
+	mockAccess.EXPECT().CheckAccess(gomock.Any(), "peer123", make([]byte, 32)).Return(errors.ErrPeerBlocked)
+
+	handler := middleware.WithAuth(mockService, mockAccess)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("a blocked peer reached the handler")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/allocate-ip", nil)
+	req.Header.Set("X-Pubkey", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	req.Header.Set("X-Nonce", "12345678-1234-1234-1234-123456789012")
+	req.Header.Set("X-Signature", base64.StdEncoding.EncodeToString(make([]byte, 64)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "PEER_BLOCKED")
+}
+
+func TestRequireCredentials(t *testing.T) {
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{"no credentials", map[string]string{}, http.StatusUnauthorized},
+		{"partial credentials are left to WithAuth", map[string]string{"X-Nonce": "12345678-1234-1234-1234-123456789012"}, http.StatusOK},
+		{"full credentials", map[string]string{"X-Pubkey": "a", "X-Nonce": "b", "X-Signature": "c"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.RequireCredentials(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/lease/token-id/1", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.Contains(t, w.Body.String(), errors.ErrAuthenticationRequired.Code)
+			}
+		})
+	}
+}
+
 func TestSecurityMiddleware(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -292,6 +390,16 @@ func TestSecurityMiddleware(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedError:  true,
 		},
+		{
+			name: "request with base64 header containing a pattern",
+			request: func() *http.Request {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.Header.Set("X-Signature", "MEUCIQDonload/AAonerror=")
+				return req
+			},
+			expectedStatus: http.StatusOK,
+			expectedError:  false,
+		},
 		{
 			name: "request with very long header",
 			request: func() *http.Request {
@@ -334,7 +442,7 @@ func TestSecurityMiddleware(t *testing.T) {
 			})
 
 			// Apply security middleware
-			securityMiddleware := middleware.SecurityMiddleware()
+			securityMiddleware := middleware.SecurityMiddleware(config.NewDefaultAppConfig())
 			handler := securityMiddleware(testHandler)
 
 			req := tt.request()
@@ -351,6 +459,33 @@ func TestSecurityMiddleware(t *testing.T) {
 	}
 }
 
+func TestSecurityMiddleware_ConfiguredRules(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Security.HeaderPatterns = []string{`union\s+select`}
+	cfg.Security.HeaderPatternsExempt = []string{"x-search"}
+	cfg.Security.MaxHeaderLength = 16
+
+	handler := middleware.SecurityMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(name, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set(name, value)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("X-Custom", "1 UNION Select")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "header X-Custom contains a suspicious value")
+
+	assert.Equal(t, http.StatusOK, serve("X-Search", "1 union select").Code, "exempt headers are not matched")
+	assert.Equal(t, http.StatusOK, serve("X-Custom", "<script>").Code, "the default patterns are replaced")
+
+	w = serve("X-Custom", strings.Repeat("a", 17))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "header X-Custom exceeds 16 bytes")
+}
+
 func TestRequestSizeMiddleware(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -451,7 +586,7 @@ func TestCombinedSecurityMiddleware(t *testing.T) {
 		})
 
 		// Apply combined security middleware
-		combinedMiddleware := middleware.CombinedSecurityMiddleware()
+		combinedMiddleware := middleware.CombinedSecurityMiddleware(config.NewDefaultAppConfig())
 		handler := combinedMiddleware(testHandler)
 
 		req := httptest.NewRequest("GET", "/test", nil)
@@ -475,7 +610,7 @@ func TestCombinedSecurityMiddleware(t *testing.T) {
 		})
 
 		// Apply combined security middleware
-		combinedMiddleware := middleware.CombinedSecurityMiddleware()
+		combinedMiddleware := middleware.CombinedSecurityMiddleware(config.NewDefaultAppConfig())
 		handler := combinedMiddleware(testHandler)
 
 		req := httptest.NewRequest("GET", "/test", nil)
@@ -489,6 +624,41 @@ func TestCombinedSecurityMiddleware(t *testing.T) {
 	})
 }
 
+func TestWithAdminToken(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		configured     string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "valid token", configured: "secret", authorization: "Bearer secret", expectedStatus: http.StatusOK},
+		{name: "wrong token", configured: "secret", authorization: "Bearer other", expectedStatus: http.StatusUnauthorized},
+		{name: "missing header", configured: "secret", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", configured: "secret", authorization: "Basic secret", expectedStatus: http.StatusUnauthorized},
+		{name: "no configured token", configured: "", authorization: "Bearer ", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.WithAdminToken(tt.configured)(testHandler)
+
+			req := httptest.NewRequest("GET", "/v1/admin/diagnostics/redis-memory", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
 func TestMiddleware_EdgeCases(t *testing.T) {
 	t.Run("concurrent middleware execution", func(t *testing.T) {
 		// Create a test handler
@@ -498,7 +668,7 @@ func TestMiddleware_EdgeCases(t *testing.T) {
 		})
 
 		// Apply combined middleware
-		combinedMiddleware := middleware.CombinedSecurityMiddleware()
+		combinedMiddleware := middleware.CombinedSecurityMiddleware(config.NewDefaultAppConfig())
 		handler := combinedMiddleware(testHandler)
 
 		const numRequests = 10
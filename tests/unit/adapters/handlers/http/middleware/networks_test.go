@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/clientip"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+)
+
+func TestAllowNetworks(t *testing.T) {
+	clientIP := func(r *http.Request) string { return clientip.Resolve(r, nil) }
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(allowed []string, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		middleware.AllowNetworks(allowed, clientIP)(ok).ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve(nil, "192.0.2.1:1234").Code)
+	assert.Equal(t, http.StatusOK, serve([]string{"10.0.0.0/8", "192.0.2.1"}, "10.1.2.3:1234").Code)
+	assert.Equal(t, http.StatusOK, serve([]string{"10.0.0.0/8", "192.0.2.1"}, "192.0.2.1:1234").Code)
+
+	w := serve([]string{"10.0.0.0/8"}, "192.0.2.1:1234")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "NETWORK_NOT_ALLOWED")
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newPayloadLogConfig() *config.AppConfig {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Log.PayloadsEnabled = true
+	return cfg
+}
+
+// echo answers with a lease certificate and checks the handler still sees the request body
+func echo(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "sig-request")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"token_id":167902210,"peer_id":"peer-a","signature":"sig-response"}}`))
+	})
+}
+
+func newPayloadRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/renew-lease?tokenID=167902210", strings.NewReader(`{"pubkey":"pk-request","signature":"sig-request","token_id":167902210,"operations":[{"pubkey":"pk-item"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Pubkey", "pk-header")
+	req.Header.Set("X-Signature", "sig-header")
+	req.Header.Set("X-API-Key", "tenant-key")
+	req.Header.Set("Authorization", "Bearer admin-token")
+	req.Header.Set("X-Nonce", "nonce-1")
+	return req
+}
+
+func TestPayloadLog_RedactsCredentials(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	payloadLog := middleware.NewPayloadLog(newPayloadLogConfig(), zap.New(core))
+
+	w := httptest.NewRecorder()
+	payloadLog.Middleware(echo(t)).ServeHTTP(w, newPayloadRequest())
+	assert.Contains(t, w.Body.String(), "sig-response", "the response is passed on unchanged")
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	entry, err := json.Marshal(fields)
+	require.NoError(t, err)
+	for _, secret := range []string{"pk-request", "sig-request", "pk-item", "pk-header", "sig-header", "tenant-key", "admin-token", "sig-response"} {
+		assert.NotContains(t, string(entry), secret)
+	}
+
+	assert.Equal(t, `{"operations":[{"pubkey":"[REDACTED]"}],"pubkey":"[REDACTED]","signature":"[REDACTED]","token_id":167902210}`, fields["requestBody"])
+	assert.Equal(t, `{"data":{"peer_id":"peer-a","signature":"[REDACTED]","token_id":167902210}}`, fields["responseBody"])
+	assert.Equal(t, "nonce-1", fields["requestHeaders"].(map[string]string)["X-Nonce"])
+	assert.Equal(t, "[REDACTED]", fields["requestHeaders"].(map[string]string)["X-Pubkey"])
+	assert.Equal(t, "tokenID=167902210", fields["query"])
+}
+
+func TestPayloadLog_LargeAndBinaryBodies(t *testing.T) {
+	cfg := newPayloadLogConfig()
+	cfg.Log.PayloadMaxBytes = 16
+	core, logs := observer.New(zap.InfoLevel)
+	payloadLog := middleware.NewPayloadLog(cfg, zap.New(core))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write([]byte{0x0a, 0x02, 0x08, 0x01})
+	})
+	payloadLog.Middleware(handler).ServeHTTP(httptest.NewRecorder(), newPayloadRequest())
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "[106 bytes, larger than log.payload_max_bytes]", fields["requestBody"])
+	assert.Equal(t, "[4 bytes, application/x-protobuf]", fields["responseBody"])
+}
+
+func TestPayloadLog_RateLimited(t *testing.T) {
+	cfg := newPayloadLogConfig()
+	cfg.Log.PayloadsPerSecond = 2
+	core, logs := observer.New(zap.InfoLevel)
+	payloadLog := middleware.NewPayloadLog(cfg, zap.New(core))
+
+	for range 5 {
+		w := httptest.NewRecorder()
+		payloadLog.Middleware(echo(t)).ServeHTTP(w, newPayloadRequest())
+		assert.Equal(t, http.StatusOK, w.Code, "requests over the limit are served without logging")
+	}
+	assert.Equal(t, 2, logs.Len())
+
+	t.Run("disabled by a reload", func(t *testing.T) {
+		disabled := config.NewDefaultAppConfig()
+		payloadLog.ApplyConfig(disabled)
+		logs.TakeAll()
+
+		payloadLog.Middleware(echo(t)).ServeHTTP(httptest.NewRecorder(), newPayloadRequest())
+		assert.Zero(t, logs.Len())
+	})
+}
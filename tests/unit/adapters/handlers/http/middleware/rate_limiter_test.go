@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/zap"
+)
+
+func TestRateLimiter_Rejected(t *testing.T) {
+	rl := middleware.NewRateLimiter(&config.AppConfig{RateLimit: config.RateLimitConfig{Enabled: true, RequestsPerMinute: 1, Burst: 2}}, nil, zap.NewNop())
+	defer rl.Stop()
+
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	codes := make([]int, 0, 4)
+	for range 4 {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes = append(codes, w.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, int64(2), rl.Rejected())
+}
+
+func TestRateLimiter_BansRepeatedViolations(t *testing.T) {
+	cfg := &config.AppConfig{RateLimit: config.RateLimitConfig{
+		Enabled: true, RequestsPerMinute: 1, Burst: 1,
+		BanEnabled: true, BanThreshold: 2, BanWindow: 60, BanDuration: 60, BanMaxDuration: 600,
+	}}
+	bans := services.NewBanService(cfg, memory.NewBanStore(clock.NewSystem()), clock.NewSystem(), zap.NewNop())
+	rl := middleware.NewRateLimiter(cfg, bans, zap.NewNop())
+	defer rl.Stop()
+
+	served := 0
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ }))
+	var codes []string
+	for range 4 {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		var body struct {
+			Code string `json:"code"`
+		}
+		json.NewDecoder(w.Body).Decode(&body)
+		codes = append(codes, body.Code)
+		if body.Code == "CLIENT_BANNED" {
+			assert.Equal(t, "60", w.Header().Get("Retry-After"))
+		}
+	}
+
+	assert.Equal(t, []string{"", "RATE_LIMIT_EXCEEDED", "CLIENT_BANNED", "CLIENT_BANNED"}, codes)
+	assert.Equal(t, 1, served)
+	assert.Equal(t, int64(3), rl.Rejected())
+	assert.Equal(t, int64(1), bans.Metrics().Bans)
+}
+
+func TestRateLimiter_BansAuthenticatedPeers(t *testing.T) {
+	cfg := &config.AppConfig{RateLimit: config.RateLimitConfig{
+		Enabled: true, RequestsPerMinute: 1, Burst: 1,
+		BanEnabled: true, BanThreshold: 2, BanWindow: 60, BanDuration: 60, BanMaxDuration: 600,
+	}}
+	bans := services.NewBanService(cfg, memory.NewBanStore(clock.NewSystem()), clock.NewSystem(), zap.NewNop())
+	rl := middleware.NewRateLimiter(cfg, bans, zap.NewNop())
+	defer rl.Stop()
+
+	served := 0
+	handler := rl.Middleware(rl.PeerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ })))
+	request := func(peerID, remoteAddr string) string {
+		req := httptest.NewRequest(http.MethodPost, "/allocate-ip", nil)
+		req.RemoteAddr = remoteAddr
+		req = req.WithContext(reqctx.WithPeerID(req.Context(), peerID))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var body struct {
+			Code string `json:"code"`
+		}
+		json.NewDecoder(w.Body).Decode(&body)
+		return body.Code
+	}
+
+	// A new address for every request keeps the client IP buckets full
+	var codes []string
+	for i := range 4 {
+		codes = append(codes, request("peer-one-0123456789", fmt.Sprintf("203.0.113.%d:1234", i+1)))
+	}
+	assert.Equal(t, []string{"", "RATE_LIMIT_EXCEEDED", "CLIENT_BANNED", "CLIENT_BANNED"}, codes)
+
+	// Other peers are served
+	assert.Equal(t, "", request("peer-two-0123456789", "203.0.113.5:1234"))
+	assert.Equal(t, 2, served)
+
+	status, err := bans.GetBanStatus(context.Background(), "peer-one-0123456789")
+	assert.NoError(t, err)
+	assert.True(t, status.Banned)
+	status, err = bans.GetBanStatus(context.Background(), "203.0.113.2")
+	assert.NoError(t, err)
+	assert.False(t, status.Banned)
+}
+
+func TestRateLimiter_Classes(t *testing.T) {
+	cfg := &config.AppConfig{RateLimit: config.RateLimitConfig{
+		Enabled: true, RequestsPerMinute: 60, Burst: 2,
+		Classes: []config.RateLimitClassConfig{
+			{Name: "allocation", Routes: []string{"/allocate-ip"}, RequestsPerMinute: 1, Burst: 1},
+			{Name: "nonces", Routes: []string{"/request-auth"}, Multiplier: 2},
+		},
+	}}
+	rl := middleware.NewRateLimiter(cfg, nil, zap.NewNop())
+	defer rl.Stop()
+
+	r := chi.NewRouter()
+	r.Use(rl.Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r.Post("/allocate-ip", ok)
+	r.Post("/request-auth", ok)
+	r.Get("/health", ok)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := serve(http.MethodPost, "/allocate-ip")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "allocation", w.Header().Get("X-RateLimit-Class"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodPost, "/allocate-ip").Code)
+
+	// Other classes and routes without a class have buckets of their own
+	for range 4 {
+		w = serve(http.MethodPost, "/request-auth")
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, "120", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodPost, "/request-auth").Code)
+
+	w = serve(http.MethodGet, "/health")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "60", w.Header().Get("X-RateLimit-Limit"))
+	assert.Empty(t, w.Header().Get("X-RateLimit-Class"))
+}
+
+func TestRateLimiter_RetryAfterIsHonored(t *testing.T) {
+	rl := middleware.NewRateLimiter(&config.AppConfig{RateLimit: config.RateLimitConfig{Enabled: true, RequestsPerMinute: 6000, Burst: 1}}, nil, zap.NewNop())
+	defer rl.Stop()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	allowed, _, _ := rl.Allow(req)
+	assert.True(t, allowed)
+
+	// Refused requests do not take the next token, so waiting retryAfter is enough
+	var retryAfter time.Duration
+	for range 3 {
+		allowed, retryAfter, _ = rl.Allow(req)
+		assert.False(t, allowed)
+		assert.LessOrEqual(t, retryAfter, 10*time.Millisecond)
+	}
+	time.Sleep(retryAfter)
+	allowed, _, _ = rl.Allow(req)
+	assert.True(t, allowed)
+
+	w := httptest.NewRecorder()
+	rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"), "Retry-After is rounded up to a whole second")
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/validation"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestWithRequestBody(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   *validation.RequestBody
+	}{
+		{"JSON body", "application/json", `{"token_id": 167772161, "affinity_group": "rack-1"}`, http.StatusOK, &validation.RequestBody{TokenID: 167772161, AffinityGroup: "rack-1"}},
+		{"JSON body with charset", "application/json; charset=utf-8", `{"nonce": "n"}`, http.StatusOK, &validation.RequestBody{Nonce: "n"}},
+		{"empty JSON body", "application/json", "", http.StatusOK, &validation.RequestBody{}},
+		{"other content type is ignored", "text/plain", `{"unknown": true}`, http.StatusOK, &validation.RequestBody{}},
+		{"unknown field", "application/json", `{"token_id": 1, "tokenID": 1}`, http.StatusBadRequest, nil},
+		{"wrong type", "application/json", `{"token_id": "1"}`, http.StatusBadRequest, nil},
+		{"trailing data", "application/json", `{"token_id": 1} {}`, http.StatusBadRequest, nil},
+		{"malformed JSON", "application/json", `{"token_id": `, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded *validation.RequestBody
+			handler := middleware.WithRequestBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				decoded = validation.RequestBodyFromContext(r)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/allocate-ip", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedBody, decoded)
+			} else {
+				assert.Contains(t, w.Body.String(), "INVALID_REQUEST")
+			}
+		})
+	}
+}
+
+func TestWithAuth_JSONBody(t *testing.T) {
+	pubkey := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	signature := base64.StdEncoding.EncodeToString(make([]byte, 64))
+	nonce := "12345678-1234-1234-1234-123456789012"
+
+	serve := func(t *testing.T, authService *mocks.MockAuthService, body string, headers map[string]string) *httptest.ResponseRecorder {
+		accessControl := mocks.NewMockAccessControlService(gomock.NewController(t))
+		accessControl.EXPECT().CheckAccess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+		handler := middleware.WithRequestBody(middleware.WithAuth(authService, accessControl)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peerID, _ := reqctx.PeerID(r.Context())
+			assert.Equal(t, "peer123", peerID)
+			w.WriteHeader(http.StatusOK)
+		})))
+
+		req := httptest.NewRequest(http.MethodPost, "/allocate-ip", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("credentials in the body", func(t *testing.T) {
+		authService := mocks.NewMockAuthService(gomock.NewController(t))
+		authService.EXPECT().VerifyAuth(gomock.Any(), &models.AuthVerifyRequest{
+			Pubkey:    make([]byte, 32),
+			NonceID:   nonce,
+			Signature: make([]byte, 64),
+			Timestamp: "2026-10-15T10:00:00Z",
+		}).Return(&models.AuthVerifyResponse{Pubkey: make([]byte, 32), PeerID: "peer123"}, nil)
+
+		body := `{"pubkey": "` + pubkey + `", "nonce": "` + nonce + `", "signature": "` + signature + `", "timestamp": "2026-10-15T10:00:00Z"}`
+		assert.Equal(t, http.StatusOK, serve(t, authService, body, nil).Code)
+	})
+
+	t.Run("headers fill in fields the body leaves out", func(t *testing.T) {
+		authService := mocks.NewMockAuthService(gomock.NewController(t))
+		authService.EXPECT().VerifyAuth(gomock.Any(), gomock.Any()).Return(&models.AuthVerifyResponse{Pubkey: make([]byte, 32), PeerID: "peer123"}, nil)
+
+		body := `{"pubkey": "` + pubkey + `", "nonce": "` + nonce + `"}`
+		assert.Equal(t, http.StatusOK, serve(t, authService, body, map[string]string{"X-Signature": signature}).Code)
+	})
+
+	t.Run("body fields are validated like headers", func(t *testing.T) {
+		authService := mocks.NewMockAuthService(gomock.NewController(t))
+
+		w := serve(t, authService, `{"pubkey": "`+pubkey+`", "nonce": "not-a-uuid", "signature": "`+signature+`"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_NONCE")
+
+		w = serve(t, authService, `{"pubkey": "`+pubkey+`", "nonce": "`+nonce+`"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+)
+
+func TestWithRequestContext(t *testing.T) {
+	clientIP := func(r *http.Request) string { return "203.0.113.7" }
+
+	tests := []struct {
+		name      string
+		requestID string
+		kept      bool
+	}{
+		{name: "request ID of the client", requestID: "abc-123", kept: true},
+		{name: "no request ID", requestID: ""},
+		{name: "request ID with spaces", requestID: "abc 123"},
+		{name: "request ID too long", requestID: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen reqctx.RequestContext
+			handler := middleware.WithRequestContext(clientIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = reqctx.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			if tt.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.requestID)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.NotEmpty(t, seen.RequestID)
+			assert.Equal(t, seen.RequestID, rr.Header().Get(middleware.RequestIDHeader))
+			assert.Equal(t, tt.kept, seen.RequestID == tt.requestID)
+			assert.Equal(t, "203.0.113.7", seen.ClientIP)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+func TestRequestStats(t *testing.T) {
+	stats := middleware.NewRequestStats(&config.AppConfig{HealthErrorWindow: 60})
+
+	var inFlight int64
+	handler := stats.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight = stats.InFlight()
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	assert.Equal(t, 0.0, stats.ErrorRate(), "no requests means no errors")
+
+	for _, path := range []string{"/ok", "/ok", "/ok", "/fail"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, int64(1), inFlight, "the request being served is counted")
+	assert.Equal(t, int64(0), stats.InFlight())
+	assert.InDelta(t, 0.25, stats.ErrorRate(), 0.0001)
+}
+
+func TestRequestStats_Panic(t *testing.T) {
+	stats := middleware.NewRequestStats(&config.AppConfig{})
+
+	handler := stats.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	assert.Panics(t, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, int64(0), stats.InFlight())
+	assert.Equal(t, 1.0, stats.ErrorRate())
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func newPoolStatsReport(pools ...*models.PoolStats) *models.PoolStatsReport {
+	return &models.PoolStatsReport{Pools: pools, ComputedAt: time.Now().UTC().Truncate(time.Second)}
+}
+
+func TestPoolStatsHandler_PoolStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	report := newPoolStatsReport(&models.PoolStats{Tenant: models.DefaultTenantID, Total: 100, Allocated: 40, Reserved: 2, ExpiredReclaimable: 8, Free: 50, AllocationsPerHour: 12, Utilization: 0.42})
+	poolStats := mocks.NewMockPoolStatsService(ctrl)
+	poolStats.EXPECT().PoolStats(gomock.Any()).Return(report, nil)
+
+	w := httptest.NewRecorder()
+	handlers.NewPoolStatsHandler(poolStats, nil, nil, nil).PoolStats(w, httptest.NewRequest(http.MethodGet, "/v1/admin/pool-stats", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data models.PoolStatsReport `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, report.Pools, resp.Data.Pools)
+	assert.True(t, report.ComputedAt.Equal(resp.Data.ComputedAt))
+}
+
+func TestPoolStatsHandler_Metrics(t *testing.T) {
+	t.Run("exports every pool as gauges", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		poolStats := mocks.NewMockPoolStatsService(ctrl)
+		gomock.InOrder(
+			poolStats.EXPECT().PoolStats(gomock.Any()).Return(newPoolStatsReport(
+				&models.PoolStats{Tenant: models.DefaultTenantID, Total: 100, Allocated: 40, Reserved: 2, Grace: 3, ExpiredReclaimable: 5, Free: 50, AllocationsPerHour: 12, Utilization: 0.45},
+				&models.PoolStats{Tenant: "acme", Total: 10, Free: 10},
+			), nil),
+			poolStats.EXPECT().PoolStats(gomock.Any()).Return(newPoolStatsReport(
+				&models.PoolStats{Tenant: models.DefaultTenantID, Total: 100, Allocated: 41, Reserved: 2, ExpiredReclaimable: 7, Free: 50, AllocationsPerHour: 13, Utilization: 0.43},
+			), nil),
+		)
+		handler := handlers.NewPoolStatsHandler(poolStats, nil, nil, nil)
+
+		w := httptest.NewRecorder()
+		handler.Metrics(w, httptest.NewRequest(http.MethodGet, "/v1/admin/metrics", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, `dhcp2p_pool_tokens{state="allocated",tenant="default"} 40`)
+		assert.Contains(t, body, `dhcp2p_pool_tokens{state="reserved",tenant="default"} 2`)
+		assert.Contains(t, body, `dhcp2p_pool_tokens{state="grace",tenant="default"} 3`)
+		assert.Contains(t, body, `dhcp2p_pool_tokens{state="expired_reclaimable",tenant="default"} 5`)
+		assert.Contains(t, body, `dhcp2p_pool_tokens{state="free",tenant="default"} 50`)
+		assert.Contains(t, body, `dhcp2p_pool_size{tenant="default"} 100`)
+		assert.Contains(t, body, `dhcp2p_pool_allocations_last_hour{tenant="default"} 12`)
+		assert.Contains(t, body, `dhcp2p_pool_utilization_ratio{tenant="default"} 0.45`)
+		assert.Contains(t, body, `dhcp2p_pool_tokens{state="free",tenant="acme"} 10`)
+
+		w = httptest.NewRecorder()
+		handler.Metrics(w, httptest.NewRequest(http.MethodGet, "/v1/admin/metrics", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `dhcp2p_pool_tokens{state="allocated",tenant="default"} 41`)
+		assert.NotContains(t, w.Body.String(), `tenant="acme"`, "pools no longer reported are dropped")
+	})
+
+	t.Run("reports errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		poolStats := mocks.NewMockPoolStatsService(ctrl)
+		poolStats.EXPECT().PoolStats(gomock.Any()).Return(nil, errors.ErrDatabaseConnection)
+
+		w := httptest.NewRecorder()
+		handlers.NewPoolStatsHandler(poolStats, nil, nil, nil).Metrics(w, httptest.NewRequest(http.MethodGet, "/v1/admin/metrics", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "DATABASE_CONNECTION_FAILED")
+	})
+}
+
+func TestPoolStatsHandler_ConnectionPools(t *testing.T) {
+	t.Run("reports both pools", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		database := mocks.NewMockDatabasePoolStatsReporter(ctrl)
+		database.EXPECT().DatabasePoolStats().Return(&models.DatabasePoolStats{MaxConns: 25, TotalConns: 10, AcquiredConns: 7, IdleConns: 3})
+		cache := mocks.NewMockCachePoolStatsReporter(ctrl)
+		cache.EXPECT().CachePoolStats().Return(&models.CachePoolStats{TotalConns: 8, IdleConns: 5, Timeouts: 2})
+
+		w := httptest.NewRecorder()
+		handlers.NewPoolStatsHandler(nil, nil, database, cache).ConnectionPools(w, httptest.NewRequest(http.MethodGet, "/v1/admin/pools", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data models.ConnectionPoolStats `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, int32(7), resp.Data.Database.AcquiredConns)
+		assert.Equal(t, uint32(2), resp.Data.Cache.Timeouts)
+		assert.False(t, resp.Data.SampledAt.IsZero())
+	})
+
+	t.Run("omits pools the backend does not have", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.NewPoolStatsHandler(nil, nil, nil, nil).ConnectionPools(w, httptest.NewRequest(http.MethodGet, "/v1/admin/pools", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), `"database"`)
+		assert.NotContains(t, w.Body.String(), `"cache"`)
+	})
+}
+
+func TestPoolStatsHandler_ConnectionPoolMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	poolStats := mocks.NewMockPoolStatsService(ctrl)
+	poolStats.EXPECT().PoolStats(gomock.Any()).Return(newPoolStatsReport(), nil)
+	database := mocks.NewMockDatabasePoolStatsReporter(ctrl)
+	database.EXPECT().DatabasePoolStats().Return(&models.DatabasePoolStats{MaxConns: 25, AcquiredConns: 7, IdleConns: 3, AcquireCount: 1200, AcquireDurationMs: 2500})
+	cache := mocks.NewMockCachePoolStatsReporter(ctrl)
+	cache.EXPECT().CachePoolStats().Return(&models.CachePoolStats{TotalConns: 8, Hits: 120, Timeouts: 2, WaitDurationMs: 40})
+
+	w := httptest.NewRecorder()
+	handlers.NewPoolStatsHandler(poolStats, nil, database, cache).Metrics(w, httptest.NewRequest(http.MethodGet, "/v1/admin/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "dhcp2p_db_pool_max_conns 25")
+	assert.Contains(t, body, "dhcp2p_db_pool_acquired_conns 7")
+	assert.Contains(t, body, "dhcp2p_db_pool_idle_conns 3")
+	assert.Contains(t, body, "dhcp2p_db_pool_acquires_total 1200")
+	assert.Contains(t, body, "dhcp2p_db_pool_acquire_wait_seconds_total 2.5")
+	assert.Contains(t, body, "dhcp2p_redis_pool_total_conns 8")
+	assert.Contains(t, body, "dhcp2p_redis_pool_hits_total 120")
+	assert.Contains(t, body, "dhcp2p_redis_pool_timeouts_total 2")
+	assert.Contains(t, body, "dhcp2p_redis_pool_wait_seconds_total 0.04")
+}
+
+func TestPoolStatsHandler_PoolRanges(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.PoolMinTokenID, cfg.PoolMaxTokenID = 167772161, 167772414 // 10.0.0.1 to 10.0.0.254
+	cfg.PoolExclusions = []config.TokenRangeConfig{{FirstTokenID: 167772161, LastTokenID: 167772170, Reason: "routers"}}
+	cfg.PoolZones = []config.ZoneRangeConfig{{Zone: "rack-2", FirstTokenID: 167772301, LastTokenID: 167772414}, {Zone: "rack-1", FirstTokenID: 167772171, LastTokenID: 167772300}}
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 167772929, PoolMaxTokenID: 167773182}}
+	tenants, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handlers.NewPoolStatsHandler(nil, tenants, nil, nil).PoolRanges(w, httptest.NewRequest(http.MethodGet, "/v1/admin/pool-ranges", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data handlers.PoolRangesResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Data.Pools, 2)
+
+	pool := resp.Data.Pools[0]
+	assert.Equal(t, models.DefaultTenantID, pool.Tenant)
+	assert.Equal(t, int64(254), pool.Pool.Size)
+	assert.Equal(t, []*handlers.TokenRangeInfo{{FirstTokenID: 167772161, LastTokenID: 167772170, FirstIP: "10.0.0.1", LastIP: "10.0.0.10", Size: 10, Reason: "routers"}}, pool.Exclusions)
+	assert.Equal(t, []*handlers.TokenRangeInfo{{FirstTokenID: 167772171, LastTokenID: 167772414, FirstIP: "10.0.0.11", LastIP: "10.0.0.254", Size: 244}}, pool.Allocatable)
+	assert.Equal(t, int64(244), pool.AllocatableSize)
+	assert.Equal(t, []*handlers.TokenRangeInfo{
+		{FirstTokenID: 167772171, LastTokenID: 167772300, FirstIP: "10.0.0.11", LastIP: "10.0.0.140", Size: 130, Zone: "rack-1"},
+		{FirstTokenID: 167772301, LastTokenID: 167772414, FirstIP: "10.0.0.141", LastIP: "10.0.0.254", Size: 114, Zone: "rack-2"},
+	}, pool.Zones)
+
+	pool = resp.Data.Pools[1]
+	assert.Equal(t, "acme", pool.Tenant)
+	assert.Empty(t, pool.Exclusions)
+	assert.Empty(t, pool.Zones)
+	assert.Equal(t, int64(254), pool.AllocatableSize)
+}
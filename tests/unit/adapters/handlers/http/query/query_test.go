@@ -0,0 +1,133 @@
+package query
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/query"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+var spec = &query.Spec{
+	Fields: map[string]query.Field{
+		"token_id":   {Type: query.Int, Sortable: true, Operators: query.OrderedOperators},
+		"peer_id":    {Type: query.String, Operators: query.TextOperators},
+		"expires_at": {Type: query.Time, Sortable: true, Operators: query.OrderedOperators},
+		"note":       {Type: query.String},
+	},
+	DefaultSort:  "token_id",
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+func parse(t *testing.T, rawQuery string) (*models.ListOptions, error) {
+	t.Helper()
+	values, err := url.ParseQuery(rawQuery)
+	require.NoError(t, err)
+	return spec.Parse(values)
+}
+
+func TestSpec_Parse_Defaults(t *testing.T) {
+	opts, err := parse(t, "")
+	require.NoError(t, err)
+	assert.Equal(t, &models.ListOptions{Limit: 20, SortField: "token_id"}, opts)
+}
+
+func TestSpec_Parse_Pagination(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedLimit int
+		expectedSkip  int
+		expectedError error
+	}{
+		{name: "limit and offset", query: "limit=100&offset=40", expectedLimit: 100, expectedSkip: 40},
+		{name: "zero limit", query: "limit=0", expectedError: errors.ErrInvalidPagination},
+		{name: "limit above maximum", query: "limit=101", expectedError: errors.ErrInvalidPagination},
+		{name: "non-numeric limit", query: "limit=ten", expectedError: errors.ErrInvalidPagination},
+		{name: "negative offset", query: "offset=-1", expectedError: errors.ErrInvalidPagination},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parse(t, tt.query)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLimit, opts.Limit)
+			assert.Equal(t, tt.expectedSkip, opts.Offset)
+		})
+	}
+}
+
+func TestSpec_Parse_Sort(t *testing.T) {
+	opts, err := parse(t, "sort=-expires_at")
+	require.NoError(t, err)
+	assert.Equal(t, "expires_at", opts.SortField)
+	assert.True(t, opts.Descending)
+
+	_, err = parse(t, "sort=peer_id")
+	assert.ErrorIs(t, err, errors.ErrInvalidSort, "field is not sortable")
+
+	_, err = parse(t, "sort=unknown")
+	assert.ErrorIs(t, err, errors.ErrInvalidSort)
+}
+
+func TestSpec_Parse_Filters(t *testing.T) {
+	opts, err := parse(t, "peer_id=12D3KooW&token_id[gte]=10&token_id[lt]=20&expires_at[gt]=2024-01-15T10:30:00Z")
+	require.NoError(t, err)
+
+	require.Len(t, opts.Filters, 4)
+	assert.Equal(t, models.Filter{Field: "expires_at", Operator: models.FilterGt, Value: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)}, opts.Filters[0])
+	assert.Equal(t, models.Filter{Field: "peer_id", Operator: models.FilterEq, Value: "12D3KooW"}, opts.Filters[1])
+	assert.Contains(t, opts.Filters, models.Filter{Field: "token_id", Operator: models.FilterGte, Value: int64(10)})
+	assert.Contains(t, opts.Filters, models.Filter{Field: "token_id", Operator: models.FilterLt, Value: int64(20)})
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "unknown field", query: "owner=abc"},
+		{name: "field without operators", query: "note=abc"},
+		{name: "unsupported operator", query: "peer_id[gt]=abc"},
+		{name: "unknown operator", query: "token_id[between]=1"},
+		{name: "invalid integer", query: "token_id=abc"},
+		{name: "invalid time", query: "expires_at[lt]=yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(t, tt.query)
+			assert.ErrorIs(t, err, errors.ErrInvalidFilter)
+		})
+	}
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 15, 10, 30, 0, 123000, time.UTC)
+	opts, err := parse(t, "sort=-expires_at")
+	require.NoError(t, err)
+
+	cursor := query.EncodeCursor(opts, expiresAt, 167902210)
+
+	next, err := parse(t, "sort=-expires_at&cursor="+cursor)
+	require.NoError(t, err)
+	require.NotNil(t, next.After)
+	assert.Equal(t, expiresAt, next.After.SortValue)
+	assert.Equal(t, int64(167902210), next.After.Key)
+
+	_, err = parse(t, "sort=expires_at&cursor="+cursor)
+	assert.ErrorIs(t, err, errors.ErrInvalidPagination, "cursor of another sort order")
+
+	_, err = parse(t, "sort=-expires_at&offset=10&cursor="+cursor)
+	assert.ErrorIs(t, err, errors.ErrInvalidPagination, "offset and cursor")
+
+	_, err = parse(t, "cursor=not-a-cursor")
+	assert.ErrorIs(t, err, errors.ErrInvalidPagination)
+}
@@ -0,0 +1,375 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	httpMiddleware "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/middleware"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/events"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/pb"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestRouter(ctrl *gomock.Controller, cfg *config.AppConfig) (*handlers.Router, *mocks.MockLeaseService) {
+	return newTestRouterWithAPIKeys(ctrl, cfg, newTestAPIKeyService())
+}
+
+func newTestAPIKeyService() *services.APIKeyService {
+	return services.NewAPIKeyService(embedded.NewAPIKeyRepository(embedded.NewMemoryStore(clock.NewSystem())), zap.NewNop())
+}
+
+func newTestRouterWithAPIKeys(ctrl *gomock.Controller, cfg *config.AppConfig, apiKeys ports.APIKeyService) (*handlers.Router, *mocks.MockLeaseService) {
+	leaseService := mocks.NewMockLeaseService(ctrl)
+	authService := mocks.NewMockAuthService(ctrl)
+	accessControl := mocks.NewMockAccessControlService(ctrl)
+	accessControl.EXPECT().CheckAccess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	stats := httpMiddleware.NewRequestStats(cfg)
+	signer := mocks.NewMockLeaseSigner(ctrl)
+	signer.EXPECT().Keys().Return(nil).AnyTimes()
+	tenants, _ := services.NewTenantService(cfg)
+	serverInfo, _ := handlers.NewServerInfoHandler(signer, tenants, cfg, "", nil, nil)
+
+	router := handlers.NewHTTPRouter(
+		zap.NewNop(),
+		handlers.NewAuthHandler(authService, nil),
+		handlers.NewLeaseHandler(leaseService, leaseService),
+		handlers.NewLeaseWaitHandler(leaseService, services.NewLeaseWatchService(events.NewBus(cfg, nil, zap.NewNop()), zap.NewNop()), clock.NewSystem(), cfg),
+		handlers.NewDelegationHandler(nil),
+		handlers.NewHealthHandler(nil, nil, cfg, nil),
+		handlers.NewHealthScoreHandler(nil, nil, stats, cfg),
+		serverInfo,
+		stats,
+		httpMiddleware.NewRequestLimits(cfg),
+		httpMiddleware.NewRateLimiter(cfg, nil, zap.NewNop()),
+		httpMiddleware.NewLoadShedder(cfg),
+		httpMiddleware.NewIdempotency(cfg, memory.NewIdempotencyStore(clock.NewSystem()), zap.NewNop()),
+		httpMiddleware.NewPayloadLog(cfg, zap.NewNop()),
+		handlers.NewAdminHandler(nil, nil, nil, nil, nil, nil, cfg),
+		handlers.NewAccessHandler(accessControl),
+		handlers.NewPeerPolicyHandler(nil),
+		handlers.NewFaultHandler(nil),
+		handlers.NewBanHandler(nil),
+		handlers.NewBatchHandler(authService, accessControl, leaseService, cfg),
+		handlers.NewLeaseQueryHandler(nil),
+		handlers.NewDashboardHandler(services.NewDashboardService(cfg, nil, tenants), httpMiddleware.NewRateLimiter(cfg, nil, zap.NewNop()), httpMiddleware.NewLoadShedder(cfg), nil),
+		handlers.NewDiagnosticsHandler(nil, nil),
+		handlers.NewPoolStatsHandler(nil, tenants, nil, nil),
+		handlers.NewSnapshotHandler(nil),
+		handlers.NewLeaseRevocationHandler(nil),
+		tenants,
+		apiKeys,
+		cfg,
+	)
+	return router, leaseService
+}
+
+func TestRouter_LookupStrictMode(t *testing.T) {
+	paths := []string{"/lease/token-id/167902210", "/lease/peer-id/12D3KooWExamplePeerID"}
+
+	t.Run("lookups are public by default", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		router, leaseService := newTestRouter(ctrl, config.NewDefaultAppConfig())
+		leaseService.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167902210)).Return(&models.Lease{TokenID: 167902210}, nil)
+		leaseService.EXPECT().GetLeaseByPeerID(gomock.Any(), "12D3KooWExamplePeerID").Return(&models.Lease{TokenID: 167902210}, nil)
+
+		for _, path := range paths {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusOK, w.Code, path)
+		}
+	})
+
+	t.Run("strict mode rejects unauthenticated lookups", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cfg := config.NewDefaultAppConfig()
+		cfg.Security.LookupAuthRequired = true
+		router, _ := newTestRouter(ctrl, cfg)
+
+		for _, path := range paths {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+			assert.Contains(t, w.Body.String(), "AUTHENTICATION_REQUIRED", path)
+		}
+	})
+}
+
+func TestRouter_Tenants(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}}
+
+	t.Run("requests are served for the tenant of the API key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		router, leaseService := newTestRouter(ctrl, cfg)
+		leaseService.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(168200001)).DoAndReturn(
+			func(ctx context.Context, tokenID int64) (*models.Lease, error) {
+				assert.Equal(t, "acme", models.TenantFromContext(ctx))
+				return &models.Lease{TokenID: tokenID}, nil
+			})
+
+		req := httptest.NewRequest(http.MethodGet, "/lease/token-id/168200001", nil)
+		req.Header.Set(httpMiddleware.APIKeyHeader, "acme-key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unknown API keys are refused", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		router, _ := newTestRouter(ctrl, cfg)
+
+		req := httptest.NewRequest(http.MethodGet, "/lease/token-id/168200001", nil)
+		req.Header.Set(httpMiddleware.APIKeyHeader, "unknown")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_API_KEY")
+	})
+}
+
+func TestRouter_Dashboard(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.AdminEnabled = true
+	cfg.AdminToken = "admin-token"
+	cfg.DashboardEnabled = true
+
+	ctrl := gomock.NewController(t)
+	router, _ := newTestRouter(ctrl, cfg)
+
+	t.Run("page is served without the admin token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dashboard/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, w.Body.String(), "dashboard.js")
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dashboard/dashboard.js", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("endpoints require the admin token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/admin/dashboard/top-peers", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/dashboard/top-peers", nil)
+		req.Header.Set("Authorization", "Bearer admin-token")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[]}`, w.Body.String())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		router, _ := newTestRouter(gomock.NewController(t), config.NewDefaultAppConfig())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dashboard/", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRouter_AdminAPIKeys(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.AdminEnabled = true
+	cfg.AdminToken = "admin-token"
+	cfg.AdminAPIKeysEnabled = true
+	cfg.DashboardEnabled = true
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}}
+
+	ctx := context.Background()
+	apiKeys := newTestAPIKeyService()
+	full, err := apiKeys.Create(ctx, "ops", []models.APIKeyScope{models.APIKeyScopeAdminFull})
+	assert.NoError(t, err)
+	reader, err := apiKeys.Create(ctx, "reporting", []models.APIKeyScope{models.APIKeyScopeLeasesRead})
+	assert.NoError(t, err)
+	revoked, err := apiKeys.Create(ctx, "former", []models.APIKeyScope{models.APIKeyScopeAdminFull})
+	assert.NoError(t, err)
+	_, err = apiKeys.Revoke(ctx, revoked.ID)
+	assert.NoError(t, err)
+
+	router, _ := newTestRouterWithAPIKeys(gomock.NewController(t), cfg, apiKeys)
+	get := func(path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(httpMiddleware.APIKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, get("/v1/admin/dashboard/top-peers", full.Key).Code)
+
+	w := get("/v1/admin/dashboard/top-peers", reader.Key)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "API_KEY_SCOPE")
+
+	w = get("/v1/admin/dashboard/top-peers", revoked.Key)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "ADMIN_UNAUTHORIZED")
+
+	assert.Equal(t, http.StatusUnauthorized, get("/v1/admin/dashboard/top-peers", models.AdminAPIKeyPrefix+"unknown").Code)
+
+	// Tenant keys are no admin credentials
+	assert.Equal(t, http.StatusUnauthorized, get("/v1/admin/dashboard/top-peers", "acme-key").Code)
+
+	t.Run("keys are ignored unless enabled", func(t *testing.T) {
+		cfg := *cfg
+		cfg.AdminAPIKeysEnabled = false
+		router, _ := newTestRouterWithAPIKeys(gomock.NewController(t), &cfg, apiKeys)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/dashboard/top-peers", nil)
+		req.Header.Set(httpMiddleware.APIKeyHeader, full.Key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestRouter_Diagnostics(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.AdminEnabled = true
+	cfg.AdminToken = "admin-token"
+	cfg.DiagnosticsEnabled = true
+
+	router, _ := newTestRouter(gomock.NewController(t), cfg)
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("/v1/admin/debug/pprof/heap", "").Code)
+	assert.Equal(t, http.StatusOK, get("/v1/admin/debug/pprof/", "admin-token").Code)
+	assert.Equal(t, http.StatusOK, get("/v1/admin/debug/pprof/heap", "admin-token").Code)
+	assert.Equal(t, http.StatusNotFound, get("/v1/admin/debug/pprof/unknown", "admin-token").Code)
+	assert.Equal(t, http.StatusOK, get("/v1/admin/debug/runtime", "admin-token").Code)
+	assert.Contains(t, get("/v1/admin/debug/vars", "admin-token").Body.String(), "memstats")
+
+	t.Run("disabled by default", func(t *testing.T) {
+		cfg := config.NewDefaultAppConfig()
+		cfg.AdminEnabled = true
+		cfg.AdminToken = "admin-token"
+		router, _ := newTestRouter(gomock.NewController(t), cfg)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/debug/pprof/heap", nil)
+		req.Header.Set("Authorization", "Bearer admin-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRouter_AllowedNetworks(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.AdminEnabled = true
+	cfg.AdminToken = "admin-token"
+	cfg.AdminAllowedCIDRs = []string{"10.0.0.0/8"}
+	cfg.DiagnosticsEnabled = true
+	cfg.DiagnosticsAllowedCIDRs = []string{"10.1.0.0/16"}
+	cfg.RateLimit.TrustedProxies = []string{"127.0.0.1"}
+
+	router, _ := newTestRouter(gomock.NewController(t), cfg)
+	get := func(path, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer admin-token")
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/v1/admin/debug/runtime", "192.0.2.1:1234", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "NETWORK_NOT_ALLOWED")
+
+	// Admin networks that are not diagnostics networks only reach the other admin routes
+	assert.Equal(t, http.StatusForbidden, get("/v1/admin/debug/runtime", "10.2.0.1:1234", "").Code)
+	assert.NotEqual(t, http.StatusForbidden, get("/v1/admin/dashboard/top-peers", "10.2.0.1:1234", "").Code)
+	assert.Equal(t, http.StatusOK, get("/v1/admin/debug/runtime", "10.1.0.1:1234", "").Code)
+
+	// Behind a trusted proxy the forwarded client address counts
+	assert.Equal(t, http.StatusOK, get("/v1/admin/debug/runtime", "127.0.0.1:1234", "10.1.0.1").Code)
+	assert.Equal(t, http.StatusForbidden, get("/v1/admin/debug/runtime", "127.0.0.1:1234", "192.0.2.1").Code)
+
+	// Unrestricted routes are not affected
+	assert.Equal(t, http.StatusOK, get("/health", "192.0.2.1:1234", "").Code)
+}
+
+func TestRouter_JSONBody(t *testing.T) {
+	router, _ := newTestRouter(gomock.NewController(t), config.NewDefaultAppConfig())
+
+	for _, path := range []string{"/request-auth", "/allocate-ip", "/renew-lease", "/release-lease"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"tokenID": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		assert.Contains(t, w.Body.String(), "INVALID_REQUEST", path)
+	}
+
+	// Routes without JSON body support keep reading their headers
+	req := httptest.NewRequest(http.MethodPost, "/v1/lease/conflict?tokenID=1", strings.NewReader(`{"tokenID": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NotContains(t, w.Body.String(), "INVALID_REQUEST")
+}
+
+func TestRouter_ResponseEncoding(t *testing.T) {
+	router, leaseService := newTestRouter(gomock.NewController(t), config.NewDefaultAppConfig())
+	leaseService.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167902210)).Return(&models.Lease{TokenID: 167902210}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/lease/token-id/167902210", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	var body pb.Response
+	assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(167902210), body.GetLease().GetTokenId())
+
+	// Errors raised before the handler are encoded too
+	req = httptest.NewRequest(http.MethodGet, "/lease/token-id/167902210", nil)
+	req.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
+
+func TestRouter_LeaseWait(t *testing.T) {
+	router, leaseService := newTestRouter(gomock.NewController(t), config.NewDefaultAppConfig())
+	leaseService.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167902210)).Return(&models.Lease{TokenID: 167902210, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/lease/167902210/wait?timeout=10ms", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"changed":false`)
+}
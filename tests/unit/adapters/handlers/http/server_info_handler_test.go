@@ -0,0 +1,188 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func serverInfo(t *testing.T, handler *handlers.ServerInfoHandler, tenantID ...string) *handlers.ServerInfoResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/v1/server-info", nil)
+	if len(tenantID) > 0 {
+		req = req.WithContext(models.WithTenant(req.Context(), tenantID[0]))
+	}
+	w := httptest.NewRecorder()
+	handler.ServerInfo(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data *handlers.ServerInfoResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp.Data
+}
+
+func TestServerInfoHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.NewDefaultAppConfig()
+	signer := mocks.NewMockLeaseSigner(ctrl)
+	signer.EXPECT().Keys().Return(nil).AnyTimes()
+
+	t.Run("defaults", func(t *testing.T) {
+		handler, err := handlers.NewServerInfoHandler(signer, nil, cfg, "", nil, nil)
+		require.NoError(t, err)
+
+		info := serverInfo(t, handler)
+		assert.Equal(t, "dev", info.Version)
+		assert.False(t, info.LeaseSigning)
+		assert.Empty(t, info.PublicKey)
+		assert.Equal(t, []*handlers.ServerPoolInfo{{
+			MinTokenID: 167902210,
+			MaxTokenID: 168162304,
+			FirstIP:    "10.2.0.2",
+			LastIP:     "10.5.255.254",
+		}}, info.Pools)
+		assert.Equal(t, &handlers.LeaseTTLBounds{MinMinutes: 120, MaxMinutes: 120}, info.LeaseTTL)
+		assert.Equal(t, []string{handlers.AuthMethodNonceSignature}, info.Auth.Methods)
+		assert.Equal(t, config.IdentitySchemePeerID, info.Auth.IdentityScheme)
+		assert.Contains(t, info.Features, handlers.FeatureIdempotencyKeys)
+		assert.NotContains(t, info.Features, handlers.FeatureLeaseCertificates)
+		assert.Empty(t, info.LeaseProtocol)
+		assert.Nil(t, info.HA)
+	})
+
+	t.Run("lease signing", func(t *testing.T) {
+		key, _, err := crypto.GenerateEd25519Key(nil)
+		require.NoError(t, err)
+		pubkey, err := crypto.MarshalPublicKey(key.GetPublic())
+		require.NoError(t, err)
+
+		signing := mocks.NewMockLeaseSigner(ctrl)
+		signing.EXPECT().Keys().Return([]*models.SigningKey{
+			{KeyID: "0a1b2c3d4e5f6071", PublicKey: pubkey, PeerID: "12D3KooWServer", Current: true},
+			{KeyID: "8192a3b4c5d6e7f8", PublicKey: pubkey, PeerID: "12D3KooWRetired"},
+		}).Times(2)
+		handler, err := handlers.NewServerInfoHandler(signing, nil, cfg, "v1.4.0", nil, nil)
+		require.NoError(t, err)
+
+		info := serverInfo(t, handler)
+		assert.Equal(t, "v1.4.0", info.Version)
+		assert.True(t, info.LeaseSigning)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(pubkey), info.PublicKey)
+		assert.Equal(t, "12D3KooWServer", info.PeerID)
+		assert.Equal(t, "0a1b2c3d4e5f6071", info.KeyID)
+		assert.Contains(t, info.Features, handlers.FeatureLeaseCertificates)
+
+		w := httptest.NewRecorder()
+		handler.Keys(w, httptest.NewRequest(http.MethodGet, "/v1/server-info/keys", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data *handlers.ServerKeysResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, "0a1b2c3d4e5f6071", resp.Data.CurrentKeyID)
+		require.Len(t, resp.Data.Keys, 2)
+		assert.Equal(t, pubkey, resp.Data.Keys[1].PublicKey)
+		assert.False(t, resp.Data.Keys[1].Current)
+	})
+
+	t.Run("follows reloaded settings", func(t *testing.T) {
+		handler, err := handlers.NewServerInfoHandler(signer, nil, cfg, "", nil, nil)
+		require.NoError(t, err)
+
+		reloaded := *cfg
+		reloaded.Lease.TTL = 30
+		handler.ApplyConfig(&reloaded)
+
+		info := serverInfo(t, handler)
+		assert.Equal(t, &handlers.LeaseTTLBounds{MinMinutes: 30, MaxMinutes: 30}, info.LeaseTTL)
+	})
+
+	t.Run("tenant pool", func(t *testing.T) {
+		tenantCfg := *cfg
+		tenantCfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100,
+			PoolZones: []config.ZoneRangeConfig{{Zone: "fra1", FirstTokenID: 168200050, LastTokenID: 168200100}, {Zone: "ams1", FirstTokenID: 168200000, LastTokenID: 168200049}}}}
+		tenants, err := services.NewTenantService(&tenantCfg)
+		require.NoError(t, err)
+
+		handler, err := handlers.NewServerInfoHandler(signer, tenants, &tenantCfg, "", nil, nil)
+		require.NoError(t, err)
+
+		info := serverInfo(t, handler)
+		assert.Empty(t, info.Tenant)
+		assert.Contains(t, info.Features, handlers.FeatureTenants)
+		assert.Empty(t, info.Pools[0].Zones)
+
+		info = serverInfo(t, handler, "acme")
+		assert.Equal(t, "acme", info.Tenant)
+		require.Len(t, info.Pools, 1)
+		assert.Equal(t, int64(168200000), info.Pools[0].MinTokenID)
+		assert.Equal(t, int64(168200100), info.Pools[0].MaxTokenID)
+		assert.Equal(t, []string{"ams1", "fra1"}, info.Pools[0].Zones)
+	})
+
+	t.Run("active/standby role", func(t *testing.T) {
+		status := models.HAStatus{Role: models.HARoleStandby, Instance: "dhcp2p-b", Epoch: 3, ActiveInstance: "dhcp2p-a", MirroredDeltas: 12}
+
+		handler, err := handlers.NewServerInfoHandler(signer, nil, cfg, "", nil, fakeHAController{status: status})
+		require.NoError(t, err)
+
+		info := serverInfo(t, handler)
+		require.NotNil(t, info.HA)
+		assert.Equal(t, status, *info.HA)
+	})
+}
+
+func TestServerInfoHandler_RotateKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.NewDefaultAppConfig()
+	signer := mocks.NewMockLeaseSigner(ctrl)
+	handler, err := handlers.NewServerInfoHandler(signer, nil, cfg, "", nil, nil)
+	require.NoError(t, err)
+
+	t.Run("rotated", func(t *testing.T) {
+		signer.EXPECT().RotateKey().Return(&models.SigningKeyRotation{
+			Current:        &models.SigningKey{KeyID: "8192a3b4c5d6e7f8"},
+			Retired:        &models.SigningKey{KeyID: "0a1b2c3d4e5f6071"},
+			RetiredKeyPath: "/var/lib/dhcp2p/server.key.0a1b2c3d4e5f6071",
+		}, nil)
+
+		w := httptest.NewRecorder()
+		handler.RotateKey(w, httptest.NewRequest(http.MethodPost, "/v1/admin/server-keys/rotate", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data models.SigningKeyRotation `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, "8192a3b4c5d6e7f8", resp.Data.Current.KeyID)
+		assert.Equal(t, "/var/lib/dhcp2p/server.key.0a1b2c3d4e5f6071", resp.Data.RetiredKeyPath)
+	})
+
+	t.Run("signing disabled", func(t *testing.T) {
+		signer.EXPECT().RotateKey().Return(nil, domainErrors.ErrSigningDisabled)
+
+		w := httptest.NewRecorder()
+		handler.RotateKey(w, httptest.NewRequest(http.MethodPost, "/v1/admin/server-keys/rotate", nil))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestSnapshotHandler_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	snapshot := &models.LeaseSnapshot{
+		Version:    models.SnapshotVersion,
+		ExportedAt: time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC),
+		Pools:      []*models.SnapshotPool{{Tenant: models.DefaultTenantID, MinTokenID: 10, MaxTokenID: 20, LastTokenID: 10}},
+		Leases:     []*models.SnapshotLease{{TokenID: 10, PeerID: "peer-1", Tenant: models.DefaultTenantID}},
+	}
+	snapshots := mocks.NewMockLeaseSnapshotService(ctrl)
+	snapshots.EXPECT().Export(gomock.Any()).Return(snapshot, nil)
+
+	w := httptest.NewRecorder()
+	handlers.NewSnapshotHandler(snapshots).Export(w, httptest.NewRequest(http.MethodGet, "/v1/admin/export", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="dhcp2p-leases-20261015T123000Z.json"`, w.Header().Get("Content-Disposition"))
+
+	var got models.LeaseSnapshot
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got), "the snapshot is not wrapped in a data envelope")
+	assert.Equal(t, snapshot.Pools, got.Pools)
+	assert.Equal(t, snapshot.Leases[0].PeerID, got.Leases[0].PeerID)
+}
+
+func TestSnapshotHandler_Import(t *testing.T) {
+	body := `{"version": 1, "pools": [{"tenant": "default", "min_token_id": 10, "max_token_id": 20, "last_token_id": 10}], "leases": []}`
+
+	tests := []struct {
+		name       string
+		url        string
+		body       string
+		setupMock  func(m *mocks.MockLeaseSnapshotService)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "imports without replacing by default",
+			url:  "/v1/admin/import",
+			body: body,
+			setupMock: func(m *mocks.MockLeaseSnapshotService) {
+				m.EXPECT().Import(gomock.Any(), gomock.Any(), false).Return(&models.SnapshotImportReport{Pools: 1}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "replace is passed on",
+			url:  "/v1/admin/import?replace=true",
+			body: body,
+			setupMock: func(m *mocks.MockLeaseSnapshotService) {
+				m.EXPECT().Import(gomock.Any(), gomock.Any(), true).Return(&models.SnapshotImportReport{Pools: 1, Replaced: 2}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid replace flag",
+			url:        "/v1/admin/import?replace=maybe",
+			body:       body,
+			setupMock:  func(m *mocks.MockLeaseSnapshotService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_REQUEST",
+		},
+		{
+			name:       "malformed snapshot",
+			url:        "/v1/admin/import",
+			body:       `{"version": "one"}`,
+			setupMock:  func(m *mocks.MockLeaseSnapshotService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_SNAPSHOT",
+		},
+		{
+			name: "stored leases conflict",
+			url:  "/v1/admin/import",
+			body: body,
+			setupMock: func(m *mocks.MockLeaseSnapshotService) {
+				m.EXPECT().Import(gomock.Any(), gomock.Any(), false).Return(nil, errors.ErrStoreNotEmpty)
+			},
+			wantStatus: http.StatusConflict,
+			wantCode:   "STORE_NOT_EMPTY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			snapshots := mocks.NewMockLeaseSnapshotService(ctrl)
+			tt.setupMock(snapshots)
+
+			w := httptest.NewRecorder()
+			handlers.NewSnapshotHandler(snapshots).Import(w, httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body)))
+			require.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantCode != "" {
+				var resp struct {
+					Code string `json:"code"`
+				}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, tt.wantCode, resp.Code)
+			}
+		})
+	}
+}
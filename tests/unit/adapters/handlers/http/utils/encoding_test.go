@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAccept_Quality(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		mediaType string
+		expected  float64
+	}{
+		{"exact match", "application/cbor", utils.MediaTypeCBOR, 1},
+		{"not listed", "application/cbor", utils.MediaTypeJSON, 0},
+		{"wildcard", "*/*", utils.MediaTypeJSON, 1},
+		{"type wildcard", "application/*;q=0.5", utils.MediaTypeProtobuf, 0.5},
+		{"most specific range wins", "application/*;q=0.5, application/json;q=0", utils.MediaTypeJSON, 0},
+		{"quality parameter", "application/json;q=0.2, application/cbor", utils.MediaTypeJSON, 0.2},
+		{"malformed entries are skipped", "application/json;q=2, text, application/cbor", utils.MediaTypeJSON, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, utils.ParseAccept(tt.header).Quality(tt.mediaType))
+		})
+	}
+
+	t.Run("nil accepts everything", func(t *testing.T) {
+		var accept utils.Accept
+		assert.Equal(t, 1.0, accept.Quality(utils.MediaTypeCBOR))
+	})
+}
+
+func TestAccept_Negotiate(t *testing.T) {
+	offers := []string{utils.MediaTypeJSON, utils.MediaTypeCBOR, utils.MediaTypeProtobuf}
+
+	assert.Equal(t, utils.MediaTypeJSON, utils.ParseAccept("*/*").Negotiate(offers...))
+	assert.Equal(t, utils.MediaTypeCBOR, utils.ParseAccept("application/cbor, application/json;q=0.9").Negotiate(offers...))
+	assert.Equal(t, utils.MediaTypeProtobuf, utils.ParseAccept("application/x-protobuf").Negotiate(offers...))
+	assert.Equal(t, "", utils.ParseAccept("text/html").Negotiate(offers...))
+}
+
+func newAcceptRecorder(header string) (*httptest.ResponseRecorder, http.ResponseWriter) {
+	w := httptest.NewRecorder()
+	return w, utils.WithAccept(w, utils.ParseAccept(header))
+}
+
+func TestWriteSuccessResponse_Encodings(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	lease := &models.Lease{TokenID: 167902210, PeerID: "12D3KooWExamplePeerID", CreatedAt: createdAt, Ttl: 120}
+
+	t.Run("json by default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		utils.WriteSuccessResponse(w, lease)
+
+		assert.Equal(t, utils.MediaTypeJSON, w.Header().Get("Content-Type"))
+		var body map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, float64(167902210), body["data"]["token_id"])
+	})
+
+	t.Run("cbor", func(t *testing.T) {
+		w, ww := newAcceptRecorder("application/cbor")
+		utils.WriteSuccessResponse(ww, lease)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utils.MediaTypeCBOR, w.Header().Get("Content-Type"))
+		var body map[string]map[string]interface{}
+		require.NoError(t, cbor.Unmarshal(w.Body.Bytes(), &body))
+		assert.EqualValues(t, 167902210, body["data"]["token_id"])
+		assert.Equal(t, "2025-01-02T03:04:05Z", body["data"]["created_at"])
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		w, ww := newAcceptRecorder("application/x-protobuf")
+		utils.WriteSuccessResponse(ww, lease)
+
+		assert.Equal(t, utils.MediaTypeProtobuf, w.Header().Get("Content-Type"))
+		var body pb.Response
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, int64(167902210), body.GetLease().GetTokenId())
+		assert.Equal(t, "12D3KooWExamplePeerID", body.GetLease().GetPeerId())
+		assert.Equal(t, createdAt, body.GetLease().GetCreatedAt().AsTime())
+		assert.Nil(t, body.GetLease().GetRenewableAt())
+	})
+
+	t.Run("protobuf unavailable for the data", func(t *testing.T) {
+		w, ww := newAcceptRecorder("application/x-protobuf")
+		utils.WriteSuccessResponse(ww, map[string]string{"status": "ok"})
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Equal(t, utils.MediaTypeJSON, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "NOT_ACCEPTABLE")
+	})
+
+	t.Run("falls back to an acceptable encoding", func(t *testing.T) {
+		w, ww := newAcceptRecorder("application/x-protobuf, application/cbor;q=0.5")
+		utils.WriteSuccessResponse(ww, map[string]string{"status": "ok"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utils.MediaTypeCBOR, w.Header().Get("Content-Type"))
+	})
+}
+
+func TestWriteErrorResponse_Protobuf(t *testing.T) {
+	w, ww := newAcceptRecorder("application/x-protobuf")
+	utils.WriteDomainError(ww, errors.ErrLeaseNotFound)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var body pb.Response
+	require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "LEASE_NOT_FOUND", body.GetError().GetCode())
+	assert.Equal(t, string(errors.ErrorTypeNotFound), body.GetError().GetType())
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/utils"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+func TestLeaseETag(t *testing.T) {
+	updatedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	lease := &models.Lease{TokenID: 167772161, UpdatedAt: updatedAt, Ttl: 120}
+
+	etag := utils.LeaseETag(lease)
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+
+	// The TTL counting down leaves the tag unchanged
+	assert.Equal(t, etag, utils.LeaseETag(&models.Lease{TokenID: 167772161, UpdatedAt: updatedAt, Ttl: 60}))
+	assert.NotEqual(t, etag, utils.LeaseETag(&models.Lease{TokenID: 167772162, UpdatedAt: updatedAt}))
+	assert.NotEqual(t, etag, utils.LeaseETag(&models.Lease{TokenID: 167772161, UpdatedAt: updatedAt.Add(time.Millisecond)}))
+}
+
+func TestMatchesETag(t *testing.T) {
+	etag := `W/"abc"`
+
+	assert.True(t, utils.MatchesETag(`W/"abc"`, etag))
+	assert.True(t, utils.MatchesETag(`"abc"`, etag))
+	assert.True(t, utils.MatchesETag(`"xyz", W/"abc"`, etag))
+	assert.True(t, utils.MatchesETag(`*`, etag))
+	assert.False(t, utils.MatchesETag(`W/"xyz"`, etag))
+}
@@ -8,9 +8,9 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
-	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/keys"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http/validation"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
 )
 
 func TestValidateHeader(t *testing.T) {
@@ -230,31 +230,27 @@ func TestValidateQueryParam(t *testing.T) {
 func TestValidatePeerIDFromContext(t *testing.T) {
 	tests := []struct {
 		name          string
-		contextValue  interface{}
+		contextValue  string
+		setInContext  bool
 		expectedValue string
 		expectedError error
 	}{
 		{
 			name:          "valid peer ID in context",
 			contextValue:  "peer123",
+			setInContext:  true,
 			expectedValue: "peer123",
 			expectedError: nil,
 		},
 		{
-			name:          "nil context value",
-			contextValue:  nil,
+			name:          "no peer ID in context",
 			expectedValue: "",
 			expectedError: errors.ErrMissingPeerID,
 		},
 		{
 			name:          "empty peer ID",
 			contextValue:  "",
-			expectedValue: "",
-			expectedError: errors.ErrMissingPeerID,
-		},
-		{
-			name:          "non-string context value",
-			contextValue:  123,
+			setInContext:  true,
 			expectedValue: "",
 			expectedError: errors.ErrMissingPeerID,
 		},
@@ -263,8 +259,8 @@ func TestValidatePeerIDFromContext(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest("GET", "/test", nil)
-			if tt.contextValue != nil {
-				req = req.WithContext(context.WithValue(req.Context(), keys.PeerIDContextKey, tt.contextValue))
+			if tt.setInContext {
+				req = req.WithContext(reqctx.WithPeerID(req.Context(), tt.contextValue))
 			}
 
 			result := validation.ValidatePeerIDFromContext(req)
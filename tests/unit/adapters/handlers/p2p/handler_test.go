@@ -0,0 +1,408 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/ethereum"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/p2p"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+// fakeConn reports a fixed remote key, as the secure channel would
+type fakeConn struct {
+	network.Conn
+	key crypto.PubKey
+}
+
+func (c *fakeConn) RemotePeer() peer.ID {
+	id, _ := peer.IDFromPublicKey(c.key)
+	return id
+}
+
+func (c *fakeConn) RemotePublicKey() crypto.PubKey { return c.key }
+
+// fakeStream is the server end of an in-memory pipe
+type fakeStream struct {
+	network.Stream
+	pipe  net.Conn
+	conn  *fakeConn
+	reset bool
+}
+
+func (s *fakeStream) Conn() network.Conn                 { return s.conn }
+func (s *fakeStream) Read(p []byte) (int, error)         { return s.pipe.Read(p) }
+func (s *fakeStream) Write(p []byte) (int, error)        { return s.pipe.Write(p) }
+func (s *fakeStream) Close() error                       { return s.pipe.Close() }
+func (s *fakeStream) SetDeadline(t time.Time) error      { return s.pipe.SetDeadline(t) }
+func (s *fakeStream) SetReadDeadline(t time.Time) error  { return s.pipe.SetReadDeadline(t) }
+func (s *fakeStream) SetWriteDeadline(t time.Time) error { return s.pipe.SetWriteDeadline(t) }
+func (s *fakeStream) Reset() error {
+	s.reset = true
+	return s.pipe.Close()
+}
+
+func newKey(t *testing.T, keyType int) crypto.PubKey {
+	_, pub, err := crypto.GenerateKeyPair(keyType, 0)
+	require.NoError(t, err)
+	return pub
+}
+
+func newPeerID(t *testing.T) peer.ID {
+	id, err := peer.IDFromPublicKey(newKey(t, crypto.Ed25519))
+	require.NoError(t, err)
+	return id
+}
+
+func newHandler(t *testing.T, identity ports.IdentityResolver) (*p2p.Handler, *mocks.MockLeaseService, *mocks.MockNonceService) {
+	ctrl := gomock.NewController(t)
+	leaseService := mocks.NewMockLeaseService(ctrl)
+	nonceService := mocks.NewMockNonceService(ctrl)
+	accessControl := mocks.NewMockAccessControlService(ctrl)
+	accessControl.EXPECT().CheckAccess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	authService := mocks.NewMockAuthService(ctrl)
+	return p2p.NewHandler(config.NewDefaultAppConfig(), leaseService, leaseService, nonceService, authService, identity, accessControl, zap.NewNop()), leaseService, nonceService
+}
+
+// openStream serves a stream from the holder of key and returns the client end
+func openStream(t *testing.T, handler *p2p.Handler, key crypto.PubKey) (net.Conn, *fakeStream, chan struct{}) {
+	client, server := net.Pipe()
+	stream := &fakeStream{pipe: server, conn: &fakeConn{key: key}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.HandleStream(stream)
+	}()
+	t.Cleanup(func() { client.Close() })
+	return client, stream, done
+}
+
+func roundTrip(t *testing.T, conn net.Conn, reader *bufio.Reader, req string) *p2p.Response {
+	_, err := conn.Write([]byte(req + "\n"))
+	require.NoError(t, err)
+
+	line, err := reader.ReadBytes('\n')
+	require.NoError(t, err)
+	var resp p2p.Response
+	require.NoError(t, json.Unmarshal(line, &resp))
+	return &resp
+}
+
+func TestHandler_HandleStream(t *testing.T) {
+	handler, leaseService, _ := newHandler(t, libp2p.NewPeerIDResolver())
+	key := newKey(t, crypto.Ed25519)
+	peerID, err := peer.IDFromPublicKey(key)
+	require.NoError(t, err)
+	lease := &models.Lease{TokenID: 167902210, PeerID: peerID.String(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	leaseService.EXPECT().AllocateIP(gomock.Any(), peerID.String()).Return(lease, nil)
+	leaseService.EXPECT().RenewLease(gomock.Any(), int64(167902210), peerID.String()).Return(lease, nil)
+
+	conn, _, done := openStream(t, handler, key)
+	reader := bufio.NewReader(conn)
+
+	resp := roundTrip(t, conn, reader, `{"id":"1","op":"allocate"}`)
+	assert.Equal(t, "1", resp.ID)
+	assert.Nil(t, resp.Error)
+	data, _ := resp.Data.(map[string]any)
+	assert.Equal(t, float64(167902210), data["token_id"])
+
+	resp = roundTrip(t, conn, reader, `{"id":"2","op":"renew","token_id":167902210}`)
+	assert.Equal(t, "2", resp.ID)
+	assert.Nil(t, resp.Error)
+
+	conn.Close()
+	<-done
+}
+
+func TestHandler_HandleStream_EthereumIdentity(t *testing.T) {
+	handler, leaseService, _ := newHandler(t, ethereum.NewAddressResolver())
+	key := newKey(t, crypto.Secp256k1)
+	pubkey, err := crypto.MarshalPublicKey(key)
+	require.NoError(t, err)
+	address, err := ethereum.NewAddressResolver().ResolvePeerID(pubkey)
+	require.NoError(t, err)
+
+	leaseService.EXPECT().GetLeaseByPeerID(gomock.Any(), address).Return(&models.Lease{TokenID: 167902210, PeerID: address}, nil)
+
+	conn, _, done := openStream(t, handler, key)
+	resp := roundTrip(t, conn, bufio.NewReader(conn), `{"op":"get"}`)
+	require.Nil(t, resp.Error)
+	assert.Equal(t, address, resp.Data.(map[string]any)["peer_id"])
+
+	conn.Close()
+	<-done
+}
+
+func TestHandler_HandleStream_UnsupportedKey(t *testing.T) {
+	handler, _, _ := newHandler(t, ethereum.NewAddressResolver())
+	conn, _, done := openStream(t, handler, newKey(t, crypto.Ed25519))
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	require.NoError(t, err)
+	var resp p2p.Response
+	require.NoError(t, json.Unmarshal(line, &resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "UNSUPPORTED_KEY_TYPE", resp.Error.Code)
+
+	<-done
+}
+
+func TestHandler_HandleStream_InvalidMessage(t *testing.T) {
+	handler, _, _ := newHandler(t, libp2p.NewPeerIDResolver())
+	conn, _, done := openStream(t, handler, newKey(t, crypto.Ed25519))
+	reader := bufio.NewReader(conn)
+
+	resp := roundTrip(t, conn, reader, `not json`)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "INVALID_REQUEST", resp.Error.Code)
+
+	// The stream stays usable after a malformed message
+	resp = roundTrip(t, conn, reader, `{"op":"unknown"}`)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "INVALID_REQUEST", resp.Error.Code)
+
+	conn.Close()
+	<-done
+}
+
+func TestHandler_HandleStream_MessageTooLarge(t *testing.T) {
+	handler, _, _ := newHandler(t, libp2p.NewPeerIDResolver())
+	conn, stream, done := openStream(t, handler, newKey(t, crypto.Ed25519))
+	reader := bufio.NewReader(conn)
+
+	go conn.Write([]byte(`{"op":"allocate","affinity_group":"` + strings.Repeat("a", 8192) + `"}` + "\n"))
+
+	line, err := reader.ReadBytes('\n')
+	require.NoError(t, err)
+	var resp p2p.Response
+	require.NoError(t, json.Unmarshal(line, &resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "REQUEST_TOO_LARGE", resp.Error.Code)
+
+	<-done
+	assert.True(t, stream.reset)
+}
+
+func TestHandler_Handle(t *testing.T) {
+	peerID := newPeerID(t).String()
+	lease := &models.Lease{TokenID: 167902210, PeerID: peerID}
+
+	tests := []struct {
+		name      string
+		req       p2p.Request
+		mockSetup func(*mocks.MockLeaseService, *mocks.MockNonceService)
+		errCode   string
+	}{
+		{
+			name: "allocate requested token ID",
+			req:  p2p.Request{Op: p2p.OpAllocate, TokenID: 167902211},
+			mockSetup: func(ls *mocks.MockLeaseService, _ *mocks.MockNonceService) {
+				ls.EXPECT().AllocateRequestedIP(gomock.Any(), peerID, int64(167902211)).Return(&models.AllocationResult{Lease: lease, RequestedTokenID: 167902211}, nil)
+			},
+		},
+		{
+			name: "allocate in affinity group",
+			req:  p2p.Request{Op: p2p.OpAllocate, AffinityGroup: "rack-1"},
+			mockSetup: func(ls *mocks.MockLeaseService, _ *mocks.MockNonceService) {
+				ls.EXPECT().AllocateAffinityIP(gomock.Any(), peerID, "rack-1").Return(lease, nil)
+			},
+		},
+		{
+			name:    "allocate with invalid affinity group",
+			req:     p2p.Request{Op: p2p.OpAllocate, AffinityGroup: "rack 1"},
+			errCode: "INVALID_AFFINITY_GROUP",
+		},
+		{
+			name: "allocate in zone",
+			req:  p2p.Request{Op: p2p.OpAllocate, Zone: "fra1"},
+			mockSetup: func(ls *mocks.MockLeaseService, _ *mocks.MockNonceService) {
+				ls.EXPECT().AllocateZoneIP(gomock.Any(), peerID, "fra1").Return(lease, nil)
+			},
+		},
+		{
+			name:    "allocate with invalid zone",
+			req:     p2p.Request{Op: p2p.OpAllocate, Zone: "fra 1"},
+			errCode: "INVALID_ZONE",
+		},
+		{
+			name:    "allocate with conflicting options",
+			req:     p2p.Request{Op: p2p.OpAllocate, TokenID: 167902211, AffinityGroup: "rack-1"},
+			errCode: errors.ErrConflictingOptions.Code,
+		},
+		{
+			name:    "renew without token ID",
+			req:     p2p.Request{Op: p2p.OpRenew},
+			errCode: "MISSING_TOKEN_ID",
+		},
+		{
+			name: "release",
+			req:  p2p.Request{Op: p2p.OpRelease, TokenID: 167902210},
+			mockSetup: func(ls *mocks.MockLeaseService, _ *mocks.MockNonceService) {
+				ls.EXPECT().ReleaseLease(gomock.Any(), int64(167902210), peerID).Return(nil)
+			},
+		},
+		{
+			name: "get own lease",
+			req:  p2p.Request{Op: p2p.OpGet},
+			mockSetup: func(ls *mocks.MockLeaseService, _ *mocks.MockNonceService) {
+				ls.EXPECT().GetLeaseByPeerID(gomock.Any(), peerID).Return(lease, nil)
+			},
+		},
+		{
+			name: "get by token ID not found",
+			req:  p2p.Request{Op: p2p.OpGet, TokenID: 167902299},
+			mockSetup: func(ls *mocks.MockLeaseService, _ *mocks.MockNonceService) {
+				ls.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167902299)).Return(nil, errors.ErrLeaseNotFound)
+			},
+			errCode: "LEASE_NOT_FOUND",
+		},
+		{
+			name: "nonce",
+			req:  p2p.Request{Op: p2p.OpNonce},
+			mockSetup: func(_ *mocks.MockLeaseService, ns *mocks.MockNonceService) {
+				ns.EXPECT().CreateNonce(gomock.Any(), peerID).Return(&models.Nonce{ID: "nonce-1", PeerID: peerID}, nil)
+			},
+		},
+		{
+			name:    "relayed nonce with invalid pubkey",
+			req:     p2p.Request{Op: p2p.OpNonce, Pubkey: "not base64"},
+			errCode: errors.ErrInvalidPubkey.Code,
+		},
+		{
+			name: "unexpected error",
+			req:  p2p.Request{Op: p2p.OpRenew, TokenID: 167902210},
+			mockSetup: func(ls *mocks.MockLeaseService, _ *mocks.MockNonceService) {
+				ls.EXPECT().RenewLease(gomock.Any(), int64(167902210), peerID).Return(nil, context.DeadlineExceeded)
+			},
+			errCode: "UNKNOWN_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, leaseService, nonceService := newHandler(t, libp2p.NewPeerIDResolver())
+			if tt.mockSetup != nil {
+				tt.mockSetup(leaseService, nonceService)
+			}
+
+			resp := handler.Handle(context.Background(), peerID, &tt.req)
+			if tt.errCode != "" {
+				require.NotNil(t, resp.Error)
+				assert.Equal(t, tt.errCode, resp.Error.Code)
+				assert.Nil(t, resp.Data)
+				return
+			}
+			assert.Nil(t, resp.Error)
+			assert.NotNil(t, resp.Data)
+		})
+	}
+}
+
+func TestHandler_HandleStream_Relayed(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	identity := libp2p.NewPeerIDResolver()
+	store := embedded.NewMemoryStore(clock.NewSystem())
+	nonceRepo := embedded.NewNonceRepository(cfg, store)
+	nonceService := services.NewNonceService(cfg, nonceRepo, libp2p.NewSignatureVerifier(), identity)
+	authService := services.NewAuthService(cfg, nonceService, identity, clock.NewSystem())
+
+	ctrl := gomock.NewController(t)
+	leaseService := mocks.NewMockLeaseService(ctrl)
+	accessControl := mocks.NewMockAccessControlService(ctrl)
+	accessControl.EXPECT().CheckAccess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	handler := p2p.NewHandler(cfg, leaseService, leaseService, nonceService, authService, identity, accessControl, zap.NewNop())
+
+	// The relayed peer only talks to the relay, which holds the stream to the server
+	priv, pub, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	pubkey, err := crypto.MarshalPublicKey(pub)
+	require.NoError(t, err)
+	relayedPeerID, err := peer.IDFromPublicKey(pub)
+	require.NoError(t, err)
+	encodedPubkey := base64.StdEncoding.EncodeToString(pubkey)
+
+	relayKey := newKey(t, crypto.Ed25519)
+	conn, _, done := openStream(t, handler, relayKey)
+	reader := bufio.NewReader(conn)
+
+	sign := func(nonce string) string {
+		signature, err := priv.Sign(dhcp2pcrypto.AuthPayload(nonce, ""))
+		require.NoError(t, err)
+		return base64.StdEncoding.EncodeToString(signature)
+	}
+	request := func(id, op, nonce, signature string) string {
+		req, err := json.Marshal(&p2p.Request{ID: id, Op: op, Pubkey: encodedPubkey, Nonce: nonce, Signature: signature})
+		require.NoError(t, err)
+		return string(req)
+	}
+	challenge := func(id string) string {
+		resp := roundTrip(t, conn, reader, request(id, p2p.OpNonce, "", ""))
+		require.Nil(t, resp.Error)
+		nonce, _ := resp.Data.(map[string]any)["nonce"].(string)
+		require.NotEmpty(t, nonce)
+		return nonce
+	}
+
+	lease := &models.Lease{TokenID: 167902210, PeerID: relayedPeerID.String()}
+	leaseService.EXPECT().AllocateIP(gomock.Any(), relayedPeerID.String()).Return(lease, nil)
+
+	nonce := challenge("1")
+	outstanding, err := nonceRepo.ListOutstandingNonces(ctx, relayedPeerID.String())
+	require.NoError(t, err)
+	require.Len(t, outstanding, 1, "the nonce is issued to the relayed peer")
+
+	resp := roundTrip(t, conn, reader, request("2", p2p.OpAllocate, nonce, sign(nonce)))
+	require.Nil(t, resp.Error)
+	assert.Equal(t, relayedPeerID.String(), resp.Data.(map[string]any)["peer_id"])
+
+	t.Run("nonces are single use", func(t *testing.T) {
+		resp := roundTrip(t, conn, reader, request("3", p2p.OpAllocate, nonce, sign(nonce)))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errors.ErrNonceUsed.Code, resp.Error.Code)
+	})
+
+	t.Run("signatures of other keys are rejected", func(t *testing.T) {
+		nonce := challenge("4")
+		otherPriv, _, err := crypto.GenerateEd25519Key(nil)
+		require.NoError(t, err)
+		signature, err := otherPriv.Sign(dhcp2pcrypto.AuthPayload(nonce, ""))
+		require.NoError(t, err)
+
+		resp := roundTrip(t, conn, reader, request("5", p2p.OpRenew, nonce, base64.StdEncoding.EncodeToString(signature)))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errors.ErrInvalidSignature.Code, resp.Error.Code)
+	})
+
+	t.Run("incomplete credentials are rejected", func(t *testing.T) {
+		resp := roundTrip(t, conn, reader, request("6", p2p.OpGet, "", sign("nonce")))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, "6", resp.ID)
+	})
+
+	conn.Close()
+	<-done
+}
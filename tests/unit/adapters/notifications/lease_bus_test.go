@@ -0,0 +1,35 @@
+package notifications
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/notifications"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+func TestLeaseBusPublisher_Publish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	event := newLeaseEvent(models.LeaseLifecycleRenewed)
+	bus := mocks.NewMockEventBus(ctrl)
+	bus.EXPECT().Publish("lease.renewed", "167772161", gomock.Any()).DoAndReturn(func(topic, key string, payload any) error {
+		p := payload.(*models.LeaseEventPayload)
+		assert.Equal(t, "10.0.0.1", p.IP)
+		assert.Equal(t, "peer1", p.PeerID)
+		assert.Equal(t, models.DefaultTenantID, p.Tenant)
+		assert.True(t, p.ExpiresAt.Equal(event.ExpiresAt))
+		return nil
+	})
+	// A rejected event is logged, the lease operation goes on
+	bus.EXPECT().Publish("lease.renewed", "167772161", gomock.Any()).Return(errors.New("event queue full"))
+
+	publisher := notifications.NewLeaseBusPublisher(bus, zap.NewNop())
+	publisher.Publish(event)
+	publisher.Publish(event)
+}
@@ -0,0 +1,155 @@
+package notifications
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/notifications"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func newLeaseWebhookConfig(webhooks ...config.LeaseWebhookConfig) *config.AppConfig {
+	return &config.AppConfig{
+		LeaseWebhooks:         webhooks,
+		LeaseWebhookTimeout:   1,
+		LeaseWebhookRetries:   2,
+		LeaseWebhookBackoff:   1,
+		LeaseWebhookQueueSize: 10,
+	}
+}
+
+func newLeaseEvent(eventType models.LeaseLifecycleEventType) *models.LeaseLifecycleEvent {
+	return &models.LeaseLifecycleEvent{
+		Type:       eventType,
+		TokenID:    167772161,
+		PeerID:     "peer1",
+		TenantID:   models.DefaultTenantID,
+		ExpiresAt:  time.Now().Add(time.Hour),
+		OccurredAt: time.Now(),
+	}
+}
+
+func TestLeaseWebhookDispatcher_Publish(t *testing.T) {
+	t.Run("delivers signed events", func(t *testing.T) {
+		payloads := make(chan notifications.LeaseWebhookPayload, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "sha256="+notifications.Sign([]byte("secret"), body), r.Header.Get(notifications.SignatureHeader))
+			assert.Equal(t, string(models.LeaseLifecycleAllocated), r.Header.Get(notifications.EventHeader))
+
+			var payload notifications.LeaseWebhookPayload
+			assert.NoError(t, json.Unmarshal(body, &payload))
+			payloads <- payload
+		}))
+		defer server.Close()
+
+		lc := fxtest.NewLifecycle(t)
+		dispatcher, err := notifications.NewLeaseWebhookDispatcher(lc, newLeaseWebhookConfig(config.LeaseWebhookConfig{URL: server.URL, Secret: "secret"}), zap.NewNop())
+		require.NoError(t, err)
+		lc.RequireStart()
+		defer lc.RequireStop()
+
+		dispatcher.Publish(newLeaseEvent(models.LeaseLifecycleAllocated))
+
+		select {
+		case payload := <-payloads:
+			assert.Equal(t, "lease.allocated", payload.Event)
+			assert.Equal(t, int64(167772161), payload.TokenID)
+			assert.Equal(t, "10.0.0.1", payload.IP)
+			assert.Equal(t, "peer1", payload.PeerID)
+			assert.Equal(t, models.DefaultTenantID, payload.Tenant)
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not called")
+		}
+	})
+
+	t.Run("skips events the webhook is not subscribed to", func(t *testing.T) {
+		events := make(chan string, 2)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			events <- r.Header.Get(notifications.EventHeader)
+		}))
+		defer server.Close()
+
+		lc := fxtest.NewLifecycle(t)
+		dispatcher, err := notifications.NewLeaseWebhookDispatcher(lc, newLeaseWebhookConfig(config.LeaseWebhookConfig{
+			URL:    server.URL,
+			Events: []string{string(models.LeaseLifecycleReleased)},
+		}), zap.NewNop())
+		require.NoError(t, err)
+		lc.RequireStart()
+
+		dispatcher.Publish(newLeaseEvent(models.LeaseLifecycleRenewed))
+		dispatcher.Publish(newLeaseEvent(models.LeaseLifecycleReleased))
+		lc.RequireStop()
+
+		require.Len(t, events, 1)
+		assert.Equal(t, string(models.LeaseLifecycleReleased), <-events)
+	})
+
+	t.Run("retries failed deliveries", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		lc := fxtest.NewLifecycle(t)
+		dispatcher, err := notifications.NewLeaseWebhookDispatcher(lc, newLeaseWebhookConfig(config.LeaseWebhookConfig{URL: server.URL}), zap.NewNop())
+		require.NoError(t, err)
+		lc.RequireStart()
+
+		dispatcher.Publish(newLeaseEvent(models.LeaseLifecycleExpired))
+		lc.RequireStop()
+
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("does not retry rejected deliveries", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		lc := fxtest.NewLifecycle(t)
+		dispatcher, err := notifications.NewLeaseWebhookDispatcher(lc, newLeaseWebhookConfig(config.LeaseWebhookConfig{URL: server.URL}), zap.NewNop())
+		require.NoError(t, err)
+		lc.RequireStart()
+
+		dispatcher.Publish(newLeaseEvent(models.LeaseLifecycleExpired))
+		lc.RequireStop()
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestNewLeaseWebhookDispatcher_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook config.LeaseWebhookConfig
+	}{
+		{"relative URL", config.LeaseWebhookConfig{URL: "/hooks"}},
+		{"unsupported scheme", config.LeaseWebhookConfig{URL: "ftp://example.com/hooks"}},
+		{"unknown event", config.LeaseWebhookConfig{URL: "https://example.com/hooks", Events: []string{"lease.stolen"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := notifications.NewLeaseWebhookDispatcher(fxtest.NewLifecycle(t), newLeaseWebhookConfig(tt.webhook), zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/notifications"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+func TestWebhookChannel_Notify(t *testing.T) {
+	leases := []*models.ExpiringLease{{TokenID: 1, PeerID: "peer1", ExpiresAt: time.Now().Add(time.Hour).UTC()}}
+
+	t.Run("posts a signed batch", func(t *testing.T) {
+		var payload notifications.WebhookPayload
+		var signature string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			signature = r.Header.Get(notifications.SignatureHeader)
+			assert.Equal(t, "sha256="+notifications.Sign([]byte("secret"), body), signature)
+			assert.NoError(t, json.Unmarshal(body, &payload))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		channel := notifications.NewWebhookChannel(server.URL, "secret", time.Second)
+		require.NoError(t, channel.Notify(context.Background(), leases))
+
+		assert.NotEmpty(t, signature)
+		assert.Equal(t, notifications.ExpiringEvent, payload.Event)
+		require.Len(t, payload.Leases, 1)
+		assert.Equal(t, "peer1", payload.Leases[0].PeerID)
+	})
+
+	t.Run("unsigned without a secret", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get(notifications.SignatureHeader))
+		}))
+		defer server.Close()
+
+		channel := notifications.NewWebhookChannel(server.URL, "", time.Second)
+		assert.NoError(t, channel.Notify(context.Background(), leases))
+	})
+
+	t.Run("non-2xx response fails the batch", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		channel := notifications.NewWebhookChannel(server.URL, "", time.Second)
+		assert.Error(t, channel.Notify(context.Background(), leases))
+	})
+}
+
+func TestNewExpiryChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *config.AppConfig
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "log and webhook",
+			cfg:      &config.AppConfig{ExpiryNotifyChannels: []string{"log", "webhook"}, ExpiryWebhookURL: "http://example.test/hook"},
+			expected: []string{"log", "webhook"},
+		},
+		{
+			name:    "webhook without url",
+			cfg:     &config.AppConfig{ExpiryNotifyChannels: []string{"webhook"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown channel",
+			cfg:     &config.AppConfig{ExpiryNotifyChannels: []string{"pager"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels, err := notifications.NewExpiryChannels(tt.cfg, zap.NewNop())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			names := make([]string, len(channels))
+			for i, c := range channels {
+				names[i] = c.Name()
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
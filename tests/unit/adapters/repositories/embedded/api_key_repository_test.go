@@ -0,0 +1,61 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+)
+
+func TestAPIKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	store, stop := newStoppableTestStore(t, cfg, clock.NewSystem())
+	repo := embedded.NewAPIKeyRepository(store)
+
+	created, err := repo.CreateAPIKey(ctx, &models.APIKey{
+		Name:   "ops",
+		Prefix: "dhcp2p_0a1b2c3d",
+		Hash:   "hash-1",
+		Scopes: []models.APIKeyScope{models.APIKeyScopeLeasesRead},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	_, err = repo.CreateAPIKey(ctx, &models.APIKey{Name: "copy", Hash: "hash-1"})
+	assert.ErrorIs(t, err, domainErrors.ErrDuplicateRecord)
+
+	_, err = repo.GetAPIKeyByHash(ctx, "unknown")
+	assert.ErrorIs(t, err, domainErrors.ErrAPIKeyNotFound)
+
+	require.NoError(t, repo.TouchAPIKey(ctx, created.ID))
+	revoked, err := repo.RevokeAPIKey(ctx, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+
+	again, err := repo.RevokeAPIKey(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, revoked.RevokedAt, again.RevokedAt, "a revocation keeps its time")
+
+	_, err = repo.RevokeAPIKey(ctx, 42)
+	assert.ErrorIs(t, err, domainErrors.ErrAPIKeyNotFound)
+
+	// Keys survive a restart
+	stop()
+	reopened := embedded.NewAPIKeyRepository(newTestStore(t, cfg))
+	fetched, err := reopened.GetAPIKeyByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, []models.APIKeyScope{models.APIKeyScopeLeasesRead}, fetched.Scopes)
+	assert.NotNil(t, fetched.LastUsedAt)
+	assert.True(t, fetched.Revoked())
+
+	keys, err := reopened.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
@@ -0,0 +1,81 @@
+package embedded
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+)
+
+// corruptState holds one violation of every checked invariant
+const corruptState = `{
+	"min_token_id": 10,
+	"max_token_id": 20,
+	"last_token_id": 30,
+	"leases": {
+		"11": {"token_id": 11, "peer_id": "peer-1", "expires_at": "2999-01-01T00:00:00Z", "created_at": "2026-01-01T00:00:00Z", "updated_at": "2026-01-01T00:00:00Z"},
+		"12": {"token_id": 12, "peer_id": "peer-1", "expires_at": "2999-01-01T00:00:00Z", "created_at": "2026-01-01T00:00:00Z", "updated_at": "2026-01-02T00:00:00Z"},
+		"13": {"token_id": 13, "peer_id": "peer-2", "expires_at": "2999-01-01T00:00:00Z", "created_at": "2026-01-01T00:00:00Z", "updated_at": "2026-01-01T00:00:00Z"}
+	},
+	"nonces": {
+		"nonce-1": {"id": "nonce-1", "peer_id": "peer-1", "issued_at": "2026-01-01T00:00:00Z", "expires_at": "2026-01-01T00:05:00Z", "used": true}
+	}
+}`
+
+func violationsByCheck(report *models.IntegrityReport) map[string][]*models.IntegrityViolation {
+	byCheck := make(map[string][]*models.IntegrityViolation)
+	for _, check := range report.Checks {
+		byCheck[check.Check] = check.Violations
+	}
+	return byCheck
+}
+
+func TestIntegrityChecker(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	require.NoError(t, os.WriteFile(cfg.StoragePath, []byte(corruptState), 0o600))
+
+	store, stop := newStoppableTestStore(t, cfg, clock.NewSystem())
+	checker := embedded.NewIntegrityChecker(store)
+
+	t.Run("check reports without repairing", func(t *testing.T) {
+		report, err := checker.Check(ctx, false)
+		require.NoError(t, err)
+
+		violations := violationsByCheck(report)
+		require.Len(t, violations[models.IntegrityCheckDuplicateActiveLeases], 1)
+		assert.Equal(t, "token:11", violations[models.IntegrityCheckDuplicateActiveLeases][0].Subject)
+		assert.Len(t, violations[models.IntegrityCheckAllocState], 1)
+		assert.Len(t, violations[models.IntegrityCheckNonceUsedAt], 1)
+		assert.Equal(t, 3, report.Unrepaired())
+
+		again, err := checker.Check(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, 3, again.Unrepaired())
+	})
+
+	t.Run("repair fixes every violation", func(t *testing.T) {
+		report, err := checker.Check(ctx, true)
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Violations())
+		assert.Zero(t, report.Unrepaired())
+	})
+
+	t.Run("repairs are persisted", func(t *testing.T) {
+		stop()
+		reloaded := newTestStore(t, cfg)
+		report, err := embedded.NewIntegrityChecker(reloaded).Check(ctx, false)
+		require.NoError(t, err)
+		assert.Zero(t, report.Violations())
+
+		repo := embedded.NewLeaseRepository(cfg, reloaded)
+		lease, err := repo.GetLeaseByPeerID(ctx, "peer-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), lease.TokenID)
+	})
+}
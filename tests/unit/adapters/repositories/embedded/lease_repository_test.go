@@ -0,0 +1,836 @@
+package embedded
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/fx/fxtest"
+)
+
+const firstTokenID = 167902210
+
+func newTestConfig(t *testing.T) *config.AppConfig {
+	cfg := config.NewDefaultAppConfig()
+	cfg.StorageBackend = config.StorageBackendEmbedded
+	cfg.StoragePath = filepath.Join(t.TempDir(), "dhcp2p.json")
+	cfg.Lease.TTL = 120
+	cfg.Nonce.TTL = 5
+	return cfg
+}
+
+func newTestStore(t *testing.T, cfg *config.AppConfig) *embedded.Store {
+	store, _ := newStoppableTestStore(t, cfg, clock.NewSystem())
+	return store
+}
+
+func newTestStoreWithClock(t *testing.T, cfg *config.AppConfig, storeClock ports.Clock) *embedded.Store {
+	store, _ := newStoppableTestStore(t, cfg, storeClock)
+	return store
+}
+
+// newStoppableTestStore also returns a function that closes the store, so that the data
+// file can be opened again. It is closed at the end of the test otherwise.
+func newStoppableTestStore(t *testing.T, cfg *config.AppConfig, storeClock ports.Clock) (*embedded.Store, func()) {
+	lc := fxtest.NewLifecycle(t)
+	store, err := embedded.NewStore(lc, cfg, storeClock)
+	require.NoError(t, err)
+	lc.RequireStart()
+
+	var once sync.Once
+	stop := func() { once.Do(func() { lc.RequireStop() }) }
+	t.Cleanup(stop)
+	return store, stop
+}
+
+func TestLeaseRepository_Allocation(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	t.Run("new leases take the next token IDs", func(t *testing.T) {
+		first, err := repo.AllocateNewLease(ctx, "peer-1")
+		require.NoError(t, err)
+		second, err := repo.AllocateNewLease(ctx, "peer-2")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(firstTokenID), first.TokenID)
+		assert.Equal(t, int64(firstTokenID+1), second.TokenID)
+		assert.InDelta(t, 120*60, first.Ttl, 1)
+	})
+
+	t.Run("lookups only return active leases", func(t *testing.T) {
+		lease, err := repo.GetLeaseByPeerID(ctx, "peer-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID), lease.TokenID)
+
+		lease, err = repo.GetLeaseByTokenID(ctx, firstTokenID+1)
+		require.NoError(t, err)
+		assert.Equal(t, "peer-2", lease.PeerID)
+
+		_, err = repo.GetLeaseByPeerID(ctx, "unknown")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+	})
+
+	t.Run("requested token IDs", func(t *testing.T) {
+		_, err := repo.AllocateRequestedLease(ctx, "peer-3", firstTokenID)
+		assert.ErrorIs(t, err, domainErrors.ErrTokenIDInUse)
+
+		_, err = repo.AllocateRequestedLease(ctx, "peer-3", 1)
+		assert.ErrorIs(t, err, domainErrors.ErrTokenIDOutOfRange)
+
+		lease, err := repo.AllocateRequestedLease(ctx, "peer-3", firstTokenID+2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID+2), lease.TokenID)
+
+		// The cursor skips token IDs handed out as preferred tokens
+		next, err := repo.AllocateNewLease(ctx, "peer-4")
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID+3), next.TokenID)
+	})
+
+	t.Run("renew and release", func(t *testing.T) {
+		_, err := repo.RenewLease(ctx, firstTokenID, "peer-2")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+
+		renewed, err := repo.RenewLease(ctx, firstTokenID, "peer-1")
+		require.NoError(t, err)
+		assert.Equal(t, "peer-1", renewed.PeerID)
+
+		require.NoError(t, repo.ReleaseLease(ctx, firstTokenID, "peer-1"))
+		_, err = repo.GetLeaseByTokenID(ctx, firstTokenID)
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+	})
+
+	t.Run("released leases are reused", func(t *testing.T) {
+		lease, err := repo.FindAndReuseExpiredLease(ctx, "peer-5")
+		require.NoError(t, err)
+		require.NotNil(t, lease)
+		assert.Equal(t, int64(firstTokenID), lease.TokenID)
+		assert.Equal(t, "peer-5", lease.PeerID)
+
+		lease, err = repo.FindAndReuseExpiredLease(ctx, "peer-6")
+		require.NoError(t, err)
+		assert.Nil(t, lease)
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		_, err := repo.TransferLease(ctx, firstTokenID, "peer-5", "peer-2")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseAlreadyExists)
+
+		_, err = repo.TransferLease(ctx, firstTokenID, "peer-1", "peer-7")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+
+		lease, err := repo.TransferLease(ctx, firstTokenID, "peer-5", "peer-7")
+		require.NoError(t, err)
+		assert.Equal(t, "peer-7", lease.PeerID)
+	})
+}
+
+func TestLeaseRepository_AffinityLease(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	lease, err := repo.AllocateAffinityLease(ctx, "peer-1", "rack-a")
+	require.NoError(t, err)
+	assert.Nil(t, lease, "an empty group has no neighbours")
+
+	first, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	require.NoError(t, repo.SetLeaseAffinityGroup(ctx, first.TokenID, "rack-a"))
+	_, err = repo.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+
+	lease, err = repo.AllocateAffinityLease(ctx, "peer-3", "rack-a")
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	// The token ID above the group is taken by peer-2 and the one below is outside the pool
+	assert.Equal(t, first.TokenID+2, lease.TokenID)
+}
+
+func TestLeaseRepository_ApplyConfig(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	lease, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.InDelta(t, 120*60, lease.Ttl, 1)
+
+	reloaded := *cfg
+	reloaded.Lease.TTL = 30
+	repo.ApplyConfig(&reloaded)
+
+	// The reloaded TTL applies to renewals and new leases, not to existing expiries
+	current, err := repo.GetLeaseByPeerID(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.InDelta(t, 120*60, current.Ttl, 1)
+
+	renewed, err := repo.RenewLease(ctx, lease.TokenID, "peer-1")
+	require.NoError(t, err)
+	assert.InDelta(t, 30*60, renewed.Ttl, 1)
+
+	lease, err = repo.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	assert.InDelta(t, 30*60, lease.Ttl, 1)
+}
+
+func TestLeaseRepository_LeaseQuota(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.Lease.MaxPerPeer = 1
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	_, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+
+	_, err = repo.AllocateNewLease(ctx, "peer-1")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseQuotaExceeded)
+	_, err = repo.AllocateRequestedLease(ctx, "peer-1", firstTokenID+5)
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseQuotaExceeded)
+	_, err = repo.FindAndReuseExpiredLease(ctx, "peer-1")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseQuotaExceeded)
+
+	t.Run("disabled", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Lease.MaxPerPeer = 0
+		repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+		_, err := repo.AllocateNewLease(ctx, "peer-1")
+		require.NoError(t, err)
+		_, err = repo.AllocateNewLease(ctx, "peer-1")
+		assert.NoError(t, err)
+	})
+}
+
+func TestLeaseRepository_ExecuteBatch(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	results, err := repo.ExecuteBatch(ctx, []*models.LeaseOperation{
+		{Type: models.LeaseOperationAllocate, PeerID: "peer-1"},
+		{Type: models.LeaseOperationRenew, PeerID: "peer-2", TokenID: firstTokenID},
+		{Type: models.LeaseOperationAllocate, PeerID: "peer-1"},
+		{Type: "unknown", PeerID: "peer-1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, int64(firstTokenID), results[0].Lease.TokenID)
+	assert.ErrorIs(t, results[1].Err, domainErrors.ErrLeaseNotFound)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, int64(firstTokenID), results[2].Lease.TokenID, "an existing lease is returned")
+	assert.ErrorIs(t, results[3].Err, domainErrors.ErrInvalidOperation)
+}
+
+func TestLeaseRepository_RevokeLeases(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}}
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	ctx := context.Background()
+	acme := models.WithTenant(ctx, "acme")
+	first, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	second, err := repo.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	third, err := repo.AllocateNewLease(ctx, "peer-3")
+	require.NoError(t, err)
+	require.NoError(t, repo.ReleaseLease(ctx, third.TokenID, "peer-3"))
+	other, err := repo.AllocateNewLease(acme, "peer-1")
+	require.NoError(t, err)
+
+	revoked, err := repo.RevokeLeases(ctx, []*models.Lease{
+		{TokenID: first.TokenID, PeerID: "peer-1"},
+		{TokenID: second.TokenID, PeerID: "peer-9"}, // transferred since it was matched
+		{TokenID: third.TokenID, PeerID: "peer-3"},  // released already
+		{TokenID: other.TokenID, PeerID: "peer-1"},  // of another tenant
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{first.TokenID}, revoked)
+
+	_, err = repo.GetLeaseByPeerID(ctx, "peer-1")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+	_, err = repo.GetLeaseByPeerID(ctx, "peer-2")
+	assert.NoError(t, err)
+	_, err = repo.GetLeaseByPeerID(acme, "peer-1")
+	assert.NoError(t, err)
+}
+
+func TestLeaseRepository_Reclamation(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.ReclaimEnabled = true
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	for _, peerID := range []string{"peer-1", "peer-2", "peer-3"} {
+		lease, err := repo.AllocateNewLease(ctx, peerID)
+		require.NoError(t, err)
+		require.NoError(t, repo.ReleaseLease(ctx, lease.TokenID, peerID))
+	}
+
+	// Expired leases are not reused before a policy reclaims them
+	lease, err := repo.FindAndReuseExpiredLease(ctx, "peer-4")
+	require.NoError(t, err)
+	assert.Nil(t, lease)
+	_, err = repo.AllocateRequestedLease(ctx, "peer-4", firstTokenID)
+	assert.ErrorIs(t, err, domainErrors.ErrTokenIDInUse)
+
+	candidates, err := repo.ListReclaimCandidates(ctx, firstTokenID, 10)
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	assert.Equal(t, int64(firstTokenID+1), candidates[0].TokenID)
+	assert.Equal(t, int64(firstTokenID+2), candidates[1].TokenID)
+
+	reclaimed, err := repo.ReclaimLeases(ctx, []int64{firstTokenID + 1, firstTokenID + 1, 42})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{firstTokenID + 1}, reclaimed)
+
+	lease, err = repo.FindAndReuseExpiredLease(ctx, "peer-4")
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	assert.Equal(t, int64(firstTokenID+1), lease.TokenID)
+}
+
+func TestLeaseRepository_Persistence(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	store, stop := newStoppableTestStore(t, cfg, clock.NewSystem())
+	repo := embedded.NewLeaseRepository(cfg, store)
+	allocated, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	stop()
+
+	// A new store reads the same data file
+	reopened := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+	lease, err := reopened.GetLeaseByPeerID(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Equal(t, allocated.TokenID, lease.TokenID)
+	assert.True(t, allocated.ExpiresAt.Equal(lease.ExpiresAt))
+
+	next, err := reopened.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	assert.Equal(t, allocated.TokenID+1, next.TokenID, "the allocation cursor is persisted")
+}
+
+func TestLeaseRepository_GetLeaseHistory(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	allocated, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	_, err = repo.RenewLease(ctx, allocated.TokenID, "peer-1")
+	require.NoError(t, err)
+	require.NoError(t, repo.ReleaseLease(ctx, allocated.TokenID, "peer-1"))
+	reused, err := repo.FindAndReuseExpiredLease(ctx, "peer-2")
+	require.NoError(t, err)
+	require.NotNil(t, reused)
+	_, err = repo.TransferLease(ctx, allocated.TokenID, "peer-2", "peer-3")
+	require.NoError(t, err)
+	_, err = repo.AllocateNewLease(ctx, "peer-4")
+	require.NoError(t, err)
+
+	history, err := repo.GetLeaseHistory(ctx, allocated.TokenID)
+	require.NoError(t, err)
+
+	type event struct {
+		PeerID string
+		Event  models.LeaseEvent
+	}
+	events := make([]event, len(history))
+	for i, entry := range history {
+		assert.Equal(t, allocated.TokenID, entry.TokenID)
+		events[i] = event{entry.PeerID, entry.Event}
+	}
+	assert.Equal(t, []event{
+		{"peer-1", models.LeaseEventAllocate},
+		{"peer-1", models.LeaseEventRenew},
+		{"peer-1", models.LeaseEventRelease},
+		{"peer-1", models.LeaseEventExpire},
+		{"peer-2", models.LeaseEventAllocate},
+		{"peer-3", models.LeaseEventTransfer},
+	}, events)
+	assert.True(t, history[3].OccurredAt.Equal(history[2].ExpiresAt), "expiry is dated when the lease expired")
+
+	history, err = repo.GetLeaseHistory(ctx, firstTokenID+100)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestLeaseRepository_RecordConflict(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	lease, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+
+	_, err = repo.RecordConflict(ctx, lease.TokenID, "peer-2", 0)
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound, "only the holder can report a conflict")
+
+	t.Run("without quarantine the lease is kept", func(t *testing.T) {
+		conflict, err := repo.RecordConflict(ctx, lease.TokenID, "peer-1", 0)
+		require.NoError(t, err)
+		assert.False(t, conflict.Quarantined)
+
+		_, err = repo.GetLeaseByTokenID(ctx, lease.TokenID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("quarantine withholds the token ID", func(t *testing.T) {
+		conflict, err := repo.RecordConflict(ctx, lease.TokenID, "peer-1", time.Hour)
+		require.NoError(t, err)
+		assert.True(t, conflict.Quarantined)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), conflict.QuarantinedUntil, time.Minute)
+
+		_, err = repo.GetLeaseByPeerID(ctx, "peer-1")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound, "the lease is released")
+
+		reused, err := repo.FindAndReuseExpiredLease(ctx, "peer-3")
+		require.NoError(t, err)
+		assert.Nil(t, reused)
+		_, err = repo.AllocateRequestedLease(ctx, "peer-3", lease.TokenID)
+		assert.ErrorIs(t, err, domainErrors.ErrTokenIDInUse)
+	})
+
+	history, err := repo.GetLeaseHistory(ctx, lease.TokenID)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, models.LeaseEventConflict, history[1].Event)
+	assert.Equal(t, models.LeaseEventConflict, history[2].Event)
+	assert.Equal(t, models.LeaseEventRelease, history[3].Event)
+}
+
+func TestLeaseRepository_ReleaseGrace(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.Lease.ReleaseGrace = 300
+
+	t.Run("released token IDs are withheld", func(t *testing.T) {
+		repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+		lease, err := repo.AllocateNewLease(ctx, "peer-1")
+		require.NoError(t, err)
+		require.NoError(t, repo.ReleaseLease(ctx, lease.TokenID, "peer-1"))
+
+		reused, err := repo.FindAndReuseExpiredLease(ctx, "peer-2")
+		require.NoError(t, err)
+		assert.Nil(t, reused)
+		_, err = repo.AllocateRequestedLease(ctx, "peer-2", lease.TokenID)
+		assert.ErrorIs(t, err, domainErrors.ErrTokenIDInUse)
+
+		next, err := repo.AllocateNewLease(ctx, "peer-2")
+		require.NoError(t, err)
+		assert.NotEqual(t, lease.TokenID, next.TokenID)
+	})
+
+	t.Run("token IDs are reused after the grace period", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Lease.ReleaseGrace = 300
+		fakeClock := clock.NewFake(time.Now())
+		repo := embedded.NewLeaseRepository(cfg, newTestStoreWithClock(t, cfg, fakeClock))
+		first, err := repo.AllocateNewLease(ctx, "peer-1")
+		require.NoError(t, err)
+		second, err := repo.AllocateNewLease(ctx, "peer-2")
+		require.NoError(t, err)
+
+		require.NoError(t, repo.ReleaseLease(ctx, first.TokenID, "peer-1"))
+		fakeClock.Advance(5 * time.Minute)
+		require.NoError(t, repo.ReleaseLease(ctx, second.TokenID, "peer-2"))
+		fakeClock.Advance(time.Nanosecond)
+
+		reused, err := repo.FindAndReuseExpiredLease(ctx, "peer-3")
+		require.NoError(t, err)
+		require.NotNil(t, reused)
+		assert.Equal(t, first.TokenID, reused.TokenID)
+
+		reused, err = repo.FindAndReuseExpiredLease(ctx, "peer-4")
+		require.NoError(t, err)
+		assert.Nil(t, reused, "the lease released just now is still withheld")
+	})
+}
+
+func TestLeaseRepository_ExpiresAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	fakeClock := clock.NewFake(time.Now())
+	repo := embedded.NewLeaseRepository(cfg, newTestStoreWithClock(t, cfg, fakeClock))
+
+	lease, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Equal(t, fakeClock.Now().Add(120*time.Minute), lease.ExpiresAt)
+
+	fakeClock.Advance(120*time.Minute - time.Nanosecond)
+	_, err = repo.GetLeaseByPeerID(ctx, "peer-1")
+	require.NoError(t, err)
+
+	fakeClock.Advance(time.Nanosecond)
+	_, err = repo.GetLeaseByPeerID(ctx, "peer-1")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+	_, err = repo.RenewLease(ctx, lease.TokenID, "peer-1")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound, "expired leases cannot be renewed")
+
+	fakeClock.Advance(time.Nanosecond)
+	reused, err := repo.FindAndReuseExpiredLease(ctx, "peer-2")
+	require.NoError(t, err)
+	require.NotNil(t, reused)
+	assert.Equal(t, lease.TokenID, reused.TokenID)
+}
+
+func TestLeaseReadModel_GetLeaseStats(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	store := newTestStore(t, cfg)
+	repo := embedded.NewLeaseRepository(cfg, store)
+	readModel := embedded.NewLeaseReadModel(store)
+
+	for _, peerID := range []string{"peer-1", "peer-2"} {
+		_, err := repo.AllocateNewLease(ctx, peerID)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.ReleaseLease(ctx, firstTokenID, "peer-1"))
+
+	require.NoError(t, readModel.Refresh(ctx))
+	stats, err := readModel.GetLeaseStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Active)
+	assert.Equal(t, int64(1), stats.Expired)
+	assert.Equal(t, int64(2), stats.Total)
+}
+
+func TestLeaseReadModel_ListLeases(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	store := newTestStore(t, cfg)
+	repo := embedded.NewLeaseRepository(cfg, store)
+	readModel := embedded.NewLeaseReadModel(store)
+
+	for _, peerID := range []string{"peer-1", "peer-2", "peer-3", "other-4"} {
+		_, err := repo.AllocateNewLease(ctx, peerID)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.ReleaseLease(ctx, firstTokenID+1, "peer-2"))
+
+	tokenIDs := func(leases []*models.Lease) []int64 {
+		var ids []int64
+		for _, lease := range leases {
+			ids = append(ids, lease.TokenID)
+		}
+		return ids
+	}
+
+	t.Run("sorted by token ID with offset", func(t *testing.T) {
+		leases, err := readModel.ListLeases(ctx, &models.ListOptions{Limit: 2, Offset: 1, SortField: "token_id"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{firstTokenID + 1, firstTokenID + 2}, tokenIDs(leases))
+	})
+
+	t.Run("descending with cursor", func(t *testing.T) {
+		leases, err := readModel.ListLeases(ctx, &models.ListOptions{
+			Limit:      10,
+			SortField:  "token_id",
+			Descending: true,
+			After:      &models.Cursor{SortValue: int64(firstTokenID + 2), Key: firstTokenID + 2},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{firstTokenID + 1, firstTokenID}, tokenIDs(leases))
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		leases, err := readModel.ListLeases(ctx, &models.ListOptions{
+			Limit:     10,
+			SortField: "expires_at",
+			Filters: []models.Filter{
+				{Field: "peer_id", Operator: models.FilterPrefix, Value: "peer-"},
+				{Field: "expires_at", Operator: models.FilterGt, Value: time.Now()},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{firstTokenID, firstTokenID + 2}, tokenIDs(leases))
+	})
+
+	t.Run("peer ID sets", func(t *testing.T) {
+		for operator, expected := range map[models.FilterOperator][]int64{
+			models.FilterIn:    {firstTokenID, firstTokenID + 3},
+			models.FilterNotIn: {firstTokenID + 1, firstTokenID + 2},
+		} {
+			leases, err := readModel.ListLeases(ctx, &models.ListOptions{
+				Limit:     10,
+				SortField: "token_id",
+				Filters:   []models.Filter{{Field: "peer_id", Operator: operator, Value: []string{"peer-1", "other-4"}}},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, expected, tokenIDs(leases), operator)
+		}
+	})
+
+	t.Run("unsupported field", func(t *testing.T) {
+		_, err := readModel.ListLeases(ctx, &models.ListOptions{Limit: 10, SortField: "owner"})
+		assert.Error(t, err)
+	})
+}
+
+func TestLeaseRepository_ListExpiringLeases(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.Lease.TTL = 30
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	for _, peerID := range []string{"peer-1", "peer-2", "peer-3"} {
+		_, err := repo.AllocateNewLease(ctx, peerID)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.ReleaseLease(ctx, firstTokenID+1, "peer-2"))
+
+	leases, err := repo.ListExpiringLeases(ctx, time.Hour, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, leases, 2)
+	assert.Equal(t, int64(firstTokenID), leases[0].TokenID)
+	assert.Equal(t, int64(firstTokenID+2), leases[1].TokenID)
+	assert.Equal(t, models.DefaultTenantID, leases[0].TenantID)
+
+	page, err := repo.ListExpiringLeases(ctx, time.Hour, firstTokenID, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "peer-3", page[0].PeerID)
+
+	none, err := repo.ListExpiringLeases(ctx, 10*time.Minute, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestLeaseRepository_Tenants(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}}
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	ctx := context.Background()
+	acme := models.WithTenant(ctx, "acme")
+
+	t.Run("tenants allocate from their own pool", func(t *testing.T) {
+		lease, err := repo.AllocateNewLease(ctx, "peer-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID), lease.TokenID)
+
+		lease, err = repo.AllocateNewLease(acme, "peer-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(168200000), lease.TokenID)
+	})
+
+	t.Run("leases of other tenants are not visible", func(t *testing.T) {
+		lease, err := repo.GetLeaseByPeerID(acme, "peer-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(168200000), lease.TokenID)
+
+		_, err = repo.GetLeaseByTokenID(acme, firstTokenID)
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+	})
+
+	t.Run("unknown tenants are refused", func(t *testing.T) {
+		_, err := repo.AllocateNewLease(models.WithTenant(ctx, "other"), "peer-1")
+		assert.ErrorIs(t, err, domainErrors.ErrTenantNotFound)
+	})
+}
+
+func TestLeaseReadModel_Tenants(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}}
+	store := newTestStore(t, cfg)
+	repo := embedded.NewLeaseRepository(cfg, store)
+	readModel := embedded.NewLeaseReadModel(store)
+
+	ctx := context.Background()
+	acme := models.WithTenant(ctx, "acme")
+	for _, peerID := range []string{"peer-1", "peer-2"} {
+		_, err := repo.AllocateNewLease(ctx, peerID)
+		require.NoError(t, err)
+	}
+	_, err := repo.AllocateNewLease(acme, "peer-3")
+	require.NoError(t, err)
+	require.NoError(t, readModel.Refresh(ctx))
+
+	stats, err := readModel.GetLeaseStats(acme)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Total)
+	stats, err = readModel.GetLeaseStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Total)
+
+	leases, err := readModel.ListLeases(acme, &models.ListOptions{Limit: 10, SortField: "token_id"})
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, "peer-3", leases[0].PeerID)
+
+	leases, err = readModel.ListLeases(ctx, &models.ListOptions{
+		Limit:     10,
+		SortField: "token_id",
+		Filters:   []models.Filter{{Field: "peer_id", Operator: models.FilterEq, Value: "peer-3"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, leases, "the leases of another tenant are not listed")
+}
+
+func TestLeaseRepository_Delegation(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+
+	first, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	second, err := repo.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	require.NoError(t, repo.SetLeaseDelegator(ctx, first.TokenID, "gateway"))
+	require.NoError(t, repo.SetLeaseDelegator(ctx, second.TokenID, "gateway"))
+
+	count, err := repo.CountDelegatedLeases(ctx, "gateway")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// Released leases no longer count against the gateway
+	require.NoError(t, repo.ReleaseLease(ctx, second.TokenID, "peer-2"))
+	count, err = repo.CountDelegatedLeases(ctx, "gateway")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = repo.CountDelegatedLeases(ctx, "other-gateway")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestLeaseRepository_PoolExclusions(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	fakeClock := clock.NewFake(time.Now())
+	store := newTestStoreWithClock(t, cfg, fakeClock)
+
+	// Leased before its token ID is excluded
+	leased, err := embedded.NewLeaseRepository(cfg, store).AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	require.Equal(t, int64(firstTokenID), leased.TokenID)
+
+	cfg.PoolExclusions = []config.TokenRangeConfig{{FirstTokenID: firstTokenID, LastTokenID: firstTokenID + 2}}
+	repo := embedded.NewLeaseRepository(cfg, store)
+
+	lease, err := repo.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(firstTokenID+3), lease.TokenID, "the cursor passes over the excluded token IDs")
+
+	_, err = repo.AllocateRequestedLease(ctx, "peer-3", firstTokenID+1)
+	assert.ErrorIs(t, err, domainErrors.ErrTokenIDOutOfRange)
+
+	// The existing lease is kept until it ends, but its token ID is not handed out again
+	_, err = repo.RenewLease(ctx, leased.TokenID, "peer-1")
+	require.NoError(t, err)
+	require.NoError(t, repo.ReleaseLease(ctx, leased.TokenID, "peer-1"))
+	fakeClock.Advance(time.Nanosecond)
+
+	reused, err := repo.FindAndReuseExpiredLease(ctx, "peer-3")
+	require.NoError(t, err)
+	assert.Nil(t, reused)
+	_, err = repo.AllocateRequestedLease(ctx, "peer-3", leased.TokenID)
+	assert.ErrorIs(t, err, domainErrors.ErrTokenIDOutOfRange)
+}
+
+func TestLeaseRepository_ZoneLease(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.PoolZones = []config.ZoneRangeConfig{
+		{Zone: "rack-1", FirstTokenID: firstTokenID + 100, LastTokenID: firstTokenID + 100},
+		{Zone: "rack-1", FirstTokenID: firstTokenID + 200, LastTokenID: firstTokenID + 200},
+	}
+	fakeClock := clock.NewFake(time.Now())
+	repo := embedded.NewLeaseRepository(cfg, newTestStoreWithClock(t, cfg, fakeClock))
+
+	lease, err := repo.AllocateZoneLease(ctx, "peer-1", "rack-1")
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	assert.Equal(t, int64(firstTokenID+100), lease.TokenID)
+
+	lease, err = repo.AllocateZoneLease(ctx, "peer-2", "rack-1")
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	assert.Equal(t, int64(firstTokenID+200), lease.TokenID, "a zone spans all of its ranges")
+
+	// The zone is full
+	lease, err = repo.AllocateZoneLease(ctx, "peer-3", "rack-1")
+	require.NoError(t, err)
+	assert.Nil(t, lease)
+	lease, err = repo.AllocateZoneLease(ctx, "peer-3", "rack-2")
+	require.NoError(t, err)
+	assert.Nil(t, lease, "unknown zone")
+
+	// A released token ID of the zone is handed out again
+	require.NoError(t, repo.ReleaseLease(ctx, firstTokenID+100, "peer-1"))
+	fakeClock.Advance(time.Nanosecond)
+	lease, err = repo.AllocateZoneLease(ctx, "peer-3", "rack-1")
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	assert.Equal(t, int64(firstTokenID+100), lease.TokenID)
+}
+
+func TestLeaseRepository_Labels(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	store := newTestStore(t, cfg)
+	repo := embedded.NewLeaseRepository(cfg, store)
+	readModel := embedded.NewLeaseReadModel(store)
+
+	for _, peerID := range []string{"peer-1", "peer-2", "peer-3"} {
+		_, err := repo.AllocateNewLease(ctx, peerID)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.SetLeaseLabels(ctx, firstTokenID, "peer-1", map[string]string{"role": "validator"}))
+	require.NoError(t, repo.SetLeaseLabels(ctx, firstTokenID+1, "peer-2", map[string]string{"role": "relay"}))
+
+	lease, err := repo.GetLeaseByPeerID(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"role": "validator"}, lease.Labels)
+
+	// Only the holder of the active lease can label it
+	assert.ErrorIs(t, repo.SetLeaseLabels(ctx, firstTokenID, "peer-2", nil), domainErrors.ErrLeaseNotFound)
+
+	for operator, expected := range map[models.FilterOperator][]int64{
+		models.FilterEq: {firstTokenID},
+		models.FilterNe: {firstTokenID + 1, firstTokenID + 2},
+	} {
+		leases, err := readModel.ListLeases(ctx, &models.ListOptions{
+			Limit:     10,
+			SortField: "token_id",
+			Filters:   []models.Filter{{Field: "label", Operator: operator, Value: models.LeaseLabel{Key: "role", Value: "validator"}}},
+		})
+		require.NoError(t, err)
+		var tokenIDs []int64
+		for _, lease := range leases {
+			tokenIDs = append(tokenIDs, lease.TokenID)
+		}
+		assert.Equal(t, expected, tokenIDs, operator)
+	}
+
+	// The next holder of the token ID starts without labels
+	require.NoError(t, repo.ReleaseLease(ctx, firstTokenID, "peer-1"))
+	reused, err := repo.FindAndReuseExpiredLease(ctx, "peer-4")
+	require.NoError(t, err)
+	require.NotNil(t, reused)
+	assert.Equal(t, int64(firstTokenID), reused.TokenID)
+	assert.Nil(t, reused.Labels)
+
+	// Nil labels clear them
+	require.NoError(t, repo.SetLeaseLabels(ctx, firstTokenID+1, "peer-2", nil))
+	lease, err = repo.GetLeaseByTokenID(ctx, firstTokenID+1)
+	require.NoError(t, err)
+	assert.Nil(t, lease.Labels)
+}
@@ -0,0 +1,114 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+)
+
+func TestNonceRepository(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewNonceRepository(cfg, newTestStore(t, cfg))
+
+	nonce, err := repo.CreateNonce(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, nonce.ID)
+	assert.Equal(t, "peer-1", nonce.PeerID)
+	assert.False(t, nonce.Used)
+
+	fetched, err := repo.GetNonce(ctx, nonce.ID)
+	require.NoError(t, err)
+	assert.Equal(t, nonce.ID, fetched.ID)
+
+	_, err = repo.GetNonce(ctx, "unknown")
+	assert.ErrorIs(t, err, domainErrors.ErrNonceNotFound)
+
+	assert.ErrorIs(t, repo.ConsumeNonce(ctx, nonce.ID, "peer-2"), domainErrors.ErrNonceNotFound)
+	require.NoError(t, repo.ConsumeNonce(ctx, nonce.ID, "peer-1"))
+	assert.ErrorIs(t, repo.ConsumeNonce(ctx, nonce.ID, "peer-1"), domainErrors.ErrNonceUsed)
+
+	_, err = repo.GetNonce(ctx, nonce.ID)
+	assert.ErrorIs(t, err, domainErrors.ErrNonceUsed)
+}
+
+func TestNonceRepository_RevokeNonce(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := embedded.NewNonceRepository(cfg, newTestStore(t, cfg))
+
+	nonce, err := repo.CreateNonce(ctx, "peer-1")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, repo.RevokeNonce(ctx, nonce.ID, "peer-2"), domainErrors.ErrNonceNotFound)
+	require.NoError(t, repo.RevokeNonce(ctx, nonce.ID, "peer-1"))
+	assert.ErrorIs(t, repo.RevokeNonce(ctx, nonce.ID, "peer-1"), domainErrors.ErrNonceNotFound)
+
+	// A revoked nonce no longer authenticates
+	_, err = repo.GetNonce(ctx, nonce.ID)
+	assert.ErrorIs(t, err, domainErrors.ErrNonceNotFound)
+	assert.ErrorIs(t, repo.ConsumeNonce(ctx, nonce.ID, "peer-1"), domainErrors.ErrNonceNotFound)
+
+	used, err := repo.CreateNonce(ctx, "peer-1")
+	require.NoError(t, err)
+	require.NoError(t, repo.ConsumeNonce(ctx, used.ID, "peer-1"))
+	assert.ErrorIs(t, repo.RevokeNonce(ctx, used.ID, "peer-1"), domainErrors.ErrNonceUsed)
+}
+
+func TestNonceRepository_DeleteExpiredNonces(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.Nonce.TTL = 0
+	repo := embedded.NewNonceRepository(cfg, newTestStore(t, cfg))
+
+	nonce, err := repo.CreateNonce(ctx, "peer-1")
+	require.NoError(t, err)
+	for range 2 {
+		_, err = repo.CreateNonce(ctx, "peer-2")
+		require.NoError(t, err)
+	}
+
+	_, err = repo.GetNonce(ctx, nonce.ID)
+	assert.ErrorIs(t, err, domainErrors.ErrNonceExpired)
+
+	deleted, err := repo.DeleteExpiredNonces(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted, "limit caps a batch")
+
+	deleted, err = repo.DeleteExpiredNonces(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = repo.GetNonce(ctx, nonce.ID)
+	assert.ErrorIs(t, err, domainErrors.ErrNonceNotFound)
+}
+
+func TestNonceRepository_ListOutstandingNonces(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	fakeClock := clock.NewFake(time.Now())
+	repo := embedded.NewNonceRepository(cfg, newTestStoreWithClock(t, cfg, fakeClock))
+
+	first, err := repo.CreateNonce(ctx, "peer-1")
+	require.NoError(t, err)
+	fakeClock.Advance(time.Second)
+	second, err := repo.CreateNonce(ctx, "peer-1")
+	require.NoError(t, err)
+	consumed, err := repo.CreateNonce(ctx, "peer-1")
+	require.NoError(t, err)
+	require.NoError(t, repo.ConsumeNonce(ctx, consumed.ID, "peer-1"))
+	_, err = repo.CreateNonce(ctx, "peer-2")
+	require.NoError(t, err)
+
+	nonces, err := repo.ListOutstandingNonces(ctx, "peer-1")
+	require.NoError(t, err)
+	require.Len(t, nonces, 2)
+	assert.Equal(t, second.ID, nonces[0].ID)
+	assert.Equal(t, first.ID, nonces[1].ID)
+}
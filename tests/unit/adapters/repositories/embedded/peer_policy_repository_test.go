@@ -0,0 +1,49 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+)
+
+func TestPeerPolicyRepository(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	store, stop := newStoppableTestStore(t, cfg, clock.NewSystem())
+	repo := embedded.NewPeerPolicyRepository(store)
+
+	created, err := repo.CreatePeerPolicy(ctx, &models.PeerPolicy{Name: "lab", Peers: []string{"peer-a", "peer-b"}, CanAllocate: true, MaxPool: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	_, err = repo.CreatePeerPolicy(ctx, &models.PeerPolicy{Name: "lab", Peers: []string{"*"}})
+	assert.ErrorIs(t, err, domainErrors.ErrPeerPolicyExists)
+
+	_, err = repo.CreatePeerPolicy(ctx, &models.PeerPolicy{Name: "everyone", Peers: []string{"*"}, CanAllocate: true})
+	require.NoError(t, err)
+
+	// Policies survive a restart, in the order they are matched
+	stop()
+	repo = embedded.NewPeerPolicyRepository(newTestStore(t, cfg))
+	policies, err := repo.ListPeerPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, "lab", policies[0].Name)
+	assert.Equal(t, []string{"peer-a", "peer-b"}, policies[0].Peers)
+	assert.Equal(t, 1, policies[0].MaxPool)
+	assert.Equal(t, "everyone", policies[1].Name)
+
+	deleted, err := repo.DeletePeerPolicy(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "lab", deleted.Name)
+
+	_, err = repo.DeletePeerPolicy(ctx, created.ID)
+	assert.ErrorIs(t, err, domainErrors.ErrPeerPolicyNotFound)
+}
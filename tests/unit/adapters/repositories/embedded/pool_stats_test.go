@@ -0,0 +1,55 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+func TestPoolStatsRepository_CountPoolLeases(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339Nano) }
+
+	cfg := newTestConfig(t)
+	cfg.Lease.ReleaseGrace = 300
+	state := fmt.Sprintf(`{
+	"min_token_id": 10,
+	"max_token_id": 20,
+	"last_token_id": 14,
+	"leases": {
+		"11": {"token_id": 11, "peer_id": "peer-1", "expires_at": %[1]q, "created_at": %[3]q, "updated_at": %[3]q},
+		"12": {"token_id": 12, "peer_id": "peer-2", "expires_at": %[1]q, "created_at": %[3]q, "updated_at": %[3]q},
+		"13": {"token_id": 13, "peer_id": "peer-3", "expires_at": %[2]q, "created_at": %[3]q, "updated_at": %[3]q, "quarantined_until": %[1]q},
+		"14": {"token_id": 14, "peer_id": "peer-4", "expires_at": %[2]q, "created_at": %[3]q, "updated_at": %[3]q},
+		"15": {"token_id": 15, "peer_id": "peer-6", "expires_at": %[4]q, "created_at": %[3]q, "updated_at": %[3]q},
+		"31": {"token_id": 31, "peer_id": "peer-5", "tenant_id": "acme", "expires_at": %[1]q, "created_at": %[3]q, "updated_at": %[3]q}
+	},
+	"nonces": {},
+	"history": [
+		{"token_id": 14, "peer_id": "peer-4", "event": "allocate", "expires_at": %[2]q, "occurred_at": %[3]q},
+		{"token_id": 11, "peer_id": "peer-1", "event": "allocate", "expires_at": %[1]q, "occurred_at": %[4]q},
+		{"token_id": 14, "peer_id": "peer-4", "event": "expire", "expires_at": %[2]q, "occurred_at": %[3]q},
+		{"token_id": 12, "peer_id": "peer-2", "event": "allocate", "expires_at": %[1]q, "occurred_at": %[5]q},
+		{"token_id": 31, "peer_id": "peer-5", "tenant_id": "acme", "event": "allocate", "expires_at": %[1]q, "occurred_at": %[5]q},
+		{"token_id": 12, "peer_id": "peer-2", "event": "renew", "expires_at": %[1]q, "occurred_at": %[5]q}
+	]
+}`, at(time.Hour), at(-time.Minute), at(-2*time.Hour), at(-30*time.Minute), at(-time.Minute))
+	require.NoError(t, os.WriteFile(cfg.StoragePath, []byte(state), 0o600))
+
+	repo := embedded.NewPoolStatsRepository(cfg, newTestStore(t, cfg))
+
+	counts, err := repo.CountPoolLeases(context.Background(), time.Hour)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]*models.PoolLeaseCounts{
+		models.DefaultTenantID: {Allocated: 2, Reserved: 1, Grace: 1, ExpiredReclaimable: 1, Allocations: 2},
+		"acme":                 {Allocated: 1, Allocations: 1},
+	}, counts)
+}
@@ -0,0 +1,84 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+func TestLeaseSnapshotRepository_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	newConfig := func(t *testing.T) *config.AppConfig {
+		cfg := newTestConfig(t)
+		cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200001, PoolMaxTokenID: 168200010}}
+		return cfg
+	}
+
+	sourceCfg := newConfig(t)
+	source := newTestStore(t, sourceCfg)
+	leases := embedded.NewLeaseRepository(sourceCfg, source)
+	_, err := leases.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	second, err := leases.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	_, err = leases.AllocateNewLease(models.WithTenant(ctx, "acme"), "peer-3")
+	require.NoError(t, err)
+	_, err = leases.RecordConflict(ctx, second.TokenID, "peer-2", time.Hour)
+	require.NoError(t, err)
+
+	exported, err := embedded.NewLeaseSnapshotRepository(source).ExportLeaseState(ctx)
+	require.NoError(t, err)
+	require.Len(t, exported.Pools, 2)
+	assert.Equal(t, &models.SnapshotPool{Tenant: "acme", MinTokenID: 168200001, MaxTokenID: 168200010, LastTokenID: 168200001}, exported.Pools[1])
+	require.Len(t, exported.Leases, 3)
+	assert.Equal(t, "acme", exported.Leases[0].Tenant)
+	assert.Equal(t, "peer-1", exported.Leases[1].PeerID)
+	assert.NotNil(t, exported.Leases[2].QuarantinedUntil)
+
+	targetCfg := newConfig(t)
+	target := newTestStore(t, targetCfg)
+	snapshots := embedded.NewLeaseSnapshotRepository(target)
+
+	t.Run("import into an empty store", func(t *testing.T) {
+		report, err := snapshots.ImportLeaseState(ctx, exported, false)
+		require.NoError(t, err)
+		assert.Equal(t, &models.SnapshotImportReport{Pools: 2, Leases: 3}, report)
+
+		imported, err := snapshots.ExportLeaseState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, exported, imported)
+
+		// Allocation continues after the imported cursor
+		next, err := embedded.NewLeaseRepository(targetCfg, target).AllocateNewLease(ctx, "peer-4")
+		require.NoError(t, err)
+		assert.Equal(t, second.TokenID+1, next.TokenID)
+	})
+
+	t.Run("stored leases are kept without replace", func(t *testing.T) {
+		_, err := snapshots.ImportLeaseState(ctx, exported, false)
+		assert.ErrorIs(t, err, domainErrors.ErrStoreNotEmpty)
+
+		current, err := snapshots.ExportLeaseState(ctx)
+		require.NoError(t, err)
+		assert.Len(t, current.Leases, 4)
+	})
+
+	t.Run("replace resets pools missing from the snapshot", func(t *testing.T) {
+		partial := &models.LeaseSnapshot{Pools: exported.Pools[:1], Leases: exported.Leases[1:]}
+		report, err := snapshots.ImportLeaseState(ctx, partial, true)
+		require.NoError(t, err)
+		assert.Equal(t, &models.SnapshotImportReport{Pools: 1, Leases: 2, Replaced: 4}, report)
+
+		current, err := snapshots.ExportLeaseState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, partial.Pools, current.Pools)
+		assert.Equal(t, partial.Leases, current.Leases)
+	})
+}
@@ -0,0 +1,74 @@
+package embedded
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/fx/fxtest"
+)
+
+// legacyState is a data file of the versions that kept everything in one JSON document
+const legacyState = `{
+	"min_token_id": 10,
+	"max_token_id": 20,
+	"last_token_id": 11,
+	"pools": {"acme": {"min_token_id": 30, "max_token_id": 40, "last_token_id": 30}},
+	"leases": {
+		"11": {"token_id": 11, "peer_id": "peer-1", "expires_at": "2099-01-01T00:00:00Z", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"},
+		"30": {"token_id": 30, "peer_id": "peer-2", "tenant_id": "acme", "expires_at": "2099-01-01T00:00:00Z", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}
+	},
+	"nonces": {},
+	"history": [
+		{"token_id": 11, "peer_id": "peer-1", "event": "allocate", "expires_at": "2099-01-01T00:00:00Z", "occurred_at": "2024-01-01T00:00:00Z"}
+	],
+	"access_rules": {
+		"3": {"id": 3, "list": "deny", "subject_type": "peer_id", "subject": "peer-9", "created_at": "2024-01-01T00:00:00Z"}
+	},
+	"last_access_rule_id": 3
+}`
+
+func TestStore_LegacyFile(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	require.NoError(t, os.WriteFile(cfg.StoragePath, []byte(legacyState), 0o600))
+
+	store, stop := newStoppableTestStore(t, cfg, clock.NewSystem())
+	repo := embedded.NewLeaseRepository(cfg, store)
+	next, err := repo.AllocateNewLease(ctx, "peer-3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), next.TokenID, "the pool of the default tenant is kept")
+	rule, err := embedded.NewAccessRuleRepository(store).CreateAccessRule(ctx, &models.AccessRule{List: models.AccessListDeny, SubjectType: models.AccessSubjectPeerID, Subject: "peer-8"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), rule.ID, "the sequence of access rule IDs is kept")
+	stop()
+
+	data, err := os.ReadFile(cfg.StoragePath)
+	require.NoError(t, err)
+	assert.NotEqual(t, byte('{'), data[0], "the file is converted to a database")
+
+	repo = embedded.NewLeaseRepository(cfg, newTestStore(t, cfg))
+	history, err := repo.GetLeaseHistory(ctx, 11)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	lease, err := repo.GetLeaseByPeerID(models.WithTenant(ctx, "acme"), "peer-2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), lease.TokenID)
+	lease, err = repo.GetLeaseByPeerID(ctx, "peer-3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), lease.TokenID)
+}
+
+func TestStore_OpenedOnce(t *testing.T) {
+	cfg := newTestConfig(t)
+	newTestStore(t, cfg)
+
+	_, err := embedded.NewStore(fxtest.NewLifecycle(t), cfg, clock.NewSystem())
+	assert.ErrorContains(t, err, "in use by another process")
+}
@@ -0,0 +1,61 @@
+package encrypted
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/encrypted"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/fieldcrypt"
+)
+
+func TestAccessRuleRepository(t *testing.T) {
+	ctx := context.Background()
+	inner := embedded.NewAccessRuleRepository(embedded.NewMemoryStore(clock.NewSystem()))
+	repo := encrypted.NewAccessRuleRepository(inner, newTestCipher(t))
+
+	rule := &models.AccessRule{List: models.AccessListDeny, SubjectType: models.AccessSubjectPubkey, Subject: "CAESIA=="}
+	created, err := repo.CreateAccessRule(ctx, rule)
+	require.NoError(t, err)
+	assert.Equal(t, "CAESIA==", created.Subject)
+	assert.Equal(t, "CAESIA==", rule.Subject, "the rule is not modified")
+
+	stored, err := inner.ListAccessRules(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.True(t, strings.HasPrefix(stored[0].Subject, fieldcrypt.Prefix))
+
+	rules, err := repo.GetAccessRules(ctx, models.AccessSubjectPubkey, "CAESIA==")
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "CAESIA==", rules[0].Subject)
+
+	deleted, err := repo.DeleteAccessRule(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "CAESIA==", deleted.Subject)
+}
+
+func TestIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	store := encrypted.NewIdempotencyStore(memory.NewIdempotencyStore(clock.NewSystem()), newTestCipher(t))
+
+	stored, err := store.Claim(ctx, "peer-1:/v1/leases:key", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	response := &models.IdempotentResponse{Status: http.StatusOK, ContentType: "application/json", Body: []byte(`{"peer_id":"peer-1"}`)}
+	require.NoError(t, store.Complete(ctx, "peer-1:/v1/leases:key", response, time.Minute))
+	assert.Equal(t, `{"peer_id":"peer-1"}`, string(response.Body), "the response is not modified")
+
+	stored, err = store.Claim(ctx, "peer-1:/v1/leases:key", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, response, stored)
+}
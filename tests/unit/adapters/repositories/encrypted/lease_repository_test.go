@@ -0,0 +1,119 @@
+package encrypted
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/encrypted"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/fieldcrypt"
+)
+
+func newTestCipher(t *testing.T) *fieldcrypt.Cipher {
+	cipher, err := fieldcrypt.New(bytes.Repeat([]byte{7}, fieldcrypt.KeySize))
+	require.NoError(t, err)
+	return cipher
+}
+
+func TestLeaseRepository(t *testing.T) {
+	ctx := context.Background()
+	cipher := newTestCipher(t)
+	store := embedded.NewMemoryStore(clock.NewSystem())
+	inner := embedded.NewLeaseRepository(config.NewDefaultAppConfig(), store)
+	repo := encrypted.NewLeaseRepository(inner, cipher)
+
+	lease, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Equal(t, "peer-1", lease.PeerID)
+
+	t.Run("stores ciphertext only", func(t *testing.T) {
+		stored, err := inner.GetLeaseByTokenID(ctx, lease.TokenID)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(stored.PeerID, fieldcrypt.Prefix))
+
+		_, err = inner.GetLeaseByPeerID(ctx, "peer-1")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+	})
+
+	t.Run("looks leases up by plaintext peer ID", func(t *testing.T) {
+		found, err := repo.GetLeaseByPeerID(ctx, "peer-1")
+		require.NoError(t, err)
+		assert.Equal(t, lease.TokenID, found.TokenID)
+		assert.Equal(t, "peer-1", found.PeerID)
+
+		renewed, err := repo.RenewLease(ctx, lease.TokenID, "peer-1")
+		require.NoError(t, err)
+		assert.Equal(t, "peer-1", renewed.PeerID)
+	})
+
+	t.Run("decrypts batches, transfers and history", func(t *testing.T) {
+		operations := []*models.LeaseOperation{{Type: models.LeaseOperationAllocate, PeerID: "peer-2"}}
+		results, err := repo.ExecuteBatch(ctx, operations)
+		require.NoError(t, err)
+		require.NoError(t, results[0].Err)
+		assert.Equal(t, "peer-2", results[0].Lease.PeerID)
+		assert.Equal(t, "peer-2", operations[0].PeerID, "operations are not modified")
+
+		transferred, err := repo.TransferLease(ctx, lease.TokenID, "peer-1", "peer-3")
+		require.NoError(t, err)
+		assert.Equal(t, "peer-3", transferred.PeerID)
+
+		history, err := repo.GetLeaseHistory(ctx, lease.TokenID)
+		require.NoError(t, err)
+		require.NotEmpty(t, history)
+		for _, entry := range history {
+			assert.Contains(t, []string{"peer-1", "peer-3"}, entry.PeerID)
+		}
+	})
+
+	t.Run("snapshots carry plaintext peer IDs", func(t *testing.T) {
+		snapshots := encrypted.NewLeaseSnapshotRepository(embedded.NewLeaseSnapshotRepository(store), cipher)
+		exported, err := snapshots.ExportLeaseState(ctx)
+		require.NoError(t, err)
+		require.Len(t, exported.Leases, 2)
+		assert.Equal(t, "peer-3", exported.Leases[0].PeerID)
+
+		_, err = snapshots.ImportLeaseState(ctx, exported, true)
+		require.NoError(t, err)
+		assert.Equal(t, "peer-3", exported.Leases[0].PeerID, "the snapshot is not modified")
+
+		found, err := repo.GetLeaseByPeerID(ctx, "peer-3")
+		require.NoError(t, err)
+		assert.Equal(t, lease.TokenID, found.TokenID)
+	})
+}
+
+func TestLeaseReadModel(t *testing.T) {
+	ctx := context.Background()
+	cipher := newTestCipher(t)
+	store := embedded.NewMemoryStore(clock.NewSystem())
+	_, err := encrypted.NewLeaseRepository(embedded.NewLeaseRepository(config.NewDefaultAppConfig(), store), cipher).AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	readModel := encrypted.NewLeaseReadModel(embedded.NewLeaseReadModel(store), cipher)
+
+	leases, err := readModel.ListLeases(ctx, &models.ListOptions{
+		Limit:     10,
+		SortField: "token_id",
+		Filters:   []models.Filter{{Field: "peer_id", Operator: models.FilterEq, Value: "peer-1"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, "peer-1", leases[0].PeerID)
+
+	_, err = readModel.ListLeases(ctx, &models.ListOptions{Limit: 10, SortField: "peer_id"})
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidSort)
+	_, err = readModel.ListLeases(ctx, &models.ListOptions{
+		Limit:     10,
+		SortField: "token_id",
+		Filters:   []models.Filter{{Field: "peer_id", Operator: models.FilterPrefix, Value: "peer"}},
+	})
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidFilter)
+}
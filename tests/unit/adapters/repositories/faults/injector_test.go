@@ -0,0 +1,65 @@
+package faults
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/faults"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/zap"
+)
+
+func TestInjector_Inject(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	injector := faults.NewInjector(clk, zap.NewNop())
+
+	assert.NoError(t, injector.Inject(ctx, models.FaultTargetDatabase), "a target without a fault is not affected")
+
+	injector.SetFault(&models.Fault{Target: models.FaultTargetDatabase, ErrorRate: 1}, 0)
+	assert.ErrorIs(t, injector.Inject(ctx, models.FaultTargetDatabase), faults.ErrInjected)
+	assert.NoError(t, injector.Inject(ctx, models.FaultTargetCache), "faults only affect their target")
+
+	injector.ClearFault(models.FaultTargetDatabase)
+	assert.NoError(t, injector.Inject(ctx, models.FaultTargetDatabase))
+
+	t.Run("latency", func(t *testing.T) {
+		injector.SetFault(&models.Fault{Target: models.FaultTargetCache, LatencyMs: 20}, 0)
+		defer injector.ClearFault(models.FaultTargetCache)
+
+		start := time.Now()
+		assert.NoError(t, injector.Inject(ctx, models.FaultTargetCache))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, injector.Inject(canceled, models.FaultTargetCache), context.Canceled)
+	})
+
+	t.Run("partial failures", func(t *testing.T) {
+		injector.SetFault(&models.Fault{Target: models.FaultTargetCache, ErrorRate: 0.5}, 0)
+		defer injector.ClearFault(models.FaultTargetCache)
+
+		failed := 0
+		for range 1000 {
+			if injector.Inject(ctx, models.FaultTargetCache) != nil {
+				failed++
+			}
+		}
+		assert.InDelta(t, 500, failed, 100)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		fault := injector.SetFault(&models.Fault{Target: models.FaultTargetDatabase, ErrorRate: 1}, time.Minute)
+		assert.Equal(t, clk.Now().Add(time.Minute), fault.ExpiresAt)
+		require.Len(t, injector.Faults(), 1)
+
+		clk.Advance(time.Minute)
+		assert.NoError(t, injector.Inject(ctx, models.FaultTargetDatabase))
+		assert.Empty(t, injector.Faults())
+	})
+}
@@ -0,0 +1,57 @@
+package hybrid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/zap"
+)
+
+func TestAccessRuleRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	repo := hybrid.NewAccessRuleRepository(
+		embedded.NewAccessRuleRepository(embedded.NewMemoryStore(clock.NewSystem())),
+		memory.NewAccessRuleCache(&config.AppConfig{Security: config.SecurityConfig{AccessCacheTTL: 60}}, clock.NewSystem()),
+		zap.NewNop(),
+	)
+
+	// Cache that the peer has no rules
+	rules, err := repo.GetAccessRules(ctx, models.AccessSubjectPeerID, "peer-a")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	rule, err := repo.CreateAccessRule(ctx, &models.AccessRule{
+		List:        models.AccessListDeny,
+		SubjectType: models.AccessSubjectPeerID,
+		Subject:     "peer-a",
+		Reason:      "abuse",
+	})
+	require.NoError(t, err)
+
+	rules, err = repo.GetAccessRules(ctx, models.AccessSubjectPeerID, "peer-a")
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, rule.ID, rules[0].ID)
+
+	_, err = repo.CreateAccessRule(ctx, rule)
+	assert.ErrorIs(t, err, errors.ErrAccessRuleExists)
+
+	_, err = repo.DeleteAccessRule(ctx, rule.ID)
+	require.NoError(t, err)
+
+	rules, err = repo.GetAccessRules(ctx, models.AccessSubjectPeerID, "peer-a")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	_, err = repo.DeleteAccessRule(ctx, rule.ID)
+	assert.ErrorIs(t, err, errors.ErrAccessRuleNotFound)
+}
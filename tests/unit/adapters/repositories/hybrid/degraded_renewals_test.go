@@ -0,0 +1,185 @@
+package hybrid
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+var errDatabaseDown = fmt.Errorf("%w: %w", domainErrors.ErrDatabaseConnection, io.ErrUnexpectedEOF)
+
+func newDegradedRenewals(t *testing.T, writer *mocks.MockLeaseRenewalWriter, cache *mocks.MockLeaseCache) *hybrid.DegradedRenewals {
+	cfg := config.NewDefaultAppConfig()
+	cfg.DegradedRenewalsEnabled = true
+	cfg.DegradedJournalPath = filepath.Join(t.TempDir(), "renewals.journal")
+	cfg.DegradedReplayInterval = 3600 // replayed by the tests
+
+	lc := fxtest.NewLifecycle(t)
+	degraded, err := hybrid.NewDegradedRenewals(lc, cfg, writer, cache, clock.NewSystem(), zap.NewNop())
+	require.NoError(t, err)
+	lc.RequireStart()
+	t.Cleanup(func() { lc.RequireStop() })
+	return degraded
+}
+
+func TestNewDegradedRenewals_Disabled(t *testing.T) {
+	degraded, err := hybrid.NewDegradedRenewals(fxtest.NewLifecycle(t), config.NewDefaultAppConfig(), nil, nil, clock.NewSystem(), zap.NewNop())
+	assert.NoError(t, err)
+	assert.Nil(t, degraded)
+}
+
+func TestLeaseRepository_RenewLeaseDegraded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockWriter := mocks.NewMockLeaseRenewalWriter(ctrl)
+	degraded := newDegradedRenewals(t, mockWriter, mockCache)
+	hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, degraded, nil, zap.NewNop())
+
+	cached := &models.Lease{TokenID: 12345, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Minute), Signature: []byte("sig")}
+	mockRepo.EXPECT().RenewLease(gomock.Any(), int64(12345), "peer123").Return(nil, errDatabaseDown)
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(cached, nil)
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).Return(nil)
+
+	lease, err := hybridRepo.RenewLease(context.Background(), 12345, "peer123")
+	require.NoError(t, err)
+	assert.Equal(t, "peer123", lease.PeerID)
+	assert.WithinDuration(t, time.Now().Add(120*time.Minute), lease.ExpiresAt, time.Second)
+	assert.Equal(t, int32(7200), lease.Ttl)
+	assert.Nil(t, lease.Signature)
+	assert.Equal(t, 1, degraded.Pending())
+
+	// Replayed once the database is back
+	mockWriter.EXPECT().ApplyRenewals(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, renewals []*models.LeaseRenewal) (int, error) {
+			require.Len(t, renewals, 1)
+			assert.Equal(t, int64(12345), renewals[0].TokenID)
+			assert.Equal(t, "peer123", renewals[0].PeerID)
+			assert.Equal(t, models.DefaultTenantID, renewals[0].TenantID)
+			assert.True(t, lease.ExpiresAt.Equal(renewals[0].ExpiresAt))
+			return 1, nil
+		})
+	require.NoError(t, degraded.Replay(context.Background()))
+	assert.Equal(t, 0, degraded.Pending())
+}
+
+func TestLeaseRepository_RenewLeaseDegradedRefused(t *testing.T) {
+	tests := []struct {
+		name          string
+		cached        *models.Lease
+		cacheErr      error
+		expectedError error
+	}{
+		{
+			name:          "lease not cached",
+			cacheErr:      io.EOF,
+			expectedError: domainErrors.ErrStorageDegraded,
+		},
+		{
+			name:          "lease cached as not found",
+			expectedError: domainErrors.ErrLeaseNotFound,
+		},
+		{
+			name:          "lease of another peer",
+			cached:        &models.Lease{TokenID: 12345, PeerID: "peer456", ExpiresAt: time.Now().Add(time.Minute)},
+			expectedError: domainErrors.ErrLeaseNotFound,
+		},
+		{
+			name:          "expired lease",
+			cached:        &models.Lease{TokenID: 12345, PeerID: "peer123", ExpiresAt: time.Now().Add(-time.Minute)},
+			expectedError: domainErrors.ErrLeaseNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockLeaseRepository(ctrl)
+			mockCache := mocks.NewMockLeaseCache(ctrl)
+			degraded := newDegradedRenewals(t, mocks.NewMockLeaseRenewalWriter(ctrl), mockCache)
+			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, degraded, nil, zap.NewNop())
+
+			mockRepo.EXPECT().RenewLease(gomock.Any(), int64(12345), "peer123").Return(nil, errDatabaseDown)
+			mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(tt.cached, tt.cacheErr)
+
+			_, err := hybridRepo.RenewLease(context.Background(), 12345, "peer123")
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.Equal(t, 0, degraded.Pending())
+		})
+	}
+}
+
+func TestLeaseRepository_AllocationRefusedWhileDegraded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockRepo.EXPECT().AllocateNewLease(gomock.Any(), "peer123").Return(nil, errDatabaseDown).Times(2)
+
+	// Without degraded renewals the connection error is reported as is
+	_, err := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, zap.NewNop()).AllocateNewLease(context.Background(), "peer123")
+	assert.ErrorIs(t, err, domainErrors.ErrDatabaseConnection)
+	assert.NotErrorIs(t, err, domainErrors.ErrStorageDegraded)
+
+	degraded := newDegradedRenewals(t, mocks.NewMockLeaseRenewalWriter(ctrl), mockCache)
+	_, err = hybrid.NewLeaseRepository(mockRepo, mockCache, degraded, nil, zap.NewNop()).AllocateNewLease(context.Background(), "peer123")
+	assert.ErrorIs(t, err, domainErrors.ErrStorageDegraded)
+
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "STORAGE_DEGRADED", appErr.Code)
+}
+
+func TestLeaseRepository_ReplaysJournalBeforeAllocating(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockWriter := mocks.NewMockLeaseRenewalWriter(ctrl)
+	degraded := newDegradedRenewals(t, mockWriter, mockCache)
+	hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, degraded, nil, zap.NewNop())
+
+	cached := &models.Lease{TokenID: 12345, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Minute)}
+	mockRepo.EXPECT().RenewLease(gomock.Any(), int64(12345), "peer123").Return(nil, errDatabaseDown)
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(cached, nil)
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	_, err := hybridRepo.RenewLease(context.Background(), 12345, "peer123")
+	require.NoError(t, err)
+
+	// The renewal must reach the database before its token ID could be reused
+	gomock.InOrder(
+		mockWriter.EXPECT().ApplyRenewals(gomock.Any(), gomock.Any()).Return(0, errDatabaseDown),
+		mockWriter.EXPECT().ApplyRenewals(gomock.Any(), gomock.Any()).Return(1, nil),
+		mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer456").Return(&models.Lease{TokenID: 67890, PeerID: "peer456"}, nil),
+	)
+
+	_, err = hybridRepo.FindAndReuseExpiredLease(context.Background(), "peer456")
+	assert.ErrorIs(t, err, domainErrors.ErrStorageDegraded)
+	assert.Equal(t, 1, degraded.Pending())
+
+	lease, err := hybridRepo.FindAndReuseExpiredLease(context.Background(), "peer456")
+	require.NoError(t, err)
+	assert.Equal(t, int64(67890), lease.TokenID)
+	assert.Equal(t, 0, degraded.Pending())
+}
@@ -3,14 +3,16 @@ package hybrid
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 	"go.uber.org/zap"
 )
 
@@ -78,6 +80,26 @@ func TestLeaseRepository_GetLeaseByPeerID(t *testing.T) {
 			expectedLease: nil,
 			expectedError: errors.New("not found"),
 		},
+		{
+			name:   "cache miss, lease not found is cached",
+			peerID: "peer000",
+			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockLeaseRepository, mockCache *mocks.MockLeaseCache) {
+				mockCache.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer000").Return(nil, errors.New("not found"))
+				mockRepo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer000").Return(nil, domainErrors.ErrLeaseNotFound)
+				mockCache.EXPECT().SetPeerNotFound(gomock.Any(), "peer000").Return(nil)
+			},
+			expectedLease: nil,
+			expectedError: domainErrors.ErrLeaseNotFound,
+		},
+		{
+			name:   "cached not found skips database",
+			peerID: "peer000",
+			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockLeaseRepository, mockCache *mocks.MockLeaseCache) {
+				mockCache.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer000").Return(nil, nil)
+			},
+			expectedLease: nil,
+			expectedError: domainErrors.ErrLeaseNotFound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -91,7 +113,7 @@ func TestLeaseRepository_GetLeaseByPeerID(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, logger)
 
 			result, err := hybridRepo.GetLeaseByPeerID(context.Background(), tt.peerID)
 
@@ -162,6 +184,26 @@ func TestLeaseRepository_GetLeaseByTokenID(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name:    "cache miss, lease not found is cached",
+			tokenID: 22222,
+			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockLeaseRepository, mockCache *mocks.MockLeaseCache) {
+				mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(22222)).Return(nil, errors.New("not found"))
+				mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(22222)).Return(nil, domainErrors.ErrLeaseNotFound)
+				mockCache.EXPECT().SetTokenNotFound(gomock.Any(), int64(22222)).Return(nil)
+			},
+			expectedLease: nil,
+			expectedError: domainErrors.ErrLeaseNotFound,
+		},
+		{
+			name:    "cached not found skips database",
+			tokenID: 22222,
+			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockLeaseRepository, mockCache *mocks.MockLeaseCache) {
+				mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(22222)).Return(nil, nil)
+			},
+			expectedLease: nil,
+			expectedError: domainErrors.ErrLeaseNotFound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -175,7 +217,7 @@ func TestLeaseRepository_GetLeaseByTokenID(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, logger)
 
 			result, err := hybridRepo.GetLeaseByTokenID(context.Background(), tt.tokenID)
 
@@ -245,6 +287,8 @@ func TestLeaseRepository_AllocateNewLease(t *testing.T) {
 				}
 				mockRepo.EXPECT().AllocateNewLease(gomock.Any(), "peer789").Return(expectedLease, nil)
 				mockCache.EXPECT().SetLease(gomock.Any(), expectedLease).Return(errors.New("cache error"))
+				// Stale "not found" entries are evicted instead
+				mockCache.EXPECT().DeleteLease(gomock.Any(), "peer789", int64(67890)).Return(nil)
 			},
 			expectedLease: &models.Lease{
 				TokenID:   67890,
@@ -268,7 +312,7 @@ func TestLeaseRepository_AllocateNewLease(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, logger)
 
 			result, err := hybridRepo.AllocateNewLease(context.Background(), tt.peerID)
 
@@ -341,7 +385,7 @@ func TestLeaseRepository_RenewLease(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, logger)
 
 			result, err := hybridRepo.RenewLease(context.Background(), tt.tokenID, tt.peerID)
 
@@ -408,7 +452,7 @@ func TestLeaseRepository_ReleaseLease(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, logger)
 
 			err := hybridRepo.ReleaseLease(context.Background(), tt.tokenID, tt.peerID)
 
@@ -421,3 +465,82 @@ func TestLeaseRepository_ReleaseLease(t *testing.T) {
 		})
 	}
 }
+
+func TestLeaseRepository_ExecuteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, zap.NewNop())
+
+	operations := []*models.LeaseOperation{
+		{Type: models.LeaseOperationAllocate, PeerID: "peer1"},
+		{Type: models.LeaseOperationRenew, PeerID: "peer2", TokenID: 200},
+		{Type: models.LeaseOperationRelease, PeerID: "peer3", TokenID: 300},
+	}
+	allocated := &models.Lease{TokenID: 100, PeerID: "peer1", Ttl: 3600}
+
+	mockRepo.EXPECT().ExecuteBatch(gomock.Any(), operations).Return([]*models.LeaseOperationResult{
+		{Lease: allocated},
+		{Err: errors.New("lease not found")},
+		{},
+	}, nil)
+	// Only successful operations reach the cache, in a single update
+	mockCache.EXPECT().UpdateLeases(gomock.Any(),
+		[]*models.Lease{allocated},
+		[]*models.Lease{{PeerID: "peer3", TokenID: 300}},
+	).Return(errors.New("cache error"))
+	// Allocated leases are evicted when they could not be cached
+	mockCache.EXPECT().UpdateLeases(gomock.Any(), ([]*models.Lease)(nil), []*models.Lease{allocated}).Return(nil)
+
+	results, err := hybridRepo.ExecuteBatch(context.Background(), operations)
+
+	assert.NoError(t, err) // Cache error should not fail the batch
+	assert.Len(t, results, 3)
+	assert.Equal(t, allocated, results[0].Lease)
+	assert.Error(t, results[1].Err)
+}
+
+func TestLeaseRepository_CacheStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockCache.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(&models.Lease{TokenID: 12345, PeerID: "peer123"}, nil)
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(&models.Lease{TokenID: 12345, PeerID: "peer123"}, nil)
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(67890)).Return(nil, errors.New("not found"))
+	mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(67890)).Return(&models.Lease{TokenID: 67890, PeerID: "peer456"}, nil)
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, zap.NewNop())
+	assert.Equal(t, &models.CacheStats{}, hybridRepo.CacheStats())
+
+	_, _ = hybridRepo.GetLeaseByPeerID(context.Background(), "peer123")
+	_, _ = hybridRepo.GetLeaseByTokenID(context.Background(), 12345)
+	_, _ = hybridRepo.GetLeaseByTokenID(context.Background(), 67890)
+
+	stats := hybridRepo.CacheStats()
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.InDelta(t, 2.0/3, stats.HitRate, 1e-9)
+}
+
+func TestLeaseRepository_RetriesTransientDBErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(nil, errors.New("not found"))
+	gomock.InOrder(
+		mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(nil, io.ErrUnexpectedEOF),
+		mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(&models.Lease{TokenID: 12345, PeerID: "peer123"}, nil),
+	)
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).Return(nil)
+
+	lease, err := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, nil, zap.NewNop()).GetLeaseByTokenID(context.Background(), 12345)
+	assert.NoError(t, err)
+	assert.Equal(t, "peer123", lease.PeerID)
+}
@@ -6,11 +6,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 	"go.uber.org/zap"
 )
 
@@ -115,7 +120,7 @@ func TestNonceRepository_GetNonce(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, 0, clock.NewSystem(), logger)
 
 			result, err := hybridRepo.GetNonce(context.Background(), tt.nonceID)
 
@@ -209,7 +214,7 @@ func TestNonceRepository_CreateNonce(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, 0, clock.NewSystem(), logger)
 
 			result, err := hybridRepo.CreateNonce(context.Background(), tt.peerID)
 
@@ -277,7 +282,7 @@ func TestNonceRepository_ConsumeNonce(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, 0, clock.NewSystem(), logger)
 
 			err := hybridRepo.ConsumeNonce(context.Background(), tt.nonceID, tt.peerID)
 
@@ -300,14 +305,14 @@ func TestNonceRepository_DeleteExpiredNonces(t *testing.T) {
 		{
 			name: "successful cleanup",
 			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockNonceRepository, mockCache *mocks.MockNonceCache) {
-				mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any()).Return(nil)
+				mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 100).Return(int64(3), nil)
 			},
 			expectedError: nil,
 		},
 		{
 			name: "database error",
 			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockNonceRepository, mockCache *mocks.MockNonceCache) {
-				mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any()).Return(errors.New("database error"))
+				mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 100).Return(int64(0), errors.New("database error"))
 			},
 			expectedError: errors.New("database error"),
 		},
@@ -324,16 +329,129 @@ func TestNonceRepository_DeleteExpiredNonces(t *testing.T) {
 
 			tt.mockSetup(ctrl, mockRepo, mockCache)
 
-			hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, logger)
+			hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, 0, clock.NewSystem(), logger)
 
-			err := hybridRepo.DeleteExpiredNonces(context.Background())
+			deleted, err := hybridRepo.DeleteExpiredNonces(context.Background(), 100)
 
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedError.Error(), err.Error())
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, int64(3), deleted)
 			}
 		})
 	}
 }
+
+func TestNonceRepository_ListOutstandingNonces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNonceRepository(ctrl)
+	mockCache := mocks.NewMockNonceCache(ctrl)
+	outstanding := []*models.Nonce{{ID: "nonce-1", PeerID: "peer-1"}}
+	mockRepo.EXPECT().ListOutstandingNonces(gomock.Any(), "peer-1").Return(outstanding, nil)
+
+	hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, 0, clock.NewSystem(), zap.NewNop())
+
+	nonces, err := hybridRepo.ListOutstandingNonces(context.Background(), "peer-1")
+	assert.NoError(t, err)
+	assert.Equal(t, outstanding, nonces)
+}
+
+func TestNonceRepository_RevokeNonce(t *testing.T) {
+	t.Run("removes the nonce from the database and the cache", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockCache := mocks.NewMockNonceCache(ctrl)
+		mockRepo.EXPECT().RevokeNonce(gomock.Any(), "nonce-1", "peer-1").Return(nil)
+		mockCache.EXPECT().DeleteNonce(gomock.Any(), "nonce-1").Return(errors.New("cache error"))
+
+		hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, 0, clock.NewSystem(), zap.NewNop())
+
+		assert.NoError(t, hybridRepo.RevokeNonce(context.Background(), "nonce-1", "peer-1"))
+	})
+
+	t.Run("unknown nonce", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockCache := mocks.NewMockNonceCache(ctrl)
+		mockRepo.EXPECT().RevokeNonce(gomock.Any(), "nonce-1", "peer-1").Return(domainErrors.ErrNonceNotFound)
+
+		hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, 0, clock.NewSystem(), zap.NewNop())
+
+		assert.ErrorIs(t, hybridRepo.RevokeNonce(context.Background(), "nonce-1", "peer-1"), domainErrors.ErrNonceNotFound)
+	})
+
+	t.Run("revokes nonces issued from the cache while the database is down", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		cache := memory.NewNonceCache(config.NewDefaultAppConfig(), clock.NewSystem())
+		mockRepo.EXPECT().RevokeNonce(gomock.Any(), gomock.Any(), gomock.Any()).Return(errDatabaseDown).Times(2)
+		require.NoError(t, cache.CreateNonce(ctx, &models.Nonce{ID: "nonce-1", PeerID: "peer-1", ExpiresAt: time.Now().Add(time.Minute)}))
+
+		hybridRepo := hybrid.NewNonceRepository(mockRepo, cache, 5*time.Minute, clock.NewSystem(), zap.NewNop())
+
+		assert.NoError(t, hybridRepo.RevokeNonce(ctx, "nonce-1", "peer-1"))
+		assert.ErrorIs(t, hybridRepo.RevokeNonce(ctx, "nonce-1", "peer-1"), domainErrors.ErrNonceNotFound)
+	})
+}
+
+func TestNonceRepository_Degraded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockRepo := mocks.NewMockNonceRepository(ctrl)
+	cache := memory.NewNonceCache(config.NewDefaultAppConfig(), clock.NewSystem())
+	mockRepo.EXPECT().ListOutstandingNonces(gomock.Any(), "peer-1").Return(nil, errDatabaseDown)
+	mockRepo.EXPECT().CreateNonce(gomock.Any(), "peer-1").Return(nil, errDatabaseDown)
+	mockRepo.EXPECT().ConsumeNonce(gomock.Any(), gomock.Any(), gomock.Any()).Return(errDatabaseDown).Times(3)
+
+	hybridRepo := hybrid.NewNonceRepository(mockRepo, cache, 5*time.Minute, clock.NewSystem(), zap.NewNop())
+
+	// The outstanding nonce cap is lifted
+	nonces, err := hybridRepo.ListOutstandingNonces(ctx, "peer-1")
+	assert.NoError(t, err)
+	assert.Empty(t, nonces)
+
+	// Nonces are issued from the cache alone
+	nonce, err := hybridRepo.CreateNonce(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Equal(t, "peer-1", nonce.PeerID)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), nonce.ExpiresAt, time.Second)
+
+	cached, err := hybridRepo.GetNonce(ctx, nonce.ID)
+	require.NoError(t, err)
+	assert.Equal(t, nonce.ID, cached.ID)
+
+	// A nonce is consumed once, and only by its peer
+	require.NoError(t, cache.CreateNonce(ctx, &models.Nonce{ID: "nonce-2", PeerID: "peer-2", ExpiresAt: time.Now().Add(time.Minute)}))
+	assert.ErrorIs(t, hybridRepo.ConsumeNonce(ctx, "nonce-2", "peer-1"), domainErrors.ErrNonceNotFound)
+	assert.NoError(t, hybridRepo.ConsumeNonce(ctx, nonce.ID, "peer-1"))
+	assert.ErrorIs(t, hybridRepo.ConsumeNonce(ctx, nonce.ID, "peer-1"), domainErrors.ErrNonceNotFound)
+}
+
+func TestNonceRepository_DatabaseDownWithoutDegradedMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNonceRepository(ctrl)
+	mockCache := mocks.NewMockNonceCache(ctrl)
+	mockRepo.EXPECT().CreateNonce(gomock.Any(), "peer-1").Return(nil, errDatabaseDown)
+	mockRepo.EXPECT().ConsumeNonce(gomock.Any(), "nonce-1", "peer-1").Return(errDatabaseDown)
+
+	hybridRepo := hybrid.NewNonceRepository(mockRepo, mockCache, 0, clock.NewSystem(), zap.NewNop())
+
+	_, err := hybridRepo.CreateNonce(context.Background(), "peer-1")
+	assert.ErrorIs(t, err, domainErrors.ErrDatabaseConnection)
+	assert.ErrorIs(t, hybridRepo.ConsumeNonce(context.Background(), "nonce-1", "peer-1"), domainErrors.ErrDatabaseConnection)
+}
@@ -0,0 +1,121 @@
+package hybrid
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+func testRenewal(tokenID int64) *models.LeaseRenewal {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &models.LeaseRenewal{
+		TokenID:   tokenID,
+		PeerID:    "peer123",
+		TenantID:  models.DefaultTenantID,
+		ExpiresAt: now.Add(time.Hour),
+		RenewedAt: now,
+	}
+}
+
+func TestRenewalJournal_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal", "renewals.journal")
+
+	journal, err := hybrid.OpenRenewalJournal(path)
+	require.NoError(t, err)
+	require.NoError(t, journal.Append(testRenewal(1)))
+	require.NoError(t, journal.Append(testRenewal(2)))
+	require.NoError(t, journal.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := hybrid.OpenRenewalJournal(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+	assert.Equal(t, 2, reopened.Pending())
+
+	var drained []*models.LeaseRenewal
+	require.NoError(t, reopened.Drain(func(renewals []*models.LeaseRenewal) error {
+		drained = renewals
+		return nil
+	}))
+	require.Len(t, drained, 2)
+	assert.Equal(t, int64(1), drained[0].TokenID)
+	assert.Equal(t, int64(2), drained[1].TokenID)
+	assert.Equal(t, 0, reopened.Pending())
+}
+
+func TestRenewalJournal_DiscardsTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "renewals.journal")
+
+	journal, err := hybrid.OpenRenewalJournal(path)
+	require.NoError(t, err)
+	require.NoError(t, journal.Append(testRenewal(1)))
+	require.NoError(t, journal.Close())
+
+	// A crash in the middle of a write leaves half a line behind
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"token_id":2,"peer_`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	reopened, err := hybrid.OpenRenewalJournal(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+	assert.Equal(t, 1, reopened.Pending())
+
+	// New entries start on a line of their own
+	require.NoError(t, reopened.Append(testRenewal(3)))
+	var tokenIDs []int64
+	require.NoError(t, reopened.Drain(func(renewals []*models.LeaseRenewal) error {
+		for _, renewal := range renewals {
+			tokenIDs = append(tokenIDs, renewal.TokenID)
+		}
+		return nil
+	}))
+	assert.Equal(t, []int64{1, 3}, tokenIDs)
+}
+
+func TestRenewalJournal_Drain(t *testing.T) {
+	journal, err := hybrid.OpenRenewalJournal(filepath.Join(t.TempDir(), "renewals.journal"))
+	require.NoError(t, err)
+	defer journal.Close()
+
+	require.NoError(t, journal.Drain(func(renewals []*models.LeaseRenewal) error {
+		t.Fatal("an empty journal is not drained")
+		return nil
+	}))
+
+	require.NoError(t, journal.Append(testRenewal(1)))
+
+	// A failed drain keeps the renewals
+	replayErr := errors.New("database unreachable")
+	err = journal.Drain(func(renewals []*models.LeaseRenewal) error { return replayErr })
+	assert.ErrorIs(t, err, replayErr)
+	assert.Equal(t, 1, journal.Pending())
+
+	// Renewals appended during a drain are kept for the next one
+	require.NoError(t, journal.Drain(func(renewals []*models.LeaseRenewal) error {
+		assert.Len(t, renewals, 1)
+		return journal.Append(testRenewal(2))
+	}))
+	assert.Equal(t, 1, journal.Pending())
+
+	var drained []*models.LeaseRenewal
+	require.NoError(t, journal.Drain(func(renewals []*models.LeaseRenewal) error {
+		drained = renewals
+		return nil
+	}))
+	require.Len(t, drained, 1)
+	assert.Equal(t, int64(2), drained[0].TokenID)
+	assert.Equal(t, 0, journal.Pending())
+}
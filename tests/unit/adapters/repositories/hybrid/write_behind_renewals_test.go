@@ -0,0 +1,214 @@
+package hybrid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/hybrid"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// newWriteBehindRenewals starts write-behind renewals that flush once a minute or every
+// batchSize renewals, and on stop
+func newWriteBehindRenewals(t *testing.T, writer *mocks.MockLeaseRenewalWriter, cache *mocks.MockLeaseCache, batchSize int) (*hybrid.WriteBehindRenewals, *fxtest.Lifecycle) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Lease.WriteBehindInterval = 60
+	cfg.Lease.WriteBehindBatchSize = batchSize
+
+	lc := fxtest.NewLifecycle(t)
+	writeBehind := hybrid.NewWriteBehindRenewals(lc, cfg, writer, cache, clock.NewSystem(), zap.NewNop())
+	lc.RequireStart()
+	return writeBehind, lc
+}
+
+func TestNewWriteBehindRenewals_Disabled(t *testing.T) {
+	assert.Nil(t, hybrid.NewWriteBehindRenewals(fxtest.NewLifecycle(t), config.NewDefaultAppConfig(), nil, nil, clock.NewSystem(), zap.NewNop()))
+}
+
+func TestLeaseRepository_RenewLeaseWriteBehind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockWriter := mocks.NewMockLeaseRenewalWriter(ctrl)
+	writeBehind, lc := newWriteBehindRenewals(t, mockWriter, mockCache, 100)
+	hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, writeBehind, zap.NewNop())
+
+	cached := &models.Lease{TokenID: 12345, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Hour)}
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(cached, nil).Times(3)
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+	// Renewals are answered from the cache and coalesced
+	var lease *models.Lease
+	for range 3 {
+		var err error
+		lease, err = hybridRepo.RenewLease(context.Background(), 12345, "peer123")
+		require.NoError(t, err)
+	}
+	assert.WithinDuration(t, time.Now().Add(120*time.Minute), lease.ExpiresAt, time.Second)
+	assert.Equal(t, 1, writeBehind.Pending())
+
+	// Flushed on shutdown
+	mockWriter.EXPECT().ApplyRenewals(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, renewals []*models.LeaseRenewal) (int, error) {
+			require.Len(t, renewals, 1)
+			assert.Equal(t, int64(12345), renewals[0].TokenID)
+			assert.True(t, lease.ExpiresAt.Equal(renewals[0].ExpiresAt))
+			return 1, nil
+		})
+	lc.RequireStop()
+	assert.Equal(t, 0, writeBehind.Pending())
+}
+
+func TestLeaseRepository_RenewLeaseWritesThrough(t *testing.T) {
+	tests := []struct {
+		name   string
+		cached *models.Lease
+	}{
+		{
+			name: "lease not cached",
+		},
+		{
+			name:   "lease expiring before the next flush",
+			cached: &models.Lease{TokenID: 12345, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Minute)},
+		},
+		{
+			name:   "lease of another peer",
+			cached: &models.Lease{TokenID: 12345, PeerID: "peer456", ExpiresAt: time.Now().Add(time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockLeaseRepository(ctrl)
+			mockCache := mocks.NewMockLeaseCache(ctrl)
+			writeBehind, lc := newWriteBehindRenewals(t, mocks.NewMockLeaseRenewalWriter(ctrl), mockCache, 100)
+			defer lc.RequireStop()
+			hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, writeBehind, zap.NewNop())
+
+			renewed := &models.Lease{TokenID: 12345, PeerID: "peer123", ExpiresAt: time.Now().Add(2 * time.Hour)}
+			mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(tt.cached, nil)
+			mockRepo.EXPECT().RenewLease(gomock.Any(), int64(12345), "peer123").Return(renewed, nil)
+			mockCache.EXPECT().SetLease(gomock.Any(), renewed).Return(nil)
+
+			lease, err := hybridRepo.RenewLease(context.Background(), 12345, "peer123")
+			require.NoError(t, err)
+			assert.Equal(t, renewed, lease)
+			assert.Equal(t, 0, writeBehind.Pending())
+		})
+	}
+}
+
+func TestWriteBehindRenewals_FlushesFullBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockWriter := mocks.NewMockLeaseRenewalWriter(ctrl)
+	writeBehind, lc := newWriteBehindRenewals(t, mockWriter, mockCache, 2)
+	defer lc.RequireStop()
+
+	for _, tokenID := range []int64{1, 2} {
+		mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), tokenID).Return(&models.Lease{TokenID: tokenID, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	}
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	mockWriter.EXPECT().ApplyRenewals(gomock.Any(), gomock.Len(2)).Return(2, nil)
+
+	for _, tokenID := range []int64{1, 2} {
+		_, ok := writeBehind.Renew(context.Background(), tokenID, "peer123")
+		require.True(t, ok)
+	}
+
+	assert.Eventually(t, func() bool { return writeBehind.Pending() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestWriteBehindRenewals_FailedFlushKeepsNewerRenewals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockWriter := mocks.NewMockLeaseRenewalWriter(ctrl)
+	writeBehind, lc := newWriteBehindRenewals(t, mockWriter, mockCache, 100)
+	defer lc.RequireStop()
+
+	cached := &models.Lease{TokenID: 12345, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Hour)}
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(cached, nil).Times(2)
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	first, ok := writeBehind.Renew(context.Background(), 12345, "peer123")
+	require.True(t, ok)
+
+	var second *models.Lease
+	gomock.InOrder(
+		// The lease is renewed again while the first flush is running, which then fails
+		mockWriter.EXPECT().ApplyRenewals(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, renewals []*models.LeaseRenewal) (int, error) {
+				assert.True(t, first.ExpiresAt.Equal(renewals[0].ExpiresAt))
+				second, ok = writeBehind.Renew(context.Background(), 12345, "peer123")
+				require.True(t, ok)
+				return 0, errDatabaseDown
+			}),
+		mockWriter.EXPECT().ApplyRenewals(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, renewals []*models.LeaseRenewal) (int, error) {
+				require.Len(t, renewals, 1)
+				assert.True(t, second.ExpiresAt.Equal(renewals[0].ExpiresAt))
+				return 1, nil
+			}),
+	)
+
+	assert.ErrorIs(t, writeBehind.Flush(context.Background()), errDatabaseDown)
+	assert.Equal(t, 1, writeBehind.Pending())
+	require.NoError(t, writeBehind.Flush(context.Background()))
+	assert.Equal(t, 0, writeBehind.Pending())
+}
+
+func TestLeaseRepository_ReloadReconcilesBufferedRenewal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockCache := mocks.NewMockLeaseCache(ctrl)
+	mockWriter := mocks.NewMockLeaseRenewalWriter(ctrl)
+	writeBehind, lc := newWriteBehindRenewals(t, mockWriter, mockCache, 100)
+	hybridRepo := hybrid.NewLeaseRepository(mockRepo, mockCache, nil, writeBehind, zap.NewNop())
+
+	stored := &models.Lease{TokenID: 12345, PeerID: "peer123", UpdatedAt: time.Now().Add(-time.Hour), ExpiresAt: time.Now().Add(time.Hour)}
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(stored, nil)
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).Return(nil)
+	renewed, ok := writeBehind.Renew(context.Background(), 12345, "peer123")
+	require.True(t, ok)
+
+	// The cache entry is evicted before the flush, so the stale lease is read again
+	mockCache.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(nil, assert.AnError)
+	mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(12345)).Return(stored, nil)
+	mockCache.EXPECT().SetLease(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, lease *models.Lease) error {
+		assert.True(t, renewed.ExpiresAt.Equal(lease.ExpiresAt))
+		return nil
+	})
+
+	lease, err := hybridRepo.GetLeaseByTokenID(context.Background(), 12345)
+	require.NoError(t, err)
+	assert.True(t, renewed.ExpiresAt.Equal(lease.ExpiresAt))
+	assert.Equal(t, 1, writeBehind.Pending())
+
+	// A release supersedes the buffered renewal
+	mockRepo.EXPECT().ReleaseLease(gomock.Any(), int64(12345), "peer123").Return(nil)
+	mockCache.EXPECT().DeleteLease(gomock.Any(), "peer123", int64(12345)).Return(nil)
+	require.NoError(t, hybridRepo.ReleaseLease(context.Background(), 12345, "peer123"))
+	assert.Equal(t, 0, writeBehind.Pending())
+
+	lc.RequireStop()
+}
@@ -0,0 +1,102 @@
+package journaled
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/journaled"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/journal"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func TestLeaseRepository_JournalsMutations(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.StorageBackend = config.StorageBackendEmbedded
+	cfg.StoragePath = filepath.Join(t.TempDir(), "dhcp2p.json")
+
+	lc := fxtest.NewLifecycle(t)
+	store, err := embedded.NewStore(lc, cfg, clock.NewSystem())
+	require.NoError(t, err)
+	lc.RequireStart()
+
+	path := filepath.Join(t.TempDir(), "leases.journal")
+	sink, err := journal.OpenFileSink(path)
+	require.NoError(t, err)
+	lj, err := journal.Open(ctx, sink, clock.NewSystem())
+	require.NoError(t, err)
+	assert.True(t, lj.NeedsCheckpoint(), "a new journal starts with a checkpoint")
+
+	snapshots := journaled.NewLeaseSnapshotRepository(embedded.NewLeaseSnapshotRepository(store), lj, zap.NewNop())
+	stored, err := snapshots.ExportLeaseState(ctx)
+	require.NoError(t, err)
+	_, err = lj.Checkpoint(ctx, stored)
+	require.NoError(t, err)
+	assert.False(t, lj.NeedsCheckpoint())
+
+	repo := journaled.NewLeaseRepository(embedded.NewLeaseRepository(cfg, store), lj, zap.NewNop())
+	first, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	second, err := repo.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	third, err := repo.AllocateNewLease(ctx, "peer-3")
+	require.NoError(t, err)
+	require.NoError(t, repo.SetLeaseAffinityGroup(ctx, third.TokenID, "rack-1"))
+
+	_, err = repo.RenewLease(ctx, first.TokenID, "peer-1")
+	require.NoError(t, err)
+	_, err = repo.TransferLease(ctx, second.TokenID, "peer-2", "peer-4")
+	require.NoError(t, err)
+	require.NoError(t, repo.SetLeaseLabels(ctx, second.TokenID, "peer-4", map[string]string{"role": "edge"}))
+	require.NoError(t, repo.ReleaseLease(ctx, third.TokenID, "peer-3"))
+	_, err = repo.RecordConflict(ctx, first.TokenID, "peer-1", time.Hour)
+	require.NoError(t, err)
+
+	// A mutation the store rejects is not journaled
+	seq, _ := lj.Compare(stored, time.Now())
+	_, err = repo.TransferLease(ctx, second.TokenID, "peer-2", "peer-5")
+	assert.Error(t, err)
+	after, _ := lj.Compare(stored, time.Now())
+	assert.Equal(t, seq, after)
+	assert.Equal(t, int64(10), after, "a checkpoint and nine mutations")
+
+	// The store ignores a release by anyone but the holder, and so does the journal
+	require.NoError(t, repo.ReleaseLease(ctx, second.TokenID, "peer-2"))
+
+	stored, err = snapshots.ExportLeaseState(ctx)
+	require.NoError(t, err)
+	_, mismatches := lj.Compare(stored, time.Now())
+	assert.Empty(t, mismatches)
+
+	// The file holds the same state
+	require.NoError(t, lj.Close(ctx))
+	sink, err = journal.OpenFileSink(path)
+	require.NoError(t, err)
+	reopened, err := journal.Open(ctx, sink, clock.NewSystem())
+	require.NoError(t, err)
+	defer reopened.Close(ctx)
+	seq, mismatches = reopened.Compare(stored, time.Now())
+	assert.Equal(t, int64(11), seq)
+	assert.Empty(t, mismatches)
+
+	state := models.NewJournalState()
+	require.NoError(t, reopened.Read(ctx, func(entry *models.JournalEntry) error {
+		state.Apply(entry)
+		return nil
+	}))
+	leases := state.Snapshot().Leases
+	require.Len(t, leases, 3)
+	assert.Equal(t, "peer-4", leases[1].PeerID)
+	assert.Equal(t, map[string]string{"role": "edge"}, leases[1].Labels)
+	assert.Equal(t, "rack-1", leases[2].AffinityGroup)
+	assert.NotNil(t, leases[0].QuarantinedUntil)
+}
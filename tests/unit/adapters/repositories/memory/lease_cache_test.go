@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+)
+
+func newTestCache() *memory.LeaseCache {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Cache.NegativeTTL = 30
+	return memory.NewLeaseCache(cfg, clock.NewSystem())
+}
+
+func TestLeaseCache_GetAndSet(t *testing.T) {
+	ctx := context.Background()
+	cache := newTestCache()
+
+	_, err := cache.GetLeaseByPeerID(ctx, "peer-1")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+
+	lease := &models.Lease{TokenID: 42, PeerID: "peer-1", Ttl: 60}
+	require.NoError(t, cache.SetLease(ctx, lease))
+
+	byPeer, err := cache.GetLeaseByPeerID(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), byPeer.TokenID)
+
+	byToken, err := cache.GetLeaseByTokenID(ctx, 42)
+	require.NoError(t, err)
+	assert.Equal(t, "peer-1", byToken.PeerID)
+
+	// Changing a returned lease must not change the cached one
+	byPeer.PeerID = "changed"
+	again, err := cache.GetLeaseByTokenID(ctx, 42)
+	require.NoError(t, err)
+	assert.Equal(t, "peer-1", again.PeerID)
+}
+
+func TestLeaseCache_SkipsExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	cache := newTestCache()
+
+	require.NoError(t, cache.SetLease(ctx, &models.Lease{TokenID: 42, PeerID: "peer-1", Ttl: 0}))
+
+	_, err := cache.GetLeaseByPeerID(ctx, "peer-1")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+}
+
+func TestLeaseCache_ExpiresAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.Cache.NegativeTTL = 30
+	fakeClock := clock.NewFake(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+	cache := memory.NewLeaseCache(cfg, fakeClock)
+
+	require.NoError(t, cache.SetLease(ctx, &models.Lease{TokenID: 42, PeerID: "peer-1", Ttl: 60}))
+	require.NoError(t, cache.SetPeerNotFound(ctx, "peer-2"))
+
+	// The "not found" marker lives for cache.negative_ttl
+	fakeClock.Advance(30*time.Second - time.Nanosecond)
+	_, err := cache.GetLeaseByPeerID(ctx, "peer-2")
+	require.NoError(t, err)
+
+	fakeClock.Advance(time.Nanosecond)
+	_, err = cache.GetLeaseByPeerID(ctx, "peer-2")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+
+	// The lease for its TTL
+
+	fakeClock.Advance(30*time.Second - time.Nanosecond)
+	_, err = cache.GetLeaseByTokenID(ctx, 42)
+	require.NoError(t, err)
+
+	fakeClock.Advance(time.Nanosecond)
+	_, err = cache.GetLeaseByTokenID(ctx, 42)
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+}
+
+func TestLeaseCache_NotFoundMarkers(t *testing.T) {
+	ctx := context.Background()
+	cache := newTestCache()
+
+	require.NoError(t, cache.SetPeerNotFound(ctx, "peer-1"))
+	require.NoError(t, cache.SetTokenNotFound(ctx, 42))
+
+	lease, err := cache.GetLeaseByPeerID(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Nil(t, lease)
+
+	lease, err = cache.GetLeaseByTokenID(ctx, 42)
+	require.NoError(t, err)
+	assert.Nil(t, lease)
+
+	// A real lease replaces the markers
+	require.NoError(t, cache.SetLease(ctx, &models.Lease{TokenID: 42, PeerID: "peer-1", Ttl: 60}))
+	lease, err = cache.GetLeaseByPeerID(ctx, "peer-1")
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+
+	// ...but a marker never replaces a lease
+	require.NoError(t, cache.SetPeerNotFound(ctx, "peer-1"))
+	lease, err = cache.GetLeaseByPeerID(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.NotNil(t, lease)
+}
+
+func TestLeaseCache_DeleteAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	cache := newTestCache()
+
+	first := &models.Lease{TokenID: 1, PeerID: "peer-1", Ttl: 60}
+	second := &models.Lease{TokenID: 2, PeerID: "peer-2", Ttl: 60}
+	require.NoError(t, cache.SetLease(ctx, first))
+
+	require.NoError(t, cache.UpdateLeases(ctx, []*models.Lease{second}, []*models.Lease{first}))
+
+	_, err := cache.GetLeaseByTokenID(ctx, 1)
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+	_, err = cache.GetLeaseByTokenID(ctx, 2)
+	require.NoError(t, err)
+
+	require.NoError(t, cache.DeleteLease(ctx, "peer-2", 2))
+	_, err = cache.GetLeaseByPeerID(ctx, "peer-2")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+}
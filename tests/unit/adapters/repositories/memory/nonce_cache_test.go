@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+)
+
+func TestNonceCache(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.NewNonceCache(config.NewDefaultAppConfig(), clock.NewSystem())
+
+	_, err := cache.GetNonce(ctx, "nonce-1")
+	assert.ErrorIs(t, err, domainErrors.ErrNonceNotFound)
+
+	require.NoError(t, cache.CreateNonce(ctx, &models.Nonce{ID: "nonce-1", PeerID: "peer-1"}))
+
+	nonce, err := cache.GetNonce(ctx, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "peer-1", nonce.PeerID)
+
+	require.NoError(t, cache.DeleteNonce(ctx, "nonce-1"))
+	_, err = cache.GetNonce(ctx, "nonce-1")
+	assert.ErrorIs(t, err, domainErrors.ErrNonceNotFound)
+
+	// A taken nonce is gone
+	require.NoError(t, cache.CreateNonce(ctx, &models.Nonce{ID: "nonce-2", PeerID: "peer-2"}))
+	nonce, err = cache.TakeNonce(ctx, "nonce-2")
+	require.NoError(t, err)
+	assert.Equal(t, "peer-2", nonce.PeerID)
+	_, err = cache.TakeNonce(ctx, "nonce-2")
+	assert.ErrorIs(t, err, domainErrors.ErrNonceNotFound)
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+func TestTranslateError(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name   string
+		err    error
+		expect error
+	}{
+		{name: "no rows", err: fmt.Errorf("get lease: %w", pgx.ErrNoRows), expect: domainErrors.ErrLeaseNotFound},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, expect: domainErrors.ErrDuplicateRecord},
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, expect: domainErrors.ErrConcurrentUpdate},
+		{name: "deadlock", err: &pgconn.PgError{Code: "40P01"}, expect: domainErrors.ErrConcurrentUpdate},
+		{name: "lock not available", err: &pgconn.PgError{Code: "55P03"}, expect: domainErrors.ErrConcurrentUpdate},
+		{name: "statement timeout", err: &pgconn.PgError{Code: "57014"}, expect: domainErrors.ErrRequestTimeout},
+		{name: "connection exception", err: &pgconn.PgError{Code: "08006"}, expect: domainErrors.ErrDatabaseConnection},
+		{name: "server shutting down", err: &pgconn.PgError{Code: "57P01"}, expect: domainErrors.ErrDatabaseConnection},
+		{name: "context deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), expect: domainErrors.ErrRequestTimeout},
+		{name: "network error", err: dialErr, expect: domainErrors.ErrDatabaseConnection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := postgres.TranslateError(tt.err, domainErrors.ErrLeaseNotFound)
+
+			assert.ErrorIs(t, err, tt.expect)
+			assert.Equal(t, tt.expect.(*domainErrors.AppError).HTTPStatus(), domainErrors.GetAppError(err).HTTPStatus())
+			if !errors.Is(tt.err, pgx.ErrNoRows) {
+				assert.ErrorIs(t, err, tt.err, "driver error is kept as the cause")
+			}
+		})
+	}
+}
+
+func TestTranslateError_PassesThrough(t *testing.T) {
+	assert.NoError(t, postgres.TranslateError(nil, domainErrors.ErrLeaseNotFound))
+
+	// Domain errors are already translated
+	assert.Same(t, domainErrors.ErrTokenIDInUse, postgres.TranslateError(domainErrors.ErrTokenIDInUse, domainErrors.ErrLeaseNotFound))
+
+	// A canceled request has no status to report
+	assert.Same(t, context.Canceled, postgres.TranslateError(context.Canceled, domainErrors.ErrLeaseNotFound))
+
+	// Unrecognised server errors stay internal errors
+	checkViolation := &pgconn.PgError{Code: "23514"}
+	err := postgres.TranslateError(checkViolation, domainErrors.ErrLeaseNotFound)
+	assert.Same(t, checkViolation, err)
+	assert.False(t, domainErrors.IsAppError(err))
+}
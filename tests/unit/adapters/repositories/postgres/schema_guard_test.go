@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/postgres"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+var available = []string{"001", "002"}
+
+func newGuard(t *testing.T, migrator *mocks.MockSchemaMigrator, configure func(*config.AppConfig)) *postgres.SchemaGuard {
+	cfg := config.NewDefaultAppConfig()
+	if configure != nil {
+		configure(cfg)
+	}
+	tenants, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+	return postgres.NewSchemaGuard(fxtest.NewLifecycle(t), cfg, migrator, tenants, zap.NewNop())
+}
+
+func TestSchemaGuard_Check(t *testing.T) {
+	ctx := context.Background()
+	defaults := config.NewDefaultAppConfig()
+
+	t.Run("up to date schema passes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		migrator := mocks.NewMockSchemaMigrator(ctrl)
+		migrator.EXPECT().Status(ctx).Return(models.NewSchemaStatus(available, available), nil)
+		migrator.EXPECT().VerifyAllocState(ctx, defaults.PoolMinTokenID, defaults.PoolMaxTokenID).Return(nil)
+
+		assert.NoError(t, newGuard(t, migrator, nil).Check(ctx))
+	})
+
+	t.Run("newer schema is refused", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		migrator := mocks.NewMockSchemaMigrator(ctrl)
+		migrator.EXPECT().Status(ctx).Return(models.NewSchemaStatus(available, []string{"001", "002", "003"}), nil)
+
+		err := newGuard(t, migrator, func(cfg *config.AppConfig) { cfg.AutoMigrate = true }).Check(ctx)
+		assert.ErrorIs(t, err, postgres.ErrSchemaTooNew)
+		assert.Contains(t, err.Error(), "003")
+	})
+
+	t.Run("pending migrations are refused without auto-migrate", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		migrator := mocks.NewMockSchemaMigrator(ctrl)
+		migrator.EXPECT().Status(ctx).Return(models.NewSchemaStatus(available, []string{"001"}), nil)
+
+		err := newGuard(t, migrator, nil).Check(ctx)
+		assert.ErrorIs(t, err, postgres.ErrSchemaOutdated)
+		assert.Contains(t, err.Error(), "002")
+	})
+
+	t.Run("pending migrations are applied with auto-migrate", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		migrator := mocks.NewMockSchemaMigrator(ctrl)
+		gomock.InOrder(
+			migrator.EXPECT().Status(ctx).Return(models.NewSchemaStatus(available, nil), nil),
+			migrator.EXPECT().Migrate(ctx).Return(available, nil),
+			migrator.EXPECT().VerifyAllocState(ctx, defaults.PoolMinTokenID, defaults.PoolMaxTokenID).Return(nil),
+		)
+
+		assert.NoError(t, newGuard(t, migrator, func(cfg *config.AppConfig) { cfg.AutoMigrate = true }).Check(ctx))
+	})
+
+	t.Run("failed migration is reported", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		migrator := mocks.NewMockSchemaMigrator(ctrl)
+		migrator.EXPECT().Status(ctx).Return(models.NewSchemaStatus(available, nil), nil)
+		migrator.EXPECT().Migrate(ctx).Return(nil, errors.New("syntax error"))
+
+		err := newGuard(t, migrator, func(cfg *config.AppConfig) { cfg.AutoMigrate = true }).Check(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "syntax error")
+	})
+
+	t.Run("pool mismatch is refused", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		migrator := mocks.NewMockSchemaMigrator(ctrl)
+		migrator.EXPECT().Status(ctx).Return(models.NewSchemaStatus(available, available), nil)
+		migrator.EXPECT().VerifyAllocState(ctx, int64(100), int64(200)).Return(postgres.ErrAllocStateMismatch)
+
+		err := newGuard(t, migrator, func(cfg *config.AppConfig) {
+			cfg.PoolMinTokenID = 100
+			cfg.PoolMaxTokenID = 200
+		}).Check(ctx)
+		assert.ErrorIs(t, err, postgres.ErrAllocStateMismatch)
+	})
+
+	t.Run("tenant pools are provisioned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		migrator := mocks.NewMockSchemaMigrator(ctrl)
+		migrator.EXPECT().Status(ctx).Return(models.NewSchemaStatus(available, available), nil)
+		migrator.EXPECT().VerifyAllocState(ctx, defaults.PoolMinTokenID, defaults.PoolMaxTokenID).Return(nil)
+		migrator.EXPECT().ProvisionTenantPools(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, tenants []*models.Tenant) error {
+				require.Len(t, tenants, 2)
+				assert.Equal(t, "acme", tenants[1].ID)
+				return nil
+			})
+
+		assert.NoError(t, newGuard(t, migrator, func(cfg *config.AppConfig) {
+			cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"key"}, PoolMinTokenID: 1, PoolMaxTokenID: 100}}
+		}).Check(ctx))
+	})
+
+	t.Run("disabled check touches nothing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		migrator := mocks.NewMockSchemaMigrator(ctrl)
+
+		assert.NoError(t, newGuard(t, migrator, func(cfg *config.AppConfig) { cfg.SchemaCheckEnabled = false }).Check(ctx))
+	})
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/redis"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	lease := &models.Lease{
+		TokenID:   167772161,
+		PeerID:    "peer123",
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+		Ttl:       3600,
+		Signature: []byte{1, 2, 3},
+	}
+
+	for _, name := range []string{"", redis.CodecJSON, redis.CodecCBOR} {
+		t.Run("codec "+name, func(t *testing.T) {
+			codec, err := redis.NewCodec(name)
+			require.NoError(t, err)
+
+			data, err := codec.Marshal(lease)
+			require.NoError(t, err)
+			assert.NotEqual(t, "-", string(data), "values must not collide with the absent marker")
+
+			var decoded *models.Lease
+			require.NoError(t, codec.Unmarshal(data, &decoded))
+			assert.Equal(t, lease.TokenID, decoded.TokenID)
+			assert.Equal(t, lease.PeerID, decoded.PeerID)
+			assert.True(t, lease.ExpiresAt.Equal(decoded.ExpiresAt))
+			assert.Equal(t, lease.Signature, decoded.Signature)
+		})
+	}
+
+	t.Run("codecs do not read each other", func(t *testing.T) {
+		cborCodec, err := redis.NewCodec(redis.CodecCBOR)
+		require.NoError(t, err)
+
+		data, err := cborCodec.Marshal(lease)
+		require.NoError(t, err)
+
+		var decoded *models.Lease
+		assert.Error(t, redis.JSONCodec{}.Unmarshal(data, &decoded))
+	})
+
+	t.Run("unknown codec", func(t *testing.T) {
+		_, err := redis.NewCodec("msgpack")
+		assert.Error(t, err)
+
+		cfg := config.NewDefaultAppConfig()
+		cfg.Cache.Codec = "msgpack"
+		_, err = redis.NewLeaseCache(unreachableClient(t), cfg)
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyBuilder(t *testing.T) {
+	tenantCtx := models.WithTenant(context.Background(), "acme")
+
+	scoped := redis.KeyBuilder{Prefix: "lease:", TenantScoped: true}
+	assert.Equal(t, "lease:peer:peer1", scoped.Key(context.Background(), "peer", "peer1"))
+	assert.Equal(t, "lease:t:acme:peer:peer1", scoped.Key(tenantCtx, "peer", "peer1"))
+	assert.Equal(t, "lease:*", scoped.Pattern())
+
+	global := redis.KeyBuilder{Prefix: "nonce:"}
+	assert.Equal(t, "nonce:n1", global.Key(tenantCtx, "n1"))
+}
+
+func TestCache_FailoverHoldoff(t *testing.T) {
+	ctx := context.Background()
+	cache := redis.NewCache(unreachableClient(t), redis.KeyBuilder{Prefix: "test:"}, redis.JSONCodec{},
+		redis.FixedTTL[*models.Lease](time.Minute), time.Minute)
+
+	// A failed pipelined write starts the holdoff for every read
+	require.Error(t, cache.Set(ctx, &models.Lease{TokenID: 1, PeerID: "peer1"}, cache.Key(ctx, "a"), cache.Key(ctx, "b")))
+
+	_, err := cache.GetMany(ctx, cache.Key(ctx, "a"), cache.Key(ctx, "b"))
+	assert.ErrorIs(t, err, redis.ErrFailoverHoldoff)
+	_, _, err = cache.Get(ctx, cache.Key(ctx, "a"))
+	assert.ErrorIs(t, err, redis.ErrFailoverHoldoff)
+
+	// Nothing is sent for values the TTL policy does not cache
+	skipped := redis.NewCache(unreachableClient(t), redis.KeyBuilder{Prefix: "test:"}, redis.JSONCodec{},
+		redis.FixedTTL[*models.Lease](0), time.Minute)
+	assert.NoError(t, skipped.Set(ctx, &models.Lease{TokenID: 1, PeerID: "peer1"}, skipped.Key(ctx, "a")))
+}
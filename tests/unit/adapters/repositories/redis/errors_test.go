@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/redis"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+)
+
+func TestTranslateError(t *testing.T) {
+	t.Run("connection errors", func(t *testing.T) {
+		err := redis.TranslateError(redisclient.ErrClosed)
+		assert.ErrorIs(t, err, domainErrors.ErrRedisConnection)
+		assert.ErrorIs(t, err, redisclient.ErrClosed)
+	})
+
+	t.Run("context deadline", func(t *testing.T) {
+		err := redis.TranslateError(fmt.Errorf("get: %w", context.DeadlineExceeded))
+		assert.ErrorIs(t, err, domainErrors.ErrRequestTimeout)
+	})
+
+	t.Run("passes through", func(t *testing.T) {
+		assert.NoError(t, redis.TranslateError(nil))
+		assert.Equal(t, redisclient.Nil, redis.TranslateError(redisclient.Nil))
+		assert.Same(t, context.Canceled, redis.TranslateError(context.Canceled))
+		assert.Same(t, domainErrors.ErrNonceNotFound, redis.TranslateError(domainErrors.ErrNonceNotFound))
+	})
+}
@@ -0,0 +1,151 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/faults"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/redis"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func TestNewRedisClient_Modes(t *testing.T) {
+	tests := []struct {
+		name        string
+		configure   func(*config.AppConfig)
+		expectError bool
+	}{
+		{name: "standalone", configure: func(cfg *config.AppConfig) { cfg.Redis.URL = "localhost:6379" }},
+		{name: "standalone with redis url", configure: func(cfg *config.AppConfig) { cfg.Redis.URL = "redis://:secret@localhost:6379/2" }},
+		{name: "standalone with invalid redis url", configure: func(cfg *config.AppConfig) { cfg.Redis.URL = "redis://localhost:6379/db" }, expectError: true},
+		{name: "standalone without url", configure: func(cfg *config.AppConfig) {}, expectError: true},
+		{name: "sentinel", configure: func(cfg *config.AppConfig) {
+			cfg.Redis.Mode = config.RedisModeSentinel
+			cfg.Redis.Addrs = []string{"sentinel-1:26379", "sentinel-2:26379"}
+			cfg.Redis.MasterName = "dhcp2p"
+		}},
+		{name: "sentinel without master name", configure: func(cfg *config.AppConfig) {
+			cfg.Redis.Mode = config.RedisModeSentinel
+			cfg.Redis.Addrs = []string{"sentinel-1:26379"}
+		}, expectError: true},
+		{name: "cluster", configure: func(cfg *config.AppConfig) {
+			cfg.Redis.Mode = config.RedisModeCluster
+			cfg.Redis.Addrs = []string{"node-1:6379", "node-2:6379"}
+		}},
+		{name: "cluster without addrs", configure: func(cfg *config.AppConfig) {
+			cfg.Redis.Mode = config.RedisModeCluster
+		}, expectError: true},
+		{name: "unknown mode", configure: func(cfg *config.AppConfig) {
+			cfg.Redis.Mode = "replicated"
+			cfg.Redis.URL = "localhost:6379"
+		}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewDefaultAppConfig()
+			tt.configure(cfg)
+
+			// The client only connects on start
+			client, err := redis.NewRedisClient(fxtest.NewLifecycle(t), cfg, nil)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, client)
+			require.NoError(t, client.Close())
+		})
+	}
+}
+
+func TestNewRedisClient_InjectsFaults(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Redis.URL = "127.0.0.1:1"
+	cfg.Redis.MaxRetries = -1
+	injector := faults.NewInjector(clock.NewSystem(), zap.NewNop())
+
+	client, err := redis.NewRedisClient(fxtest.NewLifecycle(t), cfg, injector)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	// Without a fault the command reaches the unreachable server
+	err = client.Get(context.Background(), "key").Err()
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, faults.ErrInjected)
+
+	injector.SetFault(&models.Fault{Target: models.FaultTargetCache, ErrorRate: 1}, 0)
+	assert.ErrorIs(t, client.Get(context.Background(), "key").Err(), faults.ErrInjected)
+	_, err = client.Pipelined(context.Background(), func(pipe redisclient.Pipeliner) error {
+		pipe.Get(context.Background(), "key")
+		return nil
+	})
+	assert.ErrorIs(t, err, faults.ErrInjected)
+}
+
+// unreachableClient fails every command with a connection error, like a master that
+// went away during a failover
+func unreachableClient(t *testing.T) *redisclient.Client {
+	client := redisclient.NewClient(&redisclient.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestLeaseCache_FailoverHoldoff(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reads are bypassed after a connection error", func(t *testing.T) {
+		cfg := config.NewDefaultAppConfig()
+		cache, err := redis.NewLeaseCache(unreachableClient(t), cfg)
+		require.NoError(t, err)
+
+		_, err = cache.GetLeaseByPeerID(ctx, "peer1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, redis.ErrFailoverHoldoff)
+
+		_, err = cache.GetLeaseByTokenID(ctx, 167902210)
+		assert.ErrorIs(t, err, redis.ErrFailoverHoldoff)
+	})
+
+	t.Run("failed writes start the holdoff too", func(t *testing.T) {
+		cfg := config.NewDefaultAppConfig()
+		cache, err := redis.NewLeaseCache(unreachableClient(t), cfg)
+		require.NoError(t, err)
+
+		require.Error(t, cache.DeleteLease(ctx, "peer1", 167902210))
+
+		_, err = cache.GetLeaseByPeerID(ctx, "peer1")
+		assert.ErrorIs(t, err, redis.ErrFailoverHoldoff)
+	})
+
+	t.Run("disabled without a holdoff", func(t *testing.T) {
+		cfg := config.NewDefaultAppConfig()
+		cfg.Redis.FailoverHoldoff = 0
+		cache, err := redis.NewLeaseCache(unreachableClient(t), cfg)
+		require.NoError(t, err)
+
+		_, err = cache.GetLeaseByPeerID(ctx, "peer1")
+		require.Error(t, err)
+		_, err = cache.GetLeaseByPeerID(ctx, "peer1")
+		assert.NotErrorIs(t, err, redis.ErrFailoverHoldoff)
+	})
+}
+
+func TestNonceCache_FailoverHoldoff(t *testing.T) {
+	ctx := context.Background()
+	cache, err := redis.NewNonceCache(unreachableClient(t), config.NewDefaultAppConfig())
+	require.NoError(t, err)
+
+	_, err = cache.GetNonce(ctx, "nonce1")
+	require.Error(t, err)
+
+	_, err = cache.GetNonce(ctx, "nonce1")
+	assert.ErrorIs(t, err, redis.ErrFailoverHoldoff)
+}
@@ -0,0 +1,273 @@
+package allocation
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/allocation"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+const firstTokenID = 167902210
+
+func lease(tokenID int64, peerID string) *models.Lease {
+	return &models.Lease{TokenID: tokenID, PeerID: peerID, ExpiresAt: time.Now().Add(time.Hour)}
+}
+
+// pool returns tenants whose default pool spans size token IDs from firstTokenID
+func pool(t *testing.T, size int64, tenants ...config.TenantConfig) ports.TenantResolver {
+	resolver, err := services.NewTenantService(&config.AppConfig{
+		PoolMinTokenID: firstTokenID,
+		PoolMaxTokenID: firstTokenID + size - 1,
+		Tenants:        tenants,
+	})
+	require.NoError(t, err)
+	return resolver
+}
+
+func TestNewStrategy(t *testing.T) {
+	tests := []struct {
+		strategy     string
+		expectedName string
+		expectError  bool
+	}{
+		{strategy: "", expectedName: allocation.StrategyLRU},
+		{strategy: "lru", expectedName: allocation.StrategyLRU},
+		{strategy: "sequential", expectedName: allocation.StrategySequential},
+		{strategy: "random", expectedName: allocation.StrategyRandom},
+		{strategy: "round-robin", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			cfg := config.NewDefaultAppConfig()
+			cfg.Lease.AllocationStrategy = tt.strategy
+
+			strategy, err := allocation.NewStrategy(cfg, nil, nil)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedName, strategy.Name())
+		})
+	}
+}
+
+func TestLRU_Allocate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reuses an expired lease first", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer1").Return(lease(firstTokenID, "peer1"), nil)
+
+		result, err := allocation.NewLRU(mockRepo).Allocate(ctx, "peer1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID), result.TokenID)
+	})
+
+	t.Run("takes a new token ID when nothing expired", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer1").Return(nil, nil)
+		mockRepo.EXPECT().AllocateNewLease(gomock.Any(), "peer1").Return(lease(firstTokenID+1, "peer1"), nil)
+
+		result, err := allocation.NewLRU(mockRepo).Allocate(ctx, "peer1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID+1), result.TokenID)
+	})
+
+	t.Run("stops on reuse errors", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer1").Return(nil, domainErrors.ErrLeaseQuotaExceeded)
+
+		_, err := allocation.NewLRU(mockRepo).Allocate(ctx, "peer1")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseQuotaExceeded)
+	})
+}
+
+func TestSequential_Allocate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("takes a new token ID first", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().AllocateNewLease(gomock.Any(), "peer1").Return(lease(firstTokenID, "peer1"), nil)
+
+		result, err := allocation.NewSequential(mockRepo).Allocate(ctx, "peer1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID), result.TokenID)
+	})
+
+	t.Run("reuses expired leases once the pool end is reached", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().AllocateNewLease(gomock.Any(), "peer1").Return(nil, domainErrors.ErrAllocationFailed)
+		mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer1").Return(lease(firstTokenID+5, "peer1"), nil)
+
+		result, err := allocation.NewSequential(mockRepo).Allocate(ctx, "peer1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID+5), result.TokenID)
+	})
+
+	t.Run("fails when the pool is full", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().AllocateNewLease(gomock.Any(), "peer1").Return(nil, domainErrors.ErrAllocationFailed)
+		mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer1").Return(nil, nil)
+
+		_, err := allocation.NewSequential(mockRepo).Allocate(ctx, "peer1")
+		assert.ErrorIs(t, err, domainErrors.ErrAllocationFailed)
+	})
+}
+
+func TestRandom_Allocate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requests token IDs within the pool", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().AllocateRequestedLease(gomock.Any(), "peer1", gomock.Any()).
+			DoAndReturn(func(_ context.Context, peerID string, tokenID int64) (*models.Lease, error) {
+				assert.GreaterOrEqual(t, tokenID, int64(firstTokenID))
+				assert.LessOrEqual(t, tokenID, int64(firstTokenID+9))
+				return lease(tokenID, peerID), nil
+			})
+
+		_, err := allocation.NewRandom(mockRepo, pool(t, 10), 4).Allocate(ctx, "peer1")
+		require.NoError(t, err)
+	})
+
+	t.Run("falls back to lru after taken token IDs", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().AllocateRequestedLease(gomock.Any(), "peer1", gomock.Any()).Return(nil, domainErrors.ErrTokenIDInUse).Times(4)
+		mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer1").Return(lease(firstTokenID, "peer1"), nil)
+
+		result, err := allocation.NewRandom(mockRepo, pool(t, 10), 4).Allocate(ctx, "peer1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(firstTokenID), result.TokenID)
+	})
+
+	t.Run("stops on other errors", func(t *testing.T) {
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().AllocateRequestedLease(gomock.Any(), "peer1", gomock.Any()).Return(nil, domainErrors.ErrLeaseQuotaExceeded)
+
+		_, err := allocation.NewRandom(mockRepo, pool(t, 10), 4).Allocate(ctx, "peer1")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseQuotaExceeded)
+	})
+
+	t.Run("requests token IDs within the pool of the tenant", func(t *testing.T) {
+		tenants := pool(t, 10, config.TenantConfig{ID: "acme", APIKeys: []string{"key"}, PoolMinTokenID: firstTokenID + 100, PoolMaxTokenID: firstTokenID + 109})
+		mockRepo := mocks.NewMockLeaseRepository(gomock.NewController(t))
+		mockRepo.EXPECT().AllocateRequestedLease(gomock.Any(), "peer1", gomock.Any()).
+			DoAndReturn(func(_ context.Context, peerID string, tokenID int64) (*models.Lease, error) {
+				assert.GreaterOrEqual(t, tokenID, int64(firstTokenID+100))
+				assert.LessOrEqual(t, tokenID, int64(firstTokenID+109))
+				return lease(tokenID, peerID), nil
+			})
+
+		_, err := allocation.NewRandom(mockRepo, tenants, 4).Allocate(models.WithTenant(ctx, "acme"), "peer1")
+		require.NoError(t, err)
+	})
+}
+
+// TestStrategies_NoDoubleAllocation runs random concurrent allocations and releases
+// against the embedded repository and checks that no token ID is ever held by two
+// peers and no peer holds two token IDs.
+func TestStrategies_NoDoubleAllocation(t *testing.T) {
+	const (
+		workers         = 8
+		peersPerWorker  = 16
+		opsPerWorker    = 400
+		randomPoolSize  = 64
+		releaseFraction = 0.4
+	)
+
+	strategies := map[string]func(ports.LeaseRepository) ports.AllocationStrategy{
+		allocation.StrategyLRU: func(repo ports.LeaseRepository) ports.AllocationStrategy {
+			return allocation.NewLRU(repo)
+		},
+		allocation.StrategySequential: func(repo ports.LeaseRepository) ports.AllocationStrategy {
+			return allocation.NewSequential(repo)
+		},
+		allocation.StrategyRandom: func(repo ports.LeaseRepository) ports.AllocationStrategy {
+			// A small range makes probes collide with taken token IDs
+			return allocation.NewRandom(repo, pool(t, randomPoolSize), 4)
+		},
+	}
+
+	for name, newStrategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed := uint64(time.Now().UnixNano())
+			t.Logf("seed %d", seed)
+
+			repo := embedded.NewLeaseRepository(config.NewDefaultAppConfig(), embedded.NewMemoryStore(clock.NewSystem()))
+			strategy := newStrategy(repo)
+
+			var mu sync.Mutex
+			holders := make(map[int64]string) // token ID -> peer ID
+			held := make(map[string]int64)    // peer ID -> token ID
+
+			var wg sync.WaitGroup
+			for w := range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					rng := rand.New(rand.NewPCG(seed, uint64(w)))
+
+					for range opsPerWorker {
+						peerID := fmt.Sprintf("peer-%d-%d", w, rng.IntN(peersPerWorker))
+
+						mu.Lock()
+						tokenID, holding := held[peerID]
+						release := holding && rng.Float64() < releaseFraction
+						if release {
+							// Forget the lease before releasing it, so it is never free in
+							// the repository while the model still has an owner
+							delete(held, peerID)
+							delete(holders, tokenID)
+						}
+						mu.Unlock()
+
+						if release {
+							assert.NoError(t, repo.ReleaseLease(ctx, tokenID, peerID))
+						}
+						if holding {
+							continue
+						}
+
+						result, err := strategy.Allocate(ctx, peerID)
+						if !assert.NoError(t, err) {
+							return
+						}
+
+						mu.Lock()
+						if owner, taken := holders[result.TokenID]; taken {
+							t.Errorf("token ID %d allocated to %s while held by %s", result.TokenID, peerID, owner)
+						}
+						holders[result.TokenID] = peerID
+						held[peerID] = result.TokenID
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			for peerID, tokenID := range held {
+				current, err := repo.GetLeaseByPeerID(ctx, peerID)
+				require.NoError(t, err)
+				assert.Equal(t, tokenID, current.TokenID, "lease of %s", peerID)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	stdErrors "errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestAccessControlService_CheckAccess(t *testing.T) {
+	const peerID = "12D3KooWExamplePeerID"
+	pubkey := []byte("marshaled-public-key")
+	encodedPubkey := base64.StdEncoding.EncodeToString(pubkey)
+
+	deny := &models.AccessRule{ID: 1, List: models.AccessListDeny}
+	allow := &models.AccessRule{ID: 2, List: models.AccessListAllow}
+
+	tests := []struct {
+		name              string
+		allowListRequired bool
+		peerRules         []*models.AccessRule
+		pubkeyRules       []*models.AccessRule
+		repoErr           error
+		expectedError     error
+	}{
+		{
+			name: "no rules",
+		},
+		{
+			name:          "peer ID on the deny list",
+			peerRules:     []*models.AccessRule{deny},
+			expectedError: errors.ErrPeerBlocked,
+		},
+		{
+			name:          "public key on the deny list",
+			pubkeyRules:   []*models.AccessRule{deny},
+			expectedError: errors.ErrPeerBlocked,
+		},
+		{
+			name:              "allow list required without rule",
+			allowListRequired: true,
+			expectedError:     errors.ErrPeerNotAllowed,
+		},
+		{
+			name:              "allow list required with public key rule",
+			allowListRequired: true,
+			pubkeyRules:       []*models.AccessRule{allow},
+		},
+		{
+			name:              "deny wins over allow",
+			allowListRequired: true,
+			peerRules:         []*models.AccessRule{allow},
+			pubkeyRules:       []*models.AccessRule{deny},
+			expectedError:     errors.ErrPeerBlocked,
+		},
+		{
+			name:          "repository error",
+			repoErr:       stdErrors.New("database down"),
+			expectedError: stdErrors.New("database down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockAccessRuleRepository(ctrl)
+			repo.EXPECT().GetAccessRules(gomock.Any(), models.AccessSubjectPeerID, peerID).Return(tt.peerRules, tt.repoErr).MaxTimes(1)
+			repo.EXPECT().GetAccessRules(gomock.Any(), models.AccessSubjectPubkey, encodedPubkey).Return(tt.pubkeyRules, tt.repoErr).MaxTimes(1)
+
+			service := services.NewAccessControlService(&config.AppConfig{Security: config.SecurityConfig{AccessAllowListRequired: tt.allowListRequired}}, repo)
+			err := service.CheckAccess(context.Background(), peerID, pubkey)
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else if stdErrors.Is(tt.expectedError, errors.ErrPeerBlocked) || stdErrors.Is(tt.expectedError, errors.ErrPeerNotAllowed) {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			}
+		})
+	}
+}
+
+func TestAccessControlService_RemoveRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockAccessRuleRepository(ctrl)
+	repo.EXPECT().DeleteAccessRule(gomock.Any(), int64(7)).Return(nil, errors.ErrAccessRuleNotFound)
+
+	service := services.NewAccessControlService(config.NewDefaultAppConfig(), repo)
+	assert.ErrorIs(t, service.RemoveRule(context.Background(), 7), errors.ErrAccessRuleNotFound)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestAPIKeyService() (*services.APIKeyService, *observer.ObservedLogs) {
+	core, logs := observer.New(zap.InfoLevel)
+	repo := embedded.NewAPIKeyRepository(embedded.NewMemoryStore(clock.NewSystem()))
+	return services.NewAPIKeyService(repo, zap.New(core)), logs
+}
+
+func TestAPIKeyService_Create(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestAPIKeyService()
+
+	issued, err := s.Create(ctx, " ops ", []models.APIKeyScope{models.APIKeyScopeLeasesWrite, models.APIKeyScopeLeasesRead, models.APIKeyScopeLeasesWrite})
+	require.NoError(t, err)
+	assert.Equal(t, "ops", issued.Name)
+	assert.True(t, strings.HasPrefix(issued.Key, issued.Prefix+"_"))
+	assert.True(t, strings.HasPrefix(issued.Prefix, models.AdminAPIKeyPrefix))
+	assert.Equal(t, []models.APIKeyScope{models.APIKeyScopeLeasesRead, models.APIKeyScopeLeasesWrite}, issued.Scopes)
+	assert.NotContains(t, issued.Hash, issued.Key, "only a hash is stored")
+
+	other, err := s.Create(ctx, "ops", []models.APIKeyScope{models.APIKeyScopeLeasesRead})
+	require.NoError(t, err)
+	assert.NotEqual(t, issued.Key, other.Key)
+
+	_, err = s.Create(ctx, "", []models.APIKeyScope{models.APIKeyScopeLeasesRead})
+	assert.ErrorIs(t, err, errors.ErrInvalidAPIKeyName)
+	_, err = s.Create(ctx, strings.Repeat("n", 65), []models.APIKeyScope{models.APIKeyScopeLeasesRead})
+	assert.ErrorIs(t, err, errors.ErrInvalidAPIKeyName)
+	_, err = s.Create(ctx, "ops", nil)
+	assert.ErrorIs(t, err, errors.ErrInvalidAPIKeyScope)
+	_, err = s.Create(ctx, "ops", []models.APIKeyScope{"leases:delete"})
+	assert.ErrorIs(t, err, errors.ErrInvalidAPIKeyScope)
+}
+
+func TestAPIKeyService_Authorize(t *testing.T) {
+	ctx := context.Background()
+	s, logs := newTestAPIKeyService()
+
+	issued, err := s.Create(ctx, "reporting", []models.APIKeyScope{models.APIKeyScopeLeasesRead})
+	require.NoError(t, err)
+
+	key, err := s.Authorize(ctx, issued.Key, models.APIKeyScopeLeasesRead)
+	require.NoError(t, err)
+	assert.Equal(t, issued.ID, key.ID)
+
+	_, err = s.Authorize(ctx, issued.Key, models.APIKeyScopeLeasesWrite)
+	assert.ErrorIs(t, err, errors.ErrAPIKeyScope)
+
+	_, err = s.Authorize(ctx, issued.Key+"x", models.APIKeyScopeLeasesRead)
+	assert.ErrorIs(t, err, errors.ErrAdminUnauthorized)
+	_, err = s.Authorize(ctx, "tenant-key", models.APIKeyScopeLeasesRead)
+	assert.ErrorIs(t, err, errors.ErrAdminUnauthorized)
+
+	keys, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt, "use is recorded")
+
+	_, err = s.Revoke(ctx, issued.ID)
+	require.NoError(t, err)
+	_, err = s.Authorize(ctx, issued.Key, models.APIKeyScopeLeasesRead)
+	assert.ErrorIs(t, err, errors.ErrAdminUnauthorized)
+
+	_, err = s.Revoke(ctx, 42)
+	assert.ErrorIs(t, err, errors.ErrAPIKeyNotFound)
+
+	// Every use and refusal is audited, never with the key itself
+	events := map[string]int{}
+	for _, entry := range logs.All() {
+		assert.Equal(t, "audit", entry.LoggerName)
+		assert.NotContains(t, entry.ContextMap(), issued.Key)
+		events[entry.ContextMap()["event"].(string)]++
+	}
+	assert.Equal(t, map[string]int{"api_key_created": 1, "api_key_used": 3, "api_key_rejected": 1, "api_key_revoked": 1}, events)
+}
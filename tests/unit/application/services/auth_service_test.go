@@ -2,14 +2,21 @@ package services
 
 import (
 	"context"
+	stdErrors "errors"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 )
 
 func TestAuthService_RequestAuth(t *testing.T) {
@@ -69,7 +76,7 @@ func TestAuthService_RequestAuth(t *testing.T) {
 			mockNonce := mocks.NewMockNonceService(ctrl)
 			tt.mockSetup(ctrl, mockNonce)
 
-			service := services.NewAuthService(mockNonce)
+			service := services.NewAuthService(&config.AppConfig{}, mockNonce, libp2p.NewPeerIDResolver(), clock.NewSystem())
 
 			result, err := service.RequestAuth(context.Background(), tt.request)
 
@@ -185,7 +192,10 @@ func TestAuthService_VerifyAuth(t *testing.T) {
 			mockNonce := mocks.NewMockNonceService(ctrl)
 			tt.mockSetup(ctrl, mockNonce)
 
-			service := services.NewAuthService(mockNonce)
+			mockIdentity := mocks.NewMockIdentityResolver(ctrl)
+			mockIdentity.EXPECT().ResolvePeerID(gomock.Any()).Return("peer123", nil).AnyTimes()
+
+			service := services.NewAuthService(&config.AppConfig{}, mockNonce, mockIdentity, clock.NewSystem())
 
 			result, err := service.VerifyAuth(context.Background(), tt.request)
 
@@ -196,7 +206,101 @@ func TestAuthService_VerifyAuth(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedResult.Pubkey, result.Pubkey)
+				assert.Equal(t, "peer123", result.PeerID)
+			}
+		})
+	}
+}
+
+func TestAuthService_VerifyAuthTimestamp(t *testing.T) {
+	serverClock := clock.NewFake(time.Unix(1767225600, 0))
+	now := serverClock.Now().Unix()
+
+	tests := []struct {
+		name          string
+		cfg           *config.AppConfig
+		timestamp     string
+		expectVerify  bool
+		expectedError error
+	}{
+		{
+			name:         "timestamp within window is signed with the nonce",
+			cfg:          &config.AppConfig{Security: config.SecurityConfig{TimestampWindow: 30}},
+			timestamp:    strconv.FormatInt(now-10, 10),
+			expectVerify: true,
+		},
+		{
+			name:         "timestamp at the edge of the window",
+			cfg:          &config.AppConfig{Security: config.SecurityConfig{TimestampWindow: 30}},
+			timestamp:    strconv.FormatInt(now-30, 10),
+			expectVerify: true,
+		},
+		{
+			name:          "timestamp a second beyond the window",
+			cfg:           &config.AppConfig{Security: config.SecurityConfig{TimestampWindow: 30}},
+			timestamp:     strconv.FormatInt(now+31, 10),
+			expectedError: errors.ErrTimestampOutOfWindow,
+		},
+		{
+			name:          "stale timestamp",
+			cfg:           &config.AppConfig{Security: config.SecurityConfig{TimestampWindow: 30}},
+			timestamp:     strconv.FormatInt(now-120, 10),
+			expectedError: errors.ErrTimestampOutOfWindow,
+		},
+		{
+			name:          "timestamp from the future",
+			cfg:           &config.AppConfig{Security: config.SecurityConfig{TimestampWindow: 30}},
+			timestamp:     strconv.FormatInt(now+120, 10),
+			expectedError: errors.ErrTimestampOutOfWindow,
+		},
+		{
+			name:          "malformed timestamp",
+			cfg:           &config.AppConfig{Security: config.SecurityConfig{TimestampWindow: 30}},
+			timestamp:     "2024-01-15T10:30:00Z",
+			expectedError: errors.ErrInvalidTimestamp,
+		},
+		{
+			name:          "missing timestamp when required",
+			cfg:           &config.AppConfig{Security: config.SecurityConfig{TimestampRequired: true, TimestampWindow: 30}},
+			expectedError: errors.ErrMissingTimestamp,
+		},
+		{
+			name:         "missing timestamp when optional",
+			cfg:          &config.AppConfig{Security: config.SecurityConfig{TimestampWindow: 30}},
+			expectVerify: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockNonce := mocks.NewMockNonceService(ctrl)
+			if tt.expectVerify {
+				mockNonce.EXPECT().VerifyNonce(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, req *models.NonceRequest) error {
+						assert.Equal(t, crypto.AuthPayload("test-nonce-id", tt.timestamp), req.Payload)
+						return nil
+					})
+			}
+
+			mockIdentity := mocks.NewMockIdentityResolver(ctrl)
+			mockIdentity.EXPECT().ResolvePeerID(gomock.Any()).Return("peer123", nil).AnyTimes()
+
+			service := services.NewAuthService(tt.cfg, mockNonce, mockIdentity, serverClock)
+			_, err := service.VerifyAuth(context.Background(), &models.AuthVerifyRequest{
+				NonceID:   "test-nonce-id",
+				Signature: []byte("valid-signature"),
+				Pubkey:    []byte("valid-pubkey-data"),
+				Timestamp: tt.timestamp,
+			})
+
+			if tt.expectedError != nil {
+				assert.True(t, stdErrors.Is(err, tt.expectedError))
+				return
 			}
+			assert.NoError(t, err)
 		})
 	}
 }
@@ -207,7 +311,7 @@ func TestAuthService_EdgeCases(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockNonce := mocks.NewMockNonceService(ctrl)
-		service := services.NewAuthService(mockNonce)
+		service := services.NewAuthService(&config.AppConfig{}, mockNonce, libp2p.NewPeerIDResolver(), clock.NewSystem())
 
 		// Create a very large invalid pubkey
 		largePubkey := make([]byte, 10000)
@@ -223,7 +327,9 @@ func TestAuthService_EdgeCases(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockNonce := mocks.NewMockNonceService(ctrl)
-		service := services.NewAuthService(mockNonce)
+		mockIdentity := mocks.NewMockIdentityResolver(ctrl)
+		mockIdentity.EXPECT().ResolvePeerID(gomock.Any()).Return("peer123", nil)
+		service := services.NewAuthService(&config.AppConfig{}, mockNonce, mockIdentity, clock.NewSystem())
 
 		// Create a very large signature
 		largeSignature := make([]byte, 10000)
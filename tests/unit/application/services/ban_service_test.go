@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/memory"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/zap"
+)
+
+const bannedIP = "203.0.113.7"
+
+func newTestBanService(t *testing.T) (*services.BanService, *clock.Fake) {
+	t.Helper()
+	cfg := &config.AppConfig{RateLimit: config.RateLimitConfig{
+		BanEnabled:     true,
+		BanThreshold:   3,
+		BanWindow:      60,
+		BanDuration:    60,
+		BanMaxDuration: 200,
+	}}
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	return services.NewBanService(cfg, memory.NewBanStore(fake), fake, zap.NewNop()), fake
+}
+
+// violate records violations until one bans the client
+func violate(t *testing.T, s *services.BanService, count int) *models.Ban {
+	t.Helper()
+	var ban *models.Ban
+	for i := range count {
+		var err error
+		ban, err = s.RecordViolation(context.Background(), bannedIP)
+		require.NoError(t, err)
+		if i < count-1 {
+			require.Nil(t, ban, "violation %d", i+1)
+		}
+	}
+	return ban
+}
+
+func TestBanService_BansAtThreshold(t *testing.T) {
+	s, fake := newTestBanService(t)
+	ctx := context.Background()
+
+	ban := violate(t, s, 3)
+	require.NotNil(t, ban)
+	assert.Equal(t, 1, ban.Level)
+	assert.Equal(t, int64(3), ban.Violations)
+	assert.Equal(t, fake.Now().Add(time.Minute), ban.ExpiresAt)
+
+	checked, err := s.CheckBan(ctx, bannedIP)
+	require.NoError(t, err)
+	assert.Equal(t, ban, checked)
+
+	other, err := s.CheckBan(ctx, "203.0.113.8")
+	require.NoError(t, err)
+	assert.Nil(t, other)
+
+	fake.Advance(time.Minute)
+	checked, err = s.CheckBan(ctx, bannedIP)
+	require.NoError(t, err)
+	assert.Nil(t, checked, "ban expired")
+
+	assert.Equal(t, &models.BanMetrics{Violations: 3, Bans: 1, Refused: 1}, s.Metrics())
+}
+
+func TestBanService_ViolationsOutsideWindowDoNotBan(t *testing.T) {
+	s, fake := newTestBanService(t)
+
+	violate(t, s, 2)
+	fake.Advance(time.Minute)
+
+	assert.Nil(t, violate(t, s, 2), "the window restarted")
+}
+
+func TestBanService_EscalatesRepeatedBans(t *testing.T) {
+	s, fake := newTestBanService(t)
+	ctx := context.Background()
+
+	var durations []time.Duration
+	for range 4 {
+		ban := violate(t, s, 3)
+		require.NotNil(t, ban)
+		durations = append(durations, ban.ExpiresAt.Sub(ban.BannedAt))
+		fake.Set(ban.ExpiresAt)
+	}
+	assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute, 200 * time.Second, 200 * time.Second}, durations)
+
+	// Forgotten once the maximum duration passed after the last ban
+	fake.Advance(200 * time.Second)
+	status, err := s.GetBanStatus(ctx, bannedIP)
+	require.NoError(t, err)
+	assert.Nil(t, status.Ban)
+	assert.Equal(t, 1, violate(t, s, 3).Level)
+}
+
+func TestBanService_StatusListAndLift(t *testing.T) {
+	s, fake := newTestBanService(t)
+	ctx := context.Background()
+
+	violate(t, s, 3)
+	fake.Advance(time.Second)
+	_, err := s.RecordViolation(ctx, "203.0.113.8")
+	require.NoError(t, err)
+
+	status, err := s.GetBanStatus(ctx, "203.0.113.8")
+	require.NoError(t, err)
+	assert.Equal(t, &models.BanStatus{Subject: "203.0.113.8", Violations: 1}, status)
+
+	status, err = s.GetBanStatus(ctx, bannedIP)
+	require.NoError(t, err)
+	assert.True(t, status.Banned)
+	assert.Zero(t, status.Violations, "violations are cleared by the ban")
+	require.NotNil(t, status.Ban)
+	assert.Equal(t, 1, status.Ban.Level)
+
+	bans, err := s.ListBans(ctx)
+	require.NoError(t, err)
+	require.Len(t, bans, 1)
+	assert.Equal(t, bannedIP, bans[0].Subject)
+
+	require.NoError(t, s.LiftBan(ctx, bannedIP))
+	ban, err := s.CheckBan(ctx, bannedIP)
+	require.NoError(t, err)
+	assert.Nil(t, ban)
+	assert.ErrorIs(t, s.LiftBan(ctx, bannedIP), errors.ErrBanNotFound)
+
+	// Lifting forgets earlier bans
+	assert.Equal(t, 1, violate(t, s, 3).Level)
+	assert.Equal(t, int64(1), s.Metrics().Lifted)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func newDashboardConfig() *config.AppConfig {
+	cfg := config.NewDefaultAppConfig()
+	cfg.DashboardEnabled = true
+	cfg.DashboardRecentAllocations = 2
+	cfg.DashboardTrackedPeers = 2
+	return cfg
+}
+
+func newDashboardService(t *testing.T, cfg *config.AppConfig, queries *mocks.MockLeaseQueryService) *services.DashboardService {
+	tenants, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+	return services.NewDashboardService(cfg, queries, tenants)
+}
+
+func leaseEvent(eventType models.LeaseLifecycleEventType, tokenID int64, peerID string) *models.LeaseLifecycleEvent {
+	return &models.LeaseLifecycleEvent{Type: eventType, TokenID: tokenID, PeerID: peerID, TenantID: models.DefaultTenantID, OccurredAt: time.Now()}
+}
+
+func TestDashboardService_Summary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := newDashboardConfig()
+	cfg.PoolMinTokenID = 167772161
+	cfg.PoolMaxTokenID = 167772260
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200001, PoolMaxTokenID: 168200100}}
+
+	stats := &models.LeaseStats{Active: 50, Expired: 5, Total: 55, RefreshedAt: time.Now()}
+	mockQueries := mocks.NewMockLeaseQueryService(ctrl)
+	mockQueries.EXPECT().GetLeaseStats(gomock.Any()).Return(stats, nil)
+
+	summary, err := newDashboardService(t, cfg, mockQueries).Summary(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &models.PoolUtilization{Size: 200, Active: 50, Utilization: 0.25}, summary.Pool)
+	assert.Equal(t, stats, summary.Leases)
+	assert.False(t, summary.StartedAt.IsZero())
+}
+
+func TestDashboardService_RecentAllocations(t *testing.T) {
+	service := newDashboardService(t, newDashboardConfig(), nil)
+	assert.Empty(t, service.RecentAllocations())
+
+	service.Publish(leaseEvent(models.LeaseLifecycleAllocated, 167772161, "peer1"))
+	service.Publish(leaseEvent(models.LeaseLifecycleRenewed, 167772161, "peer1"))
+	service.Publish(leaseEvent(models.LeaseLifecycleAllocated, 167772162, "peer2"))
+	service.Publish(leaseEvent(models.LeaseLifecycleAllocated, 167772163, "peer3"))
+
+	recent := service.RecentAllocations()
+	require.Len(t, recent, 2, "only the configured number of allocations is kept")
+	assert.Equal(t, "peer3", recent[0].PeerID)
+	assert.Equal(t, "10.0.0.3", recent[0].IP)
+	assert.Equal(t, "peer2", recent[1].PeerID)
+}
+
+func TestDashboardService_TopPeers(t *testing.T) {
+	service := newDashboardService(t, newDashboardConfig(), nil)
+
+	service.Publish(leaseEvent(models.LeaseLifecycleAllocated, 167772161, "peer1"))
+	service.Publish(leaseEvent(models.LeaseLifecycleRenewed, 167772161, "peer1"))
+	service.Publish(leaseEvent(models.LeaseLifecycleRenewed, 167772161, "peer1"))
+	service.Publish(leaseEvent(models.LeaseLifecycleAllocated, 167772162, "peer2"))
+	service.Publish(leaseEvent(models.LeaseLifecycleReleased, 167772162, "peer2"))
+	// peer3 replaces the least active peer and inherits its count
+	service.Publish(leaseEvent(models.LeaseLifecycleAllocated, 167772163, "peer3"))
+
+	assert.Equal(t, []*models.DashboardPeer{
+		{PeerID: "peer1", Tenant: models.DefaultTenantID, Leases: 3},
+		{PeerID: "peer3", Tenant: models.DefaultTenantID, Leases: 2},
+	}, service.TopPeers(10))
+	assert.Len(t, service.TopPeers(1), 1)
+}
+
+func TestDashboardService_Disabled(t *testing.T) {
+	service := newDashboardService(t, config.NewDefaultAppConfig(), nil)
+	service.Publish(leaseEvent(models.LeaseLifecycleAllocated, 167772161, "peer1"))
+
+	assert.Empty(t, service.RecentAllocations())
+	assert.Empty(t, service.TopPeers(10))
+}
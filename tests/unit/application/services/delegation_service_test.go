@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+func TestDelegationService_AllocateDelegatedIP(t *testing.T) {
+	gatewayKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	delegateKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+
+	gatewayPubkey, err := crypto.MarshalPublicKey(gatewayKey.GetPublic())
+	require.NoError(t, err)
+	delegatePubkey, err := crypto.MarshalPublicKey(delegateKey.GetPublic())
+	require.NoError(t, err)
+
+	gatewayPeerID, err := dhcp2pcrypto.PeerIDFromPublicKey(gatewayPubkey)
+	require.NoError(t, err)
+	delegatePeerID, err := dhcp2pcrypto.PeerIDFromPublicKey(delegatePubkey)
+	require.NoError(t, err)
+
+	const nonceID = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	validSignature, err := gatewayKey.Sign(dhcp2pcrypto.DelegationPayload(nonceID, delegatePeerID))
+	require.NoError(t, err)
+	// Signed by the downstream peer instead of the gateway
+	wrongSignature, err := delegateKey.Sign(dhcp2pcrypto.DelegationPayload(nonceID, delegatePeerID))
+	require.NoError(t, err)
+
+	allocated := &models.Lease{TokenID: 167902210, PeerID: delegatePeerID}
+
+	tests := []struct {
+		name           string
+		gatewayPeerID  string
+		delegatePubkey []byte
+		signature      []byte
+		maxLeases      int
+		setupMocks     func(*mocks.MockLeaseService, *mocks.MockLeaseRepository, *mocks.MockAccessControlService)
+		expectedError  error
+	}{
+		{
+			name:           "delegated allocation",
+			gatewayPeerID:  gatewayPeerID,
+			delegatePubkey: delegatePubkey,
+			signature:      validSignature,
+			maxLeases:      2,
+			setupMocks: func(l *mocks.MockLeaseService, r *mocks.MockLeaseRepository, a *mocks.MockAccessControlService) {
+				a.EXPECT().CheckAccess(gomock.Any(), delegatePeerID, delegatePubkey).Return(nil)
+				r.EXPECT().CountDelegatedLeases(gomock.Any(), gatewayPeerID).Return(int64(1), nil)
+				l.EXPECT().AllocateIP(gomock.Any(), delegatePeerID).Return(allocated, nil)
+				r.EXPECT().SetLeaseDelegator(gomock.Any(), allocated.TokenID, gatewayPeerID).Return(nil)
+			},
+		},
+		{
+			name:           "caller is not a gateway",
+			gatewayPeerID:  "12D3KooWNotAGateway",
+			delegatePubkey: delegatePubkey,
+			signature:      validSignature,
+			setupMocks:     func(*mocks.MockLeaseService, *mocks.MockLeaseRepository, *mocks.MockAccessControlService) {},
+			expectedError:  domainErrors.ErrNotAGateway,
+		},
+		{
+			name:           "signature from wrong key",
+			gatewayPeerID:  gatewayPeerID,
+			delegatePubkey: delegatePubkey,
+			signature:      wrongSignature,
+			setupMocks:     func(*mocks.MockLeaseService, *mocks.MockLeaseRepository, *mocks.MockAccessControlService) {},
+			expectedError:  domainErrors.ErrDelegationUnauthorized,
+		},
+		{
+			name:           "delegation to self",
+			gatewayPeerID:  gatewayPeerID,
+			delegatePubkey: gatewayPubkey,
+			signature:      validSignature,
+			setupMocks:     func(*mocks.MockLeaseService, *mocks.MockLeaseRepository, *mocks.MockAccessControlService) {},
+			expectedError:  domainErrors.ErrDelegationToSelf,
+		},
+		{
+			name:           "downstream peer is blocked",
+			gatewayPeerID:  gatewayPeerID,
+			delegatePubkey: delegatePubkey,
+			signature:      validSignature,
+			setupMocks: func(l *mocks.MockLeaseService, r *mocks.MockLeaseRepository, a *mocks.MockAccessControlService) {
+				a.EXPECT().CheckAccess(gomock.Any(), delegatePeerID, delegatePubkey).Return(domainErrors.ErrPeerBlocked)
+			},
+			expectedError: domainErrors.ErrPeerBlocked,
+		},
+		{
+			name:           "quota reached",
+			gatewayPeerID:  gatewayPeerID,
+			delegatePubkey: delegatePubkey,
+			signature:      validSignature,
+			maxLeases:      2,
+			setupMocks: func(l *mocks.MockLeaseService, r *mocks.MockLeaseRepository, a *mocks.MockAccessControlService) {
+				a.EXPECT().CheckAccess(gomock.Any(), delegatePeerID, delegatePubkey).Return(nil)
+				r.EXPECT().CountDelegatedLeases(gomock.Any(), gatewayPeerID).Return(int64(2), nil)
+			},
+			expectedError: domainErrors.ErrDelegationQuota,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			leaseService := mocks.NewMockLeaseService(ctrl)
+			repo := mocks.NewMockLeaseRepository(ctrl)
+			accessControl := mocks.NewMockAccessControlService(ctrl)
+			tt.setupMocks(leaseService, repo, accessControl)
+
+			cfg := &config.AppConfig{DelegationGateways: []config.DelegationGatewayConfig{{PeerID: gatewayPeerID, MaxLeases: tt.maxLeases}}}
+			service := services.NewDelegationService(cfg, leaseService, repo, libp2p.NewSignatureVerifier(), libp2p.NewPeerIDResolver(), accessControl, zap.NewNop())
+
+			result, err := service.AllocateDelegatedIP(context.Background(), &models.LeaseDelegationRequest{
+				GatewayPeerID:  tt.gatewayPeerID,
+				GatewayPubkey:  gatewayPubkey,
+				DelegatePubkey: tt.delegatePubkey,
+				NonceID:        nonceID,
+				Signature:      tt.signature,
+			})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, allocated, result)
+		})
+	}
+}
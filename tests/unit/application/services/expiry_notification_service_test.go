@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+func newExpiryConfig() *config.AppConfig {
+	return &config.AppConfig{
+		ExpiryNotifyWindow:    60,
+		ExpiryNotifyBatchSize: 2,
+	}
+}
+
+func expiringLeases() []*models.ExpiringLease {
+	expiresAt := time.Now().Add(30 * time.Minute)
+	return []*models.ExpiringLease{
+		{TokenID: 1, PeerID: "peer1", ExpiresAt: expiresAt},
+		{TokenID: 2, PeerID: "peer2", ExpiresAt: expiresAt},
+		{TokenID: 3, PeerID: "peer3", ExpiresAt: expiresAt},
+	}
+}
+
+func TestExpiryNotificationService_Run(t *testing.T) {
+	t.Run("notifies every batch through every channel", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leases := expiringLeases()
+		mockRepo := mocks.NewMockLeaseRepository(ctrl)
+		mockRepo.EXPECT().ListExpiringLeases(gomock.Any(), time.Hour, int64(0), 2).Return(leases[:2], nil)
+		mockRepo.EXPECT().ListExpiringLeases(gomock.Any(), time.Hour, int64(2), 2).Return(leases[2:], nil)
+
+		logChannel := mocks.NewMockExpiryChannel(ctrl)
+		logChannel.EXPECT().Name().Return("log")
+		logChannel.EXPECT().Notify(gomock.Any(), leases[:2]).Return(nil)
+		logChannel.EXPECT().Notify(gomock.Any(), leases[2:]).Return(nil)
+
+		webhook := mocks.NewMockExpiryChannel(ctrl)
+		webhook.EXPECT().Name().Return("webhook")
+		webhook.EXPECT().Notify(gomock.Any(), leases[:2]).Return(errors.New("connection refused"))
+		webhook.EXPECT().Notify(gomock.Any(), leases[2:]).Return(nil)
+
+		service := services.NewExpiryNotificationService(newExpiryConfig(), mockRepo, []ports.ExpiryChannel{logChannel, webhook}, zap.NewNop())
+		assert.Nil(t, service.LastReport())
+
+		report, err := service.Run(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Found)
+		assert.WithinDuration(t, report.StartedAt.Add(time.Hour), report.Until, time.Second)
+
+		assert.Equal(t, &models.ExpiryChannelResult{Channel: "log", Batches: 2, Notified: 3}, report.Channels[0])
+		assert.Equal(t, &models.ExpiryChannelResult{Channel: "webhook", Batches: 2, Notified: 1, Failed: 2, LastError: "connection refused"}, report.Channels[1])
+		assert.Same(t, report, service.LastReport())
+	})
+
+	t.Run("repository failure aborts the run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockLeaseRepository(ctrl)
+		mockRepo.EXPECT().ListExpiringLeases(gomock.Any(), time.Hour, int64(0), 2).Return(nil, errors.New("db down"))
+
+		service := services.NewExpiryNotificationService(newExpiryConfig(), mockRepo, nil, zap.NewNop())
+		_, err := service.Run(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, service.LastReport())
+	})
+}
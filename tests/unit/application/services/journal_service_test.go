@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/journaled"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/journal"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+type journalStore struct {
+	cfg       *config.AppConfig
+	store     *embedded.Store
+	snapshots *services.LeaseSnapshotService
+}
+
+func newJournalStore(t *testing.T) *journalStore {
+	cfg := config.NewDefaultAppConfig()
+	cfg.StorageBackend = config.StorageBackendEmbedded
+	cfg.StoragePath = filepath.Join(t.TempDir(), "dhcp2p.json")
+
+	lc := fxtest.NewLifecycle(t)
+	store, err := embedded.NewStore(lc, cfg, clock.NewSystem())
+	require.NoError(t, err)
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	tenants, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+	return &journalStore{cfg, store, services.NewLeaseSnapshotService(embedded.NewLeaseSnapshotRepository(store), tenants, zap.NewNop())}
+}
+
+func TestLeaseJournalService_Verify(t *testing.T) {
+	ctx := context.Background()
+	s := newJournalStore(t)
+
+	sink, err := journal.OpenFileSink(filepath.Join(t.TempDir(), "leases.journal"))
+	require.NoError(t, err)
+	lj, err := journal.Open(ctx, sink, clock.NewSystem())
+	require.NoError(t, err)
+	defer lj.Close(ctx)
+
+	inner := embedded.NewLeaseRepository(s.cfg, s.store)
+	repo := journaled.NewLeaseRepository(inner, lj, zap.NewNop())
+	snapshots := journaled.NewLeaseSnapshotRepository(embedded.NewLeaseSnapshotRepository(s.store), lj, zap.NewNop())
+	service := services.NewLeaseJournalService(lj, snapshots, s.snapshots, clock.NewSystem(), zap.NewNop())
+
+	// A new journal starts with a checkpoint
+	verification, err := service.Verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.JournalVerification{Seq: 1, Checkpointed: true}, verification)
+
+	_, err = repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	verification, err = service.Verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.JournalVerification{Seq: 2}, verification)
+
+	// A mutation the journal missed is only reported when seen twice
+	_, err = inner.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	verification, err = service.Verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.JournalVerification{Seq: 2}, verification)
+
+	verification, err = service.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, verification.Checkpointed)
+	assert.Equal(t, int64(3), verification.Seq)
+	require.Len(t, verification.Mismatches, 1)
+	assert.Contains(t, verification.Mismatches[0], `leased to "peer-2" in the store, not in the journal`)
+
+	// The checkpoint brought the journal up to date
+	verification, err = service.Verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.JournalVerification{Seq: 3}, verification)
+	_, mismatches := lj.Compare(mustExport(t, s), time.Now())
+	assert.Empty(t, mismatches)
+}
+
+func TestLeaseJournalService_VerifyDisabled(t *testing.T) {
+	s := newJournalStore(t)
+	service := services.NewLeaseJournalService(nil, embedded.NewLeaseSnapshotRepository(s.store), s.snapshots, clock.NewSystem(), zap.NewNop())
+
+	_, err := service.Verify(context.Background())
+	assert.Error(t, err)
+}
+
+func TestLeaseJournalService_Replay(t *testing.T) {
+	ctx := context.Background()
+	source := newJournalStore(t)
+
+	sink, err := journal.OpenFileSink(filepath.Join(t.TempDir(), "leases.journal"))
+	require.NoError(t, err)
+	lj, err := journal.Open(ctx, sink, clock.NewSystem())
+	require.NoError(t, err)
+	defer lj.Close(ctx)
+
+	_, err = lj.Checkpoint(ctx, mustExport(t, source))
+	require.NoError(t, err)
+	repo := journaled.NewLeaseRepository(embedded.NewLeaseRepository(source.cfg, source.store), lj, zap.NewNop())
+	first, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	second, err := repo.AllocateNewLease(ctx, "peer-2")
+	require.NoError(t, err)
+	require.NoError(t, repo.ReleaseLease(ctx, first.TokenID, "peer-1"))
+
+	untilSeq := func(seq int64) func(*models.JournalEntry) bool {
+		return func(entry *models.JournalEntry) bool { return entry.Seq <= seq }
+	}
+
+	t.Run("up to a sequence number", func(t *testing.T) {
+		target := newJournalStore(t)
+		service := services.NewLeaseJournalService(nil, embedded.NewLeaseSnapshotRepository(target.store), target.snapshots, clock.NewSystem(), zap.NewNop())
+
+		report, err := service.Replay(ctx, lj, untilSeq(2), true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), report.Checkpoint)
+		assert.Equal(t, int64(2), report.LastSeq)
+		assert.Equal(t, 1, report.Entries)
+
+		leases := mustExport(t, target).Leases
+		require.Len(t, leases, 1)
+		assert.Equal(t, "peer-1", leases[0].PeerID)
+		assert.True(t, leases[0].ExpiresAt.After(time.Now()))
+	})
+
+	t.Run("the whole journal", func(t *testing.T) {
+		target := newJournalStore(t)
+		service := services.NewLeaseJournalService(nil, embedded.NewLeaseSnapshotRepository(target.store), target.snapshots, clock.NewSystem(), zap.NewNop())
+
+		report, err := service.Replay(ctx, lj, nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), report.LastSeq)
+		assert.Equal(t, 3, report.Entries)
+
+		_, mismatches := lj.Compare(mustExport(t, target), time.Now())
+		assert.Empty(t, mismatches)
+		leases := mustExport(t, target).Leases
+		require.Len(t, leases, 2)
+		assert.Equal(t, second.TokenID, leases[1].TokenID)
+	})
+
+	t.Run("a journal without a checkpoint", func(t *testing.T) {
+		target := newJournalStore(t)
+		service := services.NewLeaseJournalService(nil, embedded.NewLeaseSnapshotRepository(target.store), target.snapshots, clock.NewSystem(), zap.NewNop())
+
+		_, err := service.Replay(ctx, lj, untilSeq(0), true)
+		assert.Error(t, err)
+	})
+}
+
+func mustExport(t *testing.T, s *journalStore) *models.LeaseSnapshot {
+	snapshot, err := s.snapshots.Export(context.Background())
+	require.NoError(t, err)
+	return snapshot
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func TestLeaseQueryService_GetLeaseStats(t *testing.T) {
+	refreshedAt := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name           string
+		mockSetup      func(*mocks.MockLeaseReadModel)
+		expectedResult *models.LeaseStats
+		expectError    bool
+	}{
+		{
+			name: "returns stats from read model",
+			mockSetup: func(m *mocks.MockLeaseReadModel) {
+				m.EXPECT().GetLeaseStats(gomock.Any()).Return(&models.LeaseStats{Active: 2, Expired: 1, Total: 3, RefreshedAt: refreshedAt}, nil)
+			},
+			expectedResult: &models.LeaseStats{Active: 2, Expired: 1, Total: 3, RefreshedAt: refreshedAt},
+		},
+		{
+			name: "read model error",
+			mockSetup: func(m *mocks.MockLeaseReadModel) {
+				m.EXPECT().GetLeaseStats(gomock.Any()).Return(nil, errors.New("view not populated"))
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockReadModel := mocks.NewMockLeaseReadModel(ctrl)
+			tt.mockSetup(mockReadModel)
+
+			service := services.NewLeaseQueryService(&config.AppConfig{}, mockReadModel, nil, clock.NewSystem())
+			result, err := service.GetLeaseStats(context.Background())
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
+
+func TestLeaseQueryService_ListLeases(t *testing.T) {
+	leases := []*models.Lease{{TokenID: 1}, {TokenID: 2}, {TokenID: 3}}
+
+	tests := []struct {
+		name            string
+		returned        []*models.Lease
+		expectedLeases  []*models.Lease
+		expectedHasMore bool
+	}{
+		{name: "more pages follow", returned: leases, expectedLeases: leases[:2], expectedHasMore: true},
+		{name: "last page", returned: leases[:2], expectedLeases: leases[:2]},
+		{name: "empty page", returned: nil, expectedLeases: []*models.Lease{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			readModel := mocks.NewMockLeaseReadModel(ctrl)
+			readModel.EXPECT().ListLeases(gomock.Any(), &models.ListOptions{Limit: 3, SortField: "token_id"}).Return(tt.returned, nil)
+			service := services.NewLeaseQueryService(&config.AppConfig{}, readModel, nil, clock.NewSystem())
+
+			page, err := service.ListLeases(context.Background(), &models.ListOptions{Limit: 2, SortField: "token_id"})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedLeases, page.Leases)
+			assert.Equal(t, tt.expectedHasMore, page.HasMore)
+		})
+	}
+}
+
+func TestLeaseQueryService_SearchLeases(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cfg := config.NewDefaultAppConfig()
+	cfg.PeerPolicySource = config.PeerPolicySourceConfig
+	cfg.PeerPolicies = []config.PeerPolicyConfig{
+		{Name: "gateways", Peers: []string{"peer-gw"}, CanAllocate: true},
+		{Name: "everyone", Peers: []string{"*"}, CanAllocate: true},
+	}
+	policies, _ := newTestPeerPolicyService(t, cfg)
+	labels := models.Filter{Field: "label", Operator: models.FilterEq, Value: models.LeaseLabel{Key: "region", Value: "us-east"}}
+
+	t.Run("active leases of a policy", func(t *testing.T) {
+		readModel := mocks.NewMockLeaseReadModel(gomock.NewController(t))
+		readModel.EXPECT().ListLeases(gomock.Any(), &models.ListOptions{
+			Limit:     3,
+			SortField: "token_id",
+			Filters: []models.Filter{
+				labels,
+				{Field: "expires_at", Operator: models.FilterGt, Value: now},
+				{Field: "peer_id", Operator: models.FilterIn, Value: []string{"peer-gw"}},
+			},
+		}).Return([]*models.Lease{{TokenID: 167772161, PeerID: "peer-gw"}}, nil)
+		service := services.NewLeaseQueryService(cfg, readModel, policies, clock.NewFake(now))
+
+		page, err := service.SearchLeases(ctx, &models.LeaseSearch{
+			ListOptions: models.ListOptions{Limit: 2, SortField: "token_id", Filters: []models.Filter{labels}},
+			State:       models.LeaseStateActive,
+			Policy:      "gateways",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, page.Leases, 1)
+		assert.Equal(t, "10.0.0.1", page.Leases[0].IP)
+		assert.Equal(t, "gateways", page.Leases[0].Policy)
+		assert.False(t, page.HasMore)
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		service := services.NewLeaseQueryService(cfg, mocks.NewMockLeaseReadModel(gomock.NewController(t)), policies, clock.NewFake(now))
+		_, err := service.SearchLeases(ctx, &models.LeaseSearch{ListOptions: models.ListOptions{Limit: 2, SortField: "token_id"}, Policy: "relays"})
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidFilter)
+	})
+
+	t.Run("policy without peer policies", func(t *testing.T) {
+		service := services.NewLeaseQueryService(&config.AppConfig{}, mocks.NewMockLeaseReadModel(gomock.NewController(t)), policies, clock.NewFake(now))
+		_, err := service.SearchLeases(ctx, &models.LeaseSearch{ListOptions: models.ListOptions{Limit: 2, SortField: "token_id"}, Policy: "gateways"})
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidFilter)
+	})
+}
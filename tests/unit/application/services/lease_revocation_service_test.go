@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/utils"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+func TestLeaseRevocationService_Revoke(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.NewDefaultAppConfig()
+	cfg.Lease.RevocationBatchSize = 2
+	store := embedded.NewMemoryStore(clock.NewSystem())
+	repo := embedded.NewLeaseRepository(cfg, store)
+	tenants, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var released []int64
+	events := mocks.NewMockLeaseEventPublisher(ctrl)
+	events.EXPECT().Publish(gomock.Any()).Do(func(event *models.LeaseLifecycleEvent) {
+		assert.Equal(t, models.LeaseLifecycleReleased, event.Type)
+		mu.Lock()
+		released = append(released, event.TokenID)
+		mu.Unlock()
+	}).AnyTimes()
+	service := services.NewLeaseRevocationService(cfg, repo, embedded.NewLeaseReadModel(store), tenants, events, clock.NewSystem(), zap.NewNop())
+
+	leases := make(map[string]*models.Lease)
+	for _, peerID := range []string{"peer-1", "peer-2", "peer-3", "peer-4", "peer-5"} {
+		lease, err := repo.AllocateNewLease(ctx, peerID)
+		require.NoError(t, err)
+		leases[peerID] = lease
+	}
+	for _, peerID := range []string{"peer-2", "peer-3", "peer-4"} {
+		require.NoError(t, repo.SetLeaseLabels(ctx, leases[peerID].TokenID, peerID, map[string]string{"role": "edge"}))
+	}
+	active := func(peerID string) bool {
+		// Released leases are not found
+		lease, err := repo.GetLeaseByTokenID(ctx, leases[peerID].TokenID)
+		return err == nil && lease.ExpiresAt.After(time.Now())
+	}
+
+	t.Run("a dry run only lists the matches", func(t *testing.T) {
+		revocation, err := service.Revoke(ctx, &models.LeaseRevocationRequest{
+			Filter: models.LeaseRevocationFilter{PeerIDs: []string{"peer-2", "peer-1", "peer-2"}},
+			DryRun: true,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, revocation.ID)
+		assert.Equal(t, models.LeaseRevocationCompleted, revocation.State)
+		assert.Equal(t, 2, revocation.Matched)
+		assert.Equal(t, []int64{leases["peer-1"].TokenID, leases["peer-2"].TokenID}, revocation.TokenIDs)
+		assert.True(t, active("peer-1"))
+		assert.True(t, active("peer-2"))
+	})
+
+	t.Run("every criterion must match", func(t *testing.T) {
+		revocation, err := service.Revoke(ctx, &models.LeaseRevocationRequest{
+			Filter: models.LeaseRevocationFilter{LabelSelector: "role=edge", MinTokenID: leases["peer-3"].TokenID},
+			Reason: "compromised edge nodes",
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, revocation.ID)
+		assert.Equal(t, models.LeaseRevocationCompleted, revocation.State)
+		assert.Equal(t, 2, revocation.Matched)
+		assert.Equal(t, 2, revocation.Revoked)
+		assert.Equal(t, []int64{leases["peer-3"].TokenID, leases["peer-4"].TokenID}, revocation.TokenIDs)
+		assert.True(t, active("peer-2"))
+		assert.False(t, active("peer-3"))
+		assert.False(t, active("peer-4"))
+
+		reported, err := service.Revocation(ctx, revocation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, revocation, reported)
+	})
+
+	t.Run("a CIDR selects the leases of its addresses", func(t *testing.T) {
+		first := utils.IPFromTokenID(uint32(leases["peer-1"].TokenID))
+		revocation, err := service.Revoke(ctx, &models.LeaseRevocationRequest{
+			Filter: models.LeaseRevocationFilter{CIDR: first + "/24"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, revocation.Matched, "peer-3 and peer-4 were revoked already")
+		assert.Equal(t, 3, revocation.Revoked)
+		for _, peerID := range []string{"peer-1", "peer-2", "peer-5"} {
+			assert.False(t, active(peerID), peerID)
+		}
+
+		again, err := service.Revoke(ctx, &models.LeaseRevocationRequest{
+			Filter: models.LeaseRevocationFilter{CIDR: first + "/24"},
+		})
+		require.NoError(t, err)
+		assert.Zero(t, again.Matched)
+	})
+
+	mu.Lock()
+	assert.Len(t, released, 5, "a lease.released event per revoked lease")
+	mu.Unlock()
+
+	t.Run("invalid filters", func(t *testing.T) {
+		_, err := service.Revoke(ctx, &models.LeaseRevocationRequest{
+			Filter: models.LeaseRevocationFilter{CIDR: "192.168.0.0/16"},
+		})
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidRevocation)
+
+		_, err = service.Revoke(ctx, &models.LeaseRevocationRequest{
+			Filter: models.LeaseRevocationFilter{Tenant: "acme", PeerIDs: []string{"peer-1"}},
+		})
+		assert.ErrorIs(t, err, domainErrors.ErrTenantNotFound)
+
+		_, err = service.Revocation(ctx, "4f9d3c52-8a0e-4c4b-9f57-1d2c3b4a5e6f")
+		assert.ErrorIs(t, err, domainErrors.ErrRevocationNotFound)
+	})
+}
+
+func TestLeaseRevocationService_ReportsProgress(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.Lease.RevocationBatchSize = 1
+	cfg.Lease.RevocationWait = 0
+	store := embedded.NewMemoryStore(clock.NewSystem())
+	repo := embedded.NewLeaseRepository(cfg, store)
+	tenants, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+	service := services.NewLeaseRevocationService(cfg, repo, embedded.NewLeaseReadModel(store), tenants, nil, clock.NewSystem(), zap.NewNop())
+
+	for _, peerID := range []string{"peer-1", "peer-2", "peer-3"} {
+		_, err := repo.AllocateNewLease(ctx, peerID)
+		require.NoError(t, err)
+	}
+
+	// Without a wait the revocation continues in the background
+	revocation, err := service.Revoke(ctx, &models.LeaseRevocationRequest{
+		Filter: models.LeaseRevocationFilter{PeerIDs: []string{"peer-1", "peer-2", "peer-3"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, revocation.Matched)
+
+	require.Eventually(t, func() bool {
+		reported, err := service.Revocation(ctx, revocation.ID)
+		require.NoError(t, err)
+		return reported.State == models.LeaseRevocationCompleted
+	}, time.Second, 10*time.Millisecond)
+
+	reported, err := service.Revocation(ctx, revocation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, reported.Processed)
+	assert.Equal(t, 3, reported.Revoked)
+	assert.Zero(t, reported.Skipped)
+	assert.NotNil(t, reported.FinishedAt)
+}
+
+func TestLeaseRevocationService_Tenants(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}}
+	store := embedded.NewMemoryStore(clock.NewSystem())
+	repo := embedded.NewLeaseRepository(cfg, store)
+	tenants, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+	service := services.NewLeaseRevocationService(cfg, repo, embedded.NewLeaseReadModel(store), tenants, nil, clock.NewSystem(), zap.NewNop())
+
+	lease, err := repo.AllocateNewLease(ctx, "peer-1")
+	require.NoError(t, err)
+	acmeLease, err := repo.AllocateNewLease(models.WithTenant(ctx, "acme"), "peer-1")
+	require.NoError(t, err)
+
+	revocation, err := service.Revoke(ctx, &models.LeaseRevocationRequest{
+		Filter: models.LeaseRevocationFilter{Tenant: "acme", PeerIDs: []string{"peer-1"}},
+		DryRun: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{acmeLease.TokenID}, revocation.TokenIDs)
+
+	// Without a tenant the leases of every tenant are matched
+	revocation, err = service.Revoke(ctx, &models.LeaseRevocationRequest{
+		Filter: models.LeaseRevocationFilter{PeerIDs: []string{"peer-1"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{lease.TokenID, acmeLease.TokenID}, revocation.TokenIDs)
+}
@@ -5,12 +5,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/allocation"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 	"go.uber.org/zap"
 )
 
@@ -87,9 +94,11 @@ func TestLeaseService_AllocateIP(t *testing.T) {
 			tt.mockSetup(ctrl, mockRepo)
 
 			service := services.NewLeaseService(&config.AppConfig{
-				MaxLeaseRetries: 3,
-				LeaseRetryDelay: 100,
-			}, mockRepo, zap.NewNop())
+				Lease: config.LeaseConfig{
+					MaxRetries: 3,
+					RetryDelay: 100,
+				},
+			}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 			result, err := service.AllocateIP(context.Background(), tt.peerID)
 
@@ -110,7 +119,7 @@ func TestLeaseService_GetLeaseByPeerID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockLeaseRepository(ctrl)
-	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, zap.NewNop())
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	expectedLease := &models.Lease{
 		TokenID:   167772161,
@@ -132,7 +141,7 @@ func TestLeaseService_GetLeaseByTokenID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockLeaseRepository(ctrl)
-	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, zap.NewNop())
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	expectedLease := &models.Lease{
 		TokenID:   167772161,
@@ -149,12 +158,87 @@ func TestLeaseService_GetLeaseByTokenID(t *testing.T) {
 	assert.Equal(t, expectedLease, result)
 }
 
+func TestLeaseService_GetLeaseHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+	history := []*models.LeaseHistoryEntry{
+		{TokenID: 167772161, PeerID: "peer123", Event: models.LeaseEventAllocate},
+		{TokenID: 167772161, PeerID: "peer123", Event: models.LeaseEventRelease},
+	}
+	mockRepo.EXPECT().GetLeaseHistory(gomock.Any(), int64(167772161)).Return(history, nil)
+	mockRepo.EXPECT().GetLeaseHistory(gomock.Any(), int64(167772162)).Return(nil, nil)
+
+	result, err := service.GetLeaseHistory(context.Background(), 167772161)
+	require.NoError(t, err)
+	assert.Equal(t, history, result)
+
+	_, err = service.GetLeaseHistory(context.Background(), 167772162)
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound, "a token ID that was never leased")
+}
+
+func TestLeaseService_ReportConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	service := services.NewLeaseService(&config.AppConfig{Lease: config.LeaseConfig{ConflictQuarantine: 30}}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+	mockRepo.EXPECT().RecordConflict(gomock.Any(), int64(167772161), "peer123", 30*time.Minute).
+		Return(&models.LeaseConflict{TokenID: 167772161, PeerID: "peer123", Quarantined: true}, nil)
+
+	conflict, err := service.ReportConflict(context.Background(), &models.LeaseConflictReport{
+		TokenID:        167772161,
+		PeerID:         "peer123",
+		ObservedPeerID: "peer456",
+	})
+	require.NoError(t, err)
+	assert.True(t, conflict.Quarantined)
+	assert.Equal(t, "peer456", conflict.ObservedPeerID)
+
+	mockRepo.EXPECT().RecordConflict(gomock.Any(), int64(167772161), "peer789", 30*time.Minute).
+		Return(nil, domainErrors.ErrLeaseNotFound)
+
+	_, err = service.ReportConflict(context.Background(), &models.LeaseConflictReport{TokenID: 167772161, PeerID: "peer789"})
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound, "only the holder can report a conflict")
+}
+
+func TestLeaseService_SetLeaseLabels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+	mockRepo.EXPECT().SetLeaseLabels(gomock.Any(), int64(167772161), "peer123", map[string]string{"role": "validator"}).Return(nil)
+	labels, err := service.SetLeaseLabels(context.Background(), 167772161, "peer123", map[string]string{"role": " validator\t"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"role": "validator"}, labels)
+
+	// No labels clear them
+	mockRepo.EXPECT().SetLeaseLabels(gomock.Any(), int64(167772161), "peer123", nil).Return(nil)
+	labels, err = service.SetLeaseLabels(context.Background(), 167772161, "peer123", map[string]string{})
+	require.NoError(t, err)
+	assert.Nil(t, labels)
+
+	// Invalid labels never reach the repository
+	_, err = service.SetLeaseLabels(context.Background(), 167772161, "peer123", map[string]string{"Role": "validator"})
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidLabels)
+
+	mockRepo.EXPECT().SetLeaseLabels(gomock.Any(), int64(167772161), "peer789", nil).Return(domainErrors.ErrLeaseNotFound)
+	_, err = service.SetLeaseLabels(context.Background(), 167772161, "peer789", nil)
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+}
+
 func TestLeaseService_RenewLease(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockLeaseRepository(ctrl)
-	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, zap.NewNop())
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	expectedLease := &models.Lease{
 		TokenID:   167772161,
@@ -171,12 +255,110 @@ func TestLeaseService_RenewLease(t *testing.T) {
 	assert.Equal(t, expectedLease, result)
 }
 
+func TestLeaseService_RenewLease_RenewalWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	service := services.NewLeaseService(&config.AppConfig{Lease: config.LeaseConfig{RenewalWindow: 30}}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+	t.Run("early renewals return the lease unchanged", func(t *testing.T) {
+		stored := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Hour)}
+		mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167772161)).Return(stored, nil)
+
+		lease, err := service.RenewLease(context.Background(), 167772161, "peer123")
+		require.NoError(t, err)
+		assert.Equal(t, stored.ExpiresAt, lease.ExpiresAt)
+		require.NotNil(t, lease.RenewableAt)
+		assert.Equal(t, stored.ExpiresAt.Add(-30*time.Minute), *lease.RenewableAt)
+		assert.Nil(t, stored.RenewableAt, "the stored lease is not modified")
+	})
+
+	t.Run("leases within the window are renewed", func(t *testing.T) {
+		stored := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: time.Now().Add(10 * time.Minute)}
+		renewed := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Hour)}
+		mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167772161)).Return(stored, nil)
+		mockRepo.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(renewed, nil)
+
+		lease, err := service.RenewLease(context.Background(), 167772161, "peer123")
+		require.NoError(t, err)
+		assert.Equal(t, renewed, lease)
+	})
+
+	t.Run("leases of other peers are left to the renewal", func(t *testing.T) {
+		stored := &models.Lease{TokenID: 167772161, PeerID: "other", ExpiresAt: time.Now().Add(time.Hour)}
+		mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167772161)).Return(stored, nil)
+		mockRepo.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(nil, domainErrors.ErrLeaseNotFound)
+
+		_, err := service.RenewLease(context.Background(), 167772161, "peer123")
+		assert.ErrorIs(t, err, domainErrors.ErrLeaseNotFound)
+	})
+
+	t.Run("the window opens 30 minutes before expiry", func(t *testing.T) {
+		fakeClock := clock.NewFake(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+		service := services.NewLeaseService(&config.AppConfig{Lease: config.LeaseConfig{RenewalWindow: 30}}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, fakeClock, zap.NewNop())
+		stored := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: fakeClock.Now().Add(time.Hour)}
+		renewed := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: fakeClock.Now().Add(2 * time.Hour)}
+		mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167772161)).Return(stored, nil).Times(2)
+
+		fakeClock.Advance(30*time.Minute - time.Second)
+		lease, err := service.RenewLease(context.Background(), 167772161, "peer123")
+		require.NoError(t, err)
+		assert.NotNil(t, lease.RenewableAt, "a second before the window")
+
+		fakeClock.Advance(time.Second)
+		mockRepo.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(renewed, nil)
+		lease, err = service.RenewLease(context.Background(), 167772161, "peer123")
+		require.NoError(t, err)
+		assert.Equal(t, renewed, lease)
+	})
+}
+
+func TestLeaseService_RenewAfter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	fakeClock := clock.NewFake(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+	renewed := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: fakeClock.Now().Add(time.Hour)}
+
+	t.Run("halfway through the lease, give or take the jitter", func(t *testing.T) {
+		service := services.NewLeaseService(&config.AppConfig{Lease: config.LeaseConfig{RenewHintPercent: 50, RenewHintJitter: 10}}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, fakeClock, zap.NewNop())
+		mockRepo.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(renewed, nil)
+
+		lease, err := service.RenewLease(context.Background(), 167772161, "peer123")
+		require.NoError(t, err)
+		require.NotNil(t, lease.RenewAfter)
+		assert.WithinRange(t, *lease.RenewAfter, fakeClock.Now().Add(24*time.Minute), fakeClock.Now().Add(36*time.Minute))
+		assert.Nil(t, renewed.RenewAfter, "the stored lease is not modified")
+	})
+
+	t.Run("halfway through the renewal window once it opens", func(t *testing.T) {
+		service := services.NewLeaseService(&config.AppConfig{Lease: config.LeaseConfig{RenewalWindow: 20, RenewHintPercent: 50}}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, fakeClock, zap.NewNop())
+		mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167772161)).Return(renewed, nil)
+
+		lease, err := service.RenewLease(context.Background(), 167772161, "peer123")
+		require.NoError(t, err)
+		require.NotNil(t, lease.RenewAfter)
+		assert.Equal(t, renewed.ExpiresAt.Add(-10*time.Minute), *lease.RenewAfter)
+	})
+
+	t.Run("no hint without renew_hint_percent", func(t *testing.T) {
+		service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, fakeClock, zap.NewNop())
+		mockRepo.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(renewed, nil)
+
+		lease, err := service.RenewLease(context.Background(), 167772161, "peer123")
+		require.NoError(t, err)
+		assert.Nil(t, lease.RenewAfter)
+	})
+}
+
 func TestLeaseService_ReleaseLease(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockLeaseRepository(ctrl)
-	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, zap.NewNop())
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
 
 	mockRepo.EXPECT().ReleaseLease(gomock.Any(), int64(167772161), "peer123").Return(nil)
 
@@ -184,3 +366,537 @@ func TestLeaseService_ReleaseLease(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestLeaseService_RejectsInvalidKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The repository is never reached
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+	ctx := context.Background()
+
+	_, err := service.RenewLease(ctx, 0, "peer123")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidTokenID)
+	assert.ErrorIs(t, service.ReleaseLease(ctx, 167772161, ""), domainErrors.ErrMissingPeerID)
+	_, err = service.AllocateIP(ctx, string(make([]byte, models.MaxPeerIDLength+1)))
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidPeerID)
+	_, err = service.GetLeaseByTokenID(ctx, -1)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidTokenID)
+}
+
+func TestLeaseService_LifecycleEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockEvents := mocks.NewMockLeaseEventPublisher(ctrl)
+	service := services.NewLeaseService(&config.AppConfig{Lease: config.LeaseConfig{MaxRetries: 1}}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, mockEvents, clock.NewSystem(), zap.NewNop())
+
+	ctx := models.WithTenant(context.Background(), "acme")
+	lease := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Hour)}
+
+	var published []*models.LeaseLifecycleEvent
+	mockEvents.EXPECT().Publish(gomock.Any()).Do(func(event *models.LeaseLifecycleEvent) {
+		published = append(published, event)
+	}).AnyTimes()
+
+	t.Run("allocation", func(t *testing.T) {
+		published = nil
+		mockRepo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil)
+		mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer123").Return(lease, nil)
+
+		_, err := service.AllocateIP(ctx, "peer123")
+		require.NoError(t, err)
+		require.Len(t, published, 1)
+		assert.Equal(t, models.LeaseLifecycleAllocated, published[0].Type)
+		assert.Equal(t, int64(167772161), published[0].TokenID)
+		assert.Equal(t, "peer123", published[0].PeerID)
+		assert.Equal(t, "acme", published[0].TenantID)
+		assert.Equal(t, lease.ExpiresAt, published[0].ExpiresAt)
+	})
+
+	t.Run("existing lease", func(t *testing.T) {
+		published = nil
+		mockRepo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(lease, nil)
+
+		_, err := service.AllocateIP(ctx, "peer123")
+		require.NoError(t, err)
+		assert.Empty(t, published)
+	})
+
+	t.Run("renewal", func(t *testing.T) {
+		published = nil
+		mockRepo.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(lease, nil)
+
+		_, err := service.RenewLease(ctx, 167772161, "peer123")
+		require.NoError(t, err)
+		require.Len(t, published, 1)
+		assert.Equal(t, models.LeaseLifecycleRenewed, published[0].Type)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		published = nil
+		mockRepo.EXPECT().ReleaseLease(gomock.Any(), int64(167772161), "peer123").Return(nil)
+
+		require.NoError(t, service.ReleaseLease(ctx, 167772161, "peer123"))
+		require.Len(t, published, 1)
+		assert.Equal(t, models.LeaseLifecycleReleased, published[0].Type)
+		assert.Equal(t, "peer123", published[0].PeerID)
+	})
+
+	t.Run("failed release", func(t *testing.T) {
+		published = nil
+		mockRepo.EXPECT().ReleaseLease(gomock.Any(), int64(167772161), "peer123").Return(domainErrors.ErrLeaseNotFound)
+
+		assert.Error(t, service.ReleaseLease(ctx, 167772161, "peer123"))
+		assert.Empty(t, published)
+	})
+}
+
+func TestLeaseService_AllocateIP_QuotaExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// A concurrent allocation for the same peer committed first; the quota error is
+	// returned at once instead of being retried
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockRepo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil)
+	mockRepo.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer123").Return(nil, domainErrors.ErrLeaseQuotaExceeded)
+
+	service := services.NewLeaseService(&config.AppConfig{
+		Lease: config.LeaseConfig{
+			MaxRetries: 3,
+			RetryDelay: 100,
+		},
+	}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+	lease, err := service.AllocateIP(context.Background(), "peer123")
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseQuotaExceeded)
+	assert.Nil(t, lease)
+}
+
+func TestLeaseService_AllocateRequestedIP(t *testing.T) {
+	requested := int64(167772200)
+	grantedLease := &models.Lease{TokenID: requested, PeerID: "peer123"}
+	fallbackLease := &models.Lease{TokenID: 167772161, PeerID: "peer123"}
+
+	tests := []struct {
+		name          string
+		setupMock     func(*mocks.MockLeaseRepository)
+		expectedLease *models.Lease
+		expectGranted bool
+		expectReason  models.AllocationReason
+	}{
+		{
+			name: "requested token granted",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil)
+				m.EXPECT().AllocateRequestedLease(gomock.Any(), "peer123", requested).Return(grantedLease, nil)
+			},
+			expectedLease: grantedLease,
+			expectGranted: true,
+			expectReason:  models.AllocationReasonGranted,
+		},
+		{
+			name: "requested token in use falls back",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil).Times(2)
+				m.EXPECT().AllocateRequestedLease(gomock.Any(), "peer123", requested).Return(nil, domainErrors.ErrTokenIDInUse)
+				m.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer123").Return(fallbackLease, nil)
+			},
+			expectedLease: fallbackLease,
+			expectReason:  models.AllocationReasonInUse,
+		},
+		{
+			name: "requested token out of range falls back",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil).Times(2)
+				m.EXPECT().AllocateRequestedLease(gomock.Any(), "peer123", requested).Return(nil, domainErrors.ErrTokenIDOutOfRange)
+				m.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer123").Return(fallbackLease, nil)
+			},
+			expectedLease: fallbackLease,
+			expectReason:  models.AllocationReasonOutOfRange,
+		},
+		{
+			name: "peer keeps existing lease",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(fallbackLease, nil)
+			},
+			expectedLease: fallbackLease,
+			expectReason:  models.AllocationReasonExistingLease,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockLeaseRepository(ctrl)
+			tt.setupMock(mockRepo)
+			service := services.NewLeaseService(&config.AppConfig{
+				Lease: config.LeaseConfig{
+					MaxRetries: 3,
+					RetryDelay: 100,
+				},
+			}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+			result, err := service.AllocateRequestedIP(context.Background(), "peer123", requested)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedLease, result.Lease)
+			assert.Equal(t, requested, result.RequestedTokenID)
+			assert.Equal(t, tt.expectGranted, result.Granted)
+			assert.Equal(t, tt.expectReason, result.Reason)
+		})
+	}
+}
+
+func TestLeaseService_AllocateAffinityIP(t *testing.T) {
+	contiguousLease := &models.Lease{TokenID: 167772162, PeerID: "peer123"}
+	fallbackLease := &models.Lease{TokenID: 167772300, PeerID: "peer123"}
+
+	tests := []struct {
+		name          string
+		setupMock     func(*mocks.MockLeaseRepository)
+		expectedLease *models.Lease
+	}{
+		{
+			name: "contiguous slot found",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil)
+				m.EXPECT().AllocateAffinityLease(gomock.Any(), "peer123", "gw-1").Return(contiguousLease, nil)
+			},
+			expectedLease: contiguousLease,
+		},
+		{
+			name: "no contiguous slot falls back and tags lease",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil).Times(2)
+				m.EXPECT().AllocateAffinityLease(gomock.Any(), "peer123", "gw-1").Return(nil, nil)
+				m.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer123").Return(fallbackLease, nil)
+				m.EXPECT().SetLeaseAffinityGroup(gomock.Any(), fallbackLease.TokenID, "gw-1").Return(nil)
+			},
+			expectedLease: fallbackLease,
+		},
+		{
+			name: "peer keeps existing lease",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(fallbackLease, nil)
+			},
+			expectedLease: fallbackLease,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockLeaseRepository(ctrl)
+			tt.setupMock(mockRepo)
+			service := services.NewLeaseService(&config.AppConfig{
+				Lease: config.LeaseConfig{
+					MaxRetries: 3,
+					RetryDelay: 100,
+				},
+			}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+			result, err := service.AllocateAffinityIP(context.Background(), "peer123", "gw-1")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedLease, result)
+		})
+	}
+}
+
+func TestLeaseService_AllocateZoneIP(t *testing.T) {
+	zoneLease := &models.Lease{TokenID: 167772200, PeerID: "peer123"}
+	fallbackLease := &models.Lease{TokenID: 167772300, PeerID: "peer123"}
+
+	tests := []struct {
+		name          string
+		setupMock     func(*mocks.MockLeaseRepository)
+		expectedLease *models.Lease
+	}{
+		{
+			name: "free token ID in the zone",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil)
+				m.EXPECT().AllocateZoneLease(gomock.Any(), "peer123", "rack-1").Return(zoneLease, nil)
+			},
+			expectedLease: zoneLease,
+		},
+		{
+			name: "full zone falls back to any zone",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil).Times(2)
+				m.EXPECT().AllocateZoneLease(gomock.Any(), "peer123", "rack-1").Return(nil, nil)
+				m.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer123").Return(fallbackLease, nil)
+			},
+			expectedLease: fallbackLease,
+		},
+		{
+			name: "zone allocation error falls back to any zone",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil).Times(2)
+				m.EXPECT().AllocateZoneLease(gomock.Any(), "peer123", "rack-1").Return(nil, assert.AnError)
+				m.EXPECT().FindAndReuseExpiredLease(gomock.Any(), "peer123").Return(fallbackLease, nil)
+			},
+			expectedLease: fallbackLease,
+		},
+		{
+			name: "peer keeps existing lease",
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(fallbackLease, nil)
+			},
+			expectedLease: fallbackLease,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockLeaseRepository(ctrl)
+			tt.setupMock(mockRepo)
+			service := services.NewLeaseService(&config.AppConfig{
+				Lease: config.LeaseConfig{
+					MaxRetries: 3,
+					RetryDelay: 100,
+				},
+			}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+			result, err := service.AllocateZoneIP(context.Background(), "peer123", "rack-1")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedLease, result)
+		})
+	}
+}
+
+func TestLeaseService_TransferLease(t *testing.T) {
+	oldKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	newKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+
+	oldPubkey, err := crypto.MarshalPublicKey(oldKey.GetPublic())
+	require.NoError(t, err)
+	newPubkey, err := crypto.MarshalPublicKey(newKey.GetPublic())
+	require.NoError(t, err)
+
+	oldPeerID, err := dhcp2pcrypto.PeerIDFromPublicKey(oldPubkey)
+	require.NoError(t, err)
+	newPeerID, err := dhcp2pcrypto.PeerIDFromPublicKey(newPubkey)
+	require.NoError(t, err)
+
+	const nonceID = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	tokenID := int64(167772161)
+
+	validSignature, err := oldKey.Sign(dhcp2pcrypto.TransferPayload(nonceID, tokenID, newPeerID))
+	require.NoError(t, err)
+	// Signed by the new key instead of the current owner
+	wrongSignature, err := newKey.Sign(dhcp2pcrypto.TransferPayload(nonceID, tokenID, newPeerID))
+	require.NoError(t, err)
+
+	transferred := &models.Lease{TokenID: tokenID, PeerID: newPeerID}
+
+	tests := []struct {
+		name          string
+		toPubkey      []byte
+		signature     []byte
+		setupMock     func(*mocks.MockLeaseRepository)
+		denied        error // returned by the access control for the recipient
+		expectedError error
+	}{
+		{
+			name:      "authorized transfer",
+			toPubkey:  newPubkey,
+			signature: validSignature,
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().TransferLease(gomock.Any(), tokenID, oldPeerID, newPeerID).Return(transferred, nil)
+			},
+		},
+		{
+			name:          "signature from wrong key",
+			toPubkey:      newPubkey,
+			signature:     wrongSignature,
+			setupMock:     func(m *mocks.MockLeaseRepository) {},
+			expectedError: domainErrors.ErrTransferUnauthorized,
+		},
+		{
+			name:          "transfer to self",
+			toPubkey:      oldPubkey,
+			signature:     validSignature,
+			setupMock:     func(m *mocks.MockLeaseRepository) {},
+			expectedError: domainErrors.ErrTransferToSelf,
+		},
+		{
+			name:      "caller does not own the lease",
+			toPubkey:  newPubkey,
+			signature: validSignature,
+			setupMock: func(m *mocks.MockLeaseRepository) {
+				m.EXPECT().TransferLease(gomock.Any(), tokenID, oldPeerID, newPeerID).Return(nil, domainErrors.ErrLeaseNotFound)
+			},
+			expectedError: domainErrors.ErrLeaseNotFound,
+		},
+		{
+			name:          "recipient on the deny list",
+			toPubkey:      newPubkey,
+			signature:     validSignature,
+			setupMock:     func(m *mocks.MockLeaseRepository) {},
+			denied:        domainErrors.ErrPeerBlocked,
+			expectedError: domainErrors.ErrPeerBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockLeaseRepository(ctrl)
+			tt.setupMock(mockRepo)
+			mockAccess := mocks.NewMockAccessControlService(ctrl)
+			mockAccess.EXPECT().CheckAccess(gomock.Any(), newPeerID, newPubkey).Return(tt.denied).AnyTimes()
+			service := services.NewLeaseService(&config.AppConfig{}, mockRepo, allocation.NewLRU(mockRepo), libp2p.NewSignatureVerifier(), libp2p.NewPeerIDResolver(), mockAccess, nil, nil, clock.NewSystem(), zap.NewNop())
+
+			result, err := service.TransferLease(context.Background(), &models.LeaseTransferRequest{
+				TokenID:    tokenID,
+				FromPubkey: oldPubkey,
+				ToPubkey:   tt.toPubkey,
+				NonceID:    nonceID,
+				Signature:  tt.signature,
+			})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, transferred, result)
+		})
+	}
+}
+
+func TestLeaseService_ExecuteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	service := services.NewLeaseService(&config.AppConfig{Lease: config.LeaseConfig{BatchMaxOperations: 2}}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+	_, err := service.ExecuteBatch(context.Background(), nil)
+	assert.ErrorIs(t, err, domainErrors.ErrEmptyBatch)
+
+	tooMany := []*models.LeaseOperation{
+		{Type: models.LeaseOperationAllocate, PeerID: "peer1"},
+		{Type: models.LeaseOperationAllocate, PeerID: "peer2"},
+		{Type: models.LeaseOperationAllocate, PeerID: "peer3"},
+	}
+	_, err = service.ExecuteBatch(context.Background(), tooMany)
+	assert.ErrorIs(t, err, domainErrors.ErrBatchTooLarge)
+
+	operations := tooMany[:2]
+	expected := []*models.LeaseOperationResult{{Lease: &models.Lease{TokenID: 1}}, {Lease: &models.Lease{TokenID: 2}}}
+	mockRepo.EXPECT().ExecuteBatch(gomock.Any(), operations).Return(expected, nil)
+
+	results, err := service.ExecuteBatch(context.Background(), operations)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, results)
+}
+
+func TestLeaseService_AllocateRequestedIP_QuotaExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	mockRepo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(nil, nil)
+	mockRepo.EXPECT().AllocateRequestedLease(gomock.Any(), "peer123", int64(167772200)).Return(nil, domainErrors.ErrLeaseQuotaExceeded)
+
+	service := services.NewLeaseService(&config.AppConfig{
+		Lease: config.LeaseConfig{
+			MaxRetries: 3,
+			RetryDelay: 100,
+		},
+	}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, nil, nil, clock.NewSystem(), zap.NewNop())
+
+	result, err := service.AllocateRequestedIP(context.Background(), "peer123", 167772200)
+	assert.ErrorIs(t, err, domainErrors.ErrLeaseQuotaExceeded)
+	assert.Nil(t, result)
+}
+
+func TestLeaseService_SignsIssuedLeases(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLeaseRepository(ctrl)
+	signer := mocks.NewMockLeaseSigner(ctrl)
+	service := services.NewLeaseService(&config.AppConfig{Lease: config.LeaseConfig{MaxRetries: 1}}, mockRepo, allocation.NewLRU(mockRepo), nil, nil, nil, signer, nil, clock.NewSystem(), zap.NewNop())
+
+	stored := &models.Lease{TokenID: 167772161, PeerID: "peer123", ExpiresAt: time.Now().Add(time.Hour)}
+	// certified returns the lease the certificate is signed over, bound to tenant
+	certified := func(tenant string) *models.Lease {
+		lease := *stored
+		lease.Tenant = tenant
+		return &lease
+	}
+
+	t.Run("allocated lease is signed", func(t *testing.T) {
+		mockRepo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(stored, nil)
+		signer.EXPECT().SignLease(certified(models.DefaultTenantID)).Return([]byte("signature"), "key-1", nil)
+
+		lease, err := service.AllocateIP(context.Background(), "peer123")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("signature"), lease.Signature)
+		assert.Equal(t, "key-1", lease.KeyID)
+		assert.Equal(t, models.DefaultTenantID, lease.Tenant)
+		assert.Nil(t, stored.Signature, "the repository's lease is not modified")
+	})
+
+	t.Run("renewed lease is signed for the tenant", func(t *testing.T) {
+		mockRepo.EXPECT().RenewLease(gomock.Any(), int64(167772161), "peer123").Return(stored, nil)
+		signer.EXPECT().SignLease(certified("acme")).Return([]byte("signature"), "key-1", nil)
+
+		lease, err := service.RenewLease(models.WithTenant(context.Background(), "acme"), 167772161, "peer123")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("signature"), lease.Signature)
+		assert.Equal(t, "acme", lease.Tenant)
+	})
+
+	t.Run("batch leases are signed", func(t *testing.T) {
+		operations := []*models.LeaseOperation{
+			{Type: models.LeaseOperationAllocate, PeerID: "peer123"},
+			{Type: models.LeaseOperationRelease, PeerID: "peer456", TokenID: 167772162},
+		}
+		mockRepo.EXPECT().ExecuteBatch(gomock.Any(), operations).Return([]*models.LeaseOperationResult{{Lease: stored}, {}}, nil)
+		signer.EXPECT().SignLease(certified(models.DefaultTenantID)).Return([]byte("signature"), "key-1", nil)
+
+		results, err := service.ExecuteBatch(context.Background(), operations)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("signature"), results[0].Lease.Signature)
+		assert.Nil(t, results[1].Lease)
+	})
+
+	t.Run("lease is returned unsigned when signing fails", func(t *testing.T) {
+		mockRepo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer123").Return(stored, nil)
+		signer.EXPECT().SignLease(certified(models.DefaultTenantID)).Return(nil, "", assert.AnError)
+
+		lease, err := service.AllocateIP(context.Background(), "peer123")
+		require.NoError(t, err)
+		assert.Nil(t, lease.Signature)
+		assert.Empty(t, lease.Tenant)
+	})
+
+	t.Run("lookups are not signed", func(t *testing.T) {
+		mockRepo.EXPECT().GetLeaseByTokenID(gomock.Any(), int64(167772161)).Return(stored, nil)
+
+		lease, err := service.GetLeaseByTokenID(context.Background(), 167772161)
+		require.NoError(t, err)
+		assert.Nil(t, lease.Signature)
+	})
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+// newLeaseWatchService returns the service and the handlers it subscribed on the bus, by topic
+func newLeaseWatchService(t *testing.T) (*services.LeaseWatchService, map[string]ports.EventHandler) {
+	ctrl := gomock.NewController(t)
+	bus := mocks.NewMockEventBus(ctrl)
+	handlers := make(map[string]ports.EventHandler)
+	bus.EXPECT().Subscribe("lease-watch", gomock.Any(), gomock.Any()).DoAndReturn(func(name, topic string, handler ports.EventHandler) {
+		handlers[topic] = handler
+	}).Times(len(models.LeaseLifecycleEventTypes))
+	return services.NewLeaseWatchService(bus, zap.NewNop()), handlers
+}
+
+func TestLeaseWatchService_Watch(t *testing.T) {
+	service, _ := newLeaseWatchService(t)
+
+	events, stop := service.Watch(models.DefaultTenantID, 167772161)
+	defer stop()
+	other, stopOther := service.Watch("acme", 167772161)
+	defer stopOther()
+
+	renewed := leaseEvent(models.LeaseLifecycleRenewed, 167772161, "peer1")
+	service.Publish(leaseEvent(models.LeaseLifecycleAllocated, 167772162, "peer2"))
+	service.Publish(renewed)
+	// Further events are dropped while the first one is not received
+	service.Publish(leaseEvent(models.LeaseLifecycleReleased, 167772161, "peer1"))
+
+	assert.Equal(t, renewed, <-events)
+	assert.Empty(t, events)
+	assert.Empty(t, other, "events of another tenant's token ID are not delivered")
+}
+
+func TestLeaseWatchService_Stop(t *testing.T) {
+	service, _ := newLeaseWatchService(t)
+
+	events, stop := service.Watch(models.DefaultTenantID, 167772161)
+	stop()
+	service.Publish(leaseEvent(models.LeaseLifecycleRenewed, 167772161, "peer1"))
+
+	assert.Empty(t, events)
+}
+
+func TestLeaseWatchService_BusEvents(t *testing.T) {
+	service, handlers := newLeaseWatchService(t)
+	require.Len(t, handlers, len(models.LeaseLifecycleEventTypes))
+
+	events, stop := service.Watch("acme", 167772161)
+	defer stop()
+
+	// A release on another instance, received through the broker
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	payload, err := json.Marshal(&models.LeaseEventPayload{TokenID: 167772161, PeerID: "peer1", Tenant: "acme", ExpiresAt: expiresAt})
+	require.NoError(t, err)
+	handler := handlers[string(models.LeaseLifecycleReleased)]
+	require.NoError(t, handler(context.Background(), &models.Event{ID: "event-1", Topic: string(models.LeaseLifecycleReleased), Source: "other-instance", Payload: payload}))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, models.LeaseLifecycleReleased, event.Type)
+		assert.Equal(t, "peer1", event.PeerID)
+		assert.Equal(t, expiresAt, event.ExpiresAt)
+	default:
+		t.Fatal("watcher not woken by the bus event")
+	}
+
+	// Malformed events are dropped rather than redelivered
+	assert.NoError(t, handler(context.Background(), &models.Event{ID: "event-2", Topic: string(models.LeaseLifecycleReleased), Payload: json.RawMessage(`"x"`)}))
+	assert.Empty(t, events)
+}
@@ -6,12 +6,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
 	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
-	"github.com/golang/mock/gomock"
 )
 
 func TestNonceService_CreateNonce(t *testing.T) {
@@ -48,7 +50,7 @@ func TestNonceService_CreateNonce(t *testing.T) {
 			name:   "empty peer ID",
 			peerID: "",
 			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockNonceRepository, mockVerifier *mocks.MockSignatureVerifier) {
-				mockRepo.EXPECT().CreateNonce(gomock.Any(), "").Return(nil, errors.ErrMissingPeerID)
+				// Rejected before the repository is reached
 			},
 			expectedResult: nil,
 			expectedError:  errors.ErrMissingPeerID,
@@ -66,10 +68,10 @@ func TestNonceService_CreateNonce(t *testing.T) {
 			name:   "very long peer ID",
 			peerID: string(make([]byte, 1000)), // Very long peer ID
 			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockNonceRepository, mockVerifier *mocks.MockSignatureVerifier) {
-				mockRepo.EXPECT().CreateNonce(gomock.Any(), gomock.Any()).Return(nil, errors.ErrMissingPeerID)
+				// Rejected before the repository is reached
 			},
 			expectedResult: nil,
-			expectedError:  errors.ErrMissingPeerID,
+			expectedError:  errors.ErrInvalidPeerID,
 		},
 	}
 
@@ -82,7 +84,7 @@ func TestNonceService_CreateNonce(t *testing.T) {
 			mockVerifier := mocks.NewMockSignatureVerifier(ctrl)
 			tt.mockSetup(ctrl, mockRepo, mockVerifier)
 
-			service := services.NewNonceService(mockRepo, mockVerifier)
+			service := services.NewNonceService(&config.AppConfig{}, mockRepo, mockVerifier, libp2p.NewPeerIDResolver())
 
 			result, err := service.CreateNonce(context.Background(), tt.peerID)
 
@@ -117,7 +119,7 @@ func TestNonceService_VerifyNonce(t *testing.T) {
 			},
 			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockNonceRepository, mockVerifier *mocks.MockSignatureVerifier) {
 				mockVerifier.EXPECT().VerifySignature(gomock.Any(), []byte("valid-pubkey"), []byte("payload"), []byte("signature")).Return(nil)
-				mockRepo.EXPECT().GetNonce(gomock.Any(), "nonce-123").Return(&models.Nonce{ID: "nonce-123", PeerID: "test-peer"}, nil)
+				mockRepo.EXPECT().GetNonce(gomock.Any(), "nonce-123").Return(&models.Nonce{ID: "nonce-123", PeerID: "test-peer", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(5 * time.Minute)}, nil)
 				// GetPeerIDFromPubkey will fail, but we still need the mock expectations above
 			},
 			expectedError: nil, // Will be handled by GetPeerIDFromPubkey - expect any error
@@ -149,6 +151,20 @@ func TestNonceService_VerifyNonce(t *testing.T) {
 			},
 			expectedError: errors.ErrNonceNotFound,
 		},
+		{
+			name: "malformed stored nonce",
+			request: &models.NonceRequest{
+				NonceID:   "nonce-123",
+				Pubkey:    []byte("valid-pubkey"),
+				Payload:   []byte("payload"),
+				Signature: []byte("signature"),
+			},
+			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockNonceRepository, mockVerifier *mocks.MockSignatureVerifier) {
+				mockVerifier.EXPECT().VerifySignature(gomock.Any(), []byte("valid-pubkey"), []byte("payload"), []byte("signature")).Return(nil)
+				mockRepo.EXPECT().GetNonce(gomock.Any(), "nonce-123").Return(&models.Nonce{ID: "nonce-123", PeerID: "test-peer", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+			},
+			expectedError: errors.ErrInvalidNonce,
+		},
 		{
 			name: "nonce consumption failure",
 			request: &models.NonceRequest{
@@ -159,7 +175,7 @@ func TestNonceService_VerifyNonce(t *testing.T) {
 			},
 			mockSetup: func(ctrl *gomock.Controller, mockRepo *mocks.MockNonceRepository, mockVerifier *mocks.MockSignatureVerifier) {
 				mockVerifier.EXPECT().VerifySignature(gomock.Any(), []byte("valid-pubkey"), []byte("payload"), []byte("signature")).Return(nil)
-				mockRepo.EXPECT().GetNonce(gomock.Any(), "nonce-123").Return(&models.Nonce{ID: "nonce-123", PeerID: "test-peer"}, nil)
+				mockRepo.EXPECT().GetNonce(gomock.Any(), "nonce-123").Return(&models.Nonce{ID: "nonce-123", PeerID: "test-peer", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(5 * time.Minute)}, nil)
 				// GetPeerIDFromPubkey will fail, but we still need the mock expectations above
 			},
 			expectedError: nil, // Will be handled by GetPeerIDFromPubkey - expect any error
@@ -228,7 +244,7 @@ func TestNonceService_VerifyNonce(t *testing.T) {
 			mockVerifier := mocks.NewMockSignatureVerifier(ctrl)
 			tt.mockSetup(ctrl, mockRepo, mockVerifier)
 
-			service := services.NewNonceService(mockRepo, mockVerifier)
+			service := services.NewNonceService(&config.AppConfig{}, mockRepo, mockVerifier, libp2p.NewPeerIDResolver())
 
 			err := service.VerifyNonce(context.Background(), tt.request)
 
@@ -251,7 +267,7 @@ func TestNonceService_EdgeCases(t *testing.T) {
 
 		mockRepo := mocks.NewMockNonceRepository(ctrl)
 		mockVerifier := mocks.NewMockSignatureVerifier(ctrl)
-		service := services.NewNonceService(mockRepo, mockVerifier)
+		service := services.NewNonceService(&config.AppConfig{}, mockRepo, mockVerifier, libp2p.NewPeerIDResolver())
 
 		// Create a cancelled context
 		ctx, cancel := context.WithCancel(context.Background())
@@ -277,7 +293,7 @@ func TestNonceService_EdgeCases(t *testing.T) {
 
 		mockRepo := mocks.NewMockNonceRepository(ctrl)
 		mockVerifier := mocks.NewMockSignatureVerifier(ctrl)
-		service := services.NewNonceService(mockRepo, mockVerifier)
+		service := services.NewNonceService(&config.AppConfig{}, mockRepo, mockVerifier, libp2p.NewPeerIDResolver())
 
 		request := &models.NonceRequest{
 			NonceID:   "nonce-123",
@@ -300,7 +316,7 @@ func TestNonceService_EdgeCases(t *testing.T) {
 
 		mockRepo := mocks.NewMockNonceRepository(ctrl)
 		mockVerifier := mocks.NewMockSignatureVerifier(ctrl)
-		service := services.NewNonceService(mockRepo, mockVerifier)
+		service := services.NewNonceService(&config.AppConfig{}, mockRepo, mockVerifier, libp2p.NewPeerIDResolver())
 
 		largeNonceID := string(make([]byte, 10000))
 		request := &models.NonceRequest{
@@ -324,7 +340,7 @@ func TestNonceService_EdgeCases(t *testing.T) {
 
 		mockRepo := mocks.NewMockNonceRepository(ctrl)
 		mockVerifier := mocks.NewMockSignatureVerifier(ctrl)
-		service := services.NewNonceService(mockRepo, mockVerifier)
+		service := services.NewNonceService(&config.AppConfig{}, mockRepo, mockVerifier, libp2p.NewPeerIDResolver())
 
 		const numGoroutines = 10
 		results := make(chan *models.Nonce, numGoroutines)
@@ -372,3 +388,241 @@ func TestNonceService_EdgeCases(t *testing.T) {
 		assert.Len(t, nonces, numGoroutines)
 	})
 }
+
+func TestNonceService_CreateNonce_OutstandingCap(t *testing.T) {
+	outstanding := []*models.Nonce{
+		{ID: "nonce-2", PeerID: "peer123", ExpiresAt: time.Now().Add(5 * time.Minute)},
+		{ID: "nonce-1", PeerID: "peer123", ExpiresAt: time.Now().Add(4 * time.Minute)},
+	}
+
+	t.Run("issues a nonce below the cap", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockRepo.EXPECT().ListOutstandingNonces(gomock.Any(), "peer123").Return(outstanding[:1], nil)
+		mockRepo.EXPECT().CreateNonce(gomock.Any(), "peer123").Return(&models.Nonce{ID: "nonce-3", PeerID: "peer123", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(5 * time.Minute)}, nil)
+		service := services.NewNonceService(&config.AppConfig{Nonce: config.NonceConfig{MaxOutstanding: 2}}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		nonce, err := service.CreateNonce(context.Background(), "peer123")
+		assert.NoError(t, err)
+		assert.Equal(t, "nonce-3", nonce.ID)
+		assert.Equal(t, int64(1), service.Metrics().Issued)
+	})
+
+	t.Run("reuses the newest nonce at the cap", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockRepo.EXPECT().ListOutstandingNonces(gomock.Any(), "peer123").Return(outstanding, nil)
+		service := services.NewNonceService(&config.AppConfig{Nonce: config.NonceConfig{MaxOutstanding: 2, ReuseAtCap: true}}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		nonce, err := service.CreateNonce(context.Background(), "peer123")
+		assert.NoError(t, err)
+		assert.Equal(t, "nonce-2", nonce.ID)
+		assert.Equal(t, int64(1), service.Metrics().Reused)
+	})
+
+	t.Run("rejects at the cap", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockRepo.EXPECT().ListOutstandingNonces(gomock.Any(), "peer123").Return(outstanding, nil)
+		service := services.NewNonceService(&config.AppConfig{Nonce: config.NonceConfig{MaxOutstanding: 2}}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		nonce, err := service.CreateNonce(context.Background(), "peer123")
+		assert.ErrorIs(t, err, errors.ErrTooManyNonces)
+		assert.Nil(t, nonce)
+		assert.Equal(t, int64(1), service.Metrics().Rejected)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockRepo.EXPECT().ListOutstandingNonces(gomock.Any(), "peer123").Return(nil, fmt.Errorf("database error"))
+		service := services.NewNonceService(&config.AppConfig{Nonce: config.NonceConfig{MaxOutstanding: 2}}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		_, err := service.CreateNonce(context.Background(), "peer123")
+		assert.EqualError(t, err, "database error")
+	})
+}
+
+func TestNonceService_RevokeNonce(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoErr  error
+		expected error
+		revoked  int64
+	}{
+		{name: "revokes an outstanding nonce", revoked: 1},
+		{name: "unknown nonce", repoErr: errors.ErrNonceNotFound, expected: errors.ErrNonceNotFoundErr},
+		{name: "used nonce", repoErr: errors.ErrNonceUsed, expected: errors.ErrNonceNotFoundErr},
+		{name: "expired nonce", repoErr: errors.ErrNonceExpired, expected: errors.ErrNonceNotFoundErr},
+		{name: "malformed nonce", repoErr: errors.ErrInvalidNonce, expected: errors.ErrInvalidNonce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockNonceRepository(ctrl)
+			mockRepo.EXPECT().RevokeNonce(gomock.Any(), "nonce-1", "peer123").Return(tt.repoErr)
+			service := services.NewNonceService(&config.AppConfig{}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+			err := service.RevokeNonce(context.Background(), "peer123", "nonce-1")
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.revoked, service.Metrics().Revoked)
+		})
+	}
+}
+
+func TestNonceService_RevokeNonces(t *testing.T) {
+	t.Run("revokes every outstanding nonce", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockRepo.EXPECT().ListOutstandingNonces(gomock.Any(), "peer123").Return([]*models.Nonce{
+			{ID: "nonce-3", PeerID: "peer123"},
+			{ID: "nonce-2", PeerID: "peer123"},
+			{ID: "nonce-1", PeerID: "peer123"},
+		}, nil)
+		mockRepo.EXPECT().RevokeNonce(gomock.Any(), "nonce-3", "peer123").Return(nil)
+		// Used by a request in the meantime
+		mockRepo.EXPECT().RevokeNonce(gomock.Any(), "nonce-2", "peer123").Return(errors.ErrNonceUsed)
+		mockRepo.EXPECT().RevokeNonce(gomock.Any(), "nonce-1", "peer123").Return(nil)
+		service := services.NewNonceService(&config.AppConfig{}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		revoked, err := service.RevokeNonces(context.Background(), "peer123")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), revoked)
+		assert.Equal(t, int64(2), service.Metrics().Revoked)
+	})
+
+	t.Run("stops at a repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockRepo.EXPECT().ListOutstandingNonces(gomock.Any(), "peer123").Return([]*models.Nonce{{ID: "nonce-2"}, {ID: "nonce-1"}}, nil)
+		mockRepo.EXPECT().RevokeNonce(gomock.Any(), "nonce-2", "peer123").Return(fmt.Errorf("database error"))
+		service := services.NewNonceService(&config.AppConfig{}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		revoked, err := service.RevokeNonces(context.Background(), "peer123")
+		assert.EqualError(t, err, "database error")
+		assert.Zero(t, revoked)
+	})
+
+	t.Run("invalid peer ID", func(t *testing.T) {
+		service := services.NewNonceService(&config.AppConfig{}, nil, nil, libp2p.NewPeerIDResolver())
+
+		_, err := service.RevokeNonces(context.Background(), "")
+		assert.ErrorIs(t, err, errors.ErrMissingPeerID)
+	})
+}
+
+func TestNonceService_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNonceRepository(ctrl)
+	mockRepo.EXPECT().CreateNonce(gomock.Any(), gomock.Any()).Return(&models.Nonce{ID: "nonce", PeerID: "peer-a", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(5 * time.Minute)}, nil).Times(4)
+	service := services.NewNonceService(&config.AppConfig{}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+	for _, peerID := range []string{"peer-b", "peer-a", "peer-a", "peer-c"} {
+		_, err := service.CreateNonce(context.Background(), peerID)
+		assert.NoError(t, err)
+	}
+
+	metrics := service.Metrics()
+	assert.Equal(t, int64(4), metrics.Issued)
+	assert.Len(t, metrics.Peers, 3)
+	assert.Equal(t, &models.NoncePeerRate{PeerID: "peer-a", PerMinute: 2}, metrics.Peers[0])
+	assert.Equal(t, "peer-b", metrics.Peers[1].PeerID)
+}
+
+func TestNonceService_CleanupExpired(t *testing.T) {
+	cfg := &config.AppConfig{Nonce: config.NonceConfig{CleanerBatchSize: 2, CleanerBatchPause: 1}}
+
+	t.Run("deletes in batches until one is not full", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		gomock.InOrder(
+			mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 2).Return(int64(2), nil),
+			mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 2).Return(int64(2), nil),
+			mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 2).Return(int64(1), nil),
+		)
+		service := services.NewNonceService(cfg, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		report, err := service.CleanupExpired(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 3, report.Batches)
+		assert.Equal(t, int64(5), report.Deleted)
+
+		metrics := service.Metrics()
+		assert.Equal(t, int64(5), metrics.Deleted)
+		assert.Equal(t, report, metrics.LastCleanup)
+	})
+
+	t.Run("without a batch size deletes everything at once", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 0).Return(int64(7), nil)
+		service := services.NewNonceService(&config.AppConfig{}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		report, err := service.CleanupExpired(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Batches)
+		assert.Equal(t, int64(7), report.Deleted)
+	})
+
+	t.Run("counts batches deleted before an error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		gomock.InOrder(
+			mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 2).Return(int64(2), nil),
+			mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 2).Return(int64(0), fmt.Errorf("database error")),
+		)
+		service := services.NewNonceService(cfg, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		report, err := service.CleanupExpired(context.Background())
+		assert.EqualError(t, err, "database error")
+		assert.Equal(t, int64(2), report.Deleted)
+
+		metrics := service.Metrics()
+		assert.Equal(t, int64(2), metrics.Deleted)
+		assert.Nil(t, metrics.LastCleanup)
+	})
+
+	t.Run("stops when the context ends between batches", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		mockRepo := mocks.NewMockNonceRepository(ctrl)
+		mockRepo.EXPECT().DeleteExpiredNonces(gomock.Any(), 2).DoAndReturn(func(context.Context, int) (int64, error) {
+			cancel()
+			return 2, nil
+		})
+		service := services.NewNonceService(&config.AppConfig{Nonce: config.NonceConfig{CleanerBatchSize: 2, CleanerBatchPause: 60000}}, mockRepo, nil, libp2p.NewPeerIDResolver())
+
+		report, err := service.CleanupExpired(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, report.Batches)
+	})
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/repositories/embedded"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+func newTestPeerPolicyService(t *testing.T, cfg *config.AppConfig) (*services.PeerPolicyService, *mocks.MockLeaseRepository) {
+	ctrl := gomock.NewController(t)
+	leases := mocks.NewMockLeaseRepository(ctrl)
+	repo := embedded.NewPeerPolicyRepository(embedded.NewMemoryStore(clock.NewSystem()))
+	return services.NewPeerPolicyService(cfg, repo, leases, zap.NewNop()), leases
+}
+
+func TestPeerPolicyService_Authorize(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.PeerPolicySource = config.PeerPolicySourceConfig
+	cfg.PeerPolicies = []config.PeerPolicyConfig{
+		{Name: "operators", Peers: []string{"peer-op"}, CanAllocate: true, CanReserve: true},
+		{Name: "blocked", Peers: []string{"peer-blocked"}},
+		{Name: "everyone", Peers: []string{"*"}, CanAllocate: true},
+	}
+	service, _ := newTestPeerPolicyService(t, cfg)
+
+	assert.NoError(t, service.Authorize(ctx, "peer-op", models.PeerPermissionReserve))
+	assert.NoError(t, service.Authorize(ctx, "peer-other", models.PeerPermissionAllocate))
+	assert.ErrorIs(t, service.Authorize(ctx, "peer-other", models.PeerPermissionReserve), errors.ErrPeerPolicyDenied)
+	// The policy listing a peer wins over the wildcard
+	assert.ErrorIs(t, service.Authorize(ctx, "peer-blocked", models.PeerPermissionAllocate), errors.ErrPeerPolicyDenied)
+
+	t.Run("peers no policy matches are refused", func(t *testing.T) {
+		cfg := config.NewDefaultAppConfig()
+		cfg.PeerPolicySource = config.PeerPolicySourceConfig
+		cfg.PeerPolicies = []config.PeerPolicyConfig{{Name: "operators", Peers: []string{"peer-op"}, CanAllocate: true}}
+		service, _ := newTestPeerPolicyService(t, cfg)
+
+		assert.ErrorIs(t, service.Authorize(ctx, "peer-other", models.PeerPermissionAllocate), errors.ErrPeerPolicyDenied)
+	})
+
+	t.Run("without a policy source every peer is authorized", func(t *testing.T) {
+		service, _ := newTestPeerPolicyService(t, config.NewDefaultAppConfig())
+
+		assert.NoError(t, service.Authorize(ctx, "peer-other", models.PeerPermissionReserve))
+	})
+
+	t.Run("reloaded policies replace the configured ones", func(t *testing.T) {
+		service, _ := newTestPeerPolicyService(t, cfg)
+
+		reloaded := *cfg
+		reloaded.PeerPolicies = []config.PeerPolicyConfig{{Name: "everyone", Peers: []string{"*"}, CanAllocate: true, CanReserve: true}}
+		service.ApplyConfig(&reloaded)
+
+		assert.NoError(t, service.Authorize(ctx, "peer-other", models.PeerPermissionReserve))
+	})
+}
+
+func TestPeerPolicyService_MaxPool(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.PeerPolicySource = config.PeerPolicySourceConfig
+	cfg.PeerPolicies = []config.PeerPolicyConfig{
+		{Name: "lab", Peers: []string{"peer-a", "peer-b", "peer-c"}, CanAllocate: true, MaxPool: 1},
+	}
+
+	t.Run("allocation refused once the pool is used up", func(t *testing.T) {
+		service, leases := newTestPeerPolicyService(t, cfg)
+		leases.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer-c").Return(nil, errors.ErrLeaseNotFound)
+		leases.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer-a").Return(&models.Lease{TokenID: 167902210, PeerID: "peer-a"}, nil)
+		leases.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer-b").Return(nil, errors.ErrLeaseNotFound)
+
+		assert.ErrorIs(t, service.Authorize(ctx, "peer-c", models.PeerPermissionAllocate), errors.ErrPeerPoolExhausted)
+	})
+
+	t.Run("a peer holding a lease gets it again", func(t *testing.T) {
+		service, leases := newTestPeerPolicyService(t, cfg)
+		leases.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer-a").Return(&models.Lease{TokenID: 167902210, PeerID: "peer-a"}, nil)
+
+		assert.NoError(t, service.Authorize(ctx, "peer-a", models.PeerPermissionAllocate))
+	})
+
+	t.Run("allocation allowed below the limit", func(t *testing.T) {
+		service, leases := newTestPeerPolicyService(t, cfg)
+		leases.EXPECT().GetLeaseByPeerID(gomock.Any(), gomock.Any()).Return(nil, errors.ErrLeaseNotFound).Times(3)
+
+		assert.NoError(t, service.Authorize(ctx, "peer-c", models.PeerPermissionAllocate))
+	})
+}
+
+func TestPeerPolicyService_DatabaseSource(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.PeerPolicySource = config.PeerPolicySourceDatabase
+	service, _ := newTestPeerPolicyService(t, cfg)
+
+	// No policy yet, every peer is refused
+	assert.ErrorIs(t, service.Authorize(ctx, "peer-a", models.PeerPermissionAllocate), errors.ErrPeerPolicyDenied)
+
+	_, err := service.AddPolicy(ctx, &models.PeerPolicy{Name: "bad", Peers: []string{"*"}, MaxPool: 5})
+	assert.ErrorIs(t, err, errors.ErrInvalidPeerPolicy)
+
+	created, err := service.AddPolicy(ctx, &models.PeerPolicy{Name: " everyone ", Peers: []string{"*"}, CanAllocate: true})
+	require.NoError(t, err)
+	assert.Equal(t, "everyone", created.Name)
+	assert.NoError(t, service.Authorize(ctx, "peer-a", models.PeerPermissionAllocate))
+
+	_, err = service.AddPolicy(ctx, &models.PeerPolicy{Name: "everyone", Peers: []string{"peer-a"}})
+	assert.ErrorIs(t, err, errors.ErrPeerPolicyExists)
+
+	policies, err := service.ListPolicies(ctx)
+	require.NoError(t, err)
+	assert.Len(t, policies, 1)
+
+	require.NoError(t, service.RemovePolicy(ctx, created.ID))
+	assert.ErrorIs(t, service.Authorize(ctx, "peer-a", models.PeerPermissionAllocate), errors.ErrPeerPolicyDenied)
+	assert.ErrorIs(t, service.RemovePolicy(ctx, created.ID), errors.ErrPeerPolicyNotFound)
+
+	t.Run("policies of the config source cannot be changed", func(t *testing.T) {
+		cfg := config.NewDefaultAppConfig()
+		cfg.PeerPolicySource = config.PeerPolicySourceConfig
+		cfg.PeerPolicies = []config.PeerPolicyConfig{{Name: "everyone", Peers: []string{"*"}, CanAllocate: true}}
+		service, _ := newTestPeerPolicyService(t, cfg)
+
+		_, err := service.AddPolicy(ctx, &models.PeerPolicy{Name: "other", Peers: []string{"*"}})
+		assert.ErrorIs(t, err, errors.ErrPeerPoliciesFixed)
+		assert.ErrorIs(t, service.RemovePolicy(ctx, 1), errors.ErrPeerPoliciesFixed)
+	})
+}
+
+func TestPolicyLeaseWriter(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.PeerPolicySource = config.PeerPolicySourceConfig
+	cfg.PeerPolicies = []config.PeerPolicyConfig{
+		{Name: "blocked", Peers: []string{"peer-blocked"}},
+		{Name: "everyone", Peers: []string{"*"}, CanAllocate: true},
+	}
+	policies, _ := newTestPeerPolicyService(t, cfg)
+
+	ctrl := gomock.NewController(t)
+	leases := mocks.NewMockLeaseService(ctrl)
+	writer := services.NewPolicyLeaseWriter(leases, policies, mocks.NewMockIdentityResolver(ctrl))
+
+	leases.EXPECT().AllocateIP(gomock.Any(), "peer-a").Return(&models.Lease{TokenID: 167902210, PeerID: "peer-a"}, nil)
+	_, err := writer.AllocateIP(ctx, "peer-a")
+	assert.NoError(t, err)
+
+	_, err = writer.AllocateIP(ctx, "peer-blocked")
+	assert.ErrorIs(t, err, errors.ErrPeerPolicyDenied)
+	_, err = writer.AllocateRequestedIP(ctx, "peer-a", 167902211)
+	assert.ErrorIs(t, err, errors.ErrPeerPolicyDenied)
+
+	// Refused allocations fail on their own, the other operations run as one batch
+	operations := []*models.LeaseOperation{
+		{Type: models.LeaseOperationAllocate, PeerID: "peer-blocked"},
+		{Type: models.LeaseOperationRenew, PeerID: "peer-blocked", TokenID: 167902212},
+		{Type: models.LeaseOperationAllocate, PeerID: "peer-a"},
+	}
+	leases.EXPECT().ExecuteBatch(gomock.Any(), []*models.LeaseOperation{operations[1], operations[2]}).Return([]*models.LeaseOperationResult{
+		{Lease: &models.Lease{TokenID: 167902212}},
+		{Lease: &models.Lease{TokenID: 167902210}},
+	}, nil)
+
+	results, err := writer.ExecuteBatch(ctx, operations)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.ErrorIs(t, results[0].Err, errors.ErrPeerPolicyDenied)
+	assert.Equal(t, int64(167902212), results[1].Lease.TokenID)
+	assert.Equal(t, int64(167902210), results[2].Lease.TokenID)
+}
+
+func TestPolicyLeaseWriter_TransferLease(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultAppConfig()
+	cfg.PeerPolicySource = config.PeerPolicySourceConfig
+	cfg.PeerPolicies = []config.PeerPolicyConfig{
+		{Name: "blocked", Peers: []string{"peer-blocked"}},
+		{Name: "lab", Peers: []string{"peer-a", "peer-b", "peer-c"}, CanAllocate: true, MaxPool: 1},
+		{Name: "everyone", Peers: []string{"*"}, CanAllocate: true},
+	}
+
+	setup := func(t *testing.T) (*services.PolicyLeaseWriter, *mocks.MockLeaseService, *mocks.MockLeaseRepository) {
+		policies, repo := newTestPeerPolicyService(t, cfg)
+		ctrl := gomock.NewController(t)
+		leases := mocks.NewMockLeaseService(ctrl)
+		// The test keys are the peer IDs themselves
+		identity := mocks.NewMockIdentityResolver(ctrl)
+		identity.EXPECT().ResolvePeerID(gomock.Any()).DoAndReturn(func(pubkey []byte) (string, error) {
+			return string(pubkey), nil
+		}).AnyTimes()
+		return services.NewPolicyLeaseWriter(leases, policies, identity), leases, repo
+	}
+	transfer := func(from, to string) *models.LeaseTransferRequest {
+		return &models.LeaseTransferRequest{TokenID: 167902210, FromPubkey: []byte(from), ToPubkey: []byte(to)}
+	}
+
+	t.Run("recipient denied by its policy", func(t *testing.T) {
+		writer, _, _ := setup(t)
+		_, err := writer.TransferLease(ctx, transfer("peer-x", "peer-blocked"))
+		assert.ErrorIs(t, err, errors.ErrPeerPolicyDenied)
+	})
+
+	t.Run("recipient whose pool is used up", func(t *testing.T) {
+		writer, _, repo := setup(t)
+		repo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer-c").Return(nil, errors.ErrLeaseNotFound)
+		repo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer-a").Return(&models.Lease{TokenID: 167902211, PeerID: "peer-a"}, nil)
+		repo.EXPECT().GetLeaseByPeerID(gomock.Any(), "peer-b").Return(nil, errors.ErrLeaseNotFound)
+
+		_, err := writer.TransferLease(ctx, transfer("peer-x", "peer-c"))
+		assert.ErrorIs(t, err, errors.ErrPeerPoolExhausted)
+	})
+
+	t.Run("transfer within a used up pool", func(t *testing.T) {
+		writer, leases, _ := setup(t)
+		request := transfer("peer-a", "peer-c")
+		leases.EXPECT().TransferLease(gomock.Any(), request).Return(&models.Lease{TokenID: 167902210, PeerID: "peer-c"}, nil)
+
+		_, err := writer.TransferLease(ctx, request)
+		assert.NoError(t, err)
+	})
+
+	t.Run("recipient allowed to allocate", func(t *testing.T) {
+		writer, leases, _ := setup(t)
+		request := transfer("peer-x", "peer-y")
+		leases.EXPECT().TransferLease(gomock.Any(), request).Return(&models.Lease{TokenID: 167902210, PeerID: "peer-y"}, nil)
+
+		_, err := writer.TransferLease(ctx, request)
+		assert.NoError(t, err)
+	})
+}
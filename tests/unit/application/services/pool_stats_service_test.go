@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+)
+
+func newPoolStatsService(t *testing.T, cfg *config.AppConfig, repo *mocks.MockPoolStatsRepository) *services.PoolStatsService {
+	tenants, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+	return services.NewPoolStatsService(cfg, repo, tenants)
+}
+
+func newPoolStatsConfig() *config.AppConfig {
+	cfg := config.NewDefaultAppConfig()
+	cfg.PoolMinTokenID = 167772161
+	cfg.PoolMaxTokenID = 167772260
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200001, PoolMaxTokenID: 168200010}}
+	return cfg
+}
+
+func TestPoolStatsService_PoolStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPoolStatsRepository(ctrl)
+	mockRepo.EXPECT().CountPoolLeases(gomock.Any(), time.Hour).Return(map[string]*models.PoolLeaseCounts{
+		models.DefaultTenantID: {Allocated: 40, Reserved: 2, Grace: 3, ExpiredReclaimable: 5, Allocations: 12},
+		"retired":              {Allocated: 5},
+	}, nil)
+
+	report, err := newPoolStatsService(t, newPoolStatsConfig(), mockRepo).PoolStats(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []*models.PoolStats{
+		{Tenant: models.DefaultTenantID, Total: 100, Allocated: 40, Reserved: 2, Grace: 3, ExpiredReclaimable: 5, Free: 50, AllocationsPerHour: 12, Utilization: 0.45},
+		{Tenant: "acme", Total: 10, Free: 10},
+	}, report.Pools, "tenants without leases are reported empty and unknown tenants are left out")
+	assert.False(t, report.ComputedAt.IsZero())
+}
+
+func TestPoolStatsService_Cache(t *testing.T) {
+	t.Run("report is reused within the cache TTL", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockPoolStatsRepository(ctrl)
+		mockRepo.EXPECT().CountPoolLeases(gomock.Any(), gomock.Any()).Return(map[string]*models.PoolLeaseCounts{}, nil).Times(1)
+		service := newPoolStatsService(t, newPoolStatsConfig(), mockRepo)
+
+		first, err := service.PoolStats(context.Background())
+		require.NoError(t, err)
+		second, err := service.PoolStats(context.Background())
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+	})
+
+	t.Run("zero TTL recomputes every report", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := newPoolStatsConfig()
+		cfg.AdminPoolStatsCacheTTL = 0
+		mockRepo := mocks.NewMockPoolStatsRepository(ctrl)
+		mockRepo.EXPECT().CountPoolLeases(gomock.Any(), gomock.Any()).Return(map[string]*models.PoolLeaseCounts{}, nil).Times(2)
+		service := newPoolStatsService(t, cfg, mockRepo)
+
+		for range 2 {
+			_, err := service.PoolStats(context.Background())
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockPoolStatsRepository(ctrl)
+		gomock.InOrder(
+			mockRepo.EXPECT().CountPoolLeases(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused")),
+			mockRepo.EXPECT().CountPoolLeases(gomock.Any(), gomock.Any()).Return(map[string]*models.PoolLeaseCounts{}, nil),
+		)
+		service := newPoolStatsService(t, newPoolStatsConfig(), mockRepo)
+
+		_, err := service.PoolStats(context.Background())
+		require.Error(t, err)
+		report, err := service.PoolStats(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, report.Pools, 2)
+	})
+}
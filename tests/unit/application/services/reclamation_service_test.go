@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+func newReclamationConfig() *config.AppConfig {
+	return &config.AppConfig{
+		Lease: config.LeaseConfig{
+			TTL: 60,
+		},
+		ReclaimBatchSize: 2,
+		ReclaimPolicies: []config.ReclaimPolicyConfig{
+			{Name: "stale", NotRenewedForTTLs: 3, SkipAffinityGroups: true},
+			{Name: "abandoned", ExpiredFor: 24 * 60},
+		},
+	}
+}
+
+func reclaimCandidates() []*models.ReclaimCandidate {
+	now := time.Now()
+	return []*models.ReclaimCandidate{
+		// Not renewed for 4 hours: stale
+		{TokenID: 1, PeerID: "peer1", UpdatedAt: now.Add(-4 * time.Hour), ExpiresAt: now.Add(-3 * time.Hour)},
+		// Recently expired, no policy matches
+		{TokenID: 2, PeerID: "peer2", UpdatedAt: now.Add(-90 * time.Minute), ExpiresAt: now.Add(-30 * time.Minute)},
+		// Reserved for its affinity group but expired long ago: abandoned
+		{TokenID: 3, PeerID: "peer3", AffinityGroup: "rack-1", UpdatedAt: now.Add(-49 * time.Hour), ExpiresAt: now.Add(-48 * time.Hour)},
+	}
+}
+
+func TestReclamationService_Run(t *testing.T) {
+	t.Run("reclaims leases per policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		candidates := reclaimCandidates()
+		mockRepo := mocks.NewMockLeaseRepository(ctrl)
+		mockRepo.EXPECT().ListReclaimCandidates(gomock.Any(), int64(0), 2).Return(candidates[:2], nil)
+		mockRepo.EXPECT().ListReclaimCandidates(gomock.Any(), int64(2), 2).Return(candidates[2:], nil)
+		mockRepo.EXPECT().ReclaimLeases(gomock.Any(), []int64{1}).Return([]int64{1}, nil)
+		mockRepo.EXPECT().ReclaimLeases(gomock.Any(), []int64{3}).Return([]int64{3}, nil)
+
+		service := services.NewReclamationService(newReclamationConfig(), mockRepo, nil, zap.NewNop())
+		report, err := service.Run(context.Background(), false)
+
+		require.NoError(t, err)
+		assert.False(t, report.DryRun)
+		assert.Equal(t, 3, report.Evaluated)
+		assert.Equal(t, int64(2), report.Reclaimed)
+		assert.Equal(t, []int64{1}, report.Policies[0].TokenIDs)
+		assert.Equal(t, []int64{3}, report.Policies[1].TokenIDs)
+
+		metrics := service.Metrics()
+		assert.Equal(t, int64(1), metrics.Runs)
+		assert.Equal(t, int64(1), metrics.Policies[0].Reclaimed)
+		assert.Equal(t, int64(1), metrics.Policies[1].Reclaimed)
+	})
+
+	t.Run("publishes expiry of reclaimed leases", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		candidates := reclaimCandidates()
+		candidates[0].TenantID = "acme"
+		mockRepo := mocks.NewMockLeaseRepository(ctrl)
+		mockRepo.EXPECT().ListReclaimCandidates(gomock.Any(), int64(0), 10).Return(candidates, nil)
+		mockRepo.EXPECT().ReclaimLeases(gomock.Any(), []int64{1}).Return([]int64{1}, nil)
+		// Renewed between listing and reclaiming
+		mockRepo.EXPECT().ReclaimLeases(gomock.Any(), []int64{3}).Return(nil, nil)
+
+		mockEvents := mocks.NewMockLeaseEventPublisher(ctrl)
+		mockEvents.EXPECT().Publish(gomock.Any()).Do(func(event *models.LeaseLifecycleEvent) {
+			assert.Equal(t, models.LeaseLifecycleExpired, event.Type)
+			assert.Equal(t, int64(1), event.TokenID)
+			assert.Equal(t, "peer1", event.PeerID)
+			assert.Equal(t, "acme", event.TenantID)
+		})
+
+		cfg := newReclamationConfig()
+		cfg.ReclaimBatchSize = 10
+		service := services.NewReclamationService(cfg, mockRepo, mockEvents, zap.NewNop())
+		report, err := service.Run(context.Background(), false)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), report.Reclaimed)
+	})
+
+	t.Run("dry run only reports matches", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockLeaseRepository(ctrl)
+		mockRepo.EXPECT().ListReclaimCandidates(gomock.Any(), int64(0), 10).Return(reclaimCandidates(), nil)
+
+		cfg := newReclamationConfig()
+		cfg.ReclaimBatchSize = 10
+		service := services.NewReclamationService(cfg, mockRepo, nil, zap.NewNop())
+		report, err := service.Run(context.Background(), true)
+
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, int64(0), report.Reclaimed)
+		assert.Equal(t, 1, report.Policies[0].Matched)
+		assert.Equal(t, 1, report.Policies[1].Matched)
+
+		metrics := service.Metrics()
+		assert.Equal(t, int64(1), metrics.Policies[0].DryRunMatched)
+		assert.Equal(t, int64(0), metrics.Policies[0].Matched)
+	})
+
+	t.Run("reloaded lease ttl rescales policies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockLeaseRepository(ctrl)
+		mockRepo.EXPECT().ListReclaimCandidates(gomock.Any(), int64(0), 10).Return(reclaimCandidates(), nil)
+
+		cfg := newReclamationConfig()
+		cfg.ReclaimBatchSize = 10
+		service := services.NewReclamationService(cfg, mockRepo, nil, zap.NewNop())
+
+		// Three TTLs of 20 minutes: peer2, not renewed for 90 minutes, becomes stale too
+		reloaded := *cfg
+		reloaded.Lease.TTL = 20
+		service.ApplyConfig(&reloaded)
+
+		report, err := service.Run(context.Background(), true)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, report.Policies[0].TokenIDs)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockLeaseRepository(ctrl)
+		mockRepo.EXPECT().ListReclaimCandidates(gomock.Any(), int64(0), 2).Return(nil, errors.New("database error"))
+
+		service := services.NewReclamationService(newReclamationConfig(), mockRepo, nil, zap.NewNop())
+		report, err := service.Run(context.Background(), false)
+
+		assert.Error(t, err)
+		assert.Nil(t, report)
+		assert.Equal(t, int64(1), service.Metrics().Failures)
+	})
+}
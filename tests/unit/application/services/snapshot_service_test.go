@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+func newLeaseSnapshotService(t *testing.T, repo *mocks.MockLeaseSnapshotRepository) *services.LeaseSnapshotService {
+	tenants, err := services.NewTenantService(newPoolStatsConfig())
+	require.NoError(t, err)
+	return services.NewLeaseSnapshotService(repo, tenants, zap.NewNop())
+}
+
+func TestLeaseSnapshotService_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored := &models.LeaseSnapshot{
+		Pools: []*models.SnapshotPool{{Tenant: models.DefaultTenantID, MinTokenID: 167772161, MaxTokenID: 167772260, LastTokenID: 167772161}},
+	}
+	mockRepo := mocks.NewMockLeaseSnapshotRepository(ctrl)
+	mockRepo.EXPECT().ExportLeaseState(gomock.Any()).Return(stored, nil)
+
+	before := time.Now()
+	snapshot, err := newLeaseSnapshotService(t, mockRepo).Export(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, models.SnapshotVersion, snapshot.Version)
+	assert.WithinRange(t, snapshot.ExportedAt, before.Add(-time.Second), time.Now())
+	assert.Equal(t, stored.Pools, snapshot.Pools)
+}
+
+func TestLeaseSnapshotService_Import(t *testing.T) {
+	snapshot := func() *models.LeaseSnapshot {
+		return &models.LeaseSnapshot{
+			Version: models.SnapshotVersion,
+			Pools:   []*models.SnapshotPool{{Tenant: "acme", MinTokenID: 168200001, MaxTokenID: 168200010, LastTokenID: 168200001}},
+			Leases:  []*models.SnapshotLease{{TokenID: 168200001, PeerID: "peer-1", Tenant: "acme"}},
+		}
+	}
+
+	t.Run("valid snapshots are imported", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := snapshot()
+		report := &models.SnapshotImportReport{Pools: 1, Leases: 1, Replaced: 3}
+		mockRepo := mocks.NewMockLeaseSnapshotRepository(ctrl)
+		mockRepo.EXPECT().ImportLeaseState(gomock.Any(), s, true).Return(report, nil)
+
+		got, err := newLeaseSnapshotService(t, mockRepo).Import(context.Background(), s, true)
+		require.NoError(t, err)
+		assert.Equal(t, report, got)
+	})
+
+	t.Run("invalid snapshots are rejected before the store", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := snapshot()
+		s.Leases[0].TokenID = 167772161
+		mockRepo := mocks.NewMockLeaseSnapshotRepository(ctrl)
+
+		_, err := newLeaseSnapshotService(t, mockRepo).Import(context.Background(), s, false)
+		require.ErrorIs(t, err, domainErrors.ErrInvalidSnapshot)
+		assert.Contains(t, domainErrors.GetAppError(err).Details, `token ID 167772161 is outside the pool of tenant "acme"`)
+	})
+
+	t.Run("store errors are returned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockLeaseSnapshotRepository(ctrl)
+		mockRepo.EXPECT().ImportLeaseState(gomock.Any(), gomock.Any(), false).Return(nil, domainErrors.ErrStoreNotEmpty)
+
+		_, err := newLeaseSnapshotService(t, mockRepo).Import(context.Background(), snapshot(), false)
+		assert.ErrorIs(t, err, domainErrors.ErrStoreNotEmpty)
+	})
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/application/services"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+)
+
+func TestTenantService_Validation(t *testing.T) {
+	acme := config.TenantConfig{ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}
+
+	tests := []struct {
+		name    string
+		tenants []config.TenantConfig
+		wantErr string
+	}{
+		{name: "no tenants"},
+		{name: "one tenant", tenants: []config.TenantConfig{acme}},
+		{
+			name:    "invalid ID",
+			tenants: []config.TenantConfig{{ID: "Acme Corp", APIKeys: []string{"k"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}},
+			wantErr: "must be 1 to 64",
+		},
+		{
+			name:    "duplicate ID",
+			tenants: []config.TenantConfig{acme, {ID: "acme", APIKeys: []string{"k"}, PoolMinTokenID: 168300000, PoolMaxTokenID: 168300100}},
+			wantErr: "more than once",
+		},
+		{
+			name:    "default ID",
+			tenants: []config.TenantConfig{{ID: models.DefaultTenantID, APIKeys: []string{"k"}, PoolMinTokenID: 168300000, PoolMaxTokenID: 168300100}},
+			wantErr: "more than once",
+		},
+		{
+			name:    "no API key",
+			tenants: []config.TenantConfig{{ID: "acme", PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}},
+			wantErr: "at least one API key",
+		},
+		{
+			name:    "shared API key",
+			tenants: []config.TenantConfig{acme, {ID: "other", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168300000, PoolMaxTokenID: 168300100}},
+			wantErr: "share an API key",
+		},
+		{
+			name:    "overlaps the default pool",
+			tenants: []config.TenantConfig{{ID: "acme", APIKeys: []string{"k"}, PoolMinTokenID: 168000000, PoolMaxTokenID: 168200000}},
+			wantErr: "overlaps",
+		},
+		{
+			name:    "empty pool",
+			tenants: []config.TenantConfig{{ID: "acme", APIKeys: []string{"k"}, PoolMinTokenID: 168200100, PoolMaxTokenID: 168200000}},
+			wantErr: "empty or invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewDefaultAppConfig()
+			cfg.Tenants = tt.tenants
+
+			_, err := services.NewTenantService(cfg)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTenantService_Resolve(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key", "acme-key-2"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}}
+	s, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+
+	tenant, err := s.ResolveAPIKey("acme-key-2")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", tenant.ID)
+
+	_, err = s.ResolveAPIKey("unknown")
+	assert.ErrorIs(t, err, errors.ErrInvalidAPIKey)
+
+	tenant, err = s.Tenant(models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.PoolMinTokenID, tenant.MinTokenID)
+
+	_, err = s.Tenant("other")
+	assert.ErrorIs(t, err, errors.ErrTenantNotFound)
+
+	assert.Len(t, s.Tenants(), 2)
+}
+
+func TestTenantService_PoolExclusions(t *testing.T) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Tenants = []config.TenantConfig{{
+		ID: "acme", APIKeys: []string{"acme-key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100,
+		PoolExclusions: []config.TokenRangeConfig{
+			{FirstTokenID: 168200090, LastTokenID: 168200100, Reason: "routers"},
+			{FirstTokenID: 168200000, LastTokenID: 168200009},
+		},
+	}}
+	s, err := services.NewTenantService(cfg)
+	require.NoError(t, err)
+
+	tenant, err := s.Tenant("acme")
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenRanges{
+		{FirstTokenID: 168200000, LastTokenID: 168200009},
+		{FirstTokenID: 168200090, LastTokenID: 168200100, Reason: "routers"},
+	}, tenant.Exclusions, "exclusions are sorted")
+	assert.True(t, tenant.Allocatable(168200010))
+	assert.False(t, tenant.Allocatable(168200095))
+
+	tenant, err = s.Tenant(models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.Empty(t, tenant.Exclusions)
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+func TestHealthScorePolicy_Score(t *testing.T) {
+	policy := &models.HealthScorePolicy{
+		Threshold:          50,
+		DBLatencyBudget:    200 * time.Millisecond,
+		RedisLatencyBudget: 100 * time.Millisecond,
+		ErrorRateBudget:    0.2,
+		MaxInFlight:        100,
+		DBWeight:           0.3,
+		RedisWeight:        0.2,
+		ErrorRateWeight:    0.3,
+		SaturationWeight:   0.2,
+	}
+
+	tests := []struct {
+		name            string
+		signals         models.HealthSignals
+		expectedScore   float64
+		expectedHealthy bool
+	}{
+		{
+			name:            "idle instance with instant dependencies",
+			signals:         models.HealthSignals{DBReachable: true, RedisReachable: true},
+			expectedScore:   100,
+			expectedHealthy: true,
+		},
+		{
+			name: "half of every budget used",
+			signals: models.HealthSignals{
+				DBReachable: true, DBLatency: 100 * time.Millisecond,
+				RedisReachable: true, RedisLatency: 50 * time.Millisecond,
+				ErrorRate: 0.1,
+				InFlight:  50,
+			},
+			expectedScore:   50,
+			expectedHealthy: true,
+		},
+		{
+			name: "signals beyond their budget score 0",
+			signals: models.HealthSignals{
+				DBReachable: true, DBLatency: time.Second,
+				RedisReachable: true,
+				ErrorRate:      0.5,
+				InFlight:       20,
+			},
+			expectedScore:   36,
+			expectedHealthy: false,
+		},
+		{
+			name:            "unreachable database",
+			signals:         models.HealthSignals{DBReachable: false, RedisReachable: true},
+			expectedScore:   0,
+			expectedHealthy: false,
+		},
+		{
+			name:            "unreachable redis",
+			signals:         models.HealthSignals{DBReachable: true, RedisReachable: false},
+			expectedScore:   0,
+			expectedHealthy: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := policy.Score(&tt.signals)
+
+			assert.InDelta(t, tt.expectedScore, score.Score, 0.01)
+			assert.Equal(t, tt.expectedHealthy, score.Healthy)
+			assert.Equal(t, policy.Threshold, score.Threshold)
+			assert.Len(t, score.Components, 4)
+		})
+	}
+}
+
+func TestHealthScorePolicy_ScoreComponents(t *testing.T) {
+	policy := &models.HealthScorePolicy{
+		DBLatencyBudget:  200 * time.Millisecond,
+		MaxInFlight:      0, // disabled
+		DBWeight:         1,
+		SaturationWeight: 1,
+	}
+
+	score := policy.Score(&models.HealthSignals{
+		DBReachable:    true,
+		DBLatency:      50 * time.Millisecond,
+		RedisReachable: true,
+		InFlight:       1000,
+	})
+
+	byName := make(map[string]*models.HealthComponent)
+	for _, c := range score.Components {
+		byName[c.Name] = c
+	}
+
+	assert.Equal(t, 50.0, byName[models.HealthComponentDatabase].Value)
+	assert.Equal(t, 0.75, byName[models.HealthComponentDatabase].Score)
+	assert.Equal(t, 1.0, byName[models.HealthComponentSaturation].Score, "a zero budget disables the component")
+	assert.Equal(t, 0.0, byName[models.HealthComponentRedis].Weight)
+	assert.InDelta(t, 87.5, score.Score, 0.01)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+func TestAllocStateInBounds(t *testing.T) {
+	tests := []struct {
+		name                 string
+		minID, maxID, lastID int64
+		expected             bool
+	}{
+		{"nothing allocated yet", 10, 20, 9, true},
+		{"pool exhausted", 10, 20, 20, true},
+		{"below pool", 10, 20, 5, false},
+		{"above pool", 10, 20, 21, false},
+		{"inverted bounds", 20, 10, 15, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, models.AllocStateInBounds(tt.minID, tt.maxID, tt.lastID))
+		})
+	}
+}
+
+func TestAllocStateRepair(t *testing.T) {
+	tests := []struct {
+		name                          string
+		minID, maxID, lastID, highest int64
+		expected                      int64
+		ok                            bool
+	}{
+		{"above pool is clamped to max", 10, 20, 30, 15, 20, true},
+		{"below pool with no leases restarts the pool", 10, 20, 5, 0, 9, true},
+		{"below pool skips leased tokens", 10, 20, 5, 14, 14, true},
+		{"inverted bounds cannot be repaired", 20, 10, 30, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			last, ok := models.AllocStateRepair(tt.minID, tt.maxID, tt.lastID, tt.highest)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, last)
+		})
+	}
+}
+
+func TestIntegrityReport_Counts(t *testing.T) {
+	report := &models.IntegrityReport{}
+	report.AddCheck(models.IntegrityCheckAllocState)
+	leases := report.AddCheck(models.IntegrityCheckDuplicateActiveLeases)
+	leases.Add("token:1", "duplicate", true)
+	leases.Add("token:2", "duplicate", false)
+
+	assert.Len(t, report.Checks, 2)
+	assert.Empty(t, report.Checks[0].Violations)
+	assert.Equal(t, 2, report.Violations())
+	assert.Equal(t, 1, report.Unrepaired())
+}
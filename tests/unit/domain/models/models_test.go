@@ -1,10 +1,15 @@
 package models
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	domainErrors "github.com/unicornultrafoundation/dhcp2p/internal/app/domain/errors"
 	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
 )
 
@@ -98,13 +103,12 @@ func TestLease_Properties(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Test basic properties
-			assert.Equal(t, tt.lease.TokenID, tt.lease.TokenID)
-			assert.Equal(t, tt.lease.PeerID, tt.lease.PeerID)
-
-			// Test if lease appears valid based on our expectations
-			isValid := tt.lease.TokenID > 0 && tt.lease.PeerID != "" && len(tt.lease.PeerID) <= 128 && tt.lease.ExpiresAt.After(tt.lease.CreatedAt)
-			assert.Equal(t, tt.expectValid, isValid)
+			err := tt.lease.Validate()
+			if tt.expectValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
 		})
 	}
 }
@@ -151,8 +155,7 @@ func TestLease_IsExpired(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := tt.lease.ExpiresAt.Before(time.Now())
-			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expected, tt.lease.IsExpired(now))
 		})
 	}
 }
@@ -183,7 +186,7 @@ func TestLease_TimeRemaining(t *testing.T) {
 				CreatedAt: now.Add(-2 * time.Hour),
 				ExpiresAt: now.Add(-time.Hour),
 			},
-			expected: -time.Hour,
+			expected: 0,
 		},
 		{
 			name: "lease expiring now",
@@ -199,13 +202,94 @@ func TestLease_TimeRemaining(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := time.Until(tt.lease.ExpiresAt)
-			// Allow for small time differences due to test execution time
-			assert.InDelta(t, tt.expected.Seconds(), result.Seconds(), 1.0)
+			assert.Equal(t, tt.expected, tt.lease.TimeRemaining(now))
 		})
 	}
 }
 
+func TestValidatePeerID(t *testing.T) {
+	assert.NoError(t, models.ValidatePeerID("peer123"))
+	assert.NoError(t, models.ValidatePeerID(string(make([]byte, models.MaxPeerIDLength))))
+	assert.ErrorIs(t, models.ValidatePeerID(""), domainErrors.ErrMissingPeerID)
+	assert.ErrorIs(t, models.ValidatePeerID(string(make([]byte, models.MaxPeerIDLength+1))), domainErrors.ErrInvalidPeerID)
+}
+
+func TestValidateTokenID(t *testing.T) {
+	assert.NoError(t, models.ValidateTokenID(1))
+	assert.NoError(t, models.ValidateTokenID(167772161))
+	assert.ErrorIs(t, models.ValidateTokenID(0), domainErrors.ErrInvalidTokenID)
+	assert.ErrorIs(t, models.ValidateTokenID(-1), domainErrors.ErrInvalidTokenID)
+}
+
+func TestLease_Validate_ExpiryBeforeCreation(t *testing.T) {
+	now := time.Now()
+	lease := models.Lease{TokenID: 167772161, PeerID: "peer123", CreatedAt: now, ExpiresAt: now}
+
+	assert.ErrorIs(t, lease.Validate(), domainErrors.ErrInvalidLease)
+
+	// A lease without a creation time, e.g. one built for a release event, is not checked
+	lease.CreatedAt = time.Time{}
+	assert.NoError(t, lease.Validate())
+}
+
+func TestNormalizeLeaseLabels(t *testing.T) {
+	labels, err := models.NormalizeLeaseLabels(map[string]string{"role": " validator ", "app.version": "1.4.2", "zone/rack": ""})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"role": "validator", "app.version": "1.4.2", "zone/rack": ""}, labels)
+
+	labels, err = models.NormalizeLeaseLabels(map[string]string{})
+	assert.NoError(t, err)
+	assert.Nil(t, labels)
+
+	tooMany := map[string]string{}
+	for i := range models.MaxLeaseLabels + 1 {
+		tooMany[fmt.Sprintf("key%d", i)] = "value"
+	}
+	tooLarge := map[string]string{}
+	for i := range models.MaxLeaseLabels {
+		tooLarge[fmt.Sprintf("key%d", i)] = strings.Repeat("x", 100)
+	}
+	for name, labels := range map[string]map[string]string{
+		"uppercase key":     {"Role": "validator"},
+		"empty key":         {"": "validator"},
+		"key with space":    {"node role": "validator"},
+		"long key":          {strings.Repeat("k", 64): "validator"},
+		"control character": {"role": "valid\nator"},
+		"invalid UTF-8":     {"role": "\xff"},
+		"long value":        {"role": strings.Repeat("x", models.MaxLeaseLabelValueBytes+1)},
+		"too many labels":   tooMany,
+		"too large":         tooLarge,
+	} {
+		_, err := models.NormalizeLeaseLabels(labels)
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidLabels, name)
+	}
+}
+
+func TestParseLeaseLabel(t *testing.T) {
+	label, err := models.ParseLeaseLabel("region=eu=west")
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseLabel{Key: "region", Value: "eu=west"}, label)
+
+	for _, raw := range []string{"region", "=eu", "Region=eu"} {
+		_, err := models.ParseLeaseLabel(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	filters, err := models.ParseLabelSelector("role=gateway, region!=eu")
+	require.NoError(t, err)
+	assert.Equal(t, []models.Filter{
+		{Field: "label", Operator: models.FilterEq, Value: models.LeaseLabel{Key: "role", Value: "gateway"}},
+		{Field: "label", Operator: models.FilterNe, Value: models.LeaseLabel{Key: "region", Value: "eu"}},
+	}, filters)
+
+	for _, selector := range []string{"role", "role=gateway,", "Role!=gateway"} {
+		_, err := models.ParseLabelSelector(selector)
+		assert.Error(t, err, selector)
+	}
+}
+
 func TestNonce_Properties(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -283,14 +367,12 @@ func TestNonce_Properties(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Test basic properties
-			assert.Equal(t, tt.nonce.ID, tt.nonce.ID)
-			assert.Equal(t, tt.nonce.PeerID, tt.nonce.PeerID)
-			assert.Equal(t, tt.nonce.Used, tt.nonce.Used)
-
-			// Test if nonce appears valid based on our expectations
-			isValid := tt.nonce.ID != "" && tt.nonce.PeerID != "" && tt.nonce.ExpiresAt.After(tt.nonce.IssuedAt)
-			assert.Equal(t, tt.expectValid, isValid)
+			err := tt.nonce.Validate()
+			if tt.expectValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
 		})
 	}
 }
@@ -340,8 +422,8 @@ func TestNonce_IsExpired(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := tt.nonce.ExpiresAt.Before(time.Now())
-			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expected, tt.nonce.IsExpired(now))
+			assert.Equal(t, !tt.expected, tt.nonce.TimeRemaining(now) > 0)
 		})
 	}
 }
@@ -647,3 +729,84 @@ func TestModels_EdgeCases(t *testing.T) {
 		assert.True(t, len(request.Pubkey) <= 1024)
 	})
 }
+
+func TestAPIKey_Grants(t *testing.T) {
+	key := func(scopes ...models.APIKeyScope) *models.APIKey { return &models.APIKey{Scopes: scopes} }
+
+	assert.True(t, key(models.APIKeyScopeLeasesRead).Grants(models.APIKeyScopeLeasesRead))
+	assert.False(t, key(models.APIKeyScopeLeasesRead).Grants(models.APIKeyScopeLeasesWrite))
+	assert.True(t, key(models.APIKeyScopeLeasesWrite).Grants(models.APIKeyScopeLeasesRead))
+	assert.False(t, key(models.APIKeyScopeLeasesWrite).Grants(models.APIKeyScopeAdminFull))
+	assert.True(t, key(models.APIKeyScopeAdminFull).Grants(models.APIKeyScopeLeasesWrite))
+	assert.False(t, key().Grants(models.APIKeyScopeLeasesRead))
+}
+
+func TestPeerPolicyFor(t *testing.T) {
+	listed := &models.PeerPolicy{Name: "operators", Peers: []string{"peer-op"}, CanAllocate: true, CanReserve: true}
+	wildcard := &models.PeerPolicy{Name: "everyone", Peers: []string{models.PeerPolicyWildcard}, CanReserve: true}
+	policies := []*models.PeerPolicy{wildcard, listed}
+
+	assert.Same(t, listed, models.PeerPolicyFor(policies, "peer-op"))
+	assert.Same(t, wildcard, models.PeerPolicyFor(policies, "peer-other"))
+	assert.Nil(t, models.PeerPolicyFor([]*models.PeerPolicy{listed}, "peer-other"))
+
+	assert.True(t, listed.Grants(models.PeerPermissionReserve))
+	// Reserving allocates, so can_reserve alone grants nothing
+	assert.False(t, wildcard.Grants(models.PeerPermissionReserve))
+	assert.False(t, wildcard.Grants(models.PeerPermissionAllocate))
+}
+
+func TestPeerPolicyFilter(t *testing.T) {
+	operators := &models.PeerPolicy{Name: "operators", Peers: []string{"peer-op", "peer-shared"}}
+	everyone := &models.PeerPolicy{Name: "everyone", Peers: []string{models.PeerPolicyWildcard, "peer-gw"}}
+	gateways := &models.PeerPolicy{Name: "gateways", Peers: []string{"peer-gw", "peer-gw2", "peer-shared"}}
+	policies := []*models.PeerPolicy{operators, everyone, gateways}
+
+	filter, ok := models.PeerPolicyFilter(policies, "gateways")
+	require.True(t, ok)
+	// peer-gw is listed by the wildcard policy first, peer-shared by operators
+	assert.Equal(t, models.Filter{Field: "peer_id", Operator: models.FilterIn, Value: []string{"peer-gw2"}}, filter)
+
+	filter, ok = models.PeerPolicyFilter(policies, "everyone")
+	require.True(t, ok)
+	assert.Equal(t, models.Filter{Field: "peer_id", Operator: models.FilterNotIn, Value: []string{"peer-gw2", "peer-op", "peer-shared"}}, filter)
+
+	for _, peerID := range []string{"peer-op", "peer-gw", "peer-gw2", "peer-other"} {
+		governing := models.PeerPolicyFor(policies, peerID)
+		for _, policy := range policies {
+			filter, _ := models.PeerPolicyFilter(policies, policy.Name)
+			peers := filter.Value.([]string)
+			selected := slices.Contains(peers, peerID) == (filter.Operator == models.FilterIn)
+			assert.Equal(t, governing == policy, selected, "%s under %s", peerID, policy.Name)
+		}
+	}
+
+	_, ok = models.PeerPolicyFilter(policies, "missing")
+	assert.False(t, ok)
+}
+
+func TestTenant_AllocatableRanges(t *testing.T) {
+	tenant := &models.Tenant{
+		MinTokenID: 100,
+		MaxTokenID: 199,
+		Exclusions: models.TokenRanges{
+			{FirstTokenID: 100, LastTokenID: 109},
+			{FirstTokenID: 150, LastTokenID: 150},
+			{FirstTokenID: 190, LastTokenID: 199},
+		},
+	}
+
+	ranges := tenant.AllocatableRanges()
+	assert.Equal(t, models.TokenRanges{
+		{FirstTokenID: 110, LastTokenID: 149},
+		{FirstTokenID: 151, LastTokenID: 189},
+	}, ranges)
+	assert.Equal(t, int64(79), ranges.Size())
+
+	for tokenID, allocatable := range map[int64]bool{99: false, 100: false, 109: false, 110: true, 150: false, 151: true, 189: true, 190: false, 200: false} {
+		assert.Equal(t, allocatable, tenant.Allocatable(tokenID), "token ID %d", tokenID)
+	}
+
+	whole := &models.Tenant{MinTokenID: 100, MaxTokenID: 199}
+	assert.Equal(t, models.TokenRanges{{FirstTokenID: 100, LastTokenID: 199}}, whole.AllocatableRanges())
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+func TestReclaimPolicy_Matches(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		policy    models.ReclaimPolicy
+		candidate models.ReclaimCandidate
+		expected  bool
+	}{
+		{
+			name:      "empty policy matches any expired lease",
+			policy:    models.ReclaimPolicy{Name: "expired"},
+			candidate: models.ReclaimCandidate{UpdatedAt: now.Add(-3 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+			expected:  true,
+		},
+		{
+			name:      "renewed too recently",
+			policy:    models.ReclaimPolicy{NotRenewedFor: 6 * time.Hour},
+			candidate: models.ReclaimCandidate{UpdatedAt: now.Add(-3 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+			expected:  false,
+		},
+		{
+			name:      "expired too recently",
+			policy:    models.ReclaimPolicy{ExpiredFor: 24 * time.Hour},
+			candidate: models.ReclaimCandidate{UpdatedAt: now.Add(-3 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+			expected:  false,
+		},
+		{
+			name:      "all conditions hold",
+			policy:    models.ReclaimPolicy{NotRenewedFor: 6 * time.Hour, ExpiredFor: 24 * time.Hour},
+			candidate: models.ReclaimCandidate{UpdatedAt: now.Add(-48 * time.Hour), ExpiresAt: now.Add(-46 * time.Hour)},
+			expected:  true,
+		},
+		{
+			name:      "affinity group is reserved",
+			policy:    models.ReclaimPolicy{SkipAffinityGroups: true},
+			candidate: models.ReclaimCandidate{AffinityGroup: "rack-1", UpdatedAt: now.Add(-48 * time.Hour), ExpiresAt: now.Add(-46 * time.Hour)},
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Matches(&tt.candidate, now))
+		})
+	}
+}
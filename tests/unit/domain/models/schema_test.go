@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+func TestNewSchemaStatus(t *testing.T) {
+	available := []string{"003", "001", "002"}
+
+	tests := []struct {
+		name     string
+		applied  []string
+		current  string
+		pending  []string
+		unknown  []string
+		upToDate bool
+	}{
+		{"fresh database", nil, "", []string{"001", "002", "003"}, nil, false},
+		{"partially migrated", []string{"002", "001"}, "002", []string{"003"}, nil, false},
+		{"up to date", []string{"001", "002", "003"}, "003", nil, nil, true},
+		{"newer binary migrated", []string{"001", "002", "003", "004"}, "004", nil, []string{"004"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := models.NewSchemaStatus(available, tt.applied)
+			assert.Equal(t, "003", status.Latest)
+			assert.Equal(t, tt.current, status.Current)
+			assert.Equal(t, tt.pending, status.Pending)
+			assert.Equal(t, tt.unknown, status.Unknown)
+			assert.Equal(t, tt.upToDate, status.UpToDate())
+		})
+	}
+}
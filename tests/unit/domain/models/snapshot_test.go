@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+)
+
+func TestLeaseSnapshot_Violations(t *testing.T) {
+	tenants := []*models.Tenant{
+		{ID: models.DefaultTenantID, MinTokenID: 10, MaxTokenID: 20},
+		{ID: "acme", MinTokenID: 30, MaxTokenID: 40},
+	}
+	valid := func() *models.LeaseSnapshot {
+		return &models.LeaseSnapshot{
+			Version: models.SnapshotVersion,
+			Pools: []*models.SnapshotPool{
+				{Tenant: models.DefaultTenantID, MinTokenID: 10, MaxTokenID: 20, LastTokenID: 11},
+				{Tenant: "acme", MinTokenID: 30, MaxTokenID: 40, LastTokenID: 29},
+			},
+			Leases: []*models.SnapshotLease{
+				{TokenID: 10, PeerID: "peer-1", Tenant: models.DefaultTenantID},
+				{TokenID: 11, PeerID: "peer-2", Tenant: models.DefaultTenantID},
+				{TokenID: 35, PeerID: "peer-3", Tenant: "acme"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(s *models.LeaseSnapshot)
+		expected []string
+	}{
+		{"valid", func(s *models.LeaseSnapshot) {}, nil},
+		{"empty", func(s *models.LeaseSnapshot) { s.Pools, s.Leases = nil, nil }, nil},
+		{
+			"unsupported version",
+			func(s *models.LeaseSnapshot) { s.Version = 2; s.Leases[0].PeerID = "" },
+			[]string{"unsupported snapshot version 2, expected 1"},
+		},
+		{
+			"unknown tenant pool",
+			func(s *models.LeaseSnapshot) { s.Pools[1].Tenant = "other" },
+			[]string{`pools[1]: tenant "other" is not configured`},
+		},
+		{
+			"duplicate pool",
+			func(s *models.LeaseSnapshot) { s.Pools[1] = s.Pools[0] },
+			[]string{`pools[1]: tenant "default" has more than one pool`},
+		},
+		{
+			"pool bounds differ",
+			func(s *models.LeaseSnapshot) { s.Pools[1].MaxTokenID = 50 },
+			[]string{`pools[1]: pool of tenant "acme" is [30, 50], configured [30, 40]`},
+		},
+		{
+			"cursor outside pool",
+			func(s *models.LeaseSnapshot) { s.Pools[0].LastTokenID = 21 },
+			[]string{`pools[0]: last_token_id 21 of tenant "default" is outside [9, 20]`},
+		},
+		{
+			"lease without peer",
+			func(s *models.LeaseSnapshot) { s.Leases[0].PeerID = "" },
+			[]string{"leases[0]: token ID 10 has no peer ID"},
+		},
+		{
+			"duplicate token ID",
+			func(s *models.LeaseSnapshot) { s.Leases[1].TokenID = 10 },
+			[]string{"leases[1]: token ID 10 is leased more than once"},
+		},
+		{
+			"lease of unknown tenant",
+			func(s *models.LeaseSnapshot) { s.Leases[2].Tenant = "other" },
+			[]string{`leases[2]: tenant "other" of token ID 35 is not configured`},
+		},
+		{
+			"lease outside its pool",
+			func(s *models.LeaseSnapshot) { s.Leases[2].Tenant = models.DefaultTenantID },
+			[]string{`leases[2]: token ID 35 is outside the pool of tenant "default"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.modify(s)
+			assert.Equal(t, tt.expected, s.Violations(tenants))
+		})
+	}
+
+	t.Run("violations are capped", func(t *testing.T) {
+		s := valid()
+		s.Leases = nil
+		for i := range 25 {
+			s.Leases = append(s.Leases, &models.SnapshotLease{TokenID: int64(100 + i), PeerID: fmt.Sprintf("peer-%d", i), Tenant: "acme"})
+		}
+
+		violations := s.Violations(tenants)
+		assert.Len(t, violations, 21)
+		assert.Equal(t, "and 5 more", violations[20])
+	})
+}
@@ -0,0 +1,47 @@
+package reqctx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/reqctx"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Run("empty context", func(t *testing.T) {
+		ctx := context.Background()
+
+		assert.Equal(t, reqctx.RequestContext{TenantID: models.DefaultTenantID}, reqctx.FromContext(ctx))
+		_, ok := reqctx.PeerID(ctx)
+		assert.False(t, ok)
+		_, ok = reqctx.AuthenticatedBy(ctx)
+		assert.False(t, ok)
+	})
+
+	t.Run("every value set", func(t *testing.T) {
+		ctx := reqctx.WithRequestID(context.Background(), "req-1")
+		ctx = reqctx.WithClientIP(ctx, "203.0.113.7")
+		ctx = reqctx.WithTenant(ctx, "acme")
+		ctx = reqctx.WithAuthMethod(reqctx.WithPeerID(ctx, "peer123"), reqctx.AuthMethodBody)
+
+		assert.Equal(t, reqctx.RequestContext{
+			RequestID:  "req-1",
+			PeerID:     "peer123",
+			TenantID:   "acme",
+			AuthMethod: reqctx.AuthMethodBody,
+			ClientIP:   "203.0.113.7",
+		}, reqctx.FromContext(ctx))
+
+		// The tenant is the one the storage adapters read
+		assert.Equal(t, "acme", models.TenantFromContext(ctx))
+	})
+
+	t.Run("empty values are not set", func(t *testing.T) {
+		ctx := reqctx.WithPeerID(context.Background(), "")
+
+		_, ok := reqctx.PeerID(ctx)
+		assert.False(t, ok)
+	})
+}
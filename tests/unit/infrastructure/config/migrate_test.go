@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+)
+
+func TestMigrateSettings(t *testing.T) {
+	tests := []struct {
+		name             string
+		settings         map[string]interface{}
+		expected         map[string]interface{}
+		expectedRenamed  map[string]string
+		expectedUnknown  []string
+		expectedConflict []string
+	}{
+		{
+			name:            "current keys are kept",
+			settings:        map[string]interface{}{"server.port": 8088, "lease.ttl": 60},
+			expected:        map[string]interface{}{"server.port": 8088, "lease.ttl": 60},
+			expectedRenamed: map[string]string{},
+		},
+		{
+			name:            "flat keys move into their section",
+			settings:        map[string]interface{}{"port": 8088, "db_max_conns": 50},
+			expected:        map[string]interface{}{"server.port": 8088, "database.max_conns": 50},
+			expectedRenamed: map[string]string{"port": "server.port", "db_max_conns": "database.max_conns"},
+		},
+		{
+			name:            "legacy key is renamed",
+			settings:        map[string]interface{}{"log": "debug"},
+			expected:        map[string]interface{}{"server.log_level": "debug"},
+			expectedRenamed: map[string]string{"log": "server.log_level"},
+		},
+		{
+			name:             "new key wins over legacy key",
+			settings:         map[string]interface{}{"log": "debug", "server.log_level": "info"},
+			expected:         map[string]interface{}{"server.log_level": "info"},
+			expectedRenamed:  map[string]string{},
+			expectedConflict: []string{"log"},
+		},
+		{
+			name:             "newer legacy key wins over older one",
+			settings:         map[string]interface{}{"log": "debug", "log_level": "info"},
+			expected:         map[string]interface{}{"server.log_level": "info"},
+			expectedRenamed:  map[string]string{"log_level": "server.log_level"},
+			expectedConflict: []string{"log"},
+		},
+		{
+			name:            "unknown keys are reported and dropped",
+			settings:        map[string]interface{}{"server.port": 8088, "not_a_key": true},
+			expected:        map[string]interface{}{"server.port": 8088},
+			expectedRenamed: map[string]string{},
+			expectedUnknown: []string{"not_a_key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, report := config.MigrateSettings(tt.settings)
+
+			assert.Equal(t, tt.expected, migrated)
+			assert.Equal(t, tt.expectedRenamed, report.Renamed)
+			assert.Equal(t, tt.expectedUnknown, report.Unknown)
+			assert.Equal(t, tt.expectedConflict, report.Conflicts)
+		})
+	}
+}
+
+func TestKnownKeys(t *testing.T) {
+	keys := config.KnownKeys()
+
+	for _, key := range []string{"server.port", "database.url", "rate_limit.burst", "rate_limit.trusted_proxies", "admin_token"} {
+		_, ok := keys[key]
+		assert.True(t, ok, "expected %s to be a known key", key)
+	}
+}
+
+func TestCheckEnvironment(t *testing.T) {
+	warnings := config.CheckEnvironment([]string{
+		"DHCP2P_PORT=8088",
+		"DHCP2P_SERVER_PORT=8088",
+		"DHCP2P_DB_MAX_CONNS=50",
+		"DHCP2P_LOG=debug",
+		"DHCP2P_BOGUS=1",
+		"PATH=/usr/bin",
+	})
+
+	assert.Equal(t, []string{
+		"DHCP2P_BOGUS: unknown configuration variable",
+		"DHCP2P_LOG: renamed to DHCP2P_SERVER_LOG_LEVEL",
+	}, warnings)
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "old.yaml")
+	require.NoError(t, os.WriteFile(inPath, []byte("port: 9000\nlog: debug\nstale_option: 1\n"), 0o600))
+
+	migrated, report, err := config.MigrateConfigFile(inPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9000, migrated.GetInt("server.port"))
+	assert.Equal(t, "debug", migrated.GetString("server.log_level"))
+	assert.False(t, migrated.IsSet("log"))
+	assert.Equal(t, []string{"stale_option"}, report.Unknown)
+
+	outPath := filepath.Join(dir, "new.yaml")
+	require.NoError(t, migrated.WriteConfigAs(outPath))
+
+	data, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "server:\n")
+	assert.Contains(t, string(data), "  log_level: debug")
+}
+
+func TestNewAppConfig_LegacyKeys(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("port: 9000\nlease_ttl: 30\nredis:\n  pool_size: 20\n"), 0o600))
+	viper.Set(flag.CONFIG_FLAG, path)
+	t.Setenv("DHCP2P_DB_MAX_CONNS", "50")
+	t.Setenv("DHCP2P_NONCE_TTL", "9")
+
+	cfg, err := config.NewAppConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, 9000, cfg.Server.Port, "flat file keys move into their section")
+	assert.Equal(t, 30, cfg.Lease.TTL)
+	assert.Equal(t, 20, cfg.Redis.PoolSize, "nested file keys are read as is")
+	assert.Equal(t, 50, cfg.Database.MaxConns, "legacy environment variables still apply")
+	assert.Equal(t, 9, cfg.Nonce.TTL)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+)
+
+func TestReload(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	writeConfig("server:\n  port: 8088\n  log_level: info\nlease:\n  ttl: 120\nrate_limit:\n  burst: 20\n")
+	viper.Set(flag.CONFIG_FLAG, path)
+	current, err := config.NewAppConfig()
+	require.NoError(t, err)
+
+	t.Run("nothing changed", func(t *testing.T) {
+		result, err := config.Reload(current)
+		require.NoError(t, err)
+		assert.Empty(t, result.Applied)
+		assert.Empty(t, result.Ignored)
+	})
+
+	t.Run("reloadable settings are applied, others ignored", func(t *testing.T) {
+		writeConfig("server:\n  port: 9000\n  log_level: debug\nlease:\n  ttl: 30\nrate_limit:\n  burst: 5\n  trusted_proxies: [\"10.0.0.0/8\"]\n")
+
+		result, err := config.Reload(current)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"server.log_level", "lease.ttl", "rate_limit.burst", "rate_limit.trusted_proxies"}, result.Applied)
+		assert.Equal(t, []string{"server.port"}, result.Ignored)
+
+		assert.Equal(t, "debug", result.Config.Server.LogLevel)
+		assert.Equal(t, 30, result.Config.Lease.TTL)
+		assert.Equal(t, 5, result.Config.RateLimit.Burst)
+		assert.Equal(t, []string{"10.0.0.0/8"}, result.Config.RateLimit.TrustedProxies)
+		assert.Equal(t, 8088, result.Config.Server.Port, "port needs a restart")
+		assert.Equal(t, 120, current.Lease.TTL, "current config is not modified")
+	})
+
+	t.Run("invalid file keeps the current config", func(t *testing.T) {
+		writeConfig("lease:\n  ttl: [\n")
+
+		_, err := config.Reload(current)
+		assert.Error(t, err)
+	})
+
+	t.Run("legacy flat keys are still reloaded", func(t *testing.T) {
+		writeConfig("port: 8088\nlog_level: info\nlease_ttl: 60\nrate_limit_burst: 20\n")
+
+		result, err := config.Reload(current)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"lease.ttl"}, result.Applied)
+		assert.Empty(t, result.Ignored)
+		assert.Equal(t, 60, result.Config.Lease.TTL)
+	})
+}
@@ -0,0 +1,489 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+)
+
+func TestValidate_Defaults(t *testing.T) {
+	assert.NoError(t, config.NewDefaultAppConfig().Validate())
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(c *config.AppConfig)
+		expected string
+	}{
+		{
+			name:     "negative TTL",
+			modify:   func(c *config.AppConfig) { c.Lease.TTL = -5 },
+			expected: "lease.ttl must be greater than 0, got -5",
+		},
+		{
+			name:     "burst above requests per minute",
+			modify:   func(c *config.AppConfig) { c.RateLimit.Burst = 500 },
+			expected: "rate_limit.burst (500) must not exceed rate_limit.requests_per_minute (100)",
+		},
+		{
+			name: "longest ban below the first ban",
+			modify: func(c *config.AppConfig) {
+				c.RateLimit.BanEnabled = true
+				c.RateLimit.BanMaxDuration = 30
+			},
+			expected: "rate_limit.ban_max_duration (30) must not be less than rate_limit.ban_duration (60)",
+		},
+		{
+			name: "rate limit class without a limit",
+			modify: func(c *config.AppConfig) {
+				c.RateLimit.Classes = []config.RateLimitClassConfig{{Name: "allocation", Routes: []string{"/allocate-ip"}}}
+			},
+			expected: "rate_limit.classes[0]: needs requests_per_minute and burst, or a multiplier leaving both positive",
+		},
+		{
+			name: "route in two rate limit classes",
+			modify: func(c *config.AppConfig) {
+				c.RateLimit.Classes = []config.RateLimitClassConfig{
+					{Name: "allocation", Routes: []string{"/allocate-ip"}, Multiplier: 0.5},
+					{Name: "leases", Routes: []string{"/Allocate-IP", "/renew-lease"}, Multiplier: 2},
+				}
+			},
+			expected: `rate_limit.classes[1]: route "/Allocate-IP" is already in class "allocation"`,
+		},
+		{
+			name: "rate limit class burst above its rate",
+			modify: func(c *config.AppConfig) {
+				c.RateLimit.Classes = []config.RateLimitClassConfig{{Name: "nonces", Routes: []string{"/request-auth"}, RequestsPerMinute: 10, Multiplier: 1}}
+			},
+			expected: "rate_limit.classes[0]: burst (20) must not exceed requests_per_minute (10)",
+		},
+		{
+			name:     "header pattern does not compile",
+			modify:   func(c *config.AppConfig) { c.Security.HeaderPatterns = []string{"<script", "on(load"} },
+			expected: "security.header_patterns[1]: error parsing regexp: missing closing ): `on(load`",
+		},
+		{
+			name: "verification key is the signing key",
+			modify: func(c *config.AppConfig) {
+				c.Security.ServerKeyPath = "/var/lib/dhcp2p/server.key"
+				c.Security.ServerVerificationKeyPaths = []string{"/var/lib/dhcp2p/server.key.0a1b2c3d4e5f6071", "/var/lib/dhcp2p/server.key"}
+			},
+			expected: "security.server_verification_key_paths[1]: must be a key file other than security.server_key_path",
+		},
+		{
+			name:     "trusted proxy is not an IP or CIDR",
+			modify:   func(c *config.AppConfig) { c.RateLimit.TrustedProxies = []string{"10.0.0.1", "10.0.0.0/33"} },
+			expected: `rate_limit.trusted_proxies[1]: "10.0.0.0/33" is neither an IP address nor a CIDR block`,
+		},
+		{
+			name:     "admin network is not an IP or CIDR",
+			modify:   func(c *config.AppConfig) { c.AdminAllowedCIDRs = []string{"office"} },
+			expected: `admin_allowed_cidrs[0]: "office" is neither an IP address nor a CIDR block`,
+		},
+		{
+			name:     "diagnostics network is not an IP or CIDR",
+			modify:   func(c *config.AppConfig) { c.DiagnosticsAllowedCIDRs = []string{"10.0.0.0/8", "10.0.0.0/40"} },
+			expected: `diagnostics_allowed_cidrs[1]: "10.0.0.0/40" is neither an IP address nor a CIDR block`,
+		},
+		{
+			name:     "unknown peer policy source",
+			modify:   func(c *config.AppConfig) { c.PeerPolicySource = "ldap" },
+			expected: `peer_policy_source must be one of config, database, got "ldap"`,
+		},
+		{
+			name:     "config peer policy source without policies",
+			modify:   func(c *config.AppConfig) { c.PeerPolicySource = config.PeerPolicySourceConfig },
+			expected: "peer_policies must contain at least one policy when peer_policy_source is config",
+		},
+		{
+			name: "max_pool on a wildcard peer policy",
+			modify: func(c *config.AppConfig) {
+				c.PeerPolicies = []config.PeerPolicyConfig{{Name: "everyone", Peers: []string{"*"}, MaxPool: 10}}
+			},
+			expected: `peer_policies[0]: max_pool cannot be set on a policy matching "*"`,
+		},
+		{
+			name: "unknown module log level",
+			modify: func(c *config.AppConfig) {
+				c.Log.Levels = map[string]string{"cache": "verbose"}
+			},
+			expected: `log.levels.cache must be one of debug, info, warn, error, got "verbose"`,
+		},
+		{
+			name: "fault injection without the postgres backend",
+			modify: func(c *config.AppConfig) {
+				c.AdminEnabled = true
+				c.AdminToken = "secret"
+				c.StorageBackend = config.StorageBackendMemory
+				c.FaultInjectionEnabled = true
+			},
+			expected: "fault injection is only supported by the postgres storage backend",
+		},
+		{
+			name: "payload logging without a rate",
+			modify: func(c *config.AppConfig) {
+				c.Log.PayloadsEnabled = true
+				c.Log.PayloadsPerSecond = 0
+			},
+			expected: "log.payloads_per_second must be greater than 0, got 0",
+		},
+		{
+			name: "renew hint jitter reaching the expiry",
+			modify: func(c *config.AppConfig) {
+				c.Lease.RenewHintPercent = 90
+				c.Lease.RenewHintJitter = 10
+			},
+			expected: "lease.renew_hint_percent (90) give or take lease.renew_hint_jitter (10) must stay between 0 and 100",
+		},
+		{
+			name: "discovery instance longer than a DNS label",
+			modify: func(c *config.AppConfig) {
+				c.DiscoveryEnabled = true
+				c.DiscoveryInstance = strings.Repeat("a", 64)
+			},
+			expected: "discovery_instance must not be longer than 63 bytes, got 64",
+		},
+		{
+			name: "active/standby mode without postgres",
+			modify: func(c *config.AppConfig) {
+				c.HAEnabled = true
+				c.StorageBackend = config.StorageBackendEmbedded
+			},
+			expected: "active/standby mode is only supported by the postgres storage backend",
+		},
+		{
+			name: "active/standby mode with write-behind renewals",
+			modify: func(c *config.AppConfig) {
+				c.HAEnabled = true
+				c.Lease.WriteBehindInterval = 5
+			},
+			expected: "lease.write_behind_interval must be 0 in active/standby mode",
+		},
+		{
+			name: "read-only instance in active/standby mode",
+			modify: func(c *config.AppConfig) {
+				c.HAEnabled = true
+				c.ReadOnly = true
+			},
+			expected: "read_only and ha_enabled cannot both be set",
+		},
+		{
+			name:     "unknown cache codec",
+			modify:   func(c *config.AppConfig) { c.Cache.Codec = "msgpack" },
+			expected: `cache.codec must be one of json, cbor, got "msgpack"`,
+		},
+		{
+			name:     "pool bounds reversed",
+			modify:   func(c *config.AppConfig) { c.PoolMinTokenID, c.PoolMaxTokenID = 200, 100 },
+			expected: "pool_min_token_id (200) must not exceed pool_max_token_id (100)",
+		},
+		{
+			name: "tenant pool outside IPv4",
+			modify: func(c *config.AppConfig) {
+				c.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"key"}, PoolMinTokenID: 1, PoolMaxTokenID: 1 << 33}}
+			},
+			expected: "tenants[0]: pool_min_token_id and pool_max_token_id must be between 0 and 4294967295",
+		},
+		{
+			name: "pool exclusion outside the pool",
+			modify: func(c *config.AppConfig) {
+				c.PoolExclusions = []config.TokenRangeConfig{{FirstTokenID: c.PoolMaxTokenID, LastTokenID: c.PoolMaxTokenID + 1}}
+			},
+			expected: "pool_exclusions[0]: [168162304, 168162305] must lie within the pool [167902210, 168162304]",
+		},
+		{
+			name: "overlapping tenant pool exclusions",
+			modify: func(c *config.AppConfig) {
+				c.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100,
+					PoolExclusions: []config.TokenRangeConfig{{FirstTokenID: 168200000, LastTokenID: 168200010}, {FirstTokenID: 168200010, LastTokenID: 168200020}}}}
+			},
+			expected: "tenants[0]: pool_exclusions[1]: [168200010, 168200020] overlaps pool_exclusions[0]",
+		},
+		{
+			name: "pool exclusions covering the whole pool",
+			modify: func(c *config.AppConfig) {
+				c.PoolExclusions = []config.TokenRangeConfig{{FirstTokenID: c.PoolMinTokenID, LastTokenID: c.PoolMaxTokenID}}
+			},
+			expected: "pool_exclusions must leave at least one token ID of the pool to hand out",
+		},
+		{
+			name: "pool zone outside the pool",
+			modify: func(c *config.AppConfig) {
+				c.PoolZones = []config.ZoneRangeConfig{{Zone: "rack-1", FirstTokenID: c.PoolMaxTokenID, LastTokenID: c.PoolMaxTokenID + 1}}
+			},
+			expected: "pool_zones[0]: [168162304, 168162305] must lie within the pool [167902210, 168162304]",
+		},
+		{
+			name: "overlapping pool zones",
+			modify: func(c *config.AppConfig) {
+				c.PoolZones = []config.ZoneRangeConfig{{Zone: "rack-1", FirstTokenID: 167902210, LastTokenID: 167902300}, {Zone: "rack-2", FirstTokenID: 167902300, LastTokenID: 167902400}}
+			},
+			expected: "pool_zones[1]: [167902300, 167902400] overlaps pool_zones[0]",
+		},
+		{
+			name: "tenant pool zone without a name",
+			modify: func(c *config.AppConfig) {
+				c.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100,
+					PoolZones: []config.ZoneRangeConfig{{FirstTokenID: 168200000, LastTokenID: 168200010}}}}
+			},
+			expected: `tenants[0]: pool_zones[0]: zone "" must be 1 to 64 letters, digits, '.', '_' or '-'`,
+		},
+		{
+			name: "tenant API key with the admin API key prefix",
+			modify: func(c *config.AppConfig) {
+				c.Tenants = []config.TenantConfig{{ID: "acme", APIKeys: []string{"key", "dhcp2p_key"}, PoolMinTokenID: 168200000, PoolMaxTokenID: 168200100}}
+			},
+			expected: `tenants[0]: api_keys[1] must not start with "dhcp2p_", which marks admin API keys`,
+		},
+		{
+			name:     "webhook URL without scheme",
+			modify:   func(c *config.AppConfig) { c.LeaseWebhooks = []config.LeaseWebhookConfig{{URL: "example.com/hook"}} },
+			expected: `lease_webhooks[0]: url must be an absolute http or https URL, got "example.com/hook"`,
+		},
+		{
+			name:     "redis URL without port",
+			modify:   func(c *config.AppConfig) { c.Redis.URL = "localhost" },
+			expected: `redis.url must be host:port or a redis:// or rediss:// URL, got "localhost"`,
+		},
+		{
+			name:     "database URL with another scheme",
+			modify:   func(c *config.AppConfig) { c.Database.URL = "mysql://localhost/dhcp2p" },
+			expected: "database.url must be a postgres:// URL or a key=value connection string",
+		},
+		{
+			name: "replica URL with another scheme",
+			modify: func(c *config.AppConfig) {
+				c.Database.URL = "postgres://primary/dhcp2p"
+				c.Database.ReplicaURLs = []string{"mysql://replica/dhcp2p"}
+			},
+			expected: "database.replica_urls[0] must be a postgres:// URL or a key=value connection string",
+		},
+		{
+			name:     "replicas without a primary",
+			modify:   func(c *config.AppConfig) { c.Database.ReplicaURLs = []string{"postgres://replica/dhcp2p"} },
+			expected: "database.replica_urls requires database.url, writes always go to the primary",
+		},
+		{
+			name:     "dashboard without admin API",
+			modify:   func(c *config.AppConfig) { c.DashboardEnabled = true },
+			expected: "dashboard_enabled requires admin_enabled",
+		},
+		{
+			name:     "negative pool stats cache TTL",
+			modify:   func(c *config.AppConfig) { c.AdminPoolStatsCacheTTL = -1 },
+			expected: "admin_pool_stats_cache_ttl must not be negative, got -1",
+		},
+		{
+			name:     "negative release grace",
+			modify:   func(c *config.AppConfig) { c.Lease.ReleaseGrace = -1 },
+			expected: "lease.release_grace must not be negative, got -1",
+		},
+		{
+			name: "degraded renewals without journal",
+			modify: func(c *config.AppConfig) {
+				c.DegradedRenewalsEnabled = true
+				c.DegradedJournalPath = ""
+			},
+			expected: "degraded_journal_path must be set when degraded renewals are enabled",
+		},
+		{
+			name: "degraded renewals on the embedded backend",
+			modify: func(c *config.AppConfig) {
+				c.DegradedRenewalsEnabled = true
+				c.StorageBackend = config.StorageBackendEmbedded
+			},
+			expected: "degraded renewals are only supported by the postgres storage backend",
+		},
+		{
+			name: "zero degraded replay interval",
+			modify: func(c *config.AppConfig) {
+				c.DegradedRenewalsEnabled = true
+				c.DegradedReplayInterval = 0
+			},
+			expected: "degraded_replay_interval must be greater than 0, got 0",
+		},
+		{
+			name:     "negative write-behind interval",
+			modify:   func(c *config.AppConfig) { c.Lease.WriteBehindInterval = -1 },
+			expected: "lease.write_behind_interval must not be negative, got -1",
+		},
+		{
+			name: "write-behind on the embedded backend",
+			modify: func(c *config.AppConfig) {
+				c.Lease.WriteBehindInterval = 5
+				c.StorageBackend = config.StorageBackendEmbedded
+			},
+			expected: "lease.write_behind_interval is only supported by the postgres storage backend",
+		},
+		{
+			name: "zero write-behind batch size",
+			modify: func(c *config.AppConfig) {
+				c.Lease.WriteBehindInterval = 5
+				c.Lease.WriteBehindBatchSize = 0
+			},
+			expected: "lease.write_behind_batch_size must be greater than 0, got 0",
+		},
+		{
+			name:     "zero revocation batch size",
+			modify:   func(c *config.AppConfig) { c.Lease.RevocationBatchSize = 0 },
+			expected: "lease.revocation_batch_size must be greater than 0, got 0",
+		},
+		{
+			name:     "zero lease wait timeout",
+			modify:   func(c *config.AppConfig) { c.Lease.WaitMaxTimeout = 0 },
+			expected: "lease.wait_max_timeout must be greater than 0, got 0",
+		},
+		{
+			name:     "unknown allocation strategy",
+			modify:   func(c *config.AppConfig) { c.Lease.AllocationStrategy = "fifo" },
+			expected: `lease.allocation_strategy must be one of lru, sequential, random, got "fifo"`,
+		},
+		{
+			name:     "more idle than pooled Redis connections",
+			modify:   func(c *config.AppConfig) { c.Redis.MinIdleConns = 20 },
+			expected: "redis.min_idle_conns (20) must not exceed redis.pool_size (10)",
+		},
+		{
+			name:     "unknown event bus broker",
+			modify:   func(c *config.AppConfig) { c.EventBusBroker = "rabbitmq" },
+			expected: `event_bus_broker must be one of memory, nats, kafka, got "rabbitmq"`,
+		},
+		{
+			name:     "nats broker without a server",
+			modify:   func(c *config.AppConfig) { c.EventBusBroker = "nats" },
+			expected: `nats_url must be a nats:// or tls:// URL for the nats event bus broker, got ""`,
+		},
+		{
+			name: "kafka broker with a bootstrap server",
+			modify: func(c *config.AppConfig) {
+				c.EventBusBroker = "kafka"
+				c.KafkaRESTURL = "kafka:9092"
+			},
+			expected: `kafka_rest_url must be an absolute http or https URL, got "kafka:9092"`,
+		},
+		{
+			name:     "event bus backoff above its maximum",
+			modify:   func(c *config.AppConfig) { c.EventBusRetryBackoff = 60000 },
+			expected: "event_bus_max_retry_backoff must be at least event_bus_retry_backoff (60000), got 30000",
+		},
+		{
+			name: "s3 lease journal without credentials",
+			modify: func(c *config.AppConfig) {
+				c.LeaseJournalEnabled = true
+				c.LeaseJournalSink = "s3"
+				c.LeaseJournalS3Endpoint = "https://s3.example.com"
+				c.LeaseJournalS3Bucket = "dhcp2p"
+			},
+			expected: "lease_journal_s3_access_key and lease_journal_s3_secret_key must be set for the s3 lease journal sink",
+		},
+		{
+			name: "file lease journal without a path",
+			modify: func(c *config.AppConfig) {
+				c.LeaseJournalEnabled = true
+				c.LeaseJournalPath = ""
+			},
+			expected: "lease_journal_path must be set for the file lease journal sink",
+		},
+		{
+			name:     "short field encryption key",
+			modify:   func(c *config.AppConfig) { c.Security.FieldEncryptionKey = "c2hvcnQ=" },
+			expected: "security.field_encryption_key: key is 5 bytes, expected 32",
+		},
+		{
+			name: "field encryption key and key path",
+			modify: func(c *config.AppConfig) {
+				c.Security.FieldEncryptionKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+				c.Security.FieldEncryptionKeyPath = "/run/secrets/field-key"
+			},
+			expected: "security.field_encryption_key and security.field_encryption_key_path are mutually exclusive",
+		},
+		{
+			name: "field encryption with the memory backend",
+			modify: func(c *config.AppConfig) {
+				c.StorageBackend = config.StorageBackendMemory
+				c.Security.FieldEncryptionKeyPath = "/run/secrets/field-key"
+			},
+			expected: "field encryption is only supported by the postgres storage backend",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewDefaultAppConfig()
+			tt.modify(c)
+
+			err := c.Validate()
+
+			var validationErr *config.ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, []string{tt.expected}, validationErr.Violations)
+		})
+	}
+}
+
+func TestValidate_ReportsAllViolations(t *testing.T) {
+	c := config.NewDefaultAppConfig()
+	c.Nonce.TTL = 0
+	c.RateLimit.Burst = 500
+	c.AdminEnabled = true
+
+	err := c.Validate()
+
+	require.Error(t, err)
+	assert.Equal(t, "invalid configuration (3 problems):\n"+
+		"  - nonce.ttl must be greater than 0, got 0\n"+
+		"  - rate_limit.burst (500) must not exceed rate_limit.requests_per_minute (100)\n"+
+		"  - admin_token must be set when admin_enabled is true, otherwise every admin request is rejected",
+		err.Error())
+}
+
+func TestNewAppConfig_Validates(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	t.Run("example config is valid", func(t *testing.T) {
+		viper.Set(flag.CONFIG_FLAG, filepath.Join("..", "..", "..", "..", "config", "config.example.yaml"))
+
+		_, err := config.NewAppConfig()
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("nonce_ttl: -1\nrate_limit_trusted_proxies: [\"proxy\"]\n"), 0o600))
+		viper.Set(flag.CONFIG_FLAG, path)
+
+		_, err := config.NewAppConfig()
+
+		var validationErr *config.ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Violations, 2)
+	})
+}
+
+func TestReload_RejectsInvalidConfig(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("lease_ttl: 120\n"), 0o600))
+	viper.Set(flag.CONFIG_FLAG, path)
+	current, err := config.NewAppConfig()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("lease_ttl: 120\nrate_limit_burst: 1000\n"), 0o600))
+	_, err = config.Reload(current)
+
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"rate_limit.burst (1000) must not exceed rate_limit.requests_per_minute (100)"}, validationErr.Violations)
+}
@@ -0,0 +1,214 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/events"
+	"github.com/unicornultrafoundation/dhcp2p/tests/mocks"
+	"go.uber.org/zap"
+)
+
+func newBusConfig(broker string) *config.AppConfig {
+	return &config.AppConfig{
+		EventBusBroker:          broker,
+		EventBusTopicPrefix:     "dhcp2p",
+		EventBusQueueSize:       10,
+		EventBusRetryBackoff:    1,
+		EventBusMaxRetryBackoff: 5,
+		EventBusTimeout:         1,
+	}
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	bus := events.NewBus(newBusConfig(events.BrokerMemory), nil, zap.NewNop())
+	defer bus.Stop(context.Background())
+
+	received := make(chan *models.Event, 2)
+	bus.Subscribe("audit", "lease.allocated", func(ctx context.Context, event *models.Event) error {
+		received <- event
+		return nil
+	})
+
+	require.NoError(t, bus.Publish("lease.released", "1", map[string]int{"n": 1}))
+	require.NoError(t, bus.Publish("lease.allocated", "2", map[string]int{"n": 2}))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "lease.allocated", event.Topic)
+		assert.Equal(t, "2", event.Key)
+		assert.NotEmpty(t, event.ID)
+		assert.JSONEq(t, `{"n":2}`, string(event.Payload))
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered")
+	}
+	select {
+	case event := <-received:
+		t.Fatalf("event of another topic delivered: %s", event.Topic)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBus_Redelivery(t *testing.T) {
+	bus := events.NewBus(newBusConfig(events.BrokerMemory), nil, zap.NewNop())
+
+	var attempts atomic.Int32
+	ids := make(chan string, 3)
+	bus.Subscribe("flaky", "lease.allocated", func(ctx context.Context, event *models.Event) error {
+		ids <- event.ID
+		if attempts.Add(1) < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	require.NoError(t, bus.Publish("lease.allocated", "1", "payload"))
+
+	first := <-ids
+	assert.Equal(t, first, <-ids, "a redelivery keeps the event ID")
+	assert.Equal(t, first, <-ids)
+	require.NoError(t, bus.Stop(context.Background()))
+
+	stats := bus.Stats()
+	assert.Equal(t, events.BrokerMemory, stats.Broker)
+	assert.Equal(t, int64(1), stats.Published)
+	require.Len(t, stats.Sinks, 1)
+	assert.Equal(t, int64(1), stats.Sinks[0].Delivered)
+	assert.Equal(t, int64(2), stats.Sinks[0].Retries)
+}
+
+func TestBus_Broker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	delivered := make(chan *models.Event, 1)
+	broker := mocks.NewMockEventBroker(ctrl)
+	broker.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(errors.New("broker down"))
+	broker.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *models.Event) error {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "the broker gets the event bus timeout")
+		delivered <- event
+		return nil
+	})
+	broker.EXPECT().Close().Return(nil)
+
+	bus := events.NewBus(newBusConfig(events.BrokerNATS), broker, zap.NewNop())
+	require.NoError(t, bus.Publish("lease.released", "167772161", map[string]string{"peer_id": "peer1"}))
+
+	select {
+	case event := <-delivered:
+		assert.Equal(t, "lease.released", event.Topic)
+		var payload map[string]string
+		require.NoError(t, json.Unmarshal(event.Payload, &payload))
+		assert.Equal(t, "peer1", payload["peer_id"])
+	case <-time.After(time.Second):
+		t.Fatal("event not forwarded to the broker")
+	}
+	require.NoError(t, bus.Stop(context.Background()))
+
+	stats := bus.Stats()
+	require.Len(t, stats.Sinks, 1)
+	assert.Equal(t, "broker", stats.Sinks[0].Name)
+	assert.Equal(t, int64(1), stats.Sinks[0].Delivered)
+	assert.Equal(t, int64(1), stats.Sinks[0].Retries)
+}
+
+func TestBus_Receive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var receive func(event *models.Event)
+	forwarded := make(chan *models.Event, 1)
+	broker := mocks.NewMockEventBroker(ctrl)
+	broker.EXPECT().Receive("lease.renewed", gomock.Any()).DoAndReturn(func(topic string, handler func(event *models.Event)) error {
+		receive = handler
+		return nil
+	})
+	broker.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event *models.Event) error {
+		forwarded <- event
+		return nil
+	})
+	broker.EXPECT().Close().Return(nil)
+
+	bus := events.NewBus(newBusConfig(events.BrokerNATS), broker, zap.NewNop())
+	delivered := make(chan *models.Event, 4)
+	for _, name := range []string{"watch", "audit"} {
+		bus.Subscribe(name, "lease.renewed", func(ctx context.Context, event *models.Event) error {
+			delivered <- event
+			return nil
+		})
+	}
+	require.NotNil(t, receive, "the topic is received from the broker once")
+
+	// An event of this instance coming back from the broker is not delivered again
+	require.NoError(t, bus.Publish("lease.renewed", "167772161", "local"))
+	own := <-forwarded
+	assert.NotEmpty(t, own.Source)
+	receive(own)
+
+	// An event of another instance reaches every subscriber of its topic, but not the broker
+	receive(&models.Event{ID: "remote-1", Topic: "lease.renewed", Source: "other-instance", Payload: json.RawMessage(`"remote"`)})
+	receive(&models.Event{ID: "remote-2", Topic: "lease.released", Source: "other-instance", Payload: json.RawMessage(`"remote"`)})
+
+	require.NoError(t, bus.Stop(context.Background()))
+	close(delivered)
+	var ids []string
+	for event := range delivered {
+		ids = append(ids, event.ID)
+	}
+	assert.ElementsMatch(t, []string{own.ID, own.ID, "remote-1", "remote-1"}, ids)
+
+	stats := bus.Stats()
+	assert.Equal(t, int64(1), stats.Published)
+	assert.Equal(t, int64(2), stats.Received)
+	assert.Equal(t, int64(1), stats.Sinks[0].Delivered, "received events are not forwarded to the broker")
+}
+
+func TestBus_QueueFull(t *testing.T) {
+	cfg := newBusConfig(events.BrokerMemory)
+	cfg.EventBusQueueSize = 1
+	bus := events.NewBus(cfg, nil, zap.NewNop())
+
+	release := make(chan struct{})
+	bus.Subscribe("slow", "lease.allocated", func(ctx context.Context, event *models.Event) error {
+		<-release
+		return nil
+	})
+
+	// The first event is being delivered, the second waits in the queue
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = bus.Publish("lease.allocated", "1", i)
+	}
+	assert.ErrorIs(t, err, events.ErrQueueFull)
+	assert.Equal(t, int64(1), bus.Stats().Rejected)
+
+	close(release)
+	require.NoError(t, bus.Stop(context.Background()))
+	assert.ErrorIs(t, bus.Publish("lease.allocated", "1", 0), events.ErrBusStopped)
+}
+
+func TestBus_StopAbandons(t *testing.T) {
+	bus := events.NewBus(newBusConfig(events.BrokerMemory), nil, zap.NewNop())
+	bus.Subscribe("down", "lease.allocated", func(ctx context.Context, event *models.Event) error {
+		return errors.New("unavailable")
+	})
+	require.NoError(t, bus.Publish("lease.allocated", "1", 0))
+	require.NoError(t, bus.Publish("lease.allocated", "2", 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.NoError(t, bus.Stop(ctx))
+
+	sink := bus.Stats().Sinks[0]
+	assert.Equal(t, int64(0), sink.Delivered)
+	assert.Equal(t, int64(2), sink.Abandoned)
+}
@@ -0,0 +1,146 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/events"
+	"go.uber.org/zap"
+)
+
+func TestKafkaBroker_Publish(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/dhcp2p.lease.allocated", r.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(r.Body)
+		var produce struct {
+			Records []struct {
+				Key   string          `json:"key"`
+				Value json.RawMessage `json:"value"`
+			} `json:"records"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &produce))
+		if assert.Len(t, produce.Records, 1) {
+			assert.Equal(t, "167772161", produce.Records[0].Key)
+			assert.Contains(t, string(produce.Records[0].Value), `"id":"event-1"`)
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.kafka.v2+json")
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":42,"error_code":null,"error":null}]}`))
+	}))
+	defer server.Close()
+
+	broker, err := events.NewKafkaBroker(server.URL+"/", "dhcp2p", zap.NewNop())
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, broker.Publish(ctx, newEvent("lease.allocated")))
+}
+
+func TestKafkaBroker_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		err    string
+	}{
+		{name: "unknown topic", status: http.StatusNotFound, body: `{"error_code":40401,"message":"Topic not found."}`, err: "Topic not found."},
+		{name: "proxy down", status: http.StatusBadGateway, body: `<html></html>`, err: "502"},
+		{name: "record error", status: http.StatusOK, body: `{"offsets":[{"partition":null,"offset":null,"error_code":1,"error":"leader not available"}]}`, err: "leader not available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			broker, err := events.NewKafkaBroker(server.URL, "dhcp2p", zap.NewNop())
+			require.NoError(t, err)
+
+			err = broker.Publish(context.Background(), newEvent("lease.allocated"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	_, err := events.NewKafkaBroker("kafka:9092", "dhcp2p", zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestKafkaBroker_Receive(t *testing.T) {
+	var mu sync.Mutex
+	var topics []string
+	polls, deleted := 0, false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		const instance = "/instances/consumer-1"
+		path := r.URL.Path
+		switch {
+		case r.Method == http.MethodPost && strings.HasPrefix(path, "/consumers/dhcp2p-") && strings.Count(path, "/") == 2:
+			var options map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&options))
+			assert.Equal(t, "latest", options["auto.offset.reset"])
+			w.Write([]byte(`{"instance_id":"consumer-1","base_uri":"http://proxy` + path + instance + `"}`))
+		case r.Method == http.MethodPost && strings.HasSuffix(path, instance+"/subscription"):
+			var subscription struct {
+				Topics []string `json:"topics"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&subscription))
+			topics = subscription.Topics
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && strings.HasSuffix(path, instance+"/records"):
+			polls++
+			if polls == 1 {
+				w.Write([]byte(`[{"topic":"dhcp2p.lease.allocated","key":"167772161","value":{"id":"event-1","topic":"lease.allocated","source":"other","time":"2026-10-15T10:00:00Z","payload":{}},"partition":0,"offset":7}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case r.Method == http.MethodDelete && strings.HasSuffix(path, instance):
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	broker, err := events.NewKafkaBroker(server.URL, "dhcp2p", zap.NewNop())
+	require.NoError(t, err)
+
+	received := make(chan *models.Event, 1)
+	require.NoError(t, broker.Receive("lease.allocated", func(event *models.Event) { received <- event }))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "event-1", event.ID)
+		assert.Equal(t, "other", event.Source)
+	case <-time.After(5 * time.Second):
+		t.Fatal("record not received")
+	}
+
+	require.NoError(t, broker.Close())
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"dhcp2p.lease.allocated"}, topics)
+	assert.True(t, deleted, "the consumer is deleted on close")
+	assert.Error(t, broker.Receive("lease.released", func(event *models.Event) {}))
+}
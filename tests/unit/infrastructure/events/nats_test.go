@@ -0,0 +1,259 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/events"
+)
+
+// natsPublish is an HPUB received by fakeNATS
+type natsPublish struct {
+	subject string
+	reply   string
+	header  string
+	payload []byte
+}
+
+// fakeNATS speaks enough of the NATS server protocol to accept clients, answer their
+// publishes with ack and deliver plain publishes to the matching subscriptions
+type fakeNATS struct {
+	listener  net.Listener
+	connects  chan map[string]any
+	publishes chan natsPublish
+	subs      chan string
+	ack       func(p natsPublish) (header, payload string)
+
+	mu   sync.Mutex                     // guards sids and the writes to the clients
+	sids map[net.Conn]map[string]string // subscribed subject -> sid, by client
+}
+
+func newFakeNATS(t *testing.T, ack func(p natsPublish) (header, payload string)) *fakeNATS {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeNATS{
+		listener:  listener,
+		connects:  make(chan map[string]any, 4),
+		publishes: make(chan natsPublish, 16),
+		subs:      make(chan string, 16),
+		ack:       ack,
+		sids:      make(map[net.Conn]map[string]string),
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeNATS) url(userInfo string) string {
+	return "nats://" + userInfo + s.listener.Addr().String()
+}
+
+// sidFor returns the sid of the subscription of conn matching subject, empty if none
+func (s *fakeNATS) sidFor(conn net.Conn, subject string) string {
+	for pattern, sid := range s.sids[conn] {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(subject, prefix) || pattern == subject {
+			return sid
+		}
+	}
+	return ""
+}
+
+func (s *fakeNATS) write(conn net.Conn, format string, args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(conn, format, args...)
+}
+
+func (s *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	s.write(conn, "INFO {\"server_id\":\"fake\",\"proto\":1,\"headers\":true,\"max_payload\":1048576}\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		op, args, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		switch op {
+		case "CONNECT":
+			var options map[string]any
+			json.Unmarshal([]byte(args), &options)
+			s.connects <- options
+		case "PING":
+			s.write(conn, "PONG\r\n")
+		case "SUB":
+			fields := strings.Fields(args)
+			s.mu.Lock()
+			if s.sids[conn] == nil {
+				s.sids[conn] = make(map[string]string)
+			}
+			s.sids[conn][fields[0]] = fields[len(fields)-1]
+			s.mu.Unlock()
+			s.subs <- fields[0]
+		case "PUB":
+			fields := strings.Fields(args)
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			body := make([]byte, size+2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return
+			}
+			s.mu.Lock()
+			for client := range s.sids {
+				if sid := s.sidFor(client, fields[0]); sid != "" {
+					fmt.Fprintf(client, "MSG %s %s %d\r\n%s\r\n", fields[0], sid, size, body[:size])
+				}
+			}
+			s.mu.Unlock()
+		case "HPUB":
+			fields := strings.Fields(args)
+			headerSize, _ := strconv.Atoi(fields[2])
+			total, _ := strconv.Atoi(fields[3])
+			body := make([]byte, total+2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return
+			}
+			p := natsPublish{subject: fields[0], reply: fields[1], header: string(body[:headerSize]), payload: body[headerSize:total]}
+			s.publishes <- p
+
+			header, payload := s.ack(p)
+			s.mu.Lock()
+			sid := s.sidFor(conn, p.reply)
+			if header == "" {
+				fmt.Fprintf(conn, "MSG %s %s %d\r\n%s\r\n", p.reply, sid, len(payload), payload)
+			} else {
+				fmt.Fprintf(conn, "HMSG %s %s %d %d\r\n%s%s\r\n", p.reply, sid, len(header), len(header)+len(payload), header, payload)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func newEvent(topic string) *models.Event {
+	return &models.Event{ID: "event-1", Topic: topic, Key: "167772161", Time: time.Now().UTC(), Payload: json.RawMessage(`{"peer_id":"peer1"}`)}
+}
+
+func TestNATSBroker_Publish(t *testing.T) {
+	server := newFakeNATS(t, func(p natsPublish) (string, string) {
+		return "", `{"stream":"DHCP2P","seq":1}`
+	})
+	broker, err := events.NewNATSBroker(server.url("user:pass@"), "dhcp2p")
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, broker.Publish(ctx, newEvent("lease.allocated")))
+
+	options := <-server.connects
+	assert.Equal(t, "user", options["user"])
+	assert.Equal(t, "pass", options["pass"])
+	assert.Equal(t, true, options["headers"])
+
+	p := <-server.publishes
+	assert.Equal(t, "dhcp2p.lease.allocated", p.subject)
+	assert.Contains(t, p.header, "Nats-Msg-Id: event-1\r\n")
+	var event models.Event
+	require.NoError(t, json.Unmarshal(p.payload, &event))
+	assert.Equal(t, "event-1", event.ID)
+	assert.JSONEq(t, `{"peer_id":"peer1"}`, string(event.Payload))
+
+	// The connection is reused
+	require.NoError(t, broker.Publish(ctx, newEvent("lease.released")))
+	assert.Equal(t, "dhcp2p.lease.released", (<-server.publishes).subject)
+	assert.Len(t, server.connects, 0)
+}
+
+func TestNATSBroker_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		payload string
+		err     string
+	}{
+		{name: "no stream", header: "NATS/1.0 503\r\n\r\n", err: "no JetStream stream"},
+		{name: "stream error", payload: `{"error":{"code":400,"description":"stream is sealed"}}`, err: "stream is sealed"},
+		{name: "not a stream", payload: `{}`, err: "not a JetStream acknowledgement"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeNATS(t, func(p natsPublish) (string, string) { return tt.header, tt.payload })
+			broker, err := events.NewNATSBroker(server.url(""), "dhcp2p")
+			require.NoError(t, err)
+			defer broker.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err = broker.Publish(ctx, newEvent("lease.allocated"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestNATSBroker_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	broker, err := events.NewNATSBroker("nats://"+addr, "dhcp2p")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Error(t, broker.Publish(ctx, newEvent("lease.allocated")))
+
+	_, err = events.NewNATSBroker("http://"+addr, "dhcp2p")
+	assert.Error(t, err)
+}
+
+func TestNATSBroker_Receive(t *testing.T) {
+	server := newFakeNATS(t, func(p natsPublish) (string, string) { return "", `{"stream":"DHCP2P","seq":1}` })
+	broker, err := events.NewNATSBroker(server.url(""), "dhcp2p")
+	require.NoError(t, err)
+
+	received := make(chan *models.Event, 1)
+	require.NoError(t, broker.Receive("lease.allocated", func(event *models.Event) { received <- event }))
+	assert.Equal(t, "dhcp2p.lease.allocated", <-server.subs)
+
+	// Another instance publishes the event
+	other, err := nats.Connect(server.url(""))
+	require.NoError(t, err)
+	defer other.Close()
+	data, err := json.Marshal(newEvent("lease.allocated"))
+	require.NoError(t, err)
+	require.NoError(t, other.Publish("dhcp2p.lease.allocated", data))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "event-1", event.ID)
+		assert.Equal(t, "lease.allocated", event.Topic)
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+	}
+
+	require.NoError(t, broker.Close())
+	assert.Error(t, broker.Receive("lease.released", func(event *models.Event) {}))
+}
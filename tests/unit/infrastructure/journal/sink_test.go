@@ -0,0 +1,171 @@
+package journal
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/ports"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/journal"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+	"go.uber.org/zap"
+)
+
+func readSeqs(t *testing.T, source ports.JournalSource) []int64 {
+	var seqs []int64
+	require.NoError(t, source.Read(context.Background(), func(entry *models.JournalEntry) error {
+		seqs = append(seqs, entry.Seq)
+		return nil
+	}))
+	return seqs
+}
+
+func TestFileSink_ContinuesAfterReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "journal", "leases.journal")
+
+	sink, err := journal.OpenFileSink(path)
+	require.NoError(t, err)
+	j, err := journal.Open(ctx, sink, clock.NewSystem())
+	require.NoError(t, err)
+	_, err = j.Checkpoint(ctx, &models.LeaseSnapshot{Version: models.SnapshotVersion})
+	require.NoError(t, err)
+	require.NoError(t, j.Append(ctx, &models.JournalEntry{Op: models.JournalOpRelease, TokenID: 1, PeerID: "peer-1"}))
+	require.NoError(t, j.Close(ctx))
+
+	// A crash in the middle of a write leaves a partial line behind
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":3,"op":"rel`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, []int64{1, 2}, readSeqs(t, journal.NewFileSource(path)))
+
+	sink, err = journal.OpenFileSink(path)
+	require.NoError(t, err)
+	j, err = journal.Open(ctx, sink, clock.NewSystem())
+	require.NoError(t, err)
+	assert.False(t, j.NeedsCheckpoint())
+	require.NoError(t, j.Append(ctx, &models.JournalEntry{Op: models.JournalOpRelease, TokenID: 1, PeerID: "peer-1"}))
+	require.NoError(t, j.Close(ctx))
+
+	assert.Equal(t, []int64{1, 2, 3}, readSeqs(t, journal.NewFileSource(path)))
+}
+
+func TestFileSink_RejectsOutOfOrderEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leases.journal")
+	require.NoError(t, os.WriteFile(path, []byte("{\"seq\":2,\"op\":\"checkpoint\"}\n{\"seq\":2,\"op\":\"release\"}\n"), 0o600))
+
+	sink, err := journal.OpenFileSink(path)
+	require.NoError(t, err)
+	defer sink.Close(context.Background())
+	_, err = journal.Open(context.Background(), sink, clock.NewSystem())
+	assert.Error(t, err)
+}
+
+// fakeS3 stores objects in memory and answers PUT, GET and ListObjectsV2 requests
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	auth    []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	key := strings.TrimPrefix(r.URL.Path, "/journals/")
+	switch {
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		type object struct {
+			Key string `xml:"Key"`
+		}
+		var result struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []object `xml:"Contents"`
+		}
+		for name := range f.objects {
+			if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+				result.Contents = append(result.Contents, object{name})
+			}
+		}
+		_ = xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>"))
+			return
+		}
+		_, _ = w.Write(body)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestS3Sink(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	cfg := journal.S3Config{Endpoint: server.URL, Bucket: "journals", Prefix: "/dhcp2p/", Region: "us-east-1", AccessKey: "access", SecretKey: "secret"}
+	sink, err := journal.NewS3Sink(cfg, time.Hour, zap.NewNop())
+	require.NoError(t, err)
+	j, err := journal.Open(ctx, sink, clock.NewSystem())
+	require.NoError(t, err)
+	_, err = j.Checkpoint(ctx, &models.LeaseSnapshot{Version: models.SnapshotVersion})
+	require.NoError(t, err)
+	require.NoError(t, j.Append(ctx, &models.JournalEntry{Op: models.JournalOpRelease, TokenID: 1, PeerID: "peer-1"}))
+
+	// Buffered entries are read before they are written
+	assert.Equal(t, []int64{1, 2}, readSeqs(t, sink))
+	assert.Empty(t, store.keys())
+
+	require.NoError(t, j.Close(ctx))
+	assert.Equal(t, []string{"dhcp2p/00000000000000000001.jsonl"}, store.keys())
+	for _, auth := range store.auth {
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=access/"), auth)
+	}
+
+	sink, err = journal.NewS3Sink(cfg, time.Hour, zap.NewNop())
+	require.NoError(t, err)
+	j, err = journal.Open(ctx, sink, clock.NewSystem())
+	require.NoError(t, err)
+	require.NoError(t, j.Append(ctx, &models.JournalEntry{Op: models.JournalOpRelease, TokenID: 1, PeerID: "peer-1"}))
+	require.NoError(t, j.Close(ctx))
+
+	assert.Equal(t, []string{"dhcp2p/00000000000000000001.jsonl", "dhcp2p/00000000000000000003.jsonl"}, store.keys())
+	sink, err = journal.NewS3Sink(cfg, time.Hour, zap.NewNop())
+	require.NoError(t, err)
+	defer sink.Close(ctx)
+	assert.Equal(t, []int64{1, 2, 3}, readSeqs(t, sink))
+}
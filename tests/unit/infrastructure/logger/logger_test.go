@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/logger"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap/zapcore"
+)
+
+// messages returns the logger and message of every entry in the JSON log file
+func messages(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry struct {
+			Logger string `json:"logger"`
+			Msg    string `json:"msg"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		messages = append(messages, strings.TrimPrefix(entry.Logger+": "+entry.Msg, ": "))
+	}
+	return messages
+}
+
+func newTestConfig(t *testing.T) *config.AppConfig {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Log.Encoding = "json"
+	cfg.Log.File = filepath.Join(t.TempDir(), "app.log")
+	cfg.Log.Levels = map[string]string{"cache": "debug", "audit": "warn"}
+	return cfg
+}
+
+func TestLevel_For(t *testing.T) {
+	level := logger.NewLevel(newTestConfig(t))
+
+	assert.Equal(t, zapcore.InfoLevel, level.For(""))
+	assert.Equal(t, zapcore.DebugLevel, level.For("cache"))
+	assert.Equal(t, zapcore.DebugLevel, level.For("cache.redis"), "named loggers inherit the level of their parent")
+	assert.Equal(t, zapcore.WarnLevel, level.For("audit"))
+	assert.Equal(t, zapcore.InfoLevel, level.For("reload"))
+	assert.True(t, level.Enabled(zapcore.DebugLevel), "a named logger logs at debug")
+
+	reloaded := newTestConfig(t)
+	reloaded.Server.LogLevel = "error"
+	reloaded.Log.Levels = map[string]string{}
+	level.ApplyConfig(reloaded)
+
+	assert.Equal(t, zapcore.ErrorLevel, level.For("cache"))
+	assert.False(t, level.Enabled(zapcore.WarnLevel))
+}
+
+func TestNewLogger_ModuleLevels(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Log.SamplingInitial = 0
+	lc := fxtest.NewLifecycle(t)
+	log := logger.NewLogger(lc, cfg, logger.NewLevel(cfg))
+
+	log.Debug("dropped")
+	log.Info("kept")
+	log.Named("cache").Debug("miss")
+	log.Named("cache").Named("redis").Debug("miss")
+	log.Named("audit").Info("dropped")
+	log.Named("audit").Warn("denied")
+	lc.RequireStart().RequireStop()
+
+	assert.Equal(t, []string{"kept", "cache: miss", "cache.redis: miss", "audit: denied"}, messages(t, cfg.Log.File))
+}
+
+func TestNewLogger_Sampling(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Log.SamplingInitial = 2
+	cfg.Log.SamplingThereafter = 3
+	lc := fxtest.NewLifecycle(t)
+	log := logger.NewLogger(lc, cfg, logger.NewLevel(cfg))
+
+	for range 8 {
+		log.Info("rate limit exceeded")
+	}
+	log.Info("other")
+	lc.RequireStart().RequireStop()
+
+	// The first 2, then every 3rd of the remaining 6
+	assert.Equal(t, []string{"rate limit exceeded", "rate limit exceeded", "rate limit exceeded", "rate limit exceeded", "other"}, messages(t, cfg.Log.File))
+}
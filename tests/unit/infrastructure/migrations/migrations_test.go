@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/migrations"
+)
+
+func TestAll(t *testing.T) {
+	all, err := migrations.All()
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	versions := migrations.Versions(all)
+	assert.True(t, sort.StringsAreSorted(versions))
+	assert.Equal(t, "20251003103548", versions[0])
+
+	for _, m := range all {
+		assert.True(t, strings.HasPrefix(m.Name, m.Version), m.Name)
+		assert.NotEmpty(t, m.SQL, m.Name)
+	}
+}
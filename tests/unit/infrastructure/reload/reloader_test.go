@@ -0,0 +1,96 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/flag"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/reload"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// target records the configurations it is given
+type target struct {
+	mu      sync.Mutex
+	applied []*config.AppConfig
+}
+
+func (t *target) ApplyConfig(cfg *config.AppConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.applied = append(t.applied, cfg)
+}
+
+func (t *target) last() *config.AppConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.applied) == 0 {
+		return nil
+	}
+	return t.applied[len(t.applied)-1]
+}
+
+func setup(t *testing.T) (string, *config.AppConfig) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("port: 8088\nlease_ttl: 120\n"), 0o600))
+	viper.Set(flag.CONFIG_FLAG, path)
+
+	cfg, err := config.NewAppConfig()
+	require.NoError(t, err)
+	return path, cfg
+}
+
+func TestReloader_Reload(t *testing.T) {
+	path, cfg := setup(t)
+	tgt := &target{}
+	lc := fxtest.NewLifecycle(t)
+	reloader := reload.NewReloader(lc, cfg, zap.NewNop(), []config.Reloadable{tgt})
+
+	result, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Nil(t, tgt.last(), "targets are not called without changes")
+
+	require.NoError(t, os.WriteFile(path, []byte("port: 9000\nlease_ttl: 30\n"), 0o600))
+	result, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lease.ttl"}, result.Applied)
+	assert.Equal(t, []string{"server.port"}, result.Ignored)
+	require.NotNil(t, tgt.last())
+	assert.Equal(t, 30, tgt.last().Lease.TTL)
+	assert.Equal(t, 8088, tgt.last().Server.Port)
+
+	// Later reloads compare against the applied configuration
+	result, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+}
+
+func TestReloader_SIGHUP(t *testing.T) {
+	path, cfg := setup(t)
+	tgt := &target{}
+	lc := fxtest.NewLifecycle(t)
+	reload.NewReloader(lc, cfg, zap.NewNop(), []config.Reloadable{tgt})
+	lc.RequireStart()
+	defer lc.RequireStop()
+
+	require.NoError(t, os.WriteFile(path, []byte("port: 8088\nlease_ttl: 45\n"), 0o600))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		applied := tgt.last()
+		return applied != nil && applied.Lease.TTL == 45
+	}, 2*time.Second, 10*time.Millisecond)
+}
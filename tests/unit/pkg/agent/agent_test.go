@@ -0,0 +1,455 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/agent"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	"go.uber.org/zap"
+)
+
+// fakeServer hands out short leases and answers renewals from a queue of status codes
+type fakeServer struct {
+	mu           sync.Mutex
+	ttl          time.Duration
+	renewAfter   time.Duration // suggested renewal time after issuing a lease, 0 suggests none
+	nextTokenID  int64
+	renewErrors  []int // status codes for the next renewals, 0 for success
+	heldTokenID  int64 // token ID lease lookups by peer ID return, 0 for none
+	lookupStatus int   // status code of lease lookups by peer ID, 0 for success
+	allocated    []string
+	renewed      int
+	released     []string
+}
+
+func newFakeServer(t *testing.T, ttl time.Duration) (*fakeServer, *httptest.Server) {
+	fs := &fakeServer{ttl: ttl, nextTokenID: 167902210}
+	srv := httptest.NewServer(fs)
+	t.Cleanup(srv.Close)
+	return fs, srv
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/lease/peer-id/") {
+		switch {
+		case s.lookupStatus != 0:
+			writeError(w, s.lookupStatus)
+		case s.heldTokenID == 0:
+			writeError(w, http.StatusNotFound)
+		default:
+			writeData(w, s.lease(s.heldTokenID))
+		}
+		return
+	}
+
+	switch r.URL.Path {
+	case "/request-auth":
+		writeData(w, map[string]string{"pubkey": r.Header.Get("X-Pubkey"), "nonce": "nonce"})
+	case "/allocate-ip":
+		requested := r.URL.Query().Get("tokenID")
+		s.allocated = append(s.allocated, requested)
+		tokenID := s.nextTokenID
+		s.nextTokenID++
+		if requested == "" {
+			writeData(w, s.lease(tokenID))
+			return
+		}
+		writeData(w, map[string]any{"lease": s.lease(tokenID), "requested_token_id": json.Number(requested), "granted": false, "reason": "in_use"})
+	case "/renew-lease":
+		if len(s.renewErrors) > 0 {
+			status := s.renewErrors[0]
+			s.renewErrors = s.renewErrors[1:]
+			if status != 0 {
+				writeError(w, status)
+				return
+			}
+		}
+		s.renewed++
+		tokenID, _ := strconv.ParseInt(r.URL.Query().Get("tokenID"), 10, 64)
+		writeData(w, s.lease(tokenID))
+	case "/release-lease":
+		s.released = append(s.released, r.URL.Query().Get("tokenID"))
+		writeData(w, map[string]string{"status": "success"})
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+func (s *fakeServer) lease(tokenID int64) map[string]any {
+	lease := map[string]any{"token_id": tokenID, "peer_id": "peer", "expires_at": time.Now().Add(s.ttl), "ttl": int(s.ttl.Seconds())}
+	if s.renewAfter > 0 {
+		lease["renew_after"] = time.Now().Add(s.renewAfter)
+	}
+	return lease
+}
+
+func (s *fakeServer) snapshot() (allocated []string, renewed int, released []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.allocated...), s.renewed, append([]string(nil), s.released...)
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func writeError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"type": "error", "code": http.StatusText(status), "message": "failed"})
+}
+
+func newAgent(t *testing.T, url string, cfg agent.Config, appliers ...agent.Applier) *agent.Agent {
+	return newAgentWithKey(t, url, newKey(t), cfg, appliers...)
+}
+
+func newKey(t *testing.T) crypto.PrivKey {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func newAgentWithKey(t *testing.T, url string, key crypto.PrivKey, cfg agent.Config, appliers ...agent.Applier) *agent.Agent {
+	c, err := client.New(url, key, client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}))
+	require.NoError(t, err)
+
+	a, err := agent.New(c, cfg, appliers, zap.NewNop())
+	require.NoError(t, err)
+	return a
+}
+
+// run starts the agent and returns a function stopping it and waiting for Run to return
+func run(t *testing.T, a *agent.Agent) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, a.Run(ctx))
+	}()
+	stop := func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+var fastConfig = agent.Config{RenewFraction: 0.5, RetryInterval: 10 * time.Millisecond}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	c, err := client.New("http://localhost", key)
+	require.NoError(t, err)
+
+	for _, cfg := range []agent.Config{
+		{RenewFraction: 0, RetryInterval: time.Second},
+		{RenewFraction: 1, RetryInterval: time.Second},
+		{RenewFraction: 0.5},
+	} {
+		_, err := agent.New(c, cfg, nil, zap.NewNop())
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestAgent_AllocatesAndRenews(t *testing.T) {
+	fs, srv := newFakeServer(t, 200*time.Millisecond)
+	ipFile := filepath.Join(t.TempDir(), "ip")
+	a := newAgent(t, srv.URL, fastConfig, agent.FileApplier{Path: ipFile})
+	run(t, a)
+
+	require.Eventually(t, func() bool {
+		return a.Status().Renewals >= 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status := a.Status()
+	assert.Equal(t, agent.StateBound, status.State)
+	assert.Equal(t, "10.2.0.2", status.IP)
+	assert.Equal(t, 1, status.Allocations)
+	assert.True(t, status.NextRenewal.Before(status.Lease.ExpiresAt))
+
+	allocated, _, _ := fs.snapshot()
+	assert.Equal(t, []string{""}, allocated)
+
+	data, err := os.ReadFile(ipFile)
+	require.NoError(t, err)
+	assert.Equal(t, "10.2.0.2\n", string(data))
+}
+
+func TestAgent_HonorsSuggestedRenewal(t *testing.T) {
+	// Halfway through the lease would be 5 seconds, the server suggests renewing sooner
+	fs, srv := newFakeServer(t, 10*time.Second)
+	fs.renewAfter = 50 * time.Millisecond
+	a := newAgent(t, srv.URL, fastConfig)
+	run(t, a)
+
+	require.Eventually(t, func() bool {
+		return a.Status().Renewals >= 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status := a.Status()
+	require.NotNil(t, status.Lease.RenewAfter)
+	assert.WithinDuration(t, *status.Lease.RenewAfter, status.NextRenewal, 50*time.Millisecond)
+}
+
+func TestAgent_ReallocatesLostLease(t *testing.T) {
+	fs, srv := newFakeServer(t, 100*time.Millisecond)
+	fs.renewErrors = []int{http.StatusNotFound}
+
+	var (
+		mu       sync.Mutex
+		commands []string
+	)
+	applier := agent.InterfaceApplier{
+		Interface:    "eth0",
+		PrefixLength: 32,
+		Run: func(_ context.Context, name string, args ...string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			commands = append(commands, name+" "+strings.Join(args, " "))
+			return nil
+		},
+	}
+	a := newAgent(t, srv.URL, fastConfig, applier)
+	run(t, a)
+
+	require.Eventually(t, func() bool {
+		return a.Status().Allocations == 2 && a.Status().State == agent.StateBound
+	}, 2*time.Second, 10*time.Millisecond)
+
+	allocated, _, _ := fs.snapshot()
+	assert.Equal(t, []string{"", "167902210"}, allocated[:2], "the lost token ID is requested again")
+	assert.Equal(t, "10.2.0.3", a.Status().IP)
+	assert.NotEmpty(t, a.Status().LastError)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"ip addr replace 10.2.0.2/32 dev eth0",
+		"ip addr replace 10.2.0.3/32 dev eth0",
+		"ip addr del 10.2.0.2/32 dev eth0",
+	}, commands)
+}
+
+func TestAgent_RetriesFailedRenewal(t *testing.T) {
+	fs, srv := newFakeServer(t, time.Second)
+	fs.renewErrors = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+	a := newAgent(t, srv.URL, agent.Config{RenewFraction: 0.1, RetryInterval: 10 * time.Millisecond})
+	run(t, a)
+
+	require.Eventually(t, func() bool {
+		return a.Status().Renewals >= 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status := a.Status()
+	assert.Equal(t, agent.StateBound, status.State)
+	assert.Equal(t, 1, status.Allocations, "transient failures keep the lease")
+	assert.Contains(t, status.LastError, "renew")
+}
+
+func TestAgent_ReleaseOnStop(t *testing.T) {
+	fs, srv := newFakeServer(t, time.Hour)
+	cfg := fastConfig
+	cfg.ReleaseOnStop = true
+	a := newAgent(t, srv.URL, cfg)
+	stop := run(t, a)
+
+	require.Eventually(t, func() bool {
+		return a.Status().State == agent.StateBound
+	}, 2*time.Second, 10*time.Millisecond)
+	stop()
+
+	_, _, released := fs.snapshot()
+	assert.Equal(t, []string{"167902210"}, released)
+	assert.Equal(t, agent.StateStopped, a.Status().State)
+}
+
+func TestAgent_StatusSocket(t *testing.T) {
+	_, srv := newFakeServer(t, time.Hour)
+	a := newAgent(t, srv.URL, fastConfig)
+	run(t, a)
+
+	// Unix socket paths are limited in length, so avoid the long t.TempDir
+	dir, err := os.MkdirTemp("", "agent")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "agent.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(ctx, socketPath) }()
+
+	require.Eventually(t, func() bool {
+		status, err := agent.QueryStatus(context.Background(), socketPath)
+		return err == nil && status.State == agent.StateBound
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status, err := agent.QueryStatus(context.Background(), socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, a.Status().PeerID, status.PeerID)
+	assert.Equal(t, "10.2.0.2", status.IP)
+
+	cancel()
+	require.NoError(t, <-served)
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err), "socket is removed on shutdown")
+}
+
+// leaseFileConfig returns fastConfig saving the lease to a file in a temporary directory
+func leaseFileConfig(t *testing.T) agent.Config {
+	cfg := fastConfig
+	cfg.LeaseFile = filepath.Join(t.TempDir(), "state", "lease.json")
+	return cfg
+}
+
+// bindAndStop runs an agent until it is bound and stops it, leaving its lease file behind
+func bindAndStop(t *testing.T, url string, key crypto.PrivKey, cfg agent.Config) {
+	a := newAgentWithKey(t, url, key, cfg)
+	stop := run(t, a)
+	require.Eventually(t, func() bool {
+		return a.Status().State == agent.StateBound
+	}, 2*time.Second, 10*time.Millisecond)
+	stop()
+}
+
+func TestAgent_ResumesSavedLease(t *testing.T) {
+	fs, srv := newFakeServer(t, time.Hour)
+	key, cfg := newKey(t), leaseFileConfig(t)
+	bindAndStop(t, srv.URL, key, cfg)
+
+	var saved map[string]any
+	data, err := os.ReadFile(cfg.LeaseFile)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, srv.URL, saved["server"])
+	assert.EqualValues(t, 167902210, saved["token_id"])
+
+	fs.mu.Lock()
+	fs.heldTokenID = 167902210
+	fs.mu.Unlock()
+
+	ipFile := filepath.Join(t.TempDir(), "ip")
+	a := newAgentWithKey(t, srv.URL, key, cfg, agent.FileApplier{Path: ipFile})
+	run(t, a)
+	require.Eventually(t, func() bool {
+		return a.Status().State == agent.StateBound
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status := a.Status()
+	assert.Equal(t, "10.2.0.2", status.IP)
+	assert.Equal(t, 0, status.Allocations, "the saved lease is resumed")
+	allocated, _, _ := fs.snapshot()
+	assert.Equal(t, []string{""}, allocated)
+
+	data, err = os.ReadFile(ipFile)
+	require.NoError(t, err)
+	assert.Equal(t, "10.2.0.2\n", string(data))
+}
+
+func TestAgent_ReallocatesLostSavedLease(t *testing.T) {
+	fs, srv := newFakeServer(t, time.Hour)
+	key, cfg := newKey(t), leaseFileConfig(t)
+	bindAndStop(t, srv.URL, key, cfg)
+
+	a := newAgentWithKey(t, srv.URL, key, cfg)
+	run(t, a)
+	require.Eventually(t, func() bool {
+		return a.Status().Allocations == 1 && a.Status().State == agent.StateBound
+	}, 2*time.Second, 10*time.Millisecond)
+
+	allocated, _, _ := fs.snapshot()
+	assert.Equal(t, []string{"", "167902210"}, allocated, "the saved token ID is requested again")
+}
+
+func TestAgent_IgnoresLeaseSavedForAnotherServer(t *testing.T) {
+	fs, srv := newFakeServer(t, time.Hour)
+	key, cfg := newKey(t), leaseFileConfig(t)
+	_, other := newFakeServer(t, time.Hour)
+	bindAndStop(t, other.URL, key, cfg)
+
+	fs.mu.Lock()
+	fs.heldTokenID = 167902210
+	fs.mu.Unlock()
+
+	a := newAgentWithKey(t, srv.URL, key, cfg)
+	run(t, a)
+	require.Eventually(t, func() bool {
+		return a.Status().Allocations == 1 && a.Status().State == agent.StateBound
+	}, 2*time.Second, 10*time.Millisecond)
+
+	allocated, _, _ := fs.snapshot()
+	assert.Equal(t, []string{""}, allocated)
+}
+
+func TestAgent_RenewsSavedLeaseWhenLookupFails(t *testing.T) {
+	fs, srv := newFakeServer(t, time.Hour)
+	key, cfg := newKey(t), leaseFileConfig(t)
+	bindAndStop(t, srv.URL, key, cfg)
+
+	fs.mu.Lock()
+	fs.lookupStatus = http.StatusServiceUnavailable
+	fs.mu.Unlock()
+
+	a := newAgentWithKey(t, srv.URL, key, cfg)
+	run(t, a)
+	require.Eventually(t, func() bool {
+		return a.Status().Renewals == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status := a.Status()
+	assert.Equal(t, agent.StateBound, status.State)
+	assert.Equal(t, "10.2.0.2", status.IP)
+	assert.Equal(t, 0, status.Allocations)
+	assert.Contains(t, status.LastError, "resume")
+}
+
+func TestAgent_ReleaseOnStopRemovesLeaseFile(t *testing.T) {
+	_, srv := newFakeServer(t, time.Hour)
+	cfg := leaseFileConfig(t)
+	cfg.ReleaseOnStop = true
+	a := newAgent(t, srv.URL, cfg)
+	stop := run(t, a)
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(cfg.LeaseFile)
+		return a.Status().State == agent.StateBound && err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	stop()
+
+	_, err := os.Stat(cfg.LeaseFile)
+	assert.True(t, os.IsNotExist(err), "a released lease is not resumed")
+}
+
+func TestFileApplier_ReplacesContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ip")
+	applier := agent.FileApplier{Path: path}
+
+	require.NoError(t, applier.Apply(context.Background(), "10.2.0.2", ""))
+	require.NoError(t, applier.Apply(context.Background(), "10.2.0.3", "10.2.0.2"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "10.2.0.3\n", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/auth/libp2p"
+	handlers "github.com/unicornultrafoundation/dhcp2p/internal/app/adapters/handlers/http"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/domain/models"
+	"github.com/unicornultrafoundation/dhcp2p/internal/app/infrastructure/config"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+)
+
+// newSigningServer serves /v1/server-info and /v1/server-info/keys for a server signing
+// leases with a fresh key
+func newSigningServer(t *testing.T) (*libp2p.LeaseSigner, *client.Client) {
+	cfg := config.NewDefaultAppConfig()
+	cfg.Security.ServerKeyPath = filepath.Join(t.TempDir(), "server.key")
+
+	signer, err := libp2p.NewLeaseSigner(cfg, zap.NewNop())
+	require.NoError(t, err)
+	handler, err := handlers.NewServerInfoHandler(signer, nil, cfg, "", nil, nil)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/v1/server-info", handler.ServerInfo)
+	r.Get("/v1/server-info/keys", handler.Keys)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	c, err := client.New(srv.URL, nil)
+	require.NoError(t, err)
+	return signer, c
+}
+
+func TestVerifyLease(t *testing.T) {
+	signer, c := newSigningServer(t)
+
+	info, err := c.ServerInfo(context.Background())
+	require.NoError(t, err)
+	assert.True(t, info.LeaseSigning)
+	serverKey, err := info.Key()
+	require.NoError(t, err)
+
+	issued := &models.Lease{TokenID: 167772161, PeerID: "12D3KooWExamplePeerID", ExpiresAt: time.Now().Add(time.Hour).UTC(), Tenant: "acme"}
+	signature, _, err := signer.SignLease(issued)
+	require.NoError(t, err)
+
+	lease := &client.Lease{TokenID: issued.TokenID, PeerID: issued.PeerID, ExpiresAt: issued.ExpiresAt, Signature: signature, Tenant: issued.Tenant}
+	assert.NoError(t, client.VerifyLease(serverKey, lease))
+
+	t.Run("another peer", func(t *testing.T) {
+		forged := *lease
+		forged.PeerID = "12D3KooWAnotherPeerID"
+		assert.ErrorIs(t, client.VerifyLease(serverKey, &forged), client.ErrInvalidLeaseSignature)
+	})
+
+	t.Run("another tenant", func(t *testing.T) {
+		forged := *lease
+		forged.Tenant = models.DefaultTenantID
+		assert.ErrorIs(t, client.VerifyLease(serverKey, &forged), client.ErrInvalidLeaseSignature)
+	})
+
+	t.Run("extended expiry", func(t *testing.T) {
+		forged := *lease
+		forged.ExpiresAt = forged.ExpiresAt.Add(time.Hour)
+		assert.ErrorIs(t, client.VerifyLease(serverKey, &forged), client.ErrInvalidLeaseSignature)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		unsigned := *lease
+		unsigned.Signature = nil
+		assert.ErrorIs(t, client.VerifyLease(serverKey, &unsigned), client.ErrUnsignedLease)
+	})
+}
+
+func TestServerKeySet_VerifyLease(t *testing.T) {
+	signer, c := newSigningServer(t)
+	ctx := context.Background()
+
+	issued := &models.Lease{TokenID: 167772161, PeerID: "12D3KooWExamplePeerID", ExpiresAt: time.Now().Add(time.Hour).UTC(), Tenant: models.DefaultTenantID}
+	sign := func() *client.Lease {
+		signature, keyID, err := signer.SignLease(issued)
+		require.NoError(t, err)
+		return &client.Lease{TokenID: issued.TokenID, PeerID: issued.PeerID, ExpiresAt: issued.ExpiresAt, Signature: signature, KeyID: keyID, Tenant: issued.Tenant}
+	}
+	before := sign()
+
+	keys, err := c.ServerKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys.Keys, 1)
+	assert.Equal(t, before.KeyID, keys.CurrentKeyID)
+	assert.NoError(t, keys.VerifyLease(before))
+
+	_, err = signer.RotateKey()
+	require.NoError(t, err)
+	after := sign()
+	assert.ErrorIs(t, keys.VerifyLease(after), client.ErrUnknownSigningKey, "the key set predates the rotation")
+
+	keys, err = c.ServerKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, after.KeyID, keys.CurrentKeyID)
+	assert.NoError(t, keys.VerifyLease(before), "the retired key still verifies")
+	assert.NoError(t, keys.VerifyLease(after))
+
+	info, err := c.ServerInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, after.KeyID, info.KeyID)
+
+	forged := *after
+	forged.KeyID = before.KeyID
+	assert.ErrorIs(t, keys.VerifyLease(&forged), client.ErrInvalidLeaseSignature)
+}
+
+func TestServerInfo_SigningDisabled(t *testing.T) {
+	signer, err := libp2p.NewLeaseSigner(config.NewDefaultAppConfig(), zap.NewNop())
+	require.NoError(t, err)
+
+	signature, _, err := signer.SignLease(&models.Lease{TokenID: 167772161, PeerID: "12D3KooWExamplePeerID"})
+	require.NoError(t, err)
+	assert.Nil(t, signature)
+
+	_, err = (&client.ServerInfo{}).Key()
+	assert.ErrorIs(t, err, client.ErrLeaseSigningDisabled)
+}
@@ -0,0 +1,310 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+)
+
+// fakeServer implements the authentication dance and the lease endpoints
+type fakeServer struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	nonces   map[string]bool // issued nonces, true once used
+	failures int             // remaining requests answered with 503
+	requests []*http.Request
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *httptest.Server) {
+	fs := &fakeServer{t: t, nonces: map[string]bool{}}
+	srv := httptest.NewServer(fs)
+	t.Cleanup(srv.Close)
+	return fs, srv
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+
+	if r.URL.Path == "/request-auth" {
+		nonce := fmt.Sprintf("nonce-%d", len(s.nonces))
+		s.nonces[nonce] = false
+		writeData(w, map[string]string{"pubkey": r.Header.Get("X-Pubkey"), "nonce": nonce})
+		return
+	}
+
+	if s.failures > 0 {
+		s.failures--
+		writeError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/allocate-ip", "/renew-lease", "/release-lease":
+		peerID, ok := s.verify(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "INVALID_SIGNATURE")
+			return
+		}
+		if r.URL.Path == "/release-lease" {
+			writeData(w, map[string]string{"status": "success"})
+			return
+		}
+		if r.URL.Query().Has("tokenID") && r.URL.Path == "/allocate-ip" {
+			writeData(w, map[string]any{"lease": lease(peerID), "requested_token_id": 99, "granted": false, "reason": "in_use"})
+			return
+		}
+		writeData(w, lease(peerID))
+	case "/v1/nonces":
+		if _, ok := s.verify(r); !ok {
+			writeError(w, http.StatusUnauthorized, "INVALID_SIGNATURE")
+			return
+		}
+		revoked := 0
+		for nonce, used := range s.nonces {
+			if !used {
+				s.nonces[nonce] = true
+				revoked++
+			}
+		}
+		writeData(w, map[string]int{"revoked": revoked})
+	default:
+		writeError(w, http.StatusNotFound, "LEASE_NOT_FOUND")
+	}
+}
+
+// verify checks the signature like the server's auth middleware and consumes the nonce
+func (s *fakeServer) verify(r *http.Request) (string, bool) {
+	nonce := r.Header.Get("X-Nonce")
+	if used, ok := s.nonces[nonce]; !ok || used {
+		return "", false
+	}
+	s.nonces[nonce] = true
+
+	pubBytes, err := base64.StdEncoding.DecodeString(r.Header.Get("X-Pubkey"))
+	require.NoError(s.t, err)
+	pub, err := crypto.UnmarshalPublicKey(pubBytes)
+	require.NoError(s.t, err)
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get("X-Signature"))
+	require.NoError(s.t, err)
+
+	ok, err := pub.Verify(dhcp2pcrypto.AuthPayload(nonce, r.Header.Get("X-Timestamp")), sig)
+	if err != nil || !ok {
+		return "", false
+	}
+	peerID, err := dhcp2pcrypto.PeerIDFromPublicKey(pubBytes)
+	require.NoError(s.t, err)
+	return peerID, true
+}
+
+func lease(peerID string) map[string]any {
+	return map[string]any{"token_id": 167902210, "peer_id": peerID, "expires_at": time.Now().Add(time.Hour), "ttl": 120}
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"type": "error", "code": code, "message": code})
+}
+
+func newKey(t *testing.T) crypto.PrivKey {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+var fastRetries = client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+func TestClient_AllocateIP(t *testing.T) {
+	_, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, newKey(t))
+	require.NoError(t, err)
+
+	allocation, err := c.AllocateIP(context.Background(), nil)
+	require.NoError(t, err)
+	require.NotNil(t, allocation.Lease)
+	assert.Equal(t, int64(167902210), allocation.Lease.TokenID)
+	assert.Equal(t, c.PeerID(), allocation.Lease.PeerID)
+	assert.False(t, allocation.Granted)
+}
+
+func TestClient_AllocateIP_PreferredTokenID(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, newKey(t))
+	require.NoError(t, err)
+
+	allocation, err := c.AllocateIP(context.Background(), &client.AllocateRequest{TokenID: 99})
+	require.NoError(t, err)
+	assert.Equal(t, int64(99), allocation.RequestedTokenID)
+	assert.Equal(t, "in_use", allocation.Reason)
+	assert.Equal(t, c.PeerID(), allocation.Lease.PeerID)
+	assert.Equal(t, "99", fs.requests[len(fs.requests)-1].URL.Query().Get("tokenID"))
+}
+
+func TestClient_AllocateIP_Labels(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, newKey(t))
+	require.NoError(t, err)
+
+	_, err = c.AllocateIP(context.Background(), &client.AllocateRequest{Labels: map[string]string{"role": "validator", "region": "eu"}})
+	require.NoError(t, err)
+	assert.Equal(t, "region=eu,role=validator", fs.requests[len(fs.requests)-1].URL.Query().Get("labels"))
+}
+
+func TestClient_SignedTimestamp(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, newKey(t), client.WithSignedTimestamp())
+	require.NoError(t, err)
+
+	_, err = c.RenewLease(context.Background(), 167902210)
+	require.NoError(t, err)
+	assert.NotEmpty(t, fs.requests[len(fs.requests)-1].Header.Get("X-Timestamp"))
+}
+
+func TestClient_ReleaseLease(t *testing.T) {
+	_, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, newKey(t))
+	require.NoError(t, err)
+
+	assert.NoError(t, c.ReleaseLease(context.Background(), 167902210))
+}
+
+func TestClient_RevokeNonces(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, newKey(t))
+	require.NoError(t, err)
+
+	fs.nonces["nonce-leaked"] = false
+	revoked, err := c.RevokeNonces(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+	assert.Equal(t, http.MethodDelete, fs.requests[len(fs.requests)-1].Method)
+	assert.True(t, fs.nonces["nonce-leaked"])
+}
+
+func TestClient_RetriesWithFreshNonce(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	fs.failures = 2
+	c, err := client.New(srv.URL, newKey(t), fastRetries)
+	require.NoError(t, err)
+
+	_, err = c.RenewLease(context.Background(), 167902210)
+	require.NoError(t, err)
+	assert.Len(t, fs.nonces, 3)
+}
+
+func TestClient_GivesUpAfterMaxAttempts(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	fs.failures = 5
+	c, err := client.New(srv.URL, newKey(t), fastRetries)
+	require.NoError(t, err)
+
+	_, err = c.AllocateIP(context.Background(), nil)
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Len(t, fs.nonces, 3)
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, nil, fastRetries)
+	require.NoError(t, err)
+
+	_, err = c.GetLease(context.Background(), "12D3KooWUnknown")
+	assert.True(t, client.IsCode(err, "LEASE_NOT_FOUND"))
+	assert.Len(t, fs.requests, 1)
+}
+
+func TestClient_RequiresKeyForAuthenticatedCalls(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, nil)
+	require.NoError(t, err)
+
+	_, err = c.AllocateIP(context.Background(), nil)
+	assert.ErrorIs(t, err, client.ErrNoKey)
+	assert.Empty(t, fs.requests)
+}
+
+func TestClient_ContextCancelledDuringBackoff(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	fs.failures = 5
+	c, err := client.New(srv.URL, newKey(t), client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = c.RenewLease(ctx, 167902210)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_WithMiddleware(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, newKey(t), client.WithMiddleware(client.Headers(http.Header{"X-Agent": {"test"}})))
+	require.NoError(t, err)
+
+	_, err = c.AllocateIP(context.Background(), nil)
+	require.NoError(t, err)
+	for _, req := range fs.requests {
+		assert.Equal(t, "test", req.Header.Get("X-Agent"), req.URL.Path)
+	}
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	_, err := client.New("not a url", nil)
+	assert.Error(t, err)
+}
+
+func TestClient_WithAuthenticatedLookups(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	c, err := client.New(srv.URL, newKey(t), client.WithAuthenticatedLookups())
+	require.NoError(t, err)
+
+	_, err = c.GetLeaseByTokenID(context.Background(), 167902210)
+	assert.True(t, client.IsCode(err, "LEASE_NOT_FOUND"))
+
+	last := fs.requests[len(fs.requests)-1]
+	assert.Equal(t, "/lease/token-id/167902210", last.URL.Path)
+	assert.NotEmpty(t, last.Header.Get("X-Nonce"))
+	assert.NotEmpty(t, last.Header.Get("X-Signature"))
+}
+
+func TestClient_WithEthereumIdentity(t *testing.T) {
+	_, srv := newFakeServer(t)
+	key, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	require.NoError(t, err)
+
+	c, err := client.New(srv.URL, key, client.WithEthereumIdentity())
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(c.PeerID(), "0x"))
+	assert.Len(t, c.PeerID(), 42)
+
+	// secp256k1 signatures verify like any other key
+	_, err = c.AllocateIP(context.Background(), nil)
+	require.NoError(t, err)
+
+	_, err = client.New(srv.URL, newKey(t), client.WithEthereumIdentity())
+	assert.Error(t, err, "Ethereum identities need a secp256k1 key")
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+)
+
+func TestKeystore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "peer.key")
+
+	key, err := client.GenerateKey(path)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := client.LoadKey(path)
+	require.NoError(t, err)
+	assert.True(t, key.Equals(loaded))
+}
+
+func TestKeystore_NeverOverwrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peer.key")
+
+	key, err := client.GenerateKey(path)
+	require.NoError(t, err)
+
+	_, err = client.GenerateKey(path)
+	assert.ErrorIs(t, err, os.ErrExist)
+
+	loaded, err := client.LoadOrGenerateKey(path)
+	require.NoError(t, err)
+	assert.True(t, key.Equals(loaded))
+}
+
+func TestKeystore_LoadOrGenerateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peer.key")
+
+	key, err := client.LoadOrGenerateKey(path)
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+
+	loaded, err := client.LoadKey(path)
+	require.NoError(t, err)
+	assert.True(t, key.Equals(loaded))
+}
+
+func TestKeystore_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peer.key")
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0o600))
+
+	_, err := client.LoadKey(path)
+	assert.Error(t, err)
+}
+
+func TestKeystore_EthereumPrivateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account.key")
+	require.NoError(t, os.WriteFile(path, []byte("0x4646464646464646464646464646464646464646464646464646464646464646\n"), 0o600))
+
+	key, err := client.LoadKey(path)
+	require.NoError(t, err)
+
+	address, err := client.PeerIdentity(key, true)
+	require.NoError(t, err)
+	assert.Equal(t, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", address)
+}
+
+func TestKeystore_GenerateKeyOfType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peer.key")
+
+	key, err := client.GenerateKeyOfType(path, crypto.Secp256k1)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Secp256k1, int(key.Type()))
+
+	loaded, err := client.LoadKey(path)
+	require.NoError(t, err)
+	assert.True(t, key.Equals(loaded))
+
+	_, err = client.GenerateKeyOfType(filepath.Join(t.TempDir(), "rsa.key"), crypto.RSA)
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/client"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type traceKey struct{}
+
+// recorder answers every request with status and remembers the last request
+type recorder struct {
+	status int
+	last   *http.Request
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.last = req
+	return &http.Response{StatusCode: r.status, Body: http.NoBody, Request: req}, nil
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	trace := func(name string) client.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				resp, err := next.RoundTrip(req)
+				calls = append(calls, name+" after")
+				return resp, err
+			})
+		}
+	}
+
+	transport := client.Chain(&recorder{status: http.StatusOK}, trace("outer"), trace("inner"))
+	_, err := transport.RoundTrip(httptest.NewRequest("GET", "http://dhcp2p.test/health", nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+}
+
+func TestHeadersAndPropagate(t *testing.T) {
+	base := &recorder{status: http.StatusOK}
+	transport := client.Chain(base,
+		client.Headers(http.Header{"x-tenant": {"acme"}}),
+		client.Propagate(func(ctx context.Context, header http.Header) {
+			header.Set("Traceparent", ctx.Value(traceKey{}).(string))
+		}),
+	)
+
+	req := httptest.NewRequest("GET", "http://dhcp2p.test/health", nil)
+	req = req.WithContext(context.WithValue(req.Context(), traceKey{}, "00-trace-span-01"))
+	_, err := transport.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "acme", base.last.Header.Get("X-Tenant"))
+	assert.Equal(t, "00-trace-span-01", base.last.Header.Get("Traceparent"))
+	// The caller's request is left untouched
+	assert.Empty(t, req.Header.Get("X-Tenant"))
+}
+
+func TestOnResponse(t *testing.T) {
+	t.Run("reports completed round trips", func(t *testing.T) {
+		var info *client.RoundTripInfo
+		transport := client.Chain(&recorder{status: http.StatusCreated}, client.OnResponse(func(i *client.RoundTripInfo) { info = i }))
+
+		_, err := transport.RoundTrip(httptest.NewRequest("POST", "http://dhcp2p.test/lease", nil))
+
+		require.NoError(t, err)
+		require.NotNil(t, info)
+		assert.Equal(t, http.StatusCreated, info.Response.StatusCode)
+		assert.NoError(t, info.Err)
+	})
+
+	t.Run("reports transport errors", func(t *testing.T) {
+		failing := client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})
+		var info *client.RoundTripInfo
+		transport := client.Chain(failing, client.OnResponse(func(i *client.RoundTripInfo) { info = i }))
+
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "http://dhcp2p.test/health", nil))
+
+		assert.Error(t, err)
+		require.NotNil(t, info)
+		assert.Nil(t, info.Response)
+		assert.EqualError(t, info.Err, "connection refused")
+	})
+}
+
+func TestLogging(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	ok := client.Chain(&recorder{status: http.StatusOK}, client.Logging(logger))
+	failed := client.Chain(&recorder{status: http.StatusBadGateway}, client.Logging(logger))
+
+	_, err := ok.RoundTrip(httptest.NewRequest("GET", "http://dhcp2p.test/health", nil))
+	require.NoError(t, err)
+	_, err = failed.RoundTrip(httptest.NewRequest("GET", "http://dhcp2p.test/health", nil))
+	require.NoError(t, err)
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, int64(http.StatusBadGateway), entries[1].ContextMap()["status"])
+}
@@ -0,0 +1,48 @@
+package clock
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/clock"
+)
+
+func TestSystem_Now(t *testing.T) {
+	before := time.Now()
+	now := clock.NewSystem().Now()
+	assert.False(t, now.Before(before))
+	assert.WithinDuration(t, time.Now(), now, time.Second)
+}
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	assert.Equal(t, start, fake.Now())
+	assert.Equal(t, start, fake.Now(), "time does not pass on its own")
+
+	fake.Advance(90 * time.Second)
+	assert.Equal(t, start.Add(90*time.Second), fake.Now())
+
+	fake.Set(start.Add(-time.Hour))
+	assert.Equal(t, start.Add(-time.Hour), fake.Now(), "the clock can be set back")
+}
+
+func TestFake_ConcurrentAdvance(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fake.Advance(time.Second)
+			fake.Now()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, start.Add(50*time.Second), fake.Now())
+}
@@ -0,0 +1,98 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dhcp2pcrypto "github.com/unicornultrafoundation/dhcp2p/pkg/crypto"
+)
+
+// eip155Key returns the secp256k1 key of the EIP-155 example
+func eip155Key(t *testing.T) crypto.PrivKey {
+	raw, err := hex.DecodeString("4646464646464646464646464646464646464646464646464646464646464646")
+	require.NoError(t, err)
+	key, err := crypto.UnmarshalSecp256k1PrivateKey(raw)
+	require.NoError(t, err)
+	return key
+}
+
+func TestEthereumAddress(t *testing.T) {
+	address, err := dhcp2pcrypto.EthereumAddress(eip155Key(t).GetPublic())
+	require.NoError(t, err)
+	assert.Equal(t, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", address)
+}
+
+func TestEthereumAddress_RequiresSecp256k1(t *testing.T) {
+	_, pub, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+
+	_, err = dhcp2pcrypto.EthereumAddress(pub)
+	assert.Error(t, err)
+}
+
+// TestPeerIDFromPublicKey tests that bare secp256k1 keys resolve to the same peer ID as
+// their libp2p encoding
+func TestPeerIDFromPublicKey(t *testing.T) {
+	pub := eip155Key(t).GetPublic()
+	encoded, err := crypto.MarshalPublicKey(pub)
+	require.NoError(t, err)
+	compressed, err := pub.Raw()
+	require.NoError(t, err)
+
+	for name, input := range map[string][]byte{"libp2p": encoded, "compressed": compressed} {
+		parsed, err := dhcp2pcrypto.UnmarshalPublicKey(input)
+		require.NoError(t, err, name)
+		assert.True(t, parsed.Equals(pub), name)
+
+		peerID, err := dhcp2pcrypto.PeerIDFromPublicKey(input)
+		require.NoError(t, err, name)
+		assert.Equal(t, "16Uiu2HAkzXQhHBZuBQoR5rJ3h3fK9mgWJNAYK6bhDgqU5Jg753tD", peerID, name)
+	}
+
+	_, err = dhcp2pcrypto.PeerIDFromPublicKey([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+// TestPayloads pins the digests other implementations must reproduce
+func TestPayloads(t *testing.T) {
+	expiresAt := time.Unix(1700000000, 999)
+
+	for name, tt := range map[string]struct {
+		payload []byte
+		digest  string
+	}{
+		"auth":                {dhcp2pcrypto.AuthPayload("nonce-1", ""), "9e3f156324d42f0ea4b6f4fce81d56fbd64a2143a3fdd60a130d9c90e5b4d688"},
+		"auth with timestamp": {dhcp2pcrypto.AuthPayload("nonce-1", "1700000000"), "c8d324bdc9e7609af405652317380980c91365f18f8c0d42bfb1e58d6ed3937f"},
+		"delegation":          {dhcp2pcrypto.DelegationPayload("nonce-1", "12D3KooWDelegate"), "9f410dd37e08f339373922bed7c8b1da969d0b127c23a154dcc38a9d4a7a0634"},
+		"transfer":            {dhcp2pcrypto.TransferPayload("nonce-1", 167902210, "12D3KooWNewOwner"), "48bce79d07513de4c80b50b48d180bf5be7c2d1e499680b28eb52a266f5457b8"},
+		"lease certificate":   {dhcp2pcrypto.LeaseCertificatePayload("acme", 167902210, "12D3KooWPeer", expiresAt), "b5794e4f4fa8ab78e153919d7b6415197b6a99f578ad6397cea4546c4559757d"},
+	} {
+		assert.Equal(t, tt.digest, hex.EncodeToString(tt.payload), name)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key := eip155Key(t)
+	pubkey, err := crypto.MarshalPublicKey(key.GetPublic())
+	require.NoError(t, err)
+	payload := dhcp2pcrypto.AuthPayload("nonce-1", "")
+	signature, err := key.Sign(payload)
+	require.NoError(t, err)
+
+	assert.NoError(t, dhcp2pcrypto.Verify(pubkey, payload, signature))
+
+	_, other, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	otherPubkey, err := crypto.MarshalPublicKey(other)
+	require.NoError(t, err)
+	assert.ErrorIs(t, dhcp2pcrypto.Verify(otherPubkey, payload, signature), dhcp2pcrypto.ErrInvalidSignature)
+	assert.ErrorIs(t, dhcp2pcrypto.Verify(pubkey, dhcp2pcrypto.AuthPayload("nonce-2", ""), signature), dhcp2pcrypto.ErrInvalidSignature)
+
+	err = dhcp2pcrypto.Verify([]byte("not a key"), payload, signature)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, dhcp2pcrypto.ErrInvalidSignature)
+}
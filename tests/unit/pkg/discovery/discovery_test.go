@@ -0,0 +1,169 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/discovery"
+)
+
+// serveResponder answers queries for service on a loopback port, which legacy unicast
+// queriers such as BrowseAddr reach without multicast
+func serveResponder(t *testing.T, service discovery.Service) net.Addr {
+	t.Helper()
+
+	responder, err := discovery.NewResponder(service)
+	require.NoError(t, err)
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- responder.Serve(conn) }()
+	t.Cleanup(func() {
+		conn.Close()
+		assert.NoError(t, <-done)
+	})
+
+	return conn.LocalAddr()
+}
+
+func TestBrowseAddr_FindsService(t *testing.T) {
+	advertised := discovery.Service{
+		Instance: "dhcp2p-1",
+		Host:     "node1",
+		Port:     8088,
+		Addrs:    []net.IP{net.ParseIP("192.168.1.10"), net.ParseIP("10.0.0.10")},
+		Text:     map[string]string{discovery.TextVersion: "v1.2.3", discovery.TextPeerID: "12D3KooWExample"},
+	}
+	addr := serveResponder(t, advertised)
+
+	services, err := discovery.BrowseAddr(context.Background(), addr, 300*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+
+	found := services[0]
+	assert.Equal(t, "dhcp2p-1", found.Instance)
+	assert.Equal(t, "node1", found.Host)
+	assert.Equal(t, 8088, found.Port)
+	require.Len(t, found.Addrs, 2)
+	assert.True(t, found.Addrs[0].Equal(advertised.Addrs[0]))
+	assert.True(t, found.Addrs[1].Equal(advertised.Addrs[1]))
+	assert.Equal(t, advertised.Text, found.Text)
+	assert.Equal(t, "http://192.168.1.10:8088", found.URL())
+}
+
+func TestBrowseAddr_InstanceWithSpecialCharacters(t *testing.T) {
+	addr := serveResponder(t, discovery.Service{
+		Instance: "Lab server 2.1 (rack; A)",
+		Host:     "node2",
+		Port:     9000,
+		Addrs:    []net.IP{net.ParseIP("192.168.1.11")},
+	})
+
+	services, err := discovery.BrowseAddr(context.Background(), addr, 300*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	assert.Equal(t, "Lab server 2.1 (rack; A)", services[0].Instance)
+	assert.Empty(t, services[0].Text)
+}
+
+func TestBrowseAddr_NoAnswer(t *testing.T) {
+	// A port nobody answers on
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	services, err := discovery.BrowseAddr(context.Background(), conn.LocalAddr(), 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Empty(t, services)
+}
+
+func TestBrowseAddr_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := discovery.BrowseAddr(ctx, discovery.MulticastAddr, time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestResponder_AnswersLegacyQueries(t *testing.T) {
+	addr := serveResponder(t, discovery.Service{
+		Instance: "dhcp2p-1",
+		Host:     "node1",
+		Port:     8088,
+		Addrs:    []net.IP{net.ParseIP("192.168.1.10")},
+	})
+
+	c := &dns.Client{Net: "udp", Timeout: time.Second}
+	exchange := func(name string, qtype uint16) *dns.Msg {
+		query := new(dns.Msg)
+		query.SetQuestion(name, qtype)
+		resp, _, err := c.Exchange(query, addr.String())
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("host address", func(t *testing.T) {
+		resp := exchange("node1.local.", dns.TypeA)
+		require.Len(t, resp.Answer, 1)
+		a := resp.Answer[0].(*dns.A)
+		assert.Equal(t, "192.168.1.10", a.A.String())
+		assert.Equal(t, uint16(dns.ClassINET), a.Hdr.Class, "legacy answers carry no cache-flush bit")
+		assert.LessOrEqual(t, a.Hdr.Ttl, uint32(10))
+	})
+
+	t.Run("service enumeration", func(t *testing.T) {
+		resp := exchange("_services._dns-sd._udp.local.", dns.TypePTR)
+		require.Len(t, resp.Answer, 1)
+		assert.Equal(t, "_dhcp2p._tcp.local.", resp.Answer[0].(*dns.PTR).Ptr)
+	})
+
+	t.Run("instance SRV includes the address", func(t *testing.T) {
+		resp := exchange("dhcp2p-1._dhcp2p._tcp.local.", dns.TypeSRV)
+		require.Len(t, resp.Answer, 1)
+		assert.Equal(t, uint16(8088), resp.Answer[0].(*dns.SRV).Port)
+		require.Len(t, resp.Extra, 1)
+		assert.Equal(t, "node1.local.", resp.Extra[0].Header().Name)
+	})
+
+	t.Run("unknown name is not answered", func(t *testing.T) {
+		query := new(dns.Msg)
+		query.SetQuestion("other.local.", dns.TypeA)
+		c := &dns.Client{Net: "udp", Timeout: 100 * time.Millisecond}
+		_, _, err := c.Exchange(query, addr.String())
+		assert.Error(t, err)
+	})
+}
+
+func TestNewResponder_Validation(t *testing.T) {
+	valid := discovery.Service{Instance: "dhcp2p-1", Host: "node1", Port: 8088}
+
+	_, err := discovery.NewResponder(valid)
+	assert.NoError(t, err)
+
+	noInstance := valid
+	noInstance.Instance = ""
+	_, err = discovery.NewResponder(noInstance)
+	assert.Error(t, err)
+
+	dottedHost := valid
+	dottedHost.Host = "node1.example.com"
+	_, err = discovery.NewResponder(dottedHost)
+	assert.Error(t, err)
+
+	noPort := valid
+	noPort.Port = 0
+	_, err = discovery.NewResponder(noPort)
+	assert.Error(t, err)
+}
+
+func TestService_URL(t *testing.T) {
+	service := discovery.Service{Host: "node1", Port: 8088}
+	assert.Equal(t, "http://node1.local:8088", service.URL())
+}
@@ -0,0 +1,89 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/fieldcrypt"
+)
+
+func newCipher(t *testing.T, fill byte) *fieldcrypt.Cipher {
+	cipher, err := fieldcrypt.New(bytes.Repeat([]byte{fill}, fieldcrypt.KeySize))
+	require.NoError(t, err)
+	return cipher
+}
+
+func TestCipher(t *testing.T) {
+	cipher := newCipher(t, 1)
+
+	t.Run("round trips deterministically", func(t *testing.T) {
+		encrypted, err := cipher.Encrypt("12D3KooWPeer")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encrypted, fieldcrypt.Prefix))
+		assert.NotContains(t, encrypted, "12D3KooWPeer")
+
+		again, err := cipher.Encrypt("12D3KooWPeer")
+		require.NoError(t, err)
+		assert.Equal(t, encrypted, again)
+
+		other, err := cipher.Encrypt("12D3KooWOther")
+		require.NoError(t, err)
+		assert.NotEqual(t, encrypted, other)
+
+		decrypted, err := cipher.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "12D3KooWPeer", decrypted)
+	})
+
+	t.Run("the longest peer ID fits the peer_id columns", func(t *testing.T) {
+		encrypted, err := cipher.Encrypt(strings.Repeat("p", 128))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(encrypted), 256)
+	})
+
+	t.Run("empty and unencrypted values pass through", func(t *testing.T) {
+		encrypted, err := cipher.Encrypt("")
+		require.NoError(t, err)
+		assert.Empty(t, encrypted)
+
+		decrypted, err := cipher.Decrypt("12D3KooWLegacy")
+		require.NoError(t, err)
+		assert.Equal(t, "12D3KooWLegacy", decrypted)
+	})
+
+	t.Run("other keys and tampered values fail", func(t *testing.T) {
+		encrypted, err := cipher.Encrypt("12D3KooWPeer")
+		require.NoError(t, err)
+
+		_, err = newCipher(t, 2).Decrypt(encrypted)
+		assert.ErrorIs(t, err, fieldcrypt.ErrMalformed)
+
+		_, err = cipher.Decrypt(encrypted[:len(encrypted)-2])
+		assert.ErrorIs(t, err, fieldcrypt.ErrMalformed)
+		_, err = cipher.Decrypt(fieldcrypt.Prefix + "!")
+		assert.ErrorIs(t, err, fieldcrypt.ErrMalformed)
+	})
+}
+
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xfb}, fieldcrypt.KeySize)
+
+	for _, encoded := range []string{
+		base64.StdEncoding.EncodeToString(key),
+		base64.RawURLEncoding.EncodeToString(key),
+		base64.StdEncoding.EncodeToString(key) + "\n",
+	} {
+		parsed, err := fieldcrypt.ParseKey(encoded)
+		require.NoError(t, err, encoded)
+		assert.Equal(t, key, parsed)
+	}
+
+	_, err := fieldcrypt.ParseKey(base64.StdEncoding.EncodeToString(key[:16]))
+	assert.ErrorContains(t, err, "16 bytes")
+	_, err = fieldcrypt.ParseKey("not base64!")
+	assert.Error(t, err)
+}
@@ -0,0 +1,235 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unicornultrafoundation/dhcp2p/pkg/loadtest"
+)
+
+// fakeServer keeps a lease per peer and can fail every renewal
+type fakeServer struct {
+	mu          sync.Mutex
+	nextTokenID int64
+	leases      map[string]int64 // peer ID to token ID
+	failRenew   bool
+	released    int
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *httptest.Server) {
+	fs := &fakeServer{nextTokenID: 167902210, leases: map[string]int64{}}
+	srv := httptest.NewServer(fs)
+	t.Cleanup(srv.Close)
+	return fs, srv
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if peerID, ok := strings.CutPrefix(r.URL.Path, "/lease/peer-id/"); ok {
+		if tokenID, ok := s.leases[peerID]; ok {
+			writeData(w, lease(peerID, tokenID))
+			return
+		}
+		writeError(w, http.StatusNotFound, "LEASE_NOT_FOUND")
+		return
+	}
+
+	peerID := requestPeerID(r)
+	tokenID, _ := strconv.ParseInt(r.URL.Query().Get("tokenID"), 10, 64)
+	switch r.URL.Path {
+	case "/request-auth":
+		writeData(w, map[string]string{"pubkey": r.Header.Get("X-Pubkey"), "nonce": "nonce"})
+	case "/allocate-ip":
+		if _, ok := s.leases[peerID]; !ok {
+			s.leases[peerID] = s.nextTokenID
+			s.nextTokenID++
+		}
+		writeData(w, lease(peerID, s.leases[peerID]))
+	case "/renew-lease":
+		if s.failRenew || s.leases[peerID] != tokenID {
+			delete(s.leases, peerID)
+			writeError(w, http.StatusNotFound, "LEASE_NOT_FOUND")
+			return
+		}
+		writeData(w, lease(peerID, tokenID))
+	case "/release-lease":
+		if s.leases[peerID] != tokenID {
+			writeError(w, http.StatusNotFound, "LEASE_NOT_FOUND")
+			return
+		}
+		delete(s.leases, peerID)
+		s.released++
+		writeData(w, map[string]string{"status": "success"})
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+func (s *fakeServer) snapshot() (leases, released int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.leases), s.released
+}
+
+// requestPeerID derives the peer ID from the public key the client authenticates with
+func requestPeerID(r *http.Request) string {
+	raw, err := base64.StdEncoding.DecodeString(r.Header.Get("X-Pubkey"))
+	if err != nil {
+		return ""
+	}
+	pub, err := crypto.UnmarshalPublicKey(raw)
+	if err != nil {
+		return ""
+	}
+	id, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		return ""
+	}
+	return id.String()
+}
+
+func lease(peerID string, tokenID int64) map[string]any {
+	return map[string]any{"token_id": tokenID, "peer_id": peerID, "expires_at": time.Now().Add(time.Hour), "ttl": 3600}
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"type": "error", "code": code, "message": "failed"})
+}
+
+func testConfig(server string) loadtest.Config {
+	cfg := loadtest.DefaultConfig
+	cfg.Server = server
+	cfg.Concurrency = 4
+	cfg.Peers = 8
+	cfg.Duration = 200 * time.Millisecond
+	cfg.Timeout = time.Second
+	return cfg
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := loadtest.ParseMix("allocate=1, renew=4,get=0")
+	require.NoError(t, err)
+	assert.Equal(t, loadtest.Mix{loadtest.OpAllocate: 1, loadtest.OpRenew: 4, loadtest.OpGet: 0}, mix)
+	assert.Equal(t, "allocate=1,renew=4", mix.String())
+
+	for _, value := range []string{"", "allocate", "lookup=1", "renew=-1", "renew=x", "get=0,release=0"} {
+		_, err := loadtest.ParseMix(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	cfg := testConfig("http://localhost")
+	cfg.Peers = 2
+
+	_, err := loadtest.Run(context.Background(), cfg)
+	assert.ErrorContains(t, err, "must be at least the concurrency")
+}
+
+func TestRun_ReportsOperationsAndReleasesLeases(t *testing.T) {
+	fs, srv := newFakeServer(t)
+
+	report, err := loadtest.Run(context.Background(), testConfig(srv.URL))
+	require.NoError(t, err)
+
+	assert.Positive(t, report.Operations)
+	assert.Zero(t, report.Errors)
+	assert.Empty(t, report.ErrorCodes)
+
+	var total int64
+	for _, op := range report.Ops {
+		total += op.Count
+		assert.LessOrEqual(t, op.Latency.Min, op.Latency.P50, op.Op)
+		assert.LessOrEqual(t, op.Latency.P50, op.Latency.P99, op.Op)
+		assert.LessOrEqual(t, op.Latency.P99, op.Latency.Max, op.Op)
+	}
+	assert.Equal(t, report.Operations, total)
+	assert.Equal(t, loadtest.OpAllocate, report.Ops[0].Op)
+
+	leases, _ := fs.snapshot()
+	assert.Zero(t, leases, "leases left after cleanup")
+}
+
+func TestRun_WithoutCleanupKeepsLeases(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	cfg := testConfig(srv.URL)
+	cfg.Mix = loadtest.Mix{loadtest.OpAllocate: 1}
+	cfg.Cleanup = false
+
+	_, err := loadtest.Run(context.Background(), cfg)
+	require.NoError(t, err)
+
+	leases, released := fs.snapshot()
+	assert.Equal(t, cfg.Peers, leases)
+	assert.Zero(t, released)
+}
+
+func TestRun_CountsErrorsByCode(t *testing.T) {
+	fs, srv := newFakeServer(t)
+	fs.failRenew = true
+	cfg := testConfig(srv.URL)
+	cfg.Mix = loadtest.Mix{loadtest.OpRenew: 1}
+
+	report, err := loadtest.Run(context.Background(), cfg)
+	require.NoError(t, err)
+
+	// Peers without a lease allocate, every renewal loses the lease and fails
+	require.Len(t, report.Ops, 2)
+	allocate, renew := report.Ops[0], report.Ops[1]
+	assert.Zero(t, allocate.Errors)
+	assert.Positive(t, renew.Count)
+	assert.Equal(t, renew.Count, renew.Errors)
+	assert.Equal(t, 1.0, renew.ErrorRate)
+	assert.Equal(t, map[string]int64{"LEASE_NOT_FOUND": renew.Errors}, report.ErrorCodes)
+	assert.Equal(t, renew.Errors, report.Errors)
+}
+
+func TestRun_LimitsRate(t *testing.T) {
+	_, srv := newFakeServer(t)
+	cfg := testConfig(srv.URL)
+	cfg.Rate = 40
+	cfg.Duration = 500 * time.Millisecond
+
+	report, err := loadtest.Run(context.Background(), cfg)
+	require.NoError(t, err)
+
+	// 20 operations over the duration and a burst of 10
+	assert.LessOrEqual(t, report.Operations, int64(35))
+	assert.Positive(t, report.Operations)
+}
+
+func TestRun_StopsWithContext(t *testing.T) {
+	_, srv := newFakeServer(t)
+	cfg := testConfig(srv.URL)
+	cfg.Duration = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	report, err := loadtest.Run(ctx, cfg)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Less(t, report.DurationSeconds, 10.0)
+}
@@ -0,0 +1,150 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicornultrafoundation/dhcp2p/internal/pkg/retry"
+)
+
+var errTransient = errors.New("transient")
+
+func TestPolicy_Do(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond}
+
+	t.Run("succeeds after failures", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), func(context.Context) error {
+			if calls++; calls < 3 {
+				return errTransient
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("returns the last error once attempts are used up", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), func(context.Context) error {
+			calls++
+			return fmt.Errorf("attempt %d", calls)
+		})
+		assert.EqualError(t, err, "attempt 3")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("stops at errors that are not retryable", func(t *testing.T) {
+		p := policy
+		p.Retryable = func(err error) bool { return errors.Is(err, errTransient) }
+
+		calls := 0
+		err := p.Do(context.Background(), func(context.Context) error {
+			calls++
+			return errors.New("final")
+		})
+		assert.EqualError(t, err, "final")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops at permanent errors", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), func(context.Context) error {
+			calls++
+			return retry.Permanent(errTransient)
+		})
+		assert.Equal(t, errTransient, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := retry.Policy{MaxAttempts: 3, InitialDelay: time.Hour, OnRetry: func(int, error, time.Duration) { cancel() }}
+
+		calls := 0
+		err := p.Do(ctx, func(context.Context) error {
+			calls++
+			return errTransient
+		})
+		assert.ErrorIs(t, err, errTransient)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("reports retries", func(t *testing.T) {
+		var attempts []int
+		p := policy
+		p.OnRetry = func(attempt int, err error, delay time.Duration) { attempts = append(attempts, attempt) }
+
+		_ = p.Do(context.Background(), func(context.Context) error { return errTransient })
+		assert.Equal(t, []int{1, 2}, attempts)
+	})
+}
+
+func TestDoValue(t *testing.T) {
+	calls := 0
+	value, err := retry.DoValue(context.Background(), retry.Policy{MaxAttempts: 2}, func(context.Context) (int, error) {
+		if calls++; calls < 2 {
+			return 0, errTransient
+		}
+		return 42, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := retry.Policy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Delay(2))
+	assert.Equal(t, 800*time.Millisecond, policy.Delay(4))
+	assert.Equal(t, time.Second, policy.Delay(5))
+	assert.Equal(t, time.Second, policy.Delay(1000))
+
+	policy.Multiplier = 3
+	assert.Equal(t, 300*time.Millisecond, policy.Delay(2))
+
+	policy.Jitter = 0.5
+	for range 100 {
+		delay := policy.Delay(2)
+		assert.GreaterOrEqual(t, delay, 150*time.Millisecond)
+		assert.LessOrEqual(t, delay, 300*time.Millisecond)
+	}
+}
+
+type safeToRetryError struct{ safe bool }
+
+func (e *safeToRetryError) Error() string     { return "driver error" }
+func (e *safeToRetryError) SafeToRetry() bool { return e.safe }
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errors.New("not found"), false},
+		{"canceled", context.Canceled, false},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"connection refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"network timeout", &net.DNSError{IsTimeout: true}, true},
+		{"safe to retry", fmt.Errorf("query: %w", &safeToRetryError{safe: true}), true},
+		{"unsafe to retry", &safeToRetryError{safe: false}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.transient, retry.IsTransient(tt.err))
+		})
+	}
+}